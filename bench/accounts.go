@@ -0,0 +1,121 @@
+package bench
+
+import (
+	"math/big"
+
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/crypto"
+	"github.com/thetatoken/ukulele/ledger/types"
+)
+
+// resourceID is the resource paid for by the service payments.
+const resourceID = "bench"
+
+// account is a funded account sending the load. An account has at most one
+// transaction pending at a time, since the mempools reject the transactions gossiped
+// out of sequence order.
+type account struct {
+	key      *crypto.PrivateKey
+	address  common.Address
+	sequence uint64 // Sequence of the last transaction sent
+
+	reserveSequence  uint64                    // Sequence of the fund reserved for the service payments
+	paymentSequences map[common.Address]uint64 // Last payment sequence for each target
+}
+
+func newAccount() (*account, error) {
+	key, _, err := crypto.GenerateKeyPair()
+	if err != nil {
+		return nil, err
+	}
+	return &account{
+		key:              key,
+		address:          key.PublicKey().Address(),
+		paymentSequences: make(map[common.Address]uint64),
+	}, nil
+}
+
+func minimumFee() types.Coins {
+	return types.Coins{ThetaWei: big.NewInt(0), GammaWei: new(big.Int).SetUint64(types.MinimumTransactionFeeGammaWei)}
+}
+
+func gammaWei(amount *big.Int) types.Coins {
+	return types.Coins{ThetaWei: big.NewInt(0), GammaWei: amount}
+}
+
+// newSendTx returns a signed SendTx of the given amount of Gamma from the account
+// to each of the recipients, with the next sequence of the account.
+func newSendTx(chainID string, from *account, to []common.Address, amount *big.Int) (common.Bytes, error) {
+	fee := minimumFee()
+	total := new(big.Int).Mul(amount, big.NewInt(int64(len(to))))
+	tx := &types.SendTx{
+		Fee: fee,
+		Inputs: []types.TxInput{{
+			Address:  from.address,
+			Coins:    gammaWei(total).Plus(fee),
+			Sequence: from.sequence + 1,
+		}},
+	}
+	for _, addr := range to {
+		tx.Outputs = append(tx.Outputs, types.TxOutput{Address: addr, Coins: gammaWei(amount)})
+	}
+	sig, err := from.key.Sign(tx.SignBytes(chainID))
+	if err != nil {
+		return nil, err
+	}
+	tx.SetSignature(from.address, sig)
+	return types.TxToBytes(tx)
+}
+
+// newReserveFundTx returns a signed ReserveFundTx of the account, which reserves
+// fund for the service payments to any target, with the next sequence of the account.
+func newReserveFundTx(chainID string, from *account, fund *big.Int, collateral *big.Int) (common.Bytes, error) {
+	tx := &types.ReserveFundTx{
+		Fee: minimumFee(),
+		Source: types.TxInput{
+			Address:  from.address,
+			Coins:    gammaWei(fund),
+			Sequence: from.sequence + 1,
+		},
+		Collateral:  gammaWei(collateral),
+		ResourceIDs: []string{resourceID},
+		Duration:    types.MaximumFundReserveDuration,
+	}
+	sig, err := from.key.Sign(tx.SignBytes(chainID))
+	if err != nil {
+		return nil, err
+	}
+	tx.SetSignature(from.address, sig)
+	return types.TxToBytes(tx)
+}
+
+// newServicePaymentTx returns a ServicePaymentTx of the given amount from the fund
+// reserved by the source to the target, signed by both, with the next sequence of
+// the target and the next payment sequence of the source for the target.
+func newServicePaymentTx(chainID string, source *account, target *account, amount *big.Int) (common.Bytes, error) {
+	tx := &types.ServicePaymentTx{
+		Fee: minimumFee(),
+		Source: types.TxInput{
+			Address: source.address,
+			Coins:   gammaWei(amount),
+		},
+		Target: types.TxInput{
+			Address:  target.address,
+			Sequence: target.sequence + 1,
+		},
+		PaymentSequence: source.paymentSequences[target.address] + 1,
+		ReserveSequence: source.reserveSequence,
+		ResourceID:      resourceID,
+	}
+	sig, err := source.key.Sign(tx.SourceSignBytes(chainID))
+	if err != nil {
+		return nil, err
+	}
+	tx.SetSourceSignature(sig)
+	sig, err = target.key.Sign(tx.TargetSignBytes(chainID))
+	if err != nil {
+		return nil, err
+	}
+	tx.SetTargetSignature(sig)
+	return types.TxToBytes(tx)
+}
@@ -0,0 +1,388 @@
+// Package bench measures the transaction throughput of a local cluster of
+// in-process validators. A run funds a set of accounts, submits signed SendTx and
+// ServicePaymentTx to the mempools of the nodes at a fixed rate, and measures the
+// mempool admission latency, the finalization latency of the transactions and how
+// full the finalized blocks are.
+package bench
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"math/rand"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/core"
+	"github.com/thetatoken/ukulele/crypto"
+	"github.com/thetatoken/ukulele/integration"
+	"github.com/thetatoken/ukulele/ledger/types"
+)
+
+const (
+	// setupTimeout bounds the time waited for the setup transactions to be finalized.
+	setupTimeout = 60 * time.Second
+	// pollInterval is the interval between the checks for newly finalized blocks.
+	pollInterval = 10 * time.Millisecond
+)
+
+var (
+	accountFunds = new(big.Int).Mul(big.NewInt(10000), big.NewInt(1e18)) // 10000 Gamma
+	reservedFund = new(big.Int).Mul(big.NewInt(1000), big.NewInt(1e18))  // 1000 Gamma
+	collateral   = new(big.Int).Mul(big.NewInt(2000), big.NewInt(1e18))  // 2000 Gamma
+	txAmount     = big.NewInt(1000)                                      // GammaWei
+)
+
+// Config is the configuration of a benchmark run.
+type Config struct {
+	Nodes               int           `json:"nodes"`                 // Validators of the cluster
+	Accounts            int           `json:"accounts"`              // Accounts sending the transactions
+	Rate                float64       `json:"rate"`                  // Transactions submitted per second
+	Duration            time.Duration `json:"duration_ns"`           // Time during which transactions are submitted
+	DrainTimeout        time.Duration `json:"drain_timeout_ns"`      // Time waited for the pending transactions after that
+	ServicePaymentRatio float64       `json:"service_payment_ratio"` // Fraction of the transactions that are service payments
+}
+
+// DefaultConfig returns the default configuration of a benchmark run.
+func DefaultConfig() Config {
+	return Config{
+		Nodes:               4,
+		Accounts:            200,
+		Rate:                100,
+		Duration:            30 * time.Second,
+		DrainTimeout:        60 * time.Second,
+		ServicePaymentRatio: 0,
+	}
+}
+
+func (c Config) validate() error {
+	switch {
+	case c.Nodes < 1:
+		return errors.New("At least one node is needed")
+	case c.Accounts < 2:
+		return errors.New("At least two accounts are needed")
+	case c.ServicePaymentRatio < 0 || c.ServicePaymentRatio > 1:
+		return errors.New("The service payment ratio must be between 0 and 1")
+	case c.Rate <= 0:
+		return errors.New("The rate must be positive")
+	case c.Duration <= 0:
+		return errors.New("The duration must be positive")
+	}
+	return nil
+}
+
+// Result is the outcome of a benchmark run.
+type Result struct {
+	Config Config `json:"config"`
+
+	Submitted int `json:"submitted"`
+	Admitted  int `json:"admitted"`  // Accepted by the mempool they were submitted to
+	Rejected  int `json:"rejected"`  // Rejected by the mempool they were submitted to
+	Throttled int `json:"throttled"` // Not submitted because all the accounts had a pending transaction
+	Finalized int `json:"finalized"`
+
+	Elapsed    float64 `json:"elapsed_seconds"` // From the first submission to the end of the drain
+	Throughput float64 `json:"throughput_tps"`  // Finalized transactions per second
+
+	AdmissionLatency    LatencyStats `json:"admission_latency"`    // Time taken by the mempool to admit a transaction
+	FinalizationLatency LatencyStats `json:"finalization_latency"` // From the submission to the finalization on the first node
+	Blocks              BlockStats   `json:"blocks"`               // Blocks finalized during the run
+}
+
+// pendingTx is an admitted transaction that is not finalized yet.
+type pendingTx struct {
+	submitted time.Time
+	account   *account // Account whose sequence the transaction uses
+}
+
+type benchmark struct {
+	config   Config
+	cluster  *integration.Cluster
+	accounts []*account
+	rand     *rand.Rand
+
+	mu           *sync.Mutex
+	free         []*account // Accounts without pending transaction, oldest first
+	pending      map[common.Hash]*pendingTx
+	admission    []time.Duration
+	finalization []time.Duration
+	txsPerBlock  []int
+	result       *Result
+}
+
+// Run starts a cluster, runs the benchmark on it, and stops it.
+func Run(config Config) (*Result, error) {
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+	cluster, err := integration.NewCluster(config.Nodes)
+	if err != nil {
+		return nil, err
+	}
+	b := &benchmark{
+		config:  config,
+		cluster: cluster,
+		rand:    rand.New(rand.NewSource(1)),
+		mu:      &sync.Mutex{},
+		pending: make(map[common.Hash]*pendingTx),
+		result:  &Result{Config: config},
+	}
+	for i := 0; i < config.Accounts; i++ {
+		a, err := newAccount()
+		if err != nil {
+			return nil, err
+		}
+		b.accounts = append(b.accounts, a)
+	}
+	b.free = append([]*account{}, b.accounts...)
+
+	cluster.Start(context.Background())
+	defer cluster.Stop()
+
+	if err := b.setup(); err != nil {
+		return nil, err
+	}
+	log.WithFields(log.Fields{"accounts": config.Accounts}).Info("Funded the benchmark accounts")
+	return b.run(), nil
+}
+
+// setup funds the accounts from the validators, and reserves funds for the service
+// payments.
+func (b *benchmark) setup() error {
+	c := b.cluster
+	if err := c.WaitForFinalizedHeight(1, setupTimeout); err != nil {
+		return err
+	}
+
+	hashes := []common.Hash{}
+	for i, n := range c.Nodes {
+		recipients := []common.Address{}
+		for j := i; j < len(b.accounts); j += len(c.Nodes) {
+			recipients = append(recipients, b.accounts[j].address)
+		}
+		if len(recipients) == 0 {
+			continue
+		}
+		validator := &account{key: n.PrivateKey, address: n.Address()}
+		acc, err := c.Account(validator.address, i)
+		if err != nil {
+			return err
+		}
+		if acc != nil {
+			validator.sequence = acc.Sequence
+		}
+		raw, err := newSendTx(c.ChainID, validator, recipients, accountFunds)
+		if err != nil {
+			return err
+		}
+		if err := c.SubmitTx(i, raw); err != nil {
+			return err
+		}
+		hashes = append(hashes, crypto.Keccak256Hash(raw))
+	}
+	if err := b.waitForTxs(hashes); err != nil {
+		return err
+	}
+
+	if b.config.ServicePaymentRatio == 0 {
+		return nil
+	}
+	hashes = []common.Hash{}
+	for i, a := range b.accounts {
+		raw, err := newReserveFundTx(c.ChainID, a, reservedFund, collateral)
+		if err != nil {
+			return err
+		}
+		if err := c.SubmitTx(i%len(c.Nodes), raw); err != nil {
+			return err
+		}
+		a.sequence++
+		a.reserveSequence = a.sequence
+		hashes = append(hashes, crypto.Keccak256Hash(raw))
+	}
+	return b.waitForTxs(hashes)
+}
+
+func (b *benchmark) waitForTxs(hashes []common.Hash) error {
+	for _, hash := range hashes {
+		if err := b.cluster.WaitForTx(hash, setupTimeout); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// run submits the transactions at the configured rate, round robin to the nodes,
+// and waits for them to be finalized.
+func (b *benchmark) run() *Result {
+	done := make(chan struct{})
+	tracked := make(chan struct{})
+	go func() {
+		b.trackFinalizedBlocks(done)
+		close(tracked)
+	}()
+
+	start := time.Now()
+	offered := 0
+	node := 0
+	for time.Since(start) < b.config.Duration {
+		due := int(time.Since(start).Seconds()*b.config.Rate) - offered
+		for ; due > 0; due-- {
+			offered++
+			b.submit(node)
+			node = (node + 1) % len(b.cluster.Nodes)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	log.WithFields(log.Fields{"offered": offered}).Info("Waiting for the pending transactions")
+
+	deadline := time.Now().Add(b.config.DrainTimeout)
+	for b.numPending() > 0 && time.Now().Before(deadline) {
+		time.Sleep(pollInterval)
+	}
+	elapsed := time.Since(start)
+	close(done)
+	<-tracked
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	r := b.result
+	r.Elapsed = elapsed.Seconds()
+	r.Throughput = float64(r.Finalized) / elapsed.Seconds()
+	r.AdmissionLatency = NewLatencyStats(b.admission)
+	r.FinalizationLatency = NewLatencyStats(b.finalization)
+	r.Blocks = NewBlockStats(b.txsPerBlock, core.MaxNumRegularTxsPerBlock)
+	return r
+}
+
+// submit submits a transaction from the oldest free account to a node.
+func (b *benchmark) submit(node int) {
+	b.mu.Lock()
+	if len(b.free) == 0 {
+		b.result.Throttled++
+		b.mu.Unlock()
+		return
+	}
+	from := b.free[0]
+	b.free = b.free[1:]
+
+	var raw common.Bytes
+	var err error
+	var source *account
+	if b.rand.Float64() < b.config.ServicePaymentRatio {
+		// The free account is the target, which signs with its sequence and pays the fee
+		source = b.otherAccount(from)
+		raw, err = newServicePaymentTx(b.cluster.ChainID, source, from, txAmount)
+	} else {
+		to := b.otherAccount(from)
+		raw, err = newSendTx(b.cluster.ChainID, from, []common.Address{to.address}, txAmount)
+	}
+	if err != nil {
+		log.WithFields(log.Fields{"err": err}).Panic("Failed to sign transaction")
+	}
+	hash := crypto.Keccak256Hash(raw)
+	submitted := time.Now()
+	b.pending[hash] = &pendingTx{submitted: submitted, account: from}
+	b.result.Submitted++
+	b.mu.Unlock()
+
+	err = b.cluster.SubmitTx(node, raw)
+	latency := time.Since(submitted)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err != nil {
+		log.WithFields(log.Fields{"err": err}).Debug("Transaction rejected")
+		b.result.Rejected++
+		delete(b.pending, hash)
+		b.free = append(b.free, from)
+		return
+	}
+	b.result.Admitted++
+	b.admission = append(b.admission, latency)
+	from.sequence++
+	if source != nil {
+		source.paymentSequences[from.address]++
+	}
+}
+
+// otherAccount returns a random account other than the given one. The ledger
+// rejects a SendTx to its own sender.
+func (b *benchmark) otherAccount(a *account) *account {
+	other := b.accounts[b.rand.Intn(len(b.accounts))]
+	for other == a {
+		other = b.accounts[b.rand.Intn(len(b.accounts))]
+	}
+	return other
+}
+
+func (b *benchmark) numPending() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.pending)
+}
+
+// trackFinalizedBlocks processes the blocks finalized by the first node until done
+// is closed.
+func (b *benchmark) trackFinalizedBlocks(done chan struct{}) {
+	chain := b.cluster.Nodes[0].Node.Chain
+	last, err := b.cluster.FinalizedBlock(0)
+	if err != nil {
+		log.WithFields(log.Fields{"err": err}).Panic("Failed to find the last finalized block")
+	}
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+		head, err := b.cluster.FinalizedBlock(0)
+		if err != nil || head.Height <= last.Height {
+			continue
+		}
+		// Walk back to the last processed block, then process the blocks in order
+		blocks := []*core.ExtendedBlock{}
+		for block := head; block.Height > last.Height; {
+			blocks = append(blocks, block)
+			if block, err = chain.FindBlock(block.Parent); err != nil {
+				log.WithFields(log.Fields{"err": err}).Panic("Failed to find the parent of a finalized block")
+			}
+		}
+		now := time.Now()
+		for i := len(blocks) - 1; i >= 0; i-- {
+			b.processFinalizedBlock(blocks[i], now)
+		}
+		last = head
+	}
+}
+
+func (b *benchmark) processFinalizedBlock(block *core.ExtendedBlock, now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	regular := 0
+	for _, raw := range block.Txs {
+		if tx, err := types.TxFromBytes(raw); err == nil {
+			switch tx.(type) {
+			case *types.CoinbaseTx, *types.SlashTx:
+				continue
+			}
+		}
+		regular++
+
+		hash := crypto.Keccak256Hash(raw)
+		p, ok := b.pending[hash]
+		if !ok {
+			continue
+		}
+		delete(b.pending, hash)
+		b.result.Finalized++
+		b.finalization = append(b.finalization, now.Sub(p.submitted))
+		b.free = append(b.free, p.account)
+	}
+	b.txsPerBlock = append(b.txsPerBlock, regular)
+}
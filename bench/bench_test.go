@@ -0,0 +1,42 @@
+// +build integration
+
+package bench
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/thetatoken/ukulele/common"
+)
+
+func TestRun(t *testing.T) {
+	assert := assert.New(t)
+	viper.Set(common.CfgLogLevels, "*:error")
+	viper.Set(common.CfgLogPrintSelfID, true)
+
+	config := Config{
+		Nodes:               4,
+		Accounts:            20,
+		Rate:                20,
+		Duration:            5 * time.Second,
+		DrainTimeout:        30 * time.Second,
+		ServicePaymentRatio: 0.5,
+	}
+	result, err := Run(config)
+	assert.Nil(err)
+	assert.True(result.Submitted > 0)
+	assert.Equal(result.Submitted, result.Admitted+result.Rejected)
+	assert.Equal(0, result.Rejected)
+	assert.Equal(result.Admitted, result.Finalized)
+	assert.Equal(result.Finalized, result.FinalizationLatency.Count)
+	assert.True(result.Blocks.Blocks > 0)
+}
+
+func TestInvalidConfig(t *testing.T) {
+	config := DefaultConfig()
+	config.ServicePaymentRatio = 2
+	_, err := Run(config)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,78 @@
+package bench
+
+import (
+	"sort"
+	"time"
+)
+
+// LatencyStats summarizes latencies, in milliseconds.
+type LatencyStats struct {
+	Count int     `json:"count"`
+	Mean  float64 `json:"mean_ms"`
+	P50   float64 `json:"p50_ms"`
+	P90   float64 `json:"p90_ms"`
+	P99   float64 `json:"p99_ms"`
+	Max   float64 `json:"max_ms"`
+}
+
+// NewLatencyStats computes the statistics of the given latencies.
+func NewLatencyStats(latencies []time.Duration) LatencyStats {
+	stats := LatencyStats{Count: len(latencies)}
+	if len(latencies) == 0 {
+		return stats
+	}
+	sorted := append([]time.Duration{}, latencies...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	total := time.Duration(0)
+	for _, l := range sorted {
+		total += l
+	}
+	stats.Mean = milliseconds(total / time.Duration(len(sorted)))
+	stats.P50 = milliseconds(percentile(sorted, 50))
+	stats.P90 = milliseconds(percentile(sorted, 90))
+	stats.P99 = milliseconds(percentile(sorted, 99))
+	stats.Max = milliseconds(sorted[len(sorted)-1])
+	return stats
+}
+
+// percentile returns the nearest-rank percentile of the sorted latencies.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// BlockStats summarizes the number of regular transactions in the blocks finalized
+// during a run, i.e. how full they are.
+type BlockStats struct {
+	Blocks   int     `json:"blocks"`
+	MeanTxs  float64 `json:"mean_txs"`
+	MaxTxs   int     `json:"max_txs"`
+	Fullness float64 `json:"fullness"` // Mean fraction of the maximum number of transactions per block
+}
+
+// NewBlockStats computes the statistics of the given numbers of transactions per
+// block, for blocks of at most maxTxs transactions.
+func NewBlockStats(txsPerBlock []int, maxTxs int) BlockStats {
+	stats := BlockStats{Blocks: len(txsPerBlock)}
+	if len(txsPerBlock) == 0 {
+		return stats
+	}
+	total := 0
+	for _, n := range txsPerBlock {
+		total += n
+		if n > stats.MaxTxs {
+			stats.MaxTxs = n
+		}
+	}
+	stats.MeanTxs = float64(total) / float64(len(txsPerBlock))
+	stats.Fullness = stats.MeanTxs / float64(maxTxs)
+	return stats
+}
@@ -0,0 +1,48 @@
+package bench
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thetatoken/ukulele/core"
+)
+
+func TestLatencyStats(t *testing.T) {
+	assert := assert.New(t)
+
+	stats := NewLatencyStats(nil)
+	assert.Equal(LatencyStats{}, stats)
+
+	latencies := []time.Duration{}
+	for i := 100; i >= 1; i-- {
+		latencies = append(latencies, time.Duration(i)*time.Millisecond)
+	}
+	stats = NewLatencyStats(latencies)
+	assert.Equal(100, stats.Count)
+	assert.Equal(50.5, stats.Mean)
+	assert.Equal(50.0, stats.P50)
+	assert.Equal(90.0, stats.P90)
+	assert.Equal(99.0, stats.P99)
+	assert.Equal(100.0, stats.Max)
+
+	// The latencies are not reordered
+	assert.Equal(100*time.Millisecond, latencies[0])
+
+	stats = NewLatencyStats([]time.Duration{3 * time.Millisecond})
+	assert.Equal(3.0, stats.P50)
+	assert.Equal(3.0, stats.P99)
+}
+
+func TestBlockStats(t *testing.T) {
+	assert := assert.New(t)
+
+	stats := NewBlockStats(nil, core.MaxNumRegularTxsPerBlock)
+	assert.Equal(BlockStats{}, stats)
+
+	stats = NewBlockStats([]int{0, 10, 20, 10}, 20)
+	assert.Equal(4, stats.Blocks)
+	assert.Equal(10.0, stats.MeanTxs)
+	assert.Equal(20, stats.MaxTxs)
+	assert.Equal(0.5, stats.Fullness)
+}
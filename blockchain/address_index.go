@@ -0,0 +1,149 @@
+package blockchain
+
+import (
+	"encoding/binary"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/core"
+	"github.com/thetatoken/ukulele/crypto"
+	"github.com/thetatoken/ukulele/ledger/types"
+	"github.com/thetatoken/ukulele/store"
+)
+
+// AddressTxDirection selects the transactions of an address in the address index
+type AddressTxDirection byte
+
+// Directions of the transactions of an address
+const (
+	AddressTxAll      AddressTxDirection = 'a' // Transactions sent or received by the address
+	AddressTxSent     AddressTxDirection = 's' // Transactions signed or paid for by the address
+	AddressTxReceived AddressTxDirection = 'r' // Transactions directed to the address
+)
+
+// AddressTxEntry locates a transaction of an address in the finalized chain
+type AddressTxEntry struct {
+	TxHash      common.Hash
+	BlockHash   common.Hash
+	BlockHeight uint64
+}
+
+// addressTxCountKey constructs the DB key of the number of transactions of the address
+// in the given direction. The transactions are numbered from 0 in the order of the chain.
+func addressTxCountKey(address common.Address, direction AddressTxDirection) common.Bytes {
+	key := append(common.CopyBytes(addressIndexPrefix), address[:]...)
+	return append(key, byte(direction))
+}
+
+// addressTxKey constructs the DB key of the transaction with the given number.
+func addressTxKey(address common.Address, direction AddressTxDirection, num uint64) common.Bytes {
+	key := addressTxCountKey(address, direction)
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, num)
+	return append(key, buf...)
+}
+
+// addressTxCounter tracks the numbers of transactions of the addresses written to a
+// batch, which are not visible in the DB yet.
+type addressTxCounter struct {
+	store  store.Store
+	counts map[string]uint64
+}
+
+func newAddressTxCounter(store store.Store) *addressTxCounter {
+	return &addressTxCounter{
+		store:  store,
+		counts: make(map[string]uint64),
+	}
+}
+
+func (c *addressTxCounter) next(address common.Address, direction AddressTxDirection) (common.Bytes, uint64) {
+	key := addressTxCountKey(address, direction)
+	count, ok := c.counts[string(key)]
+	if !ok {
+		if err := c.store.Get(key, &count); err != nil && err != store.ErrKeyNotFound {
+			log.Panic(err)
+		}
+	}
+	c.counts[string(key)] = count + 1
+	return key, count
+}
+
+// addTxsToAddressIndex appends the transactions of the newly finalized blocks, given in
+// ascending height, to the lists of their senders and recipients.
+func (ch *Chain) addTxsToAddressIndex(batch store.Batch, blocks []*core.ExtendedBlock) {
+	if !ch.addressIndexEnabled || len(blocks) == 0 {
+		return
+	}
+	counter := newAddressTxCounter(ch.store)
+	add := func(address common.Address, direction AddressTxDirection, entry *AddressTxEntry) {
+		countKey, num := counter.next(address, direction)
+		if err := batch.Put(addressTxKey(address, direction, num), entry); err != nil {
+			log.Panic(err)
+		}
+		if err := batch.Put(countKey, num+1); err != nil {
+			log.Panic(err)
+		}
+	}
+
+	for _, block := range blocks {
+		for _, rawTx := range block.Txs {
+			tx, err := types.TxFromBytes(rawTx)
+			if err != nil {
+				log.Warnf("Failed to decode a transaction of block %v, not indexing it by address: %v", block.Hash().Hex(), err)
+				continue
+			}
+			entry := &AddressTxEntry{
+				TxHash:      crypto.Keccak256Hash(rawTx),
+				BlockHash:   block.Hash(),
+				BlockHeight: block.Height,
+			}
+			senders, recipients := types.TxParticipants(tx)
+			all := make(map[common.Address]bool)
+			for _, address := range senders {
+				add(address, AddressTxSent, entry)
+				all[address] = true
+				add(address, AddressTxAll, entry)
+			}
+			for _, address := range recipients {
+				add(address, AddressTxReceived, entry)
+				if !all[address] {
+					add(address, AddressTxAll, entry)
+				}
+			}
+		}
+	}
+}
+
+// GetAddressTxCount returns the number of finalized transactions of the address in the
+// given direction.
+func (ch *Chain) GetAddressTxCount(address common.Address, direction AddressTxDirection) uint64 {
+	var count uint64
+	err := ch.store.Get(addressTxCountKey(address, direction), &count)
+	if err != nil && err != store.ErrKeyNotFound {
+		log.Errorf("Failed to load the transaction count of address %v: %v", address.Hex(), err)
+	}
+	return count
+}
+
+// FindAddressTxs returns up to count finalized transactions of the address in the given
+// direction, from the one with the given number, in the order of the chain, or from
+// the most recent ones if reverse is set.
+func (ch *Chain) FindAddressTxs(address common.Address, direction AddressTxDirection, start, count uint64, reverse bool) []AddressTxEntry {
+	total := ch.GetAddressTxCount(address, direction)
+	entries := []AddressTxEntry{}
+	for i := start; i < start+count && i < total; i++ {
+		num := i
+		if reverse {
+			num = total - 1 - i
+		}
+		entry := AddressTxEntry{}
+		if err := ch.store.Get(addressTxKey(address, direction, num), &entry); err != nil {
+			log.Errorf("Failed to load transaction %v of address %v: %v", num, address.Hex(), err)
+			break
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
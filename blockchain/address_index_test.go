@@ -0,0 +1,87 @@
+package blockchain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/core"
+	"github.com/thetatoken/ukulele/crypto"
+	"github.com/thetatoken/ukulele/ledger/types"
+)
+
+func newRawTestSendTx(from common.Address, to ...common.Address) common.Bytes {
+	tx := &types.SendTx{
+		Fee:    types.NewCoins(0, 1),
+		Inputs: []types.TxInput{{Address: from, Coins: types.NewCoins(1, 1), Sequence: 1}},
+	}
+	for _, addr := range to {
+		tx.Outputs = append(tx.Outputs, types.TxOutput{Address: addr, Coins: types.NewCoins(1, 0)})
+	}
+	raw, err := types.TxToBytes(tx)
+	if err != nil {
+		panic(err)
+	}
+	return raw
+}
+
+func TestAddressIndex(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	alice, bob, carol := common.HexToAddress("0xa"), common.HexToAddress("0xb"), common.HexToAddress("0xc")
+	tx1 := newRawTestSendTx(alice, bob)
+	tx2 := newRawTestSendTx(bob, alice, carol)
+	tx3 := newRawTestSendTx(carol, carol)
+
+	chain := CreateTestChain()
+	block1 := core.CreateTestBlock("ad1", "a0")
+	block1.Txs = []common.Bytes{tx1}
+	block2 := core.CreateTestBlock("ad2", "ad1")
+	block2.Txs = []common.Bytes{tx2, common.Bytes("invalid")}
+	block3 := core.CreateTestBlock("ad3", "ad2")
+	block3.Txs = []common.Bytes{tx3}
+	for _, block := range []*core.Block{block1, block2, block3} {
+		_, err := chain.AddBlock(block)
+		require.Nil(err)
+	}
+
+	// The blocks are indexed by address when they are finalized
+	assert.Equal(uint64(0), chain.GetAddressTxCount(alice, AddressTxAll))
+	eb2, err := chain.FindBlock(block2.Hash())
+	require.Nil(err)
+	chain.FinalizeBlock(eb2)
+
+	assert.Equal(uint64(2), chain.GetAddressTxCount(alice, AddressTxAll))
+	assert.Equal(uint64(1), chain.GetAddressTxCount(alice, AddressTxSent))
+	assert.Equal(uint64(1), chain.GetAddressTxCount(alice, AddressTxReceived))
+	assert.Equal(uint64(0), chain.GetAddressTxCount(carol, AddressTxSent))
+
+	entries := chain.FindAddressTxs(alice, AddressTxAll, 0, 10, false)
+	require.Equal(2, len(entries))
+	assert.Equal(crypto.Keccak256Hash(tx1), entries[0].TxHash)
+	assert.Equal(block1.Hash(), entries[0].BlockHash)
+	assert.Equal(crypto.Keccak256Hash(tx2), entries[1].TxHash)
+	assert.Equal(block2.Height, entries[1].BlockHeight)
+
+	// Most recent first, with pagination
+	entries = chain.FindAddressTxs(alice, AddressTxAll, 0, 1, true)
+	require.Equal(1, len(entries))
+	assert.Equal(crypto.Keccak256Hash(tx2), entries[0].TxHash)
+	entries = chain.FindAddressTxs(alice, AddressTxAll, 1, 1, true)
+	require.Equal(1, len(entries))
+	assert.Equal(crypto.Keccak256Hash(tx1), entries[0].TxHash)
+	assert.Equal(0, len(chain.FindAddressTxs(alice, AddressTxAll, 2, 1, true)))
+
+	// Finalizing a block again does not index it twice, and a transaction sent to
+	// oneself is listed once in all the transactions of the address
+	chain.FinalizeBlock(eb2)
+	eb3, err := chain.FindBlock(block3.Hash())
+	require.Nil(err)
+	chain.FinalizeBlock(eb3)
+	assert.Equal(uint64(2), chain.GetAddressTxCount(alice, AddressTxAll))
+	assert.Equal(uint64(2), chain.GetAddressTxCount(carol, AddressTxAll))
+	assert.Equal(uint64(1), chain.GetAddressTxCount(carol, AddressTxSent))
+	assert.Equal(uint64(2), chain.GetAddressTxCount(carol, AddressTxReceived))
+}
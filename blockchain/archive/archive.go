@@ -0,0 +1,470 @@
+// Package archive exports the finalized chain, and optionally the ledger state of
+// its head, to a portable archive file, and imports it into the database of another
+// node. Archives are used for backups, and to seed new nodes without syncing the
+// whole chain from the network.
+//
+// An archive starts with the magic bytes, followed by a gzip compressed stream of
+// RLP values: a Header, then one Entry per block from the first block after the
+// root, then one Entry per ledger state key/value pair and per smart contract
+// storage key/value pair of the head, then an end Entry holding the number of
+// entries before it.
+//
+// A snapshot holds the ledger state of a finalized block only, in chunks of state
+// entries listed in a signed Snapshot, for new nodes to download from their peers.
+package archive
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+
+	"github.com/pkg/errors"
+	"github.com/thetatoken/ukulele/blockchain"
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/consensus"
+	"github.com/thetatoken/ukulele/core"
+	"github.com/thetatoken/ukulele/ledger/state"
+	"github.com/thetatoken/ukulele/ledger/types"
+	"github.com/thetatoken/ukulele/rlp"
+	"github.com/thetatoken/ukulele/store/database"
+	"github.com/thetatoken/ukulele/store/kvstore"
+	"github.com/thetatoken/ukulele/store/treestore"
+)
+
+// Version is the version of the archive format written by Export.
+const Version = 1
+
+// magic identifies archive files.
+var magic = []byte("THETAARC")
+
+// Kinds of archive entries
+const (
+	EntryEnd     = 0 // Value is the number of entries before it
+	EntryBlock   = 1 // Key is the block hash, Value is the RLP encoded block
+	EntryState   = 2 // Key/Value pair of the ledger state
+	EntryStorage = 3 // Key is the account address followed by the storage key
+)
+
+// Header describes the content of an archive.
+type Header struct {
+	Version       uint64
+	ChainID       string
+	Root          common.Hash // Hash of the root block of the chain, not in the archive
+	Head          common.Hash // Hash of the last block of the archive
+	HeadHeight    uint64
+	HeadStateHash common.Hash
+	HasState      bool
+}
+
+// Entry is a block or a key/value pair of the archive.
+type Entry struct {
+	Kind  uint64
+	Key   common.Bytes
+	Value common.Bytes
+}
+
+// Progress is called as the blocks and the state entries are exported or imported.
+type Progress func(blocks uint64, stateEntries uint64)
+
+// Export writes the finalized blocks of the chain, from the root to the latest
+// finalized block, to w. With withState, it also writes the ledger state of the
+// latest finalized block, which must not be pruned.
+func Export(w io.Writer, chain *blockchain.Chain, db database.Database, withState bool, progress Progress) (*Header, error) {
+	if progress == nil {
+		progress = func(uint64, uint64) {}
+	}
+
+	hashes := finalizedBranch(chain)
+	head := chain.Root
+	if len(hashes) > 0 {
+		var err error
+		head, err = chain.FindBlock(hashes[len(hashes)-1])
+		if err != nil {
+			return nil, err
+		}
+	}
+	header := &Header{
+		Version:       Version,
+		ChainID:       chain.ChainID,
+		Root:          chain.Root.Hash(),
+		Head:          head.Hash(),
+		HeadHeight:    head.Height,
+		HeadStateHash: head.StateHash,
+		HasState:      withState,
+	}
+	var sv *state.StoreView
+	if withState {
+		sv = state.NewStoreView(head.Height, head.StateHash, db)
+		if sv == nil {
+			return nil, errors.Errorf("State %v of block %v is not in the database", head.StateHash.Hex(), header.Head.Hex())
+		}
+	}
+
+	if _, err := w.Write(magic); err != nil {
+		return nil, err
+	}
+	gz := gzip.NewWriter(w)
+	if err := rlp.Encode(gz, header); err != nil {
+		return nil, err
+	}
+
+	entries := uint64(0)
+	writeEntry := func(kind uint64, key, value common.Bytes) error {
+		entries++
+		return rlp.Encode(gz, Entry{Kind: kind, Key: key, Value: value})
+	}
+
+	for i, hash := range hashes {
+		block, err := chain.FindBlock(hash)
+		if err != nil {
+			return nil, err
+		}
+		raw, err := rlp.EncodeToBytes(block.Block)
+		if err != nil {
+			return nil, err
+		}
+		if err := writeEntry(EntryBlock, hash[:], raw); err != nil {
+			return nil, err
+		}
+		progress(uint64(i+1), 0)
+	}
+
+	if withState {
+		stateEntries := uint64(0)
+		err := traverseState(sv, db, func(kind uint64, key, value common.Bytes) error {
+			if err := writeEntry(kind, key, value); err != nil {
+				return err
+			}
+			stateEntries++
+			if kind == EntryState {
+				progress(uint64(len(hashes)), stateEntries)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		progress(uint64(len(hashes)), stateEntries)
+	}
+
+	count, err := rlp.EncodeToBytes(entries)
+	if err != nil {
+		return nil, err
+	}
+	if err := rlp.Encode(gz, Entry{Kind: EntryEnd, Value: count}); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return header, nil
+}
+
+// Import reads an archive written by Export from r, and adds its blocks to the
+// chain, which must have the same root. The hash of each block is checked, and each
+// block must be the child of the previous one. The head of the archive is
+// finalized, and with the ledger state in the archive or already in db, the
+// consensus state is moved to the head, so that the node resumes from there.
+func Import(r io.Reader, chain *blockchain.Chain, db database.Database, progress Progress) (*Header, error) {
+	if progress == nil {
+		progress = func(uint64, uint64) {}
+	}
+
+	br := bufio.NewReader(r)
+	prefix := make([]byte, len(magic))
+	if _, err := io.ReadFull(br, prefix); err != nil || !bytes.Equal(prefix, magic) {
+		return nil, errors.New("Not a chain archive")
+	}
+	gz, err := gzip.NewReader(br)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to decompress archive")
+	}
+	defer gz.Close()
+	stream := rlp.NewStream(gz, 0)
+
+	header := &Header{}
+	if err := stream.Decode(header); err != nil {
+		return nil, errors.Wrap(err, "Failed to decode archive header")
+	}
+	if header.Version != Version {
+		return nil, errors.Errorf("Unsupported archive version %v, expected %v", header.Version, Version)
+	}
+	if header.ChainID != chain.ChainID {
+		return nil, errors.Errorf("Archive is for chain %v, not %v", header.ChainID, chain.ChainID)
+	}
+	if header.Root != chain.Root.Hash() {
+		return nil, errors.Errorf("Archive is for root block %v, not %v", header.Root.Hex(), chain.Root.Hash().Hex())
+	}
+	if !header.HasState && state.NewStoreView(header.HeadHeight, header.HeadStateHash, db) == nil {
+		return nil, errors.Errorf("Archive has no ledger state, and state %v of its head is not in the database", header.HeadStateHash.Hex())
+	}
+
+	entries := uint64(0)
+	blocks := uint64(0)
+	stateEntries := uint64(0)
+	head := chain.Root
+	var stateImp *stateImport
+	for {
+		entry := Entry{}
+		if err := stream.Decode(&entry); err != nil {
+			return nil, errors.Wrap(err, "Failed to decode archive entry")
+		}
+		if entry.Kind == EntryEnd {
+			count := uint64(0)
+			if err := rlp.DecodeBytes(entry.Value, &count); err != nil || count != entries {
+				return nil, errors.New("Archive is truncated")
+			}
+			break
+		}
+		entries++
+
+		switch entry.Kind {
+		case EntryBlock:
+			if stateImp != nil {
+				return nil, errors.New("Archive has blocks after the ledger state")
+			}
+			block := &core.Block{}
+			if err := rlp.DecodeBytes(entry.Value, block); err != nil {
+				return nil, errors.Wrap(err, "Failed to decode block")
+			}
+			hash := block.Hash()
+			if !bytes.Equal(hash[:], entry.Key) {
+				return nil, errors.Errorf("Block hash mismatch: %v != %v", hash.Hex(), common.BytesToHash(entry.Key).Hex())
+			}
+			if block.Parent != head.Hash() || block.Height != head.Height+1 {
+				return nil, errors.Errorf("Block %v is not a child of block %v", hash.Hex(), head.Hash().Hex())
+			}
+			eb, err := chain.FindBlock(hash)
+			if err != nil {
+				eb, err = chain.AddBlock(block)
+				if err != nil {
+					return nil, errors.Wrapf(err, "Failed to add block %v", hash.Hex())
+				}
+			}
+			head = eb
+			blocks++
+			progress(blocks, stateEntries)
+		case EntryState, EntryStorage:
+			if !header.HasState {
+				return nil, errors.New("Archive has unexpected ledger state")
+			}
+			if stateImp == nil {
+				if head.Hash() != header.Head {
+					return nil, errors.Errorf("Archive ends at block %v, not its head %v", head.Hash().Hex(), header.Head.Hex())
+				}
+				stateImp = newStateImport(head.Height, db)
+			}
+			if err := stateImp.add(entry.Kind, entry.Key, entry.Value); err != nil {
+				return nil, err
+			}
+			stateEntries++
+			if stateEntries%10000 == 0 {
+				progress(blocks, stateEntries)
+			}
+		default:
+			return nil, errors.Errorf("Unknown archive entry kind %v", entry.Kind)
+		}
+	}
+
+	if head.Hash() != header.Head {
+		return nil, errors.Errorf("Archive ends at block %v, not its head %v", head.Hash().Hex(), header.Head.Hex())
+	}
+	if header.HasState {
+		if stateImp == nil {
+			stateImp = newStateImport(head.Height, db)
+		}
+		if err := stateImp.finish(head.StateHash); err != nil {
+			return nil, err
+		}
+		progress(blocks, stateEntries)
+	}
+
+	if head.Hash() != chain.Root.Hash() {
+		chain.FinalizeBlock(head)
+		if err := resumeConsensusFrom(chain, db, head); err != nil {
+			return nil, err
+		}
+	}
+	return header, nil
+}
+
+// finalizedBranch returns the hashes of the finalized blocks after the root, by
+// ascending height.
+func finalizedBranch(chain *blockchain.Chain) []common.Hash {
+	hashes := []common.Hash{}
+	parent := chain.Root.Hash()
+	for height := chain.Root.Height + 1; ; height++ {
+		var next *core.ExtendedBlock
+		for _, block := range chain.FindBlocksByHeight(height) {
+			if block.Status == core.BlockStatusFinalized && block.Parent == parent {
+				next = block
+				break
+			}
+		}
+		if next == nil {
+			return hashes
+		}
+		parent = next.Hash()
+		hashes = append(hashes, parent)
+	}
+}
+
+// resumeConsensusFrom moves the consensus state to the given finalized block, unless
+// it is already past it.
+func resumeConsensusFrom(chain *blockchain.Chain, db database.Database, block *core.ExtendedBlock) error {
+	store := kvstore.NewKVStore(db)
+	key := common.Bytes(consensus.DBStateStubKey)
+	stub := &consensus.StateStub{}
+	if err := store.Get(key, stub); err != nil || stub.Root != chain.Root.Hash() {
+		stub = &consensus.StateStub{Root: chain.Root.Hash()}
+	}
+	if !stub.LastFinalizedBlock.IsEmpty() {
+		finalized, err := chain.FindBlock(stub.LastFinalizedBlock)
+		if err == nil && finalized.Height >= block.Height {
+			return nil
+		}
+	}
+
+	stub.LastFinalizedBlock = block.Hash()
+	stub.HighestCCBlock = block.Hash()
+	if stub.LastVoteHeight < block.Height {
+		stub.LastVoteHeight = block.Height
+	}
+	if stub.Epoch < block.Epoch {
+		stub.Epoch = block.Epoch
+	}
+	return store.Put(key, stub)
+}
+
+// accountAddress returns the address of an account key of the ledger state.
+func accountAddress(key common.Bytes) (common.Address, bool) {
+	prefix := state.AccountKeyPrefix()
+	if len(key) != len(prefix)+common.AddressLength || !bytes.HasPrefix(key, prefix) {
+		return common.Address{}, false
+	}
+	return common.BytesToAddress(key[len(prefix):]), true
+}
+
+// traverseState calls fn with the key/value pairs of the ledger state, each account
+// followed by the key/value pairs of its smart contract storage.
+func traverseState(sv *state.StoreView, db database.Database, fn func(kind uint64, key, value common.Bytes) error) error {
+	var err error
+	sv.GetStore().Traverse(nil, func(k, v common.Bytes) bool {
+		if err != nil {
+			return false
+		}
+		if err = fn(EntryState, k, v); err != nil {
+			return false
+		}
+
+		addr, ok := accountAddress(k)
+		if !ok {
+			return true
+		}
+		account := &types.Account{}
+		if err = types.FromBytes(v, account); err != nil {
+			return false
+		}
+		if account.Root.IsEmpty() {
+			return true
+		}
+		storage := treestore.NewTreeStore(account.Root, db)
+		if storage == nil {
+			err = errors.Errorf("Storage %v of account %v is not in the database", account.Root.Hex(), addr.Hex())
+			return false
+		}
+		storage.Traverse(nil, func(sk, sval common.Bytes) bool {
+			if err != nil {
+				return false
+			}
+			err = fn(EntryStorage, append(addr.Bytes(), sk...), sval)
+			return err == nil
+		})
+		return err == nil
+	})
+	return err
+}
+
+// stateImport rebuilds a ledger state from the key/value pairs in the order they
+// are traversed by traverseState.
+type stateImport struct {
+	sv      *state.StoreView
+	storage *storageImport
+}
+
+func newStateImport(height uint64, db database.Database) *stateImport {
+	return &stateImport{
+		sv:      state.NewStoreView(height, common.Hash{}, db),
+		storage: &storageImport{db: db},
+	}
+}
+
+func (s *stateImport) add(kind uint64, key, value common.Bytes) error {
+	if kind == EntryStorage {
+		return s.storage.set(key, value)
+	}
+	if err := s.storage.finish(); err != nil {
+		return err
+	}
+	s.sv.Set(key, value)
+	if addr, ok := accountAddress(key); ok {
+		account := &types.Account{}
+		if err := types.FromBytes(value, account); err != nil {
+			return errors.Wrapf(err, "Failed to decode account %v", addr.Hex())
+		}
+		s.storage.start(addr, account.Root)
+	}
+	return nil
+}
+
+// finish saves the state, and checks its root.
+func (s *stateImport) finish(stateHash common.Hash) error {
+	if err := s.storage.finish(); err != nil {
+		return err
+	}
+	if root := s.sv.Save(); root != stateHash {
+		return errors.Errorf("State root mismatch: %v != %v", root.Hex(), stateHash.Hex())
+	}
+	return nil
+}
+
+// storageImport rebuilds the storage of the account imported last.
+type storageImport struct {
+	db    database.Database
+	addr  common.Address
+	root  common.Hash
+	store *treestore.TreeStore
+}
+
+func (s *storageImport) start(addr common.Address, root common.Hash) {
+	s.addr = addr
+	s.root = root
+	s.store = nil
+	if !root.IsEmpty() {
+		s.store = treestore.NewTreeStore(common.Hash{}, s.db)
+	}
+}
+
+func (s *storageImport) set(key, value common.Bytes) error {
+	if s.store == nil || len(key) < common.AddressLength || common.BytesToAddress(key[:common.AddressLength]) != s.addr {
+		return errors.Errorf("Storage entry %v does not follow its account", common.Bytes(key))
+	}
+	s.store.Set(key[common.AddressLength:], value)
+	return nil
+}
+
+func (s *storageImport) finish() error {
+	if s.store == nil {
+		return nil
+	}
+	root, err := s.store.Commit()
+	if err != nil {
+		return err
+	}
+	s.store = nil
+	if root != s.root {
+		return errors.Errorf("Storage root mismatch for account %v: %v != %v", s.addr.Hex(), root.Hex(), s.root.Hex())
+	}
+	return nil
+}
@@ -0,0 +1,205 @@
+package archive
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thetatoken/ukulele/blockchain"
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/consensus"
+	"github.com/thetatoken/ukulele/core"
+	"github.com/thetatoken/ukulele/crypto"
+	"github.com/thetatoken/ukulele/ledger/state"
+	"github.com/thetatoken/ukulele/ledger/types"
+	"github.com/thetatoken/ukulele/store/database"
+	"github.com/thetatoken/ukulele/store/database/backend"
+	"github.com/thetatoken/ukulele/store/kvstore"
+)
+
+var (
+	testAddr       = common.HexToAddress("0x2e833968e5bb786ae419c4d13189fb081cc43bab")
+	testStorageKey = common.BytesToHash([]byte("key"))
+	testStorageVal = common.BytesToHash([]byte("value"))
+)
+
+// createSourceChain creates a chain of finalized blocks a1 to a3, and a fork b2
+// that is not finalized. The ledger state of a3 has a smart contract account.
+func createSourceChain(require *require.Assertions) (*blockchain.Chain, database.Database, *core.Block) {
+	core.ResetTestBlocks()
+	db := backend.NewMemDatabase()
+	root := core.CreateTestBlock("a0", "")
+	chain := blockchain.NewChain("testchain", kvstore.NewKVStore(db), root)
+
+	sv := state.NewStoreView(3, common.Hash{}, db)
+	sv.SetAccount(testAddr, &types.Account{
+		Address: testAddr,
+		Balance: types.Coins{ThetaWei: big.NewInt(100), GammaWei: big.NewInt(200)},
+	})
+	sv.SetState(testAddr, testStorageKey, testStorageVal)
+	stateHash := sv.Save()
+
+	a1 := core.CreateTestBlock("a1", "a0")
+	a1.Txs = []common.Bytes{common.Bytes("tx1")}
+	a2 := core.CreateTestBlock("a2", "a1")
+	b2 := core.CreateTestBlock("b2", "a1")
+	a3 := core.CreateTestBlock("a3", "a2")
+	a3.StateHash = stateHash
+	a3.Epoch = 7
+	for _, block := range []*core.Block{a1, a2, b2, a3} {
+		_, err := chain.AddBlock(block)
+		require.Nil(err)
+	}
+	head, err := chain.FindBlock(a3.Hash())
+	require.Nil(err)
+	chain.FinalizeBlock(head)
+	return chain, db, root
+}
+
+func TestExportImport(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	source, sourceDB, root := createSourceChain(require)
+	buf := &bytes.Buffer{}
+	header, err := Export(buf, source, sourceDB, true, nil)
+	require.Nil(err)
+	a3 := core.GetTestBlock("a3")
+	assert.Equal(a3.Hash(), header.Head)
+	assert.Equal(uint64(3), header.HeadHeight)
+
+	db := backend.NewMemDatabase()
+	chain := blockchain.NewChain("testchain", kvstore.NewKVStore(db), root)
+	var lastBlocks, lastEntries uint64
+	_, err = Import(bytes.NewReader(buf.Bytes()), chain, db, func(blocks, entries uint64) {
+		lastBlocks, lastEntries = blocks, entries
+	})
+	require.Nil(err)
+	assert.Equal(uint64(3), lastBlocks)
+	assert.True(lastEntries > 0)
+
+	// The finalized blocks are imported, the fork is not
+	for _, name := range []string{"a1", "a2", "a3"} {
+		block, err := chain.FindBlock(core.GetTestBlock(name).Hash())
+		if assert.Nil(err, name) {
+			assert.Equal(core.BlockStatusFinalized, block.Status)
+		}
+	}
+	_, err = chain.FindBlock(core.GetTestBlock("b2").Hash())
+	assert.NotNil(err)
+	_, block, found := chain.FindTxByHash(crypto.Keccak256Hash(common.Bytes("tx1")))
+	assert.True(found)
+	assert.Equal(core.GetTestBlock("a1").Hash(), block.Hash())
+
+	// The ledger state and the contract storage of the head are imported
+	sv := state.NewStoreView(3, a3.StateHash, db)
+	require.NotNil(sv)
+	assert.Equal(big.NewInt(100), sv.GetAccount(testAddr).Balance.ThetaWei)
+	assert.Equal(testStorageVal, sv.GetState(testAddr, testStorageKey))
+
+	// The consensus resumes from the head
+	stub := &consensus.StateStub{}
+	require.Nil(kvstore.NewKVStore(db).Get(common.Bytes(consensus.DBStateStubKey), stub))
+	assert.Equal(root.Hash(), stub.Root)
+	assert.Equal(a3.Hash(), stub.LastFinalizedBlock)
+	assert.Equal(a3.Hash(), stub.HighestCCBlock)
+	assert.Equal(uint64(7), stub.Epoch)
+	assert.Equal(uint64(3), stub.LastVoteHeight)
+
+	// Importing again is a no-op
+	_, err = Import(bytes.NewReader(buf.Bytes()), chain, db, nil)
+	assert.Nil(err)
+}
+
+func TestImportInvalidArchive(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	source, sourceDB, root := createSourceChain(require)
+	buf := &bytes.Buffer{}
+	_, err := Export(buf, source, sourceDB, false, nil)
+	require.Nil(err)
+
+	// Without the ledger state in the archive, the state of the head needs to be in
+	// the database already
+	db := backend.NewMemDatabase()
+	chain := blockchain.NewChain("testchain", kvstore.NewKVStore(db), root)
+	_, err = Import(bytes.NewReader(buf.Bytes()), chain, db, nil)
+	assert.NotNil(err)
+	_, err = chain.FindBlock(core.GetTestBlock("a1").Hash())
+	assert.NotNil(err)
+
+	// Truncated archive
+	buf = &bytes.Buffer{}
+	_, err = Export(buf, source, sourceDB, true, nil)
+	require.Nil(err)
+	raw := buf.Bytes()
+	_, err = Import(bytes.NewReader(raw[:len(raw)/2]), chain, db, nil)
+	assert.NotNil(err)
+
+	// Another root block
+	otherDB := backend.NewMemDatabase()
+	other := blockchain.NewChain("testchain", kvstore.NewKVStore(otherDB), core.CreateTestBlock("x0", ""))
+	_, err = Import(bytes.NewReader(raw), other, otherDB, nil)
+	assert.NotNil(err)
+
+	// Not an archive
+	_, err = Import(bytes.NewReader([]byte("not an archive")), chain, db, nil)
+	assert.NotNil(err)
+}
+
+func TestSnapshot(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	source, sourceDB, _ := createSourceChain(require)
+	privKey, _, err := crypto.GenerateKeyPair()
+	require.Nil(err)
+	signer := core.NewPrivateKeySigner(privKey)
+
+	a2, err := source.FindBlock(core.GetTestBlock("a2").Hash())
+	require.Nil(err)
+	b2, err := source.FindBlock(core.GetTestBlock("b2").Hash())
+	require.Nil(err)
+	_, _, err = GenerateSnapshot("testchain", b2, sourceDB, signer, 0)
+	assert.NotNil(err)
+	_, _, err = GenerateSnapshot("testchain", a2, sourceDB, signer, 0)
+	assert.NotNil(err) // The state of a2 is not in the database
+
+	head, err := source.FindBlock(core.GetTestBlock("a3").Hash())
+	require.Nil(err)
+	snapshot, chunks, err := GenerateSnapshot("testchain", head, sourceDB, signer, 1)
+	require.Nil(err)
+	assert.Equal(head.Hash(), snapshot.Block)
+	assert.Equal(head.StateHash, snapshot.StateHash)
+	assert.Equal(signer.ID(), snapshot.Signer)
+	assert.True(len(chunks) > 1)
+	assert.Equal(len(chunks), len(snapshot.Chunks))
+
+	// The snapshot survives encoding, and its signature covers the chunk hashes
+	raw, err := snapshot.ToBytes()
+	require.Nil(err)
+	snapshot, err = SnapshotFromBytes(raw)
+	require.Nil(err)
+	assert.Nil(snapshot.Verify())
+	tampered, err := SnapshotFromBytes(raw)
+	require.Nil(err)
+	tampered.Chunks[0] = common.Hash{}
+	assert.NotNil(tampered.Verify())
+
+	// The chunks are checked against their hashes
+	assert.NotNil(snapshot.VerifyChunk(0, chunks[1]))
+	assert.NotNil(snapshot.VerifyChunk(len(chunks), chunks[0]))
+	db := backend.NewMemDatabase()
+	swapped := append([]common.Bytes{chunks[1], chunks[0]}, chunks[2:]...)
+	assert.NotNil(RestoreSnapshot(snapshot, swapped, db))
+
+	db = backend.NewMemDatabase()
+	require.Nil(RestoreSnapshot(snapshot, chunks, db))
+	sv := state.NewStoreView(3, head.StateHash, db)
+	require.NotNil(sv)
+	assert.Equal(big.NewInt(100), sv.GetAccount(testAddr).Balance.ThetaWei)
+	assert.Equal(testStorageVal, sv.GetState(testAddr, testStorageKey))
+}
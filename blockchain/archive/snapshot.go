@@ -0,0 +1,168 @@
+package archive
+
+import (
+	"github.com/pkg/errors"
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/core"
+	"github.com/thetatoken/ukulele/crypto"
+	"github.com/thetatoken/ukulele/ledger/state"
+	"github.com/thetatoken/ukulele/rlp"
+	"github.com/thetatoken/ukulele/store/database"
+)
+
+// DefaultSnapshotChunkSize is the default size of the chunks of a snapshot, in bytes.
+const DefaultSnapshotChunkSize = 1024 * 1024
+
+// Snapshot describes the ledger state of a finalized block, split in chunks for
+// download. Each chunk is the RLP encoding of a list of state entries, in the
+// order of an archive. The snapshot is signed by the node that generated it, and
+// lists the hashes of the chunks, so that the chunks can be downloaded from any
+// peer and checked one by one.
+type Snapshot struct {
+	ChainID   string
+	Block     common.Hash
+	Height    uint64
+	StateHash common.Hash
+	Chunks    []common.Hash // Hashes of the chunks
+	Signer    common.Address
+	Signature *crypto.Signature `rlp:"nil"`
+}
+
+// SignBytes returns the bytes signed by the node that generated the snapshot.
+func (s *Snapshot) SignBytes() common.Bytes {
+	ss := Snapshot{
+		ChainID:   s.ChainID,
+		Block:     s.Block,
+		Height:    s.Height,
+		StateHash: s.StateHash,
+		Chunks:    s.Chunks,
+		Signer:    s.Signer,
+	}
+	raw, _ := rlp.EncodeToBytes(ss)
+	return raw
+}
+
+// ToBytes encodes the snapshot.
+func (s *Snapshot) ToBytes() (common.Bytes, error) {
+	return rlp.EncodeToBytes(s)
+}
+
+// SnapshotFromBytes decodes a snapshot encoded by ToBytes.
+func SnapshotFromBytes(raw common.Bytes) (*Snapshot, error) {
+	snapshot := &Snapshot{}
+	if err := rlp.DecodeBytes(raw, snapshot); err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// Verify checks that the snapshot is signed by its signer. Whether the signer is
+// trusted is up to the caller.
+func (s *Snapshot) Verify() error {
+	if s.Signature == nil || s.Signature.IsEmpty() {
+		return errors.New("Snapshot is not signed")
+	}
+	if !s.Signature.Verify(s.SignBytes(), s.Signer) {
+		return errors.Errorf("Invalid snapshot signature of %v", s.Signer.Hex())
+	}
+	return nil
+}
+
+// VerifyChunk checks a chunk against its hash in the snapshot.
+func (s *Snapshot) VerifyChunk(index int, chunk common.Bytes) error {
+	if index < 0 || index >= len(s.Chunks) {
+		return errors.Errorf("Snapshot has no chunk %v", index)
+	}
+	if hash := crypto.Keccak256Hash(chunk); hash != s.Chunks[index] {
+		return errors.Errorf("Chunk %v hash mismatch: %v != %v", index, hash.Hex(), s.Chunks[index].Hex())
+	}
+	return nil
+}
+
+// GenerateSnapshot splits the ledger state of the given finalized block into chunks
+// of about chunkSize bytes, and signs the snapshot with signer.
+func GenerateSnapshot(chainID string, block *core.ExtendedBlock, db database.Database, signer core.Signer, chunkSize int) (*Snapshot, []common.Bytes, error) {
+	if block.Status != core.BlockStatusFinalized {
+		return nil, nil, errors.Errorf("Block %v is not finalized", block.Hash().Hex())
+	}
+	sv := state.NewStoreView(block.Height, block.StateHash, db)
+	if sv == nil {
+		return nil, nil, errors.Errorf("State %v of block %v is not in the database", block.StateHash.Hex(), block.Hash().Hex())
+	}
+	if chunkSize <= 0 {
+		chunkSize = DefaultSnapshotChunkSize
+	}
+
+	chunks := []common.Bytes{}
+	entries := []Entry{}
+	size := 0
+	flush := func() error {
+		chunk, err := rlp.EncodeToBytes(entries)
+		if err != nil {
+			return err
+		}
+		chunks = append(chunks, chunk)
+		entries = []Entry{}
+		size = 0
+		return nil
+	}
+	err := traverseState(sv, db, func(kind uint64, key, value common.Bytes) error {
+		entries = append(entries, Entry{Kind: kind, Key: common.CopyBytes(key), Value: common.CopyBytes(value)})
+		size += len(key) + len(value)
+		if size >= chunkSize {
+			return flush()
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(entries) > 0 || len(chunks) == 0 {
+		if err := flush(); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	snapshot := &Snapshot{
+		ChainID:   chainID,
+		Block:     block.Hash(),
+		Height:    block.Height,
+		StateHash: block.StateHash,
+		Signer:    signer.ID(),
+	}
+	for _, chunk := range chunks {
+		snapshot.Chunks = append(snapshot.Chunks, crypto.Keccak256Hash(chunk))
+	}
+	snapshot.Signature, err = signer.Sign(snapshot.SignBytes())
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "Failed to sign snapshot")
+	}
+	return snapshot, chunks, nil
+}
+
+// RestoreSnapshot writes the ledger state of a snapshot to db, from its chunks in
+// order, and checks the state root.
+func RestoreSnapshot(snapshot *Snapshot, chunks []common.Bytes, db database.Database) error {
+	if len(chunks) != len(snapshot.Chunks) {
+		return errors.Errorf("Snapshot has %v chunks, got %v", len(snapshot.Chunks), len(chunks))
+	}
+	imp := newStateImport(snapshot.Height, db)
+	for i, chunk := range chunks {
+		if err := snapshot.VerifyChunk(i, chunk); err != nil {
+			return err
+		}
+		entries := []Entry{}
+		if err := rlp.DecodeBytes(chunk, &entries); err != nil {
+			return errors.Wrapf(err, "Failed to decode chunk %v", i)
+		}
+		for _, entry := range entries {
+			if entry.Kind != EntryState && entry.Kind != EntryStorage {
+				return errors.Errorf("Unexpected snapshot entry kind %v", entry.Kind)
+			}
+			if err := imp.add(entry.Kind, entry.Key, entry.Value); err != nil {
+				return err
+			}
+		}
+	}
+	return imp.finish(snapshot.StateHash)
+}
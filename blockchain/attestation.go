@@ -0,0 +1,35 @@
+package blockchain
+
+import (
+	log "github.com/sirupsen/logrus"
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/core"
+)
+
+// attestationIndexKey constructs the DB key for the given block hash.
+func attestationIndexKey(hash common.Hash) common.Bytes {
+	return append(common.CopyBytes(attestationIndexPrefix), hash[:]...)
+}
+
+// AddAttestationToIndex adds a guardian attestation to index, and returns whether
+// it was not indexed already.
+func (ch *Chain) AddAttestationToIndex(attestation core.Attestation) bool {
+	key := attestationIndexKey(attestation.Block)
+	attestations := core.NewAttestationSet()
+	ch.store.Get(key, attestations)
+	if !attestations.AddAttestation(attestation) {
+		return false
+	}
+	err := ch.store.Put(key, attestations)
+	if err != nil {
+		log.Panic(err)
+	}
+	return true
+}
+
+// FindAttestationsByHash looks up the guardian attestations of a block by hash.
+func (ch *Chain) FindAttestationsByHash(hash common.Hash) *core.AttestationSet {
+	attestations := core.NewAttestationSet()
+	ch.store.Get(attestationIndexKey(hash), attestations)
+	return attestations
+}
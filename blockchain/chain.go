@@ -9,8 +9,10 @@ import (
 	log "github.com/sirupsen/logrus"
 
 	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/common/lru"
 	"github.com/thetatoken/ukulele/core"
 	"github.com/thetatoken/ukulele/store"
+	"github.com/thetatoken/ukulele/store/freezer"
 )
 
 // Chain represents the blockchain and also is the interface to underlying store.
@@ -20,15 +22,27 @@ type Chain struct {
 	ChainID string
 	Root    *core.ExtendedBlock `rlp:"nil"`
 
+	freezer               *freezer.Freezer // nil if all the blocks are kept in the store
+	freezerRetainedBlocks uint64
+
+	blockCache  *lru.Cache // recently accessed blocks by hash
+	headerCache *lru.Cache // recently accessed block headers by hash
+
+	addressIndexEnabled bool // whether the finalized transactions are indexed by address
+
 	mu *sync.RWMutex
 }
 
 // NewChain creates a new Chain instance.
 func NewChain(chainID string, store store.Store, root *core.Block) *Chain {
 	chain := &Chain{
-		ChainID: chainID,
-		store:   store,
-		mu:      &sync.RWMutex{},
+		ChainID:     chainID,
+		store:       store,
+		blockCache:  lru.New("chain/cache/block", common.GetConfig().Storage.BlockCacheSize),
+		headerCache: lru.New("chain/cache/header", common.GetConfig().Storage.HeaderCacheSize),
+		mu:          &sync.RWMutex{},
+
+		addressIndexEnabled: common.GetConfig().Storage.AddressIndexEnabled,
 	}
 	rootBlock, err := chain.FindBlock(root.Hash())
 	if err != nil {
@@ -43,7 +57,9 @@ func NewChain(chainID string, store store.Store, root *core.Block) *Chain {
 	return chain
 }
 
-// AddBlock adds a block to the chain and underlying store.
+// AddBlock adds a block to the chain and underlying store. The block, the update
+// of its parent and the indexes are written atomically, so that a crash cannot
+// leave them referencing each other partially.
 func (ch *Chain) AddBlock(block *core.Block) (*core.ExtendedBlock, error) {
 	ch.mu.Lock()
 	defer ch.mu.Unlock()
@@ -52,14 +68,14 @@ func (ch *Chain) AddBlock(block *core.Block) (*core.ExtendedBlock, error) {
 		return nil, errors.Errorf("ChainID mismatch: block.ChainID(%s) != %s", block.ChainID, ch.ChainID)
 	}
 
-	val := &core.ExtendedBlock{}
 	hash := block.Hash()
-	err := ch.store.Get(hash[:], val)
+	val, err := ch.findBlock(hash)
 	if err == nil {
 		// Block has already been added.
 		return val, fmt.Errorf("Block has already been added: %X", hash[:])
 	}
 
+	batch := ch.store.NewBatch()
 	if !block.Parent.IsEmpty() {
 		parentBlock, err := ch.findBlock(block.Parent)
 		if err == store.ErrKeyNotFound {
@@ -72,7 +88,7 @@ func (ch *Chain) AddBlock(block *core.Block) (*core.ExtendedBlock, error) {
 
 		parentBlock.Children = append(parentBlock.Children, hash)
 
-		err = ch.saveBlock(parentBlock)
+		err = ch.saveBlock(batch, parentBlock)
 		if err != nil {
 			log.Panic(err)
 		}
@@ -80,24 +96,45 @@ func (ch *Chain) AddBlock(block *core.Block) (*core.ExtendedBlock, error) {
 
 	extendedBlock := &core.ExtendedBlock{Block: block}
 
-	err = ch.saveBlock(extendedBlock)
+	err = ch.saveBlock(batch, extendedBlock)
 	if err != nil {
 		log.Panic(err)
 	}
 
-	ch.AddBlockByHeightIndex(extendedBlock.Height, extendedBlock.Hash())
-	ch.AddTxsToIndex(extendedBlock, false)
+	ch.addBlockByHeightIndex(batch, extendedBlock.Height, extendedBlock.Hash())
+	ch.addTxsToIndex(batch, extendedBlock, false)
+
+	err = batch.Write()
+	if err != nil {
+		log.Panic(err)
+	}
 
 	return extendedBlock, nil
 }
 
+// Prefixes of the DB keys of the chain indexes
+var (
+	blockByHeightIndexPrefix = common.Bytes("bh/")
+	txIndexPrefix            = common.Bytes("tx/")
+	voteIndexPrefix          = common.Bytes("vt/")
+	frozenBlockIndexPrefix   = common.Bytes("fz/")
+	attestationIndexPrefix   = common.Bytes("at/")
+	addressIndexPrefix       = common.Bytes("ad/")
+)
+
+// IndexKeyPrefixes returns the prefixes of the DB keys of the chain indexes. The
+// blocks themselves are keyed by their hashes.
+func IndexKeyPrefixes() []common.Bytes {
+	return []common.Bytes{blockByHeightIndexPrefix, txIndexPrefix, voteIndexPrefix, frozenBlockIndexPrefix, attestationIndexPrefix, addressIndexPrefix}
+}
+
 // blockByHeightIndexKey constructs the DB key for the given block height.
 func blockByHeightIndexKey(height uint64) common.Bytes {
 	// convert uint64 to []byte
 	buf := make([]byte, binary.MaxVarintLen64)
 	n := binary.PutUvarint(buf, height)
 	b := buf[:n]
-	return append(common.Bytes("bh/"), b...)
+	return append(common.CopyBytes(blockByHeightIndexPrefix), b...)
 }
 
 type BlockByHeightIndexEntry struct {
@@ -105,6 +142,15 @@ type BlockByHeightIndexEntry struct {
 }
 
 func (ch *Chain) AddBlockByHeightIndex(height uint64, block common.Hash) {
+	batch := ch.store.NewBatch()
+	ch.addBlockByHeightIndex(batch, height, block)
+	err := batch.Write()
+	if err != nil {
+		log.Panic(err)
+	}
+}
+
+func (ch *Chain) addBlockByHeightIndex(batch store.Batch, height uint64, block common.Hash) {
 	key := blockByHeightIndexKey(height)
 	blockByHeightIndexEntry := BlockByHeightIndexEntry{
 		Blocks: []common.Hash{},
@@ -121,7 +167,7 @@ func (ch *Chain) AddBlockByHeightIndex(height uint64, block common.Hash) {
 
 	blockByHeightIndexEntry.Blocks = append(blockByHeightIndexEntry.Blocks, block)
 
-	err := ch.store.Put(key, blockByHeightIndexEntry)
+	err := batch.Put(key, blockByHeightIndexEntry)
 	if err != nil {
 		log.Panic(err)
 	}
@@ -162,28 +208,67 @@ func (ch *Chain) CommitBlock(hash common.Hash) {
 		log.Panic(err)
 	}
 	block.Status = core.BlockStatusCommitted
-	err = ch.saveBlock(block)
+	err = ch.saveBlock(ch.store, block)
 	if err != nil {
 		log.Panic(err)
 	}
 }
 
+// FinalizePreviousBlocks marks the block and its ancestors as finalized, in one
+// atomic write.
 func (ch *Chain) FinalizePreviousBlocks(hash common.Hash) {
 	ch.mu.Lock()
 	defer ch.mu.Unlock()
 
+	batch := ch.store.NewBatch()
+	ch.finalizePreviousBlocks(batch, hash)
+	err := batch.Write()
+	if err != nil {
+		log.Panic(err)
+	}
+}
+
+// FinalizeBlock marks the block and its ancestors as finalized, and points the TX
+// index to the transactions of the block, so that the index doesn't point to
+// duplicate TX in fork. The updates are written atomically.
+func (ch *Chain) FinalizeBlock(block *core.ExtendedBlock) {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+
+	batch := ch.store.NewBatch()
+	ch.finalizePreviousBlocks(batch, block.Hash())
+	ch.addTxsToIndex(batch, block, true)
+	err := batch.Write()
+	if err != nil {
+		log.Panic(err)
+	}
+
+	if ch.freezer != nil && ch.freezerRetainedBlocks > 0 && block.Height >= ch.freezerRetainedBlocks {
+		ch.freeze(block.Height - ch.freezerRetainedBlocks)
+	}
+}
+
+func (ch *Chain) finalizePreviousBlocks(batch store.Batch, hash common.Hash) {
+	finalized := []*core.ExtendedBlock{}
 	for !hash.IsEmpty() {
 		block, err := ch.findBlock(hash)
 		if err != nil || block.Status == core.BlockStatusFinalized {
-			return
+			break
 		}
 		block.Status = core.BlockStatusFinalized
-		err = ch.saveBlock(block)
+		err = ch.saveBlock(batch, block)
 		if err != nil {
 			log.Panic(err)
 		}
+		finalized = append(finalized, block)
 		hash = block.Parent
 	}
+
+	// Each block is indexed by address once, when it is finalized, oldest first
+	for i, j := 0, len(finalized)-1; i < j; i, j = i+1, j-1 {
+		finalized[i], finalized[j] = finalized[j], finalized[i]
+	}
+	ch.addTxsToAddressIndex(batch, finalized)
 }
 
 // FindDeepestDescendant finds the deepest descendant of given block.
@@ -212,14 +297,23 @@ func (ch *Chain) findDeepestDescendant(hash common.Hash) (n *core.ExtendedBlock,
 }
 
 func (ch *Chain) IsOrphan(block *core.Block) bool {
-	_, err := ch.FindBlock(block.Parent)
+	_, err := ch.FindBlockHeader(block.Parent)
 	return err != nil
 }
 
-// saveBlock updates a previously stored block.
-func (ch *Chain) saveBlock(block *core.ExtendedBlock) error {
+// saveBlock updates a previously stored block, in the store or in a batch of writes
+// to the store. The block is evicted from the cache, so that it is reloaded once the
+// batch is written. A saved block must not be looked up again before the batch is
+// written.
+func (ch *Chain) saveBlock(putter putter, block *core.ExtendedBlock) error {
 	hash := block.Hash()
-	return ch.store.Put(hash[:], *block)
+	ch.blockCache.Remove(hash)
+	return putter.Put(hash[:], *block)
+}
+
+// putter is implemented by both store.Store and store.Batch.
+type putter interface {
+	Put(key common.Bytes, value interface{}) error
 }
 
 // FindBlock tries to retrieve a block by hash.
@@ -231,8 +325,46 @@ func (ch *Chain) FindBlock(hash common.Hash) (*core.ExtendedBlock, error) {
 
 // findBlock is the non-locking version of FindBlock.
 func (ch *Chain) findBlock(hash common.Hash) (*core.ExtendedBlock, error) {
+	if cached, ok := ch.blockCache.Get(hash); ok {
+		return copyBlock(cached.(*core.ExtendedBlock)), nil
+	}
+	block, err := ch.loadBlock(hash)
+	if err != nil {
+		return nil, err
+	}
+	ch.blockCache.Add(hash, copyBlock(block))
+	ch.headerCache.Add(hash, block.BlockHeader)
+	return block, nil
+}
+
+// FindBlockHeader tries to retrieve the header of a block by hash. The header is
+// shared with the cache, and must not be modified.
+func (ch *Chain) FindBlockHeader(hash common.Hash) (*core.BlockHeader, error) {
+	if cached, ok := ch.headerCache.Get(hash); ok {
+		return cached.(*core.BlockHeader), nil
+	}
+	block, err := ch.FindBlock(hash)
+	if err != nil {
+		return nil, err
+	}
+	return block.BlockHeader, nil
+}
+
+// copyBlock copies the fields of a block that are updated as the chain grows, so
+// that the callers of findBlock can update the block without altering the cache.
+func copyBlock(block *core.ExtendedBlock) *core.ExtendedBlock {
+	copied := *block
+	copied.Children = append([]common.Hash(nil), block.Children...)
+	return &copied
+}
+
+// loadBlock reads a block from the store, or from the freezer, bypassing the cache.
+func (ch *Chain) loadBlock(hash common.Hash) (*core.ExtendedBlock, error) {
 	var block core.ExtendedBlock
 	err := ch.store.Get(hash[:], &block)
+	if err == store.ErrKeyNotFound && ch.freezer != nil {
+		return ch.findFrozenBlock(hash)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -259,8 +391,7 @@ func (ch *Chain) IsDescendant(ascendantHash common.Hash, descendantHash common.H
 func (ch *Chain) PrintBranch(hash common.Hash) string {
 	ret := []string{}
 	for {
-		var currBlock core.ExtendedBlock
-		err := ch.store.Get(hash[:], &currBlock)
+		currBlock, err := ch.FindBlock(hash)
 		if err != nil {
 			break
 		}
@@ -1,11 +1,17 @@
 package blockchain
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/thetatoken/ukulele/common"
 	"github.com/thetatoken/ukulele/core"
+	"github.com/thetatoken/ukulele/crypto"
+	"github.com/thetatoken/ukulele/store/database"
+	"github.com/thetatoken/ukulele/store/database/backend"
+	"github.com/thetatoken/ukulele/store/kvstore"
 )
 
 func TestBlockchain(t *testing.T) {
@@ -124,3 +130,97 @@ func TestBlockIndex(t *testing.T) {
 	assert.Equal(core.GetTestBlock("a2").Hash(), blocks[0].Hash())
 	assert.Equal(core.GetTestBlock("b2").Hash(), blocks[1].Hash())
 }
+
+// crashingDatabase fails the writes of its batches once crashed is set.
+type crashingDatabase struct {
+	*backend.MemDatabase
+	crashed bool
+}
+
+func (db *crashingDatabase) NewBatch() database.Batch {
+	return &crashingBatch{db.MemDatabase.NewBatch(), db}
+}
+
+type crashingBatch struct {
+	database.Batch
+	db *crashingDatabase
+}
+
+func (b *crashingBatch) Write() error {
+	if b.db.crashed {
+		return errors.New("crashed")
+	}
+	return b.Batch.Write()
+}
+
+func TestAddBlockAtomic(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	core.ResetTestBlocks()
+
+	db := &crashingDatabase{MemDatabase: backend.NewMemDatabase()}
+	root := core.CreateTestBlock("a0", "")
+	root.ChainID = "testchain"
+	chain := NewChain("testchain", kvstore.NewKVStore(db), root)
+
+	a1 := core.CreateTestBlock("a1", "a0")
+	a1.Height = 1
+	a1.Txs = []common.Bytes{common.Bytes("tx1")}
+	db.crashed = true
+	assert.Panics(func() { chain.AddBlock(a1) })
+
+	// None of the writes of the block import made it to the DB
+	_, err := chain.FindBlock(a1.Hash())
+	assert.NotNil(err)
+	parent, err := chain.FindBlock(root.Hash())
+	require.Nil(err)
+	assert.Equal(0, len(parent.Children))
+	assert.Equal(0, len(chain.FindBlocksByHeight(1)))
+	_, _, found := chain.FindTxByHash(crypto.Keccak256Hash(common.Bytes("tx1")))
+	assert.False(found)
+
+	db.crashed = false
+	_, err = chain.AddBlock(a1)
+	require.Nil(err)
+	parent, err = chain.FindBlock(root.Hash())
+	require.Nil(err)
+	assert.Equal(1, len(parent.Children))
+	assert.Equal(1, len(chain.FindBlocksByHeight(1)))
+	_, _, found = chain.FindTxByHash(crypto.Keccak256Hash(common.Bytes("tx1")))
+	assert.True(found)
+}
+
+func TestBlockCache(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	core.ResetTestBlocks()
+
+	chain := CreateTestChainByBlocks([]string{"a1", "a0", "a2", "a1"})
+	a1 := core.GetTestBlock("a1").Hash()
+
+	// Updating a found block does not alter the cached block
+	block, err := chain.FindBlock(a1)
+	require.Nil(err)
+	block.Children = append(block.Children, common.HexToHash("ff"))
+	block.Status = core.BlockStatusFinalized
+	block, err = chain.FindBlock(a1)
+	require.Nil(err)
+	assert.Equal(1, len(block.Children))
+	assert.Equal(core.BlockStatusPending, block.Status)
+
+	// The updates of the chain are visible through the cache
+	_, err = chain.AddBlock(core.CreateTestBlock("b2", "a1"))
+	require.Nil(err)
+	chain.CommitBlock(a1)
+	block, err = chain.FindBlock(a1)
+	require.Nil(err)
+	assert.Equal(2, len(block.Children))
+	assert.Equal(core.BlockStatusCommitted, block.Status)
+
+	header, err := chain.FindBlockHeader(a1)
+	require.Nil(err)
+	assert.Equal(a1, header.Hash())
+	assert.Equal(uint64(1), header.Height)
+	_, err = chain.FindBlockHeader(common.HexToHash("ff"))
+	assert.NotNil(err)
+}
@@ -0,0 +1,143 @@
+package blockchain
+
+import (
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/core"
+	"github.com/thetatoken/ukulele/rlp"
+	"github.com/thetatoken/ukulele/store"
+	"github.com/thetatoken/ukulele/store/freezer"
+)
+
+// maxFreezeBatch bounds the number of blocks moved to the freezer at once, so that
+// enabling the freezer on a long chain does not stall the finalization of a block.
+const maxFreezeBatch = 1000
+
+// freezerHeadKey is the DB key of the height of the next block to move to the
+// freezer.
+var freezerHeadKey = common.Bytes("fzhead")
+
+// frozenBlockIndexKey constructs the DB key of the height of a block in the freezer.
+func frozenBlockIndexKey(hash common.Hash) common.Bytes {
+	return append(common.CopyBytes(frozenBlockIndexPrefix), hash[:]...)
+}
+
+// SetFreezer sets the freezer of the chain. As blocks are finalized, the finalized
+// blocks more than retainedBlocks heights below them are moved out of the store, to
+// the freezer. With retainedBlocks 0, no block is moved. The blocks in the freezer
+// are still found by FindBlock and FindBlocksByHeight. The freezer holds the blocks
+// from the height after the root block, so it must not be shared by chains with
+// different roots.
+func (ch *Chain) SetFreezer(f *freezer.Freezer, retainedBlocks uint64) {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+
+	ch.freezer = f
+	ch.freezerRetainedBlocks = retainedBlocks
+}
+
+// freeze moves the finalized blocks up to the given height to the freezer. The
+// blocks are appended to the freezer and synced to disk, before they are deleted
+// from the store.
+func (ch *Chain) freeze(maxHeight uint64) {
+	start := ch.freezerHead()
+	batch := ch.store.NewBatch()
+	height := start
+	for ; height <= maxHeight && height-start < maxFreezeBatch; height++ {
+		var block *core.ExtendedBlock
+		for _, b := range ch.findBlocksByHeight(height) {
+			if b.Status == core.BlockStatusFinalized {
+				block = b
+				break
+			}
+		}
+		if block == nil {
+			break
+		}
+		hash := block.Hash()
+
+		number := height - ch.Root.Height - 1
+		items := ch.freezer.Items()
+		if number < items {
+			// Appended before the node stopped, but not deleted from the store yet
+			frozen, err := ch.retrieveFrozenBlock(number)
+			if err != nil || frozen.Hash() != hash {
+				log.Panicf("Freezer item %v is not block %v", number, hash.Hex())
+			}
+		} else if number == items {
+			raw, err := rlp.EncodeToBytes(*block)
+			if err != nil {
+				log.Panic(err)
+			}
+			if err := ch.freezer.Append(raw); err != nil {
+				log.Panic(err)
+			}
+		} else {
+			log.Panicf("Freezer has %v items, cannot append block %v at height %v", items, hash.Hex(), height)
+		}
+
+		if err := batch.Put(frozenBlockIndexKey(hash), height); err != nil {
+			log.Panic(err)
+		}
+		if err := batch.Delete(hash[:]); err != nil {
+			log.Panic(err)
+		}
+	}
+	if height == start {
+		return
+	}
+
+	if err := ch.freezer.Sync(); err != nil {
+		log.Panic(err)
+	}
+	if err := batch.Put(freezerHeadKey, height); err != nil {
+		log.Panic(err)
+	}
+	if err := batch.Write(); err != nil {
+		log.Panic(err)
+	}
+	log.WithFields(log.Fields{"from": start, "to": height - 1}).Debug("Moved blocks to the freezer")
+}
+
+// freezerHead returns the height of the next block to move to the freezer.
+func (ch *Chain) freezerHead() uint64 {
+	height := uint64(0)
+	err := ch.store.Get(freezerHeadKey, &height)
+	if err == store.ErrKeyNotFound {
+		return ch.Root.Height + 1
+	}
+	if err != nil {
+		log.Panic(err)
+	}
+	return height
+}
+
+// findFrozenBlock retrieves a block from the freezer by hash.
+func (ch *Chain) findFrozenBlock(hash common.Hash) (*core.ExtendedBlock, error) {
+	height := uint64(0)
+	if err := ch.store.Get(frozenBlockIndexKey(hash), &height); err != nil {
+		return nil, err
+	}
+	block, err := ch.retrieveFrozenBlock(height - ch.Root.Height - 1)
+	if err != nil {
+		return nil, err
+	}
+	if block.Hash() != hash {
+		return nil, errors.Errorf("Freezer has block %v instead of block %v at height %v", block.Hash().Hex(), hash.Hex(), height)
+	}
+	return block, nil
+}
+
+func (ch *Chain) retrieveFrozenBlock(number uint64) (*core.ExtendedBlock, error) {
+	raw, err := ch.freezer.Retrieve(number)
+	if err != nil {
+		return nil, err
+	}
+	block := &core.ExtendedBlock{}
+	if err := rlp.DecodeBytes(raw, block); err != nil {
+		return nil, err
+	}
+	return block, nil
+}
@@ -0,0 +1,103 @@
+package blockchain
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/core"
+	"github.com/thetatoken/ukulele/crypto"
+	"github.com/thetatoken/ukulele/store"
+	"github.com/thetatoken/ukulele/store/database/backend"
+	"github.com/thetatoken/ukulele/store/freezer"
+	"github.com/thetatoken/ukulele/store/kvstore"
+)
+
+func TestFreezer(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	core.ResetTestBlocks()
+
+	dir, err := ioutil.TempDir("", "freezer")
+	require.Nil(err)
+	defer os.RemoveAll(dir)
+	f, err := freezer.Open(dir)
+	require.Nil(err)
+
+	db := backend.NewMemDatabase()
+	root := core.CreateTestBlock("a0", "")
+	chain := NewChain("testchain", kvstore.NewKVStore(db), root)
+	chain.SetFreezer(f, 3)
+
+	// a1 <- a2 <- ... <- a8, and a fork b2 that is never finalized
+	for i := 1; i <= 8; i++ {
+		block := core.CreateTestBlock(fmt.Sprintf("a%d", i), fmt.Sprintf("a%d", i-1))
+		block.Txs = []common.Bytes{common.Bytes(fmt.Sprintf("tx%d", i))}
+		_, err := chain.AddBlock(block)
+		require.Nil(err)
+	}
+	_, err = chain.AddBlock(core.CreateTestBlock("b2", "a1"))
+	require.Nil(err)
+
+	for i := 1; i <= 8; i++ {
+		eb, err := chain.FindBlock(core.GetTestBlock(fmt.Sprintf("a%d", i)).Hash())
+		require.Nil(err)
+		chain.FinalizeBlock(eb)
+	}
+
+	// a1 to a5 are moved to the freezer, a6 to a8 stay in the store
+	assert.Equal(uint64(5), f.Items())
+	assert.Equal(uint64(6), chain.freezerHead())
+	for i := 1; i <= 8; i++ {
+		name := fmt.Sprintf("a%d", i)
+		hash := core.GetTestBlock(name).Hash()
+		err := chain.store.Get(hash[:], &core.ExtendedBlock{})
+		if i <= 5 {
+			assert.Equal(store.ErrKeyNotFound, err, name)
+		} else {
+			assert.Nil(err, name)
+		}
+
+		block, err := chain.FindBlock(hash)
+		require.Nil(err, name)
+		assert.Equal(hash, block.Hash())
+		assert.Equal(core.BlockStatusFinalized, block.Status)
+		blocks := chain.FindBlocksByHeight(uint64(i))
+		assert.True(len(blocks) > 0, name)
+
+		tx, txBlock, found := chain.FindTxByHash(crypto.Keccak256Hash(common.Bytes(fmt.Sprintf("tx%d", i))))
+		if assert.True(found, name) {
+			assert.Equal(common.Bytes(fmt.Sprintf("tx%d", i)), tx)
+			assert.Equal(hash, txBlock.Hash())
+		}
+	}
+
+	// The fork is not finalized, and stays in the store
+	b2 := core.GetTestBlock("b2").Hash()
+	assert.Nil(chain.store.Get(b2[:], &core.ExtendedBlock{}))
+	assert.Equal(2, len(chain.FindBlocksByHeight(2)))
+
+	// Frozen blocks are not added again
+	_, err = chain.AddBlock(core.GetTestBlock("a2"))
+	assert.NotNil(err)
+
+	// The frozen blocks are found after reopening the freezer
+	require.Nil(f.Close())
+	f, err = freezer.Open(dir)
+	require.Nil(err)
+	defer f.Close()
+	chain = NewChain("testchain", kvstore.NewKVStore(db), root)
+	chain.SetFreezer(f, 0)
+	block, err := chain.FindBlock(core.GetTestBlock("a3").Hash())
+	require.Nil(err)
+	assert.Equal(uint64(3), block.Height)
+
+	// Without the freezer, the frozen blocks are not found
+	chain = NewChain("testchain", kvstore.NewKVStore(db), root)
+	_, err = chain.FindBlock(core.GetTestBlock("a3").Hash())
+	assert.NotNil(err)
+}
@@ -11,7 +11,7 @@ import (
 
 // txIndexKey constructs the DB key for the given transaction hash.
 func txIndexKey(hash common.Hash) common.Bytes {
-	return append(common.Bytes("tx/"), hash[:]...)
+	return append(common.CopyBytes(txIndexPrefix), hash[:]...)
 }
 
 // TxIndexEntry is a positional metadata to help looking up a transaction given only its hash.
@@ -23,6 +23,17 @@ type TxIndexEntry struct {
 
 // AddTxsToIndex adds transactions in given block to index.
 func (ch *Chain) AddTxsToIndex(block *core.ExtendedBlock, force bool) {
+	batch := ch.store.NewBatch()
+	ch.addTxsToIndex(batch, block, force)
+	err := batch.Write()
+	if err != nil {
+		log.Panic(err)
+	}
+}
+
+func (ch *Chain) addTxsToIndex(batch store.Batch, block *core.ExtendedBlock, force bool) {
+	// The batch is not visible in the DB yet, so keep track of the TXs indexed by it
+	indexed := make(map[common.Hash]bool)
 	for idx, tx := range block.Txs {
 		txIndexEntry := TxIndexEntry{
 			BlockHash:   block.Hash(),
@@ -35,12 +46,13 @@ func (ch *Chain) AddTxsToIndex(block *core.ExtendedBlock, force bool) {
 		if !force {
 			// Check if TX with given hash exists in DB.
 			err := ch.store.Get(key, &TxIndexEntry{})
-			if err != store.ErrKeyNotFound {
+			if err != store.ErrKeyNotFound || indexed[txHash] {
 				continue
 			}
 		}
+		indexed[txHash] = true
 
-		err := ch.store.Put(key, txIndexEntry)
+		err := batch.Put(key, txIndexEntry)
 		if err != nil {
 			log.Panic(err)
 		}
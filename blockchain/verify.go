@@ -0,0 +1,450 @@
+package blockchain
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sort"
+
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/core"
+	"github.com/thetatoken/ukulele/crypto"
+	"github.com/thetatoken/ukulele/rlp"
+	"github.com/thetatoken/ukulele/store"
+	"github.com/thetatoken/ukulele/store/database"
+)
+
+// Kinds of inconsistencies found by Chain.Verify
+const (
+	// InconsistencyMissingBlock is a child of a block that is not in the DB
+	InconsistencyMissingBlock = "missing_block"
+	// InconsistencyHashMismatch is a block stored under a key other than its hash
+	InconsistencyHashMismatch = "hash_mismatch"
+	// InconsistencyBrokenLink is a block whose parent or height does not match its parent block
+	InconsistencyBrokenLink = "broken_link"
+	// InconsistencyFinalization is a finalized block whose parent is not finalized
+	InconsistencyFinalization = "finalization"
+	// InconsistencyMissingState is a state root of a finalized block that is not in the DB
+	InconsistencyMissingState = "missing_state"
+	// InconsistencyHeightIndex is a block missing from the height index, or an entry of the
+	// height index that is not a block of the chain
+	InconsistencyHeightIndex = "height_index"
+	// InconsistencyTxIndex is a transaction missing from the TX index, or an entry of the
+	// TX index that does not point to the transaction
+	InconsistencyTxIndex = "tx_index"
+)
+
+// Inconsistency is a problem found in the chain stored in the DB.
+type Inconsistency struct {
+	Kind   string
+	Block  common.Hash
+	Height uint64
+	Detail string
+}
+
+// Repairable returns whether Chain.RebuildIndexes fixes the inconsistency.
+func (inc Inconsistency) Repairable() bool {
+	return inc.Kind == InconsistencyHeightIndex || inc.Kind == InconsistencyTxIndex
+}
+
+func (inc Inconsistency) String() string {
+	return fmt.Sprintf("%v: block %v at height %v: %v", inc.Kind, inc.Block.Hex(), inc.Height, inc.Detail)
+}
+
+// VerificationReport is the result of Chain.Verify.
+type VerificationReport struct {
+	Blocks          int                 // Number of blocks reachable from the root
+	LatestFinalized *core.ExtendedBlock // The finalized block of the greatest height
+	Inconsistencies []Inconsistency
+}
+
+// Repairable returns whether Chain.RebuildIndexes fixes all the inconsistencies.
+func (r *VerificationReport) Repairable() bool {
+	for _, inc := range r.Inconsistencies {
+		if !inc.Repairable() {
+			return false
+		}
+	}
+	return true
+}
+
+// Verify walks the blocks reachable from the root of the chain, and checks the
+// hashes and the links of the blocks, their state roots, and the height and TX
+// indexes. The indexes are also scanned through db, to find the entries that point
+// to blocks or transactions not in the chain. hasState checks whether a state root
+// is in the DB. Since old state roots are pruned, only the state root of the latest
+// finalized block is checked.
+func (ch *Chain) Verify(db database.Iteratee, hasState func(root common.Hash) bool) (*VerificationReport, error) {
+	ch.mu.RLock()
+	defer ch.mu.RUnlock()
+
+	report := &VerificationReport{}
+	blocks := ch.walkBlocks(func(inc Inconsistency) {
+		report.Inconsistencies = append(report.Inconsistencies, inc)
+	})
+	report.Blocks = len(blocks)
+	report.LatestFinalized = blocks[ch.Root.Hash()]
+
+	heights := make(map[uint64][]common.Hash)
+	for _, block := range blocks {
+		hash := block.Hash()
+		heights[block.Height] = append(heights[block.Height], hash)
+		if block.Status == core.BlockStatusFinalized && block.Height > report.LatestFinalized.Height {
+			report.LatestFinalized = block
+		}
+	}
+	addInconsistency := func(block *core.ExtendedBlock, kind string, format string, args ...interface{}) {
+		report.Inconsistencies = append(report.Inconsistencies, Inconsistency{
+			Kind:   kind,
+			Block:  block.Hash(),
+			Height: block.Height,
+			Detail: fmt.Sprintf(format, args...),
+		})
+	}
+
+	// State root of the latest finalized block
+	if hasState != nil && !hasState(report.LatestFinalized.StateHash) {
+		addInconsistency(report.LatestFinalized, InconsistencyMissingState, "state root %v is not in the DB", report.LatestFinalized.StateHash.Hex())
+	}
+
+	// Height index, from the blocks
+	for _, block := range blocks {
+		entry := BlockByHeightIndexEntry{}
+		ch.store.Get(blockByHeightIndexKey(block.Height), &entry)
+		if !containsHash(entry.Blocks, block.Hash()) {
+			addInconsistency(block, InconsistencyHeightIndex, "block is not in the height index")
+		}
+	}
+
+	// Height index, from the index entries
+	it := db.NewIteratorWithPrefix(blockByHeightIndexPrefix)
+	for it.Next() {
+		height, ok := decodeBlockByHeightIndexKey(it.Key())
+		if !ok {
+			continue
+		}
+		entry := BlockByHeightIndexEntry{}
+		if err := rlp.DecodeBytes(it.Value(), &entry); err != nil {
+			report.Inconsistencies = append(report.Inconsistencies, Inconsistency{
+				Kind:   InconsistencyHeightIndex,
+				Height: height,
+				Detail: fmt.Sprintf("failed to decode the height index entry: %v", err),
+			})
+			continue
+		}
+		for _, hash := range entry.Blocks {
+			if !containsHash(heights[height], hash) {
+				report.Inconsistencies = append(report.Inconsistencies, Inconsistency{
+					Kind:   InconsistencyHeightIndex,
+					Block:  hash,
+					Height: height,
+					Detail: "the height index lists a block that is not in the chain",
+				})
+			}
+		}
+	}
+	err := it.Error()
+	it.Release()
+	if err != nil {
+		return nil, err
+	}
+
+	// TX index, from the blocks
+	for _, block := range blocks {
+		for idx, tx := range block.Txs {
+			txHash := crypto.Keccak256Hash(tx)
+			entry := TxIndexEntry{}
+			if err := ch.store.Get(txIndexKey(txHash), &entry); err != nil {
+				addInconsistency(block, InconsistencyTxIndex, "TX %v at index %v is not in the TX index", txHash.Hex(), idx)
+				continue
+			}
+			indexed, ok := blocks[entry.BlockHash]
+			if !ok || !containsTx(indexed, entry.Index, txHash) {
+				// Checked below, from the index entries
+				continue
+			}
+			if block.Status == core.BlockStatusFinalized && indexed.Status != core.BlockStatusFinalized {
+				addInconsistency(block, InconsistencyTxIndex, "TX %v at index %v is indexed in block %v, which is not finalized", txHash.Hex(), idx, entry.BlockHash.Hex())
+			}
+		}
+	}
+
+	// TX index, from the index entries
+	it = db.NewIteratorWithPrefix(txIndexPrefix)
+	for it.Next() {
+		txHash, ok := decodeTxIndexKey(it.Key())
+		if !ok {
+			continue
+		}
+		entry := TxIndexEntry{}
+		if err := rlp.DecodeBytes(it.Value(), &entry); err != nil {
+			report.Inconsistencies = append(report.Inconsistencies, Inconsistency{
+				Kind:   InconsistencyTxIndex,
+				Detail: fmt.Sprintf("failed to decode the TX index entry of TX %v: %v", txHash.Hex(), err),
+			})
+			continue
+		}
+		block, ok := blocks[entry.BlockHash]
+		if !ok {
+			report.Inconsistencies = append(report.Inconsistencies, Inconsistency{
+				Kind:   InconsistencyTxIndex,
+				Block:  entry.BlockHash,
+				Height: entry.BlockHeight,
+				Detail: fmt.Sprintf("TX %v is indexed in a block that is not in the chain", txHash.Hex()),
+			})
+			continue
+		}
+		if !containsTx(block, entry.Index, txHash) {
+			addInconsistency(block, InconsistencyTxIndex, "TX %v is indexed at index %v, which holds another TX", txHash.Hex(), entry.Index)
+		}
+	}
+	err = it.Error()
+	it.Release()
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// RebuildIndexes rewrites the height and TX indexes from the blocks reachable from
+// the root of the chain, and deletes the other entries of the indexes found through
+// db. The vote index cannot be rebuilt from the blocks, so it is left alone. It
+// returns the number of blocks indexed.
+func (ch *Chain) RebuildIndexes(db database.Iteratee) (int, error) {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+
+	blocks := ch.walkBlocks(nil)
+
+	// Index the blocks by ascending height, so that among the blocks not finalized,
+	// the TX index points to the lowest one, like when the blocks are added.
+	sorted := make([]*core.ExtendedBlock, 0, len(blocks))
+	for _, block := range blocks {
+		sorted = append(sorted, block)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Height != sorted[j].Height {
+			return sorted[i].Height < sorted[j].Height
+		}
+		hi, hj := sorted[i].Hash(), sorted[j].Hash()
+		return hi.Hex() < hj.Hex()
+	})
+
+	heights := make(map[uint64]*BlockByHeightIndexEntry)
+	txs := make(map[common.Hash]TxIndexEntry)
+	finalizedTxs := make(map[common.Hash]bool)
+	for _, block := range sorted {
+		hash := block.Hash()
+		entry, ok := heights[block.Height]
+		if !ok {
+			entry = &BlockByHeightIndexEntry{Blocks: []common.Hash{}}
+			heights[block.Height] = entry
+		}
+		entry.Blocks = append(entry.Blocks, hash)
+
+		finalized := block.Status == core.BlockStatusFinalized
+		for idx, tx := range block.Txs {
+			txHash := crypto.Keccak256Hash(tx)
+			if _, ok := txs[txHash]; ok && (finalizedTxs[txHash] || !finalized) {
+				continue
+			}
+			txs[txHash] = TxIndexEntry{
+				BlockHash:   hash,
+				BlockHeight: block.Height,
+				Index:       uint64(idx),
+			}
+			finalizedTxs[txHash] = finalized
+		}
+	}
+
+	batch := ch.store.NewBatch()
+	rebuilt := make(map[string]bool)
+	for height, entry := range heights {
+		key := blockByHeightIndexKey(height)
+		rebuilt[string(key)] = true
+		if err := batch.Put(key, *entry); err != nil {
+			return 0, err
+		}
+	}
+	for txHash, entry := range txs {
+		key := txIndexKey(txHash)
+		rebuilt[string(key)] = true
+		if err := batch.Put(key, entry); err != nil {
+			return 0, err
+		}
+	}
+	for _, prefix := range []common.Bytes{blockByHeightIndexPrefix, txIndexPrefix} {
+		it := db.NewIteratorWithPrefix(prefix)
+		for it.Next() {
+			if rebuilt[string(it.Key())] || !isIndexKey(it.Key()) {
+				continue
+			}
+			if err := batch.Delete(common.CopyBytes(it.Key())); err != nil {
+				it.Release()
+				return 0, err
+			}
+		}
+		err := it.Error()
+		it.Release()
+		if err != nil {
+			return 0, err
+		}
+	}
+	if err := batch.Write(); err != nil {
+		return 0, err
+	}
+	return len(blocks), nil
+}
+
+// RemoveMissingChildren removes the children that are not in the DB from the given
+// block and its descendants. Such children are left by blocks imported partially,
+// before the blocks and the updates of their parents were written atomically. It
+// returns the children removed.
+func (ch *Chain) RemoveMissingChildren(from common.Hash) ([]Inconsistency, error) {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+
+	start, err := ch.loadBlock(from)
+	if err != nil {
+		return nil, err
+	}
+	removed := []Inconsistency{}
+	batch := ch.store.NewBatch()
+	for hash, block := range ch.walkBlocksFrom(start, nil) {
+		children := []common.Hash{}
+		for _, child := range block.Children {
+			if _, err := ch.loadBlock(child); err == store.ErrKeyNotFound {
+				removed = append(removed, Inconsistency{
+					Kind:   InconsistencyMissingBlock,
+					Block:  hash,
+					Height: block.Height,
+					Detail: fmt.Sprintf("removed child %v, which is not in the DB", child.Hex()),
+				})
+				continue
+			}
+			children = append(children, child)
+		}
+		if len(children) == len(block.Children) {
+			continue
+		}
+		block.Children = children
+		if err := ch.saveBlock(batch, block); err != nil {
+			return nil, err
+		}
+	}
+	if len(removed) == 0 {
+		return removed, nil
+	}
+	if err := batch.Write(); err != nil {
+		return nil, err
+	}
+	return removed, nil
+}
+
+// walkBlocks returns the blocks reachable from the root of the chain by their
+// hashes. It reports the blocks that are missing, are stored under another hash,
+// or do not link to their parent, to the given callback if not nil.
+func (ch *Chain) walkBlocks(report func(inc Inconsistency)) map[common.Hash]*core.ExtendedBlock {
+	// Reload the root, since its children and status may have changed since the
+	// chain was created
+	root, err := ch.loadBlock(ch.Root.Hash())
+	if err != nil {
+		root = ch.Root
+	}
+	return ch.walkBlocksFrom(root, report)
+}
+
+// walkBlocksFrom is walkBlocks from the given block instead of the root.
+func (ch *Chain) walkBlocksFrom(start *core.ExtendedBlock, report func(inc Inconsistency)) map[common.Hash]*core.ExtendedBlock {
+	if report == nil {
+		report = func(inc Inconsistency) {}
+	}
+	blocks := make(map[common.Hash]*core.ExtendedBlock)
+	blocks[start.Hash()] = start
+	stack := []*core.ExtendedBlock{start}
+	for len(stack) > 0 {
+		parent := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		parentHash := parent.Hash()
+
+		for _, hash := range parent.Children {
+			if _, ok := blocks[hash]; ok {
+				continue
+			}
+			block, err := ch.loadBlock(hash)
+			if err != nil {
+				detail := fmt.Sprintf("child %v is not in the DB", hash.Hex())
+				if err != store.ErrKeyNotFound {
+					detail = fmt.Sprintf("failed to load child %v: %v", hash.Hex(), err)
+				}
+				report(Inconsistency{Kind: InconsistencyMissingBlock, Block: parentHash, Height: parent.Height, Detail: detail})
+				continue
+			}
+			if block.Block == nil || block.Hash() != hash {
+				report(Inconsistency{Kind: InconsistencyHashMismatch, Block: hash, Height: parent.Height + 1,
+					Detail: fmt.Sprintf("the block stored under the hash has hash %v", block.Hash().Hex())})
+				continue
+			}
+			if block.Parent != parentHash || block.Height != parent.Height+1 {
+				report(Inconsistency{Kind: InconsistencyBrokenLink, Block: hash, Height: block.Height,
+					Detail: fmt.Sprintf("block is a child of %v at height %v, but has parent %v",
+						parentHash.Hex(), parent.Height, block.Parent.Hex())})
+			}
+			if block.Status == core.BlockStatusFinalized && parent.Status != core.BlockStatusFinalized {
+				report(Inconsistency{Kind: InconsistencyFinalization, Block: hash, Height: block.Height,
+					Detail: fmt.Sprintf("block is finalized, but its parent %v is not", parentHash.Hex())})
+			}
+			blocks[hash] = block
+			stack = append(stack, block)
+		}
+	}
+	return blocks
+}
+
+// decodeBlockByHeightIndexKey returns the height of a key of the height index.
+func decodeBlockByHeightIndexKey(key common.Bytes) (uint64, bool) {
+	if !bytes.HasPrefix(key, blockByHeightIndexPrefix) {
+		return 0, false
+	}
+	encoded := key[len(blockByHeightIndexPrefix):]
+	height, n := binary.Uvarint(encoded)
+	if n <= 0 || n != len(encoded) {
+		return 0, false
+	}
+	return height, true
+}
+
+// decodeTxIndexKey returns the TX hash of a key of the TX index.
+func decodeTxIndexKey(key common.Bytes) (common.Hash, bool) {
+	if !bytes.HasPrefix(key, txIndexPrefix) || len(key) != len(txIndexPrefix)+common.HashLength {
+		return common.Hash{}, false
+	}
+	return common.BytesToHash(key[len(txIndexPrefix):]), true
+}
+
+// isIndexKey returns whether the key is a key of the height or TX index. The blocks
+// and the trie nodes are keyed by their hashes, which may start with the prefix of
+// an index, but are longer than the height index keys and shorter than the TX index
+// keys.
+func isIndexKey(key common.Bytes) bool {
+	if _, ok := decodeBlockByHeightIndexKey(key); ok {
+		return true
+	}
+	_, ok := decodeTxIndexKey(key)
+	return ok
+}
+
+func containsHash(hashes []common.Hash, hash common.Hash) bool {
+	for _, h := range hashes {
+		if h == hash {
+			return true
+		}
+	}
+	return false
+}
+
+// containsTx returns whether the block has the TX of the given hash at the index.
+func containsTx(block *core.ExtendedBlock, index uint64, txHash common.Hash) bool {
+	return index < uint64(len(block.Txs)) && crypto.Keccak256Hash(block.Txs[index]) == txHash
+}
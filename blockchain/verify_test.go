@@ -0,0 +1,92 @@
+package blockchain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/core"
+	"github.com/thetatoken/ukulele/crypto"
+	"github.com/thetatoken/ukulele/store/database/backend"
+	"github.com/thetatoken/ukulele/store/kvstore"
+)
+
+func inconsistencyKinds(report *VerificationReport) map[string]int {
+	kinds := make(map[string]int)
+	for _, inc := range report.Inconsistencies {
+		kinds[inc.Kind]++
+	}
+	return kinds
+}
+
+func TestVerifyAndRebuildIndexes(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	core.ResetTestBlocks()
+
+	db := backend.NewMemDatabase()
+	root := core.CreateTestBlock("a0", "")
+	chain := NewChain("testchain", kvstore.NewKVStore(db), root)
+
+	a1 := core.CreateTestBlock("a1", "a0")
+	a1.Txs = []common.Bytes{common.Bytes("tx1"), common.Bytes("tx2")}
+	a2 := core.CreateTestBlock("a2", "a1")
+	a2.Txs = []common.Bytes{common.Bytes("tx3")}
+	b2 := core.CreateTestBlock("b2", "a1")
+	b2.Txs = []common.Bytes{common.Bytes("tx3"), common.Bytes("tx4")}
+	for _, block := range []*core.Block{a1, a2, b2} {
+		_, err := chain.AddBlock(block)
+		require.Nil(err)
+	}
+	eb, err := chain.FindBlock(a1.Hash())
+	require.Nil(err)
+	chain.FinalizeBlock(eb)
+
+	states := map[common.Hash]bool{a1.StateHash: true}
+	hasState := func(root common.Hash) bool { return states[root] }
+
+	report, err := chain.Verify(db, hasState)
+	require.Nil(err)
+	assert.Equal(4, report.Blocks)
+	assert.Equal(a1.Hash(), report.LatestFinalized.Hash())
+	assert.Empty(report.Inconsistencies)
+
+	// Corrupt the indexes
+	require.Nil(chain.store.Delete(blockByHeightIndexKey(a2.Height)))
+	require.Nil(chain.store.Delete(txIndexKey(crypto.Keccak256Hash(common.Bytes("tx4")))))
+	require.Nil(chain.store.Put(blockByHeightIndexKey(99), BlockByHeightIndexEntry{Blocks: []common.Hash{common.HexToHash("ff")}}))
+	staleTx := crypto.Keccak256Hash(common.Bytes("tx5"))
+	require.Nil(chain.store.Put(txIndexKey(staleTx), TxIndexEntry{BlockHash: common.HexToHash("ff"), BlockHeight: 99}))
+
+	report, err = chain.Verify(db, hasState)
+	require.Nil(err)
+	kinds := inconsistencyKinds(report)
+	assert.Equal(3, kinds[InconsistencyHeightIndex])
+	assert.Equal(2, kinds[InconsistencyTxIndex])
+	assert.True(report.Repairable())
+
+	indexed, err := chain.RebuildIndexes(db)
+	require.Nil(err)
+	assert.Equal(4, indexed)
+
+	report, err = chain.Verify(db, hasState)
+	require.Nil(err)
+	assert.Empty(report.Inconsistencies)
+	_, _, found := chain.FindTxByHash(staleTx)
+	assert.False(found)
+	_, block, found := chain.FindTxByHash(crypto.Keccak256Hash(common.Bytes("tx4")))
+	assert.True(found)
+	assert.Equal(b2.Hash(), block.Hash())
+
+	// Missing blocks and states cannot be repaired
+	require.Nil(chain.store.Delete(b2.Hash().Bytes()))
+	delete(states, a1.StateHash)
+
+	report, err = chain.Verify(db, hasState)
+	require.Nil(err)
+	kinds = inconsistencyKinds(report)
+	assert.Equal(1, kinds[InconsistencyMissingBlock])
+	assert.Equal(1, kinds[InconsistencyMissingState])
+	assert.False(report.Repairable())
+}
@@ -8,7 +8,7 @@ import (
 
 // voteIndexKey constructs the DB key for the given block hash.
 func voteIndexKey(hash common.Hash) common.Bytes {
-	return append(common.Bytes("vt/"), hash[:]...)
+	return append(common.CopyBytes(voteIndexPrefix), hash[:]...)
 }
 
 // AddVoteToIndex adds a vote to index.
@@ -0,0 +1,203 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultRetries is the default number of rounds over the node URLs before a call fails.
+	DefaultRetries = 3
+
+	// DefaultRetryInterval is the default wait between two rounds over the node URLs.
+	DefaultRetryInterval = time.Second
+
+	// DefaultTimeout is the default timeout of a request to a node.
+	DefaultTimeout = 30 * time.Second
+
+	adminNamespacePrefix = "admin."
+)
+
+// RPCError is an error returned by an RPC method of the node. The calls failing with
+// an RPCError are not retried, since the node did serve them.
+type RPCError struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+func (e *RPCError) Error() string {
+	if len(e.Data) == 0 {
+		return fmt.Sprintf("RPC error %v: %v", e.Code, e.Message)
+	}
+	return fmt.Sprintf("RPC error %v: %v (%s)", e.Code, e.Message, e.Data)
+}
+
+type rpcRequest struct {
+	JSONRPC string      `json:"jsonrpc"`
+	ID      uint64      `json:"id"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params"`
+}
+
+type rpcResponse struct {
+	Result json.RawMessage `json:"result"`
+	Error  *RPCError       `json:"error"`
+}
+
+// Client calls the RPC methods of Theta nodes. The nodes are given by the URLs of
+// their RPC endpoints, e.g. http://localhost:16888/rpc, and serve the same chain.
+// A call that cannot reach a node, or that a node fails to answer, fails over to the
+// next URL, and the calls stick to the last node that answered. A call fails once
+// all the URLs failed Retries times in a row.
+type Client struct {
+	Retries       int           // Rounds over the URLs before a call fails
+	RetryInterval time.Duration // Wait between two rounds
+	AdminToken    string        // Bearer token of the admin methods, if the nodes require one
+
+	urls       []string
+	httpClient *http.Client
+	nextID     uint64
+
+	mu      *sync.Mutex
+	current int    // Index of the URL of the node that answered last
+	chainID string // Cached by ChainID
+}
+
+// NewClient creates a Client calling the nodes at the given RPC endpoints.
+func NewClient(urls ...string) (*Client, error) {
+	if len(urls) == 0 {
+		return nil, errors.New("At least one node URL must be specified")
+	}
+	for _, u := range urls {
+		parsed, err := url.Parse(u)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Invalid node URL %v", u)
+		}
+		if parsed.Scheme != "http" && parsed.Scheme != "https" {
+			return nil, errors.Errorf("Invalid node URL %v: the scheme must be http or https", u)
+		}
+	}
+	return &Client{
+		Retries:       DefaultRetries,
+		RetryInterval: DefaultRetryInterval,
+		urls:          urls,
+		httpClient:    &http.Client{Timeout: DefaultTimeout},
+		mu:            &sync.Mutex{},
+	}, nil
+}
+
+// URLs returns the URLs of the nodes, starting with the node that answered last.
+func (c *Client) URLs() []string {
+	c.mu.Lock()
+	start := c.current
+	c.mu.Unlock()
+
+	urls := make([]string, 0, len(c.urls))
+	for i := range c.urls {
+		urls = append(urls, c.urls[(start+i)%len(c.urls)])
+	}
+	return urls
+}
+
+// Call calls the RPC method, e.g. "theta.GetAccount", with the arguments and decodes its
+// result into result, unless nil. The typed methods of the Client should be preferred.
+func (c *Client) Call(ctx context.Context, method string, args interface{}, result interface{}) error {
+	body, err := json.Marshal(rpcRequest{
+		JSONRPC: "2.0",
+		ID:      atomic.AddUint64(&c.nextID, 1),
+		Method:  method,
+		Params:  args,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "Failed to encode the arguments of %v", method)
+	}
+
+	rounds := c.Retries
+	if rounds < 1 {
+		rounds = 1
+	}
+	var lastErr error
+	for round := 0; round < rounds; round++ {
+		if round > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(c.RetryInterval):
+			}
+		}
+		for _, u := range c.URLs() {
+			res, err := c.post(ctx, u, method, body)
+			if err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				lastErr = err
+				continue
+			}
+			c.setCurrent(u)
+			if res.Error != nil {
+				return res.Error
+			}
+			if result == nil {
+				return nil
+			}
+			if err := json.Unmarshal(res.Result, result); err != nil {
+				return errors.Wrapf(err, "Failed to decode the result of %v", method)
+			}
+			return nil
+		}
+	}
+	return errors.Wrapf(lastErr, "Failed to call %v on any node", method)
+}
+
+// post sends the request to the node, and returns an error if the node did not answer it.
+func (c *Client) post(ctx context.Context, u string, method string, body []byte) (*rpcResponse, error) {
+	req, err := http.NewRequest("POST", u, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	if c.AdminToken != "" && strings.HasPrefix(method, adminNamespacePrefix) {
+		req.Header.Set("Authorization", "Bearer "+c.AdminToken)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &rpcResponse{}
+	if err := json.Unmarshal(respBody, res); err != nil || (res.Error == nil && resp.StatusCode != http.StatusOK) {
+		return nil, errors.Errorf("%v answered with status %v: %s", u, resp.StatusCode, bytes.TrimSpace(respBody))
+	}
+	return res, nil
+}
+
+func (c *Client) setCurrent(u string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, v := range c.urls {
+		if v == u {
+			c.current = i
+			return
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/crypto"
+	"github.com/thetatoken/ukulele/ledger/types"
+	"github.com/thetatoken/ukulele/rpc"
+	"golang.org/x/net/websocket"
+)
+
+// newTestNode returns a node answering the RPC calls with the handler, and the number
+// of calls it received.
+func newTestNode(handler func(method string) (interface{}, *RPCError)) (*httptest.Server, *int32) {
+	calls := new(int32)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(calls, 1)
+		req := rpcRequest{}
+		json.NewDecoder(r.Body).Decode(&req)
+		result, rpcErr := handler(req.Method)
+		res := map[string]interface{}{"jsonrpc": "2.0", "id": req.ID}
+		if rpcErr != nil {
+			res["error"] = rpcErr
+		} else {
+			res["result"] = result
+		}
+		json.NewEncoder(w).Encode(res)
+	}))
+	return server, calls
+}
+
+func newTestClient(t *testing.T, urls ...string) *Client {
+	c, err := NewClient(urls...)
+	require.Nil(t, err)
+	c.RetryInterval = 10 * time.Millisecond
+	return c
+}
+
+func TestCallFailover(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "Bad gateway", http.StatusBadGateway)
+	}))
+	defer down.Close()
+	up, calls := newTestNode(func(method string) (interface{}, *RPCError) {
+		return rpc.GetStatusResult{LatestFinalizedBlockHeight: 42}, nil
+	})
+	defer up.Close()
+
+	c := newTestClient(t, down.URL, up.URL)
+	status, err := c.GetStatus(context.Background(), &rpc.GetStatusArgs{})
+	require.Nil(err)
+	assert.Equal(common.JSONUint64(42), status.LatestFinalizedBlockHeight)
+
+	// The calls stick to the node that answered
+	assert.Equal([]string{up.URL, down.URL}, c.URLs())
+	_, err = c.GetStatus(context.Background(), &rpc.GetStatusArgs{})
+	require.Nil(err)
+	assert.Equal(int32(2), atomic.LoadInt32(calls))
+
+	// The calls fail once all the nodes failed Retries times
+	up.Close()
+	_, err = c.GetStatus(context.Background(), &rpc.GetStatusArgs{})
+	assert.NotNil(err)
+}
+
+func TestCallRPCError(t *testing.T) {
+	assert := assert.New(t)
+
+	node, calls := newTestNode(func(method string) (interface{}, *RPCError) {
+		return nil, &RPCError{Code: -32002, Message: "Invalid sequence", Data: json.RawMessage(`{"code":7}`)}
+	})
+	defer node.Close()
+
+	// The errors of the RPC methods are not retried
+	c := newTestClient(t, node.URL, node.URL)
+	_, err := c.BroadcastRawTransaction(context.Background(), &rpc.BroadcastRawTransactionArgs{TxBytes: "00"})
+	rpcErr, ok := err.(*RPCError)
+	if assert.True(ok) {
+		assert.Equal(-32002, rpcErr.Code)
+		assert.Equal(`{"code":7}`, string(rpcErr.Data))
+	}
+	assert.Equal(int32(1), atomic.LoadInt32(calls))
+}
+
+func TestDecodeResults(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	alice := common.HexToAddress("0x2E833968E5bB786Ae419c4d13189fB081Cc43bab")
+	bob := common.HexToAddress("0x9F1233798E905E173560071255140b4A8aBd3Ec6")
+	account := types.NewAccount(alice)
+	account.Sequence = 3
+	tx, err := NewSendTx(alice, bob, types.NewCoins(0, 100), big.NewInt(1000000000000), 4)
+	require.Nil(err)
+
+	node, _ := newTestNode(func(method string) (interface{}, *RPCError) {
+		switch method {
+		case "theta.GetAccount":
+			return rpc.GetAccountResult{Account: account, Address: alice.Hex()}, nil
+		case "theta.GetTransaction":
+			return rpc.GetTransactionResult{Status: rpc.TxStatusFinalized, Type: rpc.TxTypeSend, Tx: tx}, nil
+		default:
+			return rpc.GetBlockResult{GetBlockResultInner: &rpc.GetBlockResultInner{
+				Height: 5,
+				Txs:    []rpc.Tx{{Tx: tx, Type: rpc.TxTypeSend}},
+			}}, nil
+		}
+	})
+	defer node.Close()
+	c := newTestClient(t, node.URL)
+
+	accountResult, err := c.GetAccount(context.Background(), &rpc.GetAccountArgs{Address: alice.Hex()})
+	require.Nil(err)
+	assert.Equal(uint64(3), accountResult.Sequence)
+	assert.Equal(alice, accountResult.Account.Address)
+
+	// The transactions are decoded according to their types
+	txResult, err := c.GetTransaction(context.Background(), &rpc.GetTransactionArgs{Hash: "0x01"})
+	require.Nil(err)
+	assert.Equal(tx.Outputs[0].Coins.GammaWei, txResult.Tx.(*types.SendTx).Outputs[0].Coins.GammaWei)
+
+	block, err := c.GetBlockByHeight(context.Background(), &rpc.GetBlockByHeightArgs{Height: 5})
+	require.Nil(err)
+	require.Equal(1, len(block.Txs))
+	assert.Equal(uint64(4), block.Txs[0].Tx.(*types.SendTx).Inputs[0].Sequence)
+}
+
+func TestSignTx(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	key, pubKey, err := crypto.GenerateKeyPair()
+	require.Nil(err)
+	signer := NewPrivateKeySigner(key)
+	to := common.HexToAddress("0x9F1233798E905E173560071255140b4A8aBd3Ec6")
+	tx, err := NewSendTx(signer.Address(), to, types.NewCoins(10, 100), big.NewInt(1000000000000), 1)
+	require.Nil(err)
+	assert.Equal(big.NewInt(10), tx.Inputs[0].Coins.ThetaWei)
+	assert.Equal(big.NewInt(1000000000100), tx.Inputs[0].Coins.GammaWei)
+
+	require.Nil(SignTx(tx, "privatenet", signer))
+	assert.True(pubKey.VerifySignature(tx.SignBytes("privatenet"), tx.Inputs[0].Signature))
+
+	// The signers must sign the transaction
+	other, _, err := crypto.GenerateKeyPair()
+	require.Nil(err)
+	assert.NotNil(SignTx(tx, "privatenet", NewPrivateKeySigner(other)))
+}
+
+func TestSubscribeFinalizedBlocks(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	fromHeights := make(chan string, 10)
+	server := httptest.NewServer(websocket.Server{Handler: func(ws *websocket.Conn) {
+		fromHeight := ws.Request().URL.Query().Get("from_height")
+		fromHeights <- fromHeight
+		// Each connection sends two blocks before it is lost
+		height := common.JSONUint64(5)
+		if fromHeight == "7" {
+			height = 7
+		}
+		for i := common.JSONUint64(0); i < 2; i++ {
+			websocket.JSON.Send(ws, rpc.BlockNotification{Block: &rpc.GetBlockResult{
+				GetBlockResultInner: &rpc.GetBlockResultInner{Height: height + i},
+			}})
+		}
+		ws.Close()
+	}})
+	defer server.Close()
+
+	c := newTestClient(t, server.URL+"/rpc")
+	sub := c.SubscribeFinalizedBlocks(context.Background(), 0)
+	defer sub.Unsubscribe()
+
+	for _, expected := range []common.JSONUint64{5, 6, 7, 8} {
+		select {
+		case block := <-sub.Blocks():
+			assert.Equal(expected, block.Height)
+		case err := <-sub.Err():
+			require.FailNow("Subscription failed", "%v", err)
+		case <-time.After(5 * time.Second):
+			require.FailNow("Timed out waiting for block", "%v", expected)
+		}
+	}
+	// The subscription resumed from the block following the last received block
+	assert.Equal("", <-fromHeights)
+	assert.Equal("7", <-fromHeights)
+}
+
+func TestBlocksURL(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal("ws://localhost:16888/ws/blocks", blocksURL("http://localhost:16888/rpc", 0))
+	assert.Equal("wss://node.example.com/theta/ws/blocks?from_height=12", blocksURL("https://node.example.com/theta/rpc/", 12))
+}
@@ -0,0 +1,321 @@
+package client
+
+import (
+	"context"
+
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/ledger/types"
+	"github.com/thetatoken/ukulele/rpc"
+)
+
+// ------------------------------- theta namespace -----------------------------------
+
+func (c *Client) GetStatus(ctx context.Context, args *rpc.GetStatusArgs) (*rpc.GetStatusResult, error) {
+	result := &rpc.GetStatusResult{}
+	if err := c.Call(ctx, "theta.GetStatus", args, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (c *Client) GetVersion(ctx context.Context, args *rpc.GetVersionArgs) (*rpc.GetVersionResult, error) {
+	result := &rpc.GetVersionResult{}
+	if err := c.Call(ctx, "theta.GetVersion", args, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (c *Client) GetSyncStatus(ctx context.Context, args *rpc.GetSyncStatusArgs) (*rpc.GetSyncStatusResult, error) {
+	result := &rpc.GetSyncStatusResult{}
+	if err := c.Call(ctx, "theta.GetSyncStatus", args, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetAccount returns the account at the address. The Address of the result is the
+// address of the arguments, since the node does not return it.
+func (c *Client) GetAccount(ctx context.Context, args *rpc.GetAccountArgs) (*rpc.GetAccountResult, error) {
+	result := &rpc.GetAccountResult{Account: &types.Account{}}
+	if err := c.Call(ctx, "theta.GetAccount", args, result); err != nil {
+		return nil, err
+	}
+	result.Address = args.Address
+	result.Account.Address = common.HexToAddress(args.Address)
+	return result, nil
+}
+
+func (c *Client) GetSplitRule(ctx context.Context, args *rpc.GetSplitRuleArgs) (*rpc.GetSplitRuleResult, error) {
+	result := &rpc.GetSplitRuleResult{SplitRule: &types.SplitRule{}}
+	if err := c.Call(ctx, "theta.GetSplitRule", args, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (c *Client) GetTokens(ctx context.Context, args *rpc.GetTokensArgs) (*rpc.GetTokensResult, error) {
+	result := &rpc.GetTokensResult{}
+	if err := c.Call(ctx, "theta.GetTokens", args, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (c *Client) GetChainParameters(ctx context.Context, args *rpc.GetChainParametersArgs) (*rpc.GetChainParametersResult, error) {
+	result := &rpc.GetChainParametersResult{}
+	if err := c.Call(ctx, "theta.GetChainParameters", args, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (c *Client) GetProposals(ctx context.Context, args *rpc.GetProposalsArgs) (*rpc.GetProposalsResult, error) {
+	result := &rpc.GetProposalsResult{}
+	if err := c.Call(ctx, "theta.GetProposals", args, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (c *Client) GetValidatorDowntimes(ctx context.Context, args *rpc.GetValidatorDowntimesArgs) (*rpc.GetValidatorDowntimesResult, error) {
+	result := &rpc.GetValidatorDowntimesResult{}
+	if err := c.Call(ctx, "theta.GetValidatorDowntimes", args, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (c *Client) GetStakes(ctx context.Context, args *rpc.GetStakesArgs) (*rpc.GetStakesResult, error) {
+	result := &rpc.GetStakesResult{}
+	if err := c.Call(ctx, "theta.GetStakes", args, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (c *Client) GetValidators(ctx context.Context, args *rpc.GetValidatorsArgs) (*rpc.GetValidatorsResult, error) {
+	result := &rpc.GetValidatorsResult{}
+	if err := c.Call(ctx, "theta.GetValidators", args, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (c *Client) GetTransaction(ctx context.Context, args *rpc.GetTransactionArgs) (*rpc.GetTransactionResult, error) {
+	result := &rpc.GetTransactionResult{}
+	if err := c.Call(ctx, "theta.GetTransaction", args, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (c *Client) GetBlock(ctx context.Context, args *rpc.GetBlockArgs) (*rpc.GetBlockResult, error) {
+	result := &rpc.GetBlockResult{}
+	if err := c.Call(ctx, "theta.GetBlock", args, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (c *Client) GetBlockByHeight(ctx context.Context, args *rpc.GetBlockByHeightArgs) (*rpc.GetBlockResult, error) {
+	result := &rpc.GetBlockResult{}
+	if err := c.Call(ctx, "theta.GetBlockByHeight", args, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (c *Client) GetGuardianConfirmation(ctx context.Context, args *rpc.GetGuardianConfirmationArgs) (*rpc.GetGuardianConfirmationResult, error) {
+	result := &rpc.GetGuardianConfirmationResult{}
+	if err := c.Call(ctx, "theta.GetGuardianConfirmation", args, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (c *Client) GetBalanceChanges(ctx context.Context, args *rpc.GetBalanceChangesArgs) (*rpc.GetBalanceChangesResult, error) {
+	result := &rpc.GetBalanceChangesResult{}
+	if err := c.Call(ctx, "theta.GetBalanceChanges", args, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (c *Client) GetAccountHistory(ctx context.Context, args *rpc.GetAccountHistoryArgs) (*rpc.GetAccountHistoryResult, error) {
+	result := &rpc.GetAccountHistoryResult{}
+	if err := c.Call(ctx, "theta.GetAccountHistory", args, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (c *Client) GetTransactionsByAddress(ctx context.Context, args *rpc.GetTransactionsByAddressArgs) (*rpc.GetTransactionsByAddressResult, error) {
+	result := &rpc.GetTransactionsByAddressResult{}
+	if err := c.Call(ctx, "theta.GetTransactionsByAddress", args, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (c *Client) GetHeaders(ctx context.Context, args *rpc.GetHeadersArgs) (*rpc.GetHeadersResult, error) {
+	result := &rpc.GetHeadersResult{}
+	if err := c.Call(ctx, "theta.GetHeaders", args, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (c *Client) GetAccountProof(ctx context.Context, args *rpc.GetAccountProofArgs) (*rpc.GetAccountProofResult, error) {
+	result := &rpc.GetAccountProofResult{}
+	if err := c.Call(ctx, "theta.GetAccountProof", args, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (c *Client) GetTransactionProof(ctx context.Context, args *rpc.GetTransactionProofArgs) (*rpc.GetTransactionProofResult, error) {
+	result := &rpc.GetTransactionProofResult{}
+	if err := c.Call(ctx, "theta.GetTransactionProof", args, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (c *Client) GetVotesByBlock(ctx context.Context, args *rpc.GetVotesByBlockArgs) (*rpc.GetVotesByBlockResult, error) {
+	result := &rpc.GetVotesByBlockResult{}
+	if err := c.Call(ctx, "theta.GetVotesByBlock", args, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (c *Client) GetDoubleSpendAlerts(ctx context.Context, args *rpc.GetDoubleSpendAlertsArgs) (*rpc.GetDoubleSpendAlertsResult, error) {
+	result := &rpc.GetDoubleSpendAlertsResult{}
+	if err := c.Call(ctx, "theta.GetDoubleSpendAlerts", args, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (c *Client) GetDatabaseStats(ctx context.Context, args *rpc.GetDatabaseStatsArgs) (*rpc.GetDatabaseStatsResult, error) {
+	result := &rpc.GetDatabaseStatsResult{}
+	if err := c.Call(ctx, "theta.GetDatabaseStats", args, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (c *Client) CallSmartContract(ctx context.Context, args *rpc.CallSmartContractArgs) (*rpc.CallSmartContractResult, error) {
+	result := &rpc.CallSmartContractResult{}
+	if err := c.Call(ctx, "theta.CallSmartContract", args, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (c *Client) RecoverSigner(ctx context.Context, args *rpc.RecoverSignerArgs) (*rpc.RecoverSignerResult, error) {
+	result := &rpc.RecoverSignerResult{}
+	if err := c.Call(ctx, "theta.RecoverSigner", args, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (c *Client) EstimateFee(ctx context.Context, args *rpc.EstimateFeeArgs) (*rpc.EstimateFeeResult, error) {
+	result := &rpc.EstimateFeeResult{}
+	if err := c.Call(ctx, "theta.EstimateFee", args, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (c *Client) BroadcastRawTransaction(ctx context.Context, args *rpc.BroadcastRawTransactionArgs) (*rpc.BroadcastRawTransactionResult, error) {
+	result := &rpc.BroadcastRawTransactionResult{}
+	if err := c.Call(ctx, "theta.BroadcastRawTransaction", args, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (c *Client) SubmitPartiallySignedTx(ctx context.Context, args *rpc.SubmitPartiallySignedTxArgs) (*rpc.PartiallySignedTxResult, error) {
+	result := &rpc.PartiallySignedTxResult{}
+	if err := c.Call(ctx, "theta.SubmitPartiallySignedTx", args, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (c *Client) GetPartiallySignedTx(ctx context.Context, args *rpc.GetPartiallySignedTxArgs) (*rpc.PartiallySignedTxResult, error) {
+	result := &rpc.PartiallySignedTxResult{}
+	if err := c.Call(ctx, "theta.GetPartiallySignedTx", args, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ------------------------------- admin namespace -----------------------------------
+
+func (c *Client) GenerateSnapshot(ctx context.Context, args *rpc.GenerateSnapshotArgs) (*rpc.GenerateSnapshotResult, error) {
+	result := &rpc.GenerateSnapshotResult{}
+	if err := c.Call(ctx, "admin.GenerateSnapshot", args, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (c *Client) GetSnapshotChunk(ctx context.Context, args *rpc.GetSnapshotChunkArgs) (*rpc.GetSnapshotChunkResult, error) {
+	result := &rpc.GetSnapshotChunkResult{}
+	if err := c.Call(ctx, "admin.GetSnapshotChunk", args, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (c *Client) ReloadConfig(ctx context.Context, args *rpc.ReloadConfigArgs) (*rpc.ReloadConfigResult, error) {
+	result := &rpc.ReloadConfigResult{}
+	if err := c.Call(ctx, "admin.ReloadConfig", args, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (c *Client) DumpConsensusState(ctx context.Context, args *rpc.DumpConsensusStateArgs) (*rpc.DumpConsensusStateResult, error) {
+	result := &rpc.DumpConsensusStateResult{}
+	if err := c.Call(ctx, "admin.DumpConsensusState", args, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (c *Client) AuditSupply(ctx context.Context, args *rpc.AuditSupplyArgs) (*rpc.AuditSupplyResult, error) {
+	result := &rpc.AuditSupplyResult{}
+	if err := c.Call(ctx, "admin.AuditSupply", args, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (c *Client) RegisterWebhook(ctx context.Context, args *rpc.RegisterWebhookArgs) (*rpc.RegisterWebhookResult, error) {
+	result := &rpc.RegisterWebhookResult{}
+	if err := c.Call(ctx, "admin.RegisterWebhook", args, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (c *Client) ListWebhooks(ctx context.Context, args *rpc.ListWebhooksArgs) (*rpc.ListWebhooksResult, error) {
+	result := &rpc.ListWebhooksResult{}
+	if err := c.Call(ctx, "admin.ListWebhooks", args, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (c *Client) UnregisterWebhook(ctx context.Context, args *rpc.UnregisterWebhookArgs) (*rpc.UnregisterWebhookResult, error) {
+	result := &rpc.UnregisterWebhookResult{}
+	if err := c.Call(ctx, "admin.UnregisterWebhook", args, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
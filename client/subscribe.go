@@ -0,0 +1,166 @@
+package client
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/thetatoken/ukulele/rpc"
+	"golang.org/x/net/websocket"
+)
+
+// BlockSubscription receives the finalized blocks of the chain in the order of their
+// heights, from the websocket endpoint of the nodes (rpc.wsEnabled). When the
+// connection is lost, it reconnects, failing over to the next nodes, and resumes from
+// the height following the last received block.
+type BlockSubscription struct {
+	blocks chan *rpc.GetBlockResult
+	err    chan error
+	cancel context.CancelFunc
+
+	next uint64 // Height of the next block, 0 for the next block finalized by the node
+}
+
+// Blocks returns the channel of the finalized blocks. It is closed when the
+// subscription ends.
+func (s *BlockSubscription) Blocks() <-chan *rpc.GetBlockResult {
+	return s.blocks
+}
+
+// Err returns the channel receiving the error that ended the subscription, if it did
+// not end with Unsubscribe or its context.
+func (s *BlockSubscription) Err() <-chan error {
+	return s.err
+}
+
+// Unsubscribe ends the subscription.
+func (s *BlockSubscription) Unsubscribe() {
+	s.cancel()
+}
+
+// SubscribeFinalizedBlocks subscribes to the finalized blocks from the given height, or
+// from the next finalized block if 0. The subscription ends with an error once it
+// failed to connect to all the nodes Retries times in a row.
+func (c *Client) SubscribeFinalizedBlocks(ctx context.Context, fromHeight uint64) *BlockSubscription {
+	ctx, cancel := context.WithCancel(ctx)
+	sub := &BlockSubscription{
+		blocks: make(chan *rpc.GetBlockResult),
+		err:    make(chan error, 1),
+		cancel: cancel,
+		next:   fromHeight,
+	}
+	go c.runBlockSubscription(ctx, sub)
+	return sub
+}
+
+func (c *Client) runBlockSubscription(ctx context.Context, sub *BlockSubscription) {
+	defer close(sub.blocks)
+
+	rounds := c.Retries
+	if rounds < 1 {
+		rounds = 1
+	}
+	failedRounds := 0
+	for {
+		connected := false
+		var lastErr error
+		for _, u := range c.URLs() {
+			ok, err := c.streamBlocks(ctx, u, sub)
+			if ctx.Err() != nil {
+				return
+			}
+			if ok && err != nil {
+				sub.err <- err
+				return
+			}
+			if ok {
+				connected = true
+				c.setCurrent(u)
+				break
+			}
+			lastErr = err
+		}
+
+		if connected {
+			failedRounds = 0
+		} else {
+			failedRounds++
+			if failedRounds >= rounds {
+				sub.err <- errors.Wrap(lastErr, "Failed to subscribe to the finalized blocks on any node")
+				return
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(c.RetryInterval):
+		}
+	}
+}
+
+// streamBlocks receives the blocks from the node until the connection is lost. It
+// returns whether it connected, and the error that prevented it from connecting, or the
+// error sent by the node, which ends the subscription.
+func (c *Client) streamBlocks(ctx context.Context, u string, sub *BlockSubscription) (bool, error) {
+	config, err := websocket.NewConfig(blocksURL(u, sub.next), u)
+	if err != nil {
+		return false, err
+	}
+	config.Dialer = &net.Dialer{Timeout: DefaultTimeout}
+	conn, err := websocket.DialConfig(config)
+	if err != nil {
+		return false, errors.Wrapf(err, "Failed to connect to %v", config.Location)
+	}
+	defer conn.Close()
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-stop:
+		}
+	}()
+
+	for {
+		notification := rpc.BlockNotification{}
+		if err := websocket.JSON.Receive(conn, &notification); err != nil {
+			return true, nil
+		}
+		if notification.Error != "" {
+			return true, errors.New(notification.Error)
+		}
+		block := notification.Block
+		if block == nil || block.GetBlockResultInner == nil {
+			continue
+		}
+		select {
+		case sub.blocks <- block:
+			sub.next = uint64(block.Height) + 1
+		case <-ctx.Done():
+			return true, nil
+		}
+	}
+}
+
+// blocksURL returns the URL of the stream of finalized blocks of the node with the
+// given RPC endpoint, e.g. ws://localhost:16888/ws/blocks for http://localhost:16888/rpc.
+func blocksURL(rpcURL string, fromHeight uint64) string {
+	u, _ := url.Parse(rpcURL) // Checked by NewClient
+	if u.Scheme == "https" {
+		u.Scheme = "wss"
+	} else {
+		u.Scheme = "ws"
+	}
+	u.Path = strings.TrimSuffix(strings.TrimSuffix(u.Path, "/"), "/rpc") + "/ws/blocks"
+	u.RawQuery = ""
+	if fromHeight != 0 {
+		u.RawQuery = url.Values{"from_height": {strconv.FormatUint(fromHeight, 10)}}.Encode()
+	}
+	return u.String()
+}
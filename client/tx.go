@@ -0,0 +1,150 @@
+package client
+
+import (
+	"context"
+	"encoding/hex"
+	"math/big"
+
+	"github.com/pkg/errors"
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/crypto"
+	"github.com/thetatoken/ukulele/ledger/types"
+	"github.com/thetatoken/ukulele/rpc"
+	wtypes "github.com/thetatoken/ukulele/wallet/types"
+)
+
+// Signer signs the transactions of an address. It is a types.TxSigner.
+type Signer interface {
+	Address() common.Address
+	Sign(signBytes common.Bytes) (*crypto.Signature, error)
+}
+
+// PrivateKeySigner signs with a private key held in memory.
+type PrivateKeySigner struct {
+	key *crypto.PrivateKey
+}
+
+func NewPrivateKeySigner(key *crypto.PrivateKey) *PrivateKeySigner {
+	return &PrivateKeySigner{key: key}
+}
+
+func (s *PrivateKeySigner) Address() common.Address {
+	return s.key.PublicKey().Address()
+}
+
+func (s *PrivateKeySigner) Sign(signBytes common.Bytes) (*crypto.Signature, error) {
+	return s.key.Sign(signBytes)
+}
+
+// WalletSigner signs with an unlocked address of a wallet, e.g. a hardware wallet.
+type WalletSigner struct {
+	wallet  wtypes.Wallet
+	address common.Address
+}
+
+func NewWalletSigner(wallet wtypes.Wallet, address common.Address) *WalletSigner {
+	return &WalletSigner{wallet: wallet, address: address}
+}
+
+func (s *WalletSigner) Address() common.Address {
+	return s.address
+}
+
+func (s *WalletSigner) Sign(signBytes common.Bytes) (*crypto.Signature, error) {
+	return s.wallet.Sign(s.address, signBytes)
+}
+
+// SignTx signs the transaction for the chain with each of the signers, which must all be
+// signing addresses of the transaction.
+func SignTx(tx types.Tx, chainID string, signers ...Signer) error {
+	txSigners := make([]types.TxSigner, len(signers))
+	for i, signer := range signers {
+		txSigners[i] = signer
+	}
+	return types.SignTx(tx, chainID, txSigners...)
+}
+
+// NewSendTx builds a transaction sending the coins from an address to another, the
+// sender also paying the fee in GammaWei. The sequence is the one of the sender's
+// next transaction.
+func NewSendTx(from, to common.Address, coins types.Coins, fee *big.Int, sequence uint64) (*types.SendTx, error) {
+	return types.NewSendTxBuilder().From(from).Sequence(sequence).To(to).Amount(coins).Fee(fee).Build()
+}
+
+// ChainID returns the ID of the chain of the nodes, read from their latest finalized
+// block the first time.
+func (c *Client) ChainID(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	chainID := c.chainID
+	c.mu.Unlock()
+	if chainID != "" {
+		return chainID, nil
+	}
+
+	status, err := c.GetStatus(ctx, &rpc.GetStatusArgs{})
+	if err != nil {
+		return "", err
+	}
+	block, err := c.GetBlock(ctx, &rpc.GetBlockArgs{Hash: status.LatestFinalizedBlockHash})
+	if err != nil {
+		return "", err
+	}
+	if block.GetBlockResultInner == nil || block.ChainID == "" {
+		return "", errors.New("Failed to read the chain ID from the latest finalized block")
+	}
+
+	c.mu.Lock()
+	c.chainID = block.ChainID
+	c.mu.Unlock()
+	return block.ChainID, nil
+}
+
+// NextSequence returns the sequence of the next transaction of the address, taking the
+// transactions in the mempool of the node into account.
+func (c *Client) NextSequence(ctx context.Context, address common.Address) (uint64, error) {
+	account, err := c.GetAccount(ctx, &rpc.GetAccountArgs{Address: address.Hex(), State: rpc.LedgerStatePending})
+	if err != nil {
+		return 0, err
+	}
+	return account.Sequence + 1, nil
+}
+
+// BroadcastTx broadcasts the signed transaction, and returns its hash.
+func (c *Client) BroadcastTx(ctx context.Context, tx types.Tx) (common.Hash, error) {
+	raw, err := types.TxToBytes(tx)
+	if err != nil {
+		return common.Hash{}, errors.Wrap(err, "Failed to encode the transaction")
+	}
+	result, err := c.BroadcastRawTransaction(ctx, &rpc.BroadcastRawTransactionArgs{TxBytes: hex.EncodeToString(raw)})
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return common.HexToHash(result.TxHash), nil
+}
+
+// Send sends the coins from the signer to the address, with the next sequence of the
+// signer and the fee estimated by the node if nil, and returns the transaction hash.
+func (c *Client) Send(ctx context.Context, signer Signer, to common.Address, coins types.Coins, fee *big.Int) (common.Hash, error) {
+	chainID, err := c.ChainID(ctx)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	sequence, err := c.NextSequence(ctx, signer.Address())
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if fee == nil {
+		estimation, err := c.EstimateFee(ctx, &rpc.EstimateFeeArgs{})
+		if err != nil {
+			return common.Hash{}, err
+		}
+		fee = estimation.Fee.ToInt()
+	}
+
+	tx, err := types.NewSendTxBuilder().From(signer.Address()).Sequence(sequence).
+		To(to).Amount(coins).Fee(fee).Sign(chainID, signer)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return c.BroadcastTx(ctx, tx)
+}
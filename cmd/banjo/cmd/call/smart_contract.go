@@ -11,7 +11,6 @@ import (
 	rpcc "github.com/ybbus/jsonrpc"
 
 	"github.com/thetatoken/ukulele/cmd/banjo/cmd/utils"
-	"github.com/thetatoken/ukulele/common"
 	"github.com/thetatoken/ukulele/ledger/types"
 	"github.com/thetatoken/ukulele/rpc"
 )
@@ -23,7 +22,7 @@ import (
 //   * Deploy a smart contract (local only)
 //		banjo call smart_contract --from=2E833968E5bB786Ae419c4d13189fB081Cc43bab --value=1680 --gas_price=3 --gas_limit=50000 --data=600a600c600039600a6000f3600360135360016013f3
 //   * Call an API of a smart contract (local only)
-//		banjo call smart_contract --from=2E833968E5bB786Ae419c4d13189fB081Cc43bab --to=0x7ad6cea2bc3162e30a3c98d84f821b3233c22647 --gas_price=3 --gas_limit=50000
+//		banjo call smart_contract --from=2E833968E5bB786Ae419c4d13189fB081Cc43bab --to=0x7ad6cEA2BC3162E30A3C98d84f821b3233C22647 --gas_price=3 --gas_limit=50000
 
 var smartContractCmd = &cobra.Command{
 	Use:   "smart_contract",
@@ -33,7 +32,7 @@ var smartContractCmd = &cobra.Command{
 	banjo call smart_contract --from=2E833968E5bB786Ae419c4d13189fB081Cc43bab --value=1680 --gas_price=3 --gas_limit=50000 --data=600a600c600039600a6000f3600360135360016013f3
 	
 	[Call an API of a smart contract (local only)]
-	banjo call smart_contract --from=2E833968E5bB786Ae419c4d13189fB081Cc43bab --to=0x7ad6cea2bc3162e30a3c98d84f821b3233c22647 --gas_price=3 --gas_limit=50000
+	banjo call smart_contract --from=2E833968E5bB786Ae419c4d13189fB081Cc43bab --to=0x7ad6cEA2BC3162E30A3C98d84f821b3233C22647 --gas_price=3 --gas_limit=50000
 	`,
 	Long : `smartContractCmd represents the smart_contract command, which can be used to calls the specified smart contract.
 		However, calling a smart contract does NOT modify the globally consensus state. It can be used for dry run, or for retrieving info from smart contracts without actually spending gas.`,
@@ -41,8 +40,17 @@ var smartContractCmd = &cobra.Command{
 }
 
 func doSmartContractCmd(cmd *cobra.Command, args []string) {
+	fromAddress, err := utils.ParseAddress(fromFlag)
+	if err != nil {
+		utils.ErrorWithCode(utils.ExitCodeInvalidInput, "%v\n", err)
+	}
+	toAddress, err := utils.ParseAddress(toFlag)
+	if err != nil {
+		utils.ErrorWithCode(utils.ExitCodeInvalidInput, "%v\n", err)
+	}
+
 	from := types.TxInput{
-		Address: common.HexToAddress(fromFlag),
+		Address: fromAddress,
 		Coins: types.Coins{
 			ThetaWei: new(big.Int).SetUint64(0),
 			GammaWei: new(big.Int).SetUint64(valueFlag),
@@ -51,17 +59,17 @@ func doSmartContractCmd(cmd *cobra.Command, args []string) {
 	}
 
 	to := types.TxOutput{
-		Address: common.HexToAddress(toFlag),
+		Address: toAddress,
 	}
 
 	gasPrice, ok := types.ParseCoinAmount(gasPriceFlag)
 	if !ok {
-		utils.Error("Failed to parse gas price")
+		utils.ErrorWithCode(utils.ExitCodeInvalidInput, "Failed to parse gas price")
 	}
 
 	data, err := hex.DecodeString(dataFlag)
 	if err != nil {
-		utils.Error("Failed to decode data: %v\n", dataFlag)
+		utils.ErrorWithCode(utils.ExitCodeInvalidInput, "Failed to decode data: %v\n", dataFlag)
 	}
 
 	sctx := &types.SmartContractTx{
@@ -85,14 +93,14 @@ func doSmartContractCmd(cmd *cobra.Command, args []string) {
 
 	res, err := client.Call("theta.CallSmartContract", rpcCallArgs)
 	if err != nil {
-		utils.Error("Failed to call smart contract: %v\n", err)
+		utils.ErrorWithCode(utils.ExitCodeRPC, "Failed to call smart contract: %v\n", err)
 	}
 	if res.Error != nil {
-		utils.Error("Failed to execute smart contract: %v\n", res.Error)
+		utils.ErrorWithCode(utils.ExitCodeServer, "Failed to execute smart contract: %v\n", res.Error)
 	}
 	json, err := json.MarshalIndent(res.Result, "", "    ")
 	if err != nil {
-		utils.Error("Failed to parse server response: %v\n%s\n", err, string(json))
+		utils.ErrorWithCode(utils.ExitCodeRPC, "Failed to parse server response: %v\n%s\n", err, string(json))
 	}
 	fmt.Println(string(json))
 }
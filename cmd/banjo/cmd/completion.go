@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/thetatoken/ukulele/cmd/banjo/cmd/utils"
+)
+
+// completionCmd generates the shell completion script
+var completionCmd = &cobra.Command{
+	Use:   "completion <bash|zsh|fish>",
+	Short: "Generate shell completion script",
+	Long: `Generate the completion script of banjo for the given shell. To load the completions:
+
+  bash: source <(banjo completion bash)
+  zsh:  banjo completion zsh > "${fpath[1]}/_banjo"
+  fish: banjo completion fish > ~/.config/fish/completions/banjo.fish`,
+	Example: `banjo completion bash > /etc/bash_completion.d/banjo`,
+	Run:     doCompletionCmd,
+}
+
+func doCompletionCmd(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		utils.ErrorWithCode(utils.ExitCodeInvalidInput, "Usage: banjo completion <bash|zsh|fish>\n")
+	}
+
+	var err error
+	switch args[0] {
+	case "bash":
+		err = RootCmd.GenBashCompletion(os.Stdout)
+	case "zsh":
+		err = RootCmd.GenZshCompletion(os.Stdout)
+	case "fish":
+		err = genFishCompletion(RootCmd, os.Stdout)
+	default:
+		utils.ErrorWithCode(utils.ExitCodeInvalidInput, "Unsupported shell: %v\n", args[0])
+	}
+	if err != nil {
+		utils.Error("Failed to generate completion script: %v\n", err)
+	}
+}
+
+// genFishCompletion writes the fish completion script of the given root command
+func genFishCompletion(root *cobra.Command, w io.Writer) error {
+	name := root.Name()
+	if _, err := fmt.Fprintf(w, "# fish completion for %s\n\ncomplete -c %s -f\n", name, name); err != nil {
+		return err
+	}
+	return genFishCompletionForCommand(name, root, []string{}, w)
+}
+
+func genFishCompletionForCommand(name string, cmd *cobra.Command, path []string, w io.Writer) error {
+	condition := "__fish_use_subcommand"
+	if len(path) > 0 {
+		conditions := []string{}
+		for _, p := range path {
+			conditions = append(conditions, "__fish_seen_subcommand_from "+p)
+		}
+		condition = strings.Join(conditions, "; and ")
+	}
+
+	var err error
+	cmd.NonInheritedFlags().VisitAll(func(flag *pflag.Flag) {
+		if err != nil || flag.Hidden {
+			return
+		}
+		line := fmt.Sprintf("complete -c %s -n '%s' -l %s", name, condition, flag.Name)
+		if len(flag.Shorthand) > 0 {
+			line += " -s " + flag.Shorthand
+		}
+		if flag.Value.Type() != "bool" {
+			line += " -r"
+		}
+		_, err = fmt.Fprintf(w, "%s -d %s\n", line, fishQuote(flag.Usage))
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, child := range cmd.Commands() {
+		if !child.IsAvailableCommand() {
+			continue
+		}
+		_, err = fmt.Fprintf(w, "complete -c %s -n '%s' -a %s -d %s\n",
+			name, condition, child.Name(), fishQuote(child.Short))
+		if err != nil {
+			return err
+		}
+		err = genFishCompletionForCommand(name, child, append(path, child.Name()), w)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func fishQuote(s string) string {
+	return "'" + strings.Replace(s, "'", "\\'", -1) + "'"
+}
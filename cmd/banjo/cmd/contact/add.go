@@ -0,0 +1,27 @@
+package contact
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/thetatoken/ukulele/cmd/banjo/cmd/utils"
+)
+
+// addCmd adds a contact to the address book
+var addCmd = &cobra.Command{
+	Use:     "add",
+	Short:   "Add a contact",
+	Long:    `Add a contact to the address book. The address needs to have a valid EIP55 checksum.`,
+	Example: "banjo contact add alice 0x2E833968E5bB786Ae419c4d13189fB081Cc43bab",
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 2 {
+			utils.ErrorWithCode(utils.ExitCodeInvalidInput, "Usage: banjo contact add <name> <address>\n")
+		}
+		book := loadAddressBook(cmd)
+		contact, err := book.Add(args[0], args[1])
+		if err != nil {
+			utils.ErrorWithCode(utils.ExitCodeInvalidInput, "Failed to add contact: %v\n", err)
+		}
+		fmt.Printf("Contact %v added: %v\n", contact.Name, contact.Address.Hex())
+	},
+}
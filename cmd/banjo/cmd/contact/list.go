@@ -0,0 +1,21 @@
+package contact
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// listCmd lists the contacts in the address book
+var listCmd = &cobra.Command{
+	Use:     "list",
+	Short:   "List all contacts",
+	Long:    `List all contacts in the address book.`,
+	Example: "banjo contact list",
+	Run: func(cmd *cobra.Command, args []string) {
+		book := loadAddressBook(cmd)
+		for _, contact := range book.List() {
+			fmt.Printf("%s\t%s\n", contact.Name, contact.Address.Hex())
+		}
+	},
+}
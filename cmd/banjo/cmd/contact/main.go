@@ -0,0 +1,29 @@
+package contact
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/thetatoken/ukulele/cmd/banjo/cmd/utils"
+)
+
+// ContactCmd represents the contact command
+var ContactCmd = &cobra.Command{
+	Use:   "contact",
+	Short: "Manage the address book",
+	Long: `Manage the address book. The tx commands accept the contact names anywhere an address
+is expected.`,
+}
+
+func init() {
+	ContactCmd.AddCommand(addCmd)
+	ContactCmd.AddCommand(removeCmd)
+	ContactCmd.AddCommand(listCmd)
+}
+
+func loadAddressBook(cmd *cobra.Command) *utils.AddressBook {
+	cfgPath := cmd.Flag("config").Value.String()
+	book, err := utils.LoadAddressBook(cfgPath)
+	if err != nil {
+		utils.ErrorWithCode(utils.ExitCodeInvalidInput, "Failed to load address book: %v\n", err)
+	}
+	return book
+}
@@ -0,0 +1,26 @@
+package contact
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/thetatoken/ukulele/cmd/banjo/cmd/utils"
+)
+
+// removeCmd removes a contact from the address book
+var removeCmd = &cobra.Command{
+	Use:     "remove",
+	Short:   "Remove a contact",
+	Long:    `Remove a contact from the address book.`,
+	Example: "banjo contact remove alice",
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 1 {
+			utils.ErrorWithCode(utils.ExitCodeInvalidInput, "Usage: banjo contact remove <name>\n")
+		}
+		book := loadAddressBook(cmd)
+		if err := book.Remove(args[0]); err != nil {
+			utils.ErrorWithCode(utils.ExitCodeInvalidInput, "Failed to remove contact: %v\n", err)
+		}
+		fmt.Printf("Contact %v removed\n", args[0])
+	},
+}
@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/thetatoken/ukulele/cmd/banjo/cmd/utils"
+	"github.com/thetatoken/ukulele/wallet/daemon"
+)
+
+var (
+	socketFlag   string
+	clientsFlag  string
+	unlockFlag   string
+	autoLockFlag time.Duration
+)
+
+// daemonCmd runs the wallet daemon
+var daemonCmd = &cobra.Command{
+	Use:   "daemon",
+	Short: "Run the wallet daemon",
+	Long: `Run the wallet daemon, which serves the soft wallet to the other local processes over a unix
+socket. The keys are unlocked once, either with the --unlock flag or by a client, and are then
+shared by the clients. Each client authenticates with a token, and is granted the permissions
+(list|unlock|sign|blindsign) and the addresses listed for it in the clients file, e.g.
+
+[
+    {"name": "node", "token": "<random token>", "permissions": ["list", "sign"], "addresses": ["0x2E833968E5bB786Ae419c4d13189fB081Cc43bab"]},
+    {"name": "backup", "token": "<random token>", "permissions": ["list"]}
+]
+
+The sign permission only allows to sign decodable transactions, signing any other message
+requires the blindsign permission.
+
+The tx commands use the daemon with --wallet=daemon, authenticating with the walletDaemon.token
+config.`,
+	Example: `banjo daemon --unlock=2E833968E5bB786Ae419c4d13189fB081Cc43bab`,
+	Run:     doDaemonCmd,
+}
+
+func doDaemonCmd(cmd *cobra.Command, args []string) {
+	if len(socketFlag) == 0 {
+		socketFlag = utils.WalletDaemonSocketPath(cfgPath)
+	}
+	if len(clientsFlag) == 0 {
+		clientsFlag = path.Join(cfgPath, "daemon_clients.json")
+	}
+
+	clients, err := daemon.LoadClientConfigs(clientsFlag)
+	if err != nil {
+		utils.ErrorWithCode(utils.ExitCodeInvalidInput, "Failed to load client configs from %v: %v\n", clientsFlag, err)
+	}
+
+	wallet, err := utils.OpenSoftWallet(cfgPath)
+	if err != nil {
+		utils.ErrorWithCode(utils.ExitCodeWallet, "Failed to open wallet: %v\n", err)
+	}
+	wallet.SetAutoLockTimeout(autoLockFlag)
+
+	for _, addressStr := range strings.FieldsFunc(unlockFlag, func(c rune) bool { return c == ',' }) {
+		address, err := utils.ParseAddress(strings.TrimSpace(addressStr))
+		if err != nil {
+			utils.ErrorWithCode(utils.ExitCodeInvalidInput, "%v\n", err)
+		}
+		password, err := utils.GetPassword(fmt.Sprintf("Please enter password for %v: ", address.Hex()))
+		if err != nil {
+			utils.ErrorWithCode(utils.ExitCodeWallet, "Failed to get password: %v\n", err)
+		}
+		if err := wallet.Unlock(address, password); err != nil {
+			utils.ErrorWithCode(utils.ExitCodeWallet, "Failed to unlock address %v: %v\n", address.Hex(), err)
+		}
+	}
+
+	server, err := daemon.NewServer(wallet, socketFlag, clients)
+	if err != nil {
+		utils.ErrorWithCode(utils.ExitCodeInvalidInput, "Invalid client configs: %v\n", err)
+	}
+	if err := server.Start(context.Background()); err != nil {
+		utils.ErrorWithCode(utils.ExitCodeWallet, "Failed to start wallet daemon: %v\n", err)
+	}
+	fmt.Printf("Wallet daemon listening on %v, %v clients configured\n", socketFlag, len(clients))
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	<-sigs
+
+	server.Stop()
+	server.Wait()
+}
+
+func init() {
+	daemonCmd.Flags().StringVar(&socketFlag, "socket", "", "Path of the unix socket (default is <config>/wallet.sock)")
+	daemonCmd.Flags().StringVar(&clientsFlag, "clients", "", "Path of the client configs (default is <config>/daemon_clients.json)")
+	daemonCmd.Flags().StringVar(&unlockFlag, "unlock", "", "Comma separated addresses to unlock at startup")
+	daemonCmd.Flags().DurationVar(&autoLockFlag, "autolock", 0, "Lock the unlocked keys after being unused for the duration, zero to disable")
+}
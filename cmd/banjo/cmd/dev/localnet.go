@@ -0,0 +1,178 @@
+package dev
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path"
+	"strings"
+	"syscall"
+
+	"github.com/spf13/cobra"
+	"github.com/thetatoken/ukulele/cmd/banjo/cmd/utils"
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/consensus"
+	"github.com/thetatoken/ukulele/crypto"
+)
+
+// localnetCmd bootstraps a local multi-validator network
+var localnetCmd = &cobra.Command{
+	Use:   "localnet",
+	Short: "Bootstrap a local multi-validator network",
+	Long: `Bootstrap a local multi-validator network. It generates the validator keys, the genesis
+checkpoint, and a config directory for each node. The validator keys are also imported into
+a banjo keystore, so the funds of the validators can be spent from the command line. If the
+--launch flag is set, the nodes are started as subprocesses until banjo is interrupted.`,
+	Example: `banjo dev localnet --validators=4 --dir=./localnet --launch
+banjo --config=./localnet/banjo tx send --chain="" --from=<validator address> --to=9F1233798E905E173560071255140b4A8aBd3Ec6 --theta=10 --seq=1`,
+	Run: doLocalnetCmd,
+}
+
+func doLocalnetCmd(cmd *cobra.Command, args []string) {
+	if numValidatorsFlag == 0 {
+		utils.ErrorWithCode(utils.ExitCodeInvalidInput, "The number of validators must be positive\n")
+	}
+	if _, err := os.Stat(dirFlag); !os.IsNotExist(err) {
+		utils.ErrorWithCode(utils.ExitCodeInvalidInput, "Directory %v already exists\n", dirFlag)
+	}
+
+	privKeys := []*crypto.PrivateKey{}
+	validators := []string{}
+	for i := uint32(0); i < numValidatorsFlag; i++ {
+		privKey, _, err := crypto.GenerateKeyPair()
+		if err != nil {
+			utils.Error("Failed to generate validator key: %v\n", err)
+		}
+		privKeys = append(privKeys, privKey)
+		validators = append(validators, strings.ToUpper(hex.EncodeToString(privKey.PublicKey().ToBytes())))
+	}
+
+	nodeDirs := []string{}
+	for i, privKey := range privKeys {
+		nodeDir := path.Join(dirFlag, fmt.Sprintf("node%d", i+1))
+		if err := os.MkdirAll(nodeDir, 0700); err != nil {
+			utils.Error("Failed to create node directory %v: %v\n", nodeDir, err)
+		}
+		if err := privKey.SaveToFile(path.Join(nodeDir, "key")); err != nil {
+			utils.Error("Failed to save validator key: %v\n", err)
+		}
+		if err := consensus.WriteGenesisCheckpointForValidators(path.Join(nodeDir, "genesis"), validators); err != nil {
+			utils.Error("Failed to write genesis checkpoint: %v\n", err)
+		}
+		if err := common.WriteFileAtomic(path.Join(nodeDir, "config.yaml"), []byte(nodeConfig(i)), 0600); err != nil {
+			utils.Error("Failed to write node config: %v\n", err)
+		}
+		nodeDirs = append(nodeDirs, nodeDir)
+	}
+
+	banjoDir := path.Join(dirFlag, "banjo")
+	if err := os.MkdirAll(banjoDir, 0700); err != nil {
+		utils.Error("Failed to create banjo directory %v: %v\n", banjoDir, err)
+	}
+	banjoConfig := fmt.Sprintf("remoteRPCEndpoint: http://localhost:%d/rpc\n", rpcBasePortFlag)
+	if err := common.WriteFileAtomic(path.Join(banjoDir, "config.yaml"), []byte(banjoConfig), 0600); err != nil {
+		utils.Error("Failed to write banjo config: %v\n", err)
+	}
+	wallet, err := utils.OpenSoftWallet(banjoDir)
+	if err != nil {
+		utils.ErrorWithCode(utils.ExitCodeWallet, "Failed to open wallet: %v\n", err)
+	}
+	for _, privKey := range privKeys {
+		if _, err := wallet.ImportKey(privKey, passwordFlag); err != nil {
+			utils.ErrorWithCode(utils.ExitCodeWallet, "Failed to import key: %v\n", err)
+		}
+	}
+
+	fmt.Printf("Local network with %d validators created in %v\n", numValidatorsFlag, dirFlag)
+	for i, privKey := range privKeys {
+		fmt.Printf("    node%d: address %v, p2p port %d, rpc port %d\n",
+			i+1, privKey.PublicKey().Address().Hex(), p2pBasePortFlag+uint32(i), rpcBasePortFlag+uint32(i))
+	}
+	fmt.Printf("The validator keys are imported into %v with password %q\n", banjoDir, passwordFlag)
+
+	if !launchFlag {
+		fmt.Printf("To start a node: %v start --config=%v\n", binaryFlag, nodeDirs[0])
+		return
+	}
+	launchNodes(nodeDirs)
+}
+
+// nodeConfig returns the config of the i-th node, which uses all the other nodes as seeds
+func nodeConfig(i int) string {
+	seeds := []string{}
+	for j := 0; j < int(numValidatorsFlag); j++ {
+		if j != i {
+			seeds = append(seeds, fmt.Sprintf("127.0.0.1:%d", p2pBasePortFlag+uint32(j)))
+		}
+	}
+	return fmt.Sprintf(`# Theta configuration
+p2p:
+  port: %d
+  seeds: %s
+rpc:
+  enabled: true
+  port: %d
+`, p2pBasePortFlag+uint32(i), strings.Join(seeds, ","), rpcBasePortFlag+uint32(i))
+}
+
+// launchNodes starts the nodes as subprocesses, and stops them when banjo is interrupted
+func launchNodes(nodeDirs []string) {
+	procs := []*exec.Cmd{}
+	stopAll := func() {
+		for _, proc := range procs {
+			proc.Process.Signal(os.Interrupt)
+		}
+	}
+
+	exited := make(chan string, len(nodeDirs))
+	for _, nodeDir := range nodeDirs {
+		logFile, err := os.Create(path.Join(nodeDir, "node.log"))
+		if err != nil {
+			stopAll()
+			utils.Error("Failed to create log file: %v\n", err)
+		}
+		proc := exec.Command(binaryFlag, "start", "--config="+nodeDir)
+		proc.Stdout = logFile
+		proc.Stderr = logFile
+		if err := proc.Start(); err != nil {
+			stopAll()
+			utils.Error("Failed to launch %v: %v\n", binaryFlag, err)
+		}
+		procs = append(procs, proc)
+		fmt.Printf("Launched node %v (pid %d), logging to %v\n", nodeDir, proc.Process.Pid, logFile.Name())
+
+		go func(nodeDir string, proc *exec.Cmd, logFile *os.File) {
+			proc.Wait()
+			logFile.Close()
+			exited <- nodeDir
+		}(nodeDir, proc, logFile)
+	}
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	fmt.Println("Press Ctrl+C to stop the local network")
+
+	select {
+	case <-sigs:
+		fmt.Println("Stopping the local network")
+		stopAll()
+		for range procs {
+			<-exited
+		}
+	case nodeDir := <-exited:
+		stopAll()
+		utils.Error("Node %v exited unexpectedly, see its node.log for details\n", nodeDir)
+	}
+}
+
+func init() {
+	localnetCmd.Flags().Uint32Var(&numValidatorsFlag, "validators", 4, "Number of validators")
+	localnetCmd.Flags().StringVar(&dirFlag, "dir", "./localnet", "Directory for the node configs")
+	localnetCmd.Flags().Uint32Var(&p2pBasePortFlag, "p2p_port", 12000, "P2P port of the first node, incremented for each following node")
+	localnetCmd.Flags().Uint32Var(&rpcBasePortFlag, "rpc_port", 16888, "RPC port of the first node, incremented for each following node")
+	localnetCmd.Flags().StringVar(&passwordFlag, "password", "qwertyuiop", "Password of the validator keys imported into the banjo keystore")
+	localnetCmd.Flags().BoolVar(&launchFlag, "launch", false, "Launch the nodes as subprocesses")
+	localnetCmd.Flags().StringVar(&binaryFlag, "binary", "theta", "Path of the node binary")
+}
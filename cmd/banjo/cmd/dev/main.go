@@ -0,0 +1,27 @@
+package dev
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// Common flags used in Dev sub commands.
+var (
+	numValidatorsFlag uint32
+	dirFlag           string
+	p2pBasePortFlag   uint32
+	rpcBasePortFlag   uint32
+	passwordFlag      string
+	launchFlag        bool
+	binaryFlag        string
+)
+
+// DevCmd represents the dev command
+var DevCmd = &cobra.Command{
+	Use:   "dev",
+	Short: "Developer tools",
+	Long:  `Developer tools.`,
+}
+
+func init() {
+	DevCmd.AddCommand(localnetCmd)
+}
@@ -6,9 +6,6 @@ import (
 
 	"github.com/spf13/cobra"
 	"github.com/thetatoken/ukulele/cmd/banjo/cmd/utils"
-	"github.com/thetatoken/ukulele/common"
-	"github.com/thetatoken/ukulele/wallet"
-	wtypes "github.com/thetatoken/ukulele/wallet/types"
 )
 
 // deleteCmd deletes the key corresponding to the given address
@@ -19,44 +16,47 @@ var deleteCmd = &cobra.Command{
 	Example: "banjo delete 26d813157F7503a9057FB2DB6Eb2f83a35c4FdD7",
 	Run: func(cmd *cobra.Command, args []string) {
 		if len(args) < 1 {
-			utils.Error("Usage: banjo key <address>\n")
+			utils.ErrorWithCode(utils.ExitCodeInvalidInput, "Usage: banjo key <address>\n")
+		}
+		address, err := utils.ParseAddress(args[0])
+		if err != nil {
+			utils.ErrorWithCode(utils.ExitCodeInvalidInput, "%v\n", err)
 		}
-		address := common.HexToAddress(args[0])
 
 		cfgPath := cmd.Flag("config").Value.String()
-		wallet, err := wallet.OpenWallet(cfgPath, wtypes.WalletTypeSoft, true)
+		wallet, err := utils.OpenSoftWallet(cfgPath)
 		if err != nil {
-			utils.Error("Failed to open wallet: %v\n", err)
+			utils.ErrorWithCode(utils.ExitCodeWallet, "Failed to open wallet: %v\n", err)
 		}
 
 		prompt := fmt.Sprintf("Please enter the password: ")
 		password, err := utils.GetPassword(prompt)
 		if err != nil {
-			utils.Error("Failed to get password: %v\n", err)
+			utils.ErrorWithCode(utils.ExitCodeWallet, "Failed to get password: %v\n", err)
 		}
 
 		fmt.Println("Are you sure to delete the key? Please enter 'no' to stop or 'yes' to proceed: ")
 		confirmation, err := utils.GetConfirmation()
 		if err != nil {
-			utils.Error("Failed to get confirmation: %v\n", err)
+			utils.ErrorWithCode(utils.ExitCodeInvalidInput, "Failed to get confirmation: %v\n", err)
 		}
 		if strings.ToLower(confirmation) != "yes" {
-			return
+			utils.ErrorWithCode(utils.ExitCodeAborted, "Key deletion aborted\n")
 		}
 
 		prompt = fmt.Sprintf("Please enter the password again to proceed: ")
 		password2, err := utils.GetPassword(prompt)
 		if err != nil {
-			utils.Error("Failed to get password: %v\n", err)
+			utils.ErrorWithCode(utils.ExitCodeWallet, "Failed to get password: %v\n", err)
 		}
 
 		if password != password2 {
-			utils.Error("Passwords do not match, abort\n")
+			utils.ErrorWithCode(utils.ExitCodeInvalidInput, "Passwords do not match, abort\n")
 		}
 
 		err = wallet.Delete(address, password)
 		if err != nil {
-			utils.Error("Failed to delete key for address %v: %v\n", address.Hex(), err)
+			utils.ErrorWithCode(utils.ExitCodeWallet, "Failed to delete key for address %v: %v\n", address.Hex(), err)
 		}
 
 		fmt.Printf("Key for address %v has been deleted\n", address.Hex())
@@ -0,0 +1,57 @@
+package key
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/thetatoken/ukulele/cmd/banjo/cmd/utils"
+	wtypes "github.com/thetatoken/ukulele/wallet/types"
+)
+
+// deriveCmd derives keys from the HD seed of the wallet
+var deriveCmd = &cobra.Command{
+	Use:   "derive",
+	Short: "Derive keys from the seed",
+	Long: `Derive keys from the HD seed of the wallet, either at the account indices of the Theta derivation
+path m/44'/500'/0'/0/index, or at the custom derivation path given by the --path flag.`,
+	Example: `banjo key derive --start=1 --count=3
+banjo key derive --path="m/44'/500'/1'/0/0"`,
+	Run: doDeriveCmd,
+}
+
+func doDeriveCmd(cmd *cobra.Command, args []string) {
+	derivationPaths := []wtypes.DerivationPath{}
+	if len(pathFlag) > 0 {
+		derivationPath, err := wtypes.ParseDerivationPath(pathFlag)
+		if err != nil {
+			utils.ErrorWithCode(utils.ExitCodeInvalidInput, "Failed to parse derivation path: %v\n", err)
+		}
+		derivationPaths = append(derivationPaths, derivationPath)
+	} else {
+		for index := startFlag; index < startFlag+countFlag; index++ {
+			derivationPaths = append(derivationPaths, wtypes.ThetaDerivationPath(index))
+		}
+	}
+
+	wallet := openSoftWallet(cmd)
+
+	prompt := fmt.Sprintf("Please enter the password of the seed: ")
+	password, err := utils.GetPassword(prompt)
+	if err != nil {
+		utils.ErrorWithCode(utils.ExitCodeWallet, "Failed to get password: %v\n", err)
+	}
+
+	for _, derivationPath := range derivationPaths {
+		address, err := wallet.DeriveKey(derivationPath, password)
+		if err != nil {
+			utils.ErrorWithCode(utils.ExitCodeWallet, "Failed to derive key for path %v: %v\n", derivationPath, err)
+		}
+		fmt.Printf("%v\t%v\n", address.Hex(), derivationPath)
+	}
+}
+
+func init() {
+	deriveCmd.Flags().Uint32Var(&startFlag, "start", 0, "Start account index")
+	deriveCmd.Flags().Uint32Var(&countFlag, "count", 1, "Number of keys to derive")
+	deriveCmd.Flags().StringVar(&pathFlag, "path", "", "Custom derivation path, e.g. m/44'/500'/0'/0/0")
+}
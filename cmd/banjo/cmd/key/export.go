@@ -0,0 +1,93 @@
+package key
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/thetatoken/ukulele/cmd/banjo/cmd/utils"
+	ks "github.com/thetatoken/ukulele/wallet/softwallet/keystore"
+)
+
+// exportCmd exports the key corresponding to the given address
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export a key",
+	Long: `Export a key as an Ethereum-compatible encrypted JSON keystore file, or print the raw hex
+private key if the --hex flag is set.`,
+	Example: `banjo key export 2E833968E5bB786Ae419c4d13189fB081Cc43bab --file=./2e833968e5bb786ae419c4d13189fb081cc43bab.json
+banjo key export 2E833968E5bB786Ae419c4d13189fB081Cc43bab --hex`,
+	Run: doExportCmd,
+}
+
+func doExportCmd(cmd *cobra.Command, args []string) {
+	if len(args) < 1 {
+		utils.ErrorWithCode(utils.ExitCodeInvalidInput, "Usage: banjo key export <address>\n")
+	}
+	address, err := utils.ParseAddress(args[0])
+	if err != nil {
+		utils.ErrorWithCode(utils.ExitCodeInvalidInput, "%v\n", err)
+	}
+
+	cfgPath := cmd.Flag("config").Value.String()
+	wallet, err := utils.OpenSoftWallet(cfgPath)
+	if err != nil {
+		utils.ErrorWithCode(utils.ExitCodeWallet, "Failed to open wallet: %v\n", err)
+	}
+
+	prompt := fmt.Sprintf("Please enter the password: ")
+	password, err := utils.GetPassword(prompt)
+	if err != nil {
+		utils.ErrorWithCode(utils.ExitCodeWallet, "Failed to get password: %v\n", err)
+	}
+
+	privKey, err := wallet.ExportKey(address, password)
+	if err != nil {
+		utils.ErrorWithCode(utils.ExitCodeWallet, "Failed to export key for address %v: %v\n", address.Hex(), err)
+	}
+
+	if hexFlag {
+		fmt.Println("WARNING: The raw private key is about to be printed in plain text. Anyone who")
+		fmt.Println("obtains it has full control over the funds of the address.")
+		fmt.Println("Are you sure to proceed? Please enter 'no' to stop or 'yes' to proceed: ")
+		confirmation, err := utils.GetConfirmation()
+		if err != nil {
+			utils.ErrorWithCode(utils.ExitCodeInvalidInput, "Failed to get confirmation: %v\n", err)
+		}
+		if strings.ToLower(confirmation) != "yes" {
+			utils.ErrorWithCode(utils.ExitCodeAborted, "Key export aborted\n")
+		}
+		fmt.Printf("%v\n", hex.EncodeToString(privKey.ToBytes()))
+		return
+	}
+
+	prompt = fmt.Sprintf("Please enter a password for the keystore file: ")
+	keyfilePassword, err := utils.GetPassword(prompt)
+	if err != nil {
+		utils.ErrorWithCode(utils.ExitCodeWallet, "Failed to get password: %v\n", err)
+	}
+
+	keyjson, err := ks.EncryptKey(ks.NewKey(privKey), keyfilePassword, viper.GetInt(utils.CfgKeystoreScryptN), viper.GetInt(utils.CfgKeystoreScryptP))
+	if err != nil {
+		utils.ErrorWithCode(utils.ExitCodeWallet, "Failed to encrypt key: %v\n", err)
+	}
+
+	if len(fileFlag) == 0 {
+		fmt.Printf("%s\n", keyjson)
+		return
+	}
+	err = ioutil.WriteFile(fileFlag, keyjson, 0600)
+	if err != nil {
+		utils.ErrorWithCode(utils.ExitCodeWallet, "Failed to write keystore file: %v\n", err)
+	}
+
+	fmt.Printf("Key for address %v has been exported to %v\n", address.Hex(), fileFlag)
+}
+
+func init() {
+	exportCmd.Flags().BoolVar(&hexFlag, "hex", false, "Print the raw hex private key instead of a keystore file")
+	exportCmd.Flags().StringVar(&fileFlag, "file", "", "Path of the exported keystore file (printed to stdout if not set)")
+}
@@ -0,0 +1,88 @@
+package key
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/thetatoken/ukulele/cmd/banjo/cmd/utils"
+	"github.com/thetatoken/ukulele/crypto"
+	ks "github.com/thetatoken/ukulele/wallet/softwallet/keystore"
+)
+
+// importCmd imports a key from an encrypted JSON keystore file or a raw hex private key
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Import a key",
+	Long: `Import a key from an Ethereum-compatible encrypted JSON keystore file, or from a raw hex private key
+if the --hex flag is set. The raw private key is read from the prompt rather than the command line.`,
+	Example: `banjo key import ./UTC--2018-11-20T02-52-18.404Z--2e833968e5bb786ae419c4d13189fb081cc43bab
+banjo key import --hex`,
+	Run: doImportCmd,
+}
+
+func doImportCmd(cmd *cobra.Command, args []string) {
+	var privKey *crypto.PrivateKey
+	if hexFlag {
+		fmt.Println("WARNING: Importing a raw private key. Make sure nobody is watching your screen,")
+		fmt.Println("and delete any other copy of the private key once the import completes.")
+
+		prompt := fmt.Sprintf("Please enter the private key in hex: ")
+		privKeyStr, err := utils.GetPassword(prompt)
+		if err != nil {
+			utils.ErrorWithCode(utils.ExitCodeWallet, "Failed to get private key: %v\n", err)
+		}
+		privKeyBytes, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(privKeyStr), "0x"))
+		if err != nil {
+			utils.ErrorWithCode(utils.ExitCodeInvalidInput, "Failed to decode private key: %v\n", err)
+		}
+		privKey, err = crypto.PrivateKeyFromBytes(privKeyBytes)
+		if err != nil {
+			utils.ErrorWithCode(utils.ExitCodeInvalidInput, "Invalid private key: %v\n", err)
+		}
+	} else {
+		if len(args) < 1 {
+			utils.ErrorWithCode(utils.ExitCodeInvalidInput, "Usage: banjo key import <keystore file>\n")
+		}
+		keyjson, err := ioutil.ReadFile(args[0])
+		if err != nil {
+			utils.ErrorWithCode(utils.ExitCodeInvalidInput, "Failed to read keystore file: %v\n", err)
+		}
+
+		prompt := fmt.Sprintf("Please enter the password of the keystore file: ")
+		keyfilePassword, err := utils.GetPassword(prompt)
+		if err != nil {
+			utils.ErrorWithCode(utils.ExitCodeWallet, "Failed to get password: %v\n", err)
+		}
+		key, err := ks.DecryptKey(keyjson, keyfilePassword)
+		if err != nil {
+			utils.ErrorWithCode(utils.ExitCodeWallet, "Failed to decrypt keystore file: %v\n", err)
+		}
+		privKey = key.PrivateKey
+	}
+
+	cfgPath := cmd.Flag("config").Value.String()
+	wallet, err := utils.OpenSoftWallet(cfgPath)
+	if err != nil {
+		utils.ErrorWithCode(utils.ExitCodeWallet, "Failed to open wallet: %v\n", err)
+	}
+
+	prompt := fmt.Sprintf("Please enter a password for the imported key: ")
+	password, err := utils.GetPassword(prompt)
+	if err != nil {
+		utils.ErrorWithCode(utils.ExitCodeWallet, "Failed to get password: %v\n", err)
+	}
+
+	address, err := wallet.ImportKey(privKey, password)
+	if err != nil {
+		utils.ErrorWithCode(utils.ExitCodeWallet, "Failed to import key: %v\n", err)
+	}
+
+	fmt.Printf("Successfully imported key: %v\n", address.Hex())
+}
+
+func init() {
+	importCmd.Flags().BoolVar(&hexFlag, "hex", false, "Import a raw hex private key instead of a keystore file")
+}
@@ -5,7 +5,7 @@ import (
 
 	"github.com/spf13/cobra"
 	"github.com/thetatoken/ukulele/cmd/banjo/cmd/utils"
-	"github.com/thetatoken/ukulele/wallet"
+	"github.com/thetatoken/ukulele/common"
 	wtypes "github.com/thetatoken/ukulele/wallet/types"
 )
 
@@ -13,22 +13,39 @@ import (
 var listCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List all keys",
-	Long:  `List all keys.`,
+	Long: `List all keys. The derivation paths of the keys derived from the seed are listed along with the addresses,
+followed by the watch-only addresses.`,
 	Example: "banjo key list",
 	Run: func(cmd *cobra.Command, args []string) {
-		cfgPath := cmd.Flag("config").Value.String()
-		wallet, err := wallet.OpenWallet(cfgPath, wtypes.WalletTypeSoft, true)
-		if err != nil {
-			utils.Error("Failed to open wallet: %v\n", err)
-		}
+		wallet := openSoftWallet(cmd)
 
 		keyAddresses, err := wallet.List()
 		if err != nil {
-			utils.Error("Failed to list keys: %v\n", err)
+			utils.ErrorWithCode(utils.ExitCodeWallet, "Failed to list keys: %v\n", err)
+		}
+		derivedAccounts, err := wallet.ListDerived()
+		if err != nil {
+			utils.ErrorWithCode(utils.ExitCodeWallet, "Failed to list derived keys: %v\n", err)
+		}
+		derivationPaths := make(map[common.Address]wtypes.DerivationPath)
+		for _, account := range derivedAccounts {
+			derivationPaths[account.Address] = account.Path
 		}
 
 		for _, keyAddress := range keyAddresses {
-			fmt.Printf("%s\n", keyAddress.Hex())
+			if derivationPath, ok := derivationPaths[keyAddress]; ok {
+				fmt.Printf("%s\t%v\n", keyAddress.Hex(), derivationPath)
+			} else {
+				fmt.Printf("%s\n", keyAddress.Hex())
+			}
+		}
+
+		watchOnlyAccounts, err := wallet.ListWatchOnly()
+		if err != nil {
+			utils.ErrorWithCode(utils.ExitCodeWallet, "Failed to list watch-only addresses: %v\n", err)
+		}
+		for _, account := range watchOnlyAccounts {
+			fmt.Printf("%s\twatch-only\t%s\n", account.Address.Hex(), account.Label)
 		}
 	},
 }
@@ -0,0 +1,52 @@
+package key
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/thetatoken/ukulele/cmd/banjo/cmd/utils"
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/wallet"
+	wtypes "github.com/thetatoken/ukulele/wallet/types"
+)
+
+// listHwCmd lists the addresses derived on the hardware wallet
+var listHwCmd = &cobra.Command{
+	Use:   "list-hw",
+	Short: "List addresses on the hardware wallet",
+	Long: `List the addresses derived on the hardware wallet for a range of account indices, so that
+one can be selected with the --index or --path flag of the tx commands.`,
+	Example: "banjo key list-hw --start=0 --count=5",
+	Run: func(cmd *cobra.Command, args []string) {
+		walletType, err := utils.ParseColdWalletType(walletFlag)
+		if err != nil {
+			utils.ErrorWithCode(utils.ExitCodeInvalidInput, "%v\n", err)
+		}
+
+		wallet, err := wallet.OpenWallet("", walletType, true)
+		if err != nil {
+			utils.ErrorWithCode(utils.ExitCodeWallet, "Failed to open wallet: %v\n", err)
+		}
+
+		err = utils.UnlockColdWallet(wallet)
+		if err != nil {
+			utils.ErrorWithCode(utils.ExitCodeWallet, "Failed to unlock wallet: %v\n", err)
+		}
+		defer wallet.Lock(common.Address{})
+
+		for index := startFlag; index < startFlag+countFlag; index++ {
+			derivationPath := wtypes.LedgerDerivationPath(index)
+			address, err := wallet.Derive(derivationPath, false)
+			if err != nil {
+				utils.ErrorWithCode(utils.ExitCodeWallet, "Failed to derive address for path %v: %v\n", derivationPath, err)
+			}
+			fmt.Printf("%d\t%v\t%v\n", index, derivationPath, address.Hex())
+		}
+	},
+}
+
+func init() {
+	listHwCmd.Flags().Uint32Var(&startFlag, "start", 0, "First account index to list")
+	listHwCmd.Flags().Uint32Var(&countFlag, "count", 5, "Number of addresses to list")
+	listHwCmd.Flags().StringVar(&walletFlag, "wallet", utils.ColdWalletNano, "Hardware wallet type (nano|trezor)")
+}
@@ -4,6 +4,20 @@ import (
 	"github.com/spf13/cobra"
 )
 
+// Common flags used in Key sub commands.
+var (
+	hexFlag     bool
+	fileFlag    string
+	startFlag   uint32
+	countFlag   uint32
+	showFlag    bool
+	pathFlag    string
+	walletFlag  string
+	labelFlag   string
+	scryptNFlag int
+	scryptPFlag int
+)
+
 // KeyCmd represents the key command
 var KeyCmd = &cobra.Command{
 	Use:   "key",
@@ -13,7 +27,19 @@ var KeyCmd = &cobra.Command{
 
 func init() {
 	KeyCmd.AddCommand(newCmd)
+	KeyCmd.AddCommand(seedCmd)
+	KeyCmd.AddCommand(recoverCmd)
+	KeyCmd.AddCommand(deriveCmd)
 	KeyCmd.AddCommand(listCmd)
+	KeyCmd.AddCommand(listHwCmd)
+	KeyCmd.AddCommand(watchCmd)
+	KeyCmd.AddCommand(unwatchCmd)
 	KeyCmd.AddCommand(deleteCmd)
 	KeyCmd.AddCommand(passwordCmd)
+	KeyCmd.AddCommand(rotateCmd)
+	KeyCmd.AddCommand(auditCmd)
+	KeyCmd.AddCommand(importCmd)
+	KeyCmd.AddCommand(exportCmd)
+	KeyCmd.AddCommand(signMessageCmd)
+	KeyCmd.AddCommand(verifyMessageCmd)
 }
@@ -5,32 +5,30 @@ import (
 
 	"github.com/spf13/cobra"
 	"github.com/thetatoken/ukulele/cmd/banjo/cmd/utils"
-	"github.com/thetatoken/ukulele/wallet"
-	wtypes "github.com/thetatoken/ukulele/wallet/types"
 )
 
 // newCmd generates a new key
 var newCmd = &cobra.Command{
 	Use:   "new",
 	Short: "Generates a new private key",
-	Long:  `Generates a new private key.`,
+	Long:  `Generates a new private key. If the wallet has a seed (see 'banjo key seed'), the key is derived from the seed at the next account index.`,
 	Example: "banjo key new",
 	Run: func(cmd *cobra.Command, args []string) {
 		cfgPath := cmd.Flag("config").Value.String()
-		wallet, err := wallet.OpenWallet(cfgPath, wtypes.WalletTypeSoft, true)
+		wallet, err := utils.OpenSoftWallet(cfgPath)
 		if err != nil {
-			utils.Error("Failed to open wallet: %v\n", err)
+			utils.ErrorWithCode(utils.ExitCodeWallet, "Failed to open wallet: %v\n", err)
 		}
 
 		prompt := fmt.Sprintf("Please enter password: ")
 		password, err := utils.GetPassword(prompt)
 		if err != nil {
-			utils.Error("Failed to get password: %v\n", err)
+			utils.ErrorWithCode(utils.ExitCodeWallet, "Failed to get password: %v\n", err)
 		}
 
 		address, err := wallet.NewKey(password)
 		if err != nil {
-			utils.Error("Failed to generate new key: %v\n", err)
+			utils.ErrorWithCode(utils.ExitCodeWallet, "Failed to generate new key: %v\n", err)
 		}
 
 		fmt.Printf("Successfully created key: %v\n", address.Hex())
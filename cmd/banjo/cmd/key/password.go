@@ -5,9 +5,6 @@ import (
 
 	"github.com/spf13/cobra"
 	"github.com/thetatoken/ukulele/cmd/banjo/cmd/utils"
-	"github.com/thetatoken/ukulele/common"
-	"github.com/thetatoken/ukulele/wallet"
-	wtypes "github.com/thetatoken/ukulele/wallet/types"
 )
 
 // passwordCmd updates the password for the key corresponding to the given address
@@ -18,31 +15,34 @@ var passwordCmd = &cobra.Command{
 	Example: "banjo key password 1d8E1191E0a97C1aDa4940B79188D3B1f6f5C695",
 	Run: func(cmd *cobra.Command, args []string) {
 		if len(args) < 1 {
-			utils.Error("Usage: banjo key password <address>\n")
+			utils.ErrorWithCode(utils.ExitCodeInvalidInput, "Usage: banjo key password <address>\n")
+		}
+		address, err := utils.ParseAddress(args[0])
+		if err != nil {
+			utils.ErrorWithCode(utils.ExitCodeInvalidInput, "%v\n", err)
 		}
-		address := common.HexToAddress(args[0])
 
 		cfgPath := cmd.Flag("config").Value.String()
-		wallet, err := wallet.OpenWallet(cfgPath, wtypes.WalletTypeSoft, true)
+		wallet, err := utils.OpenSoftWallet(cfgPath)
 		if err != nil {
-			utils.Error("Failed to open wallet: %v\n", err)
+			utils.ErrorWithCode(utils.ExitCodeWallet, "Failed to open wallet: %v\n", err)
 		}
 
 		prompt := fmt.Sprintf("Please enter the current password: ")
 		oldPassword, err := utils.GetPassword(prompt)
 		if err != nil {
-			utils.Error("Failed to get password: %v\n", err)
+			utils.ErrorWithCode(utils.ExitCodeWallet, "Failed to get password: %v\n", err)
 		}
 
 		prompt = fmt.Sprintf("Please enter a new password: ")
 		newPassword, err := utils.GetPassword(prompt)
 		if err != nil {
-			utils.Error("Failed to get password: %v\n", err)
+			utils.ErrorWithCode(utils.ExitCodeWallet, "Failed to get password: %v\n", err)
 		}
 
 		err = wallet.UpdatePassword(address, oldPassword, newPassword)
 		if err != nil {
-			utils.Error("Failed to update password: %v\n", err)
+			utils.ErrorWithCode(utils.ExitCodeWallet, "Failed to update password: %v\n", err)
 		}
 
 		fmt.Printf("Password updated successfully\n")
@@ -4,14 +4,50 @@ import (
 	"fmt"
 
 	"github.com/spf13/cobra"
+	"github.com/thetatoken/ukulele/cmd/banjo/cmd/utils"
+	wtypes "github.com/thetatoken/ukulele/wallet/types"
 )
 
-// recoverCmd recovers the key from the given seed phrase
+// recoverCmd recovers the keys from the given seed phrase
 var recoverCmd = &cobra.Command{
 	Use:   "recover",
-	Short: "Recover a key from seed phrase",
-	Long:  `Recover a key from seed phrase.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Println("key recover not supported yet")
-	},
+	Short: "Recover keys from seed phrase",
+	Long: `Restore the HD seed of the wallet from a seed phrase (mnemonic), and derive the keys at the
+first --count indices of the Theta derivation path m/44'/500'/0'/0/index.`,
+	Example: "banjo key recover --count=3",
+	Run:     doRecoverCmd,
+}
+
+func doRecoverCmd(cmd *cobra.Command, args []string) {
+	wallet := openSoftWallet(cmd)
+
+	prompt := fmt.Sprintf("Please enter the seed phrase: ")
+	mnemonic, err := utils.GetPassword(prompt)
+	if err != nil {
+		utils.ErrorWithCode(utils.ExitCodeWallet, "Failed to get seed phrase: %v\n", err)
+	}
+
+	prompt = fmt.Sprintf("Please enter password: ")
+	password, err := utils.GetPassword(prompt)
+	if err != nil {
+		utils.ErrorWithCode(utils.ExitCodeWallet, "Failed to get password: %v\n", err)
+	}
+
+	err = wallet.RestoreSeed(mnemonic, password)
+	if err != nil {
+		utils.ErrorWithCode(utils.ExitCodeWallet, "Failed to restore seed: %v\n", err)
+	}
+
+	for index := uint32(0); index < countFlag; index++ {
+		derivationPath := wtypes.ThetaDerivationPath(index)
+		address, err := wallet.DeriveKey(derivationPath, password)
+		if err != nil {
+			utils.ErrorWithCode(utils.ExitCodeWallet, "Failed to derive key for path %v: %v\n", derivationPath, err)
+		}
+		fmt.Printf("Successfully recovered key: %v\t%v\n", address.Hex(), derivationPath)
+	}
+}
+
+func init() {
+	recoverCmd.Flags().Uint32Var(&countFlag, "count", 1, "Number of keys to derive")
 }
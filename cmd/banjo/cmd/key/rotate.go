@@ -0,0 +1,101 @@
+package key
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/thetatoken/ukulele/cmd/banjo/cmd/utils"
+)
+
+// rotateCmd re-encrypts all the keys and the seed with a new password and/or KDF parameters
+var rotateCmd = &cobra.Command{
+	Use:   "rotate",
+	Short: "Re-encrypt all keys with a new password or KDF parameters",
+	Long: `Re-encrypt all keys and the seed with a new password, and/or with the scrypt parameters given by
+--scrypt_n and --scrypt_p, which default to keystore.scryptN and keystore.scryptP of the config. All the
+keys need to be under the current password. They are re-encrypted to temporary files which replace the
+key files only after all of them succeed, so the keystore is left unchanged on failure. To keep stronger
+parameters for the keys saved afterwards, update the config accordingly.`,
+	Example: "banjo key rotate --scrypt_n=524288",
+	Run: func(cmd *cobra.Command, args []string) {
+		scryptN, scryptP := viper.GetInt(utils.CfgKeystoreScryptN), viper.GetInt(utils.CfgKeystoreScryptP)
+		if cmd.Flags().Changed("scrypt_n") {
+			scryptN = scryptNFlag
+		}
+		if cmd.Flags().Changed("scrypt_p") {
+			scryptP = scryptPFlag
+		}
+
+		wallet := openSoftWallet(cmd)
+
+		prompt := fmt.Sprintf("Please enter the current password: ")
+		password, err := utils.GetPassword(prompt)
+		if err != nil {
+			utils.ErrorWithCode(utils.ExitCodeWallet, "Failed to get password: %v\n", err)
+		}
+
+		prompt = fmt.Sprintf("Please enter a new password (leave empty to keep the current one): ")
+		newPassword, err := utils.GetPassword(prompt)
+		if err != nil {
+			utils.ErrorWithCode(utils.ExitCodeWallet, "Failed to get password: %v\n", err)
+		}
+		if len(newPassword) == 0 {
+			newPassword = password
+		} else {
+			prompt = fmt.Sprintf("Please enter the new password again: ")
+			confirmedPassword, err := utils.GetPassword(prompt)
+			if err != nil {
+				utils.ErrorWithCode(utils.ExitCodeWallet, "Failed to get password: %v\n", err)
+			}
+			if confirmedPassword != newPassword {
+				utils.ErrorWithCode(utils.ExitCodeInvalidInput, "Passwords do not match\n")
+			}
+		}
+
+		addresses, err := wallet.Rotate(password, newPassword, scryptN, scryptP)
+		if err != nil {
+			utils.ErrorWithCode(utils.ExitCodeWallet, "Failed to rotate keys: %v\n", err)
+		}
+
+		for _, address := range addresses {
+			fmt.Printf("%s\n", address.Hex())
+		}
+		fmt.Printf("Re-encrypted %v keys with scrypt N = %v, P = %v\n", len(addresses), scryptN, scryptP)
+	},
+}
+
+// auditCmd lists the metadata of the keys
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "List when the keys were created, last used and rotated",
+	Long: `List when the keys were created, imported or derived, when they last signed, and when they were last
+re-encrypted with a new password or KDF parameters. The times are in UTC, and "-" if not recorded.`,
+	Example: "banjo key audit",
+	Run: func(cmd *cobra.Command, args []string) {
+		wallet := openSoftWallet(cmd)
+
+		metadata, err := wallet.ListKeyMetadata()
+		if err != nil {
+			utils.ErrorWithCode(utils.ExitCodeWallet, "Failed to list key metadata: %v\n", err)
+		}
+
+		fmt.Printf("Address\tCreated\tLast used\tRotated\n")
+		for _, meta := range metadata {
+			fmt.Printf("%s\t%s\t%s\t%s\n", meta.Address.Hex(), formatTime(meta.Created), formatTime(meta.LastUsed), formatTime(meta.Rotated))
+		}
+	},
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func init() {
+	rotateCmd.Flags().IntVar(&scryptNFlag, "scrypt_n", 0, "Scrypt parameter N, a power of 2 (default: keystore.scryptN of the config)")
+	rotateCmd.Flags().IntVar(&scryptPFlag, "scrypt_p", 0, "Scrypt parameter P (default: keystore.scryptP of the config)")
+}
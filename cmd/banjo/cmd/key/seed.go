@@ -0,0 +1,89 @@
+package key
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/thetatoken/ukulele/cmd/banjo/cmd/utils"
+	sw "github.com/thetatoken/ukulele/wallet/softwallet"
+)
+
+// seedCmd generates the HD seed of the wallet
+var seedCmd = &cobra.Command{
+	Use:   "seed",
+	Short: "Generate a seed phrase",
+	Long: `Generate a 24-word seed phrase (mnemonic) as the HD seed of the wallet, and derive the first key
+from it. Once the seed is generated, new keys are derived from it. With the --show flag, the seed
+phrase of the wallet is displayed instead.`,
+	Example: `banjo key seed
+banjo key seed --show`,
+	Run: doSeedCmd,
+}
+
+func doSeedCmd(cmd *cobra.Command, args []string) {
+	wallet := openSoftWallet(cmd)
+
+	if showFlag {
+		prompt := fmt.Sprintf("Please enter the password of the seed: ")
+		password, err := utils.GetPassword(prompt)
+		if err != nil {
+			utils.ErrorWithCode(utils.ExitCodeWallet, "Failed to get password: %v\n", err)
+		}
+		mnemonic, err := wallet.ExportSeed(password)
+		if err != nil {
+			utils.ErrorWithCode(utils.ExitCodeWallet, "Failed to export seed: %v\n", err)
+		}
+
+		fmt.Println("WARNING: The seed phrase is about to be printed in plain text. Anyone who")
+		fmt.Println("obtains it has full control over the funds of all the derived addresses.")
+		fmt.Println("Are you sure to proceed? Please enter 'no' to stop or 'yes' to proceed: ")
+		confirmation, err := utils.GetConfirmation()
+		if err != nil {
+			utils.ErrorWithCode(utils.ExitCodeInvalidInput, "Failed to get confirmation: %v\n", err)
+		}
+		if strings.ToLower(confirmation) != "yes" {
+			utils.ErrorWithCode(utils.ExitCodeAborted, "Seed export aborted\n")
+		}
+		fmt.Printf("%v\n", mnemonic)
+		return
+	}
+
+	if wallet.HasSeed() {
+		utils.ErrorWithCode(utils.ExitCodeWallet, "The wallet already has a seed, use 'banjo key seed --show' to display it\n")
+	}
+
+	prompt := fmt.Sprintf("Please enter password: ")
+	password, err := utils.GetPassword(prompt)
+	if err != nil {
+		utils.ErrorWithCode(utils.ExitCodeWallet, "Failed to get password: %v\n", err)
+	}
+
+	mnemonic, err := wallet.NewSeed(password)
+	if err != nil {
+		utils.ErrorWithCode(utils.ExitCodeWallet, "Failed to generate seed: %v\n", err)
+	}
+	address, err := wallet.NewKey(password)
+	if err != nil {
+		utils.ErrorWithCode(utils.ExitCodeWallet, "Failed to derive key: %v\n", err)
+	}
+
+	fmt.Println("Please write down the seed phrase below and keep it in a safe place. It is the only")
+	fmt.Println("way to recover the keys if the wallet is lost:")
+	fmt.Printf("\n%v\n\n", mnemonic)
+	fmt.Printf("Successfully created key: %v\n", address.Hex())
+}
+
+// openSoftWallet opens the encrypted soft wallet
+func openSoftWallet(cmd *cobra.Command) *sw.SoftWallet {
+	cfgPath := cmd.Flag("config").Value.String()
+	wallet, err := utils.OpenSoftWallet(cfgPath)
+	if err != nil {
+		utils.ErrorWithCode(utils.ExitCodeWallet, "Failed to open wallet: %v\n", err)
+	}
+	return wallet
+}
+
+func init() {
+	seedCmd.Flags().BoolVar(&showFlag, "show", false, "Display the seed phrase of the wallet")
+}
@@ -0,0 +1,110 @@
+package key
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/thetatoken/ukulele/cmd/banjo/cmd/utils"
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/crypto"
+)
+
+// signMessageCmd signs an arbitrary message with the key corresponding to the given address
+var signMessageCmd = &cobra.Command{
+	Use:   "sign-message",
+	Short: "Sign an arbitrary message",
+	Long: `Sign an arbitrary message to prove the ownership of an address. The message is prefixed with
+a domain separator before signing, so the signature can never be used as a transaction signature.`,
+	Example: `banjo key sign-message 2E833968E5bB786Ae419c4d13189fB081Cc43bab "login nonce 8a7b6e"`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 2 {
+			utils.ErrorWithCode(utils.ExitCodeInvalidInput, "Usage: banjo key sign-message <address> <message>\n")
+		}
+		address, err := utils.ParseAddress(args[0])
+		if err != nil {
+			utils.ErrorWithCode(utils.ExitCodeInvalidInput, "%v\n", err)
+		}
+		msg, err := parseMessage(args[1])
+		if err != nil {
+			utils.ErrorWithCode(utils.ExitCodeInvalidInput, "Failed to parse message: %v\n", err)
+		}
+
+		cfgPath := cmd.Flag("config").Value.String()
+		wallet, err := utils.OpenSoftWallet(cfgPath)
+		if err != nil {
+			utils.ErrorWithCode(utils.ExitCodeWallet, "Failed to open wallet: %v\n", err)
+		}
+
+		prompt := fmt.Sprintf("Please enter password: ")
+		password, err := utils.GetPassword(prompt)
+		if err != nil {
+			utils.ErrorWithCode(utils.ExitCodeWallet, "Failed to get password: %v\n", err)
+		}
+
+		err = wallet.Unlock(address, password)
+		if err != nil {
+			utils.ErrorWithCode(utils.ExitCodeWallet, "Failed to unlock address %v: %v\n", address.Hex(), err)
+		}
+		defer wallet.Lock(address)
+
+		sig, err := wallet.Sign(address, crypto.MessageSignBytes(msg))
+		if err != nil {
+			utils.ErrorWithCode(utils.ExitCodeWallet, "Failed to sign message: %v\n", err)
+		}
+
+		fmt.Printf("%v\n", hex.EncodeToString(sig.ToBytes()))
+	},
+}
+
+// verifyMessageCmd verifies that a message was signed by the given address
+var verifyMessageCmd = &cobra.Command{
+	Use:     "verify-message",
+	Short:   "Verify the signature of an arbitrary message",
+	Long:    `Verify that the signature of an arbitrary message was produced by the given address.`,
+	Example: `banjo key verify-message 2E833968E5bB786Ae419c4d13189fB081Cc43bab "login nonce 8a7b6e" 5f2ed0cb...01`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 3 {
+			utils.ErrorWithCode(utils.ExitCodeInvalidInput, "Usage: banjo key verify-message <address> <message> <signature>\n")
+		}
+		address, err := utils.ParseAddress(args[0])
+		if err != nil {
+			utils.ErrorWithCode(utils.ExitCodeInvalidInput, "%v\n", err)
+		}
+		msg, err := parseMessage(args[1])
+		if err != nil {
+			utils.ErrorWithCode(utils.ExitCodeInvalidInput, "Failed to parse message: %v\n", err)
+		}
+		sigBytes, err := hex.DecodeString(strings.TrimPrefix(args[2], "0x"))
+		if err != nil {
+			utils.ErrorWithCode(utils.ExitCodeInvalidInput, "Failed to decode signature: %v\n", err)
+		}
+		sig, err := crypto.SignatureFromBytes(sigBytes)
+		if err != nil {
+			utils.ErrorWithCode(utils.ExitCodeInvalidInput, "Failed to decode signature: %v\n", err)
+		}
+
+		signer, err := sig.RecoverSignerAddress(crypto.MessageSignBytes(msg))
+		if err != nil {
+			utils.ErrorWithCode(utils.ExitCodeVerification, "Failed to recover signer: %v\n", err)
+		}
+		if signer != address {
+			utils.ErrorWithCode(utils.ExitCodeVerification, "Invalid signature, message was signed by %v\n", signer.Hex())
+		}
+
+		fmt.Printf("Valid signature from %v\n", address.Hex())
+	},
+}
+
+func parseMessage(msgStr string) (common.Bytes, error) {
+	if !hexFlag {
+		return common.Bytes(msgStr), nil
+	}
+	return hex.DecodeString(strings.TrimPrefix(msgStr, "0x"))
+}
+
+func init() {
+	signMessageCmd.Flags().BoolVar(&hexFlag, "hex", false, "Interpret the message as hex encoded bytes")
+	verifyMessageCmd.Flags().BoolVar(&hexFlag, "hex", false, "Interpret the message as hex encoded bytes")
+}
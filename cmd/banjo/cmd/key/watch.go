@@ -0,0 +1,64 @@
+package key
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/thetatoken/ukulele/cmd/banjo/cmd/utils"
+)
+
+// watchCmd adds a watch-only address to the wallet
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Add a watch-only address",
+	Long: `Add a watch-only address to the wallet. The wallet tracks the address without its private key,
+e.g. an address of a hardware wallet, so that the query commands include it. Transactions of
+the address need to be signed with the wallet holding its key.`,
+	Example: `banjo key watch 2E833968E5bB786Ae419c4d13189fB081Cc43bab --label="cold storage"`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 1 {
+			utils.ErrorWithCode(utils.ExitCodeInvalidInput, "Usage: banjo key watch <address>\n")
+		}
+		address, err := utils.ParseAddress(args[0])
+		if err != nil {
+			utils.ErrorWithCode(utils.ExitCodeInvalidInput, "%v\n", err)
+		}
+
+		wallet := openSoftWallet(cmd)
+		err = wallet.AddWatchOnly(address, labelFlag)
+		if err != nil {
+			utils.ErrorWithCode(utils.ExitCodeWallet, "Failed to watch address %v: %v\n", address.Hex(), err)
+		}
+
+		fmt.Printf("Watching address %v\n", address.Hex())
+	},
+}
+
+// unwatchCmd removes a watch-only address from the wallet
+var unwatchCmd = &cobra.Command{
+	Use:     "unwatch",
+	Short:   "Remove a watch-only address",
+	Long:    `Remove a watch-only address from the wallet.`,
+	Example: "banjo key unwatch 2E833968E5bB786Ae419c4d13189fB081Cc43bab",
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 1 {
+			utils.ErrorWithCode(utils.ExitCodeInvalidInput, "Usage: banjo key unwatch <address>\n")
+		}
+		address, err := utils.ParseAddress(args[0])
+		if err != nil {
+			utils.ErrorWithCode(utils.ExitCodeInvalidInput, "%v\n", err)
+		}
+
+		wallet := openSoftWallet(cmd)
+		err = wallet.RemoveWatchOnly(address)
+		if err != nil {
+			utils.ErrorWithCode(utils.ExitCodeWallet, "Failed to unwatch address %v: %v\n", address.Hex(), err)
+		}
+
+		fmt.Printf("Address %v is no longer watched\n", address.Hex())
+	},
+}
+
+func init() {
+	watchCmd.Flags().StringVar(&labelFlag, "label", "", "Label of the address")
+}
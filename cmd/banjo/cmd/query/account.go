@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/thetatoken/ukulele/cmd/banjo/cmd/utils"
+	"github.com/thetatoken/ukulele/common"
 	"github.com/thetatoken/ukulele/rpc"
 
 	"github.com/spf13/cobra"
@@ -14,11 +15,15 @@ import (
 
 var (
 	addressFlag string
+	stateFlag   string
+	heightFlag  uint64
 )
 
 // accountCmd represents the account command.
 // Example:
 //		banjo query account --address=0x2E833968E5bB786Ae419c4d13189fB081Cc43bab
+//		banjo query account --address=0x2E833968E5bB786Ae419c4d13189fB081Cc43bab --state=finalized
+//		banjo query account --address=0x2E833968E5bB786Ae419c4d13189fB081Cc43bab --height=1000
 var accountCmd = &cobra.Command{
 	Use:   "account",
 	Short: "Get account status",
@@ -28,23 +33,55 @@ var accountCmd = &cobra.Command{
 }
 
 func doAccountCmd(cmd *cobra.Command, args []string) {
+	address, err := utils.ParseAddress(addressFlag)
+	if err != nil {
+		utils.ErrorWithCode(utils.ExitCodeInvalidInput, "%v\n", err)
+	}
+
+	if utils.LightModeEnabled() {
+		printVerifiedAccount(cmd, address)
+		return
+	}
+
 	client := rpcc.NewRPCClient(viper.GetString(utils.CfgRemoteRPCEndpoint))
 
-	res, err := client.Call("theta.GetAccount", rpc.GetAccountArgs{Address: addressFlag})
+	res, err := client.Call("theta.GetAccount", rpc.GetAccountArgs{Address: address.Hex(), State: stateFlag, Height: common.JSONUint64(heightFlag)})
 	if err != nil {
-		utils.Error("Failed to get account details: %v\n", err)
+		utils.ErrorWithCode(utils.ExitCodeRPC, "Failed to get account details: %v\n", err)
 	}
 	if res.Error != nil {
-		utils.Error("Failed to get account details: %v\n", res.Error)
+		utils.ErrorWithCode(utils.ExitCodeServer, "Failed to get account details: %v\n", res.Error)
 	}
 	json, err := json.MarshalIndent(res.Result, "", "    ")
 	if err != nil {
-		utils.Error("Failed to parse server response: %v\n%v\n", err, string(json))
+		utils.ErrorWithCode(utils.ExitCodeRPC, "Failed to parse server response: %v\n%v\n", err, string(json))
+	}
+	fmt.Println(string(json))
+}
+
+// printVerifiedAccount prints the account after verifying its proof with a light client.
+func printVerifiedAccount(cmd *cobra.Command, address common.Address) {
+	lc, err := utils.NewLightClient(cmd.Flag("config").Value.String())
+	if err != nil {
+		utils.ErrorWithCode(utils.ExitCodeInvalidInput, "%v\n", err)
+	}
+	account, err := lc.GetAccount(address)
+	if err != nil {
+		utils.ErrorWithCode(utils.LightErrorCode(err), "Failed to get verified account details: %v\n", err)
+	}
+	if account == nil {
+		utils.ErrorWithCode(utils.ExitCodeServer, "Account with address %s is not found\n", address.Hex())
+	}
+	json, err := json.MarshalIndent(rpc.GetAccountResult{Account: account, Address: address.Hex()}, "", "    ")
+	if err != nil {
+		utils.ErrorWithCode(utils.ExitCodeGeneric, "Failed to encode account: %v\n", err)
 	}
 	fmt.Println(string(json))
 }
 
 func init() {
 	accountCmd.Flags().StringVar(&addressFlag, "address", "", "Address of the account")
+	accountCmd.Flags().StringVar(&stateFlag, "state", rpc.LedgerStatePending, "Ledger state to query: pending, latest or finalized")
+	accountCmd.Flags().Uint64Var(&heightFlag, "height", 0, "Height of a finalized block to query the account at, instead of the state")
 	accountCmd.MarkFlagRequired("address")
 }
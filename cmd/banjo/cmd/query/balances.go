@@ -0,0 +1,91 @@
+package query
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/thetatoken/ukulele/cmd/banjo/cmd/utils"
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/ledger/types"
+	"github.com/thetatoken/ukulele/rpc"
+	"github.com/thetatoken/ukulele/wallet/preview"
+
+	rpcc "github.com/ybbus/jsonrpc"
+)
+
+// balancesCmd represents the balances command.
+// Example:
+//		banjo query balances
+var balancesCmd = &cobra.Command{
+	Use:   "balances",
+	Short: "Get the balances of the wallet addresses",
+	Long: `Get the balances of the addresses of the keys in the wallet, as well as the balances
+of the watch-only addresses.`,
+	Example: `banjo query balances`,
+	Run:     doBalancesCmd,
+}
+
+func init() {
+	balancesCmd.Flags().StringVar(&stateFlag, "state", rpc.LedgerStatePending, "Ledger state to query: pending, latest or finalized")
+}
+
+func doBalancesCmd(cmd *cobra.Command, args []string) {
+	cfgPath := cmd.Flag("config").Value.String()
+	wallet, err := utils.OpenSoftWallet(cfgPath)
+	if err != nil {
+		utils.ErrorWithCode(utils.ExitCodeWallet, "Failed to open wallet: %v\n", err)
+	}
+	keyAddresses, err := wallet.List()
+	if err != nil {
+		utils.ErrorWithCode(utils.ExitCodeWallet, "Failed to list keys: %v\n", err)
+	}
+	watchOnlyAccounts, err := wallet.ListWatchOnly()
+	if err != nil {
+		utils.ErrorWithCode(utils.ExitCodeWallet, "Failed to list watch-only addresses: %v\n", err)
+	}
+
+	client := rpcc.NewRPCClient(viper.GetString(utils.CfgRemoteRPCEndpoint))
+	getAccount := func(address common.Address) *types.Account {
+		res, err := client.Call("theta.GetAccount", rpc.GetAccountArgs{Address: address.Hex(), State: stateFlag})
+		if err != nil {
+			utils.ErrorWithCode(utils.ExitCodeRPC, "Failed to get account details: %v\n", err)
+		}
+		if res.Error != nil {
+			return nil
+		}
+		account := &types.Account{}
+		err = res.GetObject(account)
+		if err != nil {
+			utils.ErrorWithCode(utils.ExitCodeRPC, "Failed to parse server response: %v\n", err)
+		}
+		return account
+	}
+	if utils.LightModeEnabled() {
+		lc, err := utils.NewLightClient(cfgPath)
+		if err != nil {
+			utils.ErrorWithCode(utils.ExitCodeInvalidInput, "%v\n", err)
+		}
+		getAccount = func(address common.Address) *types.Account {
+			account, err := lc.GetAccount(address)
+			if err != nil {
+				utils.ErrorWithCode(utils.LightErrorCode(err), "Failed to get verified account details: %v\n", err)
+			}
+			return account
+		}
+	}
+	printBalance := func(address common.Address, note string) {
+		balance := "(account not found)"
+		if account := getAccount(address); account != nil {
+			balance = preview.FormatCoins(account.Balance)
+		}
+		fmt.Printf("%s\t%s\t%s\n", address.Hex(), balance, note)
+	}
+
+	for _, address := range keyAddresses {
+		printBalance(address, "")
+	}
+	for _, account := range watchOnlyAccounts {
+		printBalance(account.Address, fmt.Sprintf("watch-only\t%v", account.Label))
+	}
+}
@@ -12,5 +12,11 @@ var QueryCmd = &cobra.Command{
 
 func init() {
 	QueryCmd.AddCommand(accountCmd)
+	QueryCmd.AddCommand(balancesCmd)
+	QueryCmd.AddCommand(proposalsCmd)
 	QueryCmd.AddCommand(splitRuleCmd)
+	QueryCmd.AddCommand(stakesCmd)
+	QueryCmd.AddCommand(tokensCmd)
+	QueryCmd.AddCommand(txCmd)
+	QueryCmd.AddCommand(validatorsCmd)
 }
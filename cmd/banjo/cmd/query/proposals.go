@@ -0,0 +1,51 @@
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/thetatoken/ukulele/cmd/banjo/cmd/utils"
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/rpc"
+
+	rpcc "github.com/ybbus/jsonrpc"
+)
+
+var (
+	proposalIDFlag uint64
+	allFlag        bool
+)
+
+// proposalsCmd represents the proposals command.
+// Example:
+//		banjo query proposals --id=1
+var proposalsCmd = &cobra.Command{
+	Use:     "proposals",
+	Short:   "Get the governance proposals and their tallies",
+	Example: `banjo query proposals --id=1`,
+	Run:     doProposalsCmd,
+}
+
+func doProposalsCmd(cmd *cobra.Command, args []string) {
+	client := rpcc.NewRPCClient(viper.GetString(utils.CfgRemoteRPCEndpoint))
+
+	res, err := client.Call("theta.GetProposals", rpc.GetProposalsArgs{ID: common.JSONUint64(proposalIDFlag), All: allFlag})
+	if err != nil {
+		utils.ErrorWithCode(utils.ExitCodeRPC, "Failed to get proposals: %v\n", err)
+	}
+	if res.Error != nil {
+		utils.ErrorWithCode(utils.ExitCodeServer, "Failed to get proposals: %v\n", res.Error)
+	}
+	json, err := json.MarshalIndent(res.Result, "", "    ")
+	if err != nil {
+		utils.ErrorWithCode(utils.ExitCodeRPC, "Failed to parse server response: %v\n%s\n", err, string(json))
+	}
+	fmt.Println(string(json))
+}
+
+func init() {
+	proposalsCmd.Flags().Uint64Var(&proposalIDFlag, "id", 0, "ID of the proposal, the active proposals if not set")
+	proposalsCmd.Flags().BoolVar(&allFlag, "all", false, "Also get the tallied proposals")
+}
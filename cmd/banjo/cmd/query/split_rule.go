@@ -34,14 +34,14 @@ func doSplitRuleCmd(cmd *cobra.Command, args []string) {
 	resourceID := hex.EncodeToString(common.Bytes(resourceIDFlag))
 	res, err := client.Call("theta.GetSplitRule", rpc.GetSplitRuleArgs{ResourceID: resourceID})
 	if err != nil {
-		utils.Error("Failed to get split rule details: %v\n", err)
+		utils.ErrorWithCode(utils.ExitCodeRPC, "Failed to get split rule details: %v\n", err)
 	}
 	if res.Error != nil {
-		utils.Error("Failed to get split rule details: %v\n", res.Error)
+		utils.ErrorWithCode(utils.ExitCodeServer, "Failed to get split rule details: %v\n", res.Error)
 	}
 	json, err := json.MarshalIndent(res.Result, "", "    ")
 	if err != nil {
-		utils.Error("Failed to parse server response: %v\n%s\n", err, string(json))
+		utils.ErrorWithCode(utils.ExitCodeRPC, "Failed to parse server response: %v\n%s\n", err, string(json))
 	}
 	fmt.Println(string(json))
 }
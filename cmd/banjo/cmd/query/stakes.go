@@ -0,0 +1,95 @@
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/thetatoken/ukulele/cmd/banjo/cmd/utils"
+	"github.com/thetatoken/ukulele/rpc"
+
+	rpcc "github.com/ybbus/jsonrpc"
+)
+
+var (
+	holderFlag string
+	sourceFlag string
+)
+
+// stakesCmd represents the stakes command.
+// Example:
+//		banjo query stakes --holder=0x9F1233798E905E173560071255140b4A8aBd3Ec6
+var stakesCmd = &cobra.Command{
+	Use:   "stakes",
+	Short: "Get stakes deposited to validators",
+	Long:  `Get stakes deposited to validators, optionally filtered by stake holder and/or source address.`,
+	Example: `banjo query stakes --holder=0x9F1233798E905E173560071255140b4A8aBd3Ec6
+banjo query stakes --source=0x2E833968E5bB786Ae419c4d13189fB081Cc43bab`,
+	Run: doStakesCmd,
+}
+
+func doStakesCmd(cmd *cobra.Command, args []string) {
+	stakesArgs := rpc.GetStakesArgs{}
+	if len(holderFlag) != 0 {
+		holder, err := utils.ParseAddress(holderFlag)
+		if err != nil {
+			utils.ErrorWithCode(utils.ExitCodeInvalidInput, "%v\n", err)
+		}
+		stakesArgs.Holder = holder.Hex()
+	}
+	if len(sourceFlag) != 0 {
+		source, err := utils.ParseAddress(sourceFlag)
+		if err != nil {
+			utils.ErrorWithCode(utils.ExitCodeInvalidInput, "%v\n", err)
+		}
+		stakesArgs.Source = source.Hex()
+	}
+
+	client := rpcc.NewRPCClient(viper.GetString(utils.CfgRemoteRPCEndpoint))
+
+	res, err := client.Call("theta.GetStakes", stakesArgs)
+	if err != nil {
+		utils.ErrorWithCode(utils.ExitCodeRPC, "Failed to get stakes: %v\n", err)
+	}
+	if res.Error != nil {
+		utils.ErrorWithCode(utils.ExitCodeServer, "Failed to get stakes: %v\n", res.Error)
+	}
+	json, err := json.MarshalIndent(res.Result, "", "    ")
+	if err != nil {
+		utils.ErrorWithCode(utils.ExitCodeRPC, "Failed to parse server response: %v\n%v\n", err, string(json))
+	}
+	fmt.Println(string(json))
+}
+
+// validatorsCmd represents the validators command.
+// Example:
+//		banjo query validators
+var validatorsCmd = &cobra.Command{
+	Use:     "validators",
+	Short:   "Get the validator set of the current epoch",
+	Example: `banjo query validators`,
+	Run:     doValidatorsCmd,
+}
+
+func doValidatorsCmd(cmd *cobra.Command, args []string) {
+	client := rpcc.NewRPCClient(viper.GetString(utils.CfgRemoteRPCEndpoint))
+
+	res, err := client.Call("theta.GetValidators", rpc.GetValidatorsArgs{})
+	if err != nil {
+		utils.ErrorWithCode(utils.ExitCodeRPC, "Failed to get validators: %v\n", err)
+	}
+	if res.Error != nil {
+		utils.ErrorWithCode(utils.ExitCodeServer, "Failed to get validators: %v\n", res.Error)
+	}
+	json, err := json.MarshalIndent(res.Result, "", "    ")
+	if err != nil {
+		utils.ErrorWithCode(utils.ExitCodeRPC, "Failed to parse server response: %v\n%v\n", err, string(json))
+	}
+	fmt.Println(string(json))
+}
+
+func init() {
+	stakesCmd.Flags().StringVar(&holderFlag, "holder", "", "Address of the stake holder")
+	stakesCmd.Flags().StringVar(&sourceFlag, "source", "", "Address the stakes were deposited from")
+}
@@ -0,0 +1,48 @@
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/thetatoken/ukulele/cmd/banjo/cmd/utils"
+	"github.com/thetatoken/ukulele/rpc"
+
+	rpcc "github.com/ybbus/jsonrpc"
+)
+
+var (
+	symbolFlag string
+)
+
+// tokensCmd represents the tokens command.
+// Example:
+//		banjo query tokens --symbol=ABC
+var tokensCmd = &cobra.Command{
+	Use:     "tokens",
+	Short:   "Get the application tokens in the token registry",
+	Example: `banjo query tokens --symbol=ABC`,
+	Run:     doTokensCmd,
+}
+
+func doTokensCmd(cmd *cobra.Command, args []string) {
+	client := rpcc.NewRPCClient(viper.GetString(utils.CfgRemoteRPCEndpoint))
+
+	res, err := client.Call("theta.GetTokens", rpc.GetTokensArgs{Symbol: symbolFlag})
+	if err != nil {
+		utils.ErrorWithCode(utils.ExitCodeRPC, "Failed to get tokens: %v\n", err)
+	}
+	if res.Error != nil {
+		utils.ErrorWithCode(utils.ExitCodeServer, "Failed to get tokens: %v\n", res.Error)
+	}
+	json, err := json.MarshalIndent(res.Result, "", "    ")
+	if err != nil {
+		utils.ErrorWithCode(utils.ExitCodeRPC, "Failed to parse server response: %v\n%s\n", err, string(json))
+	}
+	fmt.Println(string(json))
+}
+
+func init() {
+	tokensCmd.Flags().StringVar(&symbolFlag, "symbol", "", "Symbol of the token, all the tokens if not set")
+}
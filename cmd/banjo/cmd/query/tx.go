@@ -0,0 +1,67 @@
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/thetatoken/ukulele/cmd/banjo/cmd/utils"
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/rpc"
+
+	rpcc "github.com/ybbus/jsonrpc"
+)
+
+var (
+	txHashFlag string
+)
+
+// txCmd represents the tx command.
+// Example:
+//		banjo query tx --hash=0xe3e6e5a3a4ef8fc7e3d5c4d1b0c1e5f8e9a1d6b2c7f3a8e4d9b5c0a6f1e2d3c4
+var txCmd = &cobra.Command{
+	Use:   "tx",
+	Short: "Get transaction status",
+	Long: `Get the status of a transaction, and the block including it. In light mode, the
+inclusion of the transaction in a finalized block is verified.`,
+	Example: `banjo query tx --hash=0xe3e6e5a3a4ef8fc7e3d5c4d1b0c1e5f8e9a1d6b2c7f3a8e4d9b5c0a6f1e2d3c4`,
+	Run:     doTxCmd,
+}
+
+func doTxCmd(cmd *cobra.Command, args []string) {
+	hash := common.HexToHash(txHashFlag)
+
+	var result interface{}
+	if utils.LightModeEnabled() {
+		lc, err := utils.NewLightClient(cmd.Flag("config").Value.String())
+		if err != nil {
+			utils.ErrorWithCode(utils.ExitCodeInvalidInput, "%v\n", err)
+		}
+		result, err = lc.GetTransaction(hash)
+		if err != nil {
+			utils.ErrorWithCode(utils.LightErrorCode(err), "Failed to get verified transaction: %v\n", err)
+		}
+	} else {
+		client := rpcc.NewRPCClient(viper.GetString(utils.CfgRemoteRPCEndpoint))
+
+		res, err := client.Call("theta.GetTransaction", rpc.GetTransactionArgs{Hash: hash.Hex()})
+		if err != nil {
+			utils.ErrorWithCode(utils.ExitCodeRPC, "Failed to get transaction: %v\n", err)
+		}
+		if res.Error != nil {
+			utils.ErrorWithCode(utils.ExitCodeServer, "Failed to get transaction: %v\n", res.Error)
+		}
+		result = res.Result
+	}
+	json, err := json.MarshalIndent(result, "", "    ")
+	if err != nil {
+		utils.ErrorWithCode(utils.ExitCodeRPC, "Failed to parse server response: %v\n%v\n", err, string(json))
+	}
+	fmt.Println(string(json))
+}
+
+func init() {
+	txCmd.Flags().StringVar(&txHashFlag, "hash", "", "Hash of the transaction")
+	txCmd.MarkFlagRequired("hash")
+}
@@ -9,9 +9,12 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"github.com/thetatoken/ukulele/cmd/banjo/cmd/call"
+	"github.com/thetatoken/ukulele/cmd/banjo/cmd/contact"
+	"github.com/thetatoken/ukulele/cmd/banjo/cmd/dev"
 	"github.com/thetatoken/ukulele/cmd/banjo/cmd/key"
 	"github.com/thetatoken/ukulele/cmd/banjo/cmd/query"
 	"github.com/thetatoken/ukulele/cmd/banjo/cmd/tx"
+	"github.com/thetatoken/ukulele/cmd/banjo/cmd/utils"
 )
 
 var cfgPath string
@@ -20,15 +23,25 @@ var cfgPath string
 var RootCmd = &cobra.Command{
 	Use:   "banjo",
 	Short: "Theta wallet",
-	Long:  `Theta wallet.`,
+	Long: `Theta wallet.
+
+Exit codes:
+  0  success
+  1  generic error
+  2  invalid input
+  3  wallet error
+  4  failed to communicate with the node
+  5  the node returned an error
+  6  aborted by the user
+  7  signature verification failed`,
+	SilenceErrors: true,
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
 	if err := RootCmd.Execute(); err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+		utils.ErrorWithCode(utils.ExitCodeInvalidInput, "Error: %v\n", err)
 	}
 }
 
@@ -36,11 +49,22 @@ func init() {
 	cobra.OnInitialize(initConfig)
 
 	RootCmd.PersistentFlags().StringVar(&cfgPath, "config", getDefaultConfigPath(), fmt.Sprintf("config path (default is %s)", getDefaultConfigPath()))
+	RootCmd.PersistentFlags().String("output", utils.OutputFormatText, "Output format of the errors (text|json)")
+	viper.BindPFlag(utils.CfgOutput, RootCmd.PersistentFlags().Lookup("output"))
+	RootCmd.PersistentFlags().Bool("allow-lowercase", false, "Accept addresses without EIP55 checksum, i.e. all lowercase")
+	viper.BindPFlag(utils.CfgAllowLowercase, RootCmd.PersistentFlags().Lookup("allow-lowercase"))
+	RootCmd.PersistentFlags().Bool("light", false, "Verify the query results with a light client instead of trusting the node")
+	viper.BindPFlag(utils.CfgLightEnabled, RootCmd.PersistentFlags().Lookup("light"))
 
 	RootCmd.AddCommand(key.KeyCmd)
 	RootCmd.AddCommand(tx.TxCmd)
 	RootCmd.AddCommand(query.QueryCmd)
 	RootCmd.AddCommand(call.CallCmd)
+	RootCmd.AddCommand(contact.ContactCmd)
+	RootCmd.AddCommand(dev.DevCmd)
+	RootCmd.AddCommand(daemonCmd)
+	RootCmd.AddCommand(completionCmd)
+	RootCmd.AddCommand(versionCmd)
 }
 
 // initConfig reads in config file and ENV variables if set.
@@ -53,9 +77,17 @@ func initConfig() {
 	viper.AutomaticEnv() // read in environment variables that match
 
 	// If a config file is found, read it in.
-	if err := viper.ReadInConfig(); err == nil {
+	if err := viper.ReadInConfig(); err == nil && !utils.OutputJSON() {
 		fmt.Println("Using config file:", viper.ConfigFileUsed())
 	}
+
+	// Usage text would break the structured output
+	RootCmd.SilenceUsage = utils.OutputJSON()
+
+	output := viper.GetString(utils.CfgOutput)
+	if output != utils.OutputFormatText && output != utils.OutputFormatJSON {
+		utils.ErrorWithCode(utils.ExitCodeInvalidInput, "Unsupported output format: %v\n", output)
+	}
 }
 
 func getDefaultConfigPath() string {
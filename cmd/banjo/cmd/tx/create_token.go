@@ -0,0 +1,79 @@
+package tx
+
+import (
+	"encoding/hex"
+	"math/big"
+
+	"github.com/spf13/cobra"
+	"github.com/thetatoken/ukulele/cmd/banjo/cmd/utils"
+	"github.com/thetatoken/ukulele/ledger/types"
+)
+
+// createTokenCmd represents the create_token command. The max supply of the token is
+// minted to the issuer, and sent with the --tokens flag of the send command.
+// Example:
+//		banjo tx create_token --chain="" --from=2E833968E5bB786Ae419c4d13189fB081Cc43bab --symbol=ABC --decimals=6 --max_supply=1000000000000 --seq=3
+var createTokenCmd = &cobra.Command{
+	Use:     "create_token",
+	Short:   "Create an application token",
+	Example: `banjo tx create_token --chain="" --from=2E833968E5bB786Ae419c4d13189fB081Cc43bab --symbol=ABC --decimals=6 --max_supply=1000000000000 --seq=3`,
+	Run:     doCreateTokenCmd,
+}
+
+func doCreateTokenCmd(cmd *cobra.Command, args []string) {
+	wallet, fromAddress := walletUnlock(cmd, fromFlag)
+	defer wallet.Lock(fromAddress)
+
+	maxSupply, ok := new(big.Int).SetString(maxSupplyFlag, 10)
+	if !ok {
+		utils.ErrorWithCode(utils.ExitCodeInvalidInput, "Failed to parse max supply")
+	}
+	if err := types.ValidateToken(symbolFlag, decimalsFlag, maxSupply); err != nil {
+		utils.ErrorWithCode(utils.ExitCodeInvalidInput, "Invalid token: %v\n", err)
+	}
+
+	fee := getFee()
+	createTokenTx := &types.CreateTokenTx{
+		Fee: types.Coins{
+			ThetaWei: new(big.Int).SetUint64(0),
+			GammaWei: fee,
+		},
+		Issuer: types.TxInput{
+			Address:  fromAddress,
+			Sequence: uint64(seqFlag),
+		},
+		Symbol:    symbolFlag,
+		Decimals:  decimalsFlag,
+		MaxSupply: maxSupply,
+	}
+
+	sig := signTx(wallet, fromAddress, createTokenTx.SignBytes(chainIDFlag))
+	createTokenTx.SetSignature(fromAddress, sig)
+
+	raw, err := types.TxToBytes(createTokenTx)
+	if err != nil {
+		utils.Error("Failed to encode transaction: %v\n", err)
+	}
+	broadcastRawTx(hex.EncodeToString(raw))
+}
+
+func init() {
+	createTokenCmd.Flags().StringVar(&chainIDFlag, "chain", "", "Chain ID")
+	createTokenCmd.Flags().StringVar(&fromFlag, "from", "", "Address of the issuer")
+	createTokenCmd.Flags().StringVar(&symbolFlag, "symbol", "", "Symbol of the token")
+	createTokenCmd.Flags().Uint64Var(&decimalsFlag, "decimals", 18, "Number of decimals of the token")
+	createTokenCmd.Flags().StringVar(&maxSupplyFlag, "max_supply", "", "Supply of the token, in its smallest unit")
+	createTokenCmd.Flags().Uint64Var(&seqFlag, "seq", 0, "Sequence number of the transaction")
+	createTokenCmd.Flags().StringVar(&feeFlag, "fee", "", "Fee (estimated from the network if not set)")
+	createTokenCmd.Flags().StringVar(&walletFlag, "wallet", "soft", "Wallet type (soft|nano|trezor|remote|daemon)")
+	createTokenCmd.Flags().StringVar(&pathFlag, "path", "", "Derivation path of the address on the hardware wallet, e.g. m/44'/60'/0'/0")
+	createTokenCmd.Flags().Uint32Var(&indexFlag, "index", 0, "Account index of the address on the hardware wallet, ignored if --path is set")
+	createTokenCmd.Flags().BoolVar(&yesFlag, "yes", false, "Skip the confirmation prompt")
+	createTokenCmd.Flags().BoolVar(&blindSignFlag, "blind-sign", false, "Sign the transaction even if it cannot be decoded for review")
+
+	createTokenCmd.MarkFlagRequired("chain")
+	createTokenCmd.MarkFlagRequired("from")
+	createTokenCmd.MarkFlagRequired("symbol")
+	createTokenCmd.MarkFlagRequired("max_supply")
+	createTokenCmd.MarkFlagRequired("seq")
+}
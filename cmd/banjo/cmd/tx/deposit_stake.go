@@ -0,0 +1,103 @@
+package tx
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/thetatoken/ukulele/cmd/banjo/cmd/utils"
+	"github.com/thetatoken/ukulele/ledger/types"
+	"github.com/thetatoken/ukulele/rpc"
+
+	rpcc "github.com/ybbus/jsonrpc"
+)
+
+// depositStakeCmd represents the deposit_stake command
+// Example:
+//		banjo tx deposit_stake --chain="" --from=2E833968E5bB786Ae419c4d13189fB081Cc43bab --holder=9F1233798E905E173560071255140b4A8aBd3Ec6 --stake=10000 --seq=1
+var depositStakeCmd = &cobra.Command{
+	Use:     "deposit_stake",
+	Short:   "Deposit Theta as stake to a validator",
+	Example: `banjo tx deposit_stake --chain="" --from=2E833968E5bB786Ae419c4d13189fB081Cc43bab --holder=9F1233798E905E173560071255140b4A8aBd3Ec6 --stake=10000 --seq=1`,
+	Run:     doDepositStakeCmd,
+}
+
+func doDepositStakeCmd(cmd *cobra.Command, args []string) {
+	wallet, fromAddress := walletUnlock(cmd, fromFlag)
+	defer wallet.Lock(fromAddress)
+
+	stake, ok := types.ParseCoinAmount(stakeFlag)
+	if !ok {
+		utils.ErrorWithCode(utils.ExitCodeInvalidInput, "Failed to parse stake")
+	}
+	fee := getFee()
+	depositStakeTx := &types.DepositStakeTx{
+		Fee: types.Coins{
+			ThetaWei: new(big.Int).SetUint64(0),
+			GammaWei: fee,
+		},
+		Source: types.TxInput{
+			Address: fromAddress,
+			Coins: types.Coins{
+				ThetaWei: stake,
+				GammaWei: new(big.Int).SetUint64(0),
+			},
+			Sequence: uint64(seqFlag),
+		},
+		Holder: types.TxOutput{
+			Address: resolveAddress(cmd, holderFlag),
+		},
+	}
+
+	sig := signTx(wallet, fromAddress, depositStakeTx.SignBytes(chainIDFlag))
+	depositStakeTx.SetSignature(fromAddress, sig)
+
+	raw, err := types.TxToBytes(depositStakeTx)
+	if err != nil {
+		utils.Error("Failed to encode transaction: %v\n", err)
+	}
+	signedTx := hex.EncodeToString(raw)
+
+	client := rpcc.NewRPCClient(viper.GetString(utils.CfgRemoteRPCEndpoint))
+
+	res, err := client.Call("theta.BroadcastRawTransaction", rpc.BroadcastRawTransactionArgs{TxBytes: signedTx})
+	if err != nil {
+		utils.ErrorWithCode(utils.ExitCodeRPC, "Failed to broadcast transaction: %v\n", err)
+	}
+	if res.Error != nil {
+		utils.ErrorWithCode(utils.ExitCodeServer, "Server returned error: %v\n", res.Error)
+	}
+	result := &rpc.BroadcastRawTransactionResult{}
+	err = res.GetObject(result)
+	if err != nil {
+		utils.ErrorWithCode(utils.ExitCodeRPC, "Failed to parse server response: %v\n", err)
+	}
+	formatted, err := json.MarshalIndent(result, "", "    ")
+	if err != nil {
+		utils.ErrorWithCode(utils.ExitCodeRPC, "Failed to parse server response: %v\n", err)
+	}
+	fmt.Printf("Successfully broadcasted transaction:\n%s\n", formatted)
+}
+
+func init() {
+	depositStakeCmd.Flags().StringVar(&chainIDFlag, "chain", "", "Chain ID")
+	depositStakeCmd.Flags().StringVar(&fromFlag, "from", "", "Address to deposit the stake from")
+	depositStakeCmd.Flags().StringVar(&holderFlag, "holder", "", "Address of the stake holder, i.e. the validator")
+	depositStakeCmd.Flags().Uint64Var(&seqFlag, "seq", 0, "Sequence number of the transaction")
+	depositStakeCmd.Flags().StringVar(&stakeFlag, "stake", "0", "Theta amount to stake")
+	depositStakeCmd.Flags().StringVar(&feeFlag, "fee", "", "Fee (estimated from the network if not set)")
+	depositStakeCmd.Flags().StringVar(&walletFlag, "wallet", "soft", "Wallet type (soft|nano|trezor|remote|daemon)")
+	depositStakeCmd.Flags().StringVar(&pathFlag, "path", "", "Derivation path of the address on the hardware wallet, e.g. m/44'/60'/0'/0")
+	depositStakeCmd.Flags().Uint32Var(&indexFlag, "index", 0, "Account index of the address on the hardware wallet, ignored if --path is set")
+	depositStakeCmd.Flags().BoolVar(&yesFlag, "yes", false, "Skip the confirmation prompt")
+	depositStakeCmd.Flags().BoolVar(&blindSignFlag, "blind-sign", false, "Sign the transaction even if it cannot be decoded for review")
+
+	depositStakeCmd.MarkFlagRequired("chain")
+	depositStakeCmd.MarkFlagRequired("from")
+	depositStakeCmd.MarkFlagRequired("holder")
+	depositStakeCmd.MarkFlagRequired("stake")
+	depositStakeCmd.MarkFlagRequired("seq")
+}
@@ -0,0 +1,107 @@
+package tx
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/big"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/thetatoken/ukulele/cmd/banjo/cmd/utils"
+	"github.com/thetatoken/ukulele/ledger/types"
+	"github.com/thetatoken/ukulele/rpc"
+
+	rpcc "github.com/ybbus/jsonrpc"
+)
+
+// extendReserveCmd represents the extend reserve command
+// Example:
+//		banjo tx extend --chain="" --from=2E833968E5bB786Ae419c4d13189fB081Cc43bab --fund=100 --collateral=101 --duration=500 --reserve_seq=6 --seq=7
+var extendReserveCmd = &cobra.Command{
+	Use:     "extend",
+	Short:   "Add fund and collateral to a reserve and extend it",
+	Example: `banjo tx extend --chain="" --from=2E833968E5bB786Ae419c4d13189fB081Cc43bab --fund=100 --collateral=101 --duration=500 --reserve_seq=6 --seq=7`,
+	Run:     doExtendReserveCmd,
+}
+
+func doExtendReserveCmd(cmd *cobra.Command, args []string) {
+	wallet, fromAddress := walletUnlock(cmd, fromFlag)
+	defer wallet.Lock(fromAddress)
+
+	fee := getFee()
+	fund, ok := types.ParseCoinAmount(reserveFundInGammaFlag)
+	if !ok {
+		utils.ErrorWithCode(utils.ExitCodeInvalidInput, "Failed to parse fund")
+	}
+	col, ok := types.ParseCoinAmount(reserveCollateralInGammaFlag)
+	if !ok {
+		utils.ErrorWithCode(utils.ExitCodeInvalidInput, "Failed to parse collateral")
+	}
+	input := types.TxInput{
+		Address: fromAddress,
+		Coins: types.Coins{
+			ThetaWei: new(big.Int).SetUint64(0),
+			GammaWei: fund,
+		},
+		Sequence: uint64(seqFlag),
+	}
+	collateral := types.Coins{
+		ThetaWei: new(big.Int).SetUint64(0),
+		GammaWei: col,
+	}
+	if fund.Sign() == 0 && col.Sign() == 0 && durationFlag == 0 {
+		utils.ErrorWithCode(utils.ExitCodeInvalidInput, "Invalid input: one of fund, collateral and duration must be positive\n")
+	}
+
+	extendReserveTx := &types.ExtendReserveTx{
+		Fee: types.Coins{
+			ThetaWei: new(big.Int).SetUint64(0),
+			GammaWei: fee,
+		},
+		Source:          input,
+		Collateral:      collateral,
+		Duration:        durationFlag,
+		ReserveSequence: reserveSeqFlag,
+	}
+
+	sig := signTx(wallet, fromAddress, extendReserveTx.SignBytes(chainIDFlag))
+	extendReserveTx.SetSignature(fromAddress, sig)
+
+	raw, err := types.TxToBytes(extendReserveTx)
+	if err != nil {
+		utils.Error("Failed to encode transaction: %v\n", err)
+	}
+	signedTx := hex.EncodeToString(raw)
+
+	client := rpcc.NewRPCClient(viper.GetString(utils.CfgRemoteRPCEndpoint))
+
+	res, err := client.Call("theta.BroadcastRawTransaction", rpc.BroadcastRawTransactionArgs{TxBytes: signedTx})
+	if err != nil {
+		utils.ErrorWithCode(utils.ExitCodeRPC, "Failed to broadcast transaction: %v\n", err)
+	}
+	if res.Error != nil {
+		utils.ErrorWithCode(utils.ExitCodeServer, "Server returned error: %v\n", res.Error)
+	}
+	fmt.Printf("Successfully broadcasted transaction.\n")
+}
+
+func init() {
+	extendReserveCmd.Flags().StringVar(&chainIDFlag, "chain", "", "Chain ID")
+	extendReserveCmd.Flags().StringVar(&fromFlag, "from", "", "Reserve owner's address")
+	extendReserveCmd.Flags().Uint64Var(&seqFlag, "seq", 0, "Sequence number of the transaction")
+	extendReserveCmd.Flags().StringVar(&reserveFundInGammaFlag, "fund", "0", "Gamma amount to add to the reserved fund")
+	extendReserveCmd.Flags().StringVar(&reserveCollateralInGammaFlag, "collateral", "0", "Gamma amount to add to the collateral")
+	extendReserveCmd.Flags().StringVar(&feeFlag, "fee", "", "Fee (estimated from the network if not set)")
+	extendReserveCmd.Flags().Uint64Var(&durationFlag, "duration", 0, "Number of blocks to extend the reserve by")
+	extendReserveCmd.Flags().Uint64Var(&reserveSeqFlag, "reserve_seq", 0, "Reserve sequence of the reserve")
+	extendReserveCmd.Flags().StringVar(&walletFlag, "wallet", "soft", "Wallet type (soft|nano|trezor|remote|daemon)")
+	extendReserveCmd.Flags().StringVar(&pathFlag, "path", "", "Derivation path of the address on the hardware wallet, e.g. m/44'/60'/0'/0")
+	extendReserveCmd.Flags().Uint32Var(&indexFlag, "index", 0, "Account index of the address on the hardware wallet, ignored if --path is set")
+	extendReserveCmd.Flags().BoolVar(&yesFlag, "yes", false, "Skip the confirmation prompt")
+	extendReserveCmd.Flags().BoolVar(&blindSignFlag, "blind-sign", false, "Sign the transaction even if it cannot be decoded for review")
+
+	extendReserveCmd.MarkFlagRequired("chain")
+	extendReserveCmd.MarkFlagRequired("from")
+	extendReserveCmd.MarkFlagRequired("seq")
+	extendReserveCmd.MarkFlagRequired("reserve_seq")
+}
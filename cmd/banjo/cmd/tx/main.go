@@ -20,26 +20,81 @@ var (
 	reserveFundInGammaFlag       string
 	reserveCollateralInGammaFlag string
 	reserveSeqFlag               uint64
+	spendLimitsFlag              []string
 	addressesFlag                []string
 	percentagesFlag              []string
+	platformAddressesFlag        []string
+	platformPercentagesFlag      []string
 	valueFlag                    string
 	gasPriceFlag                 string
 	gasLimitFlag                 uint64
 	dataFlag                     string
 	walletFlag                   string
+	pathFlag                     string
+	indexFlag                    uint32
+	yesFlag                      bool
+	blindSignFlag                bool
+	holderFlag                   string
+	stakeFlag                    string
+	thresholdFlag                uint64
+	pubKeysFlag                  []string
+	signerFlag                   string
+	txIDFlag                     string
+	submitFlag                   bool
+	guardiansFlag                []string
+	timelockFlag                 uint64
+	accountFlag                  string
+	newSignerFlag                string
+	broadcastFlag                bool
+	unlockHeightFlag             uint64
+	symbolFlag                   string
+	decimalsFlag                 uint64
+	maxSupplyFlag                string
+	tokensFlag                   []string
+	approversFlag                []string
+	heightFlag                   uint64
+	minFeeFlag                   uint64
+	maxBlockGasFlag              uint64
+	slashPercentFlag             uint64
+	slashReporterPercentFlag     uint64
+	slashJailDurationFlag        uint64
+	blockRewardFlag              string
+	rewardHalvingIntervalFlag    uint64
+	feeBurnPercentFlag           uint64
+	proposerFeePercentFlag       uint64
+	maxReservedFundsFlag         uint64
+	maxReserveDurationFlag       uint64
+	kindFlag                     string
+	validatorFlag                string
+	periodFlag                   uint64
+	descriptionFlag              string
+	proposalIDFlag               uint64
+	approveFlag                  bool
 )
 
 // TxCmd represents the Tx command
 var TxCmd = &cobra.Command{
 	Use:   "tx",
 	Short: "Manage transactions",
-	Long:  `Manage transactions.`,
+	Long: `Manage transactions. The addresses can be given as the names of the contacts in the
+address book, see banjo contact.`,
 }
 
 func init() {
 	TxCmd.AddCommand(sendCmd)
 	TxCmd.AddCommand(reserveFundCmd)
+	TxCmd.AddCommand(extendReserveCmd)
 	//TxCmd.AddCommand(releaseFundCmd) // No need for releaseFundCmd since auto-release is already implemented
 	TxCmd.AddCommand(splitRuleCmd)
 	TxCmd.AddCommand(smartContractCmd)
+	TxCmd.AddCommand(depositStakeCmd)
+	TxCmd.AddCommand(withdrawStakeCmd)
+	TxCmd.AddCommand(multisigCmd)
+	TxCmd.AddCommand(setGuardiansCmd)
+	TxCmd.AddCommand(recoverCmd)
+	TxCmd.AddCommand(createTokenCmd)
+	TxCmd.AddCommand(updateParamsCmd)
+	TxCmd.AddCommand(proposeCmd)
+	TxCmd.AddCommand(voteCmd)
+	TxCmd.AddCommand(unjailCmd)
 }
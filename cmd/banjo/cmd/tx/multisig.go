@@ -0,0 +1,328 @@
+package tx
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/thetatoken/ukulele/cmd/banjo/cmd/utils"
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/ledger/types"
+	"github.com/thetatoken/ukulele/rpc"
+	"github.com/thetatoken/ukulele/wallet/preview"
+
+	rpcc "github.com/ybbus/jsonrpc"
+)
+
+// multisigCmd represents the multisig command
+var multisigCmd = &cobra.Command{
+	Use:   "multisig",
+	Short: "Manage multisig accounts and their transactions",
+	Long: `Manage multisig accounts, which spend with the signatures of M of their N keys. A transaction
+spending from a multisig account is created unsigned by 'banjo tx multisig send', and passed to the
+signers, each adding a partial signature with 'banjo tx multisig sign'. With --submit, the partial
+signatures are collected by the node, which broadcasts the transaction once fully signed.`,
+}
+
+// multisigCreateCmd creates a multisig account
+// Example:
+//		banjo tx multisig create --threshold=2 --pubkeys=04a4...,04b5...,04c6...
+var multisigCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create a multisig account",
+	Long: `Create an M-of-N multisig account from the public keys of the signers (see 'banjo tx multisig pubkey').
+The address is derived from the threshold and the public keys, and can receive funds right away.`,
+	Example: `banjo tx multisig create --threshold=2 --pubkeys=04a4...,04b5...,04c6...`,
+	Run: func(cmd *cobra.Command, args []string) {
+		account, err := utils.NewMultisigAccount(thresholdFlag, pubKeysFlag)
+		if err != nil {
+			utils.ErrorWithCode(utils.ExitCodeInvalidInput, "Invalid multisig account: %v\n", err)
+		}
+		book := loadMultisigBook(cmd)
+		if err := book.Add(account); err != nil {
+			utils.ErrorWithCode(utils.ExitCodeInvalidInput, "Failed to save multisig account: %v\n", err)
+		}
+		fmt.Printf("Created %v-of-%v multisig account: %v\n", account.Threshold, len(account.PublicKeys), account.Address().Hex())
+	},
+}
+
+// multisigListCmd lists the multisig accounts
+var multisigListCmd = &cobra.Command{
+	Use:     "list",
+	Short:   "List the multisig accounts",
+	Example: `banjo tx multisig list`,
+	Run: func(cmd *cobra.Command, args []string) {
+		for _, account := range loadMultisigBook(cmd).List() {
+			fmt.Printf("%s\t%v-of-%v\n", account.Address().Hex(), account.Threshold, len(account.PublicKeys))
+		}
+	},
+}
+
+// multisigPubKeyCmd displays the public key of a signer
+// Example:
+//		banjo tx multisig pubkey --signer=2E833968E5bB786Ae419c4d13189fB081Cc43bab
+var multisigPubKeyCmd = &cobra.Command{
+	Use:     "pubkey",
+	Short:   "Display the public key of a signer",
+	Long:    `Display the public key of a signer, to be shared with the creator of the multisig account.`,
+	Example: `banjo tx multisig pubkey --signer=2E833968E5bB786Ae419c4d13189fB081Cc43bab`,
+	Run: func(cmd *cobra.Command, args []string) {
+		wallet, signerAddress := walletUnlock(cmd, signerFlag)
+		defer wallet.Lock(signerAddress)
+
+		pubKey, err := wallet.GetPublicKey(signerAddress)
+		if err != nil {
+			utils.ErrorWithCode(utils.ExitCodeWallet, "Failed to get public key: %v\n", err)
+		}
+		fmt.Printf("%v\n", hex.EncodeToString(pubKey.ToBytes()))
+	},
+}
+
+// multisigSendCmd creates an unsigned send transaction from a multisig account
+// Example:
+//		banjo tx multisig send --chain="" --from=<multisig address> --to=9F1233798E905E173560071255140b4A8aBd3Ec6 --theta=10 --gamma=900000 --seq=1
+var multisigSendCmd = &cobra.Command{
+	Use:   "send",
+	Short: "Create a send transaction from a multisig account",
+	Long: `Create a send transaction from a multisig account. The transaction is printed unsigned, to be
+signed by the signers with 'banjo tx multisig sign'.`,
+	Example: `banjo tx multisig send --chain="" --from=<multisig address> --to=9F1233798E905E173560071255140b4A8aBd3Ec6 --theta=10 --gamma=900000 --seq=1`,
+	Run: func(cmd *cobra.Command, args []string) {
+		fromAddress := resolveAddress(cmd, fromFlag)
+		account, ok := loadMultisigBook(cmd).Get(fromAddress)
+		if !ok {
+			utils.ErrorWithCode(utils.ExitCodeInvalidInput, "%v is not a multisig account, see banjo tx multisig create\n", fromAddress.Hex())
+		}
+
+		theta, ok := types.ParseCoinAmount(thetaAmountFlag)
+		if !ok {
+			utils.ErrorWithCode(utils.ExitCodeInvalidInput, "Failed to parse theta amount")
+		}
+		gamma, ok := types.ParseCoinAmount(gammaAmountFlag)
+		if !ok {
+			utils.ErrorWithCode(utils.ExitCodeInvalidInput, "Failed to parse gamma amount")
+		}
+		fee := getFee()
+		sendTx := &types.SendTx{
+			Fee: types.Coins{
+				ThetaWei: new(big.Int).SetUint64(0),
+				GammaWei: fee,
+			},
+			Inputs: []types.TxInput{{
+				Address: fromAddress,
+				Coins: types.Coins{
+					GammaWei: new(big.Int).Add(gamma, fee),
+					ThetaWei: theta,
+				},
+				Sequence:  uint64(seqFlag),
+				Signature: types.NewMultisigSignature(account).ToSignature(),
+			}},
+			Outputs: []types.TxOutput{{
+				Address: resolveAddress(cmd, toFlag),
+				Coins: types.Coins{
+					GammaWei: gamma,
+					ThetaWei: theta,
+				},
+			}},
+		}
+
+		raw, err := types.TxToBytes(sendTx)
+		if err != nil {
+			utils.Error("Failed to encode transaction: %v\n", err)
+		}
+		fmt.Printf("%v\n", hex.EncodeToString(raw))
+	},
+}
+
+// multisigSignCmd adds a partial signature to a transaction spending from a multisig account
+// Example:
+//		banjo tx multisig sign --chain="" --signer=2E833968E5bB786Ae419c4d13189fB081Cc43bab --submit <tx bytes>
+var multisigSignCmd = &cobra.Command{
+	Use:   "sign",
+	Short: "Sign a transaction spending from a multisig account",
+	Long: `Add the partial signature of a signer to a transaction spending from a multisig account. The
+transaction is given either in hex, or by --tx_id if it has been submitted to the node. The transaction
+with the signature is printed, or submitted to the node with --submit.`,
+	Example: `banjo tx multisig sign --chain="" --signer=2E833968E5bB786Ae419c4d13189fB081Cc43bab --submit <tx bytes>`,
+	Run: func(cmd *cobra.Command, args []string) {
+		var txBytesStr string
+		if len(txIDFlag) > 0 {
+			txBytesStr = getPartiallySignedTx(txIDFlag).TxBytes
+		} else if len(args) > 0 {
+			txBytesStr = strings.TrimPrefix(args[0], "0x")
+		} else {
+			utils.ErrorWithCode(utils.ExitCodeInvalidInput, "Usage: banjo tx multisig sign --chain=<chain ID> --signer=<address> <tx bytes>|--tx_id=<tx ID>\n")
+		}
+		txBytes, err := hex.DecodeString(txBytesStr)
+		if err != nil {
+			utils.ErrorWithCode(utils.ExitCodeInvalidInput, "Invalid transaction bytes: %v\n", err)
+		}
+		tx, err := types.TxFromBytes(txBytes)
+		if err != nil {
+			utils.ErrorWithCode(utils.ExitCodeInvalidInput, "Failed to decode transaction: %v\n", err)
+		}
+
+		wallet, signerAddress := walletUnlock(cmd, signerFlag)
+		defer wallet.Lock(signerAddress)
+
+		// Find the multisig inputs the signer is a key of
+		signBytes := tx.SignBytes(chainIDFlag)
+		inputs := []*types.TxInput{}
+		items := [][2]string{{"Signer", signerAddress.Hex()}}
+		for _, in := range types.SpendingInputs(tx) {
+			ms, ok := types.MultisigSignatureFromSignature(in.Signature)
+			if !ok || ms.Account.KeyIndex(signerAddress) < 0 {
+				continue
+			}
+			inputs = append(inputs, in)
+			items = append(items, [2]string{"Multisig", fmt.Sprintf("%v (%v-of-%v, %v signed)",
+				in.Address.Hex(), ms.Account.Threshold, len(ms.Account.PublicKeys), ms.NumSigned())})
+		}
+		if len(inputs) == 0 {
+			utils.ErrorWithCode(utils.ExitCodeInvalidInput, "Address %v is not a key of any multisig input of the transaction\n", signerAddress.Hex())
+		}
+
+		summary, err := preview.DecodeSignBytes(signBytes)
+		if err != nil {
+			utils.ErrorWithCode(utils.ExitCodeInvalidInput, "Failed to decode transaction for review: %v\n", err)
+		}
+		confirmTx(summary.Type+" transaction", append(items, summary.Fields()...))
+
+		sig, err := wallet.Sign(signerAddress, signBytes)
+		if err != nil {
+			utils.ErrorWithCode(utils.ExitCodeWallet, "Failed to sign transaction: %v\n", err)
+		}
+		for _, in := range inputs {
+			ms, _ := types.MultisigSignatureFromSignature(in.Signature)
+			if err := ms.AddSignature(signBytes, sig); err != nil {
+				utils.ErrorWithCode(utils.ExitCodeWallet, "Failed to add signature: %v\n", err)
+			}
+			in.Signature = ms.ToSignature()
+		}
+
+		raw, err := types.TxToBytes(tx)
+		if err != nil {
+			utils.Error("Failed to encode transaction: %v\n", err)
+		}
+		if !submitFlag {
+			fmt.Printf("%v\n", hex.EncodeToString(raw))
+			return
+		}
+
+		client := rpcc.NewRPCClient(viper.GetString(utils.CfgRemoteRPCEndpoint))
+		res, err := client.Call("theta.SubmitPartiallySignedTx", rpc.SubmitPartiallySignedTxArgs{TxBytes: hex.EncodeToString(raw)})
+		if err != nil {
+			utils.ErrorWithCode(utils.ExitCodeRPC, "Failed to submit transaction: %v\n", err)
+		}
+		if res.Error != nil {
+			utils.ErrorWithCode(utils.ExitCodeServer, "Server returned error: %v\n", res.Error)
+		}
+		result := &rpc.PartiallySignedTxResult{}
+		err = res.GetObject(result)
+		if err != nil {
+			utils.ErrorWithCode(utils.ExitCodeRPC, "Failed to parse server response: %v\n", err)
+		}
+		printPartiallySignedTx(result)
+	},
+}
+
+// multisigStatusCmd displays the signatures collected by the node for a transaction
+// Example:
+//		banjo tx multisig status --tx_id=0x...
+var multisigStatusCmd = &cobra.Command{
+	Use:     "status",
+	Short:   "Display the signatures collected for a multisig transaction",
+	Long:    `Display the signatures collected by the node for a transaction submitted with 'banjo tx multisig sign --submit'.`,
+	Example: `banjo tx multisig status --tx_id=0x...`,
+	Run: func(cmd *cobra.Command, args []string) {
+		printPartiallySignedTx(getPartiallySignedTx(txIDFlag))
+	},
+}
+
+func loadMultisigBook(cmd *cobra.Command) *utils.MultisigBook {
+	cfgPath := cmd.Flag("config").Value.String()
+	book, err := utils.LoadMultisigBook(cfgPath)
+	if err != nil {
+		utils.ErrorWithCode(utils.ExitCodeInvalidInput, "Failed to load multisig accounts: %v\n", err)
+	}
+	return book
+}
+
+func getPartiallySignedTx(txID string) *rpc.PartiallySignedTxResult {
+	client := rpcc.NewRPCClient(viper.GetString(utils.CfgRemoteRPCEndpoint))
+	res, err := client.Call("theta.GetPartiallySignedTx", rpc.GetPartiallySignedTxArgs{TxID: common.HexToHash(txID).Hex()})
+	if err != nil {
+		utils.ErrorWithCode(utils.ExitCodeRPC, "Failed to get transaction: %v\n", err)
+	}
+	if res.Error != nil {
+		utils.ErrorWithCode(utils.ExitCodeServer, "Server returned error: %v\n", res.Error)
+	}
+	result := &rpc.PartiallySignedTxResult{}
+	err = res.GetObject(result)
+	if err != nil {
+		utils.ErrorWithCode(utils.ExitCodeRPC, "Failed to parse server response: %v\n", err)
+	}
+	return result
+}
+
+func printPartiallySignedTx(result *rpc.PartiallySignedTxResult) {
+	formatted, err := json.MarshalIndent(result, "", "    ")
+	if err != nil {
+		utils.ErrorWithCode(utils.ExitCodeRPC, "Failed to parse server response: %v\n", err)
+	}
+	if result.Broadcast {
+		fmt.Printf("Transaction fully signed and broadcasted:\n%s\n", formatted)
+	} else {
+		fmt.Printf("Transaction partially signed:\n%s\n", formatted)
+	}
+}
+
+func init() {
+	multisigCreateCmd.Flags().Uint64Var(&thresholdFlag, "threshold", 0, "Number of signatures required")
+	multisigCreateCmd.Flags().StringSliceVar(&pubKeysFlag, "pubkeys", []string{}, "Public keys of the signers, in hex")
+	multisigCreateCmd.MarkFlagRequired("threshold")
+	multisigCreateCmd.MarkFlagRequired("pubkeys")
+
+	multisigPubKeyCmd.Flags().StringVar(&signerFlag, "signer", "", "Address of the signer")
+	multisigPubKeyCmd.Flags().StringVar(&walletFlag, "wallet", "soft", "Wallet type (soft|nano|trezor|remote|daemon)")
+	multisigPubKeyCmd.Flags().StringVar(&pathFlag, "path", "", "Derivation path of the address on the hardware wallet, e.g. m/44'/60'/0'/0")
+	multisigPubKeyCmd.Flags().Uint32Var(&indexFlag, "index", 0, "Account index of the address on the hardware wallet, ignored if --path is set")
+	multisigPubKeyCmd.MarkFlagRequired("signer")
+
+	multisigSendCmd.Flags().StringVar(&chainIDFlag, "chain", "", "Chain ID")
+	multisigSendCmd.Flags().StringVar(&fromFlag, "from", "", "Multisig address to send from")
+	multisigSendCmd.Flags().StringVar(&toFlag, "to", "", "Address to send to")
+	multisigSendCmd.Flags().Uint64Var(&seqFlag, "seq", 0, "Sequence number of the transaction")
+	multisigSendCmd.Flags().StringVar(&thetaAmountFlag, "theta", "0", "Theta amount")
+	multisigSendCmd.Flags().StringVar(&gammaAmountFlag, "gamma", "0", "Gamma amount")
+	multisigSendCmd.Flags().StringVar(&feeFlag, "fee", "", "Fee (estimated from the network if not set)")
+	multisigSendCmd.MarkFlagRequired("chain")
+	multisigSendCmd.MarkFlagRequired("from")
+	multisigSendCmd.MarkFlagRequired("to")
+	multisigSendCmd.MarkFlagRequired("seq")
+
+	multisigSignCmd.Flags().StringVar(&chainIDFlag, "chain", "", "Chain ID")
+	multisigSignCmd.Flags().StringVar(&signerFlag, "signer", "", "Address of the signer")
+	multisigSignCmd.Flags().StringVar(&txIDFlag, "tx_id", "", "ID of the transaction submitted to the node, instead of the transaction bytes")
+	multisigSignCmd.Flags().BoolVar(&submitFlag, "submit", false, "Submit the signed transaction to the node")
+	multisigSignCmd.Flags().StringVar(&walletFlag, "wallet", "soft", "Wallet type (soft|nano|trezor|remote|daemon)")
+	multisigSignCmd.Flags().StringVar(&pathFlag, "path", "", "Derivation path of the address on the hardware wallet, e.g. m/44'/60'/0'/0")
+	multisigSignCmd.Flags().Uint32Var(&indexFlag, "index", 0, "Account index of the address on the hardware wallet, ignored if --path is set")
+	multisigSignCmd.Flags().BoolVar(&yesFlag, "yes", false, "Skip the confirmation prompt")
+	multisigSignCmd.MarkFlagRequired("chain")
+	multisigSignCmd.MarkFlagRequired("signer")
+
+	multisigStatusCmd.Flags().StringVar(&txIDFlag, "tx_id", "", "ID of the transaction submitted to the node")
+	multisigStatusCmd.MarkFlagRequired("tx_id")
+
+	multisigCmd.AddCommand(multisigCreateCmd)
+	multisigCmd.AddCommand(multisigListCmd)
+	multisigCmd.AddCommand(multisigPubKeyCmd)
+	multisigCmd.AddCommand(multisigSendCmd)
+	multisigCmd.AddCommand(multisigSignCmd)
+	multisigCmd.AddCommand(multisigStatusCmd)
+}
@@ -0,0 +1,112 @@
+package tx
+
+import (
+	"encoding/hex"
+	"math/big"
+
+	"github.com/spf13/cobra"
+	"github.com/thetatoken/ukulele/cmd/banjo/cmd/utils"
+	"github.com/thetatoken/ukulele/ledger/types"
+)
+
+// proposeCmd represents the propose command. The proposal is put to the vote of the
+// stakers for the voting period.
+// Example:
+//		banjo tx propose --chain="" --from=2E833968E5bB786Ae419c4d13189fB081Cc43bab --kind=parameter_change --min_fee=1000000000000 --max_block_gas=20000000 --slash_percent=100 --period=10000 --description="Limit the block gas" --seq=3
+//		banjo tx propose --chain="" --from=2E833968E5bB786Ae419c4d13189fB081Cc43bab --kind=jail_validator --validator=9F1233798E905E173560071255140b4A8aBd3Ec6 --duration=100 --period=10000 --seq=3
+var proposeCmd = &cobra.Command{
+	Use:   "propose",
+	Short: "Submit a governance proposal to the vote of the stakers",
+	Long: `Submit a governance proposal to the vote of the stakers, as a staker. A parameter_change proposal
+updates the chain parameters, and a jail_validator proposal excludes --validator from the proposer
+rotation for --duration epochs. The proposal is tallied once its voting period has ended, and passes
+if the voters hold at least 40% of the stake and more than half of their stake approves it.`,
+	Example: `banjo tx propose --chain="" --from=2E833968E5bB786Ae419c4d13189fB081Cc43bab --kind=parameter_change --min_fee=1000000000000 --max_block_gas=20000000 --slash_percent=100 --period=10000 --description="Limit the block gas" --seq=3`,
+	Run:     doProposeCmd,
+}
+
+func doProposeCmd(cmd *cobra.Command, args []string) {
+	var change types.ProposalChange
+	switch kindFlag {
+	case types.ProposalParameterChange.String():
+		change = types.ProposalChange{
+			Kind:       types.ProposalParameterChange,
+			Parameters: chainParametersFromFlags(),
+		}
+	case types.ProposalJailValidator.String():
+		change = types.ProposalChange{
+			Kind:         types.ProposalJailValidator,
+			Validator:    resolveAddress(cmd, validatorFlag),
+			JailDuration: durationFlag,
+		}
+	default:
+		utils.ErrorWithCode(utils.ExitCodeInvalidInput, "Unknown proposal kind %v, parameter_change or jail_validator expected\n", kindFlag)
+	}
+	if err := change.Validate(); err != nil {
+		utils.ErrorWithCode(utils.ExitCodeInvalidInput, "Invalid proposal: %v\n", err)
+	}
+
+	wallet, fromAddress := walletUnlock(cmd, fromFlag)
+	defer wallet.Lock(fromAddress)
+
+	fee := getFee()
+	proposalTx := &types.ProposalTx{
+		Fee: types.Coins{
+			ThetaWei: new(big.Int).SetUint64(0),
+			GammaWei: fee,
+		},
+		Proposer: types.TxInput{
+			Address: fromAddress,
+			Coins: types.Coins{
+				ThetaWei: new(big.Int).SetUint64(0),
+				GammaWei: new(big.Int).SetUint64(0),
+			},
+			Sequence: uint64(seqFlag),
+		},
+		Change:       change,
+		Description:  descriptionFlag,
+		VotingPeriod: periodFlag,
+	}
+
+	sig := signTx(wallet, fromAddress, proposalTx.SignBytes(chainIDFlag))
+	proposalTx.SetSignature(fromAddress, sig)
+
+	raw, err := types.TxToBytes(proposalTx)
+	if err != nil {
+		utils.Error("Failed to encode transaction: %v\n", err)
+	}
+	broadcastRawTx(hex.EncodeToString(raw))
+}
+
+func init() {
+	proposeCmd.Flags().StringVar(&chainIDFlag, "chain", "", "Chain ID")
+	proposeCmd.Flags().StringVar(&fromFlag, "from", "", "Address of the staker submitting the proposal")
+	proposeCmd.Flags().StringVar(&kindFlag, "kind", "", "Kind of the proposal (parameter_change|jail_validator)")
+	proposeCmd.Flags().Uint64Var(&minFeeFlag, "min_fee", types.MinimumTransactionFeeGammaWei, "Minimum fee of a regular transaction, in GammaWei, for a parameter change")
+	proposeCmd.Flags().Uint64Var(&maxBlockGasFlag, "max_block_gas", 0, "Maximum total gas of the transactions of a block, 0 for no limit, for a parameter change")
+	proposeCmd.Flags().Uint64Var(&slashPercentFlag, "slash_percent", 100, "Percentage of the collateral and remaining fund slashed for an overspending, for a parameter change")
+	proposeCmd.Flags().Uint64Var(&slashReporterPercentFlag, "slash_reporter_percent", 100, "Percentage of the slashed amount rewarded to the reporting validator, for a parameter change")
+	proposeCmd.Flags().Uint64Var(&slashJailDurationFlag, "slash_jail_duration", 0, "Number of epochs a slashed validator is not selected as proposer, for a parameter change")
+	proposeCmd.Flags().StringVar(&blockRewardFlag, "block_reward", "0", "GammaWei issued for each block before any halving, for a parameter change")
+	proposeCmd.Flags().Uint64Var(&rewardHalvingIntervalFlag, "reward_halving_interval", 0, "Number of blocks after which the block reward halves, 0 for a constant reward, for a parameter change")
+	proposeCmd.Flags().Uint64Var(&feeBurnPercentFlag, "fee_burn_percent", 100, "Percentage of the transaction fees that is burned, for a parameter change")
+	proposeCmd.Flags().Uint64Var(&proposerFeePercentFlag, "proposer_fee_percent", 100, "Percentage of the distributed fees rewarded to the proposer, for a parameter change")
+	proposeCmd.Flags().Uint64Var(&maxReservedFundsFlag, "max_reserved_funds", 0, "Number of reserved funds an account holds at once, 0 for no limit, for a parameter change")
+	proposeCmd.Flags().Uint64Var(&maxReserveDurationFlag, "max_reserve_duration", 0, "Sum of the remaining durations of the reserved funds of an account, in blocks, 0 for no limit, for a parameter change")
+	proposeCmd.Flags().StringVar(&validatorFlag, "validator", "", "Address of the validator to jail")
+	proposeCmd.Flags().Uint64Var(&durationFlag, "duration", 0, "Number of epochs the validator is jailed")
+	proposeCmd.Flags().Uint64Var(&periodFlag, "period", types.MinimumProposalVotingPeriod, "Number of blocks the proposal accepts votes")
+	proposeCmd.Flags().StringVar(&descriptionFlag, "description", "", "Description of the proposal")
+	proposeCmd.Flags().Uint64Var(&seqFlag, "seq", 0, "Sequence number of the transaction")
+	proposeCmd.Flags().StringVar(&feeFlag, "fee", "", "Fee (estimated from the network if not set)")
+	proposeCmd.Flags().StringVar(&walletFlag, "wallet", "soft", "Wallet type (soft|nano|trezor|remote|daemon)")
+	proposeCmd.Flags().StringVar(&pathFlag, "path", "", "Derivation path of the address on the hardware wallet, e.g. m/44'/60'/0'/0")
+	proposeCmd.Flags().Uint32Var(&indexFlag, "index", 0, "Account index of the address on the hardware wallet, ignored if --path is set")
+	proposeCmd.Flags().BoolVar(&yesFlag, "yes", false, "Skip the confirmation prompt")
+	proposeCmd.Flags().BoolVar(&blindSignFlag, "blind-sign", false, "Sign the transaction even if it cannot be decoded for review")
+
+	proposeCmd.MarkFlagRequired("chain")
+	proposeCmd.MarkFlagRequired("from")
+	proposeCmd.MarkFlagRequired("kind")
+	proposeCmd.MarkFlagRequired("seq")
+}
@@ -0,0 +1,101 @@
+package tx
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/thetatoken/ukulele/cmd/banjo/cmd/utils"
+	"github.com/thetatoken/ukulele/ledger/types"
+)
+
+// recoverCmd represents the recover command. The recovery transaction is signed by the
+// guardians of the account one after another, and broadcasted by the last of them.
+// Example:
+//		banjo tx recover --chain="" --signer=9F1233798E905E173560071255140b4A8aBd3Ec6 --account=2E833968E5bB786Ae419c4d13189fB081Cc43bab --new_signer=0d2fD67d573c8ecB4161510fc00754d64B401F86 --guardians=9F1233798E905E173560071255140b4A8aBd3Ec6,A14a2DE2b0a8BF8c0A4fdB1f7dBf9Ed7e1b29a27 --seq=3
+//		banjo tx recover --chain="" --signer=A14a2DE2b0a8BF8c0A4fdB1f7dBf9Ed7e1b29a27 --broadcast <tx bytes>
+var recoverCmd = &cobra.Command{
+	Use:   "recover",
+	Short: "Sign the recovery of an account as one of its guardians",
+	Long: `Request to rotate the key controlling an account to the key of --new_signer, as one of the guardians
+of the account. The first guardian creates the recovery transaction from the flags, and the others add
+their signatures to the transaction bytes printed by the previous one. With --broadcast, the transaction
+is broadcasted once signed. The key rotation takes effect after the timelock of the guardianship, and can
+be cancelled with 'banjo tx set_guardians' by the current key of the account until then.`,
+	Example: `banjo tx recover --chain="" --signer=9F1233798E905E173560071255140b4A8aBd3Ec6 --account=2E833968E5bB786Ae419c4d13189fB081Cc43bab --new_signer=0d2fD67d573c8ecB4161510fc00754d64B401F86 --guardians=9F1233798E905E173560071255140b4A8aBd3Ec6,A14a2DE2b0a8BF8c0A4fdB1f7dBf9Ed7e1b29a27 --seq=3`,
+	Run:     doRecoverCmd,
+}
+
+func doRecoverCmd(cmd *cobra.Command, args []string) {
+	var recoveryTx *types.RecoveryTx
+	if len(args) > 0 {
+		txBytes, err := hex.DecodeString(strings.TrimPrefix(args[0], "0x"))
+		if err != nil {
+			utils.ErrorWithCode(utils.ExitCodeInvalidInput, "Invalid transaction bytes: %v\n", err)
+		}
+		tx, err := types.TxFromBytes(txBytes)
+		if err != nil {
+			utils.ErrorWithCode(utils.ExitCodeInvalidInput, "Failed to decode transaction: %v\n", err)
+		}
+		var ok bool
+		if recoveryTx, ok = tx.(*types.RecoveryTx); !ok {
+			utils.ErrorWithCode(utils.ExitCodeInvalidInput, "Not a recovery transaction: %v\n", tx)
+		}
+	} else {
+		if len(accountFlag) == 0 || len(newSignerFlag) == 0 || len(guardiansFlag) == 0 {
+			utils.ErrorWithCode(utils.ExitCodeInvalidInput, "Usage: banjo tx recover --chain=<chain ID> --signer=<address> --account=<address> --new_signer=<address> --guardians=<addresses> --seq=<sequence>|<tx bytes>\n")
+		}
+		fee := getFee()
+		recoveryTx = &types.RecoveryTx{
+			Fee: types.Coins{
+				ThetaWei: new(big.Int).SetUint64(0),
+				GammaWei: fee,
+			},
+			Account: types.TxInput{
+				Address:  resolveAddress(cmd, accountFlag),
+				Sequence: uint64(seqFlag),
+			},
+			NewSigner: resolveAddress(cmd, newSignerFlag),
+		}
+		for _, guardian := range guardiansFlag {
+			recoveryTx.Guardians = append(recoveryTx.Guardians, types.TxInput{Address: resolveAddress(cmd, guardian)})
+		}
+	}
+
+	wallet, signerAddress := walletUnlock(cmd, signerFlag)
+	defer wallet.Lock(signerAddress)
+
+	sig := signTx(wallet, signerAddress, recoveryTx.SignBytes(chainIDFlag))
+	recoveryTx.SetSignature(signerAddress, sig)
+
+	raw, err := types.TxToBytes(recoveryTx)
+	if err != nil {
+		utils.Error("Failed to encode transaction: %v\n", err)
+	}
+	if !broadcastFlag {
+		fmt.Printf("%v\n", hex.EncodeToString(raw))
+		return
+	}
+	broadcastRawTx(hex.EncodeToString(raw))
+}
+
+func init() {
+	recoverCmd.Flags().StringVar(&chainIDFlag, "chain", "", "Chain ID")
+	recoverCmd.Flags().StringVar(&signerFlag, "signer", "", "Address of the guardian signing the recovery")
+	recoverCmd.Flags().StringVar(&accountFlag, "account", "", "Address of the account to recover")
+	recoverCmd.Flags().StringVar(&newSignerFlag, "new_signer", "", "Address of the key to control the account")
+	recoverCmd.Flags().StringSliceVar(&guardiansFlag, "guardians", []string{}, "List of the addresses of the guardians approving the recovery")
+	recoverCmd.Flags().Uint64Var(&seqFlag, "seq", 0, "Sequence number of the transaction, the next sequence of the account")
+	recoverCmd.Flags().StringVar(&feeFlag, "fee", "", "Fee paid by the account (estimated from the network if not set)")
+	recoverCmd.Flags().BoolVar(&broadcastFlag, "broadcast", false, "Broadcast the transaction once signed")
+	recoverCmd.Flags().StringVar(&walletFlag, "wallet", "soft", "Wallet type (soft|nano|trezor|remote|daemon)")
+	recoverCmd.Flags().StringVar(&pathFlag, "path", "", "Derivation path of the address on the hardware wallet, e.g. m/44'/60'/0'/0")
+	recoverCmd.Flags().Uint32Var(&indexFlag, "index", 0, "Account index of the address on the hardware wallet, ignored if --path is set")
+	recoverCmd.Flags().BoolVar(&yesFlag, "yes", false, "Skip the confirmation prompt")
+	recoverCmd.Flags().BoolVar(&blindSignFlag, "blind-sign", false, "Sign the transaction even if it cannot be decoded for review")
+
+	recoverCmd.MarkFlagRequired("chain")
+	recoverCmd.MarkFlagRequired("signer")
+}
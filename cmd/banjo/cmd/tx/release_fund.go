@@ -25,10 +25,7 @@ var releaseFundCmd = &cobra.Command{
 }
 
 func doReleaseFundCmd(cmd *cobra.Command, args []string) {
-	wallet, fromAddress, err := walletUnlock(cmd, fromFlag)
-	if err != nil {
-		return
-	}
+	wallet, fromAddress := walletUnlock(cmd, fromFlag)
 	defer wallet.Lock(fromAddress)
 
 	input := types.TxInput{
@@ -36,23 +33,17 @@ func doReleaseFundCmd(cmd *cobra.Command, args []string) {
 		Sequence: uint64(seqFlag),
 	}
 
-	gamma, ok := types.ParseCoinAmount(feeFlag)
-	if !ok {
-		utils.Error("Failed to parse gamma amount")
-	}
+	fee := getFee()
 	releaseFundTx := &types.ReleaseFundTx{
 		Fee: types.Coins{
 			ThetaWei: new(big.Int).SetUint64(0),
-			GammaWei: gamma,
+			GammaWei: fee,
 		},
 		Source:          input,
 		ReserveSequence: reserveSeqFlag,
 	}
 
-	sig, err := wallet.Sign(fromAddress, releaseFundTx.SignBytes(chainIDFlag))
-	if err != nil {
-		utils.Error("Failed to sign transaction: %v\n", err)
-	}
+	sig := signTx(wallet, fromAddress, releaseFundTx.SignBytes(chainIDFlag))
 	releaseFundTx.SetSignature(fromAddress, sig)
 
 	raw, err := types.TxToBytes(releaseFundTx)
@@ -65,10 +56,10 @@ func doReleaseFundCmd(cmd *cobra.Command, args []string) {
 
 	res, err := client.Call("theta.BroadcastRawTransaction", rpc.BroadcastRawTransactionArgs{TxBytes: signedTx})
 	if err != nil {
-		utils.Error("Failed to broadcast transaction: %v\n", err)
+		utils.ErrorWithCode(utils.ExitCodeRPC, "Failed to broadcast transaction: %v\n", err)
 	}
 	if res.Error != nil {
-		utils.Error("Server returned error: %v\n", res.Error)
+		utils.ErrorWithCode(utils.ExitCodeServer, "Server returned error: %v\n", res.Error)
 	}
 	fmt.Printf("Successfully broadcasted transaction.\n")
 }
@@ -77,9 +68,13 @@ func init() {
 	releaseFundCmd.Flags().StringVar(&chainIDFlag, "chain", "", "Chain ID")
 	releaseFundCmd.Flags().StringVar(&fromFlag, "from", "", "Reserve owner's address")
 	releaseFundCmd.Flags().Uint64Var(&seqFlag, "seq", 0, "Sequence number of the transaction")
-	releaseFundCmd.Flags().StringVar(&feeFlag, "fee", fmt.Sprintf("%dwei", types.MinimumTransactionFeeGammaWei), "Fee")
+	releaseFundCmd.Flags().StringVar(&feeFlag, "fee", "", "Fee (estimated from the network if not set)")
 	releaseFundCmd.Flags().Uint64Var(&reserveSeqFlag, "reserve_seq", 1000, "Reserve sequence")
-	releaseFundCmd.Flags().StringVar(&walletFlag, "wallet", "soft", "Wallet type (soft|nano)")
+	releaseFundCmd.Flags().StringVar(&walletFlag, "wallet", "soft", "Wallet type (soft|nano|trezor|remote|daemon)")
+	releaseFundCmd.Flags().StringVar(&pathFlag, "path", "", "Derivation path of the address on the hardware wallet, e.g. m/44'/60'/0'/0")
+	releaseFundCmd.Flags().Uint32Var(&indexFlag, "index", 0, "Account index of the address on the hardware wallet, ignored if --path is set")
+	releaseFundCmd.Flags().BoolVar(&yesFlag, "yes", false, "Skip the confirmation prompt")
+	releaseFundCmd.Flags().BoolVar(&blindSignFlag, "blind-sign", false, "Sign the transaction even if it cannot be decoded for review")
 
 	releaseFundCmd.MarkFlagRequired("chain")
 	releaseFundCmd.MarkFlagRequired("from")
@@ -4,6 +4,7 @@ import (
 	"encoding/hex"
 	"fmt"
 	"math/big"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -25,23 +26,17 @@ var reserveFundCmd = &cobra.Command{
 }
 
 func doReserveFundCmd(cmd *cobra.Command, args []string) {
-	wallet, fromAddress, err := walletUnlock(cmd, fromFlag)
-	if err != nil {
-		return
-	}
+	wallet, fromAddress := walletUnlock(cmd, fromFlag)
 	defer wallet.Lock(fromAddress)
 
-	fee, ok := types.ParseCoinAmount(feeFlag)
-	if !ok {
-		utils.Error("Failed to parse fee")
-	}
+	fee := getFee()
 	fund, ok := types.ParseCoinAmount(reserveFundInGammaFlag)
 	if !ok {
-		utils.Error("Failed to parse fund")
+		utils.ErrorWithCode(utils.ExitCodeInvalidInput, "Failed to parse fund")
 	}
 	col, ok := types.ParseCoinAmount(reserveCollateralInGammaFlag)
 	if !ok {
-		utils.Error("Failed to parse collateral")
+		utils.ErrorWithCode(utils.ExitCodeInvalidInput, "Failed to parse collateral")
 	}
 	input := types.TxInput{
 		Address: fromAddress,
@@ -60,7 +55,25 @@ func doReserveFundCmd(cmd *cobra.Command, args []string) {
 		GammaWei: col,
 	}
 	if !collateral.IsPositive() {
-		utils.Error("Invalid input: collateral must be positive\n")
+		utils.ErrorWithCode(utils.ExitCodeInvalidInput, "Invalid input: collateral must be positive\n")
+	}
+	spendLimits := []types.ResourceSpendLimit{}
+	for _, sl := range spendLimitsFlag {
+		parts := strings.SplitN(sl, ":", 2)
+		if len(parts) != 2 {
+			utils.ErrorWithCode(utils.ExitCodeInvalidInput, "Invalid spend limit %v, expecting <resource_id>:<amount>\n", sl)
+		}
+		limit, ok := types.ParseCoinAmount(parts[1])
+		if !ok {
+			utils.ErrorWithCode(utils.ExitCodeInvalidInput, "Failed to parse the spend limit of resource %v\n", parts[0])
+		}
+		spendLimits = append(spendLimits, types.ResourceSpendLimit{
+			ResourceID: parts[0],
+			Limit: types.Coins{
+				ThetaWei: new(big.Int).SetUint64(0),
+				GammaWei: limit,
+			},
+		})
 	}
 
 	reserveFundTx := &types.ReserveFundTx{
@@ -72,12 +85,10 @@ func doReserveFundCmd(cmd *cobra.Command, args []string) {
 		ResourceIDs: resourceIDs,
 		Collateral:  collateral,
 		Duration:    durationFlag,
+		SpendLimits: spendLimits,
 	}
 
-	sig, err := wallet.Sign(fromAddress, reserveFundTx.SignBytes(chainIDFlag))
-	if err != nil {
-		utils.Error("Failed to sign transaction: %v\n", err)
-	}
+	sig := signTx(wallet, fromAddress, reserveFundTx.SignBytes(chainIDFlag))
 	reserveFundTx.SetSignature(fromAddress, sig)
 
 	raw, err := types.TxToBytes(reserveFundTx)
@@ -90,10 +101,10 @@ func doReserveFundCmd(cmd *cobra.Command, args []string) {
 
 	res, err := client.Call("theta.BroadcastRawTransaction", rpc.BroadcastRawTransactionArgs{TxBytes: signedTx})
 	if err != nil {
-		utils.Error("Failed to broadcast transaction: %v\n", err)
+		utils.ErrorWithCode(utils.ExitCodeRPC, "Failed to broadcast transaction: %v\n", err)
 	}
 	if res.Error != nil {
-		utils.Error("Server returned error: %v\n", res.Error)
+		utils.ErrorWithCode(utils.ExitCodeServer, "Server returned error: %v\n", res.Error)
 	}
 	fmt.Printf("Successfully broadcasted transaction.\n")
 }
@@ -104,10 +115,15 @@ func init() {
 	reserveFundCmd.Flags().Uint64Var(&seqFlag, "seq", 0, "Sequence number of the transaction")
 	reserveFundCmd.Flags().StringVar(&reserveFundInGammaFlag, "fund", "0", "Gamma amount to reserve")
 	reserveFundCmd.Flags().StringVar(&reserveCollateralInGammaFlag, "collateral", "0", "Gamma amount as collateral")
-	reserveFundCmd.Flags().StringVar(&feeFlag, "fee", fmt.Sprintf("%dwei", types.MinimumTransactionFeeGammaWei), "Fee")
+	reserveFundCmd.Flags().StringVar(&feeFlag, "fee", "", "Fee (estimated from the network if not set)")
 	reserveFundCmd.Flags().Uint64Var(&durationFlag, "duration", 1000, "Reserve duration")
 	reserveFundCmd.Flags().StringSliceVar(&resourceIDsFlag, "resource_ids", []string{}, "Reserouce IDs")
-	reserveFundCmd.Flags().StringVar(&walletFlag, "wallet", "soft", "Wallet type (soft|nano)")
+	reserveFundCmd.Flags().StringSliceVar(&spendLimitsFlag, "spend_limits", []string{}, "Gamma amounts the reserve can pay at most for some of the resources, e.g. rid1000001:50")
+	reserveFundCmd.Flags().StringVar(&walletFlag, "wallet", "soft", "Wallet type (soft|nano|trezor|remote|daemon)")
+	reserveFundCmd.Flags().StringVar(&pathFlag, "path", "", "Derivation path of the address on the hardware wallet, e.g. m/44'/60'/0'/0")
+	reserveFundCmd.Flags().Uint32Var(&indexFlag, "index", 0, "Account index of the address on the hardware wallet, ignored if --path is set")
+	reserveFundCmd.Flags().BoolVar(&yesFlag, "yes", false, "Skip the confirmation prompt")
+	reserveFundCmd.Flags().BoolVar(&blindSignFlag, "blind-sign", false, "Sign the transaction even if it cannot be decoded for review")
 
 	reserveFundCmd.MarkFlagRequired("chain")
 	reserveFundCmd.MarkFlagRequired("from")
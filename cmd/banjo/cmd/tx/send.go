@@ -4,21 +4,22 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"math/big"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"github.com/thetatoken/ukulele/cmd/banjo/cmd/utils"
-	"github.com/thetatoken/ukulele/common"
 	"github.com/thetatoken/ukulele/ledger/types"
 	"github.com/thetatoken/ukulele/rpc"
 
 	rpcc "github.com/ybbus/jsonrpc"
 )
 
-// sendCmd represents the send command
+// sendCmd represents the send command. With --unlock_height, the recipient cannot spend
+// the coins until the unlock height.
 // Example:
 //		banjo tx send --chain="" --from=2E833968E5bB786Ae419c4d13189fB081Cc43bab --to=9F1233798E905E173560071255140b4A8aBd3Ec6 --theta=10 --gamma=900000 --seq=1
+//		banjo tx send --chain="" --from=2E833968E5bB786Ae419c4d13189fB081Cc43bab --to=9F1233798E905E173560071255140b4A8aBd3Ec6 --theta=10 --seq=2 --unlock_height=3000000
+//		banjo tx send --chain="" --from=2E833968E5bB786Ae419c4d13189fB081Cc43bab --to=9F1233798E905E173560071255140b4A8aBd3Ec6 --tokens=ABC:1000000 --seq=3
 var sendCmd = &cobra.Command{
 	Use:     "send",
 	Short:   "Send tokens",
@@ -27,55 +28,45 @@ var sendCmd = &cobra.Command{
 }
 
 func doSendCmd(cmd *cobra.Command, args []string) {
-	wallet, fromAddress, err := walletUnlock(cmd, fromFlag)
-	if err != nil {
-		return
-	}
+	wallet, fromAddress := walletUnlock(cmd, fromFlag)
 	defer wallet.Lock(fromAddress)
 
 	theta, ok := types.ParseCoinAmount(thetaAmountFlag)
 	if !ok {
-		utils.Error("Failed to parse theta amount")
+		utils.ErrorWithCode(utils.ExitCodeInvalidInput, "Failed to parse theta amount")
 	}
 	gamma, ok := types.ParseCoinAmount(gammaAmountFlag)
 	if !ok {
-		utils.Error("Failed to parse gamma amount")
-	}
-	fee, ok := types.ParseCoinAmount(feeFlag)
-	if !ok {
-		utils.Error("Failed to parse fee")
+		utils.ErrorWithCode(utils.ExitCodeInvalidInput, "Failed to parse gamma amount")
 	}
-	inputs := []types.TxInput{{
-		Address: fromAddress,
-		Coins: types.Coins{
-			GammaWei: new(big.Int).Add(gamma, fee),
-			ThetaWei: theta,
-		},
-		Sequence: uint64(seqFlag),
-	}}
-	outputs := []types.TxOutput{{
-		Address: common.HexToAddress(toFlag),
-		Coins: types.Coins{
-			GammaWei: gamma,
-			ThetaWei: theta,
-		},
-	}}
-	sendTx := &types.SendTx{
-		Fee: types.Coins{
-			ThetaWei: new(big.Int).SetUint64(0),
-			GammaWei: fee,
-		},
-		Inputs:  inputs,
-		Outputs: outputs,
+	coins := types.Coins{
+		GammaWei: gamma,
+		ThetaWei: theta,
+		Tokens:   parseTokens(tokensFlag),
 	}
-
-	sig, err := wallet.Sign(fromAddress, sendTx.SignBytes(chainIDFlag))
+	sendTx, err := types.NewSendTxBuilder().From(fromAddress).Sequence(uint64(seqFlag)).
+		To(resolveAddress(cmd, toFlag)).Amount(coins).Fee(getFee()).Build()
 	if err != nil {
-		utils.Error("Failed to sign transaction: %v\n", err)
+		utils.ErrorWithCode(utils.ExitCodeInvalidInput, "Invalid transaction: %v\n", err)
 	}
-	sendTx.SetSignature(fromAddress, sig)
 
-	raw, err := types.TxToBytes(sendTx)
+	var raw []byte
+	if unlockHeightFlag == 0 {
+		sig := signTx(wallet, fromAddress, sendTx.SignBytes(chainIDFlag))
+		sendTx.SetSignature(fromAddress, sig)
+		raw, err = types.TxToBytes(sendTx)
+	} else {
+		// The recipient cannot spend the coins until the unlock height
+		timelockedSendTx := &types.TimelockedSendTx{
+			Fee:          sendTx.Fee,
+			Inputs:       sendTx.Inputs,
+			Outputs:      sendTx.Outputs,
+			UnlockHeight: unlockHeightFlag,
+		}
+		sig := signTx(wallet, fromAddress, timelockedSendTx.SignBytes(chainIDFlag))
+		timelockedSendTx.SetSignature(fromAddress, sig)
+		raw, err = types.TxToBytes(timelockedSendTx)
+	}
 	if err != nil {
 		utils.Error("Failed to encode transaction: %v\n", err)
 	}
@@ -85,19 +76,19 @@ func doSendCmd(cmd *cobra.Command, args []string) {
 
 	res, err := client.Call("theta.BroadcastRawTransaction", rpc.BroadcastRawTransactionArgs{TxBytes: signedTx})
 	if err != nil {
-		utils.Error("Failed to broadcast transaction: %v\n", err)
+		utils.ErrorWithCode(utils.ExitCodeRPC, "Failed to broadcast transaction: %v\n", err)
 	}
 	if res.Error != nil {
-		utils.Error("Server returned error: %v\n", res.Error)
+		utils.ErrorWithCode(utils.ExitCodeServer, "Server returned error: %v\n", res.Error)
 	}
 	result := &rpc.BroadcastRawTransactionResult{}
 	err = res.GetObject(result)
 	if err != nil {
-		utils.Error("Failed to parse server response: %v\n", err)
+		utils.ErrorWithCode(utils.ExitCodeRPC, "Failed to parse server response: %v\n", err)
 	}
 	formatted, err := json.MarshalIndent(result, "", "    ")
 	if err != nil {
-		utils.Error("Failed to parse server response: %v\n", err)
+		utils.ErrorWithCode(utils.ExitCodeRPC, "Failed to parse server response: %v\n", err)
 	}
 	fmt.Printf("Successfully broadcasted transaction:\n%s\n", formatted)
 }
@@ -109,8 +100,14 @@ func init() {
 	sendCmd.Flags().Uint64Var(&seqFlag, "seq", 0, "Sequence number of the transaction")
 	sendCmd.Flags().StringVar(&thetaAmountFlag, "theta", "0", "Theta amount")
 	sendCmd.Flags().StringVar(&gammaAmountFlag, "gamma", "0", "Gamma amount")
-	sendCmd.Flags().StringVar(&feeFlag, "fee", fmt.Sprintf("%dwei", types.MinimumTransactionFeeGammaWei), "Fee")
-	sendCmd.Flags().StringVar(&walletFlag, "wallet", "soft", "Wallet type (soft|nano)")
+	sendCmd.Flags().StringSliceVar(&tokensFlag, "tokens", []string{}, "Application tokens to send, as SYMBOL:amount in the smallest unit of the token")
+	sendCmd.Flags().Uint64Var(&unlockHeightFlag, "unlock_height", 0, "Block height until which the recipient cannot spend the coins, if set")
+	sendCmd.Flags().StringVar(&feeFlag, "fee", "", "Fee (estimated from the network if not set)")
+	sendCmd.Flags().StringVar(&walletFlag, "wallet", "soft", "Wallet type (soft|nano|trezor|remote|daemon)")
+	sendCmd.Flags().StringVar(&pathFlag, "path", "", "Derivation path of the address on the hardware wallet, e.g. m/44'/60'/0'/0")
+	sendCmd.Flags().Uint32Var(&indexFlag, "index", 0, "Account index of the address on the hardware wallet, ignored if --path is set")
+	sendCmd.Flags().BoolVar(&yesFlag, "yes", false, "Skip the confirmation prompt")
+	sendCmd.Flags().BoolVar(&blindSignFlag, "blind-sign", false, "Sign the transaction even if it cannot be decoded for review")
 
 	sendCmd.MarkFlagRequired("chain")
 	sendCmd.MarkFlagRequired("from")
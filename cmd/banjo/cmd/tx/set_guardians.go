@@ -0,0 +1,107 @@
+package tx
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/thetatoken/ukulele/cmd/banjo/cmd/utils"
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/ledger/types"
+	"github.com/thetatoken/ukulele/rpc"
+
+	rpcc "github.com/ybbus/jsonrpc"
+)
+
+// setGuardiansCmd represents the set_guardians command. The guardians can recover the
+// account if its key is lost, see the recover command.
+// Example:
+//		banjo tx set_guardians --chain="" --from=2E833968E5bB786Ae419c4d13189fB081Cc43bab --guardians=9F1233798E905E173560071255140b4A8aBd3Ec6,A14a2DE2b0a8BF8c0A4fdB1f7dBf9Ed7e1b29a27 --threshold=2 --timelock=28800 --seq=2
+var setGuardiansCmd = &cobra.Command{
+	Use:   "set_guardians",
+	Short: "Register the guardians able to recover an account",
+	Long: `Register the guardians able to recover an account whose key is lost. The recovery needs the
+approval of --threshold guardians, and takes effect --timelock blocks after it is requested. Setting
+the guardians again cancels a pending recovery, and setting no guardians removes them.`,
+	Example: `banjo tx set_guardians --chain="" --from=2E833968E5bB786Ae419c4d13189fB081Cc43bab --guardians=9F1233798E905E173560071255140b4A8aBd3Ec6,A14a2DE2b0a8BF8c0A4fdB1f7dBf9Ed7e1b29a27 --threshold=2 --timelock=28800 --seq=2`,
+	Run:     doSetGuardiansCmd,
+}
+
+func doSetGuardiansCmd(cmd *cobra.Command, args []string) {
+	wallet, fromAddress := walletUnlock(cmd, fromFlag)
+	defer wallet.Lock(fromAddress)
+
+	guardians := []common.Address{}
+	for _, guardian := range guardiansFlag {
+		guardians = append(guardians, resolveAddress(cmd, guardian))
+	}
+
+	fee := getFee()
+	setGuardiansTx := &types.SetGuardiansTx{
+		Fee: types.Coins{
+			ThetaWei: new(big.Int).SetUint64(0),
+			GammaWei: fee,
+		},
+		Account: types.TxInput{
+			Address:  fromAddress,
+			Sequence: uint64(seqFlag),
+		},
+		Guardians: guardians,
+		Threshold: thresholdFlag,
+		Timelock:  timelockFlag,
+	}
+
+	sig := signTx(wallet, fromAddress, setGuardiansTx.SignBytes(chainIDFlag))
+	setGuardiansTx.SetSignature(fromAddress, sig)
+
+	raw, err := types.TxToBytes(setGuardiansTx)
+	if err != nil {
+		utils.Error("Failed to encode transaction: %v\n", err)
+	}
+	broadcastRawTx(hex.EncodeToString(raw))
+}
+
+// broadcastRawTx broadcasts the signed transaction and prints the result
+func broadcastRawTx(signedTx string) {
+	client := rpcc.NewRPCClient(viper.GetString(utils.CfgRemoteRPCEndpoint))
+
+	res, err := client.Call("theta.BroadcastRawTransaction", rpc.BroadcastRawTransactionArgs{TxBytes: signedTx})
+	if err != nil {
+		utils.ErrorWithCode(utils.ExitCodeRPC, "Failed to broadcast transaction: %v\n", err)
+	}
+	if res.Error != nil {
+		utils.ErrorWithCode(utils.ExitCodeServer, "Server returned error: %v\n", res.Error)
+	}
+	result := &rpc.BroadcastRawTransactionResult{}
+	err = res.GetObject(result)
+	if err != nil {
+		utils.ErrorWithCode(utils.ExitCodeRPC, "Failed to parse server response: %v\n", err)
+	}
+	formatted, err := json.MarshalIndent(result, "", "    ")
+	if err != nil {
+		utils.ErrorWithCode(utils.ExitCodeRPC, "Failed to parse server response: %v\n", err)
+	}
+	fmt.Printf("Successfully broadcasted transaction:\n%s\n", formatted)
+}
+
+func init() {
+	setGuardiansCmd.Flags().StringVar(&chainIDFlag, "chain", "", "Chain ID")
+	setGuardiansCmd.Flags().StringVar(&fromFlag, "from", "", "Address of the account")
+	setGuardiansCmd.Flags().StringSliceVar(&guardiansFlag, "guardians", []string{}, "List of the addresses of the guardians, none removes the guardians")
+	setGuardiansCmd.Flags().Uint64Var(&thresholdFlag, "threshold", 0, "Number of guardians needed to recover the account")
+	setGuardiansCmd.Flags().Uint64Var(&timelockFlag, "timelock", types.MinimumRecoveryTimelock, "Number of blocks between a recovery request and the key rotation")
+	setGuardiansCmd.Flags().Uint64Var(&seqFlag, "seq", 0, "Sequence number of the transaction")
+	setGuardiansCmd.Flags().StringVar(&feeFlag, "fee", "", "Fee (estimated from the network if not set)")
+	setGuardiansCmd.Flags().StringVar(&walletFlag, "wallet", "soft", "Wallet type (soft|nano|trezor|remote|daemon)")
+	setGuardiansCmd.Flags().StringVar(&pathFlag, "path", "", "Derivation path of the address on the hardware wallet, e.g. m/44'/60'/0'/0")
+	setGuardiansCmd.Flags().Uint32Var(&indexFlag, "index", 0, "Account index of the address on the hardware wallet, ignored if --path is set")
+	setGuardiansCmd.Flags().BoolVar(&yesFlag, "yes", false, "Skip the confirmation prompt")
+	setGuardiansCmd.Flags().BoolVar(&blindSignFlag, "blind-sign", false, "Sign the transaction even if it cannot be decoded for review")
+
+	setGuardiansCmd.MarkFlagRequired("chain")
+	setGuardiansCmd.MarkFlagRequired("from")
+	setGuardiansCmd.MarkFlagRequired("seq")
+}
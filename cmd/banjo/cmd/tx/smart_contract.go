@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"math/big"
 
-	"github.com/thetatoken/ukulele/common"
 	"github.com/thetatoken/ukulele/ledger/types"
 	"github.com/thetatoken/ukulele/rpc"
 
@@ -23,7 +22,7 @@ import (
 //   * Deploy a smart contract
 //		banjo tx smart_contract --chain="" --from=2E833968E5bB786Ae419c4d13189fB081Cc43bab --value=1680 --gas_price=3 --gas_limit=50000 --data=600a600c600039600a6000f3600360135360016013f3 --seq=1
 //   * Call an API of a smart contract
-//		banjo tx smart_contract --chain="" --from=2E833968E5bB786Ae419c4d13189fB081Cc43bab --to=0x7ad6cea2bc3162e30a3c98d84f821b3233c22647 --gas_price=3 --gas_limit=50000 --seq=2
+//		banjo tx smart_contract --chain="" --from=2E833968E5bB786Ae419c4d13189fB081Cc43bab --to=0x7ad6cEA2BC3162E30A3C98d84f821b3233C22647 --gas_price=3 --gas_limit=50000 --seq=2
 
 var smartContractCmd = &cobra.Command{
 	Use:   "smart_contract",
@@ -33,25 +32,22 @@ var smartContractCmd = &cobra.Command{
 	banjo tx smart_contract --chain="" --from=2E833968E5bB786Ae419c4d13189fB081Cc43bab --value=1680 --gas_price=3 --gas_limit=50000 --data=600a600c600039600a6000f3600360135360016013f3 --seq=1	
 	
 	[Call an API of a smart contract]
-	banjo tx smart_contract --chain="" --from=2E833968E5bB786Ae419c4d13189fB081Cc43bab --to=0x7ad6cea2bc3162e30a3c98d84f821b3233c22647 --gas_price=3 --gas_limit=50000 --seq=2`,
+	banjo tx smart_contract --chain="" --from=2E833968E5bB786Ae419c4d13189fB081Cc43bab --to=0x7ad6cEA2BC3162E30A3C98d84f821b3233C22647 --gas_price=3 --gas_limit=50000 --seq=2`,
 	Long: "smartContractCmd represents the smart_contract command. It will submit a smart contract transaction to the blockchain, which will modify the global consensus state when it is included in the blockchain",
 	Run:   doSmartContractCmd,
 }
 
 func doSmartContractCmd(cmd *cobra.Command, args []string) {
-	wallet, fromAddress, err := walletUnlock(cmd, fromFlag)
-	if err != nil {
-		return
-	}
+	wallet, fromAddress := walletUnlock(cmd, fromFlag)
 	defer wallet.Lock(fromAddress)
 
 	value, ok := types.ParseCoinAmount(valueFlag)
 	if !ok {
-		utils.Error("Failed to parse value")
+		utils.ErrorWithCode(utils.ExitCodeInvalidInput, "Failed to parse value")
 	}
 
 	from := types.TxInput{
-		Address: common.HexToAddress(fromFlag),
+		Address: fromAddress,
 		Coins: types.Coins{
 			ThetaWei: new(big.Int).SetUint64(0),
 			GammaWei: value,
@@ -59,18 +55,16 @@ func doSmartContractCmd(cmd *cobra.Command, args []string) {
 		Sequence: seqFlag,
 	}
 
-	to := types.TxOutput{
-		Address: common.HexToAddress(toFlag),
+	to := types.TxOutput{}
+	if len(toFlag) != 0 {
+		to.Address = resolveAddress(cmd, toFlag)
 	}
 
-	gasPrice, ok := types.ParseCoinAmount(gasPriceFlag)
-	if !ok {
-		utils.Error("Failed to parse gas price")
-	}
+	gasPrice := getGasPrice()
 
 	data, err := hex.DecodeString(dataFlag)
 	if err != nil {
-		return
+		utils.ErrorWithCode(utils.ExitCodeInvalidInput, "Failed to decode data: %v\n", err)
 	}
 
 	smartContractTx := &types.SmartContractTx{
@@ -81,10 +75,7 @@ func doSmartContractCmd(cmd *cobra.Command, args []string) {
 		Data:     data,
 	}
 
-	sig, err := wallet.Sign(fromAddress, smartContractTx.SignBytes(chainIDFlag))
-	if err != nil {
-		utils.Error("Failed to sign transaction: %v\n", err)
-	}
+	sig := signTx(wallet, fromAddress, smartContractTx.SignBytes(chainIDFlag))
 	smartContractTx.SetSignature(fromAddress, sig)
 
 	raw, err := types.TxToBytes(smartContractTx)
@@ -97,19 +88,19 @@ func doSmartContractCmd(cmd *cobra.Command, args []string) {
 
 	res, err := client.Call("theta.BroadcastRawTransaction", rpc.BroadcastRawTransactionArgs{TxBytes: signedTx})
 	if err != nil {
-		utils.Error("Failed to broadcast transaction: %v\n", err)
+		utils.ErrorWithCode(utils.ExitCodeRPC, "Failed to broadcast transaction: %v\n", err)
 	}
 	if res.Error != nil {
-		utils.Error("Server returned error: %v\n", res.Error)
+		utils.ErrorWithCode(utils.ExitCodeServer, "Server returned error: %v\n", res.Error)
 	}
 	result := &rpc.BroadcastRawTransactionResult{}
 	err = res.GetObject(result)
 	if err != nil {
-		utils.Error("Failed to parse server response: %v\n", err)
+		utils.ErrorWithCode(utils.ExitCodeRPC, "Failed to parse server response: %v\n", err)
 	}
 	formatted, err := json.MarshalIndent(result, "", "    ")
 	if err != nil {
-		utils.Error("Failed to parse server response: %v\n", err)
+		utils.ErrorWithCode(utils.ExitCodeRPC, "Failed to parse server response: %v\n", err)
 	}
 	fmt.Printf("Successfully broadcasted transaction:\n%s\n", formatted)
 }
@@ -119,15 +110,18 @@ func init() {
 	smartContractCmd.Flags().StringVar(&fromFlag, "from", "", "The caller address")
 	smartContractCmd.Flags().StringVar(&toFlag, "to", "", "The smart contract address")
 	smartContractCmd.Flags().StringVar(&valueFlag, "value", "0", "Value to be transferred")
-	smartContractCmd.Flags().StringVar(&gasPriceFlag, "gas_price", fmt.Sprintf("%dwei", types.MinimumGasPrice), "The gas price")
+	smartContractCmd.Flags().StringVar(&gasPriceFlag, "gas_price", "", "The gas price (estimated from the network if not set)")
 	smartContractCmd.Flags().Uint64Var(&gasLimitFlag, "gas_limit", 0, "The gas limit")
 	smartContractCmd.Flags().StringVar(&dataFlag, "data", "", "The data for the smart contract")
 	smartContractCmd.Flags().Uint64Var(&seqFlag, "seq", 0, "Sequence number of the transaction")
-	smartContractCmd.Flags().StringVar(&walletFlag, "wallet", "soft", "Wallet type (soft|nano)")
+	smartContractCmd.Flags().StringVar(&walletFlag, "wallet", "soft", "Wallet type (soft|nano|trezor|remote|daemon)")
+	smartContractCmd.Flags().StringVar(&pathFlag, "path", "", "Derivation path of the address on the hardware wallet, e.g. m/44'/60'/0'/0")
+	smartContractCmd.Flags().Uint32Var(&indexFlag, "index", 0, "Account index of the address on the hardware wallet, ignored if --path is set")
+	smartContractCmd.Flags().BoolVar(&yesFlag, "yes", false, "Skip the confirmation prompt")
+	smartContractCmd.Flags().BoolVar(&blindSignFlag, "blind-sign", false, "Sign the transaction even if it cannot be decoded for review")
 
 	smartContractCmd.MarkFlagRequired("chain")
 	smartContractCmd.MarkFlagRequired("from")
-	smartContractCmd.MarkFlagRequired("gas_price")
 	smartContractCmd.MarkFlagRequired("gas_limit")
 	smartContractCmd.MarkFlagRequired("seq")
 }
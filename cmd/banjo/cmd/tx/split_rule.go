@@ -9,7 +9,6 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"github.com/thetatoken/ukulele/cmd/banjo/cmd/utils"
-	"github.com/thetatoken/ukulele/common"
 	"github.com/thetatoken/ukulele/ledger/types"
 	"github.com/thetatoken/ukulele/rpc"
 
@@ -27,10 +26,7 @@ var splitRuleCmd = &cobra.Command{
 }
 
 func doSplitRuleCmd(cmd *cobra.Command, args []string) {
-	wallet, fromAddress, err := walletUnlock(cmd, fromFlag)
-	if err != nil {
-		return
-	}
+	wallet, fromAddress := walletUnlock(cmd, fromFlag)
 	defer wallet.Lock(fromAddress)
 
 	input := types.TxInput{
@@ -38,53 +34,24 @@ func doSplitRuleCmd(cmd *cobra.Command, args []string) {
 		Sequence: uint64(seqFlag),
 	}
 
-	if len(addressesFlag) != len(percentagesFlag) {
-		fmt.Println("Should have the same number of addresses and percentages")
-		return
-	}
-	var splits []types.Split
-	for idx, addressStr := range addressesFlag {
-		percentageStr := percentagesFlag[idx]
-
-		address, err := hex.DecodeString(addressStr)
-		if err != nil {
-			fmt.Println("The address must be a hex string")
-			return
-		}
-
-		percentage, err := strconv.ParseUint(percentageStr, 10, 32)
-		if err != nil {
-			fmt.Println(err)
-			return
-		}
-
-		split := types.Split{
-			Address:    common.BytesToAddress(address),
-			Percentage: uint(percentage),
-		}
-		splits = append(splits, split)
-	}
+	splits := parseSplits(cmd, addressesFlag, percentagesFlag)
+	platformSplits := parseSplits(cmd, platformAddressesFlag, platformPercentagesFlag)
 
-	fee, ok := types.ParseCoinAmount(feeFlag)
-	if !ok {
-		utils.Error("Failed to parse fee")
-	}
+	fee := getFee()
 
 	splitRuleTx := &types.SplitRuleTx{
 		Fee: types.Coins{
 			ThetaWei: new(big.Int).SetUint64(0),
 			GammaWei: fee,
 		},
-		ResourceID: resourceIDFlag,
-		Initiator:  input,
-		Duration:   durationFlag,
-		Splits:     splits,
+		ResourceID:     resourceIDFlag,
+		Initiator:      input,
+		Duration:       durationFlag,
+		Splits:         splits,
+		PlatformSplits: platformSplits,
 	}
 
-	sig, err := wallet.Sign(fromAddress, splitRuleTx.SignBytes(chainIDFlag))
-	if err != nil {
-		utils.Error("Failed to sign transaction: %v\n", err)
-	}
+	sig := signTx(wallet, fromAddress, splitRuleTx.SignBytes(chainIDFlag))
 	splitRuleTx.SetSignature(fromAddress, sig)
 
 	raw, err := types.TxToBytes(splitRuleTx)
@@ -97,24 +64,54 @@ func doSplitRuleCmd(cmd *cobra.Command, args []string) {
 
 	res, err := client.Call("theta.BroadcastRawTransaction", rpc.BroadcastRawTransactionArgs{TxBytes: signedTx})
 	if err != nil {
-		utils.Error("Failed to broadcast transaction: %v\n", err)
+		utils.ErrorWithCode(utils.ExitCodeRPC, "Failed to broadcast transaction: %v\n", err)
 	}
 	if res.Error != nil {
-		utils.Error("Server returned error: %v\n", res.Error)
+		utils.ErrorWithCode(utils.ExitCodeServer, "Server returned error: %v\n", res.Error)
 	}
 	fmt.Printf("Successfully broadcasted transaction.\n")
 }
 
+func parseSplits(cmd *cobra.Command, addresses []string, percentages []string) []types.Split {
+	if len(addresses) != len(percentages) {
+		utils.ErrorWithCode(utils.ExitCodeInvalidInput, "Should have the same number of addresses and percentages\n")
+	}
+	var splits []types.Split
+	for idx, addressStr := range addresses {
+		percentageStr := percentages[idx]
+
+		address := resolveAddress(cmd, addressStr)
+
+		percentage, err := strconv.ParseUint(percentageStr, 10, 32)
+		if err != nil {
+			utils.ErrorWithCode(utils.ExitCodeInvalidInput, "Failed to parse percentage: %v\n", err)
+		}
+
+		split := types.Split{
+			Address:    address,
+			Percentage: uint(percentage),
+		}
+		splits = append(splits, split)
+	}
+	return splits
+}
+
 func init() {
 	splitRuleCmd.Flags().StringVar(&chainIDFlag, "chain", "", "Chain ID")
 	splitRuleCmd.Flags().StringVar(&fromFlag, "from", "", "Initiator's address")
 	splitRuleCmd.Flags().Uint64Var(&seqFlag, "seq", 0, "Sequence number of the transaction")
-	splitRuleCmd.Flags().StringVar(&feeFlag, "fee", fmt.Sprintf("%dwei", types.MinimumTransactionFeeGammaWei), "Fee")
+	splitRuleCmd.Flags().StringVar(&feeFlag, "fee", "", "Fee (estimated from the network if not set)")
 	splitRuleCmd.Flags().StringVar(&resourceIDFlag, "resource_id", "", "The resourceID of interest")
 	splitRuleCmd.Flags().StringSliceVar(&addressesFlag, "addresses", []string{}, "List of addresses participating in the split")
 	splitRuleCmd.Flags().StringSliceVar(&percentagesFlag, "percentages", []string{}, "List of integers (between 0 and 100) representing of percentage of split")
+	splitRuleCmd.Flags().StringSliceVar(&platformAddressesFlag, "platform_addresses", []string{}, "List of addresses taking their percentages of the full payment before the split, e.g. for a platform fee")
+	splitRuleCmd.Flags().StringSliceVar(&platformPercentagesFlag, "platform_percentages", []string{}, "List of integers (between 0 and 100) representing of percentage of the platform split")
 	splitRuleCmd.Flags().Uint64Var(&durationFlag, "duration", 1000, "Reserve duration")
-	splitRuleCmd.Flags().StringVar(&walletFlag, "wallet", "soft", "Wallet type (soft|nano)")
+	splitRuleCmd.Flags().StringVar(&walletFlag, "wallet", "soft", "Wallet type (soft|nano|trezor|remote|daemon)")
+	splitRuleCmd.Flags().StringVar(&pathFlag, "path", "", "Derivation path of the address on the hardware wallet, e.g. m/44'/60'/0'/0")
+	splitRuleCmd.Flags().Uint32Var(&indexFlag, "index", 0, "Account index of the address on the hardware wallet, ignored if --path is set")
+	splitRuleCmd.Flags().BoolVar(&yesFlag, "yes", false, "Skip the confirmation prompt")
+	splitRuleCmd.Flags().BoolVar(&blindSignFlag, "blind-sign", false, "Sign the transaction even if it cannot be decoded for review")
 
 	splitRuleCmd.MarkFlagRequired("chain")
 	splitRuleCmd.MarkFlagRequired("from")
@@ -0,0 +1,68 @@
+package tx
+
+import (
+	"encoding/hex"
+	"math/big"
+
+	"github.com/spf13/cobra"
+	"github.com/thetatoken/ukulele/cmd/banjo/cmd/utils"
+	"github.com/thetatoken/ukulele/ledger/types"
+)
+
+// unjailCmd represents the unjail command. A validator jailed for missing its proposals
+// returns to the proposer rotation once the unjail cooldown has passed.
+// Example:
+//		banjo tx unjail --chain="" --from=2E833968E5bB786Ae419c4d13189fB081Cc43bab --seq=6
+var unjailCmd = &cobra.Command{
+	Use:     "unjail",
+	Short:   "Return a validator jailed for downtime to the proposer rotation",
+	Long:    `Return a validator jailed for missing its proposals to the proposer rotation, once the unjail cooldown has passed since it was jailed.`,
+	Example: `banjo tx unjail --chain="" --from=2E833968E5bB786Ae419c4d13189fB081Cc43bab --seq=6`,
+	Run:     doUnjailCmd,
+}
+
+func doUnjailCmd(cmd *cobra.Command, args []string) {
+	wallet, fromAddress := walletUnlock(cmd, fromFlag)
+	defer wallet.Lock(fromAddress)
+
+	fee := getFee()
+	unjailTx := &types.UnjailTx{
+		Fee: types.Coins{
+			ThetaWei: new(big.Int).SetUint64(0),
+			GammaWei: fee,
+		},
+		Validator: types.TxInput{
+			Address: fromAddress,
+			Coins: types.Coins{
+				ThetaWei: new(big.Int).SetUint64(0),
+				GammaWei: new(big.Int).SetUint64(0),
+			},
+			Sequence: uint64(seqFlag),
+		},
+	}
+
+	sig := signTx(wallet, fromAddress, unjailTx.SignBytes(chainIDFlag))
+	unjailTx.SetSignature(fromAddress, sig)
+
+	raw, err := types.TxToBytes(unjailTx)
+	if err != nil {
+		utils.Error("Failed to encode transaction: %v\n", err)
+	}
+	broadcastRawTx(hex.EncodeToString(raw))
+}
+
+func init() {
+	unjailCmd.Flags().StringVar(&chainIDFlag, "chain", "", "Chain ID")
+	unjailCmd.Flags().StringVar(&fromFlag, "from", "", "Address of the jailed validator")
+	unjailCmd.Flags().Uint64Var(&seqFlag, "seq", 0, "Sequence number of the transaction")
+	unjailCmd.Flags().StringVar(&feeFlag, "fee", "", "Fee (estimated from the network if not set)")
+	unjailCmd.Flags().StringVar(&walletFlag, "wallet", "soft", "Wallet type (soft|nano|trezor|remote|daemon)")
+	unjailCmd.Flags().StringVar(&pathFlag, "path", "", "Derivation path of the address on the hardware wallet, e.g. m/44'/60'/0'/0")
+	unjailCmd.Flags().Uint32Var(&indexFlag, "index", 0, "Account index of the address on the hardware wallet, ignored if --path is set")
+	unjailCmd.Flags().BoolVar(&yesFlag, "yes", false, "Skip the confirmation prompt")
+	unjailCmd.Flags().BoolVar(&blindSignFlag, "blind-sign", false, "Sign the transaction even if it cannot be decoded for review")
+
+	unjailCmd.MarkFlagRequired("chain")
+	unjailCmd.MarkFlagRequired("from")
+	unjailCmd.MarkFlagRequired("seq")
+}
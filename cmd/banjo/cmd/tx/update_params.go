@@ -0,0 +1,142 @@
+package tx
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/thetatoken/ukulele/cmd/banjo/cmd/utils"
+	"github.com/thetatoken/ukulele/ledger/types"
+)
+
+// updateParamsCmd represents the update params command. The parameter update is signed by
+// the proposing validator and the approving validators one after another, and broadcasted
+// by the last of them.
+// Example:
+//		banjo tx update_params --chain="" --signer=2E833968E5bB786Ae419c4d13189fB081Cc43bab --from=2E833968E5bB786Ae419c4d13189fB081Cc43bab --approvers=9F1233798E905E173560071255140b4A8aBd3Ec6,A14a2DE2b0a8BF8c0A4fdB1f7dBf9Ed7e1b29a27 --height=100000 --min_fee=1000000000000 --max_block_gas=20000000 --slash_percent=100 --seq=5
+//		banjo tx update_params --chain="" --signer=9F1233798E905E173560071255140b4A8aBd3Ec6 <tx bytes>
+var updateParamsCmd = &cobra.Command{
+	Use:   "update_params",
+	Short: "Sign an update of the chain parameters as a validator",
+	Long: `Schedule an update of the chain parameters at the block height --height, as one of the validators.
+The proposing validator, --from, creates the transaction from the flags and pays the fee, and the approving
+validators add their signatures to the transaction bytes printed by the previous one. The validators signing
+the update need to hold more than 2/3 of the stake. With --broadcast, the transaction is broadcasted once
+signed.`,
+	Example: `banjo tx update_params --chain="" --signer=2E833968E5bB786Ae419c4d13189fB081Cc43bab --from=2E833968E5bB786Ae419c4d13189fB081Cc43bab --approvers=9F1233798E905E173560071255140b4A8aBd3Ec6 --height=100000 --min_fee=1000000000000 --max_block_gas=20000000 --slash_percent=100 --seq=5`,
+	Run:     doUpdateParamsCmd,
+}
+
+func doUpdateParamsCmd(cmd *cobra.Command, args []string) {
+	var updateTx *types.ParameterUpdateTx
+	if len(args) > 0 {
+		txBytes, err := hex.DecodeString(strings.TrimPrefix(args[0], "0x"))
+		if err != nil {
+			utils.ErrorWithCode(utils.ExitCodeInvalidInput, "Invalid transaction bytes: %v\n", err)
+		}
+		tx, err := types.TxFromBytes(txBytes)
+		if err != nil {
+			utils.ErrorWithCode(utils.ExitCodeInvalidInput, "Failed to decode transaction: %v\n", err)
+		}
+		var ok bool
+		if updateTx, ok = tx.(*types.ParameterUpdateTx); !ok {
+			utils.ErrorWithCode(utils.ExitCodeInvalidInput, "Not a parameter update transaction: %v\n", tx)
+		}
+	} else {
+		if len(fromFlag) == 0 || heightFlag == 0 {
+			utils.ErrorWithCode(utils.ExitCodeInvalidInput, "Usage: banjo tx update_params --chain=<chain ID> --signer=<address> --from=<address> --approvers=<addresses> --height=<height> --min_fee=<amount> --max_block_gas=<gas> --slash_percent=<percent> --slash_reporter_percent=<percent> --slash_jail_duration=<epochs> --block_reward=<amount> --reward_halving_interval=<blocks> --fee_burn_percent=<percent> --proposer_fee_percent=<percent> --max_reserved_funds=<count> --max_reserve_duration=<blocks> --seq=<sequence>|<tx bytes>\n")
+		}
+		params := chainParametersFromFlags()
+		if err := params.Validate(); err != nil {
+			utils.ErrorWithCode(utils.ExitCodeInvalidInput, "Invalid parameters: %v\n", err)
+		}
+		fee := getFee()
+		updateTx = &types.ParameterUpdateTx{
+			Fee: types.Coins{
+				ThetaWei: new(big.Int).SetUint64(0),
+				GammaWei: fee,
+			},
+			Proposer: types.TxInput{
+				Address:  resolveAddress(cmd, fromFlag),
+				Sequence: uint64(seqFlag),
+			},
+			Height:     heightFlag,
+			Parameters: params,
+		}
+		for _, approver := range approversFlag {
+			updateTx.Approvers = append(updateTx.Approvers, types.TxInput{Address: resolveAddress(cmd, approver)})
+		}
+	}
+
+	wallet, signerAddress := walletUnlock(cmd, signerFlag)
+	defer wallet.Lock(signerAddress)
+
+	sig := signTx(wallet, signerAddress, updateTx.SignBytes(chainIDFlag))
+	if !updateTx.SetSignature(signerAddress, sig) {
+		utils.ErrorWithCode(utils.ExitCodeInvalidInput, "%v is neither the proposer nor an approver of the update\n", signerAddress.Hex())
+	}
+
+	raw, err := types.TxToBytes(updateTx)
+	if err != nil {
+		utils.Error("Failed to encode transaction: %v\n", err)
+	}
+	if !broadcastFlag {
+		fmt.Printf("%v\n", hex.EncodeToString(raw))
+		return
+	}
+	broadcastRawTx(hex.EncodeToString(raw))
+}
+
+// chainParametersFromFlags returns the chain parameters set by the flags of the update
+// and of the parameter change proposals.
+func chainParametersFromFlags() types.ChainParameters {
+	blockReward, ok := new(big.Int).SetString(blockRewardFlag, 10)
+	if !ok {
+		utils.ErrorWithCode(utils.ExitCodeInvalidInput, "Invalid block reward %v, an amount of GammaWei expected\n", blockRewardFlag)
+	}
+	return types.ChainParameters{
+		MinimumTransactionFeeGammaWei: minFeeFlag,
+		MaxBlockGas:                   maxBlockGasFlag,
+		SlashCollateralPercent:        slashPercentFlag,
+		SlashReporterRewardPercent:    slashReporterPercentFlag,
+		SlashJailDuration:             slashJailDurationFlag,
+		BlockRewardGammaWei:           blockReward,
+		RewardHalvingInterval:         rewardHalvingIntervalFlag,
+		FeeBurnPercent:                feeBurnPercentFlag,
+		ProposerFeePercent:            proposerFeePercentFlag,
+		MaxReservedFundsPerAccount:    maxReservedFundsFlag,
+		MaxTotalReserveDuration:       maxReserveDurationFlag,
+	}
+}
+
+func init() {
+	updateParamsCmd.Flags().StringVar(&chainIDFlag, "chain", "", "Chain ID")
+	updateParamsCmd.Flags().StringVar(&signerFlag, "signer", "", "Address of the validator signing the update")
+	updateParamsCmd.Flags().StringVar(&fromFlag, "from", "", "Address of the validator proposing the update and paying the fee")
+	updateParamsCmd.Flags().StringSliceVar(&approversFlag, "approvers", []string{}, "List of the addresses of the other validators approving the update")
+	updateParamsCmd.Flags().Uint64Var(&heightFlag, "height", 0, "Block height from which the parameters apply")
+	updateParamsCmd.Flags().Uint64Var(&minFeeFlag, "min_fee", types.MinimumTransactionFeeGammaWei, "Minimum fee of a regular transaction, in GammaWei")
+	updateParamsCmd.Flags().Uint64Var(&maxBlockGasFlag, "max_block_gas", 0, "Maximum total gas of the transactions of a block, 0 for no limit")
+	updateParamsCmd.Flags().Uint64Var(&slashPercentFlag, "slash_percent", 100, "Percentage of the collateral and remaining fund slashed for an overspending")
+	updateParamsCmd.Flags().Uint64Var(&slashReporterPercentFlag, "slash_reporter_percent", 100, "Percentage of the slashed amount rewarded to the reporting validator, the rest is burned")
+	updateParamsCmd.Flags().Uint64Var(&slashJailDurationFlag, "slash_jail_duration", 0, "Number of epochs a slashed validator is not selected as proposer")
+	updateParamsCmd.Flags().StringVar(&blockRewardFlag, "block_reward", "0", "GammaWei issued for each block before any halving")
+	updateParamsCmd.Flags().Uint64Var(&rewardHalvingIntervalFlag, "reward_halving_interval", 0, "Number of blocks after which the block reward halves, 0 for a constant reward")
+	updateParamsCmd.Flags().Uint64Var(&feeBurnPercentFlag, "fee_burn_percent", 100, "Percentage of the transaction fees that is burned, the rest is distributed to the validators")
+	updateParamsCmd.Flags().Uint64Var(&proposerFeePercentFlag, "proposer_fee_percent", 100, "Percentage of the distributed fees rewarded to the proposer")
+	updateParamsCmd.Flags().Uint64Var(&maxReservedFundsFlag, "max_reserved_funds", 0, "Number of reserved funds an account holds at once, 0 for no limit")
+	updateParamsCmd.Flags().Uint64Var(&maxReserveDurationFlag, "max_reserve_duration", 0, "Sum of the remaining durations of the reserved funds of an account, in blocks, 0 for no limit")
+	updateParamsCmd.Flags().Uint64Var(&seqFlag, "seq", 0, "Sequence number of the transaction, the next sequence of the proposer account")
+	updateParamsCmd.Flags().StringVar(&feeFlag, "fee", "", "Fee paid by the proposer (estimated from the network if not set)")
+	updateParamsCmd.Flags().BoolVar(&broadcastFlag, "broadcast", false, "Broadcast the transaction once signed")
+	updateParamsCmd.Flags().StringVar(&walletFlag, "wallet", "soft", "Wallet type (soft|nano|trezor|remote|daemon)")
+	updateParamsCmd.Flags().StringVar(&pathFlag, "path", "", "Derivation path of the address on the hardware wallet, e.g. m/44'/60'/0'/0")
+	updateParamsCmd.Flags().Uint32Var(&indexFlag, "index", 0, "Account index of the address on the hardware wallet, ignored if --path is set")
+	updateParamsCmd.Flags().BoolVar(&yesFlag, "yes", false, "Skip the confirmation prompt")
+	updateParamsCmd.Flags().BoolVar(&blindSignFlag, "blind-sign", false, "Sign the transaction even if it cannot be decoded for review")
+
+	updateParamsCmd.MarkFlagRequired("chain")
+	updateParamsCmd.MarkFlagRequired("signer")
+}
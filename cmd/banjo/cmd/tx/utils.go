@@ -1,92 +1,320 @@
 package tx
 
 import (
+	"encoding/hex"
 	"fmt"
+	"math/big"
+	"strings"
 
 	log "github.com/sirupsen/logrus"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 	"github.com/thetatoken/ukulele/cmd/banjo/cmd/utils"
 	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/crypto"
+	"github.com/thetatoken/ukulele/ledger/types"
+	"github.com/thetatoken/ukulele/rpc"
 	"github.com/thetatoken/ukulele/wallet"
+	"github.com/thetatoken/ukulele/wallet/daemon"
+	"github.com/thetatoken/ukulele/wallet/preview"
+	sw "github.com/thetatoken/ukulele/wallet/softwallet"
 	wtypes "github.com/thetatoken/ukulele/wallet/types"
+
+	rpcc "github.com/ybbus/jsonrpc"
 )
 
-func walletUnlock(cmd *cobra.Command, addressStr string) (wtypes.Wallet, common.Address, error) {
-	var wallet wtypes.Wallet
-	var address common.Address
-	var err error
+func walletUnlock(cmd *cobra.Command, addressStr string) (wtypes.Wallet, common.Address) {
 	walletType := getWalletType(cmd)
+	if walletType == wtypes.WalletTypeSoft || walletType == wtypes.WalletTypeRemote || walletType == wtypes.WalletTypeDaemon {
+		addressStr = resolveAddress(cmd, addressStr).Hex()
+	}
 	if walletType == wtypes.WalletTypeSoft {
 		cfgPath := cmd.Flag("config").Value.String()
-		wallet, address, err = softWalletUnlock(cfgPath, addressStr)
-	} else {
-		wallet, address, err = coldWalletUnlock()
+		return softWalletUnlock(cfgPath, addressStr)
+	}
+	if walletType == wtypes.WalletTypeRemote {
+		return remoteWalletUnlock(addressStr)
+	}
+	if walletType == wtypes.WalletTypeDaemon {
+		cfgPath := cmd.Flag("config").Value.String()
+		return daemonWalletUnlock(cfgPath, addressStr)
+	}
+
+	derivationPath, err := getDerivationPath()
+	if err != nil {
+		utils.ErrorWithCode(utils.ExitCodeInvalidInput, "Invalid derivation path: %v\n", err)
+	}
+	return coldWalletUnlock(walletType, derivationPath)
+}
+
+// resolveAddress returns the address of the contact with the name in the address book,
+// or the address itself if a hex address is given
+func resolveAddress(cmd *cobra.Command, nameOrAddress string) common.Address {
+	cfgPath := cmd.Flag("config").Value.String()
+	book, err := utils.LoadAddressBook(cfgPath)
+	if err != nil {
+		utils.ErrorWithCode(utils.ExitCodeInvalidInput, "Failed to load address book: %v\n", err)
+	}
+	address, err := book.Resolve(nameOrAddress)
+	if err != nil {
+		utils.ErrorWithCode(utils.ExitCodeInvalidInput, "Invalid address: %v\n", err)
 	}
-	return wallet, address, err
+	return address
 }
 
-func coldWalletUnlock() (wtypes.Wallet, common.Address, error) {
-	wallet, err := wallet.OpenWallet("", wtypes.WalletTypeCold, true)
+func coldWalletUnlock(walletType wtypes.WalletType, derivationPath wtypes.DerivationPath) (wtypes.Wallet, common.Address) {
+	wallet, err := wallet.OpenWallet("", walletType, true)
 	if err != nil {
-		fmt.Printf("Failed to open wallet: %v\n", err)
-		return nil, common.Address{}, err
+		utils.ErrorWithCode(utils.ExitCodeWallet, "Failed to open wallet: %v\n", err)
 	}
 
-	err = wallet.Unlock(common.Address{}, "")
+	err = utils.UnlockColdWallet(wallet)
 	if err != nil {
-		fmt.Printf("Failed to unlock wallet: %v\n", err)
-		return nil, common.Address{}, err
+		utils.ErrorWithCode(utils.ExitCodeWallet, "Failed to unlock wallet: %v\n", err)
 	}
 
 	addresses, err := wallet.List()
 	if err != nil {
-		fmt.Printf("Failed to list wallet addresses: %v\n", err)
-		return nil, common.Address{}, err
+		utils.ErrorWithCode(utils.ExitCodeWallet, "Failed to list wallet addresses: %v\n", err)
 	}
 
 	if len(addresses) == 0 {
-		errMsg := fmt.Sprintf("No address detected in the wallet\n")
-		fmt.Printf(errMsg)
-		return nil, common.Address{}, fmt.Errorf(errMsg)
+		utils.ErrorWithCode(utils.ExitCodeWallet, "No address detected in the wallet\n")
 	}
 	address := addresses[0]
 
-	log.Infof("Wallet address: %v", address)
+	if derivationPath.String() != wtypes.DefaultRootDerivationPath.String() {
+		address, err = wallet.Derive(derivationPath, true)
+		if err != nil {
+			utils.ErrorWithCode(utils.ExitCodeWallet, "Failed to derive address for path %v: %v\n", derivationPath, err)
+		}
+	}
+
+	log.Infof("Wallet address: %v, derivation path: %v", address, derivationPath)
 
-	return wallet, address, nil
+	return wallet, address
 }
 
-func softWalletUnlock(cfgPath, addressStr string) (wtypes.Wallet, common.Address, error) {
-	wallet, err := wallet.OpenWallet(cfgPath, wtypes.WalletTypeSoft, true)
+func softWalletUnlock(cfgPath, addressStr string) (wtypes.Wallet, common.Address) {
+	wallet, err := utils.OpenSoftWallet(cfgPath)
 	if err != nil {
-		fmt.Printf("Failed to open wallet: %v\n", err)
-		return nil, common.Address{}, err
+		utils.ErrorWithCode(utils.ExitCodeWallet, "Failed to open wallet: %v\n", err)
+	}
+
+	address := common.HexToAddress(addressStr)
+	if wallet.IsWatchOnly(address) {
+		utils.ErrorWithCode(utils.ExitCodeWallet, "Failed to unlock address %v: %v\n", address.Hex(), sw.ErrWatchOnly)
 	}
 
 	prompt := fmt.Sprintf("Please enter password: ")
 	password, err := utils.GetPassword(prompt)
 	if err != nil {
-		fmt.Printf("Failed to get password: %v\n", err)
-		return nil, common.Address{}, err
+		utils.ErrorWithCode(utils.ExitCodeWallet, "Failed to get password: %v\n", err)
 	}
 
-	address := common.HexToAddress(addressStr)
 	err = wallet.Unlock(address, password)
 	if err != nil {
-		fmt.Printf("Failed to unlock address %v: %v\n", address.Hex(), err)
-		return nil, common.Address{}, err
+		utils.ErrorWithCode(utils.ExitCodeWallet, "Failed to unlock address %v: %v\n", address.Hex(), err)
 	}
 
-	return wallet, address, nil
+	return wallet, address
+}
+
+func remoteWalletUnlock(addressStr string) (wtypes.Wallet, common.Address) {
+	wallet, err := utils.OpenRemoteWallet()
+	if err != nil {
+		utils.ErrorWithCode(utils.ExitCodeWallet, "Failed to open remote wallet: %v\n", err)
+	}
+
+	address := common.HexToAddress(addressStr)
+	err = wallet.Unlock(address, "")
+	if err != nil {
+		utils.ErrorWithCode(utils.ExitCodeWallet, "Failed to unlock address %v: %v\n", address.Hex(), err)
+	}
+
+	return wallet, address
+}
+
+// daemonWallet leaves the address unlocked in the wallet daemon for the other
+// clients, and only closes the connection when the command locks the address
+type daemonWallet struct {
+	*daemon.Client
+}
+
+func (w daemonWallet) Lock(address common.Address) error {
+	return w.Close()
+}
+
+func daemonWalletUnlock(cfgPath, addressStr string) (wtypes.Wallet, common.Address) {
+	client, err := utils.DialWalletDaemon(cfgPath)
+	if err != nil {
+		utils.ErrorWithCode(utils.ExitCodeWallet, "Failed to connect to wallet daemon: %v\n", err)
+	}
+
+	address := common.HexToAddress(addressStr)
+	if !client.IsUnlocked(address) {
+		prompt := fmt.Sprintf("Please enter password: ")
+		password, err := utils.GetPassword(prompt)
+		if err != nil {
+			utils.ErrorWithCode(utils.ExitCodeWallet, "Failed to get password: %v\n", err)
+		}
+		err = client.Unlock(address, password)
+		if err != nil {
+			utils.ErrorWithCode(utils.ExitCodeWallet, "Failed to unlock address %v: %v\n", address.Hex(), err)
+		}
+	}
+
+	return daemonWallet{client}, address
+}
+
+func getDerivationPath() (wtypes.DerivationPath, error) {
+	if len(pathFlag) != 0 {
+		return wtypes.ParseDerivationPath(pathFlag)
+	}
+	return wtypes.LedgerDerivationPath(indexFlag), nil
 }
 
 func getWalletType(cmd *cobra.Command) (walletType wtypes.WalletType) {
 	walletTypeStr := cmd.Flag("wallet").Value.String()
-	if walletTypeStr == "nano" {
+	switch walletTypeStr {
+	case utils.ColdWalletNano:
 		walletType = wtypes.WalletTypeCold
-	} else {
+	case utils.ColdWalletTrezor:
+		walletType = wtypes.WalletTypeTrezor
+	case "remote":
+		walletType = wtypes.WalletTypeRemote
+	case "daemon":
+		walletType = wtypes.WalletTypeDaemon
+	default:
 		walletType = wtypes.WalletTypeSoft
 	}
 	return walletType
 }
+
+// estimateFee queries the node for the suggested fee and gas price
+func estimateFee() (*rpc.EstimateFeeResult, error) {
+	client := rpcc.NewRPCClient(viper.GetString(utils.CfgRemoteRPCEndpoint))
+
+	res, err := client.Call("theta.EstimateFee", rpc.EstimateFeeArgs{})
+	if err != nil {
+		return nil, err
+	}
+	if res.Error != nil {
+		return nil, fmt.Errorf("Server returned error: %v", res.Error)
+	}
+	result := &rpc.EstimateFeeResult{}
+	err = res.GetObject(result)
+	if err != nil {
+		return nil, err
+	}
+	if result.Fee == nil || result.GasPrice == nil {
+		return nil, fmt.Errorf("Incomplete server response")
+	}
+	return result, nil
+}
+
+// getFee returns the fee specified with the --fee flag. If the flag is not set, the fee
+// suggested by the node is used instead.
+func getFee() *big.Int {
+	if len(feeFlag) != 0 {
+		fee, ok := types.ParseCoinAmount(feeFlag)
+		if !ok {
+			utils.ErrorWithCode(utils.ExitCodeInvalidInput, "Failed to parse fee\n")
+		}
+		return fee
+	}
+
+	estimation, err := estimateFee()
+	if err != nil {
+		utils.ErrorWithCode(utils.ExitCodeRPC, "Failed to estimate fee, please specify it with --fee: %v\n", err)
+	}
+	return estimation.Fee.ToInt()
+}
+
+// parseTokens parses the application tokens specified as SYMBOL:amount, and returns them
+// in their canonical order
+func parseTokens(tokens []string) []types.TokenCoin {
+	coins := types.NewCoins(0, 0)
+	for _, token := range tokens {
+		parts := strings.Split(token, ":")
+		if len(parts) != 2 {
+			utils.ErrorWithCode(utils.ExitCodeInvalidInput, "Invalid token amount %v, expected SYMBOL:amount\n", token)
+		}
+		amount, ok := new(big.Int).SetString(parts[1], 10)
+		if !ok || amount.Sign() <= 0 {
+			utils.ErrorWithCode(utils.ExitCodeInvalidInput, "Failed to parse the amount of token %v\n", parts[0])
+		}
+		if err := types.ValidateTokenSymbol(parts[0]); err != nil {
+			utils.ErrorWithCode(utils.ExitCodeInvalidInput, "%v\n", err)
+		}
+		coins = coins.Plus(types.NewTokenCoins(parts[0], amount))
+	}
+	return coins.Tokens
+}
+
+// getGasPrice returns the gas price specified with the --gas_price flag. If the flag is not
+// set, the gas price suggested by the node is used instead.
+func getGasPrice() *big.Int {
+	if len(gasPriceFlag) != 0 {
+		gasPrice, ok := types.ParseCoinAmount(gasPriceFlag)
+		if !ok {
+			utils.ErrorWithCode(utils.ExitCodeInvalidInput, "Failed to parse gas price\n")
+		}
+		return gasPrice
+	}
+
+	estimation, err := estimateFee()
+	if err != nil {
+		utils.ErrorWithCode(utils.ExitCodeRPC, "Failed to estimate gas price, please specify it with --gas_price: %v\n", err)
+	}
+	return estimation.GasPrice.ToInt()
+}
+
+// confirmTx displays the summary of the transaction and asks the user to confirm it,
+// unless the --yes flag is set. It exits if the user declines.
+func confirmTx(title string, items [][2]string) {
+	fmt.Printf("%v:\n", title)
+	for _, item := range items {
+		fmt.Printf("    %-12v %v\n", item[0]+":", item[1])
+	}
+	if yesFlag {
+		return
+	}
+
+	fmt.Println("Please enter 'no' to abort or 'yes' to sign and broadcast the transaction: ")
+	confirmation, err := utils.GetConfirmation()
+	if err != nil {
+		utils.ErrorWithCode(utils.ExitCodeInvalidInput, "Failed to get confirmation: %v\n", err)
+	}
+	if strings.ToLower(confirmation) != "yes" {
+		utils.ErrorWithCode(utils.ExitCodeAborted, "Transaction aborted\n")
+	}
+}
+
+// signTx decodes the sign bytes of the transaction, displays the summary and asks the
+// user to confirm it before signing. Sign bytes that cannot be decoded are refused,
+// unless the --blind-sign flag is set.
+func signTx(wallet wtypes.Wallet, address common.Address, signBytes common.Bytes) *crypto.Signature {
+	summary, err := preview.DecodeSignBytes(signBytes)
+	if err != nil {
+		if !blindSignFlag {
+			utils.ErrorWithCode(utils.ExitCodeInvalidInput, "Refusing to sign undecodable transaction, use --blind-sign to sign it anyway: %v\n", err)
+		}
+		confirmTx("Undecodable transaction (blind signing)", [][2]string{
+			{"Signer", address.Hex()},
+			{"Sign bytes", hex.EncodeToString(signBytes)},
+		})
+	} else {
+		if !summary.HasInput(address) {
+			utils.ErrorWithCode(utils.ExitCodeInvalidInput, "Address %v is not an input of the transaction\n", address.Hex())
+		}
+		confirmTx(summary.Type+" transaction", append([][2]string{{"Signer", address.Hex()}}, summary.Fields()...))
+	}
+
+	sig, err := wallet.Sign(address, signBytes)
+	if err != nil {
+		utils.ErrorWithCode(utils.ExitCodeWallet, "Failed to sign transaction: %v\n", err)
+	}
+	return sig
+}
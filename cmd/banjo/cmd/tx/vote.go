@@ -0,0 +1,73 @@
+package tx
+
+import (
+	"encoding/hex"
+	"math/big"
+
+	"github.com/spf13/cobra"
+	"github.com/thetatoken/ukulele/cmd/banjo/cmd/utils"
+	"github.com/thetatoken/ukulele/ledger/types"
+)
+
+// voteCmd represents the vote command. A staker can change its vote until the end of the
+// voting period, and its vote is weighted by its stake when the proposal is tallied.
+// Example:
+//		banjo tx vote --chain="" --from=2E833968E5bB786Ae419c4d13189fB081Cc43bab --proposal=1 --approve --seq=4
+var voteCmd = &cobra.Command{
+	Use:     "vote",
+	Short:   "Vote on a governance proposal",
+	Long:    `Vote on a governance proposal, as a staker. The vote can be changed until the end of the voting period, and is weighted by the stake of the voter when the proposal is tallied.`,
+	Example: `banjo tx vote --chain="" --from=2E833968E5bB786Ae419c4d13189fB081Cc43bab --proposal=1 --approve --seq=4`,
+	Run:     doVoteCmd,
+}
+
+func doVoteCmd(cmd *cobra.Command, args []string) {
+	wallet, fromAddress := walletUnlock(cmd, fromFlag)
+	defer wallet.Lock(fromAddress)
+
+	fee := getFee()
+	voteTx := &types.VoteTx{
+		Fee: types.Coins{
+			ThetaWei: new(big.Int).SetUint64(0),
+			GammaWei: fee,
+		},
+		Voter: types.TxInput{
+			Address: fromAddress,
+			Coins: types.Coins{
+				ThetaWei: new(big.Int).SetUint64(0),
+				GammaWei: new(big.Int).SetUint64(0),
+			},
+			Sequence: uint64(seqFlag),
+		},
+		ProposalID: proposalIDFlag,
+		Approve:    approveFlag,
+	}
+
+	sig := signTx(wallet, fromAddress, voteTx.SignBytes(chainIDFlag))
+	voteTx.SetSignature(fromAddress, sig)
+
+	raw, err := types.TxToBytes(voteTx)
+	if err != nil {
+		utils.Error("Failed to encode transaction: %v\n", err)
+	}
+	broadcastRawTx(hex.EncodeToString(raw))
+}
+
+func init() {
+	voteCmd.Flags().StringVar(&chainIDFlag, "chain", "", "Chain ID")
+	voteCmd.Flags().StringVar(&fromFlag, "from", "", "Address of the staker voting")
+	voteCmd.Flags().Uint64Var(&proposalIDFlag, "proposal", 0, "ID of the proposal")
+	voteCmd.Flags().BoolVar(&approveFlag, "approve", false, "Approve the proposal, reject it if not set")
+	voteCmd.Flags().Uint64Var(&seqFlag, "seq", 0, "Sequence number of the transaction")
+	voteCmd.Flags().StringVar(&feeFlag, "fee", "", "Fee (estimated from the network if not set)")
+	voteCmd.Flags().StringVar(&walletFlag, "wallet", "soft", "Wallet type (soft|nano|trezor|remote|daemon)")
+	voteCmd.Flags().StringVar(&pathFlag, "path", "", "Derivation path of the address on the hardware wallet, e.g. m/44'/60'/0'/0")
+	voteCmd.Flags().Uint32Var(&indexFlag, "index", 0, "Account index of the address on the hardware wallet, ignored if --path is set")
+	voteCmd.Flags().BoolVar(&yesFlag, "yes", false, "Skip the confirmation prompt")
+	voteCmd.Flags().BoolVar(&blindSignFlag, "blind-sign", false, "Sign the transaction even if it cannot be decoded for review")
+
+	voteCmd.MarkFlagRequired("chain")
+	voteCmd.MarkFlagRequired("from")
+	voteCmd.MarkFlagRequired("proposal")
+	voteCmd.MarkFlagRequired("seq")
+}
@@ -0,0 +1,99 @@
+package tx
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/thetatoken/ukulele/cmd/banjo/cmd/utils"
+	"github.com/thetatoken/ukulele/ledger/types"
+	"github.com/thetatoken/ukulele/rpc"
+
+	rpcc "github.com/ybbus/jsonrpc"
+)
+
+// withdrawStakeCmd represents the withdraw_stake command. The withdrawn stake is returned to
+// the source address after the locking period.
+// Example:
+//		banjo tx withdraw_stake --chain="" --from=2E833968E5bB786Ae419c4d13189fB081Cc43bab --holder=9F1233798E905E173560071255140b4A8aBd3Ec6 --seq=2
+var withdrawStakeCmd = &cobra.Command{
+	Use:     "withdraw_stake",
+	Short:   "Withdraw the stake deposited to a validator",
+	Long:    `Withdraw the stake deposited to a validator. The stake is returned to the source address after the locking period.`,
+	Example: `banjo tx withdraw_stake --chain="" --from=2E833968E5bB786Ae419c4d13189fB081Cc43bab --holder=9F1233798E905E173560071255140b4A8aBd3Ec6 --seq=2`,
+	Run:     doWithdrawStakeCmd,
+}
+
+func doWithdrawStakeCmd(cmd *cobra.Command, args []string) {
+	wallet, fromAddress := walletUnlock(cmd, fromFlag)
+	defer wallet.Lock(fromAddress)
+
+	fee := getFee()
+	withdrawStakeTx := &types.WithdrawStakeTx{
+		Fee: types.Coins{
+			ThetaWei: new(big.Int).SetUint64(0),
+			GammaWei: fee,
+		},
+		Source: types.TxInput{
+			Address: fromAddress,
+			Coins: types.Coins{
+				ThetaWei: new(big.Int).SetUint64(0),
+				GammaWei: new(big.Int).SetUint64(0),
+			},
+			Sequence: uint64(seqFlag),
+		},
+		Holder: types.TxOutput{
+			Address: resolveAddress(cmd, holderFlag),
+		},
+	}
+
+	sig := signTx(wallet, fromAddress, withdrawStakeTx.SignBytes(chainIDFlag))
+	withdrawStakeTx.SetSignature(fromAddress, sig)
+
+	raw, err := types.TxToBytes(withdrawStakeTx)
+	if err != nil {
+		utils.Error("Failed to encode transaction: %v\n", err)
+	}
+	signedTx := hex.EncodeToString(raw)
+
+	client := rpcc.NewRPCClient(viper.GetString(utils.CfgRemoteRPCEndpoint))
+
+	res, err := client.Call("theta.BroadcastRawTransaction", rpc.BroadcastRawTransactionArgs{TxBytes: signedTx})
+	if err != nil {
+		utils.ErrorWithCode(utils.ExitCodeRPC, "Failed to broadcast transaction: %v\n", err)
+	}
+	if res.Error != nil {
+		utils.ErrorWithCode(utils.ExitCodeServer, "Server returned error: %v\n", res.Error)
+	}
+	result := &rpc.BroadcastRawTransactionResult{}
+	err = res.GetObject(result)
+	if err != nil {
+		utils.ErrorWithCode(utils.ExitCodeRPC, "Failed to parse server response: %v\n", err)
+	}
+	formatted, err := json.MarshalIndent(result, "", "    ")
+	if err != nil {
+		utils.ErrorWithCode(utils.ExitCodeRPC, "Failed to parse server response: %v\n", err)
+	}
+	fmt.Printf("Successfully broadcasted transaction:\n%s\n", formatted)
+}
+
+func init() {
+	withdrawStakeCmd.Flags().StringVar(&chainIDFlag, "chain", "", "Chain ID")
+	withdrawStakeCmd.Flags().StringVar(&fromFlag, "from", "", "Address the stake was deposited from")
+	withdrawStakeCmd.Flags().StringVar(&holderFlag, "holder", "", "Address of the stake holder, i.e. the validator")
+	withdrawStakeCmd.Flags().Uint64Var(&seqFlag, "seq", 0, "Sequence number of the transaction")
+	withdrawStakeCmd.Flags().StringVar(&feeFlag, "fee", "", "Fee (estimated from the network if not set)")
+	withdrawStakeCmd.Flags().StringVar(&walletFlag, "wallet", "soft", "Wallet type (soft|nano|trezor|remote|daemon)")
+	withdrawStakeCmd.Flags().StringVar(&pathFlag, "path", "", "Derivation path of the address on the hardware wallet, e.g. m/44'/60'/0'/0")
+	withdrawStakeCmd.Flags().Uint32Var(&indexFlag, "index", 0, "Account index of the address on the hardware wallet, ignored if --path is set")
+	withdrawStakeCmd.Flags().BoolVar(&yesFlag, "yes", false, "Skip the confirmation prompt")
+	withdrawStakeCmd.Flags().BoolVar(&blindSignFlag, "blind-sign", false, "Sign the transaction even if it cannot be decoded for review")
+
+	withdrawStakeCmd.MarkFlagRequired("chain")
+	withdrawStakeCmd.MarkFlagRequired("from")
+	withdrawStakeCmd.MarkFlagRequired("holder")
+	withdrawStakeCmd.MarkFlagRequired("seq")
+}
@@ -0,0 +1,129 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/thetatoken/ukulele/common"
+)
+
+// Contact is a named address in the address book
+type Contact struct {
+	Name    string         `json:"name"`
+	Address common.Address `json:"address"`
+}
+
+// AddressBook stores the contacts in addressbook.json under the config folder
+type AddressBook struct {
+	filePath string
+	contacts []Contact
+}
+
+// LoadAddressBook loads the address book under the config folder
+func LoadAddressBook(cfgPath string) (*AddressBook, error) {
+	book := &AddressBook{
+		filePath: path.Join(cfgPath, "addressbook.json"),
+		contacts: []Contact{},
+	}
+	content, err := ioutil.ReadFile(book.filePath)
+	if os.IsNotExist(err) {
+		return book, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(content, &book.contacts); err != nil {
+		return nil, fmt.Errorf("Failed to parse address book: %v", err)
+	}
+	return book, nil
+}
+
+// List returns the contacts sorted by name
+func (book *AddressBook) List() []Contact {
+	contacts := append([]Contact{}, book.contacts...)
+	sort.Slice(contacts, func(i, j int) bool {
+		return contacts[i].Name < contacts[j].Name
+	})
+	return contacts
+}
+
+// Get returns the contact with the name
+func (book *AddressBook) Get(name string) (Contact, bool) {
+	for _, contact := range book.contacts {
+		if contact.Name == name {
+			return contact, true
+		}
+	}
+	return Contact{}, false
+}
+
+// Add adds a contact and saves the address book. The address needs to have a
+// valid EIP55 checksum.
+func (book *AddressBook) Add(name, addressStr string) (Contact, error) {
+	if err := validateContactName(name); err != nil {
+		return Contact{}, err
+	}
+	if _, exists := book.Get(name); exists {
+		return Contact{}, fmt.Errorf("Contact %v already exists", name)
+	}
+	address, err := ParseAddress(addressStr)
+	if err != nil {
+		return Contact{}, err
+	}
+
+	contact := Contact{Name: name, Address: address}
+	book.contacts = append(book.contacts, contact)
+	return contact, book.save()
+}
+
+// Remove removes the contact with the name and saves the address book
+func (book *AddressBook) Remove(name string) error {
+	for i, contact := range book.contacts {
+		if contact.Name == name {
+			book.contacts = append(book.contacts[:i], book.contacts[i+1:]...)
+			return book.save()
+		}
+	}
+	return fmt.Errorf("Contact %v not found", name)
+}
+
+// Resolve returns the address of the contact with the name, or the address itself
+// if a hex address is given
+func (book *AddressBook) Resolve(nameOrAddress string) (common.Address, error) {
+	if common.IsHexAddress(nameOrAddress) {
+		return ParseAddress(nameOrAddress)
+	}
+	if contact, exists := book.Get(nameOrAddress); exists {
+		return contact.Address, nil
+	}
+	return common.Address{}, fmt.Errorf("%v is neither an address nor a contact", nameOrAddress)
+}
+
+func (book *AddressBook) save() error {
+	content, err := json.MarshalIndent(book.contacts, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(path.Dir(book.filePath), 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(book.filePath, content, 0600)
+}
+
+func validateContactName(name string) error {
+	if len(name) == 0 {
+		return fmt.Errorf("Contact name is not specified")
+	}
+	if common.IsHexAddress(name) {
+		return fmt.Errorf("Contact name cannot be an address")
+	}
+	if strings.ContainsAny(name, ", \t\n") {
+		return fmt.Errorf("Contact name cannot contain commas or whitespaces")
+	}
+	return nil
+}
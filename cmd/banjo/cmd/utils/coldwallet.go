@@ -0,0 +1,52 @@
+package utils
+
+import (
+	"fmt"
+
+	"github.com/thetatoken/ukulele/common"
+	ks "github.com/thetatoken/ukulele/wallet/coldwallet/keystore"
+	wtypes "github.com/thetatoken/ukulele/wallet/types"
+)
+
+// Names of the hardware wallets accepted by the --wallet flag
+const (
+	ColdWalletNano   = "nano"
+	ColdWalletTrezor = "trezor"
+)
+
+// ParseColdWalletType converts the name of the hardware wallet to its wallet type
+func ParseColdWalletType(name string) (wtypes.WalletType, error) {
+	switch name {
+	case ColdWalletNano:
+		return wtypes.WalletTypeCold, nil
+	case ColdWalletTrezor:
+		return wtypes.WalletTypeTrezor, nil
+	default:
+		return wtypes.WalletTypeCold, fmt.Errorf("Unsupported hardware wallet: %v", name)
+	}
+}
+
+// UnlockColdWallet unlocks the hardware wallet. If the device is protected by a PIN
+// or a passphrase (e.g. Trezor), the user is prompted for them.
+func UnlockColdWallet(wallet wtypes.Wallet) error {
+	err := wallet.Unlock(common.Address{}, "")
+	if err == ks.ErrTrezorPINNeeded {
+		fmt.Println("Please enter the PIN using the positions of the digits shown on the device:")
+		fmt.Println("  7 8 9")
+		fmt.Println("  4 5 6")
+		fmt.Println("  1 2 3")
+		pin, perr := GetPassword("PIN: ")
+		if perr != nil {
+			return perr
+		}
+		err = wallet.Unlock(common.Address{}, pin)
+	}
+	if err == ks.ErrTrezorPassphraseNeeded {
+		passphrase, perr := GetPassword("Please enter the device passphrase (empty for the standard wallet): ")
+		if perr != nil {
+			return perr
+		}
+		err = wallet.Unlock(common.Address{}, passphrase)
+	}
+	return err
+}
@@ -1,13 +1,88 @@
 package utils
 
-import "github.com/spf13/viper"
+import (
+	"path"
+
+	"github.com/spf13/viper"
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/wallet"
+	"github.com/thetatoken/ukulele/wallet/daemon"
+	rw "github.com/thetatoken/ukulele/wallet/remotewallet"
+	sw "github.com/thetatoken/ukulele/wallet/softwallet"
+	ks "github.com/thetatoken/ukulele/wallet/softwallet/keystore"
+)
 
 const (
 	CfgRemoteRPCEndpoint = "remoteRPCEndpoint"
 	CfgDebug             = "debug"
+	CfgOutput            = "output"
+	CfgAllowLowercase    = "allowLowercase"
+	CfgKeystoreScryptN   = "keystore.scryptN"
+	CfgKeystoreScryptP   = "keystore.scryptP"
+
+	CfgRemoteSignerEndpoint = "remoteSigner.endpoint"
+	CfgRemoteSignerCertFile = "remoteSigner.certFile"
+	CfgRemoteSignerKeyFile  = "remoteSigner.keyFile"
+	CfgRemoteSignerCAFile   = "remoteSigner.caFile"
+	CfgRemoteSignerTimeout  = "remoteSigner.timeout"
+
+	CfgWalletDaemonSocket = "walletDaemon.socket"
+	CfgWalletDaemonToken  = "walletDaemon.token"
+
+	CfgLightEnabled    = "light.enabled"
+	CfgLightCheckpoint = "light.checkpoint"
+)
+
+// Output formats
+const (
+	OutputFormatText = "text"
+	OutputFormatJSON = "json"
 )
 
 func init() {
 	viper.SetDefault(CfgRemoteRPCEndpoint, "http://localhost:16888/rpc")
 	viper.SetDefault(CfgDebug, false)
+	viper.SetDefault(CfgOutput, OutputFormatText)
+	viper.SetDefault(CfgAllowLowercase, false)
+	viper.SetDefault(CfgKeystoreScryptN, ks.StandardScryptN)
+	viper.SetDefault(CfgKeystoreScryptP, ks.StandardScryptP)
+	viper.SetDefault(CfgRemoteSignerTimeout, rw.DefaultTimeout)
+	viper.SetDefault(CfgLightEnabled, false)
+}
+
+// ParseAddress parses a hex address, verifying its EIP55 checksum. Addresses without
+// checksum are only accepted with the --allow-lowercase flag.
+func ParseAddress(addressStr string) (common.Address, error) {
+	return common.ParseHexAddress(addressStr, viper.GetBool(CfgAllowLowercase))
+}
+
+// OpenSoftWallet opens the soft wallet under the config folder, which encrypts the
+// keys with the configured scrypt parameters
+func OpenSoftWallet(cfgPath string) (*sw.SoftWallet, error) {
+	return wallet.OpenSoftWallet(cfgPath, viper.GetInt(CfgKeystoreScryptN), viper.GetInt(CfgKeystoreScryptP))
+}
+
+// OpenRemoteWallet opens the remote wallet connecting to the configured signer
+func OpenRemoteWallet() (*rw.RemoteWallet, error) {
+	return wallet.OpenRemoteWallet(rw.Config{
+		Endpoint: viper.GetString(CfgRemoteSignerEndpoint),
+		CertFile: viper.GetString(CfgRemoteSignerCertFile),
+		KeyFile:  viper.GetString(CfgRemoteSignerKeyFile),
+		CAFile:   viper.GetString(CfgRemoteSignerCAFile),
+		Timeout:  viper.GetDuration(CfgRemoteSignerTimeout),
+	})
+}
+
+// WalletDaemonSocketPath returns the configured socket path of the wallet daemon,
+// which defaults to wallet.sock under the config folder
+func WalletDaemonSocketPath(cfgPath string) string {
+	if socketPath := viper.GetString(CfgWalletDaemonSocket); len(socketPath) != 0 {
+		return socketPath
+	}
+	return path.Join(cfgPath, "wallet.sock")
+}
+
+// DialWalletDaemon connects to the wallet daemon with the configured token
+func DialWalletDaemon(cfgPath string) (*daemon.Client, error) {
+	return daemon.Dial(WalletDaemonSocketPath(cfgPath), viper.GetString(CfgWalletDaemonToken))
 }
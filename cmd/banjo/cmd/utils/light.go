@@ -0,0 +1,252 @@
+package utils
+
+import (
+	"encoding/hex"
+	"fmt"
+	"path"
+
+	"github.com/spf13/viper"
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/consensus"
+	"github.com/thetatoken/ukulele/core"
+	"github.com/thetatoken/ukulele/crypto"
+	"github.com/thetatoken/ukulele/ledger/types"
+	"github.com/thetatoken/ukulele/lightclient"
+	"github.com/thetatoken/ukulele/rlp"
+	"github.com/thetatoken/ukulele/rpc"
+
+	rpcc "github.com/ybbus/jsonrpc"
+)
+
+// LightModeEnabled indicates whether the query results are verified with a light
+// client instead of trusting the node
+func LightModeEnabled() bool {
+	return viper.GetBool(CfgLightEnabled)
+}
+
+// LightCheckpointPath returns the configured path of the trusted checkpoint of the
+// light client, which defaults to the genesis file under the config folder
+func LightCheckpointPath(cfgPath string) string {
+	if checkpointPath := viper.GetString(CfgLightCheckpoint); len(checkpointPath) != 0 {
+		return checkpointPath
+	}
+	return path.Join(cfgPath, "genesis")
+}
+
+// VerificationError is returned by the LightClient when the data served by the
+// node does not match the verified headers
+type VerificationError struct {
+	error
+}
+
+// LightErrorCode returns the exit code for an error of the LightClient
+func LightErrorCode(err error) ExitCode {
+	if _, ok := err.(VerificationError); ok {
+		return ExitCodeVerification
+	}
+	return ExitCodeRPC
+}
+
+type rpcCaller interface {
+	Call(method string, params ...interface{}) (*rpcc.RPCResponse, error)
+}
+
+// LightClient follows the finalized headers served by the remote node from a trusted
+// checkpoint, and verifies the accounts and transactions served by the node against
+// them
+type LightClient struct {
+	*lightclient.Client
+
+	rpc     rpcCaller
+	pending []*core.BlockHeader // Headers not certified yet, from the child of the head
+}
+
+// NewLightClient creates a light client trusting the checkpoint, and the validators
+// listed in it, and connects it to the configured remote node
+func NewLightClient(cfgPath string) (*LightClient, error) {
+	checkpoint, err := consensus.LoadCheckpoint(LightCheckpointPath(cfgPath))
+	if err != nil {
+		return nil, fmt.Errorf("Failed to load the trusted checkpoint: %v", err)
+	}
+	if checkpoint.FirstBlock == nil {
+		return nil, fmt.Errorf("The trusted checkpoint has no block")
+	}
+	root := checkpoint.FirstBlock.BlockHeader
+	return &LightClient{
+		Client: lightclient.NewClient(root.ChainID, root, consensus.NewTestValidatorSet(checkpoint.Validators)),
+		rpc:    rpcc.NewRPCClient(viper.GetString(CfgRemoteRPCEndpoint)),
+	}, nil
+}
+
+// Sync follows the finalized headers served by the node up to the latest one. The
+// headers without a commit certificate are verified along with the next certified
+// header.
+func (c *LightClient) Sync() error {
+	for {
+		from := c.Head().Height + uint64(len(c.pending)) + 1
+		result := &rpc.GetHeadersResult{}
+		if err := c.call("theta.GetHeaders", rpc.GetHeadersArgs{From: common.JSONUint64(from)}, result); err != nil {
+			return err
+		}
+		if len(result.Headers) == 0 {
+			return nil
+		}
+		for _, h := range result.Headers {
+			header := &core.BlockHeader{}
+			if err := decodeHex(h.Header, header); err != nil {
+				return err
+			}
+			c.pending = append(c.pending, header)
+			if len(h.CommitCertificate) == 0 {
+				continue
+			}
+			cc := &core.CommitCertificate{}
+			if err := decodeHex(h.CommitCertificate, cc); err != nil {
+				return err
+			}
+			if err := c.AddHeaders(c.pending, cc); err != nil {
+				if c.pending[0].Parent != c.Head().Hash() {
+					return VerificationError{err}
+				}
+				// A certificate without a majority may be followed by a valid one of a descendant
+				continue
+			}
+			c.pending = nil
+			if err := c.addPendingValidatorSet(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// addPendingValidatorSet gets the validator set committed by a verified header from
+// the node, if any, and adds it after checking it against the committed hash.
+func (c *LightClient) addPendingValidatorSet() error {
+	epoch, _, pending := c.PendingValidatorSet()
+	if !pending {
+		return nil
+	}
+	jsonEpoch := common.JSONUint64(epoch)
+	result := &rpc.GetValidatorsResult{}
+	if err := c.call("theta.GetValidators", rpc.GetValidatorsArgs{Epoch: &jsonEpoch}, result); err != nil {
+		return err
+	}
+	validators := &core.ValidatorSet{}
+	if err := decodeHex(result.ValidatorSet, validators); err != nil {
+		return err
+	}
+	if err := c.AddValidatorSet(validators); err != nil {
+		return VerificationError{err}
+	}
+	return nil
+}
+
+// GetAccount gets the account at the given address, and its proof in the state of
+// the latest finalized block. It returns nil if the proof shows that the account does
+// not exist.
+func (c *LightClient) GetAccount(address common.Address) (*types.Account, error) {
+	result := &rpc.GetAccountProofResult{}
+	if err := c.call("theta.GetAccountProof", rpc.GetAccountProofArgs{Address: address.Hex()}, result); err != nil {
+		return nil, err
+	}
+	if err := c.syncTo(uint64(result.BlockHeight)); err != nil {
+		return nil, err
+	}
+	proof, err := decodeProof(result.Proof)
+	if err != nil {
+		return nil, err
+	}
+	account, err := c.VerifyAccount(result.BlockHash, address, proof)
+	if err != nil {
+		return nil, VerificationError{err}
+	}
+	return account, nil
+}
+
+// GetTransaction gets the transaction of the given hash, and verifies its inclusion
+// in a finalized block.
+func (c *LightClient) GetTransaction(hash common.Hash) (*rpc.GetTransactionResult, error) {
+	result := &rpc.GetTransactionProofResult{}
+	if err := c.call("theta.GetTransactionProof", rpc.GetTransactionProofArgs{Hash: hash.Hex()}, result); err != nil {
+		return nil, err
+	}
+	if result.Status == rpc.TxStatusNotFound {
+		return &rpc.GetTransactionResult{Status: result.Status}, nil
+	}
+	if result.Status != rpc.TxStatusFinalized {
+		return nil, fmt.Errorf("Transaction %v is not finalized yet", hash.Hex())
+	}
+	if err := c.syncTo(uint64(result.BlockHeight)); err != nil {
+		return nil, err
+	}
+	raw, err := hex.DecodeString(result.Tx)
+	if err != nil {
+		return nil, err
+	}
+	if crypto.Keccak256Hash(raw) != hash {
+		return nil, VerificationError{fmt.Errorf("Transaction does not match the hash %v", hash.Hex())}
+	}
+	proof, err := decodeProof(result.Proof)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.VerifyTx(result.BlockHash, int(result.Index), raw, proof); err != nil {
+		return nil, VerificationError{err}
+	}
+	tx, err := types.TxFromBytes(raw)
+	if err != nil {
+		return nil, err
+	}
+	return &rpc.GetTransactionResult{
+		BlockHash:   result.BlockHash,
+		BlockHeight: result.BlockHeight,
+		Status:      result.Status,
+		TxHash:      hash,
+		Tx:          tx,
+	}, nil
+}
+
+// syncTo syncs the light client if the given height is above the head.
+func (c *LightClient) syncTo(height uint64) error {
+	if height <= c.Head().Height {
+		return nil
+	}
+	if err := c.Sync(); err != nil {
+		return err
+	}
+	if height > c.Head().Height {
+		return VerificationError{fmt.Errorf("The node serves no certified header at height %v", height)}
+	}
+	return nil
+}
+
+func (c *LightClient) call(method string, args interface{}, result interface{}) error {
+	res, err := c.rpc.Call(method, args)
+	if err != nil {
+		return err
+	}
+	if res.Error != nil {
+		return res.Error
+	}
+	return res.GetObject(result)
+}
+
+func decodeHex(str string, val interface{}) error {
+	raw, err := hex.DecodeString(str)
+	if err != nil {
+		return err
+	}
+	return rlp.DecodeBytes(raw, val)
+}
+
+func decodeProof(strs []string) ([]common.Bytes, error) {
+	proof := []common.Bytes{}
+	for _, str := range strs {
+		node, err := hex.DecodeString(str)
+		if err != nil {
+			return nil, err
+		}
+		proof = append(proof, node)
+	}
+	return proof, nil
+}
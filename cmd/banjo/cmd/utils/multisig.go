@@ -0,0 +1,122 @@
+package utils
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/crypto"
+	"github.com/thetatoken/ukulele/ledger/types"
+)
+
+type multisigAccountJSON struct {
+	Address    common.Address `json:"address"`
+	Threshold  uint64         `json:"threshold"`
+	PublicKeys []string       `json:"public_keys"`
+}
+
+// MultisigBook stores the multisig accounts in multisig.json under the config folder,
+// so that the transactions spending from them can be created by their addresses
+type MultisigBook struct {
+	filePath string
+	accounts []*types.MultisigAccount
+}
+
+// LoadMultisigBook loads the multisig accounts under the config folder
+func LoadMultisigBook(cfgPath string) (*MultisigBook, error) {
+	book := &MultisigBook{
+		filePath: path.Join(cfgPath, "multisig.json"),
+		accounts: []*types.MultisigAccount{},
+	}
+	content, err := ioutil.ReadFile(book.filePath)
+	if os.IsNotExist(err) {
+		return book, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	accountJSONs := []multisigAccountJSON{}
+	if err := json.Unmarshal(content, &accountJSONs); err != nil {
+		return nil, fmt.Errorf("Failed to parse multisig accounts: %v", err)
+	}
+	for _, accountJSON := range accountJSONs {
+		account, err := NewMultisigAccount(accountJSON.Threshold, accountJSON.PublicKeys)
+		if err != nil {
+			return nil, err
+		}
+		if account.Address() != accountJSON.Address {
+			return nil, fmt.Errorf("Multisig account %v does not match its public keys", accountJSON.Address.Hex())
+		}
+		book.accounts = append(book.accounts, account)
+	}
+	return book, nil
+}
+
+// NewMultisigAccount creates a multisig account from the hex encoded public keys
+func NewMultisigAccount(threshold uint64, pubKeyStrs []string) (*types.MultisigAccount, error) {
+	pubKeys := []*crypto.PublicKey{}
+	for _, pubKeyStr := range pubKeyStrs {
+		pubKeyBytes, err := hex.DecodeString(strings.TrimPrefix(pubKeyStr, "0x"))
+		if err != nil {
+			return nil, fmt.Errorf("Invalid public key %v: %v", pubKeyStr, err)
+		}
+		pubKey, err := crypto.PublicKeyFromBytes(pubKeyBytes)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid public key %v: %v", pubKeyStr, err)
+		}
+		pubKeys = append(pubKeys, pubKey)
+	}
+	return types.NewMultisigAccount(threshold, pubKeys)
+}
+
+// List returns the multisig accounts
+func (book *MultisigBook) List() []*types.MultisigAccount {
+	return append([]*types.MultisigAccount{}, book.accounts...)
+}
+
+// Get returns the multisig account of the address
+func (book *MultisigBook) Get(address common.Address) (*types.MultisigAccount, bool) {
+	for _, account := range book.accounts {
+		if account.Address() == address {
+			return account, true
+		}
+	}
+	return nil, false
+}
+
+// Add adds a multisig account and saves the multisig accounts
+func (book *MultisigBook) Add(account *types.MultisigAccount) error {
+	if _, exists := book.Get(account.Address()); exists {
+		return fmt.Errorf("Multisig account %v already exists", account.Address().Hex())
+	}
+	book.accounts = append(book.accounts, account)
+	return book.save()
+}
+
+func (book *MultisigBook) save() error {
+	accountJSONs := []multisigAccountJSON{}
+	for _, account := range book.accounts {
+		pubKeyStrs := []string{}
+		for _, pubKey := range account.PublicKeys {
+			pubKeyStrs = append(pubKeyStrs, hex.EncodeToString(pubKey.ToBytes()))
+		}
+		accountJSONs = append(accountJSONs, multisigAccountJSON{
+			Address:    account.Address(),
+			Threshold:  account.Threshold,
+			PublicKeys: pubKeyStrs,
+		})
+	}
+	content, err := json.MarshalIndent(accountJSONs, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(path.Dir(book.filePath), 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(book.filePath, content, 0600)
+}
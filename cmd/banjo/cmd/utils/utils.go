@@ -2,12 +2,14 @@ package utils
 
 import (
 	"bufio"
+	"encoding/json"
 	"fmt"
 	"os"
 	"strings"
 
 	"github.com/bgentry/speakeasy"
 	isatty "github.com/mattn/go-isatty"
+	"github.com/spf13/viper"
 )
 
 var buf *bufio.Reader
@@ -41,7 +43,78 @@ func stdinLine() (string, error) {
 	return strings.TrimSpace(line), nil
 }
 
+// ExitCode is the exit status of banjo, which allows scripts to tell the failures apart
+type ExitCode int
+
+const (
+	ExitCodeOK           ExitCode = 0
+	ExitCodeGeneric      ExitCode = 1 // Unclassified error
+	ExitCodeInvalidInput ExitCode = 2 // Invalid command line arguments or input
+	ExitCodeWallet       ExitCode = 3 // Failed to access the wallet or keys
+	ExitCodeRPC          ExitCode = 4 // Failed to communicate with the node
+	ExitCodeServer       ExitCode = 5 // The node returned an error
+	ExitCodeAborted      ExitCode = 6 // The user declined the confirmation
+	ExitCodeVerification ExitCode = 7 // Signature verification failed
+)
+
+var exitCodeNames = map[ExitCode]string{
+	ExitCodeOK:           "ok",
+	ExitCodeGeneric:      "generic_error",
+	ExitCodeInvalidInput: "invalid_input",
+	ExitCodeWallet:       "wallet_error",
+	ExitCodeRPC:          "rpc_error",
+	ExitCodeServer:       "server_error",
+	ExitCodeAborted:      "aborted",
+	ExitCodeVerification: "verification_failed",
+}
+
+// String returns the machine-readable name of the exit code
+func (code ExitCode) String() string {
+	if name, ok := exitCodeNames[code]; ok {
+		return name
+	}
+	return exitCodeNames[ExitCodeGeneric]
+}
+
+// ErrorJSON is the structured error printed when the output format is json
+type ErrorJSON struct {
+	Error ErrorDetailJSON `json:"error"`
+}
+
+type ErrorDetailJSON struct {
+	Code     string `json:"code"`
+	ExitCode int    `json:"exit_code"`
+	Message  string `json:"message"`
+}
+
+// Error prints the error message and exits with the generic error code
 func Error(msg string, args ...interface{}) {
-	fmt.Printf(msg, args...)
-	os.Exit(1)
+	ErrorWithCode(ExitCodeGeneric, msg, args...)
+}
+
+// ErrorWithCode prints the error message, formatted according to the output
+// format, and exits with the given code
+func ErrorWithCode(code ExitCode, msg string, args ...interface{}) {
+	message := fmt.Sprintf(msg, args...)
+	if OutputJSON() {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetEscapeHTML(false)
+		err := encoder.Encode(ErrorJSON{
+			Error: ErrorDetailJSON{
+				Code:     code.String(),
+				ExitCode: int(code),
+				Message:  strings.TrimSpace(message),
+			},
+		})
+		if err == nil {
+			os.Exit(int(code))
+		}
+	}
+	fmt.Print(message)
+	os.Exit(int(code))
+}
+
+// OutputJSON indicates whether the output format is json
+func OutputJSON() bool {
+	return viper.GetString(CfgOutput) == OutputFormatJSON
 }
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/thetatoken/ukulele/client"
+	"github.com/thetatoken/ukulele/cmd/banjo/cmd/utils"
+	"github.com/thetatoken/ukulele/rpc"
+	"github.com/thetatoken/ukulele/version"
+)
+
+// nodeVersionTimeout is how long banjo version waits for the node.
+const nodeVersionTimeout = 5 * time.Second
+
+var nodeVersionFlag bool
+
+// versionCmd prints the version of banjo, and of the node with --node.
+// Example:
+//		banjo version --node
+var versionCmd = &cobra.Command{
+	Use:     "version",
+	Short:   "Print the version of banjo",
+	Example: `banjo version --node`,
+	Run:     doVersionCmd,
+}
+
+func doVersionCmd(cmd *cobra.Command, args []string) {
+	fmt.Print(version.String("banjo"))
+	if !nodeVersionFlag {
+		return
+	}
+
+	endpoint := viper.GetString(utils.CfgRemoteRPCEndpoint)
+	c, err := client.NewClient(endpoint)
+	if err != nil {
+		utils.ErrorWithCode(utils.ExitCodeInvalidInput, "Failed to get the node version: %v\n", err)
+	}
+	c.Retries = 1
+	ctx, cancel := context.WithTimeout(context.Background(), nodeVersionTimeout)
+	defer cancel()
+	res, err := c.GetVersion(ctx, &rpc.GetVersionArgs{})
+	if err != nil {
+		if _, ok := err.(*client.RPCError); ok {
+			utils.ErrorWithCode(utils.ExitCodeServer, "Failed to get the node version: %v\n", err)
+		}
+		utils.ErrorWithCode(utils.ExitCodeRPC, "Failed to get the node version: %v\n", err)
+	}
+	fmt.Printf("\nNode %v version %v\nGit commit:       %v\nProtocol version: %v\n", endpoint, res.Version, res.GitCommit, uint64(res.ProtocolVersion))
+	if uint64(res.ProtocolVersion) != version.ProtocolVersion {
+		fmt.Println("Warning: the protocol version of the node differs from the one of banjo")
+	}
+}
+
+func init() {
+	versionCmd.Flags().BoolVar(&nodeVersionFlag, "node", false, "Also print the version of the node at the remote RPC endpoint")
+}
@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/thetatoken/ukulele/blockchain"
+	"github.com/thetatoken/ukulele/consensus"
+	"github.com/thetatoken/ukulele/core"
+	"github.com/thetatoken/ukulele/ledger"
+	"github.com/thetatoken/ukulele/ledger/state"
+	"github.com/thetatoken/ukulele/ledger/types"
+	"github.com/thetatoken/ukulele/store/database/backend"
+)
+
+// auditCmd represents the audit command.
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Audit the ledger state of the node.",
+}
+
+// auditSupplyCmd represents the audit supply command. It iterates the ledger state
+// of a finalized block, and reconciles the supply of each denomination with the
+// supply of the genesis checkpoint and the issuance of the reward policy of the
+// chain parameters. It exits with status 1 if the supply exceeds the expected supply. The
+// node must be stopped while its state is audited.
+// Example:
+//		theta audit supply --config=../privatenet/node --height=1000
+var auditSupplyCmd = &cobra.Command{
+	Use:   "supply",
+	Short: "Report the total supply of each denomination, and detect coins created outside of the reward schedule.",
+	Run:   runAuditSupply,
+}
+
+var auditHeight uint64
+
+func init() {
+	auditSupplyCmd.Flags().Uint64Var(&auditHeight, "height", 0, "Height of the finalized block to audit, the latest finalized block by default")
+
+	auditCmd.AddCommand(auditSupplyCmd)
+	RootCmd.AddCommand(auditCmd)
+}
+
+func runAuditSupply(cmd *cobra.Command, args []string) {
+	db, chain := openChain()
+	defer db.Close()
+
+	var block *core.ExtendedBlock
+	if auditHeight == 0 {
+		block = latestFinalizedBlock(chain)
+	} else {
+		for _, b := range chain.FindBlocksByHeight(auditHeight) {
+			if b.Status == core.BlockStatusFinalized {
+				block = b
+			}
+		}
+		if block == nil {
+			log.Fatalf("No finalized block at height %v", auditHeight)
+		}
+	}
+	view := state.NewStoreView(block.Height, block.StateHash, db)
+	if view == nil {
+		log.Fatalf("State %v of block %v is not in the database", block.StateHash.Hex(), block.Hash().Hex())
+	}
+
+	checkpoint, err := consensus.LoadCheckpoint(path.Join(cfgPath, "genesis"))
+	if err != nil {
+		log.WithFields(log.Fields{"err": err}).Fatal("Failed to load checkpoint")
+	}
+	genesisDB := backend.NewMemDatabase()
+	consensus.LoadCheckpointLedgerState(checkpoint, genesisDB)
+	genesis := state.NewStoreView(checkpoint.FirstBlock.Height, checkpoint.FirstBlock.StateHash, genesisDB)
+
+	audit, err := ledger.AuditSupply(view, genesis, view.GetChainParameters().RewardPolicy())
+	if err != nil {
+		log.WithFields(log.Fields{"err": err}).Fatal("Failed to audit the supply")
+	}
+
+	fmt.Printf("Supply of block %v at height %v, %v accounts:\n", block.Hash().Hex(), block.Height, audit.Supply.Accounts)
+	printSupplyLine("Balances", audit.Supply.Balances)
+	printSupplyLine("Reserved", audit.Supply.Reserved)
+	printSupplyLine("Locked", audit.Supply.Locked)
+	printSupplyLine("Staked", audit.Supply.Staked)
+	printSupplyLine("Fee pool", audit.Supply.FeePool)
+	printSupplyLine("Total", audit.Supply.Total)
+	fmt.Println()
+	printSupplyLine("Genesis", audit.Genesis)
+	printSupplyLine("Issued", audit.Issued)
+	printSupplyLine("Expected", audit.Expected)
+	printSupplyLine("Burned", audit.Burned)
+	printSupplyLine("Inflation", audit.Inflation)
+
+	if !audit.IsConsistent() {
+		fmt.Println("The supply exceeds the expected supply.")
+		db.Close()
+		os.Exit(1)
+	}
+}
+
+// latestFinalizedBlock follows the finalized blocks from the root of the chain.
+func latestFinalizedBlock(chain *blockchain.Chain) *core.ExtendedBlock {
+	block := chain.Root
+	for {
+		var next *core.ExtendedBlock
+		for _, b := range chain.FindBlocksByHeight(block.Height + 1) {
+			if b.Status == core.BlockStatusFinalized && b.Parent == block.Hash() {
+				next = b
+			}
+		}
+		if next == nil {
+			return block
+		}
+		block = next
+	}
+}
+
+func printSupplyLine(name string, coins types.Coins) {
+	coins = coins.NoNil()
+	line := fmt.Sprintf("%-10s %v ThetaWei, %v GammaWei", name+":", coins.ThetaWei, coins.GammaWei)
+	for _, token := range coins.Tokens {
+		line += fmt.Sprintf(", %v %v", token.Amount, token.Symbol)
+	}
+	fmt.Println(line)
+}
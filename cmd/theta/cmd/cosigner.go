@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"net/http"
+	"path"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/rpc/v2"
+	json "github.com/gorilla/rpc/v2/json2"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/consensus"
+)
+
+// cosignerCmd represents the cosigner command. A cosigner serves the signature
+// shares of its key share to the validator node, whose own key share is not
+// enough to reach the threshold.
+// Example:
+//		theta cosigner --listen=10.0.0.2:16889
+var cosignerCmd = &cobra.Command{
+	Use:   "cosigner",
+	Short: "Serve the signature shares of a threshold validator key share.",
+	Run:   runCosigner,
+}
+
+var cosignerListenAddr string
+
+func init() {
+	cosignerCmd.Flags().StringVar(&cosignerListenAddr, "listen", "127.0.0.1:16889", "Address to serve the cosigner RPC on")
+	RootCmd.AddCommand(cosignerCmd)
+}
+
+func runCosigner(cmd *cobra.Command, args []string) {
+	keyFile := common.GetConfig().Consensus.ThresholdKey
+	if keyFile == "" {
+		keyFile = path.Join(cfgPath, "threshold_key")
+	}
+	keyShare, err := loadKeyShare(keyFile)
+	if err != nil {
+		log.WithFields(log.Fields{"err": err, "path": keyFile}).Fatal("Failed to load key share")
+	}
+
+	handler := rpc.NewServer()
+	handler.RegisterCodec(json.NewCodec(), "application/json")
+	handler.RegisterCodec(json.NewCodec(), "application/json;charset=UTF-8")
+	handler.RegisterService(consensus.NewCosignerService(keyShare), "cosigner")
+	router := mux.NewRouter()
+	router.Handle("/rpc", handler)
+
+	log.WithFields(log.Fields{
+		"validator": keyShare.GroupPublicKey().Address().Hex(),
+		"index":     keyShare.Index,
+		"listen":    cosignerListenAddr,
+	}).Info("Serving key share")
+	if err := http.ListenAndServe(cosignerListenAddr, router); err != nil {
+		log.WithFields(log.Fields{"err": err}).Fatal("Cosigner stopped")
+	}
+}
@@ -0,0 +1,209 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/thetatoken/ukulele/blockchain"
+	"github.com/thetatoken/ukulele/blockchain/archive"
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/consensus"
+	"github.com/thetatoken/ukulele/ledger/state"
+	"github.com/thetatoken/ukulele/store/database"
+	"github.com/thetatoken/ukulele/store/database/backend"
+	"github.com/thetatoken/ukulele/store/kvstore"
+)
+
+// dbCmd represents the db command. The node must be stopped while its database
+// is verified or repaired.
+var dbCmd = &cobra.Command{
+	Use:   "db",
+	Short: "Check and repair the database of the node.",
+}
+
+// dbVerifyCmd represents the db verify command. It exits with status 1 if the
+// database is inconsistent.
+// Example:
+//		theta db verify --config=../privatenet/node
+var dbVerifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Check the hashes and links of the blocks, the indexes and the latest finalized state.",
+	Run:   runDBVerify,
+}
+
+// dbRepairCmd represents the db repair command. It rebuilds the indexes derived
+// from the blocks. Missing blocks and states cannot be repaired, and need the
+// chain to be synced again.
+// Example:
+//		theta db repair --config=../privatenet/node
+var dbRepairCmd = &cobra.Command{
+	Use:   "repair",
+	Short: "Rebuild the block height and transaction indexes from the blocks.",
+	Run:   runDBRepair,
+}
+
+// dbExportCmd represents the db export command.
+// Example:
+//		theta db export --config=../privatenet/node --file=chain.arc --state
+var dbExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export the finalized chain, and optionally the ledger state of its head, to an archive file.",
+	Run:   runDBExport,
+}
+
+// dbImportCmd represents the db import command. The node resumes from the head of
+// the archive, which needs the ledger state of the head, either in the archive or
+// already in the database.
+// Example:
+//		theta db import --config=../privatenet/node --file=chain.arc
+var dbImportCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Import the blocks and the ledger state of an archive file.",
+	Run:   runDBImport,
+}
+
+var (
+	dbArchiveFile string
+	dbExportState bool
+)
+
+func init() {
+	dbExportCmd.Flags().StringVar(&dbArchiveFile, "file", "chain.arc", "Archive file to write")
+	dbExportCmd.Flags().BoolVar(&dbExportState, "state", false, "Also export the ledger state of the latest finalized block")
+	dbImportCmd.Flags().StringVar(&dbArchiveFile, "file", "chain.arc", "Archive file to read")
+
+	dbCmd.AddCommand(dbVerifyCmd)
+	dbCmd.AddCommand(dbRepairCmd)
+	dbCmd.AddCommand(dbExportCmd)
+	dbCmd.AddCommand(dbImportCmd)
+	RootCmd.AddCommand(dbCmd)
+}
+
+func runDBVerify(cmd *cobra.Command, args []string) {
+	db, chain := openChain()
+	defer db.Close()
+
+	report := verifyChain(db, chain)
+	if len(report.Inconsistencies) > 0 {
+		if report.Repairable() {
+			fmt.Println("All the inconsistencies can be fixed with \"theta db repair\".")
+		}
+		db.Close()
+		os.Exit(1)
+	}
+}
+
+func runDBRepair(cmd *cobra.Command, args []string) {
+	db, chain := openChain()
+	defer db.Close()
+
+	report := verifyChain(db, chain)
+	if len(report.Inconsistencies) == 0 {
+		return
+	}
+
+	indexed, err := chain.RebuildIndexes(db)
+	if err != nil {
+		log.WithFields(log.Fields{"err": err}).Fatal("Failed to rebuild the indexes")
+	}
+	fmt.Printf("Rebuilt the indexes of %v blocks.\n", indexed)
+
+	report = verifyChain(db, chain)
+	if len(report.Inconsistencies) > 0 {
+		fmt.Println("The remaining inconsistencies cannot be repaired. Sync the chain again to fix them.")
+		db.Close()
+		os.Exit(1)
+	}
+}
+
+func runDBExport(cmd *cobra.Command, args []string) {
+	db, chain := openChain()
+	defer db.Close()
+
+	file, err := os.OpenFile(dbArchiveFile, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		log.WithFields(log.Fields{"err": err, "path": dbArchiveFile}).Fatal("Failed to create archive file")
+	}
+	w := bufio.NewWriter(file)
+	header, err := archive.Export(w, chain, db, dbExportState, newArchiveProgress("Exported"))
+	if err == nil {
+		err = w.Flush()
+	}
+	if err == nil {
+		err = file.Close()
+	}
+	if err != nil {
+		file.Close()
+		os.Remove(dbArchiveFile)
+		log.WithFields(log.Fields{"err": err}).Fatal("Failed to export the chain")
+	}
+	fmt.Printf("Exported the chain up to block %v at height %v to %v.\n", header.Head.Hex(), header.HeadHeight, dbArchiveFile)
+}
+
+func runDBImport(cmd *cobra.Command, args []string) {
+	db, chain := openChain()
+	defer db.Close()
+
+	file, err := os.Open(dbArchiveFile)
+	if err != nil {
+		log.WithFields(log.Fields{"err": err, "path": dbArchiveFile}).Fatal("Failed to open archive file")
+	}
+	defer file.Close()
+	header, err := archive.Import(file, chain, db, newArchiveProgress("Imported"))
+	if err != nil {
+		log.WithFields(log.Fields{"err": err}).Fatal("Failed to import the chain")
+	}
+	fmt.Printf("Imported the chain up to block %v at height %v.\n", header.Head.Hex(), header.HeadHeight)
+}
+
+// newArchiveProgress returns a progress callback that logs the progress at most
+// every few seconds.
+func newArchiveProgress(action string) archive.Progress {
+	last := time.Now()
+	return func(blocks uint64, stateEntries uint64) {
+		if time.Since(last) < 5*time.Second {
+			return
+		}
+		last = time.Now()
+		log.Infof("%v %v blocks and %v state entries", action, blocks, stateEntries)
+	}
+}
+
+// openChain opens the database of the node and the chain stored in it.
+func openChain() (database.Backend, *blockchain.Chain) {
+	checkpoint, err := consensus.LoadCheckpoint(path.Join(cfgPath, "genesis"))
+	if err != nil {
+		log.WithFields(log.Fields{"err": err}).Fatal("Failed to load checkpoint")
+	}
+	db, err := backend.NewBackend(common.GetConfig().Storage.Backend, path.Join(cfgPath, "db"), 256, 0)
+	if err != nil {
+		log.WithFields(log.Fields{"err": err}).Fatal("Failed to open database")
+	}
+	root := checkpoint.FirstBlock
+	chain := blockchain.NewChain(root.ChainID, kvstore.NewKVStore(db), root)
+	chain.SetFreezer(openFreezer(), 0)
+	return db, chain
+}
+
+// verifyChain verifies the chain, and prints the inconsistencies found.
+func verifyChain(db database.Backend, chain *blockchain.Chain) *blockchain.VerificationReport {
+	hasState := func(root common.Hash) bool {
+		return state.NewStoreView(0, root, db) != nil
+	}
+	report, err := chain.Verify(db, hasState)
+	if err != nil {
+		log.WithFields(log.Fields{"err": err}).Fatal("Failed to verify the database")
+	}
+
+	for _, inc := range report.Inconsistencies {
+		fmt.Println(inc)
+	}
+	fmt.Printf("Verified %v blocks, latest finalized block %v at height %v: %v inconsistencies found.\n",
+		report.Blocks, report.LatestFinalized.Hash().Hex(), report.LatestFinalized.Height, len(report.Inconsistencies))
+	return report
+}
@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/thetatoken/ukulele/blockchain"
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/core"
+	"github.com/thetatoken/ukulele/ledger/state"
+	"github.com/thetatoken/ukulele/store/database"
+	"github.com/thetatoken/ukulele/store/database/backend"
+)
+
+// dbDiffCmd represents the db diff command. It compares two ledger states, e.g. the
+// state of the node and the state a protocol upgrade replays the chain to, and
+// writes the accounts, reserved funds, split rules and other keys that differ as
+// JSON. A state is given by the height of a finalized block of the node, or by a
+// state root, and is read from the database of the node unless another database is
+// given, such as the --state-dir of a replay. It exits with status 1 if the states
+// differ.
+// Example:
+//		theta db diff --config=../privatenet/node --before=1000 --after=0x3a6f... --after-db=/tmp/replay
+var dbDiffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Report the differences between two ledger states.",
+	Run:   runDBDiff,
+}
+
+var (
+	dbDiffBefore   string
+	dbDiffAfter    string
+	dbDiffBeforeDB string
+	dbDiffAfterDB  string
+	dbDiffOutput   string
+)
+
+func init() {
+	dbDiffCmd.Flags().StringVar(&dbDiffBefore, "before", "", "Height of a finalized block, or state root, of the state before")
+	dbDiffCmd.Flags().StringVar(&dbDiffAfter, "after", "", "Height of a finalized block, or state root, of the state after")
+	dbDiffCmd.Flags().StringVar(&dbDiffBeforeDB, "before-db", "", "Database directory of the state before, the database of the node by default")
+	dbDiffCmd.Flags().StringVar(&dbDiffAfterDB, "after-db", "", "Database directory of the state after, the database of the node by default")
+	dbDiffCmd.Flags().StringVar(&dbDiffOutput, "output", "", "File to write the differences to, the standard output by default")
+
+	dbCmd.AddCommand(dbDiffCmd)
+}
+
+func runDBDiff(cmd *cobra.Command, args []string) {
+	db, chain := openChain()
+	defer db.Close()
+
+	before, closeBefore := openDiffState(db, chain, dbDiffBefore, dbDiffBeforeDB)
+	after, closeAfter := openDiffState(db, chain, dbDiffAfter, dbDiffAfterDB)
+
+	diff, err := state.DiffStates(before, after)
+	if err != nil {
+		log.WithFields(log.Fields{"err": err}).Fatal("Failed to compare the states")
+	}
+	diffJSON, err := json.MarshalIndent(diff, "", "  ")
+	if err != nil {
+		log.WithFields(log.Fields{"err": err}).Fatal("Failed to encode the differences")
+	}
+	diffJSON = append(diffJSON, '\n')
+	if dbDiffOutput == "" {
+		_, err = os.Stdout.Write(diffJSON)
+	} else {
+		err = ioutil.WriteFile(dbDiffOutput, diffJSON, 0600)
+	}
+	if err != nil {
+		log.WithFields(log.Fields{"err": err}).Fatal("Failed to write the differences")
+	}
+
+	log.Infof("%v accounts, %v reserved funds, %v split rules and %v other keys differ",
+		len(diff.Accounts), len(diff.Reserves), len(diff.SplitRules), len(diff.Others))
+	closeBefore()
+	closeAfter()
+	if !diff.IsEmpty() {
+		db.Close()
+		os.Exit(1)
+	}
+}
+
+// openDiffState opens the state given by a block height or a state root, in the
+// database of the node or in the database of the given directory.
+func openDiffState(db database.Backend, chain *blockchain.Chain, spec string, dir string) (*state.StoreView, func()) {
+	closeDB := func() {}
+	stateDB := database.Database(db)
+	if dir != "" {
+		if _, err := os.Stat(dir); err != nil {
+			log.WithFields(log.Fields{"err": err, "path": dir}).Fatal("Failed to open state database")
+		}
+		dirDB, err := backend.NewBackend(common.GetConfig().Storage.Backend, dir, 256, 0)
+		if err != nil {
+			log.WithFields(log.Fields{"err": err, "path": dir}).Fatal("Failed to open state database")
+		}
+		stateDB = dirDB
+		closeDB = func() { dirDB.Close() }
+	}
+
+	var height uint64
+	var root common.Hash
+	if strings.HasPrefix(spec, "0x") {
+		root = common.HexToHash(spec)
+	} else {
+		var err error
+		if height, err = strconv.ParseUint(spec, 10, 64); err != nil {
+			log.Fatalf("Invalid height or state root: %v", spec)
+		}
+		var block *core.ExtendedBlock
+		for _, b := range chain.FindBlocksByHeight(height) {
+			if b.Status == core.BlockStatusFinalized {
+				block = b
+			}
+		}
+		if block == nil {
+			log.Fatalf("No finalized block at height %v", height)
+		}
+		root = block.StateHash
+	}
+
+	view := state.NewStoreView(height, root, stateDB)
+	if view == nil {
+		log.Fatalf("State %v is not in the database", root.Hex())
+	}
+	return view, closeDB
+}
@@ -0,0 +1,206 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/thetatoken/ukulele/common/hexutil"
+	"github.com/thetatoken/ukulele/crypto/bls"
+)
+
+// dkgCmd represents the dkg command. The participants of the distributed key
+// generation exchange files: each of them deals with "dkg deal", publishes its
+// commitment file to all the others, and sends each share file privately to the
+// participant it is for. Then each participant combines the dealings it received
+// into its key share with "dkg combine".
+var dkgCmd = &cobra.Command{
+	Use:   "dkg",
+	Short: "Generate a threshold validator key with distributed key generation.",
+}
+
+// dkgDealCmd represents the dkg deal command.
+// Example:
+//		theta dkg deal --index=1 --threshold=2 --participants=3 --dir=./dkg
+var dkgDealCmd = &cobra.Command{
+	Use:   "deal",
+	Short: "Deal the shares of this participant to all the participants.",
+	Run:   runDKGDeal,
+}
+
+// dkgCombineCmd represents the dkg combine command.
+// Example:
+//		theta dkg combine --index=1 --dir=./dkg
+var dkgCombineCmd = &cobra.Command{
+	Use:   "combine",
+	Short: "Combine the dealt shares into the key share of this participant.",
+	Run:   runDKGCombine,
+}
+
+var (
+	dkgIndex        uint64
+	dkgThreshold    int
+	dkgParticipants int
+	dkgDir          string
+)
+
+// dkgCommitment is the content of a commitment file, published to all the participants.
+type dkgCommitment struct {
+	Dealer     uint64         `json:"dealer"`
+	Commitment bls.Commitment `json:"commitment"`
+}
+
+// dkgShare is the content of a share file, sent privately to the participant.
+type dkgShare struct {
+	Dealer      uint64          `json:"dealer"`
+	Participant uint64          `json:"participant"`
+	Share       *bls.PrivateKey `json:"share"`
+}
+
+func init() {
+	dkgDealCmd.Flags().Uint64Var(&dkgIndex, "index", 0, "Index of this participant, from 1 to the number of participants")
+	dkgDealCmd.Flags().IntVar(&dkgThreshold, "threshold", 0, "Number of key shares needed to sign")
+	dkgDealCmd.Flags().IntVar(&dkgParticipants, "participants", 0, "Number of key shares")
+	dkgDealCmd.Flags().StringVar(&dkgDir, "dir", "dkg", "Folder to write the commitment and share files to")
+
+	dkgCombineCmd.Flags().Uint64Var(&dkgIndex, "index", 0, "Index of this participant, from 1 to the number of participants")
+	dkgCombineCmd.Flags().StringVar(&dkgDir, "dir", "dkg", "Folder of the commitment files and the share files received")
+
+	dkgCmd.AddCommand(dkgDealCmd)
+	dkgCmd.AddCommand(dkgCombineCmd)
+	RootCmd.AddCommand(dkgCmd)
+}
+
+func runDKGDeal(cmd *cobra.Command, args []string) {
+	if dkgIndex == 0 || dkgIndex > uint64(dkgParticipants) {
+		log.WithFields(log.Fields{"index": dkgIndex, "participants": dkgParticipants}).Fatal("Index needs to be between 1 and the number of participants")
+	}
+	dealing, err := bls.NewDealing(nil, dkgThreshold, dkgParticipants)
+	if err != nil {
+		log.WithFields(log.Fields{"err": err}).Fatal("Failed to deal")
+	}
+
+	if err := os.MkdirAll(dkgDir, 0700); err != nil {
+		log.WithFields(log.Fields{"err": err, "path": dkgDir}).Fatal("Failed to create folder")
+	}
+	writeDKGFile(commitmentFileName(dkgIndex), dkgCommitment{
+		Dealer:     dkgIndex,
+		Commitment: dealing.Commitment,
+	})
+	for j, share := range dealing.Shares {
+		participant := uint64(j + 1)
+		writeDKGFile(shareFileName(dkgIndex, participant), dkgShare{
+			Dealer:      dkgIndex,
+			Participant: participant,
+			Share:       share,
+		})
+	}
+
+	fmt.Printf("Publish %v to all the participants.\n", path.Join(dkgDir, commitmentFileName(dkgIndex)))
+	fmt.Printf("Send %v privately to participant N, and delete the share files afterwards.\n", path.Join(dkgDir, fmt.Sprintf("share-%d-N.json", dkgIndex)))
+}
+
+func runDKGCombine(cmd *cobra.Command, args []string) {
+	if dkgIndex == 0 {
+		log.Fatal("Index of this participant is not specified")
+	}
+	keyFile := path.Join(cfgPath, "threshold_key")
+	if _, err := os.Stat(keyFile); !os.IsNotExist(err) {
+		log.WithFields(log.Fields{"path": keyFile}).Fatal("Key share already exists")
+	}
+
+	commitmentFiles, err := filepath.Glob(path.Join(dkgDir, "commitment-*.json"))
+	if err != nil || len(commitmentFiles) == 0 {
+		log.WithFields(log.Fields{"path": dkgDir}).Fatal("No commitment files found")
+	}
+	dealings := []dkgCommitment{}
+	for _, file := range commitmentFiles {
+		dealing := dkgCommitment{}
+		readDKGFile(file, &dealing)
+		dealings = append(dealings, dealing)
+	}
+	sort.Slice(dealings, func(i, j int) bool {
+		return dealings[i].Dealer < dealings[j].Dealer
+	})
+
+	commitments := []bls.Commitment{}
+	shares := []*bls.PrivateKey{}
+	for _, dealing := range dealings {
+		share := dkgShare{}
+		readDKGFile(path.Join(dkgDir, shareFileName(dealing.Dealer, dkgIndex)), &share)
+		if share.Dealer != dealing.Dealer || share.Participant != dkgIndex {
+			log.WithFields(log.Fields{"dealer": dealing.Dealer}).Fatal("Share file does not match its name")
+		}
+		commitments = append(commitments, dealing.Commitment)
+		shares = append(shares, share.Share)
+	}
+
+	keyShare, err := bls.CombineDealings(dkgIndex, commitments, shares)
+	if err != nil {
+		log.WithFields(log.Fields{"err": err}).Fatal("Failed to combine the dealings")
+	}
+	content, err := json.MarshalIndent(keyShare, "", "  ")
+	if err != nil {
+		log.WithFields(log.Fields{"err": err}).Fatal("Failed to encode key share")
+	}
+	if err := ioutil.WriteFile(keyFile, content, 0600); err != nil {
+		log.WithFields(log.Fields{"err": err, "path": keyFile}).Fatal("Failed to write key share")
+	}
+
+	groupKey := keyShare.GroupPublicKey()
+	fmt.Printf("Combined %v dealings into key share %v of a %v-of-%v key.\n", len(dealings), dkgIndex, keyShare.Threshold(), len(dealings))
+	fmt.Printf("Key share: %v\n", keyFile)
+	fmt.Printf("Validator address: %v\n", groupKey.Address().Hex())
+	fmt.Printf("Group public key: %v\n", hexutil.Bytes(groupKey.ToBytes()))
+}
+
+// loadKeyShare loads the key share written by "dkg combine"
+func loadKeyShare(keyFile string) (*bls.KeyShare, error) {
+	content, err := ioutil.ReadFile(keyFile)
+	if err != nil {
+		return nil, err
+	}
+	keyShare := &bls.KeyShare{}
+	if err := json.Unmarshal(content, keyShare); err != nil {
+		return nil, err
+	}
+	if err := keyShare.Validate(); err != nil {
+		return nil, err
+	}
+	return keyShare, nil
+}
+
+func commitmentFileName(dealer uint64) string {
+	return fmt.Sprintf("commitment-%d.json", dealer)
+}
+
+func shareFileName(dealer, participant uint64) string {
+	return fmt.Sprintf("share-%d-%d.json", dealer, participant)
+}
+
+func writeDKGFile(name string, v interface{}) {
+	filePath := path.Join(dkgDir, name)
+	content, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		log.WithFields(log.Fields{"err": err, "path": filePath}).Fatal("Failed to encode file")
+	}
+	if err := ioutil.WriteFile(filePath, content, 0600); err != nil {
+		log.WithFields(log.Fields{"err": err, "path": filePath}).Fatal("Failed to write file")
+	}
+}
+
+func readDKGFile(filePath string, v interface{}) {
+	content, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		log.WithFields(log.Fields{"err": err, "path": filePath}).Fatal("Failed to read file")
+	}
+	if err := json.Unmarshal(content, v); err != nil {
+		log.WithFields(log.Fields{"err": err, "path": filePath}).Fatal("Failed to parse file")
+	}
+}
@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/consensus"
+	"github.com/thetatoken/ukulele/core"
+	"github.com/thetatoken/ukulele/ledger"
+	"github.com/thetatoken/ukulele/store/database/backend"
+)
+
+// replayCmd represents the replay command. It re-executes the finalized blocks from
+// the genesis state in a fresh state database, leaving the state of the node
+// untouched, and exits with status 1 if a block does not re-execute to the state
+// root in its header, e.g. because of a non-deterministic transaction execution.
+// The node must be stopped while its chain is replayed.
+// Example:
+//		theta replay --config=../privatenet/node
+var replayCmd = &cobra.Command{
+	Use:   "replay",
+	Short: "Re-execute the finalized blocks from genesis and report the first state root mismatch.",
+	Run:   runReplay,
+}
+
+var replayStateDir string
+
+func init() {
+	replayCmd.Flags().StringVar(&replayStateDir, "state-dir", "", "Directory of the fresh state database, a temporary directory removed after the replay by default")
+
+	RootCmd.AddCommand(replayCmd)
+}
+
+func runReplay(cmd *cobra.Command, args []string) {
+	db, chain := openChain()
+	defer db.Close()
+
+	checkpoint, err := consensus.LoadCheckpoint(path.Join(cfgPath, "genesis"))
+	if err != nil {
+		log.WithFields(log.Fields{"err": err}).Fatal("Failed to load checkpoint")
+	}
+
+	dir := replayStateDir
+	if dir == "" {
+		if dir, err = ioutil.TempDir("", "theta-replay"); err != nil {
+			log.WithFields(log.Fields{"err": err}).Fatal("Failed to create state directory")
+		}
+		defer os.RemoveAll(dir)
+	}
+	stateDB, err := backend.NewBackend(common.GetConfig().Storage.Backend, dir, 256, 0)
+	if err != nil {
+		log.WithFields(log.Fields{"err": err, "path": dir}).Fatal("Failed to open state database")
+	}
+	defer stateDB.Close()
+	consensus.LoadCheckpointLedgerState(checkpoint, stateDB)
+
+	validators := consensus.NewTestValidatorSet(checkpoint.Validators)
+	var valMgr core.ValidatorManager
+	if common.GetConfig().Consensus.ProposerSelection == "vrf" {
+		valMgr = consensus.NewVRFValidatorManager(validators, chain.Root.Hash())
+	} else {
+		valMgr = consensus.NewFixedValidatorManager(validators)
+	}
+
+	start := time.Now()
+	last := start
+	progress := func(block *core.ExtendedBlock) {
+		if time.Since(last) < 5*time.Second {
+			return
+		}
+		last = time.Now()
+		log.Infof("Replayed block %v at height %v", block.Hash().Hex(), block.Height)
+	}
+	report, err := ledger.Replay(chain, stateDB, valMgr, progress)
+	if err != nil {
+		log.WithFields(log.Fields{"err": err}).Fatal("Failed to replay the chain")
+	}
+
+	fmt.Printf("Replayed %v blocks in %v, up to block %v at height %v.\n",
+		report.Blocks, time.Since(start).Round(time.Second), report.Head.Hash().Hex(), report.Head.Height)
+	if report.Divergence != nil {
+		fmt.Printf("First divergence: %v\n", report.Divergence)
+		stateDB.Close()
+		db.Close()
+		if replayStateDir == "" {
+			os.RemoveAll(dir)
+		}
+		os.Exit(1)
+	}
+}
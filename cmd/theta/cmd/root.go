@@ -14,7 +14,7 @@ var cfgPath string
 
 // RootCmd represents the base command when called without any subcommands
 var RootCmd = &cobra.Command{
-	Use:   "ukulele",
+	Use:   "theta",
 	Short: "Theta CLI",
 }
 
@@ -50,7 +50,8 @@ func initConfig() {
 	}
 }
 
-// getDefaultConfigPath returns the default config path.
+// getDefaultConfigPath returns the default config path. It is still ~/.ukulele, so that
+// the existing nodes keep their config and data.
 func getDefaultConfigPath() string {
 	home, err := homedir.Dir()
 	if err != nil {
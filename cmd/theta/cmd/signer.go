@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"net/http"
+	"path"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/rpc/v2"
+	json "github.com/gorilla/rpc/v2/json2"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/thetatoken/ukulele/consensus"
+	"github.com/thetatoken/ukulele/core"
+	"github.com/thetatoken/ukulele/crypto"
+)
+
+// signerCmd represents the signer command. A remote signer holds the key of a
+// validator away from the validator node, and signs its votes and proposer
+// transactions, guarded against double signs by its own sign state file.
+// Example:
+//		theta signer --listen=10.0.0.2:16890 --key=/secure/validator_key
+var signerCmd = &cobra.Command{
+	Use:   "signer",
+	Short: "Serve the signatures of the votes of a validator key.",
+	Run:   runSigner,
+}
+
+var signerListenAddr string
+var signerKeyFile string
+
+func init() {
+	signerCmd.Flags().StringVar(&signerListenAddr, "listen", "127.0.0.1:16890", "Address to serve the signer RPC on")
+	signerCmd.Flags().StringVar(&signerKeyFile, "key", "", "Validator key file (default is the key in the config path)")
+	RootCmd.AddCommand(signerCmd)
+}
+
+func runSigner(cmd *cobra.Command, args []string) {
+	keyFile := signerKeyFile
+	if keyFile == "" {
+		keyFile = path.Join(cfgPath, "key")
+	}
+	privKey, err := crypto.PrivateKeyFromFile(keyFile)
+	if err != nil {
+		log.WithFields(log.Fields{"err": err, "path": keyFile}).Fatal("Failed to load validator key")
+	}
+	guard := loadSignGuard()
+
+	handler := rpc.NewServer()
+	handler.RegisterCodec(json.NewCodec(), "application/json")
+	handler.RegisterCodec(json.NewCodec(), "application/json;charset=UTF-8")
+	handler.RegisterService(consensus.NewSignerService(core.NewPrivateKeySigner(privKey), guard), "signer")
+	router := mux.NewRouter()
+	router.Handle("/rpc", handler)
+
+	state := guard.State()
+	log.WithFields(log.Fields{
+		"validator":        privKey.PublicKey().Address().Hex(),
+		"lastSignedHeight": state.Height,
+		"signStateFile":    signStateFile(),
+		"listen":           signerListenAddr,
+	}).Info("Serving validator key")
+	if err := http.ListenAndServe(signerListenAddr, router); err != nil {
+		log.WithFields(log.Fields{"err": err}).Fatal("Signer stopped")
+	}
+}
@@ -0,0 +1,238 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path"
+	"syscall"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/common/metrics"
+	"github.com/thetatoken/ukulele/common/trace"
+	"github.com/thetatoken/ukulele/consensus"
+	"github.com/thetatoken/ukulele/core"
+	"github.com/thetatoken/ukulele/crypto"
+	"github.com/thetatoken/ukulele/node"
+	"github.com/thetatoken/ukulele/p2p/messenger"
+	"github.com/thetatoken/ukulele/store/database/backend"
+	"github.com/thetatoken/ukulele/store/freezer"
+)
+
+// startCmd represents the start command
+var startCmd = &cobra.Command{
+	Use:   "start",
+	Short: "Start Theta node.",
+	Run:   runStart,
+}
+
+func init() {
+	startCmd.Flags().Bool("dry-run", false, "Validate and execute the blocks without voting or proposing")
+	viper.BindPFlag(common.CfgConsensusDryRun, startCmd.Flags().Lookup("dry-run"))
+	RootCmd.AddCommand(startCmd)
+}
+
+func runStart(cmd *cobra.Command, args []string) {
+	config, err := common.LoadConfig()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if config.Metrics.Enabled {
+		// Enabled before the components register their metrics
+		metrics.Enabled = true
+	}
+	privKey := loadOrCreateKey()
+	if config.Tracing.Enabled {
+		exporter := trace.NewOTLPExporter(config.Tracing.Endpoint, "theta", privKey.PublicKey().Address().Hex())
+		exporter.Start(context.Background())
+		trace.SetExporter(exporter)
+	}
+
+	network := newMessenger(privKey, config.P2P)
+	common.OnConfigReload(func(config *common.Config) {
+		if err := network.SetSeedPeers(config.P2P.Seeds); err != nil {
+			log.WithFields(log.Fields{"err": err}).Error("Failed to update seed peers")
+		}
+	})
+	go reloadConfigOnSignal()
+
+	checkpoint, err := consensus.LoadCheckpoint(path.Join(cfgPath, "genesis"))
+	if err != nil {
+		log.WithFields(log.Fields{"err": err}).Fatal("Failed to load checkpoint")
+	}
+	validators := checkpoint.Validators
+	db, err := backend.NewBackend(config.Storage.Backend, path.Join(cfgPath, "db"), 256, 0)
+	if err != nil {
+		log.WithFields(log.Fields{"err": err}).Fatal("Failed to open database")
+	}
+	blockFreezer := openFreezer()
+	root := checkpoint.FirstBlock
+
+	consensus.LoadCheckpointLedgerState(checkpoint, db)
+
+	params := &node.Params{
+		ChainID:    root.ChainID,
+		PrivateKey: privKey,
+		Signer:     loadSigner(),
+		SignGuard:  loadSignGuard(),
+		Root:       root,
+		Validators: consensus.NewTestValidatorSet(validators),
+		Network:    network,
+		DB:         db,
+		Freezer:    blockFreezer,
+	}
+	n := node.NewNode(params)
+	if err := n.Start(context.Background()); err != nil {
+		log.WithFields(log.Fields{"err": err}).Fatal("Failed to start node")
+	}
+	go stopOnSignal(n)
+
+	n.Wait()
+	// The node does not own the storage, which is closed once all its components stopped
+	if err := blockFreezer.Close(); err != nil {
+		log.WithFields(log.Fields{"err": err}).Error("Failed to close freezer")
+	}
+	db.Close()
+	log.Info("Node stopped")
+}
+
+// stopOnSignal stops the node once the process receives SIGINT or SIGTERM.
+func stopOnSignal(n *node.Node) {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+	sig := <-sigs
+	log.WithFields(log.Fields{"signal": sig}).Info("Stopping node")
+	n.Stop()
+}
+
+// reloadConfigOnSignal reloads the config file each time the process receives SIGHUP.
+func reloadConfigOnSignal() {
+	sighup := make(chan os.Signal, 1)
+	signal.Notify(sighup, syscall.SIGHUP)
+	for range sighup {
+		reload, err := common.ReloadConfig()
+		if err != nil {
+			log.WithFields(log.Fields{"err": err}).Error("Failed to reload config")
+			continue
+		}
+		log.WithFields(log.Fields{
+			"reloaded": reload.Reloaded,
+			"ignored":  reload.Ignored,
+		}).Info("Config reloaded")
+	}
+}
+
+// openFreezer opens the freezer of the ancient blocks, next to the database.
+func openFreezer() *freezer.Freezer {
+	f, err := freezer.Open(path.Join(cfgPath, "db", "freezer"))
+	if err != nil {
+		log.WithFields(log.Fields{"err": err}).Fatal("Failed to open freezer")
+	}
+	return f
+}
+
+func loadOrCreateKey() *crypto.PrivateKey {
+	filepath := path.Join(cfgPath, "key")
+	privKey, err := crypto.PrivateKeyFromFile(filepath)
+	if err == nil {
+		return privKey
+	}
+	log.WithFields(log.Fields{"err": err}).Warning("Failed to load private key. Generating new key")
+	privKey, _, err = crypto.GenerateKeyPair()
+	if err != nil {
+		log.WithFields(log.Fields{"err": err}).Fatal("Failed to generate private key")
+	}
+	err = privKey.SaveToFile(filepath)
+	if err != nil {
+		log.WithFields(log.Fields{"err": err}).Fatal("Failed to save private key")
+	}
+	return privKey
+}
+
+// loadSigner creates the signer of the validator key held by a remote signer or split
+// across machines, if configured. It returns nil otherwise.
+func loadSigner() core.Signer {
+	config := common.GetConfig()
+	if config.Consensus.RemoteSigner == "" {
+		return loadThresholdSigner()
+	}
+	timeout := time.Duration(config.Consensus.RemoteSignerTimeout) * time.Second
+	signer, err := consensus.NewRemoteSigner(config.Consensus.RemoteSigner, timeout)
+	if err != nil {
+		log.WithFields(log.Fields{"err": err, "endpoint": config.Consensus.RemoteSigner}).Fatal("Failed to connect to remote signer")
+	}
+	log.WithFields(log.Fields{
+		"validator": signer.ID().Hex(),
+		"endpoint":  config.Consensus.RemoteSigner,
+	}).Info("Using remote signer")
+	return signer
+}
+
+// loadSignGuard loads the double-sign protection of the votes of the validator.
+func loadSignGuard() *consensus.SignGuard {
+	filePath := signStateFile()
+	guard, err := consensus.LoadSignGuard(filePath)
+	if err != nil {
+		log.WithFields(log.Fields{"err": err, "path": filePath}).Fatal("Failed to load sign state")
+	}
+	return guard
+}
+
+// signStateFile returns the path of the double-sign protection file.
+func signStateFile() string {
+	if filePath := common.GetConfig().Consensus.SignStateFile; filePath != "" {
+		return filePath
+	}
+	return path.Join(cfgPath, "sign_state.json")
+}
+
+// loadThresholdSigner creates the signer of a validator key split across machines,
+// if a key share is configured. It returns nil otherwise.
+func loadThresholdSigner() core.Signer {
+	config := common.GetConfig()
+	keyFile := config.Consensus.ThresholdKey
+	if keyFile == "" {
+		return nil
+	}
+	keyShare, err := loadKeyShare(keyFile)
+	if err != nil {
+		log.WithFields(log.Fields{"err": err, "path": keyFile}).Fatal("Failed to load key share")
+	}
+
+	timeout := time.Duration(config.Consensus.CosignerTimeout) * time.Second
+	signers := []consensus.PartialSigner{consensus.NewLocalPartialSigner(keyShare)}
+	for _, endpoint := range config.Consensus.Cosigners {
+		signers = append(signers, consensus.NewRemoteCosigner(endpoint, timeout))
+	}
+	signer, err := consensus.NewThresholdSigner(keyShare.Commitment, signers, timeout)
+	if err != nil {
+		log.WithFields(log.Fields{"err": err}).Fatal("Failed to create threshold signer")
+	}
+	log.WithFields(log.Fields{
+		"validator": signer.ID().Hex(),
+		"threshold": keyShare.Threshold(),
+		"cosigners": len(signers) - 1,
+	}).Info("Using threshold validator key")
+	return signer
+}
+
+func newMessenger(privKey *crypto.PrivateKey, p2pConfig common.P2PConfig) *messenger.Messenger {
+	log.WithFields(log.Fields{
+		"pubKey":  fmt.Sprintf("%v", privKey.PublicKey().ToBytes()),
+		"address": fmt.Sprintf("%v", privKey.PublicKey().Address()),
+	}).Info("Using key")
+	msgrConfig := messenger.GetDefaultMessengerConfig()
+	msgrConfig.SetAddressBookFilePath(path.Join(cfgPath, "addrbook.json"))
+	msgrConfig.SetValidatorPeers(p2pConfig.ValidatorPeers)
+	msgrConfig.SetListenAddresses(p2pConfig.ListenAddresses)
+	messenger, err := messenger.CreateMessenger(privKey.PublicKey(), p2pConfig.Seeds, p2pConfig.Port, msgrConfig)
+	if err != nil {
+		log.WithFields(log.Fields{"err": err}).Fatal("Failed to create PeerDiscoveryManager instance")
+	}
+	return messenger
+}
@@ -0,0 +1,24 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/thetatoken/ukulele/version"
+)
+
+// versionCmd prints the version of the node daemon. The version of a running node is
+// served by the theta.GetVersion RPC method.
+// Example:
+//		theta version
+var versionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "Print the version of the node",
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Print(version.String(RootCmd.Use))
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(versionCmd)
+}
@@ -0,0 +1,7 @@
+package main
+
+import "github.com/thetatoken/ukulele/cmd/theta/cmd"
+
+func main() {
+	cmd.Execute()
+}
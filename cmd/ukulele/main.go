@@ -1,7 +0,0 @@
-package main
-
-import "github.com/thetatoken/ukulele/cmd/ukulele/cmd"
-
-func main() {
-	cmd.Execute()
-}
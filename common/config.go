@@ -14,18 +14,117 @@ const (
 	CfgConsensusMinProposalWait = "consensus.minProposalWait"
 	// CfgConsensusMessageQueueSize defines the capacity of consensus message queue.
 	CfgConsensusMessageQueueSize = "consensus.messageQueueSize"
+	// CfgConsensusProposerSelection sets how the proposer of each epoch is selected, "fixed" or "vrf".
+	CfgConsensusProposerSelection = "consensus.proposerSelection"
+	// CfgConsensusThresholdKey sets the key share file of a validator key split across machines.
+	CfgConsensusThresholdKey = "consensus.thresholdKey"
+	// CfgConsensusCosigners sets the endpoints of the cosigners holding the other key shares.
+	CfgConsensusCosigners = "consensus.cosigners"
+	// CfgConsensusCosignerTimeout defines how long to wait for the signature shares, in seconds.
+	CfgConsensusCosignerTimeout = "consensus.cosignerTimeout"
+	// CfgConsensusCompactProposals sets whether proposals carry the hashes of the mempool
+	// transactions instead of the transactions. All the validators need to support it.
+	CfgConsensusCompactProposals = "consensus.compactProposals"
+	// CfgConsensusDryRun sets whether the node validates and executes the blocks without ever
+	// voting or proposing, e.g. for read replicas or to canary-test a release.
+	CfgConsensusDryRun = "consensus.dryRun"
+	// CfgConsensusRemoteSigner sets the endpoint of the remote signer holding the validator key.
+	CfgConsensusRemoteSigner = "consensus.remoteSigner"
+	// CfgConsensusRemoteSignerTimeout defines how long to wait for the remote signer, in seconds.
+	CfgConsensusRemoteSignerTimeout = "consensus.remoteSignerTimeout"
+	// CfgConsensusSignStateFile sets the double-sign protection file recording the last signed vote,
+	// sign_state.json in the config directory if not set.
+	CfgConsensusSignStateFile = "consensus.signStateFile"
+	// CfgConsensusStallAlarmTimeout defines how long the node waits for a finalized block before
+	// raising a stall alarm, in seconds. 0 disables the alarm.
+	CfgConsensusStallAlarmTimeout = "consensus.stallAlarmTimeout"
+	// CfgConsensusStallAlarmWebhook sets the URL the stall alarms are POSTed to, besides being logged.
+	CfgConsensusStallAlarmWebhook = "consensus.stallAlarmWebhook"
+	// CfgConsensusTxHashActivationHeight sets the height from which the blocks must set their TxHash.
+	// Only the blocks below it, proposed before the TxHash was set, may have an empty TxHash.
+	CfgConsensusTxHashActivationHeight = "consensus.txHashActivationHeight"
+
+	// CfgGuardianEnabled sets whether the node runs as a guardian, which validates blocks and
+	// attests finalized checkpoints instead of proposing and voting.
+	CfgGuardianEnabled = "guardian.enabled"
+	// CfgGuardianAddresses sets the addresses of the guardians whose attestations are accepted.
+	CfgGuardianAddresses = "guardian.addresses"
+	// CfgGuardianCheckpointInterval defines the number of heights between checkpoints attested by guardians.
+	CfgGuardianCheckpointInterval = "guardian.checkpointInterval"
+
+	// CfgStorageBackend sets the storage backend, "leveldb", "rocksdb", "badgerdb" or "memdb".
+	CfgStorageBackend = "storage.backend"
+	// CfgStorageStatsInterval defines how often the database size is reported, in seconds.
+	CfgStorageStatsInterval = "storage.statsInterval"
+	// CfgStorageCompactionInterval defines how often the database is compacted, in seconds. 0 disables compaction.
+	CfgStorageCompactionInterval = "storage.compactionInterval"
+	// CfgStorageMode sets the node mode, "full" prunes the old state roots, "archive" keeps all the state roots.
+	CfgStorageMode = "storage.mode"
+	// CfgStorageStatePruningEnabled sets whether the trie nodes of old state roots are deleted.
+	CfgStorageStatePruningEnabled = "storage.statePruningEnabled"
+	// CfgStorageStatePruningRetainedBlocks sets how many finalized heights of state roots are kept.
+	CfgStorageStatePruningRetainedBlocks = "storage.statePruningRetainedBlocks"
+	// CfgStorageFreezerRetainedBlocks sets how many finalized heights of blocks are kept in the database
+	// before older blocks are moved to the freezer files. 0 keeps all the blocks in the database.
+	CfgStorageFreezerRetainedBlocks = "storage.freezerRetainedBlocks"
+	// CfgStorageBlockCacheSize sets how many recently accessed blocks are cached in memory.
+	CfgStorageBlockCacheSize = "storage.blockCacheSize"
+	// CfgStorageHeaderCacheSize sets how many recently accessed block headers are cached in memory.
+	CfgStorageHeaderCacheSize = "storage.headerCacheSize"
+	// CfgStorageTrieNodeCacheSize sets how many recently accessed state trie nodes are cached in memory.
+	CfgStorageTrieNodeCacheSize = "storage.trieNodeCacheSize"
+	// CfgStorageBalanceJournalEnabled sets whether the balances changed by each block are recorded.
+	CfgStorageBalanceJournalEnabled = "storage.balanceJournalEnabled"
+	// CfgStorageAddressIndexEnabled sets whether the finalized transactions are indexed by address.
+	CfgStorageAddressIndexEnabled = "storage.addressIndexEnabled"
+
+	// CfgExecutionParallelWorkers sets the number of goroutines executing the independent
+	// transactions of a block concurrently, 0 for the number of CPUs and 1 for a serial execution.
+	CfgExecutionParallelWorkers = "execution.parallelWorkers"
+	// CfgExecutionSignatureCacheSize sets the number of transactions screened by the mempool
+	// whose recovered signers are cached for the execution of the blocks, 0 to disable the cache.
+	CfgExecutionSignatureCacheSize = "execution.signatureCacheSize"
 
 	// CfgSyncMessageQueueSize defines the capacity of Sync Manager message queue.
 	CfgSyncMessageQueueSize = "sync.messageQueueSize"
+	// CfgSyncCompactBlocks sets whether blocks are downloaded as compact blocks, which carry short
+	// IDs of the transactions instead of the transactions. All the peers need to support it.
+	CfgSyncCompactBlocks = "sync.compactBlocks"
+	// CfgSyncStatusInterval defines how often the node sends its chain status to its peers, in seconds.
+	CfgSyncStatusInterval = "sync.statusInterval"
+	// CfgSyncBehindThreshold sets how many heights below the tips of its peers the node is behind,
+	// and stops proposing. 0 disables the detection.
+	CfgSyncBehindThreshold = "sync.behindThreshold"
+
+	// CfgMempoolMaxNumTxs limits the number of transactions tracked by the mempool.
+	CfgMempoolMaxNumTxs = "mempool.maxNumTxs"
+	// CfgMempoolGossipRateLimit limits the number of transaction messages per second accepted from
+	// each peer, allowing bursts of as many messages. 0 disables the limit.
+	CfgMempoolGossipRateLimit = "mempool.gossipRateLimit"
+
+	// CfgProposerExcludedAddresses lists the addresses whose transactions the node leaves out of the
+	// blocks it proposes, separated by commas.
+	CfgProposerExcludedAddresses = "proposer.excludedAddresses"
+	// CfgProposerExcludedTxTypes lists the types of the transactions the node leaves out of the blocks
+	// it proposes, e.g. "SmartContractTx", separated by commas.
+	CfgProposerExcludedTxTypes = "proposer.excludedTxTypes"
 
 	// CfgP2PName sets the ID of local node in P2P network.
 	CfgP2PName = "p2p.name"
 	// CfgP2PPort sets the port used by P2P network.
 	CfgP2PPort = "p2p.port"
+	// CfgP2PListenAddresses sets the local addresses the P2P network listens on.
+	CfgP2PListenAddresses = "p2p.listenAddresses"
 	// CfgP2PSeeds sets the boostrap peers.
 	CfgP2PSeeds = "p2p.seeds"
+	// CfgP2PValidatorPeers sets the validator peers that must always remain connected.
+	CfgP2PValidatorPeers = "p2p.validatorPeers"
+	// CfgP2PProxy sets the SOCKS5 proxy the outbound peer connections go through.
+	CfgP2PProxy = "p2p.proxy"
 	// CfgP2PMessageQueueSize sets the message queue size for network interface.
 	CfgP2PMessageQueueSize = "p2p.messageQueueSize"
+	// CfgP2PSendQueueSize sets the capacity of the queue of the messages to send to each peer.
+	CfgP2PSendQueueSize = "p2p.sendQueueSize"
 
 	// CfgRPCEnabled sets whether to run RPC service.
 	CfgRPCEnabled = "rpc.enabled"
@@ -33,6 +132,56 @@ const (
 	CfgRPCPort = "rpc.port"
 	// CfgRPCMaxConnections limits concurrent connections accepted by RPC server.
 	CfgRPCMaxConnections = "rpc.maxConnections"
+	// CfgRPCAllowLowercaseAddress sets whether RPC accepts addresses without EIP55 checksum.
+	CfgRPCAllowLowercaseAddress = "rpc.allowLowercaseAddress"
+	// CfgRPCAdminEnabled sets whether RPC serves the methods of the admin namespace.
+	CfgRPCAdminEnabled = "rpc.adminEnabled"
+	// CfgRPCAdminToken sets the bearer token required by the admin methods and the debug
+	// endpoints, none if empty.
+	CfgRPCAdminToken = "rpc.adminToken"
+	// CfgRPCRosettaEnabled sets whether RPC serves the Rosetta Data and Construction APIs under /rosetta.
+	CfgRPCRosettaEnabled = "rpc.rosettaEnabled"
+	// CfgRPCEthEnabled sets whether RPC serves the Ethereum JSON-RPC compatibility methods under /eth.
+	CfgRPCEthEnabled = "rpc.ethEnabled"
+	// CfgRPCEthChainID sets the chain ID returned by eth_chainId and net_version.
+	CfgRPCEthChainID = "rpc.ethChainID"
+	// CfgRPCRESTEnabled sets whether RPC serves the REST gateway and its OpenAPI definitions under /api/v1.
+	CfgRPCRESTEnabled = "rpc.restEnabled"
+	// CfgRPCWebSocketEnabled sets whether RPC streams the finalized blocks to the websocket subscribers on /ws/blocks.
+	CfgRPCWebSocketEnabled = "rpc.wsEnabled"
+
+	// CfgExporterEnabled sets whether the finalized blocks are exported to a sink.
+	CfgExporterEnabled = "exporter.enabled"
+	// CfgExporterSink sets the kind of sink the blocks are exported to, "ndjson", "kafka" or "postgres".
+	CfgExporterSink = "exporter.sink"
+	// CfgExporterEndpoint sets where the blocks are exported: the URL of the NDJSON endpoint, the
+	// comma separated Kafka brokers, or the PostgreSQL connection string.
+	CfgExporterEndpoint = "exporter.endpoint"
+	// CfgExporterTopic sets the Kafka topic the blocks are published to.
+	CfgExporterTopic = "exporter.topic"
+	// CfgExporterStartHeight sets the height of the first block exported to a new sink.
+	CfgExporterStartHeight = "exporter.startHeight"
+	// CfgExporterBatchSize limits the number of blocks written to the sink at once.
+	CfgExporterBatchSize = "exporter.batchSize"
+	// CfgExporterPollInterval defines how often the exporter checks for new finalized blocks, in seconds.
+	CfgExporterPollInterval = "exporter.pollInterval"
+
+	// CfgWebhookEnabled sets whether the node notifies the webhooks registered through the admin RPC.
+	CfgWebhookEnabled = "webhook.enabled"
+	// CfgWebhookMaxAttempts sets how many times a notification is attempted before it is given up.
+	CfgWebhookMaxAttempts = "webhook.maxAttempts"
+	// CfgWebhookTimeout defines how long to wait for a webhook to respond, in seconds.
+	CfgWebhookTimeout = "webhook.timeout"
+
+	// CfgMetricsEnabled sets whether to collect metrics and serve them to Prometheus.
+	CfgMetricsEnabled = "metrics.enabled"
+	// CfgMetricsPort sets the port of the /metrics endpoint.
+	CfgMetricsPort = "metrics.port"
+
+	// CfgTracingEnabled sets whether to record the spans of transactions and blocks.
+	CfgTracingEnabled = "tracing.enabled"
+	// CfgTracingEndpoint sets the OTLP/HTTP endpoint of the OpenTelemetry collector.
+	CfgTracingEndpoint = "tracing.endpoint"
 
 	// CfgLogLevels sets the log level.
 	CfgLogLevels = "log.levels"
@@ -54,17 +203,87 @@ func init() {
 	viper.SetDefault(CfgConsensusMaxEpochLength, 5)
 	viper.SetDefault(CfgConsensusMinProposalWait, 2)
 	viper.SetDefault(CfgConsensusMessageQueueSize, 512)
+	viper.SetDefault(CfgConsensusProposerSelection, "fixed")
+	viper.SetDefault(CfgConsensusThresholdKey, "")
+	viper.SetDefault(CfgConsensusCosigners, "")
+	viper.SetDefault(CfgConsensusCosignerTimeout, 5)
+	viper.SetDefault(CfgConsensusCompactProposals, false)
+	viper.SetDefault(CfgConsensusDryRun, false)
+	viper.SetDefault(CfgConsensusRemoteSigner, "")
+	viper.SetDefault(CfgConsensusRemoteSignerTimeout, 5)
+	viper.SetDefault(CfgConsensusSignStateFile, "")
+	viper.SetDefault(CfgConsensusStallAlarmTimeout, 120)
+	viper.SetDefault(CfgConsensusStallAlarmWebhook, "")
+	viper.SetDefault(CfgConsensusTxHashActivationHeight, 0)
+
+	viper.SetDefault(CfgGuardianEnabled, false)
+	viper.SetDefault(CfgGuardianAddresses, "")
+	viper.SetDefault(CfgGuardianCheckpointInterval, 10)
+
+	viper.SetDefault(CfgStorageBackend, "leveldb")
+	viper.SetDefault(CfgStorageStatsInterval, 60)
+	viper.SetDefault(CfgStorageCompactionInterval, 0)
+	viper.SetDefault(CfgStorageMode, NodeModeFull)
+	viper.SetDefault(CfgStorageStatePruningEnabled, true)
+	viper.SetDefault(CfgStorageStatePruningRetainedBlocks, 512)
+	viper.SetDefault(CfgStorageFreezerRetainedBlocks, 10000)
+	viper.SetDefault(CfgStorageBlockCacheSize, 256)
+	viper.SetDefault(CfgStorageHeaderCacheSize, 2048)
+	viper.SetDefault(CfgStorageTrieNodeCacheSize, 65536)
+	viper.SetDefault(CfgStorageBalanceJournalEnabled, true)
+	viper.SetDefault(CfgStorageAddressIndexEnabled, true)
+
+	viper.SetDefault(CfgExecutionParallelWorkers, 0)
+	viper.SetDefault(CfgExecutionSignatureCacheSize, 100000)
 
 	viper.SetDefault(CfgSyncMessageQueueSize, 512)
+	viper.SetDefault(CfgSyncCompactBlocks, false)
+	viper.SetDefault(CfgSyncStatusInterval, 10)
+	viper.SetDefault(CfgSyncBehindThreshold, 10)
+
+	viper.SetDefault(CfgMempoolMaxNumTxs, 200000)
+	viper.SetDefault(CfgMempoolGossipRateLimit, 1000)
+	viper.SetDefault(CfgProposerExcludedAddresses, "")
+	viper.SetDefault(CfgProposerExcludedTxTypes, "")
 
 	viper.SetDefault(CfgRPCEnabled, false)
 	viper.SetDefault(CfgP2PMessageQueueSize, 512)
+	viper.SetDefault(CfgP2PSendQueueSize, 256)
 	viper.SetDefault(CfgP2PName, "Anonymous")
 	viper.SetDefault(CfgP2PPort, 50001)
+	viper.SetDefault(CfgP2PListenAddresses, "")
 	viper.SetDefault(CfgP2PSeeds, "")
+	viper.SetDefault(CfgP2PValidatorPeers, "")
+	viper.SetDefault(CfgP2PProxy, "")
 
 	viper.SetDefault(CfgRPCPort, "16888")
 	viper.SetDefault(CfgRPCMaxConnections, 200)
+	viper.SetDefault(CfgRPCAllowLowercaseAddress, false)
+	viper.SetDefault(CfgRPCAdminEnabled, false)
+	viper.SetDefault(CfgRPCAdminToken, "")
+	viper.SetDefault(CfgRPCRosettaEnabled, false)
+	viper.SetDefault(CfgRPCEthEnabled, false)
+	viper.SetDefault(CfgRPCEthChainID, 366)
+	viper.SetDefault(CfgRPCRESTEnabled, false)
+	viper.SetDefault(CfgRPCWebSocketEnabled, false)
+
+	viper.SetDefault(CfgExporterEnabled, false)
+	viper.SetDefault(CfgExporterSink, "ndjson")
+	viper.SetDefault(CfgExporterEndpoint, "")
+	viper.SetDefault(CfgExporterTopic, "theta-blocks")
+	viper.SetDefault(CfgExporterStartHeight, 0)
+	viper.SetDefault(CfgExporterBatchSize, 100)
+	viper.SetDefault(CfgExporterPollInterval, 1)
+
+	viper.SetDefault(CfgWebhookEnabled, false)
+	viper.SetDefault(CfgWebhookMaxAttempts, 10)
+	viper.SetDefault(CfgWebhookTimeout, 10)
+
+	viper.SetDefault(CfgMetricsEnabled, false)
+	viper.SetDefault(CfgMetricsPort, 17888)
+
+	viper.SetDefault(CfgTracingEnabled, false)
+	viper.SetDefault(CfgTracingEndpoint, "http://localhost:4318")
 
 	viper.SetDefault(CfgLogLevels, "*:debug")
 	viper.SetDefault(CfgLogPrintSelfID, false)
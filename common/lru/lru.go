@@ -0,0 +1,107 @@
+package lru
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/thetatoken/ukulele/common/metrics"
+)
+
+//
+// Cache is a fixed size, least recently used cache, safe for concurrent use. The
+// hits and the misses of the cache are counted in the "<name>/hit" and "<name>/miss"
+// metrics, shared by the caches with the same name.
+//
+type Cache struct {
+	mu sync.Mutex
+
+	size    int
+	entries *list.List                    // most recently used entry first
+	items   map[interface{}]*list.Element // entries by key
+
+	hitCounter  metrics.Counter
+	missCounter metrics.Counter
+}
+
+type entry struct {
+	key   interface{}
+	value interface{}
+}
+
+// New creates a cache holding up to size entries. A cache of size 0 holds nothing.
+func New(name string, size int) *Cache {
+	if size < 0 {
+		size = 0
+	}
+	return &Cache{
+		size:        size,
+		entries:     list.New(),
+		items:       make(map[interface{}]*list.Element),
+		hitCounter:  metrics.GetOrRegisterCounter(name+"/hit", nil),
+		missCounter: metrics.GetOrRegisterCounter(name+"/miss", nil),
+	}
+}
+
+// Get returns the value of the given key, and marks it as the most recently used.
+func (c *Cache) Get(key interface{}) (value interface{}, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		c.missCounter.Inc(1)
+		return nil, false
+	}
+	c.hitCounter.Inc(1)
+	c.entries.MoveToFront(el)
+	return el.Value.(*entry).value, true
+}
+
+// Add adds or updates the value of the given key, evicting the least recently used
+// entry if the cache is full.
+func (c *Cache) Add(key interface{}, value interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.size == 0 {
+		return
+	}
+	if el, ok := c.items[key]; ok {
+		el.Value.(*entry).value = value
+		c.entries.MoveToFront(el)
+		return
+	}
+	c.items[key] = c.entries.PushFront(&entry{key: key, value: value})
+	if c.entries.Len() > c.size {
+		oldest := c.entries.Back()
+		c.entries.Remove(oldest)
+		delete(c.items, oldest.Value.(*entry).key)
+	}
+}
+
+// Remove removes the given key from the cache.
+func (c *Cache) Remove(key interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		c.entries.Remove(el)
+		delete(c.items, key)
+	}
+}
+
+// Len returns the number of entries in the cache.
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.entries.Len()
+}
+
+// Purge removes all the entries from the cache.
+func (c *Cache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries.Init()
+	c.items = make(map[interface{}]*list.Element)
+}
@@ -0,0 +1,49 @@
+package lru
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheEviction(t *testing.T) {
+	assert := assert.New(t)
+
+	c := New("test/eviction", 2)
+	c.Add("a", 1)
+	c.Add("b", 2)
+
+	// "a" becomes the most recently used, so "b" is evicted
+	v, ok := c.Get("a")
+	assert.True(ok)
+	assert.Equal(1, v)
+	c.Add("c", 3)
+	assert.Equal(2, c.Len())
+	_, ok = c.Get("b")
+	assert.False(ok)
+
+	c.Add("a", 4)
+	v, ok = c.Get("a")
+	assert.True(ok)
+	assert.Equal(4, v)
+
+	c.Remove("a")
+	_, ok = c.Get("a")
+	assert.False(ok)
+	assert.Equal(1, c.Len())
+
+	c.Purge()
+	assert.Equal(0, c.Len())
+	_, ok = c.Get("c")
+	assert.False(ok)
+}
+
+func TestCacheOfSizeZero(t *testing.T) {
+	assert := assert.New(t)
+
+	c := New("test/zero", 0)
+	c.Add("a", 1)
+	_, ok := c.Get("a")
+	assert.False(ok)
+	assert.Equal(0, c.Len())
+}
@@ -0,0 +1,158 @@
+// Hook go-metrics into Prometheus
+// on any /metrics request, write all the metrics of the registry in the Prometheus text exposition format
+package prometheus
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/thetatoken/ukulele/common/metrics"
+)
+
+// Namespace prefixes the names of all the exported metrics.
+const Namespace = "theta"
+
+var quantiles = []float64{0.5, 0.75, 0.95, 0.99, 0.999}
+
+// Handler returns an http.Handler serving the metrics of the registry to Prometheus.
+func Handler(reg metrics.Registry) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		w.Write(Export(reg))
+	})
+}
+
+// Export writes the metrics of the registry in the Prometheus text exposition format,
+// sorted by name. Meters are exported as counters, and timers and histograms as summaries.
+func Export(reg metrics.Registry) []byte {
+	names := []string{}
+	all := make(map[string]interface{})
+	reg.Each(func(name string, i interface{}) {
+		names = append(names, name)
+		all[name] = i
+	})
+	sort.Strings(names)
+
+	c := &collector{buf: new(bytes.Buffer)}
+	for _, name := range names {
+		metricName := MetricName(name)
+		switch m := all[name].(type) {
+		case metrics.Counter:
+			c.addCounter(metricName, m.Count())
+		case metrics.Gauge:
+			c.addGauge(metricName, float64(m.Value()))
+		case metrics.GaugeFloat64:
+			c.addGauge(metricName, m.Value())
+		case metrics.Meter:
+			c.addCounter(metricName+"_total", m.Snapshot().Count())
+		case metrics.Histogram:
+			s := m.Snapshot()
+			c.addSummary(metricName, s.Count(), float64(s.Sum()), s.Percentiles(quantiles))
+		case metrics.Timer:
+			// Timers are exported in seconds
+			s := m.Snapshot()
+			ps := s.Percentiles(quantiles)
+			for i := range ps {
+				ps[i] /= 1e9
+			}
+			c.addSummary(metricName+"_seconds", s.Count(), float64(s.Sum())/1e9, ps)
+		case metrics.ResettingTimer:
+			s := m.Snapshot()
+			values := s.Values()
+			ps := []float64{}
+			sum := int64(0)
+			for _, v := range values {
+				sum += v
+			}
+			for _, p := range s.Percentiles(quantiles) {
+				ps = append(ps, float64(p)/1e9)
+			}
+			c.addSummary(metricName+"_seconds", int64(len(values)), float64(sum)/1e9, ps)
+		}
+	}
+	return c.buf.Bytes()
+}
+
+// MetricName converts the name of a metric, e.g. "p2p/channel/vote/in/bytes", to a
+// Prometheus metric name, e.g. "theta_p2p_channel_vote_in_bytes".
+func MetricName(name string) string {
+	f := func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_' {
+			return r
+		}
+		return '_'
+	}
+	return Namespace + "_" + strings.Map(f, name)
+}
+
+type collector struct {
+	buf *bytes.Buffer
+}
+
+func (c *collector) addCounter(name string, value int64) {
+	fmt.Fprintf(c.buf, "# TYPE %s counter\n%s %d\n", name, name, value)
+}
+
+func (c *collector) addGauge(name string, value float64) {
+	fmt.Fprintf(c.buf, "# TYPE %s gauge\n%s %s\n", name, name, formatFloat(value))
+}
+
+func (c *collector) addSummary(name string, count int64, sum float64, ps []float64) {
+	fmt.Fprintf(c.buf, "# TYPE %s summary\n", name)
+	for i, q := range quantiles {
+		value := 0.0
+		if i < len(ps) {
+			value = ps[i]
+		}
+		fmt.Fprintf(c.buf, "%s{quantile=\"%s\"} %s\n", name, formatFloat(q), formatFloat(value))
+	}
+	fmt.Fprintf(c.buf, "%s_sum %s\n%s_count %d\n", name, formatFloat(sum), name, count)
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// Server serves the metrics of a registry on the /metrics endpoint.
+type Server struct {
+	server *http.Server
+	wg     *sync.WaitGroup
+}
+
+// NewServer creates a server of the metrics of the registry on the given port.
+func NewServer(reg metrics.Registry, port int) *Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", Handler(reg))
+	return &Server{
+		server: &http.Server{Addr: ":" + strconv.Itoa(port), Handler: mux},
+		wg:     &sync.WaitGroup{},
+	}
+}
+
+// Start serves the metrics until the context is done.
+func (s *Server) Start(ctx context.Context) {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		log.WithFields(log.Fields{"addr": s.server.Addr}).Info("Serving metrics")
+		if err := s.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.WithFields(log.Fields{"error": err}).Error("Failed to serve metrics")
+		}
+	}()
+	go func() {
+		<-ctx.Done()
+		s.server.Close()
+	}()
+}
+
+// Wait blocks until the server stops.
+func (s *Server) Wait() {
+	s.wg.Wait()
+}
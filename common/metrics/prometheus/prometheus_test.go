@@ -0,0 +1,57 @@
+package prometheus
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thetatoken/ukulele/common/metrics"
+)
+
+func init() {
+	metrics.Enabled = true
+}
+
+func TestMetricName(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("theta_p2p_channel_vote_in_bytes", MetricName("p2p/channel/vote/in/bytes"))
+	assert.Equal("theta_chain_cache_block_hit", MetricName("chain/cache/block/hit"))
+	assert.Equal("theta_rpc_theta_GetStatus_requests", MetricName("rpc/theta.GetStatus/requests"))
+}
+
+func TestExport(t *testing.T) {
+	assert := assert.New(t)
+
+	reg := metrics.NewRegistry()
+	metrics.NewRegisteredCounter("mempool/rejected", reg).Inc(3)
+	metrics.NewRegisteredGauge("p2p/peers", reg).Update(7)
+	metrics.NewRegisteredMeter("mempool/admitted", reg).Mark(5)
+	timer := metrics.NewRegisteredTimer("db/read", reg)
+	timer.Update(time.Second)
+	timer.Update(3 * time.Second)
+
+	out := string(Export(reg))
+	assert.Equal(`# TYPE theta_db_read_seconds summary
+theta_db_read_seconds{quantile="0.5"} 2
+theta_db_read_seconds{quantile="0.75"} 3
+theta_db_read_seconds{quantile="0.95"} 3
+theta_db_read_seconds{quantile="0.99"} 3
+theta_db_read_seconds{quantile="0.999"} 3
+theta_db_read_seconds_sum 4
+theta_db_read_seconds_count 2
+# TYPE theta_mempool_admitted_total counter
+theta_mempool_admitted_total 5
+# TYPE theta_mempool_rejected counter
+theta_mempool_rejected 3
+# TYPE theta_p2p_peers gauge
+theta_p2p_peers 7
+`, out)
+
+	rec := httptest.NewRecorder()
+	Handler(reg).ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	assert.True(strings.HasPrefix(rec.Header().Get("Content-Type"), "text/plain"))
+	assert.Equal(out, rec.Body.String())
+}
@@ -0,0 +1,701 @@
+package common
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/spf13/viper"
+)
+
+// Config is the typed configuration of the node, read from the config keys above.
+type Config struct {
+	ChainID   string
+	P2P       P2PConfig
+	Consensus ConsensusConfig
+	Guardian  GuardianConfig
+	Mempool   MempoolConfig
+	Proposer  ProposerConfig
+	Storage   StorageConfig
+	Execution ExecutionConfig
+	Sync      SyncConfig
+	RPC       RPCConfig
+	Exporter  ExporterConfig
+	Webhook   WebhookConfig
+	Metrics   MetricsConfig
+	Tracing   TracingConfig
+	Log       LogConfig
+}
+
+// P2PConfig is the configuration of the P2P network.
+type P2PConfig struct {
+	Name             string
+	Port             int
+	ListenAddresses  []string
+	Seeds            []string // Reloadable
+	ValidatorPeers   []string
+	Proxy            string
+	MessageQueueSize int
+	SendQueueSize    int
+}
+
+// ConsensusConfig is the configuration of the consensus engine.
+type ConsensusConfig struct {
+	MaxEpochLength         int // In seconds
+	MinProposalWait        int // In seconds
+	MessageQueueSize       int
+	ProposerSelection      string
+	ThresholdKey           string
+	Cosigners              []string
+	CosignerTimeout        int // In seconds
+	CompactProposals       bool
+	DryRun                 bool
+	RemoteSigner           string
+	RemoteSignerTimeout    int // In seconds
+	SignStateFile          string
+	StallAlarmTimeout      int // In seconds
+	StallAlarmWebhook      string
+	TxHashActivationHeight uint64
+}
+
+// GuardianConfig is the configuration of the guardian role.
+type GuardianConfig struct {
+	Enabled            bool
+	Addresses          []Address
+	CheckpointInterval uint64
+}
+
+// MempoolConfig is the configuration of the mempool.
+type MempoolConfig struct {
+	MaxNumTxs       uint
+	GossipRateLimit int
+}
+
+// ProposerConfig is the policy of the node on the transactions of the blocks it proposes.
+// The blocks proposed by the other validators are validated regardless of it.
+type ProposerConfig struct {
+	ExcludedAddresses []Address
+	ExcludedTxTypes   []string
+}
+
+// StorageConfig is the configuration of the storage.
+type StorageConfig struct {
+	Backend                    string
+	StatsInterval              int // In seconds
+	CompactionInterval         int // In seconds
+	Mode                       string
+	StatePruningEnabled        bool
+	StatePruningRetainedBlocks uint64
+	FreezerRetainedBlocks      uint64
+	BlockCacheSize             int
+	HeaderCacheSize            int
+	TrieNodeCacheSize          int
+	BalanceJournalEnabled      bool
+	AddressIndexEnabled        bool
+}
+
+// Node modes
+const (
+	NodeModeFull    = "full"    // Keeps the state roots of the last StatePruningRetainedBlocks finalized heights
+	NodeModeArchive = "archive" // Keeps all the historical state roots
+)
+
+// PrunesState returns whether the trie nodes of the old state roots are deleted, which
+// an archive node never does.
+func (c StorageConfig) PrunesState() bool {
+	return c.Mode != NodeModeArchive && c.StatePruningEnabled
+}
+
+// ExecutionConfig is the configuration of the execution of the block transactions.
+type ExecutionConfig struct {
+	ParallelWorkers    int // 0 for the number of CPUs
+	SignatureCacheSize int // 0 to disable the signature cache
+}
+
+// SyncConfig is the configuration of the sync manager.
+type SyncConfig struct {
+	MessageQueueSize int
+	CompactBlocks    bool
+	StatusInterval   int // In seconds
+	BehindThreshold  uint64
+}
+
+// RPCConfig is the configuration of the RPC server.
+type RPCConfig struct {
+	Enabled               bool
+	Port                  int
+	MaxConnections        int // Reloadable
+	AllowLowercaseAddress bool
+	AdminEnabled          bool
+	AdminToken            string
+	RosettaEnabled        bool
+	EthEnabled            bool
+	EthChainID            uint64
+	RESTEnabled           bool
+	WebSocketEnabled      bool
+}
+
+// ExporterConfig is the configuration of the export of the finalized blocks.
+type ExporterConfig struct {
+	Enabled      bool
+	Sink         string
+	Endpoint     string
+	Topic        string
+	StartHeight  uint64
+	BatchSize    int
+	PollInterval int // In seconds
+}
+
+// WebhookConfig is the configuration of the webhook notifications.
+type WebhookConfig struct {
+	Enabled     bool
+	MaxAttempts uint64
+	Timeout     int // In seconds
+}
+
+// MetricsConfig is the configuration of the metrics.
+type MetricsConfig struct {
+	Enabled bool
+	Port    int
+}
+
+// TracingConfig is the configuration of the tracing.
+type TracingConfig struct {
+	Enabled  bool
+	Endpoint string
+}
+
+// LogConfig is the configuration of the logs.
+type LogConfig struct {
+	Levels      string // Reloadable
+	PrintSelfID bool
+}
+
+// ReloadableConfigKeys are the config keys applied by ReloadConfig while the node runs.
+var ReloadableConfigKeys = []string{CfgLogLevels, CfgRPCMaxConnections, CfgP2PSeeds}
+
+var storageBackends = []string{"leveldb", "rocksdb", "badgerdb", "memdb"}
+var nodeModes = []string{NodeModeFull, NodeModeArchive}
+var proposerSelections = []string{"fixed", "vrf"}
+var exporterSinks = []string{"ndjson", "kafka", "postgres"}
+var logLevelNames = []string{"panic", "fatal", "error", "warn", "info", "debug"}
+
+// ConfigError lists the problems of an invalid config.
+type ConfigError struct {
+	Problems []string
+}
+
+func (e *ConfigError) Error() string {
+	return "Invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+func (e *ConfigError) addf(key string, format string, args ...interface{}) {
+	e.Problems = append(e.Problems, key+": "+fmt.Sprintf(format, args...))
+}
+
+var (
+	configMu        sync.RWMutex
+	config          *Config
+	reloadListeners []func(*Config)
+)
+
+// GetConfig returns the config loaded by LoadConfig. Before it is loaded, e.g. in
+// tests and tools, the config is read from viper on each call, without validation.
+func GetConfig() *Config {
+	configMu.RLock()
+	defer configMu.RUnlock()
+
+	if config != nil {
+		return config
+	}
+	c, _ := readConfig()
+	return c
+}
+
+// LoadConfig reads the config from viper and validates it. The config is then
+// returned by GetConfig.
+func LoadConfig() (*Config, error) {
+	c, err := readConfig()
+	if err != nil {
+		return nil, err
+	}
+	configMu.Lock()
+	defer configMu.Unlock()
+	config = c
+	return c, nil
+}
+
+// OnConfigReload registers a function called with the new config after each reload.
+func OnConfigReload(fn func(*Config)) {
+	configMu.Lock()
+	defer configMu.Unlock()
+	reloadListeners = append(reloadListeners, fn)
+}
+
+// ConfigReload describes the changes applied by ReloadConfig.
+type ConfigReload struct {
+	Reloaded []string // Keys of the reloadable fields that changed
+	Ignored  []string // Keys of the fields that changed but need a restart
+}
+
+// ReloadConfig reads the config file again, and applies the changes of the fields in
+// ReloadableConfigKeys. The changes of the other fields are ignored until the node
+// restarts. The config is not changed if the new one is invalid.
+func ReloadConfig() (*ConfigReload, error) {
+	if err := viper.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("Failed to read config file: %v", err)
+	}
+	fresh, err := readConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	configMu.Lock()
+	current := config
+	if current == nil {
+		current = fresh
+	}
+	updated := *current
+	updated.Log.Levels = fresh.Log.Levels
+	updated.RPC.MaxConnections = fresh.RPC.MaxConnections
+	updated.P2P.Seeds = fresh.P2P.Seeds
+	config = &updated
+	listeners := append([]func(*Config){}, reloadListeners...)
+	configMu.Unlock()
+
+	reload := &ConfigReload{Reloaded: []string{}, Ignored: []string{}}
+	oldValues, newValues := current.values(), fresh.values()
+	for key, value := range newValues {
+		if reflect.DeepEqual(value, oldValues[key]) {
+			continue
+		}
+		if isReloadable(key) {
+			reload.Reloaded = append(reload.Reloaded, key)
+		} else {
+			reload.Ignored = append(reload.Ignored, key)
+		}
+	}
+	sort.Strings(reload.Reloaded)
+	sort.Strings(reload.Ignored)
+
+	for _, fn := range listeners {
+		fn(&updated)
+	}
+	return reload, nil
+}
+
+func isReloadable(key string) bool {
+	for _, k := range ReloadableConfigKeys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// readConfig reads the config from viper and validates it. The config is returned
+// even if it is invalid.
+func readConfig() (*Config, error) {
+	cerr := &ConfigError{}
+	c := &Config{
+		ChainID: viper.GetString(CfgChainID),
+		P2P: P2PConfig{
+			Name:             viper.GetString(CfgP2PName),
+			Port:             viper.GetInt(CfgP2PPort),
+			ListenAddresses:  splitList(viper.GetString(CfgP2PListenAddresses)),
+			Seeds:            splitList(viper.GetString(CfgP2PSeeds)),
+			ValidatorPeers:   splitList(viper.GetString(CfgP2PValidatorPeers)),
+			Proxy:            viper.GetString(CfgP2PProxy),
+			MessageQueueSize: viper.GetInt(CfgP2PMessageQueueSize),
+			SendQueueSize:    viper.GetInt(CfgP2PSendQueueSize),
+		},
+		Consensus: ConsensusConfig{
+			MaxEpochLength:         viper.GetInt(CfgConsensusMaxEpochLength),
+			MinProposalWait:        viper.GetInt(CfgConsensusMinProposalWait),
+			MessageQueueSize:       viper.GetInt(CfgConsensusMessageQueueSize),
+			ProposerSelection:      viper.GetString(CfgConsensusProposerSelection),
+			ThresholdKey:           viper.GetString(CfgConsensusThresholdKey),
+			Cosigners:              splitList(viper.GetString(CfgConsensusCosigners)),
+			CosignerTimeout:        viper.GetInt(CfgConsensusCosignerTimeout),
+			CompactProposals:       viper.GetBool(CfgConsensusCompactProposals),
+			DryRun:                 viper.GetBool(CfgConsensusDryRun),
+			RemoteSigner:           strings.TrimSpace(viper.GetString(CfgConsensusRemoteSigner)),
+			RemoteSignerTimeout:    viper.GetInt(CfgConsensusRemoteSignerTimeout),
+			SignStateFile:          viper.GetString(CfgConsensusSignStateFile),
+			StallAlarmTimeout:      viper.GetInt(CfgConsensusStallAlarmTimeout),
+			StallAlarmWebhook:      strings.TrimSpace(viper.GetString(CfgConsensusStallAlarmWebhook)),
+			TxHashActivationHeight: viper.GetUint64(CfgConsensusTxHashActivationHeight),
+		},
+		Guardian: GuardianConfig{
+			Enabled:            viper.GetBool(CfgGuardianEnabled),
+			Addresses:          []Address{},
+			CheckpointInterval: viper.GetUint64(CfgGuardianCheckpointInterval),
+		},
+		Mempool: MempoolConfig{
+			MaxNumTxs:       viper.GetUint(CfgMempoolMaxNumTxs),
+			GossipRateLimit: viper.GetInt(CfgMempoolGossipRateLimit),
+		},
+		Proposer: ProposerConfig{
+			ExcludedTxTypes: splitList(viper.GetString(CfgProposerExcludedTxTypes)),
+		},
+		Storage: StorageConfig{
+			Backend:                    viper.GetString(CfgStorageBackend),
+			StatsInterval:              viper.GetInt(CfgStorageStatsInterval),
+			CompactionInterval:         viper.GetInt(CfgStorageCompactionInterval),
+			Mode:                       viper.GetString(CfgStorageMode),
+			StatePruningEnabled:        viper.GetBool(CfgStorageStatePruningEnabled),
+			StatePruningRetainedBlocks: viper.GetUint64(CfgStorageStatePruningRetainedBlocks),
+			FreezerRetainedBlocks:      viper.GetUint64(CfgStorageFreezerRetainedBlocks),
+			BlockCacheSize:             viper.GetInt(CfgStorageBlockCacheSize),
+			HeaderCacheSize:            viper.GetInt(CfgStorageHeaderCacheSize),
+			TrieNodeCacheSize:          viper.GetInt(CfgStorageTrieNodeCacheSize),
+			BalanceJournalEnabled:      viper.GetBool(CfgStorageBalanceJournalEnabled),
+			AddressIndexEnabled:        viper.GetBool(CfgStorageAddressIndexEnabled),
+		},
+		Execution: ExecutionConfig{
+			ParallelWorkers:    viper.GetInt(CfgExecutionParallelWorkers),
+			SignatureCacheSize: viper.GetInt(CfgExecutionSignatureCacheSize),
+		},
+		Sync: SyncConfig{
+			MessageQueueSize: viper.GetInt(CfgSyncMessageQueueSize),
+			CompactBlocks:    viper.GetBool(CfgSyncCompactBlocks),
+			StatusInterval:   viper.GetInt(CfgSyncStatusInterval),
+			BehindThreshold:  viper.GetUint64(CfgSyncBehindThreshold),
+		},
+		RPC: RPCConfig{
+			Enabled:               viper.GetBool(CfgRPCEnabled),
+			Port:                  viper.GetInt(CfgRPCPort),
+			MaxConnections:        viper.GetInt(CfgRPCMaxConnections),
+			AllowLowercaseAddress: viper.GetBool(CfgRPCAllowLowercaseAddress),
+			AdminEnabled:          viper.GetBool(CfgRPCAdminEnabled),
+			AdminToken:            viper.GetString(CfgRPCAdminToken),
+			RosettaEnabled:        viper.GetBool(CfgRPCRosettaEnabled),
+			EthEnabled:            viper.GetBool(CfgRPCEthEnabled),
+			EthChainID:            viper.GetUint64(CfgRPCEthChainID),
+			RESTEnabled:           viper.GetBool(CfgRPCRESTEnabled),
+			WebSocketEnabled:      viper.GetBool(CfgRPCWebSocketEnabled),
+		},
+		Exporter: ExporterConfig{
+			Enabled:      viper.GetBool(CfgExporterEnabled),
+			Sink:         viper.GetString(CfgExporterSink),
+			Endpoint:     viper.GetString(CfgExporterEndpoint),
+			Topic:        viper.GetString(CfgExporterTopic),
+			StartHeight:  viper.GetUint64(CfgExporterStartHeight),
+			BatchSize:    viper.GetInt(CfgExporterBatchSize),
+			PollInterval: viper.GetInt(CfgExporterPollInterval),
+		},
+		Webhook: WebhookConfig{
+			Enabled:     viper.GetBool(CfgWebhookEnabled),
+			MaxAttempts: viper.GetUint64(CfgWebhookMaxAttempts),
+			Timeout:     viper.GetInt(CfgWebhookTimeout),
+		},
+		Metrics: MetricsConfig{
+			Enabled: viper.GetBool(CfgMetricsEnabled),
+			Port:    viper.GetInt(CfgMetricsPort),
+		},
+		Tracing: TracingConfig{
+			Enabled:  viper.GetBool(CfgTracingEnabled),
+			Endpoint: viper.GetString(CfgTracingEndpoint),
+		},
+		Log: LogConfig{
+			Levels:      viper.GetString(CfgLogLevels),
+			PrintSelfID: viper.GetBool(CfgLogPrintSelfID),
+		},
+	}
+	for _, address := range splitList(viper.GetString(CfgGuardianAddresses)) {
+		if !IsHexAddress(address) {
+			cerr.addf(CfgGuardianAddresses, "%q is not an address, list the hex addresses of the guardians separated by commas", address)
+			continue
+		}
+		c.Guardian.Addresses = append(c.Guardian.Addresses, HexToAddress(address))
+	}
+	for _, address := range splitList(viper.GetString(CfgProposerExcludedAddresses)) {
+		if !IsHexAddress(address) {
+			cerr.addf(CfgProposerExcludedAddresses, "%q is not an address, list the hex addresses separated by commas", address)
+			continue
+		}
+		c.Proposer.ExcludedAddresses = append(c.Proposer.ExcludedAddresses, HexToAddress(address))
+	}
+
+	c.validate(cerr)
+	if len(cerr.Problems) > 0 {
+		return c, cerr
+	}
+	return c, nil
+}
+
+// Validate checks the config, and returns a ConfigError listing its problems.
+func (c *Config) Validate() error {
+	cerr := &ConfigError{}
+	c.validate(cerr)
+	if len(cerr.Problems) > 0 {
+		return cerr
+	}
+	return nil
+}
+
+func (c *Config) validate(cerr *ConfigError) {
+	if c.ChainID == "" {
+		cerr.addf(CfgChainID, "must not be empty")
+	}
+
+	checkPort(cerr, CfgP2PPort, c.P2P.Port)
+	for _, addr := range c.P2P.ListenAddresses {
+		if _, port, err := net.SplitHostPort(addr); err != nil {
+			cerr.addf(CfgP2PListenAddresses, "%q is not a host:port address", addr)
+		} else if p, err := strconv.Atoi(port); err != nil || p < 1 || p > 65535 {
+			cerr.addf(CfgP2PListenAddresses, "%q does not have a valid port, set it between 1 and 65535", addr)
+		}
+	}
+	for _, seed := range c.P2P.Seeds {
+		if _, _, err := net.SplitHostPort(seed); err != nil {
+			cerr.addf(CfgP2PSeeds, "%q is not a host:port address", seed)
+		}
+	}
+	for _, peer := range c.P2P.ValidatorPeers {
+		if _, _, err := net.SplitHostPort(peer); err != nil {
+			cerr.addf(CfgP2PValidatorPeers, "%q is not a host:port address", peer)
+		}
+	}
+	if c.P2P.Proxy != "" {
+		if _, _, err := net.SplitHostPort(c.P2P.Proxy); err != nil {
+			cerr.addf(CfgP2PProxy, "%q is not a host:port address", c.P2P.Proxy)
+		}
+	}
+	checkPositive(cerr, CfgP2PMessageQueueSize, c.P2P.MessageQueueSize)
+	checkPositive(cerr, CfgP2PSendQueueSize, c.P2P.SendQueueSize)
+
+	checkPositive(cerr, CfgConsensusMinProposalWait, c.Consensus.MinProposalWait)
+	if c.Consensus.MaxEpochLength <= c.Consensus.MinProposalWait {
+		cerr.addf(CfgConsensusMaxEpochLength, "%v must be larger than %s (%v)",
+			c.Consensus.MaxEpochLength, CfgConsensusMinProposalWait, c.Consensus.MinProposalWait)
+	}
+	checkPositive(cerr, CfgConsensusMessageQueueSize, c.Consensus.MessageQueueSize)
+	checkOneOf(cerr, CfgConsensusProposerSelection, c.Consensus.ProposerSelection, proposerSelections)
+	if len(c.Consensus.Cosigners) > 0 && c.Consensus.ThresholdKey == "" {
+		cerr.addf(CfgConsensusCosigners, "cosigners are only used with %s, set the key share file", CfgConsensusThresholdKey)
+	}
+	checkPositive(cerr, CfgConsensusCosignerTimeout, c.Consensus.CosignerTimeout)
+	if c.Consensus.DryRun && c.Guardian.Enabled {
+		cerr.addf(CfgConsensusDryRun, "a dry-run node does not attest checkpoints, disable %s", CfgGuardianEnabled)
+	}
+	if c.Consensus.RemoteSigner != "" {
+		if c.Consensus.ThresholdKey != "" {
+			cerr.addf(CfgConsensusRemoteSigner, "the validator key is either held by a remote signer or split across machines, unset %s", CfgConsensusThresholdKey)
+		}
+		if c.Guardian.Enabled {
+			cerr.addf(CfgConsensusRemoteSigner, "a remote signer does not sign checkpoint attestations, disable %s", CfgGuardianEnabled)
+		}
+	}
+	checkPositive(cerr, CfgConsensusRemoteSignerTimeout, c.Consensus.RemoteSignerTimeout)
+	checkNotNegative(cerr, CfgConsensusStallAlarmTimeout, c.Consensus.StallAlarmTimeout)
+	if c.Consensus.StallAlarmWebhook != "" {
+		if u, err := url.Parse(c.Consensus.StallAlarmWebhook); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			cerr.addf(CfgConsensusStallAlarmWebhook, "%q is not an http(s) URL", c.Consensus.StallAlarmWebhook)
+		} else if c.Consensus.StallAlarmTimeout == 0 {
+			cerr.addf(CfgConsensusStallAlarmWebhook, "the stall alarm is disabled, set %s", CfgConsensusStallAlarmTimeout)
+		}
+	}
+
+	if c.Guardian.CheckpointInterval == 0 {
+		cerr.addf(CfgGuardianCheckpointInterval, "must be at least 1")
+	}
+
+	if c.Mempool.MaxNumTxs == 0 {
+		cerr.addf(CfgMempoolMaxNumTxs, "must be at least 1")
+	}
+	checkNotNegative(cerr, CfgMempoolGossipRateLimit, c.Mempool.GossipRateLimit)
+
+	checkOneOf(cerr, CfgStorageBackend, c.Storage.Backend, storageBackends)
+	checkPositive(cerr, CfgStorageStatsInterval, c.Storage.StatsInterval)
+	checkNotNegative(cerr, CfgStorageCompactionInterval, c.Storage.CompactionInterval)
+	checkOneOf(cerr, CfgStorageMode, c.Storage.Mode, nodeModes)
+	checkNotNegative(cerr, CfgStorageBlockCacheSize, c.Storage.BlockCacheSize)
+	checkNotNegative(cerr, CfgStorageHeaderCacheSize, c.Storage.HeaderCacheSize)
+	checkNotNegative(cerr, CfgStorageTrieNodeCacheSize, c.Storage.TrieNodeCacheSize)
+
+	checkNotNegative(cerr, CfgExecutionParallelWorkers, c.Execution.ParallelWorkers)
+	checkNotNegative(cerr, CfgExecutionSignatureCacheSize, c.Execution.SignatureCacheSize)
+
+	checkPositive(cerr, CfgSyncMessageQueueSize, c.Sync.MessageQueueSize)
+	checkPositive(cerr, CfgSyncStatusInterval, c.Sync.StatusInterval)
+
+	checkPort(cerr, CfgRPCPort, c.RPC.Port)
+	checkPositive(cerr, CfgRPCMaxConnections, c.RPC.MaxConnections)
+	if c.RPC.Enabled && c.RPC.Port == c.P2P.Port {
+		cerr.addf(CfgRPCPort, "%v is also the P2P port, use another port", c.RPC.Port)
+	}
+	if c.RPC.EthEnabled && c.RPC.EthChainID == 0 {
+		cerr.addf(CfgRPCEthChainID, "must not be 0 when %s is set", CfgRPCEthEnabled)
+	}
+
+	if c.Exporter.Enabled {
+		checkOneOf(cerr, CfgExporterSink, c.Exporter.Sink, exporterSinks)
+		if c.Exporter.Endpoint == "" {
+			cerr.addf(CfgExporterEndpoint, "must not be empty, set the URL, the Kafka brokers or the PostgreSQL connection string")
+		}
+		if c.Exporter.Sink == "kafka" && c.Exporter.Topic == "" {
+			cerr.addf(CfgExporterTopic, "must not be empty for the kafka sink")
+		}
+		checkPositive(cerr, CfgExporterBatchSize, c.Exporter.BatchSize)
+		checkPositive(cerr, CfgExporterPollInterval, c.Exporter.PollInterval)
+	}
+
+	if c.Webhook.Enabled {
+		if !c.RPC.Enabled || !c.RPC.AdminEnabled {
+			cerr.addf(CfgWebhookEnabled, "the webhooks are registered through the admin RPC, set %s and %s", CfgRPCEnabled, CfgRPCAdminEnabled)
+		}
+		if c.Webhook.MaxAttempts == 0 {
+			cerr.addf(CfgWebhookMaxAttempts, "must be at least 1")
+		}
+		checkPositive(cerr, CfgWebhookTimeout, c.Webhook.Timeout)
+	}
+
+	checkPort(cerr, CfgMetricsPort, c.Metrics.Port)
+	if c.Metrics.Enabled && (c.Metrics.Port == c.P2P.Port || (c.RPC.Enabled && c.Metrics.Port == c.RPC.Port)) {
+		cerr.addf(CfgMetricsPort, "%v is also the P2P or RPC port, use another port", c.Metrics.Port)
+	}
+
+	if c.Tracing.Enabled {
+		if u, err := url.Parse(c.Tracing.Endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			cerr.addf(CfgTracingEndpoint, "%q is not an http(s) URL, e.g. \"http://localhost:4318\"", c.Tracing.Endpoint)
+		}
+	}
+
+	for _, moduleAndLevel := range strings.Split(c.Log.Levels, ",") {
+		tokens := strings.Split(moduleAndLevel, ":")
+		if len(tokens) != 2 {
+			cerr.addf(CfgLogLevels, "%q is not a module:level pair, e.g. \"*:info,consensus:debug\"", moduleAndLevel)
+			continue
+		}
+		checkOneOf(cerr, CfgLogLevels, strings.TrimSpace(tokens[1]), logLevelNames)
+	}
+}
+
+// values returns the values of the config by key.
+func (c *Config) values() map[string]interface{} {
+	return map[string]interface{}{
+		CfgChainID:                           c.ChainID,
+		CfgP2PName:                           c.P2P.Name,
+		CfgP2PPort:                           c.P2P.Port,
+		CfgP2PListenAddresses:                c.P2P.ListenAddresses,
+		CfgP2PSeeds:                          c.P2P.Seeds,
+		CfgP2PValidatorPeers:                 c.P2P.ValidatorPeers,
+		CfgP2PProxy:                          c.P2P.Proxy,
+		CfgP2PMessageQueueSize:               c.P2P.MessageQueueSize,
+		CfgP2PSendQueueSize:                  c.P2P.SendQueueSize,
+		CfgConsensusMaxEpochLength:           c.Consensus.MaxEpochLength,
+		CfgConsensusMinProposalWait:          c.Consensus.MinProposalWait,
+		CfgConsensusMessageQueueSize:         c.Consensus.MessageQueueSize,
+		CfgConsensusProposerSelection:        c.Consensus.ProposerSelection,
+		CfgConsensusThresholdKey:             c.Consensus.ThresholdKey,
+		CfgConsensusCosigners:                c.Consensus.Cosigners,
+		CfgConsensusCosignerTimeout:          c.Consensus.CosignerTimeout,
+		CfgConsensusCompactProposals:         c.Consensus.CompactProposals,
+		CfgConsensusDryRun:                   c.Consensus.DryRun,
+		CfgConsensusRemoteSigner:             c.Consensus.RemoteSigner,
+		CfgConsensusRemoteSignerTimeout:      c.Consensus.RemoteSignerTimeout,
+		CfgConsensusSignStateFile:            c.Consensus.SignStateFile,
+		CfgConsensusStallAlarmTimeout:        c.Consensus.StallAlarmTimeout,
+		CfgConsensusStallAlarmWebhook:        c.Consensus.StallAlarmWebhook,
+		CfgConsensusTxHashActivationHeight:   c.Consensus.TxHashActivationHeight,
+		CfgGuardianEnabled:                   c.Guardian.Enabled,
+		CfgGuardianAddresses:                 c.Guardian.Addresses,
+		CfgGuardianCheckpointInterval:        c.Guardian.CheckpointInterval,
+		CfgMempoolMaxNumTxs:                  c.Mempool.MaxNumTxs,
+		CfgMempoolGossipRateLimit:            c.Mempool.GossipRateLimit,
+		CfgProposerExcludedAddresses:         c.Proposer.ExcludedAddresses,
+		CfgProposerExcludedTxTypes:           c.Proposer.ExcludedTxTypes,
+		CfgStorageBackend:                    c.Storage.Backend,
+		CfgStorageStatsInterval:              c.Storage.StatsInterval,
+		CfgStorageCompactionInterval:         c.Storage.CompactionInterval,
+		CfgStorageMode:                       c.Storage.Mode,
+		CfgStorageStatePruningEnabled:        c.Storage.StatePruningEnabled,
+		CfgStorageStatePruningRetainedBlocks: c.Storage.StatePruningRetainedBlocks,
+		CfgStorageFreezerRetainedBlocks:      c.Storage.FreezerRetainedBlocks,
+		CfgStorageBlockCacheSize:             c.Storage.BlockCacheSize,
+		CfgStorageHeaderCacheSize:            c.Storage.HeaderCacheSize,
+		CfgStorageTrieNodeCacheSize:          c.Storage.TrieNodeCacheSize,
+		CfgStorageBalanceJournalEnabled:      c.Storage.BalanceJournalEnabled,
+		CfgStorageAddressIndexEnabled:        c.Storage.AddressIndexEnabled,
+		CfgExecutionParallelWorkers:          c.Execution.ParallelWorkers,
+		CfgExecutionSignatureCacheSize:       c.Execution.SignatureCacheSize,
+		CfgSyncMessageQueueSize:              c.Sync.MessageQueueSize,
+		CfgSyncCompactBlocks:                 c.Sync.CompactBlocks,
+		CfgSyncStatusInterval:                c.Sync.StatusInterval,
+		CfgSyncBehindThreshold:               c.Sync.BehindThreshold,
+		CfgRPCEnabled:                        c.RPC.Enabled,
+		CfgRPCPort:                           c.RPC.Port,
+		CfgRPCMaxConnections:                 c.RPC.MaxConnections,
+		CfgRPCAllowLowercaseAddress:          c.RPC.AllowLowercaseAddress,
+		CfgRPCAdminEnabled:                   c.RPC.AdminEnabled,
+		CfgRPCAdminToken:                     c.RPC.AdminToken,
+		CfgRPCRosettaEnabled:                 c.RPC.RosettaEnabled,
+		CfgRPCEthEnabled:                     c.RPC.EthEnabled,
+		CfgRPCEthChainID:                     c.RPC.EthChainID,
+		CfgRPCRESTEnabled:                    c.RPC.RESTEnabled,
+		CfgRPCWebSocketEnabled:               c.RPC.WebSocketEnabled,
+		CfgExporterEnabled:                   c.Exporter.Enabled,
+		CfgExporterSink:                      c.Exporter.Sink,
+		CfgExporterEndpoint:                  c.Exporter.Endpoint,
+		CfgExporterTopic:                     c.Exporter.Topic,
+		CfgExporterStartHeight:               c.Exporter.StartHeight,
+		CfgExporterBatchSize:                 c.Exporter.BatchSize,
+		CfgExporterPollInterval:              c.Exporter.PollInterval,
+		CfgWebhookEnabled:                    c.Webhook.Enabled,
+		CfgWebhookMaxAttempts:                c.Webhook.MaxAttempts,
+		CfgWebhookTimeout:                    c.Webhook.Timeout,
+		CfgMetricsEnabled:                    c.Metrics.Enabled,
+		CfgMetricsPort:                       c.Metrics.Port,
+		CfgTracingEnabled:                    c.Tracing.Enabled,
+		CfgTracingEndpoint:                   c.Tracing.Endpoint,
+		CfgLogLevels:                         c.Log.Levels,
+		CfgLogPrintSelfID:                    c.Log.PrintSelfID,
+	}
+}
+
+// splitList splits a comma separated list, and filters out the empty items.
+func splitList(list string) []string {
+	f := func(c rune) bool {
+		return c == ','
+	}
+	items := []string{}
+	for _, item := range strings.FieldsFunc(list, f) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func checkPort(cerr *ConfigError, key string, port int) {
+	if port <= 0 || port > 65535 {
+		cerr.addf(key, "%v is not a valid port, set it between 1 and 65535", port)
+	}
+}
+
+func checkPositive(cerr *ConfigError, key string, value int) {
+	if value <= 0 {
+		cerr.addf(key, "%v must be at least 1", value)
+	}
+}
+
+func checkNotNegative(cerr *ConfigError, key string, value int) {
+	if value < 0 {
+		cerr.addf(key, "%v must not be negative, set 0 to disable", value)
+	}
+}
+
+func checkOneOf(cerr *ConfigError, key string, value string, allowed []string) {
+	for _, a := range allowed {
+		if value == a {
+			return
+		}
+	}
+	cerr.addf(key, "%s is not supported, use one of %s", strconv.Quote(value), strings.Join(allowed, ", "))
+}
@@ -0,0 +1,216 @@
+package common
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultConfig(t *testing.T) {
+	assert := assert.New(t)
+
+	config := GetConfig()
+	assert.Nil(config.Validate())
+	assert.Equal(50001, config.P2P.Port)
+	assert.Equal(16888, config.RPC.Port)
+	assert.Equal([]string{}, config.P2P.Seeds)
+	assert.Equal(uint(200000), config.Mempool.MaxNumTxs)
+}
+
+func TestConfigValidation(t *testing.T) {
+	assert := assert.New(t)
+
+	config := *GetConfig()
+	config.P2P.Port = 70000
+	config.P2P.Seeds = []string{"127.0.0.1:50001", "127.0.0.1"}
+	config.Consensus.MaxEpochLength = 2
+	config.Storage.Backend = "mysql"
+	config.Log.Levels = "*:verbose"
+
+	err := config.Validate()
+	require.NotNil(t, err)
+	problems := err.(*ConfigError).Problems
+	assert.Equal(5, len(problems))
+	assert.Contains(problems[0], CfgP2PPort)
+	assert.Contains(problems[1], "\"127.0.0.1\" is not a host:port address")
+	assert.Contains(problems[2], CfgConsensusMaxEpochLength)
+	assert.Contains(problems[3], "use one of leveldb, rocksdb, badgerdb, memdb")
+	assert.Contains(problems[4], CfgLogLevels)
+}
+
+func TestStorageModeConfig(t *testing.T) {
+	assert := assert.New(t)
+
+	config := *GetConfig()
+	assert.Equal(NodeModeFull, config.Storage.Mode)
+	assert.True(config.Storage.PrunesState())
+
+	config.Storage.Mode = NodeModeArchive
+	assert.False(config.Storage.PrunesState())
+	assert.Nil(config.Validate())
+
+	config.Storage.Mode = "light"
+	err := config.Validate()
+	require.NotNil(t, err)
+	assert.Contains(err.Error(), "use one of full, archive")
+}
+
+func TestExporterConfig(t *testing.T) {
+	assert := assert.New(t)
+
+	config := *GetConfig()
+	config.Exporter.Enabled = true
+	config.Exporter.Endpoint = "http://localhost:8080/blocks"
+	assert.Nil(config.Validate())
+
+	config.Exporter.Sink = "mysql"
+	config.Exporter.Endpoint = ""
+	err := config.Validate()
+	require.NotNil(t, err)
+	problems := err.(*ConfigError).Problems
+	assert.Equal(2, len(problems))
+	assert.Contains(problems[0], "use one of ndjson, kafka, postgres")
+	assert.Contains(problems[1], CfgExporterEndpoint)
+}
+
+func TestDryRunConfig(t *testing.T) {
+	assert := assert.New(t)
+
+	config := *GetConfig()
+	assert.False(config.Consensus.DryRun)
+	config.Consensus.DryRun = true
+	assert.Nil(config.Validate())
+
+	// A dry-run node signs no attestation
+	config.Guardian.Enabled = true
+	err := config.Validate()
+	require.NotNil(t, err)
+	assert.Contains(err.Error(), CfgConsensusDryRun)
+}
+
+func TestRemoteSignerConfig(t *testing.T) {
+	assert := assert.New(t)
+
+	config := *GetConfig()
+	config.Consensus.RemoteSigner = "http://10.0.0.2:16890/rpc"
+	assert.Nil(config.Validate())
+
+	// The validator key is held by the remote signer alone
+	config.Consensus.ThresholdKey = "threshold_key"
+	err := config.Validate()
+	require.NotNil(t, err)
+	assert.Contains(err.Error(), CfgConsensusRemoteSigner)
+
+	config.Consensus.ThresholdKey = ""
+	config.Consensus.RemoteSignerTimeout = 0
+	err = config.Validate()
+	require.NotNil(t, err)
+	assert.Contains(err.Error(), CfgConsensusRemoteSignerTimeout)
+}
+
+func TestGuardianAddressesConfig(t *testing.T) {
+	assert := assert.New(t)
+	defer viper.Set(CfgGuardianAddresses, "")
+
+	viper.Set(CfgGuardianAddresses, "0x2E833968E5bB786Ae419c4d13189fB081Cc43bab, 0x9F1233798E905E173560071255140b4A8aBd3Ec6,")
+	assert.Equal([]Address{
+		HexToAddress("0x2E833968E5bB786Ae419c4d13189fB081Cc43bab"),
+		HexToAddress("0x9F1233798E905E173560071255140b4A8aBd3Ec6"),
+	}, GetConfig().Guardian.Addresses)
+
+	viper.Set(CfgGuardianAddresses, "0x2E833968E5bB786Ae419c4d13189fB081Cc43bab,guardian")
+	_, err := readConfig()
+	assert.NotNil(err)
+	assert.Contains(err.Error(), "\"guardian\" is not an address")
+}
+
+func TestReloadConfig(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "config")
+	require.Nil(err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "config.yaml")
+
+	require.Nil(ioutil.WriteFile(file, []byte("log:\n  levels: \"*:info\"\n"), 0600))
+	viper.SetConfigFile(file)
+	require.Nil(viper.ReadInConfig())
+	defer func() {
+		ioutil.WriteFile(file, []byte{}, 0600)
+		viper.ReadInConfig()
+		config = nil
+		reloadListeners = nil
+	}()
+
+	loaded, err := LoadConfig()
+	require.Nil(err)
+	assert.Equal("*:info", loaded.Log.Levels)
+
+	var notified *Config
+	OnConfigReload(func(c *Config) { notified = c })
+
+	require.Nil(ioutil.WriteFile(file, []byte("log:\n  levels: \"*:debug\"\np2p:\n  port: 50002\n  seeds: 127.0.0.1:50001\n"), 0600))
+	reload, err := ReloadConfig()
+	require.Nil(err)
+	assert.Equal([]string{CfgLogLevels, CfgP2PSeeds}, reload.Reloaded)
+	assert.Equal([]string{CfgP2PPort}, reload.Ignored)
+
+	config := GetConfig()
+	assert.Equal(config, notified)
+	assert.Equal("*:debug", config.Log.Levels)
+	assert.Equal([]string{"127.0.0.1:50001"}, config.P2P.Seeds)
+	assert.Equal(50001, config.P2P.Port)
+
+	// An invalid config is not applied
+	require.Nil(ioutil.WriteFile(file, []byte("log:\n  levels: \"*:verbose\"\n"), 0600))
+	_, err = ReloadConfig()
+	assert.NotNil(err)
+	assert.Equal("*:debug", GetConfig().Log.Levels)
+}
+
+func TestProposerConfig(t *testing.T) {
+	assert := assert.New(t)
+	defer viper.Set(CfgProposerExcludedAddresses, "")
+	defer viper.Set(CfgProposerExcludedTxTypes, "")
+
+	viper.Set(CfgProposerExcludedAddresses, "0x2E833968E5bB786Ae419c4d13189fB081Cc43bab")
+	viper.Set(CfgProposerExcludedTxTypes, "SmartContractTx, DepositStakeTx")
+	config := GetConfig().Proposer
+	assert.Equal([]Address{HexToAddress("0x2E833968E5bB786Ae419c4d13189fB081Cc43bab")}, config.ExcludedAddresses)
+	assert.Equal([]string{"SmartContractTx", "DepositStakeTx"}, config.ExcludedTxTypes)
+
+	viper.Set(CfgProposerExcludedAddresses, "sanctioned")
+	_, err := readConfig()
+	assert.NotNil(err)
+	assert.Contains(err.Error(), CfgProposerExcludedAddresses)
+}
+
+func TestStallAlarmConfig(t *testing.T) {
+	assert := assert.New(t)
+
+	config := *GetConfig()
+	config.Consensus.StallAlarmWebhook = "https://alerts.example.com/theta"
+	assert.Nil(config.Validate())
+
+	config.Consensus.StallAlarmWebhook = "alerts.example.com"
+	err := config.Validate()
+	require.NotNil(t, err)
+	assert.Contains(err.Error(), CfgConsensusStallAlarmWebhook)
+
+	config.Consensus.StallAlarmWebhook = "https://alerts.example.com/theta"
+	config.Consensus.StallAlarmTimeout = 0
+	err = config.Validate()
+	require.NotNil(t, err)
+	assert.Contains(err.Error(), CfgConsensusStallAlarmWebhook)
+
+	config.Consensus.StallAlarmTimeout = -1
+	err = config.Validate()
+	require.NotNil(t, err)
+	assert.Contains(err.Error(), CfgConsensusStallAlarmTimeout)
+}
@@ -1,5 +1,9 @@
 package result
 
+import "strconv"
+
+// ErrorCode identifies the outcome of a transaction. The same codes are returned by the
+// executors, by the mempool admission, and in the RPC responses and the receipts.
 type ErrorCode int
 
 const (
@@ -13,24 +17,166 @@ const (
 	CodeEmptyPubKeyWithSequence1 ErrorCode = 100004
 	CodeUnauthorizedTx           ErrorCode = 100005
 	CodeInvalidFee               ErrorCode = 100006
+	CodeInvalidTx                ErrorCode = 100007
+	CodeAccountNotFound          ErrorCode = 100008
+	CodeTooManyAccounts          ErrorCode = 100009
+	CodeUnbalancedTx             ErrorCode = 100010
+	CodeDuplicateTx              ErrorCode = 100011
 
 	// ReserveFund Errors
 	CodeReserveFundCheckFailed   ErrorCode = 101001
 	CodeReservedFundNotSpecified ErrorCode = 101002
 	CodeInvalidFundToReserve     ErrorCode = 101003
+	CodeInvalidSpendLimits       ErrorCode = 101004
+	CodeReservedFundNotFound     ErrorCode = 101005
+	CodeTooManyReservedFunds     ErrorCode = 101006
+	CodeReserveDurationExceeded  ErrorCode = 101007
 
 	// ReleaseFund Errors
 	CodeReleaseFundCheckFailed ErrorCode = 102001
 
 	// ServerPayment Errors
 	CodeCheckTransferReservedFundFailed ErrorCode = 103001
+	CodeResourceSpendLimitExceeded      ErrorCode = 103002
+	CodeInvalidBatchPayment             ErrorCode = 103003
+	CodeInvalidPaymentCoins             ErrorCode = 103004
 
 	// SplitRule Errors
 	CodeUnauthorizedToUpdateSplitRule ErrorCode = 104001
+	CodeInvalidSplits                 ErrorCode = 104002
+	CodeSplitRuleNotFound             ErrorCode = 104003
 
 	// SmartContract Errors
 	CodeEVMError               ErrorCode = 105001
 	CodeInvalidValueToTransfer ErrorCode = 105002
 	CodeInvalidGasPrice        ErrorCode = 105003
 	CodeFeeLimitTooHigh        ErrorCode = 105004
+
+	// Stake Errors
+	CodeInvalidStake       ErrorCode = 106001
+	CodeStakeNotFound      ErrorCode = 106002
+	CodeInvalidStakeHolder ErrorCode = 106003
+
+	// ExtendReserve Errors
+	CodeExtendReserveCheckFailed ErrorCode = 107001
+
+	// Recovery Errors
+	CodeInvalidGuardians    ErrorCode = 108001
+	CodeRecoveryCheckFailed ErrorCode = 108002
+
+	// TimelockedSend Errors
+	CodeInvalidUnlockHeight ErrorCode = 109001
+
+	// Token Errors
+	CodeInvalidToken  ErrorCode = 110001
+	CodeTokenNotFound ErrorCode = 110002
+
+	// Slash Errors
+	CodeInvalidSlashProof ErrorCode = 111001
+	CodeValidatorNotFound ErrorCode = 111002
+
+	// Coinbase Errors
+	CodeInvalidCoinbase ErrorCode = 112001
+
+	// Query Errors
+	CodeInvalidQuery           ErrorCode = 113001
+	CodeReceiptNotFound        ErrorCode = 113002
+	CodeBalanceJournalNotFound ErrorCode = 113003
+
+	// ParameterUpdate Errors
+	CodeInvalidParameters            ErrorCode = 114001
+	CodeParameterUpdateNotAuthorized ErrorCode = 114002
+
+	// Governance Errors
+	CodeInvalidProposal   ErrorCode = 115001
+	CodeProposalNotFound  ErrorCode = 115002
+	CodeProposalNotActive ErrorCode = 115003
+	CodeNoVotingStake     ErrorCode = 115004
+
+	// Unjail Errors
+	CodeValidatorNotJailed ErrorCode = 116001
+	CodeUnjailCooldown     ErrorCode = 116002
 )
+
+var errorCodeNames = map[ErrorCode]string{
+	CodeOK: "OK",
+
+	CodeGenericError:             "GenericError",
+	CodeInvalidSignature:         "InvalidSignature",
+	CodeInvalidSequence:          "InvalidSequence",
+	CodeInsufficientFund:         "InsufficientFund",
+	CodeEmptyPubKeyWithSequence1: "EmptyPubKeyWithSequence1",
+	CodeUnauthorizedTx:           "UnauthorizedTx",
+	CodeInvalidFee:               "InvalidFee",
+	CodeInvalidTx:                "InvalidTx",
+	CodeAccountNotFound:          "AccountNotFound",
+	CodeTooManyAccounts:          "TooManyAccounts",
+	CodeUnbalancedTx:             "UnbalancedTx",
+	CodeDuplicateTx:              "DuplicateTx",
+
+	CodeReserveFundCheckFailed:   "ReserveFundCheckFailed",
+	CodeReservedFundNotSpecified: "ReservedFundNotSpecified",
+	CodeInvalidFundToReserve:     "InvalidFundToReserve",
+	CodeInvalidSpendLimits:       "InvalidSpendLimits",
+	CodeReservedFundNotFound:     "ReservedFundNotFound",
+	CodeTooManyReservedFunds:     "TooManyReservedFunds",
+	CodeReserveDurationExceeded:  "ReserveDurationExceeded",
+
+	CodeReleaseFundCheckFailed: "ReleaseFundCheckFailed",
+
+	CodeCheckTransferReservedFundFailed: "CheckTransferReservedFundFailed",
+	CodeResourceSpendLimitExceeded:      "ResourceSpendLimitExceeded",
+	CodeInvalidBatchPayment:             "InvalidBatchPayment",
+	CodeInvalidPaymentCoins:             "InvalidPaymentCoins",
+
+	CodeUnauthorizedToUpdateSplitRule: "UnauthorizedToUpdateSplitRule",
+	CodeInvalidSplits:                 "InvalidSplits",
+	CodeSplitRuleNotFound:             "SplitRuleNotFound",
+
+	CodeEVMError:               "EVMError",
+	CodeInvalidValueToTransfer: "InvalidValueToTransfer",
+	CodeInvalidGasPrice:        "InvalidGasPrice",
+	CodeFeeLimitTooHigh:        "FeeLimitTooHigh",
+
+	CodeInvalidStake:       "InvalidStake",
+	CodeStakeNotFound:      "StakeNotFound",
+	CodeInvalidStakeHolder: "InvalidStakeHolder",
+
+	CodeExtendReserveCheckFailed: "ExtendReserveCheckFailed",
+
+	CodeInvalidGuardians:    "InvalidGuardians",
+	CodeRecoveryCheckFailed: "RecoveryCheckFailed",
+
+	CodeInvalidUnlockHeight: "InvalidUnlockHeight",
+
+	CodeInvalidToken:  "InvalidToken",
+	CodeTokenNotFound: "TokenNotFound",
+
+	CodeInvalidSlashProof: "InvalidSlashProof",
+	CodeValidatorNotFound: "ValidatorNotFound",
+
+	CodeInvalidCoinbase: "InvalidCoinbase",
+
+	CodeInvalidQuery:           "InvalidQuery",
+	CodeReceiptNotFound:        "ReceiptNotFound",
+	CodeBalanceJournalNotFound: "BalanceJournalNotFound",
+
+	CodeInvalidParameters:            "InvalidParameters",
+	CodeParameterUpdateNotAuthorized: "ParameterUpdateNotAuthorized",
+
+	CodeInvalidProposal:   "InvalidProposal",
+	CodeProposalNotFound:  "ProposalNotFound",
+	CodeProposalNotActive: "ProposalNotActive",
+	CodeNoVotingStake:     "NoVotingStake",
+
+	CodeValidatorNotJailed: "ValidatorNotJailed",
+	CodeUnjailCooldown:     "UnjailCooldown",
+}
+
+// String returns the name of the error code, or its number if it is unknown
+func (code ErrorCode) String() string {
+	if name, ok := errorCodeNames[code]; ok {
+		return name
+	}
+	return strconv.Itoa(int(code))
+}
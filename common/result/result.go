@@ -35,6 +35,52 @@ func (res Result) WithMessage(message string) Result {
 	return res
 }
 
+// Err returns nil if the execution succeeded, or a *CodedError with the code and the
+// message of the result otherwise
+func (res Result) Err() error {
+	if res.IsOK() {
+		return nil
+	}
+	return &CodedError{Code: res.Code, Message: res.Message}
+}
+
+// -------------- Errors -------------- //
+
+// Coder is implemented by the errors carrying an error code
+type Coder interface {
+	ErrorCode() ErrorCode
+}
+
+// CodedError is an error carrying the error code of a failed execution
+type CodedError struct {
+	Code    ErrorCode
+	Message string
+}
+
+var _ Coder = (*CodedError)(nil)
+
+// Error implements the error interface
+func (err *CodedError) Error() string {
+	return err.Message
+}
+
+// ErrorCode implements the Coder interface
+func (err *CodedError) ErrorCode() ErrorCode {
+	return err.Code
+}
+
+// CodeOf returns the error code carried by the error, CodeOK for nil, and
+// CodeGenericError for the errors without error code
+func CodeOf(err error) ErrorCode {
+	if err == nil {
+		return CodeOK
+	}
+	if coder, ok := err.(Coder); ok {
+		return coder.ErrorCode()
+	}
+	return CodeGenericError
+}
+
 // -------------- Constructors -------------- //
 
 // OK represents the success result
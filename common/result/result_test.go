@@ -0,0 +1,31 @@
+package result
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResultErr(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Nil(OK.Err())
+	assert.Equal(CodeOK, CodeOf(OK.Err()))
+
+	err := Error("Got %v, expected %v", 3, 2).WithErrorCode(CodeInvalidSequence).Err()
+	assert.Equal("Got 3, expected 2", err.Error())
+	assert.Equal(CodeInvalidSequence, CodeOf(err))
+
+	assert.Equal(CodeGenericError, CodeOf(Error("generic").Err()))
+	assert.Equal(CodeGenericError, CodeOf(errors.New("no code")))
+}
+
+func TestErrorCodeString(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("OK", CodeOK.String())
+	assert.Equal("InsufficientFund", CodeInsufficientFund.String())
+	assert.Equal("ReservedFundNotFound", CodeReservedFundNotFound.String())
+	assert.Equal("999", ErrorCode(999).String())
+}
@@ -0,0 +1,216 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	otlpTracesPath     = "/v1/traces"
+	otlpQueueSize      = 4096
+	otlpMaxBatchSize   = 512
+	otlpExportInterval = 5 * time.Second
+	otlpExportTimeout  = 10 * time.Second
+	otlpScopeName      = "github.com/thetatoken/ukulele"
+	otlpSpanKindIntern = 1 // SPAN_KIND_INTERNAL
+	otlpStatusOK       = 1 // STATUS_CODE_OK
+	otlpStatusError    = 2 // STATUS_CODE_ERROR
+)
+
+var _ Exporter = (*OTLPExporter)(nil)
+
+// OTLPExporter exports spans in batches to an OpenTelemetry collector, with the
+// OTLP/HTTP protocol and the JSON encoding. Spans are dropped if the collector
+// does not keep up.
+type OTLPExporter struct {
+	url      string
+	resource []otlpKeyValue
+	client   *http.Client
+	spans    chan *Span
+
+	wg *sync.WaitGroup
+}
+
+// NewOTLPExporter creates an exporter to the collector at the given endpoint, e.g.
+// "http://localhost:4318". The spans are attributed to the given service and node.
+func NewOTLPExporter(endpoint string, serviceName string, nodeID string) *OTLPExporter {
+	return &OTLPExporter{
+		url: strings.TrimRight(endpoint, "/") + otlpTracesPath,
+		resource: []otlpKeyValue{
+			newOTLPKeyValue("service.name", serviceName),
+			newOTLPKeyValue("service.instance.id", nodeID),
+		},
+		client: &http.Client{Timeout: otlpExportTimeout},
+		spans:  make(chan *Span, otlpQueueSize),
+		wg:     &sync.WaitGroup{},
+	}
+}
+
+// ExportSpan implements the Exporter interface.
+func (e *OTLPExporter) ExportSpan(span *Span) {
+	select {
+	case e.spans <- span:
+	default:
+		log.Debugf("[trace] Dropping span %v, export queue is full", span.Name)
+	}
+}
+
+// Start exports the spans until the context is done, then exports the remaining ones.
+func (e *OTLPExporter) Start(ctx context.Context) {
+	e.wg.Add(1)
+	go e.mainLoop(ctx)
+}
+
+// Wait blocks until the remaining spans are exported after the context is done.
+func (e *OTLPExporter) Wait() {
+	e.wg.Wait()
+}
+
+func (e *OTLPExporter) mainLoop(ctx context.Context) {
+	defer e.wg.Done()
+
+	ticker := time.NewTicker(otlpExportInterval)
+	defer ticker.Stop()
+
+	batch := []*Span{}
+	for {
+		select {
+		case <-ctx.Done():
+			for {
+				select {
+				case span := <-e.spans:
+					batch = append(batch, span)
+				default:
+					e.export(batch)
+					return
+				}
+			}
+		case span := <-e.spans:
+			batch = append(batch, span)
+			if len(batch) >= otlpMaxBatchSize {
+				e.export(batch)
+				batch = []*Span{}
+			}
+		case <-ticker.C:
+			e.export(batch)
+			batch = []*Span{}
+		}
+	}
+}
+
+func (e *OTLPExporter) export(spans []*Span) {
+	if len(spans) == 0 {
+		return
+	}
+	body, err := json.Marshal(e.encode(spans))
+	if err != nil {
+		log.Errorf("[trace] Failed to encode spans: %v", err)
+		return
+	}
+	resp, err := e.client.Post(e.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		log.Warnf("[trace] Failed to export %v spans: %v", len(spans), err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		log.Warnf("[trace] Failed to export %v spans: %v", len(spans), resp.Status)
+	}
+}
+
+// OTLP JSON encoding of ExportTraceServiceRequest.
+
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              int            `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Status            otlpStatus     `json:"status"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpKeyValue struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+func newOTLPKeyValue(key string, value string) otlpKeyValue {
+	return otlpKeyValue{Key: key, Value: otlpValue{StringValue: value}}
+}
+
+func (e *OTLPExporter) encode(spans []*Span) otlpRequest {
+	encoded := make([]otlpSpan, 0, len(spans))
+	for _, s := range spans {
+		span := otlpSpan{
+			TraceID:           hex.EncodeToString(s.Context.TraceID[:]),
+			SpanID:            hex.EncodeToString(s.Context.SpanID[:]),
+			Name:              s.Name,
+			Kind:              otlpSpanKindIntern,
+			StartTimeUnixNano: strconv.FormatInt(s.Start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(s.End.UnixNano(), 10),
+			Status:            otlpStatus{Code: otlpStatusOK},
+		}
+		if s.Parent != (SpanID{}) {
+			span.ParentSpanID = hex.EncodeToString(s.Parent[:])
+		}
+		for _, a := range s.Attributes {
+			span.Attributes = append(span.Attributes, newOTLPKeyValue(a.Key, a.Value))
+		}
+		if s.Error != "" {
+			span.Status = otlpStatus{Code: otlpStatusError, Message: s.Error}
+		}
+		encoded = append(encoded, span)
+	}
+	return otlpRequest{
+		ResourceSpans: []otlpResourceSpans{{
+			Resource: otlpResource{Attributes: e.resource},
+			ScopeSpans: []otlpScopeSpans{{
+				Scope: otlpScope{Name: otlpScopeName},
+				Spans: encoded,
+			}},
+		}},
+	}
+}
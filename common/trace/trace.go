@@ -0,0 +1,150 @@
+// Package trace records the spans of the lifecycle of transactions and blocks, and
+// exports them to an OpenTelemetry collector. The trace of a transaction or block is
+// identified by its hash, so that the spans recorded by different nodes for the same
+// transaction or block are part of the same trace without propagating any context.
+package trace
+
+import (
+	"crypto/rand"
+	"sync"
+	"time"
+
+	"github.com/thetatoken/ukulele/common"
+)
+
+// TraceID identifies a trace.
+type TraceID [16]byte
+
+// SpanID identifies a span in a trace.
+type SpanID [8]byte
+
+// SpanContext identifies a span, as the parent of other spans.
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+}
+
+// ContextOf returns the context of the root span of the trace of a transaction or block
+// hash. The root span is not recorded by any node, but groups the spans of the trace.
+func ContextOf(hash common.Hash) SpanContext {
+	ctx := SpanContext{}
+	copy(ctx.TraceID[:], hash[:16])
+	copy(ctx.SpanID[:], hash[16:24])
+	return ctx
+}
+
+// Attribute is a key value pair describing a span.
+type Attribute struct {
+	Key   string
+	Value string
+}
+
+// Span is an operation of the lifecycle of a transaction or block. The methods of a
+// nil span do nothing, so that the spans can be recorded unconditionally.
+type Span struct {
+	Name       string
+	Context    SpanContext
+	Parent     SpanID
+	Start      time.Time
+	End        time.Time
+	Attributes []Attribute
+	Error      string // Empty if the operation succeeded
+
+	exporter Exporter
+	ended    bool
+}
+
+// Exporter exports the ended spans.
+type Exporter interface {
+	ExportSpan(span *Span)
+}
+
+var (
+	mu       sync.RWMutex
+	exporter Exporter
+)
+
+// SetExporter sets the exporter of the spans. Spans are not recorded without exporter.
+func SetExporter(e Exporter) {
+	mu.Lock()
+	defer mu.Unlock()
+	exporter = e
+}
+
+// Enabled returns whether spans are recorded.
+func Enabled() bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	return exporter != nil
+}
+
+// StartSpan starts a child span of the given parent. It returns nil if tracing is
+// disabled.
+func StartSpan(parent SpanContext, name string) *Span {
+	mu.RLock()
+	e := exporter
+	mu.RUnlock()
+	if e == nil {
+		return nil
+	}
+
+	span := &Span{
+		Name:     name,
+		Context:  SpanContext{TraceID: parent.TraceID},
+		Parent:   parent.SpanID,
+		Start:    time.Now(),
+		exporter: e,
+	}
+	rand.Read(span.Context.SpanID[:])
+	return span
+}
+
+// StartHashSpan starts a child span of the root span of the trace of the given
+// transaction or block hash.
+func StartHashSpan(hash common.Hash, name string) *Span {
+	return StartSpan(ContextOf(hash), name)
+}
+
+// SetStart changes the start time of the span, for operations whose start is only
+// known to be traced afterwards.
+func (s *Span) SetStart(start time.Time) *Span {
+	if s != nil {
+		s.Start = start
+	}
+	return s
+}
+
+// SetAttribute adds an attribute to the span.
+func (s *Span) SetAttribute(key string, value string) *Span {
+	if s != nil {
+		s.Attributes = append(s.Attributes, Attribute{Key: key, Value: value})
+	}
+	return s
+}
+
+// SetError marks the operation of the span as failed.
+func (s *Span) SetError(err error) *Span {
+	if s != nil && err != nil {
+		s.Error = err.Error()
+	}
+	return s
+}
+
+// SpanContext returns the context of the span, to start child spans. It returns the
+// empty context for a nil span.
+func (s *Span) SpanContext() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.Context
+}
+
+// Finish ends the span and exports it. Spans are only exported once.
+func (s *Span) Finish() {
+	if s == nil || s.ended {
+		return
+	}
+	s.ended = true
+	s.End = time.Now()
+	s.exporter.ExportSpan(s)
+}
@@ -0,0 +1,110 @@
+package trace
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thetatoken/ukulele/common"
+)
+
+type capturingExporter struct {
+	spans []*Span
+}
+
+func (e *capturingExporter) ExportSpan(span *Span) {
+	e.spans = append(e.spans, span)
+}
+
+func TestDisabledTracing(t *testing.T) {
+	assert := assert.New(t)
+
+	SetExporter(nil)
+	assert.False(Enabled())
+
+	span := StartHashSpan(common.HexToHash("0x5fa6d8f1b7e83e1f6dc3e3e6bd7bf33e4b5b8d0b71a7cbd58e4b3a0e5a3d1c27"), "test")
+	assert.Nil(span)
+	span.SetAttribute("key", "value").SetError(errors.New("failed")).Finish()
+	assert.Equal(SpanContext{}, span.SpanContext())
+}
+
+func TestHashSpans(t *testing.T) {
+	assert := assert.New(t)
+
+	exporter := &capturingExporter{}
+	SetExporter(exporter)
+	defer SetExporter(nil)
+	assert.True(Enabled())
+
+	hash := common.HexToHash("0x9c1e0b7c2f5d4a3b8e6f7a1d2c3b4a5e6f7d8c9b0a1e2f3d4c5b6a7e8f9d0c1b")
+	span := StartHashSpan(hash, "parent").SetAttribute("height", "1")
+	child := StartSpan(span.SpanContext(), "child").SetError(errors.New("failed"))
+	child.Finish()
+	span.Finish()
+	span.Finish()
+
+	assert.Equal(2, len(exporter.spans))
+	assert.Equal("child", exporter.spans[0].Name)
+	assert.Equal("failed", exporter.spans[0].Error)
+	assert.Equal(span.Context.SpanID, exporter.spans[0].Parent)
+
+	assert.Equal("parent", exporter.spans[1].Name)
+	assert.Equal(ContextOf(hash).TraceID, exporter.spans[1].Context.TraceID)
+	assert.Equal(ContextOf(hash).SpanID, exporter.spans[1].Parent)
+	assert.Equal(ContextOf(hash).TraceID, exporter.spans[0].Context.TraceID)
+	assert.Equal([]Attribute{{Key: "height", Value: "1"}}, exporter.spans[1].Attributes)
+	assert.False(exporter.spans[1].End.Before(exporter.spans[1].Start))
+}
+
+func TestOTLPExporter(t *testing.T) {
+	assert := assert.New(t)
+
+	var mu sync.Mutex
+	requests := []otlpRequest{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(otlpTracesPath, r.URL.Path)
+		assert.Equal("application/json", r.Header.Get("Content-Type"))
+		body, _ := ioutil.ReadAll(r.Body)
+		req := otlpRequest{}
+		assert.Nil(json.Unmarshal(body, &req))
+		mu.Lock()
+		requests = append(requests, req)
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	exporter := NewOTLPExporter(server.URL+"/", "theta", "node1")
+	SetExporter(exporter)
+	defer SetExporter(nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	exporter.Start(ctx)
+
+	hash := common.HexToHash("0x5fa6d8f1b7e83e1f6dc3e3e6bd7bf33e4b5b8d0b71a7cbd58e4b3a0e5a3d1c27")
+	span := StartHashSpan(hash, "mempool.InsertTransaction").SetError(errors.New("invalid"))
+	span.Finish()
+
+	cancel()
+	exporter.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(1, len(requests))
+	rs := requests[0].ResourceSpans[0]
+	assert.Equal([]otlpKeyValue{newOTLPKeyValue("service.name", "theta"), newOTLPKeyValue("service.instance.id", "node1")}, rs.Resource.Attributes)
+	spans := rs.ScopeSpans[0].Spans
+	assert.Equal(1, len(spans))
+	ctxOfHash := ContextOf(hash)
+	assert.Equal(hex.EncodeToString(ctxOfHash.TraceID[:]), spans[0].TraceID)
+	assert.Equal(hex.EncodeToString(ctxOfHash.SpanID[:]), spans[0].ParentSpanID)
+	assert.Equal(hex.EncodeToString(span.Context.SpanID[:]), spans[0].SpanID)
+	assert.Equal("mempool.InsertTransaction", spans[0].Name)
+	assert.Equal(otlpStatus{Code: otlpStatusError, Message: "invalid"}, spans[0].Status)
+}
@@ -69,6 +69,22 @@ func BigToHash(b *big.Int) Hash { return BytesToHash(b.Bytes()) }
 // If b is larger than len(h), b will be cropped from the left.
 func HexToHash(s string) Hash { return BytesToHash(FromHex(s)) }
 
+// ParseHexHash parses a hex-encoded hash, with or without the 0x prefix. Unlike
+// HexToHash, it rejects the strings that are not exactly 32 bytes in hex.
+func ParseHexHash(s string) (Hash, error) {
+	unprefixed := s
+	if hasHexPrefix(s) {
+		unprefixed = s[2:]
+	}
+	if len(unprefixed) != 2*HashLength {
+		return Hash{}, fmt.Errorf("Invalid hash %v: expected %v hex digits, got %v", s, 2*HashLength, len(unprefixed))
+	}
+	if !isHex(unprefixed) {
+		return Hash{}, fmt.Errorf("Invalid hash %v: not a hex string", s)
+	}
+	return HexToHash(unprefixed), nil
+}
+
 // Bytes gets the byte representation of the underlying hash.
 func (h Hash) Bytes() []byte { return h[:] }
 
@@ -196,6 +212,49 @@ func IsHexAddress(s string) bool {
 	return len(s) == 2*AddressLength && isHex(s)
 }
 
+// ParseHexAddress parses a hex-encoded address, with or without the 0x prefix. A
+// mixed-case address must have a valid EIP55 checksum. An address without checksum,
+// i.e. all lowercase or all uppercase, is only accepted if allowNoChecksum is set.
+func ParseHexAddress(s string, allowNoChecksum bool) (Address, error) {
+	unprefixed := s
+	if hasHexPrefix(s) {
+		unprefixed = s[2:]
+	}
+	if len(unprefixed) != 2*AddressLength {
+		return Address{}, fmt.Errorf("Invalid address %v: expected %v hex digits, got %v", s, 2*AddressLength, len(unprefixed))
+	}
+	if !isHex(unprefixed) {
+		return Address{}, fmt.Errorf("Invalid address %v: not a hex string", s)
+	}
+	address := HexToAddress(unprefixed)
+
+	if "0x"+unprefixed == address.Hex() {
+		return address, nil
+	}
+	if !hasChecksum(unprefixed) && !allowNoChecksum {
+		return Address{}, fmt.Errorf("Address %v is not checksummed, expected %v", s, address.Hex())
+	}
+	if err := validateChecksum(unprefixed, address); err != nil {
+		return Address{}, err
+	}
+	return address, nil
+}
+
+// hasChecksum returns whether the unprefixed hex string of an address is mixed-case,
+// i.e. carries an EIP55 checksum.
+func hasChecksum(unprefixed string) bool {
+	return unprefixed != strings.ToLower(unprefixed) && unprefixed != strings.ToUpper(unprefixed)
+}
+
+// validateChecksum checks the EIP55 checksum of the hex string of the address, if it
+// has one.
+func validateChecksum(unprefixed string, address Address) error {
+	if hasChecksum(unprefixed) && "0x"+unprefixed != address.Hex() {
+		return fmt.Errorf("Invalid address checksum: 0x%v, expected %v", unprefixed, address.Hex())
+	}
+	return nil
+}
+
 // Bytes gets the string representation of the underlying address.
 func (a Address) Bytes() []byte { return a[:] }
 
@@ -248,19 +307,38 @@ func (a *Address) SetBytes(b []byte) {
 	copy(a[AddressLength-len(b):], b)
 }
 
-// MarshalText returns the hex representation of a.
+// MarshalText returns the EIP55 checksummed hex representation of a.
 func (a Address) MarshalText() ([]byte, error) {
-	return hexutil.Bytes(a[:]).MarshalText()
+	return []byte(a.Hex()), nil
 }
 
-// UnmarshalText parses a hash in hex syntax.
+// UnmarshalText parses an address in hex syntax. A mixed-case address must have a
+// valid EIP55 checksum.
 func (a *Address) UnmarshalText(input []byte) error {
-	return hexutil.UnmarshalFixedText("Address", input, a[:])
+	var address Address
+	if err := hexutil.UnmarshalFixedText("Address", input, address[:]); err != nil {
+		return err
+	}
+	if err := validateChecksum(string(input[2:]), address); err != nil {
+		return err
+	}
+	*a = address
+	return nil
 }
 
-// UnmarshalJSON parses a hash in hex syntax.
+// UnmarshalJSON parses an address in hex syntax. A mixed-case address must have a
+// valid EIP55 checksum.
 func (a *Address) UnmarshalJSON(input []byte) error {
-	return hexutil.UnmarshalFixedJSON(addressT, input, a[:])
+	var address Address
+	if err := hexutil.UnmarshalFixedJSON(addressT, input, address[:]); err != nil {
+		return err
+	}
+	// The input is a quoted and prefixed hex string once decoded
+	if err := validateChecksum(string(input[3:len(input)-1]), address); err != nil {
+		return err
+	}
+	*a = address
+	return nil
 }
 
 // Scan implements Scanner for database/sql.
@@ -388,4 +466,41 @@ const (
 
 	// ChannelIDPing indicates the channel for Ping/Pong messages between peers
 	ChannelIDPing
+
+	// ChannelIDGuardian indicates the channel for Guardian attestations
+	ChannelIDGuardian
+
+	// ChannelIDBlockTxs indicates the channel for the transactions a compact Proposal omits
+	ChannelIDBlockTxs
+
+	// ChannelIDCompactBlock indicates the channel for CompactBlock
+	ChannelIDCompactBlock
+
+	// ChannelIDStatus indicates the channel for the chain status of the peers
+	ChannelIDStatus
 )
+
+var channelIDNames = map[ChannelIDEnum]string{
+	ChannelIDCheckpoint:    "checkpoint",
+	ChannelIDHeader:        "header",
+	ChannelIDBlock:         "block",
+	ChannelIDProposal:      "proposal",
+	ChannelIDCC:            "cc",
+	ChannelIDVote:          "vote",
+	ChannelIDTransaction:   "transaction",
+	ChannelIDPeerDiscovery: "peer_discovery",
+	ChannelIDPing:          "ping",
+	ChannelIDGuardian:      "guardian",
+	ChannelIDBlockTxs:      "block_txs",
+	ChannelIDCompactBlock:  "compact_block",
+	ChannelIDStatus:        "status",
+}
+
+// Name returns the name of the channel used in the metrics, or its number if it
+// has no name.
+func (c ChannelIDEnum) Name() string {
+	if name, ok := channelIDNames[c]; ok {
+		return name
+	}
+	return fmt.Sprintf("%d", c)
+}
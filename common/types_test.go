@@ -103,6 +103,9 @@ func TestAddressUnmarshalJSON(t *testing.T) {
 		{`"0xG000000000000000000000000000000000000000"`, true, nil},
 		{`"0x0000000000000000000000000000000000000000"`, false, big.NewInt(0)},
 		{`"0x0000000000000000000000000000000000000010"`, false, big.NewInt(16)},
+		{`"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"`, false, HexToAddress("0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed").Big()},
+		{`"0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"`, false, HexToAddress("0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed").Big()},
+		{`"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD"`, true, nil}, // Invalid checksum
 	}
 	for i, test := range tests {
 		var v Address
@@ -145,6 +148,70 @@ func TestAddressHexChecksum(t *testing.T) {
 	}
 }
 
+func TestParseHexAddress(t *testing.T) {
+	var tests = []struct {
+		Input           string
+		AllowNoChecksum bool
+		Valid           bool
+	}{
+		{"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", false, true},
+		{"5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", false, true},
+		{"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD", false, false},
+		{"0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed", false, false},
+		{"0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed", true, true},
+		{"0x5AAEB6053F3E94C9B9A09F33669435E7EF1BEAED", true, true},
+		{"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD", true, false},
+		{"0x1111111111111111111112222222222223333323", false, true},
+		{"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeA", true, false},
+	}
+	for i, test := range tests {
+		address, err := ParseHexAddress(test.Input, test.AllowNoChecksum)
+		if (err == nil) != test.Valid {
+			t.Errorf("test #%d: expected valid %v, got error %v", i, test.Valid, err)
+		}
+		if err == nil && address != HexToAddress(test.Input) {
+			t.Errorf("test #%d: got address %v", i, address.Hex())
+		}
+	}
+}
+
+func TestParseHexHash(t *testing.T) {
+	var tests = []struct {
+		Input string
+		Valid bool
+	}{
+		{"0x" + strings.Repeat("ab", 32), true},
+		{strings.Repeat("AB", 32), true},
+		{"0X" + strings.Repeat("0", 64), true},
+		{"0x" + strings.Repeat("ab", 31), false},
+		{"0x" + strings.Repeat("ab", 33), false},
+		{"0x" + strings.Repeat("0", 63), false},
+		{"0x" + strings.Repeat("g", 64), false},
+		{"", false},
+		{"0x", false},
+	}
+	for i, test := range tests {
+		hash, err := ParseHexHash(test.Input)
+		if (err == nil) != test.Valid {
+			t.Errorf("test #%d: expected valid %v, got error %v", i, test.Valid, err)
+		}
+		if err == nil && hash != HexToHash(test.Input) {
+			t.Errorf("test #%d: got hash %v", i, hash.Hex())
+		}
+	}
+}
+
+func TestAddressMarshalJSONChecksum(t *testing.T) {
+	address := HexToAddress("0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed")
+	output, err := json.Marshal(address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(output) != `"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"` {
+		t.Errorf("got %s", output)
+	}
+}
+
 func BenchmarkAddressHex(b *testing.B) {
 	testAddr := HexToAddress("0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed")
 	for n := 0; n < b.N; n++ {
@@ -3,13 +3,23 @@ package util
 import (
 	"fmt"
 	"strings"
+	"sync"
 
 	log "github.com/sirupsen/logrus"
-	"github.com/spf13/viper"
 	"github.com/thetatoken/ukulele/common"
 )
 
-var logLevels map[string]string
+var (
+	logMu      sync.Mutex
+	logLevels  map[string]string
+	logModules = make(map[*log.Logger]string) // Loggers created so far, to their modules
+)
+
+func init() {
+	common.OnConfigReload(func(config *common.Config) {
+		SetLogLevels(config.Log.Levels)
+	})
+}
 
 const (
 	panicLevel = "panic"
@@ -39,11 +49,28 @@ func parseLogLevelConfig(config string) map[string]string {
 	return levels
 }
 
+// SetLogLevels changes the log levels of the loggers created so far, and of the ones
+// created later.
+func SetLogLevels(config string) {
+	logMu.Lock()
+	defer logMu.Unlock()
+
+	logLevels = parseLogLevelConfig(config)
+	log.Infof("Log settings: %v, %v", logLevels, config)
+	for logger, module := range logModules {
+		setLoggerLevel(logger, module)
+	}
+}
+
 // GetLoggerForModule returns the logger for given module.
 func GetLoggerForModule(module string) *log.Entry {
+	logMu.Lock()
+	defer logMu.Unlock()
+
 	if logLevels == nil {
-		logLevels = parseLogLevelConfig(viper.GetString(common.CfgLogLevels))
-		log.Infof("Log settings: %v, %v", logLevels, viper.GetString(common.CfgLogLevels))
+		config := common.GetConfig().Log.Levels
+		logLevels = parseLogLevelConfig(config)
+		log.Infof("Log settings: %v, %v", logLevels, config)
 	}
 	customFormatter := new(TextFormatter)
 	customFormatter.TimestampFormat = "2006-01-02 15:04:05"
@@ -53,7 +80,13 @@ func GetLoggerForModule(module string) *log.Entry {
 
 	logger := log.New()
 	logger.Formatter = customFormatter
+	setLoggerLevel(logger, module)
+	logModules[logger] = module
+
+	return logger.WithFields(log.Fields{"prefix": module})
+}
 
+func setLoggerLevel(logger *log.Logger, module string) {
 	level, ok := logLevels[module]
 	if !ok {
 		level = logLevels["*"]
@@ -72,6 +105,4 @@ func GetLoggerForModule(module string) *log.Entry {
 	} else if level == debugLevel {
 		logger.SetLevel(log.DebugLevel)
 	}
-
-	return logger.WithFields(log.Fields{"prefix": module})
 }
@@ -42,9 +42,23 @@ func LoadCheckpointLedgerState(checkpoint *core.Checkpoint, db database.Database
 	}
 }
 
+// DefaultGenesisValidators are the public keys of the validators in the default genesis checkpoint.
+var DefaultGenesisValidators = []string{
+	"042CA7FFB62122A220C72AA7CD87C252B21D72273275682386A099F0983C135659FF93E2E8756011074706E18113AA6529CD5833DD6463266980C6973895153C7C",
+	"048E8D53FD435265AD074597CC3E202F8E935CFB57925BB51316252027CB08767FB8099226414732543C4B5CBAA64B4EE8F173BA559258A0B5F633A0D11509E78B",
+	"0479188733862EBB3FE98A92315556D5214D908941CDC8D6C8700EEEAE5F90A6177A37E23B33B81B9FAC3A98EE2382AB24B1C92384FC151D07E36AC7209702D353",
+	"0455BDC5CF697F9519DF40E837BEE3E246C8D47C1B58CD1892FD3B0F780D2C09E718FF50A5929B86B8B88C7031164BDE553E285103F1B4DF668B44AFC907264C1C",
+}
+
 // WriteGenesisCheckpoint writes genesis checkpoint to file system.
 func WriteGenesisCheckpoint(filePath string) error {
-	genesis, err := generateGenesisCheckpoint()
+	return WriteGenesisCheckpointForValidators(filePath, DefaultGenesisValidators)
+}
+
+// WriteGenesisCheckpointForValidators writes the genesis checkpoint with the given validators,
+// specified as hex encoded public keys, to file system.
+func WriteGenesisCheckpointForValidators(filePath string, validators []string) error {
+	genesis, err := GenerateGenesisCheckpoint(validators)
 	if err != nil {
 		return err
 	}
@@ -60,16 +74,12 @@ func WriteCheckpoint(filePath string, checkpoint *core.Checkpoint) error {
 	return common.WriteFileAtomic(filePath, raw, 0600)
 }
 
-// generateGenesisCheckpoint generates the genesis checkpoint.
-func generateGenesisCheckpoint() (*core.Checkpoint, error) {
+// GenerateGenesisCheckpoint generates the genesis checkpoint with the given validators,
+// specified as hex encoded public keys, each funded with Theta and Gamma.
+func GenerateGenesisCheckpoint(validators []string) (*core.Checkpoint, error) {
 	genesis := &core.Checkpoint{}
 
-	genesis.Validators = []string{
-		"042CA7FFB62122A220C72AA7CD87C252B21D72273275682386A099F0983C135659FF93E2E8756011074706E18113AA6529CD5833DD6463266980C6973895153C7C",
-		"048E8D53FD435265AD074597CC3E202F8E935CFB57925BB51316252027CB08767FB8099226414732543C4B5CBAA64B4EE8F173BA559258A0B5F633A0D11509E78B",
-		"0479188733862EBB3FE98A92315556D5214D908941CDC8D6C8700EEEAE5F90A6177A37E23B33B81B9FAC3A98EE2382AB24B1C92384FC151D07E36AC7209702D353",
-		"0455BDC5CF697F9519DF40E837BEE3E246C8D47C1B58CD1892FD3B0F780D2C09E718FF50A5929B86B8B88C7031164BDE553E285103F1B4DF668B44AFC907264C1C",
-	}
+	genesis.Validators = validators
 
 	s := state.NewStoreView(0, common.Hash{}, backend.NewMemDatabase())
 	for _, v := range genesis.Validators {
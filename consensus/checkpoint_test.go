@@ -12,7 +12,7 @@ import (
 func TestGenerateGenesis(t *testing.T) {
 	assert := assert.New(t)
 
-	genesis, err := generateGenesisCheckpoint()
+	genesis, err := GenerateGenesisCheckpoint(DefaultGenesisValidators)
 	assert.Nil(err)
 
 	db := backend.NewMemDatabase()
@@ -0,0 +1,99 @@
+package consensus
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	json "github.com/gorilla/rpc/v2/json2"
+	log "github.com/sirupsen/logrus"
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/common/hexutil"
+	"github.com/thetatoken/ukulele/crypto/bls"
+)
+
+//
+// The cosigner RPC protocol. A cosigner holds a share of a threshold validator key
+// on a separate machine, and serves JSON-RPC 2.0 over HTTP POST to the threshold
+// signer of the validator node.
+//
+
+const (
+	MethodSignShare = "cosigner.SignShare"
+)
+
+type SignShareArgs struct {
+	Message hexutil.Bytes `json:"message"`
+}
+
+type SignShareResult struct {
+	Share *bls.SignatureShare `json:"share"`
+}
+
+// CosignerService serves the signature shares of a key share. It is registered
+// with a gorilla JSON-RPC server under the name "cosigner".
+type CosignerService struct {
+	keyShare *bls.KeyShare
+}
+
+// NewCosignerService creates an instance of CosignerService.
+func NewCosignerService(keyShare *bls.KeyShare) *CosignerService {
+	return &CosignerService{keyShare: keyShare}
+}
+
+// SignShare signs the message with the key share.
+func (s *CosignerService) SignShare(r *http.Request, args *SignShareArgs, result *SignShareResult) error {
+	if len(args.Message) == 0 {
+		return errors.New("Message is empty")
+	}
+	logger.WithFields(log.Fields{
+		"message": args.Message.String(),
+		"remote":  r.RemoteAddr,
+	}).Debug("Signing share")
+
+	result.Share = s.keyShare.Sign(common.Bytes(args.Message))
+	return nil
+}
+
+var _ PartialSigner = (*RemoteCosigner)(nil)
+
+// RemoteCosigner requests signature shares from a cosigner.
+type RemoteCosigner struct {
+	endpoint   string
+	httpClient *http.Client
+}
+
+// NewRemoteCosigner creates an instance of RemoteCosigner.
+func NewRemoteCosigner(endpoint string, timeout time.Duration) *RemoteCosigner {
+	return &RemoteCosigner{
+		endpoint:   endpoint,
+		httpClient: &http.Client{Timeout: timeout},
+	}
+}
+
+// SignShare requests the signature share of the message.
+func (c *RemoteCosigner) SignShare(msg common.Bytes) (*bls.SignatureShare, error) {
+	body, err := json.EncodeClientRequest(MethodSignShare, &SignShareArgs{Message: hexutil.Bytes(msg)})
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.httpClient.Post(c.endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Cosigner %v returned HTTP status %v", c.endpoint, resp.Status)
+	}
+
+	result := &SignShareResult{}
+	if err := json.DecodeClientResponse(resp.Body, result); err != nil {
+		return nil, fmt.Errorf("Cosigner %v returned error: %v", c.endpoint, err)
+	}
+	if result.Share == nil {
+		return nil, fmt.Errorf("Cosigner %v returned no signature share", c.endpoint)
+	}
+	return result.Share, nil
+}
@@ -0,0 +1,80 @@
+package consensus
+
+import (
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/core"
+)
+
+// maxDebugPendingHeights is the number of heights above the last finalized block whose
+// blocks are included in the debug state.
+const maxDebugPendingHeights = 100
+
+// DebugState is a snapshot of the internal state of the engine, to diagnose a node that
+// stops making progress.
+type DebugState struct {
+	ID                 string
+	Epoch              uint64
+	LastVoteHeight     uint64
+	LastVoteEpoch      uint64
+	Tip                BlockVotes
+	HighestCCBlock     BlockVotes
+	LastFinalizedBlock BlockVotes
+	PendingBlocks      []BlockVotes // Blocks of all the forks above the last finalized block
+	EpochVotes         int          // Votes received for the current epoch and later
+}
+
+// BlockVotes describes a block and the votes it received.
+type BlockVotes struct {
+	Hash     common.Hash
+	Height   uint64
+	Epoch    uint64
+	Status   core.BlockStatus
+	Votes    int
+	Majority bool // Whether the votes are from a majority of the validators of the current epoch
+}
+
+// GetDebugState returns a snapshot of the internal state of the engine.
+func (e *ConsensusEngine) GetDebugState() *DebugState {
+	epoch := e.state.GetEpoch()
+	validators := e.validatorManager.GetValidatorSetForEpoch(epoch)
+	describe := func(block *core.ExtendedBlock) BlockVotes {
+		if block == nil {
+			return BlockVotes{}
+		}
+		ret := BlockVotes{
+			Hash:   block.Hash(),
+			Height: block.Height,
+			Epoch:  block.Epoch,
+			Status: block.Status,
+		}
+		if votes, err := e.state.GetVoteSetByBlock(block.Hash()); err == nil {
+			ret.Votes = votes.Size()
+			ret.Majority = validators.HasMajority(votes)
+		}
+		return ret
+	}
+
+	ret := &DebugState{
+		ID:                 e.ID(),
+		Epoch:              epoch,
+		LastVoteHeight:     e.state.GetLastVoteHeight(),
+		Tip:                describe(e.state.GetTip()),
+		HighestCCBlock:     describe(e.state.GetHighestCCBlock()),
+		LastFinalizedBlock: describe(e.state.GetLastFinalizedBlock()),
+		PendingBlocks:      []BlockVotes{},
+	}
+	if lastVote := e.state.GetLastVote(); lastVote != nil {
+		ret.LastVoteEpoch = lastVote.Epoch
+	}
+	for _, block := range e.state.GetPendingBlocks(maxDebugPendingHeights) {
+		ret.PendingBlocks = append(ret.PendingBlocks, describe(block))
+	}
+	if epochVotes, err := e.state.GetEpochVotes(); err == nil {
+		for _, vote := range epochVotes.Votes() {
+			if vote.Epoch >= epoch {
+				ret.EpochVotes++
+			}
+		}
+	}
+	return ret
+}
@@ -2,21 +2,25 @@ package consensus
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math"
 	"math/big"
 	"math/rand"
+	"strconv"
 	"sync"
 	"time"
 
 	log "github.com/sirupsen/logrus"
-	"github.com/spf13/viper"
 	"github.com/thetatoken/ukulele/blockchain"
 	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/common/metrics"
+	"github.com/thetatoken/ukulele/common/trace"
 	"github.com/thetatoken/ukulele/common/util"
 	"github.com/thetatoken/ukulele/core"
 	"github.com/thetatoken/ukulele/crypto"
 	"github.com/thetatoken/ukulele/dispatcher"
+	"github.com/thetatoken/ukulele/ledger/types"
 	"github.com/thetatoken/ukulele/rlp"
 	"github.com/thetatoken/ukulele/store"
 )
@@ -30,11 +34,14 @@ type ConsensusEngine struct {
 	logger *log.Entry
 
 	privateKey *crypto.PrivateKey
+	signer     core.Signer
+	signGuard  *SignGuard // Double-sign protection of the votes, nil if none
 
 	chain            *blockchain.Chain
 	dispatcher       *dispatcher.Dispatcher
 	validatorManager core.ValidatorManager
 	ledger           core.Ledger
+	syncStatus       core.SyncStatusReporter
 
 	incoming        chan interface{}
 	finalizedBlocks chan *core.Block
@@ -52,6 +59,26 @@ type ConsensusEngine struct {
 	state *State
 
 	rand *rand.Rand
+
+	// Metrics
+	epochGauge             metrics.Gauge
+	epochTimeoutMeter      metrics.Meter
+	finalizedHeightGauge   metrics.Gauge
+	finalizationLagGauge   metrics.Gauge // Heights between the tip and the last finalized block
+	finalizationDelayTimer metrics.Timer // Time between the proposal and the finalization of blocks
+	finalityWatcher        *FinalityWatcher
+
+	// Tracing
+	voteCollections map[common.Hash]voteCollection // Blocks collecting votes, if tracing is enabled
+
+	// Dry run
+	dryRun bool // Whether the node validates and executes blocks without voting or proposing
+
+	// Guardian role
+	guardian           bool             // Whether the node attests checkpoints instead of proposing and voting
+	guardians          []common.Address // Guardians whose attestations are accepted
+	checkpointInterval uint64
+	lastAttestedHeight uint64
 }
 
 // NewConsensusEngine creates a instance of ConsensusEngine.
@@ -62,8 +89,8 @@ func NewConsensusEngine(privateKey *crypto.PrivateKey, db store.Store, chain *bl
 
 		privateKey: privateKey,
 
-		incoming:        make(chan interface{}, viper.GetInt(common.CfgConsensusMessageQueueSize)),
-		finalizedBlocks: make(chan *core.Block, viper.GetInt(common.CfgConsensusMessageQueueSize)),
+		incoming:        make(chan interface{}, common.GetConfig().Consensus.MessageQueueSize),
+		finalizedBlocks: make(chan *core.Block, common.GetConfig().Consensus.MessageQueueSize),
 
 		wg: &sync.WaitGroup{},
 
@@ -71,6 +98,27 @@ func NewConsensusEngine(privateKey *crypto.PrivateKey, db store.Store, chain *bl
 		state: NewState(db, chain),
 
 		validatorManager: validatorManager,
+
+		epochGauge:             metrics.GetOrRegisterGauge("consensus/epoch", nil),
+		epochTimeoutMeter:      metrics.GetOrRegisterMeter("consensus/epoch/timeouts", nil),
+		finalizedHeightGauge:   metrics.GetOrRegisterGauge("consensus/finalized/height", nil),
+		finalizationLagGauge:   metrics.GetOrRegisterGauge("consensus/finalization/lag", nil),
+		finalizationDelayTimer: metrics.GetOrRegisterTimer("consensus/finalization/delay", nil),
+		finalityWatcher:        newFinalityWatcherFromConfig(),
+
+		voteCollections: make(map[common.Hash]voteCollection),
+
+		dryRun: common.GetConfig().Consensus.DryRun,
+
+		guardian:           common.GetConfig().Guardian.Enabled,
+		guardians:          common.GetConfig().Guardian.Addresses,
+		checkpointInterval: common.GetConfig().Guardian.CheckpointInterval,
+	}
+	if privateKey != nil {
+		e.signer = core.NewPrivateKeySigner(privateKey)
+	}
+	if e.checkpointInterval == 0 {
+		e.checkpointInterval = 1
 	}
 
 	logger = util.GetLoggerForModule("consensus")
@@ -87,9 +135,39 @@ func (e *ConsensusEngine) SetLedger(ledger core.Ledger) {
 	e.ledger = ledger
 }
 
+// SetSigner sets the signer of the validator, e.g. a ThresholdSigner for a
+// validator key split across machines. The private key is used by default.
+func (e *ConsensusEngine) SetSigner(signer core.Signer) {
+	e.signer = signer
+}
+
+// SetSignGuard sets the double-sign protection of the votes of the validator. It must
+// be called before Start.
+func (e *ConsensusEngine) SetSignGuard(guard *SignGuard) {
+	e.signGuard = guard
+}
+
+// SetDryRun sets whether the node validates and executes the blocks without voting or
+// proposing, which consensus.dryRun sets by default. It must be called before Start.
+func (e *ConsensusEngine) SetDryRun(dryRun bool) {
+	e.dryRun = dryRun
+}
+
+// IsDryRun returns whether the node validates and executes the blocks without voting
+// or proposing.
+func (e *ConsensusEngine) IsDryRun() bool {
+	return e.dryRun
+}
+
+// SetSyncStatusReporter sets the reporter of the sync status of the node. The node does
+// not propose while it is behind the network.
+func (e *ConsensusEngine) SetSyncStatusReporter(syncStatus core.SyncStatusReporter) {
+	e.syncStatus = syncStatus
+}
+
 // ID returns the identifier of current node.
 func (e *ConsensusEngine) ID() string {
-	return e.privateKey.PublicKey().Address().Hex()
+	return e.signer.ID().Hex()
 }
 
 // PrivateKey returns the private key
@@ -97,6 +175,11 @@ func (e *ConsensusEngine) PrivateKey() *crypto.PrivateKey {
 	return e.privateKey
 }
 
+// Signer returns the signer of the validator
+func (e *ConsensusEngine) Signer() core.Signer {
+	return e.signer
+}
+
 // Chain return a pointer to the underlying chain store.
 func (e *ConsensusEngine) Chain() *blockchain.Chain {
 	return e.chain
@@ -118,18 +201,19 @@ func (e *ConsensusEngine) Start(ctx context.Context) {
 	e.ctx = c
 	e.cancel = cancel
 
-	// Verify configurations
-	if viper.GetInt(common.CfgConsensusMaxEpochLength) <= viper.GetInt(common.CfgConsensusMinProposalWait) {
-		log.WithFields(log.Fields{
-			"CfgConsensusMaxEpochLength":  viper.GetInt(common.CfgConsensusMaxEpochLength),
-			"CfgConsensusMinProposalWait": viper.GetInt(common.CfgConsensusMinProposalWait),
-		}).Fatal("Invalid configuration: max epoch length must be larger than minimal proposal wait")
-	}
-
 	// Set ledger state pointer to intial state.
 	lastCC := e.state.GetHighestCCBlock()
 	e.ledger.ResetState(lastCC.Height, lastCC.StateHash)
 
+	// Guardians attest the checkpoints finalized from now on
+	e.lastAttestedHeight = e.state.GetLastFinalizedBlock().Height
+
+	if e.dryRun {
+		e.logger.Info("Running in dry-run mode: the blocks are validated and executed, but never voted for or proposed")
+	}
+
+	e.finalityWatcher.Start(e.ctx)
+
 	e.wg.Add(1)
 	go e.mainLoop()
 }
@@ -142,6 +226,7 @@ func (e *ConsensusEngine) Stop() {
 // Wait blocks until all goroutines stop.
 func (e *ConsensusEngine) Wait() {
 	e.wg.Wait()
+	e.finalityWatcher.Wait()
 }
 
 func (e *ConsensusEngine) mainLoop() {
@@ -162,6 +247,7 @@ func (e *ConsensusEngine) mainLoop() {
 				}
 			case <-e.epochTimer.C:
 				e.logger.WithFields(log.Fields{"e.epoch": e.GetEpoch()}).Debug("Epoch timeout. Repeating epoch")
+				e.epochTimeoutMeter.Mark(1)
 				e.vote()
 				break Epoch
 			case <-e.proposalTimer.C:
@@ -172,17 +258,19 @@ func (e *ConsensusEngine) mainLoop() {
 }
 
 func (e *ConsensusEngine) enterEpoch() {
+	e.epochGauge.Update(int64(e.GetEpoch()))
+
 	// Reset timers.
 	if e.epochTimer != nil {
 		e.epochTimer.Stop()
 	}
-	e.epochTimer = time.NewTimer(time.Duration(viper.GetInt(common.CfgConsensusMaxEpochLength)) * time.Second)
+	e.epochTimer = time.NewTimer(time.Duration(common.GetConfig().Consensus.MaxEpochLength) * time.Second)
 
 	if e.proposalTimer != nil {
 		e.proposalTimer.Stop()
 	}
 	if e.shouldPropose(e.GetEpoch()) {
-		e.proposalTimer = time.NewTimer(time.Duration(viper.GetInt(common.CfgConsensusMinProposalWait)) * time.Second)
+		e.proposalTimer = time.NewTimer(time.Duration(common.GetConfig().Consensus.MinProposalWait) * time.Second)
 	} else {
 		e.proposalTimer = time.NewTimer(math.MaxInt64)
 		e.proposalTimer.Stop()
@@ -195,6 +283,7 @@ func (e *ConsensusEngine) GetChannelIDs() []common.ChannelIDEnum {
 		common.ChannelIDHeader,
 		common.ChannelIDBlock,
 		common.ChannelIDVote,
+		common.ChannelIDGuardian,
 	}
 }
 
@@ -205,9 +294,17 @@ func (e *ConsensusEngine) AddMessage(msg interface{}) {
 func (e *ConsensusEngine) processMessage(msg interface{}) (endEpoch bool) {
 	switch m := msg.(type) {
 	case core.Vote:
+		if res := m.Validate(); res.IsError() {
+			e.logger.WithFields(log.Fields{"vote": m, "error": res.Message}).Warn("Ignoring invalid vote")
+			return
+		}
 		return e.handleVote(m)
 	case *core.Block:
 		e.handleBlock(m)
+	case *core.ProposerReveal:
+		e.handleReveal(m)
+	case core.Attestation:
+		e.handleAttestation(m)
 	default:
 		log.Errorf("Unknown message type: %v", m)
 		panic(fmt.Sprintf("Unknown message type: %v", m))
@@ -219,13 +316,48 @@ func (e *ConsensusEngine) processMessage(msg interface{}) (endEpoch bool) {
 func (e *ConsensusEngine) handleBlock(block *core.Block) {
 	e.logger.WithFields(log.Fields{"block": block}).Debug("Received block")
 
-	parent, err := e.chain.FindBlock(block.Parent)
+	span := trace.StartHashSpan(block.Hash(), "consensus.HandleBlock").
+		SetAttribute("height", strconv.FormatUint(block.Height, 10))
+	defer span.Finish()
+
+	if res := block.Validate(); res.IsError() {
+		e.logger.WithFields(log.Fields{
+			"block": block.Hash().Hex(),
+			"error": res.Message,
+		}).Error("Invalid block header")
+		span.SetError(errors.New(res.Message))
+		return
+	}
+
+	// Only the blocks below the activation height, proposed before the TxHash was set, may
+	// have an empty TxHash
+	if err := block.ValidateTxHash(block.Txs, common.GetConfig().Consensus.TxHashActivationHeight); err != nil {
+		e.logger.WithFields(log.Fields{
+			"block":        block.Hash().Hex(),
+			"block.TxHash": block.TxHash.Hex(),
+			"error":        err,
+		}).Error("Block TxHash does not match its Txs")
+		span.SetError(err)
+		return
+	}
+
+	if block.GetValidatorSetHash() != core.ValidatorSetChangeHash(e.validatorManager, block.Epoch) {
+		e.logger.WithFields(log.Fields{
+			"block":                  block.Hash().Hex(),
+			"block.ValidatorSetHash": block.GetValidatorSetHash().Hex(),
+		}).Error("Block ValidatorSetHash does not match the validator set change")
+		span.SetError(errors.New("Block ValidatorSetHash does not match the validator set change"))
+		return
+	}
+
+	parent, err := e.chain.FindBlockHeader(block.Parent)
 	if err != nil {
 		e.logger.WithFields(log.Fields{
 			"error":  err,
 			"parent": block.Parent.Hex(),
 			"block":  block.Hash().Hex(),
 		}).Error("Failed to find parent block")
+		span.SetError(err)
 		return
 	}
 	result := e.ledger.ResetState(parent.Height, parent.StateHash)
@@ -234,9 +366,10 @@ func (e *ConsensusEngine) handleBlock(block *core.Block) {
 			"error":            result.Message,
 			"parent.StateHash": parent.StateHash,
 		}).Error("Failed to reset state to parent.StateHash")
+		span.SetError(errors.New(result.Message))
 		return
 	}
-	result = e.ledger.ApplyBlockTxs(block.Txs, block.StateHash)
+	result = e.ledger.ApplyBlockTxs(block.Epoch, block.Txs, block.StateHash)
 	if result.IsError() {
 		e.logger.WithFields(log.Fields{
 			"error":           result.String(),
@@ -244,6 +377,7 @@ func (e *ConsensusEngine) handleBlock(block *core.Block) {
 			"block":           block.Hash().Hex(),
 			"block.StateHash": block.StateHash.Hex(),
 		}).Error("Failed to apply block Txs")
+		span.SetError(errors.New(result.String()))
 		return
 	}
 
@@ -261,20 +395,45 @@ func (e *ConsensusEngine) handleBlock(block *core.Block) {
 }
 
 func (e *ConsensusEngine) vote() {
+	// Guardians and dry-run nodes validate blocks but do not vote
+	if e.guardian || e.dryRun {
+		return
+	}
+
+	// The node votes once in each epoch. On an epoch timeout, and after a restart in
+	// the same epoch, the recorded vote is sent again rather than signing another one
+	epoch := e.GetEpoch()
+	if lastVote := e.state.GetLastVote(); lastVote != nil && lastVote.Epoch >= epoch {
+		e.logger.WithFields(log.Fields{"vote": *lastVote}).Debug("Already voted in epoch, resending vote")
+		e.sendVote(*lastVote)
+		return
+	}
+
 	tip := e.state.GetTip()
 
 	var vote core.Vote
+	var err error
 	if e.state.GetLastVoteHeight() >= tip.Height {
 		e.logger.WithFields(log.Fields{
 			"lastVoteHeight": e.state.GetLastVoteHeight(),
 			"tip.Hash":       tip.Hash().Hex(),
 		}).Debug("Voting nil since already voted at height")
-		vote = e.createVote(common.Hash{})
+		vote, err = e.createVote(nil)
 	} else {
-		vote = e.createVote(tip.Hash())
-		e.state.SetLastVoteHeight(tip.Height)
+		vote, err = e.createVote(tip.BlockHeader)
 	}
+	if err != nil {
+		e.logger.WithFields(log.Fields{"error": err}).Error("Failed to sign vote")
+		return
+	}
+	if err := e.state.SetLastVote(vote); err != nil {
+		e.logger.WithFields(log.Fields{"vote": vote, "error": err}).Error("Failed to record vote, not sending it")
+		return
+	}
+	e.sendVote(vote)
+}
 
+func (e *ConsensusEngine) sendVote(vote core.Vote) {
 	e.logger.WithFields(log.Fields{"vote": vote}).Debug("Sending vote")
 
 	payload, err := rlp.EncodeToBytes(vote)
@@ -290,23 +449,51 @@ func (e *ConsensusEngine) vote() {
 	e.dispatcher.SendData([]string{}, voteMsg)
 }
 
-func (e *ConsensusEngine) createVote(block common.Hash) core.Vote {
+// createVote creates a signed vote for the block, or without block if nil. Signing
+// can fail with a threshold signer, when too few of the cosigners are reachable, or
+// when the vote could be a double sign.
+func (e *ConsensusEngine) createVote(block *core.BlockHeader) (core.Vote, error) {
 	vote := core.Vote{
-		Block: block,
-		ID:    e.privateKey.PublicKey().Address(),
+		ID:    e.signer.ID(),
 		Epoch: e.GetEpoch(),
 	}
-	sig, err := e.privateKey.Sign(vote.SignBytes())
+	if block != nil {
+		vote.Block = block.Hash()
+		vote.Height = block.Height
+	}
+	if e.signGuard != nil {
+		if err := e.signGuard.Guard(vote); err != nil {
+			return vote, err
+		}
+	}
+	var sig *crypto.Signature
+	var err error
+	if signer, ok := e.signer.(core.ValidatorSigner); ok {
+		sig, err = signer.SignVote(vote)
+	} else {
+		sig, err = e.signer.Sign(vote.SignBytes())
+	}
 	if err != nil {
-		e.logger.WithFields(log.Fields{"error": err}).Panic("Failed to sign vote")
+		return vote, err
 	}
 	vote.SetSignature(sig)
-	return vote
+	return vote, nil
 }
 
 func (e *ConsensusEngine) handleVote(vote core.Vote) (endEpoch bool) {
 	e.logger.WithFields(log.Fields{"vote": vote}).Debug("Received vote")
 
+	// The votes for a block not received yet are checked against the block when
+	// counted for it
+	if !vote.Block.IsEmpty() {
+		if block, err := e.Chain().FindBlock(vote.Block); err == nil {
+			if res := vote.ValidateForBlock(block.BlockHeader); res.IsError() {
+				e.logger.WithFields(log.Fields{"vote": vote, "error": res.Message}).Warn("Ignoring vote not matching its block")
+				return
+			}
+		}
+	}
+
 	validators := e.validatorManager.GetValidatorSetForEpoch(e.state.GetEpoch())
 	err := e.state.AddVote(&vote)
 	if err != nil {
@@ -334,7 +521,9 @@ func (e *ConsensusEngine) handleVote(vote core.Vote) (endEpoch bool) {
 				"nextEpoch":    nextEpoch,
 				"epochVoteSet": currentEpochVotes,
 			}).Debug("Majority votes for current epoch. Moving to new epoch")
-			e.state.SetEpoch(nextEpoch)
+			if err := e.state.SetEpoch(nextEpoch); err != nil {
+				e.logger.WithFields(log.Fields{"err": err}).Panic("Failed to enter next epoch")
+			}
 		}
 	}
 
@@ -359,11 +548,19 @@ func (e *ConsensusEngine) handleVote(vote core.Vote) (endEpoch bool) {
 		return
 	}
 
+	if trace.Enabled() {
+		if _, ok := e.voteCollections[vote.Block]; !ok {
+			e.voteCollections[vote.Block] = voteCollection{start: time.Now(), height: block.Height}
+		}
+	}
+
 	votes, err := e.state.GetVoteSetByBlock(vote.Block)
 	if err != nil {
 		e.logger.WithFields(log.Fields{"err": err}).Panic("Failed to retrieve vote set by block")
 	}
+	votes = votes.FilterByBlock(block.BlockHeader)
 	if validators.HasMajority(votes) {
+		e.traceVoteCollection(vote.Block, votes.Size())
 		e.processCCBlock(block)
 	}
 
@@ -378,26 +575,105 @@ func (e *ConsensusEngine) GetTip() *core.ExtendedBlock {
 	return e.state.GetTip()
 }
 
+// GetLastFinalizedBlock returns the last finalized block.
+func (e *ConsensusEngine) GetLastFinalizedBlock() *core.ExtendedBlock {
+	return e.state.GetLastFinalizedBlock()
+}
+
 // GetSummary returns a summary of consensus state.
 func (e *ConsensusEngine) GetSummary() *StateStub {
 	return e.state.GetSummary()
 }
 
+// GetCommitCertificate returns the votes received for the given block as a commit
+// certificate, nil if there is no vote for the block.
+func (e *ConsensusEngine) GetCommitCertificate(hash common.Hash) *core.CommitCertificate {
+	votes, err := e.state.GetVoteSetByBlock(hash)
+	if err != nil || votes.Size() == 0 {
+		return nil
+	}
+	return &core.CommitCertificate{Votes: votes, BlockHash: hash}
+}
+
+// GetFinalizedCommitCertificate returns the votes for the given block recorded when it
+// was finalized as a commit certificate, nil if the block was not finalized by the node.
+func (e *ConsensusEngine) GetFinalizedCommitCertificate(hash common.Hash) *core.CommitCertificate {
+	votes, err := e.state.GetFinalizedVotes(hash)
+	if err != nil {
+		return nil
+	}
+	return &core.CommitCertificate{Votes: votes, BlockHash: hash}
+}
+
+// snapshotFinalizedVotes records the votes for the block being finalized and for its
+// ancestors finalized along with it, so that the quorum behind them can be audited
+// later. Only the votes bound to the blocks are recorded, see Vote.ValidateForBlock.
+func (e *ConsensusEngine) snapshotFinalizedVotes(block *core.ExtendedBlock, lastFinalized *core.ExtendedBlock) {
+	for block != nil && block.Height > lastFinalized.Height {
+		votes, err := e.state.GetVoteSetByBlock(block.Hash())
+		if err != nil {
+			votes = core.NewVoteSet()
+		}
+		if err := e.state.SetFinalizedVotes(block.Hash(), votes.FilterByBlock(block.BlockHeader)); err != nil {
+			e.logger.WithFields(log.Fields{"error": err, "block": block.Hash().Hex()}).Warn("Failed to record the votes for finalized block")
+		}
+
+		parent, err := e.chain.FindBlock(block.Parent)
+		if err != nil {
+			break
+		}
+		block = parent
+	}
+}
+
 // FinalizedBlocks returns a channel that will be published with finalized blocks by the engine.
 func (e *ConsensusEngine) FinalizedBlocks() chan *core.Block {
 	return e.finalizedBlocks
 }
 
+// voteCollection records when a block received its first vote.
+type voteCollection struct {
+	start  time.Time
+	height uint64
+}
+
+// traceVoteCollection records the span from the first vote for the block to the
+// majority of votes.
+func (e *ConsensusEngine) traceVoteCollection(hash common.Hash, numVotes int) {
+	collection, ok := e.voteCollections[hash]
+	if !ok {
+		return
+	}
+	delete(e.voteCollections, hash)
+	trace.StartHashSpan(hash, "consensus.CollectVotes").
+		SetStart(collection.start).
+		SetAttribute("height", strconv.FormatUint(collection.height, 10)).
+		SetAttribute("votes", strconv.Itoa(numVotes)).
+		Finish()
+}
+
+// pruneVoteCollections forgets the blocks at or below the finalized height that never
+// received a majority of votes.
+func (e *ConsensusEngine) pruneVoteCollections(finalizedHeight uint64) {
+	for hash, collection := range e.voteCollections {
+		if collection.height <= finalizedHeight {
+			delete(e.voteCollections, hash)
+		}
+	}
+}
+
 func (e *ConsensusEngine) processCCBlock(ccBlock *core.ExtendedBlock) {
 	e.logger.WithFields(log.Fields{"ccBlock": ccBlock, "c.epoch": e.state.GetEpoch()}).Debug("Start processing ccBlock")
 	defer e.logger.WithFields(log.Fields{"ccBlock": ccBlock, "c.epoch": e.state.GetEpoch()}).Debug("Done processing ccBlock")
 
+	e.state.BeginWAL(WALOpCommit, ccBlock)
 	if ccBlock.Height > e.state.GetHighestCCBlock().Height {
 		e.logger.WithFields(log.Fields{"ccBlock": ccBlock}).Debug("Updating highestCCBlock since ccBlock.Height > e.highestCCBlock.Height")
 		e.state.SetHighestCCBlock(ccBlock)
 	}
 
 	e.chain.CommitBlock(ccBlock.Hash())
+	e.state.EndWAL()
 
 	parent, err := e.Chain().FindBlock(ccBlock.Parent)
 	if err != nil {
@@ -422,20 +698,43 @@ func (e *ConsensusEngine) finalizeBlock(block *core.ExtendedBlock) {
 	e.logger.WithFields(log.Fields{"block.Hash": block.Hash().Hex()}).Info("Finalizing block")
 	defer e.logger.WithFields(log.Fields{"block.Hash": block.Hash().Hex()}).Info("Done Finalized block")
 
+	span := trace.StartHashSpan(block.Hash(), "consensus.FinalizeBlock").
+		SetAttribute("height", strconv.FormatUint(block.Height, 10))
+	defer span.Finish()
+
+	e.snapshotFinalizedVotes(block, e.state.GetLastFinalizedBlock())
+
+	e.state.BeginWAL(WALOpFinalize, block)
 	e.state.SetLastFinalizedBlock(block)
-	e.ledger.FinalizeState(block.Height, block.StateHash)
+	ledgerSpan := trace.StartSpan(span.SpanContext(), "ledger.FinalizeState")
+	if res := e.ledger.FinalizeState(block.Height, block.StateHash); res.IsError() {
+		ledgerSpan.SetError(errors.New(res.Message))
+	}
+	ledgerSpan.Finish()
 
-	// Mark block and its ancestors as finalized.
-	e.chain.FinalizePreviousBlocks(block.Hash())
+	// Mark block and its ancestors as finalized, and force update TX index so that
+	// the index doesn't point to duplicate TX in fork.
+	e.chain.FinalizeBlock(block)
+	e.state.EndWAL()
 
-	// Force update TX index on block finalization so that the index doesn't point to
-	// duplicate TX in fork.
-	e.chain.AddTxsToIndex(block, true)
+	e.finalizedHeightGauge.Update(int64(block.Height))
+	e.pruneVoteCollections(block.Height)
+	if tip := e.state.GetTip(); tip != nil && tip.Height >= block.Height {
+		e.finalizationLagGauge.Update(int64(tip.Height - block.Height))
+	}
+	if block.Timestamp != nil {
+		e.finalizationDelayTimer.UpdateSince(time.Unix(block.Timestamp.Int64(), 0))
+	}
+	e.finalityWatcher.Finalized(block, time.Now())
 
 	select {
 	case e.finalizedBlocks <- block.Block:
 	default:
 	}
+
+	if e.guardian {
+		e.attestCheckpoints(block)
+	}
 }
 
 func (e *ConsensusEngine) randHex() []byte {
@@ -444,12 +743,73 @@ func (e *ConsensusEngine) randHex() []byte {
 	return bytes
 }
 
+func (e *ConsensusEngine) handleReveal(reveal *core.ProposerReveal) {
+	vm, ok := e.validatorManager.(*VRFValidatorManager)
+	if !ok {
+		return
+	}
+	if err := vm.Reveal(reveal); err != nil {
+		e.logger.WithFields(log.Fields{"reveal": reveal, "error": err}).Debug("Ignoring proposer reveal")
+	}
+}
+
+// reveal reveals the VRF output of the proposer of the epoch if proposers are
+// selected by VRF, and returns the recent reveals to attach to the proposal.
+func (e *ConsensusEngine) reveal(epoch uint64) []*core.ProposerReveal {
+	vm, ok := e.validatorManager.(*VRFValidatorManager)
+	if !ok {
+		return nil
+	}
+	// The VRF is evaluated with the validator key, which is not available to a
+	// threshold or remote signer
+	if e.privateKey == nil || e.privateKey.PublicKey().Address() != e.signer.ID() {
+		e.logger.Warn("Validator key is not available, skipping proposer reveal")
+		return vm.RecentReveals(epoch)
+	}
+	reveal, err := core.NewProposerReveal(epoch, e.privateKey, vm.VRFInput(epoch))
+	if err == nil {
+		err = vm.Reveal(reveal)
+	}
+	if err != nil {
+		e.logger.WithFields(log.Fields{"error": err}).Error("Failed to reveal proposer VRF output")
+	}
+	return vm.RecentReveals(epoch)
+}
+
+// isProposerTx returns whether the transaction is created by the proposer, rather than
+// reaped from the mempool, so that a compact proposal needs to embed it.
+func isProposerTx(tx common.Bytes) bool {
+	decoded, err := types.TxFromBytes(tx)
+	if err != nil {
+		return true
+	}
+	switch decoded.(type) {
+	case *types.CoinbaseTx, *types.SlashTx:
+		return true
+	default:
+		return false
+	}
+}
+
 func (e *ConsensusEngine) shouldPropose(epoch uint64) bool {
+	if e.guardian || e.dryRun {
+		return false
+	}
 	proposer := e.validatorManager.GetProposerForEpoch(epoch)
 	return proposer.ID().Hex() == e.ID()
 }
 
 func (e *ConsensusEngine) propose() {
+	start := time.Now()
+	if e.syncStatus != nil {
+		if status := e.syncStatus.GetSyncStatus(); status.Behind {
+			e.logger.WithFields(log.Fields{
+				"height":        status.Height,
+				"networkHeight": status.NetworkHeight,
+			}).Warn("Node is behind the network, skipping proposal")
+			return
+		}
+	}
 	tip := e.GetTip()
 	result := e.ledger.ResetState(tip.Height, tip.StateHash)
 	if result.IsError() {
@@ -464,17 +824,32 @@ func (e *ConsensusEngine) propose() {
 	block.Epoch = e.GetEpoch()
 	block.Parent = tip.Hash()
 	block.Height = tip.Height + 1
-	block.Proposer = e.privateKey.PublicKey().Address()
+	block.Proposer = e.signer.ID()
 	block.Timestamp = big.NewInt(time.Now().Unix())
+	block.SetValidatorSetHash(core.ValidatorSetChangeHash(e.validatorManager, block.Epoch))
 
-	newRoot, txs, result := e.ledger.ProposeBlockTxs()
+	newRoot, txs, result := e.ledger.ProposeBlockTxs(block.Epoch)
 	if result.IsError() {
 		e.logger.WithFields(log.Fields{"error": result.String()}).Error("Failed to collect Txs for block proposal")
 		return
 	}
 	block.Txs = txs
+	block.TxHash = core.CalculateTxHash(txs)
 	block.StateHash = newRoot
 
+	span := trace.StartHashSpan(block.Hash(), "consensus.Propose").
+		SetStart(start).
+		SetAttribute("height", strconv.FormatUint(block.Height, 10)).
+		SetAttribute("txs", strconv.Itoa(len(txs)))
+	defer span.Finish()
+	if trace.Enabled() {
+		for _, tx := range txs {
+			trace.StartHashSpan(crypto.Keccak256Hash(tx), "consensus.IncludeTx").
+				SetAttribute("block", block.Hash().Hex()).
+				Finish()
+		}
+	}
+
 	proposal := core.Proposal{
 		Block:      block,
 		ProposerID: common.HexToAddress(e.ID()),
@@ -492,8 +867,16 @@ func (e *ConsensusEngine) propose() {
 		e.logger.WithFields(log.Fields{"error": err}).Warn("Failed to load epoch votes")
 	}
 	proposal.Votes = lastCCVotes.Merge(epochVotes).UniqueVoterAndBlock()
-	selfVote := e.createVote(block.Hash())
+	selfVote, err := e.createVote(block.BlockHeader)
+	if err != nil {
+		e.logger.WithFields(log.Fields{"error": err}).Error("Failed to sign vote for proposal")
+		return
+	}
 	proposal.Votes.AddVote(selfVote)
+	proposal.Reveals = e.reveal(block.Epoch)
+	if common.GetConfig().Consensus.CompactProposals {
+		proposal.Compact(isProposerTx)
+	}
 
 	e.logger.WithFields(log.Fields{"proposal": proposal}).Info("Making proposal")
 
@@ -511,5 +894,8 @@ func (e *ConsensusEngine) propose() {
 		e.logger.WithFields(log.Fields{"err": err}).Error("Failed to add proposed block to chain")
 	}
 	e.handleBlock(block)
+
+	gossipSpan := trace.StartSpan(span.SpanContext(), "consensus.GossipProposal")
 	e.dispatcher.SendData([]string{}, proposalMsg)
+	gossipSpan.Finish()
 }
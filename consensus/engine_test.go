@@ -0,0 +1,74 @@
+package consensus
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thetatoken/ukulele/blockchain"
+	"github.com/thetatoken/ukulele/core"
+	"github.com/thetatoken/ukulele/crypto"
+	"github.com/thetatoken/ukulele/dispatcher"
+	"github.com/thetatoken/ukulele/p2p/simulation"
+	"github.com/thetatoken/ukulele/store/database/backend"
+	"github.com/thetatoken/ukulele/store/kvstore"
+)
+
+func TestEngineVotesOncePerEpochAcrossRestarts(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	core.ResetTestBlocks()
+
+	db := kvstore.NewKVStore(backend.NewMemDatabase())
+	chain := blockchain.CreateTestChainByBlocks([]string{
+		"A1", "A0",
+		"A2", "A1",
+	})
+	privKey, _, err := crypto.GenerateKeyPair()
+	require.Nil(err)
+
+	// The other validators do not vote, so the epochs only change when set by the test
+	validators := core.NewValidatorSet()
+	validators.AddValidator(core.NewValidator(privKey.PublicKey().ToBytes(), 1))
+	for i := 0; i < 3; i++ {
+		_, pubKey, err := crypto.GenerateKeyPair()
+		require.Nil(err)
+		validators.AddValidator(core.NewValidator(pubKey.ToBytes(), 1))
+	}
+	startNode := func() *ConsensusEngine {
+		net := simulation.NewSimnet().AddEndpoint("v1")
+		return NewConsensusEngine(privKey, db, chain, dispatcher.NewDispatcher(net), NewFixedValidatorManager(validators))
+	}
+
+	e1 := startNode()
+	require.Nil(e1.state.SetEpoch(5))
+	e1.vote()
+	vote := e1.state.GetLastVote()
+	require.NotNil(vote)
+	assert.Equal(uint64(5), vote.Epoch)
+	assert.Equal(core.GetTestBlock("A2").Hash(), vote.Block)
+
+	// Crash mid-epoch. The restarted node sends the same vote again on an epoch timeout
+	e2 := startNode()
+	assert.Equal(uint64(5), e2.GetEpoch())
+	e2.vote()
+	resent := e2.state.GetLastVote()
+	require.NotNil(resent)
+	assert.Equal(vote.Block, resent.Block)
+	assert.Equal(vote.Epoch, resent.Epoch)
+	assert.Equal(vote.Signature.ToBytes(), resent.Signature.ToBytes())
+
+	// Already voted at the height of the tip
+	require.Nil(e2.state.SetEpoch(6))
+	e2.vote()
+	vote = e2.state.GetLastVote()
+	require.NotNil(vote)
+	assert.Equal(uint64(6), vote.Epoch)
+	assert.True(vote.Block.IsEmpty())
+
+	e3 := startNode()
+	assert.Equal(uint64(6), e3.GetEpoch())
+	assert.Equal(core.GetTestBlock("A2").Height, e3.state.GetLastVoteHeight())
+	assert.NotNil(e3.state.SetEpoch(5))
+	assert.Equal(uint64(6), e3.GetDebugState().LastVoteEpoch)
+}
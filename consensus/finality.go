@@ -0,0 +1,241 @@
+package consensus
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/common/metrics"
+	"github.com/thetatoken/ukulele/core"
+)
+
+// finalityCheckInterval is how often the FinalityWatcher checks for a stall.
+const finalityCheckInterval = time.Second
+
+// Events of the stall alarms
+const (
+	// StallAlarmRaised is raised when no block is finalized for the timeout
+	StallAlarmRaised = "stalled"
+	// StallAlarmResolved is raised when a block is finalized again after a stall
+	StallAlarmResolved = "resumed"
+)
+
+// StallAlarm reports that no block has been finalized for too long, the earliest sign
+// of a failure of the consensus, or that the finalization resumed.
+type StallAlarm struct {
+	Event               string            `json:"event"`
+	LastFinalizedHeight common.JSONUint64 `json:"last_finalized_height"`
+	LastFinalizedBlock  common.Hash       `json:"last_finalized_block"`
+	LastFinalizedAt     time.Time         `json:"last_finalized_at"`
+	StalledSeconds      common.JSONUint64 `json:"stalled_seconds"` // Time without finalization when raised
+}
+
+// StallAlarmHook is notified of the stall alarms.
+type StallAlarmHook interface {
+	Notify(alarm StallAlarm) error
+}
+
+// LogStallAlarmHook logs the stall alarms.
+type LogStallAlarmHook struct{}
+
+func (h LogStallAlarmHook) Notify(alarm StallAlarm) error {
+	fields := log.Fields{
+		"lastFinalizedHeight": alarm.LastFinalizedHeight,
+		"lastFinalizedBlock":  alarm.LastFinalizedBlock.Hex(),
+		"stalledSeconds":      alarm.StalledSeconds,
+	}
+	if alarm.Event == StallAlarmRaised {
+		logger.WithFields(fields).Error("No block finalized within the stall alarm timeout")
+	} else {
+		logger.WithFields(fields).Info("Block finalized again after a stall")
+	}
+	return nil
+}
+
+// WebhookStallAlarmHook POSTs the stall alarms in JSON to a URL.
+type WebhookStallAlarmHook struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookStallAlarmHook creates an instance of WebhookStallAlarmHook.
+func NewWebhookStallAlarmHook(url string, timeout time.Duration) *WebhookStallAlarmHook {
+	return &WebhookStallAlarmHook{
+		url:    url,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+func (h *WebhookStallAlarmHook) Notify(alarm StallAlarm) error {
+	payload, err := json.Marshal(alarm)
+	if err != nil {
+		return err
+	}
+	resp, err := h.client.Post(h.url, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("Stall alarm webhook responded with status %v", resp.Status)
+	}
+	return nil
+}
+
+// FinalityWatcher records the time between consecutive finalized blocks, and raises a
+// stall alarm to its hooks when no block is finalized for the timeout. The alarm is
+// raised once per stall, and resolved when a block is finalized again.
+type FinalityWatcher struct {
+	timeout time.Duration // 0 if the alarm is disabled
+	hooks   []StallAlarmHook
+
+	mu              *sync.Mutex
+	hasFinalized    bool // Whether a block was finalized since the start
+	lastBlock       common.Hash
+	lastHeight      uint64
+	lastFinalizedAt time.Time
+	stalled         bool
+	stalledAt       time.Time // lastFinalizedAt when the alarm was raised
+
+	// Metrics
+	intervalTimer metrics.Timer // Time between consecutive finalized blocks
+	stalledGauge  metrics.Gauge // 1 while the stall alarm is raised
+
+	// Life cycle
+	wg     *sync.WaitGroup
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewFinalityWatcher creates an instance of FinalityWatcher. A timeout of 0 disables
+// the stall alarm, while the time between the finalized blocks is still recorded.
+func NewFinalityWatcher(timeout time.Duration, hooks ...StallAlarmHook) *FinalityWatcher {
+	return &FinalityWatcher{
+		timeout:         timeout,
+		hooks:           hooks,
+		mu:              &sync.Mutex{},
+		lastFinalizedAt: time.Now(),
+		intervalTimer:   metrics.GetOrRegisterTimer("consensus/finalization/interval", nil),
+		stalledGauge:    metrics.GetOrRegisterGauge("consensus/finalization/stalled", nil),
+		wg:              &sync.WaitGroup{},
+	}
+}
+
+// newFinalityWatcherFromConfig creates the FinalityWatcher of the consensus config,
+// which logs the stall alarms and POSTs them to the webhook if set.
+func newFinalityWatcherFromConfig() *FinalityWatcher {
+	config := common.GetConfig()
+	hooks := []StallAlarmHook{LogStallAlarmHook{}}
+	if config.Consensus.StallAlarmWebhook != "" {
+		timeout := time.Duration(config.Webhook.Timeout) * time.Second
+		hooks = append(hooks, NewWebhookStallAlarmHook(config.Consensus.StallAlarmWebhook, timeout))
+	}
+	return NewFinalityWatcher(time.Duration(config.Consensus.StallAlarmTimeout)*time.Second, hooks...)
+}
+
+// Start starts checking for stalls. The time without finalization is counted from
+// the start if no block has been finalized yet.
+func (w *FinalityWatcher) Start(ctx context.Context) {
+	c, cancel := context.WithCancel(ctx)
+	w.ctx = c
+	w.cancel = cancel
+
+	w.mu.Lock()
+	if !w.hasFinalized {
+		w.lastFinalizedAt = time.Now()
+	}
+	w.mu.Unlock()
+
+	if w.timeout == 0 {
+		return
+	}
+	w.wg.Add(1)
+	go w.mainLoop()
+}
+
+// Stop notifies the watcher to stop without blocking.
+func (w *FinalityWatcher) Stop() {
+	w.cancel()
+}
+
+// Wait blocks until the watcher stops.
+func (w *FinalityWatcher) Wait() {
+	w.wg.Wait()
+}
+
+func (w *FinalityWatcher) mainLoop() {
+	defer w.wg.Done()
+
+	ticker := time.NewTicker(finalityCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.ctx.Done():
+			return
+		case now := <-ticker.C:
+			w.Check(now)
+		}
+	}
+}
+
+// Finalized records the finalization of the block.
+func (w *FinalityWatcher) Finalized(block *core.ExtendedBlock, at time.Time) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.hasFinalized && at.After(w.lastFinalizedAt) {
+		w.intervalTimer.Update(at.Sub(w.lastFinalizedAt))
+	}
+	w.hasFinalized = true
+	w.lastBlock = block.Hash()
+	w.lastHeight = block.Height
+	w.lastFinalizedAt = at
+}
+
+// Check raises the stall alarm if no block has been finalized for the timeout, or
+// resolves it if a block has been finalized since it was raised. The hooks are
+// notified in the calling goroutine.
+func (w *FinalityWatcher) Check(now time.Time) {
+	if w.timeout == 0 {
+		return
+	}
+
+	w.mu.Lock()
+	alarm := StallAlarm{
+		LastFinalizedHeight: common.JSONUint64(w.lastHeight),
+		LastFinalizedBlock:  w.lastBlock,
+		LastFinalizedAt:     w.lastFinalizedAt,
+	}
+	switch {
+	case w.stalled && w.lastFinalizedAt.After(w.stalledAt):
+		w.stalled = false
+		alarm.Event = StallAlarmResolved
+		alarm.StalledSeconds = common.JSONUint64(w.lastFinalizedAt.Sub(w.stalledAt) / time.Second)
+		w.stalledGauge.Update(0)
+	case !w.stalled && now.Sub(w.lastFinalizedAt) >= w.timeout:
+		w.stalled = true
+		w.stalledAt = w.lastFinalizedAt
+		alarm.Event = StallAlarmRaised
+		alarm.StalledSeconds = common.JSONUint64(now.Sub(w.lastFinalizedAt) / time.Second)
+		w.stalledGauge.Update(1)
+	}
+	w.mu.Unlock()
+
+	if alarm.Event == "" {
+		return
+	}
+	for _, hook := range w.hooks {
+		if err := hook.Notify(alarm); err != nil {
+			logger.WithFields(log.Fields{"event": alarm.Event, "error": err}).Warn("Failed to notify stall alarm")
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package consensus
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thetatoken/ukulele/blockchain"
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/core"
+)
+
+type recordingStallAlarmHook struct {
+	alarms []StallAlarm
+}
+
+func (h *recordingStallAlarmHook) Notify(alarm StallAlarm) error {
+	h.alarms = append(h.alarms, alarm)
+	return nil
+}
+
+func TestFinalityWatcherStallAlarm(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	core.ResetTestBlocks()
+
+	hook := &recordingStallAlarmHook{}
+	w := NewFinalityWatcher(10*time.Second, hook)
+
+	t0 := time.Now()
+	chain := blockchain.CreateTestChainByBlocks([]string{
+		"A1", "A0",
+		"A2", "A1",
+	})
+	block1, err := chain.FindBlock(core.GetTestBlock("A1").Hash())
+	require.Nil(err)
+	block2, err := chain.FindBlock(core.GetTestBlock("A2").Hash())
+	require.Nil(err)
+	w.Finalized(block1, t0)
+
+	w.Check(t0.Add(9 * time.Second))
+	assert.Equal(0, len(hook.alarms))
+
+	// Raised once per stall
+	w.Check(t0.Add(10 * time.Second))
+	w.Check(t0.Add(30 * time.Second))
+	require.Equal(1, len(hook.alarms))
+	assert.Equal(StallAlarmRaised, hook.alarms[0].Event)
+	assert.Equal(block1.Hash(), hook.alarms[0].LastFinalizedBlock)
+	assert.Equal(common.JSONUint64(block1.Height), hook.alarms[0].LastFinalizedHeight)
+	assert.Equal(common.JSONUint64(10), hook.alarms[0].StalledSeconds)
+
+	w.Finalized(block2, t0.Add(40*time.Second))
+	w.Check(t0.Add(41 * time.Second))
+	require.Equal(2, len(hook.alarms))
+	assert.Equal(StallAlarmResolved, hook.alarms[1].Event)
+	assert.Equal(block2.Hash(), hook.alarms[1].LastFinalizedBlock)
+	assert.Equal(common.JSONUint64(40), hook.alarms[1].StalledSeconds)
+
+	// A new stall raises the alarm again
+	w.Check(t0.Add(50 * time.Second))
+	require.Equal(3, len(hook.alarms))
+	assert.Equal(StallAlarmRaised, hook.alarms[2].Event)
+
+	// Disabled
+	hook = &recordingStallAlarmHook{}
+	w = NewFinalityWatcher(0, hook)
+	w.Finalized(block1, t0)
+	w.Check(t0.Add(time.Hour))
+	assert.Equal(0, len(hook.alarms))
+}
+
+func TestWebhookStallAlarmHook(t *testing.T) {
+	assert := assert.New(t)
+
+	received := make(chan StallAlarm, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		alarm := StallAlarm{}
+		if err := json.NewDecoder(r.Body).Decode(&alarm); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		received <- alarm
+	}))
+	defer server.Close()
+
+	hook := NewWebhookStallAlarmHook(server.URL, time.Second)
+	alarm := StallAlarm{
+		Event:               StallAlarmRaised,
+		LastFinalizedHeight: 12,
+		LastFinalizedBlock:  common.HexToHash("0x1234"),
+		StalledSeconds:      120,
+	}
+	assert.Nil(hook.Notify(alarm))
+	got := <-received
+	assert.Equal(alarm.Event, got.Event)
+	assert.Equal(alarm.LastFinalizedHeight, got.LastFinalizedHeight)
+	assert.Equal(alarm.LastFinalizedBlock, got.LastFinalizedBlock)
+	assert.Equal(alarm.StalledSeconds, got.StalledSeconds)
+
+	hook = NewWebhookStallAlarmHook(server.URL+"/missing", time.Second)
+	server.Config.Handler = http.NotFoundHandler()
+	assert.NotNil(hook.Notify(alarm))
+}
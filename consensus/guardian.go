@@ -0,0 +1,148 @@
+package consensus
+
+import (
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/core"
+	"github.com/thetatoken/ukulele/dispatcher"
+	"github.com/thetatoken/ukulele/rlp"
+)
+
+// CheckpointHeight returns the height of the first checkpoint at or above the given
+// height.
+func CheckpointHeight(height uint64, interval uint64) uint64 {
+	if interval == 0 {
+		interval = 1
+	}
+	checkpoint := (height + interval - 1) / interval * interval
+	if checkpoint == 0 {
+		checkpoint = interval
+	}
+	return checkpoint
+}
+
+// IsGuardian returns whether the node runs as a guardian.
+func (e *ConsensusEngine) IsGuardian() bool {
+	return e.guardian
+}
+
+// checkCommitCertificate checks that the votes received for a finalized block are
+// signed by validators with a majority of the stake.
+func (e *ConsensusEngine) checkCommitCertificate(block *core.ExtendedBlock) error {
+	votes, err := e.state.GetVoteSetByBlock(block.Hash())
+	if err != nil {
+		return errors.Wrap(err, "Failed to load votes")
+	}
+	if res := votes.Validate(); res.IsError() {
+		return errors.Errorf("Invalid commit certificate: %v", res.Message)
+	}
+	validators := e.validatorManager.GetValidatorSetForEpoch(block.Epoch)
+	if !validators.HasMajority(votes.FilterByBlock(block.BlockHeader).UniqueVoter()) {
+		return errors.New("Commit certificate has no majority of the validators")
+	}
+	return nil
+}
+
+// attestCheckpoints checks the commit certificate of a newly finalized block, and
+// attests the checkpoints finalized with it.
+func (e *ConsensusEngine) attestCheckpoints(block *core.ExtendedBlock) {
+	if err := e.checkCommitCertificate(block); err != nil {
+		e.logger.WithFields(log.Fields{"block": block.Hash().Hex(), "error": err}).Error("Not attesting checkpoints of finalized block")
+		return
+	}
+
+	for height := CheckpointHeight(e.lastAttestedHeight+1, e.checkpointInterval); height <= block.Height; height += e.checkpointInterval {
+		checkpoint := e.findFinalizedBlockByHeight(height)
+		if checkpoint == nil {
+			e.logger.WithFields(log.Fields{"height": height}).Error("Failed to find finalized checkpoint")
+			continue
+		}
+		attestation := core.Attestation{
+			Block:  checkpoint.Hash(),
+			Height: checkpoint.Height,
+			ID:     e.signer.ID(),
+		}
+		sig, err := e.signer.Sign(attestation.SignBytes())
+		if err != nil {
+			e.logger.WithFields(log.Fields{"error": err}).Error("Failed to sign attestation")
+			return
+		}
+		attestation.SetSignature(sig)
+
+		e.logger.WithFields(log.Fields{"attestation": attestation}).Debug("Attesting checkpoint")
+		e.handleAttestation(attestation)
+	}
+	e.lastAttestedHeight = block.Height
+}
+
+// handleAttestation indexes the attestation of a guardian, and gossips it to the
+// peers if it is new.
+func (e *ConsensusEngine) handleAttestation(attestation core.Attestation) {
+	if !e.isGuardian(attestation.ID) {
+		e.logger.WithFields(log.Fields{"attestation": attestation}).Debug("Ignoring attestation of unknown guardian")
+		return
+	}
+	if res := attestation.Validate(); res.IsError() {
+		e.logger.WithFields(log.Fields{"attestation": attestation, "error": res.Message}).Warn("Ignoring invalid attestation")
+		return
+	}
+	if !e.chain.AddAttestationToIndex(attestation) {
+		return
+	}
+
+	payload, err := rlp.EncodeToBytes(attestation)
+	if err != nil {
+		e.logger.WithFields(log.Fields{"attestation": attestation}).Error("Failed to encode attestation")
+		return
+	}
+	e.dispatcher.SendData([]string{}, dispatcher.DataResponse{
+		ChannelID: common.ChannelIDGuardian,
+		Payload:   payload,
+	})
+}
+
+func (e *ConsensusEngine) isGuardian(addr common.Address) bool {
+	for _, guardian := range e.guardians {
+		if guardian == addr {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *ConsensusEngine) findFinalizedBlockByHeight(height uint64) *core.ExtendedBlock {
+	for _, block := range e.chain.FindBlocksByHeight(height) {
+		if block.Status == core.BlockStatusFinalized {
+			return block
+		}
+	}
+	return nil
+}
+
+// GuardianConfirmation describes whether the block at a height is finalized and
+// confirmed by the guardians.
+type GuardianConfirmation struct {
+	Block      *core.ExtendedBlock // Finalized block at the height, nil if none
+	Checkpoint *core.ExtendedBlock // Finalized checkpoint at or above the height, nil if none
+	Guardians  []common.Address    // Guardians that attested the checkpoint
+	Confirmed  bool                // Whether more than two thirds of the guardians attested the checkpoint
+}
+
+// GetGuardianConfirmation returns whether the block at the given height is finalized,
+// and attested by the guardians through the checkpoint at or above it.
+func (e *ConsensusEngine) GetGuardianConfirmation(height uint64) *GuardianConfirmation {
+	ret := &GuardianConfirmation{Guardians: []common.Address{}}
+	ret.Block = e.findFinalizedBlockByHeight(height)
+	if ret.Block == nil {
+		return ret
+	}
+	ret.Checkpoint = e.findFinalizedBlockByHeight(CheckpointHeight(height, e.checkpointInterval))
+	if ret.Checkpoint == nil {
+		return ret
+	}
+	attestations := e.chain.FindAttestationsByHash(ret.Checkpoint.Hash())
+	ret.Guardians = attestations.Guardians(ret.Checkpoint.Hash(), e.guardians)
+	ret.Confirmed = len(e.guardians) > 0 && len(ret.Guardians)*3 > len(e.guardians)*2
+	return ret
+}
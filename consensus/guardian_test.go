@@ -0,0 +1,18 @@
+package consensus
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckpointHeight(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal(uint64(10), CheckpointHeight(0, 10))
+	assert.Equal(uint64(10), CheckpointHeight(1, 10))
+	assert.Equal(uint64(10), CheckpointHeight(10, 10))
+	assert.Equal(uint64(20), CheckpointHeight(11, 10))
+	assert.Equal(uint64(7), CheckpointHeight(7, 1))
+	assert.Equal(uint64(7), CheckpointHeight(7, 0))
+}
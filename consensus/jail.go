@@ -0,0 +1,73 @@
+package consensus
+
+import (
+	"sync"
+
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/core"
+)
+
+// validatorJail keeps the epochs during which the validators jailed by slashing are
+// not selected as proposers. It is embedded in the validator managers.
+type validatorJail struct {
+	mu    *sync.Mutex
+	terms map[common.Address]jailTerm
+}
+
+type jailTerm struct {
+	jailedEpoch uint64
+	untilEpoch  uint64
+}
+
+func newValidatorJail() *validatorJail {
+	return &validatorJail{
+		mu:    &sync.Mutex{},
+		terms: make(map[common.Address]jailTerm),
+	}
+}
+
+// Jail implements ValidatorJailer interface. A later jail of the same validator
+// replaces the previous one.
+func (j *validatorJail) Jail(validator common.Address, jailedEpoch uint64, untilEpoch uint64) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.terms[validator] = jailTerm{jailedEpoch: jailedEpoch, untilEpoch: untilEpoch}
+}
+
+func (j *validatorJail) isJailed(validator common.Address, epoch uint64) bool {
+	term, ok := j.terms[validator]
+	return ok && epoch > term.jailedEpoch && epoch <= term.untilEpoch
+}
+
+// candidates returns the validators that can be selected as the proposer of the
+// epoch, i.e. the ones that are not jailed.
+func (j *validatorJail) candidates(validators *core.ValidatorSet, epoch uint64) []core.Validator {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return selectCandidates(validators, epoch, j.isJailed)
+}
+
+// selectCandidates returns the validators for which isJailed returns false, or all of
+// them if they are all jailed so that the chain does not halt.
+func selectCandidates(validators *core.ValidatorSet, epoch uint64, isJailed func(common.Address, uint64) bool) []core.Validator {
+	candidates := []core.Validator{}
+	for _, v := range validators.Validators() {
+		if !isJailed(v.ID(), epoch) {
+			candidates = append(candidates, v)
+		}
+	}
+	if len(candidates) == 0 {
+		return validators.Validators()
+	}
+	return candidates
+}
+
+func totalStake(validators []core.Validator) uint64 {
+	ret := uint64(0)
+	for _, v := range validators {
+		ret += v.Stake()
+	}
+	return ret
+}
@@ -0,0 +1,149 @@
+package consensus
+
+import (
+	"fmt"
+
+	"github.com/thetatoken/ukulele/blockchain"
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/core"
+	"github.com/thetatoken/ukulele/store"
+)
+
+// Kinds of repairs made by Recover
+const (
+	// RepairInterruptedUpdate is an update of the consensus state and the chain
+	// interrupted by a crash, found in the WAL
+	RepairInterruptedUpdate = "interrupted_update"
+	// RepairPartialImport is a child of a block that is not in the DB
+	RepairPartialImport = "partial_import"
+	// RepairLastFinalizedBlock is a last finalized block rolled back
+	RepairLastFinalizedBlock = "last_finalized_block"
+	// RepairHighestCCBlock is a highest CC block rolled back
+	RepairHighestCCBlock = "highest_cc_block"
+)
+
+// Repair is a problem fixed by Recover.
+type Repair struct {
+	Kind   string
+	Block  common.Hash
+	Height uint64
+	Detail string
+}
+
+func (r Repair) String() string {
+	return fmt.Sprintf("%v: block %v at height %v: %v", r.Kind, r.Block.Hex(), r.Height, r.Detail)
+}
+
+// RecoveryReport is the result of Recover.
+type RecoveryReport struct {
+	LastFinalizedBlock *core.ExtendedBlock
+	HighestCCBlock     *core.ExtendedBlock
+	Repairs            []Repair
+}
+
+// Recover checks the consensus state stored in db against the chain before the
+// consensus engine loads it, and rolls it back to the last consistent finalized block
+// if the node crashed while updating them. The last finalized block must be finalized
+// in the chain, and the highest CC block must be committed and descend from it, and
+// hasState must find their state roots. The children that are not in the DB are also
+// removed from the blocks above the last finalized block. The last vote height is kept,
+// so that the node does not vote again at the heights it voted at before the crash.
+func Recover(db store.Store, chain *blockchain.Chain, hasState func(root common.Hash) bool) (*RecoveryReport, error) {
+	report := &RecoveryReport{
+		LastFinalizedBlock: chain.Root,
+		HighestCCBlock:     chain.Root,
+	}
+	addRepair := func(kind string, hash common.Hash, height uint64, format string, args ...interface{}) {
+		report.Repairs = append(report.Repairs, Repair{
+			Kind:   kind,
+			Block:  hash,
+			Height: height,
+			Detail: fmt.Sprintf(format, args...),
+		})
+	}
+	if hasState == nil {
+		hasState = func(root common.Hash) bool { return true }
+	}
+
+	stub := &StateStub{}
+	err := db.Get([]byte(DBStateStubKey), stub)
+	if err != nil && err != store.ErrKeyNotFound {
+		return nil, err
+	}
+	if stub.Root != chain.Root.Hash() {
+		// No state yet, or a state on another root, which is ignored
+		return report, nil
+	}
+
+	wal := &WALEntry{}
+	err = db.Get([]byte(DBWALKey), wal)
+	if err != nil && err != store.ErrKeyNotFound {
+		return nil, err
+	}
+	hasWAL := err == nil
+	if hasWAL {
+		addRepair(RepairInterruptedUpdate, wal.Block, wal.Height, "the node crashed during the %v of the block", wal.Op)
+	}
+
+	// Roll the last finalized block back to its latest ancestor finalized in the chain
+	finalized := chain.Root
+	hash := stub.LastFinalizedBlock
+	for !hash.IsEmpty() && hash != chain.Root.Hash() {
+		block, err := chain.FindBlock(hash)
+		if err != nil {
+			addRepair(RepairLastFinalizedBlock, hash, 0, "block is not in the DB, rolled back to the root")
+			break
+		}
+		if block.Status != core.BlockStatusFinalized {
+			addRepair(RepairLastFinalizedBlock, hash, block.Height, "block is not finalized in the chain, rolled back to its parent")
+		} else if !hasState(block.StateHash) {
+			addRepair(RepairLastFinalizedBlock, hash, block.Height, "state root %v is not in the DB, rolled back to its parent", block.StateHash.Hex())
+		} else {
+			finalized = block
+			break
+		}
+		hash = block.Parent
+	}
+	report.LastFinalizedBlock = finalized
+	report.HighestCCBlock = finalized
+
+	// Roll the highest CC block back to the last finalized block if it is inconsistent
+	if cc := stub.HighestCCBlock; !cc.IsEmpty() && cc != finalized.Hash() {
+		block, err := chain.FindBlock(cc)
+		switch {
+		case err != nil:
+			addRepair(RepairHighestCCBlock, cc, 0, "block is not in the DB, rolled back to the last finalized block")
+		case block.Status != core.BlockStatusCommitted && block.Status != core.BlockStatusFinalized:
+			addRepair(RepairHighestCCBlock, cc, block.Height, "block is not committed in the chain, rolled back to the last finalized block")
+		case block.Height < finalized.Height || !chain.IsDescendant(finalized.Hash(), cc, int(block.Height-finalized.Height)+1):
+			addRepair(RepairHighestCCBlock, cc, block.Height, "block does not descend from the last finalized block, rolled back to the last finalized block")
+		case !hasState(block.StateHash):
+			addRepair(RepairHighestCCBlock, cc, block.Height, "state root %v is not in the DB, rolled back to the last finalized block", block.StateHash.Hex())
+		default:
+			report.HighestCCBlock = block
+		}
+	}
+
+	removed, err := chain.RemoveMissingChildren(finalized.Hash())
+	if err != nil {
+		return nil, err
+	}
+	for _, inc := range removed {
+		addRepair(RepairPartialImport, inc.Block, inc.Height, "%v", inc.Detail)
+	}
+
+	if len(report.Repairs) == 0 {
+		return report, nil
+	}
+	stub.LastFinalizedBlock = report.LastFinalizedBlock.Hash()
+	stub.HighestCCBlock = report.HighestCCBlock.Hash()
+	if err := db.Put([]byte(DBStateStubKey), stub); err != nil {
+		return nil, err
+	}
+	if hasWAL {
+		if err := db.Delete([]byte(DBWALKey)); err != nil {
+			return nil, err
+		}
+	}
+	return report, nil
+}
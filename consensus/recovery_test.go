@@ -0,0 +1,124 @@
+package consensus
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thetatoken/ukulele/blockchain"
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/core"
+	"github.com/thetatoken/ukulele/store"
+	"github.com/thetatoken/ukulele/store/database/backend"
+	"github.com/thetatoken/ukulele/store/kvstore"
+)
+
+func repairKinds(report *RecoveryReport) map[string]int {
+	kinds := make(map[string]int)
+	for _, repair := range report.Repairs {
+		kinds[repair.Kind]++
+	}
+	return kinds
+}
+
+func TestRecoverConsistentState(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	core.ResetTestBlocks()
+
+	db := kvstore.NewKVStore(backend.NewMemDatabase())
+	chain := blockchain.NewChain("testchain", db, core.CreateTestBlock("a0", ""))
+	for _, pair := range [][2]string{{"a1", "a0"}, {"a2", "a1"}, {"a3", "a2"}} {
+		_, err := chain.AddBlock(core.CreateTestBlock(pair[0], pair[1]))
+		require.Nil(err)
+	}
+
+	// No consensus state yet
+	report, err := Recover(db, chain, nil)
+	require.Nil(err)
+	assert.Empty(report.Repairs)
+
+	a1, _ := chain.FindBlock(core.GetTestBlock("a1").Hash())
+	a2, _ := chain.FindBlock(core.GetTestBlock("a2").Hash())
+	state := NewState(db, chain)
+	state.SetHighestCCBlock(a2)
+	chain.CommitBlock(a2.Hash())
+	state.SetLastFinalizedBlock(a1)
+	chain.FinalizeBlock(a1)
+
+	report, err = Recover(db, chain, nil)
+	require.Nil(err)
+	assert.Empty(report.Repairs)
+	assert.Equal(a1.Hash(), report.LastFinalizedBlock.Hash())
+	assert.Equal(a2.Hash(), report.HighestCCBlock.Hash())
+}
+
+func TestRecoverInterruptedUpdates(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	core.ResetTestBlocks()
+
+	db := kvstore.NewKVStore(backend.NewMemDatabase())
+	chain := blockchain.NewChain("testchain", db, core.CreateTestBlock("a0", ""))
+	for _, pair := range [][2]string{{"a1", "a0"}, {"a2", "a1"}, {"a3", "a2"}, {"b2", "a1"}} {
+		_, err := chain.AddBlock(core.CreateTestBlock(pair[0], pair[1]))
+		require.Nil(err)
+	}
+	a1, _ := chain.FindBlock(core.GetTestBlock("a1").Hash())
+	a2, _ := chain.FindBlock(core.GetTestBlock("a2").Hash())
+	a3, _ := chain.FindBlock(core.GetTestBlock("a3").Hash())
+
+	state := NewState(db, chain)
+	state.SetHighestCCBlock(a2)
+	chain.CommitBlock(a2.Hash())
+	state.SetLastFinalizedBlock(a1)
+	chain.FinalizeBlock(a1)
+
+	state.SetLastVoteHeight(a3.Height)
+
+	// Crash after the consensus state is updated, before the chain is
+	state.BeginWAL(WALOpCommit, a3)
+	state.SetHighestCCBlock(a3)
+	state.BeginWAL(WALOpFinalize, a2)
+	state.SetLastFinalizedBlock(a2)
+
+	// Partially imported child of a2
+	a2.Children = append(a2.Children, common.HexToHash("ff"))
+	require.Nil(db.Put(a2.Hash().Bytes(), *a2))
+
+	report, err := Recover(db, chain, nil)
+	require.Nil(err)
+	kinds := repairKinds(report)
+	assert.Equal(1, kinds[RepairInterruptedUpdate])
+	assert.Equal(1, kinds[RepairLastFinalizedBlock])
+	assert.Equal(1, kinds[RepairHighestCCBlock])
+	assert.Equal(1, kinds[RepairPartialImport])
+	assert.Equal(a1.Hash(), report.LastFinalizedBlock.Hash())
+	assert.Equal(a1.Hash(), report.HighestCCBlock.Hash())
+
+	err = db.Get([]byte(DBWALKey), &WALEntry{})
+	assert.Equal(store.ErrKeyNotFound, err)
+	block, err := chain.FindBlock(a2.Hash())
+	require.Nil(err)
+	assert.Equal([]common.Hash{a3.Hash()}, block.Children)
+
+	recovered := NewState(db, chain)
+	assert.Equal(a1.Hash(), recovered.GetLastFinalizedBlock().Hash())
+	assert.Equal(a1.Hash(), recovered.GetHighestCCBlock().Hash())
+	assert.Equal(a3.Height, recovered.GetLastVoteHeight())
+
+	// The state is consistent once recovered
+	report, err = Recover(db, chain, nil)
+	require.Nil(err)
+	assert.Empty(report.Repairs)
+
+	// Finalized blocks whose state root is missing are rolled back too
+	chain.CommitBlock(a2.Hash())
+	chain.FinalizeBlock(a2)
+	recovered.SetLastFinalizedBlock(a2)
+	hasState := func(root common.Hash) bool { return root != a2.StateHash }
+	report, err = Recover(db, chain, hasState)
+	require.Nil(err)
+	assert.Equal(1, repairKinds(report)[RepairLastFinalizedBlock])
+	assert.Equal(a1.Hash(), report.LastFinalizedBlock.Hash())
+}
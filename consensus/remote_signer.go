@@ -0,0 +1,244 @@
+package consensus
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"time"
+
+	json "github.com/gorilla/rpc/v2/json2"
+	log "github.com/sirupsen/logrus"
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/common/hexutil"
+	"github.com/thetatoken/ukulele/core"
+	"github.com/thetatoken/ukulele/crypto"
+	"github.com/thetatoken/ukulele/ledger/types"
+)
+
+//
+// The remote signer RPC protocol. A remote signer holds the key of a validator on a
+// separate machine or in an HSM, and serves JSON-RPC 2.0 over HTTP POST to the
+// validator node. It signs the votes and the transactions the validator adds to its
+// proposals, never arbitrary messages, so that it can refuse to sign conflicting votes
+// or transactions spending the funds of the validator.
+//
+
+const (
+	MethodGetSignerID    = "signer.GetID"
+	MethodSignVote       = "signer.SignVote"
+	MethodSignProposerTx = "signer.SignProposerTx"
+)
+
+type GetSignerIDArgs struct{}
+
+type GetSignerIDResult struct {
+	ID common.Address `json:"id"`
+}
+
+type SignVoteArgs struct {
+	ID     common.Address    `json:"id"`
+	Block  common.Hash       `json:"block"`
+	Height common.JSONUint64 `json:"height"`
+	Epoch  common.JSONUint64 `json:"epoch"`
+}
+
+type SignVoteResult struct {
+	Signature hexutil.Bytes `json:"signature"`
+}
+
+type SignProposerTxArgs struct {
+	ChainID string        `json:"chain_id"`
+	Tx      hexutil.Bytes `json:"tx"` // The unsigned transaction
+}
+
+type SignProposerTxResult struct {
+	Signature hexutil.Bytes `json:"signature"`
+}
+
+func (a *SignVoteArgs) vote() core.Vote {
+	return core.Vote{
+		ID:     a.ID,
+		Block:  a.Block,
+		Height: uint64(a.Height),
+		Epoch:  uint64(a.Epoch),
+	}
+}
+
+// SignerService signs the votes of a validator with its key, guarded against double
+// signs. It is registered with a gorilla JSON-RPC server under the name "signer".
+type SignerService struct {
+	signer core.Signer
+	guard  *SignGuard
+}
+
+// NewSignerService creates an instance of SignerService.
+func NewSignerService(signer core.Signer, guard *SignGuard) *SignerService {
+	return &SignerService{
+		signer: signer,
+		guard:  guard,
+	}
+}
+
+// GetID returns the address of the validator.
+func (s *SignerService) GetID(r *http.Request, args *GetSignerIDArgs, result *GetSignerIDResult) error {
+	result.ID = s.signer.ID()
+	return nil
+}
+
+// SignVote signs the vote, unless it could be a double sign.
+func (s *SignerService) SignVote(r *http.Request, args *SignVoteArgs, result *SignVoteResult) error {
+	vote := args.vote()
+	if vote.ID != s.signer.ID() {
+		return fmt.Errorf("Vote is for validator %v, not %v", vote.ID.Hex(), s.signer.ID().Hex())
+	}
+	if vote.Block.IsEmpty() && vote.Height != 0 {
+		return fmt.Errorf("Vote without block has height %v", vote.Height)
+	}
+	logger.WithFields(log.Fields{
+		"vote":   vote,
+		"remote": r.RemoteAddr,
+	}).Debug("Signing vote")
+
+	if err := s.guard.Guard(vote); err != nil {
+		logger.WithFields(log.Fields{"vote": vote, "error": err}).Warn("Refused to sign vote")
+		return err
+	}
+	sig, err := s.signer.Sign(vote.SignBytes())
+	if err != nil {
+		return err
+	}
+	result.Signature = hexutil.Bytes(sig.ToBytes())
+	return nil
+}
+
+// SignProposerTx signs a coinbase or slash transaction of the validator.
+func (s *SignerService) SignProposerTx(r *http.Request, args *SignProposerTxArgs, result *SignProposerTxResult) error {
+	tx, err := types.TxFromBytes(common.Bytes(args.Tx))
+	if err != nil {
+		return fmt.Errorf("Failed to parse transaction: %v", err)
+	}
+	var proposer common.Address
+	switch tx := tx.(type) {
+	case *types.CoinbaseTx:
+		proposer = tx.Proposer.Address
+	case *types.SlashTx:
+		proposer = tx.Proposer.Address
+	default:
+		return fmt.Errorf("Refusing to sign %v, only the coinbase and slash transactions are signed", types.TxTypeName(tx))
+	}
+	if proposer != s.signer.ID() {
+		return fmt.Errorf("Transaction is proposed by %v, not %v", proposer.Hex(), s.signer.ID().Hex())
+	}
+	logger.WithFields(log.Fields{
+		"tx":     tx,
+		"remote": r.RemoteAddr,
+	}).Debug("Signing proposer transaction")
+
+	sig, err := s.signer.Sign(tx.SignBytes(args.ChainID))
+	if err != nil {
+		return err
+	}
+	result.Signature = hexutil.Bytes(sig.ToBytes())
+	return nil
+}
+
+var _ core.ValidatorSigner = (*RemoteSigner)(nil)
+
+// RemoteSigner requests the signatures of the votes from a remote signer.
+type RemoteSigner struct {
+	endpoint   string
+	httpClient *http.Client
+	id         common.Address
+}
+
+// NewRemoteSigner creates an instance of RemoteSigner, and gets the address of the
+// validator from the remote signer.
+func NewRemoteSigner(endpoint string, timeout time.Duration) (*RemoteSigner, error) {
+	s := &RemoteSigner{
+		endpoint:   endpoint,
+		httpClient: &http.Client{Timeout: timeout},
+	}
+	result := &GetSignerIDResult{}
+	if err := s.call(MethodGetSignerID, &GetSignerIDArgs{}, result); err != nil {
+		return nil, err
+	}
+	if result.ID.IsEmpty() {
+		return nil, fmt.Errorf("Remote signer %v returned no validator address", endpoint)
+	}
+	s.id = result.ID
+	return s, nil
+}
+
+// ID returns the address of the validator.
+func (s *RemoteSigner) ID() common.Address {
+	return s.id
+}
+
+// Sign fails, since the remote signer does not sign arbitrary messages.
+func (s *RemoteSigner) Sign(msg common.Bytes) (*crypto.Signature, error) {
+	return nil, fmt.Errorf("Remote signer %v only signs votes and proposer transactions", s.endpoint)
+}
+
+// SignVote requests the signature of the vote.
+func (s *RemoteSigner) SignVote(vote core.Vote) (*crypto.Signature, error) {
+	args := &SignVoteArgs{
+		ID:     vote.ID,
+		Block:  vote.Block,
+		Height: common.JSONUint64(vote.Height),
+		Epoch:  common.JSONUint64(vote.Epoch),
+	}
+	result := &SignVoteResult{}
+	if err := s.call(MethodSignVote, args, result); err != nil {
+		return nil, err
+	}
+	return s.verify(result.Signature, vote.SignBytes())
+}
+
+// SignProposerTx requests the signature of a coinbase or slash transaction.
+func (s *RemoteSigner) SignProposerTx(chainID string, rawTx common.Bytes) (*crypto.Signature, error) {
+	tx, err := types.TxFromBytes(rawTx)
+	if err != nil {
+		return nil, err
+	}
+	args := &SignProposerTxArgs{
+		ChainID: chainID,
+		Tx:      hexutil.Bytes(rawTx),
+	}
+	result := &SignProposerTxResult{}
+	if err := s.call(MethodSignProposerTx, args, result); err != nil {
+		return nil, err
+	}
+	return s.verify(result.Signature, tx.SignBytes(chainID))
+}
+
+// verify checks that the signature returned by the remote signer is the one of the
+// validator on the message.
+func (s *RemoteSigner) verify(raw hexutil.Bytes, msg common.Bytes) (*crypto.Signature, error) {
+	sig, err := crypto.SignatureFromBytes(common.Bytes(raw))
+	if err != nil {
+		return nil, fmt.Errorf("Remote signer %v returned an invalid signature: %v", s.endpoint, err)
+	}
+	if !core.VerifyValidatorSignature(sig, msg, s.id) {
+		return nil, fmt.Errorf("Remote signer %v returned a signature not matching the message", s.endpoint)
+	}
+	return sig, nil
+}
+
+func (s *RemoteSigner) call(method string, args interface{}, result interface{}) error {
+	body, err := json.EncodeClientRequest(method, args)
+	if err != nil {
+		return err
+	}
+	resp, err := s.httpClient.Post(s.endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Remote signer %v returned HTTP status %v", s.endpoint, resp.Status)
+	}
+	if err := json.DecodeClientResponse(resp.Body, result); err != nil {
+		return fmt.Errorf("Remote signer %v returned error: %v", s.endpoint, err)
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package consensus
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/core"
+)
+
+// SignState is the last vote for a block signed with a validator key.
+type SignState struct {
+	Height common.JSONUint64 `json:"height"`
+	Epoch  common.JSONUint64 `json:"epoch"`
+	Block  common.Hash       `json:"block"`
+}
+
+// SignGuard is the double-sign protection of a validator key. It refuses to sign a
+// vote for a block below the height of the last signed one, or for another block at
+// the same height. The votes without block, which advance the epochs, are not
+// guarded. The last signed vote is persisted in a file before the vote is signed, so
+// that a restarted node or remote signer keeps the protection. The same file can be
+// handed over with the key, e.g. from the node to a remote signer.
+type SignGuard struct {
+	mu       sync.Mutex
+	filePath string
+	state    SignState
+}
+
+// LoadSignGuard loads the last signed vote from the file, which is created on the
+// first signed vote if it does not exist.
+func LoadSignGuard(filePath string) (*SignGuard, error) {
+	g := &SignGuard{filePath: filePath}
+	raw, err := ioutil.ReadFile(filePath)
+	if os.IsNotExist(err) {
+		return g, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(raw, &g.state); err != nil {
+		return nil, fmt.Errorf("Failed to parse sign state file %v: %v", filePath, err)
+	}
+	return g, nil
+}
+
+// State returns the last signed vote for a block.
+func (g *SignGuard) State() SignState {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.state
+}
+
+// Guard checks that signing the vote cannot be a double sign, and records the vote.
+// The vote must not be signed if an error is returned.
+func (g *SignGuard) Guard(vote core.Vote) error {
+	if vote.Block.IsEmpty() {
+		return nil
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	last := g.state
+	if vote.Height < uint64(last.Height) {
+		return fmt.Errorf("Refusing to sign vote at height %v, below the last signed height %v", vote.Height, last.Height)
+	}
+	if vote.Height == uint64(last.Height) && !last.Block.IsEmpty() {
+		if vote.Block != last.Block {
+			return fmt.Errorf("Refusing to sign vote for block %v at height %v, block %v is already signed at that height",
+				vote.Block.Hex(), vote.Height, last.Block.Hex())
+		}
+		if vote.Epoch <= uint64(last.Epoch) {
+			// Signing the same vote again
+			return nil
+		}
+	}
+
+	state := SignState{
+		Height: common.JSONUint64(vote.Height),
+		Epoch:  common.JSONUint64(vote.Epoch),
+		Block:  vote.Block,
+	}
+	raw, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := common.WriteFileAtomic(g.filePath, raw, 0600); err != nil {
+		return fmt.Errorf("Failed to save sign state file %v: %v", g.filePath, err)
+	}
+	g.state = state
+	return nil
+}
@@ -0,0 +1,51 @@
+package consensus
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/core"
+)
+
+func TestSignGuard(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "sign_guard")
+	require.Nil(err)
+	defer os.RemoveAll(dir)
+	filePath := path.Join(dir, "sign_state.json")
+
+	guard, err := LoadSignGuard(filePath)
+	require.Nil(err)
+	b1 := core.Vote{Block: common.HexToHash("B1"), Height: 10, Epoch: 20}
+	require.Nil(guard.Guard(b1))
+
+	// The same vote can be signed again, also in a later epoch
+	assert.Nil(guard.Guard(b1))
+	assert.Nil(guard.Guard(core.Vote{Block: common.HexToHash("B1"), Height: 10, Epoch: 21}))
+
+	// Another block at the same height, or a lower height, is a double sign
+	assert.NotNil(guard.Guard(core.Vote{Block: common.HexToHash("B2"), Height: 10, Epoch: 22}))
+	assert.NotNil(guard.Guard(core.Vote{Block: common.HexToHash("B0"), Height: 9, Epoch: 22}))
+
+	// The votes without block are not guarded
+	assert.Nil(guard.Guard(core.Vote{Epoch: 22}))
+
+	// The protection survives a restart
+	guard, err = LoadSignGuard(filePath)
+	require.Nil(err)
+	assert.Equal(common.JSONUint64(10), guard.State().Height)
+	assert.Equal(common.JSONUint64(21), guard.State().Epoch)
+	assert.NotNil(guard.Guard(core.Vote{Block: common.HexToHash("B2"), Height: 10, Epoch: 22}))
+	assert.Nil(guard.Guard(core.Vote{Block: common.HexToHash("B3"), Height: 11, Epoch: 22}))
+
+	require.Nil(ioutil.WriteFile(filePath, []byte("not json"), 0600))
+	_, err = LoadSignGuard(filePath)
+	assert.NotNil(err)
+}
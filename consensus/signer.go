@@ -0,0 +1,128 @@
+package consensus
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/core"
+	"github.com/thetatoken/ukulele/crypto"
+	"github.com/thetatoken/ukulele/crypto/bls"
+)
+
+// PartialSigner signs with one share of a threshold validator key.
+type PartialSigner interface {
+	SignShare(msg common.Bytes) (*bls.SignatureShare, error)
+}
+
+var _ PartialSigner = (*LocalPartialSigner)(nil)
+
+// LocalPartialSigner signs with a key share held by the current node.
+type LocalPartialSigner struct {
+	keyShare *bls.KeyShare
+}
+
+// NewLocalPartialSigner creates an instance of LocalPartialSigner.
+func NewLocalPartialSigner(keyShare *bls.KeyShare) *LocalPartialSigner {
+	return &LocalPartialSigner{keyShare: keyShare}
+}
+
+// SignShare signs the message with the key share.
+func (s *LocalPartialSigner) SignShare(msg common.Bytes) (*bls.SignatureShare, error) {
+	return s.keyShare.Sign(msg), nil
+}
+
+var _ core.Signer = (*ThresholdSigner)(nil)
+
+// ThresholdSigner signs for a validator whose key is split across machines with
+// t-of-n threshold signing. It requests signature shares from all the partial
+// signers in parallel, and combines the first t valid ones into the signature of
+// the group key.
+type ThresholdSigner struct {
+	commitment bls.Commitment
+	signers    []PartialSigner
+	timeout    time.Duration
+}
+
+// NewThresholdSigner creates an instance of ThresholdSigner. The commitment is the
+// commitment to the group polynomial from the key generation, which identifies
+// the group key as well as the key shares.
+func NewThresholdSigner(commitment bls.Commitment, signers []PartialSigner, timeout time.Duration) (*ThresholdSigner, error) {
+	if err := commitment.Validate(); err != nil {
+		return nil, err
+	}
+	if len(signers) < commitment.Threshold() {
+		return nil, fmt.Errorf("%v partial signers cannot reach the threshold of %v", len(signers), commitment.Threshold())
+	}
+	return &ThresholdSigner{
+		commitment: commitment,
+		signers:    signers,
+		timeout:    timeout,
+	}, nil
+}
+
+// ID returns the address of the group key.
+func (s *ThresholdSigner) ID() common.Address {
+	return s.commitment.PublicKey().Address()
+}
+
+// Sign collects the signature shares of the message and combines them.
+func (s *ThresholdSigner) Sign(msg common.Bytes) (*crypto.Signature, error) {
+	type response struct {
+		share *bls.SignatureShare
+		err   error
+	}
+	responses := make(chan response, len(s.signers))
+	for _, signer := range s.signers {
+		go func(signer PartialSigner) {
+			share, err := signer.SignShare(msg)
+			responses <- response{share: share, err: err}
+		}(signer)
+	}
+
+	threshold := s.commitment.Threshold()
+	shares := []*bls.SignatureShare{}
+	seen := make(map[uint64]bool)
+	errs := []error{}
+	timeout := time.After(s.timeout)
+	for received := 0; received < len(s.signers) && len(shares) < threshold; received++ {
+		select {
+		case resp := <-responses:
+			if resp.err != nil {
+				errs = append(errs, resp.err)
+				continue
+			}
+			if !s.commitment.VerifySignatureShare(msg, resp.share) {
+				errs = append(errs, fmt.Errorf("Invalid signature share of index %v", shareIndex(resp.share)))
+				continue
+			}
+			if seen[resp.share.Index] {
+				continue
+			}
+			seen[resp.share.Index] = true
+			shares = append(shares, resp.share)
+		case <-timeout:
+			return nil, fmt.Errorf("Timed out with %v of %v signature shares, errors: %v", len(shares), threshold, errs)
+		}
+	}
+	if len(shares) < threshold {
+		return nil, fmt.Errorf("Got %v of %v signature shares, errors: %v", len(shares), threshold, errs)
+	}
+
+	sig, err := bls.RecoverSignature(shares, threshold)
+	if err != nil {
+		return nil, err
+	}
+	groupKey := s.commitment.PublicKey()
+	if !groupKey.Verify(msg, sig) {
+		return nil, fmt.Errorf("Combined signature failed verification")
+	}
+	return core.NewThresholdSignature(groupKey, sig), nil
+}
+
+func shareIndex(share *bls.SignatureShare) uint64 {
+	if share == nil {
+		return 0
+	}
+	return share.Index
+}
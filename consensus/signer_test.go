@@ -0,0 +1,178 @@
+package consensus
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/gorilla/rpc/v2"
+	json "github.com/gorilla/rpc/v2/json2"
+	"github.com/stretchr/testify/assert"
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/core"
+	"github.com/thetatoken/ukulele/crypto"
+	"github.com/thetatoken/ukulele/crypto/bls"
+	"github.com/thetatoken/ukulele/ledger/types"
+)
+
+type failingPartialSigner struct{}
+
+func (s failingPartialSigner) SignShare(msg common.Bytes) (*bls.SignatureShare, error) {
+	return nil, errors.New("offline")
+}
+
+type wrongPartialSigner struct {
+	keyShare *bls.KeyShare
+}
+
+func (s wrongPartialSigner) SignShare(msg common.Bytes) (*bls.SignatureShare, error) {
+	return s.keyShare.Sign(common.Bytes("something else")), nil
+}
+
+func createTestKeyShares(assert *assert.Assertions, threshold, participants int) []*bls.KeyShare {
+	dealings := []*bls.Dealing{}
+	for i := 0; i < participants; i++ {
+		dealing, err := bls.NewDealing(nil, threshold, participants)
+		assert.Nil(err)
+		dealings = append(dealings, dealing)
+	}
+	keyShares := []*bls.KeyShare{}
+	for j := 1; j <= participants; j++ {
+		commitments := []bls.Commitment{}
+		shares := []*bls.PrivateKey{}
+		for _, dealing := range dealings {
+			commitments = append(commitments, dealing.Commitment)
+			shares = append(shares, dealing.Shares[j-1])
+		}
+		keyShare, err := bls.CombineDealings(uint64(j), commitments, shares)
+		assert.Nil(err)
+		keyShares = append(keyShares, keyShare)
+	}
+	return keyShares
+}
+
+func TestThresholdSigner(t *testing.T) {
+	assert := assert.New(t)
+
+	keyShares := createTestKeyShares(assert, 2, 3)
+	commitment := keyShares[0].Commitment
+
+	// Two of the three shares are available
+	signer, err := NewThresholdSigner(commitment, []PartialSigner{
+		NewLocalPartialSigner(keyShares[0]),
+		failingPartialSigner{},
+		NewLocalPartialSigner(keyShares[2]),
+	}, time.Second)
+	assert.Nil(err)
+	assert.Equal(commitment.PublicKey().Address(), signer.ID())
+
+	vote := core.Vote{
+		Block: common.HexToHash("B1"),
+		ID:    signer.ID(),
+		Epoch: 1,
+	}
+	sig, err := signer.Sign(vote.SignBytes())
+	assert.Nil(err)
+	vote.SetSignature(sig)
+	assert.True(vote.Validate().IsOK())
+
+	// One valid share is not enough
+	signer, err = NewThresholdSigner(commitment, []PartialSigner{
+		NewLocalPartialSigner(keyShares[0]),
+		failingPartialSigner{},
+		wrongPartialSigner{keyShares[1]},
+		NewLocalPartialSigner(keyShares[0]),
+	}, time.Second)
+	assert.Nil(err)
+	_, err = signer.Sign(vote.SignBytes())
+	assert.NotNil(err)
+
+	_, err = NewThresholdSigner(commitment, []PartialSigner{NewLocalPartialSigner(keyShares[0])}, time.Second)
+	assert.NotNil(err)
+}
+
+func TestRemoteCosigner(t *testing.T) {
+	assert := assert.New(t)
+
+	keyShares := createTestKeyShares(assert, 2, 2)
+
+	handler := rpc.NewServer()
+	handler.RegisterCodec(json.NewCodec(), "application/json")
+	handler.RegisterService(NewCosignerService(keyShares[1]), "cosigner")
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	signer, err := NewThresholdSigner(keyShares[0].Commitment, []PartialSigner{
+		NewLocalPartialSigner(keyShares[0]),
+		NewRemoteCosigner(server.URL, time.Second),
+	}, 5*time.Second)
+	assert.Nil(err)
+
+	vote := core.Vote{
+		Block: common.HexToHash("B1"),
+		ID:    signer.ID(),
+		Epoch: 1,
+	}
+	sig, err := signer.Sign(vote.SignBytes())
+	assert.Nil(err)
+	vote.SetSignature(sig)
+	assert.True(vote.Validate().IsOK())
+}
+
+func TestRemoteSigner(t *testing.T) {
+	assert := assert.New(t)
+
+	privKey, _, err := crypto.GenerateKeyPair()
+	assert.Nil(err)
+	dir, err := ioutil.TempDir("", "remote_signer")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+	guard, err := LoadSignGuard(path.Join(dir, "sign_state.json"))
+	assert.Nil(err)
+
+	handler := rpc.NewServer()
+	handler.RegisterCodec(json.NewCodec(), "application/json")
+	handler.RegisterService(NewSignerService(core.NewPrivateKeySigner(privKey), guard), "signer")
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	signer, err := NewRemoteSigner(server.URL, time.Second)
+	assert.Nil(err)
+	assert.Equal(privKey.PublicKey().Address(), signer.ID())
+
+	vote := core.Vote{
+		Block:  common.HexToHash("B1"),
+		Height: 10,
+		ID:     signer.ID(),
+		Epoch:  1,
+	}
+	sig, err := signer.SignVote(vote)
+	assert.Nil(err)
+	vote.SetSignature(sig)
+	assert.True(vote.Validate().IsOK())
+
+	// The remote signer refuses double signs and arbitrary messages
+	_, err = signer.SignVote(core.Vote{Block: common.HexToHash("B2"), Height: 10, ID: signer.ID(), Epoch: 2})
+	assert.NotNil(err)
+	_, err = signer.Sign(vote.SignBytes())
+	assert.NotNil(err)
+
+	// It signs the coinbase transactions of the validator, but not its sends
+	chainID := "test_chain_id"
+	coinbaseTx := &types.CoinbaseTx{Proposer: types.TxInput{Address: signer.ID()}, BlockHeight: 10}
+	raw, err := types.TxToBytes(coinbaseTx)
+	assert.Nil(err)
+	sig, err = signer.SignProposerTx(chainID, raw)
+	assert.Nil(err)
+	assert.True(sig.Verify(coinbaseTx.SignBytes(chainID), signer.ID()))
+
+	sendTx := &types.SendTx{Inputs: []types.TxInput{{Address: signer.ID()}}}
+	raw, err = types.TxToBytes(sendTx)
+	assert.Nil(err)
+	_, err = signer.SignProposerTx(chainID, raw)
+	assert.NotNil(err)
+}
@@ -20,11 +20,31 @@ type StateStub struct {
 }
 
 const (
-	DBStateStubKey      = "cs/ss"
-	DBVoteByBlockPrefix = "cs/vbb/"
-	DBEpochVotesKey     = "cs/ev"
+	DBStateStubKey         = "cs/ss"
+	DBVoteByBlockPrefix    = "cs/vbb/"
+	DBFinalizedVotesPrefix = "cs/fv/"
+	DBEpochVotesKey        = "cs/ev"
+	DBLastVoteKey          = "cs/lv"
+	DBWALKey               = "cs/wal"
 )
 
+// Operations recorded in the WAL
+const (
+	// WALOpCommit sets the highest CC block and marks it committed in the chain
+	WALOpCommit = "commit"
+	// WALOpFinalize sets the last finalized block and marks it finalized in the chain
+	WALOpFinalize = "finalize"
+)
+
+// WALEntry records an update of the consensus state and the chain in progress, which
+// are written separately. An entry left in the DB means that the node crashed during
+// the update, which is rolled back by Recover on startup.
+type WALEntry struct {
+	Op     string
+	Block  common.Hash
+	Height uint64
+}
+
 type State struct {
 	mu *sync.RWMutex
 
@@ -35,6 +55,7 @@ type State struct {
 	lastFinalizedBlock *core.ExtendedBlock
 	tip                *core.ExtendedBlock
 	lastVoteHeight     uint64
+	lastVote           *core.Vote
 	epoch              uint64
 }
 
@@ -120,7 +141,21 @@ func (s *State) Load() (err error) {
 	}
 
 	s.lastVoteHeight = stub.LastVoteHeight
-	s.epoch = stub.Epoch
+	if stub.Epoch > s.epoch {
+		s.epoch = stub.Epoch
+	}
+	// The last vote is persisted before the state stub is updated, so a node that
+	// crashed in between resumes from the epoch and the height of its last vote
+	lastVote := &core.Vote{}
+	if s.db.Get([]byte(DBLastVoteKey), lastVote) == nil {
+		s.lastVote = lastVote
+		if lastVote.Epoch > s.epoch {
+			s.epoch = lastVote.Epoch
+		}
+		if !lastVote.Block.IsEmpty() && lastVote.Height > s.lastVoteHeight {
+			s.lastVoteHeight = lastVote.Height
+		}
+	}
 	if !stub.LastFinalizedBlock.IsEmpty() {
 		lastFinalizedBlock, err := s.chain.FindBlock(stub.LastFinalizedBlock)
 		if err == nil {
@@ -136,6 +171,16 @@ func (s *State) Load() (err error) {
 	return
 }
 
+// BeginWAL records the start of an update of the given block.
+func (s *State) BeginWAL(op string, block *core.ExtendedBlock) error {
+	return s.db.Put([]byte(DBWALKey), WALEntry{Op: op, Block: block.Hash(), Height: block.Height})
+}
+
+// EndWAL records the end of the update in progress.
+func (s *State) EndWAL() error {
+	return s.db.Delete([]byte(DBWALKey))
+}
+
 func (s *State) GetEpoch() uint64 {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -143,10 +188,14 @@ func (s *State) GetEpoch() uint64 {
 	return s.epoch
 }
 
+// SetEpoch moves the node to the epoch, which cannot be below the current epoch.
 func (s *State) SetEpoch(epoch uint64) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	if epoch < s.epoch {
+		return fmt.Errorf("Epoch %v is below the current epoch %v", epoch, s.epoch)
+	}
 	s.epoch = epoch
 	return s.commit()
 }
@@ -166,6 +215,37 @@ func (s *State) SetLastVoteHeight(height uint64) error {
 	return s.commit()
 }
 
+// GetLastVote returns the last vote of the node, nil if it has not voted yet.
+func (s *State) GetLastVote() *core.Vote {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.lastVote
+}
+
+// SetLastVote records the vote of the node in the current epoch, before it is sent.
+// The node votes at most once in each epoch.
+func (s *State) SetLastVote(vote core.Vote) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if vote.Epoch < s.epoch {
+		return fmt.Errorf("Vote is in epoch %v, below the current epoch %v", vote.Epoch, s.epoch)
+	}
+	if s.lastVote != nil && vote.Epoch <= s.lastVote.Epoch {
+		return fmt.Errorf("Already voted in epoch %v", s.lastVote.Epoch)
+	}
+	if err := s.db.Put([]byte(DBLastVoteKey), vote); err != nil {
+		return err
+	}
+	s.lastVote = &vote
+	s.epoch = vote.Epoch
+	if !vote.Block.IsEmpty() && vote.Height > s.lastVoteHeight {
+		s.lastVoteHeight = vote.Height
+	}
+	return s.commit()
+}
+
 func (s *State) GetHighestCCBlock() *core.ExtendedBlock {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -205,6 +285,23 @@ func (s *State) GetTip() *core.ExtendedBlock {
 	return tip
 }
 
+// GetPendingBlocks returns the blocks of all the forks above the last finalized block,
+// up to the given number of heights, ordered by height.
+func (s *State) GetPendingBlocks(maxHeights uint64) []*core.ExtendedBlock {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tip, _ := s.chain.FindDeepestDescendant(s.lastFinalizedBlock.Hash())
+	if tip == nil {
+		return nil
+	}
+	ret := []*core.ExtendedBlock{}
+	for height := s.lastFinalizedBlock.Height + 1; height <= tip.Height && height <= s.lastFinalizedBlock.Height+maxHeights; height++ {
+		ret = append(ret, s.chain.FindBlocksByHeight(height)...)
+	}
+	return ret
+}
+
 func (s *State) AddVote(vote *core.Vote) error {
 	if err := s.AddEpochVote(vote); err != nil {
 		return err
@@ -235,6 +332,21 @@ func (s *State) AddVoteByBlock(vote *core.Vote) error {
 	return s.db.Put(key, voteset)
 }
 
+// GetFinalizedVotes returns the votes for the block recorded when it was finalized.
+func (s *State) GetFinalizedVotes(hash common.Hash) (*core.VoteSet, error) {
+	key := append([]byte(DBFinalizedVotesPrefix), hash[:]...)
+	ret := core.NewVoteSet()
+	err := s.db.Get(key, ret)
+	return ret, err
+}
+
+// SetFinalizedVotes records the votes for the finalized block. Unlike the votes by
+// block, they are not updated by the votes received later.
+func (s *State) SetFinalizedVotes(hash common.Hash, votes *core.VoteSet) error {
+	key := append([]byte(DBFinalizedVotesPrefix), hash[:]...)
+	return s.db.Put(key, votes)
+}
+
 func (s *State) GetEpochVotes() (*core.VoteSet, error) {
 	key := []byte(DBEpochVotesKey)
 	ret := core.NewVoteSet()
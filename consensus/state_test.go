@@ -96,3 +96,117 @@ func TestConsensusStateVoteSet(t *testing.T) {
 	assert.Equal(1, len(votes))
 	assert.Equal(uint64(30), votes[0].Epoch)
 }
+
+func TestConsensusStateFinalizedVotes(t *testing.T) {
+	assert := assert.New(t)
+
+	db := kvstore.NewKVStore(backend.NewMemDatabase())
+	chain := blockchain.CreateTestChainByBlocks([]string{
+		"A1", "A0",
+	})
+	block1 := core.CreateTestBlock("A1", "A0")
+
+	state := NewState(db, chain)
+	state.AddVoteByBlock(&core.Vote{
+		Block:  block1.Hash(),
+		Height: block1.Height,
+		ID:     common.HexToAddress("A1"),
+		Epoch:  block1.Epoch,
+	})
+	votes, _ := state.GetVoteSetByBlock(block1.Hash())
+	assert.Nil(state.SetFinalizedVotes(block1.Hash(), votes.FilterByBlock(block1.BlockHeader)))
+
+	// Votes received after the finalization are not recorded
+	state.AddVoteByBlock(&core.Vote{
+		Block:  block1.Hash(),
+		Height: block1.Height,
+		ID:     common.HexToAddress("A2"),
+		Epoch:  block1.Epoch,
+	})
+	finalized, err := state.GetFinalizedVotes(block1.Hash())
+	assert.Nil(err)
+	assert.Equal(1, finalized.Size())
+	assert.Equal(common.HexToAddress("A1"), finalized.Votes()[0].ID)
+
+	_, err = state.GetFinalizedVotes(core.CreateTestBlock("A2", "A1").Hash())
+	assert.NotNil(err)
+}
+
+func TestConsensusStatePendingBlocks(t *testing.T) {
+	assert := assert.New(t)
+	core.ResetTestBlocks()
+
+	db := kvstore.NewKVStore(backend.NewMemDatabase())
+	chain := blockchain.CreateTestChainByBlocks([]string{
+		"A1", "A0",
+		"A2", "A1",
+		"B2", "A1",
+		"A3", "A2",
+	})
+	state := NewState(db, chain)
+
+	heights := func(blocks []*core.ExtendedBlock) []uint64 {
+		ret := []uint64{}
+		for _, block := range blocks {
+			ret = append(ret, block.Height)
+		}
+		return ret
+	}
+	root := chain.Root.Height
+	assert.Equal([]uint64{root + 1, root + 2, root + 2, root + 3}, heights(state.GetPendingBlocks(100)))
+	assert.Equal([]uint64{root + 1, root + 2, root + 2}, heights(state.GetPendingBlocks(2)))
+
+	a1, _ := chain.FindBlock(core.GetTestBlock("A1").Hash())
+	state.SetLastFinalizedBlock(a1)
+	pending := state.GetPendingBlocks(100)
+	assert.Equal([]uint64{root + 2, root + 2, root + 3}, heights(pending))
+	assert.Equal(core.GetTestBlock("A3").Hash(), pending[2].Hash())
+}
+
+func TestConsensusStateMonotonicEpoch(t *testing.T) {
+	assert := assert.New(t)
+	core.ResetTestBlocks()
+
+	db := kvstore.NewKVStore(backend.NewMemDatabase())
+	chain := blockchain.CreateTestChainByBlocks([]string{
+		"A1", "A0",
+		"A2", "A1",
+		"A3", "A2",
+	})
+	block2 := core.GetTestBlock("A2")
+	block3 := core.GetTestBlock("A3")
+
+	state1 := NewState(db, chain)
+	assert.Nil(state1.SetEpoch(5))
+	assert.NotNil(state1.SetEpoch(4))
+	assert.Equal(uint64(5), state1.GetEpoch())
+
+	vote := core.Vote{ID: common.HexToAddress("A1"), Block: block2.Hash(), Height: block2.Height, Epoch: 5}
+	assert.Nil(state1.SetLastVote(vote))
+	assert.Equal(block2.Height, state1.GetLastVoteHeight())
+
+	// A single vote in each epoch, and none in an earlier epoch
+	assert.NotNil(state1.SetLastVote(core.Vote{ID: common.HexToAddress("A1"), Epoch: 5}))
+	assert.NotNil(state1.SetLastVote(core.Vote{ID: common.HexToAddress("A1"), Epoch: 4}))
+
+	// Restart in epoch 5
+	state2 := NewState(db, chain)
+	assert.Equal(uint64(5), state2.GetEpoch())
+	assert.Equal(block2.Height, state2.GetLastVoteHeight())
+	assert.NotNil(state2.GetLastVote())
+	assert.Equal(block2.Hash(), state2.GetLastVote().Block)
+	assert.NotNil(state2.SetLastVote(core.Vote{ID: common.HexToAddress("A1"), Epoch: 5}))
+
+	// Crash after recording the vote of epoch 7, before updating the state stub
+	vote = core.Vote{ID: common.HexToAddress("A1"), Block: block3.Hash(), Height: block3.Height, Epoch: 7}
+	assert.Nil(db.Put([]byte(DBLastVoteKey), vote))
+
+	state3 := NewState(db, chain)
+	assert.Equal(uint64(7), state3.GetEpoch())
+	assert.Equal(block3.Height, state3.GetLastVoteHeight())
+	assert.NotNil(state3.SetEpoch(6))
+	assert.NotNil(state3.SetLastVote(core.Vote{ID: common.HexToAddress("A1"), Epoch: 7}))
+	assert.Nil(state3.SetLastVote(core.Vote{ID: common.HexToAddress("A1"), Epoch: 8}))
+	assert.Equal(uint64(8), state3.GetEpoch())
+	assert.Equal(block3.Height, state3.GetLastVoteHeight())
+}
@@ -1,24 +1,35 @@
 package consensus
 
 import (
+	"encoding/binary"
+	"fmt"
+	"math/big"
 	"math/rand"
+	"sort"
+	"sync"
 
+	"github.com/thetatoken/ukulele/common"
 	"github.com/thetatoken/ukulele/core"
+	"github.com/thetatoken/ukulele/crypto"
 )
 
 //
 // -------------------------------- FixedValidatorManager ----------------------------------
 //
 var _ core.ValidatorManager = &FixedValidatorManager{}
+var _ core.ValidatorJailer = &FixedValidatorManager{}
+var _ core.ProposerSelector = &FixedValidatorManager{}
 
-// FixedValidatorManager is an implementation of ValidatorManager interface that selects a fixed validator as the proposer.
+// FixedValidatorManager is an implementation of ValidatorManager interface that selects a fixed validator as the proposer,
+// i.e. the first validator that is not jailed.
 type FixedValidatorManager struct {
+	*validatorJail
 	validators *core.ValidatorSet
 }
 
 // NewFixedValidatorManager creates an instance of FixedValidatorManager.
 func NewFixedValidatorManager(validators *core.ValidatorSet) *FixedValidatorManager {
-	m := &FixedValidatorManager{}
+	m := &FixedValidatorManager{validatorJail: newValidatorJail()}
 	m.validators = validators.Copy()
 	return m
 }
@@ -28,7 +39,15 @@ func (m *FixedValidatorManager) GetProposerForEpoch(epoch uint64) core.Validator
 	if m.validators.Size() == 0 {
 		panic("No validators have been added")
 	}
-	return m.validators.Validators()[0]
+	return m.candidates(m.validators, epoch)[0]
+}
+
+// SelectProposer implements ProposerSelector interface.
+func (m *FixedValidatorManager) SelectProposer(epoch uint64, isJailed func(common.Address, uint64) bool) core.Validator {
+	if m.validators.Size() == 0 {
+		panic("No validators have been added")
+	}
+	return selectCandidates(m.validators, epoch, isJailed)[0]
 }
 
 // GetValidatorSetForEpoch returns the validator set for given epoch.
@@ -40,16 +59,19 @@ func (m *FixedValidatorManager) GetValidatorSetForEpoch(_ uint64) *core.Validato
 // -------------------------------- RotatingValidatorManager ----------------------------------
 //
 var _ core.ValidatorManager = &RotatingValidatorManager{}
+var _ core.ValidatorJailer = &RotatingValidatorManager{}
+var _ core.ProposerSelector = &RotatingValidatorManager{}
 
 // RotatingValidatorManager is an implementation of ValidatorManager interface that selects a random validator as
 // the proposer using validator's stake as weight.
 type RotatingValidatorManager struct {
+	*validatorJail
 	validators *core.ValidatorSet
 }
 
 // NewRotatingValidatorManager creates an instance of RotatingValidatorManager.
 func NewRotatingValidatorManager(validators *core.ValidatorSet) *RotatingValidatorManager {
-	m := &RotatingValidatorManager{}
+	m := &RotatingValidatorManager{validatorJail: newValidatorJail()}
 	m.validators = validators.Copy()
 	return m
 }
@@ -73,12 +95,22 @@ func (m *RotatingValidatorManager) GetProposerForEpoch(epoch uint64) core.Valida
 	if m.validators.Size() == 0 {
 		panic("No validators have been added")
 	}
+	return m.selectProposer(epoch, m.candidates(m.validators, epoch))
+}
+
+// SelectProposer implements ProposerSelector interface.
+func (m *RotatingValidatorManager) SelectProposer(epoch uint64, isJailed func(common.Address, uint64) bool) core.Validator {
+	if m.validators.Size() == 0 {
+		panic("No validators have been added")
+	}
+	return m.selectProposer(epoch, selectCandidates(m.validators, epoch, isJailed))
+}
+
+func (m *RotatingValidatorManager) selectProposer(epoch uint64, validators []core.Validator) core.Validator {
 	// TODO: replace with more secure randomness.
 	rnd := rand.New(rand.NewSource(int64(epoch)))
-	totalStake := m.validators.TotalStake()
-	r := randUint64(rnd, totalStake)
+	r := randUint64(rnd, totalStake(validators))
 	curr := uint64(0)
-	validators := m.validators.Validators()
 	for _, v := range validators {
 		curr += v.Stake()
 		if r < curr {
@@ -93,3 +125,173 @@ func (m *RotatingValidatorManager) GetProposerForEpoch(epoch uint64) core.Valida
 func (m *RotatingValidatorManager) GetValidatorSetForEpoch(_ uint64) *core.ValidatorSet {
 	return m.validators
 }
+
+//
+// -------------------------------- VRFValidatorManager ----------------------------------
+//
+var _ core.ValidatorManager = &VRFValidatorManager{}
+var _ core.ValidatorJailer = &VRFValidatorManager{}
+
+// maxProposerReveals is the number of the most recent proposer reveals kept, and
+// attached to proposals for the nodes that missed some of them.
+const maxProposerReveals = 16
+
+// VRFValidatorManager is an implementation of ValidatorManager interface that selects a random validator as
+// the proposer using validator's stake as weight, where the randomness is the VRF output revealed by the
+// proposer of the previous epoch. Nobody but the proposer of an epoch can predict the proposer of the next
+// epoch until it reveals, which mitigates targeted DoS attacks against the next proposer.
+//
+// If the proposer of an epoch does not reveal, the randomness of the epoch is derived from the last reveal
+// before it, so that all the nodes still agree on the next proposer.
+//
+// It does not implement ProposerSelector, since the reveals are only held in memory, so the ledger does
+// not track the missed proposals with it.
+type VRFValidatorManager struct {
+	*validatorJail
+	validators  *core.ValidatorSet
+	genesisSeed common.Hash
+
+	mu          *sync.Mutex
+	reveals     map[uint64]*revealedSeed // Proposer reveals by epoch
+	latestEpoch uint64                   // Epoch of the latest reveal
+}
+
+type revealedSeed struct {
+	reveal *core.ProposerReveal
+	seed   common.Hash
+}
+
+// NewVRFValidatorManager creates an instance of VRFValidatorManager. The genesis seed is the
+// randomness before any reveal, e.g. the hash of the genesis block.
+func NewVRFValidatorManager(validators *core.ValidatorSet, genesisSeed common.Hash) *VRFValidatorManager {
+	return &VRFValidatorManager{
+		validatorJail: newValidatorJail(),
+		validators:    validators.Copy(),
+		genesisSeed:   genesisSeed,
+		mu:            &sync.Mutex{},
+		reveals:       make(map[uint64]*revealedSeed),
+	}
+}
+
+// GetProposerForEpoch implements ValidatorManager interface.
+func (m *VRFValidatorManager) GetProposerForEpoch(epoch uint64) core.Validator {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.getProposerForEpoch(epoch)
+}
+
+func (m *VRFValidatorManager) getProposerForEpoch(epoch uint64) core.Validator {
+	if m.validators.Size() == 0 {
+		panic("No validators have been added")
+	}
+	seed := m.genesisSeed
+	if epoch > 0 {
+		seed = m.seed(epoch - 1)
+	}
+	validators := m.candidates(m.validators, epoch)
+	total := new(big.Int).SetUint64(totalStake(validators))
+	r := new(big.Int).Mod(new(big.Int).SetBytes(seed[:]), total).Uint64()
+	curr := uint64(0)
+	for _, v := range validators {
+		curr += v.Stake()
+		if r < curr {
+			return v
+		}
+	}
+	// Should not reach here.
+	panic("Failed to randomly select a validator")
+}
+
+// GetValidatorSetForEpoch returns the validator set for given epoch.
+func (m *VRFValidatorManager) GetValidatorSetForEpoch(_ uint64) *core.ValidatorSet {
+	return m.validators
+}
+
+// VRFInput returns the input of the VRF evaluated by the proposer of the epoch, i.e. the
+// randomness of the previous epoch and the epoch.
+func (m *VRFValidatorManager) VRFInput(epoch uint64) common.Bytes {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.vrfInput(epoch)
+}
+
+func (m *VRFValidatorManager) vrfInput(epoch uint64) common.Bytes {
+	seed := m.genesisSeed
+	if epoch > 0 {
+		seed = m.seed(epoch - 1)
+	}
+	return append(seed.Bytes(), encodeEpoch(epoch)...)
+}
+
+// Reveal verifies that the reveal is from the proposer of its epoch, and records its VRF output as
+// the randomness of the epoch. Reveals need to be added in the order of their epochs.
+func (m *VRFValidatorManager) Reveal(reveal *core.ProposerReveal) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.reveals[reveal.Epoch]; ok {
+		return nil
+	}
+	if reveal.Epoch+maxProposerReveals <= m.latestEpoch {
+		return fmt.Errorf("Reveal of epoch %v is too old", reveal.Epoch)
+	}
+	proposer := m.getProposerForEpoch(reveal.Epoch)
+	pubKey := proposer.PublicKey()
+	seed, err := reveal.Verify(&pubKey, m.vrfInput(reveal.Epoch))
+	if err != nil {
+		return fmt.Errorf("Reveal of epoch %v is not from the proposer %v: %v", reveal.Epoch, proposer.ID().Hex(), err)
+	}
+
+	m.reveals[reveal.Epoch] = &revealedSeed{reveal: reveal, seed: seed}
+	if reveal.Epoch > m.latestEpoch {
+		m.latestEpoch = reveal.Epoch
+	}
+	for epoch := range m.reveals {
+		if epoch+maxProposerReveals <= m.latestEpoch {
+			delete(m.reveals, epoch)
+		}
+	}
+	return nil
+}
+
+// RecentReveals returns the most recent reveals up to the epoch, in the order of their epochs.
+func (m *VRFValidatorManager) RecentReveals(epoch uint64) []*core.ProposerReveal {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	reveals := []*core.ProposerReveal{}
+	for e, revealed := range m.reveals {
+		if e <= epoch {
+			reveals = append(reveals, revealed.reveal)
+		}
+	}
+	sort.Slice(reveals, func(i, j int) bool {
+		return reveals[i].Epoch < reveals[j].Epoch
+	})
+	return reveals
+}
+
+// seed returns the randomness of the epoch, i.e. the VRF output revealed by its proposer,
+// or if it did not reveal, the hash of the last randomness revealed before and the epoch.
+func (m *VRFValidatorManager) seed(epoch uint64) common.Hash {
+	if revealed, ok := m.reveals[epoch]; ok {
+		return revealed.seed
+	}
+	last := m.genesisSeed
+	lastEpoch := uint64(0)
+	for e, revealed := range m.reveals {
+		if e < epoch && e >= lastEpoch {
+			last = revealed.seed
+			lastEpoch = e
+		}
+	}
+	return crypto.Keccak256Hash(last.Bytes(), encodeEpoch(epoch))
+}
+
+func encodeEpoch(epoch uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, epoch)
+	return b
+}
@@ -0,0 +1,110 @@
+package consensus
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/core"
+	"github.com/thetatoken/ukulele/crypto"
+)
+
+func TestVRFValidatorManager(t *testing.T) {
+	assert := assert.New(t)
+
+	privKeys := make(map[common.Address]*crypto.PrivateKey)
+	validators := core.NewValidatorSet()
+	for i := 0; i < 4; i++ {
+		privKey, pubKey, err := crypto.GenerateKeyPair()
+		assert.Nil(err)
+		privKeys[pubKey.Address()] = privKey
+		validators.AddValidator(core.NewValidator(pubKey.ToBytes(), uint64(i+1)))
+	}
+	genesisSeed := common.BytesToHash([]byte("genesis"))
+
+	m1 := NewVRFValidatorManager(validators, genesisSeed)
+	m2 := NewVRFValidatorManager(validators, genesisSeed)
+	for epoch := uint64(1); epoch <= 5; epoch++ {
+		proposer := m1.GetProposerForEpoch(epoch)
+		reveal, err := core.NewProposerReveal(epoch, privKeys[proposer.ID()], m1.VRFInput(epoch))
+		assert.Nil(err)
+		assert.Nil(m1.Reveal(reveal))
+		// m2 misses the reveals of the first epochs
+		if epoch >= 4 {
+			for _, r := range m1.RecentReveals(epoch) {
+				assert.Nil(m2.Reveal(r))
+			}
+		}
+	}
+	for epoch := uint64(1); epoch <= 7; epoch++ {
+		assert.Equal(m1.GetProposerForEpoch(epoch).ID(), m2.GetProposerForEpoch(epoch).ID())
+	}
+	assert.Equal(5, len(m2.RecentReveals(5)))
+
+	// Only the proposer of the epoch can reveal
+	epoch := uint64(6)
+	proposer := m1.GetProposerForEpoch(epoch)
+	for addr, privKey := range privKeys {
+		if addr == proposer.ID() {
+			continue
+		}
+		reveal, err := core.NewProposerReveal(epoch, privKey, m1.VRFInput(epoch))
+		assert.Nil(err)
+		assert.NotNil(m1.Reveal(reveal))
+	}
+	// and only for its epoch
+	reveal, err := core.NewProposerReveal(epoch, privKeys[proposer.ID()], m1.VRFInput(epoch+1))
+	assert.Nil(err)
+	assert.NotNil(m1.Reveal(reveal))
+}
+
+func TestVRFValidatorManagerWithoutReveals(t *testing.T) {
+	assert := assert.New(t)
+
+	_, pubKey, err := crypto.GenerateKeyPair()
+	assert.Nil(err)
+	validators := core.NewValidatorSet()
+	validators.AddValidator(core.NewValidator(pubKey.ToBytes(), 1))
+	assert.Equal(1, validators.Size())
+	m := NewVRFValidatorManager(validators, common.Hash{})
+	assert.Equal(validators.Validators()[0].ID(), m.GetProposerForEpoch(1000000).ID())
+	assert.Equal(0, len(m.RecentReveals(1000000)))
+}
+
+func TestJailedValidators(t *testing.T) {
+	assert := assert.New(t)
+
+	validators := core.NewValidatorSet()
+	for i := 0; i < 3; i++ {
+		_, pubKey, err := crypto.GenerateKeyPair()
+		assert.Nil(err)
+		validators.AddValidator(core.NewValidator(pubKey.ToBytes(), uint64(i+1)))
+	}
+	first := validators.Validators()[0].ID()
+	second := validators.Validators()[1].ID()
+
+	fixed := NewFixedValidatorManager(validators)
+	fixed.Jail(first, 10, 20)
+	assert.Equal(first, fixed.GetProposerForEpoch(10).ID())
+	for epoch := uint64(11); epoch <= 20; epoch++ {
+		assert.Equal(second, fixed.GetProposerForEpoch(epoch).ID())
+	}
+	assert.Equal(first, fixed.GetProposerForEpoch(21).ID())
+
+	// A validator is still selected if all of them are jailed
+	for _, v := range validators.Validators() {
+		fixed.Jail(v.ID(), 30, 40)
+	}
+	assert.Equal(first, fixed.GetProposerForEpoch(35).ID())
+
+	managers := []core.ValidatorManager{
+		NewRotatingValidatorManager(validators),
+		NewVRFValidatorManager(validators, common.BytesToHash([]byte("genesis"))),
+	}
+	for _, m := range managers {
+		m.(core.ValidatorJailer).Jail(second, 0, 100)
+		for epoch := uint64(1); epoch <= 100; epoch++ {
+			assert.NotEqual(second, m.GetProposerForEpoch(epoch).ID())
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/common/result"
+	"github.com/thetatoken/ukulele/crypto"
+	"github.com/thetatoken/ukulele/rlp"
+)
+
+// Attestation is signed by a guardian node to confirm that a checkpoint block is
+// finalized, after checking the commit certificate finalizing it.
+type Attestation struct {
+	Block     common.Hash       // Hash of the checkpoint block.
+	Height    uint64            // Height of the checkpoint block.
+	ID        common.Address    // Guardian's address.
+	Signature *crypto.Signature `rlp:"nil"`
+}
+
+func (a Attestation) String() string {
+	return fmt.Sprintf("Attestation{ID: %s, block: %s, height: %v}", a.ID, a.Block.Hex(), a.Height)
+}
+
+// SignBytes returns raw bytes to be signed.
+func (a Attestation) SignBytes() common.Bytes {
+	aa := Attestation{
+		Block:  a.Block,
+		Height: a.Height,
+		ID:     a.ID,
+	}
+	raw, _ := rlp.EncodeToBytes(aa)
+	return raw
+}
+
+// SetSignature sets given signature in attestation.
+func (a *Attestation) SetSignature(sig *crypto.Signature) {
+	a.Signature = sig
+}
+
+// Validate checks the attestation is legitimate.
+func (a Attestation) Validate() result.Result {
+	if a.ID.IsEmpty() {
+		return result.Error("Guardian is not specified")
+	}
+	if a.Block.IsEmpty() {
+		return result.Error("Block is not specified")
+	}
+	if a.Signature == nil || a.Signature.IsEmpty() {
+		return result.Error("Attestation is not signed")
+	}
+	if !VerifyValidatorSignature(a.Signature, a.SignBytes(), a.ID) {
+		return result.Error("Signature verification failed")
+	}
+	return result.OK
+}
+
+// AttestationSet represents a set of attestations of guardians.
+type AttestationSet struct {
+	attestations map[string]Attestation // Guardian ID and block to attestation
+}
+
+// NewAttestationSet creates an instance of AttestationSet.
+func NewAttestationSet() *AttestationSet {
+	return &AttestationSet{
+		attestations: make(map[string]Attestation),
+	}
+}
+
+// AddAttestation adds an attestation to the set, and returns whether it was not in
+// the set already.
+func (s *AttestationSet) AddAttestation(a Attestation) bool {
+	key := fmt.Sprintf("%s:%s", a.ID, a.Block)
+	if _, ok := s.attestations[key]; ok {
+		return false
+	}
+	s.attestations[key] = a
+	return true
+}
+
+// Size returns the number of attestations in the set.
+func (s *AttestationSet) Size() int {
+	return len(s.attestations)
+}
+
+// Attestations returns a slice of the attestations in the set, sorted by guardian ID.
+func (s *AttestationSet) Attestations() []Attestation {
+	ret := make([]Attestation, 0, len(s.attestations))
+	for _, a := range s.attestations {
+		ret = append(ret, a)
+	}
+	sort.Slice(ret, func(i, j int) bool { return bytes.Compare(ret[i].ID.Bytes(), ret[j].ID.Bytes()) < 0 })
+	return ret
+}
+
+// Guardians returns the addresses of the given guardians that attested the block.
+func (s *AttestationSet) Guardians(block common.Hash, guardians []common.Address) []common.Address {
+	ret := []common.Address{}
+	for _, guardian := range guardians {
+		if _, ok := s.attestations[fmt.Sprintf("%s:%s", guardian, block)]; ok {
+			ret = append(ret, guardian)
+		}
+	}
+	return ret
+}
+
+var _ rlp.Encoder = (*AttestationSet)(nil)
+
+// EncodeRLP implements RLP Encoder interface.
+func (s *AttestationSet) EncodeRLP(w io.Writer) error {
+	if s == nil {
+		return rlp.Encode(w, []Attestation{})
+	}
+	return rlp.Encode(w, s.Attestations())
+}
+
+var _ rlp.Decoder = (*AttestationSet)(nil)
+
+// DecodeRLP implements RLP Decoder interface.
+func (s *AttestationSet) DecodeRLP(stream *rlp.Stream) error {
+	attestations := []Attestation{}
+	err := stream.Decode(&attestations)
+	if err != nil {
+		return err
+	}
+	s.attestations = make(map[string]Attestation)
+	for _, a := range attestations {
+		s.AddAttestation(a)
+	}
+	return nil
+}
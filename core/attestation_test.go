@@ -0,0 +1,56 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/crypto"
+	"github.com/thetatoken/ukulele/rlp"
+)
+
+func TestAttestation(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	privKey, _, err := crypto.GenerateKeyPair()
+	require.Nil(err)
+	block := CreateTestBlock("", "").Hash()
+	a := Attestation{Block: block, Height: 10, ID: privKey.PublicKey().Address()}
+	assert.True(a.Validate().IsError())
+
+	sig, err := privKey.Sign(a.SignBytes())
+	require.Nil(err)
+	a.SetSignature(sig)
+	assert.True(a.Validate().IsOK())
+
+	forged := a
+	forged.Height = 20
+	assert.True(forged.Validate().IsError())
+
+	set := NewAttestationSet()
+	assert.True(set.AddAttestation(a))
+	assert.False(set.AddAttestation(a))
+	assert.True(set.AddAttestation(Attestation{Block: block, ID: common.HexToAddress("A1")}))
+
+	raw, err := rlp.EncodeToBytes(set)
+	require.Nil(err)
+	set2 := NewAttestationSet()
+	require.Nil(rlp.DecodeBytes(raw, set2))
+	assert.Equal(2, set2.Size())
+	decoded := set2.Attestations()
+	for i, a := range set.Attestations() {
+		assert.Equal(a.ID, decoded[i].ID)
+		assert.Equal(a.Block, decoded[i].Block)
+	}
+	for _, d := range decoded {
+		if d.ID == a.ID {
+			assert.True(d.Validate().IsOK())
+		}
+	}
+
+	guardians := []common.Address{a.ID, common.HexToAddress("A2")}
+	assert.Equal([]common.Address{a.ID}, set2.Guardians(block, guardians))
+	assert.Empty(set2.Guardians(common.Hash{}, guardians))
+}
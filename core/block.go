@@ -7,6 +7,7 @@ import (
 	"math/big"
 
 	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/common/result"
 	"github.com/thetatoken/ukulele/crypto"
 	"github.com/thetatoken/ukulele/rlp"
 )
@@ -47,6 +48,12 @@ type BlockHeader struct {
 	Proposer  common.Address
 
 	hash common.Hash // Cache of calculated hash.
+
+	// Hash of the validator set in effect after the epoch of the block, present only
+	// when the validator set changes, see ValidatorSetChangeHash. As the tail of the
+	// encoding, a header without it encodes as before it was introduced. It holds at
+	// most one hash, see Validate.
+	ValidatorSetHash []common.Hash `rlp:"tail"`
 }
 
 // Hash of header.
@@ -61,6 +68,35 @@ func (h *BlockHeader) Hash() common.Hash {
 	return h.hash
 }
 
+// GetValidatorSetHash returns the hash of the validator set committed by the header,
+// or an empty hash if the validator set does not change after the block.
+func (h *BlockHeader) GetValidatorSetHash() common.Hash {
+	if len(h.ValidatorSetHash) == 0 {
+		return common.Hash{}
+	}
+	return h.ValidatorSetHash[0]
+}
+
+// SetValidatorSetHash commits the header to the validator set of the given hash, or
+// to no validator set change if the hash is empty.
+func (h *BlockHeader) SetValidatorSetHash(hash common.Hash) {
+	if hash.IsEmpty() {
+		h.ValidatorSetHash = nil
+	} else {
+		h.ValidatorSetHash = []common.Hash{hash}
+	}
+	h.hash = common.Hash{}
+}
+
+// Validate checks the header for the fields that decode without error but have no
+// meaning, i.e. more than one validator set hash.
+func (h *BlockHeader) Validate() result.Result {
+	if len(h.ValidatorSetHash) > 1 {
+		return result.Error("Block header has %v validator set hashes, at most 1 is allowed", len(h.ValidatorSetHash))
+	}
+	return result.OK
+}
+
 func (h *BlockHeader) String() string {
 	return fmt.Sprintf("{ChainID: %v, Epoch: %d, Hash: %v. Parent: %v, Height: %v, TxHash: %v, StateHash: %v, Timestamp: %v, Proposer: %s}",
 		h.ChainID, h.Epoch, h.Hash().Hex(), h.Parent.Hex(), h.Height, h.TxHash.Hex(), h.StateHash.Hex(), h.Timestamp, h.Proposer)
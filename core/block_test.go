@@ -5,6 +5,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/rlp"
 )
 
 func TestBlockHash(t *testing.T) {
@@ -27,4 +28,52 @@ func TestBlockHash(t *testing.T) {
 	}
 	assert.Equal("0xfa9b2e03cb098783f8b39cd7faa5386118cc1d10d79ee0d20b1665f2c4a7d701", eb.Hash().Hex())
 
+	// The validator set hash is only encoded when the validator set changes
+	eb.SetValidatorSetHash(common.Hash{})
+	assert.Equal("0xfa9b2e03cb098783f8b39cd7faa5386118cc1d10d79ee0d20b1665f2c4a7d701", eb.Hash().Hex())
+	eb.SetValidatorSetHash(common.HexToHash("0x01"))
+	assert.NotEqual("0xfa9b2e03cb098783f8b39cd7faa5386118cc1d10d79ee0d20b1665f2c4a7d701", eb.Hash().Hex())
+	assert.Equal(common.HexToHash("0x01"), eb.GetValidatorSetHash())
+
+	raw, err := rlp.EncodeToBytes(eb.BlockHeader)
+	assert.Nil(err)
+	header := &BlockHeader{}
+	assert.Nil(rlp.DecodeBytes(raw, header))
+	assert.Equal(eb.Hash(), header.Hash())
+	assert.True(header.Validate().IsOK())
+
+	// A header with more than one validator set hash decodes, but is invalid
+	eb.ValidatorSetHash = []common.Hash{common.HexToHash("0x01"), common.HexToHash("0x02")}
+	raw, err = rlp.EncodeToBytes(eb.BlockHeader)
+	assert.Nil(err)
+	header = &BlockHeader{}
+	assert.Nil(rlp.DecodeBytes(raw, header))
+	assert.Equal(2, len(header.ValidatorSetHash))
+	assert.True(header.Validate().IsError())
+}
+
+func TestCalculateTxHash(t *testing.T) {
+	assert := assert.New(t)
+
+	txs := []common.Bytes{common.Bytes("tx0"), common.Bytes("tx1")}
+	hash := CalculateTxHash(txs)
+	assert.Equal(hash, CalculateTxHash([]common.Bytes{common.Bytes("tx0"), common.Bytes("tx1")}))
+	assert.NotEqual(hash, CalculateTxHash([]common.Bytes{common.Bytes("tx1"), common.Bytes("tx0")}))
+	assert.NotEqual(hash, CalculateTxHash(txs[:1]))
+	assert.False(CalculateTxHash(nil).IsEmpty())
+}
+
+func TestValidateTxHash(t *testing.T) {
+	assert := assert.New(t)
+
+	txs := []common.Bytes{common.Bytes("tx0"), common.Bytes("tx1")}
+	header := &BlockHeader{Height: 10, TxHash: CalculateTxHash(txs)}
+	assert.Nil(header.ValidateTxHash(txs, 0))
+	assert.NotNil(header.ValidateTxHash(txs[:1], 0))
+
+	// An empty TxHash is only accepted below the activation height
+	header.TxHash = common.Hash{}
+	assert.NotNil(header.ValidateTxHash(txs, 0))
+	assert.NotNil(header.ValidateTxHash(txs, 10))
+	assert.Nil(header.ValidateTxHash(txs, 11))
 }
@@ -0,0 +1,80 @@
+package core
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/crypto"
+)
+
+// ShortTxID returns the short ID of a transaction, the first 8 bytes of its hash.
+func ShortTxID(tx common.Bytes) uint64 {
+	return ShortTxIDOfHash(crypto.Keccak256Hash(tx))
+}
+
+// ShortTxIDOfHash returns the short ID of the transaction of the given hash.
+func ShortTxIDOfHash(hash common.Hash) uint64 {
+	return binary.BigEndian.Uint64(hash[:8])
+}
+
+// CompactBlock is a block relayed with the short IDs of its transactions. Only the
+// transactions the receiver is not expected to find in its mempool, e.g. the
+// coinbase transaction, are carried in full.
+type CompactBlock struct {
+	Header   *BlockHeader
+	ShortIDs []uint64       // Short IDs of all the transactions of the block, in order
+	Txs      []common.Bytes // Transactions carried in full, in order
+}
+
+// NewCompactBlock creates the compact block of a block, which carries the transactions
+// for which embed returns true.
+func NewCompactBlock(block *Block, embed func(tx common.Bytes) bool) *CompactBlock {
+	cb := &CompactBlock{
+		Header:   block.BlockHeader,
+		ShortIDs: make([]uint64, 0, len(block.Txs)),
+		Txs:      []common.Bytes{},
+	}
+	for _, tx := range block.Txs {
+		cb.ShortIDs = append(cb.ShortIDs, ShortTxID(tx))
+		if embed(tx) {
+			cb.Txs = append(cb.Txs, tx)
+		}
+	}
+	return cb
+}
+
+func (cb *CompactBlock) String() string {
+	return fmt.Sprintf("CompactBlock{Header: %v, ShortIDs: %v, Txs: %v}", cb.Header, len(cb.ShortIDs), len(cb.Txs))
+}
+
+// ResolveTxs rebuilds the block from the transactions the compact block carries and
+// the transactions found by lookup. It returns the short IDs of the transactions not
+// found if any, and an error if the transactions do not match the header of the block,
+// e.g. because of a collision of short IDs, with the rule of ValidateTxHash.
+func (cb *CompactBlock) ResolveTxs(lookup func(shortID uint64) (common.Bytes, bool), txHashActivationHeight uint64) (*Block, []uint64, error) {
+	embedded := make(map[uint64]common.Bytes, len(cb.Txs))
+	for _, tx := range cb.Txs {
+		embedded[ShortTxID(tx)] = tx
+	}
+	txs := make([]common.Bytes, 0, len(cb.ShortIDs))
+	missing := []uint64{}
+	for _, shortID := range cb.ShortIDs {
+		tx, ok := embedded[shortID]
+		if !ok {
+			tx, ok = lookup(shortID)
+		}
+		if !ok {
+			missing = append(missing, shortID)
+			continue
+		}
+		txs = append(txs, tx)
+	}
+	if len(missing) > 0 {
+		return nil, missing, nil
+	}
+	if err := cb.Header.ValidateTxHash(txs, txHashActivationHeight); err != nil {
+		return nil, nil, fmt.Errorf("Invalid compact block: %v", err)
+	}
+	return &Block{BlockHeader: cb.Header, Txs: txs}, nil, nil
+}
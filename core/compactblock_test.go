@@ -0,0 +1,68 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/crypto"
+	"github.com/thetatoken/ukulele/rlp"
+)
+
+func TestCompactBlock(t *testing.T) {
+	assert := assert.New(t)
+
+	coinbase := common.Bytes("coinbase")
+	tx1 := common.Bytes("tx1")
+	tx2 := common.Bytes("tx2")
+	block := CreateTestBlock("D1", "")
+	block.Txs = []common.Bytes{coinbase, tx1, tx2}
+	block.TxHash = CalculateTxHash(block.Txs)
+
+	hash := crypto.Keccak256Hash(tx1)
+	assert.Equal(ShortTxIDOfHash(hash), ShortTxID(tx1))
+
+	cb := NewCompactBlock(block, func(tx common.Bytes) bool { return string(tx) == "coinbase" })
+	raw, err := rlp.EncodeToBytes(cb)
+	assert.Nil(err)
+	decoded := &CompactBlock{}
+	assert.Nil(rlp.DecodeBytes(raw, decoded))
+	assert.Equal(block.Hash(), decoded.Header.Hash())
+	assert.Equal([]common.Bytes{coinbase}, decoded.Txs)
+	assert.Equal(3, len(decoded.ShortIDs))
+
+	// The mempool only has tx2
+	mempool := map[uint64]common.Bytes{ShortTxID(tx2): tx2}
+	lookup := func(shortID uint64) (common.Bytes, bool) {
+		tx, ok := mempool[shortID]
+		return tx, ok
+	}
+	resolved, missing, err := decoded.ResolveTxs(lookup, 0)
+	assert.Nil(err)
+	assert.Nil(resolved)
+	assert.Equal([]uint64{ShortTxID(tx1)}, missing)
+
+	mempool[ShortTxID(tx1)] = tx1
+	resolved, missing, err = decoded.ResolveTxs(lookup, 0)
+	assert.Nil(err)
+	assert.Empty(missing)
+	assert.Equal(block.Hash(), resolved.Hash())
+	assert.Equal(block.Txs, resolved.Txs)
+
+	// A transaction of the mempool with the same short ID
+	mempool[ShortTxID(tx1)] = common.Bytes("collision")
+	_, _, err = decoded.ResolveTxs(lookup, 0)
+	assert.NotNil(err)
+
+	// A block without TxHash does not commit to the transactions the short IDs match,
+	// unless it is below the activation height
+	mempool[ShortTxID(tx1)] = tx1
+	decoded.Header.TxHash = common.Hash{}
+	_, _, err = decoded.ResolveTxs(lookup, 0)
+	assert.NotNil(err)
+	_, _, err = decoded.ResolveTxs(lookup, decoded.Header.Height)
+	assert.NotNil(err)
+	resolved, _, err = decoded.ResolveTxs(lookup, decoded.Header.Height+1)
+	assert.Nil(err)
+	assert.Equal(block.Txs, resolved.Txs)
+}
@@ -1,6 +1,7 @@
 package core
 
 import (
+	"github.com/thetatoken/ukulele/common"
 	"github.com/thetatoken/ukulele/crypto"
 )
 
@@ -8,6 +9,7 @@ import (
 type ConsensusEngine interface {
 	ID() string
 	PrivateKey() *crypto.PrivateKey
+	Signer() Signer
 	GetTip() *ExtendedBlock
 	GetEpoch() uint64
 	AddMessage(msg interface{})
@@ -19,3 +21,59 @@ type ValidatorManager interface {
 	GetProposerForEpoch(epoch uint64) Validator
 	GetValidatorSetForEpoch(epoch uint64) *ValidatorSet
 }
+
+// ValidatorJailer is implemented by the validator managers that do not select the
+// validators jailed by slashing as proposers.
+type ValidatorJailer interface {
+	// Jail excludes the validator from the proposer selection for the epochs after
+	// jailedEpoch, up to untilEpoch.
+	Jail(validator common.Address, jailedEpoch uint64, untilEpoch uint64)
+}
+
+// ProposerSelector is implemented by the validator managers whose proposer selection
+// only depends on the epoch and the jailed validators, so that the ledger can work out
+// the proposers from the jails of its state rather than from the ones held in memory.
+type ProposerSelector interface {
+	// SelectProposer returns the proposer of the epoch, which is not one of the
+	// validators for which isJailed returns true unless they are all jailed.
+	SelectProposer(epoch uint64, isJailed func(validator common.Address, epoch uint64) bool) Validator
+}
+
+// Signer signs on behalf of the validator, i.e. its votes and the transactions it
+// adds to its proposals.
+type Signer interface {
+	ID() common.Address
+	Sign(msg common.Bytes) (*crypto.Signature, error)
+}
+
+// ValidatorSigner is a Signer given what it signs rather than the sign bytes, so that
+// it can refuse to sign conflicting votes, or transactions other than the ones the
+// validator adds to its proposals, e.g. a remote signer or an HSM. The node signs with
+// SignVote and SignProposerTx if its Signer implements them.
+type ValidatorSigner interface {
+	Signer
+	SignVote(vote Vote) (*crypto.Signature, error)
+	SignProposerTx(chainID string, rawTx common.Bytes) (*crypto.Signature, error)
+}
+
+var _ Signer = (*PrivateKeySigner)(nil)
+
+// PrivateKeySigner signs with the private key of the validator address.
+type PrivateKeySigner struct {
+	privateKey *crypto.PrivateKey
+}
+
+// NewPrivateKeySigner creates an instance of PrivateKeySigner.
+func NewPrivateKeySigner(privateKey *crypto.PrivateKey) *PrivateKeySigner {
+	return &PrivateKeySigner{privateKey: privateKey}
+}
+
+// ID returns the address of the private key.
+func (s *PrivateKeySigner) ID() common.Address {
+	return s.privateKey.PublicKey().Address()
+}
+
+// Sign signs the message with the private key.
+func (s *PrivateKeySigner) Sign(msg common.Bytes) (*crypto.Signature, error) {
+	return s.privateKey.Sign(msg)
+}
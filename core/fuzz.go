@@ -0,0 +1,79 @@
+// +build gofuzz
+
+package core
+
+import (
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/rlp"
+)
+
+// The fuzz functions are the entry points for go-fuzz, e.g.
+//
+//	go-fuzz-build -func FuzzProposal github.com/thetatoken/ukulele/core
+//	go-fuzz -bin core-fuzz.zip -workdir core/testdata/fuzz/FuzzProposal
+//
+// They decode the consensus messages as received from the peers, and use them as the
+// node does before checking their signatures. They return 1 for decodable input, to
+// have go-fuzz favor it, and 0 otherwise.
+
+// FuzzProposal decodes a proposal.
+func FuzzProposal(data []byte) int {
+	proposal := &Proposal{}
+	if err := rlp.DecodeBytes(data, proposal); err != nil {
+		return 0
+	}
+	_ = proposal.String()
+	if proposal.Votes != nil {
+		proposal.Votes.Validate()
+		for _, vote := range proposal.Votes.Votes() {
+			vote.Validate()
+		}
+	}
+	if proposal.Block != nil {
+		_ = proposal.Block.String()
+		proposal.Block.Hash()
+		if proposal.IsCompact() {
+			proposal.ResolveTxs(func(hash common.Hash) (common.Bytes, bool) { return nil, false }, 0)
+		}
+	}
+	if _, err := rlp.EncodeToBytes(proposal); err != nil {
+		panic(err)
+	}
+	return 1
+}
+
+// FuzzVote decodes a vote.
+func FuzzVote(data []byte) int {
+	vote := Vote{}
+	if err := rlp.DecodeBytes(data, &vote); err != nil {
+		return 0
+	}
+	_ = vote.String()
+	vote.Validate()
+	if _, err := rlp.EncodeToBytes(vote); err != nil {
+		panic(err)
+	}
+	return 1
+}
+
+// FuzzBlockHeader decodes a block header.
+func FuzzBlockHeader(data []byte) int {
+	header := &BlockHeader{}
+	if err := rlp.DecodeBytes(data, header); err != nil {
+		return 0
+	}
+	_ = header.String()
+	header.GetValidatorSetHash()
+	raw, err := rlp.EncodeToBytes(header)
+	if err != nil {
+		panic(err)
+	}
+	decoded := &BlockHeader{}
+	if err := rlp.DecodeBytes(raw, decoded); err != nil {
+		panic(err)
+	}
+	if decoded.Hash() != header.Hash() {
+		panic("The hash of the header changed once encoded and decoded")
+	}
+	return 1
+}
@@ -0,0 +1,147 @@
+package core
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"math/big"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/crypto"
+	"github.com/thetatoken/ukulele/rlp"
+)
+
+// The golden vectors are the canonical encodings of fixed blocks and votes. A change of
+// the wire format fails TestGoldenVectors; an intended change is recorded with
+//
+//	go test ./core -run TestGoldenVectors -update
+var updateGolden = flag.Bool("update", false, "update the golden vectors in testdata")
+
+const goldenVectorsFile = "testdata/golden_vectors.json"
+
+func goldenSignature(b byte) *crypto.Signature {
+	sig, _ := crypto.SignatureFromBytes(bytes.Repeat([]byte{b}, 65))
+	return sig
+}
+
+func goldenBlock() *Block {
+	return &Block{
+		BlockHeader: &BlockHeader{
+			ChainID:   "golden_chain",
+			Epoch:     12,
+			Height:    10,
+			Parent:    common.HexToHash("0x01"),
+			TxHash:    common.HexToHash("0x02"),
+			StateHash: common.HexToHash("0x03"),
+			Timestamp: big.NewInt(1546300800),
+			Proposer:  common.HexToAddress("0x2E833968E5bB786Ae419c4d13189fB081Cc43bab"),
+		},
+		Txs: []common.Bytes{common.Hex2Bytes("02c0"), common.Hex2Bytes("05c0")},
+	}
+}
+
+func goldenVote(id string, sigByte byte) Vote {
+	return Vote{
+		Block:     common.HexToHash("0x04"),
+		Height:    10,
+		Epoch:     12,
+		ID:        common.HexToAddress(id),
+		Signature: goldenSignature(sigByte),
+	}
+}
+
+// goldenValues returns the values of the golden vectors.
+func goldenValues() map[string]interface{} {
+	blockWithValidatorSetHash := goldenBlock()
+	blockWithValidatorSetHash.SetValidatorSetHash(common.HexToHash("0x05"))
+
+	votes := NewVoteSet()
+	votes.AddVote(goldenVote("0x9F1233798E905E173560071255140b4A8aBd3Ec6", 0x02))
+	votes.AddVote(goldenVote("0x2E833968E5bB786Ae419c4d13189fB081Cc43bab", 0x01))
+
+	return map[string]interface{}{
+		"Block":                     goldenBlock(),
+		"BlockWithValidatorSetHash": blockWithValidatorSetHash,
+		"Vote":                      goldenVote("0x2E833968E5bB786Ae419c4d13189fB081Cc43bab", 0x01),
+		"VoteSet":                   votes,
+		"CommitCertificate":         &CommitCertificate{Votes: votes, BlockHash: common.HexToHash("0x04")},
+		"Proposal": &Proposal{
+			Block:      goldenBlock(),
+			ProposerID: common.HexToAddress("0x2E833968E5bB786Ae419c4d13189fB081Cc43bab"),
+			Votes:      votes,
+		},
+	}
+}
+
+func TestGoldenVectors(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	vectors := map[string]string{}
+	for name, value := range goldenValues() {
+		raw, err := rlp.EncodeToBytes(value)
+		require.Nil(err, name)
+		vectors[name] = hex.EncodeToString(raw)
+	}
+
+	// The encodings are canonical: the decoded values encode the same
+	decoded := map[string]interface{}{
+		"Block":                     &Block{},
+		"BlockWithValidatorSetHash": &Block{},
+		"Vote":                      &Vote{},
+		"VoteSet":                   NewVoteSet(),
+		"CommitCertificate":         &CommitCertificate{},
+		"Proposal":                  &Proposal{},
+	}
+	for name, value := range decoded {
+		raw, err := hex.DecodeString(vectors[name])
+		require.Nil(err, name)
+		require.Nil(rlp.DecodeBytes(raw, value), name)
+		reencoded, err := rlp.EncodeToBytes(value)
+		require.Nil(err, name)
+		assert.Equal(raw, reencoded, name)
+	}
+
+	// The block hash is the one of the encoded header
+	block := decoded["Block"].(*Block)
+	assert.Equal(goldenBlock().Hash(), block.Hash())
+	vectors["BlockHash"] = block.Hash().Hex()
+
+	checkGoldenVectors(t, goldenVectorsFile, vectors)
+}
+
+// checkGoldenVectors compares the hex encodings by name with the ones of the file, or
+// writes them to the file with -update.
+func checkGoldenVectors(t *testing.T, file string, vectors map[string]string) {
+	if *updateGolden {
+		out, err := json.MarshalIndent(vectors, "", "  ")
+		require.Nil(t, err)
+		require.Nil(t, ioutil.WriteFile(file, append(out, '\n'), 0644))
+		return
+	}
+
+	in, err := ioutil.ReadFile(file)
+	require.Nil(t, err)
+	golden := map[string]string{}
+	require.Nil(t, json.Unmarshal(in, &golden))
+
+	names := []string{}
+	for name := range vectors {
+		names = append(names, name)
+	}
+	for name := range golden {
+		if _, ok := vectors[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		assert.Equal(t, golden[name], vectors[name], "The encoding of %v changed; run the test with -update if intended", name)
+	}
+}
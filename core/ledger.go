@@ -25,12 +25,32 @@ type TxInfo struct {
 }
 
 //
-// Ledger defines the interface of the ledger
+// Ledger defines the interface of the state machine driven by the consensus engine.
+// The Theta ledger is the default implementation, and an alternative state machine,
+// e.g. a test ledger or the ledger of an application-specific chain, can be plugged
+// into the node in its place
 //
 type Ledger interface {
+	// ScreenTx checks whether the transaction can be added to the mempool, and returns
+	// the information used to prioritize it
 	ScreenTx(rawTx common.Bytes) (priority *TxInfo, res result.Result)
-	ProposeBlockTxs() (stateRootHash common.Hash, blockRawTxs []common.Bytes, res result.Result)
-	ApplyBlockTxs(blockRawTxs []common.Bytes, expectedStateRoot common.Hash) result.Result
+
+	// ProposeBlockTxs selects and executes the transactions of the next block proposed
+	// by the node in the given epoch, and returns them along with the resulting state root
+	ProposeBlockTxs(epoch uint64) (stateRootHash common.Hash, blockRawTxs []common.Bytes, res result.Result)
+
+	// ApplyBlockTxs executes the transactions of a block of the given epoch, and returns
+	// an error if the resulting state root differs from the expected one
+	ApplyBlockTxs(epoch uint64, blockRawTxs []common.Bytes, expectedStateRoot common.Hash) result.Result
+
+	// ResetState reverts the state to the given root, e.g. to apply a block on a fork
 	ResetState(height uint64, rootHash common.Hash) result.Result
+
+	// FinalizeState marks the given root as the state of the last finalized block
 	FinalizeState(height uint64, rootHash common.Hash) result.Result
+
+	// Query reads the application data at the given path, e.g. an account, from the
+	// state of the last applied block. The paths and the encoding of the data and the
+	// value are defined by the implementation
+	Query(path string, data common.Bytes) (value common.Bytes, res result.Result)
 }
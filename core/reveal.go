@@ -0,0 +1,39 @@
+package core
+
+import (
+	"fmt"
+
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/crypto"
+	"github.com/thetatoken/ukulele/crypto/vrf"
+)
+
+// ProposerReveal is the VRF output revealed by the proposer of an epoch, which
+// seeds the selection of the proposer of the next epoch.
+type ProposerReveal struct {
+	Epoch uint64
+	Proof common.Bytes // VRF proof of the output, from which the output is derived
+}
+
+func (r *ProposerReveal) String() string {
+	return fmt.Sprintf("ProposerReveal{epoch: %v, proof: %v}", r.Epoch, common.Bytes2Hex(r.Proof))
+}
+
+// NewProposerReveal evaluates the VRF on the input with the private key of the
+// proposer of the epoch.
+func NewProposerReveal(epoch uint64, privKey *crypto.PrivateKey, alpha common.Bytes) (*ProposerReveal, error) {
+	_, proof, err := vrf.Prove(privKey, alpha)
+	if err != nil {
+		return nil, err
+	}
+	return &ProposerReveal{
+		Epoch: epoch,
+		Proof: proof,
+	}, nil
+}
+
+// Verify verifies the VRF proof for the input with the public key of the proposer,
+// and returns the VRF output.
+func (r *ProposerReveal) Verify(pubKey *crypto.PublicKey, alpha common.Bytes) (common.Hash, error) {
+	return vrf.Verify(pubKey, alpha, r.Proof)
+}
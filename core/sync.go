@@ -0,0 +1,39 @@
+package core
+
+import (
+	"fmt"
+
+	"github.com/thetatoken/ukulele/common"
+)
+
+// PeerStatus is the status of the chain a node sends periodically to its peers.
+type PeerStatus struct {
+	Height          uint64 // Height of the tip
+	FinalizedHeight uint64
+	TipHash         common.Hash
+}
+
+func (s PeerStatus) String() string {
+	return fmt.Sprintf("PeerStatus{height: %v, finalized: %v, tip: %v}", s.Height, s.FinalizedHeight, s.TipHash.Hex())
+}
+
+// NodeSyncStatus compares the chain of the node with the chains reported by its peers.
+type NodeSyncStatus struct {
+	PeerStatus
+
+	// Median of the heights reported by the peers, so that a few peers reporting
+	// wrong heights cannot make the node appear behind.
+	NetworkHeight          uint64
+	NetworkFinalizedHeight uint64
+
+	// Whether the tip of the node is more than the sync.behindThreshold heights
+	// below the network height.
+	Behind bool
+
+	Peers map[string]PeerStatus // Latest status reported by each peer
+}
+
+// SyncStatusReporter reports the sync status of the node.
+type SyncStatusReporter interface {
+	GetSyncStatus() *NodeSyncStatus
+}
@@ -0,0 +1,117 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/crypto"
+	"github.com/thetatoken/ukulele/crypto/bls"
+	"github.com/thetatoken/ukulele/rlp"
+)
+
+// thresholdSignaturePrefix marks the signatures carrying a ThresholdSignature
+var thresholdSignaturePrefix = []byte("threshold")
+
+// ThresholdSignature is the signature of a validator whose key is split across
+// machines. It carries the BLS signature combined from the signature shares, and
+// the group public key from which the validator address is derived.
+type ThresholdSignature struct {
+	PublicKey common.Bytes
+	Signature common.Bytes
+}
+
+// NewThresholdSignature wraps the group public key and the combined signature into
+// a signature that can be set in votes
+func NewThresholdSignature(pubKey *bls.PublicKey, sig *bls.Signature) *crypto.Signature {
+	ts := ThresholdSignature{
+		PublicKey: pubKey.ToBytes(),
+		Signature: sig.ToBytes(),
+	}
+	tsBytes, err := rlp.EncodeToBytes(ts)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to encode threshold signature: %v", err))
+	}
+	wrapped, _ := crypto.SignatureFromBytes(append(append(common.Bytes{}, thresholdSignaturePrefix...), tsBytes...))
+	return wrapped
+}
+
+// ThresholdSignatureFromSignature decodes the threshold signature carried in the
+// signature. It returns false if the signature is not a threshold signature.
+func ThresholdSignatureFromSignature(sig *crypto.Signature) (*ThresholdSignature, bool) {
+	if sig == nil || !bytes.HasPrefix(sig.ToBytes(), thresholdSignaturePrefix) {
+		return nil, false
+	}
+	ts := &ThresholdSignature{}
+	if err := rlp.DecodeBytes(sig.ToBytes()[len(thresholdSignaturePrefix):], ts); err != nil {
+		return nil, false
+	}
+	return ts, true
+}
+
+// Verify checks that the group public key belongs to the address, and that the
+// signature is signed by the group key
+func (ts *ThresholdSignature) Verify(msg common.Bytes, addr common.Address) bool {
+	pubKey, sig, ok := ts.decode(addr)
+	if !ok {
+		return false
+	}
+	return pubKey.Verify(msg, sig)
+}
+
+// decode decodes the group public key and the signature, and checks that the
+// group public key belongs to the address
+func (ts *ThresholdSignature) decode(addr common.Address) (*bls.PublicKey, *bls.Signature, bool) {
+	pubKey, err := bls.PublicKeyFromBytes(ts.PublicKey)
+	if err != nil {
+		return nil, nil, false
+	}
+	if pubKey.Address() != addr {
+		return nil, nil, false
+	}
+	sig, err := bls.SignatureFromBytes(ts.Signature)
+	if err != nil {
+		return nil, nil, false
+	}
+	return pubKey, sig, true
+}
+
+// VerifyValidatorSignature verifies the signature of a validator, which is signed
+// either by the key of the validator address, or by the group key of a validator
+// using threshold signing
+func VerifyValidatorSignature(sig *crypto.Signature, msg common.Bytes, addr common.Address) bool {
+	if ts, ok := ThresholdSignatureFromSignature(sig); ok {
+		return ts.Verify(msg, addr)
+	}
+	return sig.Verify(msg, addr)
+}
+
+// VerifyValidatorSignatures verifies a batch of validator signatures, where sigs[i]
+// is expected to be the signature of msgs[i] by the validator of addrs[i]. The
+// threshold signatures are batch verified together, and so are the others.
+func VerifyValidatorSignatures(sigs []*crypto.Signature, msgs []common.Bytes, addrs []common.Address) bool {
+	if len(msgs) != len(sigs) || len(addrs) != len(sigs) {
+		return false
+	}
+
+	ecdsaSigs, ecdsaMsgs, ecdsaAddrs := []*crypto.Signature{}, []common.Bytes{}, []common.Address{}
+	blsSigs, blsMsgs, blsPubKeys := []*bls.Signature{}, []common.Bytes{}, []*bls.PublicKey{}
+	for i, sig := range sigs {
+		ts, ok := ThresholdSignatureFromSignature(sig)
+		if !ok {
+			ecdsaSigs = append(ecdsaSigs, sig)
+			ecdsaMsgs = append(ecdsaMsgs, msgs[i])
+			ecdsaAddrs = append(ecdsaAddrs, addrs[i])
+			continue
+		}
+		pubKey, blsSig, ok := ts.decode(addrs[i])
+		if !ok {
+			return false
+		}
+		blsSigs = append(blsSigs, blsSig)
+		blsMsgs = append(blsMsgs, msgs[i])
+		blsPubKeys = append(blsPubKeys, pubKey)
+	}
+	return crypto.VerifyAddressBatch(ecdsaSigs, ecdsaMsgs, ecdsaAddrs) &&
+		bls.VerifyBatch(blsSigs, blsMsgs, blsPubKeys)
+}
@@ -0,0 +1,59 @@
+package core
+
+import (
+	"fmt"
+
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/rlp"
+	"github.com/thetatoken/ukulele/store/trie"
+)
+
+// TxKey returns the key of the transaction at the given index in the transaction
+// trie of a block.
+func TxKey(index int) common.Bytes {
+	key, _ := rlp.EncodeToBytes(uint64(index))
+	return key
+}
+
+// CalculateTxHash returns the root of the trie of the transactions of a block, keyed
+// by their indexes, so that a transaction can be proven to be in a block from the
+// block header only.
+func CalculateTxHash(txs []common.Bytes) common.Hash {
+	return txTrie(txs).Hash()
+}
+
+// ValidateTxHash checks that the TxHash of the block header is the hash of the
+// transactions. The TxHash is required from the activation height on, so that the hash
+// of the block, and its commit certificate, commit to its transactions; only the blocks
+// below it, proposed before the TxHash was set, may have an empty TxHash.
+func (h *BlockHeader) ValidateTxHash(txs []common.Bytes, activationHeight uint64) error {
+	if h.TxHash.IsEmpty() {
+		if h.Height < activationHeight {
+			return nil
+		}
+		return fmt.Errorf("Block %v has no TxHash", h.Hash().Hex())
+	}
+	if h.TxHash != CalculateTxHash(txs) {
+		return fmt.Errorf("Transactions do not match TxHash of block %v", h.Hash().Hex())
+	}
+	return nil
+}
+
+// ProveTx returns the Merkle proof of the transaction at the given index against the
+// TxHash of the block.
+func ProveTx(txs []common.Bytes, index int) ([]common.Bytes, error) {
+	proof := trie.ProofList{}
+	if err := txTrie(txs).Prove(TxKey(index), 0, &proof); err != nil {
+		return nil, err
+	}
+	return proof, nil
+}
+
+func txTrie(txs []common.Bytes) *trie.Trie {
+	// The trie is kept in memory, so it doesn't need a database
+	tr, _ := trie.New(common.Hash{}, trie.NewDatabase(nil))
+	for i, tx := range txs {
+		tr.Update(TxKey(i), tx)
+	}
+	return tr
+}
@@ -3,10 +3,12 @@ package core
 import (
 	"bytes"
 	"errors"
+	"io"
 	"sort"
 
 	"github.com/thetatoken/ukulele/common"
 	"github.com/thetatoken/ukulele/crypto"
+	"github.com/thetatoken/ukulele/rlp"
 )
 
 var (
@@ -124,3 +126,61 @@ func (s *ValidatorSet) HasMajority(votes *VoteSet) bool {
 func (s *ValidatorSet) Validators() []Validator {
 	return s.validators
 }
+
+// validatorRLP is the encoding of a validator in an encoded validator set.
+type validatorRLP struct {
+	PubKey common.Bytes
+	Stake  uint64
+}
+
+var _ rlp.Encoder = (*ValidatorSet)(nil)
+
+// EncodeRLP implements RLP Encoder interface.
+func (s *ValidatorSet) EncodeRLP(w io.Writer) error {
+	validators := []validatorRLP{}
+	if s != nil {
+		for _, v := range s.validators {
+			pubKey := v.PublicKey()
+			validators = append(validators, validatorRLP{PubKey: pubKey.ToBytes(), Stake: v.Stake()})
+		}
+	}
+	return rlp.Encode(w, validators)
+}
+
+var _ rlp.Decoder = (*ValidatorSet)(nil)
+
+// DecodeRLP implements RLP Decoder interface.
+func (s *ValidatorSet) DecodeRLP(stream *rlp.Stream) error {
+	validators := []validatorRLP{}
+	if err := stream.Decode(&validators); err != nil {
+		return err
+	}
+	s.validators = []Validator{}
+	for _, v := range validators {
+		pubKey, err := crypto.PublicKeyFromBytes(v.PubKey)
+		if err != nil {
+			return err
+		}
+		s.AddValidator(Validator{*pubKey, v.Stake})
+	}
+	return nil
+}
+
+// Hash returns the hash of the encoding of the validator set, which lists the
+// validators by ID.
+func (s *ValidatorSet) Hash() common.Hash {
+	raw, _ := rlp.EncodeToBytes(s)
+	return crypto.Keccak256Hash(raw)
+}
+
+// ValidatorSetChangeHash returns the hash of the validator set in effect from the epoch
+// after the given epoch if it differs from the validator set of the epoch, and an empty
+// hash otherwise. The header of a block commits to it, so that light clients can follow
+// the validator set changes from the headers certified by the previous validators.
+func ValidatorSetChangeHash(valMgr ValidatorManager, epoch uint64) common.Hash {
+	next := valMgr.GetValidatorSetForEpoch(epoch + 1).Hash()
+	if next == valMgr.GetValidatorSetForEpoch(epoch).Hash() {
+		return common.Hash{}
+	}
+	return next
+}
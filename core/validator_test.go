@@ -0,0 +1,34 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thetatoken/ukulele/crypto"
+	"github.com/thetatoken/ukulele/rlp"
+)
+
+func TestValidatorSetEncoding(t *testing.T) {
+	assert := assert.New(t)
+
+	validators := NewValidatorSet()
+	for i := 0; i < 3; i++ {
+		_, pubKey, err := crypto.GenerateKeyPair()
+		assert.Nil(err)
+		validators.AddValidator(NewValidator(pubKey.ToBytes(), uint64(100*(i+1))))
+	}
+
+	raw, err := rlp.EncodeToBytes(validators)
+	assert.Nil(err)
+	decoded := &ValidatorSet{}
+	assert.Nil(rlp.DecodeBytes(raw, decoded))
+	assert.Equal(validators.Hash(), decoded.Hash())
+	assert.Equal(validators.TotalStake(), decoded.TotalStake())
+	for i, v := range decoded.Validators() {
+		assert.Equal(validators.Validators()[i].ID(), v.ID())
+	}
+
+	changed := validators.Copy()
+	changed.validators[0].stake++
+	assert.NotEqual(validators.Hash(), changed.Hash())
+}
@@ -12,15 +12,82 @@ import (
 	"github.com/thetatoken/ukulele/rlp"
 )
 
-// Proposal represents a proposal of a new block.
+// Proposal represents a proposal of a new block. A compact proposal carries the hashes
+// of all the transactions of the block in TxHashes, while its block only carries the
+// transactions the replicas cannot find in their mempools, e.g. the coinbase
+// transaction. The replicas fetch the other transactions they miss from the proposer.
 type Proposal struct {
 	Block      *Block `rlp:"nil"`
 	ProposerID common.Address
-	Votes      *VoteSet `rlp:"nil"`
+	Votes      *VoteSet          `rlp:"nil"`
+	Reveals    []*ProposerReveal // Recent proposer reveals, when the proposer is selected by VRF
+	TxHashes   []common.Hash     `rlp:"tail"`
 }
 
 func (p Proposal) String() string {
-	return fmt.Sprintf("Proposal{block: %v, proposer: %v, votes: %v}", p.Block, p.ProposerID, p.Votes)
+	return fmt.Sprintf("Proposal{block: %v, proposer: %v, votes: %v, reveals: %v, txHashes: %v}",
+		p.Block, p.ProposerID, p.Votes, p.Reveals, len(p.TxHashes))
+}
+
+// IsCompact returns whether the proposal carries the hashes of the transactions of its
+// block instead of the transactions.
+func (p *Proposal) IsCompact() bool {
+	return len(p.TxHashes) > 0
+}
+
+// Compact turns the proposal into a compact proposal, which keeps the transactions of
+// the block for which embed returns true.
+func (p *Proposal) Compact(embed func(tx common.Bytes) bool) {
+	block := &Block{BlockHeader: p.Block.BlockHeader, Txs: []common.Bytes{}}
+	txHashes := make([]common.Hash, 0, len(p.Block.Txs))
+	for _, tx := range p.Block.Txs {
+		txHashes = append(txHashes, crypto.Keccak256Hash(tx))
+		if embed(tx) {
+			block.Txs = append(block.Txs, tx)
+		}
+	}
+	p.Block = block
+	p.TxHashes = txHashes
+}
+
+// ResolveTxs rebuilds the block of a compact proposal from the transactions it embeds
+// and the transactions found by lookup. It returns the hashes of the transactions not
+// found if any, and an error if the transactions do not match the header of the block,
+// with the rule of ValidateTxHash.
+func (p *Proposal) ResolveTxs(lookup func(hash common.Hash) (common.Bytes, bool), txHashActivationHeight uint64) (*Block, []common.Hash, error) {
+	if !p.IsCompact() {
+		return p.Block, nil, nil
+	}
+	embedded := make(map[common.Hash]common.Bytes, len(p.Block.Txs))
+	for _, tx := range p.Block.Txs {
+		embedded[crypto.Keccak256Hash(tx)] = tx
+	}
+	txs := make([]common.Bytes, 0, len(p.TxHashes))
+	missing := []common.Hash{}
+	for _, hash := range p.TxHashes {
+		tx, ok := embedded[hash]
+		if !ok {
+			tx, ok = lookup(hash)
+		}
+		if !ok {
+			missing = append(missing, hash)
+			continue
+		}
+		txs = append(txs, tx)
+	}
+	if len(missing) > 0 {
+		return nil, missing, nil
+	}
+	if err := p.Block.ValidateTxHash(txs, txHashActivationHeight); err != nil {
+		return nil, nil, fmt.Errorf("Invalid compact proposal: %v", err)
+	}
+	return &Block{BlockHeader: p.Block.BlockHeader, Txs: txs}, nil, nil
+}
+
+// BlockTxs is the response to a request for the transactions a compact proposal omits.
+type BlockTxs struct {
+	Block common.Hash
+	Txs   []common.Bytes
 }
 
 // CommitCertificate represents a commit made a majority of validators.
@@ -52,28 +119,46 @@ func (cc *CommitCertificate) IsValid() bool {
 // Vote represents a vote on a block by a validaor.
 type Vote struct {
 	Block     common.Hash       // Hash of the tip as seen by the voter.
-	Epoch     uint64            // Voter's current epoch. It doesn't need to equal the epoch in the block above.
+	Height    uint64            // Height of the block above, 0 if the vote has no block.
+	Epoch     uint64            // Voter's current epoch. It doesn't need to equal the epoch in the block above, but cannot precede it.
 	ID        common.Address    // Voter's address.
 	Signature *crypto.Signature `rlp:"nil"`
 }
 
 func (v Vote) String() string {
-	return fmt.Sprintf("Vote{ID: %s, block: %s,  Epoch: %v}", v.ID, v.Block.Hex(), v.Epoch)
+	return fmt.Sprintf("Vote{ID: %s, block: %s, Height: %v, Epoch: %v}", v.ID, v.Block.Hex(), v.Height, v.Epoch)
 }
 
 // SignBytes returns raw bytes to be signed.
 func (v Vote) SignBytes() common.Bytes {
 	vv := Vote{
-		Block: v.Block,
-		Epoch: v.Epoch,
-		ID:    v.ID,
+		Block:  v.Block,
+		Height: v.Height,
+		Epoch:  v.Epoch,
+		ID:     v.ID,
 	}
 	raw, _ := rlp.EncodeToBytes(vv)
 	return raw
 }
 
+// ValidateForBlock checks that the vote is bound to the given block, i.e. that it has
+// the hash and the height of the block, and an epoch no earlier than the epoch of the
+// block, so that the vote cannot be replayed for another block or epoch.
+func (v Vote) ValidateForBlock(block *BlockHeader) result.Result {
+	if v.Block != block.Hash() {
+		return result.Error("Vote is for block %v, not %v", v.Block.Hex(), block.Hash().Hex())
+	}
+	if v.Height != block.Height {
+		return result.Error("Vote height %v does not match the block height %v", v.Height, block.Height)
+	}
+	if v.Epoch < block.Epoch {
+		return result.Error("Vote epoch %v precedes the block epoch %v", v.Epoch, block.Epoch)
+	}
+	return result.OK
+}
+
 // SetSignature sets given signature in vote.
-func (v Vote) SetSignature(sig *crypto.Signature) {
+func (v *Vote) SetSignature(sig *crypto.Signature) {
 	v.Signature = sig
 }
 
@@ -82,10 +167,13 @@ func (v Vote) Validate() result.Result {
 	if v.ID.IsEmpty() {
 		return result.Error("Voter is not specified")
 	}
-	if v.Signature.IsEmpty() {
+	if v.Block.IsEmpty() && v.Height != 0 {
+		return result.Error("Vote without block has height %v", v.Height)
+	}
+	if v.Signature == nil || v.Signature.IsEmpty() {
 		return result.Error("Vote is not signed")
 	}
-	if !v.Signature.Verify(v.SignBytes(), v.ID) {
+	if !VerifyValidatorSignature(v.Signature, v.SignBytes(), v.ID) {
 		return result.Error("Signature verification failed")
 	}
 	return result.OK
@@ -138,9 +226,27 @@ func (s *VoteSet) Votes() []Vote {
 	return ret
 }
 
-// Validate checks the vote set is legitimate.
+// Validate checks the vote set is legitimate. The signatures of the votes are batch
+// verified, and only if the batch fails are the votes validated one by one to find
+// the invalid one.
 func (s *VoteSet) Validate() result.Result {
-	for _, vote := range s.votes {
+	votes := s.Votes()
+	sigs := make([]*crypto.Signature, 0, len(votes))
+	msgs := make([]common.Bytes, 0, len(votes))
+	addrs := make([]common.Address, 0, len(votes))
+	for _, vote := range votes {
+		if vote.ID.IsEmpty() || vote.Signature == nil || vote.Signature.IsEmpty() {
+			return result.Error("Contains invalid vote: %s", vote.String())
+		}
+		sigs = append(sigs, vote.Signature)
+		msgs = append(msgs, vote.SignBytes())
+		addrs = append(addrs, vote.ID)
+	}
+	if VerifyValidatorSignatures(sigs, msgs, addrs) {
+		return result.OK
+	}
+
+	for _, vote := range votes {
 		if vote.Validate().IsError() {
 			return result.Error("Contains invalid vote: %s", vote.String())
 		}
@@ -225,6 +331,17 @@ func (s *VoteSet) UniqueVoter() *VoteSet {
 	return ret
 }
 
+// FilterByBlock returns the votes bound to the given block, see Vote.ValidateForBlock.
+func (s *VoteSet) FilterByBlock(block *BlockHeader) *VoteSet {
+	ret := NewVoteSet()
+	for _, vote := range s.votes {
+		if vote.ValidateForBlock(block).IsOK() {
+			ret.AddVote(vote)
+		}
+	}
+	return ret
+}
+
 // VoteByID implements sort.Interface for []Vote based on Voter's ID.
 type VoteByID []Vote
 
@@ -5,6 +5,8 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/crypto"
+	"github.com/thetatoken/ukulele/crypto/bls"
 	"github.com/thetatoken/ukulele/rlp"
 )
 
@@ -107,3 +109,256 @@ func TestDedup(t *testing.T) {
 	assert.Equal(v.ID, common.HexToAddress("A1"))
 	assert.Equal(uint64(5), v.Epoch)
 }
+
+func TestVoteValidate(t *testing.T) {
+	assert := assert.New(t)
+
+	privKey, _, err := crypto.GenerateKeyPair()
+	assert.Nil(err)
+	vote := Vote{
+		Block: CreateTestBlock("B1", "").Hash(),
+		ID:    privKey.PublicKey().Address(),
+		Epoch: 1,
+	}
+	assert.True(vote.Validate().IsError())
+
+	sig, err := privKey.Sign(vote.SignBytes())
+	assert.Nil(err)
+	vote.SetSignature(sig)
+	assert.True(vote.Validate().IsOK())
+
+	vote.Epoch = 2
+	assert.True(vote.Validate().IsError())
+}
+
+func TestThresholdSignedVote(t *testing.T) {
+	assert := assert.New(t)
+
+	// 2-of-3 validator key
+	dealings := []*bls.Dealing{}
+	for i := 0; i < 3; i++ {
+		dealing, err := bls.NewDealing(nil, 2, 3)
+		assert.Nil(err)
+		dealings = append(dealings, dealing)
+	}
+	keyShares := []*bls.KeyShare{}
+	for j := 1; j <= 3; j++ {
+		commitments := []bls.Commitment{}
+		shares := []*bls.PrivateKey{}
+		for _, dealing := range dealings {
+			commitments = append(commitments, dealing.Commitment)
+			shares = append(shares, dealing.Shares[j-1])
+		}
+		keyShare, err := bls.CombineDealings(uint64(j), commitments, shares)
+		assert.Nil(err)
+		keyShares = append(keyShares, keyShare)
+	}
+	groupKey := keyShares[0].GroupPublicKey()
+
+	vote := Vote{
+		Block: CreateTestBlock("B1", "").Hash(),
+		ID:    groupKey.Address(),
+		Epoch: 1,
+	}
+	signBytes := vote.SignBytes()
+	sig, err := bls.RecoverSignature([]*bls.SignatureShare{keyShares[2].Sign(signBytes), keyShares[0].Sign(signBytes)}, 2)
+	assert.Nil(err)
+	vote.SetSignature(NewThresholdSignature(groupKey, sig))
+	assert.True(vote.Validate().IsOK())
+
+	// The signature survives the encoding of votes
+	b, err := rlp.EncodeToBytes(vote)
+	assert.Nil(err)
+	decoded := Vote{}
+	assert.Nil(rlp.DecodeBytes(b, &decoded))
+	assert.True(decoded.Validate().IsOK())
+
+	// A single share is not a valid signature
+	vote.SetSignature(NewThresholdSignature(groupKey, keyShares[0].Sign(signBytes).Signature))
+	assert.True(vote.Validate().IsError())
+
+	// The group key needs to match the voter
+	vote.SetSignature(NewThresholdSignature(groupKey, sig))
+	vote.ID = common.HexToAddress("A1")
+	assert.True(vote.Validate().IsError())
+}
+
+func TestVoteSetValidate(t *testing.T) {
+	assert := assert.New(t)
+
+	votes := createTestVotes(10)
+	assert.True(votes.Validate().IsOK())
+	assert.True(NewVoteSet().Validate().IsOK())
+
+	// The invalid vote is reported
+	invalid := createTestVotes(10)
+	vote := invalid.Votes()[6]
+	vote.Epoch = 2
+	invalid.AddVote(vote)
+	res := invalid.Validate()
+	assert.True(res.IsError())
+	assert.Contains(res.Message, vote.String())
+
+	// An unsigned vote
+	unsigned := createTestVotes(10)
+	unsigned.AddVote(Vote{
+		Block: CreateTestBlock("B1", "").Hash(),
+		ID:    common.HexToAddress("A1"),
+		Epoch: 1,
+	})
+	assert.True(unsigned.Validate().IsError())
+}
+
+func BenchmarkVoteSetValidateSerial(b *testing.B) {
+	votes := createTestVotes(100)
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		for _, vote := range freshVotes(votes).Votes() {
+			if vote.Validate().IsError() {
+				b.Fatal("Invalid vote")
+			}
+		}
+	}
+}
+
+func BenchmarkVoteSetValidateBatch(b *testing.B) {
+	votes := createTestVotes(100)
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		if freshVotes(votes).Validate().IsError() {
+			b.Fatal("Invalid vote set")
+		}
+	}
+}
+
+func TestVoteValidateForBlock(t *testing.T) {
+	assert := assert.New(t)
+
+	block := CreateTestBlock("B1", "")
+	block.Height = 10
+	block.Epoch = 5
+	privKey, _, _ := crypto.GenerateKeyPair()
+	vote := Vote{
+		Block:  block.Hash(),
+		Height: 10,
+		Epoch:  5,
+		ID:     privKey.PublicKey().Address(),
+	}
+	assert.True(vote.ValidateForBlock(block.BlockHeader).IsOK())
+
+	// The height is signed
+	sig, _ := privKey.Sign(vote.SignBytes())
+	vote.SetSignature(sig)
+	assert.True(vote.Validate().IsOK())
+	replayed := vote
+	replayed.Height = 11
+	assert.True(replayed.Validate().IsError())
+
+	// Votes from later epochs are bound to the block too
+	later := vote
+	later.Epoch = 7
+	assert.True(later.ValidateForBlock(block.BlockHeader).IsOK())
+
+	earlier := vote
+	earlier.Epoch = 4
+	assert.True(earlier.ValidateForBlock(block.BlockHeader).IsError())
+	assert.True(replayed.ValidateForBlock(block.BlockHeader).IsError())
+	assert.True(vote.ValidateForBlock(CreateTestBlock("B2", "").BlockHeader).IsError())
+
+	votes := NewVoteSet()
+	votes.AddVote(vote)
+	votes.AddVote(later)
+	votes.AddVote(earlier)
+	assert.Equal(2, votes.FilterByBlock(block.BlockHeader).Size())
+
+	// A vote without block has no height
+	nilVote := Vote{Height: 10, Epoch: 5, ID: privKey.PublicKey().Address()}
+	sig, _ = privKey.Sign(nilVote.SignBytes())
+	nilVote.SetSignature(sig)
+	assert.True(nilVote.Validate().IsError())
+}
+
+func createTestVotes(size int) *VoteSet {
+	votes := NewVoteSet()
+	for i := 0; i < size; i++ {
+		privKey, _, _ := crypto.GenerateKeyPair()
+		vote := Vote{
+			Block: CreateTestBlock("B1", "").Hash(),
+			ID:    privKey.PublicKey().Address(),
+			Epoch: 1,
+		}
+		sig, _ := privKey.Sign(vote.SignBytes())
+		vote.SetSignature(sig)
+		votes.AddVote(vote)
+	}
+	return votes
+}
+
+// freshVotes copies the votes with signatures that do not remember their signers
+func freshVotes(votes *VoteSet) *VoteSet {
+	ret := NewVoteSet()
+	for _, vote := range votes.Votes() {
+		vote.Signature, _ = crypto.SignatureFromBytes(vote.Signature.ToBytes())
+		ret.AddVote(vote)
+	}
+	return ret
+}
+
+func TestCompactProposal(t *testing.T) {
+	assert := assert.New(t)
+
+	coinbase := common.Bytes("coinbase")
+	tx1 := common.Bytes("tx1")
+	tx2 := common.Bytes("tx2")
+	block := CreateTestBlock("C1", "")
+	block.Txs = []common.Bytes{coinbase, tx1, tx2}
+	block.TxHash = CalculateTxHash(block.Txs)
+
+	proposal := &Proposal{Block: block, ProposerID: common.HexToAddress("A1")}
+	assert.False(proposal.IsCompact())
+	proposal.Compact(func(tx common.Bytes) bool { return string(tx) == "coinbase" })
+	assert.True(proposal.IsCompact())
+	assert.Equal([]common.Bytes{coinbase}, proposal.Block.Txs)
+	assert.Equal(block.Hash(), proposal.Block.Hash())
+	assert.Equal(3, len(block.Txs))
+
+	raw, err := rlp.EncodeToBytes(proposal)
+	assert.Nil(err)
+	decoded := &Proposal{}
+	assert.Nil(rlp.DecodeBytes(raw, decoded))
+	assert.Equal(proposal.TxHashes, decoded.TxHashes)
+
+	// The mempool only has tx2
+	mempool := map[common.Hash]common.Bytes{crypto.Keccak256Hash(tx2): tx2}
+	lookup := func(hash common.Hash) (common.Bytes, bool) {
+		tx, ok := mempool[hash]
+		return tx, ok
+	}
+	resolved, missing, err := decoded.ResolveTxs(lookup, 0)
+	assert.Nil(err)
+	assert.Nil(resolved)
+	assert.Equal([]common.Hash{crypto.Keccak256Hash(tx1)}, missing)
+
+	mempool[crypto.Keccak256Hash(tx1)] = tx1
+	resolved, missing, err = decoded.ResolveTxs(lookup, 0)
+	assert.Nil(err)
+	assert.Empty(missing)
+	assert.Equal(block.Hash(), resolved.Hash())
+	assert.Equal(block.Txs, resolved.Txs)
+
+	// Transactions not matching the header of the block
+	decoded.TxHashes = []common.Hash{decoded.TxHashes[0], decoded.TxHashes[2], decoded.TxHashes[1]}
+	_, _, err = decoded.ResolveTxs(lookup, 0)
+	assert.NotNil(err)
+
+	// Proposals without tx hashes decode as before
+	full := &Proposal{Block: block, ProposerID: common.HexToAddress("A1")}
+	raw, err = rlp.EncodeToBytes(full)
+	assert.Nil(err)
+	decoded = &Proposal{}
+	assert.Nil(rlp.DecodeBytes(raw, decoded))
+	assert.False(decoded.IsCompact())
+	resolved, _, err = decoded.ResolveTxs(lookup, 0)
+	assert.Nil(err)
+	assert.Equal(block.Txs, resolved.Txs)
+}
@@ -0,0 +1,334 @@
+// Package bls implements BLS signatures over the BN256 curve, and the threshold
+// (t-of-n) signing and distributed key generation built on them.
+//
+// Signatures are points in G1 and public keys are points in G2. A signature of
+// msg under the private key x is x * H(msg), where H hashes msg to a point of G1,
+// and is verified with the pairing check e(sig, g2) == e(H(msg), x * g2).
+package bls
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/big"
+
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/common/hexutil"
+	"github.com/thetatoken/ukulele/crypto"
+	"github.com/thetatoken/ukulele/crypto/bn256"
+)
+
+const (
+	// PrivateKeyLength is the length of a serialized private key
+	PrivateKeyLength = 32
+	// PublicKeyLength is the length of a serialized public key (a point in G2)
+	PublicKeyLength = 128
+	// SignatureLength is the length of a serialized signature (a point in G1)
+	SignatureLength = 64
+)
+
+// hashDomainSeparator separates the hash to G1 from other uses of Keccak256
+var hashDomainSeparator = []byte("Theta BLS signature")
+
+var (
+	// order is the order of G1 and G2
+	order, _ = new(big.Int).SetString("21888242871839275222246405745257275088548364400416034343698204186575808495617", 10)
+	// fieldModulus is the modulus of the base field of G1
+	fieldModulus, _ = new(big.Int).SetString("21888242871839275222246405745257275088696311157297823662689037894645226208583", 10)
+	// sqrtExponent is (p + 1) / 4, since p = 3 mod 4 the square root of a is a^((p + 1) / 4)
+	sqrtExponent = new(big.Int).Rsh(new(big.Int).Add(fieldModulus, big.NewInt(1)), 2)
+	// curveB is the constant of the curve y^2 = x^3 + 3
+	curveB = big.NewInt(3)
+	// batchCoefficientBound bounds the random coefficients of the batch verification
+	batchCoefficientBound = new(big.Int).Lsh(big.NewInt(1), 128)
+)
+
+//
+// PrivateKey represents a BLS private key, a scalar modulo the group order
+//
+type PrivateKey struct {
+	x *big.Int
+}
+
+// GenerateKey generates a random private key with the randomness from r. It uses
+// crypto/rand if r is nil.
+func GenerateKey(r io.Reader) (*PrivateKey, error) {
+	x, err := randomScalar(r)
+	if err != nil {
+		return nil, err
+	}
+	return &PrivateKey{x: x}, nil
+}
+
+// PrivateKeyFromBytes decodes a private key
+func PrivateKeyFromBytes(skBytes common.Bytes) (*PrivateKey, error) {
+	if len(skBytes) != PrivateKeyLength {
+		return nil, errors.New("Invalid BLS private key length")
+	}
+	x := new(big.Int).SetBytes(skBytes)
+	if x.Sign() == 0 || x.Cmp(order) >= 0 {
+		return nil, errors.New("Invalid BLS private key")
+	}
+	return &PrivateKey{x: x}, nil
+}
+
+// ToBytes returns the bytes representation of the private key
+func (sk *PrivateKey) ToBytes() common.Bytes {
+	return common.LeftPadBytes(sk.x.Bytes(), PrivateKeyLength)
+}
+
+// PublicKey returns the public key corresponding to the private key
+func (sk *PrivateKey) PublicKey() *PublicKey {
+	return &PublicKey{p: new(bn256.G2).ScalarBaseMult(sk.x)}
+}
+
+// Sign signs the given message with the private key
+func (sk *PrivateKey) Sign(msg common.Bytes) *Signature {
+	return &Signature{p: new(bn256.G1).ScalarMult(hashToG1(msg), sk.x)}
+}
+
+// MarshalJSON encodes the private key as a hex string
+func (sk *PrivateKey) MarshalJSON() ([]byte, error) {
+	return json.Marshal(hexutil.Bytes(sk.ToBytes()))
+}
+
+// UnmarshalJSON decodes the private key from a hex string
+func (sk *PrivateKey) UnmarshalJSON(input []byte) error {
+	var b hexutil.Bytes
+	if err := b.UnmarshalJSON(input); err != nil {
+		return err
+	}
+	decoded, err := PrivateKeyFromBytes(common.Bytes(b))
+	if err != nil {
+		return err
+	}
+	*sk = *decoded
+	return nil
+}
+
+//
+// PublicKey represents a BLS public key, a point in G2
+//
+type PublicKey struct {
+	p *bn256.G2
+}
+
+// PublicKeyFromBytes decodes a public key. It rejects the identity and the points
+// outside of the prime order subgroup.
+func PublicKeyFromBytes(pkBytes common.Bytes) (*PublicKey, error) {
+	if len(pkBytes) != PublicKeyLength {
+		return nil, errors.New("Invalid BLS public key length")
+	}
+	p := new(bn256.G2)
+	if _, err := p.Unmarshal(pkBytes); err != nil {
+		return nil, err
+	}
+	if isZero(p.Marshal()) {
+		return nil, errors.New("Invalid BLS public key")
+	}
+	if !isZero(new(bn256.G2).ScalarMult(p, order).Marshal()) {
+		return nil, errors.New("BLS public key is not in the prime order subgroup")
+	}
+	return &PublicKey{p: p}, nil
+}
+
+// ToBytes returns the bytes representation of the public key
+func (pk *PublicKey) ToBytes() common.Bytes {
+	return pk.p.Marshal()
+}
+
+// Address returns the address corresponding to the public key. The address is
+// derived from the 128-byte public key, so it cannot collide with the address of
+// a 64-byte secp256k1 public key.
+func (pk *PublicKey) Address() common.Address {
+	return common.BytesToAddress(crypto.Keccak256(pk.ToBytes())[12:])
+}
+
+// Equals returns whether the two public keys are the same
+func (pk *PublicKey) Equals(other *PublicKey) bool {
+	return other != nil && bytes.Equal(pk.ToBytes(), other.ToBytes())
+}
+
+// Verify verifies the signature of the message with the public key
+func (pk *PublicKey) Verify(msg common.Bytes, sig *Signature) bool {
+	if sig == nil || sig.p == nil || pk.p == nil {
+		return false
+	}
+	h := new(bn256.G1).Neg(hashToG1(msg))
+	return bn256.PairingCheck([]*bn256.G1{sig.p, h}, []*bn256.G2{g2Generator(), pk.p})
+}
+
+// MarshalJSON encodes the public key as a hex string
+func (pk *PublicKey) MarshalJSON() ([]byte, error) {
+	return json.Marshal(hexutil.Bytes(pk.ToBytes()))
+}
+
+// UnmarshalJSON decodes the public key from a hex string
+func (pk *PublicKey) UnmarshalJSON(input []byte) error {
+	var b hexutil.Bytes
+	if err := b.UnmarshalJSON(input); err != nil {
+		return err
+	}
+	decoded, err := PublicKeyFromBytes(common.Bytes(b))
+	if err != nil {
+		return err
+	}
+	*pk = *decoded
+	return nil
+}
+
+//
+// Signature represents a BLS signature, a point in G1
+//
+type Signature struct {
+	p *bn256.G1
+}
+
+// SignatureFromBytes decodes a signature
+func SignatureFromBytes(sigBytes common.Bytes) (*Signature, error) {
+	if len(sigBytes) != SignatureLength {
+		return nil, errors.New("Invalid BLS signature length")
+	}
+	p := new(bn256.G1)
+	if _, err := p.Unmarshal(sigBytes); err != nil {
+		return nil, err
+	}
+	return &Signature{p: p}, nil
+}
+
+// ToBytes returns the bytes representation of the signature
+func (sig *Signature) ToBytes() common.Bytes {
+	return sig.p.Marshal()
+}
+
+// MarshalJSON encodes the signature as a hex string
+func (sig *Signature) MarshalJSON() ([]byte, error) {
+	return json.Marshal(hexutil.Bytes(sig.ToBytes()))
+}
+
+// UnmarshalJSON decodes the signature from a hex string
+func (sig *Signature) UnmarshalJSON(input []byte) error {
+	var b hexutil.Bytes
+	if err := b.UnmarshalJSON(input); err != nil {
+		return err
+	}
+	decoded, err := SignatureFromBytes(common.Bytes(b))
+	if err != nil {
+		return err
+	}
+	*sig = *decoded
+	return nil
+}
+
+// VerifyBatch verifies a batch of signatures, where sigs[i] is expected to be the
+// signature of msgs[i] by pubKeys[i]. It returns true only if all the signatures
+// are valid.
+//
+// Instead of one pairing check per signature, the batch is verified with a single
+// pairing check of a random linear combination of the signatures:
+//
+//	e(sum(r_i * sig_i), g2) == prod(e(r_i * H(msg_i), pk_i))
+//
+// where r_i are random 128-bit coefficients, so that invalid signatures cannot
+// cancel each other out, except with negligible probability.
+func VerifyBatch(sigs []*Signature, msgs []common.Bytes, pubKeys []*PublicKey) bool {
+	if len(msgs) != len(sigs) || len(pubKeys) != len(sigs) {
+		return false
+	}
+	if len(sigs) == 0 {
+		return true
+	}
+	if len(sigs) == 1 {
+		return pubKeys[0] != nil && pubKeys[0].Verify(msgs[0], sigs[0])
+	}
+
+	g1s := make([]*bn256.G1, 0, len(sigs)+1)
+	g2s := make([]*bn256.G2, 0, len(sigs)+1)
+	var aggregate *bn256.G1
+	for i, sig := range sigs {
+		if sig == nil || sig.p == nil || pubKeys[i] == nil || pubKeys[i].p == nil {
+			return false
+		}
+		r, err := randomCoefficient()
+		if err != nil {
+			return false
+		}
+		weighted := new(bn256.G1).ScalarMult(sig.p, r)
+		if aggregate == nil {
+			aggregate = weighted
+		} else {
+			aggregate = new(bn256.G1).Add(aggregate, weighted)
+		}
+		h := new(bn256.G1).ScalarMult(hashToG1(msgs[i]), r)
+		g1s = append(g1s, new(bn256.G1).Neg(h))
+		g2s = append(g2s, pubKeys[i].p)
+	}
+	g1s = append(g1s, aggregate)
+	g2s = append(g2s, g2Generator())
+	return bn256.PairingCheck(g1s, g2s)
+}
+
+// hashToG1 maps the message to a point of G1 by try-and-increment: it hashes the
+// message with a counter until the hash is the x coordinate of a point on the
+// curve. G1 has cofactor 1, so every point on the curve is in G1.
+func hashToG1(msg common.Bytes) *bn256.G1 {
+	for ctr := 0; ; ctr++ {
+		h := crypto.Keccak256(hashDomainSeparator, msg, []byte{byte(ctr >> 8), byte(ctr)})
+		x := new(big.Int).Mod(new(big.Int).SetBytes(h), fieldModulus)
+
+		y2 := new(big.Int).Exp(x, big.NewInt(3), fieldModulus)
+		y2.Add(y2, curveB).Mod(y2, fieldModulus)
+		y := new(big.Int).Exp(y2, sqrtExponent, fieldModulus)
+		if new(big.Int).Exp(y, big.NewInt(2), fieldModulus).Cmp(y2) != 0 {
+			continue
+		}
+
+		point := append(common.LeftPadBytes(x.Bytes(), 32), common.LeftPadBytes(y.Bytes(), 32)...)
+		p := new(bn256.G1)
+		if _, err := p.Unmarshal(point); err != nil {
+			continue
+		}
+		return p
+	}
+}
+
+func g2Generator() *bn256.G2 {
+	return new(bn256.G2).ScalarBaseMult(big.NewInt(1))
+}
+
+// randomScalar returns a random non-zero scalar modulo the group order
+func randomScalar(r io.Reader) (*big.Int, error) {
+	if r == nil {
+		r = rand.Reader
+	}
+	for {
+		k, err := rand.Int(r, order)
+		if err != nil {
+			return nil, err
+		}
+		if k.Sign() > 0 {
+			return k, nil
+		}
+	}
+}
+
+// randomCoefficient returns a random non-zero 128-bit coefficient for the batch
+// verification
+func randomCoefficient() (*big.Int, error) {
+	r, err := rand.Int(rand.Reader, batchCoefficientBound)
+	if err != nil {
+		return nil, err
+	}
+	return r.Add(r, big.NewInt(1)), nil
+}
+
+func isZero(b []byte) bool {
+	for _, v := range b {
+		if v != 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,221 @@
+package bls
+
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thetatoken/ukulele/common"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	assert := assert.New(t)
+
+	sk, err := GenerateKey(nil)
+	assert.Nil(err)
+	pk := sk.PublicKey()
+
+	msg := common.Bytes("Hello world!")
+	sig := sk.Sign(msg)
+	assert.Equal(SignatureLength, len(sig.ToBytes()))
+	assert.True(pk.Verify(msg, sig))
+	assert.False(pk.Verify(common.Bytes("Hello world?"), sig))
+
+	sk2, err := GenerateKey(nil)
+	assert.Nil(err)
+	assert.False(sk2.PublicKey().Verify(msg, sig))
+	assert.False(pk.Verify(msg, nil))
+}
+
+func TestToAndFromBytes(t *testing.T) {
+	assert := assert.New(t)
+
+	sk, err := GenerateKey(nil)
+	assert.Nil(err)
+	pk := sk.PublicKey()
+	msg := common.Bytes("Hello world!")
+	sig := sk.Sign(msg)
+
+	sk2, err := PrivateKeyFromBytes(sk.ToBytes())
+	assert.Nil(err)
+	assert.Equal(sk.ToBytes(), sk2.ToBytes())
+
+	pkBytes := pk.ToBytes()
+	assert.Equal(PublicKeyLength, len(pkBytes))
+	pk2, err := PublicKeyFromBytes(pkBytes)
+	assert.Nil(err)
+	assert.True(pk.Equals(pk2))
+	assert.Equal(pk.Address(), pk2.Address())
+
+	sig2, err := SignatureFromBytes(sig.ToBytes())
+	assert.Nil(err)
+	assert.True(pk2.Verify(msg, sig2))
+
+	_, err = PublicKeyFromBytes(make([]byte, PublicKeyLength))
+	assert.NotNil(err)
+	_, err = PrivateKeyFromBytes(make([]byte, PrivateKeyLength))
+	assert.NotNil(err)
+	_, err = SignatureFromBytes(pkBytes)
+	assert.NotNil(err)
+
+	pkJSON, err := json.Marshal(pk)
+	assert.Nil(err)
+	pk3 := &PublicKey{}
+	assert.Nil(json.Unmarshal(pkJSON, pk3))
+	assert.True(pk.Equals(pk3))
+}
+
+func TestThresholdSignature(t *testing.T) {
+	assert := assert.New(t)
+
+	threshold, participants := 3, 5
+	dealings := []*Dealing{}
+	for i := 0; i < participants; i++ {
+		dealing, err := NewDealing(nil, threshold, participants)
+		assert.Nil(err)
+		dealings = append(dealings, dealing)
+	}
+
+	keyShares := []*KeyShare{}
+	for j := 1; j <= participants; j++ {
+		commitments := []Commitment{}
+		shares := []*PrivateKey{}
+		for _, dealing := range dealings {
+			commitments = append(commitments, dealing.Commitment)
+			shares = append(shares, dealing.Shares[j-1])
+		}
+		keyShare, err := CombineDealings(uint64(j), commitments, shares)
+		assert.Nil(err)
+		assert.Nil(keyShare.Validate())
+		assert.Equal(threshold, keyShare.Threshold())
+		keyShares = append(keyShares, keyShare)
+	}
+	groupKey := keyShares[0].GroupPublicKey()
+	for _, keyShare := range keyShares[1:] {
+		assert.True(groupKey.Equals(keyShare.GroupPublicKey()))
+	}
+
+	msg := common.Bytes("vote")
+	sigShares := []*SignatureShare{}
+	for _, keyShare := range keyShares {
+		sigShare := keyShare.Sign(msg)
+		assert.True(keyShare.Commitment.VerifySignatureShare(msg, sigShare))
+		sigShares = append(sigShares, sigShare)
+	}
+	assert.False(keyShares[0].Commitment.VerifySignatureShare(common.Bytes("other"), sigShares[0]))
+
+	// Any threshold of the shares recover the same group signature
+	sig1, err := RecoverSignature(sigShares[:3], threshold)
+	assert.Nil(err)
+	assert.True(groupKey.Verify(msg, sig1))
+	sig2, err := RecoverSignature([]*SignatureShare{sigShares[4], sigShares[1], sigShares[3]}, threshold)
+	assert.Nil(err)
+	assert.True(groupKey.Verify(msg, sig2))
+	assert.Equal(sig1.ToBytes(), sig2.ToBytes())
+
+	// Fewer than threshold shares, counting duplicates once, are not enough
+	_, err = RecoverSignature([]*SignatureShare{sigShares[0], sigShares[1], sigShares[1]}, threshold)
+	assert.NotNil(err)
+
+	// A share signed with a wrong key is rejected, and spoils the recovery
+	sigShares[2].Signature = keyShares[2].PrivateKey.Sign(common.Bytes("other"))
+	assert.False(keyShares[0].Commitment.VerifySignatureShare(msg, sigShares[2]))
+	sig3, err := RecoverSignature(sigShares[:3], threshold)
+	assert.Nil(err)
+	assert.False(groupKey.Verify(msg, sig3))
+
+	// Key share round trip
+	ksJSON, err := json.Marshal(keyShares[1])
+	assert.Nil(err)
+	ks := &KeyShare{}
+	assert.Nil(json.Unmarshal(ksJSON, ks))
+	assert.Nil(ks.Validate())
+	assert.Equal(uint64(2), ks.Index)
+	assert.True(groupKey.Equals(ks.GroupPublicKey()))
+}
+
+func TestCombineDealingsRejectsBadShare(t *testing.T) {
+	assert := assert.New(t)
+
+	dealing1, err := NewDealing(nil, 2, 3)
+	assert.Nil(err)
+	dealing2, err := NewDealing(nil, 2, 3)
+	assert.Nil(err)
+
+	commitments := []Commitment{dealing1.Commitment, dealing2.Commitment}
+	_, err = CombineDealings(1, commitments, []*PrivateKey{dealing1.Shares[0], dealing2.Shares[1]})
+	assert.NotNil(err)
+
+	dealing3, err := NewDealing(nil, 3, 3)
+	assert.Nil(err)
+	commitments = []Commitment{dealing1.Commitment, dealing3.Commitment}
+	_, err = CombineDealings(1, commitments, []*PrivateKey{dealing1.Shares[0], dealing3.Shares[0]})
+	assert.NotNil(err)
+
+	_, err = NewDealing(nil, 4, 3)
+	assert.NotNil(err)
+}
+
+func TestVerifyBatch(t *testing.T) {
+	assert := assert.New(t)
+
+	sigs, msgs, pubKeys := createTestBatch(10)
+	assert.True(VerifyBatch(sigs, msgs, pubKeys))
+	assert.True(VerifyBatch(sigs[:1], msgs[:1], pubKeys[:1]))
+	assert.True(VerifyBatch(nil, nil, nil))
+
+	// Lengths mismatch
+	assert.False(VerifyBatch(sigs, msgs[1:], pubKeys))
+
+	// A signature of another message
+	wrongMsgs := append([]common.Bytes{}, msgs...)
+	wrongMsgs[4] = common.Bytes("Foo bar!")
+	assert.False(VerifyBatch(sigs, wrongMsgs, pubKeys))
+
+	// Two invalid signatures cannot cancel each other out
+	swapped := append([]*Signature{}, sigs...)
+	swapped[2], swapped[3] = sigs[3], sigs[2]
+	assert.False(VerifyBatch(swapped, msgs, pubKeys))
+
+	// A missing signature
+	missing := append([]*Signature{}, sigs...)
+	missing[9] = nil
+	assert.False(VerifyBatch(missing, msgs, pubKeys))
+}
+
+func BenchmarkVerifySerial(b *testing.B) {
+	sigs, msgs, pubKeys := createTestBatch(100)
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		for i, sig := range sigs {
+			if !pubKeys[i].Verify(msgs[i], sig) {
+				b.Fatal("Signature verification failed")
+			}
+		}
+	}
+}
+
+func BenchmarkVerifyBatch(b *testing.B) {
+	sigs, msgs, pubKeys := createTestBatch(100)
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		if !VerifyBatch(sigs, msgs, pubKeys) {
+			b.Fatal("Batch signature verification failed")
+		}
+	}
+}
+
+func createTestBatch(size int) ([]*Signature, []common.Bytes, []*PublicKey) {
+	sigs := []*Signature{}
+	msgs := []common.Bytes{}
+	pubKeys := []*PublicKey{}
+	for i := 0; i < size; i++ {
+		sk, _ := GenerateKey(nil)
+		msg := common.Bytes(strconv.Itoa(i))
+		sigs = append(sigs, sk.Sign(msg))
+		msgs = append(msgs, msg)
+		pubKeys = append(pubKeys, sk.PublicKey())
+	}
+	return sigs, msgs, pubKeys
+}
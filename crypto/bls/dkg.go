@@ -0,0 +1,123 @@
+package bls
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+)
+
+//
+// Distributed key generation (joint Feldman). Each of the n participants deals
+// a random polynomial of degree t - 1: it publishes the commitment to the
+// polynomial, and privately sends the share f(j) to participant j. Participant j
+// verifies the shares it receives against the commitments, and adds them up into
+// its share of the group key. The group polynomial is the sum of the dealt ones,
+// so no participant ever learns the group private key.
+//
+
+// Dealing is the contribution of a participant to the key generation
+type Dealing struct {
+	Commitment Commitment    // Published to all the participants
+	Shares     []*PrivateKey // Shares[j - 1] is sent privately to participant j
+}
+
+// NewDealing deals a random polynomial for the given threshold and number of
+// participants, with the randomness from r. It uses crypto/rand if r is nil.
+func NewDealing(r io.Reader, threshold, participants int) (*Dealing, error) {
+	if threshold <= 0 || threshold > participants {
+		return nil, fmt.Errorf("Invalid threshold %v for %v participants", threshold, participants)
+	}
+
+	coefficients := make([]*big.Int, threshold)
+	commitment := make(Commitment, threshold)
+	for k := range coefficients {
+		a, err := randomScalar(r)
+		if err != nil {
+			return nil, err
+		}
+		coefficients[k] = a
+		commitment[k] = (&PrivateKey{x: a}).PublicKey()
+	}
+
+	shares := make([]*PrivateKey, participants)
+	for j := range shares {
+		shares[j] = &PrivateKey{x: evaluatePolynomial(coefficients, uint64(j+1))}
+	}
+	return &Dealing{Commitment: commitment, Shares: shares}, nil
+}
+
+// KeyShare is the share of the group key held by a participant
+type KeyShare struct {
+	Index      uint64      `json:"index"`
+	PrivateKey *PrivateKey `json:"private_key"`
+	Commitment Commitment  `json:"commitment"` // Commitment to the group polynomial
+}
+
+// CombineDealings verifies the shares the participant of the given index received
+// from the dealers, and combines them into its key share. commitments[i] and
+// shares[i] are those dealt by the same dealer.
+func CombineDealings(index uint64, commitments []Commitment, shares []*PrivateKey) (*KeyShare, error) {
+	if index == 0 {
+		return nil, errors.New("Participant index starts from 1")
+	}
+	if len(commitments) == 0 || len(commitments) != len(shares) {
+		return nil, fmt.Errorf("Got %v commitments but %v shares", len(commitments), len(shares))
+	}
+
+	x := big.NewInt(0)
+	for i, commitment := range commitments {
+		if err := commitment.Validate(); err != nil {
+			return nil, fmt.Errorf("Invalid commitment of dealing %v: %v", i+1, err)
+		}
+		if !commitment.VerifyShare(index, shares[i]) {
+			return nil, fmt.Errorf("Share of dealing %v does not match its commitment", i+1)
+		}
+		x.Add(x, shares[i].x).Mod(x, order)
+	}
+	commitment, err := AddCommitments(commitments)
+	if err != nil {
+		return nil, err
+	}
+	if x.Sign() == 0 {
+		return nil, errors.New("Combined key share is zero")
+	}
+
+	return &KeyShare{
+		Index:      index,
+		PrivateKey: &PrivateKey{x: x},
+		Commitment: commitment,
+	}, nil
+}
+
+// Validate checks that the key share matches the commitment of the group
+func (ks *KeyShare) Validate() error {
+	if ks.PrivateKey == nil {
+		return errors.New("Key share has no private key")
+	}
+	if err := ks.Commitment.Validate(); err != nil {
+		return err
+	}
+	if !ks.Commitment.VerifyShare(ks.Index, ks.PrivateKey) {
+		return errors.New("Key share does not match the group commitment")
+	}
+	return nil
+}
+
+// Threshold returns the number of shares needed to sign
+func (ks *KeyShare) Threshold() int {
+	return ks.Commitment.Threshold()
+}
+
+// GroupPublicKey returns the public key of the group
+func (ks *KeyShare) GroupPublicKey() *PublicKey {
+	return ks.Commitment.PublicKey()
+}
+
+// Sign signs the message with the key share
+func (ks *KeyShare) Sign(msg []byte) *SignatureShare {
+	return &SignatureShare{
+		Index:     ks.Index,
+		Signature: ks.PrivateKey.Sign(msg),
+	}
+}
@@ -0,0 +1,165 @@
+package bls
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/crypto/bn256"
+)
+
+//
+// Threshold signatures. The group private key is the constant term of a secret
+// polynomial f of degree t - 1, and participant i (i >= 1) holds the share f(i).
+// Any t of the signatures of the shares can be combined into the signature of the
+// group private key by Lagrange interpolation at 0, while fewer than t of them
+// reveal nothing about it.
+//
+
+// SignatureShare is the signature of a key share, identified by the index of the share
+type SignatureShare struct {
+	Index     uint64     `json:"index"`
+	Signature *Signature `json:"signature"`
+}
+
+// Commitment is the public commitment to the coefficients of a secret polynomial
+// f(z) = a_0 + a_1 * z + ... + a_{t-1} * z^{t-1}, i.e. the points a_k * g2. The
+// public key of f(0) as well as those of the shares f(i) can be computed from it.
+type Commitment []*PublicKey
+
+// Threshold returns the number of shares needed to sign
+func (c Commitment) Threshold() int {
+	return len(c)
+}
+
+// Validate checks that the commitment is well formed
+func (c Commitment) Validate() error {
+	if len(c) == 0 {
+		return errors.New("Commitment is empty")
+	}
+	for _, pk := range c {
+		if pk == nil || pk.p == nil {
+			return errors.New("Commitment contains an empty point")
+		}
+	}
+	return nil
+}
+
+// PublicKey returns the public key of the secret f(0)
+func (c Commitment) PublicKey() *PublicKey {
+	return c[0]
+}
+
+// PublicKeyShare returns the public key of the share f(index)
+func (c Commitment) PublicKeyShare(index uint64) *PublicKey {
+	// Horner's method: sum of a_k * index^k * g2
+	i := new(big.Int).SetUint64(index)
+	p := new(bn256.G2).Set(c[len(c)-1].p)
+	for k := len(c) - 2; k >= 0; k-- {
+		p.ScalarMult(p, i)
+		p.Add(p, c[k].p)
+	}
+	return &PublicKey{p: p}
+}
+
+// VerifyShare checks that the share is f(index) of the committed polynomial
+func (c Commitment) VerifyShare(index uint64, share *PrivateKey) bool {
+	if index == 0 || share == nil {
+		return false
+	}
+	return share.PublicKey().Equals(c.PublicKeyShare(index))
+}
+
+// VerifySignatureShare checks the signature of the share over the message
+func (c Commitment) VerifySignatureShare(msg common.Bytes, share *SignatureShare) bool {
+	if share == nil || share.Index == 0 {
+		return false
+	}
+	return c.PublicKeyShare(share.Index).Verify(msg, share.Signature)
+}
+
+// AddCommitments returns the commitment of the sum of the committed polynomials
+func AddCommitments(commitments []Commitment) (Commitment, error) {
+	if len(commitments) == 0 {
+		return nil, errors.New("No commitments to add")
+	}
+	threshold := commitments[0].Threshold()
+	sum := make(Commitment, threshold)
+	for k := range sum {
+		sum[k] = &PublicKey{p: new(bn256.G2).Set(commitments[0][k].p)}
+	}
+	for _, c := range commitments[1:] {
+		if c.Threshold() != threshold {
+			return nil, fmt.Errorf("Commitments have different thresholds: %v vs %v", c.Threshold(), threshold)
+		}
+		for k := range sum {
+			sum[k].p.Add(sum[k].p, c[k].p)
+		}
+	}
+	return sum, nil
+}
+
+// RecoverSignature combines the signature shares of at least threshold distinct
+// shares into the signature of the group private key. The shares are assumed to
+// be verified.
+func RecoverSignature(shares []*SignatureShare, threshold int) (*Signature, error) {
+	if threshold <= 0 {
+		return nil, errors.New("Threshold needs to be positive")
+	}
+	selected := []*SignatureShare{}
+	seen := make(map[uint64]bool)
+	for _, share := range shares {
+		if share == nil || share.Signature == nil || share.Index == 0 || seen[share.Index] {
+			continue
+		}
+		seen[share.Index] = true
+		selected = append(selected, share)
+		if len(selected) == threshold {
+			break
+		}
+	}
+	if len(selected) < threshold {
+		return nil, fmt.Errorf("Not enough signature shares: %v of %v", len(selected), threshold)
+	}
+
+	indices := make([]uint64, len(selected))
+	for i, share := range selected {
+		indices[i] = share.Index
+	}
+	p := new(bn256.G1).ScalarBaseMult(big.NewInt(0))
+	for _, share := range selected {
+		term := new(bn256.G1).ScalarMult(share.Signature.p, lagrangeCoefficient(share.Index, indices))
+		p.Add(p, term)
+	}
+	return &Signature{p: p}, nil
+}
+
+// lagrangeCoefficient returns the Lagrange basis polynomial of index evaluated at
+// 0, i.e. the product of j / (j - index) over the other indices j
+func lagrangeCoefficient(index uint64, indices []uint64) *big.Int {
+	num := big.NewInt(1)
+	den := big.NewInt(1)
+	i := new(big.Int).SetUint64(index)
+	for _, other := range indices {
+		if other == index {
+			continue
+		}
+		j := new(big.Int).SetUint64(other)
+		num.Mul(num, j).Mod(num, order)
+		diff := new(big.Int).Sub(j, i)
+		den.Mul(den, diff).Mod(den, order)
+	}
+	den.ModInverse(den, order)
+	return num.Mul(num, den).Mod(num, order)
+}
+
+// evaluatePolynomial returns f(index) modulo the group order
+func evaluatePolynomial(coefficients []*big.Int, index uint64) *big.Int {
+	i := new(big.Int).SetUint64(index)
+	result := new(big.Int).Set(coefficients[len(coefficients)-1])
+	for k := len(coefficients) - 2; k >= 0; k-- {
+		result.Mul(result, i).Add(result, coefficients[k]).Mod(result, order)
+	}
+	return result
+}
@@ -3,8 +3,13 @@ package crypto
 import (
 	"bytes"
 	"crypto/ecdsa"
+	"errors"
 	"io"
 	"math/big"
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
 
 	"github.com/thetatoken/ukulele/common"
 	"github.com/thetatoken/ukulele/rlp"
@@ -25,6 +30,25 @@ func Keccak256Hash(data ...[]byte) (h common.Hash) {
 	return keccak256Hash(data...)
 }
 
+//
+// ---------------------- Message Signing APIs ---------------------- //
+//
+
+// MessageDomainSeparator is prepended to arbitrary messages before signing. Since a
+// valid RLP encoded transaction can never start with this prefix, a signed message
+// cannot be replayed as a transaction.
+const MessageDomainSeparator = "\x19Theta Signed Message:\n"
+
+// MessageSignBytes returns the bytes to be signed for an arbitrary message, i.e.
+// the domain separator, followed by the message length and the message itself.
+func MessageSignBytes(msg common.Bytes) common.Bytes {
+	prefix := MessageDomainSeparator + strconv.Itoa(len(msg))
+	signBytes := make(common.Bytes, 0, len(prefix)+len(msg))
+	signBytes = append(signBytes, prefix...)
+	signBytes = append(signBytes, msg...)
+	return signBytes
+}
+
 //
 // ----------------------- Digital Signature APIs ----------------------- //
 //
@@ -61,7 +85,9 @@ func (sk *PrivateKey) SaveToFile(filepath string) error {
 	return err
 }
 
-// Sign signs the given message with the private key
+// Sign signs the given message with the private key. The signature is deterministic:
+// the nonce is derived from the key and the message hash as specified in RFC 6979,
+// and S is normalized to the lower half of the curve order.
 func (sk *PrivateKey) Sign(msg common.Bytes) (*Signature, error) {
 	msgHash := keccak256(msg)
 	sigBytes, err := sign(msgHash, sk.privKey)
@@ -128,11 +154,14 @@ func (pk *PublicKey) IsEmpty() bool {
 
 // VerifySignature verifies the signature with the public key (using ecrecover)
 func (pk *PublicKey) VerifySignature(msg common.Bytes, sig *Signature) bool {
-	if sig == nil {
+	if sig == nil || sig.Validate() != nil {
 		return false
 	}
 
 	msgHash := keccak256(msg)
+	if address, ok := sig.cachedSigner(msgHash); ok {
+		return address == pk.Address()
+	}
 	recoveredUncompressedPubKey, err := ecrecover(msgHash, sig.ToBytes())
 	if err != nil {
 		return false
@@ -142,6 +171,7 @@ func (pk *PublicKey) VerifySignature(msg common.Bytes, sig *Signature) bool {
 	if bytes.Compare(recoveredUncompressedPubKey, uncompressedPubKey) != 0 {
 		return false
 	}
+	sig.cacheSigner(msgHash, pk.Address())
 
 	return true
 }
@@ -169,8 +199,27 @@ func (pk *PublicKey) VerifySignature(msg common.Bytes, sig *Signature) bool {
 //
 type Signature struct {
 	data common.Bytes
+
+	recovered atomic.Value // recoveredSigner, the last signer recovered from the signature
 }
 
+// recoveredSigner remembers the signer recovered from a signature for a message,
+// since the recovery is the costly part of the signature verification
+type recoveredSigner struct {
+	sig     common.Bytes
+	msgHash common.Bytes
+	address common.Address
+}
+
+// SignatureLength is the length of a [R || S || V] signature
+const SignatureLength = 65
+
+var (
+	ErrInvalidSignatureLength = errors.New("Invalid signature length")
+	ErrInvalidSignatureValues = errors.New("Invalid signature values")
+	ErrMalleableSignature     = errors.New("Malleable signature: S is in the upper half of the curve order")
+)
+
 var _ rlp.Encoder = (*Signature)(nil)
 
 // EncodeRLP implements RLP Encoder interface.
@@ -205,21 +254,92 @@ func (sig *Signature) IsEmpty() bool {
 	return len(sig.data) == 0
 }
 
-// RecoverSignerAddress recovers the address of the signer for the given message
+// Validate checks that the signature is a [R || S || V] signature in the canonical
+// form. Since (R, N - S) is also a valid signature of the same message, only the
+// one with S in the lower half of the curve order N is accepted, so that nobody
+// can alter a signature, and hence the hash of a signed transaction, without the key.
+func (sig *Signature) Validate() error {
+	if len(sig.data) != SignatureLength {
+		return ErrInvalidSignatureLength
+	}
+	r := new(big.Int).SetBytes(sig.data[:32])
+	s := new(big.Int).SetBytes(sig.data[32:64])
+	v := sig.data[64]
+	if !validateSignatureValues(v, r, s, false) {
+		return ErrInvalidSignatureValues
+	}
+	if !validateSignatureValues(v, r, s, true) {
+		return ErrMalleableSignature
+	}
+	return nil
+}
+
+// RecoverSignerAddress recovers the address of the signer for the given message.
+// Signatures not in the canonical form are rejected. The signature remembers the
+// signer recovered, so that verifying it again for the same message is cheap.
 func (sig *Signature) RecoverSignerAddress(msg common.Bytes) (common.Address, error) {
+	if err := sig.Validate(); err != nil {
+		return common.Address{}, err
+	}
+	if address, ok := sig.cachedSigner(keccak256(msg)); ok {
+		return address, nil
+	}
+	pk, err := sig.RecoverPublicKey(msg)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return pk.Address(), nil
+}
+
+// RecoverPublicKey recovers the public key of the signer for the given message.
+// Signatures not in the canonical form are rejected.
+func (sig *Signature) RecoverPublicKey(msg common.Bytes) (*PublicKey, error) {
+	if err := sig.Validate(); err != nil {
+		return nil, err
+	}
 	msgHash := keccak256(msg)
 	recoveredUncompressedPubKey, err := ecrecover(msgHash, sig.ToBytes())
 	if err != nil {
-		return common.Address{}, err
+		return nil, err
 	}
 
 	pk, err := PublicKeyFromBytes(recoveredUncompressedPubKey)
 	if err != nil {
-		return common.Address{}, err
+		return nil, err
+	}
+
+	sig.cacheSigner(msgHash, pk.Address())
+	return pk, nil
+}
+
+// RecoveredSigner returns the signer last recovered from the signature for the given
+// message, if any.
+func (sig *Signature) RecoveredSigner(msg common.Bytes) (common.Address, bool) {
+	return sig.cachedSigner(keccak256(msg))
+}
+
+// SetRecoveredSigner records the signer recovered from the signature for the given
+// message, so that verifying the signature for the message is cheap. The address must
+// have been recovered from the same signature and message, e.g. from another decoding
+// of the same signed transaction.
+func (sig *Signature) SetRecoveredSigner(msg common.Bytes, address common.Address) {
+	sig.cacheSigner(keccak256(msg), address)
+}
+
+func (sig *Signature) cachedSigner(msgHash common.Bytes) (common.Address, bool) {
+	cached, ok := sig.recovered.Load().(recoveredSigner)
+	if !ok || !bytes.Equal(cached.msgHash, msgHash) || !bytes.Equal(cached.sig, sig.data) {
+		return common.Address{}, false
 	}
+	return cached.address, true
+}
 
-	address := pk.Address()
-	return address, nil
+func (sig *Signature) cacheSigner(msgHash common.Bytes, address common.Address) {
+	sig.recovered.Store(recoveredSigner{
+		sig:     common.CopyBytes(sig.data),
+		msgHash: msgHash,
+		address: address,
+	})
 }
 
 // Verify verifies the signature with given raw message and address.
@@ -274,8 +394,92 @@ func PublicKeyFromBytes(pkBytes common.Bytes) (*PublicKey, error) {
 	return pk, err
 }
 
+// PublicKeyFromCompressedBytes converts the given bytes in the 33-byte compressed
+// format to a public key
+func PublicKeyFromCompressedBytes(pkBytes common.Bytes) (*PublicKey, error) {
+	key, err := decompressPubkey(pkBytes)
+	pk := &PublicKey{pubKey: key}
+	return pk, err
+}
+
 // SignatureFromBytes converts the given bytes to a signature
 func SignatureFromBytes(sigBytes common.Bytes) (*Signature, error) {
 	sig := &Signature{data: sigBytes}
 	return sig, nil
 }
+
+//
+// -------------------- Batch Signature Verification APIs -------------------- //
+//
+
+// VerifyBatch verifies a batch of signatures, where sigs[i] is expected to be the
+// signature of msgs[i] by pubKeys[i]. It returns true only if all the signatures
+// are valid.
+//
+// Recoverable ECDSA signatures over secp256k1 admit no batch verification equation
+// cheaper than verifying them one by one, so the batch is instead verified in
+// parallel on all the CPUs. The signers recovered are remembered by the signatures,
+// so verifying them again afterwards, e.g. while executing the transactions of a
+// block, does not repeat the recovery.
+func VerifyBatch(sigs []*Signature, msgs []common.Bytes, pubKeys []*PublicKey) bool {
+	if len(pubKeys) != len(sigs) {
+		return false
+	}
+	addrs := make([]common.Address, len(pubKeys))
+	for i, pk := range pubKeys {
+		if pk == nil || pk.IsEmpty() {
+			return false
+		}
+		addrs[i] = pk.Address()
+	}
+	return VerifyAddressBatch(sigs, msgs, addrs)
+}
+
+// VerifyAddressBatch is the same as VerifyBatch, but verifies the signatures with
+// the addresses of the signers instead of their public keys.
+func VerifyAddressBatch(sigs []*Signature, msgs []common.Bytes, addrs []common.Address) bool {
+	if len(msgs) != len(sigs) || len(addrs) != len(sigs) {
+		return false
+	}
+	return verifyParallel(len(sigs), func(i int) bool {
+		return sigs[i].Verify(msgs[i], addrs[i])
+	})
+}
+
+// verifyParallel calls verify for 0 to n - 1 on all the CPUs, and returns whether
+// all the calls returned true. It stops at the first failure.
+func verifyParallel(n int, verify func(i int) bool) bool {
+	workers := runtime.NumCPU()
+	if workers > n {
+		workers = n
+	}
+	if workers <= 1 {
+		for i := 0; i < n; i++ {
+			if !verify(i) {
+				return false
+			}
+		}
+		return true
+	}
+
+	var next int64 = -1
+	var failed int32
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for atomic.LoadInt32(&failed) == 0 {
+				i := int(atomic.AddInt64(&next, 1))
+				if i >= n {
+					return
+				}
+				if !verify(i) {
+					atomic.StoreInt32(&failed, 1)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return failed == 0
+}
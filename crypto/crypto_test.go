@@ -1,7 +1,10 @@
 package crypto
 
 import (
+	"crypto/sha256"
 	"encoding/hex"
+	"math/big"
+	"strconv"
 	"testing"
 
 	log "github.com/sirupsen/logrus"
@@ -97,6 +100,12 @@ func TestToAndFromBytes(t *testing.T) {
 	assert.Equal(pubKey, recoveredPubKey)
 	t.Logf("PublicBytes   : %v", hex.EncodeToString(pubKeyBytes))
 
+	compressedPubKey, err := PublicKeyFromCompressedBytes(compressPubkey(pubKey.pubKey))
+	assert.Nil(err)
+	assert.Equal(pubKey.Address(), compressedPubKey.Address())
+	_, err = PublicKeyFromCompressedBytes(pubKeyBytes)
+	assert.NotNil(err)
+
 	sigBytes := sig.ToBytes()
 	recoveredSig, err := SignatureFromBytes(sigBytes)
 	assert.Nil(err)
@@ -126,6 +135,31 @@ func TestAddressRecovery(t *testing.T) {
 	log.Infof("fake address: %v", fakeAddr)
 }
 
+func TestPublicKeyRecovery(t *testing.T) {
+	assert := assert.New(t)
+
+	privKey, pubKey, err := TEST_GenerateKeyPairWithSeed("test_seed_xyz")
+	assert.Nil(err)
+
+	msg := common.Bytes("ABCD has four letters")
+	sig, err := privKey.Sign(msg)
+	assert.Nil(err)
+
+	recoveredPubKey, err := sig.RecoverPublicKey(msg)
+	assert.Nil(err)
+	assert.Equal(pubKey.ToBytes(), recoveredPubKey.ToBytes())
+	assert.True(recoveredPubKey.VerifySignature(msg, sig))
+
+	otherPubKey, err := sig.RecoverPublicKey(common.Bytes("Hello World!"))
+	assert.Nil(err)
+	assert.NotEqual(pubKey.ToBytes(), otherPubKey.ToBytes())
+
+	invalidSig, err := SignatureFromBytes(sig.ToBytes()[:64])
+	assert.Nil(err)
+	_, err = invalidSig.RecoverPublicKey(msg)
+	assert.Equal(ErrInvalidSignatureLength, err)
+}
+
 func TestSignaureVerifyBytes(t *testing.T) {
 	assert := assert.New(t)
 
@@ -157,6 +191,24 @@ func TestSignaureVerifyBytes(t *testing.T) {
 	assert.False(sig1.Verify(msg1, anotherAddr))
 }
 
+func TestMessageSignBytes(t *testing.T) {
+	assert := assert.New(t)
+
+	privKey, pubKey, err := TEST_GenerateKeyPairWithSeed("test_seed")
+	assert.Nil(err)
+	addr := pubKey.Address()
+
+	msg := common.Bytes("Hello world!")
+	signBytes := MessageSignBytes(msg)
+	assert.Equal(common.Bytes("\x19Theta Signed Message:\n12Hello world!"), signBytes)
+	assert.Equal(common.Bytes("\x19Theta Signed Message:\n0"), MessageSignBytes(common.Bytes{}))
+
+	sig, err := privKey.Sign(signBytes)
+	assert.Nil(err)
+	assert.True(sig.Verify(signBytes, addr))
+	assert.False(sig.Verify(msg, addr)) // signature is bound to the domain separator
+}
+
 func TestSignatureVerification1(t *testing.T) {
 	assert := assert.New(t)
 
@@ -221,3 +273,195 @@ func TestSignatureVerification2(t *testing.T) {
 	assert.False(pubKeyA.VerifySignature(msg2, sig2B))
 	assert.False(pubKeyB.VerifySignature(msg2, sig2A))
 }
+
+// RFC 6979 vectors for secp256k1 with SHA-256 message hashes, with S normalized to
+// the lower half of the curve order. The signatures are in [R || S || V] format.
+var deterministicSignatureVectors = []struct {
+	key string
+	msg string
+	sig string
+}{
+	{
+		"0000000000000000000000000000000000000000000000000000000000000001",
+		"Satoshi Nakamoto",
+		"934b1ea10a4b3c1757e2b0c017d0b6143ce3c9a7e6a4a49860d7a6ab210ee3d82442ce9d2b916064108014783e923ec36b49743e2ffa1c4496f01a512aafd9e501",
+	},
+	{
+		"0000000000000000000000000000000000000000000000000000000000000001",
+		"All those moments will be lost in time, like tears in rain. Time to die...",
+		"8600dbd41e348fe5c9465ab92d23e3db8b98b873beecd930736488696438cb6b547fe64427496db33bf66019dacbf0039c04199abb0122918601db38a72cfc2100",
+	},
+	{
+		"fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364140",
+		"Satoshi Nakamoto",
+		"fd567d121db66e382991534ada77a6bd3106f0a1098c231e47993447cd6af2d06b39cd0eb1bc8603e159ef5c20a5c8ad685a45b06ce9bebed3f153d10d93bed500",
+	},
+	{
+		"69ec59eaa1f4f2e36b639716b7c30ca86d9a5375c7b38d8918bd9c0ebc80ba64",
+		"Computer science is no more about computers than astronomy is about telescopes.",
+		"7186363571d65e084e7f02b0b77c3ec44fb1b257dee26274c38c928986fea45d0de0b38e06807e46bda1f1e293f4f6323e854c86d58abdd00c46c16441085df600",
+	},
+}
+
+func TestDeterministicSignature(t *testing.T) {
+	assert := assert.New(t)
+
+	for _, vector := range deterministicSignatureVectors {
+		key, err := hexToECDSA(vector.key)
+		assert.Nil(err)
+		hash := sha256.Sum256([]byte(vector.msg))
+		sig, err := sign(hash[:], key)
+		assert.Nil(err)
+		assert.Equal(vector.sig, hex.EncodeToString(sig), "Unexpected signature of %q", vector.msg)
+	}
+
+	// Signing the same message twice gives the same signature
+	privKey, _, err := TEST_GenerateKeyPairWithSeed("test_seed")
+	assert.Nil(err)
+	msg := common.Bytes("Hello world!")
+	sig1, err := privKey.Sign(msg)
+	assert.Nil(err)
+	sig2, err := privKey.Sign(msg)
+	assert.Nil(err)
+	assert.Equal(sig1.ToBytes(), sig2.ToBytes())
+	assert.Nil(sig1.Validate())
+}
+
+func TestSignatureMalleability(t *testing.T) {
+	assert := assert.New(t)
+
+	privKey, pubKey, err := TEST_GenerateKeyPairWithSeed("test_seed")
+	assert.Nil(err)
+	addr := pubKey.Address()
+	msg := common.Bytes("Hello world!")
+	sig, err := privKey.Sign(msg)
+	assert.Nil(err)
+	assert.True(sig.Verify(msg, addr))
+	assert.True(pubKey.VerifySignature(msg, sig))
+
+	// (R, N - S) with the flipped recovery ID is the same signature in the upper half
+	sigBytes := sig.ToBytes()
+	s := new(big.Int).SetBytes(sigBytes[32:64])
+	malleated := append(common.Bytes{}, sigBytes[:32]...)
+	malleated = append(malleated, common.LeftPadBytes(new(big.Int).Sub(secp256k1N, s).Bytes(), 32)...)
+	malleated = append(malleated, sigBytes[64]^1)
+	recovered, err := ecrecover(keccak256(msg), malleated)
+	assert.Nil(err)
+	assert.Equal(pubKey.ToBytes(), common.Bytes(recovered))
+
+	malleatedSig, err := SignatureFromBytes(malleated)
+	assert.Nil(err)
+	assert.Equal(ErrMalleableSignature, malleatedSig.Validate())
+	assert.False(malleatedSig.Verify(msg, addr))
+	assert.False(pubKey.VerifySignature(msg, malleatedSig))
+	_, err = malleatedSig.RecoverSignerAddress(msg)
+	assert.Equal(ErrMalleableSignature, err)
+
+	// Malformed signatures
+	shortSig, _ := SignatureFromBytes(sigBytes[:64])
+	assert.Equal(ErrInvalidSignatureLength, shortSig.Validate())
+	badV := append(common.Bytes{}, sigBytes...)
+	badV[64] = 27
+	badVSig, _ := SignatureFromBytes(badV)
+	assert.Equal(ErrInvalidSignatureValues, badVSig.Validate())
+	zeroR := append(make(common.Bytes, 32), sigBytes[32:]...)
+	zeroRSig, _ := SignatureFromBytes(zeroR)
+	assert.Equal(ErrInvalidSignatureValues, zeroRSig.Validate())
+}
+
+func TestVerifyBatch(t *testing.T) {
+	assert := assert.New(t)
+
+	sigs, msgs, pubKeys := createTestBatch(20)
+	assert.True(VerifyBatch(sigs, msgs, pubKeys))
+	assert.True(VerifyBatch(nil, nil, nil))
+
+	addrs := []common.Address{}
+	for _, pk := range pubKeys {
+		addrs = append(addrs, pk.Address())
+	}
+	assert.True(VerifyAddressBatch(sigs, msgs, addrs))
+
+	// Lengths mismatch
+	assert.False(VerifyBatch(sigs, msgs[1:], pubKeys))
+	assert.False(VerifyAddressBatch(sigs, msgs, addrs[1:]))
+
+	// A signature of another message
+	sigs, msgs, pubKeys = createTestBatch(20)
+	msgs[7] = common.Bytes("Foo bar!")
+	assert.False(VerifyBatch(sigs, msgs, pubKeys))
+
+	// A signature of another signer
+	sigs, msgs, pubKeys = createTestBatch(20)
+	pubKeys[3] = pubKeys[4]
+	assert.False(VerifyBatch(sigs, msgs, pubKeys))
+
+	// A missing signature
+	sigs, msgs, pubKeys = createTestBatch(20)
+	sigs[19] = nil
+	assert.False(VerifyBatch(sigs, msgs, pubKeys))
+}
+
+func TestVerifyRemembersSigner(t *testing.T) {
+	assert := assert.New(t)
+
+	sigs, msgs, pubKeys := createTestBatch(2)
+	assert.True(VerifyBatch(sigs, msgs, pubKeys))
+
+	// The signer remembered is only for the message verified
+	assert.True(sigs[0].Verify(msgs[0], pubKeys[0].Address()))
+	assert.False(sigs[0].Verify(msgs[1], pubKeys[0].Address()))
+	assert.False(sigs[0].Verify(msgs[0], pubKeys[1].Address()))
+	assert.True(pubKeys[0].VerifySignature(msgs[0], sigs[0]))
+	assert.False(pubKeys[1].VerifySignature(msgs[0], sigs[0]))
+
+	// and for the signature verified
+	copy(sigs[0].data, sigs[1].data)
+	assert.False(sigs[0].Verify(msgs[0], pubKeys[0].Address()))
+}
+
+func BenchmarkVerifySerial(b *testing.B) {
+	sigs, msgs, pubKeys := createTestBatch(100)
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		for i, sig := range freshSignatures(sigs) {
+			if !pubKeys[i].VerifySignature(msgs[i], sig) {
+				b.Fatal("Signature verification failed")
+			}
+		}
+	}
+}
+
+func BenchmarkVerifyBatch(b *testing.B) {
+	sigs, msgs, pubKeys := createTestBatch(100)
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		if !VerifyBatch(freshSignatures(sigs), msgs, pubKeys) {
+			b.Fatal("Batch signature verification failed")
+		}
+	}
+}
+
+func createTestBatch(size int) ([]*Signature, []common.Bytes, []*PublicKey) {
+	sigs := []*Signature{}
+	msgs := []common.Bytes{}
+	pubKeys := []*PublicKey{}
+	for i := 0; i < size; i++ {
+		privKey, pubKey, _ := GenerateKeyPair()
+		msg := common.Bytes(strconv.Itoa(i))
+		sig, _ := privKey.Sign(msg)
+		sigs = append(sigs, sig)
+		msgs = append(msgs, msg)
+		pubKeys = append(pubKeys, pubKey)
+	}
+	return sigs, msgs, pubKeys
+}
+
+// freshSignatures copies the signatures without the signers they remember
+func freshSignatures(sigs []*Signature) []*Signature {
+	fresh := make([]*Signature, len(sigs))
+	for i, sig := range sigs {
+		fresh[i], _ = SignatureFromBytes(sig.ToBytes())
+	}
+	return fresh
+}
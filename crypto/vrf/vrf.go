@@ -0,0 +1,189 @@
+// Package vrf implements a verifiable random function (VRF) over secp256k1, with the
+// keys of the crypto package. It follows the ECVRF construction of RFC 9381, with
+// try-and-increment hashing to the curve and Keccak256 as the hash function.
+//
+// The output of the VRF for an input is unique for the key, and unpredictable to
+// anyone without the private key. The proof convinces anyone with the public key
+// that the output is the right one, without revealing the private key.
+package vrf
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/crypto"
+	"github.com/thetatoken/ukulele/crypto/secp256k1"
+)
+
+const (
+	// ProofLength is the length of a proof, i.e. Gamma (64 bytes), c and s (32 bytes each)
+	ProofLength = 128
+)
+
+// Domain separators of the hashes
+var (
+	hashToCurveDomain = []byte("Theta VRF hash to curve")
+	nonceDomain       = []byte("Theta VRF nonce")
+	challengeDomain   = []byte("Theta VRF challenge")
+	outputDomain      = []byte("Theta VRF output")
+)
+
+var (
+	curve = secp256k1.S256()
+	// sqrtExponent is (p + 1) / 4, since p = 3 mod 4 the square root of a is a^((p + 1) / 4)
+	sqrtExponent = new(big.Int).Rsh(new(big.Int).Add(curve.P, big.NewInt(1)), 2)
+)
+
+var (
+	ErrInvalidProof = errors.New("Invalid VRF proof")
+	ErrInvalidKey   = errors.New("Invalid VRF key")
+)
+
+// Prove computes the output of the VRF for the input alpha with the private key,
+// and the proof of the output.
+func Prove(sk *crypto.PrivateKey, alpha common.Bytes) (common.Hash, common.Bytes, error) {
+	x := sk.D()
+	if x == nil || x.Sign() <= 0 || x.Cmp(curve.N) >= 0 {
+		return common.Hash{}, nil, ErrInvalidKey
+	}
+	pkx, pky, err := publicKeyPoint(sk.PublicKey())
+	if err != nil {
+		return common.Hash{}, nil, err
+	}
+
+	hx, hy := hashToCurve(pkx, pky, alpha)
+	gx, gy := curve.ScalarMult(hx, hy, x.Bytes())
+
+	// Deterministic nonce, so that no randomness can leak the private key
+	k := new(big.Int).SetBytes(crypto.Keccak256(nonceDomain, common.LeftPadBytes(x.Bytes(), 32), marshalPoint(hx, hy)))
+	k.Mod(k, curve.N)
+	if k.Sign() == 0 {
+		return common.Hash{}, nil, errors.New("Failed to derive VRF nonce")
+	}
+	ux, uy := curve.ScalarBaseMult(k.Bytes())
+	vx, vy := curve.ScalarMult(hx, hy, k.Bytes())
+
+	c := challenge(hx, hy, gx, gy, ux, uy, vx, vy)
+	s := new(big.Int).Mul(c, x)
+	s.Add(s, k).Mod(s, curve.N)
+
+	proof := make(common.Bytes, 0, ProofLength)
+	proof = append(proof, marshalPoint(gx, gy)...)
+	proof = append(proof, common.LeftPadBytes(c.Bytes(), 32)...)
+	proof = append(proof, common.LeftPadBytes(s.Bytes(), 32)...)
+	return output(gx, gy), proof, nil
+}
+
+// Verify verifies the proof of the VRF output for the input alpha with the public
+// key, and returns the output.
+func Verify(pk *crypto.PublicKey, alpha common.Bytes, proof common.Bytes) (common.Hash, error) {
+	if len(proof) != ProofLength {
+		return common.Hash{}, ErrInvalidProof
+	}
+	pkx, pky, err := publicKeyPoint(pk)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	gx := new(big.Int).SetBytes(proof[:32])
+	gy := new(big.Int).SetBytes(proof[32:64])
+	c := new(big.Int).SetBytes(proof[64:96])
+	s := new(big.Int).SetBytes(proof[96:])
+	if !curve.IsOnCurve(gx, gy) || c.Cmp(curve.N) >= 0 || s.Cmp(curve.N) >= 0 {
+		return common.Hash{}, ErrInvalidProof
+	}
+
+	hx, hy := hashToCurve(pkx, pky, alpha)
+	// U = s * G - c * Y, V = s * H - c * Gamma
+	sgx, sgy := curve.ScalarBaseMult(s.Bytes())
+	ux, uy, ok := subtract(sgx, sgy, pkx, pky, c)
+	if !ok {
+		return common.Hash{}, ErrInvalidProof
+	}
+	sx, sy := curve.ScalarMult(hx, hy, s.Bytes())
+	vx, vy, ok := subtract(sx, sy, gx, gy, c)
+	if !ok {
+		return common.Hash{}, ErrInvalidProof
+	}
+
+	if challenge(hx, hy, gx, gy, ux, uy, vx, vy).Cmp(c) != 0 {
+		return common.Hash{}, ErrInvalidProof
+	}
+	return output(gx, gy), nil
+}
+
+// subtract returns (ax, ay) - c * (bx, by). It returns false if any of the points
+// involved is the point at infinity, which no valid proof leads to.
+func subtract(ax, ay, bx, by *big.Int, c *big.Int) (*big.Int, *big.Int, bool) {
+	if ax == nil || c.Sign() == 0 {
+		return nil, nil, false
+	}
+	cx, cy := curve.ScalarMult(bx, by, c.Bytes())
+	if cx == nil {
+		return nil, nil, false
+	}
+	negCy := new(big.Int).Sub(curve.P, cy)
+	if ax.Cmp(cx) == 0 {
+		return nil, nil, false
+	}
+	x, y := curve.Add(ax, ay, cx, negCy)
+	return x, y, true
+}
+
+// hashToCurve maps the public key and the input to a point of the curve by
+// try-and-increment: it hashes them with a counter until the hash is the x
+// coordinate of a point on the curve, and takes the point with the even y.
+// secp256k1 has cofactor 1, so every point on the curve is in the group.
+func hashToCurve(pkx, pky *big.Int, alpha common.Bytes) (*big.Int, *big.Int) {
+	pkBytes := marshalPoint(pkx, pky)
+	for ctr := 0; ; ctr++ {
+		h := crypto.Keccak256(hashToCurveDomain, pkBytes, alpha, []byte{byte(ctr >> 8), byte(ctr)})
+		x := new(big.Int).SetBytes(h)
+		if x.Cmp(curve.P) >= 0 {
+			continue
+		}
+		y2 := new(big.Int).Exp(x, big.NewInt(3), curve.P)
+		y2.Add(y2, curve.B).Mod(y2, curve.P)
+		y := new(big.Int).Exp(y2, sqrtExponent, curve.P)
+		if new(big.Int).Exp(y, big.NewInt(2), curve.P).Cmp(y2) != 0 {
+			continue
+		}
+		if y.Bit(0) == 1 {
+			y.Sub(curve.P, y)
+		}
+		return x, y
+	}
+}
+
+func challenge(points ...*big.Int) *big.Int {
+	data := [][]byte{challengeDomain}
+	for i := 0; i+1 < len(points); i += 2 {
+		data = append(data, marshalPoint(points[i], points[i+1]))
+	}
+	c := new(big.Int).SetBytes(crypto.Keccak256(data...))
+	return c.Mod(c, curve.N)
+}
+
+func output(gx, gy *big.Int) common.Hash {
+	return crypto.Keccak256Hash(outputDomain, marshalPoint(gx, gy))
+}
+
+func publicKeyPoint(pk *crypto.PublicKey) (*big.Int, *big.Int, error) {
+	if pk == nil || pk.IsEmpty() {
+		return nil, nil, ErrInvalidKey
+	}
+	pkBytes := pk.ToBytes()
+	if len(pkBytes) != 65 {
+		return nil, nil, ErrInvalidKey
+	}
+	x := new(big.Int).SetBytes(pkBytes[1:33])
+	y := new(big.Int).SetBytes(pkBytes[33:])
+	if !curve.IsOnCurve(x, y) {
+		return nil, nil, ErrInvalidKey
+	}
+	return x, y, nil
+}
+
+func marshalPoint(x, y *big.Int) []byte {
+	return append(common.LeftPadBytes(x.Bytes(), 32), common.LeftPadBytes(y.Bytes(), 32)...)
+}
@@ -0,0 +1,63 @@
+package vrf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/crypto"
+)
+
+func TestProveAndVerify(t *testing.T) {
+	assert := assert.New(t)
+
+	privKey, pubKey, err := crypto.GenerateKeyPair()
+	assert.Nil(err)
+	alpha := common.Bytes("epoch 42")
+
+	output, proof, err := Prove(privKey, alpha)
+	assert.Nil(err)
+	assert.Equal(ProofLength, len(proof))
+
+	verified, err := Verify(pubKey, alpha, proof)
+	assert.Nil(err)
+	assert.Equal(output, verified)
+
+	// The output is unique for the key and the input
+	output2, proof2, err := Prove(privKey, alpha)
+	assert.Nil(err)
+	assert.Equal(output, output2)
+	assert.Equal(proof, proof2)
+
+	output3, _, err := Prove(privKey, common.Bytes("epoch 43"))
+	assert.Nil(err)
+	assert.NotEqual(output, output3)
+}
+
+func TestVerifyRejectsInvalidProof(t *testing.T) {
+	assert := assert.New(t)
+
+	privKey, pubKey, err := crypto.GenerateKeyPair()
+	assert.Nil(err)
+	_, otherPubKey, err := crypto.GenerateKeyPair()
+	assert.Nil(err)
+	alpha := common.Bytes("epoch 42")
+	_, proof, err := Prove(privKey, alpha)
+	assert.Nil(err)
+
+	_, err = Verify(pubKey, common.Bytes("epoch 43"), proof)
+	assert.Equal(ErrInvalidProof, err)
+
+	_, err = Verify(otherPubKey, alpha, proof)
+	assert.Equal(ErrInvalidProof, err)
+
+	_, err = Verify(pubKey, alpha, proof[1:])
+	assert.Equal(ErrInvalidProof, err)
+
+	for _, i := range []int{0, 63, 64, 95, 96, 127} {
+		tampered := common.CopyBytes(proof)
+		tampered[i] ^= 0x01
+		_, err = Verify(pubKey, alpha, tampered)
+		assert.Equal(ErrInvalidProof, err, "byte %v", i)
+	}
+}
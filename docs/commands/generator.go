@@ -6,7 +6,7 @@ import (
 
 	"github.com/spf13/cobra/doc"
 	banjo "github.com/thetatoken/ukulele/cmd/banjo/cmd"
-	ukulele "github.com/thetatoken/ukulele/cmd/ukulele/cmd"
+	theta "github.com/thetatoken/ukulele/cmd/theta/cmd"
 )
 
 func generateBanjoDoc(filePrepender, linkHandler func(string) string) {
@@ -17,8 +17,8 @@ func generateBanjoDoc(filePrepender, linkHandler func(string) string) {
 	}
 }
 
-func generateUkuleleDoc(filePrepender, linkHandler func(string) string) {
-	var all = ukulele.RootCmd
+func generateThetaDoc(filePrepender, linkHandler func(string) string) {
+	var all = theta.RootCmd
 	err := doc.GenMarkdownTreeCustom(all, "./ledger/", filePrepender, linkHandler)
 	if err != nil {
 		log.Fatal(err)
@@ -35,6 +35,6 @@ func main() {
 	}
 
 	generateBanjoDoc(filePrepender, linkHandler)
-	generateUkuleleDoc(filePrepender, linkHandler)
+	generateThetaDoc(filePrepender, linkHandler)
 	Walk()
 }
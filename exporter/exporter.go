@@ -0,0 +1,174 @@
+package exporter
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/thetatoken/ukulele/blockchain"
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/common/metrics"
+	"github.com/thetatoken/ukulele/core"
+	"github.com/thetatoken/ukulele/crypto"
+	"github.com/thetatoken/ukulele/store"
+)
+
+var logger *log.Entry = log.WithFields(log.Fields{"prefix": "exporter"})
+
+const (
+	cursorKeyPrefix  = "ex/cursor/"
+	maxRetryInterval = 5 * time.Minute
+)
+
+// Exporter streams the finalized blocks to a sink, in the order of their heights,
+// from the height after the last block delivered to the sink. The height is only
+// saved after the sink acknowledges the blocks, so each block is delivered at least
+// once, including across restarts of the node.
+type Exporter struct {
+	chain        *blockchain.Chain
+	ledger       Ledger
+	store        store.Store
+	sink         Sink
+	startHeight  uint64
+	batchSize    int
+	pollInterval time.Duration
+
+	heightGauge  metrics.Gauge
+	failureMeter metrics.Meter
+
+	// Life cycle
+	wg     *sync.WaitGroup
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewExporter creates an instance of Exporter. A sink which has not received any
+// block yet receives the blocks from the start height, or from the root of the
+// chain if it is above.
+func NewExporter(chain *blockchain.Chain, ledger Ledger, store store.Store, sink Sink,
+	startHeight uint64, batchSize int, pollInterval time.Duration) *Exporter {
+	return &Exporter{
+		chain:        chain,
+		ledger:       ledger,
+		store:        store,
+		sink:         sink,
+		startHeight:  startHeight,
+		batchSize:    batchSize,
+		pollInterval: pollInterval,
+		heightGauge:  metrics.GetOrRegisterGauge("exporter/height", nil),
+		failureMeter: metrics.GetOrRegisterMeter("exporter/failures", nil),
+		wg:           &sync.WaitGroup{},
+	}
+}
+
+// Start starts exporting the blocks.
+func (e *Exporter) Start(ctx context.Context) {
+	c, cancel := context.WithCancel(ctx)
+	e.ctx = c
+	e.cancel = cancel
+
+	e.wg.Add(1)
+	go e.mainLoop()
+}
+
+// Stop notifies the exporter to stop without blocking.
+func (e *Exporter) Stop() {
+	e.cancel()
+}
+
+// Wait blocks until the exporter stops, and closes the sink.
+func (e *Exporter) Wait() {
+	e.wg.Wait()
+}
+
+// NextHeight returns the height of the next block to deliver to the sink.
+func (e *Exporter) NextHeight() uint64 {
+	var height uint64
+	err := e.store.Get(e.cursorKey(), &height)
+	if err == nil {
+		return height
+	}
+	if err != store.ErrKeyNotFound {
+		logger.WithFields(log.Fields{"err": err}).Panic("Failed to read the exporter cursor")
+	}
+	if e.chain.Root != nil && e.chain.Root.Height > e.startHeight {
+		return e.chain.Root.Height
+	}
+	return e.startHeight
+}
+
+func (e *Exporter) setNextHeight(height uint64) {
+	if err := e.store.Put(e.cursorKey(), height); err != nil {
+		logger.WithFields(log.Fields{"err": err}).Panic("Failed to save the exporter cursor")
+	}
+}
+
+func (e *Exporter) cursorKey() common.Bytes {
+	id := crypto.Keccak256Hash([]byte(e.sink.ID()))
+	return append(common.Bytes(cursorKeyPrefix), id[:]...)
+}
+
+// ExportNext delivers the next batch of finalized blocks to the sink, and returns
+// the number of blocks delivered.
+func (e *Exporter) ExportNext() (int, error) {
+	next := e.NextHeight()
+	records := []*BlockRecord{}
+	for height := next; len(records) < e.batchSize; height++ {
+		block := e.findFinalizedBlockByHeight(height)
+		if block == nil {
+			break
+		}
+		records = append(records, NewBlockRecord(block, e.ledger))
+	}
+	if len(records) == 0 {
+		return 0, nil
+	}
+	if err := e.sink.Write(records); err != nil {
+		return 0, err
+	}
+	e.setNextHeight(next + uint64(len(records)))
+	e.heightGauge.Update(int64(next) + int64(len(records)) - 1)
+	return len(records), nil
+}
+
+func (e *Exporter) mainLoop() {
+	defer e.wg.Done()
+	defer e.sink.Close()
+
+	logger.WithFields(log.Fields{"sink": e.sink.ID(), "height": e.NextHeight()}).Info("Exporting finalized blocks")
+
+	retryInterval := e.pollInterval
+	for {
+		wait := e.pollInterval
+		count, err := e.ExportNext()
+		if err != nil {
+			e.failureMeter.Mark(1)
+			logger.WithFields(log.Fields{"err": err, "height": e.NextHeight(), "retryIn": retryInterval}).Warn("Failed to export blocks")
+			wait = retryInterval
+			if retryInterval *= 2; retryInterval > maxRetryInterval {
+				retryInterval = maxRetryInterval
+			}
+		} else {
+			retryInterval = e.pollInterval
+			if count == e.batchSize {
+				wait = 0 // More blocks are probably finalized already
+			}
+		}
+
+		select {
+		case <-e.ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+func (e *Exporter) findFinalizedBlockByHeight(height uint64) *core.ExtendedBlock {
+	for _, block := range e.chain.FindBlocksByHeight(height) {
+		if block.Status == core.BlockStatusFinalized {
+			return block
+		}
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package exporter
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thetatoken/ukulele/blockchain"
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/core"
+	"github.com/thetatoken/ukulele/ledger/types"
+	"github.com/thetatoken/ukulele/store/database/backend"
+	"github.com/thetatoken/ukulele/store/kvstore"
+)
+
+type testLedger struct{}
+
+func (l testLedger) GetTxReceipt(hash common.Hash) (*types.TxReceipt, bool) {
+	return nil, false
+}
+
+func (l testLedger) GetBalanceJournal(height uint64) (*types.BalanceJournal, bool) {
+	return nil, false
+}
+
+type testSink struct {
+	records []*BlockRecord
+	err     error
+}
+
+func (s *testSink) ID() string { return "test" }
+
+func (s *testSink) Write(records []*BlockRecord) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.records = append(s.records, records...)
+	return nil
+}
+
+func (s *testSink) Close() error { return nil }
+
+func TestExporter(t *testing.T) {
+	assert := assert.New(t)
+	core.ResetTestBlocks()
+
+	chain := blockchain.CreateTestChainByBlocks([]string{
+		"A1", "A0",
+		"A2", "A1",
+		"A3", "A2",
+	})
+	a2, _ := chain.FindBlock(core.GetTestBlock("A2").Hash())
+	chain.FinalizeBlock(a2)
+
+	db := kvstore.NewKVStore(backend.NewMemDatabase())
+	sink := &testSink{}
+	exporter := NewExporter(chain, testLedger{}, db, sink, 0, 2, time.Second)
+	assert.Equal(uint64(0), exporter.NextHeight())
+
+	count, err := exporter.ExportNext()
+	assert.Nil(err)
+	assert.Equal(2, count)
+	assert.Equal(uint64(2), exporter.NextHeight())
+
+	// The blocks are delivered again if the sink fails
+	sink.err = errors.New("unavailable")
+	_, err = exporter.ExportNext()
+	assert.NotNil(err)
+	assert.Equal(uint64(2), exporter.NextHeight())
+	sink.err = nil
+
+	// A new exporter resumes from the saved height, and stops at the first height
+	// without a finalized block
+	exporter = NewExporter(chain, testLedger{}, db, sink, 0, 2, time.Second)
+	count, err = exporter.ExportNext()
+	assert.Nil(err)
+	assert.Equal(1, count)
+	count, err = exporter.ExportNext()
+	assert.Nil(err)
+	assert.Equal(0, count)
+
+	assert.Equal(3, len(sink.records))
+	for i, record := range sink.records {
+		assert.Equal(common.JSONUint64(i), record.Height)
+	}
+	assert.Equal(a2.Hash(), sink.records[2].Hash)
+	assert.Equal(uint64(3), exporter.NextHeight())
+}
+
+func TestExporterStartHeight(t *testing.T) {
+	assert := assert.New(t)
+	core.ResetTestBlocks()
+
+	chain := blockchain.CreateTestChainByBlocks([]string{
+		"A1", "A0",
+		"A2", "A1",
+	})
+	a2, _ := chain.FindBlock(core.GetTestBlock("A2").Hash())
+	chain.FinalizeBlock(a2)
+
+	sink := &testSink{}
+	exporter := NewExporter(chain, testLedger{}, kvstore.NewKVStore(backend.NewMemDatabase()), sink, 1, 10, time.Second)
+	count, err := exporter.ExportNext()
+	assert.Nil(err)
+	assert.Equal(2, count)
+	assert.Equal(common.JSONUint64(1), sink.records[0].Height)
+}
+
+func TestTxTypeName(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("SendTx", TxTypeName(&types.SendTx{}))
+	assert.Equal("CoinbaseTx", TxTypeName(&types.CoinbaseTx{}))
+}
@@ -0,0 +1,77 @@
+package exporter
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/Shopify/sarama"
+)
+
+var _ Sink = (*KafkaSink)(nil)
+
+// KafkaSink publishes a JSON message per block to a Kafka topic. The messages are
+// keyed by the chain ID, so that they land in the same partition in the order of
+// the heights. The records are delivered once all the in-sync replicas acknowledge
+// them.
+type KafkaSink struct {
+	brokers  []string
+	topic    string
+	producer sarama.SyncProducer
+}
+
+// NewKafkaSink creates a sink publishing to the topic of the given brokers.
+func NewKafkaSink(brokers []string, topic string) (*KafkaSink, error) {
+	addrs := []string{}
+	for _, broker := range brokers {
+		if broker = strings.TrimSpace(broker); broker != "" {
+			addrs = append(addrs, broker)
+		}
+	}
+	if len(addrs) == 0 {
+		return nil, errors.New("No Kafka broker specified")
+	}
+	if topic == "" {
+		return nil, errors.New("No Kafka topic specified")
+	}
+
+	config := sarama.NewConfig()
+	config.Producer.RequiredAcks = sarama.WaitForAll
+	config.Producer.Return.Successes = true
+	producer, err := sarama.NewSyncProducer(addrs, config)
+	if err != nil {
+		return nil, err
+	}
+	return &KafkaSink{
+		brokers:  addrs,
+		topic:    topic,
+		producer: producer,
+	}, nil
+}
+
+// ID implements the Sink interface.
+func (s *KafkaSink) ID() string {
+	return SinkKafka + ":" + strings.Join(s.brokers, ",") + "/" + s.topic
+}
+
+// Write implements the Sink interface.
+func (s *KafkaSink) Write(records []*BlockRecord) error {
+	messages := make([]*sarama.ProducerMessage, 0, len(records))
+	for _, record := range records {
+		value, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+		messages = append(messages, &sarama.ProducerMessage{
+			Topic: s.topic,
+			Key:   sarama.StringEncoder(record.ChainID),
+			Value: sarama.ByteEncoder(value),
+		})
+	}
+	return s.producer.SendMessages(messages)
+}
+
+// Close implements the Sink interface.
+func (s *KafkaSink) Close() error {
+	return s.producer.Close()
+}
@@ -0,0 +1,62 @@
+package exporter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+const ndjsonWriteTimeout = 30 * time.Second
+
+var _ Sink = (*NDJSONSink)(nil)
+
+// NDJSONSink POSTs the records to an HTTP endpoint as newline delimited JSON, one
+// block per line. The records are delivered once the endpoint responds with a 2xx
+// status.
+type NDJSONSink struct {
+	url    string
+	client *http.Client
+}
+
+// NewNDJSONSink creates a sink POSTing to the given URL.
+func NewNDJSONSink(url string) *NDJSONSink {
+	return &NDJSONSink{
+		url:    url,
+		client: &http.Client{Timeout: ndjsonWriteTimeout},
+	}
+}
+
+// ID implements the Sink interface.
+func (s *NDJSONSink) ID() string {
+	return SinkNDJSON + ":" + s.url
+}
+
+// Write implements the Sink interface.
+func (s *NDJSONSink) Write(records []*BlockRecord) error {
+	body := &bytes.Buffer{}
+	encoder := json.NewEncoder(body) // Encode terminates each record with a newline
+	for _, record := range records {
+		if err := encoder.Encode(record); err != nil {
+			return err
+		}
+	}
+	resp, err := s.client.Post(s.url, "application/x-ndjson", body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("Endpoint responded with status %v", resp.Status)
+	}
+	return nil
+}
+
+// Close implements the Sink interface.
+func (s *NDJSONSink) Close() error {
+	return nil
+}
@@ -0,0 +1,84 @@
+package exporter
+
+import (
+	"encoding/hex"
+
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/core"
+	"github.com/thetatoken/ukulele/crypto"
+	"github.com/thetatoken/ukulele/ledger/types"
+)
+
+// Ledger provides the results of the execution of the finalized blocks recorded by
+// the node.
+type Ledger interface {
+	GetTxReceipt(hash common.Hash) (*types.TxReceipt, bool)
+	GetBalanceJournal(height uint64) (*types.BalanceJournal, bool)
+}
+
+// BlockRecord is a finalized block as exported to the sinks, with its decoded
+// transactions, their receipts, and the balances it changed.
+type BlockRecord struct {
+	ChainID        string                `json:"chain_id"`
+	Hash           common.Hash           `json:"hash"`
+	Height         common.JSONUint64     `json:"height"`
+	Epoch          common.JSONUint64     `json:"epoch"`
+	Parent         common.Hash           `json:"parent"`
+	TxHash         common.Hash           `json:"transactions_hash"`
+	StateHash      common.Hash           `json:"state_hash"`
+	Timestamp      *common.JSONBig       `json:"timestamp"`
+	Proposer       common.Address        `json:"proposer"`
+	Transactions   []TxRecord            `json:"transactions"`
+	BalanceChanges []types.BalanceChange `json:"balance_changes"` // Nil if not recorded by the node, e.g. for the genesis
+}
+
+// TxRecord is a transaction of an exported block.
+type TxRecord struct {
+	Hash    common.Hash      `json:"hash"`
+	Index   int              `json:"index"`
+	Type    string           `json:"type"` // Name of the transaction type, e.g. "SendTx", "unknown" if it cannot be decoded
+	Raw     string           `json:"raw"`  // Hex encoded transaction
+	Tx      types.Tx         `json:"tx,omitempty"`
+	Receipt *types.TxReceipt `json:"receipt,omitempty"` // Receipt of a smart contract transaction
+}
+
+// NewBlockRecord creates the record of a finalized block.
+func NewBlockRecord(block *core.ExtendedBlock, ledger Ledger) *BlockRecord {
+	record := &BlockRecord{
+		ChainID:      block.ChainID,
+		Hash:         block.Hash(),
+		Height:       common.JSONUint64(block.Height),
+		Epoch:        common.JSONUint64(block.Epoch),
+		Parent:       block.Parent,
+		TxHash:       block.TxHash,
+		StateHash:    block.StateHash,
+		Timestamp:    (*common.JSONBig)(block.Timestamp),
+		Proposer:     block.Proposer,
+		Transactions: []TxRecord{},
+	}
+	for i, raw := range block.Txs {
+		txRecord := TxRecord{
+			Hash:  crypto.Keccak256Hash(raw),
+			Index: i,
+			Type:  "unknown",
+			Raw:   hex.EncodeToString(raw),
+		}
+		if tx, err := types.TxFromBytes(raw); err == nil {
+			txRecord.Tx = tx
+			txRecord.Type = TxTypeName(tx)
+		}
+		if receipt, ok := ledger.GetTxReceipt(txRecord.Hash); ok {
+			txRecord.Receipt = receipt
+		}
+		record.Transactions = append(record.Transactions, txRecord)
+	}
+	if journal, ok := ledger.GetBalanceJournal(block.Height); ok && journal.StateRoot == block.StateHash {
+		record.BalanceChanges = journal.Changes
+	}
+	return record
+}
+
+// TxTypeName returns the name of the type of the transaction, e.g. "SendTx".
+func TxTypeName(tx types.Tx) string {
+	return types.TxTypeName(tx)
+}
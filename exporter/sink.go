@@ -0,0 +1,43 @@
+package exporter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/thetatoken/ukulele/common"
+)
+
+// Sink kinds
+const (
+	SinkNDJSON   = "ndjson"   // Lines of JSON records POSTed to an HTTP endpoint
+	SinkKafka    = "kafka"    // One JSON message per block published to a Kafka topic
+	SinkPostgres = "postgres" // Rows upserted into PostgreSQL tables
+)
+
+// Sink receives the records of the finalized blocks, in the order of their heights.
+// The exporter may write the same records again, e.g. after a restart, so the sinks
+// need to tolerate duplicates.
+type Sink interface {
+	// ID identifies the destination of the records. The exporter keeps a cursor per
+	// destination, so that a new destination receives the blocks from the start height.
+	ID() string
+
+	// Write delivers the records, and returns an error if any of them may not have
+	// been delivered. The exporter then writes them again.
+	Write(records []*BlockRecord) error
+
+	Close() error
+}
+
+// NewSink creates the sink of the given config.
+func NewSink(config common.ExporterConfig) (Sink, error) {
+	switch config.Sink {
+	case SinkNDJSON:
+		return NewNDJSONSink(config.Endpoint), nil
+	case SinkKafka:
+		return NewKafkaSink(strings.Split(config.Endpoint, ","), config.Topic)
+	case SinkPostgres:
+		return NewPostgresSink(config.Endpoint)
+	}
+	return nil, fmt.Errorf("Unknown exporter sink %q", config.Sink)
+}
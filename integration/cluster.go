@@ -0,0 +1,369 @@
+package integration
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/consensus"
+	"github.com/thetatoken/ukulele/core"
+	"github.com/thetatoken/ukulele/crypto"
+	ld "github.com/thetatoken/ukulele/ledger"
+	"github.com/thetatoken/ukulele/ledger/types"
+	"github.com/thetatoken/ukulele/node"
+	p2psim "github.com/thetatoken/ukulele/p2p/simulation"
+	"github.com/thetatoken/ukulele/store/database"
+	"github.com/thetatoken/ukulele/store/database/backend"
+)
+
+// pollInterval is the interval between the checks of the conditions waited for.
+const pollInterval = 100 * time.Millisecond
+
+// Cluster is a local network of in-process validator nodes, connected by a simulated
+// network. Each node has its own in-memory database, and can be stopped and restarted
+// on it, so that transactions, crashes and recoveries can be tested end to end.
+type Cluster struct {
+	ChainID string
+	Genesis *core.Checkpoint
+	Simnet  *p2psim.Simnet
+	Nodes   []*ClusterNode
+
+	validators *core.ValidatorSet
+	sequences  map[common.Address]uint64 // Last sequence sent by each account
+
+	mu     *sync.Mutex
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// ClusterNode is a validator node of a cluster.
+type ClusterNode struct {
+	PrivateKey *crypto.PrivateKey
+	DB         database.Database
+	DryRun     bool       // Whether the node follows the chain without voting or proposing once (re)started
+	Node       *node.Node // nil while the node is stopped
+
+	cancel context.CancelFunc
+}
+
+// ID returns the ID of the node, i.e. the address of its validator.
+func (n *ClusterNode) ID() string {
+	return n.PrivateKey.PublicKey().Address().Hex()
+}
+
+// Address returns the address of the account of the node's validator.
+func (n *ClusterNode) Address() common.Address {
+	return n.PrivateKey.PublicKey().Address()
+}
+
+// Running returns whether the node is running.
+func (n *ClusterNode) Running() bool {
+	return n.Node != nil
+}
+
+// NewCluster creates a cluster of the given number of validators, with equal stakes.
+// The genesis state funds the account of each validator.
+func NewCluster(numNodes int) (*Cluster, error) {
+	c := &Cluster{
+		Simnet:    p2psim.NewSimnet(),
+		sequences: make(map[common.Address]uint64),
+		mu:        &sync.Mutex{},
+	}
+	pubKeys := []string{}
+	for i := 0; i < numNodes; i++ {
+		privKey, pubKey, err := crypto.GenerateKeyPair()
+		if err != nil {
+			return nil, err
+		}
+		pubKeys = append(pubKeys, hex.EncodeToString(pubKey.ToBytes()))
+		c.Nodes = append(c.Nodes, &ClusterNode{
+			PrivateKey: privKey,
+			DB:         backend.NewMemDatabase(),
+		})
+	}
+	genesis, err := consensus.GenerateGenesisCheckpoint(pubKeys)
+	if err != nil {
+		return nil, err
+	}
+	c.Genesis = genesis
+	c.ChainID = genesis.FirstBlock.ChainID
+	c.validators = consensus.NewTestValidatorSet(pubKeys)
+	for _, n := range c.Nodes {
+		consensus.LoadCheckpointLedgerState(genesis, n.DB)
+	}
+	return c, nil
+}
+
+// Start starts the simulated network and all the nodes.
+func (c *Cluster) Start(ctx context.Context) {
+	c.ctx, c.cancel = context.WithCancel(ctx)
+	c.Simnet.Start(c.ctx)
+	for i := range c.Nodes {
+		c.StartNode(i)
+	}
+}
+
+// Stop stops all the nodes and the simulated network, and waits for the nodes to stop.
+func (c *Cluster) Stop() {
+	for i := range c.Nodes {
+		c.StopNode(i)
+	}
+	c.cancel()
+}
+
+// StartNode starts the i-th node on its database, with a new endpoint of the simulated
+// network. It does nothing if the node is running.
+func (c *Cluster) StartNode(i int) {
+	n := c.Nodes[i]
+	if n.Running() {
+		return
+	}
+	params := &node.Params{
+		ChainID:    c.ChainID,
+		PrivateKey: n.PrivateKey,
+		Root:       c.Genesis.FirstBlock,
+		Validators: c.validators,
+		Network:    c.Simnet.ReplaceEndpoint(n.ID()),
+		DB:         n.DB,
+		DryRun:     n.DryRun,
+	}
+	c.Simnet.Connect(n.ID())
+	n.Node = node.NewNode(params)
+
+	var ctx context.Context
+	ctx, n.cancel = context.WithCancel(c.ctx)
+	if err := n.Node.Start(ctx); err != nil {
+		panic(fmt.Sprintf("Failed to start node %v: %v", n.ID(), err))
+	}
+}
+
+// StopNode stops the i-th node as if it crashed: it is disconnected from the
+// simulated network, and its database is kept as is. It does nothing if the node is
+// stopped.
+func (c *Cluster) StopNode(i int) {
+	n := c.Nodes[i]
+	if !n.Running() {
+		return
+	}
+	c.Simnet.Disconnect(n.ID())
+	n.cancel()
+	n.Node.Wait()
+	n.Node = nil
+}
+
+// RestartNode stops the i-th node and starts it again on its database.
+func (c *Cluster) RestartNode(i int) {
+	c.StopNode(i)
+	c.StartNode(i)
+}
+
+// Running returns the nodes that are running.
+func (c *Cluster) Running() []*ClusterNode {
+	ret := []*ClusterNode{}
+	for _, n := range c.Nodes {
+		if n.Running() {
+			ret = append(ret, n)
+		}
+	}
+	return ret
+}
+
+// SendTx sends Gamma from the validator account of a node to the one of another
+// node, through the mempool of the sender node. It returns the hash of the
+// transaction.
+func (c *Cluster) SendTx(from int, to int, gammaWei *big.Int) (common.Hash, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	sender := c.Nodes[from]
+	if !sender.Running() {
+		return common.Hash{}, fmt.Errorf("Node %v is stopped", from)
+	}
+	fee := new(big.Int).SetUint64(types.MinimumTransactionFeeGammaWei)
+	sequence := c.sequences[sender.Address()] + 1
+	tx := &types.SendTx{
+		Fee: types.Coins{ThetaWei: big.NewInt(0), GammaWei: fee},
+		Inputs: []types.TxInput{{
+			Address:  sender.Address(),
+			Coins:    types.Coins{ThetaWei: big.NewInt(0), GammaWei: new(big.Int).Add(gammaWei, fee)},
+			Sequence: sequence,
+		}},
+		Outputs: []types.TxOutput{{
+			Address: c.Nodes[to].Address(),
+			Coins:   types.Coins{ThetaWei: big.NewInt(0), GammaWei: gammaWei},
+		}},
+	}
+	sig, err := sender.PrivateKey.Sign(tx.SignBytes(c.ChainID))
+	if err != nil {
+		return common.Hash{}, err
+	}
+	tx.SetSignature(sender.Address(), sig)
+	raw, err := types.TxToBytes(tx)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if err := c.SubmitTx(from, raw); err != nil {
+		return common.Hash{}, err
+	}
+	c.sequences[sender.Address()] = sequence
+	return crypto.Keccak256Hash(raw), nil
+}
+
+// SubmitTx inserts a signed transaction in the mempool of a running node, which
+// gossips it to the other nodes.
+func (c *Cluster) SubmitTx(i int, raw common.Bytes) error {
+	n := c.Nodes[i]
+	if !n.Running() {
+		return fmt.Errorf("Node %v is stopped", i)
+	}
+	if err := n.Node.Mempool.InsertTransaction(raw); err != nil {
+		return errors.Wrapf(err, "Failed to insert transaction in the mempool of node %v", i)
+	}
+	return nil
+}
+
+// FinalizedBlock returns the last finalized block of a running node.
+func (c *Cluster) FinalizedBlock(i int) (*core.ExtendedBlock, error) {
+	n := c.Nodes[i]
+	if !n.Running() {
+		return nil, fmt.Errorf("Node %v is stopped", i)
+	}
+	return n.Node.Chain.FindBlock(n.Node.Consensus.GetSummary().LastFinalizedBlock)
+}
+
+// FinalizedHeight returns the height of the last finalized block of a running node.
+func (c *Cluster) FinalizedHeight(i int) uint64 {
+	block, err := c.FinalizedBlock(i)
+	if err != nil {
+		return 0
+	}
+	return block.Height
+}
+
+// MinFinalizedHeight returns the lowest height finalized by the running nodes.
+func (c *Cluster) MinFinalizedHeight() uint64 {
+	min := uint64(0)
+	first := true
+	for i, n := range c.Nodes {
+		if !n.Running() {
+			continue
+		}
+		if height := c.FinalizedHeight(i); first || height < min {
+			min = height
+		}
+		first = false
+	}
+	return min
+}
+
+// MaxFinalizedHeight returns the highest height finalized by the running nodes.
+func (c *Cluster) MaxFinalizedHeight() uint64 {
+	max := uint64(0)
+	for i, n := range c.Nodes {
+		if n.Running() && c.FinalizedHeight(i) > max {
+			max = c.FinalizedHeight(i)
+		}
+	}
+	return max
+}
+
+// WaitFor polls the condition until it holds, or fails after the timeout.
+func (c *Cluster) WaitFor(description string, timeout time.Duration, condition func() bool) error {
+	deadline := time.Now().Add(timeout)
+	for !condition() {
+		if time.Now().After(deadline) {
+			return fmt.Errorf("Timed out after %v waiting for %v", timeout, description)
+		}
+		time.Sleep(pollInterval)
+	}
+	return nil
+}
+
+// WaitForFinalizedHeight waits until all the running nodes finalize the given height.
+func (c *Cluster) WaitForFinalizedHeight(height uint64, timeout time.Duration) error {
+	return c.WaitFor(fmt.Sprintf("all the running nodes to finalize height %v", height), timeout, func() bool {
+		return c.MinFinalizedHeight() >= height
+	})
+}
+
+// WaitForTx waits until the transaction is in a finalized block of all the running
+// nodes.
+func (c *Cluster) WaitForTx(hash common.Hash, timeout time.Duration) error {
+	return c.WaitFor(fmt.Sprintf("transaction %v to be finalized", hash.Hex()), timeout, func() bool {
+		for _, n := range c.Running() {
+			_, block, found := n.Node.Chain.FindTxByHash(hash)
+			if !found || block.Status != core.BlockStatusFinalized {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// Balance returns the Gamma balance of the validator account of a node in the
+// finalized state of another running node.
+func (c *Cluster) Balance(of int, in int) (*big.Int, error) {
+	account, err := c.Account(c.Nodes[of].Address(), in)
+	if err != nil {
+		return nil, err
+	}
+	if account == nil {
+		return big.NewInt(0), nil
+	}
+	return account.Balance.GammaWei, nil
+}
+
+// Account returns an account in the finalized state of a running node, nil if the
+// account does not exist.
+func (c *Cluster) Account(address common.Address, in int) (*types.Account, error) {
+	n := c.Nodes[in]
+	if !n.Running() {
+		return nil, fmt.Errorf("Node %v is stopped", in)
+	}
+	ledger, ok := n.Node.Ledger.(*ld.Ledger)
+	if !ok {
+		return nil, fmt.Errorf("Node %v has no queryable ledger", in)
+	}
+	view, err := ledger.GetFinalizedSnapshot()
+	if err != nil {
+		return nil, err
+	}
+	return view.GetAccount(address), nil
+}
+
+// CheckSafety checks that the running nodes never finalized different blocks at the
+// same height.
+func (c *Cluster) CheckSafety() error {
+	finalizedByHeight := make(map[uint64]common.Hash)
+	finalizedBy := make(map[uint64]string)
+	for i, n := range c.Nodes {
+		if !n.Running() {
+			continue
+		}
+		block, err := c.FinalizedBlock(i)
+		if err != nil {
+			return errors.Wrapf(err, "Failed to find the last finalized block of node %v", i)
+		}
+		for {
+			hash := block.Hash()
+			if other, ok := finalizedByHeight[block.Height]; ok && other != hash {
+				return fmt.Errorf("Nodes %v and %v finalized different blocks at height %v: %v and %v",
+					finalizedBy[block.Height], n.ID(), block.Height, other.Hex(), hash.Hex())
+			}
+			finalizedByHeight[block.Height] = hash
+			finalizedBy[block.Height] = n.ID()
+			if block.Parent.IsEmpty() || hash == n.Node.Chain.Root.Hash() {
+				break
+			}
+			if block, err = n.Node.Chain.FindBlock(block.Parent); err != nil {
+				return errors.Wrapf(err, "Failed to find a finalized block of node %v", i)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,23 @@
+// +build integration
+
+package integration
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/thetatoken/ukulele/common"
+)
+
+func TestScenarios(t *testing.T) {
+	viper.Set(common.CfgLogLevels, "*:error")
+	viper.Set(common.CfgLogPrintSelfID, true)
+
+	for _, s := range Scenarios {
+		s := s
+		t.Run(s.Name, func(t *testing.T) {
+			assert.Nil(t, RunScenario(s))
+		})
+	}
+}
@@ -0,0 +1,218 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/thetatoken/ukulele/common"
+)
+
+// finalizationTimeout bounds the time waited for the cluster to finalize a few blocks.
+const finalizationTimeout = 60 * time.Second
+
+// Scenario is a run of a cluster, which fails if the cluster violates a safety or
+// liveness invariant.
+type Scenario struct {
+	Name        string
+	Description string
+	Nodes       int
+	Run         func(c *Cluster) error
+}
+
+// Scenarios are the scenarios run by the integration tests and the scenario tool.
+var Scenarios = []Scenario{
+	{
+		Name:        "liveness",
+		Description: "All the validators finalize blocks",
+		Nodes:       4,
+		Run:         runLiveness,
+	},
+	{
+		Name:        "transactions",
+		Description: "Transactions submitted to different nodes are finalized, and all the nodes agree on the balances",
+		Nodes:       4,
+		Run:         runTransactions,
+	},
+	{
+		Name:        "restart",
+		Description: "The cluster keeps finalizing blocks while a validator is down, and the validator catches up after restarting",
+		Nodes:       4,
+		Run:         runRestart,
+	},
+	{
+		Name:        "no-quorum",
+		Description: "No block is finalized while half of the validators are down, and the cluster recovers when they restart",
+		Nodes:       4,
+		Run:         runNoQuorum,
+	},
+	{
+		Name:        "dry-run",
+		Description: "A validator restarted in dry-run mode follows the chain without voting or proposing",
+		Nodes:       4,
+		Run:         runDryRun,
+	},
+}
+
+// FindScenario returns the scenario of the given name.
+func FindScenario(name string) (Scenario, bool) {
+	for _, s := range Scenarios {
+		if s.Name == name {
+			return s, true
+		}
+	}
+	return Scenario{}, false
+}
+
+// RunScenario runs the scenario on a new cluster, and checks the safety of the
+// cluster at the end.
+func RunScenario(s Scenario) error {
+	c, err := NewCluster(s.Nodes)
+	if err != nil {
+		return err
+	}
+	log.WithFields(log.Fields{"scenario": s.Name, "nodes": s.Nodes}).Info("Starting scenario")
+	c.Start(context.Background())
+	defer c.Stop()
+
+	if err := s.Run(c); err != nil {
+		return err
+	}
+	return c.CheckSafety()
+}
+
+func runLiveness(c *Cluster) error {
+	return c.WaitForFinalizedHeight(3, finalizationTimeout)
+}
+
+func runTransactions(c *Cluster) error {
+	// The mempools reject the transactions gossiped out of order, so each account
+	// sends a single transaction per round.
+	amount := big.NewInt(1000)
+	numRounds := 2
+	recipient := len(c.Nodes) - 1
+	if err := c.WaitForFinalizedHeight(1, finalizationTimeout); err != nil {
+		return err
+	}
+	initial, err := c.Balance(recipient, 0)
+	if err != nil {
+		return err
+	}
+	for round := 0; round < numRounds; round++ {
+		hashes := []common.Hash{}
+		for sender := 0; sender < recipient; sender++ {
+			hash, err := c.SendTx(sender, recipient, amount)
+			if err != nil {
+				return err
+			}
+			hashes = append(hashes, hash)
+		}
+		for _, hash := range hashes {
+			if err := c.WaitForTx(hash, finalizationTimeout); err != nil {
+				return err
+			}
+		}
+	}
+
+	numTxs := int64(numRounds * recipient)
+	expected := new(big.Int).Add(initial, new(big.Int).Mul(amount, big.NewInt(numTxs)))
+	for i := range c.Nodes {
+		balance, err := c.Balance(recipient, i)
+		if err != nil {
+			return err
+		}
+		if balance.Cmp(expected) != 0 {
+			return fmt.Errorf("Node %v has balance %v for the recipient, expected %v", i, balance, expected)
+		}
+	}
+	return nil
+}
+
+func runRestart(c *Cluster) error {
+	if err := c.WaitForFinalizedHeight(2, finalizationTimeout); err != nil {
+		return err
+	}
+	c.StopNode(3)
+	height := c.MaxFinalizedHeight()
+	if err := c.WaitForFinalizedHeight(height+2, finalizationTimeout); err != nil {
+		return err
+	}
+	if err := c.CheckSafety(); err != nil {
+		return err
+	}
+
+	c.StartNode(3)
+	height = c.MaxFinalizedHeight()
+	return c.WaitFor(fmt.Sprintf("the restarted node to catch up with height %v", height), finalizationTimeout, func() bool {
+		return c.FinalizedHeight(3) >= height
+	})
+}
+
+func runNoQuorum(c *Cluster) error {
+	if err := c.WaitForFinalizedHeight(2, finalizationTimeout); err != nil {
+		return err
+	}
+	c.StopNode(2)
+	c.StopNode(3)
+	time.Sleep(2 * time.Second) // Let the blocks voted before the crash be finalized
+	height := c.MaxFinalizedHeight()
+	time.Sleep(10 * time.Second)
+	if finalized := c.MaxFinalizedHeight(); finalized > height {
+		return fmt.Errorf("Height %v was finalized without a quorum of the validators", finalized)
+	}
+
+	c.StartNode(2)
+	c.StartNode(3)
+	return c.WaitForFinalizedHeight(height+2, finalizationTimeout)
+}
+
+func runDryRun(c *Cluster) error {
+	if err := c.WaitForFinalizedHeight(2, finalizationTimeout); err != nil {
+		return err
+	}
+	// The other validators hold enough stake to finalize blocks, as long as the
+	// proposer is one of them
+	observer := c.Nodes[0].Node
+	proposer := observer.ValidatorManager.GetProposerForEpoch(observer.Consensus.GetEpoch()).ID()
+	dryRun := len(c.Nodes) - 1
+	if c.Nodes[dryRun].Address() == proposer {
+		dryRun--
+	}
+	c.Nodes[dryRun].DryRun = true
+	c.RestartNode(dryRun)
+
+	// The votes of the node before its restart may still finalize the next blocks
+	height := c.MaxFinalizedHeight() + 2
+	if err := c.WaitForFinalizedHeight(height+3, finalizationTimeout); err != nil {
+		return err
+	}
+
+	dryRunID := c.Nodes[dryRun].Address()
+	for i, n := range c.Nodes {
+		if i == dryRun {
+			continue
+		}
+		block, err := c.FinalizedBlock(i)
+		if err != nil {
+			return err
+		}
+		for block.Height > height {
+			if block.Proposer == dryRunID {
+				return fmt.Errorf("Dry-run node %v proposed block %v", dryRun, block.Hash().Hex())
+			}
+			if cc := n.Node.Consensus.GetFinalizedCommitCertificate(block.Hash()); cc != nil {
+				for _, vote := range cc.Votes.Votes() {
+					if vote.ID == dryRunID {
+						return fmt.Errorf("Dry-run node %v voted for block %v", dryRun, block.Hash().Hex())
+					}
+				}
+			}
+			if block, err = n.Node.Chain.FindBlock(block.Parent); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -26,7 +26,7 @@ func testConsensus(assert *assert.Assertions, simnet *p2psim.Simnet, nodes []*no
 	}
 	simnet.Start(ctx)
 	for _, node := range nodes {
-		node.Start(ctx)
+		assert.Nil(node.Start(ctx))
 		wg.Add(1)
 		go func(n core.ConsensusEngine) {
 			defer func() {
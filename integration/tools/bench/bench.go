@@ -0,0 +1,50 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/spf13/viper"
+	"github.com/thetatoken/ukulele/bench"
+	"github.com/thetatoken/ukulele/common"
+)
+
+// Runs a throughput benchmark on a local cluster and prints the results as JSON,
+// e.g. bench -rate=200 -duration=1m -servicePaymentRatio=0.5 -out=results.json
+func main() {
+	config := bench.DefaultConfig()
+	flag.IntVar(&config.Nodes, "nodes", config.Nodes, "number of validators of the cluster")
+	flag.IntVar(&config.Accounts, "accounts", config.Accounts, "number of accounts sending transactions")
+	flag.Float64Var(&config.Rate, "rate", config.Rate, "transactions submitted per second")
+	flag.DurationVar(&config.Duration, "duration", config.Duration, "time during which transactions are submitted")
+	flag.DurationVar(&config.DrainTimeout, "drain", config.DrainTimeout, "time waited for the pending transactions to be finalized")
+	flag.Float64Var(&config.ServicePaymentRatio, "servicePaymentRatio", config.ServicePaymentRatio, "fraction of the transactions that are service payments")
+	outPtr := flag.String("out", "", "file the results are written to, stdout by default")
+	logPtr := flag.String("log", "*:error", "log levels of the nodes")
+	flag.Parse()
+
+	viper.Set(common.CfgLogLevels, *logPtr)
+	viper.Set(common.CfgLogPrintSelfID, true)
+
+	result, err := bench.Run(config)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	output, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	if *outPtr == "" {
+		fmt.Println(string(output))
+		return
+	}
+	if err := ioutil.WriteFile(*outPtr, output, 0644); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/thetatoken/ukulele/common"
 	"github.com/thetatoken/ukulele/consensus"
 	"github.com/thetatoken/ukulele/store/database/backend"
+	"github.com/thetatoken/ukulele/store/freezer"
 	"github.com/thetatoken/ukulele/store/kvstore"
 )
 
@@ -24,7 +25,7 @@ func handleError(err error) {
 }
 
 func printUsage() {
-	fmt.Println("Usage: query_db -config=<path_to_config_home> -type=block -hash=<hash> -height=<height>")
+	fmt.Println("Usage: query_db -config=<path_to_config_home> -type=block -hash=<hash> -height=<height> -backend=<storage_backend>")
 }
 
 func main() {
@@ -32,6 +33,7 @@ func main() {
 	queryTypePtr := flag.String("type", "block", "type of object to query")
 	hashStrPtr := flag.String("hash", "", "hash of the object")
 	heightStrPtr := flag.String("height", "", "block height")
+	backendPtr := flag.String("backend", backend.BackendLevelDB, "storage backend of the node")
 
 	flag.Parse()
 
@@ -42,12 +44,14 @@ func main() {
 
 	checkpoint, err := consensus.LoadCheckpoint(path.Join(configPath, "genesis"))
 	handleError(err)
-	mainDBPath := path.Join(configPath, "db", "main")
-	refDBPath := path.Join(configPath, "db", "ref")
-	db, err := backend.NewLDBDatabase(mainDBPath, refDBPath, 256, 0)
+	db, err := backend.NewBackend(*backendPtr, path.Join(configPath, "db"), 256, 0)
+	handleError(err)
 	root := checkpoint.FirstBlock
 	store := kvstore.NewKVStore(db)
 	chain := blockchain.NewChain(root.ChainID, store, root)
+	f, err := freezer.Open(path.Join(configPath, "db", "freezer"))
+	handleError(err)
+	chain.SetFreezer(f, 0)
 
 	if queryType == "block" {
 		if hashStr != "" {
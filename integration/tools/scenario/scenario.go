@@ -0,0 +1,56 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/integration"
+)
+
+func printUsage() {
+	fmt.Println("Usage: scenario -name=<scenario|all> -log=<log_levels>")
+	fmt.Println("Scenarios:")
+	for _, s := range integration.Scenarios {
+		fmt.Printf("  %-14v %v\n", s.Name, s.Description)
+	}
+}
+
+// Runs the integration scenarios on local clusters, e.g. in CI, and exits with
+// status 1 if any of them fails.
+func main() {
+	namePtr := flag.String("name", "all", "name of the scenario to run, or all")
+	logPtr := flag.String("log", "*:error", "log levels of the nodes")
+	flag.Parse()
+
+	viper.Set(common.CfgLogLevels, *logPtr)
+	viper.Set(common.CfgLogPrintSelfID, true)
+
+	scenarios := integration.Scenarios
+	if *namePtr != "all" {
+		s, ok := integration.FindScenario(*namePtr)
+		if !ok {
+			fmt.Printf("Error: unknown scenario %v\n", *namePtr)
+			printUsage()
+			os.Exit(1)
+		}
+		scenarios = []integration.Scenario{s}
+	}
+
+	failed := 0
+	for _, s := range scenarios {
+		start := time.Now()
+		if err := integration.RunScenario(s); err != nil {
+			fmt.Printf("FAIL %v (%v): %v\n", s.Name, time.Since(start).Round(time.Second), err)
+			failed++
+			continue
+		}
+		fmt.Printf("ok   %v (%v)\n", s.Name, time.Since(start).Round(time.Second))
+	}
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
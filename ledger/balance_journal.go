@@ -0,0 +1,79 @@
+package ledger
+
+import (
+	"bytes"
+	"encoding/binary"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/thetatoken/ukulele/common"
+	st "github.com/thetatoken/ukulele/ledger/state"
+	"github.com/thetatoken/ukulele/ledger/types"
+	"github.com/thetatoken/ukulele/store"
+)
+
+// balanceJournalPrefix prefixes the DB keys of the balance journals, stored next to
+// the transaction receipts.
+var balanceJournalPrefix = common.Bytes("balancejournal/")
+
+// balanceJournalKey constructs the DB key for the given block height.
+func balanceJournalKey(height uint64) common.Bytes {
+	key := make(common.Bytes, len(balanceJournalPrefix)+8)
+	copy(key, balanceJournalPrefix)
+	binary.BigEndian.PutUint64(key[len(balanceJournalPrefix):], height)
+	return key
+}
+
+// newBalanceJournal compares the balances of the accounts written by a block in the
+// parent state and in the state after the block. The accounts whose balance did not
+// change, e.g. only their sequence, are left out.
+func newBalanceJournal(height uint64, parent, view *st.StoreView, accesses *st.AccessSet) *types.BalanceJournal {
+	prefix := st.AccountKeyPrefix()
+	changes := []types.BalanceChange{}
+	for _, key := range accesses.WrittenKeys() {
+		if !bytes.HasPrefix(key, prefix) || len(key) != len(prefix)+common.AddressLength {
+			continue
+		}
+		address := common.BytesToAddress(key[len(prefix):])
+		before, after := types.NewCoins(0, 0), types.NewCoins(0, 0)
+		if account := parent.GetAccount(address); account != nil {
+			before = account.Balance.NoNil()
+		}
+		if account := view.GetAccount(address); account != nil {
+			after = account.Balance.NoNil()
+		}
+		if before.IsEqual(after) {
+			continue
+		}
+		changes = append(changes, types.BalanceChange{
+			Address: address,
+			Before:  before,
+			After:   after,
+		})
+	}
+	return types.NewBalanceJournal(height, view.Hash(), changes)
+}
+
+// saveBalanceJournal stores the balance journal of an applied block, by its height. The
+// journal of a block replaces the one of another block applied at the same height.
+func (ledger *Ledger) saveBalanceJournal(journal *types.BalanceJournal) {
+	if err := ledger.store.Put(balanceJournalKey(journal.Height), journal); err != nil {
+		log.Panic(err)
+	}
+}
+
+// GetBalanceJournal returns the balances changed by the last block applied at the given
+// height, if the node recorded them. The state root of the journal tells the block apart
+// from the other blocks at the same height.
+func (ledger *Ledger) GetBalanceJournal(height uint64) (*types.BalanceJournal, bool) {
+	journal := &types.BalanceJournal{}
+	err := ledger.store.Get(balanceJournalKey(height), journal)
+	if err == store.ErrKeyNotFound {
+		return nil, false
+	}
+	if err != nil {
+		log.Errorf("Failed to load the balance journal of height %v: %v", height, err)
+		return nil, false
+	}
+	return journal, true
+}
@@ -0,0 +1,229 @@
+package ledger
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/crypto"
+	"github.com/thetatoken/ukulele/ledger/types"
+)
+
+const (
+	// doubleSpendWindow is the number of recent blocks whose transactions the incoming
+	// transactions are checked against.
+	doubleSpendWindow = 1000
+
+	// maxDoubleSpendAlerts is the number of recent alerts retained for the subscribers.
+	maxDoubleSpendAlerts = 1000
+)
+
+// DoubleSpendAlert reports two different transactions spending from the same address
+// with the same sequence: one included in a block, and one arriving afterwards.
+type DoubleSpendAlert struct {
+	ID                uint64         // Increasing ID of the alert, starting from 1
+	Address           common.Address // Address of the account spending twice
+	Sequence          uint64         // Sequence used by both transactions
+	BlockHeight       uint64         // Height of the block including the first transaction
+	IncludedTxHash    common.Hash    // Hash of the transaction included in the block
+	ConflictingTxHash common.Hash    // Hash of the transaction arriving afterwards
+	Time              time.Time      // Time the conflicting transaction arrived
+}
+
+type DoubleSpendAlertJSON struct {
+	ID                common.JSONUint64 `json:"id"`
+	Address           common.Address    `json:"address"`
+	Sequence          common.JSONUint64 `json:"sequence"`
+	BlockHeight       common.JSONUint64 `json:"block_height"`
+	IncludedTxHash    common.Hash       `json:"included_tx_hash"`
+	ConflictingTxHash common.Hash       `json:"conflicting_tx_hash"`
+	Time              time.Time         `json:"time"`
+}
+
+func (a DoubleSpendAlert) MarshalJSON() ([]byte, error) {
+	return json.Marshal(DoubleSpendAlertJSON{
+		ID:                common.JSONUint64(a.ID),
+		Address:           a.Address,
+		Sequence:          common.JSONUint64(a.Sequence),
+		BlockHeight:       common.JSONUint64(a.BlockHeight),
+		IncludedTxHash:    a.IncludedTxHash,
+		ConflictingTxHash: a.ConflictingTxHash,
+		Time:              a.Time,
+	})
+}
+
+func (a *DoubleSpendAlert) UnmarshalJSON(data []byte) error {
+	var b DoubleSpendAlertJSON
+	if err := json.Unmarshal(data, &b); err != nil {
+		return err
+	}
+	*a = DoubleSpendAlert{
+		ID:                uint64(b.ID),
+		Address:           b.Address,
+		Sequence:          uint64(b.Sequence),
+		BlockHeight:       uint64(b.BlockHeight),
+		IncludedTxHash:    b.IncludedTxHash,
+		ConflictingTxHash: b.ConflictingTxHash,
+		Time:              b.Time,
+	}
+	return nil
+}
+
+type spendKey struct {
+	address  common.Address
+	sequence uint64
+}
+
+type spend struct {
+	txHash common.Hash
+	height uint64
+}
+
+// doubleSpendDetector records the sequences spent by the transactions of the recent
+// blocks, and raises an alert when another transaction spends one of them again.
+type doubleSpendDetector struct {
+	mu *sync.Mutex
+
+	spends      map[spendKey]spend
+	blockSpends [][]spendKey // Keys recorded for each recent block, oldest first
+
+	alerts []*DoubleSpendAlert
+	nextID uint64
+	notify chan struct{} // Closed and replaced when an alert is raised
+}
+
+func newDoubleSpendDetector() *doubleSpendDetector {
+	return &doubleSpendDetector{
+		mu:     &sync.Mutex{},
+		spends: make(map[spendKey]spend),
+		nextID: 1,
+		notify: make(chan struct{}),
+	}
+}
+
+// recordBlock records the sequences spent by the transactions of the block at the
+// given height, and forgets the blocks out of the window.
+func (d *doubleSpendDetector) recordBlock(height uint64, txs []types.Tx, txHashes []common.Hash) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	keys := []spendKey{}
+	for i, tx := range txs {
+		for _, input := range types.SpendingInputs(tx) {
+			key := spendKey{address: input.Address, sequence: input.Sequence}
+			d.spends[key] = spend{txHash: txHashes[i], height: height}
+			keys = append(keys, key)
+		}
+	}
+	d.blockSpends = append(d.blockSpends, keys)
+
+	for len(d.blockSpends) > doubleSpendWindow {
+		for _, key := range d.blockSpends[0] {
+			if s, ok := d.spends[key]; ok && s.height+doubleSpendWindow <= height {
+				delete(d.spends, key)
+			}
+		}
+		d.blockSpends = d.blockSpends[1:]
+	}
+}
+
+// check raises an alert for each input of the transaction spending a sequence already
+// spent by a different transaction of the recent blocks.
+func (d *doubleSpendDetector) check(tx types.Tx, txHash common.Hash) []*DoubleSpendAlert {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	raised := []*DoubleSpendAlert{}
+	for _, input := range types.SpendingInputs(tx) {
+		s, ok := d.spends[spendKey{address: input.Address, sequence: input.Sequence}]
+		if !ok || s.txHash == txHash || d.hasAlert(txHash, input.Address) {
+			continue
+		}
+		alert := &DoubleSpendAlert{
+			ID:                d.nextID,
+			Address:           input.Address,
+			Sequence:          input.Sequence,
+			BlockHeight:       s.height,
+			IncludedTxHash:    s.txHash,
+			ConflictingTxHash: txHash,
+			Time:              time.Now(),
+		}
+		d.nextID++
+		d.alerts = append(d.alerts, alert)
+		raised = append(raised, alert)
+
+		log.Warnf("Double spend detected: address %v, sequence %v, tx %v included at height %v, conflicting tx %v",
+			input.Address.Hex(), input.Sequence, s.txHash.Hex(), s.height, txHash.Hex())
+	}
+	if len(raised) == 0 {
+		return raised
+	}
+
+	if len(d.alerts) > maxDoubleSpendAlerts {
+		d.alerts = d.alerts[len(d.alerts)-maxDoubleSpendAlerts:]
+	}
+	close(d.notify)
+	d.notify = make(chan struct{})
+	return raised
+}
+
+func (d *doubleSpendDetector) hasAlert(txHash common.Hash, address common.Address) bool {
+	for _, alert := range d.alerts {
+		if alert.ConflictingTxHash == txHash && alert.Address == address {
+			return true
+		}
+	}
+	return false
+}
+
+// alertsFrom returns the retained alerts with an ID of at least fromID. If there are
+// none, it waits up to the timeout for one to be raised.
+func (d *doubleSpendDetector) alertsFrom(fromID uint64, timeout time.Duration) []*DoubleSpendAlert {
+	d.mu.Lock()
+	alerts := d.alertsFromUnsafe(fromID)
+	notify := d.notify
+	d.mu.Unlock()
+
+	if len(alerts) > 0 || timeout <= 0 {
+		return alerts
+	}
+
+	select {
+	case <-notify:
+	case <-time.After(timeout):
+		return alerts
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.alertsFromUnsafe(fromID)
+}
+
+func (d *doubleSpendDetector) alertsFromUnsafe(fromID uint64) []*DoubleSpendAlert {
+	alerts := []*DoubleSpendAlert{}
+	for _, alert := range d.alerts {
+		if alert.ID >= fromID {
+			alerts = append(alerts, alert)
+		}
+	}
+	return alerts
+}
+
+// GetDoubleSpendAlerts returns the recent double spend alerts with an ID of at least
+// fromID, waiting up to the timeout for a new alert if there are none. Subscribers
+// follow the alerts by passing the ID following the last alert they received.
+func (ledger *Ledger) GetDoubleSpendAlerts(fromID uint64, timeout time.Duration) []*DoubleSpendAlert {
+	return ledger.doubleSpends.alertsFrom(fromID, timeout)
+}
+
+// recordSpends records the sequences spent by the transactions of an applied block.
+func (ledger *Ledger) recordSpends(height uint64, txs []types.Tx, blockRawTxs []common.Bytes) {
+	txHashes := make([]common.Hash, len(blockRawTxs))
+	for i, rawTx := range blockRawTxs {
+		txHashes[i] = crypto.Keccak256Hash(rawTx)
+	}
+	ledger.doubleSpends.recordBlock(height, txs, txHashes)
+}
@@ -0,0 +1,78 @@
+package ledger
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/crypto"
+	"github.com/thetatoken/ukulele/ledger/types"
+)
+
+func newTestDoubleSpendTx(address common.Address, sequence uint64, amount int64) types.Tx {
+	return &types.SendTx{
+		Fee: types.NewCoins(0, getMinimumTxFee()),
+		Inputs: []types.TxInput{
+			{Address: address, Coins: types.NewCoins(amount, getMinimumTxFee()), Sequence: sequence},
+		},
+		Outputs: []types.TxOutput{
+			{Address: common.HexToAddress("0x1111"), Coins: types.NewCoins(amount, 0)},
+		},
+	}
+}
+
+func TestDoubleSpendDetector(t *testing.T) {
+	assert := assert.New(t)
+
+	d := newDoubleSpendDetector()
+	alice := common.HexToAddress("0xa11ce")
+	included := newTestDoubleSpendTx(alice, 1, 10)
+	includedHash := crypto.Keccak256Hash(common.Bytes("included"))
+	d.recordBlock(5, []types.Tx{included}, []common.Hash{includedHash})
+
+	// The included transaction itself, or another sequence, raises no alert
+	assert.Empty(d.check(included, includedHash))
+	assert.Empty(d.check(newTestDoubleSpendTx(alice, 2, 10), crypto.Keccak256Hash(common.Bytes("next"))))
+
+	// A different transaction with the same sequence does
+	conflictingHash := crypto.Keccak256Hash(common.Bytes("conflicting"))
+	alerts := d.check(newTestDoubleSpendTx(alice, 1, 20), conflictingHash)
+	if assert.Len(alerts, 1) {
+		assert.Equal(uint64(1), alerts[0].ID)
+		assert.Equal(alice, alerts[0].Address)
+		assert.Equal(uint64(1), alerts[0].Sequence)
+		assert.Equal(uint64(5), alerts[0].BlockHeight)
+		assert.Equal(includedHash, alerts[0].IncludedTxHash)
+		assert.Equal(conflictingHash, alerts[0].ConflictingTxHash)
+	}
+
+	// Only once per conflicting transaction
+	assert.Empty(d.check(newTestDoubleSpendTx(alice, 1, 20), conflictingHash))
+
+	assert.Len(d.alertsFrom(0, 0), 1)
+	assert.Len(d.alertsFrom(1, 0), 1)
+	assert.Empty(d.alertsFrom(2, 0))
+
+	// Subscribers waiting for the next alert get it when raised
+	done := make(chan []*DoubleSpendAlert)
+	go func() {
+		done <- d.alertsFrom(2, 10*time.Second)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	d.check(newTestDoubleSpendTx(alice, 1, 30), crypto.Keccak256Hash(common.Bytes("another")))
+	select {
+	case alerts := <-done:
+		if assert.Len(alerts, 1) {
+			assert.Equal(uint64(2), alerts[0].ID)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the alert")
+	}
+
+	// The spends of the blocks out of the window are forgotten
+	for height := uint64(6); height <= 5+doubleSpendWindow; height++ {
+		d.recordBlock(height, []types.Tx{}, []common.Hash{})
+	}
+	assert.Empty(d.check(newTestDoubleSpendTx(alice, 1, 40), crypto.Keccak256Hash(common.Bytes("late"))))
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/hex"
 	"math/big"
 
+	"github.com/pkg/errors"
 	"github.com/thetatoken/ukulele/common"
 	"github.com/thetatoken/ukulele/common/result"
 	"github.com/thetatoken/ukulele/core"
@@ -34,9 +35,8 @@ import (
 // 	return
 // }
 
-// getValidatorAddresses returns validators' addresses
-func getValidatorAddresses(consensus core.ConsensusEngine, valMgr core.ValidatorManager) []common.Address {
-	epoch := consensus.GetEpoch()
+// getValidatorAddresses returns the addresses of the validators of the given epoch
+func getValidatorAddresses(valMgr core.ValidatorManager, epoch uint64) []common.Address {
 	validators := valMgr.GetValidatorSetForEpoch(epoch).Validators()
 	validatorAddresses := make([]common.Address, len(validators))
 	for i, v := range validators {
@@ -54,7 +54,8 @@ func isAValidator(address common.Address, validatorAddresses []common.Address) r
 		}
 	}
 	if !proposerIsAValidator {
-		return result.Error("The coinbaseTx proposer is not a validator")
+		return result.Error("The coinbaseTx proposer is not a validator").
+			WithErrorCode(result.CodeInvalidCoinbase)
 	}
 
 	return result.OK
@@ -68,12 +69,14 @@ func getInputs(view *state.StoreView, ins []types.TxInput) (map[string]*types.Ac
 	for _, in := range ins {
 		// Account shouldn't be duplicated
 		if _, ok := accounts[string(in.Address[:])]; ok {
-			return nil, result.Error("getInputs - Duplicated address: %v", in.Address)
+			return nil, result.Error("getInputs - Duplicated address: %v", in.Address).
+				WithErrorCode(result.CodeInvalidTx)
 		}
 
 		acc, success := getAccount(view, in.Address)
 		if success.IsError() {
-			return nil, result.Error("getInputs - Unknown address: %v", in.Address)
+			return nil, result.Error("getInputs - Unknown address: %v", in.Address).
+				WithErrorCode(result.CodeAccountNotFound)
 		}
 
 		accounts[string(in.Address[:])] = acc
@@ -93,7 +96,8 @@ func getOrMakeInput(view *state.StoreView, in types.TxInput) (*types.Account, re
 func getOrMakeInputImpl(view *state.StoreView, in types.TxInput, makeNewAccount bool) (*types.Account, result.Result) {
 	acc, success := getOrMakeAccountImpl(view, in.Address, makeNewAccount)
 	if success.IsError() {
-		return nil, result.Error("getOrMakeInputImpl - Unknown address: %v", in.Address)
+		return nil, result.Error("getOrMakeInputImpl - Unknown address: %v", in.Address).
+			WithErrorCode(result.CodeAccountNotFound)
 	}
 
 	return acc, result.OK
@@ -113,7 +117,8 @@ func getOrMakeAccountImpl(view *state.StoreView, address common.Address, makeNew
 	acc := view.GetAccount(address)
 	if acc == nil {
 		if !makeNewAccount {
-			return nil, result.Error("getOrMakeAccountImpl - Unknown address: %v", address)
+			return nil, result.Error("getOrMakeAccountImpl - Unknown address: %v", address).
+				WithErrorCode(result.CodeAccountNotFound)
 		}
 		acc = types.NewAccount(address)
 		acc.LastUpdatedBlockHeight = view.Height()
@@ -131,7 +136,8 @@ func getOrMakeOutputs(view *state.StoreView, accounts map[string]*types.Account,
 	for _, out := range outs {
 		// Account shouldn't be duplicated
 		if _, ok := accounts[string(out.Address[:])]; ok {
-			return nil, result.Error("getOrMakeOutputs - Duplicated address: %v", out.Address)
+			return nil, result.Error("getOrMakeOutputs - Duplicated address: %v", out.Address).
+				WithErrorCode(result.CodeInvalidTx)
 		}
 
 		acc := getOrMakeAccount(view, out.Address)
@@ -183,8 +189,9 @@ func validateInputAdvanced(acc *types.Account, signBytes []byte, in types.TxInpu
 			balance, in.Coins).WithErrorCode(result.CodeInsufficientFund)
 	}
 
-	// Check signatures
-	if !in.Signature.Verify(signBytes, acc.Address) {
+	// Check signatures, either by the key of the address or by the keys of a multisig account,
+	// or by the key the account was recovered to
+	if !verifyInputSignature(acc, signBytes, in) {
 		return result.Error("Signature verification failed, SignBytes: %v",
 			hex.EncodeToString(signBytes)).WithErrorCode(result.CodeInvalidSignature)
 	}
@@ -192,6 +199,18 @@ func validateInputAdvanced(acc *types.Account, signBytes []byte, in types.TxInpu
 	return result.OK
 }
 
+// verifyInputSignature verifies the signature of the input by the key controlling the
+// account, which is another key than the one of the address once the account has been
+// recovered by its guardians. The account can be nil if it does not exist yet.
+func verifyInputSignature(acc *types.Account, signBytes []byte, in types.TxInput) bool {
+	if acc != nil {
+		if signer := acc.SignerAddress(); signer != in.Address {
+			return in.Signature.Verify(signBytes, signer)
+		}
+	}
+	return in.VerifySignature(signBytes)
+}
+
 func validateOutputsBasic(outs []types.TxOutput) result.Result {
 	for _, out := range outs {
 		// Check TxOutput basic
@@ -238,6 +257,17 @@ func adjustByOutputs(view *state.StoreView, accounts map[string]*types.Account,
 	}
 }
 
+func lockByOutputs(view *state.StoreView, accounts map[string]*types.Account, outs []types.TxOutput, unlockHeight uint64) {
+	for _, out := range outs {
+		acc := accounts[string(out.Address[:])]
+		if acc == nil {
+			panic("lockByOutputs() expects account in accounts")
+		}
+		acc.LockCoins(out.Coins, unlockHeight)
+		view.SetAccount(out.Address, acc)
+	}
+}
+
 func sanityCheckForGasPrice(gasPrice *big.Int) bool {
 	if gasPrice == nil {
 		return false
@@ -251,17 +281,61 @@ func sanityCheckForGasPrice(gasPrice *big.Int) bool {
 	return true
 }
 
-func sanityCheckForFee(fee types.Coins) bool {
+// sanityCheckForFee checks the fee is paid in GammaWei only, and meets the minimum
+// fee of the chain parameters in effect
+func sanityCheckForFee(view *state.StoreView, fee types.Coins) bool {
 	fee = fee.NoNil()
-	minimumFee := new(big.Int).SetUint64(types.MinimumTransactionFeeGammaWei)
-	return fee.ThetaWei.Cmp(types.Zero) == 0 && fee.GammaWei.Cmp(minimumFee) >= 0
+	minimumFee := new(big.Int).SetUint64(view.GetChainParameters().MinimumTransactionFeeGammaWei)
+	return fee.ThetaWei.Cmp(types.Zero) == 0 && fee.GammaWei.Cmp(minimumFee) >= 0 && len(fee.Tokens) == 0
+}
+
+// validateTokens checks the application tokens carried by the transaction, which need
+// to be registered in the token registry. The tokens are only transferred by SendTx and
+// TimelockedSendTx, the other transactions cannot carry any.
+func validateTokens(view *state.StoreView, tx types.Tx) result.Result {
+	transfer := false
+	switch tx.(type) {
+	case *types.SendTx, *types.TimelockedSendTx:
+		transfer = true
+	}
+	for _, coins := range types.TxCoins(tx) {
+		for _, token := range coins.Tokens {
+			if !transfer {
+				return result.Error("Tokens can only be transferred by SendTx and TimelockedSendTx").
+					WithErrorCode(result.CodeInvalidToken)
+			}
+			if view.GetToken(token.Symbol) == nil {
+				return result.Error("Token %v is not registered", token.Symbol).
+					WithErrorCode(result.CodeTokenNotFound)
+			}
+		}
+	}
+	return result.OK
 }
 
-func chargeFee(account *types.Account, fee types.Coins) bool {
+// chargeFee charges the fee to the account, and records it in the view so that the
+// executor collects it in the fee pool
+func chargeFee(view *state.StoreView, account *types.Account, fee types.Coins) bool {
 	if !account.Balance.IsGTE(fee) {
 		return false
 	}
 
 	account.Balance = account.Balance.Minus(fee)
+	view.AddChargedFee(fee)
 	return true
 }
+
+// reservedFundCheckFailed returns the result of a failed check of a reserved fund, with
+// CodeReservedFundNotFound if the account has no reserved fund with the reserve sequence,
+// or the code of the reserve policy limit exceeded
+func reservedFundCheckFailed(err error, code result.ErrorCode) result.Result {
+	switch errors.Cause(err) {
+	case types.ErrReservedFundNotFound:
+		code = result.CodeReservedFundNotFound
+	case types.ErrTooManyReservedFunds:
+		code = result.CodeTooManyReservedFunds
+	case types.ErrReserveDurationExceeded:
+		code = result.CodeReserveDurationExceeded
+	}
+	return result.Error(err.Error()).WithErrorCode(code)
+}
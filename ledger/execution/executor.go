@@ -2,6 +2,7 @@ package execution
 
 import (
 	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/common/metrics"
 	"github.com/thetatoken/ukulele/common/result"
 	"github.com/thetatoken/ukulele/core"
 	st "github.com/thetatoken/ukulele/ledger/state"
@@ -25,40 +26,76 @@ type Executor struct {
 	consensus core.ConsensusEngine
 	valMgr    core.ValidatorManager
 
-	coinbaseTxExec        *CoinbaseTxExecutor
-	slashTxExec           *SlashTxExecutor
-	updateValidatorTxExec *UpdateValidatorsTxExecutor
-	sendTxExec            *SendTxExecutor
-	reserveFundTxExec     *ReserveFundTxExecutor
-	releaseFundTxExec     *ReleaseFundTxExecutor
-	servicePaymentTxExec  *ServicePaymentTxExecutor
-	splitRuleTxExec       *SplitRuleTxExecutor
-	smartContractTxExec   *SmartContractTxExecutor
+	coinbaseTxExec            *CoinbaseTxExecutor
+	slashTxExec               *SlashTxExecutor
+	updateValidatorTxExec     *UpdateValidatorsTxExecutor
+	sendTxExec                *SendTxExecutor
+	timelockedSendTxExec      *TimelockedSendTxExecutor
+	reserveFundTxExec         *ReserveFundTxExecutor
+	releaseFundTxExec         *ReleaseFundTxExecutor
+	extendReserveTxExec       *ExtendReserveTxExecutor
+	servicePaymentTxExec      *ServicePaymentTxExecutor
+	batchServicePaymentTxExec *BatchServicePaymentTxExecutor
+	splitRuleTxExec           *SplitRuleTxExecutor
+	smartContractTxExec       *SmartContractTxExecutor
+	depositStakeTxExec        *DepositStakeTxExecutor
+	withdrawStakeTxExec       *WithdrawStakeTxExecutor
+	setGuardiansTxExec        *SetGuardiansTxExecutor
+	recoveryTxExec            *RecoveryTxExecutor
+	createTokenTxExec         *CreateTokenTxExecutor
+	parameterUpdateTxExec     *ParameterUpdateTxExecutor
+	proposalTxExec            *ProposalTxExecutor
+	voteTxExec                *VoteTxExecutor
+	unjailTxExec              *UnjailTxExecutor
 
 	skipSanityCheck bool
+	parallelWorkers int // Goroutines executing the transactions of a block
+
+	parallelTxMeter     metrics.Meter // Transactions executed concurrently
+	serialFallbackMeter metrics.Meter // Batches executed again serially
 }
 
 // NewExecutor creates a new instance of Executor
 func NewExecutor(state *st.LedgerState, consensus core.ConsensusEngine, valMgr core.ValidatorManager) *Executor {
 	executor := &Executor{
-		state:                 state,
-		consensus:             consensus,
-		valMgr:                valMgr,
-		coinbaseTxExec:        NewCoinbaseTxExecutor(state, consensus, valMgr),
-		slashTxExec:           NewSlashTxExecutor(consensus, valMgr),
-		updateValidatorTxExec: NewUpdateValidatorsTxExecutor(state),
-		sendTxExec:            NewSendTxExecutor(),
-		reserveFundTxExec:     NewReserveFundTxExecutor(state),
-		releaseFundTxExec:     NewReleaseFundTxExecutor(state),
-		servicePaymentTxExec:  NewServicePaymentTxExecutor(state),
-		splitRuleTxExec:       NewSplitRuleTxExecutor(state),
-		smartContractTxExec:   NewSmartContractTxExecutor(state),
-		skipSanityCheck:       false,
+		state:                     state,
+		consensus:                 consensus,
+		valMgr:                    valMgr,
+		coinbaseTxExec:            NewCoinbaseTxExecutor(state, valMgr),
+		slashTxExec:               NewSlashTxExecutor(valMgr),
+		updateValidatorTxExec:     NewUpdateValidatorsTxExecutor(state),
+		sendTxExec:                NewSendTxExecutor(),
+		timelockedSendTxExec:      NewTimelockedSendTxExecutor(),
+		reserveFundTxExec:         NewReserveFundTxExecutor(state),
+		releaseFundTxExec:         NewReleaseFundTxExecutor(state),
+		extendReserveTxExec:       NewExtendReserveTxExecutor(state),
+		servicePaymentTxExec:      NewServicePaymentTxExecutor(state),
+		batchServicePaymentTxExec: NewBatchServicePaymentTxExecutor(state),
+		splitRuleTxExec:           NewSplitRuleTxExecutor(state),
+		smartContractTxExec:       NewSmartContractTxExecutor(state),
+		depositStakeTxExec:        NewDepositStakeTxExecutor(state),
+		withdrawStakeTxExec:       NewWithdrawStakeTxExecutor(state),
+		setGuardiansTxExec:        NewSetGuardiansTxExecutor(state),
+		recoveryTxExec:            NewRecoveryTxExecutor(state),
+		createTokenTxExec:         NewCreateTokenTxExecutor(state),
+		parameterUpdateTxExec:     NewParameterUpdateTxExecutor(valMgr),
+		proposalTxExec:            NewProposalTxExecutor(),
+		voteTxExec:                NewVoteTxExecutor(),
+		unjailTxExec:              NewUnjailTxExecutor(),
+		skipSanityCheck:           false,
+		parallelWorkers:           parallelWorkersFromConfig(),
+		parallelTxMeter:           metrics.GetOrRegisterMeter("ledger/execution/parallel", nil),
+		serialFallbackMeter:       metrics.GetOrRegisterMeter("ledger/execution/fallback", nil),
 	}
 
 	return executor
 }
 
+// CalculateReward calculates the rewards of the coinbase transaction of the next block.
+func (exec *Executor) CalculateReward(view *st.StoreView, proposer common.Address, validators []core.Validator) map[string]types.Coins {
+	return CalculateReward(view, exec.state.Height(), proposer, validators)
+}
+
 // SetSkipSanityCheck sets the flag for sanity check.
 // Skip checks while replaying commmitted blocks.
 func (exec *Executor) SetSkipSanityCheck(skip bool) {
@@ -84,7 +121,7 @@ func (exec *Executor) ScreenTx(tx types.Tx) (common.Hash, result.Result) {
 func (exec *Executor) GetTxInfo(tx types.Tx) (*core.TxInfo, result.Result) {
 	txExecutor := exec.getTxExecutor(tx)
 	if txExecutor == nil {
-		return nil, result.Error("Unknown tx type")
+		return nil, result.Error("Unknown tx type").WithErrorCode(result.CodeInvalidTx)
 	}
 
 	txInfo := txExecutor.getTxInfo(tx)
@@ -121,9 +158,12 @@ func (exec *Executor) sanityCheck(chainID string, view *st.StoreView, tx types.T
 	var sanityCheckResult result.Result
 	txExecutor := exec.getTxExecutor(tx)
 	if txExecutor != nil {
+		if res := validateTokens(view, tx); res.IsError() {
+			return res
+		}
 		sanityCheckResult = txExecutor.sanityCheck(chainID, view, tx)
 	} else {
-		sanityCheckResult = result.Error("Unknown tx type")
+		sanityCheckResult = result.Error("Unknown tx type").WithErrorCode(result.CodeInvalidTx)
 	}
 
 	return sanityCheckResult
@@ -136,7 +176,12 @@ func (exec *Executor) process(chainID string, view *st.StoreView, tx types.Tx) (
 	if txExecutor != nil {
 		txHash, processResult = txExecutor.process(chainID, view, tx)
 	} else {
-		processResult = result.Error("Unknown tx type")
+		processResult = result.Error("Unknown tx type").WithErrorCode(result.CodeInvalidTx)
+	}
+
+	chargedFee := view.GetAndClearChargedFee()
+	if processResult.IsOK() {
+		exec.coinbaseTxExec.collectFee(view, chargedFee)
 	}
 
 	return txHash, processResult
@@ -151,18 +196,42 @@ func (exec *Executor) getTxExecutor(tx types.Tx) TxExecutor {
 		txExecutor = exec.slashTxExec
 	case *types.SendTx:
 		txExecutor = exec.sendTxExec
+	case *types.TimelockedSendTx:
+		txExecutor = exec.timelockedSendTxExec
 	case *types.ReserveFundTx:
 		txExecutor = exec.reserveFundTxExec
 	case *types.ReleaseFundTx:
 		txExecutor = exec.releaseFundTxExec
+	case *types.ExtendReserveTx:
+		txExecutor = exec.extendReserveTxExec
 	case *types.ServicePaymentTx:
 		txExecutor = exec.servicePaymentTxExec
+	case *types.BatchServicePaymentTx:
+		txExecutor = exec.batchServicePaymentTxExec
 	case *types.SplitRuleTx:
 		txExecutor = exec.splitRuleTxExec
 	case *types.UpdateValidatorsTx:
 		txExecutor = exec.updateValidatorTxExec
 	case *types.SmartContractTx:
 		txExecutor = exec.smartContractTxExec
+	case *types.DepositStakeTx:
+		txExecutor = exec.depositStakeTxExec
+	case *types.WithdrawStakeTx:
+		txExecutor = exec.withdrawStakeTxExec
+	case *types.SetGuardiansTx:
+		txExecutor = exec.setGuardiansTxExec
+	case *types.RecoveryTx:
+		txExecutor = exec.recoveryTxExec
+	case *types.CreateTokenTx:
+		txExecutor = exec.createTokenTxExec
+	case *types.ParameterUpdateTx:
+		txExecutor = exec.parameterUpdateTxExec
+	case *types.ProposalTx:
+		txExecutor = exec.proposalTxExec
+	case *types.VoteTx:
+		txExecutor = exec.voteTxExec
+	case *types.UnjailTx:
+		txExecutor = exec.unjailTxExec
 	default:
 		txExecutor = nil
 	}
@@ -0,0 +1,225 @@
+package execution
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/common/result"
+	st "github.com/thetatoken/ukulele/ledger/state"
+	"github.com/thetatoken/ukulele/ledger/types"
+)
+
+// parallelWorkersFromConfig returns the number of goroutines executing the transactions
+// of a block, as set in the node config
+func parallelWorkersFromConfig() int {
+	workers := common.GetConfig().Execution.ParallelWorkers
+	if workers == 0 {
+		workers = runtime.NumCPU()
+	}
+	return workers
+}
+
+// SetParallelWorkers sets the number of goroutines executing the transactions of a block,
+// 1 for a serial execution.
+func (exec *Executor) SetParallelWorkers(workers int) {
+	exec.parallelWorkers = workers
+}
+
+// ExecuteBlockTxs executes the transactions of a block on the delivered view, with the
+// same outcome as ExecuteTx called on each of them in order. The consecutive transactions
+// whose read and write sets, derived from their fields, are disjoint are executed
+// concurrently, each on its own copy of the view, and their writes are merged back in
+// the order of the block. A batch is executed again serially if the keys actually
+// accessed by its transactions conflict, or if one of them fails. It returns the
+// receipts of the transactions, or the index of the first transaction that failed.
+func (exec *Executor) ExecuteBlockTxs(txs []types.Tx) (receipts []*types.TxReceipt, failedIdx int, res result.Result) {
+	receipts = make([]*types.TxReceipt, len(txs))
+	if exec.parallelWorkers <= 1 {
+		failedIdx, res = exec.executeTxsSerially(txs, 0, len(txs), receipts)
+		return receipts, failedIdx, res
+	}
+
+	batchStart := 0
+	batchAccesses := st.NewAccessSet()
+	for i, tx := range txs {
+		accesses, ok := staticAccessSet(tx)
+		if ok && !accesses.Conflicts(batchAccesses) {
+			batchAccesses.Merge(accesses)
+			continue
+		}
+
+		if failedIdx, res = exec.executeBatch(txs, batchStart, i, receipts); res.IsError() {
+			return receipts, failedIdx, res
+		}
+		if ok {
+			batchStart = i
+			batchAccesses = accesses
+			continue
+		}
+		if failedIdx, res = exec.executeTxsSerially(txs, i, i+1, receipts); res.IsError() {
+			return receipts, failedIdx, res
+		}
+		batchStart = i + 1
+		batchAccesses = st.NewAccessSet()
+	}
+	failedIdx, res = exec.executeBatch(txs, batchStart, len(txs), receipts)
+	return receipts, failedIdx, res
+}
+
+func (exec *Executor) executeTxsSerially(txs []types.Tx, start, end int, receipts []*types.TxReceipt) (int, result.Result) {
+	view := exec.state.Delivered()
+	for i := start; i < end; i++ {
+		_, res := exec.ExecuteTx(txs[i])
+		receipt := view.GetAndClearTxReceipt()
+		if res.IsError() {
+			return i, res
+		}
+		receipts[i] = receipt
+	}
+	return -1, result.OK
+}
+
+// executeBatch executes the transactions from start to end concurrently, and falls
+// back to a serial execution if they turn out to depend on each other.
+func (exec *Executor) executeBatch(txs []types.Tx, start, end int, receipts []*types.TxReceipt) (int, result.Result) {
+	if end-start <= 1 {
+		return exec.executeTxsSerially(txs, start, end, receipts)
+	}
+
+	view := exec.state.Delivered()
+	chainID := exec.state.GetChainID()
+	views := make([]*st.StoreView, end-start)
+	for j := range views {
+		txView, err := view.Copy()
+		if err != nil {
+			log.Errorf("Failed to copy the delivered view, executing the transactions serially: %v", err)
+			return exec.executeTxsSerially(txs, start, end, receipts)
+		}
+		txView.RecordAccesses()
+		views[j] = txView
+	}
+
+	results := make([]result.Result, len(views))
+	fees := make([]types.Coins, len(views))
+	workers := exec.parallelWorkers
+	if workers > len(views) {
+		workers = len(views)
+	}
+	var next int64 = -1
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for {
+				j := int(atomic.AddInt64(&next, 1))
+				if j >= len(views) {
+					return
+				}
+				tx := txs[start+j]
+				if results[j] = exec.sanityCheck(chainID, views[j], tx); results[j].IsError() {
+					continue
+				}
+				_, results[j] = exec.getTxExecutor(tx).process(chainID, views[j], tx)
+				fees[j] = views[j].GetAndClearChargedFee()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if !independent(views, results) {
+		exec.serialFallbackMeter.Mark(1)
+		return exec.executeTxsSerially(txs, start, end, receipts)
+	}
+
+	// The fees are collected here rather than by the transactions, since they all
+	// update the fee pool
+	for j, txView := range views {
+		view.MergeWrites(txView)
+		exec.coinbaseTxExec.collectFee(view, fees[j])
+		receipts[start+j] = txView.GetAndClearTxReceipt()
+	}
+	exec.parallelTxMeter.Mark(int64(len(views)))
+	return -1, result.OK
+}
+
+// independent returns true if all the transactions of the batch succeeded, and none
+// of them accessed a key written by another one, or the fee pool.
+func independent(views []*st.StoreView, results []result.Result) bool {
+	accessed := st.NewAccessSet()
+	accessed.AddWrite(st.FeePoolKey())
+	for j, txView := range views {
+		if results[j].IsError() {
+			return false
+		}
+		accesses := txView.GetAccessSet()
+		if accesses.Conflicts(accessed) {
+			return false
+		}
+		accessed.Merge(accesses)
+	}
+	return true
+}
+
+// staticAccessSet returns the keys the transaction is expected to read and write,
+// derived from its fields, and false if the transaction is executed serially, e.g.
+// because it updates the validators or runs a smart contract.
+func staticAccessSet(tx types.Tx) (*st.AccessSet, bool) {
+	accesses := st.NewAccessSet()
+	writeAccounts := func(addrs ...common.Address) {
+		for _, addr := range addrs {
+			accesses.AddWrite(st.AccountKey(addr))
+		}
+	}
+	readSplitRule := func(resourceID string) {
+		accesses.AddRead(st.SplitRuleKey(resourceID))
+	}
+
+	switch tx := tx.(type) {
+	case *types.SendTx:
+		for _, input := range tx.Inputs {
+			writeAccounts(input.Address)
+		}
+		for _, output := range tx.Outputs {
+			writeAccounts(output.Address)
+		}
+	case *types.TimelockedSendTx:
+		for _, input := range tx.Inputs {
+			writeAccounts(input.Address)
+		}
+		for _, output := range tx.Outputs {
+			writeAccounts(output.Address)
+		}
+	case *types.ReserveFundTx:
+		writeAccounts(tx.Source.Address)
+	case *types.ReleaseFundTx:
+		writeAccounts(tx.Source.Address)
+	case *types.ExtendReserveTx:
+		writeAccounts(tx.Source.Address)
+	case *types.ServicePaymentTx:
+		writeAccounts(tx.Source.Address, tx.Target.Address)
+		readSplitRule(tx.ResourceID)
+	case *types.BatchServicePaymentTx:
+		writeAccounts(tx.Target.Address)
+		for _, payment := range tx.Payments {
+			writeAccounts(payment.Source.Address)
+			readSplitRule(payment.ResourceID)
+		}
+	case *types.SetGuardiansTx:
+		writeAccounts(tx.Account.Address)
+	case *types.RecoveryTx:
+		writeAccounts(tx.Account.Address)
+	default:
+		return nil, false
+	}
+
+	for _, coins := range types.TxCoins(tx) {
+		for _, token := range coins.Tokens {
+			accesses.AddRead(st.TokenKey(token.Symbol))
+		}
+	}
+	return accesses, true
+}
@@ -0,0 +1,129 @@
+package execution
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thetatoken/ukulele/common/result"
+	st "github.com/thetatoken/ukulele/ledger/state"
+	"github.com/thetatoken/ukulele/ledger/types"
+)
+
+func setupForBlockTxs(workers int, numAccs int) (*execTest, []types.PrivAccount) {
+	et := NewExecTest()
+	et.executor.SetParallelWorkers(workers)
+	accs := []types.PrivAccount{}
+	for i := 0; i < numAccs; i++ {
+		acc := types.MakeAccWithInitBalance("acc"+strconv.Itoa(i), types.NewCoins(1000, 100*getMinimumTxFee()))
+		accs = append(accs, acc)
+	}
+	et.acc2State(accs...)
+	return et, accs
+}
+
+func makeBlockSendTx(et *execTest, seq int, from, to types.PrivAccount) types.Tx {
+	tx := types.MakeSendTx(seq, to, from)
+	et.signSendTx(tx, from)
+	return tx
+}
+
+func TestExecuteBlockTxs(t *testing.T) {
+	assert := assert.New(t)
+
+	makeTxs := func(et *execTest, accs []types.PrivAccount) []types.Tx {
+		return []types.Tx{
+			// Independent transactions
+			makeBlockSendTx(et, 1, accs[0], accs[1]),
+			makeBlockSendTx(et, 1, accs[2], accs[3]),
+			makeBlockSendTx(et, 1, accs[4], accs[5]),
+			// Depends on the first transaction
+			makeBlockSendTx(et, 1, accs[1], accs[6]),
+			makeBlockSendTx(et, 2, accs[0], accs[7]),
+			// Executed serially
+			&types.SplitRuleTx{},
+		}
+	}
+
+	serial, accs := setupForBlockTxs(1, 8)
+	parallel, _ := setupForBlockTxs(4, 8)
+
+	// The first failure is reported at the same index, whatever the number of workers
+	_, failedIdx, res := serial.executor.ExecuteBlockTxs(makeTxs(serial, accs))
+	assert.Equal(5, failedIdx)
+	_, parallelFailedIdx, parallelRes := parallel.executor.ExecuteBlockTxs(makeTxs(parallel, accs))
+	assert.Equal(failedIdx, parallelFailedIdx)
+	assert.Equal(res.Code, parallelRes.Code)
+	assert.Equal(serial.state().Delivered().Hash(), parallel.state().Delivered().Hash())
+
+	serial, accs = setupForBlockTxs(1, 8)
+	parallel, _ = setupForBlockTxs(4, 8)
+	txs := makeTxs(serial, accs)[:5]
+	receipts, _, res := serial.executor.ExecuteBlockTxs(txs)
+	assert.True(res.IsOK(), res.Message)
+	assert.Equal(5, len(receipts))
+	receipts, _, res = parallel.executor.ExecuteBlockTxs(makeTxs(parallel, accs)[:5])
+	assert.True(res.IsOK(), res.Message)
+	assert.Equal(5, len(receipts))
+	assert.Equal(serial.state().Delivered().Hash(), parallel.state().Delivered().Hash())
+	assert.True(serial.state().Delivered().GetFeePool().IsEqual(parallel.state().Delivered().GetFeePool()))
+
+	// An invalid transaction in a concurrent batch is reported at its index
+	parallel, accs = setupForBlockTxs(4, 4)
+	txs = []types.Tx{
+		makeBlockSendTx(parallel, 1, accs[0], accs[1]),
+		makeBlockSendTx(parallel, 5, accs[2], accs[3]),
+	}
+	_, failedIdx, res = parallel.executor.ExecuteBlockTxs(txs)
+	assert.Equal(1, failedIdx)
+	assert.Equal(result.CodeInvalidSequence, res.Code)
+}
+
+func TestStaticAccessSet(t *testing.T) {
+	assert := assert.New(t)
+
+	et, accs := setupForBlockTxs(4, 4)
+	tx1, ok := staticAccessSet(makeBlockSendTx(et, 1, accs[0], accs[1]))
+	assert.True(ok)
+	tx2, ok := staticAccessSet(makeBlockSendTx(et, 1, accs[2], accs[3]))
+	assert.True(ok)
+	tx3, ok := staticAccessSet(makeBlockSendTx(et, 1, accs[3], accs[0]))
+	assert.True(ok)
+	assert.False(tx1.Conflicts(tx2))
+	assert.True(tx1.Conflicts(tx3))
+	assert.True(tx2.Conflicts(tx3))
+
+	_, ok = staticAccessSet(&types.SmartContractTx{})
+	assert.False(ok)
+	_, ok = staticAccessSet(&types.CoinbaseTx{})
+	assert.False(ok)
+}
+
+func TestIndependent(t *testing.T) {
+	assert := assert.New(t)
+
+	et, accs := setupForBlockTxs(4, 2)
+	view := et.state().Delivered()
+	newView := func() *st.StoreView {
+		txView, err := view.Copy()
+		assert.Nil(err)
+		txView.RecordAccesses()
+		return txView
+	}
+
+	// Transactions reading the same account are independent
+	view1, view2 := newView(), newView()
+	view1.GetAccount(accs[0].Address)
+	view2.GetAccount(accs[0].Address)
+	assert.True(independent([]*st.StoreView{view1, view2}, []result.Result{result.OK, result.OK}))
+	assert.False(independent([]*st.StoreView{view1, view2}, []result.Result{result.OK, result.Error("failed")}))
+
+	// Unless one of them writes it
+	view2.SetAccount(accs[0].Address, &accs[0].Account)
+	assert.False(independent([]*st.StoreView{view1, view2}, []result.Result{result.OK, result.OK}))
+
+	// The fees are collected after the batch, so the fee pool cannot be read
+	view1, view2 = newView(), newView()
+	view2.GetFeePool()
+	assert.False(independent([]*st.StoreView{view1, view2}, []result.Result{result.OK, result.OK}))
+}
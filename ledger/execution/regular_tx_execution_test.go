@@ -2,11 +2,20 @@ package execution
 
 import (
 	"math/big"
+	"math/rand"
+	"reflect"
 	"testing"
+	"testing/quick"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thetatoken/ukulele/common"
 	"github.com/thetatoken/ukulele/common/result"
+	"github.com/thetatoken/ukulele/consensus"
+	"github.com/thetatoken/ukulele/core"
+	"github.com/thetatoken/ukulele/crypto"
+	st "github.com/thetatoken/ukulele/ledger/state"
 	"github.com/thetatoken/ukulele/ledger/types"
 )
 
@@ -279,6 +288,135 @@ func TestSendTx(t *testing.T) {
 		"ExecTx/good DeliverTx: unexpected change in output balance, got: %v, expected: %v", balOut, balOutExp)
 }
 
+// sendTxCase is a random SendTx between the accounts of the test, which may or may not
+// be valid, generated for the property tests.
+type sendTxCase struct {
+	Balances []types.Coins // Balances of the accounts before the transaction
+	From     int
+	To       []int
+	Amounts  []types.Coins
+	Fee      int64
+	Excess   int64 // Coins spent by the sender in excess of the amounts and the fee
+}
+
+// Generate implements testing/quick.Generator.
+func (sendTxCase) Generate(rand *rand.Rand, size int) reflect.Value {
+	minFee := getMinimumTxFee()
+	c := sendTxCase{
+		From: rand.Intn(3),
+		Fee:  minFee + rand.Int63n(minFee),
+	}
+	if rand.Intn(8) == 0 {
+		c.Fee = rand.Int63n(minFee)
+	}
+	for i := 0; i < 3; i++ {
+		c.Balances = append(c.Balances, types.NewCoins(rand.Int63n(2000), rand.Int63n(8*minFee)))
+	}
+	for _, i := range rand.Perm(2)[:1+rand.Intn(2)] {
+		c.To = append(c.To, (c.From+1+i)%3)
+	}
+	if rand.Intn(8) == 0 {
+		c.To = append(c.To, c.From) // Rejected, as the sender cannot receive the coins
+	}
+	for range c.To {
+		c.Amounts = append(c.Amounts, types.NewCoins(rand.Int63n(500), rand.Int63n(minFee)))
+	}
+	if rand.Intn(8) == 0 {
+		c.Excess = rand.Int63n(3) - 1
+	}
+	return reflect.ValueOf(c)
+}
+
+func TestSendTxBalanceInvariants(t *testing.T) {
+	accs := []types.PrivAccount{types.MakeAcc("alice"), types.MakeAcc("bob"), types.MakeAcc("carol")}
+
+	// Whether or not the transaction is valid, no balance becomes negative, and the
+	// coins are only moved, less the fee
+	invariants := func(c sendTxCase) bool {
+		et := NewExecTest()
+		total := types.NewCoins(0, 0)
+		for i := range accs {
+			accs[i].Balance = c.Balances[i]
+			total = total.Plus(c.Balances[i])
+			et.acc2State(accs[i])
+		}
+
+		tx := &types.SendTx{Fee: types.NewCoins(0, c.Fee)}
+		spent := types.NewCoins(0, c.Fee+c.Excess)
+		for i, to := range c.To {
+			tx.Outputs = append(tx.Outputs, types.TxOutput{Address: accs[to].Address, Coins: c.Amounts[i]})
+			spent = spent.Plus(c.Amounts[i])
+		}
+		tx.Inputs = []types.TxInput{types.NewTxInput(accs[c.From].Address, spent, 1)}
+		et.signSendTx(tx, accs[c.From])
+
+		_, res := et.executor.ExecuteTx(tx)
+
+		after := types.NewCoins(0, 0)
+		for i, acc := range accs {
+			balance := et.state().Delivered().GetAccount(acc.Address).Balance
+			if !balance.IsValid() {
+				return false
+			}
+			if res.IsError() && !balance.IsEqual(c.Balances[i]) {
+				return false
+			}
+			after = after.Plus(balance)
+		}
+		if res.IsOK() {
+			return after.Plus(tx.Fee).IsEqual(total)
+		}
+		return after.IsEqual(total)
+	}
+	if err := quick.Check(invariants, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMultisigSendTx(t *testing.T) {
+	assert := assert.New(t)
+	et := NewExecTest()
+
+	signer1, signer2, signer3 := types.MakeAcc("signer1"), types.MakeAcc("signer2"), types.MakeAcc("signer3")
+	multisig, err := types.NewMultisigAccount(2, []*crypto.PublicKey{
+		signer1.PrivKey.PublicKey(), signer2.PrivKey.PublicKey(), signer3.PrivKey.PublicKey()})
+	assert.Nil(err)
+	msAcc := types.NewAccount(multisig.Address())
+	msAcc.Balance = types.NewCoins(1000, 10*getMinimumTxFee())
+	et.state().Delivered().SetAccount(msAcc.Address, msAcc)
+	et.acc2State(et.accOut)
+
+	tx := &types.SendTx{
+		Fee:     types.NewCoins(0, getMinimumTxFee()),
+		Inputs:  []types.TxInput{types.NewTxInput(msAcc.Address, types.NewCoins(100, getMinimumTxFee()), 1)},
+		Outputs: []types.TxOutput{{Address: et.accOut.Address, Coins: types.NewCoins(100, 0)}},
+	}
+	signBytes := tx.SignBytes(et.chainID)
+
+	// The signatures of the keys below the threshold are rejected
+	ms := types.NewMultisigSignature(multisig)
+	assert.Nil(ms.AddSignature(signBytes, signer1.Sign(signBytes)))
+	tx.Inputs[0].Signature = ms.ToSignature()
+	_, res := et.executor.ScreenTx(tx)
+	assert.Equal(result.CodeInvalidSignature, res.Code)
+
+	// A plain signature of one of the keys is not a multisig signature
+	tx.Inputs[0].Signature = signer2.Sign(signBytes)
+	_, res = et.executor.ScreenTx(tx)
+	assert.Equal(result.CodeInvalidSignature, res.Code)
+
+	assert.Nil(ms.AddSignature(signBytes, signer3.Sign(signBytes)))
+	tx.Inputs[0].Signature = ms.ToSignature()
+	_, res = et.executor.ScreenTx(tx)
+	assert.True(res.IsOK(), res.Message)
+	_, res = et.executor.ExecuteTx(tx)
+	assert.True(res.IsOK(), res.Message)
+
+	msAccAfter := et.state().Delivered().GetAccount(msAcc.Address)
+	assert.Equal(uint64(1), msAccAfter.Sequence)
+	assert.True(msAccAfter.Balance.IsEqual(types.NewCoins(900, 9*getMinimumTxFee())))
+}
+
 // func TestCalculateThetaReward(t *testing.T) {
 // 	assert := assert.New(t)
 
@@ -451,6 +589,58 @@ func TestCoinbaseTx(t *testing.T) {
 	// assert.Equal(int64(0), user1balance.GammaWei.Int64())
 }
 
+func TestCoinbaseTxWithRewardPolicy(t *testing.T) {
+	assert := assert.New(t)
+	et := NewExecTest()
+	params := types.DefaultChainParameters()
+	params.BlockRewardGammaWei = big.NewInt(1099000)
+	params.FeeBurnPercent = 50
+	params.ProposerFeePercent = 20
+	et.setChainParameters(params)
+
+	va1 := et.accProposer
+	va2 := et.accVal2
+	et.acc2State(va1, va2, et.accIn, et.accOut)
+
+	// Half of the fee of a transaction is collected in the fee pool
+	sendTx := types.MakeSendTx(1, et.accOut, et.accIn)
+	et.signSendTx(sendTx, et.accIn)
+	_, res := et.executor.ExecuteTx(sendTx)
+	assert.True(res.IsOK(), res.Message)
+	assert.Equal(types.NewCoins(0, 5e11), et.state().Delivered().GetFeePool())
+	et.state().Commit()
+
+	// The proposer gets 20% of the fee pool, and the validators share the rest of the
+	// pool and the block reward in proportion to their stake, i.e. 999:100
+	va1Reward := types.NewCoins(0, 463604274706)
+	va2Reward := types.NewCoins(0, 36396824294)
+
+	newCoinbaseTx := func(va1Reward, va2Reward types.Coins) *types.CoinbaseTx {
+		tx := &types.CoinbaseTx{
+			Proposer: types.TxInput{Address: va1.Address},
+			Outputs: []types.TxOutput{
+				{Address: va1.Address, Coins: va1Reward},
+				{Address: va2.Address, Coins: va2Reward},
+			},
+			BlockHeight: et.state().Height(),
+		}
+		tx.Proposer.Signature = va1.Sign(tx.SignBytes(et.chainID))
+		return tx
+	}
+
+	// The proposer cannot claim more than the policy rewards
+	tx := newCoinbaseTx(va1Reward.Plus(va2Reward), types.NewCoins(0, 0))
+	res = et.executor.getTxExecutor(tx).sanityCheck(et.chainID, et.state().Delivered(), tx)
+	assert.True(res.IsError(), res.String())
+
+	tx = newCoinbaseTx(va1Reward, va2Reward)
+	_, res = et.executor.ExecuteTx(tx)
+	assert.True(res.IsOK(), res.Message)
+	assert.Equal(va1.Balance.Plus(va1Reward), et.state().Delivered().GetAccount(va1.Address).Balance)
+	assert.Equal(va2.Balance.Plus(va2Reward), et.state().Delivered().GetAccount(va2.Address).Balance)
+	assert.True(et.state().Delivered().GetFeePool().IsZero())
+}
+
 func TestReserveFundTx(t *testing.T) {
 	assert := assert.New(t)
 	et := NewExecTest()
@@ -542,6 +732,515 @@ func TestReserveFundTx(t *testing.T) {
 	assert.Equal([]string{"rid001"}, retrievedUserAcc.ReservedFunds[0].ResourceIDs)
 	assert.Equal(types.Coins{GammaWei: big.NewInt(1001 * txFee), ThetaWei: big.NewInt(0)}, retrievedUserAcc.ReservedFunds[0].Collateral)
 	assert.Equal(uint64(1), retrievedUserAcc.ReservedFunds[0].ReserveSequence)
+
+	// The reserve policy bounds the reserved funds of the account
+	newReserveFundTx := func(duration uint64) *types.ReserveFundTx {
+		tx := &types.ReserveFundTx{
+			Fee: types.NewCoins(0, txFee),
+			Source: types.TxInput{
+				Address:  user1.Address,
+				Coins:    types.Coins{GammaWei: big.NewInt(1000 * txFee), ThetaWei: big.NewInt(0)},
+				Sequence: 2,
+			},
+			Collateral:  types.Coins{GammaWei: big.NewInt(1001 * txFee), ThetaWei: big.NewInt(0)},
+			ResourceIDs: []string{"rid002"},
+			Duration:    duration,
+		}
+		tx.Source.Signature = user1.Sign(tx.SignBytes(et.chainID))
+		return tx
+	}
+
+	params := types.DefaultChainParameters()
+	params.MaxReservedFundsPerAccount = 1
+	et.setChainParameters(params)
+	tx = newReserveFundTx(1000)
+	res = et.executor.getTxExecutor(tx).sanityCheck(et.chainID, et.state().Delivered(), tx)
+	assert.Equal(result.CodeTooManyReservedFunds, res.Code, res.Message)
+
+	params.MaxReservedFundsPerAccount = 2
+	params.MaxTotalReserveDuration = 1500
+	et.setChainParameters(params)
+	res = et.executor.getTxExecutor(tx).sanityCheck(et.chainID, et.state().Delivered(), tx)
+	assert.Equal(result.CodeReserveDurationExceeded, res.Code, res.Message)
+	tx = newReserveFundTx(500)
+	res = et.executor.getTxExecutor(tx).sanityCheck(et.chainID, et.state().Delivered(), tx)
+	assert.True(res.IsOK(), res.Message)
+}
+
+func TestExtendReserveTx(t *testing.T) {
+	assert := assert.New(t)
+	et, resourceID, alice, bob, _, _, bobInitBalance, _ := setupForServicePayment(assert)
+
+	txFee := getMinimumTxFee()
+	reservedFund := et.state().Delivered().GetAccount(alice.Address).ReservedFunds[0]
+
+	// Top up the reserve of 1000 * txFee and extend it by 100 blocks
+	extendReserveTx := &types.ExtendReserveTx{
+		Fee: types.NewCoins(0, txFee),
+		Source: types.TxInput{
+			Address:  alice.Address,
+			Coins:    types.NewCoins(0, 500*txFee),
+			Sequence: 2,
+		},
+		Collateral:      types.NewCoins(0, 500*txFee),
+		Duration:        100,
+		ReserveSequence: reservedFund.ReserveSequence,
+	}
+	extendReserveTx.Source.Signature = alice.Sign(extendReserveTx.SignBytes(et.chainID))
+
+	res := et.executor.getTxExecutor(extendReserveTx).sanityCheck(et.chainID, et.state().Delivered(), extendReserveTx)
+	assert.True(res.IsOK(), res.Message)
+	_, res = et.executor.getTxExecutor(extendReserveTx).process(et.chainID, et.state().Delivered(), extendReserveTx)
+	assert.True(res.IsOK(), res.Message)
+
+	aliceAccount := et.state().Delivered().GetAccount(alice.Address)
+	assert.Equal(1, len(aliceAccount.ReservedFunds))
+	extendedFund := aliceAccount.ReservedFunds[0]
+	assert.Equal(reservedFund.ReserveSequence, extendedFund.ReserveSequence)
+	assert.Equal(reservedFund.EndBlockHeight+100, extendedFund.EndBlockHeight)
+	assert.Equal(reservedFund.InitialFund.Plus(types.NewCoins(0, 500*txFee)), extendedFund.InitialFund)
+	assert.Equal(reservedFund.Collateral.Plus(types.NewCoins(0, 500*txFee)), extendedFund.Collateral)
+	et.state().Commit()
+
+	// The same extension cannot be replayed
+	res = et.executor.getTxExecutor(extendReserveTx).sanityCheck(et.chainID, et.state().Delivered(), extendReserveTx)
+	assert.True(res.IsError())
+
+	// A payment signed for the reserve sequence can spend the top up
+	payAmount := 1200 * txFee
+	servicePaymentTx := createServicePaymentTx(et.chainID, &alice, &bob, payAmount, 1, 1, 1, 1, resourceID)
+	res = et.executor.getTxExecutor(servicePaymentTx).sanityCheck(et.chainID, et.state().Delivered(), servicePaymentTx)
+	assert.True(res.IsOK(), res.Message)
+	_, res = et.executor.getTxExecutor(servicePaymentTx).process(et.chainID, et.state().Delivered(), servicePaymentTx)
+	assert.True(res.IsOK(), res.Message)
+	assert.Equal(0, len(et.state().Delivered().GetSlashIntents()))
+
+	bobBalance := et.state().Delivered().GetAccount(bob.Address).Balance
+	assert.Equal(bobInitBalance.Plus(types.NewCoins(0, payAmount)).Minus(types.NewCoins(0, txFee)), bobBalance)
+}
+
+func TestDepositWithdrawStakeTx(t *testing.T) {
+	assert := assert.New(t)
+	et := NewExecTest()
+
+	txFee := getMinimumTxFee()
+	stake := new(big.Int).SetUint64(types.MinimumStakeDepositThetaWei)
+
+	user1 := types.MakeAcc("user 1")
+	user1.Balance = types.Coins{
+		GammaWei: big.NewInt(100 * txFee),
+		ThetaWei: new(big.Int).Mul(stake, big.NewInt(10)),
+	}
+	validator := types.MakeAcc("validator 1")
+	et.acc2State(user1, validator)
+
+	et.fastforwardTo(1000)
+
+	var res result.Result
+
+	// Stake below the minimum
+	depositTx := &types.DepositStakeTx{
+		Fee: types.NewCoins(0, txFee),
+		Source: types.TxInput{
+			Address:  user1.Address,
+			Coins:    types.Coins{ThetaWei: big.NewInt(1), GammaWei: big.NewInt(0)},
+			Sequence: 1,
+		},
+		Holder: types.TxOutput{Address: validator.Address},
+	}
+	depositTx.Source.Signature = user1.Sign(depositTx.SignBytes(et.chainID))
+	res = et.executor.getTxExecutor(depositTx).sanityCheck(et.chainID, et.state().Delivered(), depositTx)
+	assert.False(res.IsOK(), res.String())
+	assert.Equal(result.CodeInvalidStake, res.Code)
+
+	// Withdraw without an active stake
+	withdrawTx := &types.WithdrawStakeTx{
+		Fee: types.NewCoins(0, txFee),
+		Source: types.TxInput{
+			Address:  user1.Address,
+			Sequence: 1,
+		},
+		Holder: types.TxOutput{Address: validator.Address},
+	}
+	withdrawTx.Source.Signature = user1.Sign(withdrawTx.SignBytes(et.chainID))
+	res = et.executor.getTxExecutor(withdrawTx).sanityCheck(et.chainID, et.state().Delivered(), withdrawTx)
+	assert.False(res.IsOK(), res.String())
+	assert.Equal(result.CodeStakeNotFound, res.Code)
+
+	// Regular deposit
+	depositTx = &types.DepositStakeTx{
+		Fee: types.NewCoins(0, txFee),
+		Source: types.TxInput{
+			Address:  user1.Address,
+			Coins:    types.Coins{ThetaWei: stake, GammaWei: big.NewInt(0)},
+			Sequence: 1,
+		},
+		Holder: types.TxOutput{Address: validator.Address},
+	}
+	depositTx.Source.Signature = user1.Sign(depositTx.SignBytes(et.chainID))
+	res = et.executor.getTxExecutor(depositTx).sanityCheck(et.chainID, et.state().Delivered(), depositTx)
+	assert.True(res.IsOK(), res.String())
+	_, res = et.executor.getTxExecutor(depositTx).process(et.chainID, et.state().Delivered(), depositTx)
+	assert.True(res.IsOK(), res.String())
+
+	stakeHolder := et.state().Delivered().GetStakeHolder(validator.Address)
+	assert.NotNil(stakeHolder)
+	assert.Equal(1, len(stakeHolder.Stakes))
+	assert.Equal(stake, stakeHolder.TotalStake())
+	retrievedUserAcc := et.state().Delivered().GetAccount(user1.Address)
+	assert.Equal(new(big.Int).Mul(stake, big.NewInt(9)), retrievedUserAcc.Balance.ThetaWei)
+
+	// Regular withdrawal
+	withdrawTx = &types.WithdrawStakeTx{
+		Fee: types.NewCoins(0, txFee),
+		Source: types.TxInput{
+			Address:  user1.Address,
+			Sequence: 2,
+		},
+		Holder: types.TxOutput{Address: validator.Address},
+	}
+	withdrawTx.Source.Signature = user1.Sign(withdrawTx.SignBytes(et.chainID))
+	res = et.executor.getTxExecutor(withdrawTx).sanityCheck(et.chainID, et.state().Delivered(), withdrawTx)
+	assert.True(res.IsOK(), res.String())
+	_, res = et.executor.getTxExecutor(withdrawTx).process(et.chainID, et.state().Delivered(), withdrawTx)
+	assert.True(res.IsOK(), res.String())
+
+	stakeHolder = et.state().Delivered().GetStakeHolder(validator.Address)
+	assert.Equal(1, len(stakeHolder.Stakes))
+	assert.True(stakeHolder.Stakes[0].Withdrawn)
+	assert.Equal(0, stakeHolder.TotalStake().Sign())
+	returnHeight := stakeHolder.Stakes[0].ReturnHeight
+
+	// Stake stays locked until the return height
+	et.state().Delivered().ReturnMaturedStakes(returnHeight - 1)
+	retrievedUserAcc = et.state().Delivered().GetAccount(user1.Address)
+	assert.Equal(new(big.Int).Mul(stake, big.NewInt(9)), retrievedUserAcc.Balance.ThetaWei)
+
+	et.state().Delivered().ReturnMaturedStakes(returnHeight)
+	retrievedUserAcc = et.state().Delivered().GetAccount(user1.Address)
+	assert.Equal(new(big.Int).Mul(stake, big.NewInt(10)), retrievedUserAcc.Balance.ThetaWei)
+	assert.Nil(et.state().Delivered().GetStakeHolder(validator.Address))
+}
+
+func TestSetGuardiansAndRecoveryTx(t *testing.T) {
+	assert := assert.New(t)
+	et := NewExecTest()
+
+	txFee := getMinimumTxFee()
+	owner := types.MakeAcc("owner")
+	guardian1 := types.MakeAcc("guardian 1")
+	guardian2 := types.MakeAcc("guardian 2")
+	outsider := types.MakeAcc("outsider")
+	newKey := types.MakeAcc("new key")
+	et.acc2State(owner, guardian1, guardian2, outsider)
+
+	et.fastforwardTo(1000)
+
+	var res result.Result
+	guardians := []common.Address{guardian1.Address, guardian2.Address}
+	setGuardians := func(sequence uint64, threshold uint64) *types.SetGuardiansTx {
+		tx := &types.SetGuardiansTx{
+			Fee: types.NewCoins(0, txFee),
+			Account: types.TxInput{
+				Address:  owner.Address,
+				Sequence: sequence,
+			},
+			Guardians: guardians,
+			Threshold: threshold,
+			Timelock:  types.MinimumRecoveryTimelock,
+		}
+		tx.Account.Signature = owner.Sign(tx.SignBytes(et.chainID))
+		return tx
+	}
+	requestRecovery := func(sequence uint64, signers ...types.PrivAccount) *types.RecoveryTx {
+		tx := &types.RecoveryTx{
+			Fee: types.NewCoins(0, txFee),
+			Account: types.TxInput{
+				Address:  owner.Address,
+				Sequence: sequence,
+			},
+			NewSigner: newKey.Address,
+		}
+		for _, signer := range signers {
+			tx.Guardians = append(tx.Guardians, types.TxInput{Address: signer.Address})
+		}
+		signBytes := tx.SignBytes(et.chainID)
+		for _, signer := range signers {
+			tx.SetSignature(signer.Address, signer.Sign(signBytes))
+		}
+		return tx
+	}
+
+	// No recovery without guardians
+	recoveryTx := requestRecovery(1, guardian1, guardian2)
+	res = et.executor.getTxExecutor(recoveryTx).sanityCheck(et.chainID, et.state().Delivered(), recoveryTx)
+	assert.Equal(result.CodeRecoveryCheckFailed, res.Code)
+
+	// Invalid threshold
+	setGuardiansTx := setGuardians(1, 3)
+	res = et.executor.getTxExecutor(setGuardiansTx).sanityCheck(et.chainID, et.state().Delivered(), setGuardiansTx)
+	assert.Equal(result.CodeInvalidGuardians, res.Code)
+
+	// 2-of-2 guardians
+	setGuardiansTx = setGuardians(1, 2)
+	res = et.executor.getTxExecutor(setGuardiansTx).sanityCheck(et.chainID, et.state().Delivered(), setGuardiansTx)
+	assert.True(res.IsOK(), res.Message)
+	_, res = et.executor.getTxExecutor(setGuardiansTx).process(et.chainID, et.state().Delivered(), setGuardiansTx)
+	assert.True(res.IsOK(), res.Message)
+	guardianship := et.state().Delivered().GetAccount(owner.Address).GetGuardianship()
+	assert.NotNil(guardianship)
+	assert.Equal(guardians, guardianship.Guardians)
+	assert.Equal(uint64(2), guardianship.Threshold)
+
+	// Not enough guardians, or not guardians
+	recoveryTx = requestRecovery(2, guardian1)
+	res = et.executor.getTxExecutor(recoveryTx).sanityCheck(et.chainID, et.state().Delivered(), recoveryTx)
+	assert.Equal(result.CodeRecoveryCheckFailed, res.Code)
+	recoveryTx = requestRecovery(2, guardian1, outsider)
+	res = et.executor.getTxExecutor(recoveryTx).sanityCheck(et.chainID, et.state().Delivered(), recoveryTx)
+	assert.Equal(result.CodeRecoveryCheckFailed, res.Code)
+	recoveryTx = requestRecovery(2, guardian1, guardian2)
+	recoveryTx.Guardians[1].Signature = outsider.Sign(recoveryTx.SignBytes(et.chainID))
+	res = et.executor.getTxExecutor(recoveryTx).sanityCheck(et.chainID, et.state().Delivered(), recoveryTx)
+	assert.Equal(result.CodeInvalidSignature, res.Code)
+
+	// The recovery is pending until the timelock has passed, and can be cancelled by the
+	// current key meanwhile
+	recoveryTx = requestRecovery(2, guardian1, guardian2)
+	res = et.executor.getTxExecutor(recoveryTx).sanityCheck(et.chainID, et.state().Delivered(), recoveryTx)
+	assert.True(res.IsOK(), res.Message)
+	_, res = et.executor.getTxExecutor(recoveryTx).process(et.chainID, et.state().Delivered(), recoveryTx)
+	assert.True(res.IsOK(), res.Message)
+	guardianship = et.state().Delivered().GetAccount(owner.Address).GetGuardianship()
+	assert.True(guardianship.HasPendingRecovery())
+	assert.Equal(newKey.Address, guardianship.NewSigner)
+
+	setGuardiansTx = setGuardians(3, 2)
+	res = et.executor.getTxExecutor(setGuardiansTx).sanityCheck(et.chainID, et.state().Delivered(), setGuardiansTx)
+	assert.True(res.IsOK(), res.Message)
+	_, res = et.executor.getTxExecutor(setGuardiansTx).process(et.chainID, et.state().Delivered(), setGuardiansTx)
+	assert.True(res.IsOK(), res.Message)
+	assert.False(et.state().Delivered().GetAccount(owner.Address).GetGuardianship().HasPendingRecovery())
+
+	recoveryTx = requestRecovery(4, guardian1, guardian2)
+	res = et.executor.getTxExecutor(recoveryTx).sanityCheck(et.chainID, et.state().Delivered(), recoveryTx)
+	assert.True(res.IsOK(), res.Message)
+	_, res = et.executor.getTxExecutor(recoveryTx).process(et.chainID, et.state().Delivered(), recoveryTx)
+	assert.True(res.IsOK(), res.Message)
+	recoveryHeight := et.state().Delivered().GetAccount(owner.Address).GetGuardianship().RecoveryHeight
+
+	et.fastforwardTo(recoveryHeight)
+
+	// After the timelock, the account is controlled by the new key only
+	sendTx := &types.SendTx{
+		Fee: types.NewCoins(0, txFee),
+		Inputs: []types.TxInput{{
+			Address:  owner.Address,
+			Coins:    types.NewCoins(0, 2*txFee),
+			Sequence: 5,
+		}},
+		Outputs: []types.TxOutput{{
+			Address: outsider.Address,
+			Coins:   types.NewCoins(0, txFee),
+		}},
+	}
+	sendTx.Inputs[0].Signature = owner.Sign(sendTx.SignBytes(et.chainID))
+	res = et.executor.getTxExecutor(sendTx).sanityCheck(et.chainID, et.state().Delivered(), sendTx)
+	assert.Equal(result.CodeInvalidSignature, res.Code)
+
+	sendTx.Inputs[0].Signature = newKey.Sign(sendTx.SignBytes(et.chainID))
+	res = et.executor.getTxExecutor(sendTx).sanityCheck(et.chainID, et.state().Delivered(), sendTx)
+	assert.True(res.IsOK(), res.Message)
+	_, res = et.executor.getTxExecutor(sendTx).process(et.chainID, et.state().Delivered(), sendTx)
+	assert.True(res.IsOK(), res.Message)
+
+	ownerAccount := et.state().Delivered().GetAccount(owner.Address)
+	assert.Equal(newKey.Address, ownerAccount.SignerAddress())
+	assert.Equal(uint64(5), ownerAccount.Sequence)
+}
+
+func TestTimelockedSendTx(t *testing.T) {
+	assert := assert.New(t)
+	et := NewExecTest()
+
+	txFee := getMinimumTxFee()
+	sender := types.MakeAcc("sender")
+	recipient := types.MakeAcc("recipient")
+	et.acc2State(sender, recipient)
+
+	et.fastforwardTo(1000)
+	currentHeight := et.state().Delivered().Height()
+
+	timelockedSendTx := func(sequence uint64, unlockHeight uint64) *types.TimelockedSendTx {
+		tx := &types.TimelockedSendTx{
+			Fee: types.NewCoins(0, txFee),
+			Inputs: []types.TxInput{{
+				Address:  sender.Address,
+				Coins:    types.NewCoins(1000, txFee),
+				Sequence: sequence,
+			}},
+			Outputs: []types.TxOutput{{
+				Address: recipient.Address,
+				Coins:   types.NewCoins(1000, 0),
+			}},
+			UnlockHeight: unlockHeight,
+		}
+		tx.Inputs[0].Signature = sender.Sign(tx.SignBytes(et.chainID))
+		return tx
+	}
+
+	// The unlock height needs to be in the future, and not too far
+	tx := timelockedSendTx(1, currentHeight)
+	res := et.executor.getTxExecutor(tx).sanityCheck(et.chainID, et.state().Delivered(), tx)
+	assert.Equal(result.CodeInvalidUnlockHeight, res.Code)
+	tx = timelockedSendTx(1, currentHeight+types.MaximumCoinLockDuration+1)
+	res = et.executor.getTxExecutor(tx).sanityCheck(et.chainID, et.state().Delivered(), tx)
+	assert.Equal(result.CodeInvalidUnlockHeight, res.Code)
+
+	unlockHeight := currentHeight + 100
+	tx = timelockedSendTx(1, unlockHeight)
+	res = et.executor.getTxExecutor(tx).sanityCheck(et.chainID, et.state().Delivered(), tx)
+	assert.True(res.IsOK(), res.Message)
+	_, res = et.executor.getTxExecutor(tx).process(et.chainID, et.state().Delivered(), tx)
+	assert.True(res.IsOK(), res.Message)
+
+	// The coins are locked, and the balance of the recipient is unchanged
+	recipientAcc := et.state().Delivered().GetAccount(recipient.Address)
+	assert.True(recipient.Balance.IsEqual(recipientAcc.Balance))
+	locked := recipientAcc.GetLockedCoins()
+	assert.Equal(1, len(locked))
+	assert.Equal(unlockHeight, locked[0].UnlockHeight)
+	assert.True(types.NewCoins(1000, 0).IsEqual(locked[0].Coins))
+	senderAcc := et.state().Delivered().GetAccount(sender.Address)
+	assert.True(sender.Balance.Minus(types.NewCoins(1000, txFee)).IsEqual(senderAcc.Balance))
+
+	// The recipient cannot spend the locked coins before the unlock height
+	spendable := recipient.Balance.Plus(types.NewCoins(1000, 0))
+	sendTx := &types.SendTx{
+		Fee: types.NewCoins(0, txFee),
+		Inputs: []types.TxInput{{
+			Address:  recipient.Address,
+			Coins:    spendable,
+			Sequence: 1,
+		}},
+		Outputs: []types.TxOutput{{
+			Address: sender.Address,
+			Coins:   spendable.Minus(types.NewCoins(0, txFee)),
+		}},
+	}
+	et.signSendTx(sendTx, recipient)
+	res = et.executor.getTxExecutor(sendTx).sanityCheck(et.chainID, et.state().Delivered(), sendTx)
+	assert.Equal(result.CodeInsufficientFund, res.Code)
+
+	et.fastforwardTo(unlockHeight)
+
+	res = et.executor.getTxExecutor(sendTx).sanityCheck(et.chainID, et.state().Delivered(), sendTx)
+	assert.True(res.IsOK(), res.Message)
+	_, res = et.executor.getTxExecutor(sendTx).process(et.chainID, et.state().Delivered(), sendTx)
+	assert.True(res.IsOK(), res.Message)
+	recipientAcc = et.state().Delivered().GetAccount(recipient.Address)
+	assert.Nil(recipientAcc.GetLockedCoins())
+	assert.True(recipientAcc.Balance.IsZero())
+}
+
+func TestCreateTokenTx(t *testing.T) {
+	assert := assert.New(t)
+	et := NewExecTest()
+
+	txFee := getMinimumTxFee()
+	issuer := types.MakeAcc("issuer")
+	bob := types.MakeAcc("bob")
+	et.acc2State(issuer, bob)
+
+	et.fastforwardTo(1000)
+
+	createTokenTx := func(sequence uint64, symbol string) *types.CreateTokenTx {
+		tx := &types.CreateTokenTx{
+			Fee: types.NewCoins(0, txFee),
+			Issuer: types.TxInput{
+				Address:  issuer.Address,
+				Sequence: sequence,
+			},
+			Symbol:    symbol,
+			Decimals:  6,
+			MaxSupply: big.NewInt(1000000000),
+		}
+		tx.Issuer.Signature = issuer.Sign(tx.SignBytes(et.chainID))
+		return tx
+	}
+
+	tx := createTokenTx(1, "theta")
+	res := et.executor.sanityCheck(et.chainID, et.state().Delivered(), tx)
+	assert.Equal(result.CodeInvalidToken, res.Code)
+
+	tx = createTokenTx(1, "ABC")
+	res = et.executor.sanityCheck(et.chainID, et.state().Delivered(), tx)
+	assert.True(res.IsOK(), res.Message)
+	_, res = et.executor.process(et.chainID, et.state().Delivered(), tx)
+	assert.True(res.IsOK(), res.Message)
+
+	token := et.state().Delivered().GetToken("ABC")
+	assert.NotNil(token)
+	assert.Equal(issuer.Address, token.Issuer)
+	assert.Equal(uint64(6), token.Decimals)
+	issuerAcc := et.state().Delivered().GetAccount(issuer.Address)
+	assert.Equal(big.NewInt(1000000000), issuerAcc.Balance.GetToken("ABC"))
+	assert.True(issuer.Balance.Minus(types.NewCoins(0, txFee)).Plus(types.NewTokenCoins("ABC", big.NewInt(1000000000))).IsEqual(issuerAcc.Balance))
+
+	// The symbol is taken
+	tx = createTokenTx(2, "ABC")
+	res = et.executor.sanityCheck(et.chainID, et.state().Delivered(), tx)
+	assert.Equal(result.CodeInvalidToken, res.Code)
+
+	// The tokens are sent like Theta and Gamma
+	sendTokens := func(from types.PrivAccount, to types.PrivAccount, sequence uint64, tokens types.Coins) *types.SendTx {
+		sendTx := &types.SendTx{
+			Fee: types.NewCoins(0, txFee),
+			Inputs: []types.TxInput{{
+				Address:  from.Address,
+				Coins:    tokens.Plus(types.NewCoins(0, txFee)),
+				Sequence: sequence,
+			}},
+			Outputs: []types.TxOutput{{
+				Address: to.Address,
+				Coins:   tokens.Plus(types.NewCoins(0, 0)),
+			}},
+		}
+		et.signSendTx(sendTx, from)
+		return sendTx
+	}
+	sendTx := sendTokens(issuer, bob, 2, types.NewTokenCoins("ABC", big.NewInt(300)))
+	res = et.executor.sanityCheck(et.chainID, et.state().Delivered(), sendTx)
+	assert.True(res.IsOK(), res.Message)
+	_, res = et.executor.process(et.chainID, et.state().Delivered(), sendTx)
+	assert.True(res.IsOK(), res.Message)
+	assert.Equal(big.NewInt(300), et.state().Delivered().GetAccount(bob.Address).Balance.GetToken("ABC"))
+	assert.Equal(big.NewInt(999999700), et.state().Delivered().GetAccount(issuer.Address).Balance.GetToken("ABC"))
+
+	sendTx = sendTokens(bob, issuer, 1, types.NewTokenCoins("ABC", big.NewInt(301)))
+	res = et.executor.sanityCheck(et.chainID, et.state().Delivered(), sendTx)
+	assert.Equal(result.CodeInsufficientFund, res.Code)
+
+	// Unregistered tokens are rejected
+	sendTx = sendTokens(issuer, bob, 3, types.NewTokenCoins("XYZ", big.NewInt(1)))
+	res = et.executor.sanityCheck(et.chainID, et.state().Delivered(), sendTx)
+	assert.Equal(result.CodeTokenNotFound, res.Code)
+
+	// The tokens are only transferred by SendTx and TimelockedSendTx
+	reserveFundTx := &types.ReserveFundTx{
+		Fee: types.NewCoins(0, txFee),
+		Source: types.TxInput{
+			Address:  issuer.Address,
+			Coins:    types.NewCoins(0, 1000*txFee).Plus(types.NewTokenCoins("ABC", big.NewInt(10))),
+			Sequence: 3,
+		},
+		Collateral:  types.NewCoins(0, 1001*txFee),
+		ResourceIDs: []string{"rid001"},
+		Duration:    1000,
+	}
+	reserveFundTx.Source.Signature = issuer.Sign(reserveFundTx.SignBytes(et.chainID))
+	res = et.executor.sanityCheck(et.chainID, et.state().Delivered(), reserveFundTx)
+	assert.Equal(result.CodeInvalidToken, res.Code)
 }
 
 func TestReleaseFundTx(t *testing.T) {
@@ -635,7 +1334,7 @@ func TestReleaseFundTx(t *testing.T) {
 	releaseFundTx.Source.Signature = user1.Sign(releaseFundTx.SignBytes(et.chainID))
 	res = et.executor.getTxExecutor(releaseFundTx).sanityCheck(et.chainID, et.state().Delivered(), releaseFundTx)
 	assert.False(res.IsOK(), res.String())
-	assert.Equal(res.Code, result.CodeReleaseFundCheckFailed, res.String())
+	assert.Equal(res.Code, result.CodeReservedFundNotFound, res.String())
 
 	// NOTE: The following check should FAIL, since the expired ReservedFunds are now
 	//       released by the Account.UpdateToHeight() function. Once the height
@@ -654,7 +1353,7 @@ func TestReleaseFundTx(t *testing.T) {
 	releaseFundTx.Source.Signature = user1.Sign(releaseFundTx.SignBytes(et.chainID))
 	res = et.executor.getTxExecutor(releaseFundTx).sanityCheck(et.chainID, et.state().Delivered(), releaseFundTx)
 	assert.False(res.IsOK(), res.String())
-	assert.Equal(res.Code, result.CodeReleaseFundCheckFailed, res.String())
+	assert.Equal(res.Code, result.CodeReservedFundNotFound, res.String())
 }
 
 func TestServicePaymentTxNormalExecutionAndSlash(t *testing.T) {
@@ -740,6 +1439,145 @@ func TestServicePaymentTxNormalExecutionAndSlash(t *testing.T) {
 	assert.Equal(1, len(et.state().Delivered().GetSlashIntents()))
 }
 
+func TestServicePaymentTxSpendLimit(t *testing.T) {
+	assert := assert.New(t)
+	et, resourceID, alice, bob, _, _, _, _ := setupForServicePayment(assert)
+
+	txFee := getMinimumTxFee()
+
+	newReserveFundTx := func(spendLimits []types.ResourceSpendLimit) *types.ReserveFundTx {
+		reserveFundTx := &types.ReserveFundTx{
+			Fee: types.NewCoins(0, txFee),
+			Source: types.TxInput{
+				Address:  alice.Address,
+				Coins:    types.NewCoins(0, 1000*txFee),
+				Sequence: 2,
+			},
+			Collateral:  types.NewCoins(0, 1001*txFee),
+			ResourceIDs: []string{resourceID},
+			Duration:    1000,
+			SpendLimits: spendLimits,
+		}
+		reserveFundTx.Source.Signature = alice.Sign(reserveFundTx.SignBytes(et.chainID))
+		return reserveFundTx
+	}
+
+	// The spend limits must be for the resources of the reserve
+	reserveFundTx := newReserveFundTx([]types.ResourceSpendLimit{{ResourceID: "rid002", Limit: types.NewCoins(0, 100*txFee)}})
+	res := et.executor.getTxExecutor(reserveFundTx).sanityCheck(et.chainID, et.state().Delivered(), reserveFundTx)
+	assert.Equal(result.CodeInvalidSpendLimits, res.Code)
+
+	reserveFundTx = newReserveFundTx([]types.ResourceSpendLimit{{ResourceID: resourceID, Limit: types.NewCoins(0, 100*txFee)}})
+	res = et.executor.getTxExecutor(reserveFundTx).sanityCheck(et.chainID, et.state().Delivered(), reserveFundTx)
+	assert.True(res.IsOK(), res.Message)
+	_, res = et.executor.getTxExecutor(reserveFundTx).process(et.chainID, et.state().Delivered(), reserveFundTx)
+	assert.True(res.IsOK(), res.Message)
+	et.state().Commit()
+
+	// The first payment is within the limit of the resource
+	servicePaymentTx := createServicePaymentTx(et.chainID, &alice, &bob, 60*txFee, 1, 1, 1, 2, resourceID)
+	res = et.executor.getTxExecutor(servicePaymentTx).sanityCheck(et.chainID, et.state().Delivered(), servicePaymentTx)
+	assert.True(res.IsOK(), res.Message)
+	_, res = et.executor.getTxExecutor(servicePaymentTx).process(et.chainID, et.state().Delivered(), servicePaymentTx)
+	assert.True(res.IsOK(), res.Message)
+	et.state().Commit()
+
+	// The second one would exceed it, although the reserve has enough fund left
+	servicePaymentTx = createServicePaymentTx(et.chainID, &alice, &bob, 50*txFee, 1, 2, 2, 2, resourceID)
+	res = et.executor.getTxExecutor(servicePaymentTx).sanityCheck(et.chainID, et.state().Delivered(), servicePaymentTx)
+	assert.Equal(result.CodeResourceSpendLimitExceeded, res.Code)
+
+	servicePaymentTx = createServicePaymentTx(et.chainID, &alice, &bob, 40*txFee, 1, 2, 2, 2, resourceID)
+	res = et.executor.getTxExecutor(servicePaymentTx).sanityCheck(et.chainID, et.state().Delivered(), servicePaymentTx)
+	assert.True(res.IsOK(), res.Message)
+}
+
+func TestBatchServicePaymentTx(t *testing.T) {
+	assert := assert.New(t)
+	et, resourceID, alice, bob, carol, _, bobInitBalance, _ := setupForServicePayment(assert)
+
+	txFee := getMinimumTxFee()
+
+	// Carol reserves fund too
+	reserveFundTx := &types.ReserveFundTx{
+		Fee: types.NewCoins(0, txFee),
+		Source: types.TxInput{
+			Address:  carol.Address,
+			Coins:    types.NewCoins(0, 1000*txFee),
+			Sequence: 1,
+		},
+		Collateral:  types.NewCoins(0, 1001*txFee),
+		ResourceIDs: []string{resourceID},
+		Duration:    1000,
+	}
+	reserveFundTx.Source.Signature = carol.Sign(reserveFundTx.SignBytes(et.chainID))
+	res := et.executor.getTxExecutor(reserveFundTx).sanityCheck(et.chainID, et.state().Delivered(), reserveFundTx)
+	assert.True(res.IsOK(), res.Message)
+	_, res = et.executor.getTxExecutor(reserveFundTx).process(et.chainID, et.state().Delivered(), reserveFundTx)
+	assert.True(res.IsOK(), res.Message)
+
+	// Only the source signatures of the payments are used
+	payments := []types.ServicePaymentTx{
+		*createServicePaymentTx(et.chainID, &alice, &bob, 100*txFee, 1, 1, 1, 1, resourceID),
+		*createServicePaymentTx(et.chainID, &carol, &bob, 200*txFee, 1, 1, 1, 1, resourceID),
+	}
+	for i := range payments {
+		payments[i].Fee = types.NewCoins(0, 0)
+		payments[i].Target = types.TxInput{Address: bob.Address}
+	}
+	newBatchTx := func(payments ...types.ServicePaymentTx) *types.BatchServicePaymentTx {
+		tx := &types.BatchServicePaymentTx{
+			Fee: types.NewCoins(0, txFee),
+			Target: types.TxInput{
+				Address:  bob.Address,
+				Sequence: 1,
+			},
+			Payments: payments,
+		}
+		tx.Target.Signature = bob.Sign(tx.SignBytes(et.chainID))
+		return tx
+	}
+
+	// A source can be settled only once per batch
+	batchTx := newBatchTx(payments[0], payments[1], payments[0])
+	res = et.executor.getTxExecutor(batchTx).sanityCheck(et.chainID, et.state().Delivered(), batchTx)
+	assert.Equal(result.CodeInvalidBatchPayment, res.Code)
+
+	// The payments must be to the target of the batch
+	toCarol := *createServicePaymentTx(et.chainID, &alice, &carol, 100*txFee, 1, 1, 1, 1, resourceID)
+	batchTx = newBatchTx(toCarol, payments[1])
+	res = et.executor.getTxExecutor(batchTx).sanityCheck(et.chainID, et.state().Delivered(), batchTx)
+	assert.Equal(result.CodeInvalidBatchPayment, res.Code)
+
+	// The payments must be signed by their sources
+	tampered := payments[1]
+	tampered.Source.Coins = types.NewCoins(0, 300*txFee)
+	batchTx = newBatchTx(payments[0], tampered)
+	res = et.executor.getTxExecutor(batchTx).sanityCheck(et.chainID, et.state().Delivered(), batchTx)
+	assert.True(res.IsError())
+
+	batchTx = newBatchTx(payments...)
+	res = et.executor.getTxExecutor(batchTx).sanityCheck(et.chainID, et.state().Delivered(), batchTx)
+	assert.True(res.IsOK(), res.Message)
+	_, res = et.executor.getTxExecutor(batchTx).process(et.chainID, et.state().Delivered(), batchTx)
+	assert.True(res.IsOK(), res.Message)
+
+	et.state().Commit()
+
+	// Bob receives both payments and pays a single fee
+	bobAccount := et.state().Delivered().GetAccount(bob.Address)
+	assert.Equal(bobInitBalance.Plus(types.NewCoins(0, 300*txFee)).Minus(types.NewCoins(0, txFee)), bobAccount.Balance)
+	assert.Equal(uint64(1), bobAccount.Sequence)
+	assert.Equal(types.NewCoins(0, 100*txFee), et.state().Delivered().GetAccount(alice.Address).ReservedFunds[0].UsedFund)
+	assert.Equal(types.NewCoins(0, 200*txFee), et.state().Delivered().GetAccount(carol.Address).ReservedFunds[0].UsedFund)
+
+	// The payments cannot be settled again
+	batchTx.Target.Sequence = 2
+	batchTx.Target.Signature = bob.Sign(batchTx.SignBytes(et.chainID))
+	res = et.executor.getTxExecutor(batchTx).sanityCheck(et.chainID, et.state().Delivered(), batchTx)
+	assert.Equal(result.CodeCheckTransferReservedFundFailed, res.Code)
+}
+
 func TestServicePaymentTxExpiration(t *testing.T) {
 	assert := assert.New(t)
 	et, resourceID, alice, bob, _, _, bobInitBalance, _ := setupForServicePayment(assert)
@@ -780,7 +1618,7 @@ func TestServicePaymentTxExpiration(t *testing.T) {
 	servicePaymentTx2 := createServicePaymentTx(et.chainID, &alice, &bob, payAmount2, srcSeq, tgtSeq, paymentSeq, reserveSeq, resourceID)
 	res = et.executor.getTxExecutor(servicePaymentTx2).sanityCheck(et.chainID, et.state().Delivered(), servicePaymentTx2)
 	assert.False(res.IsOK(), res.Message)
-	assert.Equal(result.CodeCheckTransferReservedFundFailed, res.Code)
+	assert.Equal(result.CodeReservedFundNotFound, res.Code)
 	log.Infof("Service payment check message: %v", res.Message)
 }
 
@@ -851,6 +1689,117 @@ func TestSlashTx(t *testing.T) {
 	log.Infof("Proposer final balance: %v", retrievedProposerAccount.Balance)
 }
 
+func TestSlashTxWithSlashingPolicy(t *testing.T) {
+	assert := assert.New(t)
+	et, resourceID, alice, bob, _, _, _, _ := setupForServicePayment(assert)
+	params := types.DefaultChainParameters()
+	params.SlashCollateralPercent = 50
+	params.SlashReporterRewardPercent = 40
+	params.SlashJailDuration = 10
+	et.setChainParameters(params)
+
+	// Alice is also a validator
+	valMgr := et.executor.valMgr.(*TestValidatorManager)
+	valMgr.valSet.AddValidator(core.NewValidator(alice.PrivKey.PublicKey().ToBytes(), uint64(100)))
+
+	proposer := et.accProposer
+	proposerInitBalance := proposer.Account.Balance
+	et.acc2State(proposer)
+	et.state().Commit()
+
+	txFee := getMinimumTxFee()
+
+	retrievedAliceAccount := et.state().Delivered().GetAccount(alice.Address)
+	slashableAmount := retrievedAliceAccount.ReservedFunds[0].Collateral.Plus(retrievedAliceAccount.ReservedFunds[0].InitialFund)
+
+	// Simulate an overspending micropayment between Alice and Bob
+	servicePaymentTx := createServicePaymentTx(et.chainID, &alice, &bob, 8000*txFee, 1, 1, 1, 1, resourceID)
+	_, res := et.executor.getTxExecutor(servicePaymentTx).process(et.chainID, et.state().Delivered(), servicePaymentTx)
+	assert.True(res.IsOK(), res.Message)
+	assert.Equal(1, len(et.state().Delivered().GetSlashIntents()))
+	slashIntent := et.state().Delivered().GetSlashIntents()[0]
+	et.state().Commit()
+
+	aliceBalanceBeforeSlash := et.state().Delivered().GetAccount(alice.Address).Balance
+
+	slashTx := &types.SlashTx{
+		Proposer: types.TxInput{
+			Address:  proposer.Address,
+			Sequence: 1,
+		},
+		SlashedAddress:  slashIntent.Address,
+		ReserveSequence: slashIntent.ReserveSequence,
+		SlashProof:      slashIntent.Proof,
+	}
+	slashTx.Proposer.Signature = proposer.Sign(slashTx.SignBytes(et.chainID))
+
+	// The slash is in a block of epoch 100
+	et.state().Delivered().SetEpoch(100)
+	res = et.executor.getTxExecutor(slashTx).sanityCheck(et.chainID, et.state().Delivered(), slashTx)
+	assert.True(res.IsOK(), res.Message)
+	_, res = et.executor.getTxExecutor(slashTx).process(et.chainID, et.state().Delivered(), slashTx)
+	assert.True(res.IsOK(), res.Message)
+
+	// Half of the slashable amount is slashed, and 40% of it is rewarded to the proposer
+	slashedAmount := slashableAmount.CalculatePercentage(50)
+	retrievedProposerAccount := et.state().Delivered().GetAccount(proposer.Address)
+	assert.Equal(proposerInitBalance.Plus(slashedAmount.CalculatePercentage(40)), retrievedProposerAccount.Balance)
+
+	retrievedAliceAccountAfterSlash := et.state().Delivered().GetAccount(alice.Address)
+	assert.Equal(0, len(retrievedAliceAccountAfterSlash.ReservedFunds))
+	assert.Equal(aliceBalanceBeforeSlash.Plus(slashableAmount.Minus(slashedAmount)), retrievedAliceAccountAfterSlash.Balance)
+
+	// Alice is jailed for the 10 epochs after the current one
+	jailedValidators := et.state().Delivered().GetJailedValidators()
+	assert.Equal(1, len(jailedValidators))
+	assert.Equal(alice.Address, jailedValidators[0].Address)
+	assert.Equal(uint64(100), jailedValidators[0].JailedEpoch)
+	assert.Equal(uint64(110), jailedValidators[0].UntilEpoch)
+}
+
+func TestSlashTxKeepsJailUntilUnjailed(t *testing.T) {
+	assert := assert.New(t)
+	et, resourceID, alice, bob, _, _, _, _ := setupForServicePayment(assert)
+	params := types.DefaultChainParameters()
+	params.SlashJailDuration = 10
+	et.setChainParameters(params)
+
+	// Alice is a validator jailed for downtime
+	valMgr := et.executor.valMgr.(*TestValidatorManager)
+	valMgr.valSet.AddValidator(core.NewValidator(alice.PrivKey.PublicKey().ToBytes(), uint64(100)))
+	et.state().Delivered().JailValidator(alice.Address, 90, types.JailedUntilUnjailed)
+
+	proposer := et.accProposer
+	et.acc2State(proposer)
+	et.state().Commit()
+
+	servicePaymentTx := createServicePaymentTx(et.chainID, &alice, &bob, 8000*getMinimumTxFee(), 1, 1, 1, 1, resourceID)
+	_, res := et.executor.getTxExecutor(servicePaymentTx).process(et.chainID, et.state().Delivered(), servicePaymentTx)
+	assert.True(res.IsOK(), res.Message)
+	slashIntent := et.state().Delivered().GetSlashIntents()[0]
+	et.state().Commit()
+
+	slashTx := &types.SlashTx{
+		Proposer: types.TxInput{
+			Address:  proposer.Address,
+			Sequence: 1,
+		},
+		SlashedAddress:  slashIntent.Address,
+		ReserveSequence: slashIntent.ReserveSequence,
+		SlashProof:      slashIntent.Proof,
+	}
+	slashTx.Proposer.Signature = proposer.Sign(slashTx.SignBytes(et.chainID))
+
+	et.state().Delivered().SetEpoch(100)
+	_, res = et.executor.getTxExecutor(slashTx).process(et.chainID, et.state().Delivered(), slashTx)
+	assert.True(res.IsOK(), res.Message)
+
+	// The slash does not release Alice after its jail duration, she still needs to unjail
+	jailed := et.state().Delivered().GetJailedValidator(alice.Address)
+	assert.Equal(uint64(90), jailed.JailedEpoch)
+	assert.True(jailed.IsJailedUntilUnjailed())
+}
+
 func TestSplitRuleTxNormalExecution(t *testing.T) {
 	assert := assert.New(t)
 	et, resourceID, alice, bob, carol, _, bobInitBalance, carolInitBalance := setupForServicePayment(assert)
@@ -1073,3 +2022,458 @@ func TestSplitRuleTxUpdate(t *testing.T) {
 	log.Infof("currHeight = %v", currHeight)
 	log.Infof("endHeight2 = %v", endHeight2)
 }
+
+func TestSplitRuleTxPlatformSplits(t *testing.T) {
+	assert := assert.New(t)
+	et, resourceID, alice, bob, carol, _, bobInitBalance, carolInitBalance := setupForServicePayment(assert)
+
+	txFee := getMinimumTxFee()
+
+	initiator := types.MakeAcc("User David")
+	initiatorInitBalance := types.Coins{GammaWei: big.NewInt(10000 * txFee), ThetaWei: big.NewInt(0)}
+	initiator.Balance = initiatorInitBalance
+	et.acc2State(initiator)
+
+	// Duplicated addresses are rejected
+	splitRuleTx := &types.SplitRuleTx{
+		Fee:        types.NewCoins(0, txFee),
+		ResourceID: resourceID,
+		Initiator: types.TxInput{
+			Address:  initiator.Address,
+			Sequence: 1,
+		},
+		Splits:   []types.Split{{Address: carol.Address, Percentage: 10}, {Address: carol.Address, Percentage: 20}},
+		Duration: uint64(99999),
+	}
+	signBytes := splitRuleTx.SignBytes(et.chainID)
+	splitRuleTx.Initiator.Signature = initiator.Sign(signBytes)
+
+	res := et.executor.getTxExecutor(splitRuleTx).sanityCheck(et.chainID, et.state().Delivered(), splitRuleTx)
+	assert.Equal(result.CodeInvalidSplits, res.Code)
+
+	// The initiator takes a 10% platform fee, then Carol and Bob, the target of the
+	// payments, split what remains
+	splitRuleTx.Splits = []types.Split{{Address: carol.Address, Percentage: 30}, {Address: bob.Address, Percentage: 20}}
+	splitRuleTx.PlatformSplits = []types.Split{{Address: initiator.Address, Percentage: 10}}
+	signBytes = splitRuleTx.SignBytes(et.chainID)
+	splitRuleTx.Initiator.Signature = initiator.Sign(signBytes)
+
+	res = et.executor.getTxExecutor(splitRuleTx).sanityCheck(et.chainID, et.state().Delivered(), splitRuleTx)
+	assert.True(res.IsOK(), res.Message)
+	_, res = et.executor.getTxExecutor(splitRuleTx).process(et.chainID, et.state().Delivered(), splitRuleTx)
+	assert.True(res.IsOK(), res.Message)
+
+	payAmount := int64(1000 * txFee)
+	servicePaymentTx := createServicePaymentTx(et.chainID, &alice, &bob, payAmount, 1, 1, 1, 1, resourceID)
+	res = et.executor.getTxExecutor(servicePaymentTx).sanityCheck(et.chainID, et.state().Delivered(), servicePaymentTx)
+	assert.True(res.IsOK(), res.Message)
+	_, res = et.executor.getTxExecutor(servicePaymentTx).process(et.chainID, et.state().Delivered(), servicePaymentTx)
+	assert.True(res.IsOK(), res.Message)
+
+	et.state().Commit()
+
+	initiatorFinalBalance := et.state().Delivered().GetAccount(initiator.Address).Balance
+	bobFinalBalance := et.state().Delivered().GetAccount(bob.Address).Balance
+	carolFinalBalance := et.state().Delivered().GetAccount(carol.Address).Balance
+
+	// 10% of the payment to the initiator, 30% and 20% of the remaining 90% to Carol
+	// and Bob, and the rest to Bob as the target
+	fee := types.NewCoins(0, txFee)
+	assert.Equal(initiatorInitBalance.Plus(types.NewCoins(0, payAmount*10/100)).Minus(fee), initiatorFinalBalance)
+	assert.Equal(carolInitBalance.Plus(types.NewCoins(0, payAmount*27/100)), carolFinalBalance)
+	assert.Equal(bobInitBalance.Plus(types.NewCoins(0, payAmount*63/100)).Minus(fee), bobFinalBalance)
+}
+
+func TestParameterUpdateTx(t *testing.T) {
+	assert := assert.New(t)
+	et := NewExecTest()
+
+	txFee := getMinimumTxFee()
+	alice := types.MakeAcc("alice")
+	bob := types.MakeAcc("bob")
+	et.acc2State(et.accProposer, et.accVal2, alice, bob)
+
+	et.fastforwardTo(1000)
+
+	params := types.ChainParameters{
+		MinimumTransactionFeeGammaWei: uint64(2 * txFee),
+		MaxBlockGas:                   types.MinimumMaxBlockGas,
+		SlashCollateralPercent:        50,
+		BlockRewardGammaWei:           big.NewInt(0),
+	}
+	parameterUpdateTx := func(proposer types.PrivAccount, sequence uint64, height uint64, approvers ...types.PrivAccount) *types.ParameterUpdateTx {
+		tx := &types.ParameterUpdateTx{
+			Fee: types.NewCoins(0, txFee),
+			Proposer: types.TxInput{
+				Address:  proposer.Address,
+				Sequence: sequence,
+			},
+			Height:     height,
+			Parameters: params,
+		}
+		for _, approver := range approvers {
+			tx.Approvers = append(tx.Approvers, types.TxInput{Address: approver.Address})
+		}
+		signBytes := tx.SignBytes(et.chainID)
+		tx.SetSignature(proposer.Address, proposer.Sign(signBytes))
+		for _, approver := range approvers {
+			tx.SetSignature(approver.Address, approver.Sign(signBytes))
+		}
+		return tx
+	}
+
+	// The second validator holds less than 2/3 of the stake
+	tx := parameterUpdateTx(et.accVal2, 1, 1010)
+	res := et.executor.sanityCheck(et.chainID, et.state().Delivered(), tx)
+	assert.Equal(result.CodeParameterUpdateNotAuthorized, res.Code)
+
+	// Only the validators can approve
+	tx = parameterUpdateTx(et.accVal2, 1, 1010, et.accProposer, alice)
+	res = et.executor.sanityCheck(et.chainID, et.state().Delivered(), tx)
+	assert.Equal(result.CodeParameterUpdateNotAuthorized, res.Code)
+
+	// The update needs to be scheduled at a future height
+	tx = parameterUpdateTx(et.accVal2, 1, 1000, et.accProposer)
+	res = et.executor.sanityCheck(et.chainID, et.state().Delivered(), tx)
+	assert.Equal(result.CodeInvalidParameters, res.Code)
+
+	tx = parameterUpdateTx(et.accVal2, 1, 1010, et.accProposer)
+	res = et.executor.sanityCheck(et.chainID, et.state().Delivered(), tx)
+	assert.True(res.IsOK(), res.Message)
+	_, res = et.executor.process(et.chainID, et.state().Delivered(), tx)
+	assert.True(res.IsOK(), res.Message)
+
+	// The parameters apply from the height on
+	assert.False(et.state().Delivered().ChainParametersUpdated())
+	assert.Equal(types.DefaultChainParameters(), et.state().Delivered().GetChainParameters())
+	assert.Equal(1, len(et.state().Delivered().GetParameterUpdates()))
+
+	sendTx := func(sequence uint64, fee int64) *types.SendTx {
+		tx := &types.SendTx{
+			Fee: types.NewCoins(0, fee),
+			Inputs: []types.TxInput{{
+				Address:  alice.Address,
+				Coins:    types.NewCoins(10, fee),
+				Sequence: sequence,
+			}},
+			Outputs: []types.TxOutput{{
+				Address: bob.Address,
+				Coins:   types.NewCoins(10, 0),
+			}},
+		}
+		et.signSendTx(tx, alice)
+		return tx
+	}
+	res = et.executor.sanityCheck(et.chainID, et.state().Delivered(), sendTx(1, txFee))
+	assert.True(res.IsOK(), res.Message)
+
+	et.fastforwardTo(1010)
+	et.state().Delivered().ActivateParameterUpdates(1010)
+	assert.True(et.state().Delivered().ChainParametersUpdated())
+	assert.Equal(params, et.state().Delivered().GetChainParameters())
+	assert.Equal(0, len(et.state().Delivered().GetParameterUpdates()))
+
+	res = et.executor.sanityCheck(et.chainID, et.state().Delivered(), sendTx(1, txFee))
+	assert.Equal(result.CodeInvalidFee, res.Code)
+	res = et.executor.sanityCheck(et.chainID, et.state().Delivered(), sendTx(1, 2*txFee))
+	assert.True(res.IsOK(), res.Message)
+}
+
+func TestGovernanceProposal(t *testing.T) {
+	assert := assert.New(t)
+	et := NewExecTest()
+
+	txFee := getMinimumTxFee()
+	alice := types.MakeAcc("alice")
+	bob := types.MakeAcc("bob")
+	carol := types.MakeAcc("carol")
+	et.acc2State(et.accProposer, et.accVal2, alice, bob, carol)
+
+	stakeHolder := types.NewStakeHolder(et.accProposer.Address)
+	stakeHolder.DepositStake(alice.Address, big.NewInt(600))
+	stakeHolder.DepositStake(bob.Address, big.NewInt(400))
+	et.state().Delivered().SetStakeHolder(stakeHolder)
+
+	et.fastforwardTo(1000)
+
+	proposalTx := func(proposer types.PrivAccount, sequence uint64, change types.ProposalChange, votingPeriod uint64) *types.ProposalTx {
+		tx := &types.ProposalTx{
+			Fee: types.NewCoins(0, txFee),
+			Proposer: types.TxInput{
+				Address:  proposer.Address,
+				Sequence: sequence,
+			},
+			Change:       change,
+			Description:  "test proposal",
+			VotingPeriod: votingPeriod,
+		}
+		tx.SetSignature(proposer.Address, proposer.Sign(tx.SignBytes(et.chainID)))
+		return tx
+	}
+	voteTx := func(voter types.PrivAccount, sequence uint64, proposalID uint64, approve bool) *types.VoteTx {
+		tx := &types.VoteTx{
+			Fee: types.NewCoins(0, txFee),
+			Voter: types.TxInput{
+				Address:  voter.Address,
+				Sequence: sequence,
+			},
+			ProposalID: proposalID,
+			Approve:    approve,
+		}
+		tx.SetSignature(voter.Address, voter.Sign(tx.SignBytes(et.chainID)))
+		return tx
+	}
+	jail := types.ProposalChange{
+		Kind:         types.ProposalJailValidator,
+		Validator:    et.accVal2.Address,
+		JailDuration: 10,
+	}
+
+	// Only the stakers can submit proposals
+	tx := proposalTx(carol, 1, jail, types.MinimumProposalVotingPeriod)
+	res := et.executor.sanityCheck(et.chainID, et.state().Delivered(), tx)
+	assert.Equal(result.CodeNoVotingStake, res.Code)
+
+	tx = proposalTx(alice, 1, jail, types.MinimumProposalVotingPeriod-1)
+	res = et.executor.sanityCheck(et.chainID, et.state().Delivered(), tx)
+	assert.Equal(result.CodeInvalidProposal, res.Code)
+
+	tx = proposalTx(alice, 1, types.ProposalChange{Kind: types.ProposalJailValidator}, types.MinimumProposalVotingPeriod)
+	res = et.executor.sanityCheck(et.chainID, et.state().Delivered(), tx)
+	assert.Equal(result.CodeInvalidProposal, res.Code)
+
+	tx = proposalTx(alice, 1, jail, types.MinimumProposalVotingPeriod)
+	res = et.executor.sanityCheck(et.chainID, et.state().Delivered(), tx)
+	assert.True(res.IsOK(), res.Message)
+	_, res = et.executor.process(et.chainID, et.state().Delivered(), tx)
+	assert.True(res.IsOK(), res.Message)
+
+	proposal := et.state().Delivered().GetProposal(1)
+	assert.NotNil(proposal)
+	assert.Equal(alice.Address, proposal.Proposer)
+	assert.Equal(uint64(1000+types.MinimumProposalVotingPeriod), proposal.EndHeight)
+	assert.Equal(types.ProposalActive, proposal.Status)
+
+	// The votes are accepted from the next block on
+	res = et.executor.sanityCheck(et.chainID, et.state().Delivered(), voteTx(bob, 1, 1, false))
+	assert.Equal(result.CodeProposalNotActive, res.Code)
+
+	et.fastforwardTo(1001)
+
+	res = et.executor.sanityCheck(et.chainID, et.state().Delivered(), voteTx(bob, 1, 2, false))
+	assert.Equal(result.CodeProposalNotFound, res.Code)
+	res = et.executor.sanityCheck(et.chainID, et.state().Delivered(), voteTx(carol, 1, 1, true))
+	assert.Equal(result.CodeNoVotingStake, res.Code)
+
+	for _, vote := range []*types.VoteTx{voteTx(bob, 1, 1, false), voteTx(alice, 2, 1, false), voteTx(alice, 3, 1, true)} {
+		res = et.executor.sanityCheck(et.chainID, et.state().Delivered(), vote)
+		assert.True(res.IsOK(), res.Message)
+		_, res = et.executor.process(et.chainID, et.state().Delivered(), vote)
+		assert.True(res.IsOK(), res.Message)
+	}
+
+	// The last vote of a voter counts
+	tally := et.state().Delivered().TallyProposal(1)
+	assert.Equal(big.NewInt(600), tally.Yes)
+	assert.Equal(big.NewInt(400), tally.No)
+	assert.Equal(big.NewInt(1000), tally.TotalStake)
+
+	// The proposal is tallied after its last voting height
+	view := et.state().Delivered()
+	tallyEndedProposals(view, proposal.EndHeight, 5)
+	assert.Equal(types.ProposalActive, view.GetProposal(1).Status)
+	assert.Equal(0, len(view.GetJailedValidators()))
+
+	tallyEndedProposals(view, proposal.EndHeight+1, 5)
+	proposal = view.GetProposal(1)
+	assert.Equal(types.ProposalPassed, proposal.Status)
+	assert.Equal(big.NewInt(600), proposal.Tally.Yes)
+	assert.Equal(0, len(view.GetProposalVotes(1)))
+	jailed := view.GetJailedValidators()
+	assert.Equal(1, len(jailed))
+	assert.Equal(et.accVal2.Address, jailed[0].Address)
+	assert.Equal(uint64(15), jailed[0].UntilEpoch)
+
+	// A proposal without votes is rejected
+	params := types.ChainParameters{
+		MinimumTransactionFeeGammaWei: uint64(2 * txFee),
+		SlashCollateralPercent:        50,
+	}
+	tx = proposalTx(alice, 4, types.ProposalChange{Kind: types.ProposalParameterChange, Parameters: params}, types.MinimumProposalVotingPeriod)
+	res = et.executor.sanityCheck(et.chainID, et.state().Delivered(), tx)
+	assert.True(res.IsOK(), res.Message)
+	_, res = et.executor.process(et.chainID, et.state().Delivered(), tx)
+	assert.True(res.IsOK(), res.Message)
+
+	proposal = view.GetProposal(2)
+	tallyEndedProposals(view, proposal.EndHeight+1, 5)
+	assert.Equal(types.ProposalRejected, view.GetProposal(2).Status)
+	assert.Equal(0, len(view.GetParameterUpdates()))
+}
+
+func TestValidatorDowntime(t *testing.T) {
+	assert := assert.New(t)
+	et := NewExecTest()
+
+	va1 := et.accProposer
+	va2 := et.accVal2
+	et.acc2State(va1, va2)
+	et.fastforwardTo(1e7)
+
+	// The epoch of the coinbase transaction is the epoch of the block
+	et.state().Delivered().SetEpoch(100)
+	coinbaseTx := func(epoch ...uint64) *types.CoinbaseTx {
+		tx := &types.CoinbaseTx{
+			Proposer: types.TxInput{Address: va1.Address},
+			Outputs: []types.TxOutput{
+				{Address: va1.Address, Coins: types.NewCoins(0, 0)},
+				{Address: va2.Address, Coins: types.NewCoins(0, 0)},
+			},
+			BlockHeight: 1e7,
+		}
+		if len(epoch) > 0 {
+			tx.SetEpoch(epoch[0])
+		}
+		tx.Proposer.Signature = va1.Sign(tx.SignBytes(et.chainID))
+		return tx
+	}
+	coinbaseTxExec := et.executor.getTxExecutor(coinbaseTx())
+	res := coinbaseTxExec.sanityCheck(et.chainID, et.state().Delivered(), coinbaseTx(101))
+	assert.Equal(result.CodeInvalidCoinbase, res.Code)
+	res = coinbaseTxExec.sanityCheck(et.chainID, et.state().Delivered(), coinbaseTx(99))
+	assert.Equal(result.CodeInvalidCoinbase, res.Code)
+	res = coinbaseTxExec.sanityCheck(et.chainID, et.state().Delivered(), coinbaseTx(100))
+	assert.True(res.IsOK(), res.Message)
+
+	// The epoch is only optional until the epoch of a block is recorded
+	res = coinbaseTxExec.sanityCheck(et.chainID, et.state().Delivered(), coinbaseTx())
+	assert.True(res.IsOK(), res.Message)
+
+	// The second validator is the proposer of all the epochs without a block
+	view := et.state().Delivered()
+	valMgr := NewTestValidatorManager(core.NewValidator(va2.PrivKey.PublicKey().ToBytes(), uint64(100)), nil)
+
+	trackMissedProposals(view, valMgr, 10)
+	trackMissedProposals(view, valMgr, 11)
+	assert.Nil(view.GetValidatorDowntime(va2.Address))
+
+	// Only the epoch directly before the block counts as missed
+	trackMissedProposals(view, valMgr, 15)
+	assert.Equal([]uint64{14}, view.GetValidatorDowntime(va2.Address).MissedEpochs)
+
+	epoch := uint64(15)
+	for i := 1; i < types.MaxMissedProposals; i++ {
+		epoch += 2
+		trackMissedProposals(view, valMgr, epoch)
+	}
+	assert.Equal(types.MaxMissedProposals, len(view.GetValidatorDowntime(va2.Address).MissedEpochs))
+	assert.Nil(view.GetJailedValidator(va2.Address))
+
+	// One more missed proposal jails the validator until it unjails
+	jailedEpoch := epoch + 2
+	trackMissedProposals(view, valMgr, jailedEpoch)
+	assert.Nil(view.GetValidatorDowntime(va2.Address))
+	jailed := view.GetJailedValidator(va2.Address)
+	assert.NotNil(jailed)
+	assert.Equal(jailedEpoch, jailed.JailedEpoch)
+	assert.True(jailed.IsJailedUntilUnjailed())
+
+	unjailTx := func(validator types.PrivAccount, sequence uint64) *types.UnjailTx {
+		tx := &types.UnjailTx{
+			Fee: types.NewCoins(0, getMinimumTxFee()),
+			Validator: types.TxInput{
+				Address:  validator.Address,
+				Sequence: sequence,
+			},
+		}
+		tx.SetSignature(validator.Address, validator.Sign(tx.SignBytes(et.chainID)))
+		return tx
+	}
+
+	res = et.executor.sanityCheck(et.chainID, view, unjailTx(va1, 1))
+	assert.Equal(result.CodeValidatorNotJailed, res.Code)
+	res = et.executor.sanityCheck(et.chainID, view, unjailTx(va2, 1))
+	assert.Equal(result.CodeUnjailCooldown, res.Code)
+
+	view.SetLastBlockEpoch(jailedEpoch + types.UnjailCooldownEpochs)
+	res = et.executor.sanityCheck(et.chainID, view, unjailTx(va2, 1))
+	assert.True(res.IsOK(), res.Message)
+	_, res = et.executor.process(et.chainID, view, unjailTx(va2, 1))
+	assert.True(res.IsOK(), res.Message)
+
+	jailed = view.GetJailedValidator(va2.Address)
+	assert.False(jailed.IsJailedUntilUnjailed())
+	assert.Equal(jailedEpoch+types.UnjailCooldownEpochs, jailed.UntilEpoch)
+
+	res = et.executor.sanityCheck(et.chainID, view, unjailTx(va2, 2))
+	assert.Equal(result.CodeValidatorNotJailed, res.Code)
+
+	res = coinbaseTxExec.sanityCheck(et.chainID, view, coinbaseTx())
+	assert.Equal(result.CodeInvalidCoinbase, res.Code)
+}
+
+func TestValidatorDowntimeIndependentOfValidatorManager(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	et := NewExecTest()
+
+	va1 := et.accProposer
+	va2 := et.accVal2
+	et.acc2State(va1, va2)
+	et.fastforwardTo(1e7)
+
+	validators := core.NewValidatorSet()
+	validators.AddValidator(core.NewValidator(va1.PrivKey.PublicKey().ToBytes(), uint64(100)))
+	validators.AddValidator(core.NewValidator(va2.PrivKey.PublicKey().ToBytes(), uint64(100)))
+
+	// The first validator is jailed in the state during the missed epoch 14, so the
+	// second one is its proposer
+	view := et.state().Delivered()
+	view.SetEpoch(15)
+	view.SetLastBlockEpoch(10)
+	view.SetJailedValidator(&types.JailedValidator{Address: va1.Address, JailedEpoch: 12, UntilEpoch: 20})
+
+	// The managers hold different jails and reveals in memory, e.g. since their nodes
+	// restarted or finalized at different times
+	rotating1 := consensus.NewRotatingValidatorManager(validators)
+	rotating2 := consensus.NewRotatingValidatorManager(validators)
+	rotating2.Jail(va2.Address, 12, 20)
+
+	genesisSeed := common.BytesToHash([]byte("genesis"))
+	vrf1 := consensus.NewVRFValidatorManager(validators, genesisSeed)
+	vrf2 := consensus.NewVRFValidatorManager(validators, genesisSeed)
+	privKeys := map[common.Address]*crypto.PrivateKey{va1.Address: va1.PrivKey, va2.Address: va2.PrivKey}
+	for epoch := uint64(11); epoch <= 13; epoch++ {
+		reveal, err := core.NewProposerReveal(epoch, privKeys[vrf1.GetProposerForEpoch(epoch).ID()], vrf1.VRFInput(epoch))
+		require.Nil(err)
+		require.Nil(vrf1.Reveal(reveal))
+	}
+	vrf2.Jail(va2.Address, 12, 20)
+
+	processBlock := func(valMgr core.ValidatorManager) *st.StoreView {
+		blockView, err := view.Copy()
+		require.Nil(err)
+		tx := &types.CoinbaseTx{
+			Proposer:    types.TxInput{Address: va1.Address},
+			BlockHeight: et.state().Height(),
+		}
+		tx.SetEpoch(15)
+		_, res := NewCoinbaseTxExecutor(et.state(), valMgr).process(et.chainID, blockView, tx)
+		require.True(res.IsOK(), res.Message)
+		return blockView
+	}
+
+	rotatingView1 := processBlock(rotating1)
+	rotatingView2 := processBlock(rotating2)
+	assert.Equal(rotatingView1.Hash(), rotatingView2.Hash())
+	assert.Equal([]uint64{14}, rotatingView1.GetValidatorDowntime(va2.Address).MissedEpochs)
+	assert.Nil(rotatingView1.GetValidatorDowntime(va1.Address))
+
+	// The missed proposals are not tracked with the VRF reveals
+	vrfView1 := processBlock(vrf1)
+	vrfView2 := processBlock(vrf2)
+	assert.Equal(vrfView1.Hash(), vrfView2.Hash())
+	assert.Equal(0, len(vrfView1.GetValidatorDowntimes()))
+	epoch, _ := vrfView1.GetLastBlockEpoch()
+	assert.Equal(uint64(15), epoch)
+}
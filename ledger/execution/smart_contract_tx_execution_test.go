@@ -361,9 +361,21 @@ func executeSmartContract(et *execTest, contractAddr common.Address, callerPrivA
 	// Execute the on-chain smart contract
 	res := et.executor.getTxExecutor(execSCTX).sanityCheck(et.chainID, et.state().Delivered(), execSCTX)
 	assert.True(res.IsOK(), res.Message)
+	et.state().Delivered().GetAndClearChargedFee()
 	_, res = et.executor.getTxExecutor(execSCTX).process(et.chainID, et.state().Delivered(), execSCTX)
 	assert.True(res.IsOK(), res.Message)
 
+	// Only the fee of the gas used is charged, the rest of the fee limit is refunded
+	receipt := et.state().Delivered().GetAndClearTxReceipt()
+	assert.NotNil(receipt)
+	assert.Equal(gasLimit, receipt.GasLimit)
+	assert.Equal(gasUsed, receipt.GasUsed)
+	assert.Equal(contractAddr, receipt.ContractAddress)
+	assert.True(types.NewCoins(0, int64(gasUsed)*int64(gasPrice)).IsEqual(receipt.Fee))
+	assert.True(types.NewCoins(0, int64(gasLimit-gasUsed)*int64(gasPrice)).IsEqual(receipt.Refund))
+	assert.True(receipt.Fee.IsEqual(et.state().Delivered().GetAndClearChargedFee()))
+	assert.Nil(et.state().Delivered().GetAndClearTxReceipt())
+
 	et.state().Commit()
 
 	// Check the smart contract execution gas fee
@@ -27,6 +27,7 @@ type TestConsensusEngine struct {
 
 func (tce *TestConsensusEngine) ID() string                        { return tce.privKey.PublicKey().Address().Hex() }
 func (tce *TestConsensusEngine) PrivateKey() *crypto.PrivateKey    { return tce.privKey }
+func (tce *TestConsensusEngine) Signer() core.Signer               { return core.NewPrivateKeySigner(tce.privKey) }
 func (tce *TestConsensusEngine) GetTip() *core.ExtendedBlock       { return nil }
 func (tce *TestConsensusEngine) GetEpoch() uint64                  { return 100 }
 func (tce *TestConsensusEngine) AddMessage(msg interface{})        {}
@@ -43,6 +44,9 @@ type TestValidatorManager struct {
 }
 
 func (tvm *TestValidatorManager) GetProposerForEpoch(epoch uint64) core.Validator { return tvm.proposer }
+func (tvm *TestValidatorManager) SelectProposer(epoch uint64, isJailed func(common.Address, uint64) bool) core.Validator {
+	return tvm.proposer
+}
 func (tvm *TestValidatorManager) GetValidatorSetForEpoch(epoch uint64) *core.ValidatorSet {
 	return tvm.valSet
 }
@@ -150,6 +154,14 @@ func (et *execTest) acc2State(accs ...types.PrivAccount) {
 	et.executor.state.Commit()
 }
 
+// setChainParameters puts the chain parameters in effect, as if a parameter update
+// were activated at the current height.
+func (et *execTest) setChainParameters(params types.ChainParameters) {
+	view := et.executor.state.Delivered()
+	view.ScheduleParameterUpdate(&types.ParameterUpdate{Height: view.Height(), Parameters: params})
+	view.ActivateParameterUpdates(view.Height())
+}
+
 // Executor returns the executor instance.
 func (et *execTest) Executor() *Executor {
 	return et.executor
@@ -0,0 +1,156 @@
+package execution
+
+import (
+	"fmt"
+	"math/big"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/common/result"
+	"github.com/thetatoken/ukulele/core"
+	st "github.com/thetatoken/ukulele/ledger/state"
+	"github.com/thetatoken/ukulele/ledger/types"
+)
+
+var _ TxExecutor = (*BatchServicePaymentTxExecutor)(nil)
+
+// ------------------------------- BatchServicePayment Transaction -----------------------------------
+
+// BatchServicePaymentTxExecutor implements the TxExecutor interface
+type BatchServicePaymentTxExecutor struct {
+	state       *st.LedgerState
+	paymentExec *ServicePaymentTxExecutor
+}
+
+// NewBatchServicePaymentTxExecutor creates a new instance of BatchServicePaymentTxExecutor
+func NewBatchServicePaymentTxExecutor(state *st.LedgerState) *BatchServicePaymentTxExecutor {
+	return &BatchServicePaymentTxExecutor{
+		state:       state,
+		paymentExec: NewServicePaymentTxExecutor(state),
+	}
+}
+
+func (exec *BatchServicePaymentTxExecutor) sanityCheck(chainID string, view *st.StoreView, transaction types.Tx) result.Result {
+	tx := transaction.(*types.BatchServicePaymentTx)
+
+	res := tx.Target.ValidateBasic()
+	if res.IsError() {
+		return res
+	}
+
+	targetAddress := tx.Target.Address
+
+	// Get the target account (that signed and broadcasted this transaction)
+	targetAccount, res := getOrMakeInput(view, tx.Target)
+	if res.IsError() {
+		return res
+	}
+
+	// Verify target
+	if targetAccount.Sequence+1 != tx.Target.Sequence {
+		return result.Error("Got %v, expected %v. (acc.seq=%v)",
+			tx.Target.Sequence, targetAccount.Sequence+1, targetAccount.Sequence).
+			WithErrorCode(result.CodeInvalidSequence)
+	}
+
+	signBytes := tx.SignBytes(chainID)
+	if !tx.Target.Signature.Verify(signBytes, targetAccount.SignerAddress()) {
+		errMsg := fmt.Sprintf("sanityCheckForBatchServicePaymentTx failed on target signature, addr: %v", targetAddress.Hex())
+		log.Infof(errMsg)
+		return result.Error(errMsg).WithErrorCode(result.CodeInvalidSignature)
+	}
+
+	if !sanityCheckForFee(view, tx.Fee) {
+		return result.Error("Insufficient fee. Transaction fee needs to be at least %v GammaWei",
+			view.GetChainParameters().MinimumTransactionFeeGammaWei).WithErrorCode(result.CodeInvalidFee)
+	}
+
+	if len(tx.Payments) == 0 {
+		return result.Error("No payment to settle").WithErrorCode(result.CodeInvalidBatchPayment)
+	}
+
+	numAccountsAffected := len(tx.Payments) + 1
+	if numAccountsAffected > types.MaxAccountsAffectedPerTx {
+		return result.Error("This allows one trasaction to modify many accounts. At most %v accounts are allowed per transaction.",
+			types.MaxAccountsAffectedPerTx).WithErrorCode(result.CodeTooManyAccounts)
+	}
+
+	// The payments are checked against the current state, so a source can only be settled
+	// once per batch
+	sources := map[common.Address]bool{}
+	for idx := range tx.Payments {
+		payment := &tx.Payments[idx]
+		sourceAddress := payment.Source.Address
+		if payment.Target.Address != targetAddress {
+			return result.Error("Payment %v is to %v instead of the target", idx, payment.Target.Address.Hex()).
+				WithErrorCode(result.CodeInvalidBatchPayment)
+		}
+		if sourceAddress == targetAddress {
+			return result.Error("Payment %v is from the target", idx).WithErrorCode(result.CodeInvalidBatchPayment)
+		}
+		if sources[sourceAddress] {
+			return result.Error("Duplicated payment from %v", sourceAddress.Hex()).WithErrorCode(result.CodeInvalidBatchPayment)
+		}
+		sources[sourceAddress] = true
+
+		res = exec.paymentExec.checkPayment(chainID, view, payment, targetAccount)
+		if res.IsError() {
+			return res.WithMessage(fmt.Sprintf(" (payment %v)", idx))
+		}
+	}
+
+	return result.OK
+}
+
+func (exec *BatchServicePaymentTxExecutor) process(chainID string, view *st.StoreView, transaction types.Tx) (common.Hash, result.Result) {
+	tx := transaction.(*types.BatchServicePaymentTx)
+
+	targetAddress := tx.Target.Address
+	targetAccount, res := getOrMakeInput(view, tx.Target)
+	if res.IsError() {
+		return common.Hash{}, res
+	}
+
+	accounts := map[common.Address]*types.Account{targetAddress: targetAccount}
+	for idx := range tx.Payments {
+		res = exec.paymentExec.transferPayment(view, &tx.Payments[idx], accounts)
+		if res.IsError() {
+			return common.Hash{}, res.WithMessage(fmt.Sprintf(" (payment %v)", idx))
+		}
+	}
+
+	if !chargeFee(view, targetAccount, tx.Fee) {
+		return common.Hash{}, result.Error("failed to charge transaction fee").
+			WithErrorCode(result.CodeInsufficientFund)
+	}
+	targetAccount.Sequence++ // targetAccount broadcasted the transaction
+
+	for address, account := range accounts {
+		view.SetAccount(address, account)
+	}
+
+	txHash := types.TxID(chainID, tx)
+	return txHash, result.OK
+}
+
+func (exec *BatchServicePaymentTxExecutor) getTxInfo(transaction types.Tx) *core.TxInfo {
+	tx := transaction.(*types.BatchServicePaymentTx)
+	return &core.TxInfo{
+		Address:           tx.Target.Address,
+		Sequence:          tx.Target.Sequence,
+		EffectiveGasPrice: exec.calculateEffectiveGasPrice(transaction),
+	}
+}
+
+func (exec *BatchServicePaymentTxExecutor) calculateEffectiveGasPrice(transaction types.Tx) *big.Int {
+	tx := transaction.(*types.BatchServicePaymentTx)
+	fee := tx.Fee
+	numPayments := uint64(len(tx.Payments))
+	if numPayments == 0 {
+		numPayments = 1
+	}
+	gas := new(big.Int).SetUint64(types.GasBatchedPayment * numPayments)
+	effectiveGasPrice := new(big.Int).Div(fee.GammaWei, gas)
+	return effectiveGasPrice
+}
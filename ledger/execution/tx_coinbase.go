@@ -16,23 +16,22 @@ var _ TxExecutor = (*CoinbaseTxExecutor)(nil)
 
 // CoinbaseTxExecutor implements the TxExecutor interface
 type CoinbaseTxExecutor struct {
-	state     *st.LedgerState
-	consensus core.ConsensusEngine
-	valMgr    core.ValidatorManager
+	state  *st.LedgerState
+	valMgr core.ValidatorManager
 }
 
 // NewCoinbaseTxExecutor creates a new instance of CoinbaseTxExecutor
-func NewCoinbaseTxExecutor(state *st.LedgerState, consensus core.ConsensusEngine, valMgr core.ValidatorManager) *CoinbaseTxExecutor {
+func NewCoinbaseTxExecutor(state *st.LedgerState, valMgr core.ValidatorManager) *CoinbaseTxExecutor {
 	return &CoinbaseTxExecutor{
-		state:     state,
-		consensus: consensus,
-		valMgr:    valMgr,
+		state:  state,
+		valMgr: valMgr,
 	}
 }
 
 func (exec *CoinbaseTxExecutor) sanityCheck(chainID string, view *st.StoreView, transaction types.Tx) result.Result {
 	tx := transaction.(*types.CoinbaseTx)
-	validatorAddresses := getValidatorAddresses(exec.consensus, exec.valMgr)
+	validators := exec.valMgr.GetValidatorSetForEpoch(view.Epoch()).Validators()
+	validatorAddresses := getValidatorAddresses(exec.valMgr, view.Epoch())
 
 	// Validate proposer, basic
 	res := tx.Proposer.ValidateBasic()
@@ -42,7 +41,8 @@ func (exec *CoinbaseTxExecutor) sanityCheck(chainID string, view *st.StoreView,
 
 	// verify that at most one coinbase transaction is processed for each block
 	if view.CoinbaseTransactinProcessed() {
-		return result.Error("Another coinbase transaction has been processed for the current block")
+		return result.Error("Another coinbase transaction has been processed for the current block").
+			WithErrorCode(result.CodeInvalidCoinbase)
 	}
 
 	// verify the proposer is one of the validators
@@ -51,15 +51,11 @@ func (exec *CoinbaseTxExecutor) sanityCheck(chainID string, view *st.StoreView,
 		return res
 	}
 
-	proposerAccount, res := getInput(view, tx.Proposer)
-	if res.IsError() {
-		return res
-	}
-
-	// verify the proposer's signature
+	// verify the proposer's signature. The proposer may have no account yet, e.g. a
+	// validator of the genesis without balance, since every block needs a coinbase
 	signBytes := tx.SignBytes(chainID)
-	if !tx.Proposer.Signature.Verify(signBytes, proposerAccount.Address) {
-		return result.Error("SignBytes: %X", signBytes)
+	if !core.VerifyValidatorSignature(tx.Proposer.Signature, signBytes, tx.Proposer.Address) {
+		return result.Error("SignBytes: %X", signBytes).WithErrorCode(result.CodeInvalidSignature)
 	}
 
 	outputAccounts := map[string]*types.Account{}
@@ -70,19 +66,46 @@ func (exec *CoinbaseTxExecutor) sanityCheck(chainID string, view *st.StoreView,
 
 	if tx.BlockHeight != exec.state.Height() {
 		return result.Error("invalid block height for the coinbase transaction, tx_block_height = %v, state_height = %v",
-			tx.BlockHeight, exec.state.Height())
+			tx.BlockHeight, exec.state.Height()).WithErrorCode(result.CodeInvalidCoinbase)
+	}
+
+	// The epoch is the epoch of the block, which follows the epoch of the previous block,
+	// and the proposer is the proposer of the epoch, so that the missed proposals are not
+	// misattributed. The epoch is required from the first block that set it on, i.e. once
+	// the epoch of the last block is recorded, so that the proposers cannot stop the
+	// tracking of the missed proposals by leaving it out.
+	epoch, ok := tx.GetEpoch()
+	lastEpoch, tracked := view.GetLastBlockEpoch()
+	if !ok && tracked {
+		return result.Error("The coinbase transaction does not set the epoch of the block").
+			WithErrorCode(result.CodeInvalidCoinbase)
+	}
+	if ok {
+		if epoch != view.Epoch() {
+			return result.Error("invalid epoch for the coinbase transaction, tx_epoch = %v, block_epoch = %v",
+				epoch, view.Epoch()).WithErrorCode(result.CodeInvalidCoinbase)
+		}
+		if tracked && epoch <= lastEpoch {
+			return result.Error("invalid epoch for the coinbase transaction, tx_epoch = %v, last_block_epoch = %v",
+				epoch, lastEpoch).WithErrorCode(result.CodeInvalidCoinbase)
+		}
+		if exec.valMgr.GetProposerForEpoch(epoch).Address() != tx.Proposer.Address {
+			return result.Error("The coinbaseTx proposer is not the proposer of epoch %v", epoch).
+				WithErrorCode(result.CodeInvalidCoinbase)
+		}
 	}
 
-	// check the reward amount
-	expectedRewards := CalculateReward(view, validatorAddresses)
+	// check the reward amount against the reward policy of the chain parameters, rather than trusting the proposer
+	expectedRewards := CalculateReward(view, exec.state.Height(), tx.Proposer.Address, validators)
 	if len(expectedRewards) != len(tx.Outputs) {
-		return result.Error("Number of rewarded account is incorrect")
+		return result.Error("Number of rewarded account is incorrect").
+			WithErrorCode(result.CodeInvalidCoinbase)
 	}
 	for _, output := range tx.Outputs {
 		exp, ok := expectedRewards[string(output.Address[:])]
 		if !ok || !exp.IsEqual(output.Coins) {
 			return result.Error("Invalid rewards, address %v expecting %v, but is %v",
-				output.Address, exp, output.Coins)
+				output.Address, exp, output.Coins).WithErrorCode(result.CodeInvalidCoinbase)
 		}
 	}
 	return result.OK
@@ -92,7 +115,8 @@ func (exec *CoinbaseTxExecutor) process(chainID string, view *st.StoreView, tran
 	tx := transaction.(*types.CoinbaseTx)
 
 	if view.CoinbaseTransactinProcessed() {
-		return common.Hash{}, result.Error("Another coinbase transaction has been processed for the current block")
+		return common.Hash{}, result.Error("Another coinbase transaction has been processed for the current block").
+			WithErrorCode(result.CodeInvalidCoinbase)
 	}
 
 	accounts := map[string]*types.Account{}
@@ -109,25 +133,59 @@ func (exec *CoinbaseTxExecutor) process(chainID string, view *st.StoreView, tran
 		}
 	}
 
+	// The fee pool is distributed by the outputs
+	if !view.GetFeePool().IsZero() {
+		view.SetFeePool(types.NewCoins(0, 0))
+	}
+
+	// Return the withdrawn stakes whose locking period has ended
+	view.ReturnMaturedStakes(exec.state.Height())
+
+	// Apply the parameter updates scheduled up to the current block
+	view.ActivateParameterUpdates(exec.state.Height())
+
+	// Tally the proposals whose voting window has ended, and execute the passed ones
+	tallyEndedProposals(view, exec.state.Height(), view.Epoch())
+
+	// Record the proposals missed since the previous block, and jail the validators
+	// missing too many
+	if epoch, ok := tx.GetEpoch(); ok {
+		trackMissedProposals(view, exec.valMgr, epoch)
+	}
+
 	view.SetCoinbaseTransactionProcessed(true)
 
 	txHash := types.TxID(chainID, tx)
 	return txHash, result.OK
 }
 
-// CalculateReward calculates the block reward for each account
-func CalculateReward(view *st.StoreView, validatorAddresses []common.Address) map[string]types.Coins {
-	accountReward := map[string]types.Coins{}
-
-	for _, validatorAddress := range validatorAddresses {
-		// Initial Mainnet release should not reward the validators until the guardians ready to deploy
-		zeroReward := types.Coins{}.NoNil()
-		accountReward[string(validatorAddress[:])] = zeroReward
+// CalculateReward calculates the block reward for each account, i.e. the issuance of the
+// block at the given height and the distribution of the fee pool, according to the reward
+// policy of the chain parameters
+func CalculateReward(view *st.StoreView, height uint64, proposer common.Address, validators []core.Validator) map[string]types.Coins {
+	policy := view.GetChainParameters().RewardPolicy()
+	stakes := map[common.Address]uint64{}
+	for _, validator := range validators {
+		stakes[validator.Address()] = validator.Stake()
 	}
 
+	accountReward := map[string]types.Coins{}
+	for address, reward := range policy.Rewards(height, view.GetFeePool(), proposer, stakes) {
+		accountReward[string(address[:])] = reward
+	}
 	return accountReward
 }
 
+// collectFee adds the part of the fee charged by a transaction that is not burned to
+// the fee pool, which the coinbase transaction of the next block distributes
+func (exec *CoinbaseTxExecutor) collectFee(view *st.StoreView, fee types.Coins) {
+	collected := view.GetChainParameters().RewardPolicy().CollectedFee(fee)
+	if collected.IsZero() {
+		return
+	}
+	view.SetFeePool(view.GetFeePool().Plus(collected))
+}
+
 func (exec *CoinbaseTxExecutor) getTxInfo(transaction types.Tx) *core.TxInfo {
 	return &core.TxInfo{
 		EffectiveGasPrice: exec.calculateEffectiveGasPrice(transaction),
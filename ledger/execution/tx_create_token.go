@@ -0,0 +1,126 @@
+package execution
+
+import (
+	"fmt"
+	"math/big"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/common/result"
+	"github.com/thetatoken/ukulele/core"
+	st "github.com/thetatoken/ukulele/ledger/state"
+	"github.com/thetatoken/ukulele/ledger/types"
+)
+
+var _ TxExecutor = (*CreateTokenTxExecutor)(nil)
+
+// ------------------------------- CreateToken Transaction -----------------------------------
+
+// CreateTokenTxExecutor implements the TxExecutor interface
+type CreateTokenTxExecutor struct {
+	state *st.LedgerState
+}
+
+// NewCreateTokenTxExecutor creates a new instance of CreateTokenTxExecutor
+func NewCreateTokenTxExecutor(state *st.LedgerState) *CreateTokenTxExecutor {
+	return &CreateTokenTxExecutor{
+		state: state,
+	}
+}
+
+func (exec *CreateTokenTxExecutor) sanityCheck(chainID string, view *st.StoreView, transaction types.Tx) result.Result {
+	tx := transaction.(*types.CreateTokenTx)
+
+	// Validate issuer, basic
+	res := tx.Issuer.ValidateBasic()
+	if res.IsError() {
+		return res
+	}
+
+	// Get input account
+	issuerAccount, success := getInput(view, tx.Issuer)
+	if success.IsError() {
+		return result.Error("Failed to get the issuer account").WithErrorCode(result.CodeAccountNotFound)
+	}
+
+	// Validate input, advanced
+	signBytes := tx.SignBytes(chainID)
+	res = validateInputAdvanced(issuerAccount, signBytes, tx.Issuer)
+	if res.IsError() {
+		log.Infof(fmt.Sprintf("validateSourceAdvanced failed on %v: %v", tx.Issuer.Address.Hex(), res))
+		return res
+	}
+
+	if !sanityCheckForFee(view, tx.Fee) {
+		return result.Error("Insufficient fee. Transaction fee needs to be at least %v GammaWei",
+			view.GetChainParameters().MinimumTransactionFeeGammaWei).WithErrorCode(result.CodeInvalidFee)
+	}
+
+	if !issuerAccount.Balance.IsGTE(tx.Fee) {
+		log.Infof(fmt.Sprintf("Issuer did not have enough balance %v", tx.Issuer.Address.Hex()))
+		return result.Error("Issuer balance is %v, but required minimal balance is %v",
+			issuerAccount.Balance, tx.Fee).WithErrorCode(result.CodeInsufficientFund)
+	}
+
+	err := types.ValidateToken(tx.Symbol, tx.Decimals, tx.MaxSupply)
+	if err != nil {
+		return result.Error(err.Error()).WithErrorCode(result.CodeInvalidToken)
+	}
+
+	if view.GetToken(tx.Symbol) != nil {
+		return result.Error("Token %v already exists", tx.Symbol).WithErrorCode(result.CodeInvalidToken)
+	}
+
+	return result.OK
+}
+
+func (exec *CreateTokenTxExecutor) process(chainID string, view *st.StoreView, transaction types.Tx) (common.Hash, result.Result) {
+	tx := transaction.(*types.CreateTokenTx)
+
+	issuerAddress := tx.Issuer.Address
+	issuerAccount, success := getInput(view, tx.Issuer)
+	if success.IsError() {
+		return common.Hash{}, result.Error("Failed to get the issuer account").
+			WithErrorCode(result.CodeAccountNotFound)
+	}
+
+	if !chargeFee(view, issuerAccount, tx.Fee) {
+		return common.Hash{}, result.Error("failed to charge transaction fee").
+			WithErrorCode(result.CodeInsufficientFund)
+	}
+
+	token := &types.Token{
+		Symbol:    tx.Symbol,
+		Decimals:  tx.Decimals,
+		MaxSupply: tx.MaxSupply,
+		Issuer:    issuerAddress,
+	}
+	view.SetToken(token)
+	issuerAccount.Balance = issuerAccount.Balance.Plus(types.NewTokenCoins(tx.Symbol, tx.MaxSupply))
+
+	issuerAccount.Sequence++
+	view.SetAccount(issuerAddress, issuerAccount)
+
+	log.Infof("Created token %v, max supply: %v, issuer: %v", tx.Symbol, tx.MaxSupply, issuerAddress.Hex())
+
+	txHash := types.TxID(chainID, tx)
+	return txHash, result.OK
+}
+
+func (exec *CreateTokenTxExecutor) getTxInfo(transaction types.Tx) *core.TxInfo {
+	tx := transaction.(*types.CreateTokenTx)
+	return &core.TxInfo{
+		Address:           tx.Issuer.Address,
+		Sequence:          tx.Issuer.Sequence,
+		EffectiveGasPrice: exec.calculateEffectiveGasPrice(transaction),
+	}
+}
+
+func (exec *CreateTokenTxExecutor) calculateEffectiveGasPrice(transaction types.Tx) *big.Int {
+	tx := transaction.(*types.CreateTokenTx)
+	fee := tx.Fee
+	gas := new(big.Int).SetUint64(types.GasCreateTokenTx)
+	effectiveGasPrice := new(big.Int).Div(fee.GammaWei, gas)
+	return effectiveGasPrice
+}
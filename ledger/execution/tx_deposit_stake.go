@@ -0,0 +1,133 @@
+package execution
+
+import (
+	"fmt"
+	"math/big"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/common/result"
+	"github.com/thetatoken/ukulele/core"
+	st "github.com/thetatoken/ukulele/ledger/state"
+	"github.com/thetatoken/ukulele/ledger/types"
+)
+
+var _ TxExecutor = (*DepositStakeTxExecutor)(nil)
+
+// ------------------------------- DepositStake Transaction -----------------------------------
+
+// DepositStakeTxExecutor implements the TxExecutor interface
+type DepositStakeTxExecutor struct {
+	state *st.LedgerState
+}
+
+// NewDepositStakeTxExecutor creates a new instance of DepositStakeTxExecutor
+func NewDepositStakeTxExecutor(state *st.LedgerState) *DepositStakeTxExecutor {
+	return &DepositStakeTxExecutor{
+		state: state,
+	}
+}
+
+func (exec *DepositStakeTxExecutor) sanityCheck(chainID string, view *st.StoreView, transaction types.Tx) result.Result {
+	tx := transaction.(*types.DepositStakeTx)
+
+	// Validate source, basic
+	res := tx.Source.ValidateBasic()
+	if res.IsError() {
+		return res
+	}
+
+	// Get input account
+	sourceAccount, success := getInput(view, tx.Source)
+	if success.IsError() {
+		return result.Error("Failed to get the source account").WithErrorCode(result.CodeAccountNotFound)
+	}
+
+	// Validate input, advanced
+	signBytes := tx.SignBytes(chainID)
+	res = validateInputAdvanced(sourceAccount, signBytes, tx.Source)
+	if res.IsError() {
+		log.Infof(fmt.Sprintf("validateSourceAdvanced failed on %v: %v", tx.Source.Address.Hex(), res))
+		return res
+	}
+
+	if (tx.Holder.Address == common.Address{}) {
+		return result.Error("Stake holder address not specified").
+			WithErrorCode(result.CodeInvalidStakeHolder)
+	}
+
+	stake := tx.Source.Coins.NoNil()
+	if stake.GammaWei.Cmp(types.Zero) != 0 {
+		return result.Error("Only Theta can be deposited as stake").
+			WithErrorCode(result.CodeInvalidStake)
+	}
+
+	minimumStake := new(big.Int).SetUint64(types.MinimumStakeDepositThetaWei)
+	if stake.ThetaWei.Cmp(minimumStake) < 0 {
+		return result.Error("Insufficient stake. Stake deposit needs to be at least %v ThetaWei",
+			types.MinimumStakeDepositThetaWei).WithErrorCode(result.CodeInvalidStake)
+	}
+
+	if !sanityCheckForFee(view, tx.Fee) {
+		return result.Error("Insufficient fee. Transaction fee needs to be at least %v GammaWei",
+			view.GetChainParameters().MinimumTransactionFeeGammaWei).WithErrorCode(result.CodeInvalidFee)
+	}
+
+	minimalBalance := stake.Plus(tx.Fee)
+	if !sourceAccount.Balance.IsGTE(minimalBalance) {
+		log.Infof(fmt.Sprintf("Source did not have enough balance %v", tx.Source.Address.Hex()))
+		return result.Error("Source balance is %v, but required minimal balance is %v",
+			sourceAccount.Balance, minimalBalance).WithErrorCode(result.CodeInsufficientFund)
+	}
+
+	return result.OK
+}
+
+func (exec *DepositStakeTxExecutor) process(chainID string, view *st.StoreView, transaction types.Tx) (common.Hash, result.Result) {
+	tx := transaction.(*types.DepositStakeTx)
+
+	sourceAddress := tx.Source.Address
+	sourceAccount, success := getInput(view, tx.Source)
+	if success.IsError() {
+		return common.Hash{}, result.Error("Failed to get the source account").
+			WithErrorCode(result.CodeAccountNotFound)
+	}
+
+	stake := tx.Source.Coins.NoNil()
+	if !chargeFee(view, sourceAccount, tx.Fee) {
+		return common.Hash{}, result.Error("failed to charge transaction fee").
+			WithErrorCode(result.CodeInsufficientFund)
+	}
+	sourceAccount.Balance = sourceAccount.Balance.Minus(stake)
+
+	stakeHolder := view.GetStakeHolder(tx.Holder.Address)
+	if stakeHolder == nil {
+		stakeHolder = types.NewStakeHolder(tx.Holder.Address)
+	}
+	stakeHolder.DepositStake(sourceAddress, stake.ThetaWei)
+	view.SetStakeHolder(stakeHolder)
+
+	sourceAccount.Sequence++
+	view.SetAccount(sourceAddress, sourceAccount)
+
+	txHash := types.TxID(chainID, tx)
+	return txHash, result.OK
+}
+
+func (exec *DepositStakeTxExecutor) getTxInfo(transaction types.Tx) *core.TxInfo {
+	tx := transaction.(*types.DepositStakeTx)
+	return &core.TxInfo{
+		Address:           tx.Source.Address,
+		Sequence:          tx.Source.Sequence,
+		EffectiveGasPrice: exec.calculateEffectiveGasPrice(transaction),
+	}
+}
+
+func (exec *DepositStakeTxExecutor) calculateEffectiveGasPrice(transaction types.Tx) *big.Int {
+	tx := transaction.(*types.DepositStakeTx)
+	fee := tx.Fee
+	gas := new(big.Int).SetUint64(types.GasDepositStakeTx)
+	effectiveGasPrice := new(big.Int).Div(fee.GammaWei, gas)
+	return effectiveGasPrice
+}
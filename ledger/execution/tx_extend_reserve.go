@@ -0,0 +1,129 @@
+package execution
+
+import (
+	"fmt"
+	"math/big"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/common/result"
+	"github.com/thetatoken/ukulele/core"
+	st "github.com/thetatoken/ukulele/ledger/state"
+	"github.com/thetatoken/ukulele/ledger/types"
+)
+
+var _ TxExecutor = (*ExtendReserveTxExecutor)(nil)
+
+// ------------------------------- ExtendReserveTx Transaction -----------------------------------
+
+// ExtendReserveTxExecutor implements the TxExecutor interface
+type ExtendReserveTxExecutor struct {
+	state *st.LedgerState
+}
+
+// NewExtendReserveTxExecutor creates a new instance of ExtendReserveTxExecutor
+func NewExtendReserveTxExecutor(state *st.LedgerState) *ExtendReserveTxExecutor {
+	return &ExtendReserveTxExecutor{
+		state: state,
+	}
+}
+
+func (exec *ExtendReserveTxExecutor) sanityCheck(chainID string, view *st.StoreView, transaction types.Tx) result.Result {
+	tx := transaction.(*types.ExtendReserveTx)
+
+	// Validate source, basic
+	res := tx.Source.ValidateBasic()
+	if res.IsError() {
+		return res
+	}
+
+	// Get input account
+	sourceAccount, success := getInput(view, tx.Source)
+	if success.IsError() {
+		return result.Error("Failed to get the source account").WithErrorCode(result.CodeAccountNotFound)
+	}
+
+	// Validate input, advanced
+	signBytes := tx.SignBytes(chainID)
+	res = validateInputAdvanced(sourceAccount, signBytes, tx.Source)
+	if res.IsError() {
+		log.Infof(fmt.Sprintf("validateSourceAdvanced failed on %v: %v", tx.Source.Address.Hex(), res))
+		return res
+	}
+
+	fund := tx.Source.Coins.NoNil()
+	collateral := tx.Collateral.NoNil()
+
+	if fund.ThetaWei.Cmp(types.Zero) != 0 {
+		return result.Error("Cannot reserve Theta as service fund!").
+			WithErrorCode(result.CodeInvalidFundToReserve)
+	}
+
+	if !sanityCheckForFee(view, tx.Fee) {
+		return result.Error("Insufficient fee. Transaction fee needs to be at least %v GammaWei",
+			view.GetChainParameters().MinimumTransactionFeeGammaWei).WithErrorCode(result.CodeInvalidFee)
+	}
+
+	minimalBalance := fund.Plus(collateral).Plus(tx.Fee)
+	if !sourceAccount.Balance.IsGTE(minimalBalance) {
+		log.Infof(fmt.Sprintf("Source did not have enough balance %v", tx.Source.Address.Hex()))
+		return result.Error("Source balance is %v, but required minimal balance is %v",
+			sourceAccount.Balance, minimalBalance).WithErrorCode(result.CodeInsufficientFund)
+	}
+
+	currentBlockHeight := exec.state.Height()
+	err := sourceAccount.CheckExtendReserve(collateral, fund, tx.Duration, currentBlockHeight, tx.ReserveSequence)
+	if err != nil {
+		return reservedFundCheckFailed(err, result.CodeExtendReserveCheckFailed)
+	}
+	err = view.GetChainParameters().ReservePolicy().CheckExtend(sourceAccount, tx.Duration, currentBlockHeight)
+	if err != nil {
+		return reservedFundCheckFailed(err, result.CodeExtendReserveCheckFailed)
+	}
+
+	return result.OK
+}
+
+func (exec *ExtendReserveTxExecutor) process(chainID string, view *st.StoreView, transaction types.Tx) (common.Hash, result.Result) {
+	tx := transaction.(*types.ExtendReserveTx)
+
+	sourceAddress := tx.Source.Address
+	sourceAccount, success := getInput(view, tx.Source)
+	if success.IsError() {
+		return common.Hash{}, result.Error("Failed to get the source account").
+			WithErrorCode(result.CodeAccountNotFound)
+	}
+
+	fund := tx.Source.Coins.NoNil()
+	collateral := tx.Collateral.NoNil()
+
+	sourceAccount.ExtendReserve(collateral, fund, tx.Duration, tx.ReserveSequence)
+	if !chargeFee(view, sourceAccount, tx.Fee) {
+		return common.Hash{}, result.Error("failed to charge transaction fee").
+			WithErrorCode(result.CodeInsufficientFund)
+	}
+
+	sourceAccount.Sequence++
+	view.SetAccount(sourceAddress, sourceAccount)
+
+	txHash := types.TxID(chainID, tx)
+	return txHash, result.OK
+}
+
+func (exec *ExtendReserveTxExecutor) getTxInfo(transaction types.Tx) *core.TxInfo {
+	tx := transaction.(*types.ExtendReserveTx)
+	return &core.TxInfo{
+		Address:           tx.Source.Address,
+		Sequence:          tx.Source.Sequence,
+		EffectiveGasPrice: exec.calculateEffectiveGasPrice(transaction),
+	}
+}
+
+func (exec *ExtendReserveTxExecutor) calculateEffectiveGasPrice(transaction types.Tx) *big.Int {
+	tx := transaction.(*types.ExtendReserveTx)
+	fee := tx.Fee
+	gas := new(big.Int).SetUint64(types.GasExtendReserveTx)
+	effectiveGasPrice := new(big.Int).Div(fee.GammaWei, gas)
+	return effectiveGasPrice
+}
@@ -0,0 +1,157 @@
+package execution
+
+import (
+	"fmt"
+	"math/big"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/common/result"
+	"github.com/thetatoken/ukulele/core"
+	st "github.com/thetatoken/ukulele/ledger/state"
+	"github.com/thetatoken/ukulele/ledger/types"
+)
+
+var _ TxExecutor = (*ParameterUpdateTxExecutor)(nil)
+
+// ------------------------------- ParameterUpdate Transaction -----------------------------------
+
+// ParameterUpdateTxExecutor implements the TxExecutor interface
+type ParameterUpdateTxExecutor struct {
+	valMgr core.ValidatorManager
+}
+
+// NewParameterUpdateTxExecutor creates a new instance of ParameterUpdateTxExecutor
+func NewParameterUpdateTxExecutor(valMgr core.ValidatorManager) *ParameterUpdateTxExecutor {
+	return &ParameterUpdateTxExecutor{
+		valMgr: valMgr,
+	}
+}
+
+func (exec *ParameterUpdateTxExecutor) sanityCheck(chainID string, view *st.StoreView, transaction types.Tx) result.Result {
+	tx := transaction.(*types.ParameterUpdateTx)
+
+	// Validate proposer, basic
+	res := tx.Proposer.ValidateBasic()
+	if res.IsError() {
+		return res
+	}
+
+	// Get input account
+	proposerAccount, success := getInput(view, tx.Proposer)
+	if success.IsError() {
+		return result.Error("Failed to get the proposer account").WithErrorCode(result.CodeAccountNotFound)
+	}
+
+	// Validate input, advanced
+	signBytes := tx.SignBytes(chainID)
+	res = validateInputAdvanced(proposerAccount, signBytes, tx.Proposer)
+	if res.IsError() {
+		log.Infof(fmt.Sprintf("validateSourceAdvanced failed on %v: %v", tx.Proposer.Address.Hex(), res))
+		return res
+	}
+
+	if !sanityCheckForFee(view, tx.Fee) {
+		return result.Error("Insufficient fee. Transaction fee needs to be at least %v GammaWei",
+			view.GetChainParameters().MinimumTransactionFeeGammaWei).WithErrorCode(result.CodeInvalidFee)
+	}
+
+	if !proposerAccount.Balance.IsGTE(tx.Fee) {
+		log.Infof(fmt.Sprintf("Proposer did not have enough balance %v", tx.Proposer.Address.Hex()))
+		return result.Error("Proposer balance is %v, but required minimal balance is %v",
+			proposerAccount.Balance, tx.Fee).WithErrorCode(result.CodeInsufficientFund)
+	}
+
+	if tx.Height <= view.Height() {
+		return result.Error("The parameter update height %v needs to be above the current height %v",
+			tx.Height, view.Height()).WithErrorCode(result.CodeInvalidParameters)
+	}
+	if err := tx.Parameters.Validate(); err != nil {
+		return result.Error(err.Error()).WithErrorCode(result.CodeInvalidParameters)
+	}
+
+	// The proposer and the approvers need to hold a supermajority of the stake of the
+	// current validators
+	validatorSet := exec.valMgr.GetValidatorSetForEpoch(view.Epoch())
+	approved := make(map[common.Address]bool)
+	approvedStake := uint64(0)
+	for i, signer := range append([]types.TxInput{tx.Proposer}, tx.Approvers...) {
+		validator, err := validatorSet.GetValidator(signer.Address)
+		if err != nil {
+			return result.Error("%v is not a validator", signer.Address.Hex()).
+				WithErrorCode(result.CodeParameterUpdateNotAuthorized)
+		}
+		if approved[signer.Address] {
+			return result.Error("Duplicated approver %v", signer.Address.Hex()).
+				WithErrorCode(result.CodeParameterUpdateNotAuthorized)
+		}
+		if i > 0 { // the signature of the proposer is already verified
+			signerAccount, _ := getAccount(view, signer.Address) // nil if the approver has no account yet
+			if !verifyInputSignature(signerAccount, signBytes, signer) {
+				return result.Error("Signature verification failed for approver %v", signer.Address.Hex()).
+					WithErrorCode(result.CodeInvalidSignature)
+			}
+		}
+		approved[signer.Address] = true
+		approvedStake += validator.Stake()
+	}
+	quorum := validatorSet.TotalStake()*2/3 + 1
+	if approvedStake < quorum {
+		return result.Error("The parameter update needs the approval of %v stake, got %v",
+			quorum, approvedStake).WithErrorCode(result.CodeParameterUpdateNotAuthorized)
+	}
+
+	return result.OK
+}
+
+func (exec *ParameterUpdateTxExecutor) process(chainID string, view *st.StoreView, transaction types.Tx) (common.Hash, result.Result) {
+	tx := transaction.(*types.ParameterUpdateTx)
+
+	proposerAddress := tx.Proposer.Address
+	proposerAccount, success := getInput(view, tx.Proposer)
+	if success.IsError() {
+		return common.Hash{}, result.Error("Failed to get the proposer account").
+			WithErrorCode(result.CodeAccountNotFound)
+	}
+
+	if !chargeFee(view, proposerAccount, tx.Fee) {
+		return common.Hash{}, result.Error("failed to charge transaction fee").
+			WithErrorCode(result.CodeInsufficientFund)
+	}
+
+	// The coinbase transaction of the block at the height activates the update, see
+	// StoreView.ActivateParameterUpdates
+	view.ScheduleParameterUpdate(&types.ParameterUpdate{
+		Height:     tx.Height,
+		Parameters: tx.Parameters,
+	})
+
+	proposerAccount.Sequence++
+	view.SetAccount(proposerAddress, proposerAccount)
+
+	log.WithFields(log.Fields{
+		"height":     tx.Height,
+		"parameters": tx.Parameters,
+	}).Info("Parameter update scheduled")
+
+	txHash := types.TxID(chainID, tx)
+	return txHash, result.OK
+}
+
+func (exec *ParameterUpdateTxExecutor) getTxInfo(transaction types.Tx) *core.TxInfo {
+	tx := transaction.(*types.ParameterUpdateTx)
+	return &core.TxInfo{
+		Address:           tx.Proposer.Address,
+		Sequence:          tx.Proposer.Sequence,
+		EffectiveGasPrice: exec.calculateEffectiveGasPrice(transaction),
+	}
+}
+
+func (exec *ParameterUpdateTxExecutor) calculateEffectiveGasPrice(transaction types.Tx) *big.Int {
+	tx := transaction.(*types.ParameterUpdateTx)
+	fee := tx.Fee
+	gas := new(big.Int).SetUint64(types.GasParameterUpdateTx)
+	effectiveGasPrice := new(big.Int).Div(fee.GammaWei, gas)
+	return effectiveGasPrice
+}
@@ -0,0 +1,181 @@
+package execution
+
+import (
+	"fmt"
+	"math/big"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/common/result"
+	"github.com/thetatoken/ukulele/core"
+	st "github.com/thetatoken/ukulele/ledger/state"
+	"github.com/thetatoken/ukulele/ledger/types"
+)
+
+var _ TxExecutor = (*ProposalTxExecutor)(nil)
+
+// ------------------------------- Proposal Transaction -----------------------------------
+
+// ProposalTxExecutor implements the TxExecutor interface
+type ProposalTxExecutor struct {
+}
+
+// NewProposalTxExecutor creates a new instance of ProposalTxExecutor
+func NewProposalTxExecutor() *ProposalTxExecutor {
+	return &ProposalTxExecutor{}
+}
+
+func (exec *ProposalTxExecutor) sanityCheck(chainID string, view *st.StoreView, transaction types.Tx) result.Result {
+	tx := transaction.(*types.ProposalTx)
+
+	// Validate proposer, basic
+	res := tx.Proposer.ValidateBasic()
+	if res.IsError() {
+		return res
+	}
+
+	// Get input account
+	proposerAccount, success := getInput(view, tx.Proposer)
+	if success.IsError() {
+		return result.Error("Failed to get the proposer account").WithErrorCode(result.CodeAccountNotFound)
+	}
+
+	// Validate input, advanced
+	signBytes := tx.SignBytes(chainID)
+	res = validateInputAdvanced(proposerAccount, signBytes, tx.Proposer)
+	if res.IsError() {
+		log.Infof(fmt.Sprintf("validateSourceAdvanced failed on %v: %v", tx.Proposer.Address.Hex(), res))
+		return res
+	}
+
+	if !sanityCheckForFee(view, tx.Fee) {
+		return result.Error("Insufficient fee. Transaction fee needs to be at least %v GammaWei",
+			view.GetChainParameters().MinimumTransactionFeeGammaWei).WithErrorCode(result.CodeInvalidFee)
+	}
+
+	if !proposerAccount.Balance.IsGTE(tx.Fee) {
+		log.Infof(fmt.Sprintf("Proposer did not have enough balance %v", tx.Proposer.Address.Hex()))
+		return result.Error("Proposer balance is %v, but required minimal balance is %v",
+			proposerAccount.Balance, tx.Fee).WithErrorCode(result.CodeInsufficientFund)
+	}
+
+	if tx.VotingPeriod < types.MinimumProposalVotingPeriod || tx.VotingPeriod > types.MaximumProposalVotingPeriod {
+		return result.Error("The voting period needs to be %v to %v blocks",
+			types.MinimumProposalVotingPeriod, types.MaximumProposalVotingPeriod).WithErrorCode(result.CodeInvalidProposal)
+	}
+	if len(tx.Description) > types.MaxProposalDescriptionLength {
+		return result.Error("The description needs to be at most %v bytes",
+			types.MaxProposalDescriptionLength).WithErrorCode(result.CodeInvalidProposal)
+	}
+	if err := tx.Change.Validate(); err != nil {
+		return result.Error(err.Error()).WithErrorCode(result.CodeInvalidProposal)
+	}
+
+	// Only the stakers can submit proposals, which prevents spamming the voters
+	if view.GetVotingStake(tx.Proposer.Address) == nil {
+		return result.Error("%v has no stake", tx.Proposer.Address.Hex()).WithErrorCode(result.CodeNoVotingStake)
+	}
+
+	return result.OK
+}
+
+func (exec *ProposalTxExecutor) process(chainID string, view *st.StoreView, transaction types.Tx) (common.Hash, result.Result) {
+	tx := transaction.(*types.ProposalTx)
+
+	proposerAddress := tx.Proposer.Address
+	proposerAccount, success := getInput(view, tx.Proposer)
+	if success.IsError() {
+		return common.Hash{}, result.Error("Failed to get the proposer account").
+			WithErrorCode(result.CodeAccountNotFound)
+	}
+
+	if !chargeFee(view, proposerAccount, tx.Fee) {
+		return common.Hash{}, result.Error("failed to charge transaction fee").
+			WithErrorCode(result.CodeInsufficientFund)
+	}
+
+	startHeight := view.Height()
+	proposal := &types.Proposal{
+		ID:          view.NextProposalID(),
+		Proposer:    proposerAddress,
+		Change:      tx.Change,
+		Description: tx.Description,
+		StartHeight: startHeight,
+		EndHeight:   startHeight + tx.VotingPeriod,
+		Status:      types.ProposalActive,
+		Tally:       types.NewProposalTally(),
+	}
+	view.SetProposal(proposal)
+
+	proposerAccount.Sequence++
+	view.SetAccount(proposerAddress, proposerAccount)
+
+	log.WithFields(log.Fields{
+		"id":         proposal.ID,
+		"change":     proposal.Change,
+		"end_height": proposal.EndHeight,
+	}).Info("Proposal submitted")
+
+	txHash := types.TxID(chainID, tx)
+	return txHash, result.OK
+}
+
+func (exec *ProposalTxExecutor) getTxInfo(transaction types.Tx) *core.TxInfo {
+	tx := transaction.(*types.ProposalTx)
+	return &core.TxInfo{
+		Address:           tx.Proposer.Address,
+		Sequence:          tx.Proposer.Sequence,
+		EffectiveGasPrice: exec.calculateEffectiveGasPrice(transaction),
+	}
+}
+
+func (exec *ProposalTxExecutor) calculateEffectiveGasPrice(transaction types.Tx) *big.Int {
+	tx := transaction.(*types.ProposalTx)
+	fee := tx.Fee
+	gas := new(big.Int).SetUint64(types.GasProposalTx)
+	effectiveGasPrice := new(big.Int).Div(fee.GammaWei, gas)
+	return effectiveGasPrice
+}
+
+// tallyEndedProposals tallies the active proposals whose voting window has ended
+// before the current block, and executes the passed ones: a parameter change takes
+// effect ProposalExecutionDelay blocks later, and a jailed validator is not selected
+// as proposer from the epoch of the current block on. It is called by the coinbase
+// transaction, so that all the nodes tally the proposals at the same height and epoch.
+func tallyEndedProposals(view *st.StoreView, currentBlockHeight uint64, epoch uint64) {
+	for _, proposal := range view.GetProposals() {
+		if proposal.Status != types.ProposalActive || proposal.EndHeight >= currentBlockHeight {
+			continue
+		}
+
+		proposal.Tally = view.TallyProposal(proposal.ID)
+		if proposal.Tally.Passes() {
+			proposal.Status = types.ProposalPassed
+			executeProposal(view, proposal, currentBlockHeight, epoch)
+		} else {
+			proposal.Status = types.ProposalRejected
+		}
+		view.SetProposal(proposal)
+		view.DeleteProposalVotes(proposal.ID)
+
+		log.WithFields(log.Fields{
+			"id":     proposal.ID,
+			"status": proposal.Status,
+			"tally":  proposal.Tally,
+		}).Info("Proposal tallied")
+	}
+}
+
+func executeProposal(view *st.StoreView, proposal *types.Proposal, currentBlockHeight uint64, epoch uint64) {
+	change := proposal.Change
+	switch change.Kind {
+	case types.ProposalParameterChange:
+		view.ScheduleParameterUpdate(&types.ParameterUpdate{
+			Height:     currentBlockHeight + types.ProposalExecutionDelay,
+			Parameters: change.Parameters,
+		})
+	case types.ProposalJailValidator:
+		view.JailValidator(change.Validator, epoch, epoch+change.JailDuration)
+	}
+}
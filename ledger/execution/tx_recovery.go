@@ -0,0 +1,151 @@
+package execution
+
+import (
+	"fmt"
+	"math/big"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/common/result"
+	"github.com/thetatoken/ukulele/core"
+	st "github.com/thetatoken/ukulele/ledger/state"
+	"github.com/thetatoken/ukulele/ledger/types"
+)
+
+var _ TxExecutor = (*RecoveryTxExecutor)(nil)
+
+// ------------------------------- RecoveryTx Transaction -----------------------------------
+
+// RecoveryTxExecutor implements the TxExecutor interface
+type RecoveryTxExecutor struct {
+	state *st.LedgerState
+}
+
+// NewRecoveryTxExecutor creates a new instance of RecoveryTxExecutor
+func NewRecoveryTxExecutor(state *st.LedgerState) *RecoveryTxExecutor {
+	return &RecoveryTxExecutor{
+		state: state,
+	}
+}
+
+func (exec *RecoveryTxExecutor) sanityCheck(chainID string, view *st.StoreView, transaction types.Tx) result.Result {
+	tx := transaction.(*types.RecoveryTx)
+
+	// Validate account, basic
+	res := tx.Account.ValidateBasic()
+	if res.IsError() {
+		return res
+	}
+
+	// Get input account, which does not sign the transaction
+	account, success := getInput(view, tx.Account)
+	if success.IsError() {
+		return result.Error("Failed to get the account").WithErrorCode(result.CodeAccountNotFound)
+	}
+
+	if account.Sequence+1 != tx.Account.Sequence {
+		return result.Error("Got %v, expected %v. (acc.seq=%v)",
+			tx.Account.Sequence, account.Sequence+1, account.Sequence).WithErrorCode(result.CodeInvalidSequence)
+	}
+
+	if !sanityCheckForFee(view, tx.Fee) {
+		return result.Error("Insufficient fee. Transaction fee needs to be at least %v GammaWei",
+			view.GetChainParameters().MinimumTransactionFeeGammaWei).WithErrorCode(result.CodeInvalidFee)
+	}
+
+	if !account.Balance.IsGTE(tx.Fee) {
+		log.Infof(fmt.Sprintf("Account did not have enough balance %v", tx.Account.Address.Hex()))
+		return result.Error("Account balance is %v, but required minimal balance is %v",
+			account.Balance, tx.Fee).WithErrorCode(result.CodeInsufficientFund)
+	}
+
+	guardianship := account.GetGuardianship()
+	if guardianship == nil || len(guardianship.Guardians) == 0 {
+		return result.Error("The account has no guardians").WithErrorCode(result.CodeRecoveryCheckFailed)
+	}
+	if guardianship.HasPendingRecovery() {
+		return result.Error("A recovery of the account is already pending until block height %v",
+			guardianship.RecoveryHeight).WithErrorCode(result.CodeRecoveryCheckFailed)
+	}
+	if tx.NewSigner == (common.Address{}) || tx.NewSigner == account.SignerAddress() {
+		return result.Error("Invalid new signer %v", tx.NewSigner.Hex()).WithErrorCode(result.CodeRecoveryCheckFailed)
+	}
+
+	// Verify the approvals of the guardians
+	signBytes := tx.SignBytes(chainID)
+	approved := make(map[common.Address]bool)
+	for _, guardian := range tx.Guardians {
+		if !guardianship.IsGuardian(guardian.Address) {
+			return result.Error("%v is not a guardian of the account", guardian.Address.Hex()).
+				WithErrorCode(result.CodeRecoveryCheckFailed)
+		}
+		if approved[guardian.Address] {
+			return result.Error("Duplicated guardian %v", guardian.Address.Hex()).
+				WithErrorCode(result.CodeRecoveryCheckFailed)
+		}
+		guardianAccount, _ := getAccount(view, guardian.Address) // nil if the guardian has no account yet
+		if !verifyInputSignature(guardianAccount, signBytes, guardian) {
+			return result.Error("Signature verification failed for guardian %v", guardian.Address.Hex()).
+				WithErrorCode(result.CodeInvalidSignature)
+		}
+		approved[guardian.Address] = true
+	}
+	if uint64(len(approved)) < guardianship.Threshold {
+		return result.Error("The recovery needs the approval of %v guardians, got %v",
+			guardianship.Threshold, len(approved)).WithErrorCode(result.CodeRecoveryCheckFailed)
+	}
+
+	return result.OK
+}
+
+func (exec *RecoveryTxExecutor) process(chainID string, view *st.StoreView, transaction types.Tx) (common.Hash, result.Result) {
+	tx := transaction.(*types.RecoveryTx)
+
+	accountAddress := tx.Account.Address
+	account, success := getInput(view, tx.Account)
+	if success.IsError() {
+		return common.Hash{}, result.Error("Failed to get the account").
+			WithErrorCode(result.CodeAccountNotFound)
+	}
+
+	// The key rotation takes effect once the timelock has passed, see Account.CompleteRecovery
+	guardianship := account.GetGuardianship()
+	guardianship.NewSigner = tx.NewSigner
+	guardianship.RecoveryHeight = view.Height() + guardianship.Timelock
+	account.SetGuardianship(guardianship)
+
+	if !chargeFee(view, account, tx.Fee) {
+		return common.Hash{}, result.Error("failed to charge transaction fee").
+			WithErrorCode(result.CodeInsufficientFund)
+	}
+
+	account.Sequence++
+	view.SetAccount(accountAddress, account)
+
+	log.WithFields(log.Fields{
+		"account":         accountAddress.Hex(),
+		"new_signer":      tx.NewSigner.Hex(),
+		"recovery_height": guardianship.RecoveryHeight,
+	}).Info("Account recovery requested")
+
+	txHash := types.TxID(chainID, tx)
+	return txHash, result.OK
+}
+
+func (exec *RecoveryTxExecutor) getTxInfo(transaction types.Tx) *core.TxInfo {
+	tx := transaction.(*types.RecoveryTx)
+	return &core.TxInfo{
+		Address:           tx.Account.Address,
+		Sequence:          tx.Account.Sequence,
+		EffectiveGasPrice: exec.calculateEffectiveGasPrice(transaction),
+	}
+}
+
+func (exec *RecoveryTxExecutor) calculateEffectiveGasPrice(transaction types.Tx) *big.Int {
+	tx := transaction.(*types.RecoveryTx)
+	fee := tx.Fee
+	gas := new(big.Int).SetUint64(types.GasRecoveryTx)
+	effectiveGasPrice := new(big.Int).Div(fee.GammaWei, gas)
+	return effectiveGasPrice
+}
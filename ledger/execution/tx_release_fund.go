@@ -41,7 +41,8 @@ func (exec *ReleaseFundTxExecutor) sanityCheck(chainID string, view *st.StoreVie
 	// Get input account
 	sourceAccount, success := getInput(view, tx.Source)
 	if success.IsError() {
-		return result.Error("Unknown address: %v", tx.Source.Address)
+		return result.Error("Unknown address: %v", tx.Source.Address).
+			WithErrorCode(result.CodeAccountNotFound)
 	}
 
 	// Validate input, advanced
@@ -52,9 +53,9 @@ func (exec *ReleaseFundTxExecutor) sanityCheck(chainID string, view *st.StoreVie
 		return res
 	}
 
-	if !sanityCheckForFee(tx.Fee) {
+	if !sanityCheckForFee(view, tx.Fee) {
 		return result.Error("Insufficient fee. Transaction fee needs to be at least %v GammaWei",
-			types.MinimumTransactionFeeGammaWei).WithErrorCode(result.CodeInvalidFee)
+			view.GetChainParameters().MinimumTransactionFeeGammaWei).WithErrorCode(result.CodeInvalidFee)
 	}
 
 	minimalBalance := tx.Fee
@@ -68,7 +69,7 @@ func (exec *ReleaseFundTxExecutor) sanityCheck(chainID string, view *st.StoreVie
 	reserveSequence := tx.ReserveSequence
 	err := sourceAccount.CheckReleaseFund(currentBlockHeight, reserveSequence)
 	if err != nil {
-		return result.Error(err.Error()).WithErrorCode(result.CodeReleaseFundCheckFailed)
+		return reservedFundCheckFailed(err, result.CodeReleaseFundCheckFailed)
 	}
 
 	return result.OK
@@ -81,7 +82,8 @@ func (exec *ReleaseFundTxExecutor) process(chainID string, view *st.StoreView, t
 	accounts, success := getInputs(view, sourceInputs)
 	if success.IsError() {
 		// TODO: revisit whether we should panic or just log the error.
-		return common.Hash{}, result.Error("Failed to get the source account")
+		return common.Hash{}, result.Error("Failed to get the source account").
+			WithErrorCode(result.CodeAccountNotFound)
 	}
 	sourceAddress := tx.Source.Address
 	sourceAccount := accounts[string(sourceAddress[:])]
@@ -90,8 +92,9 @@ func (exec *ReleaseFundTxExecutor) process(chainID string, view *st.StoreView, t
 
 	currentBlockHeight := exec.state.Height()
 	sourceAccount.ReleaseFund(currentBlockHeight, reserveSequence)
-	if !chargeFee(sourceAccount, tx.Fee) {
-		return common.Hash{}, result.Error("failed to charge transaction fee")
+	if !chargeFee(view, sourceAccount, tx.Fee) {
+		return common.Hash{}, result.Error("failed to charge transaction fee").
+			WithErrorCode(result.CodeInsufficientFund)
 	}
 
 	sourceAccount.Sequence++
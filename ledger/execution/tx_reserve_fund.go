@@ -41,7 +41,7 @@ func (exec *ReserveFundTxExecutor) sanityCheck(chainID string, view *st.StoreVie
 	// Get input account
 	sourceAccount, success := getInput(view, tx.Source)
 	if success.IsError() {
-		return result.Error("Failed to get the source account")
+		return result.Error("Failed to get the source account").WithErrorCode(result.CodeAccountNotFound)
 	}
 
 	// Validate input, advanced
@@ -64,9 +64,9 @@ func (exec *ReserveFundTxExecutor) sanityCheck(chainID string, view *st.StoreVie
 			WithErrorCode(result.CodeInvalidFundToReserve)
 	}
 
-	if !sanityCheckForFee(tx.Fee) {
+	if !sanityCheckForFee(view, tx.Fee) {
 		return result.Error("Insufficient fee. Transaction fee needs to be at least %v GammaWei",
-			types.MinimumTransactionFeeGammaWei).WithErrorCode(result.CodeInvalidFee)
+			view.GetChainParameters().MinimumTransactionFeeGammaWei).WithErrorCode(result.CodeInvalidFee)
 	}
 
 	fund := tx.Source.Coins
@@ -86,6 +86,17 @@ func (exec *ReserveFundTxExecutor) sanityCheck(chainID string, view *st.StoreVie
 		return result.Error(err.Error()).WithErrorCode(result.CodeReserveFundCheckFailed)
 	}
 
+	// Bound the state held by the reserved funds of the account
+	err = view.GetChainParameters().ReservePolicy().CheckReserve(sourceAccount, duration, exec.state.Height())
+	if err != nil {
+		return reservedFundCheckFailed(err, result.CodeReserveFundCheckFailed)
+	}
+
+	err = types.ValidateSpendLimits(tx.ResourceIDs, tx.SpendLimits)
+	if err != nil {
+		return result.Error(err.Error()).WithErrorCode(result.CodeInvalidSpendLimits)
+	}
+
 	return result.OK
 }
 
@@ -95,7 +106,8 @@ func (exec *ReserveFundTxExecutor) process(chainID string, view *st.StoreView, t
 	sourceAddress := tx.Source.Address
 	sourceAccount, success := getInput(view, tx.Source)
 	if success.IsError() {
-		return common.Hash{}, result.Error("Failed to get the source account")
+		return common.Hash{}, result.Error("Failed to get the source account").
+			WithErrorCode(result.CodeAccountNotFound)
 	}
 
 	collateral := tx.Collateral
@@ -105,9 +117,10 @@ func (exec *ReserveFundTxExecutor) process(chainID string, view *st.StoreView, t
 	reserveSequence := tx.Source.Sequence
 	endBlockHeight := exec.state.Height() + duration
 
-	sourceAccount.ReserveFund(collateral, fund, resourceIDs, endBlockHeight, reserveSequence)
-	if !chargeFee(sourceAccount, tx.Fee) {
-		return common.Hash{}, result.Error("failed to charge transaction fee")
+	sourceAccount.ReserveFund(collateral, fund, resourceIDs, endBlockHeight, reserveSequence, tx.SpendLimits)
+	if !chargeFee(view, sourceAccount, tx.Fee) {
+		return common.Hash{}, result.Error("failed to charge transaction fee").
+			WithErrorCode(result.CodeInsufficientFund)
 	}
 
 	sourceAccount.Sequence++
@@ -39,7 +39,7 @@ func (exec *SendTxExecutor) sanityCheck(chainID string, view *st.StoreView, tran
 	numAccountsAffected := uint64(len(tx.Inputs) + len(tx.Outputs))
 	if numAccountsAffected > types.MaxAccountsAffectedPerTx {
 		return result.Error("Trasaction modifying too many accounts. At most %v accounts are allowed per transaction.",
-			types.MaxAccountsAffectedPerTx)
+			types.MaxAccountsAffectedPerTx).WithErrorCode(result.CodeTooManyAccounts)
 	}
 
 	// Get inputs
@@ -61,16 +61,17 @@ func (exec *SendTxExecutor) sanityCheck(chainID string, view *st.StoreView, tran
 		return res
 	}
 
-	if !sanityCheckForFee(tx.Fee) {
+	if !sanityCheckForFee(view, tx.Fee) {
 		return result.Error("Insufficient fee. Transaction fee needs to be at least %v GammaWei",
-			types.MinimumTransactionFeeGammaWei).WithErrorCode(result.CodeInvalidFee)
+			view.GetChainParameters().MinimumTransactionFeeGammaWei).WithErrorCode(result.CodeInvalidFee)
 	}
 
 	outTotal := sumOutputs(tx.Outputs)
 	outPlusFees := outTotal
 	outPlusFees = outTotal.Plus(tx.Fee)
 	if !inTotal.IsEqual(outPlusFees) {
-		return result.Error("Input total (%v) != output total + fees (%v)", inTotal, outPlusFees)
+		return result.Error("Input total (%v) != output total + fees (%v)", inTotal, outPlusFees).
+			WithErrorCode(result.CodeUnbalancedTx)
 	}
 
 	return result.OK
@@ -91,6 +92,7 @@ func (exec *SendTxExecutor) process(chainID string, view *st.StoreView, transact
 
 	adjustByInputs(view, accounts, tx.Inputs)
 	adjustByOutputs(view, accounts, tx.Outputs)
+	view.AddChargedFee(tx.Fee)
 
 	txHash := types.TxID(chainID, tx)
 	return txHash, result.OK
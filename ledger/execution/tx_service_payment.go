@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"math/big"
 
+	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 
 	"github.com/thetatoken/ukulele/common"
@@ -32,58 +33,67 @@ func NewServicePaymentTxExecutor(state *st.LedgerState) *ServicePaymentTxExecuto
 func (exec *ServicePaymentTxExecutor) sanityCheck(chainID string, view *st.StoreView, transaction types.Tx) result.Result {
 	tx := transaction.(*types.ServicePaymentTx)
 
-	res := tx.Source.ValidateBasic()
+	res := tx.Target.ValidateBasic()
 	if res.IsError() {
 		return res
 	}
 
-	res = tx.Target.ValidateBasic()
+	targetAddress := tx.Target.Address
+
+	// Get the target account (that signed and broadcasted this transaction)
+	targetAccount, res := getOrMakeInput(view, tx.Target)
 	if res.IsError() {
 		return res
 	}
 
-	sourceAddress := tx.Source.Address
-	targetAddress := tx.Target.Address
+	// Verify target
+	if targetAccount.Sequence+1 != tx.Target.Sequence {
+		return result.Error("Got %v, expected %v. (acc.seq=%v)",
+			tx.Target.Sequence, targetAccount.Sequence+1, targetAccount.Sequence).
+			WithErrorCode(result.CodeInvalidSequence)
+	}
 
-	sourceAccount, res := getInput(view, tx.Source)
+	targetSignBytes := tx.TargetSignBytes(chainID)
+	if !tx.Target.Signature.Verify(targetSignBytes, targetAccount.SignerAddress()) {
+		errMsg := fmt.Sprintf("sanityCheckForServicePaymentTx failed on target signature, addr: %v", targetAddress.Hex())
+		log.Infof(errMsg)
+		return result.Error(errMsg).WithErrorCode(result.CodeInvalidSignature)
+	}
+
+	if !sanityCheckForFee(view, tx.Fee) {
+		return result.Error("Insufficient fee. Transaction fee needs to be at least %v GammaWei",
+			view.GetChainParameters().MinimumTransactionFeeGammaWei).WithErrorCode(result.CodeInvalidFee)
+	}
+
+	return exec.checkPayment(chainID, view, tx, targetAccount)
+}
+
+// checkPayment verifies the source side of a service payment, i.e. the signature of
+// the source and the reserved fund the payment is made from.
+func (exec *ServicePaymentTxExecutor) checkPayment(chainID string, view *st.StoreView, tx *types.ServicePaymentTx, targetAccount *types.Account) result.Result {
+	res := tx.Source.ValidateBasic()
 	if res.IsError() {
 		return res
 	}
 
-	// Get the target account (that signed and broadcasted this transaction)
-	targetAccount, res := getOrMakeInput(view, tx.Target)
+	sourceAddress := tx.Source.Address
+
+	sourceAccount, res := getInput(view, tx.Source)
 	if res.IsError() {
 		return res
 	}
 
 	if tx.Source.Coins.ThetaWei.Cmp(types.Zero) != 0 {
-		return result.Error("Cannot send ThetaWei as service payment!")
+		return result.Error("Cannot send ThetaWei as service payment!").
+			WithErrorCode(result.CodeInvalidPaymentCoins)
 	}
 
 	// Verify source
 	sourceSignBytes := tx.SourceSignBytes(chainID)
-	if !tx.Source.Signature.Verify(sourceSignBytes, sourceAccount.Address) {
+	if !tx.Source.Signature.Verify(sourceSignBytes, sourceAccount.SignerAddress()) {
 		errMsg := fmt.Sprintf("sanityCheckForServicePaymentTx failed on source signature, addr: %v", sourceAddress.Hex())
 		log.Infof(errMsg)
-		return result.Error(errMsg)
-	}
-
-	// Verify target
-	if targetAccount.Sequence+1 != tx.Target.Sequence {
-		return result.Error("Got %v, expected %v. (acc.seq=%v)",
-			tx.Target.Sequence, targetAccount.Sequence+1, targetAccount.Sequence)
-	}
-
-	targetSignBytes := tx.TargetSignBytes(chainID)
-	if !tx.Target.Signature.Verify(targetSignBytes, targetAccount.Address) {
-		errMsg := fmt.Sprintf("sanityCheckForServicePaymentTx failed on target signature, addr: %v", targetAddress.Hex())
-		log.Infof(errMsg)
-		return result.Error(errMsg)
-	}
-
-	if !sanityCheckForFee(tx.Fee) {
-		return result.Error("Insufficient fee. Transaction fee needs to be at least %v GammaWei",
-			types.MinimumTransactionFeeGammaWei).WithErrorCode(result.CodeInvalidFee)
+		return result.Error(errMsg).WithErrorCode(result.CodeInvalidSignature)
 	}
 
 	transferAmount := tx.Source.Coins
@@ -96,7 +106,15 @@ func (exec *ServicePaymentTxExecutor) sanityCheck(chainID string, view *st.Store
 	//       the source account will be slashed by the process() function
 	err := sourceAccount.CheckTransferReservedFund(targetAccount, transferAmount, paymentSequence, currentBlockHeight, reserveSequence)
 	if err != nil {
-		return result.Error(err.Error()).WithErrorCode(result.CodeCheckTransferReservedFundFailed)
+		return reservedFundCheckFailed(err, result.CodeCheckTransferReservedFundFailed)
+	}
+
+	err = sourceAccount.CheckSpendLimit(tx.ResourceID, transferAmount, reserveSequence)
+	if errors.Cause(err) == types.ErrSpendLimitExceeded {
+		return result.Error(err.Error()).WithErrorCode(result.CodeResourceSpendLimitExceeded)
+	}
+	if err != nil {
+		return reservedFundCheckFailed(err, result.CodeCheckTransferReservedFundFailed)
 	}
 
 	return result.OK
@@ -105,26 +123,54 @@ func (exec *ServicePaymentTxExecutor) sanityCheck(chainID string, view *st.Store
 func (exec *ServicePaymentTxExecutor) process(chainID string, view *st.StoreView, transaction types.Tx) (common.Hash, result.Result) {
 	tx := transaction.(*types.ServicePaymentTx)
 
-	sourceAddress := tx.Source.Address
 	targetAddress := tx.Target.Address
-
-	sourceAccount, res := getInput(view, tx.Source)
+	targetAccount, res := getOrMakeInput(view, tx.Target)
 	if res.IsError() {
 		return common.Hash{}, res
 	}
 
-	targetAccount, res := getOrMakeInput(view, tx.Target)
+	accounts := map[common.Address]*types.Account{targetAddress: targetAccount}
+	res = exec.transferPayment(view, tx, accounts)
 	if res.IsError() {
 		return common.Hash{}, res
 	}
 
+	if !chargeFee(view, targetAccount, tx.Fee) {
+		return common.Hash{}, result.Error("failed to charge transaction fee").
+			WithErrorCode(result.CodeInsufficientFund)
+	}
+	targetAccount.Sequence++ // targetAccount broadcasted the transaction
+
+	for address, account := range accounts {
+		view.SetAccount(address, account)
+	}
+
+	txHash := types.TxID(chainID, tx)
+	return txHash, result.OK
+}
+
+// transferPayment transfers the payment from the reserved fund of the source to the
+// target and the addresses of the split rule of the resource, or records a slash
+// intent if the source overspends the reserved fund. The accounts involved are taken
+// from, or loaded into, the accounts map, which the caller writes back to the view.
+func (exec *ServicePaymentTxExecutor) transferPayment(view *st.StoreView, tx *types.ServicePaymentTx, accounts map[common.Address]*types.Account) result.Result {
+	sourceAccount, exists := accounts[tx.Source.Address]
+	if !exists {
+		var res result.Result
+		sourceAccount, res = getInput(view, tx.Source)
+		if res.IsError() {
+			return res
+		}
+		accounts[tx.Source.Address] = sourceAccount
+	}
+
 	resourceID := tx.ResourceID
 	splitRule := view.GetSplitRule(resourceID)
 
 	fullTransferAmount := tx.Source.Coins
-	splitSuccess, coinsMap, accountAddressMap := exec.splitPayment(view, splitRule, resourceID, targetAddress, targetAccount, fullTransferAmount)
+	splitSuccess, coinsMap := exec.splitPayment(view, splitRule, resourceID, tx.Target.Address, fullTransferAmount, accounts)
 	if !splitSuccess {
-		return common.Hash{}, result.Error("Failed to split payment")
+		return result.Error("Failed to split payment").WithErrorCode(result.CodeInvalidSplits)
 	}
 
 	currentBlockHeight := view.Height()
@@ -133,67 +179,44 @@ func (exec *ServicePaymentTxExecutor) process(chainID string, view *st.StoreView
 	if shouldSlash {
 		view.AddSlashIntent(slashIntent)
 	}
-	if !chargeFee(targetAccount, tx.Fee) {
-		return common.Hash{}, result.Error("failed to charge transaction fee")
-	}
-	targetAccount.Sequence++ // targetAccount broadcasted the transaction
-
-	view.SetAccount(sourceAddress, sourceAccount)
-	for account := range coinsMap {
-		address, exists := accountAddressMap[account]
-		if !exists {
-			panic(fmt.Sprintf("Cannot find address for account: %v", account))
-		}
-		view.SetAccount(address, account)
-	}
-
-	txHash := types.TxID(chainID, tx)
-	return txHash, result.OK
+	return result.OK
 }
 
 func (exec *ServicePaymentTxExecutor) splitPayment(view *st.StoreView, splitRule *types.SplitRule, resourceID string,
-	targetAddress common.Address, targetAccount *types.Account, fullAmount types.Coins) (bool, map[*types.Account]types.Coins, map[*types.Account](common.Address)) {
+	targetAddress common.Address, fullAmount types.Coins, accounts map[common.Address]*types.Account) (bool, map[*types.Account]types.Coins) {
 	coinsMap := map[*types.Account]types.Coins{}
-	accountAddressMap := map[*types.Account](common.Address){}
+	targetAccount := accounts[targetAddress]
 
 	// no splitRule associated with the resourceID, full payment goes to the target account
 	if splitRule == nil {
 		coinsMap[targetAccount] = fullAmount
-		accountAddressMap[targetAccount] = targetAddress
-		return true, coinsMap, accountAddressMap
+		return true, coinsMap
 	}
 
 	// the splitRule has expired, full payment goes to the target account. also delete the splitRule
 	if exec.state.Height() > splitRule.EndBlockHeight {
 		coinsMap[targetAccount] = fullAmount
 		view.DeleteSplitRule(resourceID)
-		accountAddressMap[targetAccount] = targetAddress
-		return true, coinsMap, accountAddressMap
-	}
-
-	// the splitRule is valid, split the payment among the participated addresses
-	remainingAmount := fullAmount
-	for _, split := range splitRule.Splits {
-		splitAddress := split.Address
-		splitAccount := getOrMakeAccount(view, splitAddress)
-		percentage := split.Percentage
-		if percentage > 100 || percentage < 0 {
-			continue
-		}
-
-		splitAmount := fullAmount.CalculatePercentage(percentage)
-		coinsMap[splitAccount] = splitAmount
-		accountAddressMap[splitAccount] = splitAddress
-		remainingAmount = remainingAmount.Minus(splitAmount)
+		return true, coinsMap
 	}
 
+	// the splitRule is valid, split the payment among the participated addresses. An address
+	// also involved otherwise in the payment, e.g. the target, gets its share on the same account
+	shares, remainingAmount := splitRule.SplitAmounts(fullAmount)
 	if !remainingAmount.IsNonnegative() {
-		return false, coinsMap, accountAddressMap
+		return false, coinsMap
+	}
+	for splitAddress, splitAmount := range shares {
+		splitAccount, exists := accounts[splitAddress]
+		if !exists {
+			splitAccount = getOrMakeAccount(view, splitAddress)
+			accounts[splitAddress] = splitAccount
+		}
+		coinsMap[splitAccount] = coinsMap[splitAccount].Plus(splitAmount)
 	}
-	coinsMap[targetAccount] = remainingAmount
-	accountAddressMap[targetAccount] = targetAddress
+	coinsMap[targetAccount] = coinsMap[targetAccount].Plus(remainingAmount)
 
-	return true, coinsMap, accountAddressMap
+	return true, coinsMap
 }
 
 func (exec *ServicePaymentTxExecutor) getTxInfo(transaction types.Tx) *core.TxInfo {
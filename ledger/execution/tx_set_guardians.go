@@ -0,0 +1,126 @@
+package execution
+
+import (
+	"fmt"
+	"math/big"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/common/result"
+	"github.com/thetatoken/ukulele/core"
+	st "github.com/thetatoken/ukulele/ledger/state"
+	"github.com/thetatoken/ukulele/ledger/types"
+)
+
+var _ TxExecutor = (*SetGuardiansTxExecutor)(nil)
+
+// ------------------------------- SetGuardiansTx Transaction -----------------------------------
+
+// SetGuardiansTxExecutor implements the TxExecutor interface
+type SetGuardiansTxExecutor struct {
+	state *st.LedgerState
+}
+
+// NewSetGuardiansTxExecutor creates a new instance of SetGuardiansTxExecutor
+func NewSetGuardiansTxExecutor(state *st.LedgerState) *SetGuardiansTxExecutor {
+	return &SetGuardiansTxExecutor{
+		state: state,
+	}
+}
+
+func (exec *SetGuardiansTxExecutor) sanityCheck(chainID string, view *st.StoreView, transaction types.Tx) result.Result {
+	tx := transaction.(*types.SetGuardiansTx)
+
+	// Validate account, basic
+	res := tx.Account.ValidateBasic()
+	if res.IsError() {
+		return res
+	}
+
+	// Get input account
+	account, success := getInput(view, tx.Account)
+	if success.IsError() {
+		return result.Error("Failed to get the account").WithErrorCode(result.CodeAccountNotFound)
+	}
+
+	// Validate input, advanced
+	signBytes := tx.SignBytes(chainID)
+	res = validateInputAdvanced(account, signBytes, tx.Account)
+	if res.IsError() {
+		log.Infof(fmt.Sprintf("validateSourceAdvanced failed on %v: %v", tx.Account.Address.Hex(), res))
+		return res
+	}
+
+	if !sanityCheckForFee(view, tx.Fee) {
+		return result.Error("Insufficient fee. Transaction fee needs to be at least %v GammaWei",
+			view.GetChainParameters().MinimumTransactionFeeGammaWei).WithErrorCode(result.CodeInvalidFee)
+	}
+
+	if !account.Balance.IsGTE(tx.Fee) {
+		log.Infof(fmt.Sprintf("Account did not have enough balance %v", tx.Account.Address.Hex()))
+		return result.Error("Account balance is %v, but required minimal balance is %v",
+			account.Balance, tx.Fee).WithErrorCode(result.CodeInsufficientFund)
+	}
+
+	err := types.ValidateGuardians(tx.Account.Address, tx.Guardians, tx.Threshold, tx.Timelock)
+	if err != nil {
+		return result.Error(err.Error()).WithErrorCode(result.CodeInvalidGuardians)
+	}
+
+	return result.OK
+}
+
+func (exec *SetGuardiansTxExecutor) process(chainID string, view *st.StoreView, transaction types.Tx) (common.Hash, result.Result) {
+	tx := transaction.(*types.SetGuardiansTx)
+
+	accountAddress := tx.Account.Address
+	account, success := getInput(view, tx.Account)
+	if success.IsError() {
+		return common.Hash{}, result.Error("Failed to get the account").
+			WithErrorCode(result.CodeAccountNotFound)
+	}
+
+	// Replacing the guardians cancels the pending recovery, but keeps the key the account
+	// was recovered to
+	guardianship := account.GetGuardianship()
+	if guardianship == nil {
+		guardianship = &types.Guardianship{}
+	}
+	guardianship.Guardians = tx.Guardians
+	guardianship.Threshold = tx.Threshold
+	guardianship.Timelock = tx.Timelock
+	guardianship.CancelRecovery()
+	if len(guardianship.Guardians) == 0 && guardianship.Signer == (common.Address{}) {
+		guardianship = nil
+	}
+	account.SetGuardianship(guardianship)
+
+	if !chargeFee(view, account, tx.Fee) {
+		return common.Hash{}, result.Error("failed to charge transaction fee").
+			WithErrorCode(result.CodeInsufficientFund)
+	}
+
+	account.Sequence++
+	view.SetAccount(accountAddress, account)
+
+	txHash := types.TxID(chainID, tx)
+	return txHash, result.OK
+}
+
+func (exec *SetGuardiansTxExecutor) getTxInfo(transaction types.Tx) *core.TxInfo {
+	tx := transaction.(*types.SetGuardiansTx)
+	return &core.TxInfo{
+		Address:           tx.Account.Address,
+		Sequence:          tx.Account.Sequence,
+		EffectiveGasPrice: exec.calculateEffectiveGasPrice(transaction),
+	}
+}
+
+func (exec *SetGuardiansTxExecutor) calculateEffectiveGasPrice(transaction types.Tx) *big.Int {
+	tx := transaction.(*types.SetGuardiansTx)
+	fee := tx.Fee
+	gas := new(big.Int).SetUint64(types.GasSetGuardiansTx)
+	effectiveGasPrice := new(big.Int).Div(fee.GammaWei, gas)
+	return effectiveGasPrice
+}
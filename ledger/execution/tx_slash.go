@@ -17,22 +17,20 @@ var _ TxExecutor = (*SlashTxExecutor)(nil)
 // ------------------------------- Slash Transaction -----------------------------------
 
 type SlashTxExecutor struct {
-	consensus core.ConsensusEngine
-	valMgr    core.ValidatorManager
+	valMgr core.ValidatorManager
 }
 
 // NewSlashTxExecutor creates a new instance of SlashTxExecutor
-func NewSlashTxExecutor(consensus core.ConsensusEngine, valMgr core.ValidatorManager) *SlashTxExecutor {
+func NewSlashTxExecutor(valMgr core.ValidatorManager) *SlashTxExecutor {
 	return &SlashTxExecutor{
-		consensus: consensus,
-		valMgr:    valMgr,
+		valMgr: valMgr,
 	}
 }
 
 func (exec *SlashTxExecutor) sanityCheck(chainID string, view *st.StoreView, transaction types.Tx) result.Result {
 	tx := transaction.(*types.SlashTx)
 
-	validatorAddresses := getValidatorAddresses(exec.consensus, exec.valMgr)
+	validatorAddresses := getValidatorAddresses(exec.valMgr, view.Epoch())
 
 	// Validate proposer, basic
 	res := tx.Proposer.ValidateBasic()
@@ -53,14 +51,15 @@ func (exec *SlashTxExecutor) sanityCheck(chainID string, view *st.StoreView, tra
 
 	// verify the proposer's signature
 	signBytes := tx.SignBytes(chainID)
-	if !tx.Proposer.Signature.Verify(signBytes, proposerAccount.Address) {
-		return result.Error("SignBytes: %X", signBytes)
+	if !core.VerifyValidatorSignature(tx.Proposer.Signature, signBytes, proposerAccount.Address) {
+		return result.Error("SignBytes: %X", signBytes).WithErrorCode(result.CodeInvalidSignature)
 	}
 
 	slashedAddress := tx.SlashedAddress
 	slashedAccount := view.GetAccount(slashedAddress)
 	if slashedAccount == nil {
-		return result.Error("Account %v does not exist!", slashedAddress)
+		return result.Error("Account %v does not exist!", slashedAddress).
+			WithErrorCode(result.CodeAccountNotFound)
 	}
 
 	reservedFundFound := false
@@ -72,19 +71,22 @@ func (exec *SlashTxExecutor) sanityCheck(chainID string, view *st.StoreView, tra
 	}
 
 	if !reservedFundFound {
-		return result.Error("Reserved fund not found for %v", tx.ReserveSequence)
+		return result.Error("Reserved fund not found for %v", tx.ReserveSequence).
+			WithErrorCode(result.CodeReservedFundNotFound)
 	}
 
 	validatorAddress := tx.Proposer.Address
 	validatorAccount := view.GetAccount(validatorAddress)
 	if validatorAccount == nil {
-		return result.Error("Validator %v does not exist!", validatorAddress)
+		return result.Error("Validator %v does not exist!", validatorAddress).
+			WithErrorCode(result.CodeValidatorNotFound)
 	}
 
 	overspendingProofBytes := tx.SlashProof
 	slashProofVerified := exec.verifySlashProof(chainID, slashedAccount, overspendingProofBytes)
 	if !slashProofVerified {
-		return result.Error("Invalid slash proof: %v", overspendingProofBytes)
+		return result.Error("Invalid slash proof: %v", overspendingProofBytes).
+			WithErrorCode(result.CodeInvalidSlashProof)
 	}
 
 	return result.OK
@@ -107,33 +109,51 @@ func (exec *SlashTxExecutor) process(chainID string, view *st.StoreView, transac
 	}
 
 	if !reservedFundFound {
-		return common.Hash{}, result.Error("Reserved fund not found for %v", tx.ReserveSequence)
+		return common.Hash{}, result.Error("Reserved fund not found for %v", tx.ReserveSequence).
+			WithErrorCode(result.CodeReservedFundNotFound)
 	}
 
 	proposerAddress := tx.Proposer.Address
 	proposerAccount := view.GetAccount(proposerAddress)
 	if proposerAccount == nil {
-		return common.Hash{}, result.Error("Proposer %v does not exist!", proposerAddress)
+		return common.Hash{}, result.Error("Proposer %v does not exist!", proposerAddress).
+			WithErrorCode(result.CodeValidatorNotFound)
 	}
 
-	// TODO: We should transfer the collateral to a special address, e.g. 0x0 instead of
-	//       transfering to the proposer, so the proposer gain no extra benefit if it colludes with
-	//       the address that overspent
-
-	// Slash: transfer the collateral and remainding deposit to the validator that identified the overspending
+	// Slash: the policy splits the collateral and remaining deposit between the validator that identified
+	// the overspending, the slashed account, and the amount burned. Burning a part of it makes the
+	// proposer gain less if it colludes with the address that overspent. The policy is part of the chain
+	// parameters, so that all the nodes apply the same one.
 	remainingFund := reservedFund.InitialFund.Minus(reservedFund.UsedFund)
 	if !remainingFund.IsNonnegative() {
 		remainingFund = types.NewCoins(0, 0) // Should NOT happen, just to be on the safe side
 	}
-	slashedAmount := reservedFund.Collateral.Plus(remainingFund)
+	slashableAmount := reservedFund.Collateral.Plus(remainingFund)
+	policy := view.GetChainParameters().SlashingPolicy()
+	reward, burned, returned := policy.Slash(slashableAmount)
 
-	proposerAccount.Balance = proposerAccount.Balance.Plus(slashedAmount)
+	proposerAccount.Balance = proposerAccount.Balance.Plus(reward)
 	slashedAccount.ReservedFunds = append(slashedAccount.ReservedFunds[:reservedFundIdx],
 		slashedAccount.ReservedFunds[reservedFundIdx+1:]...)
+	if !returned.IsZero() {
+		slashedAccount.Balance = slashedAccount.Balance.Plus(returned)
+	}
 
 	view.SetAccount(proposerAddress, proposerAccount)
 	view.SetAccount(slashedAddress, slashedAccount)
 
+	// A slashed validator is not selected as proposer for a while, counted from the epoch of the block
+	if policy.JailDuration > 0 && isAValidator(slashedAddress, getValidatorAddresses(exec.valMgr, view.Epoch())).IsOK() {
+		view.JailValidator(slashedAddress, view.Epoch(), view.Epoch()+policy.JailDuration)
+	}
+
+	log.WithFields(log.Fields{
+		"slashed":  slashedAddress.Hex(),
+		"reward":   reward,
+		"burned":   burned,
+		"returned": returned,
+	}).Info("Slashed overspent reserved fund")
+
 	txHash := types.TxID(chainID, tx)
 	return txHash, result.OK
 }
@@ -167,7 +187,9 @@ func (exec *SlashTxExecutor) verifySlashProof(chainID string, slashedAccount *ty
 			}
 
 			sourceSignedBytes := servicePaymentTx.SourceSignBytes(chainID)
-			if !servicePaymentTx.Source.Signature.Verify(sourceSignedBytes, slashedAccount.Address) {
+			// The payments signed before the account was recovered to another key remain valid proofs
+			if !servicePaymentTx.Source.Signature.Verify(sourceSignedBytes, slashedAccount.Address) &&
+				!servicePaymentTx.Source.Signature.Verify(sourceSignedBytes, slashedAccount.SignerAddress()) {
 				return false // servicePaymentTx not signed by the slashed account
 			}
 
@@ -42,7 +42,7 @@ func (exec *SmartContractTxExecutor) sanityCheck(chainID string, view *st.StoreV
 	// Get input account
 	fromAccount, success := getInput(view, tx.From)
 	if success.IsError() {
-		return result.Error("Failed to get the from account")
+		return result.Error("Failed to get the from account").WithErrorCode(result.CodeAccountNotFound)
 	}
 
 	// Validate input, advanced
@@ -90,32 +90,58 @@ func (exec *SmartContractTxExecutor) sanityCheck(chainID string, view *st.StoreV
 func (exec *SmartContractTxExecutor) process(chainID string, view *st.StoreView, transaction types.Tx) (common.Hash, result.Result) {
 	tx := transaction.(*types.SmartContractTx)
 
-	// Note: for contract deployment, vm.Execute() might transfer coins from the fromAccount to the
-	//       deployed smart contract. Thus, we should call vm.Execute() before calling getInput().
-	//       Otherwise, the fromAccount returned by getInput() will have incorrect balance.
-	_, _, gasUsed, _ := vm.Execute(tx, view)
-
+	// Charge the fee of the whole gas limit up front, so that the execution cannot spend
+	// the balance needed to pay for the gas it uses
 	fromAddress := tx.From.Address
 	fromAccount, success := getInput(view, tx.From)
 	if success.IsError() {
-		return common.Hash{}, result.Error("Failed to get the from account")
+		return common.Hash{}, result.Error("Failed to get the from account").
+			WithErrorCode(result.CodeAccountNotFound)
 	}
-
-	feeAmount := new(big.Int).Mul(tx.GasPrice, new(big.Int).SetUint64(gasUsed))
-	fee := types.Coins{
-		ThetaWei: big.NewInt(int64(0)),
-		GammaWei: feeAmount,
+	feeLimit := types.GasFee(tx.GasPrice, tx.GasLimit)
+	if !fromAccount.Balance.IsGTE(feeLimit) {
+		return common.Hash{}, result.Error("failed to charge transaction fee").
+			WithErrorCode(result.CodeInsufficientFund)
 	}
-	if !chargeFee(fromAccount, fee) {
-		return common.Hash{}, result.Error("failed to charge transaction fee")
+	fromAccount.Balance = fromAccount.Balance.Minus(feeLimit)
+	view.SetAccount(fromAddress, fromAccount)
+
+	// Note: for contract deployment, vm.Execute() might transfer coins from the fromAccount to the
+	//       deployed smart contract. Thus, we should call getInput() again after vm.Execute().
+	//       Otherwise, the fromAccount will have incorrect balance.
+	_, contractAddress, gasUsed, evmErr := vm.Execute(tx, view)
+
+	fromAccount, success = getInput(view, tx.From)
+	if success.IsError() {
+		return common.Hash{}, result.Error("Failed to get the from account").
+			WithErrorCode(result.CodeAccountNotFound)
 	}
 
+	// Refund the fee of the unused gas, the fee of the gas used goes to the fee pool
+	fee := types.GasFee(tx.GasPrice, gasUsed)
+	refund := feeLimit.Minus(fee)
+	fromAccount.Balance = fromAccount.Balance.Plus(refund)
+	view.AddChargedFee(fee)
+
 	createContract := (tx.To.Address == common.Address{})
 	if !createContract { // vm.create() increments the sequence of the from account
 		fromAccount.Sequence++
 	}
 	view.SetAccount(fromAddress, fromAccount)
 
+	receipt := &types.TxReceipt{
+		GasLimit:        tx.GasLimit,
+		GasPrice:        tx.GasPrice,
+		GasUsed:         gasUsed,
+		Fee:             fee,
+		Refund:          refund,
+		ContractAddress: contractAddress,
+	}
+	if evmErr != nil {
+		receipt.EvmError = evmErr.Error()
+	}
+	view.SetTxReceipt(receipt)
+
 	txHash := types.TxID(chainID, tx)
 	return txHash, result.OK
 }
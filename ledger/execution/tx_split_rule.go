@@ -50,37 +50,29 @@ func (exec *SplitRuleTxExecutor) sanityCheck(chainID string, view *st.StoreView,
 		return res
 	}
 
-	if !sanityCheckForFee(tx.Fee) {
+	if !sanityCheckForFee(view, tx.Fee) {
 		return result.Error("Insufficient fee. Transaction fee needs to be at least %v GammaWei",
-			types.MinimumTransactionFeeGammaWei).WithErrorCode(result.CodeInvalidFee)
+			view.GetChainParameters().MinimumTransactionFeeGammaWei).WithErrorCode(result.CodeInvalidFee)
 	}
 
 	minimalBalance := tx.Fee
 	if !initiatorAccount.Balance.IsGTE(minimalBalance) {
 		log.Infof(fmt.Sprintf("the contract initiator did not have enough to cover the fee %X", tx.Initiator.Address))
-		return result.Error("the contract initiator account balance is %v, but required minimal balance is %v", initiatorAccount.Balance, minimalBalance)
+		return result.Error("the contract initiator account balance is %v, but required minimal balance is %v", initiatorAccount.Balance, minimalBalance).
+			WithErrorCode(result.CodeInsufficientFund)
 	}
 
-	numAccountsAffected := len(tx.Splits) + 1
+	numAccountsAffected := len(tx.PlatformSplits) + len(tx.Splits) + 1
 	if numAccountsAffected > types.MaxAccountsAffectedPerTx {
 		return result.Error("This allows one trasaction to modify many accounts. At most %v accounts are allowed per transaction.",
-			types.MaxAccountsAffectedPerTx)
+			types.MaxAccountsAffectedPerTx).WithErrorCode(result.CodeTooManyAccounts)
 	}
 
-	totalPercentage := uint(0)
-	for _, split := range tx.Splits {
-		percentage := split.Percentage
-		if percentage < 0 {
-			return result.Error("Percentage needs to be positive")
-		}
-		if percentage > 100 {
-			return result.Error("Percentage needs to be less than 100")
-		}
-		totalPercentage += percentage
+	if err := types.ValidateSplits(tx.PlatformSplits); err != nil {
+		return result.Error("Invalid platform splits: %v", err).WithErrorCode(result.CodeInvalidSplits)
 	}
-
-	if totalPercentage > 100 {
-		return result.Error("Sum of the percentages should be at most 100")
+	if err := types.ValidateSplits(tx.Splits); err != nil {
+		return result.Error("Invalid splits: %v", err).WithErrorCode(result.CodeInvalidSplits)
 	}
 
 	resourceID := tx.ResourceID
@@ -117,7 +109,12 @@ func (exec *SplitRuleTxExecutor) process(chainID string, view *st.StoreView, tra
 		endBlockHeight := currentBlockHeight + tx.Duration
 		splitRule.EndBlockHeight = endBlockHeight
 		splitRule.Splits = tx.Splits
-		success = view.UpdateSplitRule(splitRule)
+		splitRule.PlatformSplits = tx.PlatformSplits
+		if !view.UpdateSplitRule(splitRule) {
+			return common.Hash{}, result.Error("Split rule not found for resourceID %v", resourceID).
+				WithErrorCode(result.CodeSplitRuleNotFound)
+		}
+		success = true
 	} else {
 		endBlockHeight := currentBlockHeight + tx.Duration
 		splitRule := types.SplitRule{
@@ -125,6 +122,7 @@ func (exec *SplitRuleTxExecutor) process(chainID string, view *st.StoreView, tra
 			ResourceID:       tx.ResourceID,
 			Splits:           tx.Splits,
 			EndBlockHeight:   endBlockHeight,
+			PlatformSplits:   tx.PlatformSplits,
 		}
 		success = view.AddSplitRule(&splitRule)
 	}
@@ -133,8 +131,9 @@ func (exec *SplitRuleTxExecutor) process(chainID string, view *st.StoreView, tra
 		return common.Hash{}, result.Error("failed to add or update split rule")
 	}
 
-	if !chargeFee(initiatorAccount, tx.Fee) {
-		return common.Hash{}, result.Error("failed to charge transaction fee")
+	if !chargeFee(view, initiatorAccount, tx.Fee) {
+		return common.Hash{}, result.Error("failed to charge transaction fee").
+			WithErrorCode(result.CodeInsufficientFund)
 	}
 
 	initiatorAccount.Sequence++
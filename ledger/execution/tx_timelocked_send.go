@@ -0,0 +1,126 @@
+package execution
+
+import (
+	"math/big"
+
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/common/result"
+	"github.com/thetatoken/ukulele/core"
+	st "github.com/thetatoken/ukulele/ledger/state"
+	"github.com/thetatoken/ukulele/ledger/types"
+)
+
+var _ TxExecutor = (*TimelockedSendTxExecutor)(nil)
+
+// ------------------------------- TimelockedSend Transaction -----------------------------------
+
+// TimelockedSendTxExecutor implements the TxExecutor interface
+type TimelockedSendTxExecutor struct {
+}
+
+// NewTimelockedSendTxExecutor creates a new instance of TimelockedSendTxExecutor
+func NewTimelockedSendTxExecutor() *TimelockedSendTxExecutor {
+	return &TimelockedSendTxExecutor{}
+}
+
+func (exec *TimelockedSendTxExecutor) sanityCheck(chainID string, view *st.StoreView, transaction types.Tx) result.Result {
+	tx := transaction.(*types.TimelockedSendTx)
+
+	// Validate inputs and outputs, basic
+	res := validateInputsBasic(tx.Inputs)
+	if res.IsError() {
+		return res
+	}
+	res = validateOutputsBasic(tx.Outputs)
+	if res.IsError() {
+		return res
+	}
+
+	numAccountsAffected := uint64(len(tx.Inputs) + len(tx.Outputs))
+	if numAccountsAffected > types.MaxAccountsAffectedPerTx {
+		return result.Error("Trasaction modifying too many accounts. At most %v accounts are allowed per transaction.",
+			types.MaxAccountsAffectedPerTx).WithErrorCode(result.CodeTooManyAccounts)
+	}
+
+	currentBlockHeight := view.Height()
+	if tx.UnlockHeight <= currentBlockHeight {
+		return result.Error("Unlock height %v needs to be above the current block height %v", tx.UnlockHeight, currentBlockHeight).
+			WithErrorCode(result.CodeInvalidUnlockHeight)
+	}
+	if tx.UnlockHeight-currentBlockHeight > types.MaximumCoinLockDuration {
+		return result.Error("Coins can be locked for at most %v blocks", types.MaximumCoinLockDuration).
+			WithErrorCode(result.CodeInvalidUnlockHeight)
+	}
+
+	// Get inputs
+	accounts, res := getInputs(view, tx.Inputs)
+	if res.IsError() {
+		return res
+	}
+
+	// Get or make outputs.
+	accounts, res = getOrMakeOutputs(view, accounts, tx.Outputs)
+	if res.IsError() {
+		return res
+	}
+
+	// Validate inputs and outputs, advanced
+	signBytes := tx.SignBytes(chainID)
+	inTotal, res := validateInputsAdvanced(accounts, signBytes, tx.Inputs)
+	if res.IsError() {
+		return res
+	}
+
+	if !sanityCheckForFee(view, tx.Fee) {
+		return result.Error("Insufficient fee. Transaction fee needs to be at least %v GammaWei",
+			view.GetChainParameters().MinimumTransactionFeeGammaWei).WithErrorCode(result.CodeInvalidFee)
+	}
+
+	outTotal := sumOutputs(tx.Outputs)
+	outPlusFees := outTotal.Plus(tx.Fee)
+	if !inTotal.IsEqual(outPlusFees) {
+		return result.Error("Input total (%v) != output total + fees (%v)", inTotal, outPlusFees).
+			WithErrorCode(result.CodeUnbalancedTx)
+	}
+
+	return result.OK
+}
+
+func (exec *TimelockedSendTxExecutor) process(chainID string, view *st.StoreView, transaction types.Tx) (common.Hash, result.Result) {
+	tx := transaction.(*types.TimelockedSendTx)
+
+	accounts, res := getInputs(view, tx.Inputs)
+	if res.IsError() {
+		return common.Hash{}, res
+	}
+
+	accounts, res = getOrMakeOutputs(view, accounts, tx.Outputs)
+	if res.IsError() {
+		return common.Hash{}, res
+	}
+
+	adjustByInputs(view, accounts, tx.Inputs)
+	lockByOutputs(view, accounts, tx.Outputs, tx.UnlockHeight)
+	view.AddChargedFee(tx.Fee)
+
+	txHash := types.TxID(chainID, tx)
+	return txHash, result.OK
+}
+
+func (exec *TimelockedSendTxExecutor) getTxInfo(transaction types.Tx) *core.TxInfo {
+	tx := transaction.(*types.TimelockedSendTx)
+	return &core.TxInfo{
+		Address:           tx.Inputs[0].Address,
+		Sequence:          tx.Inputs[0].Sequence,
+		EffectiveGasPrice: exec.calculateEffectiveGasPrice(transaction),
+	}
+}
+
+func (exec *TimelockedSendTxExecutor) calculateEffectiveGasPrice(transaction types.Tx) *big.Int {
+	tx := transaction.(*types.TimelockedSendTx)
+	fee := tx.Fee
+	numAccountsAffected := uint64(len(tx.Inputs) + len(tx.Outputs))
+	gas := new(big.Int).SetUint64(types.GasSendTxPerAccount * numAccountsAffected)
+	effectiveGasPrice := new(big.Int).Div(fee.GammaWei, gas)
+	return effectiveGasPrice
+}
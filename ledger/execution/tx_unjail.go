@@ -0,0 +1,175 @@
+package execution
+
+import (
+	"fmt"
+	"math/big"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/common/result"
+	"github.com/thetatoken/ukulele/core"
+	st "github.com/thetatoken/ukulele/ledger/state"
+	"github.com/thetatoken/ukulele/ledger/types"
+)
+
+var _ TxExecutor = (*UnjailTxExecutor)(nil)
+
+// ------------------------------- Unjail Transaction -----------------------------------
+
+// UnjailTxExecutor implements the TxExecutor interface
+type UnjailTxExecutor struct {
+}
+
+// NewUnjailTxExecutor creates a new instance of UnjailTxExecutor
+func NewUnjailTxExecutor() *UnjailTxExecutor {
+	return &UnjailTxExecutor{}
+}
+
+func (exec *UnjailTxExecutor) sanityCheck(chainID string, view *st.StoreView, transaction types.Tx) result.Result {
+	tx := transaction.(*types.UnjailTx)
+
+	// Validate validator, basic
+	res := tx.Validator.ValidateBasic()
+	if res.IsError() {
+		return res
+	}
+
+	// Get input account
+	validatorAccount, success := getInput(view, tx.Validator)
+	if success.IsError() {
+		return result.Error("Failed to get the validator account").WithErrorCode(result.CodeAccountNotFound)
+	}
+
+	// Validate input, advanced
+	signBytes := tx.SignBytes(chainID)
+	res = validateInputAdvanced(validatorAccount, signBytes, tx.Validator)
+	if res.IsError() {
+		log.Infof(fmt.Sprintf("validateSourceAdvanced failed on %v: %v", tx.Validator.Address.Hex(), res))
+		return res
+	}
+
+	if !sanityCheckForFee(view, tx.Fee) {
+		return result.Error("Insufficient fee. Transaction fee needs to be at least %v GammaWei",
+			view.GetChainParameters().MinimumTransactionFeeGammaWei).WithErrorCode(result.CodeInvalidFee)
+	}
+
+	if !validatorAccount.Balance.IsGTE(tx.Fee) {
+		log.Infof(fmt.Sprintf("Validator did not have enough balance %v", tx.Validator.Address.Hex()))
+		return result.Error("Validator balance is %v, but required minimal balance is %v",
+			validatorAccount.Balance, tx.Fee).WithErrorCode(result.CodeInsufficientFund)
+	}
+
+	// Only the jails for downtime can be ended early, the other jails have a term
+	jailedValidator := view.GetJailedValidator(tx.Validator.Address)
+	if jailedValidator == nil || !jailedValidator.IsJailedUntilUnjailed() {
+		return result.Error("%v is not jailed for downtime", tx.Validator.Address.Hex()).
+			WithErrorCode(result.CodeValidatorNotJailed)
+	}
+	epoch, _ := view.GetLastBlockEpoch()
+	if epoch < jailedValidator.JailedEpoch+types.UnjailCooldownEpochs {
+		return result.Error("%v cannot unjail before epoch %v", tx.Validator.Address.Hex(),
+			jailedValidator.JailedEpoch+types.UnjailCooldownEpochs).WithErrorCode(result.CodeUnjailCooldown)
+	}
+
+	return result.OK
+}
+
+func (exec *UnjailTxExecutor) process(chainID string, view *st.StoreView, transaction types.Tx) (common.Hash, result.Result) {
+	tx := transaction.(*types.UnjailTx)
+
+	validatorAddress := tx.Validator.Address
+	validatorAccount, success := getInput(view, tx.Validator)
+	if success.IsError() {
+		return common.Hash{}, result.Error("Failed to get the validator account").
+			WithErrorCode(result.CodeAccountNotFound)
+	}
+
+	if !chargeFee(view, validatorAccount, tx.Fee) {
+		return common.Hash{}, result.Error("failed to charge transaction fee").
+			WithErrorCode(result.CodeInsufficientFund)
+	}
+
+	// The validator returns to the proposer rotation from the epoch after the block
+	epoch, _ := view.GetLastBlockEpoch()
+	jailedValidator := view.GetJailedValidator(validatorAddress)
+	jailedValidator.UntilEpoch = epoch
+	view.SetJailedValidator(jailedValidator)
+
+	validatorAccount.Sequence++
+	view.SetAccount(validatorAddress, validatorAccount)
+
+	log.WithFields(log.Fields{
+		"validator": validatorAddress.Hex(),
+		"epoch":     epoch,
+	}).Info("Validator unjailed")
+
+	txHash := types.TxID(chainID, tx)
+	return txHash, result.OK
+}
+
+func (exec *UnjailTxExecutor) getTxInfo(transaction types.Tx) *core.TxInfo {
+	tx := transaction.(*types.UnjailTx)
+	return &core.TxInfo{
+		Address:           tx.Validator.Address,
+		Sequence:          tx.Validator.Sequence,
+		EffectiveGasPrice: exec.calculateEffectiveGasPrice(transaction),
+	}
+}
+
+func (exec *UnjailTxExecutor) calculateEffectiveGasPrice(transaction types.Tx) *big.Int {
+	tx := transaction.(*types.UnjailTx)
+	fee := tx.Fee
+	gas := new(big.Int).SetUint64(types.GasUnjailTx)
+	effectiveGasPrice := new(big.Int).Div(fee.GammaWei, gas)
+	return effectiveGasPrice
+}
+
+// trackMissedProposals records the proposal missed in the epoch directly before the
+// block of the given epoch, if no block was produced in it, and jails the validators
+// that missed more than MaxMissedProposals within the last DowntimeWindowEpochs epochs
+// until they send an UnjailTx. The proposer of the missed epoch is selected with the
+// jails of the view rather than the ones the validator manager holds in memory, so that
+// all the nodes record the same missed proposals whenever they restarted, synced or
+// finalized. The missed proposals are not tracked with the validator managers that
+// cannot select the proposers this way, e.g. the VRF one, whose reveals are only held
+// in memory. The earlier epochs without a block are not counted either. It is called
+// by the coinbase transaction with the epoch of its block.
+func trackMissedProposals(view *st.StoreView, valMgr core.ValidatorManager, epoch uint64) {
+	lastEpoch, ok := view.GetLastBlockEpoch()
+	view.SetLastBlockEpoch(epoch)
+	if !ok || epoch <= lastEpoch+1 {
+		return
+	}
+	selector, ok := valMgr.(core.ProposerSelector)
+	if !ok {
+		return
+	}
+
+	missedEpoch := epoch - 1
+	proposer := selector.SelectProposer(missedEpoch, func(validator common.Address, epoch uint64) bool {
+		jailedValidator := view.GetJailedValidator(validator)
+		return jailedValidator != nil && jailedValidator.IsJailed(epoch)
+	}).Address()
+	if jailedValidator := view.GetJailedValidator(proposer); jailedValidator != nil && jailedValidator.IsJailedUntilUnjailed() {
+		return
+	}
+
+	downtime := view.GetValidatorDowntime(proposer)
+	if downtime == nil {
+		downtime = &types.ValidatorDowntime{Address: proposer}
+	}
+	downtime.RecordMissedEpoch(missedEpoch)
+	if downtime.MissedInWindow(missedEpoch) <= types.MaxMissedProposals {
+		view.SetValidatorDowntime(downtime)
+		return
+	}
+
+	view.JailValidator(proposer, epoch, types.JailedUntilUnjailed)
+	view.DeleteValidatorDowntime(proposer)
+
+	log.WithFields(log.Fields{
+		"validator": proposer.Hex(),
+		"epoch":     epoch,
+	}).Warn("Validator jailed for missing its proposals")
+}
@@ -0,0 +1,123 @@
+package execution
+
+import (
+	"fmt"
+	"math/big"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/common/result"
+	"github.com/thetatoken/ukulele/core"
+	st "github.com/thetatoken/ukulele/ledger/state"
+	"github.com/thetatoken/ukulele/ledger/types"
+)
+
+var _ TxExecutor = (*VoteTxExecutor)(nil)
+
+// ------------------------------- Vote Transaction -----------------------------------
+
+// VoteTxExecutor implements the TxExecutor interface
+type VoteTxExecutor struct {
+}
+
+// NewVoteTxExecutor creates a new instance of VoteTxExecutor
+func NewVoteTxExecutor() *VoteTxExecutor {
+	return &VoteTxExecutor{}
+}
+
+func (exec *VoteTxExecutor) sanityCheck(chainID string, view *st.StoreView, transaction types.Tx) result.Result {
+	tx := transaction.(*types.VoteTx)
+
+	// Validate voter, basic
+	res := tx.Voter.ValidateBasic()
+	if res.IsError() {
+		return res
+	}
+
+	// Get input account
+	voterAccount, success := getInput(view, tx.Voter)
+	if success.IsError() {
+		return result.Error("Failed to get the voter account").WithErrorCode(result.CodeAccountNotFound)
+	}
+
+	// Validate input, advanced
+	signBytes := tx.SignBytes(chainID)
+	res = validateInputAdvanced(voterAccount, signBytes, tx.Voter)
+	if res.IsError() {
+		log.Infof(fmt.Sprintf("validateSourceAdvanced failed on %v: %v", tx.Voter.Address.Hex(), res))
+		return res
+	}
+
+	if !sanityCheckForFee(view, tx.Fee) {
+		return result.Error("Insufficient fee. Transaction fee needs to be at least %v GammaWei",
+			view.GetChainParameters().MinimumTransactionFeeGammaWei).WithErrorCode(result.CodeInvalidFee)
+	}
+
+	if !voterAccount.Balance.IsGTE(tx.Fee) {
+		log.Infof(fmt.Sprintf("Voter did not have enough balance %v", tx.Voter.Address.Hex()))
+		return result.Error("Voter balance is %v, but required minimal balance is %v",
+			voterAccount.Balance, tx.Fee).WithErrorCode(result.CodeInsufficientFund)
+	}
+
+	proposal := view.GetProposal(tx.ProposalID)
+	if proposal == nil {
+		return result.Error("Proposal %v not found", tx.ProposalID).WithErrorCode(result.CodeProposalNotFound)
+	}
+	if !proposal.IsActive(view.Height()) {
+		return result.Error("Proposal %v does not accept votes at height %v",
+			tx.ProposalID, view.Height()).WithErrorCode(result.CodeProposalNotActive)
+	}
+
+	// The votes are weighted by the stakes when tallied, but a voter without stake
+	// is rejected early
+	if view.GetVotingStake(tx.Voter.Address) == nil {
+		return result.Error("%v has no stake", tx.Voter.Address.Hex()).WithErrorCode(result.CodeNoVotingStake)
+	}
+
+	return result.OK
+}
+
+func (exec *VoteTxExecutor) process(chainID string, view *st.StoreView, transaction types.Tx) (common.Hash, result.Result) {
+	tx := transaction.(*types.VoteTx)
+
+	voterAddress := tx.Voter.Address
+	voterAccount, success := getInput(view, tx.Voter)
+	if success.IsError() {
+		return common.Hash{}, result.Error("Failed to get the voter account").
+			WithErrorCode(result.CodeAccountNotFound)
+	}
+
+	if !chargeFee(view, voterAccount, tx.Fee) {
+		return common.Hash{}, result.Error("failed to charge transaction fee").
+			WithErrorCode(result.CodeInsufficientFund)
+	}
+
+	view.SetProposalVote(tx.ProposalID, &types.ProposalVote{
+		Voter:   voterAddress,
+		Approve: tx.Approve,
+	})
+
+	voterAccount.Sequence++
+	view.SetAccount(voterAddress, voterAccount)
+
+	txHash := types.TxID(chainID, tx)
+	return txHash, result.OK
+}
+
+func (exec *VoteTxExecutor) getTxInfo(transaction types.Tx) *core.TxInfo {
+	tx := transaction.(*types.VoteTx)
+	return &core.TxInfo{
+		Address:           tx.Voter.Address,
+		Sequence:          tx.Voter.Sequence,
+		EffectiveGasPrice: exec.calculateEffectiveGasPrice(transaction),
+	}
+}
+
+func (exec *VoteTxExecutor) calculateEffectiveGasPrice(transaction types.Tx) *big.Int {
+	tx := transaction.(*types.VoteTx)
+	fee := tx.Fee
+	gas := new(big.Int).SetUint64(types.GasVoteTx)
+	effectiveGasPrice := new(big.Int).Div(fee.GammaWei, gas)
+	return effectiveGasPrice
+}
@@ -0,0 +1,130 @@
+package execution
+
+import (
+	"fmt"
+	"math/big"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/common/result"
+	"github.com/thetatoken/ukulele/core"
+	st "github.com/thetatoken/ukulele/ledger/state"
+	"github.com/thetatoken/ukulele/ledger/types"
+)
+
+var _ TxExecutor = (*WithdrawStakeTxExecutor)(nil)
+
+// ------------------------------- WithdrawStake Transaction -----------------------------------
+
+// WithdrawStakeTxExecutor implements the TxExecutor interface
+type WithdrawStakeTxExecutor struct {
+	state *st.LedgerState
+}
+
+// NewWithdrawStakeTxExecutor creates a new instance of WithdrawStakeTxExecutor
+func NewWithdrawStakeTxExecutor(state *st.LedgerState) *WithdrawStakeTxExecutor {
+	return &WithdrawStakeTxExecutor{
+		state: state,
+	}
+}
+
+func (exec *WithdrawStakeTxExecutor) sanityCheck(chainID string, view *st.StoreView, transaction types.Tx) result.Result {
+	tx := transaction.(*types.WithdrawStakeTx)
+
+	// Validate source, basic
+	res := tx.Source.ValidateBasic()
+	if res.IsError() {
+		return res
+	}
+
+	// Get input account
+	sourceAccount, success := getInput(view, tx.Source)
+	if success.IsError() {
+		return result.Error("Failed to get the source account").WithErrorCode(result.CodeAccountNotFound)
+	}
+
+	// Validate input, advanced
+	signBytes := tx.SignBytes(chainID)
+	res = validateInputAdvanced(sourceAccount, signBytes, tx.Source)
+	if res.IsError() {
+		log.Infof(fmt.Sprintf("validateSourceAdvanced failed on %v: %v", tx.Source.Address.Hex(), res))
+		return res
+	}
+
+	if !tx.Source.Coins.NoNil().IsZero() {
+		return result.Error("Source coins must be zero for a stake withdrawal").
+			WithErrorCode(result.CodeInvalidStake)
+	}
+
+	if !sanityCheckForFee(view, tx.Fee) {
+		return result.Error("Insufficient fee. Transaction fee needs to be at least %v GammaWei",
+			view.GetChainParameters().MinimumTransactionFeeGammaWei).WithErrorCode(result.CodeInvalidFee)
+	}
+
+	if !sourceAccount.Balance.IsGTE(tx.Fee) {
+		return result.Error("Source balance is %v, but required minimal balance is %v",
+			sourceAccount.Balance, tx.Fee).WithErrorCode(result.CodeInsufficientFund)
+	}
+
+	stakeHolder := view.GetStakeHolder(tx.Holder.Address)
+	if stakeHolder == nil || !stakeHolder.HasActiveStake(tx.Source.Address) {
+		return result.Error("No active stake from %v to %v",
+			tx.Source.Address.Hex(), tx.Holder.Address.Hex()).WithErrorCode(result.CodeStakeNotFound)
+	}
+
+	return result.OK
+}
+
+func (exec *WithdrawStakeTxExecutor) process(chainID string, view *st.StoreView, transaction types.Tx) (common.Hash, result.Result) {
+	tx := transaction.(*types.WithdrawStakeTx)
+
+	sourceAddress := tx.Source.Address
+	sourceAccount, success := getInput(view, tx.Source)
+	if success.IsError() {
+		return common.Hash{}, result.Error("Failed to get the source account").
+			WithErrorCode(result.CodeAccountNotFound)
+	}
+
+	stakeHolder := view.GetStakeHolder(tx.Holder.Address)
+	if stakeHolder == nil {
+		return common.Hash{}, result.Error("Stake holder %v not found", tx.Holder.Address.Hex()).
+			WithErrorCode(result.CodeStakeNotFound)
+	}
+
+	returnHeight := exec.state.Height() + types.ReturnLockingPeriod
+	if stakeHolder.WithdrawStake(sourceAddress, returnHeight) == nil {
+		return common.Hash{}, result.Error("No active stake from %v to %v",
+			sourceAddress.Hex(), tx.Holder.Address.Hex()).WithErrorCode(result.CodeStakeNotFound)
+	}
+
+	if !chargeFee(view, sourceAccount, tx.Fee) {
+		return common.Hash{}, result.Error("failed to charge transaction fee").
+			WithErrorCode(result.CodeInsufficientFund)
+	}
+
+	view.SetStakeHolder(stakeHolder)
+
+	sourceAccount.Sequence++
+	view.SetAccount(sourceAddress, sourceAccount)
+
+	txHash := types.TxID(chainID, tx)
+	return txHash, result.OK
+}
+
+func (exec *WithdrawStakeTxExecutor) getTxInfo(transaction types.Tx) *core.TxInfo {
+	tx := transaction.(*types.WithdrawStakeTx)
+	return &core.TxInfo{
+		Address:           tx.Source.Address,
+		Sequence:          tx.Source.Sequence,
+		EffectiveGasPrice: exec.calculateEffectiveGasPrice(transaction),
+	}
+}
+
+func (exec *WithdrawStakeTxExecutor) calculateEffectiveGasPrice(transaction types.Tx) *big.Int {
+	tx := transaction.(*types.WithdrawStakeTx)
+	fee := tx.Fee
+	gas := new(big.Int).SetUint64(types.GasWithdrawStakeTx)
+	effectiveGasPrice := new(big.Int).Div(fee.GammaWei, gas)
+	return effectiveGasPrice
+}
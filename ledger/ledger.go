@@ -7,6 +7,7 @@ import (
 	log "github.com/sirupsen/logrus"
 
 	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/common/metrics"
 	"github.com/thetatoken/ukulele/common/result"
 	"github.com/thetatoken/ukulele/core"
 	"github.com/thetatoken/ukulele/crypto"
@@ -14,7 +15,10 @@ import (
 	st "github.com/thetatoken/ukulele/ledger/state"
 	"github.com/thetatoken/ukulele/ledger/types"
 	mp "github.com/thetatoken/ukulele/mempool"
+	"github.com/thetatoken/ukulele/store"
 	"github.com/thetatoken/ukulele/store/database"
+	"github.com/thetatoken/ukulele/store/kvstore"
+	"github.com/thetatoken/ukulele/store/trie"
 )
 
 var _ core.Ledger = (*Ledger)(nil)
@@ -30,11 +34,23 @@ type Ledger struct {
 	mu       *sync.RWMutex // Lock for accessing ledger state.
 	state    *st.LedgerState
 	executor *exec.Executor
+	store    store.Store // Store of the transaction receipts
+
+	doubleSpends *doubleSpendDetector
+	sigCache     *types.SignatureCache // Signers of the transactions screened by the mempool
+
+	txFilter        TxFilter // Policy on the transactions of the proposed blocks, nil if none
+	excludedTxMeter metrics.Meter
 }
 
 // NewLedger creates an instance of Ledger
 func NewLedger(chainID string, db database.Database, consensus core.ConsensusEngine, valMgr core.ValidatorManager, mempool *mp.Mempool) *Ledger {
+	receiptStore := kvstore.NewKVStore(db)
+	db = trie.NewNodeCacheDatabase(db, common.GetConfig().Storage.TrieNodeCacheSize)
 	state := st.NewLedgerState(chainID, db)
+	if common.GetConfig().Storage.PrunesState() {
+		state.EnablePruning(common.GetConfig().Storage.StatePruningRetainedBlocks)
+	}
 	executor := exec.NewExecutor(state, consensus, valMgr)
 	ledger := &Ledger{
 		consensus: consensus,
@@ -43,6 +59,12 @@ func NewLedger(chainID string, db database.Database, consensus core.ConsensusEng
 		mu:        &sync.RWMutex{},
 		state:     state,
 		executor:  executor,
+		store:     receiptStore,
+
+		doubleSpends: newDoubleSpendDetector(),
+		sigCache:     types.NewSignatureCache(common.GetConfig().Execution.SignatureCacheSize),
+
+		excludedTxMeter: metrics.GetOrRegisterMeter("ledger/proposer/excluded", nil),
 	}
 	return ledger
 }
@@ -71,12 +93,21 @@ func (ledger *Ledger) GetFinalizedSnapshot() (*st.StoreView, error) {
 	return ledger.state.Finalized().Copy()
 }
 
+// NearestRetainedHeight returns the lowest height, at or above the given height, whose
+// state has not been pruned by the node.
+func (ledger *Ledger) NearestRetainedHeight(height uint64) uint64 {
+	ledger.mu.RLock()
+	defer ledger.mu.RUnlock()
+
+	return ledger.state.NearestRetainedHeight(height)
+}
+
 // ScreenTx screens the given transaction
 func (ledger *Ledger) ScreenTx(rawTx common.Bytes) (txInfo *core.TxInfo, res result.Result) {
 	var tx types.Tx
 	tx, err := types.TxFromBytes(rawTx)
 	if err != nil {
-		return nil, result.Error("Error decoding tx: %v", err)
+		return nil, result.Error("Error decoding tx: %v", err).WithErrorCode(result.CodeInvalidTx)
 	}
 
 	if ledger.shouldSkipCheckTx(tx) {
@@ -84,6 +115,11 @@ func (ledger *Ledger) ScreenTx(rawTx common.Bytes) (txInfo *core.TxInfo, res res
 			WithErrorCode(result.CodeUnauthorizedTx)
 	}
 
+	// A transaction spending the sequence of a transaction already included in a block
+	// is rejected below, but alerts the subscribers first
+	txHash := crypto.Keccak256Hash(rawTx)
+	ledger.doubleSpends.check(tx, txHash)
+
 	ledger.mu.RLock()
 	defer ledger.mu.RUnlock()
 
@@ -92,6 +128,10 @@ func (ledger *Ledger) ScreenTx(rawTx common.Bytes) (txInfo *core.TxInfo, res res
 		return nil, res
 	}
 
+	// The signatures have been verified, so the execution of the block including the
+	// transaction can skip them
+	ledger.sigCache.Add(ledger.state.GetChainID(), txHash, tx)
+
 	txInfo, res = ledger.executor.GetTxInfo(tx)
 	if res.IsError() {
 		return nil, res
@@ -101,8 +141,8 @@ func (ledger *Ledger) ScreenTx(rawTx common.Bytes) (txInfo *core.TxInfo, res res
 }
 
 // ProposeBlockTxs collects and executes a list of transactions, which will be used to assemble the next blockl
-// It also clears these transactions from the mempool.
-func (ledger *Ledger) ProposeBlockTxs() (stateRootHash common.Hash, blockRawTxs []common.Bytes, res result.Result) {
+// of the given epoch. It also clears these transactions from the mempool.
+func (ledger *Ledger) ProposeBlockTxs(epoch uint64) (stateRootHash common.Hash, blockRawTxs []common.Bytes, res result.Result) {
 	// Must always acquire locks in following order to avoid deadlock: mempool, ledger.
 	ledger.mempool.Lock()
 	defer ledger.mempool.Unlock()
@@ -111,10 +151,12 @@ func (ledger *Ledger) ProposeBlockTxs() (stateRootHash common.Hash, blockRawTxs
 	defer ledger.mu.Unlock()
 
 	view := ledger.state.Checked()
+	view.SetEpoch(epoch)
 
 	// Add special transactions
 	rawTxCandidates := []common.Bytes{}
 	ledger.addSpecialTransactions(view, &rawTxCandidates)
+	numSpecialTxs := len(rawTxCandidates)
 
 	// Add regular transactions submitted by the clients
 	regularRawTxs := ledger.mempool.ReapUnsafe(core.MaxNumRegularTxsPerBlock)
@@ -122,18 +164,32 @@ func (ledger *Ledger) ProposeBlockTxs() (stateRootHash common.Hash, blockRawTxs
 		rawTxCandidates = append(rawTxCandidates, regularRawTx)
 	}
 
+	// The transactions exceeding the block gas limit are left in the mempool
+	maxBlockGas := view.GetChainParameters().MaxBlockGas
+	blockGas := uint64(0)
+
 	blockRawTxs = []common.Bytes{}
-	for _, rawTxCandidate := range rawTxCandidates {
+	for idx, rawTxCandidate := range rawTxCandidates {
 		tx, err := types.TxFromBytes(rawTxCandidate)
 		if err != nil {
 			continue
 		}
+		// The policy of the operator only applies to the transactions from the mempool
+		if idx >= numSpecialTxs && ledger.excludeTx(tx) {
+			continue
+		}
+		txGas := types.TxGas(tx)
+		if maxBlockGas != 0 && blockGas+txGas > maxBlockGas {
+			continue
+		}
+		ledger.sigCache.Load(ledger.state.GetChainID(), crypto.Keccak256Hash(rawTxCandidate), tx)
 		_, res := ledger.executor.CheckTx(tx)
 		if res.IsError() {
 			log.Errorf("Transaction check failed: errMsg = %v, tx = %v", res.Message, tx)
 			continue
 		}
 		blockRawTxs = append(blockRawTxs, rawTxCandidate)
+		blockGas += txGas
 	}
 
 	stateRootHash = view.Hash()
@@ -143,8 +199,10 @@ func (ledger *Ledger) ProposeBlockTxs() (stateRootHash common.Hash, blockRawTxs
 
 // ApplyBlockTxs applies the given block transactions. If any of the transactions failed, it returns
 // an error immediately. If all the transactions execute successfully, it then validates the state
-// root hash. If the states root hash matches the expected value, it clears the transactions from the mempool
-func (ledger *Ledger) ApplyBlockTxs(blockRawTxs []common.Bytes, expectedStateRoot common.Hash) result.Result {
+// root hash. If the states root hash matches the expected value, it clears the transactions from the mempool.
+// The transactions are executed with the epoch of the block header, so that all the nodes apply the
+// block alike whatever their own epoch.
+func (ledger *Ledger) ApplyBlockTxs(epoch uint64, blockRawTxs []common.Bytes, expectedStateRoot common.Hash) result.Result {
 	// Must always acquire locks in following order to avoid deadlock: mempool, ledger.
 	ledger.mempool.Lock()
 	defer ledger.mempool.Unlock()
@@ -153,20 +211,79 @@ func (ledger *Ledger) ApplyBlockTxs(blockRawTxs []common.Bytes, expectedStateRoo
 	defer ledger.mu.Unlock()
 
 	view := ledger.state.Delivered()
+	view.SetEpoch(epoch)
 
 	currHeight := view.Height()
 	currStateRoot := view.Hash()
 
+	chainID := ledger.state.GetChainID()
+	txs := make([]types.Tx, 0, len(blockRawTxs))
+	txHashes := make([]common.Hash, 0, len(blockRawTxs))
 	for _, rawTx := range blockRawTxs {
 		tx, err := types.TxFromBytes(rawTx)
 		if err != nil {
 			ledger.resetState(currHeight, currStateRoot)
-			return result.Error("Failed to parse transaction: %v", hex.EncodeToString(rawTx))
+			return result.Error("Failed to parse transaction: %v", hex.EncodeToString(rawTx)).
+				WithErrorCode(result.CodeInvalidTx)
 		}
-		_, res := ledger.executor.ExecuteTx(tx)
-		if res.IsError() {
+		txs = append(txs, tx)
+		txHashes = append(txHashes, crypto.Keccak256Hash(rawTx))
+	}
+
+	// The coinbase transaction distributes the block reward and the fee pool, so each
+	// block must lead with one
+	if len(txs) == 0 {
+		ledger.resetState(currHeight, currStateRoot)
+		return result.Error("Block has no coinbase transaction")
+	}
+	if _, ok := txs[0].(*types.CoinbaseTx); !ok {
+		ledger.resetState(currHeight, currStateRoot)
+		return result.Error("The first transaction of the block is not a coinbase transaction")
+	}
+
+	if maxBlockGas := view.GetChainParameters().MaxBlockGas; maxBlockGas != 0 {
+		blockGas := uint64(0)
+		for _, tx := range txs {
+			blockGas += types.TxGas(tx)
+		}
+		if blockGas > maxBlockGas {
 			ledger.resetState(currHeight, currStateRoot)
-			return res
+			return result.Error("Block gas %v exceeds the limit %v", blockGas, maxBlockGas)
+		}
+	}
+
+	// The signatures of the transactions screened by the mempool are not verified
+	// again. The others are batch verified up front, so that the execution below does
+	// not verify them one by one. If the batch fails, the execution reports the
+	// transaction with the invalid signature.
+	for i, tx := range txs {
+		ledger.sigCache.Load(chainID, txHashes[i], tx)
+	}
+	if !types.BatchVerifyTxSignatures(chainID, txs) {
+		log.Debugf("Batch signature verification failed, verifying the block transactions one by one")
+	}
+
+	// The accounts written by the block are recorded to journal their balance changes
+	var parent *st.StoreView
+	if common.GetConfig().Storage.BalanceJournalEnabled {
+		var err error
+		if parent, err = view.Copy(); err != nil {
+			log.Errorf("Failed to copy the parent state, the balance changes are not recorded: %v", err)
+		} else {
+			view.RecordAccesses()
+		}
+	}
+
+	// The independent transactions are executed concurrently
+	txReceipts, _, res := ledger.executor.ExecuteBlockTxs(txs)
+	if res.IsError() {
+		ledger.resetState(currHeight, currStateRoot)
+		return res
+	}
+	receipts := make(map[common.Hash]*types.TxReceipt)
+	for i, receipt := range txReceipts {
+		if receipt != nil {
+			receipts[txHashes[i]] = receipt
 		}
 	}
 
@@ -178,9 +295,22 @@ func (ledger *Ledger) ApplyBlockTxs(blockRawTxs []common.Bytes, expectedStateRoo
 			hex.EncodeToString(expectedStateRoot[:]))
 	}
 
+	var journal *types.BalanceJournal
+	if parent != nil {
+		journal = newBalanceJournal(currHeight+1, parent, view, view.StopRecordingAccesses())
+	}
+
 	ledger.state.Commit() // commit to persistent storage
+	ledger.saveTxReceipts(receipts)
+	if journal != nil {
+		ledger.saveBalanceJournal(journal)
+	}
+	ledger.recordSpends(currHeight, txs, blockRawTxs)
 
 	ledger.mempool.UpdateUnsafe(blockRawTxs) // clear txs from the mempool
+	for _, txHash := range txHashes {
+		ledger.sigCache.Remove(txHash) // the included txs cannot be executed again
+	}
 
 	return result.OK
 }
@@ -202,9 +332,23 @@ func (ledger *Ledger) FinalizeState(height uint64, rootHash common.Hash) result.
 	if res.IsError() {
 		return result.Error("Failed to finalize state root: %v", hex.EncodeToString(rootHash[:]))
 	}
+	ledger.jailValidators()
 	return result.OK
 }
 
+// jailValidators passes the jails of the finalized state to the validator manager, so
+// that all the nodes stop selecting a slashed validator as proposer once its slash is
+// finalized.
+func (ledger *Ledger) jailValidators() {
+	jailer, ok := ledger.valMgr.(core.ValidatorJailer)
+	if !ok {
+		return
+	}
+	for _, jv := range ledger.state.Finalized().GetJailedValidators() {
+		jailer.Jail(jv.Address, jv.JailedEpoch, jv.UntilEpoch)
+	}
+}
+
 // resetState sets the ledger state with the designated root
 func (ledger *Ledger) resetState(height uint64, rootHash common.Hash) result.Result {
 	log.Debugf("Reseting state to height %v, hash %v\n", height, rootHash.Hex())
@@ -213,6 +357,9 @@ func (ledger *Ledger) resetState(height uint64, rootHash common.Hash) result.Res
 	if res.IsError() {
 		return result.Error("Failed to set state root: %v", hex.EncodeToString(rootHash[:]))
 	}
+
+	// The transactions are screened against the current epoch, as there is no block yet
+	ledger.state.Screened().SetEpoch(ledger.consensus.GetEpoch())
 	return result.OK
 }
 
@@ -232,7 +379,7 @@ func (ledger *Ledger) shouldSkipCheckTx(tx types.Tx) bool {
 
 // addSpecialTransactions adds special transactions (e.g. coinbase transaction, slash transaction) to the block
 func (ledger *Ledger) addSpecialTransactions(view *st.StoreView, rawTxs *[]common.Bytes) {
-	epoch := ledger.consensus.GetEpoch()
+	epoch := view.Epoch()
 	proposer := ledger.valMgr.GetProposerForEpoch(epoch)
 	validators := ledger.valMgr.GetValidatorSetForEpoch(epoch).Validators()
 
@@ -247,12 +394,7 @@ func (ledger *Ledger) addCoinbaseTx(view *st.StoreView, proposer *core.Validator
 		Address: proposerAddress,
 	}
 
-	validatorAddresses := make([]common.Address, len(*validators))
-	for idx, validator := range *validators {
-		validatorAddress := validator.Address()
-		validatorAddresses[idx] = validatorAddress
-	}
-	accountRewardMap := exec.CalculateReward(view, validatorAddresses)
+	accountRewardMap := ledger.executor.CalculateReward(view, proposerAddress, *validators)
 
 	coinbaseTxOutputs := []types.TxOutput{}
 	for accountAddressStr, accountReward := range accountRewardMap {
@@ -269,6 +411,7 @@ func (ledger *Ledger) addCoinbaseTx(view *st.StoreView, proposer *core.Validator
 		Outputs:     coinbaseTxOutputs,
 		BlockHeight: ledger.state.Height(),
 	}
+	coinbaseTx.SetEpoch(view.Epoch())
 
 	signature, err := ledger.signTransaction(coinbaseTx)
 	if err != nil {
@@ -323,8 +466,15 @@ func (ledger *Ledger) addSlashTxs(view *st.StoreView, proposer *core.Validator,
 // signTransaction signs the given transaction
 func (ledger *Ledger) signTransaction(tx types.Tx) (*crypto.Signature, error) {
 	chainID := ledger.state.GetChainID()
+	if signer, ok := ledger.consensus.Signer().(core.ValidatorSigner); ok {
+		rawTx, err := types.TxToBytes(tx)
+		if err != nil {
+			return nil, err
+		}
+		return signer.SignProposerTx(chainID, rawTx)
+	}
 	signBytes := tx.SignBytes(chainID)
-	signature, err := ledger.consensus.PrivateKey().Sign(signBytes)
+	signature, err := ledger.consensus.Signer().Sign(signBytes)
 	if err != nil {
 		return nil, err
 	}
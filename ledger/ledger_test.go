@@ -49,6 +49,79 @@ func TestLedgerScreenTx(t *testing.T) {
 	assert.Equal(result.CodeUnauthorizedTx, res.Code, res.Message)
 }
 
+func TestLedgerSignatureCache(t *testing.T) {
+	assert := assert.New(t)
+
+	chainID, ledger, mempool := newTestLedger()
+	accOut, accIns := prepareInitLedgerState(ledger, 2)
+
+	// The signers of the screened transactions are cached, the others are not
+	sendTx1Bytes := newRawSendTx(chainID, 1, true, accOut, accIns[0], false)
+	sendTx2Bytes := newRawSendTx(chainID, 1, true, accOut, accIns[1], false)
+	assert.Nil(mempool.InsertTransaction(sendTx1Bytes))
+	assert.Nil(mempool.InsertTransaction(sendTx2Bytes))
+	assert.Equal(2, ledger.sigCache.Len())
+	_, res := ledger.ScreenTx(newRawSendTx(chainID, 5, true, accOut, accIns[0], false))
+	assert.True(res.IsError())
+	assert.Equal(2, ledger.sigCache.Len())
+
+	// The transactions included in a block are evicted
+	stateRoot, blockRawTxs, res := ledger.ProposeBlockTxs(ledger.consensus.GetEpoch())
+	assert.True(res.IsOK(), res.Message)
+	assert.Equal(3, len(blockRawTxs))
+	res = ledger.ApplyBlockTxs(ledger.consensus.GetEpoch(), blockRawTxs, stateRoot)
+	assert.True(res.IsOK(), res.Message)
+	assert.Equal(0, ledger.sigCache.Len())
+}
+
+func TestLedgerBalanceJournal(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	chainID, ledger, mempool := newTestLedger()
+	accOut, accIns := prepareInitLedgerState(ledger, 1)
+	height := ledger.state.Height() + 1
+	accOutBefore := ledger.state.Delivered().GetAccount(accOut.Address).Balance
+	accInBefore := ledger.state.Delivered().GetAccount(accIns[0].Address).Balance
+
+	require.Nil(mempool.InsertTransaction(newRawSendTx(chainID, 1, true, accOut, accIns[0], false)))
+	stateRoot, blockRawTxs, res := ledger.ProposeBlockTxs(ledger.consensus.GetEpoch())
+	require.True(res.IsOK(), res.Message)
+	res = ledger.ApplyBlockTxs(ledger.consensus.GetEpoch(), blockRawTxs, stateRoot)
+	require.True(res.IsOK(), res.Message)
+
+	journal, ok := ledger.GetBalanceJournal(height)
+	require.True(ok)
+	assert.Equal(height, journal.Height)
+	assert.Equal(stateRoot, journal.StateRoot)
+
+	txFee := getMinimumTxFee()
+	change, ok := journal.Find(accIns[0].Address)
+	require.True(ok)
+	assert.True(accInBefore.IsEqual(change.Before))
+	assert.True(types.NewCoins(-15, -txFee).IsEqual(change.Delta()))
+	change, ok = journal.Find(accOut.Address)
+	require.True(ok)
+	assert.True(accOutBefore.IsEqual(change.Before))
+	assert.True(types.NewCoins(15, 0).IsEqual(change.Delta()))
+
+	// Every balance change is journaled, including the block rewards
+	for _, change := range journal.Changes {
+		account := ledger.state.Delivered().GetAccount(change.Address)
+		require.NotNil(account)
+		assert.True(account.Balance.IsEqual(change.After))
+	}
+
+	value, res := ledger.Query(QueryPathBalanceJournal, balanceJournalKey(height)[len(balanceJournalPrefix):])
+	assert.True(res.IsOK(), res.Message)
+	queried := &types.BalanceJournal{}
+	assert.Nil(types.FromBytes(value, queried))
+	assert.Equal(len(journal.Changes), len(queried.Changes))
+
+	_, ok = ledger.GetBalanceJournal(height + 1)
+	assert.False(ok)
+}
+
 func TestLedgerProposerBlockTxs(t *testing.T) {
 	assert := assert.New(t)
 
@@ -71,7 +144,7 @@ func TestLedgerProposerBlockTxs(t *testing.T) {
 	startTime := time.Now()
 
 	// Propose block transactions
-	_, blockTxs, res := ledger.ProposeBlockTxs()
+	_, blockTxs, res := ledger.ProposeBlockTxs(ledger.consensus.GetEpoch())
 
 	endTime := time.Now()
 	elapsed := endTime.Sub(startTime)
@@ -133,7 +206,7 @@ func TestLedgerApplyBlockTxs(t *testing.T) {
 	}
 	expectedStateRoot := common.HexToHash("0d7bff2377e3638b82b09c21b7d0636ed593d2225164cb9b67f7296432194c58")
 
-	res := ledger.ApplyBlockTxs(blockRawTxs, expectedStateRoot)
+	res := ledger.ApplyBlockTxs(ledger.consensus.GetEpoch(), blockRawTxs, expectedStateRoot)
 	require.True(res.IsOK(), res.Message)
 
 	//
@@ -168,6 +241,83 @@ func TestLedgerApplyBlockTxs(t *testing.T) {
 	}
 }
 
+func TestLedgerApplyBlockTxsWithoutCoinbase(t *testing.T) {
+	assert := assert.New(t)
+
+	chainID, ledger, _ := newTestLedger()
+	accOut, accIns := prepareInitLedgerState(ledger, 1)
+	stateRoot := ledger.state.Delivered().Hash()
+
+	coinbaseTxBytes := newRawCoinbaseTx(chainID, ledger, 1)
+	sendTxBytes := newRawSendTx(chainID, 1, true, accOut, accIns[0], false)
+
+	res := ledger.ApplyBlockTxs(ledger.consensus.GetEpoch(), []common.Bytes{}, stateRoot)
+	assert.True(res.IsError())
+
+	res = ledger.ApplyBlockTxs(ledger.consensus.GetEpoch(), []common.Bytes{sendTxBytes, coinbaseTxBytes}, stateRoot)
+	assert.True(res.IsError())
+	assert.Equal(stateRoot, ledger.state.Delivered().Hash())
+}
+
+func TestLedgerTxReceipts(t *testing.T) {
+	assert := assert.New(t)
+
+	_, ledger, _ := newTestLedger()
+
+	hash := crypto.Keccak256Hash(common.Bytes("raw_tx"))
+	_, found := ledger.GetTxReceipt(hash)
+	assert.False(found)
+
+	receipt := &types.TxReceipt{
+		GasLimit: 50000,
+		GasPrice: big.NewInt(1e9),
+		GasUsed:  26000,
+		Fee:      types.GasFee(big.NewInt(1e9), 26000),
+		Refund:   types.GasFee(big.NewInt(1e9), 24000),
+	}
+	ledger.saveTxReceipts(map[common.Hash]*types.TxReceipt{hash: receipt})
+
+	retrieved, found := ledger.GetTxReceipt(hash)
+	assert.True(found)
+	assert.Equal(receipt.GasUsed, retrieved.GasUsed)
+	assert.True(receipt.Fee.IsEqual(retrieved.Fee))
+	assert.True(receipt.Refund.IsEqual(retrieved.Refund))
+}
+
+func TestLedgerQuery(t *testing.T) {
+	assert := assert.New(t)
+
+	_, ledger, _ := newTestLedger()
+	accOut, _ := prepareInitLedgerState(ledger, 1)
+
+	value, res := ledger.Query(QueryPathAccount, accOut.Account.Address.Bytes())
+	assert.True(res.IsOK(), res.Message)
+	account := &types.Account{}
+	assert.Nil(types.FromBytes(value, account))
+	assert.Equal(accOut.Account.Address, account.Address)
+	assert.True(accOut.Account.Balance.IsEqual(account.Balance))
+
+	_, res = ledger.Query(QueryPathAccount, common.HexToAddress("0x1234").Bytes())
+	assert.Equal(result.CodeAccountNotFound, res.Code)
+	_, res = ledger.Query(QueryPathAccount, common.Bytes("short"))
+	assert.Equal(result.CodeInvalidQuery, res.Code)
+	_, res = ledger.Query(QueryPathSplitRule, common.Bytes("rid0"))
+	assert.Equal(result.CodeSplitRuleNotFound, res.Code)
+
+	hash := crypto.Keccak256Hash(common.Bytes("raw_tx"))
+	_, res = ledger.Query(QueryPathReceipt, hash.Bytes())
+	assert.Equal(result.CodeReceiptNotFound, res.Code)
+	ledger.saveTxReceipts(map[common.Hash]*types.TxReceipt{hash: {GasUsed: 26000}})
+	value, res = ledger.Query(QueryPathReceipt, hash.Bytes())
+	assert.True(res.IsOK(), res.Message)
+	receipt := &types.TxReceipt{}
+	assert.Nil(types.FromBytes(value, receipt))
+	assert.Equal(uint64(26000), receipt.GasUsed)
+
+	_, res = ledger.Query("unknown", nil)
+	assert.Equal(result.CodeInvalidQuery, res.Code)
+}
+
 // ----------- Utilities ----------- //
 
 func newTestLedger() (chainID string, ledger *Ledger, mempool *mp.Mempool) {
@@ -0,0 +1,79 @@
+package ledger
+
+import (
+	"encoding/binary"
+
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/common/result"
+	"github.com/thetatoken/ukulele/ledger/types"
+)
+
+// Paths of the data that can be queried from the ledger
+const (
+	QueryPathAccount        = "account"         // data: 20-byte address, value: RLP encoded types.Account
+	QueryPathSplitRule      = "split_rule"      // data: resource ID, value: RLP encoded types.SplitRule
+	QueryPathReceipt        = "receipt"         // data: 32-byte tx hash, value: RLP encoded types.TxReceipt
+	QueryPathBalanceJournal = "balance_journal" // data: 8-byte big-endian height, value: RLP encoded types.BalanceJournal
+)
+
+// Query implements the core.Ledger interface. The accounts and the split rules are
+// read from the delivered state, as the RPC queries do by default.
+func (ledger *Ledger) Query(path string, data common.Bytes) (value common.Bytes, res result.Result) {
+	var obj interface{}
+	switch path {
+	case QueryPathAccount:
+		if len(data) != common.AddressLength {
+			return nil, result.Error("Invalid address length: %v", len(data)).WithErrorCode(result.CodeInvalidQuery)
+		}
+		view, err := ledger.GetDeliveredSnapshot()
+		if err != nil {
+			return nil, result.Error("Failed to get the delivered state: %v", err)
+		}
+		account := view.GetAccount(common.BytesToAddress(data))
+		if account == nil {
+			return nil, result.Error("Account not found: %v", common.BytesToAddress(data).Hex()).
+				WithErrorCode(result.CodeAccountNotFound)
+		}
+		obj = account
+	case QueryPathSplitRule:
+		view, err := ledger.GetDeliveredSnapshot()
+		if err != nil {
+			return nil, result.Error("Failed to get the delivered state: %v", err)
+		}
+		splitRule := view.GetSplitRule(string(data))
+		if splitRule == nil {
+			return nil, result.Error("Split rule not found: %v", string(data)).
+				WithErrorCode(result.CodeSplitRuleNotFound)
+		}
+		obj = splitRule
+	case QueryPathReceipt:
+		if len(data) != common.HashLength {
+			return nil, result.Error("Invalid transaction hash length: %v", len(data)).WithErrorCode(result.CodeInvalidQuery)
+		}
+		receipt, ok := ledger.GetTxReceipt(common.BytesToHash(data))
+		if !ok {
+			return nil, result.Error("Receipt not found: %v", common.BytesToHash(data).Hex()).
+				WithErrorCode(result.CodeReceiptNotFound)
+		}
+		obj = receipt
+	case QueryPathBalanceJournal:
+		if len(data) != 8 {
+			return nil, result.Error("Invalid height length: %v", len(data)).WithErrorCode(result.CodeInvalidQuery)
+		}
+		height := binary.BigEndian.Uint64(data)
+		journal, ok := ledger.GetBalanceJournal(height)
+		if !ok {
+			return nil, result.Error("Balance journal not found: %v", height).
+				WithErrorCode(result.CodeBalanceJournalNotFound)
+		}
+		obj = journal
+	default:
+		return nil, result.Error("Unknown query path: %v", path).WithErrorCode(result.CodeInvalidQuery)
+	}
+
+	value, err := types.ToBytes(obj)
+	if err != nil {
+		return nil, result.Error("Failed to encode the query result: %v", err)
+	}
+	return value, result.OK
+}
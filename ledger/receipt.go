@@ -0,0 +1,51 @@
+package ledger
+
+import (
+	log "github.com/sirupsen/logrus"
+
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/ledger/types"
+	"github.com/thetatoken/ukulele/store"
+)
+
+// txReceiptPrefix prefixes the DB keys of the transaction receipts. The receipts are
+// stored next to the state trie rather than in it, since they are not consensus state.
+var txReceiptPrefix = common.Bytes("txreceipt/")
+
+// txReceiptKey constructs the DB key for the given transaction hash.
+func txReceiptKey(hash common.Hash) common.Bytes {
+	return append(common.CopyBytes(txReceiptPrefix), hash[:]...)
+}
+
+// saveTxReceipts stores the receipts of the transactions of an applied block, by the
+// hash of the raw transactions, as indexed by the chain.
+func (ledger *Ledger) saveTxReceipts(receipts map[common.Hash]*types.TxReceipt) {
+	if len(receipts) == 0 {
+		return
+	}
+	batch := ledger.store.NewBatch()
+	for hash, receipt := range receipts {
+		if err := batch.Put(txReceiptKey(hash), receipt); err != nil {
+			log.Panic(err)
+		}
+	}
+	if err := batch.Write(); err != nil {
+		log.Panic(err)
+	}
+}
+
+// GetTxReceipt returns the receipt of the transaction with the given hash, as executed
+// in the last block applied that includes it. Only the transactions paying for gas have
+// a receipt.
+func (ledger *Ledger) GetTxReceipt(hash common.Hash) (*types.TxReceipt, bool) {
+	receipt := &types.TxReceipt{}
+	err := ledger.store.Get(txReceiptKey(hash), receipt)
+	if err == store.ErrKeyNotFound {
+		return nil, false
+	}
+	if err != nil {
+		log.Errorf("Failed to load the receipt of transaction %v: %v", hash.Hex(), err)
+		return nil, false
+	}
+	return receipt, true
+}
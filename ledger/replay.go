@@ -0,0 +1,130 @@
+package ledger
+
+import (
+	"fmt"
+
+	"github.com/thetatoken/ukulele/blockchain"
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/core"
+	"github.com/thetatoken/ukulele/crypto"
+	exec "github.com/thetatoken/ukulele/ledger/execution"
+	st "github.com/thetatoken/ukulele/ledger/state"
+	"github.com/thetatoken/ukulele/ledger/types"
+	"github.com/thetatoken/ukulele/store/database"
+)
+
+// Divergence is the first finalized block whose transactions do not re-execute to the
+// state root in its header.
+type Divergence struct {
+	Block    common.Hash
+	Height   uint64
+	Expected common.Hash // State root in the block header
+	Computed common.Hash // State root computed by the replay, empty if a tx failed
+	TxIndex  int         // Index of the transaction that failed, or -1
+	Error    string
+}
+
+func (d Divergence) String() string {
+	if d.TxIndex >= 0 {
+		return fmt.Sprintf("block %v at height %v: transaction %v failed: %v", d.Block.Hex(), d.Height, d.TxIndex, d.Error)
+	}
+	return fmt.Sprintf("block %v at height %v: computed state root %v, expected %v",
+		d.Block.Hex(), d.Height, d.Computed.Hex(), d.Expected.Hex())
+}
+
+// ReplayReport is the result of Replay.
+type ReplayReport struct {
+	Blocks     uint64              // Number of blocks replayed
+	Head       *core.ExtendedBlock // Last block replayed without divergence
+	Divergence *Divergence         // nil if all the blocks replayed to their state roots
+}
+
+// Replay re-executes the transactions of the finalized blocks of the chain, from the
+// first block after the root, on top of the ledger state of the root found in db. It
+// compares the state root computed for each block with the one in its header, and
+// stops at the first divergence. The progress callback, if any, is called after each
+// block is replayed.
+func Replay(chain *blockchain.Chain, db database.Database, valMgr core.ValidatorManager, progress func(block *core.ExtendedBlock)) (*ReplayReport, error) {
+	if progress == nil {
+		progress = func(*core.ExtendedBlock) {}
+	}
+
+	state := st.NewLedgerState(chain.ChainID, db)
+	engine := &replayConsensusEngine{}
+	executor := exec.NewExecutor(state, engine, valMgr)
+
+	report := &ReplayReport{Head: chain.Root}
+	for {
+		block := nextFinalizedBlock(chain, report.Head)
+		if block == nil {
+			return report, nil
+		}
+		engine.block = block
+
+		// Like the consensus engine, execute each block on a fresh view of its parent
+		if res := state.ResetState(report.Head.Height, report.Head.StateHash); res.IsError() {
+			return nil, fmt.Errorf("State of block %v is not in the DB: %v", report.Head.Hash().Hex(), res.Message)
+		}
+		view := state.Delivered()
+		view.SetEpoch(block.Epoch)
+		txs := make([]types.Tx, 0, len(block.Txs))
+		for idx, rawTx := range block.Txs {
+			tx, err := types.TxFromBytes(rawTx)
+			if err != nil {
+				report.Divergence = newDivergence(block, idx, err.Error())
+				return report, nil
+			}
+			txs = append(txs, tx)
+		}
+		if _, idx, res := executor.ExecuteBlockTxs(txs); res.IsError() {
+			report.Divergence = newDivergence(block, idx, res.Message)
+			return report, nil
+		}
+		if computed := view.Hash(); computed != block.StateHash {
+			report.Divergence = newDivergence(block, -1, "")
+			report.Divergence.Computed = computed
+			return report, nil
+		}
+		state.Commit()
+
+		report.Blocks++
+		report.Head = block
+		progress(block)
+	}
+}
+
+func newDivergence(block *core.ExtendedBlock, txIndex int, err string) *Divergence {
+	return &Divergence{
+		Block:    block.Hash(),
+		Height:   block.Height,
+		Expected: block.StateHash,
+		TxIndex:  txIndex,
+		Error:    err,
+	}
+}
+
+// nextFinalizedBlock returns the finalized child of the block, or nil if there is none.
+func nextFinalizedBlock(chain *blockchain.Chain, parent *core.ExtendedBlock) *core.ExtendedBlock {
+	for _, block := range chain.FindBlocksByHeight(parent.Height + 1) {
+		if block.Status == core.BlockStatusFinalized && block.Parent == parent.Hash() {
+			return block
+		}
+	}
+	return nil
+}
+
+// replayConsensusEngine stands in for the consensus engine of the executor, and reports
+// the epoch of the block being replayed. The executors take the epoch from the view.
+type replayConsensusEngine struct {
+	block *core.ExtendedBlock
+}
+
+var _ core.ConsensusEngine = (*replayConsensusEngine)(nil)
+
+func (e *replayConsensusEngine) ID() string                        { return "" }
+func (e *replayConsensusEngine) PrivateKey() *crypto.PrivateKey    { return nil }
+func (e *replayConsensusEngine) Signer() core.Signer               { return nil }
+func (e *replayConsensusEngine) GetTip() *core.ExtendedBlock       { return nil }
+func (e *replayConsensusEngine) GetEpoch() uint64                  { return e.block.Epoch }
+func (e *replayConsensusEngine) AddMessage(msg interface{})        {}
+func (e *replayConsensusEngine) FinalizedBlocks() chan *core.Block { return nil }
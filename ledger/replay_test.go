@@ -0,0 +1,89 @@
+package ledger
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thetatoken/ukulele/blockchain"
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/core"
+	st "github.com/thetatoken/ukulele/ledger/state"
+	"github.com/thetatoken/ukulele/store/database/backend"
+	"github.com/thetatoken/ukulele/store/kvstore"
+)
+
+func TestReplay(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	chainID, ledger, mempool := newTestLedger()
+	accOut, accIns := prepareInitLedgerState(ledger, 3)
+
+	root := core.NewBlock()
+	root.ChainID = chainID
+	root.Height = ledger.state.Height()
+	root.StateHash = ledger.state.Delivered().Hash()
+	chain := blockchain.NewChain(chainID, kvstore.NewKVStore(backend.NewMemDatabase()), root)
+
+	// Copy the state of the root to a fresh DB
+	replayDB := backend.NewMemDatabase()
+	rootState := st.NewStoreView(0, common.Hash{}, replayDB)
+	ledger.state.Delivered().GetStore().Traverse(nil, func(k, v common.Bytes) bool {
+		rootState.Set(k, v)
+		return false
+	})
+	require.Equal(root.StateHash, rootState.Save())
+
+	// Finalize blocks with a coinbase transaction and a send transaction each
+	parent := root
+	for i, accIn := range accIns {
+		require.Nil(mempool.InsertTransaction(newRawSendTx(chainID, 1, true, accOut, accIn, false)))
+		ledger.ResetState(parent.Height, parent.StateHash)
+		epoch := uint64(i + 1)
+		stateHash, txs, res := ledger.ProposeBlockTxs(epoch)
+		require.True(res.IsOK(), res.Message)
+		require.Equal(2, len(txs))
+
+		block := core.NewBlock()
+		block.ChainID = chainID
+		block.Epoch = epoch
+		block.Parent = parent.Hash()
+		block.Height = parent.Height + 1
+		block.Txs = txs
+		block.TxHash = core.CalculateTxHash(txs)
+		block.StateHash = stateHash
+		res = ledger.ApplyBlockTxs(epoch, txs, stateHash)
+		require.True(res.IsOK(), res.Message)
+
+		eb, err := chain.AddBlock(block)
+		require.Nil(err)
+		chain.FinalizeBlock(eb)
+		parent = block
+	}
+
+	report, err := Replay(chain, replayDB, ledger.valMgr, nil)
+	require.Nil(err)
+	assert.Nil(report.Divergence)
+	assert.Equal(uint64(len(accIns)), report.Blocks)
+	assert.Equal(parent.Hash(), report.Head.Hash())
+
+	// A block whose state root does not match its transactions
+	bad := core.NewBlock()
+	bad.ChainID = chainID
+	bad.Parent = parent.Hash()
+	bad.Height = parent.Height + 1
+	bad.StateHash = common.HexToHash("ff")
+	eb, err := chain.AddBlock(bad)
+	require.Nil(err)
+	chain.FinalizeBlock(eb)
+
+	report, err = Replay(chain, replayDB, ledger.valMgr, nil)
+	require.Nil(err)
+	require.NotNil(report.Divergence)
+	assert.Equal(bad.Hash(), report.Divergence.Block)
+	assert.Equal(bad.Height, report.Divergence.Height)
+	assert.Equal(parent.StateHash, report.Divergence.Computed)
+	assert.Equal(-1, report.Divergence.TxIndex)
+	assert.Equal(parent.Hash(), report.Head.Hash())
+}
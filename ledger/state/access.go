@@ -0,0 +1,82 @@
+package state
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/thetatoken/ukulele/common"
+)
+
+//
+// AccessSet records the keys of the state read and written by a transaction, and the
+// key prefixes it traversed
+//
+type AccessSet struct {
+	reads     map[string]bool
+	writes    map[string]bool
+	traversed []common.Bytes
+}
+
+// NewAccessSet creates an empty AccessSet
+func NewAccessSet() *AccessSet {
+	return &AccessSet{
+		reads:  make(map[string]bool),
+		writes: make(map[string]bool),
+	}
+}
+
+// AddRead records a key read
+func (as *AccessSet) AddRead(key common.Bytes) {
+	as.reads[string(key)] = true
+}
+
+// AddWrite records a key written or deleted
+func (as *AccessSet) AddWrite(key common.Bytes) {
+	as.writes[string(key)] = true
+}
+
+// AddTraversal records a traversal of the keys with the prefix
+func (as *AccessSet) AddTraversal(prefix common.Bytes) {
+	as.traversed = append(as.traversed, common.CopyBytes(prefix))
+}
+
+// Merge adds the accesses of the other set to the set
+func (as *AccessSet) Merge(other *AccessSet) {
+	for key := range other.reads {
+		as.reads[key] = true
+	}
+	for key := range other.writes {
+		as.writes[key] = true
+	}
+	as.traversed = append(as.traversed, other.traversed...)
+}
+
+// WrittenKeys returns the keys written, in increasing order
+func (as *AccessSet) WrittenKeys() []common.Bytes {
+	keys := make([]common.Bytes, 0, len(as.writes))
+	for key := range as.writes {
+		keys = append(keys, common.Bytes(key))
+	}
+	sort.Slice(keys, func(i, j int) bool { return bytes.Compare(keys[i], keys[j]) < 0 })
+	return keys
+}
+
+// Conflicts returns true if one of the sets writes a key the other one reads, writes
+// or traverses, i.e. if the order of the two transactions matters
+func (as *AccessSet) Conflicts(other *AccessSet) bool {
+	return as.writesAccessedBy(other) || other.writesAccessedBy(as)
+}
+
+func (as *AccessSet) writesAccessedBy(other *AccessSet) bool {
+	for key := range as.writes {
+		if other.reads[key] || other.writes[key] {
+			return true
+		}
+		for _, prefix := range other.traversed {
+			if bytes.HasPrefix(common.Bytes(key), prefix) {
+				return true
+			}
+		}
+	}
+	return false
+}
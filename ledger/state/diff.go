@@ -0,0 +1,226 @@
+package state
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/common/hexutil"
+	"github.com/thetatoken/ukulele/ledger/types"
+	"github.com/thetatoken/ukulele/store/trie"
+)
+
+// StateDiff is the difference between two ledger states, e.g. the states a chain
+// replays to before and after a protocol upgrade. The storage of a smart contract
+// is compared through the storage root of its account.
+type StateDiff struct {
+	Accounts   []*AccountDiff   `json:"accounts"`
+	Reserves   []*ReserveDiff   `json:"reserves"`
+	SplitRules []*SplitRuleDiff `json:"split_rules"`
+	Others     []*KeyDiff       `json:"others"` // Stakes, tokens, codes, the fee pool, etc.
+}
+
+// IsEmpty returns whether the two states are identical.
+func (diff *StateDiff) IsEmpty() bool {
+	return len(diff.Accounts) == 0 && len(diff.Reserves) == 0 && len(diff.SplitRules) == 0 && len(diff.Others) == 0
+}
+
+// AccountDiff is an account created, deleted or changed.
+type AccountDiff struct {
+	Address common.Address `json:"address"`
+	Before  *types.Account `json:"before"` // Nil if the account is created
+	After   *types.Account `json:"after"`  // Nil if the account is deleted
+	Fields  []string       `json:"fields"` // Names of the fields changed
+}
+
+// ReserveDiff is a reserved fund created, released or changed. The reserved funds of
+// an account are matched by reserve sequence.
+type ReserveDiff struct {
+	Address         common.Address      `json:"address"`
+	ReserveSequence common.JSONUint64   `json:"reserve_sequence"`
+	Before          *types.ReservedFund `json:"before"`
+	After           *types.ReservedFund `json:"after"`
+}
+
+// SplitRuleDiff is a split rule created, deleted or changed.
+type SplitRuleDiff struct {
+	ResourceID string           `json:"resource_id"`
+	Before     *types.SplitRule `json:"before"`
+	After      *types.SplitRule `json:"after"`
+}
+
+// KeyDiff is any other key of the state whose value differs, with a nil value for a
+// missing key.
+type KeyDiff struct {
+	Key    hexutil.Bytes `json:"key"`
+	Before hexutil.Bytes `json:"before"`
+	After  hexutil.Bytes `json:"after"`
+}
+
+// DiffStates compares two ledger states. Only the subtrees whose hashes differ are
+// iterated, so the cost depends on the size of the difference rather than the size
+// of the states.
+func DiffStates(before, after *StoreView) (*StateDiff, error) {
+	keys, err := diffKeys(before.store.Trie, after.store.Trie)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := &StateDiff{
+		Accounts:   []*AccountDiff{},
+		Reserves:   []*ReserveDiff{},
+		SplitRules: []*SplitRuleDiff{},
+		Others:     []*KeyDiff{},
+	}
+	for _, key := range keys {
+		beforeValue := before.Get(key)
+		afterValue := after.Get(key)
+		switch {
+		case bytes.HasPrefix(key, AccountKeyPrefix()):
+			accDiff, err := diffAccounts(common.BytesToAddress(key[len(AccountKeyPrefix()):]), beforeValue, afterValue)
+			if err != nil {
+				return nil, err
+			}
+			diff.Accounts = append(diff.Accounts, accDiff)
+			diff.Reserves = append(diff.Reserves, diffReserves(accDiff)...)
+		case bytes.HasPrefix(key, SplitRuleKeyPrefix()):
+			ruleDiff := &SplitRuleDiff{ResourceID: string(key[len(SplitRuleKeyPrefix()):])}
+			if ruleDiff.Before, err = decodeSplitRule(beforeValue); err != nil {
+				return nil, err
+			}
+			if ruleDiff.After, err = decodeSplitRule(afterValue); err != nil {
+				return nil, err
+			}
+			diff.SplitRules = append(diff.SplitRules, ruleDiff)
+		default:
+			diff.Others = append(diff.Others, &KeyDiff{Key: hexutil.Bytes(key), Before: hexutil.Bytes(beforeValue), After: hexutil.Bytes(afterValue)})
+		}
+	}
+	return diff, nil
+}
+
+// diffKeys returns the sorted keys whose values differ between the two tries.
+func diffKeys(before, after *trie.Trie) ([]common.Bytes, error) {
+	seen := map[string]bool{}
+	keys := []common.Bytes{}
+	for _, pair := range [][2]*trie.Trie{{before, after}, {after, before}} {
+		diffIt, _ := trie.NewDifferenceIterator(pair[0].NodeIterator(nil), pair[1].NodeIterator(nil))
+		it := trie.NewIterator(diffIt)
+		for it.Next() {
+			if !seen[string(it.Key)] {
+				seen[string(it.Key)] = true
+				keys = append(keys, common.CopyBytes(it.Key))
+			}
+		}
+		if it.Err != nil {
+			return nil, it.Err
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return bytes.Compare(keys[i], keys[j]) < 0 })
+	return keys, nil
+}
+
+func diffAccounts(address common.Address, beforeValue, afterValue common.Bytes) (*AccountDiff, error) {
+	accDiff := &AccountDiff{Address: address, Fields: []string{}}
+	var err error
+	if accDiff.Before, err = decodeAccount(beforeValue); err != nil {
+		return nil, err
+	}
+	if accDiff.After, err = decodeAccount(afterValue); err != nil {
+		return nil, err
+	}
+	if accDiff.Before == nil || accDiff.After == nil {
+		return accDiff, nil
+	}
+
+	b, a := accDiff.Before, accDiff.After
+	fields := []struct {
+		name          string
+		before, after interface{}
+	}{
+		{"sequence", b.Sequence, a.Sequence},
+		{"coins", b.Balance, a.Balance},
+		{"reserved_funds", b.ReservedFunds, a.ReservedFunds},
+		{"last_updated_block_height", b.LastUpdatedBlockHeight, a.LastUpdatedBlockHeight},
+		{"root", b.Root, a.Root},
+		{"code", b.CodeHash, a.CodeHash},
+		{"guardianship", b.GetGuardianship(), a.GetGuardianship()},
+		{"locked_coins", b.GetLockedCoins(), a.GetLockedCoins()},
+	}
+	for _, field := range fields {
+		if !equalEncodings(field.before, field.after) {
+			accDiff.Fields = append(accDiff.Fields, field.name)
+		}
+	}
+	return accDiff, nil
+}
+
+// diffReserves matches the reserved funds of the account before and after by reserve
+// sequence.
+func diffReserves(accDiff *AccountDiff) []*ReserveDiff {
+	funds := map[uint64]*ReserveDiff{}
+	sequences := []uint64{}
+	fundDiff := func(sequence uint64) *ReserveDiff {
+		if funds[sequence] == nil {
+			funds[sequence] = &ReserveDiff{Address: accDiff.Address, ReserveSequence: common.JSONUint64(sequence)}
+			sequences = append(sequences, sequence)
+		}
+		return funds[sequence]
+	}
+	if accDiff.Before != nil {
+		for i := range accDiff.Before.ReservedFunds {
+			fund := &accDiff.Before.ReservedFunds[i]
+			fundDiff(fund.ReserveSequence).Before = fund
+		}
+	}
+	if accDiff.After != nil {
+		for i := range accDiff.After.ReservedFunds {
+			fund := &accDiff.After.ReservedFunds[i]
+			fundDiff(fund.ReserveSequence).After = fund
+		}
+	}
+
+	sort.Slice(sequences, func(i, j int) bool { return sequences[i] < sequences[j] })
+	diffs := []*ReserveDiff{}
+	for _, sequence := range sequences {
+		fundDiff := funds[sequence]
+		if fundDiff.Before == nil || fundDiff.After == nil || !equalEncodings(fundDiff.Before, fundDiff.After) {
+			diffs = append(diffs, fundDiff)
+		}
+	}
+	return diffs
+}
+
+func decodeAccount(value common.Bytes) (*types.Account, error) {
+	if value == nil {
+		return nil, nil
+	}
+	acc := &types.Account{}
+	if err := types.FromBytes(value, acc); err != nil {
+		return nil, fmt.Errorf("Error reading account %X: %v", value, err)
+	}
+	return acc, nil
+}
+
+func decodeSplitRule(value common.Bytes) (*types.SplitRule, error) {
+	if value == nil {
+		return nil, nil
+	}
+	splitRule := &types.SplitRule{}
+	if err := types.FromBytes(value, splitRule); err != nil {
+		return nil, fmt.Errorf("Error reading split rule %X: %v", value, err)
+	}
+	return splitRule, nil
+}
+
+// equalEncodings compares the RLP encodings of the values, so that e.g. a nil and a
+// zero amount compare as the ledger stores them.
+func equalEncodings(a, b interface{}) bool {
+	aBytes, aErr := types.ToBytes(a)
+	bBytes, bErr := types.ToBytes(b)
+	if aErr != nil || bErr != nil {
+		return false
+	}
+	return bytes.Equal(aBytes, bBytes)
+}
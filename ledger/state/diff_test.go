@@ -0,0 +1,78 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/ledger/types"
+	"github.com/thetatoken/ukulele/store/database/backend"
+)
+
+func TestDiffStates(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	db := backend.NewMemDatabase()
+	alice := common.HexToAddress("0xa11ce")
+	bob := common.HexToAddress("0xb0b")
+	carol := common.HexToAddress("0xca201")
+
+	before := NewStoreView(10, common.Hash{}, db)
+	aliceAcc := &types.Account{Address: alice, Sequence: 3, Balance: types.NewCoins(100, 200)}
+	aliceAcc.ReserveFund(types.NewCoins(0, 10), types.NewCoins(0, 20), []string{"rid1"}, 100, 1, nil)
+	aliceAcc.ReserveFund(types.NewCoins(0, 10), types.NewCoins(0, 20), []string{"rid2"}, 100, 2, nil)
+	before.SetAccount(alice, aliceAcc)
+	before.SetAccount(bob, &types.Account{Address: bob, Balance: types.NewCoins(1, 1)})
+	before.SetSplitRule("rid1", &types.SplitRule{InitiatorAddress: alice, ResourceID: "rid1", EndBlockHeight: 50})
+	before.SetSplitRule("rid2", &types.SplitRule{InitiatorAddress: alice, ResourceID: "rid2", EndBlockHeight: 50})
+	before.SetFeePool(types.NewCoins(0, 5))
+	root := before.Save()
+
+	diff, err := DiffStates(before, NewStoreView(10, root, db))
+	require.Nil(err)
+	assert.True(diff.IsEmpty())
+
+	// Alice pays from her first reserve and releases the second, Bob is deleted and
+	// Carol created, a split rule is extended and the fee pool is distributed. The
+	// accounts are sorted by address
+	after := NewStoreView(10, root, db)
+	aliceAcc = after.GetAccount(alice)
+	aliceAcc.Sequence = 4
+	aliceAcc.ReservedFunds[0].UsedFund = types.NewCoins(0, 5)
+	aliceAcc.ReservedFunds = aliceAcc.ReservedFunds[:1]
+	after.SetAccount(alice, aliceAcc)
+	after.DeleteAccount(bob)
+	after.SetAccount(carol, &types.Account{Address: carol, Balance: types.NewCoins(0, 5)})
+	after.SetSplitRule("rid2", &types.SplitRule{InitiatorAddress: alice, ResourceID: "rid2", EndBlockHeight: 80})
+	after.SetFeePool(types.NewCoins(0, 0))
+	after.Save()
+
+	diff, err = DiffStates(before, after)
+	require.Nil(err)
+	require.Len(diff.Accounts, 3)
+	assert.Equal(bob, diff.Accounts[0].Address)
+	assert.NotNil(diff.Accounts[0].Before)
+	assert.Nil(diff.Accounts[0].After)
+	assert.Equal(alice, diff.Accounts[1].Address)
+	assert.Equal([]string{"sequence", "reserved_funds"}, diff.Accounts[1].Fields)
+	assert.Equal(carol, diff.Accounts[2].Address)
+	assert.Nil(diff.Accounts[2].Before)
+	assert.NotNil(diff.Accounts[2].After)
+
+	require.Len(diff.Reserves, 2)
+	assert.Equal(common.JSONUint64(1), diff.Reserves[0].ReserveSequence)
+	assert.True(types.NewCoins(0, 5).IsEqual(diff.Reserves[0].After.UsedFund))
+	assert.Equal(common.JSONUint64(2), diff.Reserves[1].ReserveSequence)
+	assert.NotNil(diff.Reserves[1].Before)
+	assert.Nil(diff.Reserves[1].After)
+
+	require.Len(diff.SplitRules, 1)
+	assert.Equal("rid2", diff.SplitRules[0].ResourceID)
+	assert.Equal(uint64(50), diff.SplitRules[0].Before.EndBlockHeight)
+	assert.Equal(uint64(80), diff.SplitRules[0].After.EndBlockHeight)
+
+	require.Len(diff.Others, 1)
+	assert.Equal(FeePoolKey(), common.Bytes(diff.Others[0].Key))
+}
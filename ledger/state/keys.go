@@ -1,6 +1,10 @@
 package state
 
-import "github.com/thetatoken/ukulele/common"
+import (
+	"encoding/binary"
+
+	"github.com/thetatoken/ukulele/common"
+)
 
 //
 // ------------------------- Ledger State Keys -------------------------
@@ -11,9 +15,14 @@ func ChainIDKey() common.Bytes {
 	return common.Bytes("chainid")
 }
 
+// AccountKeyPrefix returns the prefix for the account key
+func AccountKeyPrefix() common.Bytes {
+	return common.Bytes("ls/a/")
+}
+
 // AccountKey construct the state key for the given address
 func AccountKey(addr common.Address) common.Bytes {
-	return append(common.Bytes("ls/a/"), addr[:]...)
+	return append(AccountKeyPrefix(), addr[:]...)
 }
 
 // SplitRuleKeyPrefix returns the prefix for the split rule key
@@ -27,7 +36,127 @@ func SplitRuleKey(resourceID string) common.Bytes {
 	return append(SplitRuleKeyPrefix(), resourceIDBytes[:]...)
 }
 
+// TokenKeyPrefix returns the prefix for the token key
+func TokenKeyPrefix() common.Bytes {
+	return common.Bytes("ls/tk/")
+}
+
+// TokenKey construct the state key for the given token symbol
+func TokenKey(symbol string) common.Bytes {
+	return append(TokenKeyPrefix(), common.Bytes(symbol)...)
+}
+
 // CodeKey construct the state key for the given code hash
 func CodeKey(codeHash common.Bytes) common.Bytes {
 	return append(common.Bytes("ls/ch/"), codeHash...)
 }
+
+// StakeHolderKeyPrefix returns the prefix for the stake holder key
+func StakeHolderKeyPrefix() common.Bytes {
+	return common.Bytes("ls/sh/")
+}
+
+// StakeHolderKey construct the state key for the given stake holder address
+func StakeHolderKey(holder common.Address) common.Bytes {
+	return append(StakeHolderKeyPrefix(), holder[:]...)
+}
+
+// StakeReturnKeyPrefix returns the prefix for the keys of the stake holders with withdrawn stakes
+// returned at the given height
+func StakeReturnKeyPrefix(height uint64) common.Bytes {
+	heightBytes := make(common.Bytes, 8)
+	binary.BigEndian.PutUint64(heightBytes, height)
+	return append(append(common.Bytes("ls/sr/"), heightBytes...), '/')
+}
+
+// StakeReturnKey construct the state key for the stake holder with withdrawn stakes returned at the given height
+func StakeReturnKey(height uint64, holder common.Address) common.Bytes {
+	return append(StakeReturnKeyPrefix(height), holder[:]...)
+}
+
+// VotingStakeKey construct the state key for the voting stake of the given source address
+func VotingStakeKey(source common.Address) common.Bytes {
+	return append(common.Bytes("ls/vs/"), source[:]...)
+}
+
+// TotalVotingStakeKey returns the key for the sum of the voting stakes
+func TotalVotingStakeKey() common.Bytes {
+	return common.Bytes("ls/tvs")
+}
+
+// JailedValidatorKeyPrefix returns the prefix for the jailed validator key
+func JailedValidatorKeyPrefix() common.Bytes {
+	return common.Bytes("ls/jv/")
+}
+
+// JailedValidatorKey construct the state key for the given jailed validator address
+func JailedValidatorKey(addr common.Address) common.Bytes {
+	return append(JailedValidatorKeyPrefix(), addr[:]...)
+}
+
+// ValidatorDowntimeKeyPrefix returns the prefix for the validator downtime key
+func ValidatorDowntimeKeyPrefix() common.Bytes {
+	return common.Bytes("ls/vd/")
+}
+
+// ValidatorDowntimeKey construct the state key for the downtime of the given validator address
+func ValidatorDowntimeKey(addr common.Address) common.Bytes {
+	return append(ValidatorDowntimeKeyPrefix(), addr[:]...)
+}
+
+// LastBlockEpochKey returns the key for the epoch of the last block, see CoinbaseTx.GetEpoch
+func LastBlockEpochKey() common.Bytes {
+	return common.Bytes("ls/lbe")
+}
+
+// ChainParametersKey returns the key for the chain parameters set by the last parameter update in effect
+func ChainParametersKey() common.Bytes {
+	return common.Bytes("ls/cp")
+}
+
+// ParameterUpdateKeyPrefix returns the prefix for the scheduled parameter update key
+func ParameterUpdateKeyPrefix() common.Bytes {
+	return common.Bytes("ls/pu/")
+}
+
+// ParameterUpdateKey construct the state key for the parameter update scheduled at the given height.
+// The height is big-endian encoded so that the updates are traversed in height order.
+func ParameterUpdateKey(height uint64) common.Bytes {
+	heightBytes := make(common.Bytes, 8)
+	binary.BigEndian.PutUint64(heightBytes, height)
+	return append(ParameterUpdateKeyPrefix(), heightBytes...)
+}
+
+// NextProposalIDKey returns the key for the ID of the next governance proposal
+func NextProposalIDKey() common.Bytes {
+	return common.Bytes("ls/gn")
+}
+
+// ProposalKeyPrefix returns the prefix for the governance proposal key
+func ProposalKeyPrefix() common.Bytes {
+	return common.Bytes("ls/gp/")
+}
+
+// ProposalKey construct the state key for the governance proposal with the given ID
+func ProposalKey(id uint64) common.Bytes {
+	idBytes := make(common.Bytes, 8)
+	binary.BigEndian.PutUint64(idBytes, id)
+	return append(ProposalKeyPrefix(), idBytes...)
+}
+
+// ProposalVoteKeyPrefix returns the prefix for the keys of the votes on the given governance proposal
+func ProposalVoteKeyPrefix(id uint64) common.Bytes {
+	idBytes := make(common.Bytes, 8)
+	binary.BigEndian.PutUint64(idBytes, id)
+	return append(append(common.Bytes("ls/gv/"), idBytes...), '/')
+}
+
+// ProposalVoteKey construct the state key for the vote of the voter on the given governance proposal
+func ProposalVoteKey(id uint64, voter common.Address) common.Bytes {
+	return append(ProposalVoteKeyPrefix(id), voter[:]...)
+}
+
+// FeePoolKey returns the key for the transaction fees collected for the next coinbase transaction
+func FeePoolKey() common.Bytes {
+	return common.Bytes("ls/fp")
+}
@@ -0,0 +1,122 @@
+package state
+
+import (
+	log "github.com/sirupsen/logrus"
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/rlp"
+	"github.com/thetatoken/ukulele/store"
+	"github.com/thetatoken/ukulele/store/database"
+)
+
+// committedRootsKey is the database key of the state roots waiting to be pruned
+var committedRootsKey = common.Bytes("pruner/roots")
+
+// CommittedRoot is a state root committed to the database at the given height
+type CommittedRoot struct {
+	Height uint64
+	Root   common.Hash
+}
+
+//
+// Pruner deletes the trie nodes of the old state roots. The trie nodes are reference
+// counted, so pruning a state root only deletes the nodes not shared with the other
+// state roots.
+//
+type Pruner struct {
+	db             database.Database
+	retainedBlocks uint64
+	roots          []CommittedRoot
+}
+
+// NewPruner creates an instance of Pruner, which keeps the state roots of the last
+// retainedBlocks finalized heights. It keeps at least the latest finalized one.
+func NewPruner(db database.Database, retainedBlocks uint64) *Pruner {
+	if retainedBlocks < 1 {
+		retainedBlocks = 1
+	}
+	p := &Pruner{
+		db:             db,
+		retainedBlocks: retainedBlocks,
+		roots:          []CommittedRoot{},
+	}
+	if raw, err := db.Get(committedRootsKey); err == nil {
+		if err := rlp.DecodeBytes(raw, &p.roots); err != nil {
+			log.Errorf("Failed to load the state roots to prune: %v", err)
+		}
+	} else if err != store.ErrKeyNotFound {
+		log.Errorf("Failed to load the state roots to prune: %v", err)
+	}
+	return p
+}
+
+// Roots returns the state roots waiting to be pruned
+func (p *Pruner) Roots() []CommittedRoot {
+	return p.roots
+}
+
+// NearestRetainedHeight returns the lowest height, at or above the given height, of the
+// state roots not pruned yet. It returns false if there is none.
+func (p *Pruner) NearestRetainedHeight(height uint64) (uint64, bool) {
+	nearest, found := uint64(0), false
+	for _, cr := range p.roots {
+		if cr.Height >= height && (!found || cr.Height < nearest) {
+			nearest, found = cr.Height, true
+		}
+	}
+	return nearest, found
+}
+
+// Record records a state root committed at the given height
+func (p *Pruner) Record(height uint64, root common.Hash) {
+	if root == (common.Hash{}) {
+		return
+	}
+	p.roots = append(p.roots, CommittedRoot{Height: height, Root: root})
+	p.save()
+}
+
+// Prune prunes the state roots committed at least retainedBlocks heights below the
+// finalized height. The finalized state root is never pruned, even if it was also
+// committed at a lower height. It returns the number of pruned state roots.
+func (p *Pruner) Prune(finalizedHeight uint64, finalizedRoot common.Hash) int {
+	if finalizedHeight < p.retainedBlocks {
+		return 0
+	}
+	maxHeight := finalizedHeight - p.retainedBlocks
+
+	pruned := 0
+	remaining := []CommittedRoot{}
+	for _, cr := range p.roots {
+		if cr.Height > maxHeight || cr.Root == finalizedRoot {
+			remaining = append(remaining, cr)
+			continue
+		}
+		sv := NewStoreView(cr.Height, cr.Root, p.db)
+		if sv == nil {
+			// Already pruned
+			continue
+		}
+		if err := sv.PruneAccounts(); err != nil {
+			log.Errorf("Failed to prune state root %v at height %v: %v", cr.Root.Hex(), cr.Height, err)
+			remaining = append(remaining, cr)
+			continue
+		}
+		pruned++
+	}
+	if len(remaining) != len(p.roots) {
+		p.roots = remaining
+		p.save()
+	}
+	return pruned
+}
+
+func (p *Pruner) save() {
+	raw, err := rlp.EncodeToBytes(p.roots)
+	if err != nil {
+		log.Errorf("Failed to encode the state roots to prune: %v", err)
+		return
+	}
+	if err := p.db.Put(committedRootsKey, raw); err != nil {
+		log.Errorf("Failed to save the state roots to prune: %v", err)
+	}
+}
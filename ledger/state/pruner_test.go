@@ -0,0 +1,94 @@
+package state
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/store/database/backend"
+)
+
+func TestPruneStateRoots(t *testing.T) {
+	assert := assert.New(t)
+
+	db := backend.NewMemDatabase()
+	ls := NewLedgerState("testchain", db)
+	ls.ResetState(uint64(0), common.Hash{})
+	ls.EnablePruning(2)
+
+	shared := common.Bytes("shared")
+	sharedValue := common.Bytes(strings.Repeat("s", 64))
+	ls.Delivered().Set(shared, sharedValue)
+
+	// Heights 1 to 5 have distinct state roots, height 6 has the same state root as height 5
+	roots := []common.Hash{{}}
+	for h := 1; h <= 6; h++ {
+		if h <= 5 {
+			ls.Delivered().Set(common.Bytes("key"), common.Bytes(fmt.Sprintf("value%v", h)))
+		}
+		roots = append(roots, ls.Commit())
+		assert.Equal(uint64(h), ls.Height())
+	}
+	assert.Equal(roots[5], roots[6])
+	assert.Equal(6, len(ls.pruner.Roots()))
+
+	// Nothing is pruned until more than the retained heights are finalized
+	assert.True(ls.Finalize(2, roots[2]).IsOK())
+	for h := 1; h <= 6; h++ {
+		assert.NotNil(NewStoreView(uint64(h), roots[h], db))
+	}
+
+	assert.True(ls.Finalize(4, roots[4]).IsOK())
+	assert.Nil(NewStoreView(1, roots[1], db))
+	assert.Nil(NewStoreView(2, roots[2], db))
+	assert.Equal(uint64(3), ls.NearestRetainedHeight(1))
+	assert.Equal(uint64(5), ls.NearestRetainedHeight(5))
+	assert.Equal(uint64(4), ls.NearestRetainedHeight(10))
+	for h := 3; h <= 6; h++ {
+		value := common.Bytes(fmt.Sprintf("value%v", h))
+		if h == 6 {
+			value = common.Bytes("value5")
+		}
+		sv := NewStoreView(uint64(h), roots[h], db)
+		if assert.NotNil(sv) {
+			assert.Equal(sharedValue, sv.Get(shared))
+			assert.Equal(value, sv.Get(common.Bytes("key")))
+		}
+	}
+
+	// The state root of height 5 is still referenced by height 6
+	assert.True(ls.Finalize(7, roots[6]).IsOK())
+	assert.Nil(NewStoreView(3, roots[3], db))
+	assert.Nil(NewStoreView(4, roots[4], db))
+	sv := NewStoreView(6, roots[6], db)
+	if assert.NotNil(sv) {
+		assert.Equal(sharedValue, sv.Get(shared))
+		assert.Equal(common.Bytes("value5"), sv.Get(common.Bytes("key")))
+	}
+
+	// The remaining state roots are persisted
+	assert.Equal(ls.pruner.Roots(), NewPruner(db, 2).Roots())
+}
+
+func TestPrunerKeepsFinalizedRoot(t *testing.T) {
+	assert := assert.New(t)
+
+	db := backend.NewMemDatabase()
+	sv := NewStoreView(uint64(1), common.Hash{}, db)
+	sv.Set(common.Bytes("key"), common.Bytes("value"))
+	root := sv.Save()
+
+	pruner := NewPruner(db, 1)
+	pruner.Record(1, root)
+
+	// The root is old enough to prune, but is also the finalized one
+	assert.Equal(0, pruner.Prune(10, root))
+	assert.NotNil(NewStoreView(10, root, db))
+	assert.Equal(1, len(pruner.Roots()))
+
+	assert.Equal(1, pruner.Prune(11, common.BytesToHash([]byte("newroot"))))
+	assert.Nil(NewStoreView(1, root, db))
+	assert.Equal(0, len(pruner.Roots()))
+}
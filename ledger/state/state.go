@@ -20,6 +20,8 @@ type LedgerState struct {
 	delivered *StoreView // for actually applying the transactions
 	checked   *StoreView // for block proposal check
 	screened  *StoreView // for mempool screening
+
+	pruner *Pruner // nil if the old state roots are kept
 }
 
 // NewLedgerState creates a new Leger State with given store.
@@ -63,9 +65,33 @@ func (s *LedgerState) Finalize(height uint64, stateRootHash common.Hash) result.
 		return result.Error(fmt.Sprintf("Failed to finalize ledger state with state root hash: %v", stateRootHash))
 	}
 	s.finalized = storeview
+
+	if s.pruner != nil {
+		s.pruner.Prune(height, stateRootHash)
+	}
 	return result.OK
 }
 
+// EnablePruning enables the pruning of the state roots committed at least
+// retainedBlocks heights below the finalized height.
+func (s *LedgerState) EnablePruning(retainedBlocks uint64) {
+	s.pruner = NewPruner(s.db, retainedBlocks)
+}
+
+// NearestRetainedHeight returns the lowest height, at or above the given height, whose
+// state has not been pruned. It returns the finalized height if there is none.
+func (s *LedgerState) NearestRetainedHeight(height uint64) uint64 {
+	if s.pruner != nil {
+		if nearest, ok := s.pruner.NearestRetainedHeight(height); ok {
+			return nearest
+		}
+	}
+	if s.finalized == nil {
+		return s.delivered.Height()
+	}
+	return s.finalized.Height()
+}
+
 // GetChainID gets chain ID.
 func (s *LedgerState) GetChainID() string {
 	if s.chainID != "" {
@@ -106,6 +132,9 @@ func (s *LedgerState) Finalized() *StoreView {
 func (s *LedgerState) Commit() common.Hash {
 	hash := s.delivered.Save()
 	s.delivered.IncrementHeight()
+	if s.pruner != nil {
+		s.pruner.Record(s.delivered.Height(), hash)
+	}
 
 	var err error
 	s.checked, err = s.delivered.Copy()
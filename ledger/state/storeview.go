@@ -13,6 +13,7 @@ import (
 	"github.com/thetatoken/ukulele/rlp"
 	"github.com/thetatoken/ukulele/store/database"
 	"github.com/thetatoken/ukulele/store/treestore"
+	"github.com/thetatoken/ukulele/store/trie"
 )
 
 //
@@ -21,12 +22,16 @@ import (
 
 type StoreView struct {
 	height uint64 // block height
+	epoch  uint64 // epoch of the block whose transactions are executed on the view
 	store  *treestore.TreeStore
 
 	coinbaseTransactinProcessed bool
+	chargedFee                  types.Coins // Fee charged by the transaction being processed
 	slashIntents                []types.SlashIntent
 	validatorsDiff              []*core.Validator
-	refund                      uint64 // Gas refund during smart contract execution
+	refund                      uint64           // Gas refund during smart contract execution
+	txReceipt                   *types.TxReceipt // Receipt of the transaction being processed
+	accesses                    *AccessSet       // Keys accessed, nil if they are not recorded
 }
 
 // NewStoreView creates an instance of the StoreView
@@ -53,6 +58,7 @@ func (sv *StoreView) Copy() (*StoreView, error) {
 	}
 	copiedStoreView := &StoreView{
 		height:         sv.height,
+		epoch:          sv.epoch,
 		store:          copiedStore,
 		slashIntents:   []types.SlashIntent{},
 		validatorsDiff: []*core.Validator{},
@@ -71,6 +77,17 @@ func (sv *StoreView) Height() uint64 {
 	return sv.height
 }
 
+// Epoch returns the epoch of the block whose transactions are executed on the view
+func (sv *StoreView) Epoch() uint64 {
+	return sv.epoch
+}
+
+// SetEpoch sets the epoch of the block whose transactions are executed on the view,
+// i.e. the epoch in the block header rather than the epoch of the local node
+func (sv *StoreView) SetEpoch(epoch uint64) {
+	sv.epoch = epoch
+}
+
 // IncrementHeight increments the block height by 1
 func (sv *StoreView) IncrementHeight() {
 	sv.height++
@@ -87,20 +104,74 @@ func (sv *StoreView) Save() common.Hash {
 
 // Get returns the value corresponding to the key
 func (sv *StoreView) Get(key common.Bytes) common.Bytes {
+	if sv.accesses != nil {
+		sv.accesses.AddRead(key)
+	}
 	value := sv.store.Get(key)
 	return value
 }
 
 // Delete removes the value corresponding to the key
 func (sv *StoreView) Delete(key common.Bytes) {
-	sv.store.Delete(key)
+	sv.delete(key)
+}
+
+func (sv *StoreView) delete(key common.Bytes) bool {
+	if sv.accesses != nil {
+		sv.accesses.AddWrite(key)
+	}
+	return sv.store.Delete(key)
 }
 
 // Set returns the value corresponding to the key
 func (sv *StoreView) Set(key common.Bytes, value common.Bytes) {
+	if sv.accesses != nil {
+		sv.accesses.AddWrite(key)
+	}
 	sv.store.Set(key, value)
 }
 
+func (sv *StoreView) traverse(prefix common.Bytes, cb func(k, v common.Bytes) bool) {
+	if sv.accesses != nil {
+		sv.accesses.AddTraversal(prefix)
+	}
+	sv.store.Traverse(prefix, cb)
+}
+
+// RecordAccesses starts recording the keys read and written through the view
+func (sv *StoreView) RecordAccesses() {
+	sv.accesses = NewAccessSet()
+}
+
+// GetAccessSet returns the keys accessed since RecordAccesses was called, nil if they
+// are not recorded
+func (sv *StoreView) GetAccessSet() *AccessSet {
+	return sv.accesses
+}
+
+// StopRecordingAccesses stops recording the keys accessed through the view, and returns
+// the keys accessed since RecordAccesses was called
+func (sv *StoreView) StopRecordingAccesses() *AccessSet {
+	accesses := sv.accesses
+	sv.accesses = nil
+	return accesses
+}
+
+// MergeWrites sets the keys written through the other view, which records its accesses,
+// to their values in the other view, and carries over its slash intents. The two views
+// must derive from the same state.
+func (sv *StoreView) MergeWrites(other *StoreView) {
+	for _, key := range other.accesses.WrittenKeys() {
+		value := other.store.Get(key)
+		if len(value) == 0 {
+			sv.delete(key)
+		} else {
+			sv.Set(key, value)
+		}
+	}
+	sv.slashIntents = append(sv.slashIntents, other.slashIntents...)
+}
+
 // AddSlashIntent adds slashIntent
 func (sv *StoreView) AddSlashIntent(slashIntent types.SlashIntent) {
 	sv.slashIntents = append(sv.slashIntents, slashIntent)
@@ -126,6 +197,31 @@ func (sv *StoreView) SetCoinbaseTransactionProcessed(processed bool) {
 	sv.coinbaseTransactinProcessed = processed
 }
 
+// AddChargedFee records a fee charged by the transaction being processed
+func (sv *StoreView) AddChargedFee(fee types.Coins) {
+	sv.chargedFee = sv.chargedFee.Plus(fee)
+}
+
+// GetAndClearChargedFee retrieves and clears the fee charged by the transaction being processed
+func (sv *StoreView) GetAndClearChargedFee() types.Coins {
+	fee := sv.chargedFee.NoNil()
+	sv.chargedFee = types.Coins{}
+	return fee
+}
+
+// SetTxReceipt records the receipt of the transaction being processed
+func (sv *StoreView) SetTxReceipt(receipt *types.TxReceipt) {
+	sv.txReceipt = receipt
+}
+
+// GetAndClearTxReceipt retrieves and clears the receipt of the transaction being processed,
+// nil if it has none
+func (sv *StoreView) GetAndClearTxReceipt() *types.TxReceipt {
+	receipt := sv.txReceipt
+	sv.txReceipt = nil
+	return receipt
+}
+
 // GetAndClearValidatorDiff retrives and clear validator diff
 func (sv *StoreView) GetAndClearValidatorDiff() []*core.Validator {
 	res := sv.validatorsDiff
@@ -153,6 +249,16 @@ func (sv *StoreView) GetAccount(addr common.Address) *types.Account {
 	return acc
 }
 
+// ProveAccount returns the Merkle proof of the account, or of its absence, against
+// the root of the state. The view must have no uncommitted changes.
+func (sv *StoreView) ProveAccount(addr common.Address) ([]common.Bytes, error) {
+	proof := trie.ProofList{}
+	if err := sv.store.Prove(AccountKey(addr), 0, &proof); err != nil {
+		return nil, err
+	}
+	return proof, nil
+}
+
 // SetAccount sets an account.
 func (sv *StoreView) SetAccount(addr common.Address, acc *types.Account) {
 	accBytes, err := types.ToBytes(acc)
@@ -168,6 +274,19 @@ func (sv *StoreView) DeleteAccount(addr common.Address) {
 	sv.Delete(AccountKey(addr))
 }
 
+// TraverseAccounts calls the callback with each account of the state.
+func (sv *StoreView) TraverseAccounts(cb func(acc *types.Account)) {
+	sv.traverse(AccountKeyPrefix(), func(key, value common.Bytes) bool {
+		acc := &types.Account{}
+		err := types.FromBytes(value, acc)
+		if err != nil {
+			panic(fmt.Sprintf("Error reading account %X error: %v", value, err.Error()))
+		}
+		cb(acc)
+		return true
+	})
+}
+
 // SplitRuleExists checks if a split rule associated with the given resourceID already exists
 func (sv *StoreView) SplitRuleExists(resourceID string) bool {
 	return sv.GetSplitRule(resourceID) != nil
@@ -221,7 +340,7 @@ func (sv *StoreView) SetSplitRule(resourceID string, splitRule *types.SplitRule)
 // DeleteSplitRule deletes a split rule.
 func (sv *StoreView) DeleteSplitRule(resourceID string) bool {
 	key := SplitRuleKey(resourceID)
-	deleted := sv.store.Delete(key)
+	deleted := sv.delete(key)
 	return deleted
 }
 
@@ -230,7 +349,7 @@ func (sv *StoreView) DeleteExpiredSplitRules(currentBlockHeight uint64) bool {
 	prefix := SplitRuleKeyPrefix()
 
 	expiredKeys := []common.Bytes{}
-	sv.store.Traverse(prefix, func(key, value common.Bytes) bool {
+	sv.traverse(prefix, func(key, value common.Bytes) bool {
 		var splitRule types.SplitRule
 		err := types.FromBytes(value, &splitRule)
 		if err != nil {
@@ -245,7 +364,7 @@ func (sv *StoreView) DeleteExpiredSplitRules(currentBlockHeight uint64) bool {
 	})
 
 	for _, key := range expiredKeys {
-		deleted := sv.store.Delete(key)
+		deleted := sv.delete(key)
 		if !deleted {
 			log.Errorf("Failed to delete expired split rules")
 			return false
@@ -255,6 +374,558 @@ func (sv *StoreView) DeleteExpiredSplitRules(currentBlockHeight uint64) bool {
 	return true
 }
 
+// GetToken gets the token with the given symbol from the token registry.
+func (sv *StoreView) GetToken(symbol string) *types.Token {
+	data := sv.Get(TokenKey(symbol))
+	if data == nil || len(data) == 0 {
+		return nil
+	}
+	token := &types.Token{}
+	err := types.FromBytes(data, token)
+	if err != nil {
+		panic(fmt.Sprintf("Error reading token %X error: %v",
+			data, err.Error()))
+	}
+	return token
+}
+
+// SetToken registers the token in the token registry.
+func (sv *StoreView) SetToken(token *types.Token) {
+	tokenBytes, err := types.ToBytes(token)
+	if err != nil {
+		panic(fmt.Sprintf("Error writing token %v error: %v",
+			token, err.Error()))
+	}
+	sv.Set(TokenKey(token.Symbol), tokenBytes)
+}
+
+// GetTokens returns all the tokens in the token registry, sorted by symbol.
+func (sv *StoreView) GetTokens() []*types.Token {
+	tokens := []*types.Token{}
+	sv.traverse(TokenKeyPrefix(), func(key, value common.Bytes) bool {
+		token := &types.Token{}
+		err := types.FromBytes(value, token)
+		if err != nil {
+			panic(fmt.Sprintf("Error reading token %X error: %v", value, err.Error()))
+		}
+		tokens = append(tokens, token)
+		return true
+	})
+	return tokens
+}
+
+// GetStakeHolder gets the stake holder with the given address.
+func (sv *StoreView) GetStakeHolder(holder common.Address) *types.StakeHolder {
+	data := sv.Get(StakeHolderKey(holder))
+	if data == nil || len(data) == 0 {
+		return nil
+	}
+	stakeHolder := &types.StakeHolder{}
+	err := types.FromBytes(data, stakeHolder)
+	if err != nil {
+		panic(fmt.Sprintf("Error reading stakeHolder %X error: %v",
+			data, err.Error()))
+	}
+	return stakeHolder
+}
+
+// SetStakeHolder sets the stake holder. A stake holder without any stakes is deleted.
+// It also keeps the indexes derived from its stakes up to date, i.e. the voting stakes
+// of their sources and the heights at which the withdrawn stakes are returned.
+func (sv *StoreView) SetStakeHolder(stakeHolder *types.StakeHolder) {
+	sv.updateVotingStakes(sv.GetStakeHolder(stakeHolder.Holder), stakeHolder)
+	for _, stake := range stakeHolder.Stakes {
+		if stake.Withdrawn {
+			sv.Set(StakeReturnKey(stake.ReturnHeight, stakeHolder.Holder), stakeHolder.Holder[:])
+		}
+	}
+
+	if len(stakeHolder.Stakes) == 0 {
+		sv.delete(StakeHolderKey(stakeHolder.Holder))
+		return
+	}
+	stakeHolderBytes, err := types.ToBytes(stakeHolder)
+	if err != nil {
+		panic(fmt.Sprintf("Error writing stakeHolder %v error: %v",
+			stakeHolder, err.Error()))
+	}
+	sv.Set(StakeHolderKey(stakeHolder.Holder), stakeHolderBytes)
+}
+
+// GetStakeHolders returns all the stake holders.
+func (sv *StoreView) GetStakeHolders() []*types.StakeHolder {
+	stakeHolders := []*types.StakeHolder{}
+	sv.traverse(StakeHolderKeyPrefix(), func(key, value common.Bytes) bool {
+		stakeHolder := &types.StakeHolder{}
+		err := types.FromBytes(value, stakeHolder)
+		if err != nil {
+			panic(fmt.Sprintf("Error reading stakeHolder %X error: %v", value, err.Error()))
+		}
+		stakeHolders = append(stakeHolders, stakeHolder)
+		return true
+	})
+	return stakeHolders
+}
+
+// ReturnMaturedStakes credits the withdrawn stakes whose locking period ends at the
+// height back to their source accounts. Only the stake holders indexed by the height
+// are read, so that the cost does not grow with the number of stake holders.
+func (sv *StoreView) ReturnMaturedStakes(currentBlockHeight uint64) {
+	holders := []common.Address{}
+	sv.traverse(StakeReturnKeyPrefix(currentBlockHeight), func(key, value common.Bytes) bool {
+		holders = append(holders, common.BytesToAddress(value))
+		return true
+	})
+	for _, holder := range holders {
+		sv.delete(StakeReturnKey(currentBlockHeight, holder))
+		stakeHolder := sv.GetStakeHolder(holder)
+		if stakeHolder == nil {
+			continue
+		}
+		returnedStakes := stakeHolder.ReturnMaturedStakes(currentBlockHeight)
+		if len(returnedStakes) == 0 {
+			continue
+		}
+		for _, stake := range returnedStakes {
+			source := sv.GetOrCreateAccount(stake.Source)
+			source.Balance = source.Balance.Plus(types.Coins{
+				ThetaWei: stake.Amount,
+				GammaWei: big.NewInt(0),
+			})
+			sv.SetAccount(stake.Source, source)
+		}
+		sv.SetStakeHolder(stakeHolder)
+	}
+}
+
+// GetFeePool returns the transaction fees collected for the next coinbase transaction.
+func (sv *StoreView) GetFeePool() types.Coins {
+	data := sv.Get(FeePoolKey())
+	if data == nil || len(data) == 0 {
+		return types.NewCoins(0, 0)
+	}
+	feePool := types.Coins{}
+	err := types.FromBytes(data, &feePool)
+	if err != nil {
+		panic(fmt.Sprintf("Error reading feePool %X error: %v", data, err.Error()))
+	}
+	return feePool.NoNil()
+}
+
+// SetFeePool sets the transaction fees collected for the next coinbase transaction. An
+// empty fee pool is deleted.
+func (sv *StoreView) SetFeePool(feePool types.Coins) {
+	if feePool.IsZero() {
+		sv.delete(FeePoolKey())
+		return
+	}
+	feePoolBytes, err := types.ToBytes(feePool)
+	if err != nil {
+		panic(fmt.Sprintf("Error writing feePool %v error: %v", feePool, err.Error()))
+	}
+	sv.Set(FeePoolKey(), feePoolBytes)
+}
+
+// SetJailedValidator records the jail of a validator, replacing its previous jail. The
+// validators are jailed with JailValidator.
+func (sv *StoreView) SetJailedValidator(jailedValidator *types.JailedValidator) {
+	jailedValidatorBytes, err := types.ToBytes(jailedValidator)
+	if err != nil {
+		panic(fmt.Sprintf("Error writing jailedValidator %v error: %v",
+			jailedValidator, err.Error()))
+	}
+	sv.Set(JailedValidatorKey(jailedValidator.Address), jailedValidatorBytes)
+}
+
+// JailValidator jails the validator for the epochs after jailedEpoch, up to untilEpoch,
+// merged with its current jail rather than replacing it: a jail until the validator
+// unjails is kept, otherwise the later UntilEpoch is kept, so that a validator jailed
+// for downtime is not released without sending an UnjailTx.
+func (sv *StoreView) JailValidator(addr common.Address, jailedEpoch uint64, untilEpoch uint64) {
+	jailedValidator := sv.GetJailedValidator(addr)
+	switch {
+	case jailedValidator == nil || jailedValidator.UntilEpoch < jailedEpoch:
+		jailedValidator = &types.JailedValidator{
+			Address:     addr,
+			JailedEpoch: jailedEpoch,
+			UntilEpoch:  untilEpoch,
+		}
+	case jailedValidator.IsJailedUntilUnjailed():
+		return
+	case untilEpoch == types.JailedUntilUnjailed:
+		// The cooldown before the validator can unjail starts with this jail
+		jailedValidator.JailedEpoch = jailedEpoch
+		jailedValidator.UntilEpoch = untilEpoch
+	case untilEpoch > jailedValidator.UntilEpoch:
+		jailedValidator.UntilEpoch = untilEpoch
+	default:
+		return
+	}
+	sv.SetJailedValidator(jailedValidator)
+}
+
+// GetJailedValidators returns the jails of all the validators ever jailed.
+func (sv *StoreView) GetJailedValidators() []*types.JailedValidator {
+	jailedValidators := []*types.JailedValidator{}
+	sv.traverse(JailedValidatorKeyPrefix(), func(key, value common.Bytes) bool {
+		jailedValidator := &types.JailedValidator{}
+		err := types.FromBytes(value, jailedValidator)
+		if err != nil {
+			panic(fmt.Sprintf("Error reading jailedValidator %X error: %v", value, err.Error()))
+		}
+		jailedValidators = append(jailedValidators, jailedValidator)
+		return true
+	})
+	return jailedValidators
+}
+
+// GetJailedValidator returns the jail of the validator, or nil if it was never jailed.
+func (sv *StoreView) GetJailedValidator(addr common.Address) *types.JailedValidator {
+	data := sv.Get(JailedValidatorKey(addr))
+	if data == nil || len(data) == 0 {
+		return nil
+	}
+	jailedValidator := &types.JailedValidator{}
+	err := types.FromBytes(data, jailedValidator)
+	if err != nil {
+		panic(fmt.Sprintf("Error reading jailedValidator %X error: %v", data, err.Error()))
+	}
+	return jailedValidator
+}
+
+// GetValidatorDowntime returns the proposals missed by the validator, or nil if it
+// missed none.
+func (sv *StoreView) GetValidatorDowntime(addr common.Address) *types.ValidatorDowntime {
+	data := sv.Get(ValidatorDowntimeKey(addr))
+	if data == nil || len(data) == 0 {
+		return nil
+	}
+	downtime := &types.ValidatorDowntime{}
+	err := types.FromBytes(data, downtime)
+	if err != nil {
+		panic(fmt.Sprintf("Error reading validator downtime %X error: %v", data, err.Error()))
+	}
+	return downtime
+}
+
+// SetValidatorDowntime sets the proposals missed by the validator.
+func (sv *StoreView) SetValidatorDowntime(downtime *types.ValidatorDowntime) {
+	downtimeBytes, err := types.ToBytes(downtime)
+	if err != nil {
+		panic(fmt.Sprintf("Error writing validator downtime %v error: %v", downtime, err.Error()))
+	}
+	sv.Set(ValidatorDowntimeKey(downtime.Address), downtimeBytes)
+}
+
+// DeleteValidatorDowntime forgets the proposals missed by the validator.
+func (sv *StoreView) DeleteValidatorDowntime(addr common.Address) {
+	sv.delete(ValidatorDowntimeKey(addr))
+}
+
+// GetValidatorDowntimes returns the proposals missed by all the validators.
+func (sv *StoreView) GetValidatorDowntimes() []*types.ValidatorDowntime {
+	downtimes := []*types.ValidatorDowntime{}
+	sv.traverse(ValidatorDowntimeKeyPrefix(), func(key, value common.Bytes) bool {
+		downtime := &types.ValidatorDowntime{}
+		err := types.FromBytes(value, downtime)
+		if err != nil {
+			panic(fmt.Sprintf("Error reading validator downtime %X error: %v", value, err.Error()))
+		}
+		downtimes = append(downtimes, downtime)
+		return true
+	})
+	return downtimes
+}
+
+// GetLastBlockEpoch returns the epoch of the last block whose coinbase transaction
+// set it, and false if there is none.
+func (sv *StoreView) GetLastBlockEpoch() (uint64, bool) {
+	data := sv.Get(LastBlockEpochKey())
+	if data == nil || len(data) == 0 {
+		return 0, false
+	}
+	var epoch uint64
+	err := types.FromBytes(data, &epoch)
+	if err != nil {
+		panic(fmt.Sprintf("Error reading last block epoch %X error: %v", data, err.Error()))
+	}
+	return epoch, true
+}
+
+// SetLastBlockEpoch sets the epoch of the last block.
+func (sv *StoreView) SetLastBlockEpoch(epoch uint64) {
+	epochBytes, err := types.ToBytes(epoch)
+	if err != nil {
+		panic(fmt.Sprintf("Error writing last block epoch %v error: %v", epoch, err.Error()))
+	}
+	sv.Set(LastBlockEpochKey(), epochBytes)
+}
+
+// GetChainParameters returns the chain parameters in effect at the height of the view,
+// i.e. the parameters of the last update whose height has been reached, or the
+// default parameters if there is none.
+func (sv *StoreView) GetChainParameters() types.ChainParameters {
+	params := types.DefaultChainParameters()
+	data := sv.Get(ChainParametersKey())
+	if data != nil && len(data) != 0 {
+		err := types.FromBytes(data, &params)
+		if err != nil {
+			panic(fmt.Sprintf("Error reading chain parameters %X error: %v", data, err.Error()))
+		}
+	}
+	for _, update := range sv.GetParameterUpdates() {
+		if update.Height > sv.Height() {
+			break
+		}
+		params = update.Parameters
+	}
+	return params
+}
+
+// ChainParametersUpdated returns whether a parameter update is in effect at the height
+// of the view, i.e. the chain parameters are no longer the default ones.
+func (sv *StoreView) ChainParametersUpdated() bool {
+	data := sv.Get(ChainParametersKey())
+	if data != nil && len(data) != 0 {
+		return true
+	}
+	updates := sv.GetParameterUpdates()
+	return len(updates) > 0 && updates[0].Height <= sv.Height()
+}
+
+// ScheduleParameterUpdate records a parameter update, replacing the update already
+// scheduled at the same height if any.
+func (sv *StoreView) ScheduleParameterUpdate(update *types.ParameterUpdate) {
+	updateBytes, err := types.ToBytes(update)
+	if err != nil {
+		panic(fmt.Sprintf("Error writing parameter update %v error: %v", update, err.Error()))
+	}
+	sv.Set(ParameterUpdateKey(update.Height), updateBytes)
+}
+
+// GetParameterUpdates returns the parameter updates not activated yet, sorted by height.
+func (sv *StoreView) GetParameterUpdates() []*types.ParameterUpdate {
+	updates := []*types.ParameterUpdate{}
+	sv.traverse(ParameterUpdateKeyPrefix(), func(key, value common.Bytes) bool {
+		update := &types.ParameterUpdate{}
+		err := types.FromBytes(value, update)
+		if err != nil {
+			panic(fmt.Sprintf("Error reading parameter update %X error: %v", value, err.Error()))
+		}
+		updates = append(updates, update)
+		return true
+	})
+	return updates
+}
+
+// ActivateParameterUpdates makes the parameter updates whose height has been reached
+// the chain parameters, and removes them from the scheduled updates.
+func (sv *StoreView) ActivateParameterUpdates(currentBlockHeight uint64) {
+	for _, update := range sv.GetParameterUpdates() {
+		if update.Height > currentBlockHeight {
+			break
+		}
+		paramsBytes, err := types.ToBytes(update.Parameters)
+		if err != nil {
+			panic(fmt.Sprintf("Error writing chain parameters %v error: %v", update.Parameters, err.Error()))
+		}
+		sv.Set(ChainParametersKey(), paramsBytes)
+		sv.delete(ParameterUpdateKey(update.Height))
+	}
+}
+
+// NextProposalID returns the ID of a new governance proposal, and increments the ID
+// of the next one.
+func (sv *StoreView) NextProposalID() uint64 {
+	id := uint64(1)
+	data := sv.Get(NextProposalIDKey())
+	if data != nil && len(data) != 0 {
+		err := types.FromBytes(data, &id)
+		if err != nil {
+			panic(fmt.Sprintf("Error reading next proposal ID %X error: %v", data, err.Error()))
+		}
+	}
+	idBytes, err := types.ToBytes(id + 1)
+	if err != nil {
+		panic(fmt.Sprintf("Error writing next proposal ID %v error: %v", id+1, err.Error()))
+	}
+	sv.Set(NextProposalIDKey(), idBytes)
+	return id
+}
+
+// GetProposal gets the governance proposal with the given ID.
+func (sv *StoreView) GetProposal(id uint64) *types.Proposal {
+	data := sv.Get(ProposalKey(id))
+	if data == nil || len(data) == 0 {
+		return nil
+	}
+	proposal := &types.Proposal{}
+	err := types.FromBytes(data, proposal)
+	if err != nil {
+		panic(fmt.Sprintf("Error reading proposal %X error: %v", data, err.Error()))
+	}
+	return proposal
+}
+
+// SetProposal sets the governance proposal.
+func (sv *StoreView) SetProposal(proposal *types.Proposal) {
+	proposalBytes, err := types.ToBytes(proposal)
+	if err != nil {
+		panic(fmt.Sprintf("Error writing proposal %v error: %v", proposal, err.Error()))
+	}
+	sv.Set(ProposalKey(proposal.ID), proposalBytes)
+}
+
+// GetProposals returns all the governance proposals, sorted by ID.
+func (sv *StoreView) GetProposals() []*types.Proposal {
+	proposals := []*types.Proposal{}
+	sv.traverse(ProposalKeyPrefix(), func(key, value common.Bytes) bool {
+		proposal := &types.Proposal{}
+		err := types.FromBytes(value, proposal)
+		if err != nil {
+			panic(fmt.Sprintf("Error reading proposal %X error: %v", value, err.Error()))
+		}
+		proposals = append(proposals, proposal)
+		return true
+	})
+	return proposals
+}
+
+// SetProposalVote records the vote on the governance proposal, replacing the previous
+// vote of the voter.
+func (sv *StoreView) SetProposalVote(id uint64, vote *types.ProposalVote) {
+	voteBytes, err := types.ToBytes(vote)
+	if err != nil {
+		panic(fmt.Sprintf("Error writing proposal vote %v error: %v", vote, err.Error()))
+	}
+	sv.Set(ProposalVoteKey(id, vote.Voter), voteBytes)
+}
+
+// GetProposalVotes returns the votes on the governance proposal, sorted by voter.
+func (sv *StoreView) GetProposalVotes(id uint64) []*types.ProposalVote {
+	votes := []*types.ProposalVote{}
+	sv.traverse(ProposalVoteKeyPrefix(id), func(key, value common.Bytes) bool {
+		vote := &types.ProposalVote{}
+		err := types.FromBytes(value, vote)
+		if err != nil {
+			panic(fmt.Sprintf("Error reading proposal vote %X error: %v", value, err.Error()))
+		}
+		votes = append(votes, vote)
+		return true
+	})
+	return votes
+}
+
+// DeleteProposalVotes deletes the votes on the governance proposal, once tallied.
+func (sv *StoreView) DeleteProposalVotes(id uint64) {
+	for _, vote := range sv.GetProposalVotes(id) {
+		sv.delete(ProposalVoteKey(id, vote.Voter))
+	}
+}
+
+// GetVotingStake returns the stake of the staker, i.e. the sum of its stakes not
+// withdrawn, which weights its votes on the governance proposals, or nil if it has none.
+func (sv *StoreView) GetVotingStake(source common.Address) *big.Int {
+	return sv.getBigInt(VotingStakeKey(source))
+}
+
+// GetTotalVotingStake returns the sum of the voting stakes of all the stakers.
+func (sv *StoreView) GetTotalVotingStake() *big.Int {
+	total := sv.getBigInt(TotalVotingStakeKey())
+	if total == nil {
+		return big.NewInt(0)
+	}
+	return total
+}
+
+// updateVotingStakes updates the voting stakes of the sources of the stakes, and their
+// total, with the change of the stakes of a stake holder, so that the tallies do not
+// need to traverse all the stake holders.
+func (sv *StoreView) updateVotingStakes(before *types.StakeHolder, after *types.StakeHolder) {
+	deltas := make(map[common.Address]*big.Int)
+	sources := []common.Address{}
+	add := func(stakeHolder *types.StakeHolder, sign int64) {
+		if stakeHolder == nil {
+			return
+		}
+		for _, stake := range stakeHolder.Stakes {
+			if stake.Withdrawn {
+				continue
+			}
+			if _, ok := deltas[stake.Source]; !ok {
+				deltas[stake.Source] = big.NewInt(0)
+				sources = append(sources, stake.Source)
+			}
+			deltas[stake.Source].Add(deltas[stake.Source], new(big.Int).Mul(stake.Amount, big.NewInt(sign)))
+		}
+	}
+	add(before, -1)
+	add(after, 1)
+
+	totalDelta := big.NewInt(0)
+	for _, source := range sources {
+		delta := deltas[source]
+		if delta.Sign() == 0 {
+			continue
+		}
+		stake := sv.GetVotingStake(source)
+		if stake == nil {
+			stake = big.NewInt(0)
+		}
+		sv.setBigInt(VotingStakeKey(source), stake.Add(stake, delta))
+		totalDelta.Add(totalDelta, delta)
+	}
+	if totalDelta.Sign() != 0 {
+		total := sv.GetTotalVotingStake()
+		sv.setBigInt(TotalVotingStakeKey(), total.Add(total, totalDelta))
+	}
+}
+
+func (sv *StoreView) getBigInt(key common.Bytes) *big.Int {
+	data := sv.Get(key)
+	if data == nil || len(data) == 0 {
+		return nil
+	}
+	value := new(big.Int)
+	err := types.FromBytes(data, value)
+	if err != nil {
+		panic(fmt.Sprintf("Error reading big.Int %X error: %v", data, err.Error()))
+	}
+	return value
+}
+
+// setBigInt sets the value of the key, or deletes it if the value is zero.
+func (sv *StoreView) setBigInt(key common.Bytes, value *big.Int) {
+	if value.Sign() == 0 {
+		sv.delete(key)
+		return
+	}
+	valueBytes, err := types.ToBytes(value)
+	if err != nil {
+		panic(fmt.Sprintf("Error writing big.Int %v error: %v", value, err.Error()))
+	}
+	sv.Set(key, valueBytes)
+}
+
+// TallyProposal counts the votes on the governance proposal, weighted by the current
+// stakes of the voters.
+func (sv *StoreView) TallyProposal(id uint64) types.ProposalTally {
+	tally := types.NewProposalTally()
+	tally.TotalStake.Set(sv.GetTotalVotingStake())
+	for _, vote := range sv.GetProposalVotes(id) {
+		stake := sv.GetVotingStake(vote.Voter)
+		if stake == nil {
+			continue
+		}
+		if vote.Approve {
+			tally.Yes.Add(tally.Yes, stake)
+		} else {
+			tally.No.Add(tally.No, stake)
+		}
+	}
+	return tally
+}
+
 func (sv *StoreView) GetStore() *treestore.TreeStore {
 	return sv.store
 }
@@ -483,6 +1154,13 @@ func (sv *StoreView) Prune() bool {
 	return true
 }
 
+// PruneAccounts deletes the nodes of the account trie that are not shared with other
+// state roots. Unlike Prune, it leaves the storage tries of the accounts alone, since
+// the account trie holds no references to them.
+func (sv *StoreView) PruneAccounts() error {
+	return sv.store.Prune(nil)
+}
+
 func (sv *StoreView) AddLog(*types.Log) {
 	// TODO
 }
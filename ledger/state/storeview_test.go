@@ -73,6 +73,53 @@ func TestStoreViewBasics(t *testing.T) {
 	assert.NotEqual(sv2RootHashCalculated, sv2RootHashCalculatedAfterInsertion)
 }
 
+func TestStoreViewMergeWrites(t *testing.T) {
+	assert := assert.New(t)
+
+	db := backend.NewMemDatabase()
+	sv := NewStoreView(1, common.Hash{}, db)
+	k1, k2, k3 := common.Bytes("key1"), common.Bytes("key2"), common.Bytes("key3")
+	sv.Set(k1, common.Bytes("value1"))
+	sv.Set(k2, common.Bytes("value2"))
+
+	sv1, err := sv.Copy()
+	assert.Nil(err)
+	sv1.RecordAccesses()
+	sv2, err := sv.Copy()
+	assert.Nil(err)
+	sv2.RecordAccesses()
+
+	sv1.Get(k1)
+	sv1.Set(k3, common.Bytes("value3"))
+	sv2.Delete(k2)
+	sv2.AddSlashIntent(types.SlashIntent{Address: common.HexToAddress("0x1")})
+	assert.False(sv1.GetAccessSet().Conflicts(sv2.GetAccessSet()))
+	assert.Equal([]common.Bytes{k3}, sv1.GetAccessSet().WrittenKeys())
+
+	sv.MergeWrites(sv1)
+	sv.MergeWrites(sv2)
+	assert.Equal(common.Bytes("value1"), sv.Get(k1))
+	assert.Nil(sv.Get(k2))
+	assert.Equal(common.Bytes("value3"), sv.Get(k3))
+	assert.Equal(1, len(sv.GetSlashIntents()))
+
+	// Writing a key read or traversed by another view conflicts
+	sv2.Set(k1, common.Bytes("value4"))
+	assert.True(sv1.GetAccessSet().Conflicts(sv2.GetAccessSet()))
+	sv3, err := sv.Copy()
+	assert.Nil(err)
+	sv3.RecordAccesses()
+	sv3.TraverseAccounts(func(acc *types.Account) {})
+	sv1.SetAccount(common.HexToAddress("0x2"), types.NewAccount(common.HexToAddress("0x2")))
+	assert.True(sv3.GetAccessSet().Conflicts(sv1.GetAccessSet()))
+
+	// The accesses are no longer recorded once stopped
+	assert.NotNil(sv3.StopRecordingAccesses())
+	assert.Nil(sv3.GetAccessSet())
+	sv3.Get(k1)
+	assert.Nil(sv3.GetAccessSet())
+}
+
 func TestStoreViewAccountAccess(t *testing.T) {
 	assert := assert.New(t)
 
@@ -108,6 +155,86 @@ func TestStoreViewAccountAccess(t *testing.T) {
 	log.Infof("Balance: %v\n", accRetrieved.Balance)
 }
 
+func TestStoreViewStakeIndexes(t *testing.T) {
+	assert := assert.New(t)
+
+	db := backend.NewMemDatabase()
+	sv := NewStoreView(uint64(1), common.Hash{}, db)
+	holder1 := common.HexToAddress("0x01")
+	holder2 := common.HexToAddress("0x02")
+	alice := common.HexToAddress("0xa1")
+	bob := common.HexToAddress("0xb0")
+
+	sh1 := types.NewStakeHolder(holder1)
+	sh1.DepositStake(alice, big.NewInt(100))
+	sh1.DepositStake(bob, big.NewInt(50))
+	sv.SetStakeHolder(sh1)
+	sh2 := types.NewStakeHolder(holder2)
+	sh2.DepositStake(alice, big.NewInt(30))
+	sv.SetStakeHolder(sh2)
+
+	assert.Equal(big.NewInt(130), sv.GetVotingStake(alice))
+	assert.Equal(big.NewInt(50), sv.GetVotingStake(bob))
+	assert.Equal(big.NewInt(180), sv.GetTotalVotingStake())
+
+	// A withdrawn stake does not vote, and is returned at its return height only
+	sh1 = sv.GetStakeHolder(holder1)
+	sh1.WithdrawStake(alice, 10)
+	sv.SetStakeHolder(sh1)
+	assert.Equal(big.NewInt(30), sv.GetVotingStake(alice))
+	assert.Equal(big.NewInt(80), sv.GetTotalVotingStake())
+
+	sv.ReturnMaturedStakes(9)
+	assert.Nil(sv.GetAccount(alice))
+	sv.ReturnMaturedStakes(10)
+	assert.Equal(big.NewInt(100), sv.GetAccount(alice).Balance.ThetaWei)
+	assert.Equal(1, len(sv.GetStakeHolder(holder1).Stakes))
+	assert.Nil(sv.Get(StakeReturnKey(10, holder1)))
+
+	// The last stake of a voter
+	sh1 = sv.GetStakeHolder(holder1)
+	sh1.WithdrawStake(bob, 20)
+	sv.SetStakeHolder(sh1)
+	assert.Nil(sv.GetVotingStake(bob))
+	assert.Equal(big.NewInt(30), sv.GetTotalVotingStake())
+	sv.ReturnMaturedStakes(20)
+	assert.Nil(sv.GetStakeHolder(holder1))
+	assert.Equal(big.NewInt(50), sv.GetAccount(bob).Balance.ThetaWei)
+}
+
+func TestStoreViewJailValidator(t *testing.T) {
+	assert := assert.New(t)
+
+	db := backend.NewMemDatabase()
+	sv := NewStoreView(uint64(1), common.Hash{}, db)
+	addr := common.HexToAddress("0x1234")
+
+	sv.JailValidator(addr, 10, 20)
+	assert.Equal(uint64(20), sv.GetJailedValidator(addr).UntilEpoch)
+
+	// A shorter jail does not shorten the current one, a longer one extends it
+	sv.JailValidator(addr, 12, 15)
+	assert.Equal(uint64(10), sv.GetJailedValidator(addr).JailedEpoch)
+	assert.Equal(uint64(20), sv.GetJailedValidator(addr).UntilEpoch)
+	sv.JailValidator(addr, 12, 30)
+	assert.Equal(uint64(10), sv.GetJailedValidator(addr).JailedEpoch)
+	assert.Equal(uint64(30), sv.GetJailedValidator(addr).UntilEpoch)
+
+	// A jail until the validator unjails is kept
+	sv.JailValidator(addr, 14, types.JailedUntilUnjailed)
+	assert.Equal(uint64(14), sv.GetJailedValidator(addr).JailedEpoch)
+	assert.True(sv.GetJailedValidator(addr).IsJailedUntilUnjailed())
+	sv.JailValidator(addr, 16, 100)
+	assert.Equal(uint64(14), sv.GetJailedValidator(addr).JailedEpoch)
+	assert.True(sv.GetJailedValidator(addr).IsJailedUntilUnjailed())
+
+	// An ended jail is replaced
+	sv.SetJailedValidator(&types.JailedValidator{Address: addr, JailedEpoch: 14, UntilEpoch: 50})
+	sv.JailValidator(addr, 60, 70)
+	assert.Equal(uint64(60), sv.GetJailedValidator(addr).JailedEpoch)
+	assert.Equal(uint64(70), sv.GetJailedValidator(addr).UntilEpoch)
+}
+
 func TestStoreViewSplitRuleAccess(t *testing.T) {
 	assert := assert.New(t)
 
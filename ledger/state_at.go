@@ -0,0 +1,73 @@
+package ledger
+
+import (
+	"fmt"
+
+	"github.com/thetatoken/ukulele/blockchain"
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/core"
+	"github.com/thetatoken/ukulele/crypto"
+	exec "github.com/thetatoken/ukulele/ledger/execution"
+	st "github.com/thetatoken/ukulele/ledger/state"
+	"github.com/thetatoken/ukulele/ledger/types"
+	"github.com/thetatoken/ukulele/store/database"
+)
+
+// TxState is the intermediate ledger state of a block after one of its transactions.
+type TxState struct {
+	Block   *core.ExtendedBlock
+	TxIndex int              // -1 for the state of the parent block
+	TxHash  common.Hash      // Empty for the state of the parent block
+	Receipt *types.TxReceipt // Receipt of the transaction, nil if it records none
+	View    *st.StoreView    // The state, which is only held in memory
+}
+
+// StateAt materializes the ledger state of the block after its transaction at txIndex,
+// by re-executing the transactions of the block up to that one on the state of the
+// parent block found in db. The index -1 gives the state of the parent. Nothing is
+// written to db, and the state of the block after its last transaction has the state
+// root of its header unless the execution diverged. It fails if the state of the parent
+// is not in db, e.g. pruned, or if one of the transactions fails.
+func StateAt(chain *blockchain.Chain, db database.Database, valMgr core.ValidatorManager, block *core.ExtendedBlock, txIndex int) (*TxState, error) {
+	if txIndex < -1 || txIndex >= len(block.Txs) {
+		return nil, fmt.Errorf("Block %v has no transaction %v, it has %v transactions", block.Hash().Hex(), txIndex, len(block.Txs))
+	}
+	parent, err := chain.FindBlock(block.Parent)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to find the parent of block %v: %v", block.Hash().Hex(), err)
+	}
+
+	// Like Replay, execute the block on a fresh view of its parent
+	state := st.NewLedgerState(chain.ChainID, db)
+	if res := state.ResetState(parent.Height, parent.StateHash); res.IsError() {
+		return nil, fmt.Errorf("State of block %v is not in the DB: %v", parent.Hash().Hex(), res.Message)
+	}
+	executor := exec.NewExecutor(state, &replayConsensusEngine{block: block}, valMgr)
+	view := state.Delivered()
+	view.SetEpoch(block.Epoch)
+
+	ret := &TxState{Block: block, TxIndex: txIndex, View: view}
+	// The concurrent execution of a block has the effects of the serial one
+	for idx, rawTx := range block.Txs[:txIndex+1] {
+		tx, err := types.TxFromBytes(rawTx)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse transaction %v of block %v: %v", idx, block.Hash().Hex(), err)
+		}
+		if _, res := executor.ExecuteTx(tx); res.IsError() {
+			return nil, fmt.Errorf("Transaction %v of block %v failed: %v", idx, block.Hash().Hex(), res.Message)
+		}
+		ret.TxHash = crypto.Keccak256Hash(rawTx)
+		ret.Receipt = view.GetAndClearTxReceipt()
+	}
+	return ret, nil
+}
+
+// StateAt materializes the ledger state of the block after its transaction at txIndex
+// from the state DB of the ledger, see StateAt.
+func (ledger *Ledger) StateAt(chain *blockchain.Chain, block *core.ExtendedBlock, txIndex int) (*TxState, error) {
+	ledger.mu.RLock()
+	db := ledger.state.Finalized().GetStore().GetDB()
+	ledger.mu.RUnlock()
+
+	return StateAt(chain, db, ledger.valMgr, block, txIndex)
+}
@@ -0,0 +1,82 @@
+package ledger
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thetatoken/ukulele/blockchain"
+	"github.com/thetatoken/ukulele/core"
+	"github.com/thetatoken/ukulele/crypto"
+	"github.com/thetatoken/ukulele/store/database/backend"
+	"github.com/thetatoken/ukulele/store/kvstore"
+)
+
+func TestStateAt(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	chainID, ledger, mempool := newTestLedger()
+	accOut, accIns := prepareInitLedgerState(ledger, 2)
+
+	root := core.NewBlock()
+	root.ChainID = chainID
+	root.Height = ledger.state.Height()
+	root.StateHash = ledger.state.Delivered().Hash()
+	chain := blockchain.NewChain(chainID, kvstore.NewKVStore(backend.NewMemDatabase()), root)
+
+	// A block with a coinbase transaction and two sends to accOut
+	for _, accIn := range accIns {
+		require.Nil(mempool.InsertTransaction(newRawSendTx(chainID, 1, true, accOut, accIn, false)))
+	}
+	stateHash, txs, res := ledger.ProposeBlockTxs(ledger.consensus.GetEpoch())
+	require.True(res.IsOK(), res.Message)
+	require.Equal(3, len(txs))
+	block := core.NewBlock()
+	block.ChainID = chainID
+	block.Epoch = ledger.consensus.GetEpoch()
+	block.Parent = root.Hash()
+	block.Height = root.Height + 1
+	block.Txs = txs
+	block.TxHash = core.CalculateTxHash(txs)
+	block.StateHash = stateHash
+	require.True(ledger.ApplyBlockTxs(ledger.consensus.GetEpoch(), txs, stateHash).IsOK())
+	eb, err := chain.AddBlock(block)
+	require.Nil(err)
+	delivered := ledger.state.Delivered().Hash()
+
+	balance := func(txState *TxState) *big.Int {
+		return txState.View.GetAccount(accOut.Address).Balance.ThetaWei
+	}
+
+	// The state of the parent, then after each transaction
+	txState, err := ledger.StateAt(chain, eb, -1)
+	require.Nil(err)
+	assert.Equal(root.StateHash, txState.View.Hash())
+	assert.Equal(-1, txState.TxIndex)
+	assert.True(txState.TxHash.IsEmpty())
+	assert.Equal(big.NewInt(700000), balance(txState))
+
+	txState, err = ledger.StateAt(chain, eb, 1)
+	require.Nil(err)
+	assert.Equal(crypto.Keccak256Hash(txs[1]), txState.TxHash)
+	assert.Equal(big.NewInt(700015), balance(txState))
+
+	txState, err = ledger.StateAt(chain, eb, 2)
+	require.Nil(err)
+	assert.Equal(big.NewInt(700030), balance(txState))
+	assert.Equal(block.StateHash, txState.View.Hash())
+
+	// The state of the ledger is untouched
+	assert.Equal(delivered, ledger.state.Delivered().Hash())
+
+	_, err = ledger.StateAt(chain, eb, 3)
+	assert.NotNil(err)
+	_, err = ledger.StateAt(chain, eb, -2)
+	assert.NotNil(err)
+
+	// The state of the parent is needed
+	_, err = StateAt(chain, backend.NewMemDatabase(), ledger.valMgr, eb, 1)
+	assert.NotNil(err)
+}
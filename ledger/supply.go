@@ -0,0 +1,117 @@
+package ledger
+
+import (
+	"fmt"
+	"math/big"
+
+	st "github.com/thetatoken/ukulele/ledger/state"
+	"github.com/thetatoken/ukulele/ledger/types"
+)
+
+// Supply breaks down the coins of a ledger state by where they are held.
+type Supply struct {
+	Accounts uint64      // Number of accounts
+	Balances types.Coins // Spendable balances of the accounts
+	Reserved types.Coins // Collateral and remaining funds of the reserves
+	Locked   types.Coins // Coins locked until their unlock height
+	Staked   types.Coins // Stakes, including the withdrawn stakes not returned yet
+	FeePool  types.Coins // Fees distributed by the next coinbase transaction
+	Total    types.Coins
+}
+
+// CalculateSupply iterates the ledger state and sums the coins of each denomination.
+func CalculateSupply(view *st.StoreView) *Supply {
+	supply := &Supply{
+		Balances: types.NewCoins(0, 0),
+		Reserved: types.NewCoins(0, 0),
+		Locked:   types.NewCoins(0, 0),
+		Staked:   types.NewCoins(0, 0),
+		FeePool:  view.GetFeePool().NoNil(),
+	}
+	view.TraverseAccounts(func(acc *types.Account) {
+		supply.Accounts++
+		supply.Balances = supply.Balances.Plus(acc.Balance)
+		for _, fund := range acc.ReservedFunds {
+			supply.Reserved = supply.Reserved.Plus(fund.Collateral).Plus(fund.InitialFund).Minus(fund.UsedFund)
+		}
+		supply.Locked = supply.Locked.Plus(acc.GetTotalLockedCoins())
+	})
+	for _, holder := range view.GetStakeHolders() {
+		for _, stake := range holder.Stakes {
+			supply.Staked = supply.Staked.Plus(types.Coins{ThetaWei: stake.Amount, GammaWei: big.NewInt(0)})
+		}
+	}
+	supply.Total = supply.Balances.Plus(supply.Reserved).Plus(supply.Locked).Plus(supply.Staked).Plus(supply.FeePool)
+	return supply
+}
+
+// SupplyAudit reconciles the supply of a ledger state with the supply of the genesis
+// state and the coins issued since. No transaction creates coins other than the block
+// rewards of the coinbase transactions and the tokens minted on creation, while the
+// burned fees and slashed collateral are destroyed. So the supply exceeding the
+// expected supply reveals an inflation bug.
+type SupplyAudit struct {
+	Height  uint64
+	Supply  *Supply
+	Genesis types.Coins // Supply of the genesis state
+	Issued  types.Coins // Block rewards issued since the genesis, and tokens minted
+
+	Expected  types.Coins // Genesis supply plus the issued coins
+	Burned    types.Coins // Expected supply in excess of the supply
+	Inflation types.Coins // Supply in excess of the expected supply, zero unless a bug created coins
+}
+
+// AuditSupply audits the supply of the ledger state against the supply of the genesis
+// state and the issuance of the reward policy. The block at each height is applied to
+// the state of its parent, whose height determines its block reward. The callers pass
+// the reward policy of the chain parameters of the audited state, so the issuance is
+// only exact if the block reward was not changed by a parameter update since the genesis.
+func AuditSupply(view *st.StoreView, genesis *st.StoreView, policy types.RewardPolicy) (*SupplyAudit, error) {
+	if view.Height() < genesis.Height() {
+		return nil, fmt.Errorf("Height %v is below the genesis height %v", view.Height(), genesis.Height())
+	}
+
+	audit := &SupplyAudit{
+		Height:  view.Height(),
+		Supply:  CalculateSupply(view),
+		Genesis: CalculateSupply(genesis).Total,
+		Issued:  types.Coins{ThetaWei: big.NewInt(0), GammaWei: policy.IssuanceBetween(genesis.Height(), view.Height())},
+	}
+	genesisTokens := map[string]bool{}
+	for _, token := range genesis.GetTokens() {
+		genesisTokens[token.Symbol] = true
+	}
+	for _, token := range view.GetTokens() {
+		if !genesisTokens[token.Symbol] {
+			audit.Issued = audit.Issued.Plus(types.NewTokenCoins(token.Symbol, token.MaxSupply))
+		}
+	}
+
+	audit.Expected = audit.Genesis.Plus(audit.Issued)
+	audit.Burned = positiveCoins(audit.Expected.Minus(audit.Supply.Total))
+	audit.Inflation = positiveCoins(audit.Supply.Total.Minus(audit.Expected))
+	return audit, nil
+}
+
+// IsConsistent returns whether the supply does not exceed the expected supply.
+func (audit *SupplyAudit) IsConsistent() bool {
+	return audit.Inflation.IsZero()
+}
+
+// positiveCoins returns the positive amounts of each denomination of the coins.
+func positiveCoins(coins types.Coins) types.Coins {
+	coins = coins.NoNil()
+	positive := types.NewCoins(0, 0)
+	if coins.ThetaWei.Sign() > 0 {
+		positive.ThetaWei = coins.ThetaWei
+	}
+	if coins.GammaWei.Sign() > 0 {
+		positive.GammaWei = coins.GammaWei
+	}
+	for _, token := range coins.Tokens {
+		if token.Amount.Sign() > 0 {
+			positive = positive.Plus(types.Coins{Tokens: []types.TokenCoin{token}})
+		}
+	}
+	return positive
+}
@@ -0,0 +1,64 @@
+package ledger
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thetatoken/ukulele/common"
+	st "github.com/thetatoken/ukulele/ledger/state"
+	"github.com/thetatoken/ukulele/ledger/types"
+	"github.com/thetatoken/ukulele/store/database/backend"
+)
+
+func TestAuditSupply(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	alice := common.HexToAddress("0xa11ce")
+	bob := common.HexToAddress("0xb0b")
+	policy := types.RewardPolicy{BlockReward: big.NewInt(5)}
+
+	genesis := st.NewStoreView(1, common.Hash{}, backend.NewMemDatabase())
+	genesis.SetAccount(alice, &types.Account{Address: alice, Balance: types.NewCoins(1000, 2000)})
+
+	// Alice stakes, reserves, sends locked coins to Bob and creates a token, the block
+	// rewards of 10 blocks are paid, and 130 GammaWei of fees are burned
+	view := st.NewStoreView(11, common.Hash{}, backend.NewMemDatabase())
+	aliceAcc := &types.Account{Address: alice, Balance: types.NewCoins(600, 1800).Plus(types.NewTokenCoins("ABC", big.NewInt(70)))}
+	aliceAcc.ReserveFund(types.NewCoins(0, 40), types.NewCoins(0, 60), []string{"rid"}, 100, 1, nil)
+	aliceAcc.ReservedFunds[0].UsedFund = types.NewCoins(0, 10)
+	view.SetAccount(alice, aliceAcc)
+	bobAcc := &types.Account{Address: bob, Balance: types.NewCoins(0, 110)}
+	bobAcc.LockCoins(types.NewCoins(100, 0).Plus(types.NewTokenCoins("ABC", big.NewInt(30))), 20)
+	view.SetAccount(bob, bobAcc)
+	holder := types.NewStakeHolder(alice)
+	holder.Stakes = append(holder.Stakes, &types.Stake{Source: alice, Amount: big.NewInt(300)})
+	view.SetStakeHolder(holder)
+	view.SetFeePool(types.NewCoins(0, 20))
+	view.SetToken(&types.Token{Symbol: "ABC", Decimals: 6, MaxSupply: big.NewInt(100), Issuer: alice})
+
+	audit, err := AuditSupply(view, genesis, policy)
+	require.Nil(err)
+	assert.Equal(uint64(2), audit.Supply.Accounts)
+	assert.True(types.NewCoins(0, 90).IsEqual(audit.Supply.Reserved))
+	assert.True(types.NewCoins(300, 0).IsEqual(audit.Supply.Staked))
+	assert.True(types.NewCoins(0, 20).IsEqual(audit.Supply.FeePool))
+	assert.True(types.NewCoins(1000, 1920).Plus(types.NewTokenCoins("ABC", big.NewInt(100))).IsEqual(audit.Supply.Total))
+	assert.True(types.NewCoins(0, 50).Plus(types.NewTokenCoins("ABC", big.NewInt(100))).IsEqual(audit.Issued))
+	assert.True(types.NewCoins(0, 130).IsEqual(audit.Burned))
+	assert.True(audit.Inflation.IsZero())
+	assert.True(audit.IsConsistent())
+
+	// Coins created outside of the reward schedule
+	bobAcc.Balance = bobAcc.Balance.Plus(types.NewCoins(1, 200)).Plus(types.NewTokenCoins("ABC", big.NewInt(1)))
+	view.SetAccount(bob, bobAcc)
+	audit, err = AuditSupply(view, genesis, policy)
+	require.Nil(err)
+	assert.True(types.NewCoins(1, 70).Plus(types.NewTokenCoins("ABC", big.NewInt(1))).IsEqual(audit.Inflation))
+	assert.False(audit.IsConsistent())
+
+	_, err = AuditSupply(genesis, view, policy)
+	assert.NotNil(err)
+}
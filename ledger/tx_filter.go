@@ -0,0 +1,112 @@
+package ledger
+
+import (
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/ledger/types"
+)
+
+// TxFilter is a policy of the operator of the node on the transactions of the blocks it
+// proposes. The transactions it excludes are left out of the proposed blocks, while the
+// blocks proposed by the other validators are validated regardless of it.
+type TxFilter interface {
+	// Exclude returns whether the transaction is left out, and why
+	Exclude(tx types.Tx) (excluded bool, reason string)
+}
+
+// excludableTxTypes are the types of the transactions the proposers take from the
+// mempool. The coinbase and slash transactions are added by the proposers themselves.
+var excludableTxTypes = []string{
+	"SendTx", "TimelockedSendTx", "ReserveFundTx", "ReleaseFundTx", "ExtendReserveTx",
+	"ServicePaymentTx", "BatchServicePaymentTx", "SplitRuleTx", "UpdateValidatorsTx",
+	"SmartContractTx", "DepositStakeTx", "WithdrawStakeTx", "SetGuardiansTx", "RecoveryTx",
+	"CreateTokenTx", "ParameterUpdateTx", "ProposalTx", "VoteTx", "UnjailTx",
+}
+
+// ListTxFilter excludes the transactions of any of its types, and the transactions sent
+// from or to any of its addresses.
+type ListTxFilter struct {
+	addresses map[common.Address]bool
+	txTypes   map[string]bool
+}
+
+var _ TxFilter = (*ListTxFilter)(nil)
+
+// NewListTxFilter creates a ListTxFilter. The types are named after the transactions,
+// e.g. "SmartContractTx".
+func NewListTxFilter(addresses []common.Address, txTypes []string) (*ListTxFilter, error) {
+	f := &ListTxFilter{
+		addresses: make(map[common.Address]bool),
+		txTypes:   make(map[string]bool),
+	}
+	for _, address := range addresses {
+		f.addresses[address] = true
+	}
+	for _, txType := range txTypes {
+		if !isExcludableTxType(txType) {
+			return nil, fmt.Errorf("%q is not a type of transaction proposed from the mempool, e.g. \"SendTx\"", txType)
+		}
+		f.txTypes[txType] = true
+	}
+	return f, nil
+}
+
+// NewTxFilterFromConfig creates a ListTxFilter from the proposer config, nil if it
+// excludes nothing.
+func NewTxFilterFromConfig(config common.ProposerConfig) (TxFilter, error) {
+	if len(config.ExcludedAddresses) == 0 && len(config.ExcludedTxTypes) == 0 {
+		return nil, nil
+	}
+	f, err := NewListTxFilter(config.ExcludedAddresses, config.ExcludedTxTypes)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *ListTxFilter) Exclude(tx types.Tx) (bool, string) {
+	if txType := types.TxTypeName(tx); f.txTypes[txType] {
+		return true, "excluded type " + txType
+	}
+	senders, recipients := types.TxParticipants(tx)
+	for _, address := range append(senders, recipients...) {
+		if f.addresses[address] {
+			return true, "excluded address " + address.Hex()
+		}
+	}
+	return false, ""
+}
+
+func isExcludableTxType(txType string) bool {
+	for _, name := range excludableTxTypes {
+		if name == txType {
+			return true
+		}
+	}
+	return false
+}
+
+// SetTxFilter sets the policy on the transactions of the blocks proposed by the node, nil
+// to propose any valid transaction.
+func (ledger *Ledger) SetTxFilter(filter TxFilter) {
+	ledger.mu.Lock()
+	defer ledger.mu.Unlock()
+
+	ledger.txFilter = filter
+}
+
+// excludeTx returns whether the proposer leaves the transaction out of its block.
+func (ledger *Ledger) excludeTx(tx types.Tx) bool {
+	if ledger.txFilter == nil {
+		return false
+	}
+	excluded, reason := ledger.txFilter.Exclude(tx)
+	if excluded {
+		ledger.excludedTxMeter.Mark(1)
+		log.Debugf("Transaction left out of the proposed block: reason = %v, tx = %v", reason, tx)
+	}
+	return excluded
+}
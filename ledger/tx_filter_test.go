@@ -0,0 +1,86 @@
+package ledger
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/ledger/types"
+)
+
+func TestListTxFilter(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	sender := common.HexToAddress("0x2E833968E5bB786Ae419c4d13189fB081Cc43bab")
+	recipient := common.HexToAddress("0x9F1233798E905E173560071255140b4A8aBd3Ec6")
+	other := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	sendTx := &types.SendTx{
+		Inputs:  []types.TxInput{{Address: sender}},
+		Outputs: []types.TxOutput{{Address: recipient}},
+	}
+
+	f, err := NewListTxFilter([]common.Address{recipient}, nil)
+	require.Nil(err)
+	excluded, reason := f.Exclude(sendTx)
+	assert.True(excluded)
+	assert.Contains(reason, recipient.Hex())
+
+	f, err = NewListTxFilter([]common.Address{other}, []string{"SmartContractTx"})
+	require.Nil(err)
+	excluded, _ = f.Exclude(sendTx)
+	assert.False(excluded)
+	excluded, reason = f.Exclude(&types.SmartContractTx{From: types.TxInput{Address: sender}})
+	assert.True(excluded)
+	assert.Contains(reason, "SmartContractTx")
+
+	// The proposers add the coinbase transactions themselves
+	_, err = NewListTxFilter(nil, []string{"CoinbaseTx"})
+	assert.NotNil(err)
+	_, err = NewListTxFilter(nil, []string{"Send"})
+	assert.NotNil(err)
+
+	filter, err := NewTxFilterFromConfig(common.ProposerConfig{})
+	assert.Nil(err)
+	assert.Nil(filter)
+}
+
+func TestProposeBlockTxsWithTxFilter(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	chainID, ledger, mempool := newTestLedger()
+	accOut, accIns := prepareInitLedgerState(ledger, 2)
+	initHeight := ledger.state.Height()
+	initRoot := ledger.state.Delivered().Hash()
+
+	excludedTx := newRawSendTx(chainID, 1, true, accOut, accIns[0], false)
+	includedTx := newRawSendTx(chainID, 1, true, accOut, accIns[1], false)
+	require.Nil(mempool.InsertTransaction(excludedTx))
+	require.Nil(mempool.InsertTransaction(includedTx))
+
+	f, err := NewListTxFilter([]common.Address{accIns[0].Address}, nil)
+	require.Nil(err)
+	ledger.SetTxFilter(f)
+
+	_, txs, res := ledger.ProposeBlockTxs(ledger.consensus.GetEpoch())
+	require.True(res.IsOK(), res.Message)
+	require.Equal(2, len(txs))
+	assert.Equal(includedTx, txs[1])
+
+	// The block of another proposer with the excluded transaction is still valid
+	blockTxs := []common.Bytes{txs[0], excludedTx, includedTx}
+	require.True(ledger.ResetState(initHeight, initRoot).IsOK())
+	ledger.state.Checked().SetEpoch(ledger.consensus.GetEpoch())
+	for _, rawTx := range blockTxs {
+		tx, err := types.TxFromBytes(rawTx)
+		require.Nil(err)
+		_, res := ledger.executor.CheckTx(tx)
+		require.True(res.IsOK(), res.Message)
+	}
+	stateHash := ledger.state.Checked().Hash()
+	require.True(ledger.ResetState(initHeight, initRoot).IsOK())
+	res = ledger.ApplyBlockTxs(ledger.consensus.GetEpoch(), blockTxs, stateHash)
+	assert.True(res.IsOK(), res.Message)
+}
@@ -21,6 +21,16 @@ type Account struct {
 	// Smart contract
 	Root     common.Hash `json:"root"`      // merkle root of the storage trie
 	CodeHash common.Hash `json:"code_hash"` // hash of the smart contract code
+
+	// Optional state, at most one, see GetGuardianship and GetLockedCoins. As the tail of
+	// the encoding, an account without any encodes as before it was introduced.
+	Extension []AccountExtension `rlp:"tail"`
+}
+
+// AccountExtension holds the optional state of an account
+type AccountExtension struct {
+	Guardianship []Guardianship // Social recovery, at most one
+	LockedCoins  []LockedCoins  // Coins received with a timelock, by increasing unlock height
 }
 
 type AccountJSON struct {
@@ -30,6 +40,8 @@ type AccountJSON struct {
 	LastUpdatedBlockHeight common.JSONUint64 `json:"last_updated_block_height"`
 	Root                   common.Hash       `json:"root"`
 	CodeHash               common.Hash       `json:"code"`
+	Guardianship           *Guardianship     `json:"guardianship,omitempty"`
+	LockedCoins            []LockedCoins     `json:"locked_coins,omitempty"`
 }
 
 func NewAccountJSON(acc Account) AccountJSON {
@@ -40,11 +52,13 @@ func NewAccountJSON(acc Account) AccountJSON {
 		LastUpdatedBlockHeight: common.JSONUint64(acc.LastUpdatedBlockHeight),
 		Root:     acc.Root,
 		CodeHash: acc.CodeHash,
+		Guardianship: acc.GetGuardianship(),
+		LockedCoins:  acc.GetLockedCoins(),
 	}
 }
 
 func (acc AccountJSON) Account() Account {
-	return Account{
+	account := Account{
 		Sequence:               uint64(acc.Sequence),
 		Balance:                acc.Balance,
 		ReservedFunds:          acc.ReservedFunds,
@@ -52,6 +66,9 @@ func (acc AccountJSON) Account() Account {
 		Root:     acc.Root,
 		CodeHash: acc.CodeHash,
 	}
+	account.SetGuardianship(acc.Guardianship)
+	account.setLockedCoins(acc.LockedCoins)
+	return account
 }
 
 func (acc Account) MarshalJSON() ([]byte, error) {
@@ -76,6 +93,23 @@ func NewAccount(address common.Address) *Account {
 	}
 }
 
+func (acc *Account) extension() AccountExtension {
+	if len(acc.Extension) == 0 {
+		return AccountExtension{}
+	}
+	return acc.Extension[0]
+}
+
+// setExtension replaces the extension of the account, removing it if it is empty. The
+// extension is never modified in place, as it is shared with the copies of the account.
+func (acc *Account) setExtension(ext AccountExtension) {
+	if len(ext.Guardianship) == 0 && len(ext.LockedCoins) == 0 {
+		acc.Extension = nil
+		return
+	}
+	acc.Extension = []AccountExtension{ext}
+}
+
 func (acc *Account) Copy() *Account {
 	if acc == nil {
 		return nil
@@ -124,8 +158,9 @@ func (acc *Account) CheckReserveFund(collateral Coins, fund Coins, duration uint
 	return nil
 }
 
-// ReserveFund reserves the given amount of fund for subsequence service payments
-func (acc *Account) ReserveFund(collateral Coins, fund Coins, resourceIDs []string, endBlockHeight uint64, reserveSequence uint64) {
+// ReserveFund reserves the given amount of fund for subsequence service payments, with
+// optional spend limits for the resources
+func (acc *Account) ReserveFund(collateral Coins, fund Coins, resourceIDs []string, endBlockHeight uint64, reserveSequence uint64, spendLimits []ResourceSpendLimit) {
 	newReservedFund := ReservedFund{
 		Collateral:      collateral,
 		InitialFund:     fund,
@@ -133,6 +168,7 @@ func (acc *Account) ReserveFund(collateral Coins, fund Coins, resourceIDs []stri
 		ResourceIDs:     resourceIDs,
 		EndBlockHeight:  endBlockHeight,
 		ReserveSequence: reserveSequence,
+		SpendLimits:     spendLimits,
 	}
 	acc.ReservedFunds = append(acc.ReservedFunds, newReservedFund)
 	acc.Balance = acc.Balance.Minus(collateral).Minus(fund)
@@ -170,7 +206,7 @@ func (acc *Account) CheckReleaseFund(currentBlockHeight uint64, reserveSequence
 		return nil // at most one matching reserveSequence
 	}
 
-	return errors.Errorf("No matching ReserveSequence")
+	return errors.Wrapf(ErrReservedFundNotFound, "reserveSequence %d", reserveSequence)
 }
 
 func calcMinimumReleaseBlockHeight(reservedFund *ReservedFund) uint64 {
@@ -197,6 +233,67 @@ func (acc *Account) ReleaseFund(currentBlockHeight uint64, reserveSequence uint6
 	}
 }
 
+// CheckExtendReserve verifies inputs for ExtendReserve
+func (acc *Account) CheckExtendReserve(collateral Coins, fund Coins, duration uint64, currentBlockHeight uint64, reserveSequence uint64) error {
+	if duration > MaximumFundReserveDuration {
+		return errors.New("Duration is out of permitted range")
+	}
+
+	if !collateral.IsValid() || !collateral.IsNonnegative() {
+		return errors.New("Invalid collateral")
+	}
+
+	if !fund.IsValid() || !fund.IsNonnegative() {
+		return errors.New("Invalid fund")
+	}
+
+	if duration == 0 && collateral.IsZero() && fund.IsZero() {
+		return errors.New("The reserve is neither topped up nor extended")
+	}
+
+	minimalBalance := collateral.Plus(fund)
+	if !acc.Balance.IsGTE(minimalBalance) {
+		return errors.New("Not enough balance")
+	}
+
+	for _, reservedFund := range acc.ReservedFunds {
+		if reservedFund.ReserveSequence != reserveSequence {
+			continue
+		}
+
+		if reservedFund.EndBlockHeight < currentBlockHeight {
+			return errors.Errorf("The reserve ended at blockheight %d", reservedFund.EndBlockHeight)
+		}
+		if reservedFund.EndBlockHeight+duration > currentBlockHeight+MaximumFundReserveDuration {
+			return errors.New("Duration is out of permitted range")
+		}
+		if !reservedFund.Collateral.Plus(collateral).Minus(reservedFund.InitialFund.Plus(fund)).IsPositive() {
+			return errors.New("Collateral should be strictly greater than the fund")
+		}
+		return nil // at most one matching reserveSequence
+	}
+
+	return errors.Wrapf(ErrReservedFundNotFound, "reserveSequence %d", reserveSequence)
+}
+
+// ExtendReserve adds the given collateral and fund to the reserved fund, and extends its
+// end by the given duration. The reserve sequence is unchanged, so the service payments
+// already signed for the reserve remain valid.
+func (acc *Account) ExtendReserve(collateral Coins, fund Coins, duration uint64, reserveSequence uint64) {
+	for idx := range acc.ReservedFunds {
+		reservedFund := &acc.ReservedFunds[idx]
+		if reservedFund.ReserveSequence != reserveSequence {
+			continue
+		}
+
+		reservedFund.Collateral = reservedFund.Collateral.Plus(collateral)
+		reservedFund.InitialFund = reservedFund.InitialFund.Plus(fund)
+		reservedFund.EndBlockHeight += duration
+		acc.Balance = acc.Balance.Minus(collateral).Minus(fund)
+		return
+	}
+}
+
 // CheckTransferReservedFund verifies inputs for SplitReservedFund
 func (acc *Account) CheckTransferReservedFund(tgtAcc *Account, transferAmount Coins, paymentSequence uint64, currentBlockHeight uint64, reserveSequence uint64) error {
 	for _, reservedFund := range acc.ReservedFunds {
@@ -216,7 +313,20 @@ func (acc *Account) CheckTransferReservedFund(tgtAcc *Account, transferAmount Co
 
 		return nil // at most one matching reserveSequence
 	}
-	return errors.Errorf("No matching ReservedFund with reserveSequence %d", reserveSequence)
+	return errors.Wrapf(ErrReservedFundNotFound, "reserveSequence %d", reserveSequence)
+}
+
+// CheckSpendLimit verifies that the transfer from the reserved fund does not exceed the
+// spend limit of the resource. The error is caused by ErrSpendLimitExceeded if it does.
+func (acc *Account) CheckSpendLimit(resourceID string, transferAmount Coins, reserveSequence uint64) error {
+	for idx := range acc.ReservedFunds {
+		reservedFund := &acc.ReservedFunds[idx]
+		if reservedFund.ReserveSequence != reserveSequence {
+			continue
+		}
+		return reservedFund.CheckSpendLimit(resourceID, transferAmount) // at most one matching reserveSequence
+	}
+	return errors.Wrapf(ErrReservedFundNotFound, "reserveSequence %d", reserveSequence)
 }
 
 // TransferReservedFund transfers the specified amount of reserved fund to the accounts participated in the payment split, and send remainder back to the source account (i.e. the acount itself)
@@ -272,6 +382,8 @@ func (acc *Account) generateSlashIntent(reservedFund *ReservedFund, currentServi
 func (acc *Account) UpdateToHeight(height uint64) {
 	//	acc.UpdateAccountGammaReward(height) // Initial Gamma inflation should be zero for all accounts
 	acc.ReleaseExpiredFunds(height)
+	acc.CompleteRecovery(height)
+	acc.UnlockCoins(height)
 }
 
 // func (acc *Account) UpdateAccountGammaReward(currentBlockHeight uint64) {
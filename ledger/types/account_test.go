@@ -20,7 +20,7 @@ func makeAccount(secret string, balance Coins) Account {
 func makeAccountAndReserveFund(initialBalance Coins, collateral Coins, fund Coins, resourceID string, endBlockHeight uint64, reserveSequence uint64) Account {
 	acc := makeAccount("srcAcc", initialBalance)
 	resourceIDs := []string{resourceID}
-	acc.ReserveFund(collateral, fund, resourceIDs, endBlockHeight, reserveSequence)
+	acc.ReserveFund(collateral, fund, resourceIDs, endBlockHeight, reserveSequence, nil)
 
 	return acc
 }
@@ -96,9 +96,9 @@ func TestReleaseExpiredFunds(t *testing.T) {
 	resourceIDs := []string{"rid001"}
 
 	acc := makeAccount("foo", initialBalance)
-	acc.ReserveFund(collateral, fund, resourceIDs, 10, 1)
-	acc.ReserveFund(collateral, fund, resourceIDs, 20, 2)
-	acc.ReserveFund(collateral, fund, resourceIDs, 30, 3)
+	acc.ReserveFund(collateral, fund, resourceIDs, 10, 1, nil)
+	acc.ReserveFund(collateral, fund, resourceIDs, 20, 2, nil)
+	acc.ReserveFund(collateral, fund, resourceIDs, 30, 3, nil)
 
 	acc.ReleaseExpiredFunds(20) // only the first ReservedFund can be released
 	assert.Equal(t, 2, len(acc.ReservedFunds))
@@ -136,6 +136,50 @@ func TestCheckReleaseFund(t *testing.T) {
 	assert.Equal(t, 0, len(acc.ReservedFunds))
 }
 
+func TestExtendReserve(t *testing.T) {
+	initialBalance := NewCoins(1000, 20000)
+	collateral := NewCoins(0, 101)
+	fund := NewCoins(0, 100)
+	resourceID := "rid001"
+	endBlockHeight := uint64(199)
+	reserveSequence := uint64(1)
+
+	acc := makeAccountAndReserveFund(initialBalance, collateral, fund, resourceID, endBlockHeight, reserveSequence)
+
+	currentBlockHeight := uint64(80)
+	noCoins := NewCoins(0, 0)
+
+	// Nothing to extend
+	assert.NotNil(t, acc.CheckExtendReserve(noCoins, noCoins, 0, currentBlockHeight, reserveSequence))
+
+	// The fund would reach the collateral
+	assert.NotNil(t, acc.CheckExtendReserve(noCoins, NewCoins(0, 10), 0, currentBlockHeight, reserveSequence))
+
+	// The reserve sequence does not match
+	assert.NotNil(t, acc.CheckExtendReserve(noCoins, noCoins, 100, currentBlockHeight, 2))
+
+	// The reserve has ended
+	assert.NotNil(t, acc.CheckExtendReserve(noCoins, noCoins, 100, endBlockHeight+1, reserveSequence))
+
+	// The reserve would end more than MaximumFundReserveDuration blocks from now
+	assert.NotNil(t, acc.CheckExtendReserve(noCoins, noCoins, MaximumFundReserveDuration, currentBlockHeight, reserveSequence))
+
+	// Not enough balance
+	assert.NotNil(t, acc.CheckExtendReserve(NewCoins(0, 20000), noCoins, 0, currentBlockHeight, reserveSequence))
+
+	moreCollateral := NewCoins(0, 50)
+	moreFund := NewCoins(0, 40)
+	assert.Nil(t, acc.CheckExtendReserve(moreCollateral, moreFund, 100, currentBlockHeight, reserveSequence))
+	acc.ExtendReserve(moreCollateral, moreFund, 100, reserveSequence)
+
+	assert.Equal(t, 1, len(acc.ReservedFunds))
+	assert.Equal(t, reserveSequence, acc.ReservedFunds[0].ReserveSequence)
+	assert.Equal(t, collateral.Plus(moreCollateral), acc.ReservedFunds[0].Collateral)
+	assert.Equal(t, fund.Plus(moreFund), acc.ReservedFunds[0].InitialFund)
+	assert.Equal(t, endBlockHeight+100, acc.ReservedFunds[0].EndBlockHeight)
+	assert.Equal(t, initialBalance, acc.Balance.Plus(acc.ReservedFunds[0].Collateral).Plus(acc.ReservedFunds[0].InitialFund))
+}
+
 // Test 1: currentBlockHeight > endBlockHeight
 func TestTransferReservedFund1(t *testing.T) {
 	srcAcc, tgtAcc, splitAcc1, _, servicePaymentTx, reserveSequence := prepareForTransferReservedFund()
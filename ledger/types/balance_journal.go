@@ -0,0 +1,108 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+
+	"github.com/thetatoken/ukulele/common"
+)
+
+// ** Balance journal: Balances changed by the transactions of a block **
+//
+
+// BalanceChange records the balance of an account before and after a block. The
+// balance of an account created by the block is zero before it.
+type BalanceChange struct {
+	Address common.Address
+	Before  Coins
+	After   Coins
+}
+
+// Delta returns the change of the balance, negative for the coins spent
+func (c BalanceChange) Delta() Coins {
+	return c.After.Minus(c.Before)
+}
+
+type BalanceChangeJSON struct {
+	Address common.Address `json:"address"`
+	Before  Coins          `json:"before"`
+	After   Coins          `json:"after"`
+	Delta   Coins          `json:"delta"`
+}
+
+func (c BalanceChange) MarshalJSON() ([]byte, error) {
+	return json.Marshal(BalanceChangeJSON{
+		Address: c.Address,
+		Before:  c.Before,
+		After:   c.After,
+		Delta:   c.Delta(),
+	})
+}
+
+func (c *BalanceChange) UnmarshalJSON(data []byte) error {
+	var b BalanceChangeJSON
+	if err := json.Unmarshal(data, &b); err != nil {
+		return err
+	}
+	c.Address = b.Address
+	c.Before = b.Before
+	c.After = b.After
+	return nil
+}
+
+// BalanceJournal records the balances changed by the transactions of a block, so that
+// the history of an account can be followed without replaying the blocks.
+type BalanceJournal struct {
+	Height    uint64          // Height of the block
+	StateRoot common.Hash     // State root after the block, as in its header
+	Changes   []BalanceChange // Ordered by address
+}
+
+// NewBalanceJournal creates a journal of the given changes, ordering them by address
+func NewBalanceJournal(height uint64, stateRoot common.Hash, changes []BalanceChange) *BalanceJournal {
+	sort.Slice(changes, func(i, j int) bool {
+		return bytes.Compare(changes[i].Address[:], changes[j].Address[:]) < 0
+	})
+	return &BalanceJournal{
+		Height:    height,
+		StateRoot: stateRoot,
+		Changes:   changes,
+	}
+}
+
+// Find returns the change of the balance of the given address, if the block changed it
+func (j *BalanceJournal) Find(address common.Address) (*BalanceChange, bool) {
+	i := sort.Search(len(j.Changes), func(i int) bool {
+		return bytes.Compare(j.Changes[i].Address[:], address[:]) >= 0
+	})
+	if i < len(j.Changes) && j.Changes[i].Address == address {
+		return &j.Changes[i], true
+	}
+	return nil, false
+}
+
+type BalanceJournalJSON struct {
+	Height    common.JSONUint64 `json:"height"`
+	StateRoot common.Hash       `json:"state_root"`
+	Changes   []BalanceChange   `json:"changes"`
+}
+
+func (j BalanceJournal) MarshalJSON() ([]byte, error) {
+	return json.Marshal(BalanceJournalJSON{
+		Height:    common.JSONUint64(j.Height),
+		StateRoot: j.StateRoot,
+		Changes:   j.Changes,
+	})
+}
+
+func (j *BalanceJournal) UnmarshalJSON(data []byte) error {
+	var b BalanceJournalJSON
+	if err := json.Unmarshal(data, &b); err != nil {
+		return err
+	}
+	j.Height = uint64(b.Height)
+	j.StateRoot = b.StateRoot
+	j.Changes = b.Changes
+	return nil
+}
@@ -0,0 +1,45 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thetatoken/ukulele/common"
+)
+
+func TestBalanceJournal(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	addr1, addr2, addr3 := common.HexToAddress("0x3"), common.HexToAddress("0x1"), common.HexToAddress("0x2")
+	journal := NewBalanceJournal(12, common.HexToHash("0x12"), []BalanceChange{
+		{Address: addr1, Before: NewCoins(10, 20), After: NewCoins(5, 30)},
+		{Address: addr2, Before: NewCoins(0, 0), After: NewCoins(1, 1)},
+	})
+	assert.Equal(addr2, journal.Changes[0].Address)
+	assert.Equal(addr1, journal.Changes[1].Address)
+
+	change, ok := journal.Find(addr1)
+	require.True(ok)
+	assert.True(NewCoins(-5, 10).IsEqual(change.Delta()))
+	_, ok = journal.Find(addr3)
+	assert.False(ok)
+
+	raw, err := ToBytes(journal)
+	require.Nil(err)
+	decoded := &BalanceJournal{}
+	require.Nil(FromBytes(raw, decoded))
+	assert.Equal(uint64(12), decoded.Height)
+	assert.Equal(journal.StateRoot, decoded.StateRoot)
+	require.Equal(2, len(decoded.Changes))
+	assert.True(journal.Changes[1].After.IsEqual(decoded.Changes[1].After))
+
+	js, err := json.Marshal(journal)
+	require.Nil(err)
+	assert.Contains(string(js), `"delta":{"thetawei":"-5","gammawei":"10"}`)
+	decoded = &BalanceJournal{}
+	require.Nil(json.Unmarshal(js, decoded))
+	assert.Equal(uint64(12), decoded.Height)
+	assert.True(journal.Changes[1].Before.IsEqual(decoded.Changes[1].Before))
+}
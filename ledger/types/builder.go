@@ -0,0 +1,202 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/crypto"
+)
+
+// AccountGetter looks up the accounts, e.g. a state.StoreView.
+type AccountGetter interface {
+	GetAccount(addr common.Address) *Account
+}
+
+// TxSigner signs the transactions of an address, e.g. with a private key or a wallet.
+type TxSigner interface {
+	Address() common.Address
+	Sign(signBytes common.Bytes) (*crypto.Signature, error)
+}
+
+// signableTx is implemented by the transactions signed by their signing addresses.
+type signableTx interface {
+	Tx
+	SetSignature(addr common.Address, sig *crypto.Signature) bool
+}
+
+// SignTx signs the transaction for the chain with each of the signers, which must all be
+// signing addresses of the transaction.
+func SignTx(tx Tx, chainID string, signers ...TxSigner) error {
+	stx, ok := tx.(signableTx)
+	if !ok {
+		return fmt.Errorf("Transactions of type %T cannot be signed", tx)
+	}
+	signBytes := stx.SignBytes(chainID)
+	for _, signer := range signers {
+		sig, err := signer.Sign(signBytes)
+		if err != nil {
+			return fmt.Errorf("Failed to sign with %v: %v", signer.Address().Hex(), err)
+		}
+		if !stx.SetSignature(signer.Address(), sig) {
+			return fmt.Errorf("%v does not sign the transaction", signer.Address().Hex())
+		}
+	}
+	return nil
+}
+
+// SendTxBuilder builds a SendTx step by step, e.g.
+//
+//	tx, err := NewSendTxBuilder().From(alice).To(bob).Amount(NewCoins(10, 0)).Fee(fee).
+//		Accounts(view).Sign(chainID, aliceSigner)
+//
+// A sender added with From pays the amounts of all the recipients plus the fee, unless
+// its coins are set with Spend, which is needed when there are several senders. The
+// sequences not set with Sequence are the next sequences of the senders in the
+// accounts given to Accounts. The first error of the steps is returned by Build.
+type SendTxBuilder struct {
+	inputs   []TxInput
+	spends   []bool // Whether the coins of each input are set
+	outputs  []TxOutput
+	fee      *big.Int
+	accounts AccountGetter
+	err      error
+}
+
+func NewSendTxBuilder() *SendTxBuilder {
+	return &SendTxBuilder{
+		fee: big.NewInt(0),
+	}
+}
+
+// From adds a sender.
+func (b *SendTxBuilder) From(address common.Address) *SendTxBuilder {
+	b.inputs = append(b.inputs, TxInput{Address: address})
+	b.spends = append(b.spends, false)
+	return b
+}
+
+// Spend sets the coins spent by the last sender, including its part of the fee.
+func (b *SendTxBuilder) Spend(coins Coins) *SendTxBuilder {
+	if len(b.inputs) == 0 {
+		return b.fail(errors.New("Spend must follow From"))
+	}
+	b.inputs[len(b.inputs)-1].Coins = coins.NoNil()
+	b.spends[len(b.spends)-1] = true
+	return b
+}
+
+// Sequence sets the sequence of the transaction for the last sender.
+func (b *SendTxBuilder) Sequence(sequence uint64) *SendTxBuilder {
+	if len(b.inputs) == 0 {
+		return b.fail(errors.New("Sequence must follow From"))
+	}
+	b.inputs[len(b.inputs)-1].Sequence = sequence
+	return b
+}
+
+// To adds a recipient.
+func (b *SendTxBuilder) To(address common.Address) *SendTxBuilder {
+	b.outputs = append(b.outputs, TxOutput{Address: address, Coins: NewCoins(0, 0)})
+	return b
+}
+
+// Amount sets the coins received by the last recipient.
+func (b *SendTxBuilder) Amount(coins Coins) *SendTxBuilder {
+	if len(b.outputs) == 0 {
+		return b.fail(errors.New("Amount must follow To"))
+	}
+	b.outputs[len(b.outputs)-1].Coins = coins.NoNil()
+	return b
+}
+
+// Fee sets the fee in GammaWei.
+func (b *SendTxBuilder) Fee(gammaWei *big.Int) *SendTxBuilder {
+	if gammaWei == nil || gammaWei.Sign() < 0 {
+		return b.fail(errors.New("The fee must be a non-negative amount of GammaWei"))
+	}
+	b.fee = gammaWei
+	return b
+}
+
+// Accounts sets the accounts the sequences of the senders are read from.
+func (b *SendTxBuilder) Accounts(accounts AccountGetter) *SendTxBuilder {
+	b.accounts = accounts
+	return b
+}
+
+func (b *SendTxBuilder) fail(err error) *SendTxBuilder {
+	if b.err == nil {
+		b.err = err
+	}
+	return b
+}
+
+// Build returns the unsigned transaction.
+func (b *SendTxBuilder) Build() (*SendTx, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	if len(b.inputs) == 0 || len(b.outputs) == 0 {
+		return nil, errors.New("The transaction needs at least one sender and one recipient")
+	}
+
+	fee := Coins{ThetaWei: big.NewInt(0), GammaWei: new(big.Int).Set(b.fee)}
+	total := fee
+	for _, output := range b.outputs {
+		if !output.Coins.IsNonnegative() {
+			return nil, fmt.Errorf("Negative amount sent to %v", output.Address.Hex())
+		}
+		total = total.Plus(output.Coins)
+	}
+
+	inputs := make([]TxInput, len(b.inputs))
+	for i, input := range b.inputs {
+		if !b.spends[i] {
+			if len(b.inputs) > 1 {
+				return nil, errors.New("The coins of each sender must be set with Spend when there are several senders")
+			}
+			input.Coins = total
+		}
+		if input.Sequence == 0 {
+			if b.accounts == nil {
+				return nil, fmt.Errorf("The sequence of %v must be set, or the accounts given", input.Address.Hex())
+			}
+			account := b.accounts.GetAccount(input.Address)
+			if account == nil {
+				return nil, fmt.Errorf("Account %v is not found", input.Address.Hex())
+			}
+			input.Sequence = account.Sequence + 1
+		}
+		inputs[i] = input
+	}
+
+	return &SendTx{
+		Fee:     fee,
+		Inputs:  inputs,
+		Outputs: append([]TxOutput{}, b.outputs...),
+	}, nil
+}
+
+// SignBytes builds the transaction and returns its sign bytes for the chain, e.g. to sign
+// them offline.
+func (b *SendTxBuilder) SignBytes(chainID string) (common.Bytes, error) {
+	tx, err := b.Build()
+	if err != nil {
+		return nil, err
+	}
+	return tx.SignBytes(chainID), nil
+}
+
+// Sign builds the transaction and signs it for the chain with each of the signers.
+func (b *SendTxBuilder) Sign(chainID string, signers ...TxSigner) (*SendTx, error) {
+	tx, err := b.Build()
+	if err != nil {
+		return nil, err
+	}
+	if err := SignTx(tx, chainID, signers...); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
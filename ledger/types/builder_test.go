@@ -0,0 +1,94 @@
+package types
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/crypto"
+)
+
+type testAccounts map[common.Address]*Account
+
+func (accounts testAccounts) GetAccount(addr common.Address) *Account {
+	return accounts[addr]
+}
+
+type privAccountSigner struct {
+	acc PrivAccount
+}
+
+func (s privAccountSigner) Address() common.Address {
+	return s.acc.Address
+}
+
+func (s privAccountSigner) Sign(signBytes common.Bytes) (*crypto.Signature, error) {
+	return s.acc.PrivKey.Sign(signBytes)
+}
+
+func TestSendTxBuilder(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	alice := MakeAcc("alice")
+	alice.Sequence = 6
+	bob := MakeAcc("bob")
+	carol := MakeAcc("carol")
+	accounts := testAccounts{alice.Address: &alice.Account}
+	fee := big.NewInt(1000000000000)
+
+	// The sender pays the amounts and the fee, with its next sequence
+	tx, err := NewSendTxBuilder().From(alice.Address).
+		To(bob.Address).Amount(NewCoins(10, 100)).
+		To(carol.Address).Amount(NewCoins(0, 50)).
+		Fee(fee).Accounts(accounts).Sign(chainID, privAccountSigner{alice})
+	require.Nil(err)
+	require.Equal(1, len(tx.Inputs))
+	assert.Equal(uint64(7), tx.Inputs[0].Sequence)
+	assert.Equal(big.NewInt(10), tx.Inputs[0].Coins.ThetaWei)
+	assert.Equal(big.NewInt(1000000000150), tx.Inputs[0].Coins.GammaWei)
+	assert.Equal(fee, tx.Fee.GammaWei)
+	require.Equal(2, len(tx.Outputs))
+	assert.Equal(carol.Address, tx.Outputs[1].Address)
+	assert.True(alice.PrivKey.PublicKey().VerifySignature(tx.SignBytes(chainID), tx.Inputs[0].Signature))
+
+	// The sign bytes are the ones of the built transaction
+	builder := NewSendTxBuilder().From(alice.Address).Sequence(9).To(bob.Address).Amount(NewCoins(0, 1)).Fee(fee)
+	signBytes, err := builder.SignBytes(chainID)
+	require.Nil(err)
+	built, err := builder.Build()
+	require.Nil(err)
+	assert.Equal(common.Bytes(built.SignBytes(chainID)), signBytes)
+	assert.Equal(uint64(9), built.Inputs[0].Sequence)
+
+	// Several senders set their coins
+	tx, err = NewSendTxBuilder().
+		From(alice.Address).Sequence(7).Spend(NewCoins(0, 1000000000060)).
+		From(bob.Address).Sequence(1).Spend(NewCoins(0, 40)).
+		To(carol.Address).Amount(NewCoins(0, 100)).
+		Fee(fee).Sign(chainID, privAccountSigner{alice}, privAccountSigner{bob})
+	require.Nil(err)
+	assert.Equal(big.NewInt(40), tx.Inputs[1].Coins.GammaWei)
+	assert.True(bob.PrivKey.PublicKey().VerifySignature(tx.SignBytes(chainID), tx.Inputs[1].Signature))
+
+	_, err = NewSendTxBuilder().From(alice.Address).Sequence(7).From(bob.Address).Sequence(1).
+		To(carol.Address).Amount(NewCoins(0, 100)).Build()
+	assert.NotNil(err)
+
+	// The sequence is set or read from the accounts
+	_, err = NewSendTxBuilder().From(alice.Address).To(bob.Address).Amount(NewCoins(0, 1)).Build()
+	assert.NotNil(err)
+	_, err = NewSendTxBuilder().From(bob.Address).To(alice.Address).Amount(NewCoins(0, 1)).Accounts(accounts).Build()
+	assert.NotNil(err)
+
+	// The steps must be in order, and the signers must sign the transaction
+	_, err = NewSendTxBuilder().Amount(NewCoins(0, 1)).To(bob.Address).From(alice.Address).Sequence(7).Build()
+	assert.NotNil(err)
+	_, err = NewSendTxBuilder().From(alice.Address).Sequence(7).To(bob.Address).Amount(NewCoins(0, 1)).
+		Sign(chainID, privAccountSigner{carol})
+	assert.NotNil(err)
+	_, err = NewSendTxBuilder().From(alice.Address).Sequence(7).To(bob.Address).Amount(NewCoins(0, -1)).Build()
+	assert.NotNil(err)
+}
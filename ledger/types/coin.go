@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/rlp"
 )
 
 var (
@@ -22,17 +23,23 @@ func init() {
 type Coins struct {
 	ThetaWei *big.Int
 	GammaWei *big.Int
+
+	// Optional application tokens, sorted by symbol, see TokenCoin. As the tail of the
+	// encoding, coins without tokens encode as before they were introduced.
+	Tokens []TokenCoin `rlp:"tail"`
 }
 
 type CoinsJSON struct {
 	ThetaWei *common.JSONBig `json:"thetawei"`
 	GammaWei *common.JSONBig `json:"gammawei"`
+	Tokens   []TokenCoin     `json:"tokens,omitempty"`
 }
 
 func NewCoinsJSON(coin Coins) CoinsJSON {
 	return CoinsJSON{
 		ThetaWei: (*common.JSONBig)(coin.ThetaWei),
 		GammaWei: (*common.JSONBig)(coin.GammaWei),
+		Tokens:   coin.Tokens,
 	}
 }
 
@@ -40,6 +47,7 @@ func (c CoinsJSON) Coins() Coins {
 	return Coins{
 		ThetaWei: (*big.Int)(c.ThetaWei),
 		GammaWei: (*big.Int)(c.GammaWei),
+		Tokens:   c.Tokens,
 	}
 }
 
@@ -56,6 +64,21 @@ func (c *Coins) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// DecodeRLP decodes the coins, leaving the tokens nil if there are none, as for the
+// coins encoded before the tokens were introduced.
+func (c *Coins) DecodeRLP(stream *rlp.Stream) error {
+	type coins Coins
+	var dec coins
+	if err := stream.Decode(&dec); err != nil {
+		return err
+	}
+	if len(dec.Tokens) == 0 {
+		dec.Tokens = nil
+	}
+	*c = Coins(dec)
+	return nil
+}
+
 // NewCoins is a convenient method for creating small amount of coins.
 func NewCoins(theta int64, gamma int64) Coins {
 	return Coins{
@@ -65,11 +88,28 @@ func NewCoins(theta int64, gamma int64) Coins {
 }
 
 func (coins Coins) String() string {
-	return fmt.Sprintf("%v %v, %v %v", coins.ThetaWei, DenomThetaWei, coins.GammaWei, DenomGammaWei)
+	s := fmt.Sprintf("%v %v, %v %v", coins.ThetaWei, DenomThetaWei, coins.GammaWei, DenomGammaWei)
+	for _, token := range coins.Tokens {
+		s += fmt.Sprintf(", %v %v", token.Amount, token.Symbol)
+	}
+	return s
 }
 
+// IsValid checks the coins are nonnegative, and that the tokens are in their canonical
+// form, i.e. sorted by symbol with positive amounts.
 func (coins Coins) IsValid() bool {
-	return coins.IsNonnegative()
+	if !coins.IsNonnegative() {
+		return false
+	}
+	for i, token := range coins.Tokens {
+		if ValidateTokenSymbol(token.Symbol) != nil || token.Amount == nil || token.Amount.Sign() <= 0 {
+			return false
+		}
+		if i > 0 && coins.Tokens[i-1].Symbol >= token.Symbol {
+			return false
+		}
+	}
+	return true
 }
 
 func (coins Coins) NoNil() Coins {
@@ -85,6 +125,7 @@ func (coins Coins) NoNil() Coins {
 	return Coins{
 		ThetaWei: theta,
 		GammaWei: gamma,
+		Tokens:   coins.Tokens,
 	}
 }
 
@@ -102,9 +143,16 @@ func (coins Coins) CalculatePercentage(percentage uint) Coins {
 	gamma.Mul(c.GammaWei, p)
 	gamma.Div(gamma, Hundred)
 
+	tokens := []TokenCoin{}
+	for _, token := range c.Tokens {
+		amount := new(big.Int).Mul(token.amount(), p)
+		tokens = append(tokens, TokenCoin{Symbol: token.Symbol, Amount: amount.Div(amount, Hundred)})
+	}
+
 	return Coins{
 		ThetaWei: theta,
 		GammaWei: gamma,
+		Tokens:   normalizeTokens(tokens),
 	}
 }
 
@@ -119,9 +167,15 @@ func (coinsA Coins) Plus(coinsB Coins) Coins {
 	gamma := new(big.Int)
 	gamma.Add(cA.GammaWei, cB.GammaWei)
 
+	tokens := cA.Tokens
+	if len(cB.Tokens) > 0 {
+		tokens = normalizeTokens(append(append([]TokenCoin{}, cA.Tokens...), cB.Tokens...))
+	}
+
 	return Coins{
 		ThetaWei: theta,
 		GammaWei: gamma,
+		Tokens:   tokens,
 	}
 }
 
@@ -134,9 +188,15 @@ func (coins Coins) Negative() Coins {
 	gamma := new(big.Int)
 	gamma.Neg(c.GammaWei)
 
+	var tokens []TokenCoin
+	for _, token := range c.Tokens {
+		tokens = append(tokens, TokenCoin{Symbol: token.Symbol, Amount: new(big.Int).Neg(token.amount())})
+	}
+
 	return Coins{
 		ThetaWei: theta,
 		GammaWei: gamma,
+		Tokens:   tokens,
 	}
 }
 
@@ -151,26 +211,37 @@ func (coinsA Coins) IsGTE(coinsB Coins) bool {
 
 func (coins Coins) IsZero() bool {
 	c := coins.NoNil()
-	return c.ThetaWei.Cmp(Zero) == 0 && c.GammaWei.Cmp(Zero) == 0
+	return c.ThetaWei.Cmp(Zero) == 0 && c.GammaWei.Cmp(Zero) == 0 && len(normalizeTokens(c.Tokens)) == 0
 }
 
 func (coinsA Coins) IsEqual(coinsB Coins) bool {
-	cA := coinsA.NoNil()
-	cB := coinsB.NoNil()
-	return cA.ThetaWei.Cmp(cB.ThetaWei) == 0 && cA.GammaWei.Cmp(cB.GammaWei) == 0
+	return coinsA.Minus(coinsB).IsZero()
 }
 
 func (coins Coins) IsPositive() bool {
-	c := coins.NoNil()
-	return (c.ThetaWei.Cmp(Zero) > 0 && c.GammaWei.Cmp(Zero) >= 0) ||
-		(c.ThetaWei.Cmp(Zero) >= 0 && c.GammaWei.Cmp(Zero) > 0)
+	return coins.IsNonnegative() && !coins.IsZero()
 }
 
 func (coins Coins) IsNonnegative() bool {
 	c := coins.NoNil()
+	for _, token := range c.Tokens {
+		if token.amount().Sign() < 0 {
+			return false
+		}
+	}
 	return c.ThetaWei.Cmp(Zero) >= 0 && c.GammaWei.Cmp(Zero) >= 0
 }
 
+// GetToken returns the amount of the given token, zero if the coins carry none
+func (coins Coins) GetToken(symbol string) *big.Int {
+	for _, token := range coins.Tokens {
+		if token.Symbol == symbol {
+			return new(big.Int).Set(token.amount())
+		}
+	}
+	return big.NewInt(0)
+}
+
 // ParseCoinAmount parses a string representation of coin amount.
 func ParseCoinAmount(in string) (*big.Int, bool) {
 	inWei := false
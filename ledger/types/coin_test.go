@@ -3,10 +3,14 @@ package types
 import (
 	"encoding/json"
 	"math/big"
+	"math/rand"
+	"reflect"
 	"testing"
+	"testing/quick"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/thetatoken/ukulele/rlp"
 )
 
 func TestCoins(t *testing.T) {
@@ -137,3 +141,87 @@ func TestJSON(t *testing.T) {
 	assert.Equal(0, num.Cmp(d.ThetaWei))
 	assert.Nil(d.GammaWei)
 }
+
+// randCoins are valid coins, generated for the property tests.
+type randCoins struct {
+	Coins
+}
+
+// Generate implements testing/quick.Generator.
+func (randCoins) Generate(rand *rand.Rand, size int) reflect.Value {
+	coins := Coins{ThetaWei: randAmount(rand), GammaWei: randAmount(rand)}
+	for _, symbol := range []string{"AAA", "BBB", "CCC"} {
+		if rand.Intn(3) == 0 {
+			amount := randAmount(rand)
+			coins.Tokens = append(coins.Tokens, TokenCoin{Symbol: symbol, Amount: amount.Add(amount, big.NewInt(1))})
+		}
+	}
+	return reflect.ValueOf(randCoins{coins})
+}
+
+// randAmount returns a non-negative amount, from zero to 256 bits.
+func randAmount(rand *rand.Rand) *big.Int {
+	switch rand.Intn(4) {
+	case 0:
+		return big.NewInt(0)
+	case 1:
+		return big.NewInt(rand.Int63n(1000))
+	default:
+		b := make([]byte, 1+rand.Intn(32))
+		rand.Read(b)
+		return new(big.Int).SetBytes(b)
+	}
+}
+
+func TestCoinsProperties(t *testing.T) {
+	properties := map[string]interface{}{
+		"Minus reverts Plus": func(a, b randCoins) bool {
+			return a.Plus(b.Coins).Minus(b.Coins).IsEqual(a.Coins)
+		},
+		"Plus is commutative": func(a, b randCoins) bool {
+			return a.Plus(b.Coins).IsEqual(b.Plus(a.Coins))
+		},
+		"Plus is associative": func(a, b, c randCoins) bool {
+			return a.Plus(b.Coins).Plus(c.Coins).IsEqual(a.Plus(b.Plus(c.Coins)))
+		},
+		"Sums are valid and not less than their terms": func(a, b randCoins) bool {
+			sum := a.Plus(b.Coins)
+			return sum.IsValid() && sum.IsGTE(a.Coins) && sum.IsGTE(b.Coins)
+		},
+		"Coins minus themselves are zero": func(a randCoins) bool {
+			return a.Minus(a.Coins).IsZero()
+		},
+		"Differences are valid only if not negative": func(a, b randCoins) bool {
+			return a.Minus(b.Coins).IsValid() == a.IsGTE(b.Coins)
+		},
+		"Percentages are valid and not more than the coins": func(a randCoins, percentage uint) bool {
+			part := a.CalculatePercentage(percentage % 101)
+			return part.IsValid() && a.IsGTE(part)
+		},
+		"RLP encoding round-trips": func(a randCoins) bool {
+			raw, err := rlp.EncodeToBytes(a.Coins)
+			if err != nil {
+				return false
+			}
+			var decoded Coins
+			if err := rlp.DecodeBytes(raw, &decoded); err != nil {
+				return false
+			}
+			reencoded, err := rlp.EncodeToBytes(decoded)
+			return err == nil && decoded.IsEqual(a.Coins) && reflect.DeepEqual(raw, reencoded)
+		},
+		"JSON encoding round-trips": func(a randCoins) bool {
+			raw, err := json.Marshal(a.Coins)
+			if err != nil {
+				return false
+			}
+			var decoded Coins
+			return json.Unmarshal(raw, &decoded) == nil && decoded.IsEqual(a.Coins)
+		},
+	}
+	for name, property := range properties {
+		if err := quick.Check(property, nil); err != nil {
+			t.Errorf("%v: %v", name, err)
+		}
+	}
+}
@@ -68,3 +68,94 @@ const (
 	// ReservedFundFreezePeriodDuration indicates the freeze duration (in terms of number of blocks) of the reserved fund
 	ReservedFundFreezePeriodDuration uint64 = 5
 )
+
+const (
+
+	// MinimumStakeDepositThetaWei specifies the minimum amount of ThetaWei for a stake deposit
+	MinimumStakeDepositThetaWei uint64 = 1e18
+
+	// ReturnLockingPeriod indicates the number of blocks a withdrawn stake stays locked before it is returned to the source
+	ReturnLockingPeriod uint64 = 28800
+)
+
+const (
+
+	// MaxMultisigPublicKeys specifies the maximum number of public keys of a multisig account
+	MaxMultisigPublicKeys = 16
+)
+
+const (
+
+	// MaxGuardians specifies the maximum number of guardians of an account
+	MaxGuardians = 16
+
+	// MinimumRecoveryTimelock indicates the minimum number of blocks between a recovery request and the key rotation
+	MinimumRecoveryTimelock uint64 = 28800
+
+	// MaximumRecoveryTimelock indicates the maximum number of blocks between a recovery request and the key rotation
+	MaximumRecoveryTimelock uint64 = 10 * 28800
+)
+
+const (
+
+	// MaximumCoinLockDuration indicates the maximum number of blocks coins sent by a TimelockedSendTx can stay locked
+	MaximumCoinLockDuration uint64 = 5 * 365 * 28800
+)
+
+const (
+
+	// MinTokenSymbolLength specifies the minimum length of the symbol of an application token
+	MinTokenSymbolLength = 2
+
+	// MaxTokenSymbolLength specifies the maximum length of the symbol of an application token
+	MaxTokenSymbolLength = 10
+
+	// MaxTokenDecimals specifies the maximum number of decimals of an application token
+	MaxTokenDecimals uint64 = 18
+
+	// MaxTokenSupplyBits specifies the maximum bit length of the supply of an application token
+	MaxTokenSupplyBits = 128
+)
+
+const (
+
+	// MinimumMaxBlockGas specifies the lowest block gas limit a ParameterUpdateTx can set
+	MinimumMaxBlockGas uint64 = 1000000
+)
+
+const (
+
+	// MinimumProposalVotingPeriod indicates the minimum number of blocks a proposal accepts votes
+	MinimumProposalVotingPeriod uint64 = 1000
+
+	// MaximumProposalVotingPeriod indicates the maximum number of blocks a proposal accepts votes. It is
+	// below ReturnLockingPeriod, so that a withdrawn stake cannot vote again from another address.
+	MaximumProposalVotingPeriod uint64 = 20000
+
+	// ProposalQuorumPercent specifies the percentage of the total stake that needs to vote on a proposal
+	ProposalQuorumPercent int64 = 40
+
+	// ProposalThresholdPercent specifies the percentage of the voting stake a proposal needs to exceed to pass
+	ProposalThresholdPercent int64 = 50
+
+	// ProposalExecutionDelay indicates the number of blocks between the tally of a parameter change and its update
+	ProposalExecutionDelay uint64 = 100
+
+	// MaximumProposalJailDuration indicates the maximum number of epochs a proposal can jail a validator
+	MaximumProposalJailDuration uint64 = 100000
+
+	// MaxProposalDescriptionLength specifies the maximum length of the description of a proposal
+	MaxProposalDescriptionLength = 1024
+)
+
+const (
+
+	// DowntimeWindowEpochs indicates the number of the last epochs the missed proposals are counted over
+	DowntimeWindowEpochs uint64 = 1000
+
+	// MaxMissedProposals specifies the number of proposals a validator can miss within the window before being jailed
+	MaxMissedProposals = 10
+
+	// UnjailCooldownEpochs indicates the number of epochs a validator jailed for downtime waits before it can unjail
+	UnjailCooldownEpochs uint64 = 1000
+)
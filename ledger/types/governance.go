@@ -0,0 +1,273 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/pkg/errors"
+	"github.com/thetatoken/ukulele/common"
+)
+
+// ** Governance: Proposals submitted by a ProposalTx and voted on by the stakers **
+//
+
+// ProposalKind is the kind of change a proposal makes once passed
+type ProposalKind uint8
+
+const (
+	// ProposalParameterChange schedules an update of the chain parameters
+	ProposalParameterChange ProposalKind = iota + 1
+
+	// ProposalJailValidator excludes a validator from the proposer rotation for a number of epochs
+	ProposalJailValidator
+)
+
+func (kind ProposalKind) String() string {
+	switch kind {
+	case ProposalParameterChange:
+		return "parameter_change"
+	case ProposalJailValidator:
+		return "jail_validator"
+	}
+	return fmt.Sprintf("ProposalKind(%d)", uint8(kind))
+}
+
+// ProposalStatus is the stage of a proposal
+type ProposalStatus uint8
+
+const (
+	// ProposalActive is the status of a proposal during its voting window
+	ProposalActive ProposalStatus = iota
+
+	// ProposalPassed is the status of a proposal approved by the votes, whose change has been executed
+	ProposalPassed
+
+	// ProposalRejected is the status of a proposal which did not reach the quorum or the approval threshold
+	ProposalRejected
+)
+
+func (status ProposalStatus) String() string {
+	switch status {
+	case ProposalActive:
+		return "active"
+	case ProposalPassed:
+		return "passed"
+	case ProposalRejected:
+		return "rejected"
+	}
+	return fmt.Sprintf("ProposalStatus(%d)", uint8(status))
+}
+
+// ProposalChange is the change a proposal makes once passed. Only the fields of its
+// kind are set.
+type ProposalChange struct {
+	Kind         ProposalKind
+	Parameters   ChainParameters // Parameters of a parameter change
+	Validator    common.Address  // Validator to jail
+	JailDuration uint64          // Number of epochs the validator is jailed
+}
+
+type ProposalChangeJSON struct {
+	Kind         string            `json:"kind"`
+	Parameters   *ChainParameters  `json:"parameters,omitempty"`
+	Validator    *common.Address   `json:"validator,omitempty"`
+	JailDuration common.JSONUint64 `json:"jail_duration,omitempty"`
+}
+
+func (c ProposalChange) MarshalJSON() ([]byte, error) {
+	b := ProposalChangeJSON{
+		Kind: c.Kind.String(),
+	}
+	switch c.Kind {
+	case ProposalParameterChange:
+		b.Parameters = &c.Parameters
+	case ProposalJailValidator:
+		b.Validator = &c.Validator
+		b.JailDuration = common.JSONUint64(c.JailDuration)
+	}
+	return json.Marshal(b)
+}
+
+func (c *ProposalChange) UnmarshalJSON(data []byte) error {
+	var b ProposalChangeJSON
+	if err := json.Unmarshal(data, &b); err != nil {
+		return err
+	}
+	switch b.Kind {
+	case ProposalParameterChange.String():
+		c.Kind = ProposalParameterChange
+	case ProposalJailValidator.String():
+		c.Kind = ProposalJailValidator
+	default:
+		return errors.Errorf("Unknown proposal kind %v", b.Kind)
+	}
+	if b.Parameters != nil {
+		c.Parameters = *b.Parameters
+	}
+	if b.Validator != nil {
+		c.Validator = *b.Validator
+	}
+	c.JailDuration = uint64(b.JailDuration)
+	return nil
+}
+
+func (c ProposalChange) String() string {
+	switch c.Kind {
+	case ProposalParameterChange:
+		return fmt.Sprintf("ProposalChange{%v, parameters: %v}", c.Kind, c.Parameters)
+	case ProposalJailValidator:
+		return fmt.Sprintf("ProposalChange{%v, validator: %v, jail_duration: %v}", c.Kind, c.Validator.Hex(), c.JailDuration)
+	}
+	return fmt.Sprintf("ProposalChange{%v}", c.Kind)
+}
+
+// Validate checks the change is of a known kind and keeps the chain operable
+func (c ProposalChange) Validate() error {
+	switch c.Kind {
+	case ProposalParameterChange:
+		return c.Parameters.Validate()
+	case ProposalJailValidator:
+		if c.Validator == (common.Address{}) {
+			return errors.New("The validator to jail needs to be set")
+		}
+		if c.JailDuration == 0 || c.JailDuration > MaximumProposalJailDuration {
+			return errors.Errorf("The jail duration needs to be 1 to %v epochs", MaximumProposalJailDuration)
+		}
+		return nil
+	}
+	return errors.Errorf("Unknown proposal kind %v", c.Kind)
+}
+
+// ProposalTally is the stake-weighted count of the votes of a proposal, in ThetaWei
+type ProposalTally struct {
+	Yes        *big.Int // Stake of the voters approving the proposal
+	No         *big.Int // Stake of the voters rejecting the proposal
+	TotalStake *big.Int // Stake of all the stakers when tallied
+}
+
+type ProposalTallyJSON struct {
+	Yes        *common.JSONBig `json:"yes"`
+	No         *common.JSONBig `json:"no"`
+	TotalStake *common.JSONBig `json:"total_stake"`
+}
+
+func NewProposalTallyJSON(t ProposalTally) ProposalTallyJSON {
+	return ProposalTallyJSON{
+		Yes:        (*common.JSONBig)(t.Yes),
+		No:         (*common.JSONBig)(t.No),
+		TotalStake: (*common.JSONBig)(t.TotalStake),
+	}
+}
+
+func (t ProposalTallyJSON) ProposalTally() ProposalTally {
+	return ProposalTally{
+		Yes:        (*big.Int)(t.Yes),
+		No:         (*big.Int)(t.No),
+		TotalStake: (*big.Int)(t.TotalStake),
+	}
+}
+
+func (t ProposalTally) MarshalJSON() ([]byte, error) {
+	return json.Marshal(NewProposalTallyJSON(t))
+}
+
+func (t *ProposalTally) UnmarshalJSON(data []byte) error {
+	var b ProposalTallyJSON
+	if err := json.Unmarshal(data, &b); err != nil {
+		return err
+	}
+	*t = b.ProposalTally()
+	return nil
+}
+
+// NewProposalTally creates an empty tally
+func NewProposalTally() ProposalTally {
+	return ProposalTally{
+		Yes:        big.NewInt(0),
+		No:         big.NewInt(0),
+		TotalStake: big.NewInt(0),
+	}
+}
+
+// Passes returns whether the votes pass the proposal: the voters hold at least
+// ProposalQuorumPercent of the total stake, and more than ProposalThresholdPercent
+// of their stake approves it.
+func (t ProposalTally) Passes() bool {
+	voted := new(big.Int).Add(t.Yes, t.No)
+	if voted.Sign() == 0 {
+		return false
+	}
+	quorum := new(big.Int).Mul(t.TotalStake, big.NewInt(ProposalQuorumPercent))
+	if new(big.Int).Mul(voted, big.NewInt(100)).Cmp(quorum) < 0 {
+		return false
+	}
+	threshold := new(big.Int).Mul(voted, big.NewInt(ProposalThresholdPercent))
+	return new(big.Int).Mul(t.Yes, big.NewInt(100)).Cmp(threshold) > 0
+}
+
+func (t ProposalTally) String() string {
+	return fmt.Sprintf("ProposalTally{yes: %v, no: %v, total_stake: %v}", t.Yes, t.No, t.TotalStake)
+}
+
+// Proposal is a change submitted to the vote of the stakers. The votes are open from
+// the block following the proposal up to EndHeight, and the proposal is tallied and,
+// if passed, executed by the coinbase transaction of the next block.
+type Proposal struct {
+	ID          uint64
+	Proposer    common.Address
+	Change      ProposalChange
+	Description string
+	StartHeight uint64 // Height of the block including the proposal
+	EndHeight   uint64 // Last height at which the votes are accepted
+	Status      ProposalStatus
+	Tally       ProposalTally // Final tally, once the proposal is not active anymore
+}
+
+type ProposalJSON struct {
+	ID          common.JSONUint64 `json:"id"`
+	Proposer    common.Address    `json:"proposer"`
+	Change      ProposalChange    `json:"change"`
+	Description string            `json:"description"`
+	StartHeight common.JSONUint64 `json:"start_height"`
+	EndHeight   common.JSONUint64 `json:"end_height"`
+	Status      string            `json:"status"`
+	Tally       ProposalTally     `json:"tally"`
+}
+
+func NewProposalJSON(p Proposal) ProposalJSON {
+	return ProposalJSON{
+		ID:          common.JSONUint64(p.ID),
+		Proposer:    p.Proposer,
+		Change:      p.Change,
+		Description: p.Description,
+		StartHeight: common.JSONUint64(p.StartHeight),
+		EndHeight:   common.JSONUint64(p.EndHeight),
+		Status:      p.Status.String(),
+		Tally:       p.Tally,
+	}
+}
+
+func (p Proposal) MarshalJSON() ([]byte, error) {
+	return json.Marshal(NewProposalJSON(p))
+}
+
+func (p *Proposal) String() string {
+	if p == nil {
+		return "nil-Proposal"
+	}
+	return fmt.Sprintf("Proposal{%v, proposer: %v, change: %v, start_height: %v, end_height: %v, status: %v, tally: %v}",
+		p.ID, p.Proposer.Hex(), p.Change, p.StartHeight, p.EndHeight, p.Status, p.Tally)
+}
+
+// IsActive returns whether the proposal accepts votes at the given height
+func (p *Proposal) IsActive(height uint64) bool {
+	return p.Status == ProposalActive && height > p.StartHeight && height <= p.EndHeight
+}
+
+// ProposalVote is the vote of a staker on a proposal. A staker can change its vote
+// until the end of the voting window.
+type ProposalVote struct {
+	Voter   common.Address
+	Approve bool
+}
@@ -0,0 +1,97 @@
+package types
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thetatoken/ukulele/common"
+)
+
+func TestProposalChangeValidate(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.NotNil(ProposalChange{}.Validate())
+
+	change := ProposalChange{Kind: ProposalParameterChange, Parameters: DefaultChainParameters()}
+	assert.Nil(change.Validate())
+	change.Parameters.MinimumTransactionFeeGammaWei = 0
+	assert.NotNil(change.Validate())
+
+	change = ProposalChange{Kind: ProposalJailValidator, JailDuration: 10}
+	assert.NotNil(change.Validate())
+	change.Validator = common.HexToAddress("0x2E833968E5bB786Ae419c4d13189fB081Cc43bab")
+	assert.Nil(change.Validate())
+	change.JailDuration = MaximumProposalJailDuration + 1
+	assert.NotNil(change.Validate())
+}
+
+func TestProposalChangeJSON(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	change := ProposalChange{
+		Kind:         ProposalJailValidator,
+		Validator:    common.HexToAddress("0x2E833968E5bB786Ae419c4d13189fB081Cc43bab"),
+		JailDuration: 10,
+	}
+	b, err := json.Marshal(change)
+	require.Nil(err)
+	assert.Contains(string(b), `"kind":"jail_validator"`)
+	assert.NotContains(string(b), `"parameters"`)
+
+	var change2 ProposalChange
+	require.Nil(json.Unmarshal(b, &change2))
+	assert.Equal(change, change2)
+
+	assert.NotNil(json.Unmarshal([]byte(`{"kind":"unknown"}`), &change2))
+}
+
+func TestProposalTallyPasses(t *testing.T) {
+	assert := assert.New(t)
+
+	tally := func(yes, no, total int64) ProposalTally {
+		return ProposalTally{Yes: big.NewInt(yes), No: big.NewInt(no), TotalStake: big.NewInt(total)}
+	}
+	assert.False(tally(0, 0, 1000).Passes())
+	assert.False(tally(390, 0, 1000).Passes())   // below the quorum
+	assert.True(tally(400, 0, 1000).Passes())    // quorum reached
+	assert.False(tally(300, 300, 1000).Passes()) // no majority
+	assert.True(tally(301, 300, 1000).Passes())
+}
+
+func TestProposalSerialization(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	proposal := &Proposal{
+		ID:       1,
+		Proposer: common.HexToAddress("0x2E833968E5bB786Ae419c4d13189fB081Cc43bab"),
+		Change: ProposalChange{
+			Kind:       ProposalParameterChange,
+			Parameters: DefaultChainParameters(),
+		},
+		Description: "test proposal",
+		StartHeight: 100,
+		EndHeight:   1100,
+		Status:      ProposalActive,
+		Tally:       NewProposalTally(),
+	}
+	assert.False(proposal.IsActive(100))
+	assert.True(proposal.IsActive(101))
+	assert.True(proposal.IsActive(1100))
+	assert.False(proposal.IsActive(1101))
+
+	b, err := ToBytes(proposal)
+	require.Nil(err)
+	proposal2 := &Proposal{}
+	require.Nil(FromBytes(b, proposal2))
+	assert.Equal(proposal.Change, proposal2.Change)
+	assert.Equal(proposal.EndHeight, proposal2.EndHeight)
+	assert.Equal(0, proposal2.Tally.TotalStake.Sign())
+
+	b, err = json.Marshal(proposal)
+	require.Nil(err)
+	assert.Contains(string(b), `"status":"active"`)
+	assert.Contains(string(b), `"end_height":"1100"`)
+}
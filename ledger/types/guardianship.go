@@ -0,0 +1,176 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/thetatoken/ukulele/common"
+)
+
+// ** Guardianship: Social recovery of an account whose key is lost **
+//
+
+// Guardianship lets the guardians of an account recover it. A RecoveryTx signed by at
+// least Threshold of the guardians requests to rotate the key controlling the account
+// to the key of NewSigner, which takes effect Timelock blocks later. Until then, the
+// account can cancel the recovery with a SetGuardiansTx signed by its current key.
+type Guardianship struct {
+	Guardians []common.Address // Addresses of the guardians
+	Threshold uint64           // Number of guardians needed to recover the account
+	Timelock  uint64           // Number of blocks between a recovery request and the key rotation
+
+	Signer         common.Address // Address of the key controlling the account, empty for the key of the account address
+	NewSigner      common.Address // Signer requested by the pending recovery
+	RecoveryHeight uint64         // Block height at which the pending recovery takes effect, 0 if no recovery is pending
+}
+
+type GuardianshipJSON struct {
+	Guardians      []common.Address  `json:"guardians"`
+	Threshold      common.JSONUint64 `json:"threshold"`
+	Timelock       common.JSONUint64 `json:"timelock"`
+	Signer         common.Address    `json:"signer"`
+	NewSigner      common.Address    `json:"new_signer"`
+	RecoveryHeight common.JSONUint64 `json:"recovery_height"`
+}
+
+func NewGuardianshipJSON(g Guardianship) GuardianshipJSON {
+	return GuardianshipJSON{
+		Guardians:      g.Guardians,
+		Threshold:      common.JSONUint64(g.Threshold),
+		Timelock:       common.JSONUint64(g.Timelock),
+		Signer:         g.Signer,
+		NewSigner:      g.NewSigner,
+		RecoveryHeight: common.JSONUint64(g.RecoveryHeight),
+	}
+}
+
+func (g GuardianshipJSON) Guardianship() Guardianship {
+	return Guardianship{
+		Guardians:      g.Guardians,
+		Threshold:      uint64(g.Threshold),
+		Timelock:       uint64(g.Timelock),
+		Signer:         g.Signer,
+		NewSigner:      g.NewSigner,
+		RecoveryHeight: uint64(g.RecoveryHeight),
+	}
+}
+
+func (g Guardianship) MarshalJSON() ([]byte, error) {
+	return json.Marshal(NewGuardianshipJSON(g))
+}
+
+func (g *Guardianship) UnmarshalJSON(data []byte) error {
+	var b GuardianshipJSON
+	if err := json.Unmarshal(data, &b); err != nil {
+		return err
+	}
+	*g = b.Guardianship()
+	return nil
+}
+
+func (g *Guardianship) String() string {
+	if g == nil {
+		return "nil-Guardianship"
+	}
+	return fmt.Sprintf("Guardianship{%v-of-%v, timelock: %v, signer: %v, new_signer: %v, recovery_height: %v}",
+		g.Threshold, len(g.Guardians), g.Timelock, g.Signer.Hex(), g.NewSigner.Hex(), g.RecoveryHeight)
+}
+
+// ValidateGuardians checks the guardians registered for the account. No guardians, with
+// a zero threshold and timelock, removes the guardianship.
+func ValidateGuardians(account common.Address, guardians []common.Address, threshold uint64, timelock uint64) error {
+	if len(guardians) == 0 {
+		if threshold != 0 || timelock != 0 {
+			return errors.New("Threshold and timelock need to be zero without guardians")
+		}
+		return nil
+	}
+	if len(guardians) > MaxGuardians {
+		return errors.Errorf("An account can have at most %v guardians, got %v", MaxGuardians, len(guardians))
+	}
+	if threshold == 0 || threshold > uint64(len(guardians)) {
+		return errors.Errorf("Invalid threshold %v for %v guardians", threshold, len(guardians))
+	}
+	if timelock < MinimumRecoveryTimelock || timelock > MaximumRecoveryTimelock {
+		return errors.Errorf("Timelock needs to be between %v and %v blocks", MinimumRecoveryTimelock, MaximumRecoveryTimelock)
+	}
+	seen := make(map[common.Address]bool)
+	for _, guardian := range guardians {
+		if guardian == (common.Address{}) {
+			return errors.New("Empty guardian address")
+		}
+		if guardian == account {
+			return errors.New("An account cannot be its own guardian")
+		}
+		if seen[guardian] {
+			return errors.Errorf("Duplicated guardian %v", guardian.Hex())
+		}
+		seen[guardian] = true
+	}
+	return nil
+}
+
+// IsGuardian returns whether the address is a guardian of the account
+func (g *Guardianship) IsGuardian(address common.Address) bool {
+	for _, guardian := range g.Guardians {
+		if guardian == address {
+			return true
+		}
+	}
+	return false
+}
+
+// HasPendingRecovery returns whether a recovery is waiting for its timelock
+func (g *Guardianship) HasPendingRecovery() bool {
+	return g.RecoveryHeight != 0
+}
+
+// CancelRecovery cancels the pending recovery, if any
+func (g *Guardianship) CancelRecovery() {
+	g.NewSigner = common.Address{}
+	g.RecoveryHeight = 0
+}
+
+// GetGuardianship returns the guardianship of the account, or nil if the account has
+// never registered guardians
+func (acc *Account) GetGuardianship() *Guardianship {
+	ext := acc.extension()
+	if len(ext.Guardianship) == 0 {
+		return nil
+	}
+	g := ext.Guardianship[0]
+	return &g
+}
+
+// SetGuardianship replaces the guardianship of the account, nil removes it
+func (acc *Account) SetGuardianship(g *Guardianship) {
+	ext := acc.extension()
+	if g == nil {
+		ext.Guardianship = nil
+	} else {
+		ext.Guardianship = []Guardianship{*g}
+	}
+	acc.setExtension(ext)
+}
+
+// SignerAddress returns the address of the key controlling the account, which is the
+// account address unless the account has been recovered to another key
+func (acc *Account) SignerAddress() common.Address {
+	if g := acc.GetGuardianship(); g != nil && g.Signer != (common.Address{}) {
+		return g.Signer
+	}
+	return acc.Address
+}
+
+// CompleteRecovery rotates the key controlling the account if the timelock of the
+// pending recovery has passed
+func (acc *Account) CompleteRecovery(currentBlockHeight uint64) {
+	g := acc.GetGuardianship()
+	if g == nil || !g.HasPendingRecovery() || g.RecoveryHeight > currentBlockHeight {
+		return
+	}
+	g.Signer = g.NewSigner
+	g.CancelRecovery()
+	acc.SetGuardianship(g)
+}
@@ -0,0 +1,120 @@
+package types
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/rlp"
+)
+
+func TestValidateGuardians(t *testing.T) {
+	assert := assert.New(t)
+
+	account := common.HexToAddress("0x01")
+	g1 := common.HexToAddress("0x02")
+	g2 := common.HexToAddress("0x03")
+	timelock := MinimumRecoveryTimelock
+
+	assert.Nil(ValidateGuardians(account, nil, 0, 0))
+	assert.Nil(ValidateGuardians(account, []common.Address{g1, g2}, 2, timelock))
+	assert.NotNil(ValidateGuardians(account, nil, 1, timelock))
+	assert.NotNil(ValidateGuardians(account, []common.Address{g1, g2}, 0, timelock))
+	assert.NotNil(ValidateGuardians(account, []common.Address{g1, g2}, 3, timelock))
+	assert.NotNil(ValidateGuardians(account, []common.Address{g1, g2}, 1, MinimumRecoveryTimelock-1))
+	assert.NotNil(ValidateGuardians(account, []common.Address{g1, g2}, 1, MaximumRecoveryTimelock+1))
+	assert.NotNil(ValidateGuardians(account, []common.Address{g1, g1}, 1, timelock))
+	assert.NotNil(ValidateGuardians(account, []common.Address{g1, account}, 1, timelock))
+	assert.NotNil(ValidateGuardians(account, []common.Address{g1, {}}, 1, timelock))
+
+	tooMany := []common.Address{}
+	for i := 0; i <= MaxGuardians; i++ {
+		tooMany = append(tooMany, common.BigToAddress(big.NewInt(int64(i+10))))
+	}
+	assert.NotNil(ValidateGuardians(account, tooMany, 1, timelock))
+}
+
+func TestAccountRecovery(t *testing.T) {
+	assert := assert.New(t)
+
+	address := common.HexToAddress("0x01")
+	newSigner := common.HexToAddress("0x04")
+	acc := NewAccount(address)
+	assert.Nil(acc.GetGuardianship())
+	assert.Equal(address, acc.SignerAddress())
+
+	acc.SetGuardianship(&Guardianship{
+		Guardians:      []common.Address{common.HexToAddress("0x02")},
+		Threshold:      1,
+		Timelock:       MinimumRecoveryTimelock,
+		NewSigner:      newSigner,
+		RecoveryHeight: 1000,
+	})
+	assert.True(acc.GetGuardianship().HasPendingRecovery())
+
+	// The key rotation takes effect once the timelock has passed
+	acc.UpdateToHeight(999)
+	assert.Equal(address, acc.SignerAddress())
+	acc.UpdateToHeight(1000)
+	assert.Equal(newSigner, acc.SignerAddress())
+	assert.False(acc.GetGuardianship().HasPendingRecovery())
+	assert.True(acc.GetGuardianship().IsGuardian(common.HexToAddress("0x02")))
+
+	// The account copies do not share the guardianship
+	accCopy := acc.Copy()
+	g := accCopy.GetGuardianship()
+	g.Signer = common.Address{}
+	accCopy.SetGuardianship(g)
+	assert.Equal(newSigner, acc.SignerAddress())
+	assert.Equal(address, accCopy.SignerAddress())
+}
+
+func TestAccountGuardianshipEncoding(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// An account without guardians encodes as before the guardianship was introduced
+	type accountWithoutGuardianship struct {
+		Address                common.Address
+		Sequence               uint64
+		Balance                Coins
+		ReservedFunds          []ReservedFund
+		LastUpdatedBlockHeight uint64
+		Root                   common.Hash
+		CodeHash               common.Hash
+	}
+	acc := NewAccount(common.HexToAddress("0x01"))
+	acc.Sequence = 7
+	raw, err := rlp.EncodeToBytes(acc)
+	require.Nil(err)
+	legacyRaw, err := rlp.EncodeToBytes(accountWithoutGuardianship{
+		Address:  acc.Address,
+		Sequence: acc.Sequence,
+		Balance:  acc.Balance,
+		CodeHash: acc.CodeHash,
+	})
+	require.Nil(err)
+	assert.Equal(legacyRaw, raw)
+
+	acc.SetGuardianship(&Guardianship{
+		Guardians: []common.Address{common.HexToAddress("0x02"), common.HexToAddress("0x03")},
+		Threshold: 2,
+		Timelock:  MinimumRecoveryTimelock,
+		Signer:    common.HexToAddress("0x04"),
+	})
+	raw, err = rlp.EncodeToBytes(acc)
+	require.Nil(err)
+	var decoded Account
+	require.Nil(rlp.DecodeBytes(raw, &decoded))
+	assert.Equal(acc.GetGuardianship(), decoded.GetGuardianship())
+
+	s, err := json.Marshal(acc)
+	require.Nil(err)
+	var d Account
+	require.Nil(json.Unmarshal(s, &d))
+	assert.Equal(acc.GetGuardianship(), d.GetGuardianship())
+	assert.Equal(common.HexToAddress("0x04"), d.SignerAddress())
+}
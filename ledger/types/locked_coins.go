@@ -0,0 +1,111 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/thetatoken/ukulele/common"
+)
+
+// LockedCoins are coins received by an account through a TimelockedSendTx. They are
+// not part of the balance of the account, and cannot be spent until they are unlocked
+// at the unlock height, e.g. for vesting-style payouts.
+type LockedCoins struct {
+	Coins        Coins
+	UnlockHeight uint64 // Block height at which the coins are added to the balance
+}
+
+type LockedCoinsJSON struct {
+	Coins        Coins             `json:"coins"`
+	UnlockHeight common.JSONUint64 `json:"unlock_height"`
+}
+
+func NewLockedCoinsJSON(l LockedCoins) LockedCoinsJSON {
+	return LockedCoinsJSON{
+		Coins:        l.Coins,
+		UnlockHeight: common.JSONUint64(l.UnlockHeight),
+	}
+}
+
+func (l LockedCoinsJSON) LockedCoins() LockedCoins {
+	return LockedCoins{
+		Coins:        l.Coins,
+		UnlockHeight: uint64(l.UnlockHeight),
+	}
+}
+
+func (l LockedCoins) MarshalJSON() ([]byte, error) {
+	return json.Marshal(NewLockedCoinsJSON(l))
+}
+
+func (l *LockedCoins) UnmarshalJSON(data []byte) error {
+	var b LockedCoinsJSON
+	if err := json.Unmarshal(data, &b); err != nil {
+		return err
+	}
+	*l = b.LockedCoins()
+	return nil
+}
+
+func (l LockedCoins) String() string {
+	return fmt.Sprintf("LockedCoins{%v until %v}", l.Coins, l.UnlockHeight)
+}
+
+// GetLockedCoins returns the coins locked in the account, by increasing unlock height
+func (acc *Account) GetLockedCoins() []LockedCoins {
+	locked := acc.extension().LockedCoins
+	if len(locked) == 0 {
+		return nil
+	}
+	return append([]LockedCoins{}, locked...)
+}
+
+func (acc *Account) setLockedCoins(locked []LockedCoins) {
+	ext := acc.extension()
+	ext.LockedCoins = locked
+	acc.setExtension(ext)
+}
+
+// GetTotalLockedCoins returns the sum of the coins locked in the account
+func (acc *Account) GetTotalLockedCoins() Coins {
+	total := NewCoins(0, 0)
+	for _, l := range acc.extension().LockedCoins {
+		total = total.Plus(l.Coins)
+	}
+	return total
+}
+
+// LockCoins adds coins to the account which are unlocked at the given height. Coins
+// unlocked at the same height are merged.
+func (acc *Account) LockCoins(coins Coins, unlockHeight uint64) {
+	locked := []LockedCoins{}
+	added := false
+	for _, l := range acc.extension().LockedCoins {
+		if !added && l.UnlockHeight == unlockHeight {
+			l.Coins = l.Coins.Plus(coins)
+			added = true
+		} else if !added && l.UnlockHeight > unlockHeight {
+			locked = append(locked, LockedCoins{Coins: coins, UnlockHeight: unlockHeight})
+			added = true
+		}
+		locked = append(locked, l)
+	}
+	if !added {
+		locked = append(locked, LockedCoins{Coins: coins, UnlockHeight: unlockHeight})
+	}
+	acc.setLockedCoins(locked)
+}
+
+// UnlockCoins adds the coins whose unlock height has been reached to the balance
+func (acc *Account) UnlockCoins(currentBlockHeight uint64) {
+	locked := acc.extension().LockedCoins
+	unlocked := 0
+	for unlocked < len(locked) && locked[unlocked].UnlockHeight <= currentBlockHeight {
+		acc.Balance = acc.Balance.Plus(locked[unlocked].Coins)
+		unlocked++
+	}
+	if unlocked == 0 {
+		return
+	}
+	acc.setLockedCoins(append([]LockedCoins{}, locked[unlocked:]...))
+}
@@ -0,0 +1,82 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/rlp"
+)
+
+func TestAccountLockCoins(t *testing.T) {
+	assert := assert.New(t)
+
+	acc := NewAccount(common.HexToAddress("0x01"))
+	acc.Balance = NewCoins(10, 100)
+	assert.Nil(acc.GetLockedCoins())
+
+	acc.LockCoins(NewCoins(1, 0), 300)
+	acc.LockCoins(NewCoins(0, 20), 100)
+	acc.LockCoins(NewCoins(2, 0), 200)
+	acc.LockCoins(NewCoins(0, 30), 100)
+
+	// Sorted by unlock height, merged by height, and not part of the balance
+	locked := acc.GetLockedCoins()
+	assert.Equal(3, len(locked))
+	assert.Equal(uint64(100), locked[0].UnlockHeight)
+	assert.True(NewCoins(0, 50).IsEqual(locked[0].Coins))
+	assert.Equal(uint64(200), locked[1].UnlockHeight)
+	assert.Equal(uint64(300), locked[2].UnlockHeight)
+	assert.True(NewCoins(3, 50).IsEqual(acc.GetTotalLockedCoins()))
+	assert.True(NewCoins(10, 100).IsEqual(acc.Balance))
+
+	// The copies of the account are not affected
+	accCopy := acc.Copy()
+	acc.UpdateToHeight(99)
+	assert.Equal(3, len(acc.GetLockedCoins()))
+	acc.UpdateToHeight(200)
+	assert.Equal(1, len(acc.GetLockedCoins()))
+	assert.True(NewCoins(12, 150).IsEqual(acc.Balance))
+	assert.Equal(3, len(accCopy.GetLockedCoins()))
+	assert.True(NewCoins(10, 100).IsEqual(accCopy.Balance))
+
+	// Once all the coins are unlocked, the account encodes as before they were locked
+	acc.UnlockCoins(300)
+	assert.Nil(acc.GetLockedCoins())
+	assert.True(NewCoins(13, 150).IsEqual(acc.Balance))
+	assert.Nil(acc.Extension)
+}
+
+func TestAccountLockedCoinsEncoding(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	acc := NewAccount(common.HexToAddress("0x01"))
+	acc.LockCoins(NewCoins(1, 2), 1000)
+	acc.SetGuardianship(&Guardianship{
+		Guardians: []common.Address{common.HexToAddress("0x02")},
+		Threshold: 1,
+		Timelock:  MinimumRecoveryTimelock,
+	})
+
+	raw, err := rlp.EncodeToBytes(acc)
+	require.Nil(err)
+	var decoded Account
+	require.Nil(rlp.DecodeBytes(raw, &decoded))
+	assert.Equal(acc.GetGuardianship(), decoded.GetGuardianship())
+	require.Equal(1, len(decoded.GetLockedCoins()))
+	assert.Equal(uint64(1000), decoded.GetLockedCoins()[0].UnlockHeight)
+	assert.True(NewCoins(1, 2).IsEqual(decoded.GetLockedCoins()[0].Coins))
+
+	s, err := json.Marshal(acc)
+	require.Nil(err)
+	assert.Contains(string(s), `"locked_coins":[{"coins":`)
+	assert.Contains(string(s), `"unlock_height":"1000"`)
+	var d Account
+	require.Nil(json.Unmarshal(s, &d))
+	require.Equal(1, len(d.GetLockedCoins()))
+	assert.Equal(uint64(1000), d.GetLockedCoins()[0].UnlockHeight)
+	assert.True(NewCoins(1, 2).IsEqual(d.GetLockedCoins()[0].Coins))
+}
@@ -0,0 +1,257 @@
+package types
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/crypto"
+	"github.com/thetatoken/ukulele/rlp"
+)
+
+// multisigPrefix separates the multisig addresses and signatures from the regular ones
+var multisigPrefix = []byte("multisig")
+
+// MultisigAccount is an account controlled by M of its N public keys, i.e. a TxInput
+// spending from the account needs the signatures of at least Threshold of the keys.
+// The address of the account is derived from the threshold and the public keys, so
+// the account needs no registration: funds can be sent to the address right away,
+// and the keys are revealed by the first transaction the account signs.
+type MultisigAccount struct {
+	Threshold  uint64
+	PublicKeys []*crypto.PublicKey
+}
+
+// NewMultisigAccount creates an M-of-N multisig account. The public keys are sorted,
+// so that the address does not depend on their order.
+func NewMultisigAccount(threshold uint64, pubKeys []*crypto.PublicKey) (*MultisigAccount, error) {
+	sorted := append([]*crypto.PublicKey{}, pubKeys...)
+	for _, pubKey := range sorted {
+		if pubKey == nil || pubKey.IsEmpty() {
+			return nil, errors.New("Empty public key")
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return bytes.Compare(sorted[i].ToBytes(), sorted[j].ToBytes()) < 0
+	})
+	account := &MultisigAccount{
+		Threshold:  threshold,
+		PublicKeys: sorted,
+	}
+	if err := account.Validate(); err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+// Validate checks the threshold, and that the public keys are distinct and sorted
+func (ma *MultisigAccount) Validate() error {
+	numKeys := uint64(len(ma.PublicKeys))
+	if numKeys == 0 || numKeys > MaxMultisigPublicKeys {
+		return fmt.Errorf("A multisig account needs 1 to %v public keys, got %v", MaxMultisigPublicKeys, numKeys)
+	}
+	if ma.Threshold == 0 || ma.Threshold > numKeys {
+		return fmt.Errorf("Invalid threshold %v for %v public keys", ma.Threshold, numKeys)
+	}
+	for i, pubKey := range ma.PublicKeys {
+		if pubKey == nil || pubKey.IsEmpty() {
+			return errors.New("Empty public key")
+		}
+		if i > 0 && bytes.Compare(ma.PublicKeys[i-1].ToBytes(), pubKey.ToBytes()) >= 0 {
+			return errors.New("Public keys need to be distinct and sorted")
+		}
+	}
+	return nil
+}
+
+// Address returns the address of the multisig account
+func (ma *MultisigAccount) Address() common.Address {
+	accountBytes, err := rlp.EncodeToBytes(ma)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to encode multisig account: %v", err))
+	}
+	return common.BytesToAddress(crypto.Keccak256(multisigPrefix, accountBytes)[12:])
+}
+
+// KeyIndex returns the index of the public key of the address, or -1 if the
+// address is not a key of the account
+func (ma *MultisigAccount) KeyIndex(address common.Address) int {
+	for i, pubKey := range ma.PublicKeys {
+		if pubKey.Address() == address {
+			return i
+		}
+	}
+	return -1
+}
+
+func (ma *MultisigAccount) String() string {
+	return fmt.Sprintf("MultisigAccount{%v-of-%v %v}", ma.Threshold, len(ma.PublicKeys), ma.Address().Hex())
+}
+
+// MultisigSignature aggregates the partial signatures of a multisig account. It is
+// carried in the Signature of the TxInput spending from the account (see ToSignature),
+// so that the partially signed transaction can be passed between the signers.
+type MultisigSignature struct {
+	Account    MultisigAccount
+	Signatures []*crypto.Signature // Signatures[i] is signed by PublicKeys[i], empty if not signed yet
+}
+
+// NewMultisigSignature creates a multisig signature without any partial signature
+func NewMultisigSignature(account *MultisigAccount) *MultisigSignature {
+	signatures := make([]*crypto.Signature, len(account.PublicKeys))
+	for i := range signatures {
+		signatures[i], _ = crypto.SignatureFromBytes(common.Bytes{})
+	}
+	return &MultisigSignature{
+		Account:    *account,
+		Signatures: signatures,
+	}
+}
+
+// AddSignature adds the partial signature of one of the keys over the sign bytes
+func (ms *MultisigSignature) AddSignature(signBytes []byte, sig *crypto.Signature) error {
+	signer, err := sig.RecoverSignerAddress(signBytes)
+	if err != nil {
+		return err
+	}
+	index := ms.Account.KeyIndex(signer)
+	if index < 0 {
+		return fmt.Errorf("%v is not a key of the multisig account %v", signer.Hex(), ms.Account.Address().Hex())
+	}
+	ms.Signatures[index] = sig
+	return nil
+}
+
+// Merge adds the partial signatures of another multisig signature of the same account
+func (ms *MultisigSignature) Merge(other *MultisigSignature) error {
+	if ms.Account.Address() != other.Account.Address() {
+		return errors.New("Cannot merge the signatures of different multisig accounts")
+	}
+	for i, sig := range other.Signatures {
+		if sig != nil && !sig.IsEmpty() {
+			ms.Signatures[i] = sig
+		}
+	}
+	return nil
+}
+
+// NumSigned returns the number of the partial signatures
+func (ms *MultisigSignature) NumSigned() uint64 {
+	numSigned := uint64(0)
+	for _, sig := range ms.Signatures {
+		if sig != nil && !sig.IsEmpty() {
+			numSigned++
+		}
+	}
+	return numSigned
+}
+
+// Verify checks that the multisig signature is of the account of the address, and
+// that at least Threshold keys have signed the sign bytes. All the partial signatures
+// need to be valid.
+func (ms *MultisigSignature) Verify(signBytes []byte, address common.Address) bool {
+	numSigned, ok := ms.VerifyPartial(signBytes, address)
+	return ok && numSigned >= ms.Account.Threshold
+}
+
+// VerifyPartial checks that the multisig signature is of the account of the address,
+// and that all its partial signatures are valid, regardless of the threshold. It
+// returns the number of the partial signatures.
+func (ms *MultisigSignature) VerifyPartial(signBytes []byte, address common.Address) (uint64, bool) {
+	if ms.Account.Validate() != nil || ms.Account.Address() != address {
+		return 0, false
+	}
+	if len(ms.Signatures) != len(ms.Account.PublicKeys) {
+		return 0, false
+	}
+	numSigned := uint64(0)
+	for i, sig := range ms.Signatures {
+		if sig == nil || sig.IsEmpty() {
+			continue
+		}
+		if !ms.Account.PublicKeys[i].VerifySignature(signBytes, sig) {
+			return 0, false
+		}
+		numSigned++
+	}
+	return numSigned, true
+}
+
+// ToSignature encodes the multisig signature into the signature of a TxInput
+func (ms *MultisigSignature) ToSignature() *crypto.Signature {
+	msBytes, err := rlp.EncodeToBytes(ms)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to encode multisig signature: %v", err))
+	}
+	sig, _ := crypto.SignatureFromBytes(append(append(common.Bytes{}, multisigPrefix...), msBytes...))
+	return sig
+}
+
+// MultisigSignatureFromSignature decodes the multisig signature carried in the signature
+// of a TxInput. It returns false if the signature is not a multisig signature.
+func MultisigSignatureFromSignature(sig *crypto.Signature) (*MultisigSignature, bool) {
+	if sig == nil || !bytes.HasPrefix(sig.ToBytes(), multisigPrefix) {
+		return nil, false
+	}
+	ms := &MultisigSignature{}
+	if err := rlp.DecodeBytes(sig.ToBytes()[len(multisigPrefix):], ms); err != nil {
+		return nil, false
+	}
+	return ms, true
+}
+
+// VerifySignature verifies the signature of the input over the sign bytes, which is
+// either signed by the key of the input address, or a multisig signature of the
+// multisig account of the address
+func (txIn TxInput) VerifySignature(signBytes []byte) bool {
+	if ms, ok := MultisigSignatureFromSignature(txIn.Signature); ok {
+		return ms.Verify(signBytes, txIn.Address)
+	}
+	return txIn.Signature.Verify(signBytes, txIn.Address)
+}
+
+// SpendingInputs returns the inputs of the accounts spending in the transaction,
+// i.e. the inputs that can be signed by multisig accounts
+func SpendingInputs(tx Tx) []*TxInput {
+	switch tx := tx.(type) {
+	case *SendTx:
+		inputs := []*TxInput{}
+		for i := range tx.Inputs {
+			inputs = append(inputs, &tx.Inputs[i])
+		}
+		return inputs
+	case *TimelockedSendTx:
+		inputs := []*TxInput{}
+		for i := range tx.Inputs {
+			inputs = append(inputs, &tx.Inputs[i])
+		}
+		return inputs
+	case *ReserveFundTx:
+		return []*TxInput{&tx.Source}
+	case *ReleaseFundTx:
+		return []*TxInput{&tx.Source}
+	case *ExtendReserveTx:
+		return []*TxInput{&tx.Source}
+	case *SplitRuleTx:
+		return []*TxInput{&tx.Initiator}
+	case *SmartContractTx:
+		return []*TxInput{&tx.From}
+	case *DepositStakeTx:
+		return []*TxInput{&tx.Source}
+	case *WithdrawStakeTx:
+		return []*TxInput{&tx.Source}
+	case *SetGuardiansTx:
+		return []*TxInput{&tx.Account}
+	case *CreateTokenTx:
+		return []*TxInput{&tx.Issuer}
+	case *ProposalTx:
+		return []*TxInput{&tx.Proposer}
+	case *VoteTx:
+		return []*TxInput{&tx.Voter}
+	case *UnjailTx:
+		return []*TxInput{&tx.Validator}
+	}
+	return []*TxInput{}
+}
@@ -0,0 +1,95 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thetatoken/ukulele/crypto"
+)
+
+func TestMultisigAccount(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	acc1, acc2, acc3 := MakeAcc("signer1"), MakeAcc("signer2"), MakeAcc("signer3")
+	pubKeys := []*crypto.PublicKey{acc1.PrivKey.PublicKey(), acc2.PrivKey.PublicKey(), acc3.PrivKey.PublicKey()}
+
+	account, err := NewMultisigAccount(2, pubKeys)
+	require.Nil(err)
+	reordered, err := NewMultisigAccount(2, []*crypto.PublicKey{pubKeys[2], pubKeys[0], pubKeys[1]})
+	require.Nil(err)
+	assert.Equal(account.Address(), reordered.Address())
+	otherThreshold, err := NewMultisigAccount(3, pubKeys)
+	require.Nil(err)
+	assert.NotEqual(account.Address(), otherThreshold.Address())
+
+	_, err = NewMultisigAccount(0, pubKeys)
+	assert.NotNil(err)
+	_, err = NewMultisigAccount(4, pubKeys)
+	assert.NotNil(err)
+	_, err = NewMultisigAccount(1, []*crypto.PublicKey{pubKeys[0], pubKeys[0]})
+	assert.NotNil(err)
+
+	assert.True(account.KeyIndex(acc2.Address) >= 0)
+	assert.Equal(-1, account.KeyIndex(MakeAcc("outsider").Address))
+}
+
+func TestMultisigSignature(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	acc1, acc2, acc3 := MakeAcc("signer1"), MakeAcc("signer2"), MakeAcc("signer3")
+	outsider := MakeAcc("outsider")
+	account, err := NewMultisigAccount(2, []*crypto.PublicKey{
+		acc1.PrivKey.PublicKey(), acc2.PrivKey.PublicKey(), acc3.PrivKey.PublicKey()})
+	require.Nil(err)
+
+	tx := &SendTx{
+		Fee:     NewCoins(0, 1000000000000),
+		Inputs:  []TxInput{NewTxInput(account.Address(), NewCoins(10, 1000000000000), 1)},
+		Outputs: []TxOutput{{Address: outsider.Address, Coins: NewCoins(10, 0)}},
+	}
+	signBytes := tx.SignBytes(chainID)
+
+	ms := NewMultisigSignature(account)
+	require.Nil(ms.AddSignature(signBytes, acc1.Sign(signBytes)))
+	assert.NotNil(ms.AddSignature(signBytes, outsider.Sign(signBytes)))
+	assert.Equal(uint64(1), ms.NumSigned())
+	tx.Inputs[0].Signature = ms.ToSignature()
+	assert.False(tx.Inputs[0].VerifySignature(signBytes), "one signature is below the threshold")
+
+	// The partial signatures are merged, and survive the serialization of the transaction
+	other := NewMultisigSignature(account)
+	require.Nil(other.AddSignature(signBytes, acc3.Sign(signBytes)))
+	require.Nil(ms.Merge(other))
+	tx.Inputs[0].Signature = ms.ToSignature()
+	txBytes, err := TxToBytes(tx)
+	require.Nil(err)
+	decoded, err := TxFromBytes(txBytes)
+	require.Nil(err)
+	decodedInput := decoded.(*SendTx).Inputs[0]
+	assert.True(decodedInput.VerifySignature(signBytes))
+	decodedMs, ok := MultisigSignatureFromSignature(decodedInput.Signature)
+	require.True(ok)
+	assert.Equal(uint64(2), decodedMs.NumSigned())
+
+	// The signature does not verify for other sign bytes or addresses
+	assert.False(decodedInput.VerifySignature(tx.SignBytes("other_chain")))
+	assert.False(ms.Verify(signBytes, acc1.Address))
+
+	// An invalid partial signature fails the verification
+	ms.Signatures[1] = outsider.Sign(signBytes)
+	assert.False(ms.Verify(signBytes, account.Address()))
+
+	// Merging the signatures of another account fails
+	otherAccount, err := NewMultisigAccount(1, []*crypto.PublicKey{acc1.PrivKey.PublicKey()})
+	require.Nil(err)
+	assert.NotNil(ms.Merge(NewMultisigSignature(otherAccount)))
+
+	// Regular signatures still verify
+	regular := TxInput{Address: acc1.Address, Signature: acc1.Sign(signBytes)}
+	assert.True(regular.VerifySignature(signBytes))
+	_, ok = MultisigSignatureFromSignature(regular.Signature)
+	assert.False(ok)
+}
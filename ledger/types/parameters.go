@@ -0,0 +1,270 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/pkg/errors"
+	"github.com/thetatoken/ukulele/common"
+)
+
+// ** Parameter governance: Ledger parameters changed by a ParameterUpdateTx **
+//
+
+// ChainParameters are the ledger parameters that the validators can change with a
+// ParameterUpdateTx.
+type ChainParameters struct {
+	MinimumTransactionFeeGammaWei uint64   // Minimum fee of a regular transaction
+	MaxBlockGas                   uint64   // Maximum total gas of the transactions of a block, 0 for no limit
+	SlashCollateralPercent        uint64   // Percentage of the slashable amount that is slashed
+	SlashReporterRewardPercent    uint64   // Percentage of the slashed amount rewarded to the reporter
+	SlashJailDuration             uint64   // Number of epochs a slashed validator is not selected as proposer
+	BlockRewardGammaWei           *big.Int // GammaWei issued for each block before any halving
+	RewardHalvingInterval         uint64   // Number of blocks after which the block reward halves, 0 for a constant reward
+	FeeBurnPercent                uint64   // Percentage of the transaction fees that is burned
+	ProposerFeePercent            uint64   // Percentage of the fee pool rewarded to the proposer
+	MaxReservedFundsPerAccount    uint64   // Number of reserved funds an account holds at once, 0 for no limit
+	MaxTotalReserveDuration       uint64   // Sum of the remaining durations of the reserved funds of an account, 0 for no limit
+}
+
+type ChainParametersJSON struct {
+	MinimumTransactionFeeGammaWei common.JSONUint64 `json:"minimum_transaction_fee_gamma_wei"`
+	MaxBlockGas                   common.JSONUint64 `json:"max_block_gas"`
+	SlashCollateralPercent        common.JSONUint64 `json:"slash_collateral_percent"`
+	SlashReporterRewardPercent    common.JSONUint64 `json:"slash_reporter_reward_percent"`
+	SlashJailDuration             common.JSONUint64 `json:"slash_jail_duration"`
+	BlockRewardGammaWei           *common.JSONBig   `json:"block_reward_gamma_wei"`
+	RewardHalvingInterval         common.JSONUint64 `json:"reward_halving_interval"`
+	FeeBurnPercent                common.JSONUint64 `json:"fee_burn_percent"`
+	ProposerFeePercent            common.JSONUint64 `json:"proposer_fee_percent"`
+	MaxReservedFundsPerAccount    common.JSONUint64 `json:"max_reserved_funds_per_account"`
+	MaxTotalReserveDuration       common.JSONUint64 `json:"max_total_reserve_duration"`
+}
+
+func NewChainParametersJSON(p ChainParameters) ChainParametersJSON {
+	return ChainParametersJSON{
+		MinimumTransactionFeeGammaWei: common.JSONUint64(p.MinimumTransactionFeeGammaWei),
+		MaxBlockGas:                   common.JSONUint64(p.MaxBlockGas),
+		SlashCollateralPercent:        common.JSONUint64(p.SlashCollateralPercent),
+		SlashReporterRewardPercent:    common.JSONUint64(p.SlashReporterRewardPercent),
+		SlashJailDuration:             common.JSONUint64(p.SlashJailDuration),
+		BlockRewardGammaWei:           (*common.JSONBig)(p.BlockRewardGammaWei),
+		RewardHalvingInterval:         common.JSONUint64(p.RewardHalvingInterval),
+		FeeBurnPercent:                common.JSONUint64(p.FeeBurnPercent),
+		ProposerFeePercent:            common.JSONUint64(p.ProposerFeePercent),
+		MaxReservedFundsPerAccount:    common.JSONUint64(p.MaxReservedFundsPerAccount),
+		MaxTotalReserveDuration:       common.JSONUint64(p.MaxTotalReserveDuration),
+	}
+}
+
+func (p ChainParametersJSON) ChainParameters() ChainParameters {
+	return ChainParameters{
+		MinimumTransactionFeeGammaWei: uint64(p.MinimumTransactionFeeGammaWei),
+		MaxBlockGas:                   uint64(p.MaxBlockGas),
+		SlashCollateralPercent:        uint64(p.SlashCollateralPercent),
+		SlashReporterRewardPercent:    uint64(p.SlashReporterRewardPercent),
+		SlashJailDuration:             uint64(p.SlashJailDuration),
+		BlockRewardGammaWei:           (*big.Int)(p.BlockRewardGammaWei),
+		RewardHalvingInterval:         uint64(p.RewardHalvingInterval),
+		FeeBurnPercent:                uint64(p.FeeBurnPercent),
+		ProposerFeePercent:            uint64(p.ProposerFeePercent),
+		MaxReservedFundsPerAccount:    uint64(p.MaxReservedFundsPerAccount),
+		MaxTotalReserveDuration:       uint64(p.MaxTotalReserveDuration),
+	}
+}
+
+func (p ChainParameters) MarshalJSON() ([]byte, error) {
+	return json.Marshal(NewChainParametersJSON(p))
+}
+
+func (p *ChainParameters) UnmarshalJSON(data []byte) error {
+	var b ChainParametersJSON
+	if err := json.Unmarshal(data, &b); err != nil {
+		return err
+	}
+	*p = b.ChainParameters()
+	return nil
+}
+
+func (p ChainParameters) String() string {
+	return fmt.Sprintf("ChainParameters{min_fee: %v, max_block_gas: %v, slash_collateral_percent: %v, slash_reporter_reward_percent: %v, slash_jail_duration: %v, "+
+		"block_reward: %v, reward_halving_interval: %v, fee_burn_percent: %v, proposer_fee_percent: %v, "+
+		"max_reserved_funds_per_account: %v, max_total_reserve_duration: %v}",
+		p.MinimumTransactionFeeGammaWei, p.MaxBlockGas, p.SlashCollateralPercent, p.SlashReporterRewardPercent, p.SlashJailDuration,
+		p.BlockRewardGammaWei, p.RewardHalvingInterval, p.FeeBurnPercent, p.ProposerFeePercent,
+		p.MaxReservedFundsPerAccount, p.MaxTotalReserveDuration)
+}
+
+// DefaultChainParameters returns the parameters in effect before any update, i.e.
+// the minimum fee of the protocol, no block gas limit, and the default slashing,
+// reward and reserve policies.
+func DefaultChainParameters() ChainParameters {
+	slashing := DefaultSlashingPolicy()
+	reward := DefaultRewardPolicy()
+	reserve := DefaultReservePolicy()
+	return ChainParameters{
+		MinimumTransactionFeeGammaWei: MinimumTransactionFeeGammaWei,
+		MaxBlockGas:                   0,
+		SlashCollateralPercent:        uint64(slashing.CollateralPercent),
+		SlashReporterRewardPercent:    uint64(slashing.ReporterRewardPercent),
+		SlashJailDuration:             slashing.JailDuration,
+		BlockRewardGammaWei:           reward.BlockReward,
+		RewardHalvingInterval:         reward.HalvingInterval,
+		FeeBurnPercent:                uint64(reward.FeeBurnPercent),
+		ProposerFeePercent:            uint64(reward.ProposerFeePercent),
+		MaxReservedFundsPerAccount:    uint64(reserve.MaxFundsPerAccount),
+		MaxTotalReserveDuration:       reserve.MaxTotalDuration,
+	}
+}
+
+// Validate checks that the parameters keep the chain operable: transactions cost a
+// fee, a block can hold at least a few transactions, slashing takes at most the
+// slashable amount, and the rewards distribute at most the fees.
+func (p ChainParameters) Validate() error {
+	if p.MinimumTransactionFeeGammaWei == 0 {
+		return errors.New("Minimum transaction fee needs to be positive")
+	}
+	if p.MaxBlockGas != 0 && p.MaxBlockGas < MinimumMaxBlockGas {
+		return errors.Errorf("Max block gas needs to be 0 or at least %v", MinimumMaxBlockGas)
+	}
+	if p.SlashCollateralPercent > 100 {
+		return errors.New("Slash collateral percent needs to be at most 100")
+	}
+	if p.SlashReporterRewardPercent > 100 {
+		return errors.New("Slash reporter reward percent needs to be at most 100")
+	}
+	if p.BlockRewardGammaWei != nil && p.BlockRewardGammaWei.Sign() < 0 {
+		return errors.New("Block reward needs to be non-negative")
+	}
+	if p.FeeBurnPercent > 100 {
+		return errors.New("Fee burn percent needs to be at most 100")
+	}
+	if p.ProposerFeePercent > 100 {
+		return errors.New("Proposer fee percent needs to be at most 100")
+	}
+	return nil
+}
+
+// SlashingPolicy returns the slashing policy of the parameters.
+func (p ChainParameters) SlashingPolicy() SlashingPolicy {
+	return SlashingPolicy{
+		CollateralPercent:     uint(p.SlashCollateralPercent),
+		ReporterRewardPercent: uint(p.SlashReporterRewardPercent),
+		JailDuration:          p.SlashJailDuration,
+	}
+}
+
+// RewardPolicy returns the reward policy of the parameters.
+func (p ChainParameters) RewardPolicy() RewardPolicy {
+	blockReward := new(big.Int)
+	if p.BlockRewardGammaWei != nil {
+		blockReward.Set(p.BlockRewardGammaWei)
+	}
+	return RewardPolicy{
+		BlockReward:        blockReward,
+		HalvingInterval:    p.RewardHalvingInterval,
+		FeeBurnPercent:     uint(p.FeeBurnPercent),
+		ProposerFeePercent: uint(p.ProposerFeePercent),
+	}
+}
+
+// ReservePolicy returns the reserve policy of the parameters.
+func (p ChainParameters) ReservePolicy() ReservePolicy {
+	return ReservePolicy{
+		MaxFundsPerAccount: uint(p.MaxReservedFundsPerAccount),
+		MaxTotalDuration:   p.MaxTotalReserveDuration,
+	}
+}
+
+// ParameterUpdate is an update of the chain parameters approved by the validators,
+// which takes effect at the given block height.
+type ParameterUpdate struct {
+	Height     uint64          // Block height from which the parameters apply
+	Parameters ChainParameters // Parameters from the height on
+}
+
+type ParameterUpdateJSON struct {
+	Height     common.JSONUint64 `json:"height"`
+	Parameters ChainParameters   `json:"parameters"`
+}
+
+func NewParameterUpdateJSON(u ParameterUpdate) ParameterUpdateJSON {
+	return ParameterUpdateJSON{
+		Height:     common.JSONUint64(u.Height),
+		Parameters: u.Parameters,
+	}
+}
+
+func (u ParameterUpdateJSON) ParameterUpdate() ParameterUpdate {
+	return ParameterUpdate{
+		Height:     uint64(u.Height),
+		Parameters: u.Parameters,
+	}
+}
+
+func (u ParameterUpdate) MarshalJSON() ([]byte, error) {
+	return json.Marshal(NewParameterUpdateJSON(u))
+}
+
+func (u *ParameterUpdate) UnmarshalJSON(data []byte) error {
+	var b ParameterUpdateJSON
+	if err := json.Unmarshal(data, &b); err != nil {
+		return err
+	}
+	*u = b.ParameterUpdate()
+	return nil
+}
+
+func (u *ParameterUpdate) String() string {
+	if u == nil {
+		return "nil-ParameterUpdate"
+	}
+	return fmt.Sprintf("ParameterUpdate{height: %v, parameters: %v}", u.Height, u.Parameters)
+}
+
+// TxGas returns the gas of the transaction, which the block gas limit is checked
+// against. The gas of a smart contract transaction is its gas limit.
+func TxGas(tx Tx) uint64 {
+	switch tx := tx.(type) {
+	case *SendTx:
+		return GasSendTxPerAccount * uint64(len(tx.Inputs)+len(tx.Outputs))
+	case *TimelockedSendTx:
+		return GasSendTxPerAccount * uint64(len(tx.Inputs)+len(tx.Outputs))
+	case *ReserveFundTx:
+		return GasReserveFundTx
+	case *ReleaseFundTx:
+		return GasReleaseFundTx
+	case *ExtendReserveTx:
+		return GasExtendReserveTx
+	case *ServicePaymentTx:
+		return GasServicePaymentTx
+	case *BatchServicePaymentTx:
+		return GasBatchedPayment * uint64(len(tx.Payments))
+	case *SplitRuleTx:
+		return GasSplitRuleTx
+	case *UpdateValidatorsTx:
+		return GasUpdateValidatorsTx
+	case *SmartContractTx:
+		return tx.GasLimit
+	case *DepositStakeTx:
+		return GasDepositStakeTx
+	case *WithdrawStakeTx:
+		return GasWithdrawStakeTx
+	case *SetGuardiansTx:
+		return GasSetGuardiansTx
+	case *RecoveryTx:
+		return GasRecoveryTx
+	case *CreateTokenTx:
+		return GasCreateTokenTx
+	case *ParameterUpdateTx:
+		return GasParameterUpdateTx
+	case *ProposalTx:
+		return GasProposalTx
+	case *VoteTx:
+		return GasVoteTx
+	case *UnjailTx:
+		return GasUnjailTx
+	}
+	return 0
+}
@@ -0,0 +1,132 @@
+package types
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChainParametersValidate(t *testing.T) {
+	assert := assert.New(t)
+
+	params := DefaultChainParameters()
+	assert.Nil(params.Validate())
+
+	params.MinimumTransactionFeeGammaWei = 0
+	assert.NotNil(params.Validate())
+	params.MinimumTransactionFeeGammaWei = MinimumTransactionFeeGammaWei
+
+	params.MaxBlockGas = MinimumMaxBlockGas - 1
+	assert.NotNil(params.Validate())
+	params.MaxBlockGas = MinimumMaxBlockGas
+	assert.Nil(params.Validate())
+
+	params.SlashCollateralPercent = 101
+	assert.NotNil(params.Validate())
+	params.SlashCollateralPercent = 0
+	assert.Nil(params.Validate())
+
+	params.SlashReporterRewardPercent = 101
+	assert.NotNil(params.Validate())
+	params.SlashReporterRewardPercent = 0
+	assert.Nil(params.Validate())
+
+	params.BlockRewardGammaWei = big.NewInt(-1)
+	assert.NotNil(params.Validate())
+	params.BlockRewardGammaWei = big.NewInt(1000)
+	assert.Nil(params.Validate())
+
+	params.FeeBurnPercent = 101
+	assert.NotNil(params.Validate())
+	params.FeeBurnPercent = 0
+	params.ProposerFeePercent = 101
+	assert.NotNil(params.Validate())
+	params.ProposerFeePercent = 0
+	assert.Nil(params.Validate())
+}
+
+func TestChainParametersSlashingPolicy(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal(DefaultSlashingPolicy(), DefaultChainParameters().SlashingPolicy())
+
+	params := DefaultChainParameters()
+	params.SlashCollateralPercent = 50
+	params.SlashReporterRewardPercent = 40
+	params.SlashJailDuration = 10
+	assert.Equal(SlashingPolicy{CollateralPercent: 50, ReporterRewardPercent: 40, JailDuration: 10}, params.SlashingPolicy())
+}
+
+func TestChainParametersRewardPolicy(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal(DefaultRewardPolicy(), DefaultChainParameters().RewardPolicy())
+
+	params := DefaultChainParameters()
+	params.BlockRewardGammaWei = big.NewInt(1000)
+	params.RewardHalvingInterval = 100
+	params.FeeBurnPercent = 50
+	params.ProposerFeePercent = 20
+	policy := params.RewardPolicy()
+	assert.Equal(int64(500), policy.BlockRewardAt(100).Int64())
+	assert.Equal(uint(50), policy.FeeBurnPercent)
+	assert.Equal(uint(20), policy.ProposerFeePercent)
+
+	// The policy does not share the block reward of the parameters
+	policy.BlockReward.SetInt64(1)
+	assert.Equal(int64(1000), params.BlockRewardGammaWei.Int64())
+}
+
+func TestChainParametersReservePolicy(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal(DefaultReservePolicy(), DefaultChainParameters().ReservePolicy())
+
+	params := DefaultChainParameters()
+	params.MaxReservedFundsPerAccount = 2
+	params.MaxTotalReserveDuration = 1500
+	assert.Equal(ReservePolicy{MaxFundsPerAccount: 2, MaxTotalDuration: 1500}, params.ReservePolicy())
+}
+
+func TestParameterUpdateJSON(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	update := ParameterUpdate{
+		Height: 1000,
+		Parameters: ChainParameters{
+			MinimumTransactionFeeGammaWei: 2e12,
+			MaxBlockGas:                   MinimumMaxBlockGas,
+			SlashCollateralPercent:        50,
+			SlashReporterRewardPercent:    40,
+			SlashJailDuration:             10,
+			BlockRewardGammaWei:           big.NewInt(1000),
+			FeeBurnPercent:                50,
+		},
+	}
+	b, err := json.Marshal(update)
+	require.Nil(err)
+	assert.Contains(string(b), `"height":"1000"`)
+	assert.Contains(string(b), `"slash_collateral_percent":"50"`)
+	assert.Contains(string(b), `"slash_jail_duration":"10"`)
+	assert.Contains(string(b), `"block_reward_gamma_wei":"1000"`)
+
+	var update2 ParameterUpdate
+	require.Nil(json.Unmarshal(b, &update2))
+	assert.Equal(update, update2)
+}
+
+func TestTxGas(t *testing.T) {
+	assert := assert.New(t)
+
+	sendTx := &SendTx{
+		Inputs:  []TxInput{{}, {}},
+		Outputs: []TxOutput{{}},
+	}
+	assert.Equal(3*GasSendTxPerAccount, TxGas(sendTx))
+	assert.Equal(uint64(50000), TxGas(&SmartContractTx{GasLimit: 50000}))
+	assert.Equal(GasParameterUpdateTx, TxGas(&ParameterUpdateTx{}))
+	assert.Equal(uint64(0), TxGas(&CoinbaseTx{}))
+}
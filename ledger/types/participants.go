@@ -0,0 +1,98 @@
+package types
+
+import (
+	"github.com/thetatoken/ukulele/common"
+)
+
+// TxParticipants returns the addresses taking part in the transaction: the senders,
+// which sign or pay for it, and the recipients it is directed to. An address appears
+// at most once in each list, and in both if it sends to itself.
+func TxParticipants(tx Tx) (senders []common.Address, recipients []common.Address) {
+	sent, received := map[common.Address]bool{}, map[common.Address]bool{}
+	addSenders := func(addrs ...common.Address) {
+		for _, addr := range addrs {
+			if addr != (common.Address{}) && !sent[addr] {
+				sent[addr] = true
+				senders = append(senders, addr)
+			}
+		}
+	}
+	addRecipients := func(addrs ...common.Address) {
+		for _, addr := range addrs {
+			if addr != (common.Address{}) && !received[addr] {
+				received[addr] = true
+				recipients = append(recipients, addr)
+			}
+		}
+	}
+	addInputs := func(inputs ...TxInput) {
+		for _, input := range inputs {
+			addSenders(input.Address)
+		}
+	}
+	addOutputs := func(outputs ...TxOutput) {
+		for _, output := range outputs {
+			addRecipients(output.Address)
+		}
+	}
+
+	switch tx := tx.(type) {
+	case *CoinbaseTx:
+		addInputs(tx.Proposer)
+		addOutputs(tx.Outputs...)
+	case *SlashTx:
+		addInputs(tx.Proposer)
+		addRecipients(tx.SlashedAddress)
+	case *SendTx:
+		addInputs(tx.Inputs...)
+		addOutputs(tx.Outputs...)
+	case *TimelockedSendTx:
+		addInputs(tx.Inputs...)
+		addOutputs(tx.Outputs...)
+	case *ReserveFundTx:
+		addInputs(tx.Source)
+	case *ReleaseFundTx:
+		addInputs(tx.Source)
+	case *ExtendReserveTx:
+		addInputs(tx.Source)
+	case *ServicePaymentTx:
+		addInputs(tx.Source)
+		addRecipients(tx.Target.Address)
+	case *BatchServicePaymentTx:
+		for _, payment := range tx.Payments {
+			addInputs(payment.Source)
+		}
+		addRecipients(tx.Target.Address)
+	case *SplitRuleTx:
+		addInputs(tx.Initiator)
+	case *UpdateValidatorsTx:
+		addInputs(tx.Proposer)
+	case *SmartContractTx:
+		addInputs(tx.From)
+		addOutputs(tx.To)
+	case *DepositStakeTx:
+		addInputs(tx.Source)
+		addOutputs(tx.Holder)
+	case *WithdrawStakeTx:
+		addInputs(tx.Source)
+		addOutputs(tx.Holder)
+	case *SetGuardiansTx:
+		addInputs(tx.Account)
+		addRecipients(tx.Guardians...)
+	case *RecoveryTx:
+		addInputs(tx.Guardians...)
+		addRecipients(tx.Account.Address)
+	case *CreateTokenTx:
+		addInputs(tx.Issuer)
+	case *ParameterUpdateTx:
+		addInputs(tx.Proposer)
+		addInputs(tx.Approvers...)
+	case *ProposalTx:
+		addInputs(tx.Proposer)
+	case *VoteTx:
+		addInputs(tx.Voter)
+	case *UnjailTx:
+		addInputs(tx.Validator)
+	}
+	return senders, recipients
+}
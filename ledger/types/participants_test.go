@@ -0,0 +1,41 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thetatoken/ukulele/common"
+)
+
+func TestTxParticipants(t *testing.T) {
+	assert := assert.New(t)
+
+	alice, bob, carol := common.HexToAddress("0xa"), common.HexToAddress("0xb"), common.HexToAddress("0xc")
+
+	senders, recipients := TxParticipants(&SendTx{
+		Inputs:  []TxInput{{Address: alice}, {Address: bob}},
+		Outputs: []TxOutput{{Address: bob}, {Address: carol}, {Address: carol}},
+	})
+	assert.Equal([]common.Address{alice, bob}, senders)
+	assert.Equal([]common.Address{bob, carol}, recipients)
+
+	senders, recipients = TxParticipants(&BatchServicePaymentTx{
+		Target:   TxInput{Address: carol},
+		Payments: []ServicePaymentTx{{Source: TxInput{Address: alice}}, {Source: TxInput{Address: bob}}},
+	})
+	assert.Equal([]common.Address{alice, bob}, senders)
+	assert.Equal([]common.Address{carol}, recipients)
+
+	// The guardians approve the recovery of the account
+	senders, recipients = TxParticipants(&RecoveryTx{
+		Account:   TxInput{Address: alice},
+		Guardians: []TxInput{{Address: bob}, {Address: carol}},
+	})
+	assert.Equal([]common.Address{bob, carol}, senders)
+	assert.Equal([]common.Address{alice}, recipients)
+
+	// A contract deployment has no recipient
+	senders, recipients = TxParticipants(&SmartContractTx{From: TxInput{Address: alice}})
+	assert.Equal([]common.Address{alice}, senders)
+	assert.Empty(recipients)
+}
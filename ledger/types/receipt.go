@@ -0,0 +1,100 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/common/result"
+)
+
+// ** Transaction receipt: Gas accounting of an executed transaction **
+//
+
+// TxReceipt records the gas accounting of a transaction paying for gas. The fee of the
+// gas limit is charged up front, and the fee of the unused gas is refunded after the
+// execution, so only the fee of the gas used goes to the fee pool.
+type TxReceipt struct {
+	GasLimit        uint64         // Gas limit of the transaction
+	GasPrice        *big.Int       // Gas price of the transaction, in GammaWei
+	GasUsed         uint64         // Gas used by the execution
+	Fee             Coins          // Fee of the gas used, collected in the fee pool
+	Refund          Coins          // Fee of the unused gas, refunded to the sender
+	ContractAddress common.Address // Address of the contract called or deployed
+	EvmError        string         // Error of the EVM execution, if any
+}
+
+type TxReceiptJSON struct {
+	GasLimit        common.JSONUint64 `json:"gas_limit"`
+	GasPrice        *common.JSONBig   `json:"gas_price"`
+	GasUsed         common.JSONUint64 `json:"gas_used"`
+	Fee             Coins             `json:"fee"`
+	Refund          Coins             `json:"refund"`
+	ContractAddress common.Address    `json:"contract_address"`
+	EvmError        string            `json:"evm_error,omitempty"`
+	Code            result.ErrorCode  `json:"code"`
+}
+
+func NewTxReceiptJSON(r TxReceipt) TxReceiptJSON {
+	return TxReceiptJSON{
+		GasLimit:        common.JSONUint64(r.GasLimit),
+		GasPrice:        (*common.JSONBig)(r.GasPrice),
+		GasUsed:         common.JSONUint64(r.GasUsed),
+		Fee:             r.Fee,
+		Refund:          r.Refund,
+		ContractAddress: r.ContractAddress,
+		EvmError:        r.EvmError,
+		Code:            r.Code(),
+	}
+}
+
+func (r TxReceiptJSON) TxReceipt() TxReceipt {
+	return TxReceipt{
+		GasLimit:        uint64(r.GasLimit),
+		GasPrice:        (*big.Int)(r.GasPrice),
+		GasUsed:         uint64(r.GasUsed),
+		Fee:             r.Fee,
+		Refund:          r.Refund,
+		ContractAddress: r.ContractAddress,
+		EvmError:        r.EvmError,
+	}
+}
+
+// Code returns the result code of the execution, CodeEVMError if the EVM execution failed.
+// The fee of the gas used is charged in either case.
+func (r TxReceipt) Code() result.ErrorCode {
+	if r.EvmError != "" {
+		return result.CodeEVMError
+	}
+	return result.CodeOK
+}
+
+func (r TxReceipt) MarshalJSON() ([]byte, error) {
+	return json.Marshal(NewTxReceiptJSON(r))
+}
+
+func (r *TxReceipt) UnmarshalJSON(data []byte) error {
+	var b TxReceiptJSON
+	if err := json.Unmarshal(data, &b); err != nil {
+		return err
+	}
+	*r = b.TxReceipt()
+	return nil
+}
+
+func (r *TxReceipt) String() string {
+	if r == nil {
+		return "nil-TxReceipt"
+	}
+	return fmt.Sprintf("TxReceipt{%v %v %v %v %v %v %v}", r.GasLimit, r.GasPrice, r.GasUsed,
+		r.Fee, r.Refund, r.ContractAddress.Hex(), r.EvmError)
+}
+
+// GasFee returns the fee of the given amount of gas at the gas price
+func GasFee(gasPrice *big.Int, gas uint64) Coins {
+	return Coins{
+		ThetaWei: big.NewInt(0),
+		GammaWei: new(big.Int).Mul(gasPrice, new(big.Int).SetUint64(gas)),
+	}
+}
@@ -0,0 +1,71 @@
+package types
+
+import (
+	"encoding/json"
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/common/result"
+	"github.com/thetatoken/ukulele/rlp"
+)
+
+func TestTxReceiptJSON(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	receipt := TxReceipt{
+		GasLimit:        math.MaxUint64,
+		GasPrice:        big.NewInt(1e9),
+		GasUsed:         21000,
+		Fee:             GasFee(big.NewInt(1e9), 21000),
+		Refund:          NewCoins(0, 1000),
+		ContractAddress: common.HexToAddress("0x5C3159dDD2fe0F9862bC7b7D60C1875fa8F81337"),
+		EvmError:        "out of gas",
+	}
+
+	s, err := json.Marshal(receipt)
+	require.Nil(err)
+
+	var d TxReceipt
+	err = json.Unmarshal(s, &d)
+	require.Nil(err)
+	assert.Equal(uint64(math.MaxUint64), d.GasLimit)
+	assert.Equal(0, receipt.GasPrice.Cmp(d.GasPrice))
+	assert.Equal(uint64(21000), d.GasUsed)
+	assert.True(receipt.Fee.IsEqual(d.Fee))
+	assert.True(receipt.Refund.IsEqual(d.Refund))
+	assert.Equal(receipt.ContractAddress, d.ContractAddress)
+	assert.Equal("out of gas", d.EvmError)
+	assert.Equal(result.CodeEVMError, d.Code())
+	assert.Contains(string(s), `"code":105001`)
+	assert.Equal(result.CodeOK, TxReceipt{}.Code())
+}
+
+func TestTxReceiptRLP(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	receipt := TxReceipt{
+		GasLimit: 50000,
+		GasPrice: big.NewInt(1e9),
+		GasUsed:  26000,
+		Fee:      GasFee(big.NewInt(1e9), 26000),
+		Refund:   GasFee(big.NewInt(1e9), 24000),
+	}
+
+	raw, err := rlp.EncodeToBytes(receipt)
+	require.Nil(err)
+
+	var d TxReceipt
+	err = rlp.DecodeBytes(raw, &d)
+	require.Nil(err)
+	assert.Equal(receipt.GasLimit, d.GasLimit)
+	assert.Equal(receipt.GasUsed, d.GasUsed)
+	assert.True(receipt.Fee.IsEqual(d.Fee))
+	assert.True(receipt.Refund.IsEqual(d.Refund))
+	assert.Equal("", d.EvmError)
+}
@@ -0,0 +1,57 @@
+package types
+
+import (
+	"github.com/pkg/errors"
+)
+
+// ErrTooManyReservedFunds is returned when an account already holds the maximum number
+// of reserved funds.
+var ErrTooManyReservedFunds = errors.New("Too many ReservedFunds")
+
+// ErrReserveDurationExceeded is returned when the remaining durations of the reserved
+// funds of an account would add up to more than the maximum.
+var ErrReserveDurationExceeded = errors.New("Total reserve duration exceeded")
+
+// ReservePolicy bounds the reserved funds an account holds at once, so that the
+// micropayment pool cannot grow the state without bounds. A zero limit is no limit.
+type ReservePolicy struct {
+	MaxFundsPerAccount uint   // Number of reserved funds an account holds at once, released or not
+	MaxTotalDuration   uint64 // Sum of the remaining durations of the reserved funds of an account, in blocks
+}
+
+// DefaultReservePolicy returns the policy that does not limit the reserved funds
+func DefaultReservePolicy() ReservePolicy {
+	return ReservePolicy{
+		MaxFundsPerAccount: 0,
+		MaxTotalDuration:   0,
+	}
+}
+
+// CheckReserve verifies that the account can reserve another fund for the duration
+func (p ReservePolicy) CheckReserve(acc *Account, duration uint64, currentBlockHeight uint64) error {
+	if p.MaxFundsPerAccount != 0 && uint(len(acc.ReservedFunds)) >= p.MaxFundsPerAccount {
+		return errors.Wrapf(ErrTooManyReservedFunds, "the account holds %d, the maximum", len(acc.ReservedFunds))
+	}
+	return p.checkTotalDuration(acc, duration, currentBlockHeight)
+}
+
+// CheckExtend verifies that the account can extend one of its reserved funds by the duration
+func (p ReservePolicy) CheckExtend(acc *Account, duration uint64, currentBlockHeight uint64) error {
+	return p.checkTotalDuration(acc, duration, currentBlockHeight)
+}
+
+func (p ReservePolicy) checkTotalDuration(acc *Account, duration uint64, currentBlockHeight uint64) error {
+	if p.MaxTotalDuration == 0 {
+		return nil
+	}
+	total := duration
+	for _, reservedFund := range acc.ReservedFunds {
+		if reservedFund.EndBlockHeight > currentBlockHeight {
+			total += reservedFund.EndBlockHeight - currentBlockHeight
+		}
+	}
+	if total > p.MaxTotalDuration {
+		return errors.Wrapf(ErrReserveDurationExceeded, "%d blocks, more than the maximum of %d", total, p.MaxTotalDuration)
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReservePolicy(t *testing.T) {
+	assert := assert.New(t)
+
+	acc := &Account{
+		ReservedFunds: []ReservedFund{
+			{EndBlockHeight: 1500, ReserveSequence: 1},
+			{EndBlockHeight: 900, ReserveSequence: 2}, // Ended, but not released yet
+		},
+	}
+
+	// No limit by default
+	assert.Nil(DefaultReservePolicy().CheckReserve(acc, MaximumFundReserveDuration, 1000))
+
+	policy := ReservePolicy{MaxFundsPerAccount: 2}
+	assert.Equal(ErrTooManyReservedFunds, errors.Cause(policy.CheckReserve(acc, 300, 1000)))
+	policy.MaxFundsPerAccount = 3
+	assert.Nil(policy.CheckReserve(acc, 300, 1000))
+
+	// Only the remaining durations count
+	policy.MaxTotalDuration = 1000
+	assert.Nil(policy.CheckReserve(acc, 500, 1000))
+	assert.Equal(ErrReserveDurationExceeded, errors.Cause(policy.CheckReserve(acc, 501, 1000)))
+	assert.Nil(policy.CheckExtend(acc, 500, 1000))
+	assert.Equal(ErrReserveDurationExceeded, errors.Cause(policy.CheckExtend(acc, 501, 1000)))
+	assert.Nil(policy.CheckExtend(acc, 1000, 1500))
+}
@@ -12,6 +12,47 @@ type TransferRecord struct {
 	ServicePayment ServicePaymentTx `json:"service_payment"`
 }
 
+// ErrSpendLimitExceeded is returned when a service payment would take the fund paid for
+// a resource over the spend limit of the resource.
+var ErrSpendLimitExceeded = errors.New("Resource spend limit exceeded")
+
+// ErrReservedFundNotFound is returned when an account has no reserved fund with the
+// reserve sequence of a transaction.
+var ErrReservedFundNotFound = errors.New("No matching ReservedFund")
+
+// ResourceSpendLimit caps the fund a reserve pays for a resource, so that the whole
+// reserve cannot be drained through a single resource.
+type ResourceSpendLimit struct {
+	ResourceID string `json:"resource_id"`
+	Limit      Coins  `json:"limit"`
+}
+
+// ValidateSpendLimits checks that the spend limits are valid, and are for distinct
+// resources among the given ones.
+func ValidateSpendLimits(resourceIDs []string, spendLimits []ResourceSpendLimit) error {
+	limited := map[string]bool{}
+	for _, spendLimit := range spendLimits {
+		found := false
+		for _, rid := range resourceIDs {
+			if rid == spendLimit.ResourceID {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return errors.Errorf("Spend limit for unknown resource %v", spendLimit.ResourceID)
+		}
+		if limited[spendLimit.ResourceID] {
+			return errors.Errorf("Duplicated spend limit for resource %v", spendLimit.ResourceID)
+		}
+		limited[spendLimit.ResourceID] = true
+		if !spendLimit.Limit.IsValid() || !spendLimit.Limit.IsPositive() {
+			return errors.Errorf("Invalid spend limit for resource %v: %v", spendLimit.ResourceID, spendLimit.Limit)
+		}
+	}
+	return nil
+}
+
 type ReservedFund struct {
 	Collateral      Coins
 	InitialFund     Coins
@@ -20,16 +61,21 @@ type ReservedFund struct {
 	EndBlockHeight  uint64
 	ReserveSequence uint64           // sequence number of the corresponding ReserveFundTx transaction
 	TransferRecords []TransferRecord // signed ServerPaymentTransactions
+
+	// Optional spend limits of the resources. As the tail of the encoding, a reserve
+	// without limits encodes as before they were introduced.
+	SpendLimits []ResourceSpendLimit `rlp:"tail"`
 }
 
 type ReservedFundJSON struct {
-	Collateral      Coins             `json:"collateral"`
-	InitialFund     Coins             `json:"initial_fund"`
-	UsedFund        Coins             `json:"used_fund"`
-	ResourceIDs     []string          `json:"resource_ids"` // List of resource ID
-	EndBlockHeight  common.JSONUint64 `json:"end_block_height"`
-	ReserveSequence common.JSONUint64 `json:"reserve_sequence"` // sequence number of the corresponding ReserveFundTx transaction
-	TransferRecords []TransferRecord  `json:"transfer_records"` // signed ServerPaymentTransactions
+	Collateral      Coins                `json:"collateral"`
+	InitialFund     Coins                `json:"initial_fund"`
+	UsedFund        Coins                `json:"used_fund"`
+	ResourceIDs     []string             `json:"resource_ids"` // List of resource ID
+	EndBlockHeight  common.JSONUint64    `json:"end_block_height"`
+	ReserveSequence common.JSONUint64    `json:"reserve_sequence"` // sequence number of the corresponding ReserveFundTx transaction
+	TransferRecords []TransferRecord     `json:"transfer_records"` // signed ServerPaymentTransactions
+	SpendLimits     []ResourceSpendLimit `json:"spend_limits,omitempty"`
 }
 
 func NewReservedFundJSON(resv ReservedFund) ReservedFundJSON {
@@ -41,6 +87,7 @@ func NewReservedFundJSON(resv ReservedFund) ReservedFundJSON {
 		EndBlockHeight:  common.JSONUint64(resv.EndBlockHeight),
 		ReserveSequence: common.JSONUint64(resv.ReserveSequence),
 		TransferRecords: resv.TransferRecords,
+		SpendLimits:     resv.SpendLimits,
 	}
 }
 
@@ -53,6 +100,7 @@ func (resv ReservedFundJSON) ReservedFund() ReservedFund {
 		EndBlockHeight:  uint64(resv.EndBlockHeight),
 		ReserveSequence: uint64(resv.ReserveSequence),
 		TransferRecords: resv.TransferRecords,
+		SpendLimits:     resv.SpendLimits,
 	}
 }
 
@@ -103,3 +151,26 @@ func (reservedFund *ReservedFund) HasResourceID(resourceID string) bool {
 	}
 	return false
 }
+
+// CheckSpendLimit verifies that transferring the given amount for the resource does not
+// exceed the spend limit of the resource, if any.
+func (reservedFund *ReservedFund) CheckSpendLimit(resourceID string, transferAmount Coins) error {
+	for _, spendLimit := range reservedFund.SpendLimits {
+		if spendLimit.ResourceID != resourceID {
+			continue
+		}
+
+		spent := NewCoins(0, 0)
+		for _, transferRecord := range reservedFund.TransferRecords {
+			if transferRecord.ServicePayment.ResourceID == resourceID {
+				spent = spent.Plus(transferRecord.ServicePayment.Source.Coins)
+			}
+		}
+		if !spendLimit.Limit.IsGTE(spent.Plus(transferAmount)) {
+			return errors.Wrapf(ErrSpendLimitExceeded, "resource %v has %v spent of the limit %v, cannot transfer %v",
+				resourceID, spent, spendLimit.Limit, transferAmount)
+		}
+		return nil
+	}
+	return nil
+}
@@ -5,8 +5,10 @@ import (
 	"math"
 	"testing"
 
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/thetatoken/ukulele/rlp"
 )
 
 func TestHasResourceID(t *testing.T) {
@@ -58,3 +60,67 @@ func TestReserveFundJSON(t *testing.T) {
 	require.Nil(err)
 	assert.Equal(uint64(math.MaxUint64), d.EndBlockHeight)
 }
+
+func TestValidateSpendLimits(t *testing.T) {
+	assert := assert.New(t)
+
+	resourceIDs := []string{"rid001", "rid002"}
+	limit := NewCoins(0, 100)
+
+	assert.Nil(ValidateSpendLimits(resourceIDs, nil))
+	assert.Nil(ValidateSpendLimits(resourceIDs, []ResourceSpendLimit{{"rid001", limit}, {"rid002", limit}}))
+	assert.NotNil(ValidateSpendLimits(resourceIDs, []ResourceSpendLimit{{"rid003", limit}}))
+	assert.NotNil(ValidateSpendLimits(resourceIDs, []ResourceSpendLimit{{"rid001", limit}, {"rid001", limit}}))
+	assert.NotNil(ValidateSpendLimits(resourceIDs, []ResourceSpendLimit{{"rid001", NewCoins(0, 0)}}))
+}
+
+func TestCheckSpendLimit(t *testing.T) {
+	assert := assert.New(t)
+
+	rf := ReservedFund{
+		ResourceIDs: []string{"rid001", "rid002"},
+		SpendLimits: []ResourceSpendLimit{{"rid001", NewCoins(0, 100)}},
+	}
+	rf.RecordTransfer(&ServicePaymentTx{Source: TxInput{Coins: NewCoins(0, 60)}, ResourceID: "rid001"})
+	rf.RecordTransfer(&ServicePaymentTx{Source: TxInput{Coins: NewCoins(0, 500)}, ResourceID: "rid002"})
+
+	assert.Nil(rf.CheckSpendLimit("rid001", NewCoins(0, 40)))
+	assert.Equal(ErrSpendLimitExceeded, errors.Cause(rf.CheckSpendLimit("rid001", NewCoins(0, 41))))
+	assert.Nil(rf.CheckSpendLimit("rid002", NewCoins(0, 1000))) // No limit
+}
+
+func TestReservedFundWithoutSpendLimitsRLP(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// The encoding of the reserves without spend limits is unchanged
+	type legacyReservedFund struct {
+		Collateral      Coins
+		InitialFund     Coins
+		UsedFund        Coins
+		ResourceIDs     []string
+		EndBlockHeight  uint64
+		ReserveSequence uint64
+		TransferRecords []TransferRecord
+	}
+	rf := ReservedFund{
+		Collateral:      NewCoins(0, 101),
+		InitialFund:     NewCoins(0, 100),
+		UsedFund:        NewCoins(0, 0),
+		ResourceIDs:     []string{"rid001"},
+		EndBlockHeight:  199,
+		ReserveSequence: 1,
+	}
+	b, err := rlp.EncodeToBytes(rf)
+	require.Nil(err)
+	legacy, err := rlp.EncodeToBytes(legacyReservedFund{rf.Collateral, rf.InitialFund, rf.UsedFund, rf.ResourceIDs, rf.EndBlockHeight, rf.ReserveSequence, nil})
+	require.Nil(err)
+	assert.Equal(legacy, b)
+
+	rf.SpendLimits = []ResourceSpendLimit{{"rid001", NewCoins(0, 50)}}
+	b, err = rlp.EncodeToBytes(rf)
+	require.Nil(err)
+	var d ReservedFund
+	require.Nil(rlp.DecodeBytes(b, &d))
+	assert.Equal(rf.SpendLimits, d.SpendLimits)
+}
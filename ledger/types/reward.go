@@ -0,0 +1,115 @@
+package types
+
+import (
+	"math/big"
+
+	"github.com/thetatoken/ukulele/common"
+)
+
+// ** Reward: block issuance and transaction fee distribution to the validators **
+//
+
+// maxHalvings bounds the number of halvings, after which the block reward is zero
+const maxHalvings = 256
+
+// RewardPolicy determines the rewards of the validators paid by the coinbase
+// transaction of each block. The fees of the transactions are collected in a fee
+// pool, which the coinbase transaction of the next block distributes.
+type RewardPolicy struct {
+	BlockReward        *big.Int // GammaWei issued for each block before any halving, shared by the validators in proportion to their stake
+	HalvingInterval    uint64   // Number of blocks after which the block reward halves, 0 for a constant reward
+	FeeBurnPercent     uint     // Percentage of the transaction fees that is burned, the rest is added to the fee pool
+	ProposerFeePercent uint     // Percentage of the fee pool rewarded to the proposer, the rest is shared by the validators in proportion to their stake
+}
+
+// DefaultRewardPolicy returns the policy that issues no block reward and burns all
+// the transaction fees.
+func DefaultRewardPolicy() RewardPolicy {
+	return RewardPolicy{
+		BlockReward:        big.NewInt(0),
+		HalvingInterval:    0,
+		FeeBurnPercent:     100,
+		ProposerFeePercent: 100,
+	}
+}
+
+// BlockRewardAt returns the GammaWei issued for the block at the given height.
+func (p RewardPolicy) BlockRewardAt(height uint64) *big.Int {
+	if p.BlockReward == nil {
+		return big.NewInt(0)
+	}
+	if p.HalvingInterval == 0 {
+		return new(big.Int).Set(p.BlockReward)
+	}
+	halvings := height / p.HalvingInterval
+	if halvings >= maxHalvings {
+		return big.NewInt(0)
+	}
+	return new(big.Int).Rsh(p.BlockReward, uint(halvings))
+}
+
+// IssuanceBetween returns the GammaWei issued for the blocks at the heights from
+// fromHeight, inclusive, to toHeight, exclusive.
+func (p RewardPolicy) IssuanceBetween(fromHeight uint64, toHeight uint64) *big.Int {
+	issuance := big.NewInt(0)
+	for height := fromHeight; height < toHeight; {
+		reward := p.BlockRewardAt(height)
+		if reward.Sign() == 0 {
+			break // The block reward never increases
+		}
+		end := toHeight
+		if p.HalvingInterval != 0 {
+			next := (height/p.HalvingInterval + 1) * p.HalvingInterval
+			if next > height && next < end {
+				end = next
+			}
+		}
+		issuance.Add(issuance, reward.Mul(reward, new(big.Int).SetUint64(end-height)))
+		height = end
+	}
+	return issuance
+}
+
+// CollectedFee returns the part of a transaction fee that is added to the fee pool.
+func (p RewardPolicy) CollectedFee(fee Coins) Coins {
+	return fee.CalculatePercentage(100 - p.FeeBurnPercent)
+}
+
+// Rewards returns the rewards of the validators for a block at the given height,
+// which distributes the fee pool. The validators share the block reward and the part
+// of the fee pool not rewarded to the proposer in proportion to their stake, and the
+// rounding remainder goes to the proposer.
+func (p RewardPolicy) Rewards(height uint64, feePool Coins, proposer common.Address, stakes map[common.Address]uint64) map[common.Address]Coins {
+	rewards := map[common.Address]Coins{}
+	for address := range stakes {
+		rewards[address] = NewCoins(0, 0)
+	}
+
+	feePool = feePool.NoNil()
+	proposerFee := feePool.CalculatePercentage(p.ProposerFeePercent)
+	shared := feePool.Minus(proposerFee).Plus(Coins{ThetaWei: big.NewInt(0), GammaWei: p.BlockRewardAt(height)})
+
+	totalStake := new(big.Int)
+	for _, stake := range stakes {
+		totalStake.Add(totalStake, new(big.Int).SetUint64(stake))
+	}
+	distributed := NewCoins(0, 0)
+	if totalStake.Sign() > 0 {
+		for address, stake := range stakes {
+			s := new(big.Int).SetUint64(stake)
+			share := Coins{
+				ThetaWei: new(big.Int).Div(new(big.Int).Mul(shared.ThetaWei, s), totalStake),
+				GammaWei: new(big.Int).Div(new(big.Int).Mul(shared.GammaWei, s), totalStake),
+			}
+			rewards[address] = rewards[address].Plus(share)
+			distributed = distributed.Plus(share)
+		}
+	}
+
+	proposerReward, ok := rewards[proposer]
+	if !ok {
+		proposerReward = NewCoins(0, 0)
+	}
+	rewards[proposer] = proposerReward.Plus(proposerFee).Plus(shared.Minus(distributed))
+	return rewards
+}
@@ -0,0 +1,70 @@
+package types
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thetatoken/ukulele/common"
+)
+
+func TestBlockRewardAt(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal(int64(0), DefaultRewardPolicy().BlockRewardAt(100).Int64())
+
+	policy := RewardPolicy{BlockReward: big.NewInt(1000)}
+	assert.Equal(int64(1000), policy.BlockRewardAt(0).Int64())
+	assert.Equal(int64(1000), policy.BlockRewardAt(1e9).Int64())
+
+	policy.HalvingInterval = 100
+	assert.Equal(int64(1000), policy.BlockRewardAt(99).Int64())
+	assert.Equal(int64(500), policy.BlockRewardAt(100).Int64())
+	assert.Equal(int64(250), policy.BlockRewardAt(250).Int64())
+	assert.Equal(int64(0), policy.BlockRewardAt(100*maxHalvings).Int64())
+}
+
+func TestIssuanceBetween(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal(int64(0), DefaultRewardPolicy().IssuanceBetween(0, 100).Int64())
+
+	policy := RewardPolicy{BlockReward: big.NewInt(1000)}
+	assert.Equal(int64(0), policy.IssuanceBetween(10, 10).Int64())
+	assert.Equal(int64(100000), policy.IssuanceBetween(10, 110).Int64())
+
+	policy.HalvingInterval = 100
+	assert.Equal(int64(90*1000+10*500), policy.IssuanceBetween(10, 110).Int64())
+	assert.Equal(int64(100*1000+100*500+50*250), policy.IssuanceBetween(0, 250).Int64())
+
+	expected := big.NewInt(0)
+	for height := uint64(0); height < 100*12; height++ {
+		expected.Add(expected, policy.BlockRewardAt(height))
+	}
+	assert.Equal(expected, policy.IssuanceBetween(0, 1e12))
+}
+
+func TestRewards(t *testing.T) {
+	assert := assert.New(t)
+
+	proposer := common.HexToAddress("0x1")
+	validator := common.HexToAddress("0x2")
+	stakes := map[common.Address]uint64{proposer: 1, validator: 2}
+	feePool := NewCoins(30, 1000)
+
+	// All the fees are burned by default, so the validators get no reward
+	rewards := DefaultRewardPolicy().Rewards(0, NewCoins(0, 0), proposer, stakes)
+	assert.Equal(2, len(rewards))
+	assert.True(rewards[proposer].IsZero())
+	assert.True(rewards[validator].IsZero())
+
+	// The proposer gets its cut of the fee pool, and the rest of the pool and the
+	// block reward are shared by stake, the rounding remainder going to the proposer
+	policy := RewardPolicy{BlockReward: big.NewInt(201), ProposerFeePercent: 10}
+	rewards = policy.Rewards(0, feePool, proposer, stakes)
+	assert.Equal(NewCoins(3+9, 100+367), rewards[proposer])
+	assert.Equal(NewCoins(18, 734), rewards[validator])
+	assert.Equal(feePool.Plus(NewCoins(0, 201)), rewards[proposer].Plus(rewards[validator]))
+
+	assert.Equal(NewCoins(15, 500), RewardPolicy{FeeBurnPercent: 50}.CollectedFee(feePool))
+}
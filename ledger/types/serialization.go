@@ -32,9 +32,34 @@ const (
 	TxSplitRule
 	TxUpdateValidators
 	TxSmartContract
+	TxDepositStake
+	TxWithdrawStake
+	TxExtendReserve
+	TxBatchServicePayment
+	TxSetGuardians
+	TxRecovery
+	TxTimelockedSend
+	TxCreateToken
+	TxParameterUpdate
+	TxProposal
+	TxVote
+	TxUnjail
 )
 
+// TxFromBytes decodes a transaction. Transactions carrying malleable signatures are
+// rejected, see ValidateTxSignatures.
 func TxFromBytes(raw []byte) (Tx, error) {
+	tx, err := decodeTx(raw)
+	if err != nil {
+		return nil, err
+	}
+	if err := ValidateTxSignatures(tx); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+func decodeTx(raw []byte) (Tx, error) {
 	var txType TxType
 	buff := bytes.NewBuffer(raw)
 	err := rlp.Decode(buff, &txType)
@@ -44,6 +69,9 @@ func TxFromBytes(raw []byte) (Tx, error) {
 	if txType == TxCoinbase {
 		data := &CoinbaseTx{}
 		err = rlp.Decode(buff, data)
+		if len(data.Epoch) == 0 {
+			data.Epoch = nil // the tail decodes as an empty slice
+		}
 		return data, err
 	} else if txType == TxSlash {
 		data := &SlashTx{}
@@ -77,6 +105,54 @@ func TxFromBytes(raw []byte) (Tx, error) {
 		data := &SmartContractTx{}
 		err = rlp.Decode(buff, data)
 		return data, err
+	} else if txType == TxDepositStake {
+		data := &DepositStakeTx{}
+		err = rlp.Decode(buff, data)
+		return data, err
+	} else if txType == TxWithdrawStake {
+		data := &WithdrawStakeTx{}
+		err = rlp.Decode(buff, data)
+		return data, err
+	} else if txType == TxExtendReserve {
+		data := &ExtendReserveTx{}
+		err = rlp.Decode(buff, data)
+		return data, err
+	} else if txType == TxBatchServicePayment {
+		data := &BatchServicePaymentTx{}
+		err = rlp.Decode(buff, data)
+		return data, err
+	} else if txType == TxSetGuardians {
+		data := &SetGuardiansTx{}
+		err = rlp.Decode(buff, data)
+		return data, err
+	} else if txType == TxRecovery {
+		data := &RecoveryTx{}
+		err = rlp.Decode(buff, data)
+		return data, err
+	} else if txType == TxTimelockedSend {
+		data := &TimelockedSendTx{}
+		err = rlp.Decode(buff, data)
+		return data, err
+	} else if txType == TxCreateToken {
+		data := &CreateTokenTx{}
+		err = rlp.Decode(buff, data)
+		return data, err
+	} else if txType == TxParameterUpdate {
+		data := &ParameterUpdateTx{}
+		err = rlp.Decode(buff, data)
+		return data, err
+	} else if txType == TxProposal {
+		data := &ProposalTx{}
+		err = rlp.Decode(buff, data)
+		return data, err
+	} else if txType == TxVote {
+		data := &VoteTx{}
+		err = rlp.Decode(buff, data)
+		return data, err
+	} else if txType == TxUnjail {
+		data := &UnjailTx{}
+		err = rlp.Decode(buff, data)
+		return data, err
 	} else {
 		return nil, fmt.Errorf("Unknown TX type: %v", txType)
 	}
@@ -104,6 +180,30 @@ func TxToBytes(t Tx) ([]byte, error) {
 		txType = TxUpdateValidators
 	case *SmartContractTx:
 		txType = TxSmartContract
+	case *DepositStakeTx:
+		txType = TxDepositStake
+	case *WithdrawStakeTx:
+		txType = TxWithdrawStake
+	case *ExtendReserveTx:
+		txType = TxExtendReserve
+	case *BatchServicePaymentTx:
+		txType = TxBatchServicePayment
+	case *SetGuardiansTx:
+		txType = TxSetGuardians
+	case *RecoveryTx:
+		txType = TxRecovery
+	case *TimelockedSendTx:
+		txType = TxTimelockedSend
+	case *CreateTokenTx:
+		txType = TxCreateToken
+	case *ParameterUpdateTx:
+		txType = TxParameterUpdate
+	case *ProposalTx:
+		txType = TxProposal
+	case *VoteTx:
+		txType = TxVote
+	case *UnjailTx:
+		txType = TxUnjail
 	default:
 		return nil, errors.New("Unsupported message type")
 	}
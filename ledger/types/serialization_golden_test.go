@@ -0,0 +1,337 @@
+package types
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"math/big"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/core"
+	"github.com/thetatoken/ukulele/crypto"
+	"github.com/thetatoken/ukulele/rlp"
+)
+
+// The golden vectors are the canonical encodings of fixed transactions and accounts. A
+// change of the wire format fails TestGoldenVectors; an intended change is recorded with
+//
+//	go test ./ledger/types -run TestGoldenVectors -update
+var updateGolden = flag.Bool("update", false, "update the golden vectors in testdata")
+
+const goldenVectorsFile = "testdata/golden_vectors.json"
+
+// The generator point of secp256k1, a valid public key.
+const goldenPubKey = "0479be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798483ada7726a3c4655da4fbfc0e1108a8fd17b448a68554199c47d08ffb10d4b8"
+
+func goldenSignature(b byte) *crypto.Signature {
+	sig, _ := crypto.SignatureFromBytes(bytes.Repeat([]byte{b}, 65))
+	return sig
+}
+
+func goldenInput(name string, coins Coins, sequence uint64, sigByte byte) TxInput {
+	return TxInput{
+		Address:   getTestAddress(name),
+		Coins:     coins,
+		Sequence:  sequence,
+		Signature: goldenSignature(sigByte),
+	}
+}
+
+func goldenServicePayment() ServicePaymentTx {
+	return ServicePaymentTx{
+		Fee:             NewCoins(0, 1000000000000),
+		Source:          goldenInput("source", NewCoins(0, 5000), 1, 0x11),
+		Target:          goldenInput("target", NewCoins(0, 0), 2, 0x12),
+		PaymentSequence: 3,
+		ReserveSequence: 4,
+		ResourceID:      "rid001",
+	}
+}
+
+// goldenTxs returns the transactions of the golden vectors, at least one of each type.
+func goldenTxs() map[string]Tx {
+	fee := NewCoins(0, 1000000000000)
+	servicePayment := goldenServicePayment()
+	pubKey, err := crypto.PublicKeyFromBytes(common.Hex2Bytes(goldenPubKey))
+	if err != nil {
+		panic(err)
+	}
+	validator := core.NewValidator(pubKey.ToBytes(), 100000000)
+	parameters := ChainParameters{
+		MinimumTransactionFeeGammaWei: 1000000000000,
+		MaxBlockGas:                   20000000,
+		SlashCollateralPercent:        50,
+		SlashReporterRewardPercent:    40,
+		SlashJailDuration:             10,
+		BlockRewardGammaWei:           big.NewInt(1000000000000000000),
+		RewardHalvingInterval:         1000000,
+		FeeBurnPercent:                50,
+		ProposerFeePercent:            20,
+		MaxReservedFundsPerAccount:    16,
+		MaxTotalReserveDuration:       100000,
+	}
+
+	return map[string]Tx{
+		"CoinbaseTx": &CoinbaseTx{
+			Proposer:    goldenInput("proposer", NewCoins(0, 0), 1, 0x01),
+			Outputs:     []TxOutput{{Address: getTestAddress("validator"), Coins: NewCoins(333, 0)}},
+			BlockHeight: 10,
+		},
+		"CoinbaseTxWithEpoch": &CoinbaseTx{
+			Proposer:    goldenInput("proposer", NewCoins(0, 0), 1, 0x01),
+			Outputs:     []TxOutput{{Address: getTestAddress("validator"), Coins: NewCoins(333, 0)}},
+			BlockHeight: 10,
+			Epoch:       []uint64{12},
+		},
+		"SlashTx": &SlashTx{
+			Proposer:        goldenInput("proposer", NewCoins(0, 0), 1, 0x02),
+			SlashedAddress:  getTestAddress("slashed"),
+			ReserveSequence: 5,
+			SlashProof:      common.Bytes("proof"),
+		},
+		"SendTx": &SendTx{
+			Fee:     fee,
+			Inputs:  []TxInput{goldenInput("alice", NewCoins(10, 1000000000100), 7, 0x03)},
+			Outputs: []TxOutput{{Address: getTestAddress("bob"), Coins: NewCoins(10, 100)}},
+		},
+		"SendTxWithTokens": &SendTx{
+			Fee: fee,
+			Inputs: []TxInput{goldenInput("alice", Coins{
+				ThetaWei: big.NewInt(0),
+				GammaWei: big.NewInt(1000000000000),
+				Tokens:   []TokenCoin{{Symbol: "TKN", Amount: big.NewInt(25)}},
+			}, 8, 0x04)},
+			Outputs: []TxOutput{{Address: getTestAddress("bob"), Coins: Coins{
+				ThetaWei: big.NewInt(0),
+				GammaWei: big.NewInt(0),
+				Tokens:   []TokenCoin{{Symbol: "TKN", Amount: big.NewInt(25)}},
+			}}},
+		},
+		"TimelockedSendTx": &TimelockedSendTx{
+			Fee:          fee,
+			Inputs:       []TxInput{goldenInput("alice", NewCoins(10, 1000000000000), 9, 0x05)},
+			Outputs:      []TxOutput{{Address: getTestAddress("bob"), Coins: NewCoins(10, 0)}},
+			UnlockHeight: 1000,
+		},
+		"ReserveFundTx": &ReserveFundTx{
+			Fee:         fee,
+			Source:      goldenInput("source", NewCoins(0, 1000), 1, 0x06),
+			Collateral:  NewCoins(0, 1001),
+			ResourceIDs: []string{"rid001", "rid002"},
+			Duration:    300,
+		},
+		"ReserveFundTxWithSpendLimits": &ReserveFundTx{
+			Fee:         fee,
+			Source:      goldenInput("source", NewCoins(0, 1000), 1, 0x06),
+			Collateral:  NewCoins(0, 1001),
+			ResourceIDs: []string{"rid001", "rid002"},
+			Duration:    300,
+			SpendLimits: []ResourceSpendLimit{{ResourceID: "rid001", Limit: NewCoins(0, 400)}},
+		},
+		"ReleaseFundTx": &ReleaseFundTx{
+			Fee:             fee,
+			Source:          goldenInput("source", NewCoins(0, 0), 2, 0x07),
+			ReserveSequence: 1,
+		},
+		"ExtendReserveTx": &ExtendReserveTx{
+			Fee:             fee,
+			Source:          goldenInput("source", NewCoins(0, 500), 3, 0x08),
+			Collateral:      NewCoins(0, 501),
+			Duration:        100,
+			ReserveSequence: 1,
+		},
+		"ServicePaymentTx": &servicePayment,
+		"BatchServicePaymentTx": &BatchServicePaymentTx{
+			Fee:      fee,
+			Target:   goldenInput("target", NewCoins(0, 0), 5, 0x09),
+			Payments: []ServicePaymentTx{goldenServicePayment()},
+		},
+		"SplitRuleTx": &SplitRuleTx{
+			Fee:        fee,
+			ResourceID: "rid001",
+			Initiator:  goldenInput("initiator", NewCoins(0, 0), 1, 0x0a),
+			Splits:     []Split{{Address: getTestAddress("creator"), Percentage: 30}},
+			Duration:   1000,
+		},
+		"SplitRuleTxWithPlatformSplits": &SplitRuleTx{
+			Fee:            fee,
+			ResourceID:     "rid001",
+			Initiator:      goldenInput("initiator", NewCoins(0, 0), 1, 0x0a),
+			Splits:         []Split{{Address: getTestAddress("creator"), Percentage: 30}},
+			Duration:       1000,
+			PlatformSplits: []Split{{Address: getTestAddress("platform"), Percentage: 5}},
+		},
+		"UpdateValidatorsTx": &UpdateValidatorsTx{
+			Fee:        fee,
+			Validators: []*core.Validator{&validator},
+			Proposer:   goldenInput("proposer", NewCoins(0, 0), 2, 0x0b),
+		},
+		"SmartContractTx": &SmartContractTx{
+			From:     goldenInput("caller", NewCoins(0, 0), 4, 0x0c),
+			To:       TxOutput{Address: getTestAddress("contract"), Coins: NewCoins(0, 0)},
+			GasLimit: 50000,
+			GasPrice: big.NewInt(1000000000),
+			Data:     common.Hex2Bytes("a9059cbb"),
+		},
+		"DepositStakeTx": &DepositStakeTx{
+			Fee:    fee,
+			Source: goldenInput("staker", NewCoins(100000000, 0), 2, 0x0d),
+			Holder: TxOutput{Address: getTestAddress("validator"), Coins: NewCoins(0, 0)},
+		},
+		"WithdrawStakeTx": &WithdrawStakeTx{
+			Fee:    fee,
+			Source: goldenInput("staker", NewCoins(0, 0), 3, 0x0e),
+			Holder: TxOutput{Address: getTestAddress("validator"), Coins: NewCoins(0, 0)},
+		},
+		"SetGuardiansTx": &SetGuardiansTx{
+			Fee:       fee,
+			Account:   goldenInput("alice", NewCoins(0, 0), 10, 0x0f),
+			Guardians: []common.Address{getTestAddress("guardian1"), getTestAddress("guardian2")},
+			Threshold: 2,
+			Timelock:  500,
+		},
+		"RecoveryTx": &RecoveryTx{
+			Fee:       fee,
+			Account:   TxInput{Address: getTestAddress("alice"), Coins: NewCoins(0, 0)},
+			NewSigner: getTestAddress("newsigner"),
+			Guardians: []TxInput{
+				goldenInput("guardian1", NewCoins(0, 1000000000000), 1, 0x10),
+				goldenInput("guardian2", NewCoins(0, 0), 1, 0x13),
+			},
+		},
+		"CreateTokenTx": &CreateTokenTx{
+			Fee:       fee,
+			Issuer:    goldenInput("issuer", NewCoins(0, 0), 1, 0x14),
+			Symbol:    "TKN",
+			Decimals:  18,
+			MaxSupply: new(big.Int).Exp(big.NewInt(10), big.NewInt(27), nil),
+		},
+		"ParameterUpdateTx": &ParameterUpdateTx{
+			Fee:        fee,
+			Proposer:   goldenInput("validator1", NewCoins(0, 0), 3, 0x15),
+			Height:     20000,
+			Parameters: parameters,
+			Approvers:  []TxInput{goldenInput("validator2", NewCoins(0, 0), 0, 0x16)},
+		},
+		"ProposalTx": &ProposalTx{
+			Fee:          fee,
+			Proposer:     goldenInput("staker", NewCoins(0, 0), 4, 0x17),
+			Change:       ProposalChange{Kind: ProposalParameterChange, Parameters: parameters},
+			Description:  "Double the block gas",
+			VotingPeriod: 1000,
+		},
+		"VoteTx": &VoteTx{
+			Fee:        fee,
+			Voter:      goldenInput("staker", NewCoins(0, 0), 5, 0x18),
+			ProposalID: 1,
+			Approve:    true,
+		},
+		"UnjailTx": &UnjailTx{
+			Fee:       fee,
+			Validator: goldenInput("validator", NewCoins(0, 0), 6, 0x19),
+		},
+	}
+}
+
+// goldenAccount returns the account of the golden vectors, with all its optional state.
+func goldenAccount() *Account {
+	return &Account{
+		Address:  getTestAddress("alice"),
+		Sequence: 12,
+		Balance:  NewCoins(1000, 2000000000000),
+		ReservedFunds: []ReservedFund{{
+			Collateral:      NewCoins(0, 1001),
+			InitialFund:     NewCoins(0, 1000),
+			UsedFund:        NewCoins(0, 100),
+			ResourceIDs:     []string{"rid001"},
+			EndBlockHeight:  400,
+			ReserveSequence: 1,
+			TransferRecords: []TransferRecord{{ServicePayment: goldenServicePayment()}},
+		}},
+		LastUpdatedBlockHeight: 99,
+		Root:                   common.HexToHash("0x01"),
+		CodeHash:               common.HexToHash("0x02"),
+		Extension: []AccountExtension{{
+			Guardianship: []Guardianship{{
+				Guardians: []common.Address{getTestAddress("guardian1"), getTestAddress("guardian2")},
+				Threshold: 2,
+				Timelock:  500,
+			}},
+			LockedCoins: []LockedCoins{{Coins: NewCoins(10, 0), UnlockHeight: 1000}},
+		}},
+	}
+}
+
+func TestGoldenVectors(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	vectors := map[string]string{}
+	types := map[TxType]bool{}
+	for name, tx := range goldenTxs() {
+		raw, err := TxToBytes(tx)
+		require.Nil(err, name)
+		vectors[name] = hex.EncodeToString(raw)
+
+		var txType TxType
+		require.Nil(rlp.Decode(bytes.NewReader(raw), &txType), name)
+		types[txType] = true
+
+		// The encoding is canonical: the decoded transaction encodes the same
+		decoded, err := decodeTx(raw)
+		require.Nil(err, name)
+		reencoded, err := TxToBytes(decoded)
+		require.Nil(err, name)
+		assert.Equal(raw, reencoded, name)
+	}
+	for txType := TxCoinbase; txType <= TxUnjail; txType++ {
+		assert.True(types[txType], "No golden vector for transaction type %v", txType)
+	}
+
+	raw, err := rlp.EncodeToBytes(goldenAccount())
+	require.Nil(err)
+	vectors["Account"] = hex.EncodeToString(raw)
+	account := &Account{}
+	require.Nil(rlp.DecodeBytes(raw, account))
+	reencoded, err := rlp.EncodeToBytes(account)
+	require.Nil(err)
+	assert.Equal(raw, reencoded)
+
+	checkGoldenVectors(t, goldenVectorsFile, vectors)
+}
+
+// checkGoldenVectors compares the hex encodings by name with the ones of the file, or
+// writes them to the file with -update.
+func checkGoldenVectors(t *testing.T, file string, vectors map[string]string) {
+	if *updateGolden {
+		out, err := json.MarshalIndent(vectors, "", "  ")
+		require.Nil(t, err)
+		require.Nil(t, ioutil.WriteFile(file, append(out, '\n'), 0644))
+		return
+	}
+
+	in, err := ioutil.ReadFile(file)
+	require.Nil(t, err)
+	golden := map[string]string{}
+	require.Nil(t, json.Unmarshal(in, &golden))
+
+	names := []string{}
+	for name := range vectors {
+		names = append(names, name)
+	}
+	for name := range golden {
+		if _, ok := vectors[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		assert.Equal(t, golden[name], vectors[name], "The encoding of %v changed; run the test with -update if intended", name)
+	}
+}
@@ -1,7 +1,12 @@
 package types
 
 import (
+	"bytes"
+	"math/big"
+	"math/rand"
+	"reflect"
 	"testing"
+	"testing/quick"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -162,3 +167,168 @@ func getTestAddress(addr string) common.Address {
 	copy(address[:], addr)
 	return address
 }
+
+func TestTxFromBytesRejectsMalleableSignature(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	chainID := "test_chain_id"
+	va1PrivAcc := PrivAccountFromSecret("validator1")
+	va2PrivAcc := PrivAccountFromSecret("validator2")
+	tx := &SendTx{
+		Fee:     NewCoins(0, 1000000000000),
+		Inputs:  []TxInput{NewTxInput(va1PrivAcc.Address, NewCoins(10, 1000000000000), 1)},
+		Outputs: []TxOutput{{Address: va2PrivAcc.Address, Coins: NewCoins(10, 0)}},
+	}
+	tx.Inputs[0].Signature = va1PrivAcc.Sign(tx.SignBytes(chainID))
+	b, err := TxToBytes(tx)
+	require.Nil(err)
+	_, err = TxFromBytes(b)
+	assert.Nil(err)
+
+	// (R, N - S) with the flipped recovery ID recovers the same signer
+	sigBytes := tx.Inputs[0].Signature.ToBytes()
+	n, _ := new(big.Int).SetString("fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364141", 16)
+	s := new(big.Int).SetBytes(sigBytes[32:64])
+	malleated := append(common.Bytes{}, sigBytes[:32]...)
+	malleated = append(malleated, common.LeftPadBytes(new(big.Int).Sub(n, s).Bytes(), 32)...)
+	malleated = append(malleated, sigBytes[64]^1)
+	tx.Inputs[0].Signature, _ = crypto.SignatureFromBytes(malleated)
+	assert.False(tx.Inputs[0].Signature.Verify(tx.SignBytes(chainID), va1PrivAcc.Address))
+
+	b, err = TxToBytes(tx)
+	require.Nil(err)
+	_, err = TxFromBytes(b)
+	assert.NotNil(err)
+
+	// Including inside a multisig signature
+	account, err := NewMultisigAccount(1, []*crypto.PublicKey{va1PrivAcc.PrivKey.PublicKey(), va2PrivAcc.PrivKey.PublicKey()})
+	require.Nil(err)
+	ms := NewMultisigSignature(account)
+	ms.Signatures[account.KeyIndex(va1PrivAcc.Address)] = tx.Inputs[0].Signature
+	tx.Inputs[0].Signature = ms.ToSignature()
+	b, err = TxToBytes(tx)
+	require.Nil(err)
+	_, err = TxFromBytes(b)
+	assert.NotNil(err)
+}
+
+// randTx is a transaction of a random type with random fields, generated for the
+// property tests.
+type randTx struct {
+	Tx
+}
+
+// Generate implements testing/quick.Generator.
+func (randTx) Generate(rand *rand.Rand, size int) reflect.Value {
+	coins := func() Coins {
+		return randCoins{}.Generate(rand, size).Interface().(randCoins).Coins
+	}
+	address := func() common.Address {
+		var addr common.Address
+		rand.Read(addr[:])
+		return addr
+	}
+	input := func() TxInput {
+		in := TxInput{Address: address(), Coins: coins(), Sequence: rand.Uint64()}
+		if rand.Intn(4) > 0 {
+			sig := make(common.Bytes, 65)
+			rand.Read(sig)
+			in.Signature, _ = crypto.SignatureFromBytes(sig)
+		}
+		return in
+	}
+	output := func() TxOutput {
+		return TxOutput{Address: address(), Coins: coins()}
+	}
+	inputs := func() []TxInput {
+		ins := make([]TxInput, 1+rand.Intn(3))
+		for i := range ins {
+			ins[i] = input()
+		}
+		return ins
+	}
+	outputs := func() []TxOutput {
+		outs := make([]TxOutput, 1+rand.Intn(3))
+		for i := range outs {
+			outs[i] = output()
+		}
+		return outs
+	}
+	resourceID := func() string {
+		b := make([]byte, rand.Intn(20))
+		rand.Read(b)
+		return string(b)
+	}
+	servicePayment := func() ServicePaymentTx {
+		return ServicePaymentTx{
+			Fee:             coins(),
+			Source:          input(),
+			Target:          input(),
+			PaymentSequence: rand.Uint64(),
+			ReserveSequence: rand.Uint64(),
+			ResourceID:      resourceID(),
+		}
+	}
+
+	var tx Tx
+	switch rand.Intn(7) {
+	case 0:
+		coinbaseTx := &CoinbaseTx{Proposer: input(), Outputs: outputs(), BlockHeight: rand.Uint64()}
+		if rand.Intn(2) == 0 {
+			coinbaseTx.Epoch = []uint64{rand.Uint64()}
+		}
+		tx = coinbaseTx
+	case 1:
+		tx = &SendTx{Fee: coins(), Inputs: inputs(), Outputs: outputs()}
+	case 2:
+		tx = &TimelockedSendTx{Fee: coins(), Inputs: inputs(), Outputs: outputs(), UnlockHeight: rand.Uint64()}
+	case 3:
+		reserveFundTx := &ReserveFundTx{
+			Fee:         coins(),
+			Source:      input(),
+			Collateral:  coins(),
+			ResourceIDs: []string{resourceID(), resourceID()},
+			Duration:    rand.Uint64(),
+		}
+		if rand.Intn(2) == 0 {
+			reserveFundTx.SpendLimits = []ResourceSpendLimit{{ResourceID: reserveFundTx.ResourceIDs[0], Limit: coins()}}
+		}
+		tx = reserveFundTx
+	case 4:
+		servicePaymentTx := servicePayment()
+		tx = &servicePaymentTx
+	case 5:
+		tx = &BatchServicePaymentTx{Fee: coins(), Target: input(), Payments: []ServicePaymentTx{servicePayment(), servicePayment()}}
+	default:
+		data := make(common.Bytes, rand.Intn(100))
+		rand.Read(data)
+		tx = &SmartContractTx{
+			From:     input(),
+			To:       output(),
+			GasLimit: rand.Uint64(),
+			GasPrice: randAmount(rand),
+			Data:     data,
+		}
+	}
+	return reflect.ValueOf(randTx{tx})
+}
+
+func TestTxRLPRoundTrip(t *testing.T) {
+	roundTrip := func(tx randTx) bool {
+		raw, err := TxToBytes(tx.Tx)
+		if err != nil {
+			return false
+		}
+		decoded, err := decodeTx(raw)
+		if err != nil {
+			return false
+		}
+		reencoded, err := TxToBytes(decoded)
+		return err == nil && reflect.TypeOf(decoded) == reflect.TypeOf(tx.Tx) && bytes.Equal(raw, reencoded) &&
+			TxID(chainID, decoded) == TxID(chainID, tx.Tx)
+	}
+	if err := quick.Check(roundTrip, nil); err != nil {
+		t.Error(err)
+	}
+}
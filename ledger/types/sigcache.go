@@ -0,0 +1,79 @@
+package types
+
+import (
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/common/lru"
+	"github.com/thetatoken/ukulele/crypto"
+)
+
+//
+// SignatureCache remembers the signers recovered from the signatures of the transactions
+// screened by the mempool, so that the execution of the blocks including them does not
+// verify the signatures again. The entries are keyed by the hash of the raw transaction,
+// which covers the signatures and the signed fields, so a cached signer is only applied to
+// the very signature it was recovered from. The cache is bounded, and safe for concurrent
+// use.
+//
+type SignatureCache struct {
+	signers *lru.Cache // tx hash -> []common.Address, in the order of forEachSignerSignature
+}
+
+// NewSignatureCache creates a cache holding the signers of up to size transactions
+func NewSignatureCache(size int) *SignatureCache {
+	return &SignatureCache{
+		signers: lru.New("ledger/sigcache", size),
+	}
+}
+
+// Add caches the signers recovered from the signatures of the transaction. The
+// transaction is only cached if all of its signatures have been verified.
+func (sc *SignatureCache) Add(chainID string, txHash common.Hash, tx Tx) {
+	signers := []common.Address{}
+	verified := true
+	forEachSignerSignature(chainID, tx, func(sig *crypto.Signature, signBytes common.Bytes, addr common.Address) {
+		if !verified {
+			return
+		}
+		if sig == nil || sig.IsEmpty() {
+			verified = false
+			return
+		}
+		signer, ok := sig.RecoveredSigner(signBytes)
+		if !ok || signer != addr {
+			verified = false
+			return
+		}
+		signers = append(signers, signer)
+	})
+	if verified {
+		sc.signers.Add(txHash, signers)
+	}
+}
+
+// Load sets the signers cached for the transaction on its signatures, and returns false
+// if the transaction is not cached.
+func (sc *SignatureCache) Load(chainID string, txHash common.Hash, tx Tx) bool {
+	cached, ok := sc.signers.Get(txHash)
+	if !ok {
+		return false
+	}
+	signers := cached.([]common.Address)
+	i := 0
+	forEachSignerSignature(chainID, tx, func(sig *crypto.Signature, signBytes common.Bytes, addr common.Address) {
+		if i < len(signers) && sig != nil && !sig.IsEmpty() {
+			sig.SetRecoveredSigner(signBytes, signers[i])
+		}
+		i++
+	})
+	return true
+}
+
+// Remove evicts the transaction from the cache, e.g. once it is included in a block
+func (sc *SignatureCache) Remove(txHash common.Hash) {
+	sc.signers.Remove(txHash)
+}
+
+// Len returns the number of transactions cached
+func (sc *SignatureCache) Len() int {
+	return sc.signers.Len()
+}
@@ -0,0 +1,70 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thetatoken/ukulele/crypto"
+)
+
+func newSignedSendTx(sender, recipient PrivAccount, sequence int) *SendTx {
+	tx := &SendTx{
+		Fee:     NewCoins(0, 111),
+		Inputs:  []TxInput{NewTxInput(sender.Address, NewCoins(10, 111), sequence)},
+		Outputs: []TxOutput{{Address: recipient.Address, Coins: NewCoins(10, 0)}},
+	}
+	tx.SetSignature(sender.Address, sender.Sign(tx.SignBytes(chainID)))
+	return tx
+}
+
+func TestSignatureCache(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	sender := PrivAccountFromSecret("sigcachesender")
+	recipient := PrivAccountFromSecret("sigcacherecipient")
+	cache := NewSignatureCache(2)
+
+	// The signature of the screened tx is verified, so its signer is cached
+	tx := newSignedSendTx(sender, recipient, 1)
+	raw, err := TxToBytes(tx)
+	require.Nil(err)
+	txHash := crypto.Keccak256Hash(raw)
+	assert.True(tx.Inputs[0].Signature.Verify(tx.SignBytes(chainID), sender.Address))
+	cache.Add(chainID, txHash, tx)
+	assert.Equal(1, cache.Len())
+
+	// Another decoding of the same raw tx gets the signer without recovering it
+	decoded, err := TxFromBytes(raw)
+	require.Nil(err)
+	sig := decoded.(*SendTx).Inputs[0].Signature
+	_, ok := sig.RecoveredSigner(tx.SignBytes(chainID))
+	assert.False(ok)
+	assert.True(cache.Load(chainID, txHash, decoded))
+	signer, ok := sig.RecoveredSigner(tx.SignBytes(chainID))
+	assert.True(ok)
+	assert.Equal(sender.Address, signer)
+	assert.True(BatchVerifyTxSignatures(chainID, []Tx{decoded}))
+
+	// A tx whose signatures have not been verified is not cached
+	unverified := newSignedSendTx(sender, recipient, 2)
+	raw, err = TxToBytes(unverified)
+	require.Nil(err)
+	cache.Add(chainID, crypto.Keccak256Hash(raw), unverified)
+	assert.Equal(1, cache.Len())
+	assert.False(cache.Load(chainID, crypto.Keccak256Hash(raw), unverified))
+
+	// Nor is a tx with a missing signature
+	unsigned := &SendTx{
+		Fee:    NewCoins(0, 111),
+		Inputs: []TxInput{NewTxInput(sender.Address, NewCoins(10, 111), 3)},
+	}
+	raw, err = TxToBytes(unsigned)
+	require.Nil(err)
+	cache.Add(chainID, crypto.Keccak256Hash(raw), unsigned)
+	assert.Equal(1, cache.Len())
+
+	cache.Remove(txHash)
+	assert.Equal(0, cache.Len())
+	assert.False(cache.Load(chainID, txHash, tx))
+}
@@ -0,0 +1,153 @@
+package types
+
+import (
+	"fmt"
+
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/core"
+	"github.com/thetatoken/ukulele/crypto"
+)
+
+// ValidateTxSignatures checks that none of the signatures of the transaction is
+// malleable, so that a signed transaction cannot be altered into another valid
+// transaction with a different hash. Missing signatures are allowed, e.g. for
+// transactions still collecting the signatures of their inputs, and malformed
+// signatures are left to fail the signature verification.
+func ValidateTxSignatures(tx Tx) error {
+	for _, sig := range txSignatures(tx) {
+		if err := validateSignature(sig); err != nil {
+			return fmt.Errorf("Invalid transaction signature: %v", err)
+		}
+	}
+	return nil
+}
+
+func validateSignature(sig *crypto.Signature) error {
+	if sig == nil || sig.IsEmpty() {
+		return nil
+	}
+	if ms, ok := MultisigSignatureFromSignature(sig); ok {
+		for _, s := range ms.Signatures {
+			if err := validateSignature(s); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if _, ok := core.ThresholdSignatureFromSignature(sig); ok {
+		// BLS signatures are unique, and the public key is bound to the signer address
+		return nil
+	}
+	if err := sig.Validate(); err == crypto.ErrMalleableSignature {
+		return err
+	}
+	return nil
+}
+
+// txSignatures returns the signatures carried by the transaction
+func txSignatures(tx Tx) []*crypto.Signature {
+	switch tx := tx.(type) {
+	case *CoinbaseTx:
+		return []*crypto.Signature{tx.Proposer.Signature}
+	case *SlashTx:
+		return []*crypto.Signature{tx.Proposer.Signature}
+	case *ServicePaymentTx:
+		return []*crypto.Signature{tx.Source.Signature, tx.Target.Signature}
+	case *BatchServicePaymentTx:
+		sigs := []*crypto.Signature{tx.Target.Signature}
+		for _, payment := range tx.Payments {
+			sigs = append(sigs, payment.Source.Signature)
+		}
+		return sigs
+	case *RecoveryTx:
+		sigs := []*crypto.Signature{}
+		for _, guardian := range tx.Guardians {
+			sigs = append(sigs, guardian.Signature)
+		}
+		return sigs
+	case *UpdateValidatorsTx:
+		return []*crypto.Signature{tx.Proposer.Signature}
+	case *ParameterUpdateTx:
+		sigs := []*crypto.Signature{tx.Proposer.Signature}
+		for _, approver := range tx.Approvers {
+			sigs = append(sigs, approver.Signature)
+		}
+		return sigs
+	}
+	sigs := []*crypto.Signature{}
+	for _, input := range SpendingInputs(tx) {
+		sigs = append(sigs, input.Signature)
+	}
+	return sigs
+}
+
+// BatchVerifyTxSignatures batch verifies the signatures of the transactions that
+// are signed by the keys of the signer addresses. It returns false if any of them
+// is invalid. The signatures remember the signers recovered, so the execution of
+// the transactions does not verify them again. The multisig and threshold
+// signatures are left to be verified by the execution.
+func BatchVerifyTxSignatures(chainID string, txs []Tx) bool {
+	sigs := []*crypto.Signature{}
+	msgs := []common.Bytes{}
+	addrs := []common.Address{}
+	for _, tx := range txs {
+		forEachSignerSignature(chainID, tx, func(sig *crypto.Signature, signBytes common.Bytes, addr common.Address) {
+			sigs = append(sigs, sig)
+			msgs = append(msgs, signBytes)
+			addrs = append(addrs, addr)
+		})
+	}
+	return crypto.VerifyAddressBatch(sigs, msgs, addrs)
+}
+
+// forEachSignerSignature calls the callback with each signature of the transaction
+// that is signed by the key of a signer address, i.e. not a multisig or threshold
+// signature, along with the message signed and the signer address, always in the
+// same order.
+func forEachSignerSignature(chainID string, tx Tx, cb func(sig *crypto.Signature, signBytes common.Bytes, addr common.Address)) {
+	add := func(sig *crypto.Signature, signBytes []byte, addr common.Address) {
+		if _, ok := MultisigSignatureFromSignature(sig); ok {
+			return
+		}
+		if _, ok := core.ThresholdSignatureFromSignature(sig); ok {
+			return
+		}
+		cb(sig, signBytes, addr)
+	}
+
+	switch tx := tx.(type) {
+	case *CoinbaseTx:
+		add(tx.Proposer.Signature, tx.SignBytes(chainID), tx.Proposer.Address)
+	case *SlashTx:
+		add(tx.Proposer.Signature, tx.SignBytes(chainID), tx.Proposer.Address)
+	case *ServicePaymentTx:
+		add(tx.Source.Signature, tx.SourceSignBytes(chainID), tx.Source.Address)
+		add(tx.Target.Signature, tx.TargetSignBytes(chainID), tx.Target.Address)
+	case *BatchServicePaymentTx:
+		add(tx.Target.Signature, tx.SignBytes(chainID), tx.Target.Address)
+		for i := range tx.Payments {
+			payment := &tx.Payments[i]
+			add(payment.Source.Signature, payment.SourceSignBytes(chainID), payment.Source.Address)
+		}
+	case *RecoveryTx:
+		signBytes := tx.SignBytes(chainID)
+		for _, guardian := range tx.Guardians {
+			add(guardian.Signature, signBytes, guardian.Address)
+		}
+	case *ParameterUpdateTx:
+		signBytes := tx.SignBytes(chainID)
+		add(tx.Proposer.Signature, signBytes, tx.Proposer.Address)
+		for _, approver := range tx.Approvers {
+			add(approver.Signature, signBytes, approver.Address)
+		}
+	default:
+		inputs := SpendingInputs(tx)
+		if len(inputs) == 0 {
+			return
+		}
+		signBytes := tx.SignBytes(chainID)
+		for _, input := range inputs {
+			add(input.Signature, signBytes, input.Address)
+		}
+	}
+}
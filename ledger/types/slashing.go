@@ -0,0 +1,183 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"github.com/thetatoken/ukulele/common"
+)
+
+// ** Slashing: penalty of an account that overspent its reserved fund **
+//
+
+// SlashingPolicy determines how an account that overspent its reserved fund is
+// penalized. The slashable amount is the collateral and the remaining fund of the
+// overspent reserved fund.
+type SlashingPolicy struct {
+	CollateralPercent     uint   // Percentage of the slashable amount that is slashed, the rest is returned to the account
+	ReporterRewardPercent uint   // Percentage of the slashed amount rewarded to the reporter, the rest is burned
+	JailDuration          uint64 // Number of epochs a slashed validator is not selected as proposer
+}
+
+// DefaultSlashingPolicy returns the policy that slashes the whole slashable amount,
+// rewards all of it to the reporter, and does not jail validators.
+func DefaultSlashingPolicy() SlashingPolicy {
+	return SlashingPolicy{
+		CollateralPercent:     100,
+		ReporterRewardPercent: 100,
+		JailDuration:          0,
+	}
+}
+
+// Slash splits the slashable amount into the reward of the reporter, the amount
+// burned, and the amount returned to the slashed account.
+func (p SlashingPolicy) Slash(slashable Coins) (reward Coins, burned Coins, returned Coins) {
+	slashed := slashable.CalculatePercentage(p.CollateralPercent)
+	reward = slashed.CalculatePercentage(p.ReporterRewardPercent)
+	return reward, slashed.Minus(reward), slashable.Minus(slashed)
+}
+
+// JailedUntilUnjailed is the UntilEpoch of a validator jailed for downtime, which stays
+// jailed until it sends an UnjailTx.
+const JailedUntilUnjailed uint64 = math.MaxUint64
+
+// JailedValidator records that a validator was jailed by a slash, a governance proposal
+// or for downtime, and is not selected as proposer for the epochs after JailedEpoch,
+// up to UntilEpoch.
+type JailedValidator struct {
+	Address     common.Address
+	JailedEpoch uint64
+	UntilEpoch  uint64
+}
+
+type JailedValidatorJSON struct {
+	Address     common.Address    `json:"address"`
+	JailedEpoch common.JSONUint64 `json:"jailed_epoch"`
+	UntilEpoch  common.JSONUint64 `json:"until_epoch"`
+}
+
+func NewJailedValidatorJSON(jv JailedValidator) JailedValidatorJSON {
+	return JailedValidatorJSON{
+		Address:     jv.Address,
+		JailedEpoch: common.JSONUint64(jv.JailedEpoch),
+		UntilEpoch:  common.JSONUint64(jv.UntilEpoch),
+	}
+}
+
+func (jv JailedValidatorJSON) JailedValidator() JailedValidator {
+	return JailedValidator{
+		Address:     jv.Address,
+		JailedEpoch: uint64(jv.JailedEpoch),
+		UntilEpoch:  uint64(jv.UntilEpoch),
+	}
+}
+
+func (jv JailedValidator) MarshalJSON() ([]byte, error) {
+	return json.Marshal(NewJailedValidatorJSON(jv))
+}
+
+func (jv *JailedValidator) UnmarshalJSON(data []byte) error {
+	var a JailedValidatorJSON
+	if err := json.Unmarshal(data, &a); err != nil {
+		return err
+	}
+	*jv = a.JailedValidator()
+	return nil
+}
+
+func (jv *JailedValidator) String() string {
+	if jv == nil {
+		return "nil-JailedValidator"
+	}
+	return fmt.Sprintf("JailedValidator{%v %v %v}", jv.Address.Hex(), jv.JailedEpoch, jv.UntilEpoch)
+}
+
+// IsJailed returns whether the validator is not selected as proposer in the epoch.
+func (jv *JailedValidator) IsJailed(epoch uint64) bool {
+	return epoch > jv.JailedEpoch && epoch <= jv.UntilEpoch
+}
+
+// IsJailedUntilUnjailed returns whether the validator was jailed for downtime, and
+// has not been unjailed yet.
+func (jv *JailedValidator) IsJailedUntilUnjailed() bool {
+	return jv.UntilEpoch == JailedUntilUnjailed
+}
+
+// ValidatorDowntime records the epochs for which a validator was the proposer but
+// no block was produced, within the last DowntimeWindowEpochs epochs.
+type ValidatorDowntime struct {
+	Address      common.Address
+	MissedEpochs []uint64
+}
+
+type ValidatorDowntimeJSON struct {
+	Address      common.Address      `json:"address"`
+	MissedEpochs []common.JSONUint64 `json:"missed_epochs"`
+}
+
+func NewValidatorDowntimeJSON(vd ValidatorDowntime) ValidatorDowntimeJSON {
+	missedEpochs := []common.JSONUint64{}
+	for _, epoch := range vd.MissedEpochs {
+		missedEpochs = append(missedEpochs, common.JSONUint64(epoch))
+	}
+	return ValidatorDowntimeJSON{
+		Address:      vd.Address,
+		MissedEpochs: missedEpochs,
+	}
+}
+
+func (vd ValidatorDowntimeJSON) ValidatorDowntime() ValidatorDowntime {
+	missedEpochs := []uint64{}
+	for _, epoch := range vd.MissedEpochs {
+		missedEpochs = append(missedEpochs, uint64(epoch))
+	}
+	return ValidatorDowntime{
+		Address:      vd.Address,
+		MissedEpochs: missedEpochs,
+	}
+}
+
+func (vd ValidatorDowntime) MarshalJSON() ([]byte, error) {
+	return json.Marshal(NewValidatorDowntimeJSON(vd))
+}
+
+func (vd *ValidatorDowntime) UnmarshalJSON(data []byte) error {
+	var a ValidatorDowntimeJSON
+	if err := json.Unmarshal(data, &a); err != nil {
+		return err
+	}
+	*vd = a.ValidatorDowntime()
+	return nil
+}
+
+// RecordMissedEpoch records that the validator missed its proposal of the epoch, and
+// forgets the missed epochs that left the window.
+func (vd *ValidatorDowntime) RecordMissedEpoch(epoch uint64) {
+	missedEpochs := []uint64{}
+	for _, missed := range vd.MissedEpochs {
+		if missed+DowntimeWindowEpochs > epoch {
+			missedEpochs = append(missedEpochs, missed)
+		}
+	}
+	vd.MissedEpochs = append(missedEpochs, epoch)
+}
+
+// MissedInWindow returns the number of proposals the validator missed within the
+// window ending at the epoch.
+func (vd *ValidatorDowntime) MissedInWindow(epoch uint64) int {
+	count := 0
+	for _, missed := range vd.MissedEpochs {
+		if missed+DowntimeWindowEpochs > epoch {
+			count++
+		}
+	}
+	return count
+}
+
+func (vd *ValidatorDowntime) String() string {
+	if vd == nil {
+		return "nil-ValidatorDowntime"
+	}
+	return fmt.Sprintf("ValidatorDowntime{%v %v}", vd.Address.Hex(), vd.MissedEpochs)
+}
@@ -0,0 +1,74 @@
+package types
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thetatoken/ukulele/common"
+)
+
+func TestSlashingPolicy(t *testing.T) {
+	assert := assert.New(t)
+
+	slashable := NewCoins(1000, 2000)
+
+	reward, burned, returned := DefaultSlashingPolicy().Slash(slashable)
+	assert.Equal(slashable, reward)
+	assert.True(burned.IsZero())
+	assert.True(returned.IsZero())
+
+	policy := SlashingPolicy{CollateralPercent: 50, ReporterRewardPercent: 20}
+	reward, burned, returned = policy.Slash(slashable)
+	assert.Equal(NewCoins(100, 200), reward)
+	assert.Equal(NewCoins(400, 800), burned)
+	assert.Equal(NewCoins(500, 1000), returned)
+
+	policy = SlashingPolicy{CollateralPercent: 0, ReporterRewardPercent: 100}
+	reward, burned, returned = policy.Slash(slashable)
+	assert.True(reward.IsZero())
+	assert.True(burned.IsZero())
+	assert.Equal(slashable, returned)
+}
+
+func TestJailedValidatorJSON(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	jv := JailedValidator{
+		Address:     common.HexToAddress("0x2E833968E5bB786Ae419c4d13189fB081Cc43bab"),
+		JailedEpoch: 100,
+		UntilEpoch:  math.MaxUint64,
+	}
+
+	s, err := json.Marshal(jv)
+	require.Nil(err)
+
+	var d JailedValidator
+	err = json.Unmarshal(s, &d)
+	require.Nil(err)
+	assert.Equal(jv, d)
+}
+
+func TestValidatorDowntime(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	vd := &ValidatorDowntime{Address: common.HexToAddress("0x2E833968E5bB786Ae419c4d13189fB081Cc43bab")}
+	vd.RecordMissedEpoch(10)
+	vd.RecordMissedEpoch(20)
+	assert.Equal(2, vd.MissedInWindow(20))
+	assert.Equal(1, vd.MissedInWindow(10+DowntimeWindowEpochs))
+
+	// The epochs out of the window are forgotten
+	vd.RecordMissedEpoch(15 + DowntimeWindowEpochs)
+	assert.Equal([]uint64{20, 15 + DowntimeWindowEpochs}, vd.MissedEpochs)
+
+	s, err := json.Marshal(vd)
+	require.Nil(err)
+	var d ValidatorDowntime
+	require.Nil(json.Unmarshal(s, &d))
+	assert.Equal(*vd, d)
+}
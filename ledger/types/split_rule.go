@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 
+	"github.com/pkg/errors"
 	"github.com/thetatoken/ukulele/common"
 )
 
@@ -22,6 +23,11 @@ type SplitRule struct {
 	ResourceID       string         // ResourceID of the payment to be split
 	Splits           []Split        // Splits of the payments
 	EndBlockHeight   uint64         // The block height when the split rule expires
+
+	// Optional first-level splits, e.g. a platform fee, taken from the full payment
+	// before the Splits divide what remains. As the tail of the encoding, a split rule
+	// without them encodes as before they were introduced.
+	PlatformSplits []Split `rlp:"tail"`
 }
 
 type SplitRuleJSON struct {
@@ -29,7 +35,7 @@ type SplitRuleJSON struct {
 	ResourceID       string            `json:"resource_id"`       // ResourceID of the payment to be split
 	Splits           []Split           `json:"splits"`            // Splits of the payments
 	EndBlockHeight   common.JSONUint64 `json:"end_block_height"`  // The block height when the split rule expires
-
+	PlatformSplits   []Split           `json:"platform_splits,omitempty"`
 }
 
 func NewSplitRuleJSON(a SplitRule) SplitRuleJSON {
@@ -38,6 +44,7 @@ func NewSplitRuleJSON(a SplitRule) SplitRuleJSON {
 		ResourceID:       a.ResourceID,
 		Splits:           a.Splits,
 		EndBlockHeight:   common.JSONUint64(a.EndBlockHeight),
+		PlatformSplits:   a.PlatformSplits,
 	}
 }
 
@@ -47,6 +54,7 @@ func (a SplitRuleJSON) SplitRule() SplitRule {
 		ResourceID:       a.ResourceID,
 		Splits:           a.Splits,
 		EndBlockHeight:   uint64(a.EndBlockHeight),
+		PlatformSplits:   a.PlatformSplits,
 	}
 }
 
@@ -67,6 +75,45 @@ func (sc *SplitRule) String() string {
 	if sc == nil {
 		return "nil-SlashIntent"
 	}
-	return fmt.Sprintf("SplitRule{%v %v %v %v}",
-		sc.InitiatorAddress.Hex(), string(sc.ResourceID), sc.Splits, sc.EndBlockHeight)
+	return fmt.Sprintf("SplitRule{%v %v %v %v %v}",
+		sc.InitiatorAddress.Hex(), string(sc.ResourceID), sc.PlatformSplits, sc.Splits, sc.EndBlockHeight)
+}
+
+// ValidateSplits checks that the percentages of the splits are at most 100, also in
+// sum, and that the splits are for distinct addresses.
+func ValidateSplits(splits []Split) error {
+	totalPercentage := uint(0)
+	seen := map[common.Address]bool{}
+	for _, split := range splits {
+		if split.Percentage > 100 {
+			return errors.Errorf("Percentage of %v needs to be at most 100", split.Address.Hex())
+		}
+		if seen[split.Address] {
+			return errors.Errorf("Duplicated split for %v", split.Address.Hex())
+		}
+		seen[split.Address] = true
+		totalPercentage += split.Percentage
+	}
+	if totalPercentage > 100 {
+		return errors.Errorf("Sum of the percentages should be at most 100")
+	}
+	return nil
+}
+
+// SplitAmounts divides a payment according to the split rule. The platform splits
+// take their percentages of the full amount first, then the splits take theirs of
+// what remains. Each share is rounded down, and the remainder, rounding included, is
+// left for the target of the payment. An address in both levels gets both shares.
+func (sc *SplitRule) SplitAmounts(amount Coins) (shares map[common.Address]Coins, remainder Coins) {
+	shares = map[common.Address]Coins{}
+	remainder = amount
+	for _, level := range [][]Split{sc.PlatformSplits, sc.Splits} {
+		base := remainder
+		for _, split := range level {
+			share := base.CalculatePercentage(split.Percentage)
+			shares[split.Address] = shares[split.Address].Plus(share)
+			remainder = remainder.Minus(share)
+		}
+	}
+	return shares, remainder
 }
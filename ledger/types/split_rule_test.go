@@ -7,6 +7,8 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/rlp"
 )
 
 func TestSplitRuleJSON(t *testing.T) {
@@ -25,3 +27,84 @@ func TestSplitRuleJSON(t *testing.T) {
 	require.Nil(err)
 	assert.Equal(uint64(math.MaxUint64), d.EndBlockHeight)
 }
+
+func TestValidateSplits(t *testing.T) {
+	assert := assert.New(t)
+
+	addr1 := common.HexToAddress("0x111")
+	addr2 := common.HexToAddress("0x222")
+
+	assert.Nil(ValidateSplits(nil))
+	assert.Nil(ValidateSplits([]Split{{addr1, 0}, {addr2, 100}}))
+	assert.Nil(ValidateSplits([]Split{{addr1, 40}, {addr2, 60}}))
+
+	assert.NotNil(ValidateSplits([]Split{{addr1, 101}}))
+	assert.NotNil(ValidateSplits([]Split{{addr1, 40}, {addr2, 61}}))
+	assert.NotNil(ValidateSplits([]Split{{addr1, 10}, {addr1, 20}}))
+}
+
+func TestSplitAmounts(t *testing.T) {
+	assert := assert.New(t)
+
+	platform := common.HexToAddress("0x111")
+	artist := common.HexToAddress("0x222")
+	label := common.HexToAddress("0x333")
+
+	// Without platform splits, the splits take their percentages of the full amount
+	splitRule := SplitRule{
+		Splits: []Split{{artist, 30}, {label, 20}},
+	}
+	shares, remainder := splitRule.SplitAmounts(NewCoins(1000, 999))
+	assert.Equal(2, len(shares))
+	assert.Equal(NewCoins(300, 299), shares[artist])
+	assert.Equal(NewCoins(200, 199), shares[label])
+	assert.Equal(NewCoins(500, 501), remainder)
+
+	// The platform takes its fee first, and the splits divide what remains
+	splitRule.PlatformSplits = []Split{{platform, 10}}
+	shares, remainder = splitRule.SplitAmounts(NewCoins(1000, 999))
+	assert.Equal(3, len(shares))
+	assert.Equal(NewCoins(100, 99), shares[platform])
+	assert.Equal(NewCoins(270, 270), shares[artist])
+	assert.Equal(NewCoins(180, 180), shares[label])
+	assert.Equal(NewCoins(450, 450), remainder)
+
+	// An address in both levels gets both shares
+	splitRule.PlatformSplits = []Split{{artist, 10}}
+	shares, remainder = splitRule.SplitAmounts(NewCoins(1000, 0))
+	assert.Equal(2, len(shares))
+	assert.Equal(NewCoins(370, 0), shares[artist])
+	assert.Equal(NewCoins(180, 0), shares[label])
+	assert.Equal(NewCoins(450, 0), remainder)
+}
+
+func TestSplitRuleWithoutPlatformSplitsRLP(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// The encoding of the split rules without platform splits is unchanged
+	type legacySplitRule struct {
+		InitiatorAddress common.Address
+		ResourceID       string
+		Splits           []Split
+		EndBlockHeight   uint64
+	}
+	splitRule := SplitRule{
+		InitiatorAddress: common.HexToAddress("0x111"),
+		ResourceID:       "rid001",
+		Splits:           []Split{{common.HexToAddress("0x222"), 30}},
+		EndBlockHeight:   199,
+	}
+	b, err := rlp.EncodeToBytes(splitRule)
+	require.Nil(err)
+	legacy, err := rlp.EncodeToBytes(legacySplitRule{splitRule.InitiatorAddress, splitRule.ResourceID, splitRule.Splits, splitRule.EndBlockHeight})
+	require.Nil(err)
+	assert.Equal(legacy, b)
+
+	splitRule.PlatformSplits = []Split{{common.HexToAddress("0x333"), 5}}
+	b, err = rlp.EncodeToBytes(splitRule)
+	require.Nil(err)
+	var decoded SplitRule
+	require.Nil(rlp.DecodeBytes(b, &decoded))
+	assert.Equal(splitRule, decoded)
+}
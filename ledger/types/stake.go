@@ -0,0 +1,152 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/thetatoken/ukulele/common"
+)
+
+// ** Stake: Theta deposited by a source address to back a validator (the stake holder) **
+//
+
+// Stake represents the Theta a source address has deposited to a stake holder
+type Stake struct {
+	Source       common.Address // Address the stake was deposited from
+	Amount       *big.Int       // Amount of ThetaWei staked
+	Withdrawn    bool           // Whether the stake has been withdrawn
+	ReturnHeight uint64         // The block height at which a withdrawn stake is returned to the source
+}
+
+type StakeJSON struct {
+	Source       common.Address    `json:"source"`
+	Amount       *common.JSONBig   `json:"amount"`
+	Withdrawn    bool              `json:"withdrawn"`
+	ReturnHeight common.JSONUint64 `json:"return_height"`
+}
+
+func NewStakeJSON(s Stake) StakeJSON {
+	return StakeJSON{
+		Source:       s.Source,
+		Amount:       (*common.JSONBig)(s.Amount),
+		Withdrawn:    s.Withdrawn,
+		ReturnHeight: common.JSONUint64(s.ReturnHeight),
+	}
+}
+
+func (s StakeJSON) Stake() Stake {
+	return Stake{
+		Source:       s.Source,
+		Amount:       (*big.Int)(s.Amount),
+		Withdrawn:    s.Withdrawn,
+		ReturnHeight: uint64(s.ReturnHeight),
+	}
+}
+
+func (s Stake) MarshalJSON() ([]byte, error) {
+	return json.Marshal(NewStakeJSON(s))
+}
+
+func (s *Stake) UnmarshalJSON(data []byte) error {
+	var b StakeJSON
+	if err := json.Unmarshal(data, &b); err != nil {
+		return err
+	}
+	*s = b.Stake()
+	return nil
+}
+
+func (s *Stake) String() string {
+	if s == nil {
+		return "nil-Stake"
+	}
+	return fmt.Sprintf("Stake{%v %v %v %v}", s.Source.Hex(), s.Amount, s.Withdrawn, s.ReturnHeight)
+}
+
+// StakeHolder represents an address (typically a validator) and the stakes deposited to it
+type StakeHolder struct {
+	Holder common.Address `json:"holder"`
+	Stakes []*Stake       `json:"stakes"`
+}
+
+// NewStakeHolder creates a new instance of StakeHolder
+func NewStakeHolder(holder common.Address) *StakeHolder {
+	return &StakeHolder{
+		Holder: holder,
+		Stakes: []*Stake{},
+	}
+}
+
+// TotalStake returns the total amount of stakes that have not been withdrawn
+func (sh *StakeHolder) TotalStake() *big.Int {
+	total := new(big.Int)
+	for _, stake := range sh.Stakes {
+		if stake.Withdrawn {
+			continue
+		}
+		total.Add(total, stake.Amount)
+	}
+	return total
+}
+
+// HasActiveStake checks whether the source address has a stake that has not been withdrawn
+func (sh *StakeHolder) HasActiveStake(source common.Address) bool {
+	for _, stake := range sh.Stakes {
+		if stake.Source == source && !stake.Withdrawn {
+			return true
+		}
+	}
+	return false
+}
+
+// DepositStake adds the amount to the active stake of the source address
+func (sh *StakeHolder) DepositStake(source common.Address, amount *big.Int) {
+	for _, stake := range sh.Stakes {
+		if stake.Source == source && !stake.Withdrawn {
+			stake.Amount = new(big.Int).Add(stake.Amount, amount)
+			return
+		}
+	}
+	sh.Stakes = append(sh.Stakes, &Stake{
+		Source: source,
+		Amount: new(big.Int).Set(amount),
+	})
+}
+
+// WithdrawStake marks the active stake of the source address as withdrawn, to be
+// returned at the given height. It returns the withdrawn stake, or nil if the
+// source has no active stake.
+func (sh *StakeHolder) WithdrawStake(source common.Address, returnHeight uint64) *Stake {
+	for _, stake := range sh.Stakes {
+		if stake.Source == source && !stake.Withdrawn {
+			stake.Withdrawn = true
+			stake.ReturnHeight = returnHeight
+			return stake
+		}
+	}
+	return nil
+}
+
+// ReturnMaturedStakes removes the withdrawn stakes whose return height has been
+// reached and returns them
+func (sh *StakeHolder) ReturnMaturedStakes(currentBlockHeight uint64) []*Stake {
+	returned := []*Stake{}
+	remaining := []*Stake{}
+	for _, stake := range sh.Stakes {
+		if stake.Withdrawn && stake.ReturnHeight <= currentBlockHeight {
+			returned = append(returned, stake)
+		} else {
+			remaining = append(remaining, stake)
+		}
+	}
+	sh.Stakes = remaining
+	return returned
+}
+
+func (sh *StakeHolder) String() string {
+	if sh == nil {
+		return "nil-StakeHolder"
+	}
+	return fmt.Sprintf("StakeHolder{%v %v}", sh.Holder.Hex(), sh.Stakes)
+}
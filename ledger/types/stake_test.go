@@ -0,0 +1,76 @@
+package types
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thetatoken/ukulele/common"
+)
+
+func TestStakeJSON(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	stake := Stake{
+		Source:       common.HexToAddress("0x2E833968E5bB786Ae419c4d13189fB081Cc43bab"),
+		Amount:       big.NewInt(1e18),
+		Withdrawn:    true,
+		ReturnHeight: 1024,
+	}
+
+	s, err := json.Marshal(stake)
+	require.Nil(err)
+
+	var d Stake
+	err = json.Unmarshal(s, &d)
+	require.Nil(err)
+	assert.Equal(stake.Source, d.Source)
+	assert.Equal(0, stake.Amount.Cmp(d.Amount))
+	assert.True(d.Withdrawn)
+	assert.Equal(uint64(1024), d.ReturnHeight)
+}
+
+func TestStakeHolder(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	source1 := common.HexToAddress("0x2E833968E5bB786Ae419c4d13189fB081Cc43bab")
+	source2 := common.HexToAddress("0x9F1233798E905E173560071255140b4A8aBd3Ec6")
+	sh := NewStakeHolder(common.HexToAddress("0x7ad6cea2bc3162e30a3c98d84f821b3233c22647"))
+
+	sh.DepositStake(source1, big.NewInt(100))
+	sh.DepositStake(source2, big.NewInt(200))
+	sh.DepositStake(source1, big.NewInt(50))
+	assert.Equal(2, len(sh.Stakes))
+	assert.Equal(big.NewInt(350), sh.TotalStake())
+
+	withdrawn := sh.WithdrawStake(source1, 10)
+	require.NotNil(withdrawn)
+	assert.Equal(big.NewInt(150), withdrawn.Amount)
+	assert.False(sh.HasActiveStake(source1))
+	assert.True(sh.HasActiveStake(source2))
+	assert.Equal(big.NewInt(200), sh.TotalStake())
+	assert.Nil(sh.WithdrawStake(source1, 10))
+
+	// Deposits after a withdrawal create a new stake
+	sh.DepositStake(source1, big.NewInt(10))
+	assert.Equal(3, len(sh.Stakes))
+
+	assert.Equal(0, len(sh.ReturnMaturedStakes(9)))
+	returned := sh.ReturnMaturedStakes(10)
+	require.Equal(1, len(returned))
+	assert.Equal(source1, returned[0].Source)
+	assert.Equal(2, len(sh.Stakes))
+
+	raw, err := ToBytes(sh)
+	require.Nil(err)
+	decoded := &StakeHolder{}
+	err = FromBytes(raw, decoded)
+	require.Nil(err)
+	assert.Equal(sh.Holder, decoded.Holder)
+	assert.Equal(2, len(decoded.Stakes))
+	assert.Equal(0, sh.TotalStake().Cmp(decoded.TotalStake()))
+}
@@ -0,0 +1,260 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/thetatoken/ukulele/common"
+)
+
+// ** Token registry: Application tokens created by a CreateTokenTx **
+//
+
+// Token is the metadata of an application token in the token registry. The maximum
+// supply is minted to the issuer on creation, and no transaction mints the token
+// afterwards.
+type Token struct {
+	Symbol    string         // Symbol of the token, which is also its denomination
+	Decimals  uint64         // Number of decimals of the token, for display
+	MaxSupply *big.Int       // Supply of the token, in its smallest unit
+	Issuer    common.Address // Account which created the token
+}
+
+type TokenJSON struct {
+	Symbol    string            `json:"symbol"`
+	Decimals  common.JSONUint64 `json:"decimals"`
+	MaxSupply *common.JSONBig   `json:"max_supply"`
+	Issuer    common.Address    `json:"issuer"`
+}
+
+func NewTokenJSON(t Token) TokenJSON {
+	return TokenJSON{
+		Symbol:    t.Symbol,
+		Decimals:  common.JSONUint64(t.Decimals),
+		MaxSupply: (*common.JSONBig)(t.MaxSupply),
+		Issuer:    t.Issuer,
+	}
+}
+
+func (t TokenJSON) Token() Token {
+	return Token{
+		Symbol:    t.Symbol,
+		Decimals:  uint64(t.Decimals),
+		MaxSupply: (*big.Int)(t.MaxSupply),
+		Issuer:    t.Issuer,
+	}
+}
+
+func (t Token) MarshalJSON() ([]byte, error) {
+	return json.Marshal(NewTokenJSON(t))
+}
+
+func (t *Token) UnmarshalJSON(data []byte) error {
+	var b TokenJSON
+	if err := json.Unmarshal(data, &b); err != nil {
+		return err
+	}
+	*t = b.Token()
+	return nil
+}
+
+func (t *Token) String() string {
+	if t == nil {
+		return "nil-Token"
+	}
+	return fmt.Sprintf("Token{%v, decimals: %v, max_supply: %v, issuer: %v}",
+		t.Symbol, t.Decimals, t.MaxSupply, t.Issuer.Hex())
+}
+
+// ValidateTokenSymbol checks the symbol of an application token: uppercase letters and
+// digits, starting with a letter, and not one of the native denominations.
+func ValidateTokenSymbol(symbol string) error {
+	if len(symbol) < MinTokenSymbolLength || len(symbol) > MaxTokenSymbolLength {
+		return errors.Errorf("Token symbol needs to be %v to %v characters long", MinTokenSymbolLength, MaxTokenSymbolLength)
+	}
+	for i, c := range symbol {
+		if !(c >= 'A' && c <= 'Z') && !(i > 0 && c >= '0' && c <= '9') {
+			return errors.Errorf("Invalid token symbol %v, only uppercase letters and digits are allowed", symbol)
+		}
+	}
+	for _, reserved := range []string{"THETA", "GAMMA", DenomThetaWei, DenomGammaWei} {
+		if strings.EqualFold(symbol, reserved) {
+			return errors.Errorf("Token symbol %v is reserved", symbol)
+		}
+	}
+	return nil
+}
+
+// ValidateToken checks the metadata of a token to create
+func ValidateToken(symbol string, decimals uint64, maxSupply *big.Int) error {
+	if err := ValidateTokenSymbol(symbol); err != nil {
+		return err
+	}
+	if decimals > MaxTokenDecimals {
+		return errors.Errorf("A token can have at most %v decimals", MaxTokenDecimals)
+	}
+	if maxSupply == nil || maxSupply.Sign() <= 0 {
+		return errors.New("Max supply needs to be positive")
+	}
+	if maxSupply.BitLen() > MaxTokenSupplyBits {
+		return errors.Errorf("Max supply needs to be below 2^%v", MaxTokenSupplyBits)
+	}
+	return nil
+}
+
+// TokenCoin is an amount of an application token
+type TokenCoin struct {
+	Symbol string   // Symbol of the token
+	Amount *big.Int // Amount in the smallest unit of the token
+}
+
+type TokenCoinJSON struct {
+	Symbol string          `json:"symbol"`
+	Amount *common.JSONBig `json:"amount"`
+}
+
+func (t TokenCoin) MarshalJSON() ([]byte, error) {
+	return json.Marshal(TokenCoinJSON{Symbol: t.Symbol, Amount: (*common.JSONBig)(t.Amount)})
+}
+
+func (t *TokenCoin) UnmarshalJSON(data []byte) error {
+	var b TokenCoinJSON
+	if err := json.Unmarshal(data, &b); err != nil {
+		return err
+	}
+	*t = TokenCoin{Symbol: b.Symbol, Amount: (*big.Int)(b.Amount)}
+	return nil
+}
+
+func (t TokenCoin) amount() *big.Int {
+	if t.Amount == nil {
+		return Zero
+	}
+	return t.Amount
+}
+
+// NewTokenCoins returns coins of the given amount of a token
+func NewTokenCoins(symbol string, amount *big.Int) Coins {
+	return NewCoins(0, 0).Plus(Coins{Tokens: []TokenCoin{{Symbol: symbol, Amount: amount}}})
+}
+
+// normalizeTokens sums the amounts of each token, and returns the non-zero sums sorted
+// by symbol
+func normalizeTokens(tokens []TokenCoin) []TokenCoin {
+	if len(tokens) == 0 {
+		return nil
+	}
+	sums := make(map[string]*big.Int)
+	for _, token := range tokens {
+		if sum, ok := sums[token.Symbol]; ok {
+			sum.Add(sum, token.amount())
+		} else {
+			sums[token.Symbol] = new(big.Int).Set(token.amount())
+		}
+	}
+	var normalized []TokenCoin
+	for symbol, sum := range sums {
+		if sum.Sign() != 0 {
+			normalized = append(normalized, TokenCoin{Symbol: symbol, Amount: sum})
+		}
+	}
+	sort.Slice(normalized, func(i, j int) bool { return normalized[i].Symbol < normalized[j].Symbol })
+	return normalized
+}
+
+// TxCoins returns all the coins carried by the transaction, including its fee
+func TxCoins(tx Tx) []Coins {
+	coins := []Coins{}
+	addInputs := func(inputs ...TxInput) {
+		for _, input := range inputs {
+			coins = append(coins, input.Coins)
+		}
+	}
+	addOutputs := func(outputs ...TxOutput) {
+		for _, output := range outputs {
+			coins = append(coins, output.Coins)
+		}
+	}
+	switch tx := tx.(type) {
+	case *CoinbaseTx:
+		addInputs(tx.Proposer)
+		addOutputs(tx.Outputs...)
+	case *SlashTx:
+		addInputs(tx.Proposer)
+	case *SendTx:
+		coins = append(coins, tx.Fee)
+		addInputs(tx.Inputs...)
+		addOutputs(tx.Outputs...)
+	case *TimelockedSendTx:
+		coins = append(coins, tx.Fee)
+		addInputs(tx.Inputs...)
+		addOutputs(tx.Outputs...)
+	case *ReserveFundTx:
+		coins = append(coins, tx.Fee, tx.Collateral)
+		addInputs(tx.Source)
+		for _, spendLimit := range tx.SpendLimits {
+			coins = append(coins, spendLimit.Limit)
+		}
+	case *ReleaseFundTx:
+		coins = append(coins, tx.Fee)
+		addInputs(tx.Source)
+	case *ExtendReserveTx:
+		coins = append(coins, tx.Fee, tx.Collateral)
+		addInputs(tx.Source)
+	case *ServicePaymentTx:
+		coins = append(coins, tx.Fee)
+		addInputs(tx.Source, tx.Target)
+	case *BatchServicePaymentTx:
+		coins = append(coins, tx.Fee)
+		addInputs(tx.Target)
+		for _, payment := range tx.Payments {
+			coins = append(coins, payment.Fee)
+			addInputs(payment.Source, payment.Target)
+		}
+	case *SplitRuleTx:
+		coins = append(coins, tx.Fee)
+		addInputs(tx.Initiator)
+	case *UpdateValidatorsTx:
+		coins = append(coins, tx.Fee)
+		addInputs(tx.Proposer)
+	case *SmartContractTx:
+		addInputs(tx.From)
+		addOutputs(tx.To)
+	case *DepositStakeTx:
+		coins = append(coins, tx.Fee)
+		addInputs(tx.Source)
+		addOutputs(tx.Holder)
+	case *WithdrawStakeTx:
+		coins = append(coins, tx.Fee)
+		addInputs(tx.Source)
+		addOutputs(tx.Holder)
+	case *SetGuardiansTx:
+		coins = append(coins, tx.Fee)
+		addInputs(tx.Account)
+	case *RecoveryTx:
+		coins = append(coins, tx.Fee)
+		addInputs(tx.Account)
+		addInputs(tx.Guardians...)
+	case *CreateTokenTx:
+		coins = append(coins, tx.Fee)
+		addInputs(tx.Issuer)
+	case *ParameterUpdateTx:
+		coins = append(coins, tx.Fee)
+		addInputs(tx.Proposer)
+		addInputs(tx.Approvers...)
+	case *ProposalTx:
+		coins = append(coins, tx.Fee)
+		addInputs(tx.Proposer)
+	case *VoteTx:
+		coins = append(coins, tx.Fee)
+		addInputs(tx.Voter)
+	case *UnjailTx:
+		coins = append(coins, tx.Fee)
+		addInputs(tx.Validator)
+	}
+	return coins
+}
@@ -0,0 +1,113 @@
+package types
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thetatoken/ukulele/rlp"
+)
+
+func TestValidateToken(t *testing.T) {
+	assert := assert.New(t)
+
+	supply := big.NewInt(1000000)
+	assert.Nil(ValidateToken("ABC", 6, supply))
+	assert.Nil(ValidateToken("A1", 0, supply))
+	assert.Nil(ValidateToken("ABCDEFGHIJ", MaxTokenDecimals, supply))
+
+	assert.NotNil(ValidateToken("A", 6, supply))
+	assert.NotNil(ValidateToken("ABCDEFGHIJK", 6, supply))
+	assert.NotNil(ValidateToken("abc", 6, supply))
+	assert.NotNil(ValidateToken("1AB", 6, supply))
+	assert.NotNil(ValidateToken("A-B", 6, supply))
+	assert.NotNil(ValidateToken("THETA", 6, supply))
+	assert.NotNil(ValidateToken("GAMMAWEI", 6, supply))
+	assert.NotNil(ValidateToken("ABC", MaxTokenDecimals+1, supply))
+	assert.NotNil(ValidateToken("ABC", 6, nil))
+	assert.NotNil(ValidateToken("ABC", 6, big.NewInt(0)))
+	assert.NotNil(ValidateToken("ABC", 6, new(big.Int).Lsh(big.NewInt(1), MaxTokenSupplyBits)))
+}
+
+func TestTokenCoins(t *testing.T) {
+	assert := assert.New(t)
+
+	abc := NewTokenCoins("ABC", big.NewInt(100))
+	xyz := NewTokenCoins("XYZ", big.NewInt(5))
+	a := NewCoins(1, 2).Plus(xyz).Plus(abc)
+	assert.True(a.IsValid())
+	assert.True(a.IsPositive())
+	assert.Equal(2, len(a.Tokens))
+	assert.Equal("ABC", a.Tokens[0].Symbol)
+	assert.Equal("XYZ", a.Tokens[1].Symbol)
+	assert.Equal(big.NewInt(100), a.GetToken("ABC"))
+	assert.Equal(big.NewInt(0), a.GetToken("DEF"))
+	assert.Equal("1 ThetaWei, 2 GammaWei, 100 ABC, 5 XYZ", a.String())
+
+	// The amounts of a token are summed, and the tokens of zero amount dropped
+	b := a.Plus(abc).Minus(xyz)
+	assert.Equal(1, len(b.Tokens))
+	assert.Equal(big.NewInt(200), b.GetToken("ABC"))
+	assert.False(b.IsEqual(a))
+	assert.True(b.Minus(abc).Plus(xyz).IsEqual(a))
+
+	// Tokens missing from the balance are not covered
+	assert.True(a.IsGTE(NewCoins(1, 2)))
+	assert.False(NewCoins(1, 2).IsGTE(a))
+	assert.False(a.IsGTE(b))
+	assert.True(abc.IsPositive())
+	assert.False(abc.Negative().IsNonnegative())
+	assert.True(a.Minus(a).IsZero())
+	assert.True(NewCoins(0, 0).Plus(NewTokenCoins("ABC", big.NewInt(0))).IsZero())
+
+	assert.Equal(big.NewInt(50), a.CalculatePercentage(50).GetToken("ABC"))
+	assert.Equal(1, len(a.CalculatePercentage(10).Tokens))
+
+	// Only the canonical form of the tokens is valid
+	assert.False(Coins{Tokens: []TokenCoin{{"XYZ", big.NewInt(1)}, {"ABC", big.NewInt(1)}}}.IsValid())
+	assert.False(Coins{Tokens: []TokenCoin{{"ABC", big.NewInt(1)}, {"ABC", big.NewInt(1)}}}.IsValid())
+	assert.False(Coins{Tokens: []TokenCoin{{"ABC", big.NewInt(0)}}}.IsValid())
+	assert.False(Coins{Tokens: []TokenCoin{{"ABC", nil}}}.IsValid())
+	assert.False(Coins{Tokens: []TokenCoin{{"abc", big.NewInt(1)}}}.IsValid())
+	assert.True(Coins{Tokens: []TokenCoin{{"ABC", big.NewInt(1)}, {"XYZ", big.NewInt(1)}}}.IsValid())
+}
+
+func TestTokenCoinsEncoding(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// Coins without tokens encode as before the tokens were introduced
+	type coinsWithoutTokens struct {
+		ThetaWei *big.Int
+		GammaWei *big.Int
+	}
+	coins := NewCoins(123, 456)
+	raw, err := rlp.EncodeToBytes(coins)
+	require.Nil(err)
+	legacyRaw, err := rlp.EncodeToBytes(coinsWithoutTokens{big.NewInt(123), big.NewInt(456)})
+	require.Nil(err)
+	assert.Equal(legacyRaw, raw)
+	var decoded Coins
+	require.Nil(rlp.DecodeBytes(raw, &decoded))
+	assert.Equal(coins, decoded)
+
+	coins = coins.Plus(NewTokenCoins("ABC", big.NewInt(789)))
+	raw, err = rlp.EncodeToBytes(coins)
+	require.Nil(err)
+	require.Nil(rlp.DecodeBytes(raw, &decoded))
+	assert.True(coins.IsEqual(decoded))
+	assert.Equal(big.NewInt(789), decoded.GetToken("ABC"))
+
+	s, err := json.Marshal(coins)
+	require.Nil(err)
+	assert.Equal(`{"thetawei":"123","gammawei":"456","tokens":[{"symbol":"ABC","amount":"789"}]}`, string(s))
+	var d Coins
+	require.Nil(json.Unmarshal(s, &d))
+	assert.True(coins.IsEqual(d))
+
+	s, err = json.Marshal(NewCoins(1, 2))
+	require.Nil(err)
+	assert.Equal(`{"thetawei":"1","gammawei":"2"}`, string(s))
+}
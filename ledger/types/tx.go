@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"math/big"
+	"reflect"
 
 	"github.com/thetatoken/ukulele/common"
 	"github.com/thetatoken/ukulele/common/result"
@@ -20,12 +21,24 @@ Transaction Types:
  - CoinbaseTx           Coinbase transaction for block rewards
  - SlashTx     			Transaction for slashing dishonest user
  - SendTx               Send coins to address
+ - TimelockedSendTx     Send coins locked until a block height
  - ReserveFundTx        Reserve fund for subsequence service payments
  - ReleaseFundTx        Release fund reserved for service payments
+ - ExtendReserveTx      Add fund and collateral to a reserve and extend it
  - ServicePaymentTx     Payments for service
+ - BatchServicePaymentTx Settlement of the payments from many sources to a target
  - SplitRuleTx          Payment split rule
  - UpdateValidatorsTx   Update validator set
  - SmartContractTx      Execute smart contract
+ - DepositStakeTx       Deposit stake to a validator
+ - WithdrawStakeTx      Withdraw stake from a validator
+ - SetGuardiansTx       Register the guardians able to recover an account
+ - RecoveryTx           Recovery of an account by its guardians
+ - CreateTokenTx        Register an application token
+ - ParameterUpdateTx    Schedule an update of the chain parameters
+ - ProposalTx           Submit a governance proposal to the vote of the stakers
+ - VoteTx               Vote on a governance proposal
+ - UnjailTx             Return a validator jailed for downtime to the proposer rotation
 */
 
 // Gas of regular transactions
@@ -33,9 +46,20 @@ const (
 	GasSendTxPerAccount   uint64 = 5000
 	GasReserveFundTx      uint64 = 10000
 	GasReleaseFundTx      uint64 = 10000
+	GasExtendReserveTx    uint64 = 10000
 	GasServicePaymentTx   uint64 = 10000
+	GasBatchedPayment     uint64 = 5000
 	GasSplitRuleTx        uint64 = 10000
 	GasUpdateValidatorsTx uint64 = 10000
+	GasDepositStakeTx     uint64 = 10000
+	GasWithdrawStakeTx    uint64 = 10000
+	GasSetGuardiansTx     uint64 = 10000
+	GasRecoveryTx         uint64 = 10000
+	GasCreateTokenTx      uint64 = 10000
+	GasParameterUpdateTx  uint64 = 10000
+	GasProposalTx         uint64 = 10000
+	GasVoteTx             uint64 = 10000
+	GasUnjailTx           uint64 = 10000
 )
 
 type Tx interface {
@@ -43,6 +67,15 @@ type Tx interface {
 	SignBytes(chainID string) []byte
 }
 
+// TxTypeName returns the name of the type of the transaction, e.g. "SendTx".
+func TxTypeName(tx Tx) string {
+	t := reflect.TypeOf(tx)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Name()
+}
+
 //-----------------------------------------------------------------------------
 
 func TxID(chainID string, tx Tx) common.Hash {
@@ -78,6 +111,9 @@ func encodeToBytes(str string) []byte {
 
 //-----------------------------------------------------------------------------
 
+// TxInput is an input of a transaction. It carries no public key: the public key
+// of the signer is recovered from the signature, so an address can spend without
+// ever publishing its public key, including in its first transaction.
 type TxInput struct {
 	Address   common.Address // Hash of the PubKey
 	Coins     Coins
@@ -125,10 +161,10 @@ func (a *TxInput) UnmarshalJSON(data []byte) error {
 
 func (txIn TxInput) ValidateBasic() result.Result {
 	if len(txIn.Address) != 20 {
-		return result.Error("Invalid address length")
+		return result.Error("Invalid address length").WithErrorCode(result.CodeInvalidTx)
 	}
 	if !txIn.Coins.IsValid() {
-		return result.Error("Invalid coins: %v", txIn.Coins)
+		return result.Error("Invalid coins: %v", txIn.Coins).WithErrorCode(result.CodeInvalidTx)
 	}
 	// if txIn.Coins.IsZero() {
 	// 	return result.Error("Coins cannot be zero")
@@ -166,11 +202,11 @@ type TxOutput struct {
 
 func (txOut TxOutput) ValidateBasic() result.Result {
 	if len(txOut.Address) != 20 {
-		return result.Error("Invalid address length")
+		return result.Error("Invalid address length").WithErrorCode(result.CodeInvalidTx)
 	}
 
 	if !txOut.Coins.IsValid() {
-		return result.Error("Invalid coins: %v", txOut.Coins)
+		return result.Error("Invalid coins: %v", txOut.Coins).WithErrorCode(result.CodeInvalidTx)
 	}
 	// if txOut.Coins.IsZero() {
 	// 	return result.Error("Coins cannot be zero")
@@ -188,28 +224,55 @@ type CoinbaseTx struct {
 	Proposer    TxInput
 	Outputs     []TxOutput
 	BlockHeight uint64
+
+	// Epoch of the block, see GetEpoch. As the tail of the encoding, a coinbase
+	// transaction without it encodes as before it was introduced.
+	Epoch []uint64 `rlp:"tail"`
 }
 
 type CoinbaseTxJSON struct {
-	Proposer    TxInput           `json:"proposer"`
-	Outputs     []TxOutput        `json:"outputs"`
-	BlockHeight common.JSONUint64 `json:"block_height"`
+	Proposer    TxInput            `json:"proposer"`
+	Outputs     []TxOutput         `json:"outputs"`
+	BlockHeight common.JSONUint64  `json:"block_height"`
+	Epoch       *common.JSONUint64 `json:"epoch,omitempty"`
 }
 
 func NewCoinbaseTxJSON(a CoinbaseTx) CoinbaseTxJSON {
-	return CoinbaseTxJSON{
+	b := CoinbaseTxJSON{
 		Proposer:    a.Proposer,
 		Outputs:     a.Outputs,
 		BlockHeight: common.JSONUint64(a.BlockHeight),
 	}
+	if epoch, ok := a.GetEpoch(); ok {
+		b.Epoch = (*common.JSONUint64)(&epoch)
+	}
+	return b
 }
 
 func (a CoinbaseTxJSON) CoinbaseTx() CoinbaseTx {
-	return CoinbaseTx{
+	tx := CoinbaseTx{
 		Proposer:    a.Proposer,
 		Outputs:     a.Outputs,
 		BlockHeight: uint64(a.BlockHeight),
 	}
+	if a.Epoch != nil {
+		tx.SetEpoch(uint64(*a.Epoch))
+	}
+	return tx
+}
+
+// GetEpoch returns the epoch of the block, and false if the proposer did not set it.
+func (a *CoinbaseTx) GetEpoch() (uint64, bool) {
+	if len(a.Epoch) == 0 {
+		return 0, false
+	}
+	return a.Epoch[0], true
+}
+
+// SetEpoch sets the epoch of the block, which the validators track the missed
+// proposals with.
+func (a *CoinbaseTx) SetEpoch(epoch uint64) {
+	a.Epoch = []uint64{epoch}
 }
 
 func (a CoinbaseTx) MarshalJSON() ([]byte, error) {
@@ -369,20 +432,107 @@ func (tx *SendTx) String() string {
 
 //-----------------------------------------------------------------------------
 
+// TimelockedSendTx sends coins which the outputs receive as locked coins, that they
+// cannot spend until the unlock height.
+type TimelockedSendTx struct {
+	Fee          Coins      // Fee
+	Inputs       []TxInput  // Inputs
+	Outputs      []TxOutput // Outputs, receiving locked coins
+	UnlockHeight uint64     // Block height at which the outputs can spend the coins
+}
+
+type TimelockedSendTxJSON struct {
+	Fee          Coins             `json:"fee"`           // Fee
+	Inputs       []TxInput         `json:"inputs"`        // Inputs
+	Outputs      []TxOutput        `json:"outputs"`       // Outputs, receiving locked coins
+	UnlockHeight common.JSONUint64 `json:"unlock_height"` // Block height at which the outputs can spend the coins
+}
+
+func NewTimelockedSendTxJSON(a TimelockedSendTx) TimelockedSendTxJSON {
+	return TimelockedSendTxJSON{
+		Fee:          a.Fee,
+		Inputs:       a.Inputs,
+		Outputs:      a.Outputs,
+		UnlockHeight: common.JSONUint64(a.UnlockHeight),
+	}
+}
+
+func (a TimelockedSendTxJSON) TimelockedSendTx() TimelockedSendTx {
+	return TimelockedSendTx{
+		Fee:          a.Fee,
+		Inputs:       a.Inputs,
+		Outputs:      a.Outputs,
+		UnlockHeight: uint64(a.UnlockHeight),
+	}
+}
+
+func (a TimelockedSendTx) MarshalJSON() ([]byte, error) {
+	return json.Marshal(NewTimelockedSendTxJSON(a))
+}
+
+func (a *TimelockedSendTx) UnmarshalJSON(data []byte) error {
+	var b TimelockedSendTxJSON
+	if err := json.Unmarshal(data, &b); err != nil {
+		return err
+	}
+	*a = b.TimelockedSendTx()
+	return nil
+}
+
+func (_ *TimelockedSendTx) AssertIsTx() {}
+
+func (tx *TimelockedSendTx) SignBytes(chainID string) []byte {
+	signBytes := encodeToBytes(chainID)
+	sigz := make([]*crypto.Signature, len(tx.Inputs))
+	for i := range tx.Inputs {
+		sigz[i] = tx.Inputs[i].Signature
+		tx.Inputs[i].Signature = nil
+	}
+	txBytes, _ := TxToBytes(tx)
+	signBytes = append(signBytes, txBytes...)
+	signBytes = addPrefixForSignBytes(signBytes)
+
+	for i := range tx.Inputs {
+		tx.Inputs[i].Signature = sigz[i]
+	}
+	return signBytes
+}
+
+func (tx *TimelockedSendTx) SetSignature(addr common.Address, sig *crypto.Signature) bool {
+	for i, input := range tx.Inputs {
+		if input.Address == addr {
+			tx.Inputs[i].Signature = sig
+			return true
+		}
+	}
+	return false
+}
+
+func (tx *TimelockedSendTx) String() string {
+	return fmt.Sprintf("TimelockedSendTx{fee: %v, %v->%v, unlock_height: %v}", tx.Fee, tx.Inputs, tx.Outputs, tx.UnlockHeight)
+}
+
+//-----------------------------------------------------------------------------
+
 type ReserveFundTx struct {
 	Fee         Coins    // Fee
 	Source      TxInput  // Source account
 	Collateral  Coins    // Collateral for the micropayment pool
 	ResourceIDs []string // List of resource ID
 	Duration    uint64
+
+	// Optional spend limits of the resources. As the tail of the encoding, a transaction
+	// without limits encodes and signs as before they were introduced.
+	SpendLimits []ResourceSpendLimit `rlp:"tail"`
 }
 
 type ReserveFundTxJSON struct {
-	Fee         Coins             `json:"fee"`          // Fee
-	Source      TxInput           `json:"source"`       // Source account
-	Collateral  Coins             `json:"collateral"`   // Collateral for the micropayment pool
-	ResourceIDs []string          `json:"resource_ids"` // List of resource ID
-	Duration    common.JSONUint64 `json:"duration"`
+	Fee         Coins                `json:"fee"`          // Fee
+	Source      TxInput              `json:"source"`       // Source account
+	Collateral  Coins                `json:"collateral"`   // Collateral for the micropayment pool
+	ResourceIDs []string             `json:"resource_ids"` // List of resource ID
+	Duration    common.JSONUint64    `json:"duration"`
+	SpendLimits []ResourceSpendLimit `json:"spend_limits,omitempty"`
 }
 
 func NewReserveFundTxJSON(a ReserveFundTx) ReserveFundTxJSON {
@@ -392,6 +542,7 @@ func NewReserveFundTxJSON(a ReserveFundTx) ReserveFundTxJSON {
 		Collateral:  a.Collateral,
 		ResourceIDs: a.ResourceIDs,
 		Duration:    common.JSONUint64(a.Duration),
+		SpendLimits: a.SpendLimits,
 	}
 }
 
@@ -402,6 +553,7 @@ func (a ReserveFundTxJSON) ReserveFundTx() ReserveFundTx {
 		Collateral:  a.Collateral,
 		ResourceIDs: a.ResourceIDs,
 		Duration:    uint64(a.Duration),
+		SpendLimits: a.SpendLimits,
 	}
 }
 
@@ -441,8 +593,8 @@ func (tx *ReserveFundTx) SetSignature(addr common.Address, sig *crypto.Signature
 }
 
 func (tx *ReserveFundTx) String() string {
-	return fmt.Sprintf("ReserveFundTx{fee: %v, source: %v, collateral: %v, resource_ids: %v, duration: %v}",
-		tx.Fee, tx.Source, tx.Collateral, tx.ResourceIDs, tx.Duration)
+	return fmt.Sprintf("ReserveFundTx{fee: %v, source: %v, collateral: %v, resource_ids: %v, duration: %v, spend_limits: %v}",
+		tx.Fee, tx.Source, tx.Collateral, tx.ResourceIDs, tx.Duration, tx.SpendLimits)
 }
 
 //-----------------------------------------------------------------------------
@@ -516,6 +668,87 @@ func (tx *ReleaseFundTx) String() string {
 
 //-----------------------------------------------------------------------------
 
+// ExtendReserveTx adds fund and collateral to an existing reserve and/or extends its
+// end, keeping its reserve sequence so that the off-chain payments already signed
+// for the reserve remain valid.
+type ExtendReserveTx struct {
+	Fee             Coins   // Fee
+	Source          TxInput // Source account, the coins are the fund added to the reserve
+	Collateral      Coins   // Collateral added to the reserve
+	Duration        uint64  // Number of blocks the end of the reserve is extended by
+	ReserveSequence uint64  // Reserve sequence of the reserve
+}
+
+type ExtendReserveTxJSON struct {
+	Fee             Coins             `json:"fee"`        // Fee
+	Source          TxInput           `json:"source"`     // Source account, the coins are the fund added to the reserve
+	Collateral      Coins             `json:"collateral"` // Collateral added to the reserve
+	Duration        common.JSONUint64 `json:"duration"`   // Number of blocks the end of the reserve is extended by
+	ReserveSequence common.JSONUint64 `json:"reserve_sequence"`
+}
+
+func NewExtendReserveTxJSON(a ExtendReserveTx) ExtendReserveTxJSON {
+	return ExtendReserveTxJSON{
+		Fee:             a.Fee,
+		Source:          a.Source,
+		Collateral:      a.Collateral,
+		Duration:        common.JSONUint64(a.Duration),
+		ReserveSequence: common.JSONUint64(a.ReserveSequence),
+	}
+}
+
+func (a ExtendReserveTxJSON) ExtendReserveTx() ExtendReserveTx {
+	return ExtendReserveTx{
+		Fee:             a.Fee,
+		Source:          a.Source,
+		Collateral:      a.Collateral,
+		Duration:        uint64(a.Duration),
+		ReserveSequence: uint64(a.ReserveSequence),
+	}
+}
+
+func (a ExtendReserveTx) MarshalJSON() ([]byte, error) {
+	return json.Marshal(NewExtendReserveTxJSON(a))
+}
+
+func (a *ExtendReserveTx) UnmarshalJSON(data []byte) error {
+	var b ExtendReserveTxJSON
+	if err := json.Unmarshal(data, &b); err != nil {
+		return err
+	}
+	*a = b.ExtendReserveTx()
+	return nil
+}
+
+func (_ *ExtendReserveTx) AssertIsTx() {}
+
+func (tx *ExtendReserveTx) SignBytes(chainID string) []byte {
+	signBytes := encodeToBytes(chainID)
+	sig := tx.Source.Signature
+	tx.Source.Signature = nil
+	txBytes, _ := TxToBytes(tx)
+	signBytes = append(signBytes, txBytes...)
+	signBytes = addPrefixForSignBytes(signBytes)
+
+	tx.Source.Signature = sig
+	return signBytes
+}
+
+func (tx *ExtendReserveTx) SetSignature(addr common.Address, sig *crypto.Signature) bool {
+	if tx.Source.Address == addr {
+		tx.Source.Signature = sig
+		return true
+	}
+	return false
+}
+
+func (tx *ExtendReserveTx) String() string {
+	return fmt.Sprintf("ExtendReserveTx{fee: %v, source: %v, collateral: %v, duration: %v, reserve_sequence: %v}",
+		tx.Fee, tx.Source, tx.Collateral, tx.Duration, tx.ReserveSequence)
+}
+
+//-----------------------------------------------------------------------------
+
 type ServicePaymentTx struct {
 	Fee             Coins   // Fee
 	Source          TxInput // source account
@@ -638,39 +871,124 @@ func (tx *ServicePaymentTx) TxBytes() ([]byte, error) {
 
 //-----------------------------------------------------------------------------
 
+// BatchServicePaymentTx settles in one transaction the service payments from many
+// sources to the same target, e.g. an edge node paid by thousands of viewers. Each
+// payment carries the signature of its source over its SourceSignBytes, and the target
+// signs the batch once, paying a single fee for it. The fee, target sequence and
+// target signature of the payments are not used.
+type BatchServicePaymentTx struct {
+	Fee      Coins              // Fee
+	Target   TxInput            // Target account, the target of all the payments
+	Payments []ServicePaymentTx // Payments signed by their sources
+}
+
+type BatchServicePaymentTxJSON struct {
+	Fee      Coins              `json:"fee"`      // Fee
+	Target   TxInput            `json:"target"`   // Target account, the target of all the payments
+	Payments []ServicePaymentTx `json:"payments"` // Payments signed by their sources
+}
+
+func NewBatchServicePaymentTxJSON(a BatchServicePaymentTx) BatchServicePaymentTxJSON {
+	return BatchServicePaymentTxJSON{
+		Fee:      a.Fee,
+		Target:   a.Target,
+		Payments: a.Payments,
+	}
+}
+
+func (a BatchServicePaymentTxJSON) BatchServicePaymentTx() BatchServicePaymentTx {
+	return BatchServicePaymentTx{
+		Fee:      a.Fee,
+		Target:   a.Target,
+		Payments: a.Payments,
+	}
+}
+
+func (a BatchServicePaymentTx) MarshalJSON() ([]byte, error) {
+	return json.Marshal(NewBatchServicePaymentTxJSON(a))
+}
+
+func (a *BatchServicePaymentTx) UnmarshalJSON(data []byte) error {
+	var b BatchServicePaymentTxJSON
+	if err := json.Unmarshal(data, &b); err != nil {
+		return err
+	}
+	*a = b.BatchServicePaymentTx()
+	return nil
+}
+
+func (_ *BatchServicePaymentTx) AssertIsTx() {}
+
+// SignBytes returns the bytes signed by the target, which cover the payments along
+// with the signatures of their sources.
+func (tx *BatchServicePaymentTx) SignBytes(chainID string) []byte {
+	signBytes := encodeToBytes(chainID)
+	sig := tx.Target.Signature
+	tx.Target.Signature = nil
+	txBytes, _ := TxToBytes(tx)
+	signBytes = append(signBytes, txBytes...)
+	signBytes = addPrefixForSignBytes(signBytes)
+
+	tx.Target.Signature = sig
+	return signBytes
+}
+
+func (tx *BatchServicePaymentTx) SetSignature(addr common.Address, sig *crypto.Signature) bool {
+	if tx.Target.Address == addr {
+		tx.Target.Signature = sig
+		return true
+	}
+	return false
+}
+
+func (tx *BatchServicePaymentTx) String() string {
+	return fmt.Sprintf("BatchServicePaymentTx{fee: %v, target: %v, payments: %v}",
+		tx.Fee, tx.Target, tx.Payments)
+}
+
+//-----------------------------------------------------------------------------
+
 type SplitRuleTx struct {
 	Fee        Coins   // Fee
 	ResourceID string  // ResourceID of the payment to be split
 	Initiator  TxInput // Initiator of the split rule
 	Splits     []Split // Agreed splits
 	Duration   uint64  // Duration of the payment split in terms of blocks
+
+	// Optional first-level splits, e.g. a platform fee, applied before the Splits. As
+	// the tail of the encoding, a transaction without them encodes and signs as before
+	// they were introduced.
+	PlatformSplits []Split `rlp:"tail"`
 }
 
 type SplitRuleTxJSON struct {
-	Fee        Coins             `json:"fee"`         // Fee
-	ResourceID string            `json:"resource_id"` // ResourceID of the payment to be split
-	Initiator  TxInput           `json:"initiator"`   // Initiator of the split rule
-	Splits     []Split           `json:"splits"`      // Agreed splits
-	Duration   common.JSONUint64 `json:"duration"`    // Duration of the payment split in terms of blocks
+	Fee            Coins             `json:"fee"`                       // Fee
+	ResourceID     string            `json:"resource_id"`               // ResourceID of the payment to be split
+	Initiator      TxInput           `json:"initiator"`                 // Initiator of the split rule
+	Splits         []Split           `json:"splits"`                    // Agreed splits
+	Duration       common.JSONUint64 `json:"duration"`                  // Duration of the payment split in terms of blocks
+	PlatformSplits []Split           `json:"platform_splits,omitempty"` // Splits applied before the agreed splits
 }
 
 func NewSplitRuleTxJSON(a SplitRuleTx) SplitRuleTxJSON {
 	return SplitRuleTxJSON{
-		Fee:        a.Fee,
-		ResourceID: a.ResourceID,
-		Initiator:  a.Initiator,
-		Splits:     a.Splits,
-		Duration:   common.JSONUint64(a.Duration),
+		Fee:            a.Fee,
+		ResourceID:     a.ResourceID,
+		Initiator:      a.Initiator,
+		Splits:         a.Splits,
+		Duration:       common.JSONUint64(a.Duration),
+		PlatformSplits: a.PlatformSplits,
 	}
 }
 
 func (a SplitRuleTxJSON) SplitRuleTx() SplitRuleTx {
 	return SplitRuleTx{
-		Fee:        a.Fee,
-		ResourceID: a.ResourceID,
-		Initiator:  a.Initiator,
-		Splits:     a.Splits,
-		Duration:   uint64(a.Duration),
+		Fee:            a.Fee,
+		ResourceID:     a.ResourceID,
+		Initiator:      a.Initiator,
+		Splits:         a.Splits,
+		Duration:       uint64(a.Duration),
+		PlatformSplits: a.PlatformSplits,
 	}
 }
 
@@ -710,8 +1028,8 @@ func (tx *SplitRuleTx) SetSignature(addr common.Address, sig *crypto.Signature)
 }
 
 func (tx *SplitRuleTx) String() string {
-	return fmt.Sprintf("SplitRuleTx{fee: %v, resource_id: %v, initiator: %v, splits: %v, duration: %v}",
-		tx.Fee, tx.ResourceID, tx.Initiator, tx.Splits, tx.Duration)
+	return fmt.Sprintf("SplitRuleTx{fee: %v, resource_id: %v, initiator: %v, platform_splits: %v, splits: %v, duration: %v}",
+		tx.Fee, tx.ResourceID, tx.Initiator, tx.PlatformSplits, tx.Splits, tx.Duration)
 }
 
 //-----------------------------------------------------------------------------
@@ -826,6 +1144,712 @@ func (tx *SmartContractTx) String() string {
 		tx.From.Address.Hex(), tx.To.Address.Hex(), tx.From.Coins.GammaWei, tx.GasLimit, tx.GasPrice, tx.Data)
 }
 
+//-----------------------------------------------------------------------------
+
+type DepositStakeTx struct {
+	Fee    Coins    // Fee
+	Source TxInput  // Source staker account
+	Holder TxOutput // Stake holder account, i.e. the validator
+}
+
+type DepositStakeTxJSON struct {
+	Fee    Coins    `json:"fee"`    // Fee
+	Source TxInput  `json:"source"` // Source staker account
+	Holder TxOutput `json:"holder"` // Stake holder account, i.e. the validator
+}
+
+func NewDepositStakeTxJSON(a DepositStakeTx) DepositStakeTxJSON {
+	return DepositStakeTxJSON{
+		Fee:    a.Fee,
+		Source: a.Source,
+		Holder: a.Holder,
+	}
+}
+
+func (a DepositStakeTxJSON) DepositStakeTx() DepositStakeTx {
+	return DepositStakeTx{
+		Fee:    a.Fee,
+		Source: a.Source,
+		Holder: a.Holder,
+	}
+}
+
+func (a DepositStakeTx) MarshalJSON() ([]byte, error) {
+	return json.Marshal(NewDepositStakeTxJSON(a))
+}
+
+func (a *DepositStakeTx) UnmarshalJSON(data []byte) error {
+	var b DepositStakeTxJSON
+	if err := json.Unmarshal(data, &b); err != nil {
+		return err
+	}
+	*a = b.DepositStakeTx()
+	return nil
+}
+
+func (_ *DepositStakeTx) AssertIsTx() {}
+
+func (tx *DepositStakeTx) SignBytes(chainID string) []byte {
+	signBytes := encodeToBytes(chainID)
+	sig := tx.Source.Signature
+	tx.Source.Signature = nil
+	txBytes, _ := TxToBytes(tx)
+	signBytes = append(signBytes, txBytes...)
+	signBytes = addPrefixForSignBytes(signBytes)
+
+	tx.Source.Signature = sig
+	return signBytes
+}
+
+func (tx *DepositStakeTx) SetSignature(addr common.Address, sig *crypto.Signature) bool {
+	if tx.Source.Address == addr {
+		tx.Source.Signature = sig
+		return true
+	}
+	return false
+}
+
+func (tx *DepositStakeTx) String() string {
+	return fmt.Sprintf("DepositStakeTx{%v -> %v, stake: %v, fee: %v}",
+		tx.Source.Address.Hex(), tx.Holder.Address.Hex(), tx.Source.Coins.ThetaWei, tx.Fee)
+}
+
+//-----------------------------------------------------------------------------
+
+type WithdrawStakeTx struct {
+	Fee    Coins    // Fee
+	Source TxInput  // Source staker account
+	Holder TxOutput // Stake holder account, i.e. the validator
+}
+
+type WithdrawStakeTxJSON struct {
+	Fee    Coins    `json:"fee"`    // Fee
+	Source TxInput  `json:"source"` // Source staker account
+	Holder TxOutput `json:"holder"` // Stake holder account, i.e. the validator
+}
+
+func NewWithdrawStakeTxJSON(a WithdrawStakeTx) WithdrawStakeTxJSON {
+	return WithdrawStakeTxJSON{
+		Fee:    a.Fee,
+		Source: a.Source,
+		Holder: a.Holder,
+	}
+}
+
+func (a WithdrawStakeTxJSON) WithdrawStakeTx() WithdrawStakeTx {
+	return WithdrawStakeTx{
+		Fee:    a.Fee,
+		Source: a.Source,
+		Holder: a.Holder,
+	}
+}
+
+func (a WithdrawStakeTx) MarshalJSON() ([]byte, error) {
+	return json.Marshal(NewWithdrawStakeTxJSON(a))
+}
+
+func (a *WithdrawStakeTx) UnmarshalJSON(data []byte) error {
+	var b WithdrawStakeTxJSON
+	if err := json.Unmarshal(data, &b); err != nil {
+		return err
+	}
+	*a = b.WithdrawStakeTx()
+	return nil
+}
+
+func (_ *WithdrawStakeTx) AssertIsTx() {}
+
+func (tx *WithdrawStakeTx) SignBytes(chainID string) []byte {
+	signBytes := encodeToBytes(chainID)
+	sig := tx.Source.Signature
+	tx.Source.Signature = nil
+	txBytes, _ := TxToBytes(tx)
+	signBytes = append(signBytes, txBytes...)
+	signBytes = addPrefixForSignBytes(signBytes)
+
+	tx.Source.Signature = sig
+	return signBytes
+}
+
+func (tx *WithdrawStakeTx) SetSignature(addr common.Address, sig *crypto.Signature) bool {
+	if tx.Source.Address == addr {
+		tx.Source.Signature = sig
+		return true
+	}
+	return false
+}
+
+func (tx *WithdrawStakeTx) String() string {
+	return fmt.Sprintf("WithdrawStakeTx{%v <- %v, fee: %v}",
+		tx.Source.Address.Hex(), tx.Holder.Address.Hex(), tx.Fee)
+}
+
+//-----------------------------------------------------------------------------
+
+type SetGuardiansTx struct {
+	Fee       Coins            // Fee
+	Account   TxInput          // Account registering the guardians
+	Guardians []common.Address // Guardians of the account, none removes the guardianship
+	Threshold uint64           // Number of guardians needed to recover the account
+	Timelock  uint64           // Number of blocks between a recovery request and the key rotation
+}
+
+type SetGuardiansTxJSON struct {
+	Fee       Coins             `json:"fee"`       // Fee
+	Account   TxInput           `json:"account"`   // Account registering the guardians
+	Guardians []common.Address  `json:"guardians"` // Guardians of the account, none removes the guardianship
+	Threshold common.JSONUint64 `json:"threshold"` // Number of guardians needed to recover the account
+	Timelock  common.JSONUint64 `json:"timelock"`  // Number of blocks between a recovery request and the key rotation
+}
+
+func NewSetGuardiansTxJSON(a SetGuardiansTx) SetGuardiansTxJSON {
+	return SetGuardiansTxJSON{
+		Fee:       a.Fee,
+		Account:   a.Account,
+		Guardians: a.Guardians,
+		Threshold: common.JSONUint64(a.Threshold),
+		Timelock:  common.JSONUint64(a.Timelock),
+	}
+}
+
+func (a SetGuardiansTxJSON) SetGuardiansTx() SetGuardiansTx {
+	return SetGuardiansTx{
+		Fee:       a.Fee,
+		Account:   a.Account,
+		Guardians: a.Guardians,
+		Threshold: uint64(a.Threshold),
+		Timelock:  uint64(a.Timelock),
+	}
+}
+
+func (a SetGuardiansTx) MarshalJSON() ([]byte, error) {
+	return json.Marshal(NewSetGuardiansTxJSON(a))
+}
+
+func (a *SetGuardiansTx) UnmarshalJSON(data []byte) error {
+	var b SetGuardiansTxJSON
+	if err := json.Unmarshal(data, &b); err != nil {
+		return err
+	}
+	*a = b.SetGuardiansTx()
+	return nil
+}
+
+func (_ *SetGuardiansTx) AssertIsTx() {}
+
+func (tx *SetGuardiansTx) SignBytes(chainID string) []byte {
+	signBytes := encodeToBytes(chainID)
+	sig := tx.Account.Signature
+	tx.Account.Signature = nil
+	txBytes, _ := TxToBytes(tx)
+	signBytes = append(signBytes, txBytes...)
+	signBytes = addPrefixForSignBytes(signBytes)
+
+	tx.Account.Signature = sig
+	return signBytes
+}
+
+func (tx *SetGuardiansTx) SetSignature(addr common.Address, sig *crypto.Signature) bool {
+	if tx.Account.Address == addr {
+		tx.Account.Signature = sig
+		return true
+	}
+	return false
+}
+
+func (tx *SetGuardiansTx) String() string {
+	return fmt.Sprintf("SetGuardiansTx{fee: %v, account: %v, guardians: %v, threshold: %v, timelock: %v}",
+		tx.Fee, tx.Account, tx.Guardians, tx.Threshold, tx.Timelock)
+}
+
+//-----------------------------------------------------------------------------
+
+// RecoveryTx requests to rotate the key controlling an account to the key of NewSigner.
+// It is signed by the guardians of the account rather than by the account, which pays
+// the fee.
+type RecoveryTx struct {
+	Fee       Coins          // Fee
+	Account   TxInput        // Account to recover, not signed
+	NewSigner common.Address // Address of the key to control the account
+	Guardians []TxInput      // Guardians approving the recovery
+}
+
+type RecoveryTxJSON struct {
+	Fee       Coins          `json:"fee"`        // Fee
+	Account   TxInput        `json:"account"`    // Account to recover, not signed
+	NewSigner common.Address `json:"new_signer"` // Address of the key to control the account
+	Guardians []TxInput      `json:"guardians"`  // Guardians approving the recovery
+}
+
+func NewRecoveryTxJSON(a RecoveryTx) RecoveryTxJSON {
+	return RecoveryTxJSON{
+		Fee:       a.Fee,
+		Account:   a.Account,
+		NewSigner: a.NewSigner,
+		Guardians: a.Guardians,
+	}
+}
+
+func (a RecoveryTxJSON) RecoveryTx() RecoveryTx {
+	return RecoveryTx{
+		Fee:       a.Fee,
+		Account:   a.Account,
+		NewSigner: a.NewSigner,
+		Guardians: a.Guardians,
+	}
+}
+
+func (a RecoveryTx) MarshalJSON() ([]byte, error) {
+	return json.Marshal(NewRecoveryTxJSON(a))
+}
+
+func (a *RecoveryTx) UnmarshalJSON(data []byte) error {
+	var b RecoveryTxJSON
+	if err := json.Unmarshal(data, &b); err != nil {
+		return err
+	}
+	*a = b.RecoveryTx()
+	return nil
+}
+
+func (_ *RecoveryTx) AssertIsTx() {}
+
+// SignBytes returns the bytes signed by each of the guardians, without any signature.
+func (tx *RecoveryTx) SignBytes(chainID string) []byte {
+	signBytes := encodeToBytes(chainID)
+	accountSig := tx.Account.Signature
+	tx.Account.Signature = nil
+	sigs := make([]*crypto.Signature, len(tx.Guardians))
+	for i := range tx.Guardians {
+		sigs[i] = tx.Guardians[i].Signature
+		tx.Guardians[i].Signature = nil
+	}
+	txBytes, _ := TxToBytes(tx)
+	signBytes = append(signBytes, txBytes...)
+	signBytes = addPrefixForSignBytes(signBytes)
+
+	tx.Account.Signature = accountSig
+	for i := range tx.Guardians {
+		tx.Guardians[i].Signature = sigs[i]
+	}
+	return signBytes
+}
+
+func (tx *RecoveryTx) SetSignature(addr common.Address, sig *crypto.Signature) bool {
+	for i := range tx.Guardians {
+		if tx.Guardians[i].Address == addr {
+			tx.Guardians[i].Signature = sig
+			return true
+		}
+	}
+	return false
+}
+
+func (tx *RecoveryTx) String() string {
+	return fmt.Sprintf("RecoveryTx{fee: %v, account: %v, new_signer: %v, guardians: %v}",
+		tx.Fee, tx.Account, tx.NewSigner.Hex(), tx.Guardians)
+}
+
+//-----------------------------------------------------------------------------
+
+// CreateTokenTx registers an application token in the token registry, and mints its
+// maximum supply to the issuer.
+type CreateTokenTx struct {
+	Fee       Coins    // Fee
+	Issuer    TxInput  // Account creating the token
+	Symbol    string   // Symbol of the token, which is also its denomination
+	Decimals  uint64   // Number of decimals of the token, for display
+	MaxSupply *big.Int // Supply of the token, in its smallest unit
+}
+
+type CreateTokenTxJSON struct {
+	Fee       Coins             `json:"fee"`        // Fee
+	Issuer    TxInput           `json:"issuer"`     // Account creating the token
+	Symbol    string            `json:"symbol"`     // Symbol of the token, which is also its denomination
+	Decimals  common.JSONUint64 `json:"decimals"`   // Number of decimals of the token, for display
+	MaxSupply *common.JSONBig   `json:"max_supply"` // Supply of the token, in its smallest unit
+}
+
+func NewCreateTokenTxJSON(a CreateTokenTx) CreateTokenTxJSON {
+	return CreateTokenTxJSON{
+		Fee:       a.Fee,
+		Issuer:    a.Issuer,
+		Symbol:    a.Symbol,
+		Decimals:  common.JSONUint64(a.Decimals),
+		MaxSupply: (*common.JSONBig)(a.MaxSupply),
+	}
+}
+
+func (a CreateTokenTxJSON) CreateTokenTx() CreateTokenTx {
+	return CreateTokenTx{
+		Fee:       a.Fee,
+		Issuer:    a.Issuer,
+		Symbol:    a.Symbol,
+		Decimals:  uint64(a.Decimals),
+		MaxSupply: (*big.Int)(a.MaxSupply),
+	}
+}
+
+func (a CreateTokenTx) MarshalJSON() ([]byte, error) {
+	return json.Marshal(NewCreateTokenTxJSON(a))
+}
+
+func (a *CreateTokenTx) UnmarshalJSON(data []byte) error {
+	var b CreateTokenTxJSON
+	if err := json.Unmarshal(data, &b); err != nil {
+		return err
+	}
+	*a = b.CreateTokenTx()
+	return nil
+}
+
+func (_ *CreateTokenTx) AssertIsTx() {}
+
+func (tx *CreateTokenTx) SignBytes(chainID string) []byte {
+	signBytes := encodeToBytes(chainID)
+	sig := tx.Issuer.Signature
+	tx.Issuer.Signature = nil
+	txBytes, _ := TxToBytes(tx)
+	signBytes = append(signBytes, txBytes...)
+	signBytes = addPrefixForSignBytes(signBytes)
+
+	tx.Issuer.Signature = sig
+	return signBytes
+}
+
+func (tx *CreateTokenTx) SetSignature(addr common.Address, sig *crypto.Signature) bool {
+	if tx.Issuer.Address == addr {
+		tx.Issuer.Signature = sig
+		return true
+	}
+	return false
+}
+
+func (tx *CreateTokenTx) String() string {
+	return fmt.Sprintf("CreateTokenTx{fee: %v, issuer: %v, symbol: %v, decimals: %v, max_supply: %v}",
+		tx.Fee, tx.Issuer, tx.Symbol, tx.Decimals, tx.MaxSupply)
+}
+
+//-----------------------------------------------------------------------------
+
+// ParameterUpdateTx schedules an update of the chain parameters at a future block
+// height. The proposer and the approvers are validators, who together need to hold
+// a supermajority of the stake. The proposer pays the fee.
+type ParameterUpdateTx struct {
+	Fee        Coins           // Fee
+	Proposer   TxInput         // Validator proposing the update
+	Height     uint64          // Block height from which the parameters apply
+	Parameters ChainParameters // Parameters from the height on
+	Approvers  []TxInput       // Other validators approving the update
+}
+
+type ParameterUpdateTxJSON struct {
+	Fee        Coins             `json:"fee"`        // Fee
+	Proposer   TxInput           `json:"proposer"`   // Validator proposing the update
+	Height     common.JSONUint64 `json:"height"`     // Block height from which the parameters apply
+	Parameters ChainParameters   `json:"parameters"` // Parameters from the height on
+	Approvers  []TxInput         `json:"approvers"`  // Other validators approving the update
+}
+
+func NewParameterUpdateTxJSON(a ParameterUpdateTx) ParameterUpdateTxJSON {
+	return ParameterUpdateTxJSON{
+		Fee:        a.Fee,
+		Proposer:   a.Proposer,
+		Height:     common.JSONUint64(a.Height),
+		Parameters: a.Parameters,
+		Approvers:  a.Approvers,
+	}
+}
+
+func (a ParameterUpdateTxJSON) ParameterUpdateTx() ParameterUpdateTx {
+	return ParameterUpdateTx{
+		Fee:        a.Fee,
+		Proposer:   a.Proposer,
+		Height:     uint64(a.Height),
+		Parameters: a.Parameters,
+		Approvers:  a.Approvers,
+	}
+}
+
+func (a ParameterUpdateTx) MarshalJSON() ([]byte, error) {
+	return json.Marshal(NewParameterUpdateTxJSON(a))
+}
+
+func (a *ParameterUpdateTx) UnmarshalJSON(data []byte) error {
+	var b ParameterUpdateTxJSON
+	if err := json.Unmarshal(data, &b); err != nil {
+		return err
+	}
+	*a = b.ParameterUpdateTx()
+	return nil
+}
+
+func (_ *ParameterUpdateTx) AssertIsTx() {}
+
+// SignBytes returns the bytes signed by the proposer and each of the approvers,
+// without any signature.
+func (tx *ParameterUpdateTx) SignBytes(chainID string) []byte {
+	signBytes := encodeToBytes(chainID)
+	proposerSig := tx.Proposer.Signature
+	tx.Proposer.Signature = nil
+	sigs := make([]*crypto.Signature, len(tx.Approvers))
+	for i := range tx.Approvers {
+		sigs[i] = tx.Approvers[i].Signature
+		tx.Approvers[i].Signature = nil
+	}
+	txBytes, _ := TxToBytes(tx)
+	signBytes = append(signBytes, txBytes...)
+	signBytes = addPrefixForSignBytes(signBytes)
+
+	tx.Proposer.Signature = proposerSig
+	for i := range tx.Approvers {
+		tx.Approvers[i].Signature = sigs[i]
+	}
+	return signBytes
+}
+
+func (tx *ParameterUpdateTx) SetSignature(addr common.Address, sig *crypto.Signature) bool {
+	if tx.Proposer.Address == addr {
+		tx.Proposer.Signature = sig
+		return true
+	}
+	for i := range tx.Approvers {
+		if tx.Approvers[i].Address == addr {
+			tx.Approvers[i].Signature = sig
+			return true
+		}
+	}
+	return false
+}
+
+func (tx *ParameterUpdateTx) String() string {
+	return fmt.Sprintf("ParameterUpdateTx{fee: %v, proposer: %v, height: %v, parameters: %v, approvers: %v}",
+		tx.Fee, tx.Proposer, tx.Height, tx.Parameters, tx.Approvers)
+}
+
+//-----------------------------------------------------------------------------
+
+// ProposalTx submits a governance proposal, which the stakers vote on with VoteTxs
+// during VotingPeriod blocks. The proposer needs to be a staker.
+type ProposalTx struct {
+	Fee          Coins          // Fee
+	Proposer     TxInput        // Staker submitting the proposal
+	Change       ProposalChange // Change made by the proposal once passed
+	Description  string         // Description of the proposal
+	VotingPeriod uint64         // Number of blocks the proposal accepts votes
+}
+
+type ProposalTxJSON struct {
+	Fee          Coins             `json:"fee"`           // Fee
+	Proposer     TxInput           `json:"proposer"`      // Staker submitting the proposal
+	Change       ProposalChange    `json:"change"`        // Change made by the proposal once passed
+	Description  string            `json:"description"`   // Description of the proposal
+	VotingPeriod common.JSONUint64 `json:"voting_period"` // Number of blocks the proposal accepts votes
+}
+
+func NewProposalTxJSON(a ProposalTx) ProposalTxJSON {
+	return ProposalTxJSON{
+		Fee:          a.Fee,
+		Proposer:     a.Proposer,
+		Change:       a.Change,
+		Description:  a.Description,
+		VotingPeriod: common.JSONUint64(a.VotingPeriod),
+	}
+}
+
+func (a ProposalTxJSON) ProposalTx() ProposalTx {
+	return ProposalTx{
+		Fee:          a.Fee,
+		Proposer:     a.Proposer,
+		Change:       a.Change,
+		Description:  a.Description,
+		VotingPeriod: uint64(a.VotingPeriod),
+	}
+}
+
+func (a ProposalTx) MarshalJSON() ([]byte, error) {
+	return json.Marshal(NewProposalTxJSON(a))
+}
+
+func (a *ProposalTx) UnmarshalJSON(data []byte) error {
+	var b ProposalTxJSON
+	if err := json.Unmarshal(data, &b); err != nil {
+		return err
+	}
+	*a = b.ProposalTx()
+	return nil
+}
+
+func (_ *ProposalTx) AssertIsTx() {}
+
+func (tx *ProposalTx) SignBytes(chainID string) []byte {
+	signBytes := encodeToBytes(chainID)
+	sig := tx.Proposer.Signature
+	tx.Proposer.Signature = nil
+	txBytes, _ := TxToBytes(tx)
+	signBytes = append(signBytes, txBytes...)
+	signBytes = addPrefixForSignBytes(signBytes)
+
+	tx.Proposer.Signature = sig
+	return signBytes
+}
+
+func (tx *ProposalTx) SetSignature(addr common.Address, sig *crypto.Signature) bool {
+	if tx.Proposer.Address == addr {
+		tx.Proposer.Signature = sig
+		return true
+	}
+	return false
+}
+
+func (tx *ProposalTx) String() string {
+	return fmt.Sprintf("ProposalTx{fee: %v, proposer: %v, change: %v, description: %v, voting_period: %v}",
+		tx.Fee, tx.Proposer, tx.Change, tx.Description, tx.VotingPeriod)
+}
+
+//-----------------------------------------------------------------------------
+
+// VoteTx casts the vote of a staker on an active proposal, weighted by its stake when
+// the proposal is tallied. A later vote replaces the previous one.
+type VoteTx struct {
+	Fee        Coins   // Fee
+	Voter      TxInput // Staker voting
+	ProposalID uint64  // Proposal voted on
+	Approve    bool    // Whether the voter approves the proposal
+}
+
+type VoteTxJSON struct {
+	Fee        Coins             `json:"fee"`         // Fee
+	Voter      TxInput           `json:"voter"`       // Staker voting
+	ProposalID common.JSONUint64 `json:"proposal_id"` // Proposal voted on
+	Approve    bool              `json:"approve"`     // Whether the voter approves the proposal
+}
+
+func NewVoteTxJSON(a VoteTx) VoteTxJSON {
+	return VoteTxJSON{
+		Fee:        a.Fee,
+		Voter:      a.Voter,
+		ProposalID: common.JSONUint64(a.ProposalID),
+		Approve:    a.Approve,
+	}
+}
+
+func (a VoteTxJSON) VoteTx() VoteTx {
+	return VoteTx{
+		Fee:        a.Fee,
+		Voter:      a.Voter,
+		ProposalID: uint64(a.ProposalID),
+		Approve:    a.Approve,
+	}
+}
+
+func (a VoteTx) MarshalJSON() ([]byte, error) {
+	return json.Marshal(NewVoteTxJSON(a))
+}
+
+func (a *VoteTx) UnmarshalJSON(data []byte) error {
+	var b VoteTxJSON
+	if err := json.Unmarshal(data, &b); err != nil {
+		return err
+	}
+	*a = b.VoteTx()
+	return nil
+}
+
+func (_ *VoteTx) AssertIsTx() {}
+
+func (tx *VoteTx) SignBytes(chainID string) []byte {
+	signBytes := encodeToBytes(chainID)
+	sig := tx.Voter.Signature
+	tx.Voter.Signature = nil
+	txBytes, _ := TxToBytes(tx)
+	signBytes = append(signBytes, txBytes...)
+	signBytes = addPrefixForSignBytes(signBytes)
+
+	tx.Voter.Signature = sig
+	return signBytes
+}
+
+func (tx *VoteTx) SetSignature(addr common.Address, sig *crypto.Signature) bool {
+	if tx.Voter.Address == addr {
+		tx.Voter.Signature = sig
+		return true
+	}
+	return false
+}
+
+func (tx *VoteTx) String() string {
+	return fmt.Sprintf("VoteTx{fee: %v, voter: %v, proposal_id: %v, approve: %v}",
+		tx.Fee, tx.Voter, tx.ProposalID, tx.Approve)
+}
+
+//-----------------------------------------------------------------------------
+
+// UnjailTx returns a validator jailed for missing its proposals to the proposer
+// rotation, once the unjail cooldown has passed.
+type UnjailTx struct {
+	Fee       Coins   // Fee
+	Validator TxInput // Jailed validator
+}
+
+type UnjailTxJSON struct {
+	Fee       Coins   `json:"fee"`       // Fee
+	Validator TxInput `json:"validator"` // Jailed validator
+}
+
+func NewUnjailTxJSON(a UnjailTx) UnjailTxJSON {
+	return UnjailTxJSON{
+		Fee:       a.Fee,
+		Validator: a.Validator,
+	}
+}
+
+func (a UnjailTxJSON) UnjailTx() UnjailTx {
+	return UnjailTx{
+		Fee:       a.Fee,
+		Validator: a.Validator,
+	}
+}
+
+func (a UnjailTx) MarshalJSON() ([]byte, error) {
+	return json.Marshal(NewUnjailTxJSON(a))
+}
+
+func (a *UnjailTx) UnmarshalJSON(data []byte) error {
+	var b UnjailTxJSON
+	if err := json.Unmarshal(data, &b); err != nil {
+		return err
+	}
+	*a = b.UnjailTx()
+	return nil
+}
+
+func (_ *UnjailTx) AssertIsTx() {}
+
+func (tx *UnjailTx) SignBytes(chainID string) []byte {
+	signBytes := encodeToBytes(chainID)
+	sig := tx.Validator.Signature
+	tx.Validator.Signature = nil
+	txBytes, _ := TxToBytes(tx)
+	signBytes = append(signBytes, txBytes...)
+	signBytes = addPrefixForSignBytes(signBytes)
+
+	tx.Validator.Signature = sig
+	return signBytes
+}
+
+func (tx *UnjailTx) SetSignature(addr common.Address, sig *crypto.Signature) bool {
+	if tx.Validator.Address == addr {
+		tx.Validator.Signature = sig
+		return true
+	}
+	return false
+}
+
+func (tx *UnjailTx) String() string {
+	return fmt.Sprintf("UnjailTx{fee: %v, validator: %v}", tx.Fee, tx.Validator)
+}
+
 // --------------- Utils --------------- //
 
 // Need to add the following prefix to the tx signbytes to be compatible with
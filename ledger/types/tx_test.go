@@ -10,7 +10,9 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/thetatoken/ukulele/common"
 	"github.com/thetatoken/ukulele/core"
+	"github.com/thetatoken/ukulele/rlp"
 )
 
 var chainID string = "test_chain"
@@ -429,6 +431,100 @@ func TestReleaseFundTxProto(t *testing.T) {
 	assert.False(tx2.Source.Signature.IsEmpty())
 }
 
+func TestExtendReserveTxProto(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	chainID := "test_chain_id"
+	test1PrivAcc := PrivAccountFromSecret("extendreservetx")
+
+	tx := &ExtendReserveTx{
+		Fee:             Coins{ThetaWei: Zero, GammaWei: big.NewInt(111)},
+		Source:          NewTxInput(test1PrivAcc.Address, Coins{ThetaWei: Zero, GammaWei: big.NewInt(10)}, 2),
+		Collateral:      Coins{ThetaWei: Zero, GammaWei: big.NewInt(11)},
+		Duration:        100,
+		ReserveSequence: 1,
+	}
+
+	// serialize this and back
+	b, err := TxToBytes(tx)
+	require.Nil(err)
+	txs, err := TxFromBytes(b)
+	require.Nil(err)
+	tx2 := txs.(*ExtendReserveTx)
+
+	// make sure they are the same!
+	signBytes := tx.SignBytes(chainID)
+	signBytes2 := tx2.SignBytes(chainID)
+	assert.Equal(signBytes, signBytes2)
+	assert.Equal(uint64(100), tx2.Duration)
+	assert.Equal(uint64(1), tx2.ReserveSequence)
+
+	// sign this thing
+	sig := test1PrivAcc.Sign(signBytes)
+	tx.SetSignature(test1PrivAcc.PrivKey.PublicKey().Address(), sig)
+
+	b, err = TxToBytes(tx)
+	require.Nil(err)
+	txs, err = TxFromBytes(b)
+	require.Nil(err)
+	tx2 = txs.(*ExtendReserveTx)
+
+	// and make sure the sig is preserved
+	assert.Equal(tx.Source.Signature, tx2.Source.Signature)
+	assert.False(tx2.Source.Signature.IsEmpty())
+}
+
+func TestBatchServicePaymentTxProto(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	chainID := "test_chain_id"
+	source := PrivAccountFromSecret("batchservicepaymenttxsource")
+	target := PrivAccountFromSecret("batchservicepaymenttxtarget")
+
+	payment := ServicePaymentTx{
+		Source:          TxInput{Address: source.Address, Coins: Coins{ThetaWei: Zero, GammaWei: big.NewInt(10)}},
+		Target:          TxInput{Address: target.Address},
+		PaymentSequence: 3,
+		ReserveSequence: 1,
+		ResourceID:      "rid00123",
+	}
+	payment.SetSourceSignature(source.Sign(payment.SourceSignBytes(chainID)))
+	tx := &BatchServicePaymentTx{
+		Fee:      Coins{ThetaWei: Zero, GammaWei: big.NewInt(111)},
+		Target:   NewTxInput(target.Address, NewCoins(0, 0), 2),
+		Payments: []ServicePaymentTx{payment},
+	}
+
+	// serialize this and back
+	b, err := TxToBytes(tx)
+	require.Nil(err)
+	txs, err := TxFromBytes(b)
+	require.Nil(err)
+	tx2 := txs.(*BatchServicePaymentTx)
+
+	// make sure they are the same!
+	signBytes := tx.SignBytes(chainID)
+	signBytes2 := tx2.SignBytes(chainID)
+	assert.Equal(signBytes, signBytes2)
+	require.Equal(1, len(tx2.Payments))
+	assert.True(tx2.Payments[0].Source.Signature.Verify(tx2.Payments[0].SourceSignBytes(chainID), source.Address))
+
+	// sign this thing
+	sig := target.Sign(signBytes)
+	tx.SetSignature(target.Address, sig)
+
+	b, err = TxToBytes(tx)
+	require.Nil(err)
+	txs, err = TxFromBytes(b)
+	require.Nil(err)
+	tx2 = txs.(*BatchServicePaymentTx)
+
+	// and make sure the sig is preserved
+	assert.Equal(tx.Target.Signature, tx2.Target.Signature)
+	assert.False(tx2.Target.Signature.IsEmpty())
+	assert.True(BatchVerifyTxSignatures(chainID, []Tx{tx2}))
+}
+
 func TestServicePaymentTxSourceSignable(t *testing.T) {
 	servicePaymentTx := &ServicePaymentTx{
 		Fee: Coins{GammaWei: big.NewInt(111)},
@@ -660,6 +756,298 @@ func TestUpdateValidatorsTxProto(t *testing.T) {
 	assert.False(tx2.Proposer.Signature.IsEmpty())
 }
 
+func TestSetGuardiansTxProto(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	chainID := "test_chain_id"
+	account := PrivAccountFromSecret("setguardianstxaccount")
+	guardian := PrivAccountFromSecret("setguardianstxguardian")
+
+	tx := &SetGuardiansTx{
+		Fee:       Coins{ThetaWei: Zero, GammaWei: big.NewInt(111)},
+		Account:   NewTxInput(account.Address, NewCoins(0, 0), 2),
+		Guardians: []common.Address{guardian.Address},
+		Threshold: 1,
+		Timelock:  MinimumRecoveryTimelock,
+	}
+
+	// serialize this and back
+	b, err := TxToBytes(tx)
+	require.Nil(err)
+	txs, err := TxFromBytes(b)
+	require.Nil(err)
+	tx2 := txs.(*SetGuardiansTx)
+
+	// make sure they are the same!
+	signBytes := tx.SignBytes(chainID)
+	signBytes2 := tx2.SignBytes(chainID)
+	assert.Equal(signBytes, signBytes2)
+	assert.Equal(tx.Guardians, tx2.Guardians)
+
+	// sign this thing
+	sig := account.Sign(signBytes)
+	assert.True(tx.SetSignature(account.Address, sig))
+	assert.False(tx.SetSignature(guardian.Address, sig))
+
+	b, err = TxToBytes(tx)
+	require.Nil(err)
+	txs, err = TxFromBytes(b)
+	require.Nil(err)
+	tx2 = txs.(*SetGuardiansTx)
+
+	// and make sure the sig is preserved
+	assert.Equal(tx.Account.Signature, tx2.Account.Signature)
+	assert.False(tx2.Account.Signature.IsEmpty())
+}
+
+func TestRecoveryTxProto(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	chainID := "test_chain_id"
+	account := PrivAccountFromSecret("recoverytxaccount")
+	newSigner := PrivAccountFromSecret("recoverytxnewsigner")
+	guardian1 := PrivAccountFromSecret("recoverytxguardian1")
+	guardian2 := PrivAccountFromSecret("recoverytxguardian2")
+
+	tx := &RecoveryTx{
+		Fee:       Coins{ThetaWei: Zero, GammaWei: big.NewInt(111)},
+		Account:   NewTxInput(account.Address, NewCoins(0, 0), 3),
+		NewSigner: newSigner.Address,
+		Guardians: []TxInput{{Address: guardian1.Address}, {Address: guardian2.Address}},
+	}
+
+	// The guardians sign the same bytes, whatever the signatures already added
+	signBytes := tx.SignBytes(chainID)
+	assert.True(tx.SetSignature(guardian1.Address, guardian1.Sign(signBytes)))
+	assert.Equal(signBytes, tx.SignBytes(chainID))
+	assert.True(tx.SetSignature(guardian2.Address, guardian2.Sign(signBytes)))
+	assert.False(tx.SetSignature(account.Address, account.Sign(signBytes)))
+
+	// serialize this and back
+	b, err := TxToBytes(tx)
+	require.Nil(err)
+	txs, err := TxFromBytes(b)
+	require.Nil(err)
+	tx2 := txs.(*RecoveryTx)
+
+	// and make sure the sigs are preserved
+	assert.Equal(signBytes, tx2.SignBytes(chainID))
+	assert.Equal(newSigner.Address, tx2.NewSigner)
+	require.Equal(2, len(tx2.Guardians))
+	assert.True(tx2.Guardians[0].Signature.Verify(signBytes, guardian1.Address))
+	assert.True(tx2.Guardians[1].Signature.Verify(signBytes, guardian2.Address))
+	assert.True(BatchVerifyTxSignatures(chainID, []Tx{tx2}))
+}
+
+func TestTimelockedSendTxProto(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	chainID := "test_chain_id"
+	sender := PrivAccountFromSecret("timelockedsendtxsender")
+	recipient := PrivAccountFromSecret("timelockedsendtxrecipient")
+
+	tx := &TimelockedSendTx{
+		Fee:          Coins{ThetaWei: Zero, GammaWei: big.NewInt(111)},
+		Inputs:       []TxInput{NewTxInput(sender.Address, NewCoins(10, 111), 5)},
+		Outputs:      []TxOutput{{Address: recipient.Address, Coins: NewCoins(10, 0)}},
+		UnlockHeight: 12345,
+	}
+	signBytes := tx.SignBytes(chainID)
+	assert.True(tx.SetSignature(sender.Address, sender.Sign(signBytes)))
+
+	// The unlock height is signed, and the tx differs from a SendTx
+	sendTx := &SendTx{Fee: tx.Fee, Inputs: tx.Inputs, Outputs: tx.Outputs}
+	assert.NotEqual(signBytes, sendTx.SignBytes(chainID))
+
+	// serialize this and back
+	b, err := TxToBytes(tx)
+	require.Nil(err)
+	txs, err := TxFromBytes(b)
+	require.Nil(err)
+	tx2 := txs.(*TimelockedSendTx)
+
+	// and make sure the sig is preserved
+	assert.Equal(signBytes, tx2.SignBytes(chainID))
+	assert.Equal(uint64(12345), tx2.UnlockHeight)
+	assert.True(tx2.Inputs[0].Signature.Verify(signBytes, sender.Address))
+	assert.True(BatchVerifyTxSignatures(chainID, []Tx{tx2}))
+}
+
+func TestCreateTokenTxProto(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	chainID := "test_chain_id"
+	issuer := PrivAccountFromSecret("createtokentxissuer")
+
+	tx := &CreateTokenTx{
+		Fee:       Coins{ThetaWei: Zero, GammaWei: big.NewInt(111)},
+		Issuer:    NewTxInput(issuer.Address, NewCoins(0, 0), 2),
+		Symbol:    "ABC",
+		Decimals:  6,
+		MaxSupply: big.NewInt(1000000000),
+	}
+	signBytes := tx.SignBytes(chainID)
+	assert.True(tx.SetSignature(issuer.Address, issuer.Sign(signBytes)))
+
+	// serialize this and back
+	b, err := TxToBytes(tx)
+	require.Nil(err)
+	txs, err := TxFromBytes(b)
+	require.Nil(err)
+	tx2 := txs.(*CreateTokenTx)
+
+	// and make sure the sig is preserved
+	assert.Equal(signBytes, tx2.SignBytes(chainID))
+	assert.Equal("ABC", tx2.Symbol)
+	assert.Equal(uint64(6), tx2.Decimals)
+	assert.Equal(big.NewInt(1000000000), tx2.MaxSupply)
+	assert.True(tx2.Issuer.Signature.Verify(signBytes, issuer.Address))
+	assert.True(BatchVerifyTxSignatures(chainID, []Tx{tx2}))
+}
+
+func TestParameterUpdateTxProto(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	chainID := "test_chain_id"
+	proposer := PrivAccountFromSecret("parameterupdatetxproposer")
+	approver := PrivAccountFromSecret("parameterupdatetxapprover")
+
+	tx := &ParameterUpdateTx{
+		Fee:      Coins{ThetaWei: Zero, GammaWei: big.NewInt(111)},
+		Proposer: NewTxInput(proposer.Address, NewCoins(0, 0), 3),
+		Height:   1000,
+		Parameters: ChainParameters{
+			MinimumTransactionFeeGammaWei: 2e12,
+			MaxBlockGas:                   MinimumMaxBlockGas,
+			SlashCollateralPercent:        50,
+			BlockRewardGammaWei:           big.NewInt(1000),
+		},
+		Approvers: []TxInput{{Address: approver.Address}},
+	}
+	signBytes := tx.SignBytes(chainID)
+	assert.True(tx.SetSignature(proposer.Address, proposer.Sign(signBytes)))
+	assert.Equal(signBytes, tx.SignBytes(chainID))
+	assert.True(tx.SetSignature(approver.Address, approver.Sign(signBytes)))
+
+	// serialize this and back
+	b, err := TxToBytes(tx)
+	require.Nil(err)
+	txs, err := TxFromBytes(b)
+	require.Nil(err)
+	tx2 := txs.(*ParameterUpdateTx)
+
+	// and make sure the sigs are preserved
+	assert.Equal(signBytes, tx2.SignBytes(chainID))
+	assert.Equal(uint64(1000), tx2.Height)
+	assert.Equal(tx.Parameters, tx2.Parameters)
+	assert.True(tx2.Proposer.Signature.Verify(signBytes, proposer.Address))
+	assert.True(tx2.Approvers[0].Signature.Verify(signBytes, approver.Address))
+	assert.True(BatchVerifyTxSignatures(chainID, []Tx{tx2}))
+}
+
+func TestProposalTxProto(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	chainID := "test_chain_id"
+	proposer := PrivAccountFromSecret("proposaltxproposer")
+
+	tx := &ProposalTx{
+		Fee:      Coins{ThetaWei: Zero, GammaWei: big.NewInt(111)},
+		Proposer: NewTxInput(proposer.Address, NewCoins(0, 0), 3),
+		Change: ProposalChange{
+			Kind:         ProposalJailValidator,
+			Validator:    PrivAccountFromSecret("proposaltxvalidator").Address,
+			JailDuration: 10,
+		},
+		Description:  "test proposal",
+		VotingPeriod: MinimumProposalVotingPeriod,
+	}
+	signBytes := tx.SignBytes(chainID)
+	assert.True(tx.SetSignature(proposer.Address, proposer.Sign(signBytes)))
+	assert.Equal(signBytes, tx.SignBytes(chainID))
+
+	// serialize this and back
+	b, err := TxToBytes(tx)
+	require.Nil(err)
+	txs, err := TxFromBytes(b)
+	require.Nil(err)
+	tx2 := txs.(*ProposalTx)
+
+	// and make sure the sigs are preserved
+	assert.Equal(signBytes, tx2.SignBytes(chainID))
+	assert.Equal(tx.Change.Kind, tx2.Change.Kind)
+	assert.Equal(tx.Change.Validator, tx2.Change.Validator)
+	assert.Equal(tx.Change.JailDuration, tx2.Change.JailDuration)
+	assert.Equal(tx.Description, tx2.Description)
+	assert.True(tx2.Proposer.Signature.Verify(signBytes, proposer.Address))
+}
+
+func TestVoteTxProto(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	chainID := "test_chain_id"
+	voter := PrivAccountFromSecret("votetxvoter")
+
+	tx := &VoteTx{
+		Fee:        Coins{ThetaWei: Zero, GammaWei: big.NewInt(111)},
+		Voter:      NewTxInput(voter.Address, NewCoins(0, 0), 4),
+		ProposalID: 7,
+		Approve:    true,
+	}
+	signBytes := tx.SignBytes(chainID)
+	assert.True(tx.SetSignature(voter.Address, voter.Sign(signBytes)))
+
+	// serialize this and back
+	b, err := TxToBytes(tx)
+	require.Nil(err)
+	txs, err := TxFromBytes(b)
+	require.Nil(err)
+	tx2 := txs.(*VoteTx)
+
+	// and make sure the sigs are preserved
+	assert.Equal(signBytes, tx2.SignBytes(chainID))
+	assert.Equal(uint64(7), tx2.ProposalID)
+	assert.True(tx2.Approve)
+	assert.True(tx2.Voter.Signature.Verify(signBytes, voter.Address))
+}
+
+func TestCoinbaseTxEpoch(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	tx := &CoinbaseTx{
+		Proposer:    TxInput{Address: PrivAccountFromSecret("coinbasetxproposer").Address},
+		BlockHeight: 10,
+	}
+	_, ok := tx.GetEpoch()
+	assert.False(ok)
+	raw, err := TxToBytes(tx)
+	require.Nil(err)
+
+	// The coinbase transactions without epoch keep their encoding
+	legacy, err := rlp.EncodeToBytes(struct {
+		Proposer    TxInput
+		Outputs     []TxOutput
+		BlockHeight uint64
+	}{tx.Proposer, tx.Outputs, tx.BlockHeight})
+	require.Nil(err)
+	assert.Equal(legacy, raw[1:]) // after the transaction type
+
+	tx.SetEpoch(25)
+	rawWithEpoch, err := TxToBytes(tx)
+	require.Nil(err)
+
+	decoded, err := TxFromBytes(raw)
+	require.Nil(err)
+	_, ok = decoded.(*CoinbaseTx).GetEpoch()
+	assert.False(ok)
+
+	decoded, err = TxFromBytes(rawWithEpoch)
+	require.Nil(err)
+	epoch, ok := decoded.(*CoinbaseTx).GetEpoch()
+	assert.True(ok)
+	assert.Equal(uint64(25), epoch)
+}
+
 func TestCoinbaseTxJSON(t *testing.T) {
 	assert := assert.New(t)
 	require := require.New(t)
@@ -724,6 +1112,43 @@ func TestReleaseFundTxJSON(t *testing.T) {
 	assert.Equal(uint64(math.MaxUint64), d.ReserveSequence)
 }
 
+func TestExtendReserveTxJSON(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	a := ExtendReserveTx{
+		Duration:        math.MaxUint64,
+		ReserveSequence: math.MaxUint64,
+	}
+	s, err := json.Marshal(a)
+	require.Nil(err)
+
+	var d ExtendReserveTx
+	err = json.Unmarshal(s, &d)
+	require.Nil(err)
+	assert.Equal(uint64(math.MaxUint64), d.Duration)
+	assert.Equal(uint64(math.MaxUint64), d.ReserveSequence)
+}
+
+func TestBatchServicePaymentTxJSON(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	a := BatchServicePaymentTx{
+		Target:   TxInput{Sequence: math.MaxUint64},
+		Payments: []ServicePaymentTx{{ReserveSequence: math.MaxUint64}},
+	}
+	s, err := json.Marshal(a)
+	require.Nil(err)
+
+	var d BatchServicePaymentTx
+	err = json.Unmarshal(s, &d)
+	require.Nil(err)
+	assert.Equal(uint64(math.MaxUint64), d.Target.Sequence)
+	require.Equal(1, len(d.Payments))
+	assert.Equal(uint64(math.MaxUint64), d.Payments[0].ReserveSequence)
+}
+
 func TestServicePaymentTxJSON(t *testing.T) {
 	assert := assert.New(t)
 	require := require.New(t)
@@ -756,6 +1181,45 @@ func TestSplitRuleTxJSON(t *testing.T) {
 	assert.Equal(uint64(math.MaxUint64), d.Duration)
 }
 
+func TestSetGuardiansTxJSON(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	a := SetGuardiansTx{
+		Account:   TxInput{Sequence: math.MaxUint64},
+		Threshold: math.MaxUint64,
+		Timelock:  math.MaxUint64,
+	}
+	s, err := json.Marshal(a)
+	require.Nil(err)
+
+	var d SetGuardiansTx
+	err = json.Unmarshal(s, &d)
+	require.Nil(err)
+	assert.Equal(uint64(math.MaxUint64), d.Account.Sequence)
+	assert.Equal(uint64(math.MaxUint64), d.Threshold)
+	assert.Equal(uint64(math.MaxUint64), d.Timelock)
+}
+
+func TestRecoveryTxJSON(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	a := RecoveryTx{
+		Account:   TxInput{Sequence: math.MaxUint64},
+		Guardians: []TxInput{{Sequence: math.MaxUint64}},
+	}
+	s, err := json.Marshal(a)
+	require.Nil(err)
+
+	var d RecoveryTx
+	err = json.Unmarshal(s, &d)
+	require.Nil(err)
+	assert.Equal(uint64(math.MaxUint64), d.Account.Sequence)
+	require.Equal(1, len(d.Guardians))
+	assert.Equal(uint64(math.MaxUint64), d.Guardians[0].Sequence)
+}
+
 func TestSmartContractTxJSON(t *testing.T) {
 	assert := assert.New(t)
 	require := require.New(t)
@@ -0,0 +1,263 @@
+// Package lightclient follows the chain from the block headers and the commit
+// certificates of the blocks, without their transactions or the ledger state, and
+// verifies the Merkle proofs of transactions and accounts against the verified
+// headers. It is meant for clients that cannot run a full node, e.g. on mobile or
+// edge devices, and get the headers, certificates and proofs from untrusted nodes.
+package lightclient
+
+import (
+	"bytes"
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/core"
+	"github.com/thetatoken/ukulele/ledger/state"
+	"github.com/thetatoken/ukulele/ledger/types"
+	"github.com/thetatoken/ukulele/store/trie"
+)
+
+// epochValidatorSet is a validator set, and the epoch from which it is in effect.
+type epochValidatorSet struct {
+	epoch      uint64
+	validators *core.ValidatorSet
+}
+
+// validatorSetChange is a validator set change committed by a verified header, whose
+// validator set is not added yet.
+type validatorSetChange struct {
+	epoch  uint64 // Epoch from which the validator set is in effect
+	height uint64 // Height of the header committing to the validator set
+	hash   common.Hash
+}
+
+// Client maintains a chain of headers, each certified by the votes of a majority
+// of the validators of its epoch, from a trusted root header, e.g. of a checkpoint.
+// It follows the validator set changes committed by the headers.
+type Client struct {
+	mu sync.RWMutex
+
+	chainID       string
+	validatorSets []epochValidatorSet // by ascending epoch
+	headers       []*core.BlockHeader // by ascending height, from the root
+	pendingChange *validatorSetChange
+}
+
+// NewClient creates a client trusting the given root header, and the validators of
+// the epoch of the root and after.
+func NewClient(chainID string, root *core.BlockHeader, validators *core.ValidatorSet) *Client {
+	return &Client{
+		chainID:       chainID,
+		validatorSets: []epochValidatorSet{{epoch: root.Epoch, validators: validators}},
+		headers:       []*core.BlockHeader{root},
+	}
+}
+
+// SetValidatorSet sets the validators in effect from the given epoch, replacing the
+// validator sets of the epochs after it. The validator set needs to come from a
+// trusted source, unlike the validator sets added with AddValidatorSet.
+func (c *Client) SetValidatorSet(epoch uint64, validators *core.ValidatorSet) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.setValidatorSet(epoch, validators)
+}
+
+func (c *Client) setValidatorSet(epoch uint64, validators *core.ValidatorSet) {
+	i := sort.Search(len(c.validatorSets), func(i int) bool { return c.validatorSets[i].epoch >= epoch })
+	c.validatorSets = append(c.validatorSets[:i], epochValidatorSet{epoch: epoch, validators: validators})
+}
+
+// PendingValidatorSet returns the epoch from which the validator set committed by a
+// verified header is in effect, and its hash, if the validator set is not added yet.
+// The headers of that epoch and after cannot be verified until it is added.
+func (c *Client) PendingValidatorSet() (epoch uint64, hash common.Hash, pending bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.pendingChange == nil {
+		return 0, common.Hash{}, false
+	}
+	return c.pendingChange.epoch, c.pendingChange.hash, true
+}
+
+// AddValidatorSet adds the validator set committed by a verified header, e.g. as
+// served by an untrusted node, after checking it against the hash in the header.
+func (c *Client) AddValidatorSet(validators *core.ValidatorSet) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	change := c.pendingChange
+	if change == nil {
+		return errors.New("No validator set change is pending")
+	}
+	if hash := validators.Hash(); hash != change.hash {
+		return errors.Errorf("Validator set %v does not match the hash %v committed at height %v", hash.Hex(), change.hash.Hex(), change.height)
+	}
+	c.setValidatorSet(change.epoch, validators)
+	c.pendingChange = nil
+	return nil
+}
+
+// ValidatorSet returns the validators of the given epoch, nil if the epoch is before
+// the root.
+func (c *Client) ValidatorSet(epoch uint64) *core.ValidatorSet {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.validatorSet(epoch)
+}
+
+func (c *Client) validatorSet(epoch uint64) *core.ValidatorSet {
+	i := sort.Search(len(c.validatorSets), func(i int) bool { return c.validatorSets[i].epoch > epoch })
+	if i == 0 {
+		return nil
+	}
+	return c.validatorSets[i-1].validators
+}
+
+// Head returns the highest verified header.
+func (c *Client) Head() *core.BlockHeader {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.headers[len(c.headers)-1]
+}
+
+// HeaderByHeight returns the verified header at the given height.
+func (c *Client) HeaderByHeight(height uint64) (*core.BlockHeader, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	root := c.headers[0]
+	if height < root.Height || height-root.Height >= uint64(len(c.headers)) {
+		return nil, errors.Errorf("No verified header at height %v", height)
+	}
+	return c.headers[height-root.Height], nil
+}
+
+// AddHeader verifies a header and the commit certificate of its block, and appends
+// the header to the chain. The header needs to be the child of the head.
+func (c *Client) AddHeader(header *core.BlockHeader, cc *core.CommitCertificate) error {
+	return c.AddHeaders([]*core.BlockHeader{header}, cc)
+}
+
+// AddHeaders verifies a chain of headers from the child of the head, and the commit
+// certificate of the last header, and appends the headers to the chain. The blocks
+// finalized along with a certified descendant do not need a certificate of their own.
+// A header committing to a validator set change needs to be certified by the
+// validators of its own epoch, and the headers of the epochs after it can only be
+// added once its validator set is added with AddValidatorSet.
+func (c *Client) AddHeaders(headers []*core.BlockHeader, cc *core.CommitCertificate) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(headers) == 0 {
+		return errors.New("No header to add")
+	}
+	head := c.headers[len(c.headers)-1]
+	change := c.pendingChange
+	for _, header := range headers {
+		hash := header.Hash()
+		if res := header.Validate(); res.IsError() {
+			return errors.Errorf("Header %v is invalid: %v", hash.Hex(), res.Message)
+		}
+		if header.ChainID != c.chainID {
+			return errors.Errorf("ChainID mismatch: header.ChainID(%s) != %s", header.ChainID, c.chainID)
+		}
+		if header.Parent != head.Hash() || header.Height != head.Height+1 {
+			return errors.Errorf("Header %v is not a child of the head %v", hash.Hex(), head.Hash().Hex())
+		}
+		if header.Epoch < head.Epoch {
+			return errors.Errorf("Header %v has epoch %v, before the epoch %v of its parent", hash.Hex(), header.Epoch, head.Epoch)
+		}
+		if change != nil && header.Epoch >= change.epoch {
+			return errors.Errorf("Header %v needs the validator set committed at height %v", hash.Hex(), change.height)
+		}
+		if setHash := header.GetValidatorSetHash(); !setHash.IsEmpty() {
+			change = &validatorSetChange{epoch: header.Epoch + 1, height: header.Height, hash: setHash}
+		}
+		head = header
+	}
+	if err := c.verifyCommitCertificate(head, cc); err != nil {
+		return err
+	}
+	c.headers = append(c.headers, headers...)
+	c.pendingChange = change
+	return nil
+}
+
+// verifyCommitCertificate checks that the validators of the epoch of the header with
+// a majority of the stake signed votes for the header.
+func (c *Client) verifyCommitCertificate(header *core.BlockHeader, cc *core.CommitCertificate) error {
+	hash := header.Hash()
+	if cc == nil || cc.Votes == nil || cc.BlockHash != hash {
+		return errors.Errorf("No commit certificate for header %v", hash.Hex())
+	}
+	validators := c.validatorSet(header.Epoch)
+	if validators == nil {
+		return errors.Errorf("No validator set for epoch %v", header.Epoch)
+	}
+	votes := cc.Votes.FilterByBlock(header)
+	if res := votes.Validate(); res.IsError() {
+		return errors.Errorf("Invalid commit certificate for header %v: %v", hash.Hex(), res.Message)
+	}
+	if !validators.HasMajority(votes.UniqueVoter()) {
+		return errors.Errorf("Commit certificate for header %v has no majority of the validators", hash.Hex())
+	}
+	return nil
+}
+
+// findHeader returns the verified header of the given hash.
+func (c *Client) findHeader(hash common.Hash) (*core.BlockHeader, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for i := len(c.headers) - 1; i >= 0; i-- {
+		if c.headers[i].Hash() == hash {
+			return c.headers[i], nil
+		}
+	}
+	return nil, errors.Errorf("Header %v is not verified", hash.Hex())
+}
+
+// VerifyTx checks the proof, made by core.ProveTx, that tx is the transaction at the
+// given index of a block with a verified header.
+func (c *Client) VerifyTx(block common.Hash, index int, tx common.Bytes, proof []common.Bytes) error {
+	header, err := c.findHeader(block)
+	if err != nil {
+		return err
+	}
+	if header.TxHash.IsEmpty() {
+		return errors.Errorf("Header %v has no TxHash", block.Hex())
+	}
+	value, _, err := trie.VerifyProof(header.TxHash, core.TxKey(index), trie.NewProofSet(proof))
+	if err != nil {
+		return errors.Wrap(err, "Invalid transaction proof")
+	}
+	if value == nil || !bytes.Equal(value, tx) {
+		return errors.Errorf("Transaction %v of block %v does not match the proof", index, block.Hex())
+	}
+	return nil
+}
+
+// VerifyAccount checks the proof, made by StoreView.ProveAccount, of the account at
+// the given address in the ledger state of a block with a verified header. It
+// returns nil if the proof shows that the account does not exist.
+func (c *Client) VerifyAccount(block common.Hash, addr common.Address, proof []common.Bytes) (*types.Account, error) {
+	header, err := c.findHeader(block)
+	if err != nil {
+		return nil, err
+	}
+	value, _, err := trie.VerifyProof(header.StateHash, state.AccountKey(addr), trie.NewProofSet(proof))
+	if err != nil {
+		return nil, errors.Wrap(err, "Invalid account proof")
+	}
+	if len(value) == 0 {
+		return nil, nil
+	}
+	account := &types.Account{}
+	if err := types.FromBytes(value, account); err != nil {
+		return nil, errors.Wrapf(err, "Failed to decode account %v", addr.Hex())
+	}
+	return account, nil
+}
@@ -0,0 +1,198 @@
+package lightclient
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/core"
+	"github.com/thetatoken/ukulele/crypto"
+	"github.com/thetatoken/ukulele/ledger/state"
+	"github.com/thetatoken/ukulele/ledger/types"
+	"github.com/thetatoken/ukulele/store/database/backend"
+)
+
+func createValidators(require *require.Assertions, n int) ([]*crypto.PrivateKey, *core.ValidatorSet) {
+	keys := []*crypto.PrivateKey{}
+	validators := core.NewValidatorSet()
+	for i := 0; i < n; i++ {
+		privKey, pubKey, err := crypto.GenerateKeyPair()
+		require.Nil(err)
+		keys = append(keys, privKey)
+		validators.AddValidator(core.NewValidator(pubKey.ToBytes(), 100))
+	}
+	return keys, validators
+}
+
+func createCC(require *require.Assertions, header *core.BlockHeader, keys []*crypto.PrivateKey) *core.CommitCertificate {
+	votes := core.NewVoteSet()
+	for _, key := range keys {
+		vote := core.Vote{Block: header.Hash(), Height: header.Height, Epoch: header.Epoch, ID: key.PublicKey().Address()}
+		sig, err := key.Sign(vote.SignBytes())
+		require.Nil(err)
+		vote.SetSignature(sig)
+		votes.AddVote(vote)
+	}
+	return &core.CommitCertificate{Votes: votes, BlockHash: header.Hash()}
+}
+
+func createHeader(parent *core.BlockHeader) *core.BlockHeader {
+	return &core.BlockHeader{
+		ChainID:   parent.ChainID,
+		Epoch:     parent.Epoch + 1,
+		Height:    parent.Height + 1,
+		Parent:    parent.Hash(),
+		Timestamp: big.NewInt(0),
+	}
+}
+
+func TestAddHeader(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	keys, validators := createValidators(require, 4)
+	root := &core.BlockHeader{ChainID: "testchain", Timestamp: big.NewInt(0)}
+	client := NewClient("testchain", root, validators)
+
+	h1 := createHeader(root)
+	assert.NotNil(client.AddHeader(h1, nil))
+	assert.NotNil(client.AddHeader(h1, createCC(require, h1, keys[:2])))
+	assert.NotNil(client.AddHeader(h1, createCC(require, root, keys)))
+	outsiders, _ := createValidators(require, 3)
+	assert.NotNil(client.AddHeader(h1, createCC(require, h1, append(outsiders, keys[0]))))
+	replayed := *h1
+	replayed.Height = 5
+	assert.NotNil(client.AddHeader(h1, &core.CommitCertificate{Votes: createCC(require, &replayed, keys).Votes, BlockHash: h1.Hash()}))
+	require.Nil(client.AddHeader(h1, createCC(require, h1, keys[:3])))
+	assert.Equal(h1.Hash(), client.Head().Hash())
+
+	// Not a child of the head
+	orphan := createHeader(root)
+	orphan.Epoch = 5
+	assert.NotNil(client.AddHeader(orphan, createCC(require, orphan, keys)))
+
+	// The validator set changes from epoch 2
+	newKeys, newValidators := createValidators(require, 2)
+	client.SetValidatorSet(2, newValidators)
+	assert.Equal(validators, client.ValidatorSet(1))
+	assert.Equal(newValidators, client.ValidatorSet(2))
+	h2 := createHeader(h1)
+	assert.NotNil(client.AddHeader(h2, createCC(require, h2, keys)))
+	require.Nil(client.AddHeader(h2, createCC(require, h2, newKeys)))
+
+	header, err := client.HeaderByHeight(1)
+	require.Nil(err)
+	assert.Equal(h1.Hash(), header.Hash())
+	_, err = client.HeaderByHeight(3)
+	assert.NotNil(err)
+}
+
+func TestAddHeaders(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	keys, validators := createValidators(require, 4)
+	root := &core.BlockHeader{ChainID: "testchain", Timestamp: big.NewInt(0)}
+	client := NewClient("testchain", root, validators)
+
+	h1 := createHeader(root)
+	h2 := createHeader(h1)
+	h3 := createHeader(h2)
+	assert.NotNil(client.AddHeaders(nil, createCC(require, h1, keys)))
+	assert.NotNil(client.AddHeaders([]*core.BlockHeader{h1, h2, h3}, createCC(require, h2, keys)))
+	assert.NotNil(client.AddHeaders([]*core.BlockHeader{h1, h3}, createCC(require, h3, keys)))
+	assert.Equal(root.Hash(), client.Head().Hash())
+
+	// Only the last header needs a commit certificate
+	require.Nil(client.AddHeaders([]*core.BlockHeader{h1, h2, h3}, createCC(require, h3, keys)))
+	assert.Equal(h3.Hash(), client.Head().Hash())
+	header, err := client.HeaderByHeight(2)
+	require.Nil(err)
+	assert.Equal(h2.Hash(), header.Hash())
+}
+
+func TestValidatorSetChange(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	keys, validators := createValidators(require, 4)
+	newKeys, newValidators := createValidators(require, 3)
+	root := &core.BlockHeader{ChainID: "testchain", Timestamp: big.NewInt(0)}
+	client := NewClient("testchain", root, validators)
+	assert.NotNil(client.AddValidatorSet(newValidators))
+
+	// h2 commits to the validator set in effect from epoch 3, the epoch of h3
+	h1 := createHeader(root)
+	h2 := createHeader(h1)
+	h2.SetValidatorSetHash(newValidators.Hash())
+	h3 := createHeader(h2)
+	assert.NotNil(client.AddHeaders([]*core.BlockHeader{h1, h2, h3}, createCC(require, h3, newKeys)))
+	require.Nil(client.AddHeaders([]*core.BlockHeader{h1, h2}, createCC(require, h2, keys)))
+	epoch, hash, pending := client.PendingValidatorSet()
+	assert.True(pending)
+	assert.Equal(uint64(3), epoch)
+	assert.Equal(newValidators.Hash(), hash)
+
+	// The headers after the change need the new validator set
+	assert.NotNil(client.AddHeader(h3, createCC(require, h3, newKeys)))
+	assert.NotNil(client.AddValidatorSet(validators))
+	require.Nil(client.AddValidatorSet(newValidators))
+	_, _, pending = client.PendingValidatorSet()
+	assert.False(pending)
+	assert.Equal(validators, client.ValidatorSet(2))
+	assert.Equal(newValidators, client.ValidatorSet(3))
+	assert.NotNil(client.AddHeader(h3, createCC(require, h3, keys)))
+	require.Nil(client.AddHeader(h3, createCC(require, h3, newKeys)))
+	assert.Equal(h3.Hash(), client.Head().Hash())
+}
+
+func TestVerifyProofs(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	keys, validators := createValidators(require, 1)
+	root := &core.BlockHeader{ChainID: "testchain", Timestamp: big.NewInt(0)}
+	client := NewClient("testchain", root, validators)
+
+	txs := []common.Bytes{common.Bytes("tx0"), common.Bytes("tx1"), common.Bytes("tx2")}
+	addr := common.HexToAddress("0x2e833968e5bb786ae419c4d13189fb081cc43bab")
+	sv := state.NewStoreView(1, common.Hash{}, backend.NewMemDatabase())
+	sv.SetAccount(addr, &types.Account{
+		Address: addr,
+		Balance: types.Coins{ThetaWei: big.NewInt(100), GammaWei: big.NewInt(200)},
+	})
+
+	h1 := createHeader(root)
+	h1.TxHash = core.CalculateTxHash(txs)
+	h1.StateHash = sv.Save()
+	require.Nil(client.AddHeader(h1, createCC(require, h1, keys)))
+
+	// Transactions
+	proof, err := core.ProveTx(txs, 1)
+	require.Nil(err)
+	assert.Nil(client.VerifyTx(h1.Hash(), 1, txs[1], proof))
+	assert.NotNil(client.VerifyTx(h1.Hash(), 1, common.Bytes("tx3"), proof))
+	assert.NotNil(client.VerifyTx(h1.Hash(), 2, txs[1], proof))
+	assert.NotNil(client.VerifyTx(root.Hash(), 1, txs[1], proof))
+	assert.NotNil(client.VerifyTx(h1.Hash(), 1, txs[1], proof[1:]))
+
+	// Accounts
+	proof, err = sv.ProveAccount(addr)
+	require.Nil(err)
+	account, err := client.VerifyAccount(h1.Hash(), addr, proof)
+	require.Nil(err)
+	require.NotNil(account)
+	assert.Equal(big.NewInt(100), account.Balance.ThetaWei)
+
+	other := common.HexToAddress("0x1234")
+	proof, err = sv.ProveAccount(other)
+	require.Nil(err)
+	account, err = client.VerifyAccount(h1.Hash(), other, proof)
+	require.Nil(err)
+	assert.Nil(account)
+
+	_, err = client.VerifyAccount(h1.Hash(), addr, proof[:0])
+	assert.NotNil(err)
+}
@@ -0,0 +1,34 @@
+// +build gofuzz
+
+package mempool
+
+import (
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/ledger/types"
+)
+
+// FuzzParseMessage is the entry point for go-fuzz, e.g.
+//
+//	go-fuzz-build -func FuzzParseMessage github.com/thetatoken/ukulele/mempool
+//	go-fuzz -bin mempool-fuzz.zip -workdir mempool/testdata/fuzz/FuzzParseMessage
+//
+// It parses a message gossiped on the transaction channel, and decodes its transaction
+// as the ledger does when screening it. It returns 1 for a decodable transaction, to
+// have go-fuzz favor it, and 0 otherwise.
+func FuzzParseMessage(data []byte) int {
+	mmh := CreateMempoolMessageHandler(nil)
+	message, err := mmh.ParseMessage("peer", common.ChannelIDTransaction, data)
+	if err != nil {
+		return 0
+	}
+	tx, err := types.TxFromBytes(message.Content.(common.Bytes))
+	if err != nil {
+		return 0
+	}
+	_ = types.TxID("fuzz_chain", tx)
+	types.TxCoins(tx)
+	if _, err := types.TxToBytes(tx); err != nil {
+		panic(err)
+	}
+	return 1
+}
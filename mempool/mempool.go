@@ -3,7 +3,6 @@ package mempool
 import (
 	"context"
 	"encoding/hex"
-	"errors"
 	"math/big"
 	"sync"
 
@@ -12,8 +11,12 @@ import (
 	"github.com/thetatoken/ukulele/common"
 	"github.com/thetatoken/ukulele/common/clist"
 	"github.com/thetatoken/ukulele/common/math"
+	"github.com/thetatoken/ukulele/common/metrics"
 	"github.com/thetatoken/ukulele/common/pqueue"
+	"github.com/thetatoken/ukulele/common/result"
+	"github.com/thetatoken/ukulele/common/trace"
 	"github.com/thetatoken/ukulele/core"
+	"github.com/thetatoken/ukulele/crypto"
 	dp "github.com/thetatoken/ukulele/dispatcher"
 )
 
@@ -23,6 +26,14 @@ func (m MempoolError) Error() string {
 	return string(m)
 }
 
+// ErrorCode implements the result.Coder interface
+func (m MempoolError) ErrorCode() result.ErrorCode {
+	if m == DuplicateTxError {
+		return result.CodeDuplicateTx
+	}
+	return result.CodeGenericError
+}
+
 const DuplicateTxError = MempoolError("Transaction already seen")
 
 //
@@ -141,6 +152,10 @@ type Mempool struct {
 	addressToTxGroup map[common.Address]*mempoolTransactionGroup
 	size             int
 
+	sizeGauge     metrics.Gauge
+	admittedMeter metrics.Meter
+	rejectedMeter metrics.Meter
+
 	// Life cycle
 	wg      *sync.WaitGroup
 	quit    chan struct{}
@@ -157,7 +172,10 @@ func CreateMempool(dispatcher *dp.Dispatcher) *Mempool {
 		newTxs:           clist.New(),
 		candidateTxs:     pqueue.CreatePriorityQueue(),
 		addressToTxGroup: make(map[common.Address]*mempoolTransactionGroup),
-		txBookeepper:     createTransactionBookkeeper(defaultMaxNumTxs),
+		txBookeepper:     createTransactionBookkeeper(common.GetConfig().Mempool.MaxNumTxs),
+		sizeGauge:        metrics.GetOrRegisterGauge("mempool/size", nil),
+		admittedMeter:    metrics.GetOrRegisterMeter("mempool/admitted", nil),
+		rejectedMeter:    metrics.GetOrRegisterMeter("mempool/rejected", nil),
 		wg:               &sync.WaitGroup{},
 	}
 }
@@ -167,20 +185,30 @@ func (mp *Mempool) SetLedger(ledger core.Ledger) {
 	mp.ledger = ledger
 }
 
-// InsertTransaction inserts the incoming transaction to mempool (submitted by the clients or relayed from peers)
-func (mp *Mempool) InsertTransaction(rawTx common.Bytes) error {
+// InsertTransaction inserts the incoming transaction to mempool (submitted by the clients or relayed from peers).
+// The error of a rejected transaction carries its result code, see result.CodeOf
+func (mp *Mempool) InsertTransaction(rawTx common.Bytes) (err error) {
 	mp.mutex.Lock()
 	defer mp.mutex.Unlock()
 
+	if trace.Enabled() {
+		span := trace.StartHashSpan(crypto.Keccak256Hash(rawTx), "mempool.InsertTransaction")
+		defer func() {
+			span.SetError(err).Finish()
+		}()
+	}
+
 	if mp.txBookeepper.hasSeen(rawTx) {
 		log.Infof("[mempool] Transaction already seen: %v", hex.EncodeToString(rawTx))
+		mp.rejectedMeter.Mark(1)
 		return DuplicateTxError
 	}
 
 	txInfo, checkTxRes := mp.ledger.ScreenTx(rawTx)
 	if !checkTxRes.IsOK() {
 		log.Infof("[mempool] Transaction screening failed, tx: %v, error: %v", hex.EncodeToString(rawTx), checkTxRes.Message)
-		return errors.New(checkTxRes.Message)
+		mp.rejectedMeter.Mark(1)
+		return checkTxRes.Err()
 	}
 
 	log.Infof("[mempool] Insert tx: %v, txInfo: %v", hex.EncodeToString(rawTx), txInfo)
@@ -204,6 +232,8 @@ func (mp *Mempool) InsertTransaction(rawTx common.Bytes) error {
 
 	mp.newTxs.PushBack(rawTx)
 	mp.size++
+	mp.admittedMeter.Mark(1)
+	mp.sizeGauge.Update(int64(mp.size))
 	return nil
 }
 
@@ -244,6 +274,33 @@ func (mp *Mempool) Size() int {
 	return mp.size
 }
 
+// GetTransaction returns the raw transaction of the given hash if the Mempool has seen
+// it recently, including the transactions already reaped.
+func (mp *Mempool) GetTransaction(hash common.Hash) (common.Bytes, bool) {
+	return mp.txBookeepper.get(hash)
+}
+
+// GetTransactionByShortID returns the raw transaction of the given short ID if the
+// Mempool has seen it recently.
+func (mp *Mempool) GetTransactionByShortID(shortID uint64) (common.Bytes, bool) {
+	return mp.txBookeepper.getByShortID(shortID)
+}
+
+// ListTransactions returns the raw transactions in the candidate pool, without
+// removing them.
+func (mp *Mempool) ListTransactions() []common.Bytes {
+	mp.mutex.Lock()
+	defer mp.mutex.Unlock()
+
+	txs := make([]common.Bytes, 0, mp.size)
+	for _, txGroup := range mp.addressToTxGroup {
+		for _, elem := range *txGroup.txs.ElementList() {
+			txs = append(txs, elem.(*mempoolTransaction).rawTransaction)
+		}
+	}
+	return txs
+}
+
 // Reap returns a list of valid raw transactions and remove these
 // transactions from the candidate pool. maxNumTxs == 0 means
 // none, maxNumTxs < 0 means uncapped. Note that Reap does NOT remove
@@ -288,6 +345,7 @@ func (mp *Mempool) ReapUnsafe(maxNumTxs int) []common.Bytes {
 	}
 
 	mp.size -= len(txs)
+	mp.sizeGauge.Update(int64(mp.size))
 
 	return txs
 }
@@ -326,6 +384,7 @@ func (mp *Mempool) UpdateUnsafe(committedRawTxs []common.Bytes) bool {
 	for _, elem := range elemsTobeRemoved {
 		mp.candidateTxs.Remove(elem.GetIndex())
 	}
+	mp.sizeGauge.Update(int64(mp.size))
 
 	return true
 }
@@ -341,6 +400,7 @@ func (mp *Mempool) Flush() {
 		mp.candidateTxs.Pop()
 	}
 	mp.size = 0
+	mp.sizeGauge.Update(0)
 }
 
 // broadcastTransactionRoutine broadcasts transactions to neighoring peers
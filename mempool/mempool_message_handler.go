@@ -13,6 +13,10 @@ import (
 	dp "github.com/thetatoken/ukulele/dispatcher"
 )
 
+// GossipDedupCacheSize is the number of recent transaction messages remembered to drop
+// the copies gossiped by other peers before they are decoded
+const GossipDedupCacheSize = 8192
+
 //
 // MempoolMessageHandler handles the messages received over the
 // ChannelIDTransaction channel
@@ -43,7 +47,10 @@ func (mmh *MempoolMessageHandler) EncodeMessage(message interface{}) (common.Byt
 // ParseMessage implements the p2p.MessageHandler interface
 func (mmh *MempoolMessageHandler) ParseMessage(peerID string, channelID common.ChannelIDEnum, rawMessageBytes common.Bytes) (types.Message, error) {
 	var dataResponse dp.DataResponse
-	rlp.DecodeBytes(rawMessageBytes, &dataResponse)
+	err := rlp.DecodeBytes(rawMessageBytes, &dataResponse)
+	if err != nil {
+		return types.Message{}, err
+	}
 
 	rawTx := dataResponse.Payload
 	message := types.Message{
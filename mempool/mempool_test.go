@@ -58,6 +58,8 @@ func TestMempoolBasics(t *testing.T) {
 	assert.Nil(mempool.InsertTransaction(tx4))
 	assert.Nil(mempool.InsertTransaction(tx5))
 	assert.Equal(5, mempool.Size())
+	assert.Equal(5, len(mempool.ListTransactions()))
+	assert.Equal(5, mempool.Size())
 
 	// Reap operation
 	log.Infof("----- Reap 3 transactions -----")
@@ -152,6 +154,25 @@ func TestMempoolBasics(t *testing.T) {
 	assert.Equal("tx3", string(reapedRawTxs[2][:])) // priority: 32
 }
 
+func TestMempoolRejectionCodes(t *testing.T) {
+	assert := assert.New(t)
+
+	p2psimnet := p2psim.NewSimnetWithHandler(nil)
+	mempool, _ := newTestMempool("peer0", p2psimnet)
+
+	tx1 := createTestRawTx("tx1")
+	assert.Nil(mempool.InsertTransaction(tx1))
+	err := mempool.InsertTransaction(tx1)
+	assert.Equal(DuplicateTxError, err)
+	assert.Equal(result.CodeDuplicateTx, result.CodeOf(err))
+
+	mempool.ledger.(*TestLedger).rejectCode = result.CodeInvalidSequence
+	err = mempool.InsertTransaction(createTestRawTx("tx2"))
+	assert.NotNil(err)
+	assert.Equal(result.CodeInvalidSequence, result.CodeOf(err))
+	assert.Equal(1, mempool.Size())
+}
+
 func TestMempoolReapOrder(t *testing.T) {
 	assert := assert.New(t)
 
@@ -414,6 +435,7 @@ type TestLedger struct {
 	effectiveGasPriceList []uint64
 	addressList           []string
 	sequenceList          []uint64
+	rejectCode            result.ErrorCode // Code of the transactions rejected by ScreenTx, if any
 }
 
 func newTestLedger() core.Ledger {
@@ -459,6 +481,9 @@ func newTestLedger() core.Ledger {
 }
 
 func (tl *TestLedger) ScreenTx(rawTx common.Bytes) (*core.TxInfo, result.Result) {
+	if tl.rejectCode != result.CodeOK {
+		return nil, result.Error("Rejected").WithErrorCode(tl.rejectCode)
+	}
 	txInfo := &core.TxInfo{
 		EffectiveGasPrice: new(big.Int).SetUint64(tl.effectiveGasPriceList[tl.counter]),
 		Address:           common.HexToAddress(tl.addressList[tl.counter]),
@@ -468,11 +493,11 @@ func (tl *TestLedger) ScreenTx(rawTx common.Bytes) (*core.TxInfo, result.Result)
 	return txInfo, result.OK
 }
 
-func (tl *TestLedger) ProposeBlockTxs() (stateRootHash common.Hash, blockRawTxs []common.Bytes, res result.Result) {
+func (tl *TestLedger) ProposeBlockTxs(epoch uint64) (stateRootHash common.Hash, blockRawTxs []common.Bytes, res result.Result) {
 	return common.Hash{}, []common.Bytes{}, result.OK
 }
 
-func (tl *TestLedger) ApplyBlockTxs(blockRawTxs []common.Bytes, expectedStateRoot common.Hash) result.Result {
+func (tl *TestLedger) ApplyBlockTxs(epoch uint64, blockRawTxs []common.Bytes, expectedStateRoot common.Hash) result.Result {
 	return result.OK
 }
 
@@ -484,6 +509,10 @@ func (tl *TestLedger) FinalizeState(height uint64, rootHash common.Hash) result.
 	return result.OK
 }
 
+func (tl *TestLedger) Query(path string, data common.Bytes) (common.Bytes, result.Result) {
+	return nil, result.Error("Unknown query path: %v", path).WithErrorCode(result.CodeInvalidQuery)
+}
+
 type TestNetworkMessageInterceptor struct {
 	lock             *sync.Mutex
 	ReceivedMessages chan p2ptypes.Message
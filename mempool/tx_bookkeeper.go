@@ -1,40 +1,43 @@
 package mempool
 
 import (
+	"bytes"
 	"container/list"
 	"encoding/hex"
 	"sync"
 
 	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/core"
 	"github.com/thetatoken/ukulele/crypto"
 )
 
-const defaultMaxNumTxs = uint(200000)
-
 //
 // transactionBookkeeper keeps tracks of recently seen transactions
 //
 type transactionBookkeeper struct {
 	mutex *sync.Mutex
 
-	txMap  map[string]bool // map: transaction hash -> bool
-	txList list.List       // FIFO list of transaction hashes
+	txMap      map[string]common.Bytes // map: transaction hash -> raw transaction
+	shortIDMap map[uint64]common.Bytes // map: transaction short ID -> raw transaction
+	txList     list.List               // FIFO list of transaction hashes
 
 	maxNumTxs uint
 }
 
 func createTransactionBookkeeper(maxNumTxs uint) transactionBookkeeper {
 	return transactionBookkeeper{
-		mutex:     &sync.Mutex{},
-		txMap:     make(map[string]bool),
-		maxNumTxs: maxNumTxs,
+		mutex:      &sync.Mutex{},
+		txMap:      make(map[string]common.Bytes),
+		shortIDMap: make(map[uint64]common.Bytes),
+		maxNumTxs:  maxNumTxs,
 	}
 }
 
 func (tb *transactionBookkeeper) reset() {
 	tb.mutex.Lock()
 	defer tb.mutex.Unlock()
-	tb.txMap = make(map[string]bool)
+	tb.txMap = make(map[string]common.Bytes)
+	tb.shortIDMap = make(map[uint64]common.Bytes)
 	tb.txList.Init()
 }
 
@@ -58,20 +61,49 @@ func (tb *transactionBookkeeper) record(rawTx common.Bytes) bool {
 	if uint(tb.txList.Len()) >= tb.maxNumTxs { // remove the oldest transactions
 		popped := tb.txList.Front()
 		poppedTxhash := popped.Value.(string)
+		tb.deleteShortID(tb.txMap[poppedTxhash])
 		delete(tb.txMap, poppedTxhash)
 		tb.txList.Remove(popped)
 	}
 
-	tb.txMap[txhash] = true
+	tb.txMap[txhash] = rawTx
+	tb.shortIDMap[core.ShortTxID(rawTx)] = rawTx
 	tb.txList.PushBack(txhash)
 
 	return true
 }
 
+func (tb *transactionBookkeeper) get(hash common.Hash) (common.Bytes, bool) {
+	tb.mutex.Lock()
+	defer tb.mutex.Unlock()
+	rawTx, exists := tb.txMap[hex.EncodeToString(hash[:])]
+	return rawTx, exists
+}
+
+func (tb *transactionBookkeeper) getByShortID(shortID uint64) (common.Bytes, bool) {
+	tb.mutex.Lock()
+	defer tb.mutex.Unlock()
+	rawTx, exists := tb.shortIDMap[shortID]
+	return rawTx, exists
+}
+
+// deleteShortID deletes the short ID of the transaction, unless it has been taken over
+// by another transaction with the same short ID.
+func (tb *transactionBookkeeper) deleteShortID(rawTx common.Bytes) {
+	if rawTx == nil {
+		return
+	}
+	shortID := core.ShortTxID(rawTx)
+	if bytes.Equal(tb.shortIDMap[shortID], rawTx) {
+		delete(tb.shortIDMap, shortID)
+	}
+}
+
 func (tb *transactionBookkeeper) remove(rawTx common.Bytes) {
 	tb.mutex.Lock()
 	defer tb.mutex.Unlock()
 	txhash := getTransactionHash(rawTx)
+	tb.deleteShortID(tb.txMap[txhash])
 	delete(tb.txMap, txhash)
 }
 
@@ -5,6 +5,8 @@ import (
 
 	log "github.com/sirupsen/logrus"
 	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/core"
+	"github.com/thetatoken/ukulele/crypto"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -47,8 +49,21 @@ func TestTxBookkeeper(t *testing.T) {
 	assert.True(txb.hasSeen(tx5))
 	assert.False(txb.hasSeen(tx2)) // tx2 should have been purged
 
+	rawTx, ok := txb.get(crypto.Keccak256Hash(tx3))
+	assert.True(ok)
+	assert.Equal(tx3, rawTx)
+	_, ok = txb.get(crypto.Keccak256Hash(tx2))
+	assert.False(ok)
+	rawTx, ok = txb.getByShortID(core.ShortTxID(tx3))
+	assert.True(ok)
+	assert.Equal(tx3, rawTx)
+	_, ok = txb.getByShortID(core.ShortTxID(tx2))
+	assert.False(ok)
+
 	txb.remove(tx4)
 	assert.False(txb.hasSeen(tx4))
+	_, ok = txb.getByShortID(core.ShortTxID(tx4))
+	assert.False(ok)
 
 	txb.remove(tx5)
 	assert.False(txb.hasSeen(tx5))
@@ -46,6 +46,9 @@ func encodeMessage(message interface{}) (common.Bytes, error) {
 }
 
 func decodeMessage(raw common.Bytes) (interface{}, error) {
+	if len(raw) == 0 {
+		return nil, errors.New("Empty message")
+	}
 	var msgID MessageIDEnum
 	err := rlp.DecodeBytes(raw[:1], &msgID)
 	if err != nil {
@@ -26,3 +26,14 @@ func TestMessageEncoding(t *testing.T) {
 	assert.Equal(1, len(dataReq2.Entries))
 	assert.Equal("A0", dataReq2.Entries[0])
 }
+
+func TestDecodeMalformedMessage(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := decodeMessage(common.Bytes{})
+	assert.NotNil(err)
+	_, err = decodeMessage(common.Bytes{byte(MessageIDDataResponse)})
+	assert.NotNil(err)
+	_, err = decodeMessage(common.Bytes{0xff, 0xc0})
+	assert.NotNil(err)
+}
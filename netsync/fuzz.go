@@ -0,0 +1,53 @@
+// +build gofuzz
+
+package netsync
+
+import (
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/core"
+	"github.com/thetatoken/ukulele/dispatcher"
+	"github.com/thetatoken/ukulele/rlp"
+)
+
+// FuzzDecodeMessage is the entry point for go-fuzz, e.g.
+//
+//	go-fuzz-build -func FuzzDecodeMessage github.com/thetatoken/ukulele/netsync
+//	go-fuzz -bin netsync-fuzz.zip -workdir netsync/testdata/fuzz/FuzzDecodeMessage
+//
+// It decodes a message of the sync channels, and the payload of a data response as
+// handleDataResponse does. It returns 1 for a decodable message, to have go-fuzz favor
+// it, and 0 otherwise.
+func FuzzDecodeMessage(data []byte) int {
+	message, err := decodeMessage(data)
+	if err != nil {
+		return 0
+	}
+	response, ok := message.(dispatcher.DataResponse)
+	if !ok {
+		return 1
+	}
+
+	var payload interface{}
+	switch response.ChannelID {
+	case common.ChannelIDBlock:
+		payload = core.NewBlock()
+	case common.ChannelIDVote:
+		payload = &core.Vote{}
+	case common.ChannelIDProposal:
+		payload = &core.Proposal{}
+	case common.ChannelIDGuardian:
+		payload = &core.Attestation{}
+	case common.ChannelIDBlockTxs:
+		payload = &core.BlockTxs{}
+	case common.ChannelIDCompactBlock:
+		payload = &core.CompactBlock{}
+	case common.ChannelIDStatus:
+		payload = &core.PeerStatus{}
+	default:
+		return 1
+	}
+	if err := rlp.DecodeBytes(response.Payload, payload); err != nil {
+		return 0
+	}
+	return 1
+}
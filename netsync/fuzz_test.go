@@ -0,0 +1,33 @@
+// +build gofuzz
+
+package netsync
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFuzzCorpus runs the fuzz functions on their seed corpora, which must decode, and
+// on the truncations of the seeds.
+func TestFuzzCorpus(t *testing.T) {
+	fuzzFuncs := map[string]func([]byte) int{
+		"FuzzDecodeMessage": FuzzDecodeMessage,
+	}
+	for name, fuzz := range fuzzFuncs {
+		files, err := filepath.Glob(filepath.Join("testdata", "fuzz", name, "corpus", "*"))
+		require.Nil(t, err)
+		require.NotEmpty(t, files, name)
+		for _, file := range files {
+			data, err := ioutil.ReadFile(file)
+			require.Nil(t, err)
+			assert.Equal(t, 1, fuzz(data), file)
+			for i := 0; i < len(data); i++ {
+				fuzz(data[:i])
+			}
+		}
+	}
+}
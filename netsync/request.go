@@ -7,7 +7,6 @@ import (
 	"sync"
 	"time"
 
-	"github.com/spf13/viper"
 	"github.com/thetatoken/ukulele/blockchain"
 	"github.com/thetatoken/ukulele/common"
 	"github.com/thetatoken/ukulele/common/util"
@@ -97,7 +96,7 @@ func NewRequestManager(syncMgr *SyncManager) *RequestManager {
 	}
 
 	logger := util.GetLoggerForModule("request")
-	if viper.GetBool(common.CfgLogPrintSelfID) {
+	if common.GetConfig().Log.PrintSelfID {
 		logger = logger.WithFields(log.Fields{"id": rm.syncMgr.consensus.ID()})
 	}
 	rm.logger = logger
@@ -173,6 +172,9 @@ func (rm *RequestManager) tryToDownload() {
 				ChannelID: common.ChannelIDBlock,
 				Entries:   []string{pendingBlock.hash.String()},
 			}
+			if common.GetConfig().Sync.CompactBlocks {
+				request.ChannelID = common.ChannelIDCompactBlock
+			}
 			rm.logger.WithFields(log.Fields{
 				"channelID":       request.ChannelID,
 				"request.Entries": request.Entries,
@@ -188,7 +190,7 @@ func (rm *RequestManager) tryToDownload() {
 }
 
 func (rm *RequestManager) AddHash(x common.Hash, peerIDs []string) {
-	if _, err := rm.chain.FindBlock(x); err == nil {
+	if _, err := rm.chain.FindBlockHeader(x); err == nil {
 		return
 	}
 
@@ -220,7 +222,7 @@ func (rm *RequestManager) AddHash(x common.Hash, peerIDs []string) {
 }
 
 func (rm *RequestManager) AddBlock(block *core.Block) {
-	if _, err := rm.chain.FindBlock(block.Hash()); err == nil {
+	if _, err := rm.chain.FindBlockHeader(block.Hash()); err == nil {
 		return
 	}
 	if pendingBlockEl, ok := rm.pendingBlocksByHash[block.Hash().String()]; ok {
@@ -228,7 +230,7 @@ func (rm *RequestManager) AddBlock(block *core.Block) {
 		pendingBlock.block = block
 	}
 	parent := block.Parent
-	if _, err := rm.chain.FindBlock(parent); err == nil {
+	if _, err := rm.chain.FindBlockHeader(parent); err == nil {
 		rm.dumpReadyBlocks(block)
 		return
 	}
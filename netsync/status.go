@@ -0,0 +1,113 @@
+package netsync
+
+import (
+	"sort"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/core"
+	"github.com/thetatoken/ukulele/dispatcher"
+	"github.com/thetatoken/ukulele/rlp"
+)
+
+var _ core.SyncStatusReporter = (*SyncManager)(nil)
+
+// StatusExpiryIntervals is the number of status intervals after which the status of a
+// peer that stopped reporting it is discarded.
+const StatusExpiryIntervals = 3
+
+// finalizedBlockGetter is implemented by the consensus engines that report their last
+// finalized block.
+type finalizedBlockGetter interface {
+	GetLastFinalizedBlock() *core.ExtendedBlock
+}
+
+type peerStatusEntry struct {
+	status    core.PeerStatus
+	updatedAt time.Time
+}
+
+func statusInterval() time.Duration {
+	return time.Duration(common.GetConfig().Sync.StatusInterval) * time.Second
+}
+
+// localStatus returns the status of the chain of the node.
+func (sm *SyncManager) localStatus() core.PeerStatus {
+	status := core.PeerStatus{}
+	if tip := sm.consensus.GetTip(); tip != nil {
+		status.Height = tip.Height
+		status.TipHash = tip.Hash()
+	}
+	if getter, ok := sm.consensus.(finalizedBlockGetter); ok {
+		if finalized := getter.GetLastFinalizedBlock(); finalized != nil {
+			status.FinalizedHeight = finalized.Height
+		}
+	}
+	return status
+}
+
+// broadcastStatus sends the status of the chain of the node to all the peers.
+func (sm *SyncManager) broadcastStatus() {
+	status := sm.localStatus()
+	payload, err := rlp.EncodeToBytes(status)
+	if err != nil {
+		sm.logger.WithFields(log.Fields{"status": status, "err": err}).Error("Failed to encode status")
+		return
+	}
+	sm.dispatcher.SendData([]string{}, dispatcher.DataResponse{
+		ChannelID: common.ChannelIDStatus,
+		Payload:   payload,
+	})
+}
+
+func (sm *SyncManager) handleStatus(peerID string, status core.PeerStatus) {
+	sm.logger.WithFields(log.Fields{
+		"peer":   peerID,
+		"status": status,
+	}).Debug("Received peer status")
+
+	sm.statusMu.Lock()
+	defer sm.statusMu.Unlock()
+	sm.peerStatuses[peerID] = &peerStatusEntry{status: status, updatedAt: time.Now()}
+}
+
+// GetSyncStatus compares the chain of the node with the chains reported by its peers
+// recently.
+func (sm *SyncManager) GetSyncStatus() *core.NodeSyncStatus {
+	syncStatus := &core.NodeSyncStatus{
+		PeerStatus: sm.localStatus(),
+		Peers:      make(map[string]core.PeerStatus),
+	}
+
+	sm.statusMu.Lock()
+	expiry := StatusExpiryIntervals * statusInterval()
+	heights := []uint64{}
+	finalizedHeights := []uint64{}
+	for peerID, entry := range sm.peerStatuses {
+		if time.Since(entry.updatedAt) > expiry {
+			delete(sm.peerStatuses, peerID)
+			continue
+		}
+		syncStatus.Peers[peerID] = entry.status
+		heights = append(heights, entry.status.Height)
+		finalizedHeights = append(finalizedHeights, entry.status.FinalizedHeight)
+	}
+	sm.statusMu.Unlock()
+
+	syncStatus.NetworkHeight = median(heights)
+	syncStatus.NetworkFinalizedHeight = median(finalizedHeights)
+	threshold := common.GetConfig().Sync.BehindThreshold
+	syncStatus.Behind = threshold > 0 && syncStatus.NetworkHeight > syncStatus.Height+threshold
+	return syncStatus
+}
+
+// median returns the median of the values, the lower one for an even number of values,
+// and 0 for none.
+func median(values []uint64) uint64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+	return values[(len(values)-1)/2]
+}
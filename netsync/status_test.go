@@ -0,0 +1,71 @@
+package netsync
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thetatoken/ukulele/blockchain"
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/consensus"
+	"github.com/thetatoken/ukulele/core"
+	"github.com/thetatoken/ukulele/dispatcher"
+	"github.com/thetatoken/ukulele/p2p/simulation"
+	"github.com/thetatoken/ukulele/p2p/types"
+	"github.com/thetatoken/ukulele/rlp"
+	"github.com/thetatoken/ukulele/store/database/backend"
+	"github.com/thetatoken/ukulele/store/kvstore"
+)
+
+func TestSyncStatus(t *testing.T) {
+	assert := assert.New(t)
+	core.ResetTestBlocks()
+
+	chain := blockchain.CreateTestChainByBlocks([]string{
+		"A1", "A0",
+	})
+	simnet := simulation.NewSimnet()
+	net1 := simnet.AddEndpoint("node1")
+	simnet.Start(context.Background())
+
+	valMgr := consensus.NewFixedValidatorManager(core.NewValidatorSet())
+	db := kvstore.NewKVStore(backend.NewMemDatabase())
+	dispatch := dispatcher.NewDispatcher(net1)
+	engine := consensus.NewConsensusEngine(nil, db, chain, dispatch, valMgr)
+	sm := NewSyncManager(chain, engine, net1, dispatch, NewMockMessageConsumer())
+
+	// Without peers, the node is not behind
+	status := sm.GetSyncStatus()
+	assert.Equal(engine.GetTip().Height, status.Height)
+	assert.Equal(engine.GetTip().Hash(), status.TipHash)
+	assert.Equal(uint64(0), status.NetworkHeight)
+	assert.False(status.Behind)
+	assert.Empty(status.Peers)
+
+	// A status received from a peer is decoded and recorded
+	payload, err := rlp.EncodeToBytes(core.PeerStatus{Height: 100, FinalizedHeight: 98})
+	assert.Nil(err)
+	sm.processMessage(types.Message{
+		PeerID:    "peer1",
+		ChannelID: common.ChannelIDStatus,
+		Content: dispatcher.DataResponse{
+			ChannelID: common.ChannelIDStatus,
+			Payload:   payload,
+		},
+	})
+	status = sm.GetSyncStatus()
+	assert.Equal(uint64(100), status.NetworkHeight)
+	assert.Equal(uint64(98), status.NetworkFinalizedHeight)
+	assert.True(status.Behind)
+
+	// A minority of peers reporting high heights cannot make the node appear behind
+	sm.handleStatus("peer2", core.PeerStatus{Height: status.Height + 1})
+	sm.handleStatus("peer3", core.PeerStatus{Height: status.Height})
+	status = sm.GetSyncStatus()
+	assert.Equal(3, len(status.Peers))
+	assert.Equal(status.Height+1, status.NetworkHeight)
+	assert.False(status.Behind)
+
+	assert.Equal(uint64(2), median([]uint64{3, 1, 2, 4}))
+	assert.Equal(uint64(3), median([]uint64{5, 3, 1}))
+}
@@ -2,14 +2,18 @@ package netsync
 
 import (
 	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
 	"sync"
+	"time"
 
 	log "github.com/sirupsen/logrus"
-	"github.com/spf13/viper"
 	"github.com/thetatoken/ukulele/blockchain"
 	"github.com/thetatoken/ukulele/common"
 	"github.com/thetatoken/ukulele/common/util"
 	"github.com/thetatoken/ukulele/core"
+	"github.com/thetatoken/ukulele/crypto"
 	"github.com/thetatoken/ukulele/dispatcher"
 	"github.com/thetatoken/ukulele/p2p"
 	p2ptypes "github.com/thetatoken/ukulele/p2p/types"
@@ -20,6 +24,60 @@ type MessageConsumer interface {
 	AddMessage(interface{})
 }
 
+// TxSource looks up the transactions compact proposals and compact blocks omit, e.g.
+// in the mempool.
+type TxSource interface {
+	GetTransaction(hash common.Hash) (common.Bytes, bool)
+	GetTransactionByShortID(shortID uint64) (common.Bytes, bool)
+}
+
+// MaxPendingCompactBlocks is the maximum number of compact proposals and compact blocks
+// waiting for their transactions.
+const MaxPendingCompactBlocks = 16
+
+// pendingCompactBlock is a compact proposal or a compact block whose missing
+// transactions are requested from the peer that sent it.
+type pendingCompactBlock struct {
+	proposal    *core.Proposal
+	block       *core.CompactBlock
+	peerID      string
+	requestedAt time.Time
+}
+
+// resolve rebuilds the block with the transactions received from the peer and the
+// transactions found locally.
+func (pb *pendingCompactBlock) resolve(sm *SyncManager, txs []common.Bytes) (*core.Block, error) {
+	byHash := make(map[common.Hash]common.Bytes, len(txs))
+	for _, tx := range txs {
+		byHash[crypto.Keccak256Hash(tx)] = tx
+	}
+	var block *core.Block
+	var err error
+	if pb.proposal != nil {
+		block, _, err = pb.proposal.ResolveTxs(func(hash common.Hash) (common.Bytes, bool) {
+			if tx, ok := byHash[hash]; ok {
+				return tx, true
+			}
+			return sm.lookupTx(hash)
+		}, common.GetConfig().Consensus.TxHashActivationHeight)
+	} else {
+		byShortID := make(map[uint64]common.Bytes, len(txs))
+		for hash, tx := range byHash {
+			byShortID[core.ShortTxIDOfHash(hash)] = tx
+		}
+		block, _, err = pb.block.ResolveTxs(func(shortID uint64) (common.Bytes, bool) {
+			if tx, ok := byShortID[shortID]; ok {
+				return tx, true
+			}
+			return sm.lookupShortTx(shortID)
+		}, common.GetConfig().Consensus.TxHashActivationHeight)
+	}
+	if err == nil && block == nil {
+		err = errors.New("Transactions are still missing")
+	}
+	return block, err
+}
+
 var _ p2p.MessageHandler = (*SyncManager)(nil)
 
 // SyncManager is an intermediate layer between consensus engine and p2p network. Its main responsibilities are to manage
@@ -30,6 +88,12 @@ type SyncManager struct {
 	consumer   MessageConsumer
 	dispatcher *dispatcher.Dispatcher
 	requestMgr *RequestManager
+	txSource   TxSource
+
+	pendingCompactBlocks map[common.Hash]*pendingCompactBlock
+
+	statusMu     *sync.Mutex
+	peerStatuses map[string]*peerStatusEntry
 
 	wg      *sync.WaitGroup
 	ctx     context.Context
@@ -48,14 +112,19 @@ func NewSyncManager(chain *blockchain.Chain, cons core.ConsensusEngine, network
 		consumer:   consumer,
 		dispatcher: disp,
 
+		pendingCompactBlocks: make(map[common.Hash]*pendingCompactBlock),
+
+		statusMu:     &sync.Mutex{},
+		peerStatuses: make(map[string]*peerStatusEntry),
+
 		wg:       &sync.WaitGroup{},
-		incoming: make(chan p2ptypes.Message, viper.GetInt(common.CfgSyncMessageQueueSize)),
+		incoming: make(chan p2ptypes.Message, common.GetConfig().Sync.MessageQueueSize),
 	}
 	sm.requestMgr = NewRequestManager(sm)
 	network.RegisterMessageHandler(sm)
 
 	logger := util.GetLoggerForModule("sync")
-	if viper.GetBool(common.CfgLogPrintSelfID) {
+	if common.GetConfig().Log.PrintSelfID {
 		logger = logger.WithFields(log.Fields{"id": sm.consensus.ID()})
 	}
 	sm.logger = logger
@@ -63,6 +132,12 @@ func NewSyncManager(chain *blockchain.Chain, cons core.ConsensusEngine, network
 	return sm
 }
 
+// SetTxSource sets where the transactions omitted by compact proposals are looked up
+// before requesting them from the proposer.
+func (sm *SyncManager) SetTxSource(txSource TxSource) {
+	sm.txSource = txSource
+}
+
 func (sm *SyncManager) Start(ctx context.Context) {
 	c, cancel := context.WithCancel(ctx)
 	sm.ctx = c
@@ -86,6 +161,9 @@ func (sm *SyncManager) Wait() {
 func (sm *SyncManager) mainLoop() {
 	defer sm.wg.Done()
 
+	statusTicker := time.NewTicker(statusInterval())
+	defer statusTicker.Stop()
+
 	for {
 		select {
 		case <-sm.ctx.Done():
@@ -93,6 +171,8 @@ func (sm *SyncManager) mainLoop() {
 			return
 		case msg := <-sm.incoming:
 			sm.processMessage(msg)
+		case <-statusTicker.C:
+			sm.broadcastStatus()
 		}
 	}
 }
@@ -105,6 +185,10 @@ func (sm *SyncManager) GetChannelIDs() []common.ChannelIDEnum {
 		common.ChannelIDProposal,
 		common.ChannelIDCC,
 		common.ChannelIDVote,
+		common.ChannelIDGuardian,
+		common.ChannelIDBlockTxs,
+		common.ChannelIDCompactBlock,
+		common.ChannelIDStatus,
 	}
 }
 
@@ -262,6 +346,10 @@ func (m *SyncManager) handleDataRequest(peerID string, data *dispatcher.DataRequ
 			}).Debug("Sending requested block")
 			m.dispatcher.SendData([]string{peerID}, data)
 		}
+	case common.ChannelIDCompactBlock:
+		m.handleCompactBlockRequest(peerID, data.Entries)
+	case common.ChannelIDBlockTxs:
+		m.handleBlockTxsRequest(peerID, data.Entries)
 	default:
 		m.logger.WithFields(log.Fields{
 			"channelID": data.ChannelID,
@@ -306,7 +394,55 @@ func (m *SyncManager) handleDataResponse(peerID string, data *dispatcher.DataRes
 			}).Error("Failed to decode DataResponse payload")
 			return
 		}
-		m.handleProposal(proposal)
+		m.handleProposal(peerID, proposal)
+	case common.ChannelIDGuardian:
+		attestation := core.Attestation{}
+		err := rlp.DecodeBytes(data.Payload, &attestation)
+		if err != nil {
+			m.logger.WithFields(log.Fields{
+				"channelID": data.ChannelID,
+				"payload":   data.Payload,
+				"error":     err,
+			}).Error("Failed to decode DataResponse payload")
+			return
+		}
+		m.handleAttestation(attestation)
+	case common.ChannelIDBlockTxs:
+		blockTxs := core.BlockTxs{}
+		err := rlp.DecodeBytes(data.Payload, &blockTxs)
+		if err != nil {
+			m.logger.WithFields(log.Fields{
+				"channelID": data.ChannelID,
+				"payload":   data.Payload,
+				"error":     err,
+			}).Error("Failed to decode DataResponse payload")
+			return
+		}
+		m.handleBlockTxs(peerID, blockTxs)
+	case common.ChannelIDCompactBlock:
+		compactBlock := &core.CompactBlock{}
+		err := rlp.DecodeBytes(data.Payload, compactBlock)
+		if err != nil {
+			m.logger.WithFields(log.Fields{
+				"channelID": data.ChannelID,
+				"payload":   data.Payload,
+				"error":     err,
+			}).Error("Failed to decode DataResponse payload")
+			return
+		}
+		m.handleCompactBlock(peerID, compactBlock)
+	case common.ChannelIDStatus:
+		status := core.PeerStatus{}
+		err := rlp.DecodeBytes(data.Payload, &status)
+		if err != nil {
+			m.logger.WithFields(log.Fields{
+				"channelID": data.ChannelID,
+				"payload":   data.Payload,
+				"error":     err,
+			}).Error("Failed to decode DataResponse payload")
+			return
+		}
+		m.handleStatus(peerID, status)
 	default:
 		m.logger.WithFields(log.Fields{
 			"channelID": data.ChannelID,
@@ -314,17 +450,258 @@ func (m *SyncManager) handleDataResponse(peerID string, data *dispatcher.DataRes
 	}
 }
 
-func (sm *SyncManager) handleProposal(p *core.Proposal) {
+func (sm *SyncManager) handleProposal(peerID string, p *core.Proposal) {
 	sm.logger.WithFields(log.Fields{
 		"proposal": p,
 	}).Debug("Received proposal")
 
+	for _, reveal := range p.Reveals {
+		sm.PassdownMessage(reveal)
+	}
 	if p.Votes != nil {
 		for _, vote := range p.Votes.Votes() {
 			sm.handleVote(vote)
 		}
 	}
-	sm.handleBlock(p.Block)
+	if p.Block == nil {
+		return
+	}
+	if !p.IsCompact() {
+		sm.handleBlock(p.Block)
+		return
+	}
+	sm.handleCompactProposal(peerID, p)
+}
+
+// handleCompactProposal rebuilds the block of a compact proposal from the transactions
+// found locally, and requests the missing ones from the peer that sent the proposal.
+func (sm *SyncManager) handleCompactProposal(peerID string, p *core.Proposal) {
+	hash := p.Block.Hash()
+	if _, err := sm.chain.FindBlockHeader(hash); err == nil {
+		return
+	}
+	block, missing, err := p.ResolveTxs(sm.lookupTx, common.GetConfig().Consensus.TxHashActivationHeight)
+	if err != nil {
+		sm.logger.WithFields(log.Fields{"block": hash.Hex(), "error": err}).Warn("Failed to rebuild block of compact proposal")
+		sm.requestMgr.AddHash(hash, []string{peerID})
+		return
+	}
+	if block != nil {
+		sm.handleBlock(block)
+		return
+	}
+
+	entries := []string{}
+	for _, txHash := range missing {
+		entries = append(entries, txHash.Hex())
+	}
+	pending := &pendingCompactBlock{proposal: p, peerID: peerID}
+	if !sm.requestMissingTxs(hash, pending, entries) {
+		sm.requestMgr.AddHash(hash, []string{peerID})
+	}
+}
+
+// handleCompactBlock rebuilds a block downloaded as a compact block from the
+// transactions found locally, and requests the missing ones from the peer that sent it.
+func (sm *SyncManager) handleCompactBlock(peerID string, cb *core.CompactBlock) {
+	if cb.Header == nil {
+		return
+	}
+	hash := cb.Header.Hash()
+	if _, err := sm.chain.FindBlockHeader(hash); err == nil {
+		return
+	}
+	block, missing, err := cb.ResolveTxs(sm.lookupShortTx, common.GetConfig().Consensus.TxHashActivationHeight)
+	if err != nil {
+		sm.logger.WithFields(log.Fields{"block": hash.Hex(), "error": err}).Warn("Failed to rebuild compact block")
+		sm.requestFullBlock(hash, peerID)
+		return
+	}
+	if block != nil {
+		sm.handleBlock(block)
+		return
+	}
+
+	entries := []string{}
+	for _, shortID := range missing {
+		entries = append(entries, fmt.Sprintf("0x%016x", shortID))
+	}
+	pending := &pendingCompactBlock{block: cb, peerID: peerID}
+	if !sm.requestMissingTxs(hash, pending, entries) {
+		sm.requestFullBlock(hash, peerID)
+	}
+}
+
+// requestMissingTxs requests the missing transactions of a block from the peer that
+// sent its compact proposal or compact block. The entries are the hashes of the
+// transactions, or their short IDs. It returns false if too many blocks are already
+// waiting for their transactions.
+func (sm *SyncManager) requestMissingTxs(hash common.Hash, pending *pendingCompactBlock, entries []string) bool {
+	sm.expirePendingCompactBlocks()
+	if _, ok := sm.pendingCompactBlocks[hash]; ok {
+		return true
+	}
+	if len(sm.pendingCompactBlocks) >= MaxPendingCompactBlocks {
+		return false
+	}
+	pending.requestedAt = time.Now()
+	sm.pendingCompactBlocks[hash] = pending
+
+	request := dispatcher.DataRequest{
+		ChannelID: common.ChannelIDBlockTxs,
+		Entries:   append([]string{hash.Hex()}, entries...),
+	}
+	sm.logger.WithFields(log.Fields{
+		"block":   hash.Hex(),
+		"missing": len(entries),
+		"peer":    pending.peerID,
+	}).Debug("Requesting missing transactions of block")
+	sm.dispatcher.GetData([]string{pending.peerID}, request)
+	return true
+}
+
+// requestFullBlock requests a block whose transactions could not be resolved from a
+// compact block.
+func (sm *SyncManager) requestFullBlock(hash common.Hash, peerID string) {
+	request := dispatcher.DataRequest{
+		ChannelID: common.ChannelIDBlock,
+		Entries:   []string{hash.Hex()},
+	}
+	sm.dispatcher.GetData([]string{peerID}, request)
+}
+
+// expirePendingCompactBlocks falls back to requesting the full blocks of the compact
+// proposals and compact blocks whose transactions were not received in time.
+func (sm *SyncManager) expirePendingCompactBlocks() {
+	for hash, pending := range sm.pendingCompactBlocks {
+		if time.Since(pending.requestedAt) > RequestTimeout {
+			delete(sm.pendingCompactBlocks, hash)
+			sm.fallBackToFullBlock(hash, pending)
+		}
+	}
+}
+
+func (sm *SyncManager) fallBackToFullBlock(hash common.Hash, pending *pendingCompactBlock) {
+	if pending.proposal != nil {
+		sm.requestMgr.AddHash(hash, []string{pending.peerID})
+	} else {
+		sm.requestFullBlock(hash, pending.peerID)
+	}
+}
+
+func (sm *SyncManager) lookupTx(hash common.Hash) (common.Bytes, bool) {
+	if sm.txSource == nil {
+		return nil, false
+	}
+	return sm.txSource.GetTransaction(hash)
+}
+
+func (sm *SyncManager) lookupShortTx(shortID uint64) (common.Bytes, bool) {
+	if sm.txSource == nil {
+		return nil, false
+	}
+	return sm.txSource.GetTransactionByShortID(shortID)
+}
+
+// handleCompactBlockRequest sends the requested blocks as compact blocks, which carry
+// in full the transactions the mempool has not seen, e.g. the coinbase transactions.
+func (sm *SyncManager) handleCompactBlockRequest(peerID string, entries []string) {
+	embed := func(tx common.Bytes) bool {
+		_, ok := sm.lookupTx(crypto.Keccak256Hash(tx))
+		return !ok
+	}
+	for _, hashStr := range entries {
+		hash := common.HexToHash(hashStr)
+		block, err := sm.chain.FindBlock(hash)
+		if err != nil {
+			sm.logger.WithFields(log.Fields{
+				"channelID": common.ChannelIDCompactBlock,
+				"hashStr":   hashStr,
+				"err":       err,
+			}).Error("Failed to find hash string locally")
+			return
+		}
+
+		payload, err := rlp.EncodeToBytes(core.NewCompactBlock(block.Block, embed))
+		if err != nil {
+			sm.logger.WithFields(log.Fields{
+				"block": block,
+			}).Error("Failed to encode compact block")
+			return
+		}
+		sm.logger.WithFields(log.Fields{
+			"channelID": common.ChannelIDCompactBlock,
+			"hashStr":   hashStr,
+		}).Debug("Sending requested compact block")
+		sm.dispatcher.SendData([]string{peerID}, dispatcher.DataResponse{
+			ChannelID: common.ChannelIDCompactBlock,
+			Payload:   payload,
+		})
+	}
+}
+
+// handleBlockTxsRequest sends the transactions of a block requested by a peer for a
+// compact proposal or a compact block. The first entry is the hash of the block and the
+// others are the hashes of the transactions, or their short IDs.
+func (sm *SyncManager) handleBlockTxsRequest(peerID string, entries []string) {
+	if len(entries) == 0 {
+		return
+	}
+	hash := common.HexToHash(entries[0])
+	block, err := sm.chain.FindBlock(hash)
+	if err != nil {
+		sm.logger.WithFields(log.Fields{"block": hash.Hex(), "err": err}).Error("Failed to find block of requested transactions")
+		return
+	}
+	// The short ID of a transaction is the prefix of its hash
+	requested := make(map[uint64]bool, len(entries)-1)
+	for _, entry := range entries[1:] {
+		if id := common.FromHex(entry); len(id) >= 8 {
+			requested[binary.BigEndian.Uint64(id[:8])] = true
+		}
+	}
+	blockTxs := core.BlockTxs{Block: hash, Txs: []common.Bytes{}}
+	for _, tx := range block.Txs {
+		if requested[core.ShortTxID(tx)] {
+			blockTxs.Txs = append(blockTxs.Txs, tx)
+		}
+	}
+
+	payload, err := rlp.EncodeToBytes(blockTxs)
+	if err != nil {
+		sm.logger.WithFields(log.Fields{"block": hash.Hex(), "err": err}).Error("Failed to encode block transactions")
+		return
+	}
+	sm.logger.WithFields(log.Fields{
+		"block": hash.Hex(),
+		"txs":   len(blockTxs.Txs),
+	}).Debug("Sending requested transactions")
+	sm.dispatcher.SendData([]string{peerID}, dispatcher.DataResponse{
+		ChannelID: common.ChannelIDBlockTxs,
+		Payload:   payload,
+	})
+}
+
+// handleBlockTxs completes the block of a pending compact proposal or compact block
+// with the transactions received. If the block is still incomplete, the full block is
+// requested instead.
+func (sm *SyncManager) handleBlockTxs(peerID string, blockTxs core.BlockTxs) {
+	pending, ok := sm.pendingCompactBlocks[blockTxs.Block]
+	if !ok {
+		return
+	}
+	delete(sm.pendingCompactBlocks, blockTxs.Block)
+
+	block, err := pending.resolve(sm, blockTxs.Txs)
+	if err != nil {
+		sm.logger.WithFields(log.Fields{
+			"block": blockTxs.Block.Hex(),
+			"error": err,
+		}).Warn("Failed to complete block from its transactions, requesting full block")
+		sm.fallBackToFullBlock(blockTxs.Block, pending)
+		return
+	}
+	sm.handleBlock(block)
 }
 
 func (sm *SyncManager) handleBlock(block *core.Block) {
@@ -333,6 +710,13 @@ func (sm *SyncManager) handleBlock(block *core.Block) {
 		"block.Parent": block.Parent.Hex(),
 	}).Debug("Received block")
 
+	if res := block.Validate(); res.IsError() {
+		sm.logger.WithFields(log.Fields{
+			"block.Hash": block.Hash().Hex(),
+			"error":      res.Message,
+		}).Warn("Ignoring block with invalid header")
+		return
+	}
 	sm.requestMgr.AddBlock(block)
 }
 
@@ -345,3 +729,13 @@ func (sm *SyncManager) handleVote(vote core.Vote) {
 
 	sm.PassdownMessage(vote)
 }
+
+func (sm *SyncManager) handleAttestation(attestation core.Attestation) {
+	sm.logger.WithFields(log.Fields{
+		"attestation.Block":  attestation.Block.Hex(),
+		"attestation.Height": attestation.Height,
+		"attestation.ID":     attestation.ID.Hex(),
+	}).Debug("Received guardian attestation")
+
+	sm.PassdownMessage(attestation)
+}
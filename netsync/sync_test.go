@@ -2,11 +2,13 @@ package netsync
 
 import (
 	"context"
+	"fmt"
 	"testing"
 	"time"
 
 	"github.com/thetatoken/ukulele/consensus"
 	"github.com/thetatoken/ukulele/core"
+	"github.com/thetatoken/ukulele/crypto"
 	"github.com/thetatoken/ukulele/rlp"
 	"github.com/thetatoken/ukulele/store/database/backend"
 	"github.com/thetatoken/ukulele/store/kvstore"
@@ -149,3 +151,168 @@ func TestSyncManager(t *testing.T) {
 		assert.Equal(core.GetTestBlock(expected[i]).Hash(), msg.(*core.Block).Hash())
 	}
 }
+
+type MockTxSource map[common.Hash]common.Bytes
+
+func (m MockTxSource) GetTransaction(hash common.Hash) (common.Bytes, bool) {
+	tx, ok := m[hash]
+	return tx, ok
+}
+
+func (m MockTxSource) GetTransactionByShortID(shortID uint64) (common.Bytes, bool) {
+	for hash, tx := range m {
+		if core.ShortTxIDOfHash(hash) == shortID {
+			return tx, true
+		}
+	}
+	return nil, false
+}
+
+func TestCompactProposal(t *testing.T) {
+	assert := assert.New(t)
+	core.ResetTestBlocks()
+
+	initChain := blockchain.CreateTestChainByBlocks([]string{
+		"A1", "A0",
+	})
+	simnet := simulation.NewSimnet()
+	net1 := simnet.AddEndpoint("node1")
+	net2 := simnet.AddEndpoint("node2")
+	mockMsgHandler := &MockMsgHandler{C: make(chan interface{}, 128)}
+	net2.RegisterMessageHandler(mockMsgHandler)
+	simnet.Start(context.Background())
+
+	valSet := core.NewValidatorSet()
+	valMgr := consensus.NewFixedValidatorManager(valSet)
+	db := kvstore.NewKVStore(backend.NewMemDatabase())
+	dispatch := dispatcher.NewDispatcher(net1)
+	consensus := consensus.NewConsensusEngine(nil, db, initChain, dispatch, valMgr)
+	mockMsgConsumer := NewMockMessageConsumer()
+
+	coinbase := common.Bytes("coinbase")
+	tx1 := common.Bytes("tx1")
+	tx2 := common.Bytes("tx2")
+	sm := NewSyncManager(initChain, consensus, net1, dispatch, mockMsgConsumer)
+	sm.SetTxSource(MockTxSource{crypto.Keccak256Hash(tx2): tx2})
+	sm.Start(context.Background())
+
+	// node2 proposes A2, embedding only the coinbase transaction
+	block := core.CreateTestBlock("A2", "A1")
+	block.Txs = []common.Bytes{coinbase, tx1, tx2}
+	block.TxHash = core.CalculateTxHash(block.Txs)
+	proposal := &core.Proposal{Block: block}
+	proposal.Compact(func(tx common.Bytes) bool { return string(tx) == "coinbase" })
+	payload, _ := rlp.EncodeToBytes(proposal)
+	net2.Broadcast(types.Message{
+		ChannelID: common.ChannelIDProposal,
+		Content: dispatcher.DataResponse{
+			ChannelID: common.ChannelIDProposal,
+			Payload:   payload,
+		},
+	})
+
+	// node1 only requests tx1, which is not in its mempool
+	res := <-mockMsgHandler.C
+	req, ok := res.(dispatcher.DataRequest)
+	assert.True(ok)
+	assert.Equal(common.ChannelIDBlockTxs, req.ChannelID)
+	assert.Equal([]string{block.Hash().Hex(), crypto.Keccak256Hash(tx1).Hex()}, req.Entries)
+
+	payload, _ = rlp.EncodeToBytes(core.BlockTxs{Block: block.Hash(), Txs: []common.Bytes{tx1}})
+	net2.Broadcast(types.Message{
+		ChannelID: common.ChannelIDBlockTxs,
+		Content: dispatcher.DataResponse{
+			ChannelID: common.ChannelIDBlockTxs,
+			Payload:   payload,
+		},
+	})
+
+	time.Sleep(1 * time.Second)
+
+	// Sync manager should output the complete block
+	assert.Equal(1, len(mockMsgConsumer.Received))
+	received := mockMsgConsumer.Received[0].(*core.Block)
+	assert.Equal(block.Hash(), received.Hash())
+	assert.Equal([]common.Bytes{coinbase, tx1, tx2}, received.Txs)
+}
+
+func TestCompactBlock(t *testing.T) {
+	assert := assert.New(t)
+	core.ResetTestBlocks()
+
+	initChain := blockchain.CreateTestChainByBlocks([]string{
+		"A1", "A0",
+	})
+	simnet := simulation.NewSimnet()
+	net1 := simnet.AddEndpoint("node1")
+	net2 := simnet.AddEndpoint("node2")
+	mockMsgHandler := &MockMsgHandler{C: make(chan interface{}, 128)}
+	net2.RegisterMessageHandler(mockMsgHandler)
+	simnet.Start(context.Background())
+
+	valSet := core.NewValidatorSet()
+	valMgr := consensus.NewFixedValidatorManager(valSet)
+	db := kvstore.NewKVStore(backend.NewMemDatabase())
+	dispatch := dispatcher.NewDispatcher(net1)
+	consensus := consensus.NewConsensusEngine(nil, db, initChain, dispatch, valMgr)
+	mockMsgConsumer := NewMockMessageConsumer()
+
+	coinbase := common.Bytes("coinbase")
+	tx1 := common.Bytes("tx1")
+	tx2 := common.Bytes("tx2")
+	sm := NewSyncManager(initChain, consensus, net1, dispatch, mockMsgConsumer)
+	sm.SetTxSource(MockTxSource{crypto.Keccak256Hash(tx2): tx2})
+	sm.Start(context.Background())
+
+	// node2 sends A2 as a compact block, carrying only the coinbase transaction
+	block := core.CreateTestBlock("A2", "A1")
+	block.Txs = []common.Bytes{coinbase, tx1, tx2}
+	block.TxHash = core.CalculateTxHash(block.Txs)
+	payload, _ := rlp.EncodeToBytes(core.NewCompactBlock(block, func(tx common.Bytes) bool { return string(tx) == "coinbase" }))
+	net2.Broadcast(types.Message{
+		ChannelID: common.ChannelIDCompactBlock,
+		Content: dispatcher.DataResponse{
+			ChannelID: common.ChannelIDCompactBlock,
+			Payload:   payload,
+		},
+	})
+
+	// node1 only requests tx1 by its short ID, the prefix of its hash
+	res := <-mockMsgHandler.C
+	req, ok := res.(dispatcher.DataRequest)
+	assert.True(ok)
+	assert.Equal(common.ChannelIDBlockTxs, req.ChannelID)
+	assert.Equal([]string{block.Hash().Hex(), crypto.Keccak256Hash(tx1).Hex()[:18]}, req.Entries)
+
+	payload, _ = rlp.EncodeToBytes(core.BlockTxs{Block: block.Hash(), Txs: []common.Bytes{tx1}})
+	net2.Broadcast(types.Message{
+		ChannelID: common.ChannelIDBlockTxs,
+		Content: dispatcher.DataResponse{
+			ChannelID: common.ChannelIDBlockTxs,
+			Payload:   payload,
+		},
+	})
+
+	time.Sleep(1 * time.Second)
+
+	// Sync manager should output the complete block
+	assert.Equal(1, len(mockMsgConsumer.Received))
+	received := mockMsgConsumer.Received[0].(*core.Block)
+	assert.Equal(block.Hash(), received.Hash())
+	assert.Equal([]common.Bytes{coinbase, tx1, tx2}, received.Txs)
+
+	// node1 serves the transactions of A2 by short ID
+	net2.Broadcast(types.Message{
+		ChannelID: common.ChannelIDBlockTxs,
+		Content: dispatcher.DataRequest{
+			ChannelID: common.ChannelIDBlockTxs,
+			Entries:   []string{block.Hash().Hex(), fmt.Sprintf("0x%016x", core.ShortTxID(tx2))},
+		},
+	})
+	res = <-mockMsgHandler.C
+	resp, ok := res.(dispatcher.DataResponse)
+	assert.True(ok)
+	blockTxs := core.BlockTxs{}
+	assert.Nil(rlp.DecodeBytes(resp.Payload, &blockTxs))
+	assert.Equal([]common.Bytes{tx2}, blockTxs.Txs)
+}
@@ -0,0 +1,125 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Service is a subsystem of the node. It runs from Start until the context given to
+// Start is canceled, and Wait blocks until it has stopped.
+type Service interface {
+	Start(ctx context.Context) error
+	Wait()
+}
+
+// ServiceFuncs turns the Start and Wait functions of a subsystem into a Service. Wait
+// may be nil for a subsystem which stops at once.
+type ServiceFuncs struct {
+	StartFunc func(ctx context.Context) error
+	WaitFunc  func()
+}
+
+func (s ServiceFuncs) Start(ctx context.Context) error {
+	return s.StartFunc(ctx)
+}
+
+func (s ServiceFuncs) Wait() {
+	if s.WaitFunc != nil {
+		s.WaitFunc()
+	}
+}
+
+// runningService is a Service started by a lifecycle.
+type runningService struct {
+	name    string
+	service Service
+	cancel  context.CancelFunc
+}
+
+// lifecycle starts services in the order they are added, and stops them in the
+// reverse order: a service is only stopped once the services started after it, which
+// may depend on it, have stopped.
+type lifecycle struct {
+	mu       sync.Mutex
+	names    []string
+	services []Service
+	running  []runningService
+	started  bool
+	done     chan struct{}
+}
+
+func newLifecycle() *lifecycle {
+	return &lifecycle{
+		done: make(chan struct{}),
+	}
+}
+
+// add adds a service to start after the services already added.
+func (l *lifecycle) add(name string, service Service) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.started {
+		return fmt.Errorf("Cannot add service %v to a started node", name)
+	}
+	for _, existing := range l.names {
+		if existing == name {
+			return fmt.Errorf("Service %v is already added", name)
+		}
+	}
+	l.names = append(l.names, name)
+	l.services = append(l.services, service)
+	return nil
+}
+
+// start starts the services in order, each one with its own context, and stops them
+// all in the reverse order once ctx is canceled. If a service fails to start, the
+// services already started are stopped, and the error is returned.
+func (l *lifecycle) start(ctx context.Context) error {
+	l.mu.Lock()
+	if l.started {
+		l.mu.Unlock()
+		return fmt.Errorf("The node is already started")
+	}
+	l.started = true
+	l.mu.Unlock()
+
+	for i, service := range l.services {
+		// The contexts of the services are not derived from ctx, so that they are
+		// canceled one by one on shutdown
+		c, cancel := context.WithCancel(context.Background())
+		l.running = append(l.running, runningService{name: l.names[i], service: service, cancel: cancel})
+		if err := service.Start(c); err != nil {
+			l.stop()
+			close(l.done)
+			return fmt.Errorf("Failed to start service %v: %v", l.names[i], err)
+		}
+		log.WithFields(log.Fields{"service": l.names[i]}).Debug("Service started")
+	}
+
+	go func() {
+		<-ctx.Done()
+		l.stop()
+		close(l.done)
+	}()
+	return nil
+}
+
+// stop stops the running services in the reverse order of their start.
+func (l *lifecycle) stop() {
+	for i := len(l.running) - 1; i >= 0; i-- {
+		s := l.running[i]
+		s.cancel()
+		s.service.Wait()
+		log.WithFields(log.Fields{"service": s.name}).Debug("Service stopped")
+	}
+	l.running = nil
+}
+
+// wait blocks until all the services have stopped.
+func (l *lifecycle) wait() {
+	<-l.done
+}
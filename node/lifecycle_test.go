@@ -0,0 +1,130 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeService records its start and its stop in the events.
+type fakeService struct {
+	name     string
+	events   *events
+	startErr error
+	wg       sync.WaitGroup
+}
+
+type events struct {
+	mu  sync.Mutex
+	log []string
+}
+
+func (e *events) add(event string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.log = append(e.log, event)
+}
+
+func (e *events) get() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]string{}, e.log...)
+}
+
+func (s *fakeService) Start(ctx context.Context) error {
+	if s.startErr != nil {
+		return s.startErr
+	}
+	s.events.add("start " + s.name)
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		<-ctx.Done()
+		s.events.add("stop " + s.name)
+	}()
+	return nil
+}
+
+func (s *fakeService) Wait() {
+	s.wg.Wait()
+}
+
+func TestLifecycleOrder(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	events := &events{}
+	l := newLifecycle()
+	for _, name := range []string{"a", "b", "c"} {
+		require.Nil(l.add(name, &fakeService{name: name, events: events}))
+	}
+	assert.NotNil(l.add("a", &fakeService{name: "a", events: events}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	require.Nil(l.start(ctx))
+	assert.Equal([]string{"start a", "start b", "start c"}, events.get())
+	assert.NotNil(l.add("d", &fakeService{name: "d", events: events}))
+	assert.NotNil(l.start(ctx))
+
+	// The services stop in the reverse order once the context is canceled
+	cancel()
+	waitOrFail(t, l)
+	assert.Equal([]string{"start a", "start b", "start c", "stop c", "stop b", "stop a"}, events.get())
+}
+
+func TestLifecycleStartFailure(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	events := &events{}
+	l := newLifecycle()
+	require.Nil(l.add("a", &fakeService{name: "a", events: events}))
+	require.Nil(l.add("b", &fakeService{name: "b", events: events}))
+	require.Nil(l.add("c", &fakeService{name: "c", events: events, startErr: errors.New("failed")}))
+	require.Nil(l.add("d", &fakeService{name: "d", events: events}))
+
+	// The services already started are stopped, and the next ones are not started
+	err := l.start(context.Background())
+	require.NotNil(err)
+	assert.Contains(err.Error(), "c")
+	waitOrFail(t, l)
+	assert.Equal([]string{"start a", "start b", "stop b", "stop a"}, events.get())
+}
+
+func TestServiceFuncs(t *testing.T) {
+	assert := assert.New(t)
+
+	started := false
+	l := newLifecycle()
+	assert.Nil(l.add("funcs", ServiceFuncs{
+		StartFunc: func(ctx context.Context) error {
+			started = true
+			return nil
+		},
+	}))
+	ctx, cancel := context.WithCancel(context.Background())
+	assert.Nil(l.start(ctx))
+	assert.True(started)
+
+	// A service without WaitFunc stops at once
+	cancel()
+	waitOrFail(t, l)
+}
+
+func waitOrFail(t *testing.T, l *lifecycle) {
+	done := make(chan struct{})
+	go func() {
+		l.wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("The services did not stop")
+	}
+}
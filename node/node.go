@@ -2,23 +2,30 @@ package node
 
 import (
 	"context"
-	"sync"
+	"time"
 
-	"github.com/spf13/viper"
+	log "github.com/sirupsen/logrus"
 	"github.com/thetatoken/ukulele/blockchain"
 	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/common/metrics"
+	"github.com/thetatoken/ukulele/common/metrics/prometheus"
 	"github.com/thetatoken/ukulele/consensus"
 	"github.com/thetatoken/ukulele/core"
 	"github.com/thetatoken/ukulele/crypto"
 	dp "github.com/thetatoken/ukulele/dispatcher"
+	"github.com/thetatoken/ukulele/exporter"
 	ld "github.com/thetatoken/ukulele/ledger"
+	"github.com/thetatoken/ukulele/ledger/state"
 	mp "github.com/thetatoken/ukulele/mempool"
 	"github.com/thetatoken/ukulele/netsync"
 	"github.com/thetatoken/ukulele/p2p"
 	"github.com/thetatoken/ukulele/rpc"
 	"github.com/thetatoken/ukulele/store"
 	"github.com/thetatoken/ukulele/store/database"
+	"github.com/thetatoken/ukulele/store/database/backend"
+	"github.com/thetatoken/ukulele/store/freezer"
 	"github.com/thetatoken/ukulele/store/kvstore"
+	"github.com/thetatoken/ukulele/webhook"
 )
 
 type Node struct {
@@ -31,38 +38,100 @@ type Node struct {
 	Ledger           core.Ledger
 	Mempool          *mp.Mempool
 	RPC              *rpc.ThetaRPCServer
+	DBMonitor        *backend.Monitor
+	Exporter         *exporter.Exporter
+	Webhooks         *webhook.Service
+	Metrics          *prometheus.Server
 
 	// Life cycle
-	wg      *sync.WaitGroup
-	quit    chan struct{}
-	ctx     context.Context
-	cancel  context.CancelFunc
-	stopped bool
+	services *lifecycle
+	cancel   context.CancelFunc
 }
 
 type Params struct {
 	ChainID    string
 	PrivateKey *crypto.PrivateKey
+	Signer     core.Signer          // Signer of the validator, PrivateKey signs if not set
+	SignGuard  *consensus.SignGuard // Double-sign protection of the votes, none if not set
 	Root       *core.Block
 	Validators *core.ValidatorSet
 	Network    p2p.Network
 	DB         database.Database
+	Freezer    *freezer.Freezer // Freezer of the ancient blocks, all the blocks are kept in DB if not set
+	NewLedger  LedgerCreator    // Creates the state machine of the chain, the Theta ledger if not set
+	DryRun     bool             // Validates and executes the blocks without voting or proposing, also set by consensus.dryRun
+	TxFilter   ld.TxFilter      // Leaves transactions out of the proposed blocks, built from the proposer config if not set
+}
+
+// LedgerCreator creates the ledger, i.e. the state machine, driven by the consensus engine
+// of the node
+type LedgerCreator func(chainID string, db database.Database, consensus core.ConsensusEngine,
+	valMgr core.ValidatorManager, mempool *mp.Mempool) core.Ledger
+
+// NewThetaLedger is the default LedgerCreator
+func NewThetaLedger(chainID string, db database.Database, consensus core.ConsensusEngine,
+	valMgr core.ValidatorManager, mempool *mp.Mempool) core.Ledger {
+	return ld.NewLedger(chainID, db, consensus, valMgr, mempool)
 }
 
 func NewNode(params *Params) *Node {
+	if db, ok := params.DB.(database.Backend); ok && metrics.Enabled {
+		params.DB = backend.NewMeteredBackend(db)
+	}
 	store := kvstore.NewKVStore(params.DB)
 	chain := blockchain.NewChain(params.ChainID, store, params.Root)
-	validatorManager := consensus.NewFixedValidatorManager(params.Validators)
+	if params.Freezer != nil {
+		chain.SetFreezer(params.Freezer, common.GetConfig().Storage.FreezerRetainedBlocks)
+	}
+	recoverConsensusState(store, chain, params.DB, params.NewLedger == nil)
+	var validatorManager core.ValidatorManager
+	if common.GetConfig().Consensus.ProposerSelection == "vrf" {
+		validatorManager = consensus.NewVRFValidatorManager(params.Validators, params.Root.Hash())
+	} else {
+		validatorManager = consensus.NewFixedValidatorManager(params.Validators)
+	}
 	dispatcher := dp.NewDispatcher(params.Network)
 	consensus := consensus.NewConsensusEngine(params.PrivateKey, store, chain, dispatcher, validatorManager)
+	if params.Signer != nil {
+		consensus.SetSigner(params.Signer)
+	}
+	if params.SignGuard != nil {
+		consensus.SetSignGuard(params.SignGuard)
+	}
+	if params.DryRun {
+		consensus.SetDryRun(true)
+	}
 	syncMgr := netsync.NewSyncManager(chain, consensus, params.Network, dispatcher, consensus)
 	mempool := mp.CreateMempool(dispatcher)
-	ledger := ld.NewLedger(params.ChainID, params.DB, consensus, validatorManager, mempool)
+	newLedger := params.NewLedger
+	if newLedger == nil {
+		newLedger = NewThetaLedger
+	}
+	ledger := newLedger(params.ChainID, params.DB, consensus, validatorManager, mempool)
+	setTxFilter(params, ledger)
 	consensus.SetLedger(ledger)
 	mempool.SetLedger(ledger)
-	txMsgHandler := mp.CreateMempoolMessageHandler(mempool)
+	syncMgr.SetTxSource(mempool)
+	consensus.SetSyncStatusReporter(syncMgr)
+	gossipRateLimit := common.GetConfig().Mempool.GossipRateLimit
+	txMsgHandler := p2p.WrapMessageHandler(mp.CreateMempoolMessageHandler(mempool),
+		p2p.DedupMiddleware(mp.GossipDedupCacheSize),
+		p2p.RateLimitMiddleware(float64(gossipRateLimit), gossipRateLimit))
 	params.Network.RegisterMessageHandler(txMsgHandler)
 
+	var dbMonitor *backend.Monitor
+	if db, ok := params.DB.(database.Backend); ok {
+		categories := []backend.Category{
+			{Name: "indexes", Prefixes: blockchain.IndexKeyPrefixes()},
+			// The blocks and the state trie nodes are both keyed by their hashes, so they
+			// share the rest of the key space.
+			{Name: "blocks_and_state"},
+		}
+		dbMonitor = backend.NewMonitor(db, categories,
+			time.Duration(common.GetConfig().Storage.StatsInterval)*time.Second,
+			time.Duration(common.GetConfig().Storage.CompactionInterval)*time.Second)
+	}
+
 	node := &Node{
 		Store:            store,
 		Chain:            chain,
@@ -72,29 +141,161 @@ func NewNode(params *Params) *Node {
 		Dispatcher:       dispatcher,
 		Ledger:           ledger,
 		Mempool:          mempool,
+		DBMonitor:        dbMonitor,
 	}
 
-	if viper.GetBool(common.CfgRPCEnabled) {
-		node.RPC = rpc.NewThetaRPCServer(mempool, ledger, chain, consensus)
+	if config := common.GetConfig().Webhook; config.Enabled {
+		node.Webhooks = webhook.NewService(chain, consensus, store, config.MaxAttempts,
+			time.Duration(config.Timeout)*time.Second)
 	}
 
+	if common.GetConfig().RPC.Enabled {
+		// The RPC methods read the accounts, receipts, etc. of the Theta ledger
+		if thetaLedger, ok := ledger.(*ld.Ledger); ok {
+			node.RPC = rpc.NewThetaRPCServer(mempool, thetaLedger, chain, consensus, syncMgr, dbMonitor, node.Webhooks)
+		} else {
+			log.Warnf("RPC server disabled, since it requires the Theta ledger")
+		}
+	}
+	if config := common.GetConfig().Exporter; config.Enabled {
+		// The records carry the receipts and the balance changes of the Theta ledger
+		if thetaLedger, ok := ledger.(*ld.Ledger); ok {
+			sink, err := exporter.NewSink(config)
+			if err != nil {
+				log.WithFields(log.Fields{"err": err}).Fatal("Failed to create the exporter sink")
+			}
+			node.Exporter = exporter.NewExporter(chain, thetaLedger, store, sink, config.StartHeight,
+				config.BatchSize, time.Duration(config.PollInterval)*time.Second)
+		} else {
+			log.Warnf("Exporter disabled, since it requires the Theta ledger")
+		}
+	}
+	if metrics.Enabled {
+		node.Metrics = prometheus.NewServer(metrics.DefaultRegistry, common.GetConfig().Metrics.Port)
+	}
+	node.addServices()
+
 	return node
 }
 
-// Start starts sub components and kick off the main loop.
-func (n *Node) Start(ctx context.Context) {
+// setTxFilter applies the policy of the operator on the transactions of the blocks
+// proposed by the Theta ledger.
+func setTxFilter(params *Params, ledger core.Ledger) {
+	filter := params.TxFilter
+	if filter == nil {
+		var err error
+		if filter, err = ld.NewTxFilterFromConfig(common.GetConfig().Proposer); err != nil {
+			log.WithFields(log.Fields{"err": err}).Fatal("Failed to create the proposer transaction filter")
+		}
+	}
+	if filter == nil {
+		return
+	}
+	if thetaLedger, ok := ledger.(*ld.Ledger); ok {
+		thetaLedger.SetTxFilter(filter)
+	} else {
+		log.Warnf("Proposer transaction filter disabled, since it requires the Theta ledger")
+	}
+}
+
+// addServices adds the sub components in the order of their start. The network is
+// started once the components handling its messages are running, and it is stopped
+// first on shutdown; the servers reading the chain come last.
+func (n *Node) addServices() {
+	n.services = newLifecycle()
+	if n.DBMonitor != nil {
+		n.mustAddService("dbmonitor", startWait(n.DBMonitor.Start, n.DBMonitor.Wait))
+	}
+	n.mustAddService("consensus", startWait(n.Consensus.Start, n.Consensus.Wait))
+	n.mustAddService("sync", startWait(n.SyncManager.Start, n.SyncManager.Wait))
+	n.mustAddService("mempool", n.Mempool)
+	n.mustAddService("dispatcher", n.Dispatcher)
+	if n.Exporter != nil {
+		n.mustAddService("exporter", startWait(n.Exporter.Start, n.Exporter.Wait))
+	}
+	if n.Webhooks != nil {
+		n.mustAddService("webhooks", startWait(n.Webhooks.Start, n.Webhooks.Wait))
+	}
+	if n.RPC != nil {
+		n.mustAddService("rpc", startWait(n.RPC.Start, n.RPC.Wait))
+	}
+	if n.Metrics != nil {
+		n.mustAddService("metrics", startWait(n.Metrics.Start, n.Metrics.Wait))
+	}
+}
+
+func (n *Node) mustAddService(name string, service Service) {
+	if err := n.services.add(name, service); err != nil {
+		log.Panic(err)
+	}
+}
+
+// startWait turns the Start and Wait methods of a sub component which cannot fail to
+// start into a Service.
+func startWait(start func(ctx context.Context), wait func()) Service {
+	return ServiceFuncs{
+		StartFunc: func(ctx context.Context) error {
+			start(ctx)
+			return nil
+		},
+		WaitFunc: wait,
+	}
+}
+
+// AddService adds a service to run along with the node, e.g. to inject a component in
+// tests. The services added start after the sub components of the node, in the order
+// they are added, and stop before them. It must be called before Start.
+func (n *Node) AddService(name string, service Service) error {
+	return n.services.add(name, service)
+}
+
+// recoverConsensusState rolls the consensus state back to the last consistent
+// finalized block if the node crashed while updating it, and logs what was repaired.
+// The state roots are only checked against the DB for the Theta ledger, whose state
+// trie is known.
+func recoverConsensusState(chainStore store.Store, chain *blockchain.Chain, db database.Database, thetaLedger bool) {
+	var hasState func(root common.Hash) bool
+	if thetaLedger {
+		hasState = func(root common.Hash) bool {
+			return state.NewStoreView(0, root, db) != nil
+		}
+	}
+	report, err := consensus.Recover(chainStore, chain, hasState)
+	if err != nil {
+		log.WithFields(log.Fields{"err": err}).Fatal("Failed to recover the consensus state")
+	}
+	for _, repair := range report.Repairs {
+		log.WithFields(log.Fields{
+			"kind":   repair.Kind,
+			"block":  repair.Block.Hex(),
+			"height": repair.Height,
+		}).Warn(repair.Detail)
+	}
+	if len(report.Repairs) > 0 {
+		log.WithFields(log.Fields{
+			"lastFinalizedBlock":  report.LastFinalizedBlock.Hash().Hex(),
+			"lastFinalizedHeight": report.LastFinalizedBlock.Height,
+			"highestCCBlock":      report.HighestCCBlock.Hash().Hex(),
+			"highestCCHeight":     report.HighestCCBlock.Height,
+		}).Warn("Recovered the consensus state after a crash")
+	}
+}
+
+// Start starts the sub components in order, and stops them in the reverse order once
+// ctx is canceled or Stop is called. If a sub component fails to start, the ones
+// already started are stopped and the error is returned.
+func (n *Node) Start(ctx context.Context) error {
 	c, cancel := context.WithCancel(ctx)
-	n.ctx = c
 	n.cancel = cancel
 
-	n.Consensus.Start(n.ctx)
-	n.SyncManager.Start(n.ctx)
-	n.Dispatcher.Start(n.ctx)
-	n.Mempool.Start(n.ctx)
-
-	if viper.GetBool(common.CfgRPCEnabled) {
-		n.RPC.Start(n.ctx)
+	if n.Metrics != nil {
+		go metrics.CollectProcessMetrics(3 * time.Second)
+	}
+	if err := n.services.start(c); err != nil {
+		cancel()
+		return err
 	}
+	return nil
 }
 
 // Stop notifies all sub components to stop without blocking.
@@ -104,9 +305,5 @@ func (n *Node) Stop() {
 
 // Wait blocks until all sub components stop.
 func (n *Node) Wait() {
-	n.Consensus.Wait()
-	n.SyncManager.Wait()
-	if n.RPC != nil {
-		n.RPC.Wait()
-	}
+	n.services.wait()
 }
@@ -98,6 +98,10 @@ func CreateConnection(netconn net.Conn, config ConnectionConfig) *Connection {
 	channelTransaction := createDefaultChannel(common.ChannelIDTransaction)
 	channelPeerDiscover := createDefaultChannel(common.ChannelIDPeerDiscovery)
 	channelPing := createDefaultChannel(common.ChannelIDPing)
+	channelGuardian := createDefaultChannel(common.ChannelIDGuardian)
+	channelBlockTxs := createDefaultChannel(common.ChannelIDBlockTxs)
+	channelCompactBlock := createDefaultChannel(common.ChannelIDCompactBlock)
+	channelStatus := createDefaultChannel(common.ChannelIDStatus)
 	channels := []*Channel{
 		&channelCheckpoint,
 		&channelHeader,
@@ -107,6 +111,10 @@ func CreateConnection(netconn net.Conn, config ConnectionConfig) *Connection {
 		&channelTransaction,
 		&channelPeerDiscover,
 		&channelPing,
+		&channelGuardian,
+		&channelBlockTxs,
+		&channelCompactBlock,
+		&channelStatus,
 	}
 
 	success, channelGroup := createChannelGroup(getDefaultChannelGroupConfig(), channels)
@@ -218,6 +226,7 @@ func (conn *Connection) EnqueueMessage(channelID common.ChannelIDEnum, message i
 	}
 	success := channel.enqueueMessage(msgBytes)
 	if success {
+		getChannelMetrics(channelID).markOut(len(msgBytes))
 		conn.scheduleSendPulse()
 	}
 
@@ -240,6 +249,7 @@ func (conn *Connection) AttemptToEnqueueMessage(channelID common.ChannelIDEnum,
 	}
 	success := channel.attemptToEnqueueMessage(msgBytes)
 	if success {
+		getChannelMetrics(channelID).markOut(len(msgBytes))
 		conn.scheduleSendPulse()
 	}
 
@@ -406,6 +416,7 @@ func (conn *Connection) handleReceivedPacket(packet *Packet) (success bool) {
 	if aggregatedBytes == nil {
 		return true
 	}
+	getChannelMetrics(channelID).markIn(len(aggregatedBytes))
 
 	message, err := conn.onParse(packet.ChannelID, aggregatedBytes)
 	if err != nil {
@@ -0,0 +1,52 @@
+package connection
+
+import (
+	"sync"
+
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/common/metrics"
+)
+
+// channelMetrics counts the messages and bytes sent and received on a channel,
+// summed over all the connections
+type channelMetrics struct {
+	inMessages  metrics.Meter
+	inBytes     metrics.Meter
+	outMessages metrics.Meter
+	outBytes    metrics.Meter
+}
+
+var (
+	channelMetricsMu  sync.Mutex
+	channelMetricsMap = make(map[common.ChannelIDEnum]*channelMetrics)
+)
+
+// getChannelMetrics returns the metrics of the given channel. They are registered on
+// first use, so that they are enabled if metrics collection is enabled at startup.
+func getChannelMetrics(channelID common.ChannelIDEnum) *channelMetrics {
+	channelMetricsMu.Lock()
+	defer channelMetricsMu.Unlock()
+
+	if cm, ok := channelMetricsMap[channelID]; ok {
+		return cm
+	}
+	prefix := "p2p/channel/" + channelID.Name()
+	cm := &channelMetrics{
+		inMessages:  metrics.GetOrRegisterMeter(prefix+"/in/messages", nil),
+		inBytes:     metrics.GetOrRegisterMeter(prefix+"/in/bytes", nil),
+		outMessages: metrics.GetOrRegisterMeter(prefix+"/out/messages", nil),
+		outBytes:    metrics.GetOrRegisterMeter(prefix+"/out/bytes", nil),
+	}
+	channelMetricsMap[channelID] = cm
+	return cm
+}
+
+func (cm *channelMetrics) markIn(numBytes int) {
+	cm.inMessages.Mark(1)
+	cm.inBytes.Mark(int64(numBytes))
+}
+
+func (cm *channelMetrics) markOut(numBytes int) {
+	cm.outMessages.Mark(1)
+	cm.outBytes.Mark(int64(numBytes))
+}
@@ -682,6 +682,9 @@ func (a *AddrBook) calcOldBucket(addr *nu.NetAddress) int {
 // "local" for a local address and the string "unroutable for an unroutable
 // address.
 func (a *AddrBook) groupKey(na *nu.NetAddress) string {
+	if na.Onion() {
+		return "onion"
+	}
 	if a.routabilityStrict && na.Local() {
 		return "local"
 	}
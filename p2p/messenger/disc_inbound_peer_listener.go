@@ -24,9 +24,9 @@ const (
 type InboundPeerListener struct {
 	discMgr *PeerDiscoveryManager
 
-	netListener  net.Listener
-	internalAddr *netutil.NetAddress
-	externalAddr *netutil.NetAddress
+	netListeners  []net.Listener
+	internalAddrs []*netutil.NetAddress
+	externalAddrs []*netutil.NetAddress // dialable addresses, in the order of the listeners
 
 	inboundCallback InboundCallback
 
@@ -50,24 +50,28 @@ type InboundPeerListenerConfig struct {
 // InboundCallback is called when an inbound peer is created
 type InboundCallback func(peer *pr.Peer, err error)
 
-// createInboundPeerListener creates a new inbound peer listener instance
-func createInboundPeerListener(discMgr *PeerDiscoveryManager, protocol string, localAddr string,
+// createInboundPeerListener creates a new inbound peer listener instance, listening on
+// each of the local addresses
+func createInboundPeerListener(discMgr *PeerDiscoveryManager, protocol string, localAddrs []string,
 	skipUPNP bool, config InboundPeerListenerConfig) (InboundPeerListener, error) {
-	localAddrIP, localAddrPort := splitHostPort(localAddr)
-	netListener := initiateNetListener(protocol, localAddr)
-	netListenerIP, netListenerPort := splitHostPort(netListener.Addr().String())
-	log.Infof("[p2p] Local network listener, ip: %v, port: %v", netListenerIP, netListenerPort)
+	inboundPeerListener := InboundPeerListener{
+		discMgr: discMgr,
+		config:  config,
+		wg:      &sync.WaitGroup{},
+	}
 
-	internalNetAddr := getInternalNetAddress(localAddr)
-	externalNetAddr := getExternalNetAddress(localAddrIP, localAddrPort, netListenerPort, skipUPNP)
+	for _, localAddr := range localAddrs {
+		localAddrIP, localAddrPort := splitHostPort(localAddr)
+		netListener := initiateNetListener(listenProtocol(protocol, localAddrIP), localAddr)
+		netListenerIP, netListenerPort := splitHostPort(netListener.Addr().String())
+		log.Infof("[p2p] Local network listener, ip: %v, port: %v", netListenerIP, netListenerPort)
 
-	inboundPeerListener := InboundPeerListener{
-		discMgr:      discMgr,
-		netListener:  netListener,
-		internalAddr: internalNetAddr,
-		externalAddr: externalNetAddr,
-		config:       config,
-		wg:           &sync.WaitGroup{},
+		internalNetAddr := getInternalNetAddress(localAddr)
+		externalNetAddr := getExternalNetAddress(localAddrIP, localAddrPort, netListenerPort, skipUPNP)
+
+		inboundPeerListener.netListeners = append(inboundPeerListener.netListeners, netListener)
+		inboundPeerListener.internalAddrs = append(inboundPeerListener.internalAddrs, internalNetAddr)
+		inboundPeerListener.externalAddrs = append(inboundPeerListener.externalAddrs, externalNetAddr)
 	}
 
 	return inboundPeerListener, nil
@@ -86,15 +90,27 @@ func (ipl *InboundPeerListener) Start(ctx context.Context) error {
 	ipl.ctx = c
 	ipl.cancel = cancel
 
-	ipl.wg.Add(1)
-	go ipl.listenRoutine()
+	for _, netListener := range ipl.netListeners {
+		ipl.wg.Add(1)
+		go ipl.listenRoutine(netListener)
+	}
+
+	// Closing the listeners unblocks the listen routines on shutdown
+	go func() {
+		<-c.Done()
+		for _, netListener := range ipl.netListeners {
+			netListener.Close()
+		}
+	}()
 
 	return nil
 }
 
 // Stop is called when the InboundPeerListener instance stops
 func (ipl *InboundPeerListener) Stop() {
-	ipl.netListener.Close()
+	for _, netListener := range ipl.netListeners {
+		netListener.Close()
+	}
 	ipl.cancel()
 }
 
@@ -108,12 +124,17 @@ func (ipl *InboundPeerListener) SetInboundCallback(incb InboundCallback) {
 	ipl.inboundCallback = incb
 }
 
-func (ipl *InboundPeerListener) listenRoutine() {
+func (ipl *InboundPeerListener) listenRoutine(netListener net.Listener) {
 	defer ipl.wg.Done()
 
 	for {
-		netconn, err := ipl.netListener.Accept()
+		netconn, err := netListener.Accept()
 		if err != nil {
+			select {
+			case <-ipl.ctx.Done():
+				return
+			default:
+			}
 			panic(fmt.Sprintf("[p2p] net listener error: %v", err))
 		}
 
@@ -126,21 +147,27 @@ func (ipl *InboundPeerListener) listenRoutine() {
 
 // InternalAddress returns the internal address of the current node
 func (ipl *InboundPeerListener) InternalAddress() *netutil.NetAddress {
-	return ipl.internalAddr
+	return ipl.internalAddrs[0]
 }
 
 // ExternalAddress returns the external address of the current node
 func (ipl *InboundPeerListener) ExternalAddress() *netutil.NetAddress {
-	return ipl.externalAddr
+	return ipl.externalAddrs[0]
+}
+
+// ExternalAddresses returns the external addresses of the current node, one
+// for each listener
+func (ipl *InboundPeerListener) ExternalAddresses() []*netutil.NetAddress {
+	return ipl.externalAddrs
 }
 
 // NetListener returns the attached network listener
 func (ipl *InboundPeerListener) NetListener() net.Listener {
-	return ipl.netListener
+	return ipl.netListeners[0]
 }
 
 func (ipl *InboundPeerListener) String() string {
-	return fmt.Sprintf("InboundPeerListener(@%v)", ipl.externalAddr)
+	return fmt.Sprintf("InboundPeerListener(@%v)", ipl.externalAddrs)
 }
 
 func splitHostPort(addr string) (host string, port int) {
@@ -155,6 +182,22 @@ func splitHostPort(addr string) (host string, port int) {
 	return host, port
 }
 
+// listenProtocol restricts the protocol to IPv4 or IPv6 depending on the IP to
+// listen on, so that the node can listen on the IPv4 and IPv6 wildcard addresses
+// with the same port
+func listenProtocol(protocol string, ip string) string {
+	if protocol != "tcp" || ip == "" {
+		return protocol
+	}
+	if parsed := net.ParseIP(ip); parsed != nil {
+		if parsed.To4() != nil {
+			return "tcp4"
+		}
+		return "tcp6"
+	}
+	return protocol
+}
+
 func initiateNetListener(protocol string, localAddr string) (netListener net.Listener) {
 	var err error
 	for i := 0; i < tryListenSeconds; i++ {
@@ -190,7 +233,12 @@ func getExternalNetAddress(localAddrIP string, localAddrPort int, listenerPort i
 	}
 	// Otherwise just use the local address
 	if externalAddr == nil {
-		externalAddr = getNaiveExternalAddress(listenerPort)
+		ip := net.ParseIP(localAddrIP)
+		if ip == nil || ip.IsUnspecified() {
+			externalAddr = getNaiveExternalAddress(listenerPort, ip != nil && ip.To4() == nil)
+		} else {
+			externalAddr = netutil.NewNetAddressIPPort(ip, uint16(listenerPort))
+		}
 	}
 	if externalAddr == nil {
 		panic(fmt.Sprintf("[p2p] Could not determine external address!"))
@@ -227,7 +275,9 @@ func getUPNPExternalAddress(externalPort, internalPort int) *netutil.NetAddress
 	return netutil.NewNetAddressIPPort(ext, uint16(externalPort))
 }
 
-func getNaiveExternalAddress(port int) *netutil.NetAddress {
+// getNaiveExternalAddress returns the first IPv4, or global unicast IPv6 if ipv6
+// is set, address of the interfaces
+func getNaiveExternalAddress(port int, ipv6 bool) *netutil.NetAddress {
 	addrs, err := net.InterfaceAddrs()
 	if err != nil {
 		panic(fmt.Sprintf("[p2p] Could not fetch interface addresses: %v", err))
@@ -238,6 +288,12 @@ func getNaiveExternalAddress(port int) *netutil.NetAddress {
 		if !ok {
 			continue
 		}
+		if ipv6 {
+			if ipnet.IP.To4() != nil || !ipnet.IP.IsGlobalUnicast() {
+				continue
+			}
+			return netutil.NewNetAddressIPPort(ipnet.IP, uint16(port))
+		}
 		v4 := ipnet.IP.To4()
 		if v4 == nil || v4[0] == 127 {
 			continue
@@ -166,17 +166,20 @@ func (pdmh *PeerDiscoveryMessageHandler) handlePeerAddressRequest(peer *pr.Peer,
 }
 
 func (pdmh *PeerDiscoveryMessageHandler) handlePeerAddressReply(peer *pr.Peer, message PeerDiscoveryMessage) {
-	validAddressMap := make(map[*netutil.NetAddress]bool)
+	validAddressMap := make(map[string][]*netutil.NetAddress) // map: peerID |-> addresses of the peer
 
 	for _, idAddr := range message.Addresses {
 		if idAddr.Addr.Valid() && pdmh.discMgr.messenger.ID() != idAddr.ID && !pdmh.discMgr.peerTable.PeerExists(idAddr.ID) {
-			validAddressMap[idAddr.Addr] = true
+			validAddressMap[idAddr.ID] = append(validAddressMap[idAddr.ID], idAddr.Addr)
 		}
 	}
 	if len(validAddressMap) > 0 {
+		// Dial each peer at its most reachable address
+		localAddresses := pdmh.discMgr.inboundPeerListener.ExternalAddresses()
 		var validAddresses []*netutil.NetAddress
-		for addr := range validAddressMap {
-			validAddresses = append(validAddresses, addr)
+		for _, addrs := range validAddressMap {
+			netutil.SortByReachability(addrs, localAddresses)
+			validAddresses = append(validAddresses, addrs[0])
 		}
 		pdmh.connectToOutboundPeers(validAddresses)
 	}
@@ -188,7 +191,7 @@ func (pdmh *PeerDiscoveryMessageHandler) SetDiscoveryCallback(disccb InboundCall
 }
 
 func (pdmh *PeerDiscoveryMessageHandler) connectToOutboundPeers(addresses []*netutil.NetAddress) {
-	numPeers := int(pdmh.discMgr.peerTable.GetTotalNumPeers())
+	numPeers := int(pdmh.discMgr.peerTable.GetTotalNumPeers() - pdmh.discMgr.peerTable.GetTotalNumPinnedPeers())
 	numNeeded := int(GetDefaultPeerDiscoveryManagerConfig().MaxNumPeers) - numPeers
 	if numNeeded > 0 {
 		numToAdd := len(addresses) * peersAddressesSubSamplingPercent / 100
@@ -221,8 +224,11 @@ func (pdmh *PeerDiscoveryMessageHandler) maintainSufficientConnectivityRoutine()
 	defer pdmh.wg.Done()
 
 	peerDiscoveryPulse := time.NewTicker(pdmh.peerDiscoveryPulseInterval)
+	defer peerDiscoveryPulse.Stop()
 	for {
 		select {
+		case <-pdmh.ctx.Done():
+			return
 		case <-peerDiscoveryPulse.C:
 			pdmh.maintainSufficientConnectivity()
 		}
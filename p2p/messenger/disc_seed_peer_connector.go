@@ -16,6 +16,7 @@ import (
 type SeedPeerConnector struct {
 	discMgr *PeerDiscoveryManager
 
+	mu                   *sync.Mutex
 	selfNetAddress       netutil.NetAddress
 	seedPeerNetAddresses []netutil.NetAddress
 
@@ -36,6 +37,7 @@ func createSeedPeerConnector(discMgr *PeerDiscoveryManager,
 	spc := SeedPeerConnector{
 		discMgr:   discMgr,
 		Connected: make(chan bool, numSeedPeers),
+		mu:        &sync.Mutex{},
 		wg:        &sync.WaitGroup{},
 	}
 
@@ -46,19 +48,53 @@ func createSeedPeerConnector(discMgr *PeerDiscoveryManager,
 	}
 	spc.selfNetAddress = *selfNetAddress
 
+	spc.seedPeerNetAddresses, err = spc.parseSeedPeers(seedPeerNetAddressStrs)
+	return spc, err
+}
+
+func (spc *SeedPeerConnector) parseSeedPeers(seedPeerNetAddressStrs []string) ([]netutil.NetAddress, error) {
+	seedPeerNetAddresses := []netutil.NetAddress{}
 	for _, seedPeerNetAddressStr := range seedPeerNetAddressStrs {
 		seedNetAddress, err := netutil.NewNetAddressString(seedPeerNetAddressStr)
 		if err != nil {
 			log.Errorf("[p2p] Failed to parse the seed network address: %v", seedPeerNetAddressStr)
-			return spc, err
+			return seedPeerNetAddresses, err
 		}
-		if seedNetAddress.Equals(selfNetAddress) {
+		if seedNetAddress.Equals(&spc.selfNetAddress) {
 			continue
 		}
-		spc.seedPeerNetAddresses = append(spc.seedPeerNetAddresses, *seedNetAddress)
+		seedPeerNetAddresses = append(seedPeerNetAddresses, *seedNetAddress)
+	}
+	return seedPeerNetAddresses, nil
+}
+
+// SetSeedPeers replaces the seed peers, and connects to the ones that were not seed
+// peers before. The peers already connected stay connected.
+func (spc *SeedPeerConnector) SetSeedPeers(seedPeerNetAddressStrs []string) error {
+	seedPeerNetAddresses, err := spc.parseSeedPeers(seedPeerNetAddressStrs)
+	if err != nil {
+		return err
+	}
+
+	spc.mu.Lock()
+	added := []netutil.NetAddress{}
+	for _, addr := range seedPeerNetAddresses {
+		known := false
+		for _, prev := range spc.seedPeerNetAddresses {
+			if addr.Equals(&prev) {
+				known = true
+				break
+			}
+		}
+		if !known {
+			added = append(added, addr)
+		}
 	}
+	spc.seedPeerNetAddresses = seedPeerNetAddresses
+	spc.mu.Unlock()
 
-	return spc, nil
+	spc.connectToPeers(added, false)
+	return nil
 }
 
 // Start is called when the SeedPeerConnector starts
@@ -82,7 +118,17 @@ func (spc *SeedPeerConnector) Wait() {
 }
 
 func (spc *SeedPeerConnector) connectToSeedPeers() {
-	perm := rand.Perm(len(spc.seedPeerNetAddresses))
+	spc.mu.Lock()
+	seedPeerNetAddresses := spc.seedPeerNetAddresses
+	spc.mu.Unlock()
+
+	spc.connectToPeers(seedPeerNetAddresses, true)
+}
+
+// connectToPeers connects to the given peers, and reports the outcomes through the
+// Connected channel if notify is set.
+func (spc *SeedPeerConnector) connectToPeers(peerNetAddresses []netutil.NetAddress, notify bool) {
+	perm := rand.Perm(len(peerNetAddresses))
 	for i := 0; i < len(perm); i++ { // create outbound peers in a random order
 		spc.wg.Add(1)
 		go func(i int) {
@@ -90,13 +136,14 @@ func (spc *SeedPeerConnector) connectToSeedPeers() {
 
 			time.Sleep(time.Duration(rand.Int63n(3000)) * time.Millisecond)
 			j := perm[i]
-			peerNetAddress := spc.seedPeerNetAddresses[j]
+			peerNetAddress := peerNetAddresses[j]
 			_, err := spc.discMgr.connectToOutboundPeer(&peerNetAddress, true)
+			if notify {
+				spc.Connected <- err == nil
+			}
 			if err != nil {
-				spc.Connected <- false
 				log.Errorf("[p2p] Failed to connect to seed peer %v: %v", peerNetAddress.String(), err)
 			} else {
-				spc.Connected <- true
 				log.Infof("[p2p] Successfully connected to seed peer %v", peerNetAddress.String())
 			}
 		}(i)
@@ -0,0 +1,139 @@
+package messenger
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/thetatoken/ukulele/p2p/netutil"
+	pr "github.com/thetatoken/ukulele/p2p/peer"
+)
+
+const (
+	// Delay before the first reconnection attempt, doubled after each failed attempt
+	validatorPeerMinBackoff = 1 * time.Second
+
+	// Upper bound of the delay between two reconnection attempts
+	validatorPeerMaxBackoff = 60 * time.Second
+
+	// Interval at which a connected validator peer is checked
+	validatorPeerCheckInterval = 2 * time.Second
+)
+
+//
+// ValidatorPeerConnector keeps the node connected to its validator peers. Each
+// validator peer has a dedicated dialer that reconnects with exponential backoff
+// whenever the peer drops out of the peer table
+//
+type ValidatorPeerConnector struct {
+	discMgr *PeerDiscoveryManager
+
+	selfNetAddress            netutil.NetAddress
+	validatorPeerNetAddresses []netutil.NetAddress
+
+	// Life cycle
+	wg      *sync.WaitGroup
+	quit    chan struct{}
+	ctx     context.Context
+	cancel  context.CancelFunc
+	stopped bool
+}
+
+// createValidatorPeerConnector creates an instance of the ValidatorPeerConnector
+func createValidatorPeerConnector(discMgr *PeerDiscoveryManager,
+	selfNetAddressStr string, validatorPeerNetAddressStrs []string) (ValidatorPeerConnector, error) {
+	vpc := ValidatorPeerConnector{
+		discMgr: discMgr,
+		wg:      &sync.WaitGroup{},
+	}
+
+	selfNetAddress, err := netutil.NewNetAddressString(selfNetAddressStr)
+	if err != nil {
+		log.Errorf("[p2p] Failed to parse the self network address: %v", selfNetAddressStr)
+		return vpc, err
+	}
+	vpc.selfNetAddress = *selfNetAddress
+
+	for _, validatorPeerNetAddressStr := range validatorPeerNetAddressStrs {
+		netAddress, err := netutil.NewNetAddressString(validatorPeerNetAddressStr)
+		if err != nil {
+			log.Errorf("[p2p] Failed to parse the validator peer network address: %v", validatorPeerNetAddressStr)
+			return vpc, err
+		}
+		if netAddress.Equals(&vpc.selfNetAddress) {
+			continue
+		}
+		vpc.validatorPeerNetAddresses = append(vpc.validatorPeerNetAddresses, *netAddress)
+	}
+	return vpc, nil
+}
+
+// Start is called when the ValidatorPeerConnector starts
+func (vpc *ValidatorPeerConnector) Start(ctx context.Context) error {
+	c, cancel := context.WithCancel(ctx)
+	vpc.ctx = c
+	vpc.cancel = cancel
+
+	for i := range vpc.validatorPeerNetAddresses {
+		vpc.wg.Add(1)
+		go vpc.dialRoutine(&vpc.validatorPeerNetAddresses[i])
+	}
+	return nil
+}
+
+// Stop is called when the ValidatorPeerConnector stops
+func (vpc *ValidatorPeerConnector) Stop() {
+	vpc.cancel()
+}
+
+// Wait suspends the caller goroutine
+func (vpc *ValidatorPeerConnector) Wait() {
+	vpc.wg.Wait()
+}
+
+// dialRoutine connects to the validator peer, and reconnects to it whenever the
+// connection is lost, until the connector stops
+func (vpc *ValidatorPeerConnector) dialRoutine(peerNetAddress *netutil.NetAddress) {
+	defer vpc.wg.Done()
+
+	var peer *pr.Peer
+	backoff := time.Duration(0)
+	for {
+		wait := validatorPeerCheckInterval
+		if peer == nil || vpc.discMgr.peerTable.GetPeer(peer.ID()) == nil {
+			var err error
+			peer, err = vpc.discMgr.connectToValidatorPeer(peerNetAddress)
+			if err != nil {
+				peer = nil
+				backoff = nextValidatorPeerBackoff(backoff)
+				wait = backoff
+				log.Warnf("[p2p] Failed to connect to validator peer %v, retrying in %v: %v",
+					peerNetAddress.String(), backoff, err)
+			} else {
+				backoff = 0
+				log.Infof("[p2p] Successfully connected to validator peer %v", peerNetAddress.String())
+			}
+		}
+
+		select {
+		case <-vpc.ctx.Done():
+			vpc.stopped = true
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// nextValidatorPeerBackoff returns the delay before the next reconnection attempt
+// after a failed attempt, given the previous delay
+func nextValidatorPeerBackoff(backoff time.Duration) time.Duration {
+	if backoff < validatorPeerMinBackoff {
+		return validatorPeerMinBackoff
+	}
+	backoff *= 2
+	if backoff > validatorPeerMaxBackoff {
+		backoff = validatorPeerMaxBackoff
+	}
+	return backoff
+}
@@ -0,0 +1,22 @@
+package messenger
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidatorPeerBackoff(t *testing.T) {
+	assert := assert.New(t)
+
+	backoff := nextValidatorPeerBackoff(0)
+	assert.Equal(validatorPeerMinBackoff, backoff)
+
+	expected := []time.Duration{2 * time.Second, 4 * time.Second, 8 * time.Second,
+		16 * time.Second, 32 * time.Second, 60 * time.Second, 60 * time.Second}
+	for _, delay := range expected {
+		backoff = nextValidatorPeerBackoff(backoff)
+		assert.Equal(delay, backoff)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	log "github.com/sirupsen/logrus"
+	"github.com/thetatoken/ukulele/common/metrics"
 	cn "github.com/thetatoken/ukulele/p2p/connection"
 	"github.com/thetatoken/ukulele/p2p/netutil"
 	pr "github.com/thetatoken/ukulele/p2p/peer"
@@ -29,6 +30,8 @@ type PeerDiscoveryManager struct {
 	peerDiscMsgHandler  PeerDiscoveryMessageHandler // pro-actively connect to peer candidates obtained from connected peers
 	inboundPeerListener InboundPeerListener         // listen to incoming peering requests
 
+	validatorPeerConnector ValidatorPeerConnector // keep the validator peers connected
+
 	// Life cycle
 	wg      *sync.WaitGroup
 	quit    chan struct{}
@@ -41,8 +44,10 @@ type PeerDiscoveryManager struct {
 // PeerDiscoveryManagerConfig specifies the configuration for PeerDiscoveryManager
 //
 type PeerDiscoveryManagerConfig struct {
-	MaxNumPeers        uint
+	MaxNumPeers        uint // Not counting the validator peers
 	SufficientNumPeers uint
+	ValidatorPeers     []string
+	ListenAddresses    []string // Listen on the local network address if empty
 }
 
 // CreatePeerDiscoveryManager creates an instance of the PeerDiscoveryManager
@@ -66,16 +71,32 @@ func CreatePeerDiscoveryManager(msgr *Messenger, nodeInfo *p2ptypes.NodeInfo, ad
 		return discMgr, err
 	}
 
+	discMgr.validatorPeerConnector, err = createValidatorPeerConnector(discMgr, localNetworkAddr, config.ValidatorPeers)
+	if err != nil {
+		return discMgr, err
+	}
+
 	discMgr.peerDiscMsgHandler, err = createPeerDiscoveryMessageHandler(discMgr, localNetworkAddr)
 	if err != nil {
 		return discMgr, err
 	}
 
 	inlConfig := GetDefaultInboundPeerListenerConfig()
-	discMgr.inboundPeerListener, err = createInboundPeerListener(discMgr, networkProtocol, localNetworkAddr, skipUPNP, inlConfig)
+	listenAddrs := config.ListenAddresses
+	if len(listenAddrs) == 0 {
+		listenAddrs = []string{localNetworkAddr}
+	}
+	discMgr.inboundPeerListener, err = createInboundPeerListener(discMgr, networkProtocol, listenAddrs, skipUPNP, inlConfig)
 	if err != nil {
 		return discMgr, err
 	}
+	if len(config.ListenAddresses) > 0 {
+		// Advertise the dialable addresses of all the listeners in the handshake
+		nodeInfo.Port = discMgr.inboundPeerListener.InternalAddress().Port
+		for _, addr := range discMgr.inboundPeerListener.ExternalAddresses() {
+			nodeInfo.Addresses = append(nodeInfo.Addresses, addr.String())
+		}
+	}
 	discMgr.inboundPeerListener.SetInboundCallback(func(peer *pr.Peer, err error) {
 		if err == nil {
 			log.Infof("Inbound peer connected, ID: %v, from: %v", peer.ID(), peer.GetConnection().GetNetconn().RemoteAddr())
@@ -112,6 +133,11 @@ func (discMgr *PeerDiscoveryManager) Start(ctx context.Context) error {
 		return err
 	}
 
+	err = discMgr.validatorPeerConnector.Start(c)
+	if err != nil {
+		return err
+	}
+
 	err = discMgr.inboundPeerListener.Start(c)
 	if err != nil {
 		return err
@@ -133,6 +159,7 @@ func (discMgr *PeerDiscoveryManager) Stop() {
 // Wait suspends the caller goroutine
 func (discMgr *PeerDiscoveryManager) Wait() {
 	discMgr.seedPeerConnector.wg.Wait()
+	discMgr.validatorPeerConnector.wg.Wait()
 	discMgr.inboundPeerListener.wg.Wait()
 	discMgr.peerDiscMsgHandler.wg.Wait()
 	discMgr.wg.Wait()
@@ -140,12 +167,14 @@ func (discMgr *PeerDiscoveryManager) Wait() {
 
 // HandlePeerWithErrors handles peers that are in the error state.
 // If the peer is persistent, it will attempt to reconnect to the
-// peer. Otherwise, it disconnects from that peer
+// peer. Otherwise, it disconnects from that peer. The validator peers
+// are reconnected by the ValidatorPeerConnector
 func (discMgr *PeerDiscoveryManager) HandlePeerWithErrors(peer *pr.Peer) {
 	discMgr.peerTable.DeletePeer(peer.ID())
+	discMgr.updatePeersGauge()
 	peer.Stop()
 
-	if peer.IsPersistent() {
+	if peer.IsPersistent() && !peer.IsPinned() {
 		var err error
 		for i := 0; i < 3; i++ { // retry up to 3 times
 			if peer.IsOutbound() {
@@ -163,6 +192,22 @@ func (discMgr *PeerDiscoveryManager) HandlePeerWithErrors(peer *pr.Peer) {
 	}
 }
 
+// DisconnectMisbehavingPeer disconnects from the peer without reconnecting to it, and
+// removes its address from the address book. The pinned validator peers are kept
+func (discMgr *PeerDiscoveryManager) DisconnectMisbehavingPeer(peer *pr.Peer) {
+	if peer.IsPinned() {
+		log.Warnf("[p2p] Keeping misbehaving validator peer %v", peer.ID())
+		return
+	}
+	log.Warnf("[p2p] Disconnecting from misbehaving peer %v", peer.ID())
+	discMgr.peerTable.DeletePeer(peer.ID())
+	discMgr.updatePeersGauge()
+	peer.Stop()
+	if peer.IsOutbound() {
+		discMgr.addrBook.MarkBad(peer.NetAddress())
+	}
+}
+
 func (discMgr *PeerDiscoveryManager) connectToOutboundPeer(peerNetAddress *netutil.NetAddress, persistent bool) (*pr.Peer, error) {
 	log.Infof("[p2p] Connecting to outbound peer: %v...", peerNetAddress)
 	peerConfig := pr.GetDefaultPeerConfig()
@@ -177,6 +222,24 @@ func (discMgr *PeerDiscoveryManager) connectToOutboundPeer(peerNetAddress *netut
 	return peer, err
 }
 
+// connectToValidatorPeer connects to a validator peer. The validator peers are
+// pinned, and their connections are not subject to the send and receive rate limits
+func (discMgr *PeerDiscoveryManager) connectToValidatorPeer(peerNetAddress *netutil.NetAddress) (*pr.Peer, error) {
+	log.Infof("[p2p] Connecting to validator peer: %v...", peerNetAddress)
+	peerConfig := pr.GetDefaultPeerConfig()
+	connConfig := cn.GetDefaultConnectionConfig()
+	connConfig.SendRate = 0
+	connConfig.RecvRate = 0
+	peer, err := pr.CreateOutboundPeer(peerNetAddress, peerConfig, connConfig)
+	if err != nil {
+		log.Errorf("[p2p] Failed to create validator peer: %v", peerNetAddress)
+		return nil, err
+	}
+	peer.SetPinned(true)
+	err = discMgr.handshakeAndAddPeer(peer)
+	return peer, err
+}
+
 func (discMgr *PeerDiscoveryManager) connectWithInboundPeer(netconn net.Conn, persistent bool) (*pr.Peer, error) {
 	log.Infof("[p2p] Connecting with inbound peer: %v...", netconn.RemoteAddr())
 	peerConfig := pr.GetDefaultPeerConfig()
@@ -217,8 +280,14 @@ func (discMgr *PeerDiscoveryManager) handshakeAndAddPeer(peer *pr.Peer) error {
 		return errors.New(errMsg)
 	}
 
+	discMgr.updatePeersGauge()
+
 	discMgr.addrBook.AddAddress(peer.NetAddress(), peer.NetAddress())
 	discMgr.addrBook.Save()
 
 	return nil
 }
+
+func (discMgr *PeerDiscoveryManager) updatePeersGauge() {
+	metrics.GetOrRegisterGauge("p2p/peers", nil).Update(int64(discMgr.peerTable.GetTotalNumPeers()))
+}
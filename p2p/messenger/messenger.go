@@ -2,6 +2,7 @@ package messenger
 
 import (
 	"context"
+	"fmt"
 	"strconv"
 	"sync"
 
@@ -14,14 +15,26 @@ import (
 	p2ptypes "github.com/thetatoken/ukulele/p2p/types"
 )
 
+const (
+	// Penalty of a peer whose raw message made a handler panic while parsing it
+	inputPanicPenalty = 100
+
+	// Penalty of a peer whose message made a handler panic while handling it, which
+	// may come from the state of the node as well
+	handlerPanicPenalty = 20
+
+	// Misbehavior score from which the node disconnects from a peer
+	maxMisbehavior = 100
+)
+
 //
 // Messenger implements the Network interface
 //
 var _ p2p.Network = (*Messenger)(nil)
 
 type Messenger struct {
-	discMgr       *PeerDiscoveryManager
-	msgHandlerMap map[common.ChannelIDEnum](p2p.MessageHandler)
+	discMgr     *PeerDiscoveryManager
+	msgHandlers *p2p.MessageHandlerRegistry
 
 	peerTable pr.PeerTable
 	nodeInfo  p2ptypes.NodeInfo // information of our blockchain node
@@ -44,6 +57,8 @@ type MessengerConfig struct {
 	routabilityRestrict bool
 	skipUPNP            bool
 	networkProtocol     string
+	validatorPeers      []string
+	listenAddresses     []string
 }
 
 // CreateMessenger creates an instance of Messenger
@@ -51,15 +66,17 @@ func CreateMessenger(pubKey *crypto.PublicKey, seedPeerNetAddresses []string,
 	port int, msgrConfig MessengerConfig) (*Messenger, error) {
 
 	messenger := &Messenger{
-		msgHandlerMap: make(map[common.ChannelIDEnum](p2p.MessageHandler)),
-		peerTable:     pr.CreatePeerTable(),
-		nodeInfo:      p2ptypes.CreateNodeInfo(pubKey, uint16(port)),
-		config:        msgrConfig,
-		wg:            &sync.WaitGroup{},
+		msgHandlers: p2p.NewMessageHandlerRegistry(p2p.DefaultMiddlewares()...),
+		peerTable:   pr.CreatePeerTable(),
+		nodeInfo:    p2ptypes.CreateNodeInfo(pubKey, uint16(port)),
+		config:      msgrConfig,
+		wg:          &sync.WaitGroup{},
 	}
 
 	localNetAddress := "0.0.0.0:" + strconv.Itoa(port)
 	discMgrConfig := GetDefaultPeerDiscoveryManagerConfig()
+	discMgrConfig.ValidatorPeers = msgrConfig.validatorPeers
+	discMgrConfig.ListenAddresses = msgrConfig.listenAddresses
 	discMgr, err := CreatePeerDiscoveryManager(messenger, &(messenger.nodeInfo),
 		msgrConfig.addrBookFilePath, msgrConfig.routabilityRestrict,
 		seedPeerNetAddresses, msgrConfig.networkProtocol,
@@ -112,6 +129,11 @@ func (msgr *Messenger) Wait() {
 	msgr.wg.Wait()
 }
 
+// SetSeedPeers replaces the seed peers, and connects to the new ones
+func (msgr *Messenger) SetSeedPeers(seedPeerNetAddresses []string) error {
+	return msgr.discMgr.seedPeerConnector.SetSeedPeers(seedPeerNetAddresses)
+}
+
 // Broadcast broadcasts the given message to all the connected peers
 func (msgr *Messenger) Broadcast(message p2ptypes.Message) (successes chan bool) {
 	log.Debugf("[p2p] Broadcasting messages...")
@@ -139,15 +161,11 @@ func (msgr *Messenger) Send(peerID string, message p2ptypes.Message) bool {
 	return success
 }
 
-// RegisterMessageHandler registers the message handler
+// RegisterMessageHandler registers the message handler, wrapped with the default
+// middlewares
 func (msgr *Messenger) RegisterMessageHandler(msgHandler p2p.MessageHandler) {
-	channelIDs := msgHandler.GetChannelIDs()
-	for _, channelID := range channelIDs {
-		if msgr.msgHandlerMap[channelID] != nil {
-			log.Errorf("[p2p] Message handler is already added for channelID: %v", channelID)
-			return
-		}
-		msgr.msgHandlerMap[channelID] = msgHandler
+	if err := msgr.msgHandlers.Register(msgHandler); err != nil {
+		log.Errorf("[p2p] Failed to register message handler: %v", err)
 	}
 }
 
@@ -160,28 +178,33 @@ func (msgr *Messenger) ID() string {
 func (msgr *Messenger) AttachMessageHandlersToPeer(peer *pr.Peer) {
 	messageParser := func(channelID common.ChannelIDEnum, rawMessageBytes common.Bytes) (p2ptypes.Message, error) {
 		peerID := peer.ID()
-		msgHandler := msgr.msgHandlerMap[channelID]
+		msgHandler := msgr.msgHandlers.GetHandler(channelID)
 		if msgHandler == nil {
-			log.Errorf("[p2p] Failed to setup message parser for channelID %v", channelID)
+			return p2ptypes.Message{}, fmt.Errorf("No message handler for channel %v", channelID.Name())
 		}
 		message, err := msgHandler.ParseMessage(peerID, channelID, rawMessageBytes)
+		msgr.penalizePanic(peer, err)
 		return message, err
 	}
 	peer.GetConnection().SetMessageParser(messageParser)
 
 	messageEncoder := func(channelID common.ChannelIDEnum, message interface{}) (common.Bytes, error) {
-		msgHandler := msgr.msgHandlerMap[channelID]
+		msgHandler := msgr.msgHandlers.GetHandler(channelID)
+		if msgHandler == nil {
+			return nil, fmt.Errorf("No message handler for channel %v", channelID.Name())
+		}
 		return msgHandler.EncodeMessage(message)
 	}
 	peer.GetConnection().SetMessageEncoder(messageEncoder)
 
 	receiveHandler := func(message p2ptypes.Message) error {
 		channelID := message.ChannelID
-		msgHandler := msgr.msgHandlerMap[channelID]
+		msgHandler := msgr.msgHandlers.GetHandler(channelID)
 		if msgHandler == nil {
-			log.Errorf("[p2p] Failed to setup message handler for peer %v on channelID %v", message.PeerID, channelID)
+			return fmt.Errorf("No message handler for peer %v on channel %v", message.PeerID, channelID.Name())
 		}
 		err := msgHandler.HandleMessage(message)
+		msgr.penalizePanic(peer, err)
 		return err
 	}
 	peer.GetConnection().SetReceiveHandler(receiveHandler)
@@ -192,7 +215,35 @@ func (msgr *Messenger) AttachMessageHandlersToPeer(peer *pr.Peer) {
 	peer.GetConnection().SetErrorHandler(errorHandler)
 }
 
+// penalizePanic penalizes the peer if the error is a panic of the message handler,
+// and disconnects from the peer once it has misbehaved too much
+func (msgr *Messenger) penalizePanic(peer *pr.Peer, err error) {
+	panicErr, ok := err.(*p2p.HandlerPanicError)
+	if !ok {
+		return
+	}
+	penalty := handlerPanicPenalty
+	if panicErr.InputTriggered {
+		penalty = inputPanicPenalty
+	}
+	score := peer.AddMisbehavior(penalty)
+	if score >= maxMisbehavior && score-penalty < maxMisbehavior {
+		msgr.discMgr.DisconnectMisbehavingPeer(peer)
+	}
+}
+
 // SetAddressBookFilePath sets the address book file path
 func (msgrConfig *MessengerConfig) SetAddressBookFilePath(filePath string) {
 	msgrConfig.addrBookFilePath = filePath
 }
+
+// SetValidatorPeers sets the network addresses of the validator peers to keep connected
+func (msgrConfig *MessengerConfig) SetValidatorPeers(validatorPeerNetAddresses []string) {
+	msgrConfig.validatorPeers = validatorPeerNetAddresses
+}
+
+// SetListenAddresses sets the local network addresses to listen on. The node listens
+// on all the interfaces on the P2P port if none is set
+func (msgrConfig *MessengerConfig) SetListenAddresses(listenAddresses []string) {
+	msgrConfig.listenAddresses = listenAddresses
+}
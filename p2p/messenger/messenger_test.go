@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strconv"
 	"testing"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
@@ -119,6 +120,28 @@ type TestMessageHandler struct {
 	recvMsgChan chan string
 }
 
+func TestMessengerStop(t *testing.T) {
+	assert := assert.New(t)
+
+	messenger := newTestMessenger([]string{}, 24614)
+	ctx, cancel := context.WithCancel(context.Background())
+	assert.Nil(messenger.Start(ctx))
+
+	// The routines, including the ones listening for inbound peers, stop once the
+	// context is canceled
+	cancel()
+	stopped := make(chan struct{})
+	go func() {
+		messenger.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		assert.Fail("The messenger did not stop")
+	}
+}
+
 func newTestMessageHandler(selfPeerID string, t *testing.T, assert *assert.Assertions) p2p.MessageHandler {
 	return &TestMessageHandler{
 		selfPeerID:  selfPeerID,
@@ -0,0 +1,299 @@
+package p2p
+
+import (
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/common/lru"
+	"github.com/thetatoken/ukulele/common/metrics"
+	"github.com/thetatoken/ukulele/crypto"
+	"github.com/thetatoken/ukulele/p2p/types"
+)
+
+// droppedContent replaces the content of the messages dropped by a middleware, so
+// that the inner handlers skip them
+type droppedContent struct {
+	reason string
+}
+
+func dropMessage(peerID string, channelID common.ChannelIDEnum, reason string) types.Message {
+	return types.Message{
+		PeerID:    peerID,
+		ChannelID: channelID,
+		Content:   droppedContent{reason: reason},
+	}
+}
+
+// IsDropped returns true if the message was dropped by a middleware
+func IsDropped(message types.Message) bool {
+	_, dropped := message.Content.(droppedContent)
+	return dropped
+}
+
+func handlerMetricName(channelID common.ChannelIDEnum, name string) string {
+	return "p2p/handler/" + channelID.Name() + "/" + name
+}
+
+// ------------------------------ Recover -----------------------------------
+
+//
+// HandlerPanicError is returned by the RecoverMiddleware in place of a panic of the
+// message handler
+//
+type HandlerPanicError struct {
+	PeerID    string
+	ChannelID common.ChannelIDEnum
+	Value     interface{} // Value passed to panic
+	Stack     []byte
+
+	// InputTriggered is true if the panic occurred while parsing the raw bytes sent by
+	// the peer, which only a malformed input can cause. A panic while handling a parsed
+	// message may come from the state of the node as well
+	InputTriggered bool
+}
+
+var _ error = (*HandlerPanicError)(nil)
+
+func (e *HandlerPanicError) Error() string {
+	return fmt.Sprintf("Message handler panicked on channel %v for peer %v: %v", e.ChannelID.Name(), e.PeerID, e.Value)
+}
+
+type recoverHandler struct {
+	MessageHandler
+}
+
+// RecoverMiddleware recovers from the panics of the handler, and turns them into
+// HandlerPanicErrors, so that a message crashing the handler does not stop the node
+func RecoverMiddleware() Middleware {
+	return func(channelID common.ChannelIDEnum, handler MessageHandler) MessageHandler {
+		return &recoverHandler{MessageHandler: handler}
+	}
+}
+
+func (h *recoverHandler) ParseMessage(peerID string, channelID common.ChannelIDEnum, rawMessageBytes common.Bytes) (message types.Message, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = recoveredError(peerID, channelID, r, true)
+		}
+	}()
+	return h.MessageHandler.ParseMessage(peerID, channelID, rawMessageBytes)
+}
+
+func (h *recoverHandler) HandleMessage(message types.Message) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = recoveredError(message.PeerID, message.ChannelID, r, false)
+		}
+	}()
+	return h.MessageHandler.HandleMessage(message)
+}
+
+func recoveredError(peerID string, channelID common.ChannelIDEnum, r interface{}, inputTriggered bool) error {
+	err := &HandlerPanicError{
+		PeerID:         peerID,
+		ChannelID:      channelID,
+		Value:          r,
+		Stack:          debug.Stack(),
+		InputTriggered: inputTriggered,
+	}
+	log.WithFields(log.Fields{
+		"peer":           peerID,
+		"channel":        channelID.Name(),
+		"panic":          r,
+		"inputTriggered": inputTriggered,
+		"stack":          string(err.Stack),
+	}).Error("[p2p] Recovered from message handler panic")
+	return err
+}
+
+// ------------------------------ Metrics -----------------------------------
+
+type metricsHandler struct {
+	MessageHandler
+
+	messages metrics.Meter
+	errors   metrics.Meter
+	dropped  metrics.Meter
+	latency  metrics.Timer
+}
+
+// MetricsMiddleware counts the messages handled, the errors and the messages dropped
+// by the inner middlewares, and times the handling of the messages
+func MetricsMiddleware() Middleware {
+	return func(channelID common.ChannelIDEnum, handler MessageHandler) MessageHandler {
+		return &metricsHandler{
+			MessageHandler: handler,
+			messages:       metrics.GetOrRegisterMeter(handlerMetricName(channelID, "messages"), nil),
+			errors:         metrics.GetOrRegisterMeter(handlerMetricName(channelID, "errors"), nil),
+			dropped:        metrics.GetOrRegisterMeter(handlerMetricName(channelID, "dropped"), nil),
+			latency:        metrics.GetOrRegisterTimer(handlerMetricName(channelID, "latency"), nil),
+		}
+	}
+}
+
+func (h *metricsHandler) ParseMessage(peerID string, channelID common.ChannelIDEnum, rawMessageBytes common.Bytes) (types.Message, error) {
+	message, err := h.MessageHandler.ParseMessage(peerID, channelID, rawMessageBytes)
+	if err != nil {
+		h.errors.Mark(1)
+	}
+	return message, err
+}
+
+func (h *metricsHandler) HandleMessage(message types.Message) error {
+	if IsDropped(message) {
+		h.dropped.Mark(1)
+		return nil
+	}
+	start := time.Now()
+	err := h.MessageHandler.HandleMessage(message)
+	h.latency.UpdateSince(start)
+	h.messages.Mark(1)
+	if err != nil {
+		h.errors.Mark(1)
+	}
+	return err
+}
+
+// ------------------------------ Decode -----------------------------------
+
+type decodeHandler struct {
+	MessageHandler
+}
+
+// DecodeMiddleware rejects the empty messages before they are parsed, and adds the
+// channel and the peer to the parsing errors
+func DecodeMiddleware() Middleware {
+	return func(channelID common.ChannelIDEnum, handler MessageHandler) MessageHandler {
+		return &decodeHandler{MessageHandler: handler}
+	}
+}
+
+func (h *decodeHandler) ParseMessage(peerID string, channelID common.ChannelIDEnum, rawMessageBytes common.Bytes) (types.Message, error) {
+	if len(rawMessageBytes) == 0 {
+		return types.Message{}, fmt.Errorf("Empty message on channel %v from peer %v", channelID.Name(), peerID)
+	}
+	message, err := h.MessageHandler.ParseMessage(peerID, channelID, rawMessageBytes)
+	if err != nil {
+		return message, fmt.Errorf("Failed to decode message on channel %v from peer %v: %v", channelID.Name(), peerID, err)
+	}
+	return message, nil
+}
+
+func (h *decodeHandler) HandleMessage(message types.Message) error {
+	if IsDropped(message) {
+		return nil
+	}
+	return h.MessageHandler.HandleMessage(message)
+}
+
+// ------------------------------ Dedup -----------------------------------
+
+type dedupHandler struct {
+	MessageHandler
+
+	seen *lru.Cache // map: hash of the raw message |-> bool
+}
+
+// DedupMiddleware drops the messages identical to one of the last cacheSize messages
+// received on the channel from any peer, before they are parsed
+func DedupMiddleware(cacheSize int) Middleware {
+	return func(channelID common.ChannelIDEnum, handler MessageHandler) MessageHandler {
+		return &dedupHandler{
+			MessageHandler: handler,
+			seen:           lru.New(handlerMetricName(channelID, "dedup"), cacheSize),
+		}
+	}
+}
+
+func (h *dedupHandler) ParseMessage(peerID string, channelID common.ChannelIDEnum, rawMessageBytes common.Bytes) (types.Message, error) {
+	hash := crypto.Keccak256Hash(rawMessageBytes)
+	if _, seen := h.seen.Get(hash); seen {
+		return dropMessage(peerID, channelID, "duplicate"), nil
+	}
+	h.seen.Add(hash, true)
+	return h.MessageHandler.ParseMessage(peerID, channelID, rawMessageBytes)
+}
+
+func (h *dedupHandler) HandleMessage(message types.Message) error {
+	if IsDropped(message) {
+		return nil
+	}
+	return h.MessageHandler.HandleMessage(message)
+}
+
+// ------------------------------ Rate Limit -----------------------------------
+
+// RateLimitMaxNumPeers is the number of peers whose rate of messages is tracked by
+// a RateLimitMiddleware. The least recently active peers are forgotten first
+const RateLimitMaxNumPeers = 1024
+
+type tokenBucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+type rateLimitHandler struct {
+	MessageHandler
+
+	rate    float64    // Tokens added per second
+	burst   float64    // Capacity of the buckets
+	buckets *lru.Cache // map: peerID |-> *tokenBucket
+}
+
+// RateLimitMiddleware drops the messages received on the channel from a peer beyond
+// rate messages per second on average, allowing bursts of burst messages, before
+// they are parsed. A rate of 0 disables the limit
+func RateLimitMiddleware(rate float64, burst int) Middleware {
+	return func(channelID common.ChannelIDEnum, handler MessageHandler) MessageHandler {
+		if rate <= 0 {
+			return handler
+		}
+		return &rateLimitHandler{
+			MessageHandler: handler,
+			rate:           rate,
+			burst:          float64(burst),
+			buckets:        lru.New(handlerMetricName(channelID, "ratelimit"), RateLimitMaxNumPeers),
+		}
+	}
+}
+
+func (h *rateLimitHandler) ParseMessage(peerID string, channelID common.ChannelIDEnum, rawMessageBytes common.Bytes) (types.Message, error) {
+	if !h.allow(peerID, time.Now()) {
+		return dropMessage(peerID, channelID, "rate limited"), nil
+	}
+	return h.MessageHandler.ParseMessage(peerID, channelID, rawMessageBytes)
+}
+
+func (h *rateLimitHandler) HandleMessage(message types.Message) error {
+	if IsDropped(message) {
+		return nil
+	}
+	return h.MessageHandler.HandleMessage(message)
+}
+
+// allow takes a token from the bucket of the peer, and returns false if it is empty.
+// The messages of a peer are parsed by the receiving routine of its connection, one
+// at a time
+func (h *rateLimitHandler) allow(peerID string, now time.Time) bool {
+	var bucket *tokenBucket
+	if value, ok := h.buckets.Get(peerID); ok {
+		bucket = value.(*tokenBucket)
+	} else {
+		bucket = &tokenBucket{tokens: h.burst, updated: now}
+		h.buckets.Add(peerID, bucket)
+	}
+
+	bucket.tokens += now.Sub(bucket.updated).Seconds() * h.rate
+	if bucket.tokens > h.burst {
+		bucket.tokens = h.burst
+	}
+	bucket.updated = now
+	if bucket.tokens < 1 {
+		return false
+	}
+	bucket.tokens--
+	return true
+}
@@ -9,8 +9,12 @@ import (
 	"flag"
 	"fmt"
 	"net"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
+
+	"golang.org/x/net/proxy"
 )
 
 // NetAddress defines information about a peer on the network
@@ -18,6 +22,7 @@ import (
 type NetAddress struct {
 	IP   net.IP
 	Port uint16
+	Host string `json:",omitempty" rlp:"-"` // .onion host name, not resolved
 	str  string
 }
 
@@ -60,6 +65,16 @@ func NewNetAddressString(addr string) (*NetAddress, error) {
 		return nil, err
 	}
 
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil {
+		return nil, err
+	}
+
+	// Onion addresses can only be resolved by Tor, and must not be looked up in the DNS
+	if IsOnionHost(host) {
+		return NewNetAddressOnionPort(host, uint16(port)), nil
+	}
+
 	ip := net.ParseIP(host)
 	if ip == nil {
 		if len(host) > 0 {
@@ -71,11 +86,6 @@ func NewNetAddressString(addr string) (*NetAddress, error) {
 		}
 	}
 
-	port, err := strconv.ParseUint(portStr, 10, 16)
-	if err != nil {
-		return nil, err
-	}
-
 	na := NewNetAddressIPPort(ip, uint16(port))
 	return na, nil
 }
@@ -108,6 +118,30 @@ func NewNetAddressIPPort(ip net.IP, port uint16) *NetAddress {
 	return na
 }
 
+// NewNetAddressOnionPort returns a new NetAddress using the provided .onion
+// host name and port number.
+func NewNetAddressOnionPort(host string, port uint16) *NetAddress {
+	na := &NetAddress{
+		Host: host,
+		Port: port,
+		str: net.JoinHostPort(
+			host,
+			strconv.FormatUint(uint64(port), 10),
+		),
+	}
+	return na
+}
+
+// IsOnionHost returns true if the host is a Tor onion service host name.
+func IsOnionHost(host string) bool {
+	return strings.HasSuffix(strings.ToLower(host), ".onion")
+}
+
+// Onion returns true if it is the address of a Tor onion service.
+func (na *NetAddress) Onion() bool {
+	return na.Host != ""
+}
+
 // Equals reports whether na and other are the same addresses.
 func (na *NetAddress) Equals(other interface{}) bool {
 	if o, ok := other.(*NetAddress); ok {
@@ -129,8 +163,12 @@ func (na *NetAddress) Less(other interface{}) bool {
 // String representation.
 func (na *NetAddress) String() string {
 	if na.str == "" {
+		host := na.IP.String()
+		if na.Onion() {
+			host = na.Host
+		}
 		na.str = net.JoinHostPort(
-			na.IP.String(),
+			host,
 			strconv.FormatUint(uint64(na.Port), 10),
 		)
 	}
@@ -155,6 +193,21 @@ func (na *NetAddress) DialTimeout(timeout time.Duration) (net.Conn, error) {
 	return conn, nil
 }
 
+// DialTimeoutThroughProxy connects to the address through the SOCKS5 proxy at
+// proxyAddr. The proxy resolves the host names, which is required for the .onion
+// addresses.
+func (na *NetAddress) DialTimeoutThroughProxy(proxyAddr string, timeout time.Duration) (net.Conn, error) {
+	dialer, err := proxy.SOCKS5("tcp", proxyAddr, nil, &net.Dialer{Timeout: timeout})
+	if err != nil {
+		return nil, err
+	}
+	conn, err := dialer.Dial("tcp", na.String())
+	if err != nil {
+		return nil, err
+	}
+	return conn, nil
+}
+
 // Routable returns true if the address is routable.
 func (na *NetAddress) Routable() bool {
 	// TODO(oga) bitcoind doesn't include RFC3849 here, but should we?
@@ -221,6 +274,22 @@ func (na *NetAddress) ReachabilityTo(o *NetAddress) int {
 	}
 }
 
+// SortByReachability sorts the addresses by their reachability from the best of the
+// local addresses, the most reachable first. Equally reachable addresses keep their order.
+func SortByReachability(addrs []*NetAddress, localAddrs []*NetAddress) {
+	scores := make(map[*NetAddress]int, len(addrs))
+	for _, addr := range addrs {
+		for _, localAddr := range localAddrs {
+			if score := localAddr.ReachabilityTo(addr); score > scores[addr] {
+				scores[addr] = score
+			}
+		}
+	}
+	sort.SliceStable(addrs, func(i, j int) bool {
+		return scores[addrs[i]] > scores[addrs[j]]
+	})
+}
+
 // RFC1918: IPv4 Private networks (10.0.0.0/8, 192.168.0.0/16, 172.16.0.0/12)
 // RFC3849: IPv6 Documentation address  (2001:0DB8::/32)
 // RFC3927: IPv4 Autoconfig (169.254.0.0/16)
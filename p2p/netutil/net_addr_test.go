@@ -3,8 +3,12 @@
 package netutil
 
 import (
+	"encoding/binary"
+	"io"
 	"net"
+	"strconv"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -114,3 +118,90 @@ func TestNetAddressReachabilityTo(t *testing.T) {
 		assert.Equal(t.reachability, addr.ReachabilityTo(other))
 	}
 }
+
+func TestSortByReachability(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	localAddrs, err := NewNetAddressStrings([]string{"1.2.3.4:5000", "[2a00:1450::1]:5000"})
+	require.Nil(err)
+
+	addrs, err := NewNetAddressStrings([]string{"127.0.0.1:5000", "8.8.8.8:5000", "[2a00:1450:4001::1]:5000"})
+	require.Nil(err)
+	SortByReachability(addrs, localAddrs)
+	assert.Equal("[2a00:1450:4001::1]:5000", addrs[0].String())
+	assert.Equal("8.8.8.8:5000", addrs[1].String())
+	assert.Equal("127.0.0.1:5000", addrs[2].String())
+
+	// Without a local IPv6 address, the IPv4 address is preferred
+	SortByReachability(addrs, localAddrs[:1])
+	assert.Equal("8.8.8.8:5000", addrs[0].String())
+	assert.Equal("[2a00:1450:4001::1]:5000", addrs[1].String())
+	assert.Equal("127.0.0.1:5000", addrs[2].String())
+}
+
+func TestOnionNetAddress(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	// Onion addresses are not looked up in the DNS
+	addr, err := NewNetAddressString("expyuzz4wqqyqhjn.onion:5000")
+	require.Nil(err)
+	assert.True(addr.Onion())
+	assert.Nil(addr.IP)
+	assert.False(addr.Valid())
+	assert.Equal("expyuzz4wqqyqhjn.onion:5000", addr.String())
+
+	addr, err = NewNetAddressString("127.0.0.1:5000")
+	require.Nil(err)
+	assert.False(addr.Onion())
+}
+
+func TestDialTimeoutThroughProxy(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(err)
+	defer listener.Close()
+
+	// A SOCKS5 proxy without authentication that echoes the data sent to the
+	// requested destination
+	requested := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		greeting := make([]byte, 2)
+		io.ReadFull(conn, greeting)
+		io.ReadFull(conn, make([]byte, greeting[1]))
+		conn.Write([]byte{5, 0})
+
+		request := make([]byte, 5) // version, command, reserved, address type, host length
+		io.ReadFull(conn, request)
+		host := make([]byte, request[4])
+		io.ReadFull(conn, host)
+		port := make([]byte, 2)
+		io.ReadFull(conn, port)
+		requested <- net.JoinHostPort(string(host), strconv.Itoa(int(binary.BigEndian.Uint16(port))))
+		conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0})
+
+		io.Copy(conn, conn)
+	}()
+
+	addr, err := NewNetAddressString("expyuzz4wqqyqhjn.onion:5000")
+	require.Nil(err)
+	conn, err := addr.DialTimeoutThroughProxy(listener.Addr().String(), 5*time.Second)
+	require.Nil(err)
+	defer conn.Close()
+
+	// The proxy resolves the onion address
+	assert.Equal("expyuzz4wqqyqhjn.onion:5000", <-requested)
+
+	_, err = conn.Write([]byte("ping"))
+	require.Nil(err)
+	reply := make([]byte, 4)
+	_, err = io.ReadFull(conn, reply)
+	require.Nil(err)
+	assert.Equal("ping", string(reply))
+}
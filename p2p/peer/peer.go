@@ -6,6 +6,7 @@ import (
 	"errors"
 	"net"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	log "github.com/sirupsen/logrus"
@@ -22,10 +23,13 @@ import (
 //
 type Peer struct {
 	connection *cn.Connection
+	sendQueue  *sendQueue
 
 	isPersistent bool
+	isPinned     bool
 	isOutbound   bool
 	netAddress   *nu.NetAddress
+	misbehavior  int32 // Penalty accumulated by the peer, accessed atomically
 
 	nodeInfo p2ptypes.NodeInfo // information of the blockchain node of the peer
 
@@ -45,6 +49,8 @@ type Peer struct {
 type PeerConfig struct {
 	HandshakeTimeout time.Duration
 	DialTimeout      time.Duration
+	SendQueueSize    int
+	Proxy            string // Address of the SOCKS5 proxy of the outbound connections, if any
 }
 
 // CreateOutboundPeer creates an instance of an outbound peer
@@ -58,6 +64,10 @@ func CreateOutboundPeer(peerAddr *nu.NetAddress, peerConfig PeerConfig, connConf
 	if peer == nil {
 		return nil, errors.New("[p2p] Failed to create outbound peer")
 	}
+	if peerConfig.Proxy != "" {
+		// The remote address of the connection is the one of the proxy
+		peer.SetNetAddress(peerAddr)
+	}
 	return peer, nil
 }
 
@@ -75,6 +85,8 @@ func GetDefaultPeerConfig() PeerConfig {
 	return PeerConfig{
 		HandshakeTimeout: 10 * time.Second,
 		DialTimeout:      10 * time.Second,
+		SendQueueSize:    cmn.GetConfig().P2P.SendQueueSize,
+		Proxy:            cmn.GetConfig().P2P.Proxy,
 	}
 }
 
@@ -85,6 +97,10 @@ func (peer *Peer) Start(ctx context.Context) bool {
 	peer.ctx = c
 	peer.cancel = cancel
 
+	peer.sendQueue.setMetrics(peer.ID())
+	peer.wg.Add(1)
+	go peer.sendRoutine()
+
 	success := peer.connection.Start(c)
 	return success
 }
@@ -101,6 +117,9 @@ func (peer *Peer) CancelConnection() {
 
 // Stop is called when the peer stops
 func (peer *Peer) Stop() {
+	if peer.cancel != nil {
+		peer.cancel()
+	}
 	peer.connection.Stop()
 }
 
@@ -147,12 +166,39 @@ func (peer *Peer) Handshake(sourceNodeInfo *p2ptypes.NodeInfo) error {
 	return nil
 }
 
-// Send sends the given message through the specified channel to the target peer
+// Send queues the given message to be sent through the specified channel to the target peer.
+// It returns false if the message is dropped by the drop policy of the channel, see DropPolicy
 func (peer *Peer) Send(channelID cmn.ChannelIDEnum, message interface{}) bool {
-	success := peer.connection.EnqueueMessage(channelID, message)
+	success := peer.sendQueue.push(channelID, message)
 	return success
 }
 
+// SendQueueSize returns the number of messages queued to be sent to the peer
+func (peer *Peer) SendQueueSize() int {
+	return peer.sendQueue.size()
+}
+
+// sendRoutine moves the queued messages to the connection until the peer stops
+func (peer *Peer) sendRoutine() {
+	defer peer.wg.Done()
+
+	go func() {
+		<-peer.ctx.Done()
+		peer.sendQueue.close()
+		unregisterSendQueueMetrics(peer.ID())
+	}()
+
+	for {
+		msg, ok := peer.sendQueue.pop()
+		if !ok {
+			return
+		}
+		if !peer.connection.EnqueueMessage(msg.channelID, msg.message) {
+			log.Warnf("[p2p] Failed to send message to peer %v on channelID %v", peer.ID(), msg.channelID)
+		}
+	}
+}
+
 // AttemptToSend attempts to send the given message through the specified channel to the target peer (non-blocking)
 func (peer *Peer) AttemptToSend(channelID cmn.ChannelIDEnum, message interface{}) bool {
 	success := peer.connection.AttemptToEnqueueMessage(channelID, message)
@@ -185,6 +231,23 @@ func (peer *Peer) IsPersistent() bool {
 	return peer.isPersistent
 }
 
+// SetPinned sets whether the given peer is pinned. A pinned peer is a validator
+// peer that must always remain connected
+func (peer *Peer) SetPinned(pinned bool) {
+	peer.isPinned = pinned
+}
+
+// IsPinned returns whether the peer is pinned
+func (peer *Peer) IsPinned() bool {
+	return peer.isPinned
+}
+
+// AddMisbehavior adds the penalty to the misbehavior score of the peer, and returns
+// the new score
+func (peer *Peer) AddMisbehavior(penalty int) int {
+	return int(atomic.AddInt32(&peer.misbehavior, int32(penalty)))
+}
+
 // IsOutbound returns whether the peer is an outbound peer
 func (peer *Peer) IsOutbound() bool {
 	return peer.isOutbound
@@ -200,6 +263,20 @@ func (peer *Peer) NetAddress() *nu.NetAddress {
 	return peer.netAddress
 }
 
+// DialableAddresses returns the addresses the peer advertised in the handshake
+func (peer *Peer) DialableAddresses() []*nu.NetAddress {
+	addrs := []*nu.NetAddress{}
+	for _, addrStr := range peer.nodeInfo.Addresses {
+		addr, err := nu.NewNetAddressString(addrStr)
+		if err != nil || !addr.Valid() {
+			log.Debugf("[p2p] Ignoring invalid address %v advertised by peer %v", addrStr, peer.ID())
+			continue
+		}
+		addrs = append(addrs, addr)
+	}
+	return addrs
+}
+
 // ID returns the unique idenitifier of the peer in the P2P network
 func (peer *Peer) ID() string {
 	peerID := peer.nodeInfo.PubKey.Address() // use the blockchain address as the peer ID
@@ -208,7 +285,15 @@ func (peer *Peer) ID() string {
 }
 
 func dial(addr *nu.NetAddress, config PeerConfig) (net.Conn, error) {
-	netconn, err := addr.DialTimeout(config.DialTimeout)
+	var netconn net.Conn
+	var err error
+	if config.Proxy != "" {
+		netconn, err = addr.DialTimeoutThroughProxy(config.Proxy, config.DialTimeout)
+	} else if addr.Onion() {
+		err = errors.New("[p2p] Cannot dial an onion address without a proxy")
+	} else {
+		netconn, err = addr.DialTimeout(config.DialTimeout)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -228,6 +313,7 @@ func createPeer(netconn net.Conn, isOutbound bool,
 	}
 	peer := &Peer{
 		connection: connection,
+		sendQueue:  createSendQueue(peerConfig.SendQueueSize),
 		isOutbound: isOutbound,
 		netAddress: netAddress,
 		config:     peerConfig,
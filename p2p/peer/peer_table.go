@@ -144,6 +144,13 @@ func (pt *PeerTable) GetSelection() (peerIDAddrs []PeerIDAddress) {
 			Addr: peer.netAddress,
 		}
 		peerIDAddrs = append(peerIDAddrs, peerIDAddr)
+
+		// Also share the other addresses the peer can be dialed at
+		for _, addr := range peer.DialableAddresses() {
+			if peer.netAddress == nil || !addr.Equals(peer.netAddress) {
+				peerIDAddrs = append(peerIDAddrs, PeerIDAddress{ID: peer.ID(), Addr: addr})
+			}
+		}
 	}
 	return
 }
@@ -155,3 +162,17 @@ func (pt *PeerTable) GetTotalNumPeers() uint {
 
 	return uint(len(pt.peers))
 }
+
+// GetTotalNumPinnedPeers returns the number of pinned peers in the PeerTable
+func (pt *PeerTable) GetTotalNumPinnedPeers() uint {
+	pt.mutex.Lock()
+	defer pt.mutex.Unlock()
+
+	numPinned := uint(0)
+	for _, peer := range pt.peers {
+		if peer.IsPinned() {
+			numPinned++
+		}
+	}
+	return numPinned
+}
@@ -138,6 +138,28 @@ func TestDefaultPeerIterationOrder(t *testing.T) {
 
 // --------------- Test Utilities --------------- //
 
+func TestDefaultPeerTablePinnedPeers(t *testing.T) {
+	assert := assert.New(t)
+
+	pt := newTestEmptyPeerTable()
+	assert.Equal(uint(0), pt.GetTotalNumPinnedPeers())
+
+	port := 37859
+	netconn := newIncomingNetconn(port)
+
+	peer1 := newSimulatedInboundPeer(netconn, p2ptypes.GetTestRandPubKey())
+	peer2 := newSimulatedInboundPeer(netconn, p2ptypes.GetTestRandPubKey())
+	peer2.SetPinned(true)
+
+	assert.True(pt.AddPeer(peer1))
+	assert.True(pt.AddPeer(peer2))
+	assert.Equal(uint(2), pt.GetTotalNumPeers())
+	assert.Equal(uint(1), pt.GetTotalNumPinnedPeers())
+
+	pt.DeletePeer(peer2.ID())
+	assert.Equal(uint(0), pt.GetTotalNumPinnedPeers())
+}
+
 func newTestEmptyPeerTable() PeerTable {
 	pt := CreatePeerTable()
 	return pt
@@ -173,3 +173,11 @@ func newInboundPeer(netconn net.Conn) *Peer {
 	}
 	return inboundPeer
 }
+
+func TestPeerMisbehavior(t *testing.T) {
+	assert := assert.New(t)
+
+	peer := &Peer{}
+	assert.Equal(20, peer.AddMisbehavior(20))
+	assert.Equal(120, peer.AddMisbehavior(100))
+}
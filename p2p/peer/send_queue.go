@@ -0,0 +1,165 @@
+package peer
+
+import (
+	"container/list"
+	"sync"
+
+	cmn "github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/common/metrics"
+)
+
+//
+// DropPolicy defines which message is dropped when a message is sent to a peer
+// whose send queue is full
+//
+type DropPolicy uint8
+
+const (
+	// DropNever never drops the message. The sender waits for room in the queue
+	DropNever DropPolicy = iota
+
+	// DropOldest drops the oldest queued message of the same policy to make room
+	DropOldest
+
+	// DropNewest drops the message sent
+	DropNewest
+)
+
+// channelDropPolicies sets the drop policies of the channels. The consensus messages,
+// i.e. the other channels, are never dropped
+var channelDropPolicies = map[cmn.ChannelIDEnum]DropPolicy{
+	cmn.ChannelIDTransaction:   DropOldest,
+	cmn.ChannelIDPeerDiscovery: DropNewest,
+}
+
+// GetDropPolicy returns the drop policy of the given channel
+func GetDropPolicy(channelID cmn.ChannelIDEnum) DropPolicy {
+	return channelDropPolicies[channelID]
+}
+
+type queuedMessage struct {
+	channelID cmn.ChannelIDEnum
+	message   interface{}
+}
+
+//
+// sendQueue buffers the messages to send to a peer. When the queue is full, the
+// oldest transaction gossip is dropped first to make room, and the senders of
+// the messages never dropped wait for room
+//
+type sendQueue struct {
+	mutex    *sync.Mutex
+	notEmpty *sync.Cond
+	notFull  *sync.Cond
+
+	messages *list.List
+	capacity int
+	closed   bool
+
+	sizeGauge metrics.Gauge
+	dropMeter metrics.Meter
+}
+
+func createSendQueue(capacity int) *sendQueue {
+	mutex := &sync.Mutex{}
+	return &sendQueue{
+		mutex:     mutex,
+		notEmpty:  sync.NewCond(mutex),
+		notFull:   sync.NewCond(mutex),
+		messages:  list.New(),
+		capacity:  capacity,
+		sizeGauge: metrics.NilGauge{},
+		dropMeter: metrics.NilMeter{},
+	}
+}
+
+// setMetrics registers the metrics of the queue, named after the peer
+func (sq *sendQueue) setMetrics(peerID string) {
+	prefix := "p2p/peer/" + peerID + "/sendqueue"
+	sq.mutex.Lock()
+	defer sq.mutex.Unlock()
+	sq.sizeGauge = metrics.GetOrRegisterGauge(prefix+"/size", nil)
+	sq.dropMeter = metrics.GetOrRegisterMeter(prefix+"/drops", nil)
+	sq.sizeGauge.Update(int64(sq.messages.Len()))
+}
+
+// unregisterMetrics unregisters the metrics of the queue
+func unregisterSendQueueMetrics(peerID string) {
+	prefix := "p2p/peer/" + peerID + "/sendqueue"
+	metrics.Unregister(prefix + "/size")
+	metrics.Unregister(prefix + "/drops")
+}
+
+// push queues the message, and returns false if the message is dropped
+func (sq *sendQueue) push(channelID cmn.ChannelIDEnum, message interface{}) bool {
+	sq.mutex.Lock()
+	defer sq.mutex.Unlock()
+
+	policy := GetDropPolicy(channelID)
+	for !sq.closed && sq.messages.Len() >= sq.capacity {
+		if sq.dropOldest() {
+			continue
+		}
+		if policy != DropNever {
+			sq.dropMeter.Mark(1)
+			return false
+		}
+		sq.notFull.Wait()
+	}
+	if sq.closed {
+		return false
+	}
+
+	sq.messages.PushBack(&queuedMessage{channelID: channelID, message: message})
+	sq.sizeGauge.Update(int64(sq.messages.Len()))
+	sq.notEmpty.Signal()
+	return true
+}
+
+// dropOldest drops the oldest queued message with the DropOldest policy, and
+// returns false if there is none. The caller must hold the mutex
+func (sq *sendQueue) dropOldest() bool {
+	for elem := sq.messages.Front(); elem != nil; elem = elem.Next() {
+		if GetDropPolicy(elem.Value.(*queuedMessage).channelID) == DropOldest {
+			sq.messages.Remove(elem)
+			sq.dropMeter.Mark(1)
+			return true
+		}
+	}
+	return false
+}
+
+// pop waits for the next message, and returns false once the queue is closed
+func (sq *sendQueue) pop() (*queuedMessage, bool) {
+	sq.mutex.Lock()
+	defer sq.mutex.Unlock()
+
+	for !sq.closed && sq.messages.Len() == 0 {
+		sq.notEmpty.Wait()
+	}
+	if sq.closed {
+		return nil, false
+	}
+	msg := sq.messages.Remove(sq.messages.Front()).(*queuedMessage)
+	sq.sizeGauge.Update(int64(sq.messages.Len()))
+	sq.notFull.Signal()
+	return msg, true
+}
+
+// size returns the number of queued messages
+func (sq *sendQueue) size() int {
+	sq.mutex.Lock()
+	defer sq.mutex.Unlock()
+	return sq.messages.Len()
+}
+
+// close drops the queued messages and wakes up the waiting senders
+func (sq *sendQueue) close() {
+	sq.mutex.Lock()
+	defer sq.mutex.Unlock()
+	sq.closed = true
+	sq.messages.Init()
+	sq.sizeGauge.Update(0)
+	sq.notEmpty.Broadcast()
+	sq.notFull.Broadcast()
+}
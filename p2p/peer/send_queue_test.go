@@ -0,0 +1,74 @@
+package peer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thetatoken/ukulele/common"
+)
+
+func TestSendQueueDropPolicies(t *testing.T) {
+	assert := assert.New(t)
+
+	sq := createSendQueue(3)
+	assert.True(sq.push(common.ChannelIDTransaction, "tx1"))
+	assert.True(sq.push(common.ChannelIDVote, "vote1"))
+	assert.True(sq.push(common.ChannelIDTransaction, "tx2"))
+	assert.Equal(3, sq.size())
+
+	// The oldest transaction gossip is dropped to make room
+	assert.True(sq.push(common.ChannelIDProposal, "proposal1"))
+	assert.True(sq.push(common.ChannelIDPeerDiscovery, "disc1"))
+	assert.Equal(3, sq.size())
+
+	// No transaction gossip left to drop: peer discovery and transaction gossip
+	// messages are dropped
+	assert.False(sq.push(common.ChannelIDPeerDiscovery, "disc2"))
+	assert.False(sq.push(common.ChannelIDTransaction, "tx3"))
+
+	expected := []string{"vote1", "proposal1", "disc1"}
+	for _, content := range expected {
+		msg, ok := sq.pop()
+		assert.True(ok)
+		assert.Equal(content, msg.message)
+	}
+	assert.Equal(0, sq.size())
+}
+
+func TestSendQueueNeverDropsConsensusMessages(t *testing.T) {
+	assert := assert.New(t)
+
+	sq := createSendQueue(1)
+	assert.True(sq.push(common.ChannelIDVote, "vote1"))
+
+	// The sender of a consensus message waits for room in the queue
+	pushed := make(chan bool)
+	go func() {
+		pushed <- sq.push(common.ChannelIDVote, "vote2")
+	}()
+	select {
+	case <-pushed:
+		assert.Fail("Consensus message queued while the queue is full")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	msg, ok := sq.pop()
+	assert.True(ok)
+	assert.Equal("vote1", msg.message)
+	assert.True(<-pushed)
+	msg, ok = sq.pop()
+	assert.True(ok)
+	assert.Equal("vote2", msg.message)
+
+	// Closing the queue wakes up the waiting senders and receivers
+	assert.True(sq.push(common.ChannelIDVote, "vote3"))
+	go func() {
+		pushed <- sq.push(common.ChannelIDVote, "vote4")
+	}()
+	time.Sleep(100 * time.Millisecond)
+	sq.close()
+	assert.False(<-pushed)
+	_, ok = sq.pop()
+	assert.False(ok)
+}
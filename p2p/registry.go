@@ -0,0 +1,130 @@
+package p2p
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/p2p/types"
+)
+
+//
+// Middleware wraps the message handler of a channel to take care of a cross-cutting
+// concern, e.g. metrics, around the parsing and the handling of the messages
+//
+type Middleware func(channelID common.ChannelIDEnum, handler MessageHandler) MessageHandler
+
+// DefaultMiddlewares returns the middlewares the networks wrap around all the
+// message handlers
+func DefaultMiddlewares() []Middleware {
+	return []Middleware{
+		RecoverMiddleware(),
+		MetricsMiddleware(),
+		DecodeMiddleware(),
+	}
+}
+
+// wrapChannelHandler wraps the handler of the channel with the middlewares, the
+// first middleware being the outermost
+func wrapChannelHandler(channelID common.ChannelIDEnum, handler MessageHandler, middlewares []Middleware) MessageHandler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](channelID, handler)
+	}
+	return handler
+}
+
+//
+// channelMux is a message handler passing the messages of each channel to the
+// wrapped handler of the channel
+//
+type channelMux struct {
+	MessageHandler // for GetChannelIDs and EncodeMessage
+
+	handlers map[common.ChannelIDEnum]MessageHandler
+}
+
+var _ MessageHandler = (*channelMux)(nil)
+
+// WrapMessageHandler wraps the handler of each channel of the message handler with
+// the middlewares, the first middleware being the outermost
+func WrapMessageHandler(handler MessageHandler, middlewares ...Middleware) MessageHandler {
+	mux := &channelMux{
+		MessageHandler: handler,
+		handlers:       make(map[common.ChannelIDEnum]MessageHandler),
+	}
+	for _, channelID := range handler.GetChannelIDs() {
+		mux.handlers[channelID] = wrapChannelHandler(channelID, handler, middlewares)
+	}
+	return mux
+}
+
+// ParseMessage implements the MessageHandler interface
+func (mux *channelMux) ParseMessage(peerID string, channelID common.ChannelIDEnum, rawMessageBytes common.Bytes) (types.Message, error) {
+	return mux.handlers[channelID].ParseMessage(peerID, channelID, rawMessageBytes)
+}
+
+// HandleMessage implements the MessageHandler interface
+func (mux *channelMux) HandleMessage(message types.Message) error {
+	return mux.handlers[message.ChannelID].HandleMessage(message)
+}
+
+//
+// MessageHandlerRegistry maps the channels to their message handlers, wrapped with
+// the middlewares of the registry
+//
+type MessageHandlerRegistry struct {
+	mutex *sync.RWMutex
+
+	middlewares []Middleware
+	handlers    map[common.ChannelIDEnum]MessageHandler
+}
+
+// NewMessageHandlerRegistry creates an instance of the MessageHandlerRegistry. The
+// middlewares wrap all the handlers registered, the first middleware being the outermost
+func NewMessageHandlerRegistry(middlewares ...Middleware) *MessageHandlerRegistry {
+	return &MessageHandlerRegistry{
+		mutex:       &sync.RWMutex{},
+		middlewares: middlewares,
+		handlers:    make(map[common.ChannelIDEnum]MessageHandler),
+	}
+}
+
+// Register registers the message handler for its channels. It returns an error,
+// and registers nothing, if a handler is already registered for one of them
+func (r *MessageHandlerRegistry) Register(handler MessageHandler) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	channelIDs := handler.GetChannelIDs()
+	for _, channelID := range channelIDs {
+		if r.handlers[channelID] != nil {
+			return fmt.Errorf("Message handler is already registered for channel %v", channelID.Name())
+		}
+	}
+	for _, channelID := range channelIDs {
+		r.handlers[channelID] = wrapChannelHandler(channelID, handler, r.middlewares)
+	}
+	return nil
+}
+
+// GetHandler returns the wrapped message handler of the channel, or nil if no handler
+// is registered for the channel
+func (r *MessageHandlerRegistry) GetHandler(channelID common.ChannelIDEnum) MessageHandler {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return r.handlers[channelID]
+}
+
+// GetChannelIDs returns the channels with a registered handler, in increasing order
+func (r *MessageHandlerRegistry) GetChannelIDs() []common.ChannelIDEnum {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	channelIDs := make([]common.ChannelIDEnum, 0, len(r.handlers))
+	for channelID := range r.handlers {
+		channelIDs = append(channelIDs, channelID)
+	}
+	sort.Slice(channelIDs, func(i, j int) bool { return channelIDs[i] < channelIDs[j] })
+	return channelIDs
+}
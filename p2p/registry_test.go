@@ -0,0 +1,179 @@
+package p2p
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/p2p/types"
+)
+
+type testMessageHandler struct {
+	channelIDs []common.ChannelIDEnum
+	parsed     int
+	handled    []types.Message
+	panic      bool
+}
+
+func (h *testMessageHandler) GetChannelIDs() []common.ChannelIDEnum {
+	return h.channelIDs
+}
+
+func (h *testMessageHandler) ParseMessage(peerID string, channelID common.ChannelIDEnum, rawMessageBytes common.Bytes) (types.Message, error) {
+	h.parsed++
+	if string(rawMessageBytes) == "panic" {
+		var content *string
+		return types.Message{PeerID: peerID, ChannelID: channelID, Content: *content}, nil
+	}
+	if string(rawMessageBytes) == "invalid" {
+		return types.Message{}, errors.New("invalid message")
+	}
+	return types.Message{PeerID: peerID, ChannelID: channelID, Content: string(rawMessageBytes)}, nil
+}
+
+func (h *testMessageHandler) EncodeMessage(message interface{}) (common.Bytes, error) {
+	return common.Bytes(message.(string)), nil
+}
+
+func (h *testMessageHandler) HandleMessage(message types.Message) error {
+	if h.panic {
+		panic("test panic")
+	}
+	h.handled = append(h.handled, message)
+	return nil
+}
+
+func receive(handler MessageHandler, peerID string, channelID common.ChannelIDEnum, raw string) error {
+	message, err := handler.ParseMessage(peerID, channelID, common.Bytes(raw))
+	if err != nil {
+		return err
+	}
+	return handler.HandleMessage(message)
+}
+
+func TestMessageHandlerRegistry(t *testing.T) {
+	assert := assert.New(t)
+
+	order := []string{}
+	tracer := func(name string) Middleware {
+		return func(channelID common.ChannelIDEnum, handler MessageHandler) MessageHandler {
+			order = append(order, name)
+			return handler
+		}
+	}
+
+	registry := NewMessageHandlerRegistry(tracer("outer"), tracer("inner"))
+	handler1 := &testMessageHandler{channelIDs: []common.ChannelIDEnum{common.ChannelIDVote, common.ChannelIDBlock}}
+	assert.Nil(registry.Register(handler1))
+	// The middlewares are applied from the innermost
+	assert.Equal([]string{"inner", "outer", "inner", "outer"}, order)
+	assert.Equal([]common.ChannelIDEnum{common.ChannelIDBlock, common.ChannelIDVote}, registry.GetChannelIDs())
+
+	// A handler conflicting on one of its channels is not registered at all
+	handler2 := &testMessageHandler{channelIDs: []common.ChannelIDEnum{common.ChannelIDTransaction, common.ChannelIDVote}}
+	assert.NotNil(registry.Register(handler2))
+	assert.Nil(registry.GetHandler(common.ChannelIDTransaction))
+
+	assert.Nil(receive(registry.GetHandler(common.ChannelIDVote), "peer1", common.ChannelIDVote, "vote"))
+	assert.Equal(1, len(handler1.handled))
+	assert.Equal("vote", handler1.handled[0].Content)
+	assert.Equal(0, len(handler2.handled))
+}
+
+func TestDefaultMiddlewares(t *testing.T) {
+	assert := assert.New(t)
+
+	handler := &testMessageHandler{channelIDs: []common.ChannelIDEnum{common.ChannelIDVote}}
+	registry := NewMessageHandlerRegistry(DefaultMiddlewares()...)
+	assert.Nil(registry.Register(handler))
+	wrapped := registry.GetHandler(common.ChannelIDVote)
+
+	// Empty messages are rejected before they are parsed
+	assert.NotNil(receive(wrapped, "peer1", common.ChannelIDVote, ""))
+	assert.Equal(0, handler.parsed)
+
+	// Parsing errors carry the channel and the peer
+	err := receive(wrapped, "peer1", common.ChannelIDVote, "invalid")
+	assert.NotNil(err)
+	assert.Contains(err.Error(), "vote")
+	assert.Contains(err.Error(), "peer1")
+
+	// Panics are turned into errors
+	err = receive(wrapped, "peer1", common.ChannelIDVote, "panic")
+	panicErr, ok := err.(*HandlerPanicError)
+	assert.True(ok)
+	assert.Equal("peer1", panicErr.PeerID)
+	assert.Equal(common.ChannelIDVote, panicErr.ChannelID)
+	assert.True(panicErr.InputTriggered)
+	assert.NotEmpty(panicErr.Stack)
+
+	handler.panic = true
+	err = receive(wrapped, "peer1", common.ChannelIDVote, "vote")
+	panicErr, ok = err.(*HandlerPanicError)
+	assert.True(ok)
+	assert.Equal("test panic", panicErr.Value)
+	assert.False(panicErr.InputTriggered)
+	handler.panic = false
+	assert.Nil(receive(wrapped, "peer1", common.ChannelIDVote, "vote"))
+	assert.Equal(1, len(handler.handled))
+}
+
+func TestDedupMiddleware(t *testing.T) {
+	assert := assert.New(t)
+
+	handler := &testMessageHandler{channelIDs: []common.ChannelIDEnum{common.ChannelIDTransaction}}
+	wrapped := WrapMessageHandler(handler, DedupMiddleware(2))
+
+	assert.Nil(receive(wrapped, "peer1", common.ChannelIDTransaction, "tx1"))
+	assert.Nil(receive(wrapped, "peer2", common.ChannelIDTransaction, "tx1"))
+	assert.Nil(receive(wrapped, "peer2", common.ChannelIDTransaction, "tx2"))
+	assert.Equal(2, handler.parsed)
+	assert.Equal(2, len(handler.handled))
+
+	// The oldest messages are forgotten
+	assert.Nil(receive(wrapped, "peer1", common.ChannelIDTransaction, "tx3"))
+	assert.Nil(receive(wrapped, "peer1", common.ChannelIDTransaction, "tx1"))
+	assert.Equal(4, len(handler.handled))
+
+	// The other methods of the handler are not wrapped
+	assert.Equal(handler.GetChannelIDs(), wrapped.GetChannelIDs())
+	raw, err := wrapped.EncodeMessage("tx4")
+	assert.Nil(err)
+	assert.Equal(common.Bytes("tx4"), raw)
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	assert := assert.New(t)
+
+	handler := &testMessageHandler{channelIDs: []common.ChannelIDEnum{common.ChannelIDTransaction}}
+	wrapped := WrapMessageHandler(handler, RateLimitMiddleware(10, 3))
+
+	for i := 0; i < 5; i++ {
+		assert.Nil(receive(wrapped, "peer1", common.ChannelIDTransaction, "tx"))
+	}
+	assert.Equal(3, len(handler.handled))
+
+	// The limit applies to each peer
+	assert.Nil(receive(wrapped, "peer2", common.ChannelIDTransaction, "tx"))
+	assert.Equal(4, len(handler.handled))
+
+	limiter := WrapMessageHandler(handler, RateLimitMiddleware(10, 3)).(*channelMux).
+		handlers[common.ChannelIDTransaction].(*rateLimitHandler)
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		assert.True(limiter.allow("peer1", now))
+	}
+	assert.False(limiter.allow("peer1", now))
+	assert.True(limiter.allow("peer1", now.Add(100*time.Millisecond)))
+	assert.False(limiter.allow("peer1", now.Add(100*time.Millisecond)))
+
+	// A rate of 0 disables the limit
+	handler = &testMessageHandler{channelIDs: []common.ChannelIDEnum{common.ChannelIDTransaction}}
+	wrapped = WrapMessageHandler(handler, RateLimitMiddleware(0, 0))
+	for i := 0; i < 5; i++ {
+		assert.Nil(receive(wrapped, "peer1", common.ChannelIDTransaction, "tx"))
+	}
+	assert.Equal(5, len(handler.handled))
+}
@@ -5,7 +5,6 @@ import (
 	"sync"
 	"time"
 
-	"github.com/spf13/viper"
 	"github.com/thetatoken/ukulele/common"
 	"github.com/thetatoken/ukulele/p2p"
 	p2ptypes "github.com/thetatoken/ukulele/p2p/types"
@@ -13,9 +12,10 @@ import (
 
 // Envelope wraps a message with network information for delivery.
 type Envelope struct {
-	From    string
-	To      string
-	Content interface{}
+	From      string
+	To        string
+	ChannelID common.ChannelIDEnum
+	Content   interface{}
 }
 
 // Simnet represents an instance of simluated network.
@@ -25,6 +25,8 @@ type Simnet struct {
 	messages   chan Envelope
 	MsgLogs    []Envelope
 
+	disconnected map[string]bool // Endpoints whose messages are dropped
+
 	// Life cycle.
 	wg      *sync.WaitGroup
 	mu      *sync.Mutex
@@ -36,44 +38,93 @@ type Simnet struct {
 // NewSimnet creates a new instance of Simnet.
 func NewSimnet() *Simnet {
 	return &Simnet{
-		messages: make(chan Envelope, viper.GetInt(common.CfgP2PMessageQueueSize)),
-		MsgLogs:  []Envelope{},
-		wg:       &sync.WaitGroup{},
-		mu:       &sync.Mutex{},
+		messages:     make(chan Envelope, common.GetConfig().P2P.MessageQueueSize),
+		MsgLogs:      []Envelope{},
+		disconnected: make(map[string]bool),
+		wg:           &sync.WaitGroup{},
+		mu:           &sync.Mutex{},
 	}
 }
 
 // NewSimnetWithHandler creates a new instance of Simnet with given MessageHandler as the default handler.
 func NewSimnetWithHandler(msgHandler p2p.MessageHandler) *Simnet {
 	return &Simnet{
-		msgHandler: msgHandler,
-		messages:   make(chan Envelope, viper.GetInt(common.CfgP2PMessageQueueSize)),
-		wg:         &sync.WaitGroup{},
-		mu:         &sync.Mutex{},
+		msgHandler:   msgHandler,
+		messages:     make(chan Envelope, common.GetConfig().P2P.MessageQueueSize),
+		disconnected: make(map[string]bool),
+		wg:           &sync.WaitGroup{},
+		mu:           &sync.Mutex{},
 	}
 }
 
-// AddEndpoint adds an endpoint with given ID to the Simnet instance.
+// AddEndpoint adds an endpoint with given ID to the Simnet instance. The endpoint is
+// started right away if the Simnet is already started.
 func (sn *Simnet) AddEndpoint(id string) *SimnetEndpoint {
+	sn.mu.Lock()
+	defer sn.mu.Unlock()
+
+	endpoint := sn.newEndpoint(id)
+	sn.Endpoints = append(sn.Endpoints, endpoint)
+	return endpoint
+}
+
+// ReplaceEndpoint replaces the endpoint with the given ID by a new endpoint without
+// message handlers, e.g. for a node restarted on the same ID. The old endpoint stops
+// receiving messages.
+func (sn *Simnet) ReplaceEndpoint(id string) *SimnetEndpoint {
+	sn.mu.Lock()
+	defer sn.mu.Unlock()
+
+	endpoint := sn.newEndpoint(id)
+	for i, e := range sn.Endpoints {
+		if e.ID() == id {
+			sn.Endpoints[i] = endpoint
+			return endpoint
+		}
+	}
+	sn.Endpoints = append(sn.Endpoints, endpoint)
+	return endpoint
+}
+
+func (sn *Simnet) newEndpoint(id string) *SimnetEndpoint {
 	endpoint := &SimnetEndpoint{
 		id:       id,
 		network:  sn,
-		incoming: make(chan Envelope, viper.GetInt(common.CfgP2PMessageQueueSize)),
-		outgoing: make(chan Envelope, viper.GetInt(common.CfgP2PMessageQueueSize)),
+		registry: p2p.NewMessageHandlerRegistry(p2p.DefaultMiddlewares()...),
+		incoming: make(chan Envelope, common.GetConfig().P2P.MessageQueueSize),
+		outgoing: make(chan Envelope, common.GetConfig().P2P.MessageQueueSize),
+	}
+	if sn.ctx != nil {
+		endpoint.Start(sn.ctx)
 	}
-	sn.Endpoints = append(sn.Endpoints, endpoint)
 	return endpoint
 }
 
+// Disconnect drops the messages sent from and to the endpoint with the given ID,
+// until it is connected again.
+func (sn *Simnet) Disconnect(id string) {
+	sn.mu.Lock()
+	defer sn.mu.Unlock()
+	sn.disconnected[id] = true
+}
+
+// Connect delivers the messages sent from and to the endpoint with the given ID again.
+func (sn *Simnet) Connect(id string) {
+	sn.mu.Lock()
+	defer sn.mu.Unlock()
+	delete(sn.disconnected, id)
+}
+
 // Start is the main entry point for Simnet. It starts all endpoints and start a goroutine to handle message dlivery.
 func (sn *Simnet) Start(ctx context.Context) {
 	c, cancel := context.WithCancel(ctx)
+	sn.mu.Lock()
 	sn.ctx = c
 	sn.cancel = cancel
-
 	for _, endpoint := range sn.Endpoints {
-		endpoint.Start(ctx)
+		endpoint.Start(c)
 	}
+	sn.mu.Unlock()
 
 	go sn.mainLoop()
 }
@@ -101,7 +152,17 @@ func (sn *Simnet) mainLoop() {
 			return
 		case envelope := <-sn.messages:
 			time.Sleep(1 * time.Microsecond)
-			for _, endpoint := range sn.Endpoints {
+			sn.mu.Lock()
+			endpoints := append([]*SimnetEndpoint{}, sn.Endpoints...)
+			disconnected := sn.disconnected[envelope.From]
+			sn.mu.Unlock()
+			if disconnected {
+				continue
+			}
+			for _, endpoint := range endpoints {
+				if sn.isDisconnected(endpoint.ID()) {
+					continue
+				}
 				if (envelope.To == "" && envelope.From != endpoint.ID()) || envelope.To == endpoint.ID() {
 					go func(endpoint *SimnetEndpoint, envelope Envelope) {
 						// Simulate network delay except for messages to self.
@@ -117,6 +178,12 @@ func (sn *Simnet) mainLoop() {
 	}
 }
 
+func (sn *Simnet) isDisconnected(id string) bool {
+	sn.mu.Lock()
+	defer sn.mu.Unlock()
+	return sn.disconnected[id]
+}
+
 // AddMessage send a message through the network.
 func (sn *Simnet) AddMessage(msg Envelope) {
 	sn.mu.Lock()
@@ -135,6 +202,7 @@ type SimnetEndpoint struct {
 	id       string
 	network  *Simnet
 	handlers []p2p.MessageHandler
+	registry *p2p.MessageHandlerRegistry
 	incoming chan Envelope
 	outgoing chan Envelope
 }
@@ -146,10 +214,13 @@ func (se *SimnetEndpoint) Start(ctx context.Context) error {
 	go func() {
 		for {
 			select {
+			case <-ctx.Done():
+				return
 			case envelope := <-se.incoming:
 				message := p2ptypes.Message{
-					PeerID:  envelope.From,
-					Content: envelope.Content,
+					PeerID:    envelope.From,
+					ChannelID: envelope.ChannelID,
+					Content:   envelope.Content,
 				}
 				se.HandleMessage(message)
 			}
@@ -159,6 +230,8 @@ func (se *SimnetEndpoint) Start(ctx context.Context) error {
 	go func() {
 		for {
 			select {
+			case <-ctx.Done():
+				return
 			case envelope := <-se.outgoing:
 				se.network.messages <- envelope
 			}
@@ -180,7 +253,7 @@ func (se *SimnetEndpoint) Wait() {
 func (se *SimnetEndpoint) Broadcast(message p2ptypes.Message) (successes chan bool) {
 	successes = make(chan bool, 10)
 	go func() {
-		se.network.AddMessage(Envelope{From: se.ID(), Content: message.Content})
+		se.network.AddMessage(Envelope{From: se.ID(), ChannelID: message.ChannelID, Content: message.Content})
 		successes <- true
 	}()
 	return successes
@@ -189,14 +262,17 @@ func (se *SimnetEndpoint) Broadcast(message p2ptypes.Message) (successes chan bo
 // Send implements the Network interface.
 func (se *SimnetEndpoint) Send(id string, message p2ptypes.Message) bool {
 	go func() {
-		se.network.AddMessage(Envelope{From: se.ID(), To: id, Content: message.Content})
+		se.network.AddMessage(Envelope{From: se.ID(), To: id, ChannelID: message.ChannelID, Content: message.Content})
 	}()
 	return true
 }
 
-// RegisterMessageHandler implements the Network interface.
+// RegisterMessageHandler implements the Network interface. As with the Messenger, the
+// handler is wrapped with the default middlewares, and the first handler registered
+// for a channel wins.
 func (se *SimnetEndpoint) RegisterMessageHandler(handler p2p.MessageHandler) {
 	se.handlers = append(se.handlers, handler)
+	se.registry.Register(handler)
 }
 
 // ID implements the Network interface.
@@ -204,13 +280,31 @@ func (se *SimnetEndpoint) ID() string {
 	return se.id
 }
 
-// HandleMessage implements the MessageHandler interface.
+// HandleMessage implements the MessageHandler interface. Like the Messenger, it
+// passes the message to the handler of its channel after encoding and parsing it as
+// on the wire. Messages of channels without handlers go to all the handlers.
 func (se *SimnetEndpoint) HandleMessage(message p2ptypes.Message) error {
-	for _, handler := range se.handlers {
-		handler.HandleMessage(message)
+	if handler := se.channelHandler(message.ChannelID); handler != nil {
+		raw, err := handler.EncodeMessage(message.Content)
+		if err != nil {
+			return err
+		}
+		parsed, err := handler.ParseMessage(message.PeerID, message.ChannelID, raw)
+		if err != nil {
+			return err
+		}
+		handler.HandleMessage(parsed)
+	} else {
+		for _, handler := range se.handlers {
+			handler.HandleMessage(message)
+		}
 	}
 	if se.network.msgHandler != nil {
 		se.network.msgHandler.HandleMessage(message)
 	}
 	return nil
 }
+
+func (se *SimnetEndpoint) channelHandler(channelID common.ChannelIDEnum) p2p.MessageHandler {
+	return se.registry.GetHandler(channelID)
+}
@@ -106,3 +106,31 @@ func TestSimnetSend(t *testing.T) {
 	msgHandler.lock.Unlock()
 	assert.EqualValues([]string{"e1 -> world!"}, msgHandler.ReceivedMessages)
 }
+
+func TestSimnetDisconnect(t *testing.T) {
+	assert := assert.New(t)
+	msgHandler := &SimMessageHandler{lock: &sync.Mutex{}}
+	simnet := NewSimnetWithHandler(msgHandler)
+	e1 := simnet.AddEndpoint("e1")
+	simnet.AddEndpoint("e2")
+	e3 := simnet.AddEndpoint("e3")
+	simnet.Start(context.Background())
+
+	simnet.Disconnect("e3")
+	e1.Broadcast(createBlockMessage("hello!"))
+	e3.Broadcast(createBlockMessage("lost!"))
+	time.Sleep(1 * time.Second)
+	msgHandler.lock.Lock()
+	assert.EqualValues([]string{"e1 -> hello!"}, msgHandler.ReceivedMessages)
+	msgHandler.ReceivedMessages = make([]string, 0)
+	msgHandler.lock.Unlock()
+
+	simnet.Connect("e3")
+	e3 = simnet.ReplaceEndpoint("e3")
+	e3.Broadcast(createBlockMessage("world!"))
+	time.Sleep(1 * time.Second)
+	msgHandler.lock.Lock()
+	sort.Strings(msgHandler.ReceivedMessages)
+	msgHandler.lock.Unlock()
+	assert.EqualValues([]string{"e3 -> world!", "e3 -> world!"}, msgHandler.ReceivedMessages)
+}
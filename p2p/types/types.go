@@ -23,6 +23,7 @@ type NodeInfo struct {
 	PubKey      *crypto.PublicKey `rlp:"-"`
 	PubKeyBytes common.Bytes      // needed for RLP serialization
 	Port        uint16
+	Addresses   []string `rlp:"tail"` // dialable addresses of the node, "host:port"
 }
 
 // CreateNodeInfo creates an instance of NodeInfo
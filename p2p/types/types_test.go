@@ -55,3 +55,29 @@ func TestNodeInfoRLPEncoding2(t *testing.T) {
 
 	assert.Equal(nodeInfo.PubKey.Address(), decodedNodeInfo.PubKey.Address())
 }
+
+func TestNodeInfoAddressesRLPEncoding(t *testing.T) {
+	assert := assert.New(t)
+
+	_, randPubKey, err := crypto.GenerateKeyPair()
+	assert.Nil(err)
+	nodeInfo := CreateNodeInfo(randPubKey, 1234)
+
+	// Without addresses, the encoding is the one of the nodes that do not advertise any
+	legacyNodeInfo := struct {
+		PubKeyBytes []byte
+		Port        uint16
+	}{nodeInfo.PubKeyBytes, nodeInfo.Port}
+	encodedNodeInfoBytes, err := rlp.EncodeToBytes(nodeInfo)
+	assert.Nil(err)
+	encodedLegacyNodeInfoBytes, err := rlp.EncodeToBytes(legacyNodeInfo)
+	assert.Nil(err)
+	assert.Equal(encodedLegacyNodeInfoBytes, encodedNodeInfoBytes)
+
+	nodeInfo.Addresses = []string{"1.2.3.4:1234", "[2a00:1450::1]:1234"}
+	encodedNodeInfoBytes, err = rlp.EncodeToBytes(nodeInfo)
+	assert.Nil(err)
+	var decodedNodeInfo NodeInfo
+	assert.Nil(rlp.DecodeBytes(encodedNodeInfoBytes, &decodedNodeInfo))
+	assert.Equal(nodeInfo.Addresses, decodedNodeInfo.Addresses)
+}
@@ -0,0 +1,427 @@
+package rpc
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/thetatoken/ukulele/blockchain/archive"
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/consensus"
+	"github.com/thetatoken/ukulele/core"
+	"github.com/thetatoken/ukulele/crypto"
+	"github.com/thetatoken/ukulele/ledger"
+	"github.com/thetatoken/ukulele/ledger/state"
+	"github.com/thetatoken/ukulele/ledger/types"
+	"github.com/thetatoken/ukulele/webhook"
+)
+
+// ThetaAdminRPCService serves the methods of the "admin" namespace, which are only
+// registered if enabled in the config, since they are expensive or operate the node.
+type ThetaAdminRPCService struct {
+	server *ThetaRPCServer
+
+	mu             sync.Mutex
+	snapshot       *archive.Snapshot // Snapshot generated last, nil if none
+	snapshotChunks []common.Bytes
+}
+
+func newThetaAdminRPCService(server *ThetaRPCServer) *ThetaAdminRPCService {
+	return &ThetaAdminRPCService{server: server}
+}
+
+// ------------------------------ GenerateSnapshot -----------------------------------
+
+type GenerateSnapshotArgs struct {
+	ChunkSize common.JSONUint64 `json:"chunk_size"` // Approximate size of the chunks in bytes, 1MB if not set
+}
+
+type GenerateSnapshotResult struct {
+	BlockHash   common.Hash       `json:"block_hash"`
+	BlockHeight common.JSONUint64 `json:"block_height"`
+	StateHash   common.Hash       `json:"state_hash"`
+	ChunkHashes []common.Hash     `json:"chunk_hashes"`
+	Signer      common.Address    `json:"signer"`
+	Snapshot    string            `json:"snapshot"` // Hex encoded RLP of the signed snapshot
+}
+
+// GenerateSnapshot generates a snapshot of the ledger state of the latest finalized
+// block, signed by the node. The snapshot replaces the one generated before, and its
+// chunks are downloaded with GetSnapshotChunk.
+func (s *ThetaAdminRPCService) GenerateSnapshot(r *http.Request, args *GenerateSnapshotArgs, result *GenerateSnapshotResult) (err error) {
+	t := s.server
+	finalized := t.consensus.GetSummary().LastFinalizedBlock
+	if finalized.IsEmpty() {
+		return errors.New("No block is finalized yet")
+	}
+	block, err := t.chain.FindBlock(finalized)
+	if err != nil {
+		return err
+	}
+	ledgerState, err := t.ledger.GetFinalizedSnapshot()
+	if err != nil {
+		return err
+	}
+	db := ledgerState.GetStore().GetDB()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snapshot, chunks, err := archive.GenerateSnapshot(t.chain.ChainID, block, db, t.consensus.Signer(), int(args.ChunkSize))
+	if err != nil {
+		return err
+	}
+	raw, err := snapshot.ToBytes()
+	if err != nil {
+		return err
+	}
+	s.snapshot = snapshot
+	s.snapshotChunks = chunks
+
+	result.BlockHash = snapshot.Block
+	result.BlockHeight = common.JSONUint64(snapshot.Height)
+	result.StateHash = snapshot.StateHash
+	result.ChunkHashes = snapshot.Chunks
+	result.Signer = snapshot.Signer
+	result.Snapshot = hex.EncodeToString(raw)
+	return nil
+}
+
+// ------------------------------ GetSnapshotChunk -----------------------------------
+
+type GetSnapshotChunkArgs struct {
+	BlockHash common.Hash       `json:"block_hash"` // Block of the snapshot, to detect a newer snapshot
+	Index     common.JSONUint64 `json:"index"`
+}
+
+type GetSnapshotChunkResult struct {
+	Chunk string `json:"chunk"` // Hex encoded chunk
+}
+
+// GetSnapshotChunk returns a chunk of the snapshot generated last.
+func (s *ThetaAdminRPCService) GetSnapshotChunk(r *http.Request, args *GetSnapshotChunkArgs, result *GetSnapshotChunkResult) (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.snapshot == nil || s.snapshot.Block != args.BlockHash {
+		return fmt.Errorf("No snapshot of block %v", args.BlockHash.Hex())
+	}
+	if uint64(args.Index) >= uint64(len(s.snapshotChunks)) {
+		return fmt.Errorf("Snapshot has %v chunks", len(s.snapshotChunks))
+	}
+	result.Chunk = hex.EncodeToString(s.snapshotChunks[args.Index])
+	return nil
+}
+
+// ------------------------------ ReloadConfig -----------------------------------
+
+type ReloadConfigArgs struct{}
+
+type ReloadConfigResult struct {
+	Reloaded []string `json:"reloaded"` // Config keys whose new values are applied
+	Ignored  []string `json:"ignored"`  // Config keys changed in the file, applied after a restart
+}
+
+// ReloadConfig reads the config file again, and applies the new values of the
+// reloadable fields, as on SIGHUP.
+func (s *ThetaAdminRPCService) ReloadConfig(r *http.Request, args *ReloadConfigArgs, result *ReloadConfigResult) (err error) {
+	reload, err := common.ReloadConfig()
+	if err != nil {
+		return err
+	}
+	result.Reloaded = reload.Reloaded
+	result.Ignored = reload.Ignored
+	return nil
+}
+
+// ------------------------------ DumpConsensusState -----------------------------------
+
+type DumpConsensusStateArgs struct{}
+
+type DumpConsensusStateResult struct {
+	ID                 string            `json:"id"`
+	Epoch              common.JSONUint64 `json:"epoch"`
+	LastVoteHeight     common.JSONUint64 `json:"last_vote_height"`
+	Tip                BlockVotes        `json:"tip"`
+	HighestCCBlock     BlockVotes        `json:"highest_cc_block"`
+	LastFinalizedBlock BlockVotes        `json:"last_finalized_block"`
+	PendingBlocks      []BlockVotes      `json:"pending_blocks"`
+	EpochVotes         common.JSONUint64 `json:"epoch_votes"`
+}
+
+type BlockVotes struct {
+	Hash     common.Hash       `json:"hash"`
+	Height   common.JSONUint64 `json:"height"`
+	Epoch    common.JSONUint64 `json:"epoch"`
+	Status   core.BlockStatus  `json:"status"`
+	Votes    common.JSONUint64 `json:"votes"`
+	Majority bool              `json:"majority"`
+}
+
+// DumpConsensusState returns the internal state of the consensus engine: the tip, the
+// highest committed and the last finalized blocks, and the votes received by the blocks
+// above the last finalized block, to diagnose a node that stops making progress.
+func (s *ThetaAdminRPCService) DumpConsensusState(r *http.Request, args *DumpConsensusStateArgs, result *DumpConsensusStateResult) (err error) {
+	state := s.server.consensus.GetDebugState()
+	result.ID = state.ID
+	result.Epoch = common.JSONUint64(state.Epoch)
+	result.LastVoteHeight = common.JSONUint64(state.LastVoteHeight)
+	result.Tip = newBlockVotes(state.Tip)
+	result.HighestCCBlock = newBlockVotes(state.HighestCCBlock)
+	result.LastFinalizedBlock = newBlockVotes(state.LastFinalizedBlock)
+	result.PendingBlocks = []BlockVotes{}
+	for _, block := range state.PendingBlocks {
+		result.PendingBlocks = append(result.PendingBlocks, newBlockVotes(block))
+	}
+	result.EpochVotes = common.JSONUint64(state.EpochVotes)
+	return nil
+}
+
+func newBlockVotes(block consensus.BlockVotes) BlockVotes {
+	return BlockVotes{
+		Hash:     block.Hash,
+		Height:   common.JSONUint64(block.Height),
+		Epoch:    common.JSONUint64(block.Epoch),
+		Status:   block.Status,
+		Votes:    common.JSONUint64(block.Votes),
+		Majority: block.Majority,
+	}
+}
+
+// ------------------------------ AuditSupply -----------------------------------
+
+type AuditSupplyArgs struct {
+	Height common.JSONUint64 `json:"height"` // Height of a finalized block, the latest finalized block if not set
+}
+
+type AuditSupplyResult struct {
+	BlockHash   common.Hash       `json:"block_hash"`
+	BlockHeight common.JSONUint64 `json:"block_height"`
+	Accounts    common.JSONUint64 `json:"accounts"`
+	Balances    types.Coins       `json:"balances"`
+	Reserved    types.Coins       `json:"reserved"`
+	Locked      types.Coins       `json:"locked"`
+	Staked      types.Coins       `json:"staked"`
+	FeePool     types.Coins       `json:"fee_pool"`
+	Total       types.Coins       `json:"total"`
+	Genesis     types.Coins       `json:"genesis"`
+	Issued      types.Coins       `json:"issued"`
+	Expected    types.Coins       `json:"expected"`
+	Burned      types.Coins       `json:"burned"`
+	Inflation   types.Coins       `json:"inflation"`
+	Consistent  bool              `json:"consistent"` // Whether the supply does not exceed the expected supply
+}
+
+// AuditSupply iterates the ledger state of a finalized block, and reconciles the supply
+// of each denomination with the genesis supply and the issuance of the reward policy.
+func (s *ThetaAdminRPCService) AuditSupply(r *http.Request, args *AuditSupplyArgs, result *AuditSupplyResult) (err error) {
+	t := s.server
+	view, block, err := t.getFinalizedState(uint64(args.Height))
+	if err != nil {
+		return err
+	}
+	db := view.GetStore().GetDB()
+
+	root := t.chain.Root
+	genesis := state.NewStoreView(root.Height, root.StateHash, db)
+	if genesis == nil {
+		return fmt.Errorf("Genesis state %v is not in the database", root.StateHash.Hex())
+	}
+	audit, err := ledger.AuditSupply(view, genesis, view.GetChainParameters().RewardPolicy())
+	if err != nil {
+		return err
+	}
+
+	result.BlockHash = block.Hash()
+	result.BlockHeight = common.JSONUint64(block.Height)
+	result.Accounts = common.JSONUint64(audit.Supply.Accounts)
+	result.Balances = audit.Supply.Balances
+	result.Reserved = audit.Supply.Reserved
+	result.Locked = audit.Supply.Locked
+	result.Staked = audit.Supply.Staked
+	result.FeePool = audit.Supply.FeePool
+	result.Total = audit.Supply.Total
+	result.Genesis = audit.Genesis
+	result.Issued = audit.Issued
+	result.Expected = audit.Expected
+	result.Burned = audit.Burned
+	result.Inflation = audit.Inflation
+	result.Consistent = audit.IsConsistent()
+	return nil
+}
+
+// ------------------------------ GetStateAt -----------------------------------
+
+type GetStateAtArgs struct {
+	BlockHash string            `json:"block_hash"`
+	TxIndex   common.JSONUint64 `json:"tx_index"` // Index of the transaction in the block
+	Parent    bool              `json:"parent"`   // State of the parent block, before the first transaction
+	TxHash    string            `json:"tx_hash"`  // Transaction after which to get the state, instead of the block and index
+	Addresses []string          `json:"addresses"`
+}
+
+type GetStateAtResult struct {
+	BlockHash   common.Hash        `json:"block_hash"`
+	BlockHeight common.JSONUint64  `json:"block_height"`
+	TxIndex     int                `json:"tx_index"` // -1 for the state of the parent block
+	TxHash      common.Hash        `json:"tx_hash"`
+	StateRoot   common.Hash        `json:"state_root"`
+	Receipt     *types.TxReceipt   `json:"receipt,omitempty"`
+	Accounts    []GetAccountResult `json:"accounts"` // The accounts of the addresses, without the accounts which do not exist
+}
+
+// GetStateAt materializes the ledger state of a block after one of its transactions, by
+// replaying the block from the state of its parent, and returns the state root and the
+// accounts of the given addresses in that state. It is meant to trace transactions and
+// reproduce execution bugs, and needs the state of the parent block to be retained.
+func (s *ThetaAdminRPCService) GetStateAt(r *http.Request, args *GetStateAtArgs, result *GetStateAtResult) (err error) {
+	t := s.server
+	block, txIndex, err := t.findBlockTx(args)
+	if err != nil {
+		return err
+	}
+	addresses := []common.Address{}
+	for _, addressStr := range args.Addresses {
+		address, err := parseAddress(addressStr)
+		if err != nil {
+			return err
+		}
+		addresses = append(addresses, address)
+	}
+	parent, err := t.chain.FindBlock(block.Parent)
+	if err != nil {
+		return fmt.Errorf("Failed to find the parent of block %v: %v", block.Hash().Hex(), err)
+	}
+	if _, err := t.getBlockState(parent); err != nil {
+		return err
+	}
+
+	txState, err := t.ledger.StateAt(t.chain, block, txIndex)
+	if err != nil {
+		return err
+	}
+	result.BlockHash = block.Hash()
+	result.BlockHeight = common.JSONUint64(block.Height)
+	result.TxIndex = txState.TxIndex
+	result.TxHash = txState.TxHash
+	result.StateRoot = txState.View.Hash()
+	result.Receipt = txState.Receipt
+	result.Accounts = []GetAccountResult{}
+	for _, address := range addresses {
+		if account := txState.View.GetAccount(address); account != nil {
+			result.Accounts = append(result.Accounts, GetAccountResult{Account: account, Address: address.Hex()})
+		}
+	}
+	return nil
+}
+
+// findBlockTx returns the block and the index of the transaction designated by the
+// arguments of GetStateAt.
+func (t *ThetaRPCServer) findBlockTx(args *GetStateAtArgs) (*core.ExtendedBlock, int, error) {
+	if args.TxHash != "" {
+		hash, err := parseHash(args.TxHash)
+		if err != nil {
+			return nil, 0, err
+		}
+		_, block, found := t.chain.FindTxByHash(hash)
+		if !found {
+			return nil, 0, fmt.Errorf("Transaction %v is not found", hash.Hex())
+		}
+		for idx, rawTx := range block.Txs {
+			if crypto.Keccak256Hash(rawTx) == hash {
+				return block, idx, nil
+			}
+		}
+		return nil, 0, fmt.Errorf("Transaction %v is not in block %v", hash.Hex(), block.Hash().Hex())
+	}
+
+	if args.BlockHash == "" {
+		return nil, 0, errors.New("Block hash or transaction hash must be specified")
+	}
+	hash, err := parseHash(args.BlockHash)
+	if err != nil {
+		return nil, 0, err
+	}
+	block, err := t.chain.FindBlock(hash)
+	if err != nil {
+		return nil, 0, fmt.Errorf("Block %v is not found", hash.Hex())
+	}
+	if args.Parent {
+		return block, -1, nil
+	}
+	if uint64(args.TxIndex) >= uint64(len(block.Txs)) {
+		return nil, 0, fmt.Errorf("Block %v has no transaction %v, it has %v transactions", hash.Hex(), uint64(args.TxIndex), len(block.Txs))
+	}
+	return block, int(args.TxIndex), nil
+}
+
+// ------------------------------ Webhooks -----------------------------------
+
+type RegisterWebhookArgs struct {
+	URL    string             `json:"url"`
+	Filter webhook.FilterJSON `json:"filter"`
+}
+
+type RegisterWebhookResult struct {
+	ID         string            `json:"id"`
+	Secret     string            `json:"secret"` // Key of the HMAC-SHA256 signature of the payloads, only returned here
+	FromHeight common.JSONUint64 `json:"from_height"`
+}
+
+// RegisterWebhook registers a webhook notified of the transactions selected by the
+// filter in the blocks finalized from now on.
+func (s *ThetaAdminRPCService) RegisterWebhook(r *http.Request, args *RegisterWebhookArgs, result *RegisterWebhookResult) (err error) {
+	if s.server.webhooks == nil {
+		return errors.New("Webhooks are not enabled")
+	}
+	w, err := s.server.webhooks.Register(args.URL, args.Filter.Filter())
+	if err != nil {
+		return err
+	}
+	result.ID = w.ID
+	result.Secret = w.Secret
+	result.FromHeight = common.JSONUint64(w.FromHeight)
+	return nil
+}
+
+type ListWebhooksArgs struct{}
+
+type WebhookInfo struct {
+	webhook.WebhookJSON
+	PendingDeliveries common.JSONUint64 `json:"pending_deliveries"`
+}
+
+type ListWebhooksResult struct {
+	Webhooks []WebhookInfo `json:"webhooks"`
+}
+
+// ListWebhooks returns the registered webhooks, without their secrets.
+func (s *ThetaAdminRPCService) ListWebhooks(r *http.Request, args *ListWebhooksArgs, result *ListWebhooksResult) (err error) {
+	if s.server.webhooks == nil {
+		return errors.New("Webhooks are not enabled")
+	}
+	result.Webhooks = []WebhookInfo{}
+	for _, w := range s.server.webhooks.Webhooks() {
+		result.Webhooks = append(result.Webhooks, WebhookInfo{
+			WebhookJSON:       webhook.NewWebhookJSON(w),
+			PendingDeliveries: common.JSONUint64(s.server.webhooks.PendingDeliveries(w.ID)),
+		})
+	}
+	return nil
+}
+
+type UnregisterWebhookArgs struct {
+	ID string `json:"id"`
+}
+
+type UnregisterWebhookResult struct{}
+
+// UnregisterWebhook removes a webhook and drops its pending notifications.
+func (s *ThetaAdminRPCService) UnregisterWebhook(r *http.Request, args *UnregisterWebhookArgs, result *UnregisterWebhookResult) (err error) {
+	if s.server.webhooks == nil {
+		return errors.New("Webhooks are not enabled")
+	}
+	return s.server.webhooks.Unregister(args.ID)
+}
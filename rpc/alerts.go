@@ -0,0 +1,36 @@
+package rpc
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/ledger"
+)
+
+// MaxDoubleSpendAlertsWait is the maximum number of seconds GetDoubleSpendAlerts waits
+// for a new alert.
+const MaxDoubleSpendAlertsWait = 30
+
+// ------------------------------ GetDoubleSpendAlerts -----------------------------------
+
+type GetDoubleSpendAlertsArgs struct {
+	FromID common.JSONUint64 `json:"from_id"` // ID of the first alert to return, 0 for all the retained alerts
+	Wait   common.JSONUint64 `json:"wait"`    // Seconds to wait for an alert if there are none, at most MaxDoubleSpendAlertsWait
+}
+
+type GetDoubleSpendAlertsResult struct {
+	Alerts []*ledger.DoubleSpendAlert `json:"alerts"`
+}
+
+// GetDoubleSpendAlerts returns the alerts raised when a transaction arrives spending the
+// sequence of a different transaction already included in a block. Clients subscribe to
+// the alerts by long polling, passing the ID following the last alert they received.
+func (t *ThetaRPCServer) GetDoubleSpendAlerts(r *http.Request, args *GetDoubleSpendAlertsArgs, result *GetDoubleSpendAlertsResult) (err error) {
+	wait := uint64(args.Wait)
+	if wait > MaxDoubleSpendAlertsWait {
+		wait = MaxDoubleSpendAlertsWait
+	}
+	result.Alerts = t.ledger.GetDoubleSpendAlerts(uint64(args.FromID), time.Duration(wait)*time.Second)
+	return nil
+}
@@ -0,0 +1,78 @@
+package rpc
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/pprof"
+	"strings"
+
+	"github.com/thetatoken/ukulele/common"
+)
+
+// adminNamespacePrefix prefixes the methods of the admin namespace.
+const adminNamespacePrefix = "admin."
+
+// registerDebugHandlers serves the pprof profiles, including the goroutine dumps on
+// /debug/pprof/goroutine?debug=2, behind the admin token.
+func (t *ThetaRPCServer) registerDebugHandlers() {
+	t.router.Handle("/debug/pprof/cmdline", t.requireAdminToken(http.HandlerFunc(pprof.Cmdline)))
+	t.router.Handle("/debug/pprof/profile", t.requireAdminToken(http.HandlerFunc(pprof.Profile)))
+	t.router.Handle("/debug/pprof/symbol", t.requireAdminToken(http.HandlerFunc(pprof.Symbol)))
+	t.router.Handle("/debug/pprof/trace", t.requireAdminToken(http.HandlerFunc(pprof.Trace)))
+	t.router.PathPrefix("/debug/pprof/").Handler(t.requireAdminToken(http.HandlerFunc(pprof.Index)))
+}
+
+// requireAdminToken rejects the requests without the admin token, if one is set.
+func (t *ThetaRPCServer) requireAdminToken(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !hasAdminToken(r) {
+			http.Error(w, "Invalid admin token", http.StatusUnauthorized)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// requireAdminTokenForAdminMethods wraps the RPC handler to reject the calls of the
+// methods of the admin namespace without the admin token, if one is set.
+func (t *ThetaRPCServer) requireAdminTokenForAdminMethods(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if common.GetConfig().RPC.AdminToken == "" {
+			h.ServeHTTP(w, r)
+			return
+		}
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		var req struct {
+			Method string `json:"method"`
+		}
+		// Requests that do not parse are rejected by the RPC handler anyway
+		if json.Unmarshal(body, &req) == nil && strings.HasPrefix(req.Method, adminNamespacePrefix) && !hasAdminToken(r) {
+			http.Error(w, "Invalid admin token", http.StatusUnauthorized)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// hasAdminToken returns whether the request has the admin token as bearer token, or
+// whether no admin token is set.
+func hasAdminToken(r *http.Request) bool {
+	token := common.GetConfig().RPC.AdminToken
+	if token == "" {
+		return true
+	}
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "Bearer ") {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(strings.TrimPrefix(auth, "Bearer ")), []byte(token)) == 1
+}
@@ -0,0 +1,368 @@
+package rpc
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+
+	jsonrpc "github.com/gorilla/rpc/v2/json2"
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/core"
+	"github.com/thetatoken/ukulele/crypto"
+	"github.com/thetatoken/ukulele/ledger/state"
+	"github.com/thetatoken/ukulele/ledger/types"
+	"github.com/thetatoken/ukulele/rlp"
+)
+
+// The Ethereum JSON-RPC compatibility methods, served on /eth so that the Ethereum
+// tools and libraries can query the node and broadcast transactions. The native
+// currency of the Ethereum tools is GAMMA, as for the smart contracts, and the nonce
+// of an account is its sequence, i.e. the number of transactions it sent.
+
+const (
+	ethErrCodeInvalidRequest = -32600
+	ethErrCodeMethodNotFound = -32601
+	ethErrCodeInvalidParams  = -32602
+	ethErrCodeInternal       = -32603
+	ethErrCodeTxRejected     = -32000
+
+	ethBlockTagLatest    = "latest"
+	ethBlockTagSafe      = "safe"
+	ethBlockTagFinalized = "finalized"
+	ethBlockTagPending   = "pending"
+	ethBlockTagEarliest  = "earliest"
+)
+
+type ethRequest struct {
+	JSONRPC string            `json:"jsonrpc"`
+	ID      json.RawMessage   `json:"id"`
+	Method  string            `json:"method"`
+	Params  []json.RawMessage `json:"params"`
+}
+
+type ethResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *ethError       `json:"error,omitempty"`
+}
+
+type ethError struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+func (e *ethError) Error() string {
+	return e.Message
+}
+
+func newEthError(code int, format string, args ...interface{}) *ethError {
+	return &ethError{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+type ethMethod func(params []json.RawMessage) (interface{}, error)
+
+func (t *ThetaRPCServer) ethMethods() map[string]ethMethod {
+	return map[string]ethMethod{
+		"eth_chainId":             t.ethChainID,
+		"net_version":             t.ethNetVersion,
+		"eth_blockNumber":         t.ethBlockNumber,
+		"eth_getBalance":          t.ethGetBalance,
+		"eth_getTransactionCount": t.ethGetTransactionCount,
+		"eth_gasPrice":            t.ethGasPrice,
+		"eth_sendRawTransaction":  t.ethSendRawTransaction,
+	}
+}
+
+// registerEthHandlers serves the Ethereum JSON-RPC methods on /eth, including the
+// batches of calls.
+func (t *ThetaRPCServer) registerEthHandlers() {
+	methods := t.ethMethods()
+	t.router.Handle("/eth", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			json.NewEncoder(w).Encode(ethResponse{JSONRPC: "2.0", Error: newEthError(ethErrCodeInvalidRequest, "%v", err)})
+			return
+		}
+
+		body = bytes.TrimSpace(body)
+		if len(body) > 0 && body[0] == '[' {
+			var reqs []ethRequest
+			if err := json.Unmarshal(body, &reqs); err != nil {
+				json.NewEncoder(w).Encode(ethResponse{JSONRPC: "2.0", Error: newEthError(ethErrCodeInvalidRequest, "%v", err)})
+				return
+			}
+			responses := []ethResponse{}
+			for _, req := range reqs {
+				responses = append(responses, callEthMethod(methods, req))
+			}
+			json.NewEncoder(w).Encode(responses)
+			return
+		}
+
+		var req ethRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			json.NewEncoder(w).Encode(ethResponse{JSONRPC: "2.0", Error: newEthError(ethErrCodeInvalidRequest, "%v", err)})
+			return
+		}
+		json.NewEncoder(w).Encode(callEthMethod(methods, req))
+	})).Methods("POST")
+}
+
+func callEthMethod(methods map[string]ethMethod, req ethRequest) ethResponse {
+	resp := ethResponse{JSONRPC: "2.0", ID: req.ID}
+	method, ok := methods[req.Method]
+	if !ok {
+		resp.Error = newEthError(ethErrCodeMethodNotFound, "The method %v does not exist/is not available", req.Method)
+		return resp
+	}
+	result, err := method(req.Params)
+	if err != nil {
+		if ethErr, ok := err.(*ethError); ok {
+			resp.Error = ethErr
+		} else {
+			resp.Error = newEthError(ethErrCodeInternal, "%v", err)
+		}
+		return resp
+	}
+	resp.Result = result
+	return resp
+}
+
+func (t *ThetaRPCServer) ethChainID(params []json.RawMessage) (interface{}, error) {
+	return encodeEthUint64(common.GetConfig().RPC.EthChainID), nil
+}
+
+func (t *ThetaRPCServer) ethNetVersion(params []json.RawMessage) (interface{}, error) {
+	return strconv.FormatUint(common.GetConfig().RPC.EthChainID, 10), nil
+}
+
+// ethBlockNumber returns the height of the last finalized block, the latest block
+// whose state is served.
+func (t *ThetaRPCServer) ethBlockNumber(params []json.RawMessage) (interface{}, error) {
+	hash := t.consensus.GetSummary().LastFinalizedBlock
+	if hash.IsEmpty() {
+		return encodeEthUint64(0), nil
+	}
+	block, err := t.chain.FindBlock(hash)
+	if err != nil {
+		return nil, err
+	}
+	return encodeEthUint64(block.Height), nil
+}
+
+// ethGetBalance returns the GAMMA balance of an account, in GammaWei.
+func (t *ThetaRPCServer) ethGetBalance(params []json.RawMessage) (interface{}, error) {
+	account, err := t.ethGetAccount(params)
+	if err != nil {
+		return nil, err
+	}
+	if account == nil {
+		return encodeEthBig(big.NewInt(0)), nil
+	}
+	return encodeEthBig(account.Balance.NoNil().GammaWei), nil
+}
+
+// ethGetTransactionCount returns the sequence of an account. The nonce of an Ethereum
+// transaction wrapping a native transaction is ignored, the native sequence is the
+// one after the returned count.
+func (t *ThetaRPCServer) ethGetTransactionCount(params []json.RawMessage) (interface{}, error) {
+	account, err := t.ethGetAccount(params)
+	if err != nil {
+		return nil, err
+	}
+	if account == nil {
+		return encodeEthUint64(0), nil
+	}
+	return encodeEthUint64(account.Sequence), nil
+}
+
+// ethGasPrice returns the gas price suggested by EstimateFee.
+func (t *ThetaRPCServer) ethGasPrice(params []json.RawMessage) (interface{}, error) {
+	var result EstimateFeeResult
+	if err := t.EstimateFee(nil, &EstimateFeeArgs{}, &result); err != nil {
+		return nil, err
+	}
+	return encodeEthBig((*big.Int)(result.GasPrice)), nil
+}
+
+// ethSendRawTransaction broadcasts a transaction, either in the native format or as a
+// signed Ethereum transaction wrapping a native transaction, see
+// decodeEthRawTransaction. It returns the hash of the native transaction.
+func (t *ThetaRPCServer) ethSendRawTransaction(params []json.RawMessage) (interface{}, error) {
+	var rawHex string
+	if len(params) < 1 || json.Unmarshal(params[0], &rawHex) != nil {
+		return nil, newEthError(ethErrCodeInvalidParams, "Expected the hex encoded signed transaction")
+	}
+	raw, err := hex.DecodeString(strings.TrimPrefix(rawHex, "0x"))
+	if err != nil {
+		return nil, newEthError(ethErrCodeInvalidParams, "Invalid transaction: %v", err)
+	}
+	if _, err := types.TxFromBytes(raw); err != nil {
+		tx, err := decodeEthRawTransaction(raw, t.chain.ChainID)
+		if err != nil {
+			return nil, newEthError(ethErrCodeInvalidParams, "Invalid transaction: %v", err)
+		}
+		if raw, err = types.TxToBytes(tx); err != nil {
+			return nil, err
+		}
+	}
+
+	var result BroadcastRawTransactionResult
+	if err := t.BroadcastRawTransaction(nil, &BroadcastRawTransactionArgs{TxBytes: hex.EncodeToString(raw)}, &result); err != nil {
+		return nil, &ethError{Code: ethErrCodeTxRejected, Message: err.Error(), Data: rejectionData(err)}
+	}
+	return result.TxHash, nil
+}
+
+// rejectionData returns the TxRejected data of the error of BroadcastRawTransaction.
+func rejectionData(err error) interface{} {
+	if jsonErr, ok := err.(*jsonrpc.Error); ok {
+		return jsonErr.Data
+	}
+	return nil
+}
+
+// ethGetAccount returns the account of the [address, block] parameters, or nil if it
+// does not exist.
+func (t *ThetaRPCServer) ethGetAccount(params []json.RawMessage) (*types.Account, error) {
+	var addressStr string
+	if len(params) < 1 || json.Unmarshal(params[0], &addressStr) != nil {
+		return nil, newEthError(ethErrCodeInvalidParams, "Expected an address")
+	}
+	// The Ethereum tools do not always checksum the addresses
+	address, err := common.ParseHexAddress(addressStr, true)
+	if err != nil {
+		return nil, newEthError(ethErrCodeInvalidParams, "%v", err)
+	}
+	tag := ethBlockTagLatest
+	if len(params) > 1 && json.Unmarshal(params[1], &tag) != nil {
+		return nil, newEthError(ethErrCodeInvalidParams, "Expected a block number or tag")
+	}
+	view, err := t.ethGetState(tag)
+	if err != nil {
+		return nil, err
+	}
+	return view.GetAccount(address), nil
+}
+
+// ethGetState returns the ledger state of the given block number or tag. The latest,
+// safe and finalized blocks are all the last finalized block, and the pending state
+// includes the transactions in the mempool.
+func (t *ThetaRPCServer) ethGetState(tag string) (*state.StoreView, error) {
+	var block *core.ExtendedBlock
+	switch tag {
+	case ethBlockTagLatest, ethBlockTagSafe, ethBlockTagFinalized:
+		view, _, err := t.getFinalizedState(0)
+		return view, err
+	case ethBlockTagPending:
+		return t.getLedgerSnapshot(LedgerStatePending)
+	case ethBlockTagEarliest:
+		block = t.chain.Root
+	default:
+		height, err := decodeEthUint64(tag)
+		if err != nil {
+			return nil, newEthError(ethErrCodeInvalidParams, "Invalid block number %v", tag)
+		}
+		if block = t.findFinalizedBlockByHeight(height); block == nil {
+			return nil, newEthError(ethErrCodeInvalidParams, "No finalized block at height %v", height)
+		}
+	}
+	return t.getBlockState(block)
+}
+
+// ethLegacyTx is a signed Ethereum transaction, as sent by eth_sendRawTransaction.
+type ethLegacyTx struct {
+	Nonce    uint64
+	GasPrice *big.Int
+	Gas      uint64
+	To       common.Bytes
+	Value    *big.Int
+	Data     common.Bytes
+	V        *big.Int
+	R        *big.Int
+	S        *big.Int
+}
+
+// decodeEthRawTransaction returns the native transaction wrapped in a signed Ethereum
+// transaction, with the signature of its signer set. The sign bytes of the native
+// transactions are the encoding of an Ethereum transaction with a zero nonce, gas
+// price, gas limit, recipient and value, and the chain ID followed by the unsigned
+// native transaction as data. Such an Ethereum transaction signed without replay
+// protection (EIP-155) by a sender of the native transaction thus carries the native
+// signature. Other Ethereum transactions cannot be mapped to native transactions.
+func decodeEthRawTransaction(raw common.Bytes, chainID string) (types.Tx, error) {
+	var ethTx ethLegacyTx
+	if err := rlp.DecodeBytes(raw, &ethTx); err != nil {
+		return nil, err
+	}
+	if ethTx.Nonce != 0 || ethTx.GasPrice.Sign() != 0 || ethTx.Gas != 0 || ethTx.Value.Sign() != 0 ||
+		len(ethTx.To) != common.AddressLength || common.BytesToAddress(ethTx.To) != (common.Address{}) {
+		return nil, errors.New("Only the Ethereum transactions wrapping a native transaction are supported, " +
+			"with a zero nonce, gas price, gas limit and value, and the zero address as recipient")
+	}
+
+	prefix, err := rlp.EncodeToBytes(chainID)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(ethTx.Data, prefix) {
+		return nil, fmt.Errorf("The wrapped transaction is not for chain %v", chainID)
+	}
+	tx, err := types.TxFromBytes(ethTx.Data[len(prefix):])
+	if err != nil {
+		return nil, err
+	}
+
+	// Replay protected signatures sign another encoding, which is not the native one
+	if !ethTx.V.IsUint64() || (ethTx.V.Uint64() != 27 && ethTx.V.Uint64() != 28) {
+		return nil, errors.New("The Ethereum transaction must be signed without EIP-155 replay protection, " +
+			"the chain ID of the wrapped transaction protects against replays")
+	}
+	if ethTx.R.BitLen() > 256 || ethTx.S.BitLen() > 256 {
+		return nil, errors.New("Invalid signature values")
+	}
+	sigBytes := make([]byte, crypto.SignatureLength)
+	copy(sigBytes[32-len(ethTx.R.Bytes()):32], ethTx.R.Bytes())
+	copy(sigBytes[64-len(ethTx.S.Bytes()):64], ethTx.S.Bytes())
+	sigBytes[64] = byte(ethTx.V.Uint64() - 27)
+	sig, err := crypto.SignatureFromBytes(sigBytes)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := sig.RecoverSignerAddress(tx.SignBytes(chainID))
+	if err != nil {
+		return nil, err
+	}
+	signable, ok := tx.(interface {
+		SetSignature(addr common.Address, sig *crypto.Signature) bool
+	})
+	if !ok || !signable.SetSignature(signer, sig) {
+		return nil, fmt.Errorf("The signer %v is not a signer of the wrapped transaction", signer.Hex())
+	}
+	return tx, nil
+}
+
+func encodeEthUint64(value uint64) string {
+	return "0x" + strconv.FormatUint(value, 16)
+}
+
+func encodeEthBig(value *big.Int) string {
+	return "0x" + value.Text(16)
+}
+
+func decodeEthUint64(s string) (uint64, error) {
+	if !strings.HasPrefix(s, "0x") {
+		return 0, fmt.Errorf("Missing 0x prefix in %v", s)
+	}
+	return strconv.ParseUint(s[2:], 16, 64)
+}
@@ -0,0 +1,89 @@
+package rpc
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/crypto"
+	"github.com/thetatoken/ukulele/ledger/types"
+	"github.com/thetatoken/ukulele/rlp"
+)
+
+func TestDecodeEthRawTransaction(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	chainID := "privatenet"
+	privKey, pubKey, err := crypto.TEST_GenerateKeyPairWithSeed("eth")
+	require.Nil(err)
+	tx := &types.SendTx{
+		Fee: types.NewCoins(0, 1000000000000),
+		Inputs: []types.TxInput{{
+			Address:  pubKey.Address(),
+			Coins:    types.NewCoins(0, 1000000000005),
+			Sequence: 1,
+		}},
+		Outputs: []types.TxOutput{{
+			Address: common.HexToAddress("0x9F1233798E905E173560071255140b4A8aBd3Ec6"),
+			Coins:   types.NewCoins(0, 5),
+		}},
+	}
+	sig, err := privKey.Sign(tx.SignBytes(chainID))
+	require.Nil(err)
+
+	// The Ethereum transaction an Ethereum wallet signs for the native transaction
+	prefix, _ := rlp.EncodeToBytes(chainID)
+	unsigned, _ := types.TxToBytes(tx)
+	sigBytes := sig.ToBytes()
+	ethTx := ethLegacyTx{
+		GasPrice: big.NewInt(0),
+		To:       common.Address{}.Bytes(),
+		Value:    big.NewInt(0),
+		Data:     append(prefix, unsigned...),
+		V:        big.NewInt(int64(sigBytes[64]) + 27),
+		R:        new(big.Int).SetBytes(sigBytes[:32]),
+		S:        new(big.Int).SetBytes(sigBytes[32:64]),
+	}
+	raw, err := rlp.EncodeToBytes(ethTx)
+	require.Nil(err)
+
+	decoded, err := decodeEthRawTransaction(raw, chainID)
+	require.Nil(err)
+	sendTx, ok := decoded.(*types.SendTx)
+	require.True(ok)
+	assert.Equal(sigBytes, sendTx.Inputs[0].Signature.ToBytes())
+	assert.Equal(tx.Outputs, sendTx.Outputs)
+
+	_, err = decodeEthRawTransaction(raw, "testnet")
+	assert.NotNil(err)
+
+	// Replay protected signatures do not sign the native sign bytes
+	ethTx.V = big.NewInt(int64(sigBytes[64]) + 366*2 + 35)
+	raw, _ = rlp.EncodeToBytes(ethTx)
+	_, err = decodeEthRawTransaction(raw, chainID)
+	assert.NotNil(err)
+
+	// Regular Ethereum transactions cannot be mapped
+	ethTx.V = big.NewInt(int64(sigBytes[64]) + 27)
+	ethTx.Nonce = 1
+	raw, _ = rlp.EncodeToBytes(ethTx)
+	_, err = decodeEthRawTransaction(raw, chainID)
+	assert.NotNil(err)
+}
+
+func TestEthQuantities(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("0x0", encodeEthUint64(0))
+	assert.Equal("0x1a", encodeEthUint64(26))
+	assert.Equal("0xde0b6b3a7640000", encodeEthBig(big.NewInt(1000000000000000000)))
+
+	height, err := decodeEthUint64("0x1a")
+	assert.Nil(err)
+	assert.Equal(uint64(26), height)
+	_, err = decodeEthUint64("26")
+	assert.NotNil(err)
+}
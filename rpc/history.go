@@ -0,0 +1,184 @@
+package rpc
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/thetatoken/ukulele/blockchain"
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/ledger/types"
+)
+
+// MaxAccountHistoryHeights is the maximum number of heights scanned by GetAccountHistory.
+const MaxAccountHistoryHeights = 1000
+
+// MaxGetTransactionsByAddressCount is the maximum number of transactions returned by
+// GetTransactionsByAddress.
+const MaxGetTransactionsByAddressCount = 100
+
+// ------------------------------ GetBalanceChanges -----------------------------------
+
+type GetBalanceChangesArgs struct {
+	Height common.JSONUint64 `json:"height"`
+}
+
+type GetBalanceChangesResult struct {
+	BlockHash   common.Hash           `json:"block_hash"`
+	BlockHeight common.JSONUint64     `json:"block_height"`
+	Changes     []types.BalanceChange `json:"changes"`
+}
+
+// GetBalanceChanges returns the balances changed by the finalized block at the given
+// height, as journaled when the node applied it.
+func (t *ThetaRPCServer) GetBalanceChanges(r *http.Request, args *GetBalanceChangesArgs, result *GetBalanceChangesResult) (err error) {
+	height := uint64(args.Height)
+	block := t.findFinalizedBlockByHeight(height)
+	if block == nil {
+		return fmt.Errorf("No finalized block at height %v", height)
+	}
+	journal, ok := t.ledger.GetBalanceJournal(height)
+	if !ok || journal.StateRoot != block.StateHash {
+		return fmt.Errorf("The balance changes of the block at height %v are not recorded", height)
+	}
+	result.BlockHash = block.Hash()
+	result.BlockHeight = common.JSONUint64(height)
+	result.Changes = journal.Changes
+	return nil
+}
+
+// ------------------------------ GetAccountHistory -----------------------------------
+
+type GetAccountHistoryArgs struct {
+	Address string            `json:"address"`
+	From    common.JSONUint64 `json:"from"`  // Height of the first block to scan
+	Count   common.JSONUint64 `json:"count"` // At most MaxAccountHistoryHeights
+}
+
+type AccountHistoryEntry struct {
+	BlockHash   common.Hash       `json:"block_hash"`
+	BlockHeight common.JSONUint64 `json:"block_height"`
+	Before      types.Coins       `json:"before"`
+	After       types.Coins       `json:"after"`
+	Delta       types.Coins       `json:"delta"`
+}
+
+type GetAccountHistoryResult struct {
+	Entries []AccountHistoryEntry `json:"entries"`
+	Missing []common.JSONUint64   `json:"missing"` // Heights whose balance changes are not recorded, e.g. the genesis
+	Next    common.JSONUint64     `json:"next"`    // Height to continue the scan from
+}
+
+// GetAccountHistory returns the balance changes of the account in the finalized blocks
+// from the given height, read from the balance journals rather than by replaying the
+// blocks. It stops at the first height without a finalized block. The heights whose
+// journal is missing, e.g. applied before the journal was enabled, are reported so that
+// the history is never silently incomplete.
+func (t *ThetaRPCServer) GetAccountHistory(r *http.Request, args *GetAccountHistoryArgs, result *GetAccountHistoryResult) (err error) {
+	if args.Address == "" {
+		return errors.New("Address must be specified")
+	}
+	address, err := parseAddress(args.Address)
+	if err != nil {
+		return err
+	}
+	count := uint64(args.Count)
+	if count == 0 || count > MaxAccountHistoryHeights {
+		count = MaxAccountHistoryHeights
+	}
+
+	result.Entries = []AccountHistoryEntry{}
+	result.Missing = []common.JSONUint64{}
+	height := uint64(args.From)
+	for ; height < uint64(args.From)+count; height++ {
+		block := t.findFinalizedBlockByHeight(height)
+		if block == nil {
+			break
+		}
+		journal, ok := t.ledger.GetBalanceJournal(height)
+		if !ok || journal.StateRoot != block.StateHash {
+			result.Missing = append(result.Missing, common.JSONUint64(height))
+			continue
+		}
+		change, ok := journal.Find(address)
+		if !ok {
+			continue
+		}
+		result.Entries = append(result.Entries, AccountHistoryEntry{
+			BlockHash:   block.Hash(),
+			BlockHeight: common.JSONUint64(height),
+			Before:      change.Before,
+			After:       change.After,
+			Delta:       change.Delta(),
+		})
+	}
+	result.Next = common.JSONUint64(height)
+	return nil
+}
+
+// ------------------------------ GetTransactionsByAddress -----------------------------------
+
+// Directions of the transactions returned by GetTransactionsByAddress
+const (
+	TxDirectionAll      = "all"      // Transactions sent or received by the address, the default
+	TxDirectionSent     = "sent"     // Transactions signed or paid for by the address
+	TxDirectionReceived = "received" // Transactions directed to the address
+)
+
+type GetTransactionsByAddressArgs struct {
+	Address   string            `json:"address"`
+	Direction string            `json:"direction"` // TxDirectionAll if empty
+	Offset    common.JSONUint64 `json:"offset"`    // Number of transactions to skip
+	Count     common.JSONUint64 `json:"count"`     // At most MaxGetTransactionsByAddressCount
+	Ascending bool              `json:"ascending"` // Oldest transactions first, instead of the most recent
+}
+
+type AddressTx struct {
+	TxHash      common.Hash       `json:"hash"`
+	BlockHash   common.Hash       `json:"block_hash"`
+	BlockHeight common.JSONUint64 `json:"block_height"`
+}
+
+type GetTransactionsByAddressResult struct {
+	Total        common.JSONUint64 `json:"total"` // Number of transactions of the address in the direction
+	Transactions []AddressTx       `json:"transactions"`
+}
+
+// GetTransactionsByAddress returns a page of the finalized transactions sent or received
+// by the address, from the address index of the chain.
+func (t *ThetaRPCServer) GetTransactionsByAddress(r *http.Request, args *GetTransactionsByAddressArgs, result *GetTransactionsByAddressResult) (err error) {
+	if args.Address == "" {
+		return errors.New("Address must be specified")
+	}
+	address, err := parseAddress(args.Address)
+	if err != nil {
+		return err
+	}
+	var direction blockchain.AddressTxDirection
+	switch args.Direction {
+	case "", TxDirectionAll:
+		direction = blockchain.AddressTxAll
+	case TxDirectionSent:
+		direction = blockchain.AddressTxSent
+	case TxDirectionReceived:
+		direction = blockchain.AddressTxReceived
+	default:
+		return fmt.Errorf("Invalid direction %v, expected %v, %v or %v",
+			args.Direction, TxDirectionAll, TxDirectionSent, TxDirectionReceived)
+	}
+	count := uint64(args.Count)
+	if count == 0 || count > MaxGetTransactionsByAddressCount {
+		count = MaxGetTransactionsByAddressCount
+	}
+
+	result.Total = common.JSONUint64(t.chain.GetAddressTxCount(address, direction))
+	result.Transactions = []AddressTx{}
+	for _, entry := range t.chain.FindAddressTxs(address, direction, uint64(args.Offset), count, !args.Ascending) {
+		result.Transactions = append(result.Transactions, AddressTx{
+			TxHash:      entry.TxHash,
+			BlockHash:   entry.BlockHash,
+			BlockHeight: common.JSONUint64(entry.BlockHeight),
+		})
+	}
+	return nil
+}
@@ -0,0 +1,297 @@
+package rpc
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/core"
+	"github.com/thetatoken/ukulele/crypto"
+	"github.com/thetatoken/ukulele/ledger/state"
+	"github.com/thetatoken/ukulele/rlp"
+)
+
+// MaxGetHeadersCount is the maximum number of headers returned by GetHeaders.
+const MaxGetHeadersCount = 100
+
+// ------------------------------ GetHeaders -----------------------------------
+
+type GetHeadersArgs struct {
+	From  common.JSONUint64 `json:"from"`  // Height of the first header
+	Count common.JSONUint64 `json:"count"` // At most MaxGetHeadersCount
+}
+
+type HeaderWithCC struct {
+	Hash              common.Hash       `json:"hash"`
+	Height            common.JSONUint64 `json:"height"`
+	Header            string            `json:"header"`             // Hex encoded RLP of the header
+	CommitCertificate string            `json:"commit_certificate"` // Hex encoded RLP of the commit certificate, empty if none
+}
+
+type GetHeadersResult struct {
+	Headers []HeaderWithCC `json:"headers"`
+}
+
+// GetHeaders returns the headers of the finalized blocks from the given height, with
+// their commit certificates, for the light clients. It stops at the first height
+// without a finalized block.
+func (t *ThetaRPCServer) GetHeaders(r *http.Request, args *GetHeadersArgs, result *GetHeadersResult) (err error) {
+	count := uint64(args.Count)
+	if count == 0 || count > MaxGetHeadersCount {
+		count = MaxGetHeadersCount
+	}
+
+	result.Headers = []HeaderWithCC{}
+	for height := uint64(args.From); height < uint64(args.From)+count; height++ {
+		block := t.findFinalizedBlockByHeight(height)
+		if block == nil {
+			break
+		}
+		raw, err := rlp.EncodeToBytes(block.BlockHeader)
+		if err != nil {
+			return err
+		}
+		header := HeaderWithCC{
+			Hash:   block.Hash(),
+			Height: common.JSONUint64(block.Height),
+			Header: hex.EncodeToString(raw),
+		}
+		if cc := t.consensus.GetCommitCertificate(block.Hash()); cc != nil {
+			raw, err = rlp.EncodeToBytes(cc)
+			if err != nil {
+				return err
+			}
+			header.CommitCertificate = hex.EncodeToString(raw)
+		}
+		result.Headers = append(result.Headers, header)
+	}
+	return nil
+}
+
+// ------------------------------ GetAccountProof -----------------------------------
+
+type GetAccountProofArgs struct {
+	Address string `json:"address"`
+}
+
+type GetAccountProofResult struct {
+	BlockHash   common.Hash       `json:"block_hash"`
+	BlockHeight common.JSONUint64 `json:"block_height"`
+	Proof       []string          `json:"proof"` // Hex encoded trie nodes
+}
+
+// GetAccountProof returns the Merkle proof of an account in the ledger state of the
+// latest finalized block. The proof also shows that an account does not exist.
+func (t *ThetaRPCServer) GetAccountProof(r *http.Request, args *GetAccountProofArgs, result *GetAccountProofResult) (err error) {
+	if args.Address == "" {
+		return errors.New("Address must be specified")
+	}
+	address, err := parseAddress(args.Address)
+	if err != nil {
+		return err
+	}
+
+	finalized := t.consensus.GetSummary().LastFinalizedBlock
+	if finalized.IsEmpty() {
+		return errors.New("No block is finalized yet")
+	}
+	block, err := t.chain.FindBlock(finalized)
+	if err != nil {
+		return err
+	}
+	ledgerState, err := t.ledger.GetFinalizedSnapshot()
+	if err != nil {
+		return err
+	}
+	sv := state.NewStoreView(block.Height, block.StateHash, ledgerState.GetStore().GetDB())
+	if sv == nil {
+		return fmt.Errorf("State %v of block %v is not in the database", block.StateHash.Hex(), block.Hash().Hex())
+	}
+	proof, err := sv.ProveAccount(address)
+	if err != nil {
+		return err
+	}
+
+	result.BlockHash = block.Hash()
+	result.BlockHeight = common.JSONUint64(block.Height)
+	result.Proof = encodeProof(proof)
+	return nil
+}
+
+// ------------------------------ GetTransactionProof -----------------------------------
+
+type GetTransactionProofArgs struct {
+	Hash string `json:"hash"`
+}
+
+type GetTransactionProofResult struct {
+	BlockHash   common.Hash       `json:"block_hash"`
+	BlockHeight common.JSONUint64 `json:"block_height"`
+	Status      TxStatus          `json:"status"`
+	Index       common.JSONUint64 `json:"index"`
+	Tx          string            `json:"transaction"` // Hex encoded raw transaction
+	Proof       []string          `json:"proof"`       // Hex encoded trie nodes
+}
+
+// GetTransactionProof returns a transaction, and the Merkle proof of its inclusion in
+// the transactions of its block.
+func (t *ThetaRPCServer) GetTransactionProof(r *http.Request, args *GetTransactionProofArgs, result *GetTransactionProofResult) (err error) {
+	if args.Hash == "" {
+		return errors.New("Transanction hash must be specified")
+	}
+	hash, err := parseHash(args.Hash)
+	if err != nil {
+		return err
+	}
+	_, block, found := t.chain.FindTxByHash(hash)
+	if !found {
+		result.Status = TxStatusNotFound
+		return nil
+	}
+	index := -1
+	for i, tx := range block.Txs {
+		if crypto.Keccak256Hash(tx) == hash {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return fmt.Errorf("Transaction %v is not in block %v", hash.Hex(), block.Hash().Hex())
+	}
+	proof, err := core.ProveTx(block.Txs, index)
+	if err != nil {
+		return err
+	}
+
+	result.BlockHash = block.Hash()
+	result.BlockHeight = common.JSONUint64(block.Height)
+	if block.Status == core.BlockStatusFinalized {
+		result.Status = TxStatusFinalized
+	} else {
+		result.Status = TxStatusPending
+	}
+	result.Index = common.JSONUint64(index)
+	result.Tx = hex.EncodeToString(block.Txs[index])
+	result.Proof = encodeProof(proof)
+	return nil
+}
+
+// ------------------------------ GetVotesByBlock -----------------------------------
+
+type GetVotesByBlockArgs struct {
+	Hash   common.Hash       `json:"hash"`   // Hash of the block, takes precedence over the height
+	Height common.JSONUint64 `json:"height"` // Height of the finalized block, if the hash is not specified
+}
+
+type VoteInfo struct {
+	ID        common.Address    `json:"id"`
+	Block     common.Hash       `json:"block"`
+	Height    common.JSONUint64 `json:"height"`
+	Epoch     common.JSONUint64 `json:"epoch"`
+	Signature string            `json:"signature"` // Hex encoded signature of the vote
+	Valid     bool              `json:"valid"`     // Whether the signature verifies and the voter is a validator of the block epoch
+}
+
+type GetVotesByBlockResult struct {
+	BlockHash         common.Hash       `json:"block_hash"`
+	BlockHeight       common.JSONUint64 `json:"block_height"`
+	BlockEpoch        common.JSONUint64 `json:"block_epoch"`
+	Votes             []VoteInfo        `json:"votes"`
+	Validators        []ValidatorInfo   `json:"validators"`         // Validators of the block epoch
+	VotedStake        common.JSONUint64 `json:"voted_stake"`        // Stake of the validators with a valid vote
+	TotalStake        common.JSONUint64 `json:"total_stake"`        // Stake of all the validators
+	Quorum            bool              `json:"quorum"`             // Whether the valid votes hold more than 2/3 of the stake
+	CommitCertificate string            `json:"commit_certificate"` // Hex encoded RLP of the commit certificate
+}
+
+// GetVotesByBlock returns the votes recorded when the block was finalized, so that
+// the quorum of validator signatures behind the finalization can be audited. The
+// votes are checked against the validator set of the block epoch.
+func (t *ThetaRPCServer) GetVotesByBlock(r *http.Request, args *GetVotesByBlockArgs, result *GetVotesByBlockResult) (err error) {
+	var block *core.ExtendedBlock
+	if !args.Hash.IsEmpty() {
+		block, err = t.chain.FindBlock(args.Hash)
+		if err != nil {
+			return err
+		}
+	} else {
+		block = t.findFinalizedBlockByHeight(uint64(args.Height))
+	}
+	if block == nil || block.Status != core.BlockStatusFinalized {
+		return errors.New("Block is not finalized")
+	}
+
+	cc := t.consensus.GetFinalizedCommitCertificate(block.Hash())
+	if cc == nil {
+		return fmt.Errorf("No votes recorded for block %v", block.Hash().Hex())
+	}
+
+	result.BlockHash = block.Hash()
+	result.BlockHeight = common.JSONUint64(block.Height)
+	result.BlockEpoch = common.JSONUint64(block.Epoch)
+
+	validatorSet := t.consensus.GetValidatorManager().GetValidatorSetForEpoch(block.Epoch)
+	result.Validators = []ValidatorInfo{}
+	for _, v := range validatorSet.Validators() {
+		result.Validators = append(result.Validators, ValidatorInfo{
+			Address: v.Address(),
+			Stake:   common.JSONUint64(v.Stake()),
+		})
+	}
+
+	validVotes := core.NewVoteSet()
+	result.Votes = []VoteInfo{}
+	for _, vote := range cc.Votes.Votes() {
+		info := VoteInfo{
+			ID:     vote.ID,
+			Block:  vote.Block,
+			Height: common.JSONUint64(vote.Height),
+			Epoch:  common.JSONUint64(vote.Epoch),
+		}
+		if vote.Signature != nil {
+			info.Signature = hex.EncodeToString(vote.Signature.ToBytes())
+		}
+		if _, err := validatorSet.GetValidator(vote.ID); err == nil && vote.Validate().IsOK() {
+			info.Valid = true
+			validVotes.AddVote(vote)
+		}
+		result.Votes = append(result.Votes, info)
+	}
+
+	votedStake := uint64(0)
+	for _, vote := range validVotes.Votes() {
+		validator, _ := validatorSet.GetValidator(vote.ID)
+		votedStake += validator.Stake()
+	}
+	result.VotedStake = common.JSONUint64(votedStake)
+	result.TotalStake = common.JSONUint64(validatorSet.TotalStake())
+	result.Quorum = validatorSet.HasMajority(validVotes)
+
+	raw, err := rlp.EncodeToBytes(cc)
+	if err != nil {
+		return err
+	}
+	result.CommitCertificate = hex.EncodeToString(raw)
+	return nil
+}
+
+// ------------------------------ Utils -----------------------------------
+
+func (t *ThetaRPCServer) findFinalizedBlockByHeight(height uint64) *core.ExtendedBlock {
+	for _, block := range t.chain.FindBlocksByHeight(height) {
+		if block.Status == core.BlockStatusFinalized {
+			return block
+		}
+	}
+	return nil
+}
+
+func encodeProof(proof []common.Bytes) []string {
+	ret := make([]string, len(proof))
+	for i, node := range proof {
+		ret[i] = hex.EncodeToString(node)
+	}
+	return ret
+}
@@ -0,0 +1,88 @@
+package rpc
+
+import (
+	"errors"
+	"net"
+	"sync"
+)
+
+// limitListener is a net.Listener accepting at most limit simultaneous connections.
+// Unlike netutil.LimitListener, the limit can be changed while serving.
+type limitListener struct {
+	net.Listener
+
+	mu     *sync.Mutex
+	cond   *sync.Cond
+	limit  int
+	active int
+	closed bool
+}
+
+func newLimitListener(l net.Listener, limit int) *limitListener {
+	mu := &sync.Mutex{}
+	return &limitListener{
+		Listener: l,
+		mu:       mu,
+		cond:     sync.NewCond(mu),
+		limit:    limit,
+	}
+}
+
+// SetLimit changes the maximum number of simultaneous connections. Connections
+// above a lowered limit are not closed, but no new one is accepted until enough
+// of them are.
+func (l *limitListener) SetLimit(limit int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.limit = limit
+	l.cond.Broadcast()
+}
+
+// Accept waits until a connection is available under the limit and accepts it.
+func (l *limitListener) Accept() (net.Conn, error) {
+	l.mu.Lock()
+	for l.active >= l.limit && !l.closed {
+		l.cond.Wait()
+	}
+	if l.closed {
+		l.mu.Unlock()
+		return nil, errors.New("Listener is closed")
+	}
+	l.active++
+	l.mu.Unlock()
+
+	c, err := l.Listener.Accept()
+	if err != nil {
+		l.release()
+		return nil, err
+	}
+	return &limitListenerConn{Conn: c, release: l.release}, nil
+}
+
+// Close closes the listener, and unblocks the pending Accept.
+func (l *limitListener) Close() error {
+	l.mu.Lock()
+	l.closed = true
+	l.cond.Broadcast()
+	l.mu.Unlock()
+	return l.Listener.Close()
+}
+
+func (l *limitListener) release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.active--
+	l.cond.Broadcast()
+}
+
+type limitListenerConn struct {
+	net.Conn
+	releaseOnce sync.Once
+	release     func()
+}
+
+func (c *limitListenerConn) Close() error {
+	err := c.Conn.Close()
+	c.releaseOnce.Do(c.release)
+	return err
+}
@@ -0,0 +1,42 @@
+package rpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/thetatoken/ukulele/common/metrics"
+)
+
+// maxMeteredBodySize is the size of the requests above which the method is not
+// parsed, and the request is counted as "unknown".
+const maxMeteredBodySize = 1 << 20
+
+// meterRequests wraps the RPC handler to count the requests and measure their
+// latency by method.
+func (t *ThetaRPCServer) meterRequests(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		method := "unknown"
+		if r.ContentLength >= 0 && r.ContentLength <= maxMeteredBodySize {
+			body, err := ioutil.ReadAll(r.Body)
+			if err == nil {
+				var req struct {
+					Method string `json:"method"`
+				}
+				// Unknown methods are grouped, so that the clients do not create metrics
+				if json.Unmarshal(body, &req) == nil && t.handler.HasMethod(req.Method) {
+					method = req.Method
+				}
+			}
+			r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		}
+
+		h.ServeHTTP(w, r)
+
+		metrics.GetOrRegisterMeter("rpc/"+method+"/requests", nil).Mark(1)
+		metrics.GetOrRegisterTimer("rpc/"+method+"/latency", nil).UpdateSince(start)
+	})
+}
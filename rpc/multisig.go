@@ -0,0 +1,246 @@
+package rpc
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/crypto"
+	"github.com/thetatoken/ukulele/ledger/types"
+)
+
+// maxPartiallySignedTxs is the maximum number of partially signed transactions kept by
+// the RPC server. The oldest ones are dropped beyond the limit.
+const maxPartiallySignedTxs = 1024
+
+// ------------------------------- SubmitPartiallySignedTx -----------------------------------
+
+type SubmitPartiallySignedTxArgs struct {
+	TxBytes string `json:"tx_bytes"`
+}
+
+type PartiallySignedTxResult struct {
+	TxID      string                 `json:"tx_id"`    // Identifies the transaction regardless of its signatures
+	TxBytes   string                 `json:"tx_bytes"` // The transaction with all the signatures collected so far
+	Inputs    []PartiallySignedInput `json:"inputs"`
+	Broadcast bool                   `json:"broadcast"`      // Whether the fully signed transaction has been broadcast
+	TxHash    string                 `json:"hash,omitempty"` // Hash of the broadcast transaction
+}
+
+type PartiallySignedInput struct {
+	Address   common.Address    `json:"address"`
+	Signed    common.JSONUint64 `json:"signed"`    // Number of signatures collected
+	Threshold common.JSONUint64 `json:"threshold"` // Number of signatures required
+}
+
+// SubmitPartiallySignedTx collects the signatures of a transaction spending from multisig
+// accounts. The partial signatures of the submission are merged into the ones submitted
+// earlier for the same transaction, and the transaction is broadcast once all its inputs
+// are fully signed.
+func (t *ThetaRPCServer) SubmitPartiallySignedTx(r *http.Request, args *SubmitPartiallySignedTxArgs, result *PartiallySignedTxResult) (err error) {
+	txBytes, err := hex.DecodeString(args.TxBytes)
+	if err != nil {
+		return err
+	}
+	tx, err := types.TxFromBytes(txBytes)
+	if err != nil {
+		return err
+	}
+	inputs := types.SpendingInputs(tx)
+	if len(inputs) == 0 {
+		return errors.New("Transaction type does not support multisig")
+	}
+	signBytes := tx.SignBytes(t.chain.ChainID)
+	for _, in := range inputs {
+		if err := verifyPartialSignature(signBytes, in); err != nil {
+			return err
+		}
+	}
+
+	txID := types.TxID(t.chain.ChainID, tx)
+	tx, err = t.partialTxs.merge(txID, tx)
+	if err != nil {
+		return err
+	}
+
+	complete, err := fillPartiallySignedTxResult(tx, signBytes, txID, result)
+	if err != nil {
+		return err
+	}
+	if !complete {
+		return nil
+	}
+
+	signedTxBytes, err := types.TxToBytes(tx)
+	if err != nil {
+		return err
+	}
+	if err := t.mempool.InsertTransaction(signedTxBytes); err != nil {
+		return newTxRejectedError(err)
+	}
+	t.partialTxs.remove(txID)
+	result.Broadcast = true
+	result.TxHash = crypto.Keccak256Hash(signedTxBytes).Hex()
+
+	logger.Infof("Broadcast multisig transaction %v", result.TxHash)
+
+	return nil
+}
+
+// ------------------------------- GetPartiallySignedTx -----------------------------------
+
+type GetPartiallySignedTxArgs struct {
+	TxID string `json:"tx_id"`
+}
+
+// GetPartiallySignedTx returns a transaction submitted by SubmitPartiallySignedTx with the
+// signatures collected so far, so that the other signers can review and sign it
+func (t *ThetaRPCServer) GetPartiallySignedTx(r *http.Request, args *GetPartiallySignedTxArgs, result *PartiallySignedTxResult) (err error) {
+	txID, err := parseHash(args.TxID)
+	if err != nil {
+		return err
+	}
+	tx, ok, err := t.partialTxs.get(txID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("Partially signed transaction %v not found", args.TxID)
+	}
+	_, err = fillPartiallySignedTxResult(tx, tx.SignBytes(t.chain.ChainID), txID, result)
+	return err
+}
+
+// ------------------------------- Utils -----------------------------------
+
+// verifyPartialSignature checks the signature of an input, if any. Multisig signatures may
+// be below the threshold, but all their partial signatures need to be valid.
+func verifyPartialSignature(signBytes []byte, in *types.TxInput) error {
+	if in.Signature == nil || in.Signature.IsEmpty() {
+		return nil
+	}
+	if ms, ok := types.MultisigSignatureFromSignature(in.Signature); ok {
+		if _, ok := ms.VerifyPartial(signBytes, in.Address); !ok {
+			return fmt.Errorf("Invalid multisig signature for %v", in.Address.Hex())
+		}
+		return nil
+	}
+	if !in.VerifySignature(signBytes) {
+		return fmt.Errorf("Invalid signature for %v", in.Address.Hex())
+	}
+	return nil
+}
+
+// fillPartiallySignedTxResult reports the signatures collected for each input, and returns
+// whether the transaction is fully signed
+func fillPartiallySignedTxResult(tx types.Tx, signBytes []byte, txID common.Hash, result *PartiallySignedTxResult) (bool, error) {
+	txBytes, err := types.TxToBytes(tx)
+	if err != nil {
+		return false, err
+	}
+	result.TxID = txID.Hex()
+	result.TxBytes = hex.EncodeToString(txBytes)
+	result.Inputs = []PartiallySignedInput{}
+
+	complete := true
+	for _, in := range types.SpendingInputs(tx) {
+		input := PartiallySignedInput{Address: in.Address, Threshold: 1}
+		if ms, ok := types.MultisigSignatureFromSignature(in.Signature); ok {
+			input.Signed = common.JSONUint64(ms.NumSigned())
+			input.Threshold = common.JSONUint64(ms.Account.Threshold)
+		} else if in.Signature != nil && !in.Signature.IsEmpty() {
+			input.Signed = 1
+		}
+		result.Inputs = append(result.Inputs, input)
+		complete = complete && in.VerifySignature(signBytes)
+	}
+	return complete, nil
+}
+
+// partiallySignedTxPool keeps the partially signed transactions by their TxIDs
+type partiallySignedTxPool struct {
+	mu    *sync.Mutex
+	txs   map[common.Hash]types.Tx
+	order []common.Hash // In the order of submission, to drop the oldest transactions
+}
+
+func newPartiallySignedTxPool() *partiallySignedTxPool {
+	return &partiallySignedTxPool{
+		mu:  &sync.Mutex{},
+		txs: make(map[common.Hash]types.Tx),
+	}
+}
+
+// merge merges the signatures of the transaction into the pooled one, and returns a
+// copy of the merged transaction
+func (pool *partiallySignedTxPool) merge(txID common.Hash, tx types.Tx) (types.Tx, error) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	pooled, ok := pool.txs[txID]
+	if !ok {
+		if len(pool.order) >= maxPartiallySignedTxs {
+			delete(pool.txs, pool.order[0])
+			pool.order = pool.order[1:]
+		}
+		pool.txs[txID] = tx
+		pool.order = append(pool.order, txID)
+		return copyTx(tx)
+	}
+
+	pooledInputs := types.SpendingInputs(pooled)
+	for i, in := range types.SpendingInputs(tx) {
+		if in.Signature == nil || in.Signature.IsEmpty() {
+			continue
+		}
+		pooledIn := pooledInputs[i]
+		pooledMs, pooledIsMultisig := types.MultisigSignatureFromSignature(pooledIn.Signature)
+		ms, isMultisig := types.MultisigSignatureFromSignature(in.Signature)
+		if pooledIsMultisig && isMultisig && pooledMs.Merge(ms) == nil {
+			pooledIn.Signature = pooledMs.ToSignature()
+		} else if pooledIn.Signature == nil || pooledIn.Signature.IsEmpty() || !pooledIsMultisig {
+			pooledIn.Signature = in.Signature
+		}
+	}
+	return copyTx(pooled)
+}
+
+// get returns a copy of the pooled transaction
+func (pool *partiallySignedTxPool) get(txID common.Hash) (types.Tx, bool, error) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	tx, ok := pool.txs[txID]
+	if !ok {
+		return nil, false, nil
+	}
+	txCopy, err := copyTx(tx)
+	return txCopy, true, err
+}
+
+func (pool *partiallySignedTxPool) remove(txID common.Hash) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	if _, ok := pool.txs[txID]; !ok {
+		return
+	}
+	delete(pool.txs, txID)
+	for i, id := range pool.order {
+		if id == txID {
+			pool.order = append(pool.order[:i], pool.order[i+1:]...)
+			break
+		}
+	}
+}
+
+func copyTx(tx types.Tx) (types.Tx, error) {
+	txBytes, err := types.TxToBytes(tx)
+	if err != nil {
+		return nil, err
+	}
+	return types.TxFromBytes(txBytes)
+}
@@ -0,0 +1,276 @@
+package rpc
+
+import (
+	"encoding"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/thetatoken/ukulele/ledger/types"
+)
+
+// The OpenAPI definitions of the REST gateway, generated from the argument and result
+// structs of the RPC methods, so that clients can be generated in other languages.
+
+const openAPIVersion = "3.0.3"
+
+var (
+	httpRequestType    = reflect.TypeOf((*http.Request)(nil))
+	errorType          = reflect.TypeOf((*error)(nil)).Elem()
+	textMarshalerType  = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	jsonMarshalerType  = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	bigIntType         = reflect.TypeOf(big.Int{})
+	rawJSONMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+// jsonMirrors maps the types marshaling through another struct, by convention the
+// struct of the same name suffixed with JSON, to that struct.
+var jsonMirrors = map[reflect.Type]reflect.Type{
+	reflect.TypeOf(types.Account{}):               reflect.TypeOf(types.AccountJSON{}),
+	reflect.TypeOf(types.BalanceChange{}):         reflect.TypeOf(types.BalanceChangeJSON{}),
+	reflect.TypeOf(types.BalanceJournal{}):        reflect.TypeOf(types.BalanceJournalJSON{}),
+	reflect.TypeOf(types.Coins{}):                 reflect.TypeOf(types.CoinsJSON{}),
+	reflect.TypeOf(types.ProposalChange{}):        reflect.TypeOf(types.ProposalChangeJSON{}),
+	reflect.TypeOf(types.ProposalTally{}):         reflect.TypeOf(types.ProposalTallyJSON{}),
+	reflect.TypeOf(types.Proposal{}):              reflect.TypeOf(types.ProposalJSON{}),
+	reflect.TypeOf(types.Guardianship{}):          reflect.TypeOf(types.GuardianshipJSON{}),
+	reflect.TypeOf(types.LockedCoins{}):           reflect.TypeOf(types.LockedCoinsJSON{}),
+	reflect.TypeOf(types.ChainParameters{}):       reflect.TypeOf(types.ChainParametersJSON{}),
+	reflect.TypeOf(types.ParameterUpdate{}):       reflect.TypeOf(types.ParameterUpdateJSON{}),
+	reflect.TypeOf(types.TxReceipt{}):             reflect.TypeOf(types.TxReceiptJSON{}),
+	reflect.TypeOf(types.ReservedFund{}):          reflect.TypeOf(types.ReservedFundJSON{}),
+	reflect.TypeOf(types.SlashIntent{}):           reflect.TypeOf(types.SlashIntentJSON{}),
+	reflect.TypeOf(types.OverspendingProof{}):     reflect.TypeOf(types.OverspendingProofJSON{}),
+	reflect.TypeOf(types.JailedValidator{}):       reflect.TypeOf(types.JailedValidatorJSON{}),
+	reflect.TypeOf(types.ValidatorDowntime{}):     reflect.TypeOf(types.ValidatorDowntimeJSON{}),
+	reflect.TypeOf(types.SplitRule{}):             reflect.TypeOf(types.SplitRuleJSON{}),
+	reflect.TypeOf(types.Stake{}):                 reflect.TypeOf(types.StakeJSON{}),
+	reflect.TypeOf(types.Token{}):                 reflect.TypeOf(types.TokenJSON{}),
+	reflect.TypeOf(types.TokenCoin{}):             reflect.TypeOf(types.TokenCoinJSON{}),
+	reflect.TypeOf(types.TxInput{}):               reflect.TypeOf(types.TxInputJSON{}),
+	reflect.TypeOf(types.CoinbaseTx{}):            reflect.TypeOf(types.CoinbaseTxJSON{}),
+	reflect.TypeOf(types.SlashTx{}):               reflect.TypeOf(types.SlashTxJSON{}),
+	reflect.TypeOf(types.TimelockedSendTx{}):      reflect.TypeOf(types.TimelockedSendTxJSON{}),
+	reflect.TypeOf(types.ReserveFundTx{}):         reflect.TypeOf(types.ReserveFundTxJSON{}),
+	reflect.TypeOf(types.ReleaseFundTx{}):         reflect.TypeOf(types.ReleaseFundTxJSON{}),
+	reflect.TypeOf(types.ExtendReserveTx{}):       reflect.TypeOf(types.ExtendReserveTxJSON{}),
+	reflect.TypeOf(types.ServicePaymentTx{}):      reflect.TypeOf(types.ServicePaymentTxJSON{}),
+	reflect.TypeOf(types.BatchServicePaymentTx{}): reflect.TypeOf(types.BatchServicePaymentTxJSON{}),
+	reflect.TypeOf(types.SplitRuleTx{}):           reflect.TypeOf(types.SplitRuleTxJSON{}),
+	reflect.TypeOf(types.SmartContractTx{}):       reflect.TypeOf(types.SmartContractTxJSON{}),
+	reflect.TypeOf(types.DepositStakeTx{}):        reflect.TypeOf(types.DepositStakeTxJSON{}),
+	reflect.TypeOf(types.WithdrawStakeTx{}):       reflect.TypeOf(types.WithdrawStakeTxJSON{}),
+	reflect.TypeOf(types.SetGuardiansTx{}):        reflect.TypeOf(types.SetGuardiansTxJSON{}),
+	reflect.TypeOf(types.RecoveryTx{}):            reflect.TypeOf(types.RecoveryTxJSON{}),
+	reflect.TypeOf(types.CreateTokenTx{}):         reflect.TypeOf(types.CreateTokenTxJSON{}),
+	reflect.TypeOf(types.ParameterUpdateTx{}):     reflect.TypeOf(types.ParameterUpdateTxJSON{}),
+	reflect.TypeOf(types.ProposalTx{}):            reflect.TypeOf(types.ProposalTxJSON{}),
+	reflect.TypeOf(types.VoteTx{}):                reflect.TypeOf(types.VoteTxJSON{}),
+	reflect.TypeOf(types.UnjailTx{}):              reflect.TypeOf(types.UnjailTxJSON{}),
+}
+
+// rpcMethod is a method of an RPC service, with the signature required by the
+// gorilla RPC server: func (*http.Request, *Args, *Result) error.
+type rpcMethod struct {
+	Name       string
+	Method     reflect.Method
+	ArgsType   reflect.Type // Pointed by the argument
+	ResultType reflect.Type // Pointed by the argument
+}
+
+// rpcMethods returns the RPC methods of the service type, ordered by name.
+func rpcMethods(serviceType reflect.Type) []rpcMethod {
+	methods := []rpcMethod{}
+	for i := 0; i < serviceType.NumMethod(); i++ {
+		method := serviceType.Method(i)
+		mtype := method.Type
+		if method.PkgPath != "" || mtype.NumIn() != 4 || mtype.NumOut() != 1 ||
+			mtype.In(1) != httpRequestType || mtype.Out(0) != errorType ||
+			mtype.In(2).Kind() != reflect.Ptr || mtype.In(3).Kind() != reflect.Ptr {
+			continue
+		}
+		methods = append(methods, rpcMethod{
+			Name:       method.Name,
+			Method:     method,
+			ArgsType:   mtype.In(2).Elem(),
+			ResultType: mtype.In(3).Elem(),
+		})
+	}
+	sort.Slice(methods, func(i, j int) bool {
+		return methods[i].Name < methods[j].Name
+	})
+	return methods
+}
+
+// OpenAPISpec returns the OpenAPI definitions of the REST gateway: an operation
+// POST <prefix><Method> for each method of the theta namespace.
+func OpenAPISpec(prefix string) map[string]interface{} {
+	g := &openAPIGenerator{schemas: make(map[string]interface{})}
+	errorSchema := g.schemaOf(reflect.TypeOf(RESTError{}))
+
+	paths := make(map[string]interface{})
+	for _, method := range rpcMethods(reflect.TypeOf(&ThetaRPCServer{})) {
+		paths[prefix+method.Name] = map[string]interface{}{
+			"post": map[string]interface{}{
+				"operationId": method.Name,
+				"tags":        []string{"theta"},
+				"requestBody": map[string]interface{}{
+					"content": map[string]interface{}{
+						"application/json": map[string]interface{}{"schema": g.schemaOf(method.ArgsType)},
+					},
+				},
+				"responses": map[string]interface{}{
+					"200": map[string]interface{}{
+						"description": "The result of theta." + method.Name,
+						"content": map[string]interface{}{
+							"application/json": map[string]interface{}{"schema": g.schemaOf(method.ResultType)},
+						},
+					},
+					"400": map[string]interface{}{
+						"description": "The error of theta." + method.Name,
+						"content": map[string]interface{}{
+							"application/json": map[string]interface{}{"schema": errorSchema},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	return map[string]interface{}{
+		"openapi": openAPIVersion,
+		"info": map[string]interface{}{
+			"title":   "Theta RPC API",
+			"version": "v1",
+		},
+		"paths":      paths,
+		"components": map[string]interface{}{"schemas": g.schemas},
+	}
+}
+
+type openAPIGenerator struct {
+	schemas map[string]interface{} // By name
+}
+
+// schemaOf returns the schema of the JSON encoding of the type, referring to the
+// components for the structs.
+func (g *openAPIGenerator) schemaOf(t reflect.Type) map[string]interface{} {
+	for {
+		if t.Implements(textMarshalerType) || reflect.PtrTo(t).Implements(textMarshalerType) {
+			return map[string]interface{}{"type": "string"}
+		}
+		if mirror, ok := jsonMirrors[t]; ok {
+			return g.ref(t, mirror)
+		}
+		if t.Kind() != reflect.Ptr {
+			break
+		}
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t == rawJSONMessageType {
+			return map[string]interface{}{}
+		}
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "format": "byte"}
+		}
+		return map[string]interface{}{"type": "array", "items": g.schemaOf(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": g.schemaOf(t.Elem())}
+	case reflect.Struct:
+		if t == bigIntType {
+			return map[string]interface{}{"type": "integer"}
+		}
+		if t.Implements(jsonMarshalerType) || reflect.PtrTo(t).Implements(jsonMarshalerType) {
+			// The encoding of an embedded struct is promoted, e.g. GetAccountResult
+			// encodes as its Account
+			for i := 0; i < t.NumField(); i++ {
+				field := t.Field(i)
+				if field.Anonymous && (field.Type.Implements(jsonMarshalerType) || reflect.PtrTo(field.Type).Implements(jsonMarshalerType)) {
+					return g.schemaOf(field.Type)
+				}
+			}
+			// Custom encoding without a mirror struct
+			return map[string]interface{}{}
+		}
+		return g.ref(t, t)
+	default:
+		// Interfaces, e.g. the transactions, can hold any value
+		return map[string]interface{}{}
+	}
+}
+
+// ref returns a reference to the schema of the struct t, encoded as the struct s.
+func (g *openAPIGenerator) ref(t reflect.Type, s reflect.Type) map[string]interface{} {
+	name := path.Base(t.PkgPath()) + "." + t.Name()
+	ref := map[string]interface{}{"$ref": "#/components/schemas/" + name}
+	if _, ok := g.schemas[name]; ok {
+		return ref
+	}
+	// Registered before the properties, for the recursive structs
+	schema := map[string]interface{}{"type": "object"}
+	g.schemas[name] = schema
+
+	properties := make(map[string]interface{})
+	g.addProperties(properties, s)
+	schema["properties"] = properties
+	return ref
+}
+
+// addProperties adds the JSON properties of the fields of the struct, including the
+// fields of the embedded structs.
+func (g *openAPIGenerator) addProperties(properties map[string]interface{}, s reflect.Type) {
+	for i := 0; i < s.NumField(); i++ {
+		field := s.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options := tag, ""
+		if idx := strings.Index(tag, ","); idx >= 0 {
+			name, options = tag[:idx], tag[idx+1:]
+		}
+
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if field.Anonymous && name == "" && fieldType.Kind() == reflect.Struct && jsonMirrors[fieldType] == nil &&
+			!fieldType.Implements(jsonMarshalerType) && !reflect.PtrTo(fieldType).Implements(jsonMarshalerType) {
+			g.addProperties(properties, fieldType)
+			continue
+		}
+		if !isExportedName(field.Name) {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if strings.Contains(options, "string") {
+			properties[name] = map[string]interface{}{"type": "string"}
+		} else {
+			properties[name] = g.schemaOf(field.Type)
+		}
+	}
+}
+
+func isExportedName(name string) bool {
+	return name != "" && unicode.IsUpper([]rune(name)[0])
+}
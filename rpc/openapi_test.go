@@ -0,0 +1,36 @@
+package rpc
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenAPISpec(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	spec := OpenAPISpec(restPathPrefix)
+	_, err := json.Marshal(spec)
+	require.Nil(err)
+
+	paths := spec["paths"].(map[string]interface{})
+	require.Contains(paths, "/api/v1/GetAccount")
+	require.Contains(paths, "/api/v1/BroadcastRawTransaction")
+	assert.NotContains(paths, "/api/v1/Start")
+
+	schemas := spec["components"].(map[string]interface{})["schemas"].(map[string]interface{})
+	args := schemas["rpc.GetAccountArgs"].(map[string]interface{})["properties"].(map[string]interface{})
+	assert.Equal(map[string]interface{}{"type": "string"}, args["address"])
+	assert.Equal(map[string]interface{}{"type": "string"}, args["height"]) // JSONUint64 encodes as a string
+
+	// GetAccountResult encodes as its embedded Account, which encodes as AccountJSON
+	post := paths["/api/v1/GetAccount"].(map[string]interface{})["post"].(map[string]interface{})
+	response := post["responses"].(map[string]interface{})["200"].(map[string]interface{})
+	schema := response["content"].(map[string]interface{})["application/json"].(map[string]interface{})["schema"]
+	assert.Equal(map[string]interface{}{"$ref": "#/components/schemas/types.Account"}, schema)
+	account := schemas["types.Account"].(map[string]interface{})["properties"].(map[string]interface{})
+	assert.Equal(map[string]interface{}{"$ref": "#/components/schemas/types.Coins"}, account["coins"])
+}
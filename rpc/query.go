@@ -1,23 +1,39 @@
 package rpc
 
 import (
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"math/big"
 	"net/http"
+	"sort"
 	"time"
 
 	"github.com/thetatoken/ukulele/common"
 	"github.com/thetatoken/ukulele/core"
 	"github.com/thetatoken/ukulele/crypto"
+	"github.com/thetatoken/ukulele/ledger/state"
 	"github.com/thetatoken/ukulele/ledger/types"
+	"github.com/thetatoken/ukulele/rlp"
+	"github.com/thetatoken/ukulele/version"
 )
 
 // ------------------------------- GetAccount -----------------------------------
 
+// Ledger states an account can be queried at
+const (
+	LedgerStatePending   = "pending"   // Latest block plus the transactions screened by the mempool since, the default
+	LedgerStateLatest    = "latest"    // Latest block
+	LedgerStateFinalized = "finalized" // Latest finalized block
+)
+
 type GetAccountArgs struct {
 	Name    string `json:"name"`
 	Address string `json:"address"`
+	State   string `json:"state"` // Ledger state to query, LedgerStatePending if empty
+
+	Height common.JSONUint64 `json:"height"` // Height of a finalized block to query the account at instead, if set
 }
 
 type GetAccountResult struct {
@@ -29,10 +45,18 @@ func (t *ThetaRPCServer) GetAccount(r *http.Request, args *GetAccountArgs, resul
 	if args.Address == "" {
 		return errors.New("Address must be specified")
 	}
-	address := common.HexToAddress(args.Address)
-	result.Address = args.Address
+	address, err := parseAddress(args.Address)
+	if err != nil {
+		return err
+	}
+	result.Address = address.Hex()
 
-	ledgerState, err := t.ledger.GetScreenedSnapshot()
+	var ledgerState *state.StoreView
+	if args.Height != 0 {
+		ledgerState, _, err = t.getFinalizedState(uint64(args.Height))
+	} else {
+		ledgerState, err = t.getLedgerSnapshot(args.State)
+	}
 	if err != nil {
 		return err
 	}
@@ -40,10 +64,28 @@ func (t *ThetaRPCServer) GetAccount(r *http.Request, args *GetAccountArgs, resul
 	if account == nil {
 		return fmt.Errorf("Account with address %s is not found", address.Hex())
 	}
+	// Unlock the coins, etc. as the next transaction of the account would
+	account.UpdateToHeight(ledgerState.Height())
 	result.Account = account
 	return nil
 }
 
+// getLedgerSnapshot returns a snapshot of the ledger at the given state. The pending
+// state lets wallets chain transactions without waiting for them to be included.
+func (t *ThetaRPCServer) getLedgerSnapshot(name string) (*state.StoreView, error) {
+	switch name {
+	case "", LedgerStatePending:
+		return t.ledger.GetScreenedSnapshot()
+	case LedgerStateLatest:
+		return t.ledger.GetDeliveredSnapshot()
+	case LedgerStateFinalized:
+		return t.ledger.GetFinalizedSnapshot()
+	default:
+		return nil, fmt.Errorf("Invalid ledger state %v, expected %v, %v or %v",
+			name, LedgerStatePending, LedgerStateLatest, LedgerStateFinalized)
+	}
+}
+
 // ------------------------------- GetSplitRule -----------------------------------
 
 type GetSplitRuleArgs struct {
@@ -67,6 +109,234 @@ func (t *ThetaRPCServer) GetSplitRule(r *http.Request, args *GetSplitRuleArgs, r
 	return nil
 }
 
+// ------------------------------- GetTokens -----------------------------------
+
+type GetTokensArgs struct {
+	Symbol string `json:"symbol"` // Symbol of the token, all the tokens if empty
+}
+
+type GetTokensResult struct {
+	Tokens []*types.Token `json:"tokens"`
+}
+
+func (t *ThetaRPCServer) GetTokens(r *http.Request, args *GetTokensArgs, result *GetTokensResult) (err error) {
+	ledgerState, err := t.ledger.GetDeliveredSnapshot()
+	if err != nil {
+		return err
+	}
+	if args.Symbol == "" {
+		result.Tokens = ledgerState.GetTokens()
+		return nil
+	}
+	token := ledgerState.GetToken(args.Symbol)
+	if token == nil {
+		return fmt.Errorf("Token %s is not found", args.Symbol)
+	}
+	result.Tokens = []*types.Token{token}
+	return nil
+}
+
+// ------------------------------- GetChainParameters -----------------------------------
+
+type GetChainParametersArgs struct{}
+
+type GetChainParametersResult struct {
+	Parameters types.ChainParameters    `json:"parameters"` // Parameters in effect
+	Updates    []*types.ParameterUpdate `json:"updates"`    // Updates scheduled at future heights
+}
+
+func (t *ThetaRPCServer) GetChainParameters(r *http.Request, args *GetChainParametersArgs, result *GetChainParametersResult) (err error) {
+	ledgerState, err := t.ledger.GetDeliveredSnapshot()
+	if err != nil {
+		return err
+	}
+	result.Parameters = ledgerState.GetChainParameters()
+	result.Updates = []*types.ParameterUpdate{}
+	for _, update := range ledgerState.GetParameterUpdates() {
+		if update.Height > ledgerState.Height() {
+			result.Updates = append(result.Updates, update)
+		}
+	}
+	return nil
+}
+
+// ------------------------------- GetProposals -----------------------------------
+
+type GetProposalsArgs struct {
+	ID  common.JSONUint64 `json:"id"`  // ID of the proposal, the active proposals if not set
+	All bool              `json:"all"` // Also return the tallied proposals if the ID is not set
+}
+
+type GetProposalsResult struct {
+	Proposals []*types.Proposal `json:"proposals"`
+}
+
+// GetProposals returns the governance proposals. The tally of an active proposal
+// counts its votes so far, weighted by the current stakes.
+func (t *ThetaRPCServer) GetProposals(r *http.Request, args *GetProposalsArgs, result *GetProposalsResult) (err error) {
+	ledgerState, err := t.ledger.GetDeliveredSnapshot()
+	if err != nil {
+		return err
+	}
+
+	var proposals []*types.Proposal
+	if args.ID != 0 {
+		proposal := ledgerState.GetProposal(uint64(args.ID))
+		if proposal == nil {
+			return fmt.Errorf("Proposal %v is not found", args.ID)
+		}
+		proposals = []*types.Proposal{proposal}
+	} else {
+		proposals = ledgerState.GetProposals()
+	}
+
+	result.Proposals = []*types.Proposal{}
+	for _, proposal := range proposals {
+		if proposal.Status == types.ProposalActive {
+			proposal.Tally = ledgerState.TallyProposal(proposal.ID)
+		} else if args.ID == 0 && !args.All {
+			continue
+		}
+		result.Proposals = append(result.Proposals, proposal)
+	}
+	return nil
+}
+
+// ------------------------------- GetValidatorDowntimes -----------------------------------
+
+type GetValidatorDowntimesArgs struct{}
+
+type GetValidatorDowntimesResult struct {
+	Epoch     common.JSONUint64          `json:"epoch"`     // Epoch of the last block
+	Downtimes []*types.ValidatorDowntime `json:"downtimes"` // Proposals missed within the window
+	Jailed    []*types.JailedValidator   `json:"jailed"`    // Validators jailed for downtime, until they unjail
+}
+
+// GetValidatorDowntimes returns the proposals missed by the validators, and the
+// validators jailed for missing too many of them.
+func (t *ThetaRPCServer) GetValidatorDowntimes(r *http.Request, args *GetValidatorDowntimesArgs, result *GetValidatorDowntimesResult) (err error) {
+	ledgerState, err := t.ledger.GetDeliveredSnapshot()
+	if err != nil {
+		return err
+	}
+
+	epoch, _ := ledgerState.GetLastBlockEpoch()
+	result.Epoch = common.JSONUint64(epoch)
+	result.Downtimes = []*types.ValidatorDowntime{}
+	for _, downtime := range ledgerState.GetValidatorDowntimes() {
+		if downtime.MissedInWindow(epoch) > 0 {
+			result.Downtimes = append(result.Downtimes, downtime)
+		}
+	}
+	result.Jailed = []*types.JailedValidator{}
+	for _, jailedValidator := range ledgerState.GetJailedValidators() {
+		if jailedValidator.IsJailedUntilUnjailed() {
+			result.Jailed = append(result.Jailed, jailedValidator)
+		}
+	}
+	return nil
+}
+
+// ------------------------------- GetStakes -----------------------------------
+
+type GetStakesArgs struct {
+	Holder string `json:"holder"`
+	Source string `json:"source"`
+}
+
+type GetStakesResult struct {
+	StakeHolders []*types.StakeHolder `json:"stake_holders"`
+}
+
+func (t *ThetaRPCServer) GetStakes(r *http.Request, args *GetStakesArgs, result *GetStakesResult) (err error) {
+	ledgerState, err := t.ledger.GetDeliveredSnapshot()
+	if err != nil {
+		return err
+	}
+
+	var holder, source common.Address
+	if args.Holder != "" {
+		if holder, err = parseAddress(args.Holder); err != nil {
+			return err
+		}
+	}
+	if args.Source != "" {
+		if source, err = parseAddress(args.Source); err != nil {
+			return err
+		}
+	}
+
+	var stakeHolders []*types.StakeHolder
+	if args.Holder != "" {
+		stakeHolder := ledgerState.GetStakeHolder(holder)
+		if stakeHolder != nil {
+			stakeHolders = append(stakeHolders, stakeHolder)
+		}
+	} else {
+		stakeHolders = ledgerState.GetStakeHolders()
+	}
+
+	result.StakeHolders = []*types.StakeHolder{}
+	for _, stakeHolder := range stakeHolders {
+		if args.Source != "" {
+			stakes := []*types.Stake{}
+			for _, stake := range stakeHolder.Stakes {
+				if stake.Source == source {
+					stakes = append(stakes, stake)
+				}
+			}
+			if len(stakes) == 0 {
+				continue
+			}
+			stakeHolder.Stakes = stakes
+		}
+		result.StakeHolders = append(result.StakeHolders, stakeHolder)
+	}
+	return nil
+}
+
+// ------------------------------- GetValidators -----------------------------------
+
+type GetValidatorsArgs struct {
+	Epoch *common.JSONUint64 `json:"epoch,omitempty"` // The current epoch by default
+}
+
+type ValidatorInfo struct {
+	Address common.Address    `json:"address"`
+	Stake   common.JSONUint64 `json:"stake"`
+}
+
+type GetValidatorsResult struct {
+	Epoch        common.JSONUint64 `json:"epoch"`
+	Validators   []ValidatorInfo   `json:"validators"`
+	Hash         common.Hash       `json:"hash"`          // Hash committed by the block headers when the validator set changes
+	ValidatorSet string            `json:"validator_set"` // Hex encoded RLP of the validator set, for the light clients
+}
+
+func (t *ThetaRPCServer) GetValidators(r *http.Request, args *GetValidatorsArgs, result *GetValidatorsResult) (err error) {
+	epoch := t.consensus.GetEpoch()
+	if args.Epoch != nil {
+		epoch = uint64(*args.Epoch)
+	}
+	validatorSet := t.consensus.GetValidatorManager().GetValidatorSetForEpoch(epoch)
+	raw, err := rlp.EncodeToBytes(validatorSet)
+	if err != nil {
+		return err
+	}
+
+	result.Epoch = common.JSONUint64(epoch)
+	result.Hash = validatorSet.Hash()
+	result.ValidatorSet = hex.EncodeToString(raw)
+	result.Validators = []ValidatorInfo{}
+	for _, v := range validatorSet.Validators() {
+		result.Validators = append(result.Validators, ValidatorInfo{
+			Address: v.Address(),
+			Stake:   common.JSONUint64(v.Stake()),
+		})
+	}
+	return nil
+}
+
 // ------------------------------ GetTransaction -----------------------------------
 
 type GetTransactionArgs struct {
@@ -78,7 +348,32 @@ type GetTransactionResult struct {
 	BlockHeight common.JSONUint64 `json:"block_height"`
 	Status      TxStatus          `json:"status"`
 	TxHash      common.Hash       `json:"hash"`
+	Type        byte              `json:"type"`
 	Tx          types.Tx          `json:"transaction"`
+	Receipt     *types.TxReceipt  `json:"receipt,omitempty"`
+}
+
+// UnmarshalJSON decodes the transaction according to its type, so that the clients
+// can decode the result.
+func (r *GetTransactionResult) UnmarshalJSON(data []byte) error {
+	type getTransactionResult GetTransactionResult
+	var aux struct {
+		*getTransactionResult
+		Tx json.RawMessage `json:"transaction"`
+	}
+	aux.getTransactionResult = (*getTransactionResult)(r)
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	if r.Status == TxStatusNotFound {
+		return nil
+	}
+	tx, err := decodeTx(r.Type, aux.Tx)
+	if err != nil {
+		return err
+	}
+	r.Tx = tx
+	return nil
 }
 
 type TxStatus string
@@ -93,7 +388,10 @@ func (t *ThetaRPCServer) GetTransaction(r *http.Request, args *GetTransactionArg
 	if args.Hash == "" {
 		return errors.New("Transanction hash must be specified")
 	}
-	hash := common.HexToHash(args.Hash)
+	hash, err := parseHash(args.Hash)
+	if err != nil {
+		return err
+	}
 	raw, block, found := t.chain.FindTxByHash(hash)
 	if !found {
 		result.Status = TxStatusNotFound
@@ -113,8 +411,13 @@ func (t *ThetaRPCServer) GetTransaction(r *http.Request, args *GetTransactionArg
 	if err != nil {
 		return err
 	}
+	result.Type = getTxType(tx)
 	result.Tx = tx
 
+	if receipt, ok := t.ledger.GetTxReceipt(hash); ok {
+		result.Receipt = receipt
+	}
+
 	return nil
 }
 
@@ -144,6 +447,8 @@ type GetBlockResultInner struct {
 	Timestamp *common.JSONBig   `json:"timestamp"`
 	Proposer  common.Address    `json:"proposer"`
 
+	ValidatorSetHash *common.Hash `json:"validator_set_hash,omitempty"` // Present when the validator set changes after the block
+
 	Children []common.Hash    `json:"children"`
 	Status   core.BlockStatus `json:"status"`
 
@@ -163,8 +468,152 @@ const (
 	TxTypeServicePayment
 	TxTypeSplitRule
 	TxUpdateValidators
+	TxTypeSmartContract
+	TxTypeDepositStake
+	TxTypeWithdrawStake
+	TxTypeExtendReserve
+	TxTypeBatchServicePayment
+	TxTypeSetGuardians
+	TxTypeRecovery
+	TxTypeTimelockedSend
+	TxTypeCreateToken
+	TxTypeParameterUpdate
+	TxTypeProposal
+	TxTypeVote
+	TxTypeUnjail
 )
 
+// getTxType returns the TxType of the transaction, 0 if unknown.
+func getTxType(tx types.Tx) byte {
+	switch tx.(type) {
+	case *types.CoinbaseTx:
+		return TxTypeCoinbase
+	case *types.SlashTx:
+		return TxTypeSlash
+	case *types.SendTx:
+		return TxTypeSend
+	case *types.ReserveFundTx:
+		return TxTypeReserveFund
+	case *types.ReleaseFundTx:
+		return TxTypeReleaseFund
+	case *types.ServicePaymentTx:
+		return TxTypeServicePayment
+	case *types.SplitRuleTx:
+		return TxTypeSplitRule
+	case *types.UpdateValidatorsTx:
+		return TxUpdateValidators
+	case *types.SmartContractTx:
+		return TxTypeSmartContract
+	case *types.DepositStakeTx:
+		return TxTypeDepositStake
+	case *types.WithdrawStakeTx:
+		return TxTypeWithdrawStake
+	case *types.ExtendReserveTx:
+		return TxTypeExtendReserve
+	case *types.BatchServicePaymentTx:
+		return TxTypeBatchServicePayment
+	case *types.SetGuardiansTx:
+		return TxTypeSetGuardians
+	case *types.RecoveryTx:
+		return TxTypeRecovery
+	case *types.TimelockedSendTx:
+		return TxTypeTimelockedSend
+	case *types.CreateTokenTx:
+		return TxTypeCreateToken
+	case *types.ParameterUpdateTx:
+		return TxTypeParameterUpdate
+	case *types.ProposalTx:
+		return TxTypeProposal
+	case *types.VoteTx:
+		return TxTypeVote
+	case *types.UnjailTx:
+		return TxTypeUnjail
+	}
+	return 0
+}
+
+// newTxOfType returns an empty transaction of the TxType, nil if unknown.
+func newTxOfType(t byte) types.Tx {
+	switch t {
+	case TxTypeCoinbase:
+		return &types.CoinbaseTx{}
+	case TxTypeSlash:
+		return &types.SlashTx{}
+	case TxTypeSend:
+		return &types.SendTx{}
+	case TxTypeReserveFund:
+		return &types.ReserveFundTx{}
+	case TxTypeReleaseFund:
+		return &types.ReleaseFundTx{}
+	case TxTypeServicePayment:
+		return &types.ServicePaymentTx{}
+	case TxTypeSplitRule:
+		return &types.SplitRuleTx{}
+	case TxUpdateValidators:
+		return &types.UpdateValidatorsTx{}
+	case TxTypeSmartContract:
+		return &types.SmartContractTx{}
+	case TxTypeDepositStake:
+		return &types.DepositStakeTx{}
+	case TxTypeWithdrawStake:
+		return &types.WithdrawStakeTx{}
+	case TxTypeExtendReserve:
+		return &types.ExtendReserveTx{}
+	case TxTypeBatchServicePayment:
+		return &types.BatchServicePaymentTx{}
+	case TxTypeSetGuardians:
+		return &types.SetGuardiansTx{}
+	case TxTypeRecovery:
+		return &types.RecoveryTx{}
+	case TxTypeTimelockedSend:
+		return &types.TimelockedSendTx{}
+	case TxTypeCreateToken:
+		return &types.CreateTokenTx{}
+	case TxTypeParameterUpdate:
+		return &types.ParameterUpdateTx{}
+	case TxTypeProposal:
+		return &types.ProposalTx{}
+	case TxTypeVote:
+		return &types.VoteTx{}
+	case TxTypeUnjail:
+		return &types.UnjailTx{}
+	}
+	return nil
+}
+
+// decodeTx decodes the JSON of a transaction of the TxType.
+func decodeTx(t byte, data []byte) (types.Tx, error) {
+	tx := newTxOfType(t)
+	if tx == nil {
+		return nil, fmt.Errorf("Unknown transaction type %v", t)
+	}
+	if err := json.Unmarshal(data, tx); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// UnmarshalJSON decodes the transaction according to its type, so that the clients
+// can decode the blocks.
+func (t *Tx) UnmarshalJSON(data []byte) error {
+	var aux struct {
+		Raw  json.RawMessage `json:"raw"`
+		Type byte            `json:"type"`
+		Hash common.Hash     `json:"hash"`
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	tx, err := decodeTx(aux.Type, aux.Raw)
+	if err != nil {
+		return err
+	}
+	t.Tx = tx
+	t.Type = aux.Type
+	t.Hash = aux.Hash
+	return nil
+}
+
 func (t *ThetaRPCServer) GetBlock(r *http.Request, args *GetBlockArgs, result *GetBlockResult) (err error) {
 	if args.Hash.IsEmpty() {
 		return errors.New("Block hash must be specified")
@@ -184,6 +633,9 @@ func (t *ThetaRPCServer) GetBlock(r *http.Request, args *GetBlockArgs, result *G
 	result.StateHash = block.StateHash
 	result.Timestamp = (*common.JSONBig)(block.Timestamp)
 	result.Proposer = block.Proposer
+	if hash := block.GetValidatorSetHash(); !hash.IsEmpty() {
+		result.ValidatorSetHash = &hash
+	}
 	result.Children = block.Children
 	result.Status = block.Status
 
@@ -198,29 +650,10 @@ func (t *ThetaRPCServer) GetBlock(r *http.Request, args *GetBlockArgs, result *G
 		}
 		hash := crypto.Keccak256Hash(txBytes)
 
-		t := byte(0x0)
-		switch tx.(type) {
-		case *types.CoinbaseTx:
-			t = TxTypeCoinbase
-		case *types.SlashTx:
-			t = TxTypeSlash
-		case *types.SendTx:
-			t = TxTypeSend
-		case *types.ReserveFundTx:
-			t = TxTypeReserveFund
-		case *types.ReleaseFundTx:
-			t = TxTypeReleaseFund
-		case *types.ServicePaymentTx:
-			t = TxTypeServicePayment
-		case *types.SplitRuleTx:
-			t = TxTypeSplitRule
-		case *types.UpdateValidatorsTx:
-			t = TxUpdateValidators
-		}
 		txw := Tx{
 			Tx:   tx,
 			Hash: hash,
-			Type: t,
+			Type: getTxType(tx),
 		}
 		result.Txs = append(result.Txs, txw)
 	}
@@ -261,6 +694,9 @@ func (t *ThetaRPCServer) GetBlockByHeight(r *http.Request, args *GetBlockByHeigh
 	result.StateHash = block.StateHash
 	result.Timestamp = (*common.JSONBig)(block.Timestamp)
 	result.Proposer = block.Proposer
+	if hash := block.GetValidatorSetHash(); !hash.IsEmpty() {
+		result.ValidatorSetHash = &hash
+	}
 	result.Children = block.Children
 	result.Status = block.Status
 
@@ -275,29 +711,10 @@ func (t *ThetaRPCServer) GetBlockByHeight(r *http.Request, args *GetBlockByHeigh
 		}
 		hash := crypto.Keccak256Hash(txBytes)
 
-		t := byte(0x0)
-		switch tx.(type) {
-		case *types.CoinbaseTx:
-			t = TxTypeCoinbase
-		case *types.SlashTx:
-			t = TxTypeSlash
-		case *types.SendTx:
-			t = TxTypeSend
-		case *types.ReserveFundTx:
-			t = TxTypeReserveFund
-		case *types.ReleaseFundTx:
-			t = TxTypeReleaseFund
-		case *types.ServicePaymentTx:
-			t = TxTypeServicePayment
-		case *types.SplitRuleTx:
-			t = TxTypeSplitRule
-		case *types.UpdateValidatorsTx:
-			t = TxUpdateValidators
-		}
 		txw := Tx{
 			Tx:   tx,
 			Hash: hash,
-			Type: t,
+			Type: getTxType(tx),
 		}
 		result.Txs = append(result.Txs, txw)
 	}
@@ -315,6 +732,7 @@ type GetStatusResult struct {
 	LatestFinalizedBlockEpoch  common.JSONUint64 `json:"latest_finalized_block_epoch"`
 	CurrentEpoch               common.JSONUint64 `json:"current_epoch"`
 	CurrentTime                *common.JSONBig   `json:"current_time"`
+	DryRun                     bool              `json:"dry_run"`
 }
 
 func (t *ThetaRPCServer) GetStatus(r *http.Request, args *GetStatusArgs, result *GetStatusResult) (err error) {
@@ -332,5 +750,118 @@ func (t *ThetaRPCServer) GetStatus(r *http.Request, args *GetStatusArgs, result
 	}
 	result.CurrentEpoch = common.JSONUint64(s.Epoch)
 	result.CurrentTime = (*common.JSONBig)(big.NewInt(time.Now().Unix()))
+	result.DryRun = t.consensus.IsDryRun()
+	return
+}
+
+// ------------------------------ GetVersion -----------------------------------
+
+type GetVersionArgs struct{}
+
+type GetVersionResult struct {
+	Version         string            `json:"version"`
+	GitCommit       string            `json:"git_commit"`
+	ProtocolVersion common.JSONUint64 `json:"protocol_version"`
+}
+
+// GetVersion returns the version of the node, so that the clients can check that it
+// is up to date.
+func (t *ThetaRPCServer) GetVersion(r *http.Request, args *GetVersionArgs, result *GetVersionResult) (err error) {
+	result.Version = version.Version
+	result.GitCommit = version.Commit()
+	result.ProtocolVersion = common.JSONUint64(version.ProtocolVersion)
+	return
+}
+
+// ------------------------------ GetSyncStatus -----------------------------------
+
+type GetSyncStatusArgs struct{}
+
+type PeerSyncStatus struct {
+	PeerID          string            `json:"peer_id"`
+	Height          common.JSONUint64 `json:"height"`
+	FinalizedHeight common.JSONUint64 `json:"finalized_height"`
+	TipHash         common.Hash       `json:"tip_hash"`
+}
+
+type GetSyncStatusResult struct {
+	Height                 common.JSONUint64 `json:"height"`
+	FinalizedHeight        common.JSONUint64 `json:"finalized_height"`
+	TipHash                common.Hash       `json:"tip_hash"`
+	NetworkHeight          common.JSONUint64 `json:"network_height"`
+	NetworkFinalizedHeight common.JSONUint64 `json:"network_finalized_height"`
+	Behind                 bool              `json:"behind"`
+	Peers                  []PeerSyncStatus  `json:"peers"`
+}
+
+// GetSyncStatus compares the chain of the node with the chains its peers reported
+// recently. The network heights are the medians of the heights of the peers.
+func (t *ThetaRPCServer) GetSyncStatus(r *http.Request, args *GetSyncStatusArgs, result *GetSyncStatusResult) (err error) {
+	s := t.syncMgr.GetSyncStatus()
+	result.Height = common.JSONUint64(s.Height)
+	result.FinalizedHeight = common.JSONUint64(s.FinalizedHeight)
+	result.TipHash = s.TipHash
+	result.NetworkHeight = common.JSONUint64(s.NetworkHeight)
+	result.NetworkFinalizedHeight = common.JSONUint64(s.NetworkFinalizedHeight)
+	result.Behind = s.Behind
+	result.Peers = []PeerSyncStatus{}
+	for peerID, status := range s.Peers {
+		result.Peers = append(result.Peers, PeerSyncStatus{
+			PeerID:          peerID,
+			Height:          common.JSONUint64(status.Height),
+			FinalizedHeight: common.JSONUint64(status.FinalizedHeight),
+			TipHash:         status.TipHash,
+		})
+	}
+	sort.Slice(result.Peers, func(i, j int) bool { return result.Peers[i].PeerID < result.Peers[j].PeerID })
 	return
 }
+
+// ------------------------------ GetGuardianConfirmation -----------------------------------
+
+type GetGuardianConfirmationArgs struct {
+	Height common.JSONUint64 `json:"height"`
+}
+
+type GetGuardianConfirmationResult struct {
+	BlockHash        common.Hash       `json:"block_hash"`
+	Finalized        bool              `json:"finalized"`
+	CheckpointHash   common.Hash       `json:"checkpoint_hash"`
+	CheckpointHeight common.JSONUint64 `json:"checkpoint_height"`
+	Guardians        []common.Address  `json:"guardians"`
+	Confirmed        bool              `json:"confirmed"`
+}
+
+// GetGuardianConfirmation returns whether the block at the given height is finalized
+// by the validators, and confirmed by the guardians attesting a checkpoint at or
+// above it.
+func (t *ThetaRPCServer) GetGuardianConfirmation(r *http.Request, args *GetGuardianConfirmationArgs, result *GetGuardianConfirmationResult) (err error) {
+	if args.Height == 0 {
+		return errors.New("Block height must be specified")
+	}
+	c := t.consensus.GetGuardianConfirmation(uint64(args.Height))
+	if c.Block != nil {
+		result.BlockHash = c.Block.Hash()
+		result.Finalized = true
+	}
+	if c.Checkpoint != nil {
+		result.CheckpointHash = c.Checkpoint.Hash()
+		result.CheckpointHeight = common.JSONUint64(c.Checkpoint.Height)
+	}
+	result.Guardians = c.Guardians
+	result.Confirmed = c.Confirmed
+	return nil
+}
+
+// ------------------------------ Utils -----------------------------------
+
+// parseAddress parses an address argument, which needs to have a valid EIP55 checksum
+// unless the node is configured to accept lowercase addresses
+func parseAddress(addressStr string) (common.Address, error) {
+	return common.ParseHexAddress(addressStr, common.GetConfig().RPC.AllowLowercaseAddress)
+}
+
+// parseHash parses a hash argument, which needs to be exactly 32 bytes in hex
+func parseHash(hashStr string) (common.Hash, error) {
+	return common.ParseHexHash(hashStr)
+}
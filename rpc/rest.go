@@ -0,0 +1,84 @@
+package rpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"time"
+
+	jsonrpc "github.com/gorilla/rpc/v2/json2"
+	"github.com/thetatoken/ukulele/common/metrics"
+)
+
+// restPathPrefix prefixes the paths of the REST gateway.
+const restPathPrefix = "/api/v1/"
+
+// RESTError is the body of the responses of the REST gateway to the failed requests,
+// with the code and the data of the error of the RPC method.
+type RESTError struct {
+	Code    jsonrpc.ErrorCode `json:"code"`
+	Message string            `json:"message"`
+	Data    interface{}       `json:"data,omitempty"`
+}
+
+// registerRESTHandlers serves the methods of the theta namespace as POST
+// /api/v1/<Method>, e.g. /api/v1/GetAccount, taking the arguments of the method as
+// the JSON body and returning its result, and their OpenAPI definitions on
+// /api/v1/openapi.json.
+func (t *ThetaRPCServer) registerRESTHandlers() {
+	for _, method := range rpcMethods(reflect.TypeOf(t)) {
+		t.router.Handle(restPathPrefix+method.Name, t.restHandler(method)).Methods("POST")
+	}
+
+	spec, err := json.Marshal(OpenAPISpec(restPathPrefix))
+	if err != nil {
+		logger.Panicf("Failed to generate the OpenAPI definitions: %v", err)
+	}
+	t.router.HandleFunc(restPathPrefix+"openapi.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(spec)
+	}).Methods("GET")
+}
+
+func (t *ThetaRPCServer) restHandler(method rpcMethod) http.Handler {
+	requests := metrics.GetOrRegisterMeter("rpc/theta."+method.Name+"/requests", nil)
+	latency := metrics.GetOrRegisterTimer("rpc/theta."+method.Name+"/latency", nil)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		defer func() {
+			requests.Mark(1)
+			latency.UpdateSince(start)
+		}()
+
+		w.Header().Set("Content-Type", "application/json")
+		args := reflect.New(method.ArgsType)
+		body, err := ioutil.ReadAll(r.Body)
+		if err == nil && len(bytes.TrimSpace(body)) > 0 {
+			err = json.Unmarshal(body, args.Interface())
+		}
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(RESTError{Code: jsonrpc.E_PARSE, Message: err.Error()})
+			return
+		}
+
+		result := reflect.New(method.ResultType)
+		out := method.Method.Func.Call([]reflect.Value{reflect.ValueOf(t), reflect.ValueOf(r), args, result})
+		if errValue := out[0].Interface(); errValue != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(newRESTError(errValue.(error)))
+			return
+		}
+		json.NewEncoder(w).Encode(result.Interface())
+	})
+}
+
+func newRESTError(err error) RESTError {
+	if jsonErr, ok := err.(*jsonrpc.Error); ok {
+		return RESTError{Code: jsonErr.Code, Message: jsonErr.Message, Data: jsonErr.Data}
+	}
+	return RESTError{Code: jsonrpc.E_SERVER, Message: err.Error()}
+}
@@ -0,0 +1,73 @@
+package rpc
+
+import (
+	"errors"
+	"fmt"
+
+	json "github.com/gorilla/rpc/v2/json2"
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/core"
+	"github.com/thetatoken/ukulele/ledger/state"
+)
+
+// ErrCodeStateNotRetained is the JSON-RPC error code of a query for the ledger state of
+// a block older than the node retains. The data of the error is a StateNotRetained.
+const ErrCodeStateNotRetained json.ErrorCode = -32001
+
+// StateNotRetained is the data of the error returned for a query exceeding the retention
+// of the node.
+type StateNotRetained struct {
+	Height        common.JSONUint64 `json:"height"`         // Height queried
+	NearestHeight common.JSONUint64 `json:"nearest_height"` // Lowest height at or above the height queried whose state is retained
+	NodeMode      string            `json:"node_mode"`      // "full" or "archive"
+}
+
+func newStateNotRetainedError(height uint64, nearestHeight uint64) error {
+	return &json.Error{
+		Code: ErrCodeStateNotRetained,
+		Message: fmt.Sprintf("State at height %v is not retained by the node, the nearest height available is %v",
+			height, nearestHeight),
+		Data: StateNotRetained{
+			Height:        common.JSONUint64(height),
+			NearestHeight: common.JSONUint64(nearestHeight),
+			NodeMode:      common.GetConfig().Storage.Mode,
+		},
+	}
+}
+
+// getFinalizedState returns the ledger state of the finalized block at the given
+// height, or of the latest finalized block if the height is 0.
+func (t *ThetaRPCServer) getFinalizedState(height uint64) (*state.StoreView, *core.ExtendedBlock, error) {
+	var block *core.ExtendedBlock
+	if height == 0 {
+		finalized := t.consensus.GetSummary().LastFinalizedBlock
+		if finalized.IsEmpty() {
+			return nil, nil, errors.New("No block is finalized yet")
+		}
+		var err error
+		if block, err = t.chain.FindBlock(finalized); err != nil {
+			return nil, nil, err
+		}
+	} else if block = t.findFinalizedBlockByHeight(height); block == nil {
+		return nil, nil, fmt.Errorf("No finalized block at height %v", height)
+	}
+
+	view, err := t.getBlockState(block)
+	if err != nil {
+		return nil, nil, err
+	}
+	return view, block, nil
+}
+
+// getBlockState returns the ledger state after the given finalized block.
+func (t *ThetaRPCServer) getBlockState(block *core.ExtendedBlock) (*state.StoreView, error) {
+	ledgerState, err := t.ledger.GetFinalizedSnapshot()
+	if err != nil {
+		return nil, err
+	}
+	view := state.NewStoreView(block.Height, block.StateHash, ledgerState.GetStore().GetDB())
+	if view == nil {
+		return nil, newStateNotRetainedError(block.Height, t.ledger.NearestRetainedHeight(block.Height))
+	}
+	return view, nil
+}
@@ -0,0 +1,1076 @@
+package rpc
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"sort"
+	"strings"
+
+	jsonrpc "github.com/gorilla/rpc/v2/json2"
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/core"
+	"github.com/thetatoken/ukulele/crypto"
+	"github.com/thetatoken/ukulele/exporter"
+	"github.com/thetatoken/ukulele/ledger/types"
+	"github.com/thetatoken/ukulele/version"
+)
+
+// The Rosetta Data and Construction APIs, see https://www.rosetta-api.org/docs/. Only
+// the THETA and GAMMA balances are reported, and only SendTx can be constructed.
+//
+// The operations of a block are the transfers of its SendTx and CoinbaseTx, followed
+// by a "BalanceChange" transaction adjusting the balances changed otherwise, e.g. by
+// the stakes or the smart contracts, as recorded by the balance journal of the block.
+// The balances of the accounts thus reconcile with the operations, as long as the
+// journal of the block is retained by the node.
+
+const (
+	rosettaVersion    = "1.4.10"
+	rosettaBlockchain = "theta"
+
+	rosettaOpTransfer      = "Transfer"
+	rosettaOpFee           = "Fee"
+	rosettaOpCoinbase      = "Coinbase"
+	rosettaOpBalanceChange = "BalanceChange"
+
+	rosettaStatusSuccess = "SUCCESS"
+
+	rosettaCurveSecp256k1      = "secp256k1"
+	rosettaSigEcdsaRecovery    = "ecdsa_recovery"
+	rosettaBalanceChangePrefix = "balance_changes:"
+)
+
+var (
+	rosettaTheta = RosettaCurrency{Symbol: "THETA", Decimals: 18}
+	rosettaGamma = RosettaCurrency{Symbol: "GAMMA", Decimals: 18}
+)
+
+type RosettaNetworkIdentifier struct {
+	Blockchain string `json:"blockchain"`
+	Network    string `json:"network"`
+}
+
+type RosettaBlockIdentifier struct {
+	Index int64  `json:"index"`
+	Hash  string `json:"hash"`
+}
+
+type RosettaPartialBlockIdentifier struct {
+	Index *int64  `json:"index,omitempty"`
+	Hash  *string `json:"hash,omitempty"`
+}
+
+type RosettaTransactionIdentifier struct {
+	Hash string `json:"hash"`
+}
+
+type RosettaAccountIdentifier struct {
+	Address string `json:"address"`
+}
+
+type RosettaCurrency struct {
+	Symbol   string `json:"symbol"`
+	Decimals int32  `json:"decimals"`
+}
+
+type RosettaAmount struct {
+	Value    string          `json:"value"`
+	Currency RosettaCurrency `json:"currency"`
+}
+
+type RosettaOperationIdentifier struct {
+	Index int64 `json:"index"`
+}
+
+type RosettaOperation struct {
+	OperationIdentifier RosettaOperationIdentifier `json:"operation_identifier"`
+	Type                string                     `json:"type"`
+	Status              string                     `json:"status,omitempty"` // Empty for the operations of a transaction under construction
+	Account             *RosettaAccountIdentifier  `json:"account,omitempty"`
+	Amount              *RosettaAmount             `json:"amount,omitempty"`
+}
+
+type RosettaTransaction struct {
+	TransactionIdentifier RosettaTransactionIdentifier `json:"transaction_identifier"`
+	Operations            []RosettaOperation           `json:"operations"`
+	Metadata              map[string]interface{}       `json:"metadata,omitempty"`
+}
+
+type RosettaBlock struct {
+	BlockIdentifier       RosettaBlockIdentifier `json:"block_identifier"`
+	ParentBlockIdentifier RosettaBlockIdentifier `json:"parent_block_identifier"`
+	Timestamp             int64                  `json:"timestamp"` // In milliseconds
+	Transactions          []RosettaTransaction   `json:"transactions"`
+}
+
+type RosettaPublicKey struct {
+	HexBytes  string `json:"hex_bytes"`
+	CurveType string `json:"curve_type"`
+}
+
+type RosettaSigningPayload struct {
+	AccountIdentifier *RosettaAccountIdentifier `json:"account_identifier"`
+	HexBytes          string                    `json:"hex_bytes"`
+	SignatureType     string                    `json:"signature_type"`
+}
+
+type RosettaSignature struct {
+	SigningPayload RosettaSigningPayload `json:"signing_payload"`
+	PublicKey      RosettaPublicKey      `json:"public_key"`
+	SignatureType  string                `json:"signature_type"`
+	HexBytes       string                `json:"hex_bytes"`
+}
+
+// RosettaError is the body of the responses to the failed requests.
+type RosettaError struct {
+	Code      int32                  `json:"code"`
+	Message   string                 `json:"message"`
+	Retriable bool                   `json:"retriable"`
+	Details   map[string]interface{} `json:"details,omitempty"`
+}
+
+var (
+	rosettaErrInvalidRequest     = &RosettaError{Code: 1, Message: "Invalid request"}
+	rosettaErrUnknownNetwork     = &RosettaError{Code: 2, Message: "Unknown network"}
+	rosettaErrUnavailable        = &RosettaError{Code: 3, Message: "No block is finalized yet", Retriable: true}
+	rosettaErrBlockNotFound      = &RosettaError{Code: 4, Message: "Finalized block not found", Retriable: true}
+	rosettaErrTxNotFound         = &RosettaError{Code: 5, Message: "Transaction not found"}
+	rosettaErrStateNotRetained   = &RosettaError{Code: 6, Message: "State not retained by the node"}
+	rosettaErrInvalidOperations  = &RosettaError{Code: 7, Message: "Invalid operations"}
+	rosettaErrInvalidTransaction = &RosettaError{Code: 8, Message: "Invalid transaction"}
+	rosettaErrInvalidPublicKey   = &RosettaError{Code: 9, Message: "Invalid public key"}
+	rosettaErrInvalidSignature   = &RosettaError{Code: 10, Message: "Invalid signature"}
+	rosettaErrTxRejected         = &RosettaError{Code: 11, Message: "Transaction rejected"}
+
+	rosettaErrors = []*RosettaError{
+		rosettaErrInvalidRequest, rosettaErrUnknownNetwork, rosettaErrUnavailable, rosettaErrBlockNotFound,
+		rosettaErrTxNotFound, rosettaErrStateNotRetained, rosettaErrInvalidOperations, rosettaErrInvalidTransaction,
+		rosettaErrInvalidPublicKey, rosettaErrInvalidSignature, rosettaErrTxRejected,
+	}
+)
+
+// withError returns a copy of the error detailing the cause.
+func (e *RosettaError) withError(err error) *RosettaError {
+	ret := *e
+	ret.Details = map[string]interface{}{"error": err.Error()}
+	if jsonErr, ok := err.(*jsonrpc.Error); ok && jsonErr.Data != nil {
+		ret.Details["data"] = jsonErr.Data
+	}
+	return &ret
+}
+
+type rosettaEndpoint func(body []byte) (interface{}, *RosettaError)
+
+// registerRosettaHandlers serves the Rosetta endpoints under /rosetta.
+func (t *ThetaRPCServer) registerRosettaHandlers() {
+	endpoints := map[string]rosettaEndpoint{
+		"/network/list":            t.rosettaNetworkList,
+		"/network/options":         t.rosettaNetworkOptions,
+		"/network/status":          t.rosettaNetworkStatus,
+		"/block":                   t.rosettaBlock,
+		"/block/transaction":       t.rosettaBlockTransaction,
+		"/account/balance":         t.rosettaAccountBalance,
+		"/mempool":                 t.rosettaMempool,
+		"/mempool/transaction":     t.rosettaMempoolTransaction,
+		"/construction/derive":     t.rosettaConstructionDerive,
+		"/construction/preprocess": t.rosettaConstructionPreprocess,
+		"/construction/metadata":   t.rosettaConstructionMetadata,
+		"/construction/payloads":   t.rosettaConstructionPayloads,
+		"/construction/combine":    t.rosettaConstructionCombine,
+		"/construction/parse":      t.rosettaConstructionParse,
+		"/construction/hash":       t.rosettaConstructionHash,
+		"/construction/submit":     t.rosettaConstructionSubmit,
+	}
+	for path, endpoint := range endpoints {
+		t.router.Handle("/rosetta"+path, rosettaHandler(endpoint)).Methods("POST")
+	}
+}
+
+func rosettaHandler(endpoint rosettaEndpoint) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var response interface{}
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			response = rosettaErrInvalidRequest.withError(err)
+		} else if result, rerr := endpoint(body); rerr != nil {
+			response = rerr
+		} else {
+			response = result
+		}
+		if _, ok := response.(*RosettaError); ok {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		json.NewEncoder(w).Encode(response)
+	})
+}
+
+func (t *ThetaRPCServer) rosettaNetworkIdentifier() RosettaNetworkIdentifier {
+	return RosettaNetworkIdentifier{Blockchain: rosettaBlockchain, Network: t.chain.ChainID}
+}
+
+// decodeRosettaRequest decodes the request after checking it is for the network of the
+// node.
+func (t *ThetaRPCServer) decodeRosettaRequest(body []byte, req interface{}) *RosettaError {
+	var network struct {
+		NetworkIdentifier *RosettaNetworkIdentifier `json:"network_identifier"`
+	}
+	if err := json.Unmarshal(body, &network); err != nil {
+		return rosettaErrInvalidRequest.withError(err)
+	}
+	if network.NetworkIdentifier == nil || *network.NetworkIdentifier != t.rosettaNetworkIdentifier() {
+		return rosettaErrUnknownNetwork
+	}
+	if err := json.Unmarshal(body, req); err != nil {
+		return rosettaErrInvalidRequest.withError(err)
+	}
+	return nil
+}
+
+// ------------------------------- Network -----------------------------------
+
+type RosettaNetworkListResponse struct {
+	NetworkIdentifiers []RosettaNetworkIdentifier `json:"network_identifiers"`
+}
+
+func (t *ThetaRPCServer) rosettaNetworkList(body []byte) (interface{}, *RosettaError) {
+	return RosettaNetworkListResponse{
+		NetworkIdentifiers: []RosettaNetworkIdentifier{t.rosettaNetworkIdentifier()},
+	}, nil
+}
+
+type RosettaVersion struct {
+	RosettaVersion string `json:"rosetta_version"`
+	NodeVersion    string `json:"node_version"`
+}
+
+type RosettaOperationStatus struct {
+	Status     string `json:"status"`
+	Successful bool   `json:"successful"`
+}
+
+type RosettaAllow struct {
+	OperationStatuses       []RosettaOperationStatus `json:"operation_statuses"`
+	OperationTypes          []string                 `json:"operation_types"`
+	Errors                  []*RosettaError          `json:"errors"`
+	HistoricalBalanceLookup bool                     `json:"historical_balance_lookup"`
+}
+
+type RosettaNetworkOptionsResponse struct {
+	Version RosettaVersion `json:"version"`
+	Allow   RosettaAllow   `json:"allow"`
+}
+
+func (t *ThetaRPCServer) rosettaNetworkOptions(body []byte) (interface{}, *RosettaError) {
+	if rerr := t.decodeRosettaRequest(body, &struct{}{}); rerr != nil {
+		return nil, rerr
+	}
+	return RosettaNetworkOptionsResponse{
+		Version: RosettaVersion{RosettaVersion: rosettaVersion, NodeVersion: version.Version},
+		Allow: RosettaAllow{
+			OperationStatuses:       []RosettaOperationStatus{{Status: rosettaStatusSuccess, Successful: true}},
+			OperationTypes:          []string{rosettaOpTransfer, rosettaOpFee, rosettaOpCoinbase, rosettaOpBalanceChange},
+			Errors:                  rosettaErrors,
+			HistoricalBalanceLookup: true,
+		},
+	}, nil
+}
+
+type RosettaPeer struct {
+	PeerID string `json:"peer_id"`
+}
+
+type RosettaSyncStatus struct {
+	CurrentIndex int64 `json:"current_index"`
+	TargetIndex  int64 `json:"target_index"`
+	Synced       bool  `json:"synced"`
+}
+
+type RosettaNetworkStatusResponse struct {
+	CurrentBlockIdentifier RosettaBlockIdentifier `json:"current_block_identifier"`
+	CurrentBlockTimestamp  int64                  `json:"current_block_timestamp"`
+	GenesisBlockIdentifier RosettaBlockIdentifier `json:"genesis_block_identifier"`
+	SyncStatus             *RosettaSyncStatus     `json:"sync_status,omitempty"`
+	Peers                  []RosettaPeer          `json:"peers"`
+}
+
+func (t *ThetaRPCServer) rosettaNetworkStatus(body []byte) (interface{}, *RosettaError) {
+	if rerr := t.decodeRosettaRequest(body, &struct{}{}); rerr != nil {
+		return nil, rerr
+	}
+	block, rerr := t.rosettaLastFinalizedBlock()
+	if rerr != nil {
+		return nil, rerr
+	}
+
+	// The root of the chain is the genesis block, or the snapshot the node started from
+	result := RosettaNetworkStatusResponse{
+		CurrentBlockIdentifier: rosettaBlockIdentifier(block),
+		CurrentBlockTimestamp:  rosettaTimestamp(block),
+		GenesisBlockIdentifier: rosettaBlockIdentifier(t.chain.Root),
+		Peers:                  []RosettaPeer{},
+	}
+	if t.syncMgr != nil {
+		s := t.syncMgr.GetSyncStatus()
+		result.SyncStatus = &RosettaSyncStatus{
+			CurrentIndex: int64(s.FinalizedHeight),
+			TargetIndex:  int64(s.NetworkFinalizedHeight),
+			Synced:       !s.Behind,
+		}
+		for peerID := range s.Peers {
+			result.Peers = append(result.Peers, RosettaPeer{PeerID: peerID})
+		}
+		sort.Slice(result.Peers, func(i, j int) bool {
+			return result.Peers[i].PeerID < result.Peers[j].PeerID
+		})
+	}
+	return result, nil
+}
+
+// ------------------------------- Block -----------------------------------
+
+type RosettaBlockRequest struct {
+	BlockIdentifier RosettaPartialBlockIdentifier `json:"block_identifier"`
+}
+
+type RosettaBlockResponse struct {
+	Block RosettaBlock `json:"block"`
+}
+
+func (t *ThetaRPCServer) rosettaBlock(body []byte) (interface{}, *RosettaError) {
+	var req RosettaBlockRequest
+	if rerr := t.decodeRosettaRequest(body, &req); rerr != nil {
+		return nil, rerr
+	}
+	block, rerr := t.rosettaFindFinalizedBlock(req.BlockIdentifier)
+	if rerr != nil {
+		return nil, rerr
+	}
+
+	parent := rosettaBlockIdentifier(block)
+	if block.Height > 0 {
+		parent = RosettaBlockIdentifier{Index: int64(block.Height) - 1, Hash: block.Parent.Hex()}
+	}
+	return RosettaBlockResponse{
+		Block: RosettaBlock{
+			BlockIdentifier:       rosettaBlockIdentifier(block),
+			ParentBlockIdentifier: parent,
+			Timestamp:             rosettaTimestamp(block),
+			Transactions:          t.rosettaBlockTransactions(block),
+		},
+	}, nil
+}
+
+type RosettaBlockTransactionRequest struct {
+	BlockIdentifier       RosettaBlockIdentifier       `json:"block_identifier"`
+	TransactionIdentifier RosettaTransactionIdentifier `json:"transaction_identifier"`
+}
+
+type RosettaTransactionResponse struct {
+	Transaction RosettaTransaction `json:"transaction"`
+}
+
+func (t *ThetaRPCServer) rosettaBlockTransaction(body []byte) (interface{}, *RosettaError) {
+	var req RosettaBlockTransactionRequest
+	if rerr := t.decodeRosettaRequest(body, &req); rerr != nil {
+		return nil, rerr
+	}
+	block, rerr := t.rosettaFindFinalizedBlock(RosettaPartialBlockIdentifier{
+		Index: &req.BlockIdentifier.Index,
+		Hash:  &req.BlockIdentifier.Hash,
+	})
+	if rerr != nil {
+		return nil, rerr
+	}
+	for _, tx := range t.rosettaBlockTransactions(block) {
+		if strings.EqualFold(tx.TransactionIdentifier.Hash, req.TransactionIdentifier.Hash) {
+			return RosettaTransactionResponse{Transaction: tx}, nil
+		}
+	}
+	return nil, rosettaErrTxNotFound
+}
+
+// rosettaBlockTransactions returns the transactions of the block, followed by the
+// transaction adjusting the balances changed by the block but not by the operations of
+// its transactions.
+func (t *ThetaRPCServer) rosettaBlockTransactions(block *core.ExtendedBlock) []RosettaTransaction {
+	txs := []RosettaTransaction{}
+	deltas := make(map[common.Address]types.Coins)
+	for _, raw := range block.Txs {
+		tx, err := types.TxFromBytes(raw)
+		if err != nil {
+			logger.Errorf("Failed to decode a transaction of block %v: %v", block.Hash().Hex(), err)
+			continue
+		}
+		ops := rosettaTxOperations(tx, rosettaStatusSuccess)
+		for _, op := range ops {
+			address := common.HexToAddress(op.Account.Address)
+			deltas[address] = deltas[address].Plus(rosettaCoins(op.Amount))
+		}
+		txs = append(txs, RosettaTransaction{
+			TransactionIdentifier: RosettaTransactionIdentifier{Hash: crypto.Keccak256Hash(raw).Hex()},
+			Operations:            ops,
+			Metadata:              map[string]interface{}{"type": exporter.TxTypeName(tx)},
+		})
+	}
+
+	journal, ok := t.ledger.GetBalanceJournal(block.Height)
+	if !ok || journal.StateRoot != block.StateHash {
+		return txs
+	}
+	addresses := []common.Address{}
+	for _, change := range journal.Changes {
+		if _, ok := deltas[change.Address]; !ok {
+			addresses = append(addresses, change.Address)
+		}
+	}
+	for address := range deltas {
+		addresses = append(addresses, address)
+	}
+	sort.Slice(addresses, func(i, j int) bool {
+		return bytes.Compare(addresses[i][:], addresses[j][:]) < 0
+	})
+
+	ops := rosettaOperations{}
+	for _, address := range addresses {
+		adjustment := deltas[address].Negative()
+		if change, ok := journal.Find(address); ok {
+			adjustment = adjustment.Plus(change.Delta())
+		}
+		ops.add(rosettaOpBalanceChange, rosettaStatusSuccess, address, adjustment)
+	}
+	if len(ops) > 0 {
+		txs = append(txs, RosettaTransaction{
+			TransactionIdentifier: RosettaTransactionIdentifier{Hash: rosettaBalanceChangePrefix + block.Hash().Hex()},
+			Operations:            ops,
+		})
+	}
+	return txs
+}
+
+// ------------------------------- Account -----------------------------------
+
+type RosettaAccountBalanceRequest struct {
+	AccountIdentifier RosettaAccountIdentifier       `json:"account_identifier"`
+	BlockIdentifier   *RosettaPartialBlockIdentifier `json:"block_identifier,omitempty"`
+}
+
+type RosettaAccountBalanceResponse struct {
+	BlockIdentifier RosettaBlockIdentifier `json:"block_identifier"`
+	Balances        []RosettaAmount        `json:"balances"`
+	Metadata        map[string]interface{} `json:"metadata"`
+}
+
+// rosettaAccountBalance returns the balances of an account after a finalized block, the
+// last one by default. The metadata holds the sequence of the account.
+func (t *ThetaRPCServer) rosettaAccountBalance(body []byte) (interface{}, *RosettaError) {
+	var req RosettaAccountBalanceRequest
+	if rerr := t.decodeRosettaRequest(body, &req); rerr != nil {
+		return nil, rerr
+	}
+	address, err := parseAddress(req.AccountIdentifier.Address)
+	if err != nil {
+		return nil, rosettaErrInvalidRequest.withError(err)
+	}
+
+	height := uint64(0)
+	if req.BlockIdentifier != nil {
+		block, rerr := t.rosettaFindFinalizedBlock(*req.BlockIdentifier)
+		if rerr != nil {
+			return nil, rerr
+		}
+		height = block.Height
+	}
+	view, block, err := t.getFinalizedState(height)
+	if _, ok := err.(*jsonrpc.Error); ok {
+		return nil, rosettaErrStateNotRetained.withError(err)
+	} else if err != nil {
+		return nil, rosettaErrUnavailable.withError(err)
+	}
+
+	balance := types.NewCoins(0, 0)
+	sequence := uint64(0)
+	if account := view.GetAccount(address); account != nil {
+		balance = account.Balance.NoNil()
+		sequence = account.Sequence
+	}
+	return RosettaAccountBalanceResponse{
+		BlockIdentifier: rosettaBlockIdentifier(block),
+		Balances: []RosettaAmount{
+			{Value: balance.ThetaWei.String(), Currency: rosettaTheta},
+			{Value: balance.GammaWei.String(), Currency: rosettaGamma},
+		},
+		Metadata: map[string]interface{}{"sequence": common.JSONUint64(sequence)},
+	}, nil
+}
+
+// ------------------------------- Mempool -----------------------------------
+
+type RosettaMempoolResponse struct {
+	TransactionIdentifiers []RosettaTransactionIdentifier `json:"transaction_identifiers"`
+}
+
+func (t *ThetaRPCServer) rosettaMempool(body []byte) (interface{}, *RosettaError) {
+	if rerr := t.decodeRosettaRequest(body, &struct{}{}); rerr != nil {
+		return nil, rerr
+	}
+	result := RosettaMempoolResponse{TransactionIdentifiers: []RosettaTransactionIdentifier{}}
+	for _, raw := range t.mempool.ListTransactions() {
+		result.TransactionIdentifiers = append(result.TransactionIdentifiers,
+			RosettaTransactionIdentifier{Hash: crypto.Keccak256Hash(raw).Hex()})
+	}
+	return result, nil
+}
+
+type RosettaMempoolTransactionRequest struct {
+	TransactionIdentifier RosettaTransactionIdentifier `json:"transaction_identifier"`
+}
+
+func (t *ThetaRPCServer) rosettaMempoolTransaction(body []byte) (interface{}, *RosettaError) {
+	var req RosettaMempoolTransactionRequest
+	if rerr := t.decodeRosettaRequest(body, &req); rerr != nil {
+		return nil, rerr
+	}
+	hash, err := parseHash(req.TransactionIdentifier.Hash)
+	if err != nil {
+		return nil, rosettaErrInvalidRequest.withError(err)
+	}
+	raw, ok := t.mempool.GetTransaction(hash)
+	if !ok {
+		return nil, rosettaErrTxNotFound
+	}
+	tx, err := types.TxFromBytes(raw)
+	if err != nil {
+		return nil, rosettaErrInvalidTransaction.withError(err)
+	}
+	return RosettaTransactionResponse{
+		Transaction: RosettaTransaction{
+			TransactionIdentifier: RosettaTransactionIdentifier{Hash: hash.Hex()},
+			Operations:            rosettaTxOperations(tx, ""),
+			Metadata:              map[string]interface{}{"type": exporter.TxTypeName(tx)},
+		},
+	}, nil
+}
+
+// ------------------------------- Construction -----------------------------------
+
+type RosettaConstructionDeriveRequest struct {
+	PublicKey RosettaPublicKey `json:"public_key"`
+}
+
+type RosettaConstructionDeriveResponse struct {
+	AccountIdentifier RosettaAccountIdentifier `json:"account_identifier"`
+}
+
+// rosettaConstructionDerive returns the address of a secp256k1 public key, in the
+// compressed or the uncompressed format.
+func (t *ThetaRPCServer) rosettaConstructionDerive(body []byte) (interface{}, *RosettaError) {
+	var req RosettaConstructionDeriveRequest
+	if rerr := t.decodeRosettaRequest(body, &req); rerr != nil {
+		return nil, rerr
+	}
+	if req.PublicKey.CurveType != rosettaCurveSecp256k1 {
+		return nil, rosettaErrInvalidPublicKey.withError(fmt.Errorf("Unsupported curve %v", req.PublicKey.CurveType))
+	}
+	keyBytes, err := hex.DecodeString(strings.TrimPrefix(req.PublicKey.HexBytes, "0x"))
+	if err != nil {
+		return nil, rosettaErrInvalidPublicKey.withError(err)
+	}
+	var pubKey *crypto.PublicKey
+	if len(keyBytes) == 33 {
+		pubKey, err = crypto.PublicKeyFromCompressedBytes(keyBytes)
+	} else {
+		pubKey, err = crypto.PublicKeyFromBytes(keyBytes)
+	}
+	if err != nil {
+		return nil, rosettaErrInvalidPublicKey.withError(err)
+	}
+	return RosettaConstructionDeriveResponse{
+		AccountIdentifier: RosettaAccountIdentifier{Address: pubKey.Address().Hex()},
+	}, nil
+}
+
+type RosettaConstructionPreprocessRequest struct {
+	Operations []RosettaOperation `json:"operations"`
+}
+
+type RosettaConstructionPreprocessResponse struct {
+	Options map[string]interface{} `json:"options"`
+}
+
+// rosettaConstructionPreprocess checks the operations describe a SendTx, and returns the
+// senders whose sequences the metadata needs.
+func (t *ThetaRPCServer) rosettaConstructionPreprocess(body []byte) (interface{}, *RosettaError) {
+	var req RosettaConstructionPreprocessRequest
+	if rerr := t.decodeRosettaRequest(body, &req); rerr != nil {
+		return nil, rerr
+	}
+	tx, _, err := rosettaSendTxFromOperations(req.Operations)
+	if err != nil {
+		return nil, rosettaErrInvalidOperations.withError(err)
+	}
+	senders := []string{}
+	for _, input := range tx.Inputs {
+		senders = append(senders, input.Address.Hex())
+	}
+	return RosettaConstructionPreprocessResponse{
+		Options: map[string]interface{}{"senders": senders},
+	}, nil
+}
+
+type RosettaConstructionMetadataRequest struct {
+	Options struct {
+		Senders []string `json:"senders"`
+	} `json:"options"`
+}
+
+// RosettaConstructionMetadata is the metadata of a SendTx under construction.
+type RosettaConstructionMetadata struct {
+	Sequences map[string]common.JSONUint64 `json:"sequences"` // Sequence of the transaction for each sender
+	Fee       *common.JSONBig              `json:"fee"`       // Fee in GammaWei, if not set by a Fee operation
+}
+
+type RosettaConstructionMetadataResponse struct {
+	Metadata     RosettaConstructionMetadata `json:"metadata"`
+	SuggestedFee []RosettaAmount             `json:"suggested_fee"`
+}
+
+// rosettaConstructionMetadata returns the sequences of the senders, after the
+// transactions pending in the mempool, and the fee suggested by EstimateFee.
+func (t *ThetaRPCServer) rosettaConstructionMetadata(body []byte) (interface{}, *RosettaError) {
+	var req RosettaConstructionMetadataRequest
+	if rerr := t.decodeRosettaRequest(body, &req); rerr != nil {
+		return nil, rerr
+	}
+	view, err := t.getLedgerSnapshot(LedgerStatePending)
+	if err != nil {
+		return nil, rosettaErrUnavailable.withError(err)
+	}
+	metadata := RosettaConstructionMetadata{Sequences: make(map[string]common.JSONUint64)}
+	for _, sender := range req.Options.Senders {
+		address, err := parseAddress(sender)
+		if err != nil {
+			return nil, rosettaErrInvalidRequest.withError(err)
+		}
+		sequence := uint64(1)
+		if account := view.GetAccount(address); account != nil {
+			sequence = account.Sequence + 1
+		}
+		metadata.Sequences[address.Hex()] = common.JSONUint64(sequence)
+	}
+
+	var fee EstimateFeeResult
+	if err := t.EstimateFee(nil, &EstimateFeeArgs{}, &fee); err != nil {
+		return nil, rosettaErrUnavailable.withError(err)
+	}
+	metadata.Fee = fee.Fee
+	return RosettaConstructionMetadataResponse{
+		Metadata:     metadata,
+		SuggestedFee: []RosettaAmount{{Value: (*big.Int)(fee.Fee).String(), Currency: rosettaGamma}},
+	}, nil
+}
+
+type RosettaConstructionPayloadsRequest struct {
+	Operations []RosettaOperation          `json:"operations"`
+	Metadata   RosettaConstructionMetadata `json:"metadata"`
+}
+
+type RosettaConstructionPayloadsResponse struct {
+	UnsignedTransaction string                  `json:"unsigned_transaction"`
+	Payloads            []RosettaSigningPayload `json:"payloads"`
+}
+
+// rosettaConstructionPayloads builds the unsigned SendTx, and the payloads each sender
+// signs: the Keccak256 hash of the sign bytes of the transaction, as PrivateKey.Sign
+// would hash them.
+func (t *ThetaRPCServer) rosettaConstructionPayloads(body []byte) (interface{}, *RosettaError) {
+	var req RosettaConstructionPayloadsRequest
+	if rerr := t.decodeRosettaRequest(body, &req); rerr != nil {
+		return nil, rerr
+	}
+	tx, hasFee, err := rosettaSendTxFromOperations(req.Operations)
+	if err != nil {
+		return nil, rosettaErrInvalidOperations.withError(err)
+	}
+	if !hasFee {
+		if req.Metadata.Fee == nil {
+			return nil, rosettaErrInvalidRequest.withError(errors.New("No Fee operation nor fee in the metadata"))
+		}
+		tx.Fee = types.Coins{ThetaWei: big.NewInt(0), GammaWei: new(big.Int).Set((*big.Int)(req.Metadata.Fee))}
+		tx.Inputs[0].Coins = tx.Inputs[0].Coins.Plus(tx.Fee)
+	}
+	for i, input := range tx.Inputs {
+		sequence, ok := req.Metadata.Sequences[input.Address.Hex()]
+		if !ok {
+			return nil, rosettaErrInvalidRequest.withError(fmt.Errorf("No sequence in the metadata for %v", input.Address.Hex()))
+		}
+		tx.Inputs[i].Sequence = uint64(sequence)
+	}
+
+	raw, err := types.TxToBytes(tx)
+	if err != nil {
+		return nil, rosettaErrInvalidTransaction.withError(err)
+	}
+	signBytes := crypto.Keccak256(tx.SignBytes(t.chain.ChainID))
+	result := RosettaConstructionPayloadsResponse{
+		UnsignedTransaction: hex.EncodeToString(raw),
+		Payloads:            []RosettaSigningPayload{},
+	}
+	for _, input := range tx.Inputs {
+		result.Payloads = append(result.Payloads, RosettaSigningPayload{
+			AccountIdentifier: &RosettaAccountIdentifier{Address: input.Address.Hex()},
+			HexBytes:          hex.EncodeToString(signBytes),
+			SignatureType:     rosettaSigEcdsaRecovery,
+		})
+	}
+	return result, nil
+}
+
+type RosettaConstructionCombineRequest struct {
+	UnsignedTransaction string             `json:"unsigned_transaction"`
+	Signatures          []RosettaSignature `json:"signatures"`
+}
+
+type RosettaConstructionCombineResponse struct {
+	SignedTransaction string `json:"signed_transaction"`
+}
+
+// rosettaConstructionCombine sets the 65-byte recoverable signatures of the senders on
+// the transaction, after checking them.
+func (t *ThetaRPCServer) rosettaConstructionCombine(body []byte) (interface{}, *RosettaError) {
+	var req RosettaConstructionCombineRequest
+	if rerr := t.decodeRosettaRequest(body, &req); rerr != nil {
+		return nil, rerr
+	}
+	tx, rerr := rosettaDecodeSendTx(req.UnsignedTransaction)
+	if rerr != nil {
+		return nil, rerr
+	}
+	signBytes := tx.SignBytes(t.chain.ChainID)
+	for _, s := range req.Signatures {
+		if s.SignatureType != rosettaSigEcdsaRecovery || s.SigningPayload.AccountIdentifier == nil {
+			return nil, rosettaErrInvalidSignature.withError(errors.New("Expected an ecdsa_recovery signature of an account"))
+		}
+		address, err := parseAddress(s.SigningPayload.AccountIdentifier.Address)
+		if err != nil {
+			return nil, rosettaErrInvalidSignature.withError(err)
+		}
+		sigBytes, err := hex.DecodeString(strings.TrimPrefix(s.HexBytes, "0x"))
+		if err != nil {
+			return nil, rosettaErrInvalidSignature.withError(err)
+		}
+		sig, err := crypto.SignatureFromBytes(sigBytes)
+		if err != nil || !sig.Verify(signBytes, address) {
+			return nil, rosettaErrInvalidSignature.withError(fmt.Errorf("Invalid signature of %v", address.Hex()))
+		}
+		if !tx.SetSignature(address, sig) {
+			return nil, rosettaErrInvalidSignature.withError(fmt.Errorf("%v is not a sender of the transaction", address.Hex()))
+		}
+	}
+
+	raw, err := types.TxToBytes(tx)
+	if err != nil {
+		return nil, rosettaErrInvalidTransaction.withError(err)
+	}
+	return RosettaConstructionCombineResponse{SignedTransaction: hex.EncodeToString(raw)}, nil
+}
+
+type RosettaConstructionParseRequest struct {
+	Signed      bool   `json:"signed"`
+	Transaction string `json:"transaction"`
+}
+
+type RosettaConstructionParseResponse struct {
+	Operations               []RosettaOperation         `json:"operations"`
+	AccountIdentifierSigners []RosettaAccountIdentifier `json:"account_identifier_signers"`
+}
+
+func (t *ThetaRPCServer) rosettaConstructionParse(body []byte) (interface{}, *RosettaError) {
+	var req RosettaConstructionParseRequest
+	if rerr := t.decodeRosettaRequest(body, &req); rerr != nil {
+		return nil, rerr
+	}
+	tx, rerr := rosettaDecodeSendTx(req.Transaction)
+	if rerr != nil {
+		return nil, rerr
+	}
+	result := RosettaConstructionParseResponse{
+		Operations:               rosettaTxOperations(tx, ""),
+		AccountIdentifierSigners: []RosettaAccountIdentifier{},
+	}
+	if req.Signed {
+		for _, input := range tx.Inputs {
+			if input.Signature != nil && !input.Signature.IsEmpty() {
+				result.AccountIdentifierSigners = append(result.AccountIdentifierSigners,
+					RosettaAccountIdentifier{Address: input.Address.Hex()})
+			}
+		}
+	}
+	return result, nil
+}
+
+type RosettaConstructionHashRequest struct {
+	SignedTransaction string `json:"signed_transaction"`
+}
+
+type RosettaTransactionIdentifierResponse struct {
+	TransactionIdentifier RosettaTransactionIdentifier `json:"transaction_identifier"`
+}
+
+func (t *ThetaRPCServer) rosettaConstructionHash(body []byte) (interface{}, *RosettaError) {
+	var req RosettaConstructionHashRequest
+	if rerr := t.decodeRosettaRequest(body, &req); rerr != nil {
+		return nil, rerr
+	}
+	raw, err := hex.DecodeString(strings.TrimPrefix(req.SignedTransaction, "0x"))
+	if err != nil {
+		return nil, rosettaErrInvalidTransaction.withError(err)
+	}
+	return RosettaTransactionIdentifierResponse{
+		TransactionIdentifier: RosettaTransactionIdentifier{Hash: crypto.Keccak256Hash(raw).Hex()},
+	}, nil
+}
+
+// rosettaConstructionSubmit inserts the signed transaction in the mempool, as
+// BroadcastRawTransaction does.
+func (t *ThetaRPCServer) rosettaConstructionSubmit(body []byte) (interface{}, *RosettaError) {
+	var req RosettaConstructionHashRequest
+	if rerr := t.decodeRosettaRequest(body, &req); rerr != nil {
+		return nil, rerr
+	}
+	var result BroadcastRawTransactionResult
+	err := t.BroadcastRawTransaction(nil, &BroadcastRawTransactionArgs{TxBytes: strings.TrimPrefix(req.SignedTransaction, "0x")}, &result)
+	if err != nil {
+		return nil, rosettaErrTxRejected.withError(err)
+	}
+	return RosettaTransactionIdentifierResponse{
+		TransactionIdentifier: RosettaTransactionIdentifier{Hash: result.TxHash},
+	}, nil
+}
+
+// ------------------------------- Operations -----------------------------------
+
+type rosettaOperations []RosettaOperation
+
+// add appends an operation of the account for each currency the coins hold.
+func (ops *rosettaOperations) add(opType string, status string, address common.Address, coins types.Coins) {
+	coins = coins.NoNil()
+	amounts := []RosettaAmount{
+		{Value: coins.ThetaWei.String(), Currency: rosettaTheta},
+		{Value: coins.GammaWei.String(), Currency: rosettaGamma},
+	}
+	for i, value := range []*big.Int{coins.ThetaWei, coins.GammaWei} {
+		if value.Sign() == 0 {
+			continue
+		}
+		amount := amounts[i]
+		*ops = append(*ops, RosettaOperation{
+			OperationIdentifier: RosettaOperationIdentifier{Index: int64(len(*ops))},
+			Type:                opType,
+			Status:              status,
+			Account:             &RosettaAccountIdentifier{Address: address.Hex()},
+			Amount:              &amount,
+		})
+	}
+}
+
+// rosettaTxOperations returns the transfers of a SendTx or a CoinbaseTx. The fee of a
+// SendTx is a separate operation, charged to its first input.
+func rosettaTxOperations(tx types.Tx, status string) []RosettaOperation {
+	ops := rosettaOperations{}
+	switch tx := tx.(type) {
+	case *types.SendTx:
+		for i, input := range tx.Inputs {
+			coins := input.Coins.NoNil()
+			if i == 0 {
+				coins = coins.Minus(tx.Fee)
+			}
+			ops.add(rosettaOpTransfer, status, input.Address, coins.Negative())
+		}
+		if len(tx.Inputs) > 0 {
+			ops.add(rosettaOpFee, status, tx.Inputs[0].Address, tx.Fee.Negative())
+		}
+		for _, output := range tx.Outputs {
+			ops.add(rosettaOpTransfer, status, output.Address, output.Coins)
+		}
+	case *types.CoinbaseTx:
+		for _, output := range tx.Outputs {
+			ops.add(rosettaOpCoinbase, status, output.Address, output.Coins)
+		}
+	}
+	return ops
+}
+
+// rosettaSendTxFromOperations builds a SendTx from Transfer operations, negative for the
+// inputs and positive for the outputs, and optionally Fee operations of GAMMA charged to
+// a single sender. The transfers must balance. It returns whether the fee was set.
+func rosettaSendTxFromOperations(ops []RosettaOperation) (*types.SendTx, bool, error) {
+	tx := &types.SendTx{Fee: types.NewCoins(0, 0)}
+	inputs := make(map[common.Address]int)
+	outputs := make(map[common.Address]int)
+	transferred := types.NewCoins(0, 0)
+	var feePayer *common.Address
+	for _, op := range ops {
+		if op.Account == nil || op.Amount == nil {
+			return nil, false, errors.New("Every operation must have an account and an amount")
+		}
+		address, err := parseAddress(op.Account.Address)
+		if err != nil {
+			return nil, false, err
+		}
+		coins, err := rosettaParseAmount(op.Amount)
+		if err != nil {
+			return nil, false, err
+		}
+		negative := !coins.IsNonnegative()
+
+		switch {
+		case op.Type == rosettaOpFee:
+			if !negative || coins.ThetaWei.Sign() != 0 {
+				return nil, false, errors.New("The fee must be a negative GAMMA amount")
+			}
+			if feePayer != nil && *feePayer != address {
+				return nil, false, errors.New("The fee must be paid by a single sender")
+			}
+			feePayer = &address
+			tx.Fee = tx.Fee.Plus(coins.Negative())
+			fallthrough
+		case op.Type == rosettaOpTransfer && negative:
+			i, ok := inputs[address]
+			if !ok {
+				i = len(tx.Inputs)
+				inputs[address] = i
+				tx.Inputs = append(tx.Inputs, types.TxInput{Address: address, Coins: types.NewCoins(0, 0)})
+			}
+			tx.Inputs[i].Coins = tx.Inputs[i].Coins.Plus(coins.Negative())
+			if op.Type == rosettaOpTransfer {
+				transferred = transferred.Plus(coins)
+			}
+		case op.Type == rosettaOpTransfer:
+			i, ok := outputs[address]
+			if !ok {
+				i = len(tx.Outputs)
+				outputs[address] = i
+				tx.Outputs = append(tx.Outputs, types.TxOutput{Address: address, Coins: types.NewCoins(0, 0)})
+			}
+			tx.Outputs[i].Coins = tx.Outputs[i].Coins.Plus(coins)
+			transferred = transferred.Plus(coins)
+		default:
+			return nil, false, fmt.Errorf("Unsupported operation type %v", op.Type)
+		}
+	}
+
+	if len(tx.Inputs) == 0 || len(tx.Outputs) == 0 {
+		return nil, false, errors.New("Expected at least a sender and a recipient")
+	}
+	if !transferred.IsZero() {
+		return nil, false, errors.New("The coins sent and received must balance")
+	}
+	if feePayer != nil {
+		// The fee is charged to the first input, as by rosettaTxOperations
+		i := inputs[*feePayer]
+		tx.Inputs[0], tx.Inputs[i] = tx.Inputs[i], tx.Inputs[0]
+	}
+	return tx, feePayer != nil, nil
+}
+
+func rosettaParseAmount(amount *RosettaAmount) (types.Coins, error) {
+	value, ok := new(big.Int).SetString(amount.Value, 10)
+	if !ok {
+		return types.Coins{}, fmt.Errorf("Invalid amount %v", amount.Value)
+	}
+	switch amount.Currency {
+	case rosettaTheta:
+		return types.Coins{ThetaWei: value, GammaWei: big.NewInt(0)}, nil
+	case rosettaGamma:
+		return types.Coins{ThetaWei: big.NewInt(0), GammaWei: value}, nil
+	default:
+		return types.Coins{}, fmt.Errorf("Unsupported currency %v", amount.Currency.Symbol)
+	}
+}
+
+// rosettaCoins returns the coins of an amount built by rosettaOperations.add.
+func rosettaCoins(amount *RosettaAmount) types.Coins {
+	coins, _ := rosettaParseAmount(amount)
+	return coins
+}
+
+func rosettaDecodeSendTx(txHex string) (*types.SendTx, *RosettaError) {
+	raw, err := hex.DecodeString(strings.TrimPrefix(txHex, "0x"))
+	if err != nil {
+		return nil, rosettaErrInvalidTransaction.withError(err)
+	}
+	tx, err := types.TxFromBytes(raw)
+	if err != nil {
+		return nil, rosettaErrInvalidTransaction.withError(err)
+	}
+	sendTx, ok := tx.(*types.SendTx)
+	if !ok {
+		return nil, rosettaErrInvalidTransaction.withError(errors.New("Only SendTx can be constructed"))
+	}
+	return sendTx, nil
+}
+
+// ------------------------------- Blocks -----------------------------------
+
+func (t *ThetaRPCServer) rosettaLastFinalizedBlock() (*core.ExtendedBlock, *RosettaError) {
+	hash := t.consensus.GetSummary().LastFinalizedBlock
+	if hash.IsEmpty() {
+		return nil, rosettaErrUnavailable
+	}
+	block, err := t.chain.FindBlock(hash)
+	if err != nil {
+		return nil, rosettaErrUnavailable.withError(err)
+	}
+	return block, nil
+}
+
+// rosettaFindFinalizedBlock returns the finalized block of the given height and/or hash,
+// or the last finalized block if none is given.
+func (t *ThetaRPCServer) rosettaFindFinalizedBlock(id RosettaPartialBlockIdentifier) (*core.ExtendedBlock, *RosettaError) {
+	var hash *common.Hash
+	if id.Hash != nil && *id.Hash != "" {
+		parsed, err := parseHash(*id.Hash)
+		if err != nil {
+			return nil, rosettaErrInvalidRequest.withError(err)
+		}
+		hash = &parsed
+	}
+
+	var block *core.ExtendedBlock
+	switch {
+	case id.Index != nil:
+		if *id.Index < 0 {
+			return nil, rosettaErrInvalidRequest.withError(fmt.Errorf("Invalid block index %v", *id.Index))
+		}
+		block = t.findFinalizedBlockByHeight(uint64(*id.Index))
+	case hash != nil:
+		found, err := t.chain.FindBlock(*hash)
+		if err == nil && found.Status == core.BlockStatusFinalized {
+			block = found
+		}
+	default:
+		return t.rosettaLastFinalizedBlock()
+	}
+	if block == nil || (hash != nil && *hash != block.Hash()) {
+		return nil, rosettaErrBlockNotFound
+	}
+	return block, nil
+}
+
+func rosettaBlockIdentifier(block *core.ExtendedBlock) RosettaBlockIdentifier {
+	return RosettaBlockIdentifier{Index: int64(block.Height), Hash: block.Hash().Hex()}
+}
+
+func rosettaTimestamp(block *core.ExtendedBlock) int64 {
+	if block.Timestamp == nil {
+		return 0
+	}
+	return block.Timestamp.Int64() * 1000
+}
@@ -0,0 +1,66 @@
+package rpc
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/ledger/types"
+)
+
+func TestRosettaSendTxOperations(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	alice := common.HexToAddress("0x2E833968E5bB786Ae419c4d13189fB081Cc43bab")
+	bob := common.HexToAddress("0x9F1233798E905E173560071255140b4A8aBd3Ec6")
+	tx := &types.SendTx{
+		Fee: types.NewCoins(0, 1000000000000),
+		Inputs: []types.TxInput{{
+			Address: alice,
+			Coins:   types.NewCoins(10, 1000000000005),
+		}},
+		Outputs: []types.TxOutput{{
+			Address: bob,
+			Coins:   types.NewCoins(10, 5),
+		}},
+	}
+
+	ops := rosettaTxOperations(tx, "")
+	require.Equal(5, len(ops))
+	assert.Equal(rosettaOpTransfer, ops[0].Type)
+	assert.Equal("-10", ops[0].Amount.Value)
+	assert.Equal(rosettaTheta, ops[0].Amount.Currency)
+	assert.Equal(rosettaOpFee, ops[2].Type)
+	assert.Equal("-1000000000000", ops[2].Amount.Value)
+	assert.Equal(bob.Hex(), ops[4].Account.Address)
+	for i, op := range ops {
+		assert.Equal(int64(i), op.OperationIdentifier.Index)
+	}
+
+	// The operations build the same transaction
+	built, hasFee, err := rosettaSendTxFromOperations(ops)
+	require.Nil(err)
+	assert.True(hasFee)
+	assert.Equal(tx.Fee.GammaWei, built.Fee.GammaWei)
+	assert.Equal(big.NewInt(1000000000005), built.Inputs[0].Coins.GammaWei)
+	assert.Equal(big.NewInt(10), built.Outputs[0].Coins.ThetaWei)
+	assert.Equal(ops, rosettaTxOperations(built, ""))
+
+	// The transfers must balance
+	_, _, err = rosettaSendTxFromOperations(ops[:4])
+	assert.NotNil(err)
+
+	// The fee is paid in GAMMA
+	fee := ops[2]
+	fee.Amount = &RosettaAmount{Value: "-1", Currency: rosettaTheta}
+	_, _, err = rosettaSendTxFromOperations([]RosettaOperation{ops[0], ops[1], fee, ops[3], ops[4]})
+	assert.NotNil(err)
+
+	// Without fee operation
+	_, hasFee, err = rosettaSendTxFromOperations([]RosettaOperation{ops[0], ops[3]})
+	assert.Nil(err)
+	assert.False(hasFee)
+}
@@ -4,35 +4,45 @@ import (
 	"context"
 	"net"
 	"net/http"
+	"strconv"
 	"sync"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/gorilla/rpc/v2"
 	json "github.com/gorilla/rpc/v2/json2"
 	log "github.com/sirupsen/logrus"
-	"github.com/spf13/viper"
 	"github.com/thetatoken/ukulele/blockchain"
 	"github.com/thetatoken/ukulele/common"
 	"github.com/thetatoken/ukulele/common/util"
 	"github.com/thetatoken/ukulele/consensus"
 	"github.com/thetatoken/ukulele/ledger"
 	"github.com/thetatoken/ukulele/mempool"
-	"golang.org/x/net/netutil"
+	"github.com/thetatoken/ukulele/netsync"
+	"github.com/thetatoken/ukulele/store/database/backend"
+	"github.com/thetatoken/ukulele/webhook"
 )
 
 var logger *log.Entry
 
+const shutdownTimeout = 5 * time.Second
+
 // ThetaRPCServer is an instance of RPC service.
 type ThetaRPCServer struct {
 	mempool   *mempool.Mempool
 	ledger    *ledger.Ledger
 	chain     *blockchain.Chain
 	consensus *consensus.ConsensusEngine
+	syncMgr   *netsync.SyncManager
+	dbMonitor *backend.Monitor
+	webhooks  *webhook.Service // Nil if the webhooks are disabled
+
+	partialTxs *partiallySignedTxPool // Transactions collecting the signatures of multisig accounts
 
 	server   *http.Server
 	handler  *rpc.Server
 	router   *mux.Router
-	listener net.Listener
+	listener *limitListener
 
 	// Life cycle
 	wg      *sync.WaitGroup
@@ -42,7 +52,7 @@ type ThetaRPCServer struct {
 }
 
 // NewThetaRPCServer creates a new instance of ThetaRPCServer.
-func NewThetaRPCServer(mempool *mempool.Mempool, ledger *ledger.Ledger, chain *blockchain.Chain, consensus *consensus.ConsensusEngine) *ThetaRPCServer {
+func NewThetaRPCServer(mempool *mempool.Mempool, ledger *ledger.Ledger, chain *blockchain.Chain, consensus *consensus.ConsensusEngine, syncMgr *netsync.SyncManager, dbMonitor *backend.Monitor, webhooks *webhook.Service) *ThetaRPCServer {
 	t := &ThetaRPCServer{
 		wg: &sync.WaitGroup{},
 	}
@@ -51,14 +61,36 @@ func NewThetaRPCServer(mempool *mempool.Mempool, ledger *ledger.Ledger, chain *b
 	t.ledger = ledger
 	t.chain = chain
 	t.consensus = consensus
+	t.syncMgr = syncMgr
+	t.dbMonitor = dbMonitor
+	t.webhooks = webhooks
+	t.partialTxs = newPartiallySignedTxPool()
 
 	t.handler = rpc.NewServer()
 	t.handler.RegisterCodec(json.NewCodec(), "application/json")
 	t.handler.RegisterCodec(json.NewCodec(), "application/json;charset=UTF-8")
 	t.handler.RegisterService(t, "theta")
+	if common.GetConfig().RPC.AdminEnabled {
+		t.handler.RegisterService(newThetaAdminRPCService(t), "admin")
+	}
 
 	t.router = mux.NewRouter()
-	t.router.Handle("/rpc", t.handler)
+	t.router.Handle("/rpc", t.meterRequests(t.requireAdminTokenForAdminMethods(t.handler)))
+	if common.GetConfig().RPC.AdminEnabled {
+		t.registerDebugHandlers()
+	}
+	if common.GetConfig().RPC.RosettaEnabled {
+		t.registerRosettaHandlers()
+	}
+	if common.GetConfig().RPC.EthEnabled {
+		t.registerEthHandlers()
+	}
+	if common.GetConfig().RPC.RESTEnabled {
+		t.registerRESTHandlers()
+	}
+	if common.GetConfig().RPC.WebSocketEnabled {
+		t.registerWebSocketHandlers()
+	}
 
 	t.server = &http.Server{
 		Handler: t.router,
@@ -75,23 +107,26 @@ func (t *ThetaRPCServer) Start(ctx context.Context) {
 	t.ctx = c
 	t.cancel = cancel
 
+	t.wg.Add(1)
 	go t.mainLoop()
 }
 
 func (t *ThetaRPCServer) mainLoop() {
-	t.wg.Add(1)
 	defer t.wg.Done()
 
 	go t.serve()
 
 	<-t.ctx.Done()
 	t.stopped = true
-	t.server.Shutdown(t.ctx)
+	// The pending requests are given a few seconds to complete
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	t.server.Shutdown(ctx)
 }
 
 func (t *ThetaRPCServer) serve() {
-	port := viper.GetString(common.CfgRPCPort)
-	l, err := net.Listen("tcp", ":"+port)
+	port := common.GetConfig().RPC.Port
+	l, err := net.Listen("tcp", ":"+strconv.Itoa(port))
 	if err != nil {
 		logger.WithFields(log.Fields{"error": err}).Fatal("Failed to create listener")
 	} else {
@@ -99,10 +134,15 @@ func (t *ThetaRPCServer) serve() {
 	}
 	defer l.Close()
 
-	ll := netutil.LimitListener(l, viper.GetInt(common.CfgRPCMaxConnections))
+	ll := newLimitListener(l, common.GetConfig().RPC.MaxConnections)
 	t.listener = ll
+	common.OnConfigReload(func(config *common.Config) {
+		ll.SetLimit(config.RPC.MaxConnections)
+	})
 
-	logger.Fatal(t.server.Serve(ll))
+	if err := t.server.Serve(ll); err != http.ErrServerClosed {
+		logger.Fatal(err)
+	}
 }
 
 // Stop notifies all goroutines to stop without blocking.
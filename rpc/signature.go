@@ -0,0 +1,51 @@
+package rpc
+
+import (
+	"encoding/hex"
+	"net/http"
+
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/crypto"
+)
+
+// ------------------------------- RecoverSigner -----------------------------------
+
+type RecoverSignerArgs struct {
+	Data      string `json:"data"`      // Hex encoded bytes that were signed
+	Signature string `json:"signature"` // Hex encoded [R || S || V] signature
+	Message   bool   `json:"message"`   // Whether the data was signed as an arbitrary message, i.e. prefixed with the message domain separator
+}
+
+type RecoverSignerResult struct {
+	Address   common.Address `json:"address"`
+	PublicKey string         `json:"public_key"` // Hex encoded uncompressed public key
+}
+
+// RecoverSigner recovers the public key and the address of the signer of a signed
+// payload, e.g. the sign bytes of a transaction, or a message signed with
+// "banjo key sign-message".
+func (t *ThetaRPCServer) RecoverSigner(r *http.Request, args *RecoverSignerArgs, result *RecoverSignerResult) (err error) {
+	data, err := hex.DecodeString(args.Data)
+	if err != nil {
+		return err
+	}
+	sigBytes, err := hex.DecodeString(args.Signature)
+	if err != nil {
+		return err
+	}
+	sig, err := crypto.SignatureFromBytes(sigBytes)
+	if err != nil {
+		return err
+	}
+	if args.Message {
+		data = crypto.MessageSignBytes(data)
+	}
+
+	pubKey, err := sig.RecoverPublicKey(data)
+	if err != nil {
+		return err
+	}
+	result.Address = pubKey.Address()
+	result.PublicKey = hex.EncodeToString(pubKey.ToBytes())
+	return nil
+}
@@ -0,0 +1,50 @@
+package rpc
+
+import (
+	"errors"
+	"math/big"
+	"net/http"
+
+	"github.com/thetatoken/ukulele/common"
+)
+
+// ------------------------------ GetDatabaseStats -----------------------------------
+
+type GetDatabaseStatsArgs struct{}
+
+type DatabaseSize struct {
+	Category string            `json:"category"`
+	Size     common.JSONUint64 `json:"size"` // Approximate on-disk size in bytes
+}
+
+type GetDatabaseStatsResult struct {
+	Backend        string          `json:"backend"`
+	Sizes          []DatabaseSize  `json:"sizes"`
+	LastCompaction *common.JSONBig `json:"last_compaction"` // Unix time of the last background compaction, null if none
+}
+
+// GetDatabaseStats returns the approximate on-disk size of the database of the
+// node, in total and for each part of it, i.e. the chain indexes, and the blocks
+// and the ledger state.
+func (t *ThetaRPCServer) GetDatabaseStats(r *http.Request, args *GetDatabaseStatsArgs, result *GetDatabaseStatsResult) (err error) {
+	if t.dbMonitor == nil {
+		return errors.New("Database stats are not supported by the database of the node")
+	}
+	sizes, err := t.dbMonitor.Sizes()
+	if err != nil {
+		return err
+	}
+
+	result.Backend = common.GetConfig().Storage.Backend
+	result.Sizes = []DatabaseSize{}
+	for _, size := range sizes {
+		result.Sizes = append(result.Sizes, DatabaseSize{
+			Category: size.Name,
+			Size:     common.JSONUint64(size.Size),
+		})
+	}
+	if lastCompaction := t.dbMonitor.LastCompaction(); !lastCompaction.IsZero() {
+		result.LastCompaction = (*common.JSONBig)(big.NewInt(lastCompaction.Unix()))
+	}
+	return
+}
@@ -2,11 +2,18 @@ package rpc
 
 import (
 	"encoding/hex"
+	"math/big"
 	"net/http"
+	"sort"
 
+	json "github.com/gorilla/rpc/v2/json2"
 	log "github.com/sirupsen/logrus"
 
+	"github.com/thetatoken/ukulele/common"
+	rs "github.com/thetatoken/ukulele/common/result"
+	"github.com/thetatoken/ukulele/common/trace"
 	"github.com/thetatoken/ukulele/crypto"
+	"github.com/thetatoken/ukulele/ledger/types"
 )
 
 // ------------------------------- BroadcastRawTransaction -----------------------------------
@@ -19,6 +26,28 @@ type BroadcastRawTransactionResult struct {
 	TxHash string `json:"hash"`
 }
 
+// ErrCodeTxRejected is the JSON-RPC error code of a transaction rejected by the mempool.
+// The data of the error is a TxRejected.
+const ErrCodeTxRejected json.ErrorCode = -32002
+
+// TxRejected is the data of the error returned for a rejected transaction.
+type TxRejected struct {
+	Code rs.ErrorCode `json:"code"` // Result code of the transaction, as in the receipts
+	Name string       `json:"name"` // Name of the result code, e.g. "InvalidSequence"
+}
+
+func newTxRejectedError(err error) error {
+	code := rs.CodeOf(err)
+	return &json.Error{
+		Code:    ErrCodeTxRejected,
+		Message: err.Error(),
+		Data: TxRejected{
+			Code: code,
+			Name: code.String(),
+		},
+	}
+}
+
 func (t *ThetaRPCServer) BroadcastRawTransaction(r *http.Request, args *BroadcastRawTransactionArgs, result *BroadcastRawTransactionResult) (err error) {
 	txBytes, err := hex.DecodeString(args.TxBytes)
 	if err != nil {
@@ -30,5 +59,125 @@ func (t *ThetaRPCServer) BroadcastRawTransaction(r *http.Request, args *Broadcas
 
 	log.Infof("[rpc] broadcast raw transaction: %v", hex.EncodeToString(txBytes))
 
-	return t.mempool.InsertTransaction(txBytes)
+	span := trace.StartHashSpan(hash, "rpc.BroadcastRawTransaction")
+	defer span.Finish()
+	err = t.mempool.InsertTransaction(txBytes)
+	span.SetError(err)
+	if err != nil {
+		return newTxRejectedError(err)
+	}
+	return nil
+}
+
+// ------------------------------- EstimateFee -----------------------------------
+
+const (
+	defaultFeeEstimationBlocks = 20
+	maxFeeEstimationBlocks     = 100
+)
+
+type EstimateFeeArgs struct {
+	NumBlocks common.JSONUint64 `json:"num_blocks"` // Number of recent finalized blocks to sample
+}
+
+type EstimateFeeResult struct {
+	Fee      *common.JSONBig `json:"fee"`       // Suggested fee in GammaWei for regular transactions
+	GasPrice *common.JSONBig `json:"gas_price"` // Suggested gas price in GammaWei for smart contract transactions
+}
+
+// EstimateFee suggests the transaction fee and gas price based on the median values paid by
+// the transactions included in the most recent finalized blocks. The suggestions never go
+// below the minimum values accepted by the ledger.
+func (t *ThetaRPCServer) EstimateFee(r *http.Request, args *EstimateFeeArgs, result *EstimateFeeResult) (err error) {
+	numBlocks := uint64(args.NumBlocks)
+	if numBlocks == 0 {
+		numBlocks = defaultFeeEstimationBlocks
+	}
+	if numBlocks > maxFeeEstimationBlocks {
+		numBlocks = maxFeeEstimationBlocks
+	}
+
+	fees := []*big.Int{}
+	gasPrices := []*big.Int{}
+	hash := t.consensus.GetSummary().LastFinalizedBlock
+	for i := uint64(0); i < numBlocks && !hash.IsEmpty(); i++ {
+		block, err := t.chain.FindBlock(hash)
+		if err != nil {
+			break
+		}
+		for _, txBytes := range block.Txs {
+			tx, err := types.TxFromBytes(txBytes)
+			if err != nil {
+				continue
+			}
+			switch tx := tx.(type) {
+			case *types.SendTx:
+				fees = append(fees, tx.Fee.GammaWei)
+			case *types.TimelockedSendTx:
+				fees = append(fees, tx.Fee.GammaWei)
+			case *types.ReserveFundTx:
+				fees = append(fees, tx.Fee.GammaWei)
+			case *types.ReleaseFundTx:
+				fees = append(fees, tx.Fee.GammaWei)
+			case *types.ExtendReserveTx:
+				fees = append(fees, tx.Fee.GammaWei)
+			case *types.ServicePaymentTx:
+				fees = append(fees, tx.Fee.GammaWei)
+			case *types.BatchServicePaymentTx:
+				fees = append(fees, tx.Fee.GammaWei)
+			case *types.SplitRuleTx:
+				fees = append(fees, tx.Fee.GammaWei)
+			case *types.SetGuardiansTx:
+				fees = append(fees, tx.Fee.GammaWei)
+			case *types.RecoveryTx:
+				fees = append(fees, tx.Fee.GammaWei)
+			case *types.CreateTokenTx:
+				fees = append(fees, tx.Fee.GammaWei)
+			case *types.ParameterUpdateTx:
+				fees = append(fees, tx.Fee.GammaWei)
+			case *types.ProposalTx:
+				fees = append(fees, tx.Fee.GammaWei)
+			case *types.VoteTx:
+				fees = append(fees, tx.Fee.GammaWei)
+			case *types.UnjailTx:
+				fees = append(fees, tx.Fee.GammaWei)
+			case *types.SmartContractTx:
+				gasPrices = append(gasPrices, tx.GasPrice)
+			}
+		}
+		hash = block.Parent
+	}
+
+	minimumFee := types.DefaultChainParameters().MinimumTransactionFeeGammaWei
+	if ledgerState, err := t.ledger.GetDeliveredSnapshot(); err == nil {
+		minimumFee = ledgerState.GetChainParameters().MinimumTransactionFeeGammaWei
+	}
+	fee := medianOrMinimum(fees, new(big.Int).SetUint64(minimumFee))
+	gasPrice := medianOrMinimum(gasPrices, new(big.Int).SetUint64(types.MinimumGasPrice))
+	result.Fee = (*common.JSONBig)(fee)
+	result.GasPrice = (*common.JSONBig)(gasPrice)
+
+	return nil
+}
+
+// medianOrMinimum returns the median of the given values, or the minimum if the median is
+// smaller or there is no value at all.
+func medianOrMinimum(values []*big.Int, minimum *big.Int) *big.Int {
+	sorted := []*big.Int{}
+	for _, v := range values {
+		if v != nil {
+			sorted = append(sorted, v)
+		}
+	}
+	if len(sorted) == 0 {
+		return minimum
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Cmp(sorted[j]) < 0
+	})
+	median := sorted[len(sorted)/2]
+	if median.Cmp(minimum) < 0 {
+		return minimum
+	}
+	return new(big.Int).Set(median)
 }
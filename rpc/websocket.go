@@ -0,0 +1,91 @@
+package rpc
+
+import (
+	"io"
+	"io/ioutil"
+	"strconv"
+	"time"
+
+	"github.com/thetatoken/ukulele/common"
+	"golang.org/x/net/websocket"
+)
+
+const (
+	// wsPollInterval is the interval at which the subscriptions check for new finalized blocks.
+	wsPollInterval = time.Second
+
+	// wsWriteTimeout is how long a slow subscriber can take to receive a block before
+	// it is disconnected.
+	wsWriteTimeout = 10 * time.Second
+)
+
+// BlockNotification is a message of the stream of finalized blocks: a block, or the
+// error that ends the stream.
+type BlockNotification struct {
+	Block *GetBlockResult `json:"block,omitempty"`
+	Error string          `json:"error,omitempty"`
+}
+
+// registerWebSocketHandlers serves the stream of finalized blocks on /ws/blocks.
+func (t *ThetaRPCServer) registerWebSocketHandlers() {
+	// The subscribers are programs rather than browsers, so the origin is not checked
+	t.router.Handle("/ws/blocks", websocket.Server{Handler: t.streamFinalizedBlocks})
+}
+
+// streamFinalizedBlocks sends the finalized blocks to the subscriber in the order of
+// their heights, as returned by GetBlockByHeight, from the height in the from_height
+// query parameter or from the next finalized block. A subscriber resumes after a
+// disconnection from the height following the last block it received.
+func (t *ThetaRPCServer) streamFinalizedBlocks(ws *websocket.Conn) {
+	defer ws.Close()
+
+	next := t.consensus.GetLastFinalizedBlock().Height + 1
+	if fromHeight := ws.Request().URL.Query().Get("from_height"); fromHeight != "" {
+		height, err := strconv.ParseUint(fromHeight, 10, 64)
+		if err != nil || height == 0 {
+			t.sendBlockNotification(ws, BlockNotification{Error: "Invalid from_height: " + fromHeight})
+			return
+		}
+		next = height
+	}
+
+	closed := make(chan struct{})
+	go func() {
+		// The subscribers send nothing, so the reads only return once the connection is closed
+		io.Copy(ioutil.Discard, ws)
+		close(closed)
+	}()
+
+	ticker := time.NewTicker(wsPollInterval)
+	defer ticker.Stop()
+	for {
+		last := t.consensus.GetLastFinalizedBlock().Height
+		for ; next <= last; next++ {
+			result := GetBlockResult{}
+			err := t.GetBlockByHeight(ws.Request(), &GetBlockByHeightArgs{Height: common.JSONUint64(next)}, &result)
+			if err != nil {
+				t.sendBlockNotification(ws, BlockNotification{Error: err.Error()})
+				return
+			}
+			if result.GetBlockResultInner == nil {
+				break // Not indexed yet, retried at the next poll
+			}
+			if err := t.sendBlockNotification(ws, BlockNotification{Block: &result}); err != nil {
+				return
+			}
+		}
+
+		select {
+		case <-closed:
+			return
+		case <-t.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (t *ThetaRPCServer) sendBlockNotification(ws *websocket.Conn, notification BlockNotification) error {
+	ws.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	return websocket.JSON.Send(ws, notification)
+}
@@ -0,0 +1,40 @@
+package backend
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/thetatoken/ukulele/store/database"
+)
+
+// Names of the storage backends a node can be configured with.
+const (
+	BackendLevelDB  = "leveldb"
+	BackendRocksDB  = "rocksdb"
+	BackendBadgerDB = "badgerdb"
+	BackendMemDB    = "memdb"
+)
+
+var (
+	_ database.Backend = (*LDBDatabase)(nil)
+	_ database.Backend = (*BadgerDatabase)(nil)
+	_ database.Backend = (*MemDatabase)(nil)
+)
+
+// NewBackend opens the storage backend of the given name under the folder dir. The
+// cache (in MB) and the file handles only apply to LevelDB and RocksDB. The
+// in-memory backend is not persisted, and is meant for tests.
+func NewBackend(name string, dir string, cache int, handles int) (database.Backend, error) {
+	switch name {
+	case BackendLevelDB, "":
+		return NewLDBDatabase(path.Join(dir, "main"), path.Join(dir, "ref"), cache, handles)
+	case BackendRocksDB:
+		return openRocksDatabase(path.Join(dir, "rocksdb", "main"), path.Join(dir, "rocksdb", "ref"), cache, handles)
+	case BackendBadgerDB:
+		return NewBadgerDatabase(path.Join(dir, "badgerdb"))
+	case BackendMemDB:
+		return NewMemDatabase(), nil
+	default:
+		return nil, fmt.Errorf("Unknown storage backend: %v", name)
+	}
+}
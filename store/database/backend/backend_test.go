@@ -0,0 +1,35 @@
+package backend
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewBackend(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir(os.TempDir(), "backend_test_")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+
+	db, err := NewBackend(BackendLevelDB, dir, 0, 0)
+	assert.Nil(err)
+	_, ok := db.(*LDBDatabase)
+	assert.True(ok)
+	assert.Nil(db.Put([]byte("key"), []byte("value")))
+	value, err := db.Get([]byte("key"))
+	assert.Nil(err)
+	assert.Equal([]byte("value"), value)
+	db.Close()
+
+	db, err = NewBackend(BackendMemDB, dir, 0, 0)
+	assert.Nil(err)
+	_, ok = db.(*MemDatabase)
+	assert.True(ok)
+
+	_, err = NewBackend("unknown", dir, 0, 0)
+	assert.NotNil(err)
+}
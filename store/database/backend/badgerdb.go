@@ -318,3 +318,163 @@ func (b *badgerdbBatch) Reset() {
 	b.references = make(map[string]int)
 	b.size = 0
 }
+
+// NewIteratorWithPrefix returns an iterator over the keys with the given prefix. The
+// iterator does not see the writes made after it was created.
+func (db *BadgerDatabase) NewIteratorWithPrefix(prefix []byte) database.Iterator {
+	return newBadgerIterator(db.db.NewTransaction(false), prefix, true)
+}
+
+// NewSnapshot returns a read-only view of the current content of the database,
+// backed by a read-only transaction.
+func (db *BadgerDatabase) NewSnapshot() (database.Snapshot, error) {
+	return &badgerSnapshot{txn: db.db.NewTransaction(false)}, nil
+}
+
+// SizeOfPrefix returns the approximate on-disk size of the keys with the given
+// prefix. The size of the whole database comes from the size of the LSM tree and
+// the value log, while the size of a prefix is estimated from its items.
+func (db *BadgerDatabase) SizeOfPrefix(prefix []byte) (uint64, error) {
+	if len(prefix) == 0 {
+		lsm, vlog := db.db.Size()
+		return uint64(lsm + vlog), nil
+	}
+
+	size := uint64(0)
+	err := db.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			size += uint64(it.Item().EstimatedSize())
+		}
+		return nil
+	})
+	return size, err
+}
+
+// Compact runs the garbage collection of the value log until there is nothing
+// left to rewrite. Badger compacts the LSM tree by itself.
+func (db *BadgerDatabase) Compact() error {
+	for {
+		err := db.db.RunValueLogGC(0.5)
+		if err == badger.ErrNoRewrite {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+type badgerSnapshot struct {
+	txn *badger.Txn
+}
+
+func (s *badgerSnapshot) Get(key []byte) ([]byte, error) {
+	item, err := s.txn.Get(key)
+	if err != nil {
+		if err == badger.ErrKeyNotFound || err == badger.ErrEmptyKey {
+			return nil, store.ErrKeyNotFound
+		}
+		return nil, err
+	}
+	var document Document
+	err = item.Value(func(val []byte) error {
+		return json.Unmarshal(val, &document)
+	})
+	return document.Value, err
+}
+
+func (s *badgerSnapshot) Has(key []byte) (bool, error) {
+	_, err := s.txn.Get(key)
+	if err != nil {
+		if err == badger.ErrKeyNotFound || err == badger.ErrEmptyKey {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *badgerSnapshot) NewIteratorWithPrefix(prefix []byte) database.Iterator {
+	return newBadgerIterator(s.txn, prefix, false)
+}
+
+func (s *badgerSnapshot) Release() {
+	s.txn.Discard()
+}
+
+type badgerIterator struct {
+	txn     *badger.Txn
+	it      *badger.Iterator
+	prefix  []byte
+	ownsTxn bool // whether releasing the iterator discards the transaction
+	started bool
+	key     []byte
+	value   []byte
+	err     error
+}
+
+func newBadgerIterator(txn *badger.Txn, prefix []byte, ownsTxn bool) *badgerIterator {
+	return &badgerIterator{
+		txn:     txn,
+		it:      txn.NewIterator(badger.DefaultIteratorOptions),
+		prefix:  prefix,
+		ownsTxn: ownsTxn,
+	}
+}
+
+func (it *badgerIterator) Next() bool {
+	if it.err != nil || it.it == nil {
+		return false
+	}
+	if it.started {
+		it.it.Next()
+	} else {
+		it.it.Seek(it.prefix)
+		it.started = true
+	}
+	if !it.it.ValidForPrefix(it.prefix) {
+		it.key, it.value = nil, nil
+		return false
+	}
+
+	item := it.it.Item()
+	var document Document
+	err := item.Value(func(val []byte) error {
+		return json.Unmarshal(val, &document)
+	})
+	if err != nil {
+		it.err = err
+		it.key, it.value = nil, nil
+		return false
+	}
+	it.key = item.KeyCopy(nil)
+	it.value = document.Value
+	return true
+}
+
+func (it *badgerIterator) Key() []byte {
+	return it.key
+}
+
+func (it *badgerIterator) Value() []byte {
+	return it.value
+}
+
+func (it *badgerIterator) Error() error {
+	return it.err
+}
+
+func (it *badgerIterator) Release() {
+	if it.it == nil {
+		return
+	}
+	it.it.Close()
+	it.it = nil
+	if it.ownsTxn {
+		it.txn.Discard()
+	}
+}
@@ -34,3 +34,15 @@ func TestBadgerDB_PutGet(t *testing.T) {
 	defer close()
 	testPutGet(db, batch, t)
 }
+
+func TestBadgerDB_Iterator(t *testing.T) {
+	db, _, close := newTestBDB()
+	defer close()
+	testIterator(db, t)
+}
+
+func TestBadgerDB_Snapshot(t *testing.T) {
+	db, _, close := newTestBDB()
+	defer close()
+	testSnapshot(db, t)
+}
@@ -19,6 +19,8 @@ package backend
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"sync"
@@ -44,6 +46,7 @@ var OpenFileLimit = 64
 
 type LDBDatabase struct {
 	fn    string      // filename for reporting
+	reffn string      // filename of the reference db
 	db    *leveldb.DB // LevelDB instance
 	refdb *leveldb.DB // LevelDB instance for references
 
@@ -102,6 +105,7 @@ func NewLDBDatabase(file string, reffile string, cache int, handles int) (*LDBDa
 
 	return &LDBDatabase{
 		fn:    file,
+		reffn: reffile,
 		db:    db,
 		refdb: refdb,
 	}, nil
@@ -239,10 +243,19 @@ func (db *LDBDatabase) NewIterator() iterator.Iterator {
 }
 
 // NewIteratorWithPrefix returns a iterator to iterate over subset of database content with a particular prefix.
-func (db *LDBDatabase) NewIteratorWithPrefix(prefix []byte) iterator.Iterator {
+func (db *LDBDatabase) NewIteratorWithPrefix(prefix []byte) database.Iterator {
 	return db.db.NewIterator(util.BytesPrefix(prefix), nil)
 }
 
+// NewSnapshot returns a read-only view of the current content of the database.
+func (db *LDBDatabase) NewSnapshot() (database.Snapshot, error) {
+	snap, err := db.db.GetSnapshot()
+	if err != nil {
+		return nil, err
+	}
+	return &ldbSnapshot{snap: snap}, nil
+}
+
 func (db *LDBDatabase) Close() {
 	// Stop the metrics collection to avoid internal database races
 	db.quitLock.Lock()
@@ -569,6 +582,78 @@ func (b *ldbBatch) Reset() {
 	b.size = 0
 }
 
+// SizeOfPrefix returns the approximate on-disk size of the keys with the given
+// prefix. It only counts the data already written to the table files. The size of
+// the whole database is the size of its files, including the reference counts.
+func (db *LDBDatabase) SizeOfPrefix(prefix []byte) (uint64, error) {
+	if len(prefix) == 0 {
+		size, err := dirSize(db.fn)
+		if err != nil {
+			return 0, err
+		}
+		refSize, err := dirSize(db.reffn)
+		if err != nil {
+			return 0, err
+		}
+		return size + refSize, nil
+	}
+	sizes, err := db.db.SizeOf([]util.Range{*util.BytesPrefix(prefix)})
+	if err != nil {
+		return 0, err
+	}
+	return uint64(sizes.Sum()), nil
+}
+
+// dirSize returns the total size of the files in the folder.
+func dirSize(dir string) (uint64, error) {
+	size := uint64(0)
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			size += uint64(info.Size())
+		}
+		return nil
+	})
+	return size, err
+}
+
+// Compact compacts the whole database, including the reference counts.
+func (db *LDBDatabase) Compact() error {
+	if err := db.db.CompactRange(util.Range{}); err != nil {
+		return err
+	}
+	return db.refdb.CompactRange(util.Range{})
+}
+
+type ldbSnapshot struct {
+	snap *leveldb.Snapshot
+}
+
+func (s *ldbSnapshot) Get(key []byte) ([]byte, error) {
+	dat, err := s.snap.Get(key, nil)
+	if err != nil {
+		if err == leveldb.ErrNotFound {
+			return nil, store.ErrKeyNotFound
+		}
+		return nil, err
+	}
+	return dat, nil
+}
+
+func (s *ldbSnapshot) Has(key []byte) (bool, error) {
+	return s.snap.Has(key, nil)
+}
+
+func (s *ldbSnapshot) NewIteratorWithPrefix(prefix []byte) database.Iterator {
+	return s.snap.NewIterator(util.BytesPrefix(prefix), nil)
+}
+
+func (s *ldbSnapshot) Release() {
+	s.snap.Release()
+}
+
 type table struct {
 	db     database.Database
 	prefix string
@@ -479,3 +479,105 @@ func testParallelPutGet(db database.Database, t *testing.T) {
 	}
 	pending.Wait()
 }
+
+func TestLDB_Iterator(t *testing.T) {
+	db, remove := newTestLDB()
+	defer remove()
+	testIterator(db, t)
+}
+
+func TestMemoryDB_Iterator(t *testing.T) {
+	testIterator(NewMemDatabase(), t)
+}
+
+func testIterator(db database.Backend, t *testing.T) {
+	content := map[string]string{
+		"a1": "v1", "a3": "v3", "a2": "v2", "b1": "w1", "a": "v",
+	}
+	for k, v := range content {
+		if err := db.Put([]byte(k), []byte(v)); err != nil {
+			t.Fatalf("put failed: %v", err)
+		}
+	}
+
+	it := db.NewIteratorWithPrefix([]byte("a"))
+	keys := []string{}
+	for it.Next() {
+		if string(it.Value()) != content[string(it.Key())] {
+			t.Fatalf("wrong value for %q: got %q expected %q", it.Key(), it.Value(), content[string(it.Key())])
+		}
+		keys = append(keys, string(it.Key()))
+	}
+	if err := it.Error(); err != nil {
+		t.Fatalf("iteration failed: %v", err)
+	}
+	it.Release()
+	if fmt.Sprint(keys) != "[a a1 a2 a3]" {
+		t.Fatalf("wrong keys iterated: %v", keys)
+	}
+
+	it = db.NewIteratorWithPrefix([]byte("c"))
+	if it.Next() {
+		t.Fatalf("iterated over unexpected key %q", it.Key())
+	}
+	it.Release()
+}
+
+func TestLDB_Snapshot(t *testing.T) {
+	db, remove := newTestLDB()
+	defer remove()
+	testSnapshot(db, t)
+}
+
+func TestMemoryDB_Snapshot(t *testing.T) {
+	testSnapshot(NewMemDatabase(), t)
+}
+
+func testSnapshot(db database.Backend, t *testing.T) {
+	if err := db.Put([]byte("k1"), []byte("v1")); err != nil {
+		t.Fatalf("put failed: %v", err)
+	}
+	snap, err := db.NewSnapshot()
+	if err != nil {
+		t.Fatalf("snapshot failed: %v", err)
+	}
+	defer snap.Release()
+
+	// Writes after the snapshot is taken are not visible through it
+	if err := db.Put([]byte("k1"), []byte("v2")); err != nil {
+		t.Fatalf("put failed: %v", err)
+	}
+	if err := db.Put([]byte("k2"), []byte("v2")); err != nil {
+		t.Fatalf("put failed: %v", err)
+	}
+
+	data, err := snap.Get([]byte("k1"))
+	if err != nil {
+		t.Fatalf("get failed: %v", err)
+	}
+	if !bytes.Equal(data, []byte("v1")) {
+		t.Fatalf("get returned wrong result, got %q expected %q", data, "v1")
+	}
+	if _, err := snap.Get([]byte("k2")); err != store.ErrKeyNotFound {
+		t.Fatalf("expected key not found, got %v", err)
+	}
+	has, err := snap.Has([]byte("k2"))
+	if err != nil || has {
+		t.Fatalf("has returned wrong result: %v, %v", has, err)
+	}
+
+	it := snap.NewIteratorWithPrefix([]byte("k"))
+	defer it.Release()
+	count := 0
+	for it.Next() {
+		count++
+	}
+	if count != 1 {
+		t.Fatalf("iterated over %d keys, expected 1", count)
+	}
+
+	data, err = db.Get([]byte("k1"))
+	if err != nil || !bytes.Equal(data, []byte("v2")) {
+		t.Fatalf("get returned wrong result, got %q expected %q", data, "v2")
+	}
+}
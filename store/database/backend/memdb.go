@@ -18,6 +18,8 @@
 package backend
 
 import (
+	"sort"
+	"strings"
 	"sync"
 
 	"github.com/thetatoken/ukulele/common"
@@ -141,6 +143,121 @@ func (db *MemDatabase) NewBatch() database.Batch {
 
 func (db *MemDatabase) Len() int { return len(db.db) }
 
+// NewIteratorWithPrefix returns an iterator over the keys with the given prefix. The
+// iterator does not see the writes made after it was created.
+func (db *MemDatabase) NewIteratorWithPrefix(prefix []byte) database.Iterator {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	return newMemIterator(db.db, prefix)
+}
+
+// NewSnapshot returns a read-only view of the current content of the database. It
+// copies the content, which is fine for the test database.
+func (db *MemDatabase) NewSnapshot() (database.Snapshot, error) {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	content := make(map[string][]byte, len(db.db))
+	for k, v := range db.db {
+		content[k] = v
+	}
+	return &memSnapshot{db: content}, nil
+}
+
+// SizeOfPrefix returns the size of the keys with the given prefix and their values.
+func (db *MemDatabase) SizeOfPrefix(prefix []byte) (uint64, error) {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	size := uint64(0)
+	for k, v := range db.db {
+		if strings.HasPrefix(k, string(prefix)) {
+			size += uint64(len(k) + len(v))
+		}
+	}
+	return size, nil
+}
+
+// Compact does nothing, there is nothing to reclaim in memory.
+func (db *MemDatabase) Compact() error {
+	return nil
+}
+
+type memSnapshot struct {
+	db map[string][]byte
+}
+
+func (s *memSnapshot) Get(key []byte) ([]byte, error) {
+	if entry, ok := s.db[string(key)]; ok {
+		return common.CopyBytes(entry), nil
+	}
+	return nil, store.ErrKeyNotFound
+}
+
+func (s *memSnapshot) Has(key []byte) (bool, error) {
+	_, ok := s.db[string(key)]
+	return ok, nil
+}
+
+func (s *memSnapshot) NewIteratorWithPrefix(prefix []byte) database.Iterator {
+	return newMemIterator(s.db, prefix)
+}
+
+func (s *memSnapshot) Release() {}
+
+type memIterator struct {
+	keys   []string
+	values [][]byte
+	index  int
+}
+
+// newMemIterator collects the pairs with the prefix in ascending key order. The
+// caller needs to hold the lock of the content.
+func newMemIterator(content map[string][]byte, prefix []byte) *memIterator {
+	keys := []string{}
+	for k := range content {
+		if strings.HasPrefix(k, string(prefix)) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	values := make([][]byte, len(keys))
+	for i, k := range keys {
+		values[i] = content[k]
+	}
+	return &memIterator{keys: keys, values: values, index: -1}
+}
+
+func (it *memIterator) Next() bool {
+	if it.index < len(it.keys) {
+		it.index++
+	}
+	return it.index < len(it.keys)
+}
+
+func (it *memIterator) Key() []byte {
+	if it.index < 0 || it.index >= len(it.keys) {
+		return nil
+	}
+	return []byte(it.keys[it.index])
+}
+
+func (it *memIterator) Value() []byte {
+	if it.index < 0 || it.index >= len(it.keys) {
+		return nil
+	}
+	return common.CopyBytes(it.values[it.index])
+}
+
+func (it *memIterator) Error() error { return nil }
+
+func (it *memIterator) Release() {
+	it.keys = nil
+	it.values = nil
+}
+
 type kv struct {
 	k, v []byte
 	del  bool
@@ -0,0 +1,67 @@
+package backend
+
+import (
+	"time"
+
+	"github.com/thetatoken/ukulele/common/metrics"
+	"github.com/thetatoken/ukulele/store/database"
+)
+
+var _ database.Backend = (*MeteredBackend)(nil)
+
+// MeteredBackend wraps a backend to measure the latency of its reads and writes.
+type MeteredBackend struct {
+	database.Backend
+
+	readTimer  metrics.Timer // Get and Has
+	writeTimer metrics.Timer // Put, Delete and the writes of batches
+}
+
+// NewMeteredBackend wraps the given backend to measure the latency of its reads and writes.
+func NewMeteredBackend(db database.Backend) *MeteredBackend {
+	return &MeteredBackend{
+		Backend:    db,
+		readTimer:  metrics.GetOrRegisterTimer("db/read", nil),
+		writeTimer: metrics.GetOrRegisterTimer("db/write", nil),
+	}
+}
+
+// Get implements database.Database.
+func (db *MeteredBackend) Get(key []byte) ([]byte, error) {
+	defer db.readTimer.UpdateSince(time.Now())
+	return db.Backend.Get(key)
+}
+
+// Has implements database.Database.
+func (db *MeteredBackend) Has(key []byte) (bool, error) {
+	defer db.readTimer.UpdateSince(time.Now())
+	return db.Backend.Has(key)
+}
+
+// Put implements database.Database.
+func (db *MeteredBackend) Put(key []byte, value []byte) error {
+	defer db.writeTimer.UpdateSince(time.Now())
+	return db.Backend.Put(key, value)
+}
+
+// Delete implements database.Database.
+func (db *MeteredBackend) Delete(key []byte) error {
+	defer db.writeTimer.UpdateSince(time.Now())
+	return db.Backend.Delete(key)
+}
+
+// NewBatch implements database.Database.
+func (db *MeteredBackend) NewBatch() database.Batch {
+	return &meteredBatch{Batch: db.Backend.NewBatch(), writeTimer: db.writeTimer}
+}
+
+type meteredBatch struct {
+	database.Batch
+
+	writeTimer metrics.Timer
+}
+
+func (b *meteredBatch) Write() error {
+	defer b.writeTimer.UpdateSince(time.Now())
+	return b.Batch.Write()
+}
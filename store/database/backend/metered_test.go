@@ -0,0 +1,33 @@
+package backend
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thetatoken/ukulele/common/metrics"
+)
+
+func TestMeteredBackend(t *testing.T) {
+	assert := assert.New(t)
+
+	enabled := metrics.Enabled
+	metrics.Enabled = true
+	defer func() { metrics.Enabled = enabled }()
+
+	db := NewMeteredBackend(NewMemDatabase())
+	reads, writes := db.readTimer.Count(), db.writeTimer.Count()
+
+	assert.Nil(db.Put([]byte("k1"), []byte("v1")))
+	batch := db.NewBatch()
+	batch.Put([]byte("k2"), []byte("v2"))
+	assert.Nil(batch.Write())
+	assert.Equal(writes+2, db.writeTimer.Count())
+
+	value, err := db.Get([]byte("k2"))
+	assert.Nil(err)
+	assert.Equal([]byte("v2"), value)
+	has, err := db.Has([]byte("k1"))
+	assert.Nil(err)
+	assert.True(has)
+	assert.Equal(reads+2, db.readTimer.Count())
+}
@@ -0,0 +1,190 @@
+package backend
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/common/metrics"
+	"github.com/thetatoken/ukulele/store/database"
+)
+
+// TotalCategory is the name of the size of the whole database.
+const TotalCategory = "total"
+
+// Category is a named part of the key space of a database, made of the keys with
+// any of the prefixes. A category without prefixes is made of the keys not in any
+// other category.
+type Category struct {
+	Name     string
+	Prefixes []common.Bytes
+}
+
+// CategorySize is the approximate on-disk size of a category in bytes.
+type CategorySize struct {
+	Name string
+	Size uint64
+}
+
+// Monitor periodically reports the on-disk size of the categories of a database
+// as "db/size/<category>" gauges, and periodically compacts the database.
+type Monitor struct {
+	db                 database.Backend
+	categories         []Category
+	statsInterval      time.Duration
+	compactionInterval time.Duration
+
+	compactionTimer metrics.Timer
+
+	mu             sync.Mutex
+	lastCompaction time.Time
+
+	// Life cycle
+	wg     *sync.WaitGroup
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewMonitor creates an instance of Monitor. A zero interval disables the size
+// reports or the compactions respectively.
+func NewMonitor(db database.Backend, categories []Category, statsInterval, compactionInterval time.Duration) *Monitor {
+	return &Monitor{
+		db:                 db,
+		categories:         categories,
+		statsInterval:      statsInterval,
+		compactionInterval: compactionInterval,
+		compactionTimer:    metrics.GetOrRegisterTimer("db/compaction", nil),
+		wg:                 &sync.WaitGroup{},
+	}
+}
+
+// Start starts the periodic size reports and compactions.
+func (m *Monitor) Start(ctx context.Context) {
+	c, cancel := context.WithCancel(ctx)
+	m.ctx = c
+	m.cancel = cancel
+
+	if m.statsInterval > 0 {
+		m.wg.Add(1)
+		go m.reportLoop()
+	}
+	if m.compactionInterval > 0 {
+		m.wg.Add(1)
+		go m.compactionLoop()
+	}
+}
+
+// Stop notifies all goroutines to stop without blocking.
+func (m *Monitor) Stop() {
+	m.cancel()
+}
+
+// Wait blocks until all goroutines stop.
+func (m *Monitor) Wait() {
+	m.wg.Wait()
+}
+
+// Sizes returns the approximate on-disk size of the whole database, followed by
+// the size of each category.
+func (m *Monitor) Sizes() ([]CategorySize, error) {
+	total, err := m.db.SizeOfPrefix(nil)
+	if err != nil {
+		return nil, err
+	}
+	sizes := []CategorySize{{Name: TotalCategory, Size: total}}
+
+	covered := uint64(0)
+	rest := -1
+	for i, category := range m.categories {
+		if len(category.Prefixes) == 0 {
+			rest = i
+			sizes = append(sizes, CategorySize{Name: category.Name})
+			continue
+		}
+		size := uint64(0)
+		for _, prefix := range category.Prefixes {
+			prefixSize, err := m.db.SizeOfPrefix(prefix)
+			if err != nil {
+				return nil, err
+			}
+			size += prefixSize
+		}
+		covered += size
+		sizes = append(sizes, CategorySize{Name: category.Name, Size: size})
+	}
+	// The sizes are estimated separately, so the prefixes may add up to more than
+	// the total.
+	if rest >= 0 && total > covered {
+		sizes[rest+1].Size = total - covered
+	}
+	return sizes, nil
+}
+
+// Compact compacts the database, and records the time it took.
+func (m *Monitor) Compact() error {
+	start := time.Now()
+	if err := m.db.Compact(); err != nil {
+		return err
+	}
+	m.compactionTimer.UpdateSince(start)
+
+	m.mu.Lock()
+	m.lastCompaction = time.Now()
+	m.mu.Unlock()
+	return nil
+}
+
+// LastCompaction returns when the database was last compacted by the monitor. It
+// returns the zero time if it has not been.
+func (m *Monitor) LastCompaction() time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.lastCompaction
+}
+
+func (m *Monitor) reportLoop() {
+	defer m.wg.Done()
+
+	ticker := time.NewTicker(m.statsInterval)
+	defer ticker.Stop()
+	for {
+		m.report()
+		select {
+		case <-m.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (m *Monitor) report() {
+	sizes, err := m.Sizes()
+	if err != nil {
+		log.WithFields(log.Fields{"err": err}).Warn("Failed to estimate database size")
+		return
+	}
+	for _, size := range sizes {
+		metrics.GetOrRegisterGauge("db/size/"+size.Name, nil).Update(int64(size.Size))
+	}
+}
+
+func (m *Monitor) compactionLoop() {
+	defer m.wg.Done()
+
+	ticker := time.NewTicker(m.compactionInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		log.Infof("Compacting database")
+		if err := m.Compact(); err != nil {
+			log.WithFields(log.Fields{"err": err}).Warn("Failed to compact database")
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package backend
+
+import (
+	"crypto/rand"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thetatoken/ukulele/common"
+)
+
+func TestMonitorSizes(t *testing.T) {
+	assert := assert.New(t)
+
+	db := NewMemDatabase()
+	db.Put([]byte("bh/1"), []byte("12345"))
+	db.Put([]byte("tx/1"), []byte("123"))
+	db.Put([]byte("0123456789"), []byte(strings.Repeat("0", 90)))
+
+	categories := []Category{
+		{Name: "indexes", Prefixes: []common.Bytes{common.Bytes("bh/"), common.Bytes("tx/")}},
+		{Name: "rest"},
+	}
+	m := NewMonitor(db, categories, 0, 0)
+	sizes, err := m.Sizes()
+	assert.Nil(err)
+	assert.Equal([]CategorySize{
+		{Name: TotalCategory, Size: 116},
+		{Name: "indexes", Size: 16},
+		{Name: "rest", Size: 100},
+	}, sizes)
+
+	assert.True(m.LastCompaction().IsZero())
+	assert.Nil(m.Compact())
+	assert.False(m.LastCompaction().IsZero())
+}
+
+func TestLDB_SizeOfPrefix(t *testing.T) {
+	assert := assert.New(t)
+
+	db, remove := newTestLDB()
+	defer remove()
+
+	for i := 0; i < 1000; i++ {
+		value := make([]byte, 1024)
+		rand.Read(value)
+		assert.Nil(db.Put([]byte(fmt.Sprintf("a/%04d", i)), value))
+		assert.Nil(db.Put([]byte(fmt.Sprintf("b/%04d", i)), value[:16]))
+	}
+	assert.Nil(db.Compact())
+
+	prefixSize, err := db.SizeOfPrefix([]byte("a/"))
+	assert.Nil(err)
+	totalSize, err := db.SizeOfPrefix(nil)
+	assert.Nil(err)
+	assert.True(prefixSize > 1000*1024/2)
+	assert.True(totalSize >= prefixSize)
+}
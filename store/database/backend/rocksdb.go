@@ -0,0 +1,381 @@
+// +build rocksdb
+
+package backend
+
+import (
+	"strconv"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/syndtr/goleveldb/leveldb/util"
+	"github.com/tecbot/gorocksdb"
+	"github.com/thetatoken/ukulele/store"
+	"github.com/thetatoken/ukulele/store/database"
+)
+
+// RocksDatabase a RocksDB wrapped object. Like LDBDatabase, it keeps the reference
+// counts in a separate instance.
+type RocksDatabase struct {
+	fn    string        // filename for reporting
+	db    *gorocksdb.DB // RocksDB instance
+	refdb *gorocksdb.DB // RocksDB instance for references
+	opts  *gorocksdb.Options
+
+	ro *gorocksdb.ReadOptions
+	wo *gorocksdb.WriteOptions
+}
+
+// NewRocksDatabase returns a RocksDB wrapped object.
+func NewRocksDatabase(file string, reffile string, cache int, handles int) (*RocksDatabase, error) {
+	// Ensure we have some minimal caching and file guarantees
+	if cache < 16 {
+		cache = 16
+	}
+	if handles < 16 {
+		handles = 16
+	}
+	log.Infof("Allocated cache and file handles, cache: %v, handles: %v", cache, handles)
+
+	bbto := gorocksdb.NewDefaultBlockBasedTableOptions()
+	bbto.SetFilterPolicy(gorocksdb.NewBloomFilter(10))
+	opts := gorocksdb.NewDefaultOptions()
+	opts.SetBlockBasedTableFactory(bbto)
+	opts.SetCreateIfMissing(true)
+	opts.SetMaxOpenFiles(handles)
+	opts.SetWriteBufferSize(cache / 4 * 1024 * 1024)
+
+	db, err := gorocksdb.OpenDb(opts, file)
+	if err != nil {
+		return nil, err
+	}
+	refdb, err := gorocksdb.OpenDb(opts, reffile)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &RocksDatabase{
+		fn:    file,
+		db:    db,
+		refdb: refdb,
+		opts:  opts,
+		ro:    gorocksdb.NewDefaultReadOptions(),
+		wo:    gorocksdb.NewDefaultWriteOptions(),
+	}, nil
+}
+
+// openRocksDatabase opens the RocksDB backend for NewBackend.
+func openRocksDatabase(file string, reffile string, cache int, handles int) (database.Backend, error) {
+	return NewRocksDatabase(file, reffile, cache, handles)
+}
+
+// Path returns the path to the database directory.
+func (db *RocksDatabase) Path() string {
+	return db.fn
+}
+
+// Put puts the given key / value to the database
+func (db *RocksDatabase) Put(key []byte, value []byte) error {
+	return db.db.Put(db.wo, key, value)
+}
+
+// Has checks if the given key is present in the database
+func (db *RocksDatabase) Has(key []byte) (bool, error) {
+	return rocksHas(db.db, db.ro, key)
+}
+
+// Get returns the given key if it's present.
+func (db *RocksDatabase) Get(key []byte) ([]byte, error) {
+	return rocksGet(db.db, db.ro, key)
+}
+
+// Delete deletes the key from the database
+func (db *RocksDatabase) Delete(key []byte) error {
+	db.refdb.Delete(db.wo, key)
+	return db.db.Delete(db.wo, key)
+}
+
+func (db *RocksDatabase) Reference(key []byte) error {
+	// check if k/v exists
+	if _, err := db.Get(key); err != nil {
+		return err
+	}
+
+	ref, err := db.getReference(key)
+	if err != nil && err != store.ErrKeyNotFound {
+		return err
+	}
+	return db.refdb.Put(db.wo, key, []byte(strconv.Itoa(ref+1)))
+}
+
+func (db *RocksDatabase) Dereference(key []byte) error {
+	// check if k/v exists
+	if _, err := db.Get(key); err != nil {
+		return err
+	}
+
+	ref, err := db.getReference(key)
+	if err != nil {
+		if err == store.ErrKeyNotFound {
+			return nil
+		}
+		return err
+	}
+	if ref > 0 {
+		return db.refdb.Put(db.wo, key, []byte(strconv.Itoa(ref-1)))
+	}
+	return nil
+}
+
+func (db *RocksDatabase) CountReference(key []byte) (int, error) {
+	// check if k/v exists
+	if _, err := db.Get(key); err != nil {
+		return 0, err
+	}
+	return db.getReference(key)
+}
+
+func (db *RocksDatabase) getReference(key []byte) (int, error) {
+	dat, err := rocksGet(db.refdb, db.ro, key)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(string(dat))
+}
+
+// NewIteratorWithPrefix returns an iterator over the keys with the given prefix.
+func (db *RocksDatabase) NewIteratorWithPrefix(prefix []byte) database.Iterator {
+	return newRocksIterator(db.db.NewIterator(db.ro), prefix)
+}
+
+// NewSnapshot returns a read-only view of the current content of the database.
+func (db *RocksDatabase) NewSnapshot() (database.Snapshot, error) {
+	snap := db.db.NewSnapshot()
+	ro := gorocksdb.NewDefaultReadOptions()
+	ro.SetSnapshot(snap)
+	return &rocksSnapshot{db: db.db, snap: snap, ro: ro}, nil
+}
+
+// SizeOfPrefix returns the approximate on-disk size of the keys with the given
+// prefix.
+func (db *RocksDatabase) SizeOfPrefix(prefix []byte) (uint64, error) {
+	if len(prefix) == 0 {
+		size, err := strconv.ParseUint(db.db.GetProperty("rocksdb.total-sst-files-size"), 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		refSize, err := strconv.ParseUint(db.refdb.GetProperty("rocksdb.total-sst-files-size"), 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		return size + refSize, nil
+	}
+	r := util.BytesPrefix(prefix)
+	return db.db.GetApproximateSizes([]gorocksdb.Range{{Start: r.Start, Limit: r.Limit}})[0], nil
+}
+
+// Compact compacts the whole database, including the reference counts.
+func (db *RocksDatabase) Compact() error {
+	db.db.CompactRange(gorocksdb.Range{})
+	db.refdb.CompactRange(gorocksdb.Range{})
+	return nil
+}
+
+func (db *RocksDatabase) Close() {
+	db.db.Close()
+	db.refdb.Close()
+	db.ro.Destroy()
+	db.wo.Destroy()
+	db.opts.Destroy()
+	log.Infof("Database closed")
+}
+
+func (db *RocksDatabase) NewBatch() database.Batch {
+	return &rocksBatch{db: db, b: gorocksdb.NewWriteBatch(), references: make(map[string]int)}
+}
+
+func rocksGet(db *gorocksdb.DB, ro *gorocksdb.ReadOptions, key []byte) ([]byte, error) {
+	slice, err := db.Get(ro, key)
+	if err != nil {
+		return nil, err
+	}
+	defer slice.Free()
+	if !slice.Exists() {
+		return nil, store.ErrKeyNotFound
+	}
+	return append([]byte{}, slice.Data()...), nil
+}
+
+func rocksHas(db *gorocksdb.DB, ro *gorocksdb.ReadOptions, key []byte) (bool, error) {
+	slice, err := db.Get(ro, key)
+	if err != nil {
+		return false, err
+	}
+	defer slice.Free()
+	return slice.Exists(), nil
+}
+
+type rocksSnapshot struct {
+	db   *gorocksdb.DB
+	snap *gorocksdb.Snapshot
+	ro   *gorocksdb.ReadOptions
+}
+
+func (s *rocksSnapshot) Get(key []byte) ([]byte, error) {
+	return rocksGet(s.db, s.ro, key)
+}
+
+func (s *rocksSnapshot) Has(key []byte) (bool, error) {
+	return rocksHas(s.db, s.ro, key)
+}
+
+func (s *rocksSnapshot) NewIteratorWithPrefix(prefix []byte) database.Iterator {
+	return newRocksIterator(s.db.NewIterator(s.ro), prefix)
+}
+
+func (s *rocksSnapshot) Release() {
+	s.ro.Destroy()
+	s.db.ReleaseSnapshot(s.snap)
+}
+
+type rocksIterator struct {
+	it      *gorocksdb.Iterator
+	prefix  []byte
+	started bool
+	key     []byte
+	value   []byte
+}
+
+func newRocksIterator(it *gorocksdb.Iterator, prefix []byte) *rocksIterator {
+	return &rocksIterator{it: it, prefix: prefix}
+}
+
+func (it *rocksIterator) Next() bool {
+	if it.it == nil {
+		return false
+	}
+	if it.started {
+		it.it.Next()
+	} else {
+		it.it.Seek(it.prefix)
+		it.started = true
+	}
+	if !it.it.ValidForPrefix(it.prefix) {
+		it.key, it.value = nil, nil
+		return false
+	}
+
+	key := it.it.Key()
+	it.key = append([]byte{}, key.Data()...)
+	key.Free()
+	value := it.it.Value()
+	it.value = append([]byte{}, value.Data()...)
+	value.Free()
+	return true
+}
+
+func (it *rocksIterator) Key() []byte {
+	return it.key
+}
+
+func (it *rocksIterator) Value() []byte {
+	return it.value
+}
+
+func (it *rocksIterator) Error() error {
+	if it.it == nil {
+		return nil
+	}
+	return it.it.Err()
+}
+
+func (it *rocksIterator) Release() {
+	if it.it == nil {
+		return
+	}
+	it.it.Close()
+	it.it = nil
+}
+
+type rocksBatch struct {
+	db         *RocksDatabase
+	b          *gorocksdb.WriteBatch
+	references map[string]int
+	size       int
+}
+
+func (b *rocksBatch) Put(key, value []byte) error {
+	b.b.Put(key, value)
+	b.size += len(value)
+	return nil
+}
+
+func (b *rocksBatch) Delete(key []byte) error {
+	delete(b.references, string(key))
+	b.b.Delete(key)
+	b.size += 1
+	return nil
+}
+
+func (b *rocksBatch) Reference(key []byte) error {
+	b.references[string(key)]++
+	b.size++
+	return nil
+}
+
+func (b *rocksBatch) Dereference(key []byte) error {
+	b.references[string(key)]--
+	b.size++
+	return nil
+}
+
+func (b *rocksBatch) Write() error {
+	err := b.db.db.Write(b.db.wo, b.b)
+	if err != nil {
+		return err
+	}
+
+	for k, v := range b.references {
+		if v == 0 {
+			// refs and derefs canceled out
+			continue
+		}
+		// check if k/v exists
+		has, err := b.db.Has([]byte(k))
+		if err != nil {
+			return err
+		}
+		if !has {
+			continue
+		}
+
+		ref, err := b.db.getReference([]byte(k))
+		if err != nil && err != store.ErrKeyNotFound {
+			return err
+		}
+		if ref <= 0 && v < 0 {
+			continue
+		}
+		ref = ref + v
+		if ref < 0 {
+			ref = 0
+		}
+		err = b.db.refdb.Put(b.db.wo, []byte(k), []byte(strconv.Itoa(ref)))
+		if err != nil {
+			return err
+		}
+	}
+
+	b.Reset()
+
+	return nil
+}
+
+func (b *rocksBatch) ValueSize() int {
+	return b.size
+}
+
+func (b *rocksBatch) Reset() {
+	b.b.Clear()
+	b.references = make(map[string]int)
+	b.size = 0
+}
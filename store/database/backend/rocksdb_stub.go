@@ -0,0 +1,15 @@
+// +build !rocksdb
+
+package backend
+
+import (
+	"errors"
+
+	"github.com/thetatoken/ukulele/store/database"
+)
+
+// openRocksDatabase fails, since the RocksDB backend needs cgo and librocksdb, and
+// is only built with the rocksdb build tag.
+func openRocksDatabase(file string, reffile string, cache int, handles int) (database.Backend, error) {
+	return nil, errors.New("RocksDB backend is not supported by this build, rebuild with -tags rocksdb")
+}
@@ -66,3 +66,60 @@ type Batch interface {
 	// Reset resets the batch for reuse
 	Reset()
 }
+
+// Iterator iterates over the key / value pairs of a database in ascending key
+// order. Next needs to be called before the first pair is available, and the
+// iterator needs to be released when done. Iterator cannot be used concurrently.
+type Iterator interface {
+	// Next moves the iterator to the next pair. It returns false when the
+	// iterator is exhausted or has failed.
+	Next() bool
+	Key() []byte
+	Value() []byte
+	// Error returns the error the iteration failed with, if any
+	Error() error
+	Release()
+}
+
+// Iteratee wraps the iteration operation supported by both snapshots and backends.
+type Iteratee interface {
+	// NewIteratorWithPrefix returns an iterator over the keys with the given prefix.
+	NewIteratorWithPrefix(prefix []byte) Iterator
+}
+
+// Snapshot is a read-only view of the database at the time it was taken. Writes to
+// the database after that are not visible through the snapshot. The snapshot needs
+// to be released when done.
+type Snapshot interface {
+	Iteratee
+	Get(key []byte) ([]byte, error)
+	Has(key []byte) (bool, error)
+	Release()
+}
+
+// Sizer wraps the size estimation supported by backends.
+type Sizer interface {
+	// SizeOfPrefix returns the approximate on-disk size in bytes of the keys with
+	// the given prefix, and of their values. The empty prefix stands for the whole
+	// database.
+	SizeOfPrefix(prefix []byte) (uint64, error)
+}
+
+// Compacter wraps the compaction supported by backends.
+type Compacter interface {
+	// Compact compacts the whole database, reclaiming the space of the deleted
+	// and overwritten values.
+	Compact() error
+}
+
+// Backend is a storage backend that supports iteration, snapshots, size
+// estimation and compaction on top of the database operations. The chain, the
+// ledger state and the indexes of a node are stored in a Backend selected by the
+// node configuration.
+type Backend interface {
+	Database
+	Iteratee
+	Sizer
+	Compacter
+	NewSnapshot() (Snapshot, error)
+}
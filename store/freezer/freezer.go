@@ -0,0 +1,195 @@
+// Package freezer implements append-only tables of items stored in flat files, for
+// data that is no longer modified, such as the ancient blocks of the chain. Keeping
+// such data out of the key/value store spares it from being rewritten by compactions.
+package freezer
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+	"path"
+	"sync"
+)
+
+const (
+	dataFileName  = "data"
+	indexFileName = "index"
+
+	// indexEntrySize is the size of an index entry, the end offset of an item in the data file
+	indexEntrySize = 8
+)
+
+// ErrOutOfBounds is returned when retrieving an item that has not been appended.
+var ErrOutOfBounds = errors.New("Freezer item out of bounds")
+
+//
+// Freezer is an append-only table. The items are appended to a data file, and the
+// end offset of each item in the data file is appended to an index file, so that
+// any item is read with two reads. Items are numbered from 0 in the order they are
+// appended.
+//
+type Freezer struct {
+	mu sync.RWMutex
+
+	data  *os.File
+	index *os.File
+	items uint64 // number of items in the table
+	size  uint64 // size of the items in the data file
+}
+
+// Open opens the table in the given folder, and creates it if it does not exist.
+// Items partially appended when the process stopped are discarded.
+func Open(dir string) (*Freezer, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	data, err := os.OpenFile(path.Join(dir, dataFileName), os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	index, err := os.OpenFile(path.Join(dir, indexFileName), os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		data.Close()
+		return nil, err
+	}
+	f := &Freezer{data: data, index: index}
+	if err := f.repair(); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+// repair truncates the files to the last item fully written to both of them.
+func (f *Freezer) repair() error {
+	indexInfo, err := f.index.Stat()
+	if err != nil {
+		return err
+	}
+	dataInfo, err := f.data.Stat()
+	if err != nil {
+		return err
+	}
+	dataSize := uint64(dataInfo.Size())
+
+	items := uint64(indexInfo.Size()) / indexEntrySize
+	size := uint64(0)
+	for items > 0 {
+		size, err = f.readOffset(items - 1)
+		if err != nil {
+			return err
+		}
+		if size <= dataSize {
+			break
+		}
+		items--
+	}
+	if items == 0 {
+		size = 0
+	}
+
+	if err := f.index.Truncate(int64(items * indexEntrySize)); err != nil {
+		return err
+	}
+	if err := f.data.Truncate(int64(size)); err != nil {
+		return err
+	}
+	f.items = items
+	f.size = size
+	return nil
+}
+
+// Items returns the number of items in the table.
+func (f *Freezer) Items() uint64 {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.items
+}
+
+// Size returns the size of the items in the data file.
+func (f *Freezer) Size() uint64 {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.size
+}
+
+// Append appends an item to the table. The item is not durable until Sync is
+// called.
+func (f *Freezer) Append(item []byte) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// Write the data first, so that an index entry never points past the data
+	if _, err := f.data.WriteAt(item, int64(f.size)); err != nil {
+		return err
+	}
+	end := f.size + uint64(len(item))
+	var entry [indexEntrySize]byte
+	binary.BigEndian.PutUint64(entry[:], end)
+	if _, err := f.index.WriteAt(entry[:], int64(f.items*indexEntrySize)); err != nil {
+		return err
+	}
+	f.items++
+	f.size = end
+	return nil
+}
+
+// Retrieve returns the item of the given number.
+func (f *Freezer) Retrieve(number uint64) ([]byte, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	if number >= f.items {
+		return nil, ErrOutOfBounds
+	}
+	start := uint64(0)
+	if number > 0 {
+		var err error
+		start, err = f.readOffset(number - 1)
+		if err != nil {
+			return nil, err
+		}
+	}
+	end, err := f.readOffset(number)
+	if err != nil {
+		return nil, err
+	}
+	item := make([]byte, end-start)
+	if _, err := f.data.ReadAt(item, int64(start)); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// Sync flushes the appended items to disk, data first.
+func (f *Freezer) Sync() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.data.Sync(); err != nil {
+		return err
+	}
+	return f.index.Sync()
+}
+
+// Close closes the files of the table.
+func (f *Freezer) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	dataErr := f.data.Close()
+	indexErr := f.index.Close()
+	if dataErr != nil {
+		return dataErr
+	}
+	return indexErr
+}
+
+// readOffset returns the end offset of the given item in the data file.
+func (f *Freezer) readOffset(number uint64) (uint64, error) {
+	var entry [indexEntrySize]byte
+	if _, err := f.index.ReadAt(entry[:], int64(number*indexEntrySize)); err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint64(entry[:]), nil
+}
@@ -0,0 +1,101 @@
+package freezer
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFreezerAppendRetrieve(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "freezer")
+	require.Nil(err)
+	defer os.RemoveAll(dir)
+
+	f, err := Open(dir)
+	require.Nil(err)
+	assert.Equal(uint64(0), f.Items())
+
+	for i := 0; i < 10; i++ {
+		require.Nil(f.Append([]byte(fmt.Sprintf("item%d", i))))
+	}
+	require.Nil(f.Append([]byte{}))
+	require.Nil(f.Sync())
+	assert.Equal(uint64(11), f.Items())
+
+	for i := 0; i < 10; i++ {
+		item, err := f.Retrieve(uint64(i))
+		require.Nil(err)
+		assert.Equal(fmt.Sprintf("item%d", i), string(item))
+	}
+	item, err := f.Retrieve(10)
+	require.Nil(err)
+	assert.Empty(item)
+	_, err = f.Retrieve(11)
+	assert.Equal(ErrOutOfBounds, err)
+
+	// The items persist
+	require.Nil(f.Close())
+	f, err = Open(dir)
+	require.Nil(err)
+	defer f.Close()
+	assert.Equal(uint64(11), f.Items())
+	item, err = f.Retrieve(3)
+	require.Nil(err)
+	assert.Equal("item3", string(item))
+}
+
+func TestFreezerRepair(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "freezer")
+	require.Nil(err)
+	defer os.RemoveAll(dir)
+
+	f, err := Open(dir)
+	require.Nil(err)
+	for i := 0; i < 3; i++ {
+		require.Nil(f.Append([]byte(fmt.Sprintf("item%d", i))))
+	}
+	require.Nil(f.Close())
+
+	// Simulate a crash in the middle of appending the data of an item, and after
+	// writing part of an index entry
+	data, err := os.OpenFile(path.Join(dir, dataFileName), os.O_WRONLY|os.O_APPEND, 0600)
+	require.Nil(err)
+	_, err = data.Write([]byte("partial"))
+	require.Nil(err)
+	require.Nil(data.Close())
+	index, err := os.OpenFile(path.Join(dir, indexFileName), os.O_WRONLY|os.O_APPEND, 0600)
+	require.Nil(err)
+	_, err = index.Write([]byte{0, 0, 0})
+	require.Nil(err)
+	require.Nil(index.Close())
+
+	f, err = Open(dir)
+	require.Nil(err)
+	assert.Equal(uint64(3), f.Items())
+	require.Nil(f.Append([]byte("item3")))
+	for i := 0; i < 4; i++ {
+		item, err := f.Retrieve(uint64(i))
+		require.Nil(err)
+		assert.Equal(fmt.Sprintf("item%d", i), string(item))
+	}
+	require.Nil(f.Close())
+
+	// Simulate an index entry written without its data
+	require.Nil(os.Truncate(path.Join(dir, dataFileName), int64(len("item0item1item2it"))))
+	f, err = Open(dir)
+	require.Nil(err)
+	defer f.Close()
+	assert.Equal(uint64(3), f.Items())
+	assert.Equal(uint64(len("item0item1item2")), f.Size())
+}
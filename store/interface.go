@@ -9,4 +9,14 @@ type Store interface {
 	Put(key common.Bytes, value interface{}) error
 	Delete(key common.Bytes) error
 	Get(key common.Bytes, value interface{}) error
+	NewBatch() Batch
+}
+
+// Batch collects writes to a Store, and applies them to the Store atomically when
+// Write is called. The writes are not visible through the Store before that.
+// Batch cannot be used concurrently.
+type Batch interface {
+	Put(key common.Bytes, value interface{}) error
+	Delete(key common.Bytes) error
+	Write() error
 }
@@ -39,3 +39,32 @@ func (store *KVStore) Get(key common.Bytes, value interface{}) error {
 	}
 	return rlp.DecodeBytes(encodedValue, value)
 }
+
+// NewBatch returns a batch of writes to apply to the DB atomically.
+func (store *KVStore) NewBatch() store.Batch {
+	return &kvBatch{store.db.NewBatch()}
+}
+
+// kvBatch a Batch wrapped object.
+type kvBatch struct {
+	batch database.Batch
+}
+
+// Put upserts key/value into the batch
+func (b *kvBatch) Put(key common.Bytes, value interface{}) error {
+	encodedValue, err := rlp.EncodeToBytes(value)
+	if err != nil {
+		return err
+	}
+	return b.batch.Put(key, encodedValue)
+}
+
+// Delete deletes key entry in the batch
+func (b *kvBatch) Delete(key common.Bytes) error {
+	return b.batch.Delete(key)
+}
+
+// Write applies the batch to the DB
+func (b *kvBatch) Write() error {
+	return b.batch.Write()
+}
@@ -0,0 +1,121 @@
+package trie
+
+import (
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/common/lru"
+	"github.com/thetatoken/ukulele/store/database"
+)
+
+// nodeCacheDatabase is a database with a read-through cache of the trie nodes, i.e.
+// of the values keyed by hashes. A cached node is evicted as soon as its key is
+// written, deleted or dereferenced, so that pruned nodes are not served from the
+// cache.
+type nodeCacheDatabase struct {
+	database.Database
+	cache *lru.Cache
+}
+
+// NewNodeCacheDatabase wraps the database with a cache of up to size trie nodes.
+// The writes to the database that bypass the returned database must not touch
+// the trie nodes.
+func NewNodeCacheDatabase(db database.Database, size int) database.Database {
+	return &nodeCacheDatabase{
+		Database: db,
+		cache:    lru.New("trie/cache/node", size),
+	}
+}
+
+func isNodeKey(key []byte) bool {
+	return len(key) == common.HashLength
+}
+
+// Get retrieves the value of the key, from the cache if the key is a cached node.
+func (db *nodeCacheDatabase) Get(key []byte) ([]byte, error) {
+	if !isNodeKey(key) {
+		return db.Database.Get(key)
+	}
+	hash := common.BytesToHash(key)
+	if cached, ok := db.cache.Get(hash); ok {
+		return common.CopyBytes(cached.([]byte)), nil
+	}
+	value, err := db.Database.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	db.cache.Add(hash, common.CopyBytes(value))
+	return value, nil
+}
+
+// Has retrieves whether the key is present, from the cache if the key is a cached
+// node.
+func (db *nodeCacheDatabase) Has(key []byte) (bool, error) {
+	if isNodeKey(key) {
+		if _, ok := db.cache.Get(common.BytesToHash(key)); ok {
+			return true, nil
+		}
+	}
+	return db.Database.Has(key)
+}
+
+func (db *nodeCacheDatabase) Put(key []byte, value []byte) error {
+	defer db.evict(key)
+	return db.Database.Put(key, value)
+}
+
+func (db *nodeCacheDatabase) Delete(key []byte) error {
+	defer db.evict(key)
+	return db.Database.Delete(key)
+}
+
+func (db *nodeCacheDatabase) Dereference(key []byte) error {
+	defer db.evict(key)
+	return db.Database.Dereference(key)
+}
+
+func (db *nodeCacheDatabase) NewBatch() database.Batch {
+	return &nodeCacheBatch{Batch: db.Database.NewBatch(), db: db}
+}
+
+func (db *nodeCacheDatabase) evict(key []byte) {
+	if isNodeKey(key) {
+		db.cache.Remove(common.BytesToHash(key))
+	}
+}
+
+// nodeCacheBatch evicts the nodes written by the batch from the cache once the
+// batch is written.
+type nodeCacheBatch struct {
+	database.Batch
+	db   *nodeCacheDatabase
+	keys [][]byte
+}
+
+func (b *nodeCacheBatch) Put(key []byte, value []byte) error {
+	b.keys = append(b.keys, common.CopyBytes(key))
+	return b.Batch.Put(key, value)
+}
+
+func (b *nodeCacheBatch) Delete(key []byte) error {
+	b.keys = append(b.keys, common.CopyBytes(key))
+	return b.Batch.Delete(key)
+}
+
+func (b *nodeCacheBatch) Dereference(key []byte) error {
+	b.keys = append(b.keys, common.CopyBytes(key))
+	return b.Batch.Dereference(key)
+}
+
+func (b *nodeCacheBatch) Write() error {
+	defer func() {
+		for _, key := range b.keys {
+			b.db.evict(key)
+		}
+		b.keys = nil
+	}()
+	return b.Batch.Write()
+}
+
+func (b *nodeCacheBatch) Reset() {
+	b.Batch.Reset()
+	b.keys = nil
+}
@@ -0,0 +1,59 @@
+package trie
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thetatoken/ukulele/common"
+	dbbackend "github.com/thetatoken/ukulele/store/database/backend"
+)
+
+func TestNodeCacheDatabase(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	diskdb := dbbackend.NewMemDatabase()
+	db := NewNodeCacheDatabase(diskdb, 16)
+
+	trie, err := New(common.Hash{}, NewDatabase(db))
+	require.Nil(err)
+	trie.Update([]byte("doe"), []byte("reindeer"))
+	trie.Update([]byte("dog"), []byte("puppy"))
+	root, err := trie.Commit(nil)
+	require.Nil(err)
+	require.Nil(trie.GetDB().Commit(root, false))
+
+	// The nodes are served from the cache once read
+	trie, err = New(root, NewDatabase(db))
+	require.Nil(err)
+	assert.Equal([]byte("puppy"), trie.Get([]byte("dog")))
+	has, err := db.Has(root[:])
+	require.Nil(err)
+	assert.True(has)
+
+	// Deleted nodes are evicted, directly or through a batch
+	require.Nil(db.Delete(root[:]))
+	_, err = db.Get(root[:])
+	assert.NotNil(err)
+	has, err = db.Has(root[:])
+	require.Nil(err)
+	assert.False(has)
+
+	require.Nil(db.Put(root[:], []byte("node")))
+	value, err := db.Get(root[:])
+	require.Nil(err)
+	assert.Equal([]byte("node"), value)
+	batch := db.NewBatch()
+	require.Nil(batch.Delete(root[:]))
+	require.Nil(batch.Write())
+	_, err = db.Get(root[:])
+	assert.NotNil(err)
+
+	// Other keys are not cached
+	require.Nil(db.Put([]byte("key"), []byte("value")))
+	require.Nil(diskdb.Put([]byte("key"), []byte("updated")))
+	value, err = db.Get([]byte("key"))
+	require.Nil(err)
+	assert.Equal([]byte("updated"), value)
+}
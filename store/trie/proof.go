@@ -152,3 +152,39 @@ func get(tn node, key []byte) ([]byte, node) {
 		}
 	}
 }
+
+// ProofList collects the nodes of a proof, in the order Prove puts them.
+type ProofList []common.Bytes
+
+// Put implements database.Putter.
+func (l *ProofList) Put(key []byte, value []byte) error {
+	*l = append(*l, common.CopyBytes(value))
+	return nil
+}
+
+// ProofSet serves the nodes of a proof by their hashes, for VerifyProof.
+type ProofSet map[common.Hash][]byte
+
+// NewProofSet creates a ProofSet from the nodes of a proof.
+func NewProofSet(nodes []common.Bytes) ProofSet {
+	set := make(ProofSet)
+	for _, n := range nodes {
+		set[crypto.Keccak256Hash(n)] = n
+	}
+	return set
+}
+
+// Get implements DatabaseReader.
+func (s ProofSet) Get(key []byte) ([]byte, error) {
+	n, ok := s[common.BytesToHash(key)]
+	if !ok {
+		return nil, fmt.Errorf("proof node %x missing", key)
+	}
+	return n, nil
+}
+
+// Has implements DatabaseReader.
+func (s ProofSet) Has(key []byte) (bool, error) {
+	_, ok := s[common.BytesToHash(key)]
+	return ok, nil
+}
@@ -496,6 +496,9 @@ func (t *Trie) Prune(cb func(n []byte) bool) error {
 }
 
 func (t *Trie) pruneNode(n node, cb func(n []byte) bool) error {
+	if n == nil {
+		return nil
+	}
 	hash, _ := n.cache()
 	if hash == nil {
 		return nil
@@ -523,60 +526,13 @@ func (t *Trie) pruneNode(n node, cb func(n []byte) bool) error {
 }
 
 func (t *Trie) pruneChildren(nd node, cb func(n []byte) bool) error {
-	var err error
 	switch n := nd.(type) {
 	case *shortNode:
-		if _, ok := n.Val.(valueNode); !ok {
-			switch m := n.Val.(type) {
-			case valueNode:
-				if cb != nil {
-					cb(n.Val.(valueNode))
-				}
-			case hashNode:
-				childNode := t.db.node(common.BytesToHash(m[:]), 0)
-				err = t.pruneNode(childNode, cb)
-				if err != nil {
-					return err
-				}
-			case *shortNode:
-				t.pruneNode(m, cb)
-			case *fullNode:
-				t.pruneNode(m, cb)
-			default:
-			}
-		} else {
-			if cb != nil {
-				cb(n.Val.(valueNode))
-			}
-		}
+		return t.pruneChild(n.Val, cb)
 	case *fullNode:
 		for i := 0; i < 16; i++ {
-			if n.Children[i] != nil {
-				switch m := n.Children[i].(type) {
-				case valueNode:
-					if cb != nil {
-						cb(n.Children[i].(valueNode))
-					}
-				case hashNode:
-					hashNode := n.Children[i].(hashNode)
-					childNode := t.db.node(common.BytesToHash(hashNode[:]), 0)
-					err = t.pruneNode(childNode, cb)
-					if err != nil {
-						return err
-					}
-				case *shortNode:
-					if _, ok := m.Val.(valueNode); !ok {
-						hashNode := m.Val.(hashNode)
-						childNode := t.db.node(common.BytesToHash(hashNode[:]), 0)
-						err = t.pruneNode(childNode, cb)
-						if err != nil {
-							return err
-						}
-					}
-				case *fullNode:
-					return t.pruneNode(m, cb)
-				default:
-				}
+			if err := t.pruneChild(n.Children[i], cb); err != nil {
+				return err
 			}
 		}
 	default:
@@ -584,3 +540,23 @@ func (t *Trie) pruneChildren(nd node, cb func(n []byte) bool) error {
 
 	return nil
 }
+
+func (t *Trie) pruneChild(child node, cb func(n []byte) bool) error {
+	switch c := child.(type) {
+	case valueNode:
+		if cb != nil {
+			cb(c)
+		}
+	case hashNode:
+		return t.pruneNode(t.db.node(common.BytesToHash(c[:]), 0), cb)
+	case *shortNode, *fullNode:
+		// Nodes embedded in their parent are not stored on their own, but their
+		// children may be
+		if hash, _ := c.cache(); hash != nil {
+			return t.pruneNode(c, cb)
+		}
+		return t.pruneChildren(c, cb)
+	default:
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package version
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// Version is the release of the node daemon and the wallet, which are released together.
+const Version = "0.1.0"
+
+// ProtocolVersion is the version of the blocks and the messages exchanged by the nodes.
+// It is increased when the nodes of a release cannot interoperate with the older ones.
+const ProtocolVersion uint64 = 1
+
+// GitCommit is the commit the binaries are built from, set by the linker, e.g.
+//
+//	go build -ldflags "-X github.com/thetatoken/ukulele/version.GitCommit=$(git rev-parse HEAD)" ./cmd/theta
+var GitCommit = ""
+
+// Commit returns the commit the binaries are built from, "unknown" if not set at build time.
+func Commit() string {
+	if GitCommit == "" {
+		return "unknown"
+	}
+	return GitCommit
+}
+
+// String describes the version of the binary.
+func String(binary string) string {
+	return fmt.Sprintf("%s version %s\nGit commit:       %s\nProtocol version: %d\nGo version:       %s\nOS/Arch:          %s/%s\n",
+		binary, Version, Commit(), ProtocolVersion, runtime.Version(), runtime.GOOS, runtime.GOARCH)
+}
@@ -0,0 +1,21 @@
+package version
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestString(t *testing.T) {
+	assert := assert.New(t)
+
+	s := String("theta")
+	assert.True(strings.HasPrefix(s, "theta version "+Version+"\n"))
+	assert.Contains(s, "Git commit:       unknown\n")
+	assert.Contains(s, "Protocol version: 1\n")
+
+	GitCommit = "0634632"
+	defer func() { GitCommit = "" }()
+	assert.Contains(String("banjo"), "Git commit:       0634632\n")
+}
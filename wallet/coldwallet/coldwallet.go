@@ -34,16 +34,13 @@ type ColdWallet struct {
 
 func NewColdWallet(hub *Hub, deviceInfo hid.DeviceInfo) (*ColdWallet, error) {
 	var driver ks.Driver
-	var err error
 
 	scheme := hub.scheme
-	if scheme == LedgerScheme {
-		driver = ks.NewLedgerDriver()
-	} else {
-		panic(fmt.Sprintf("Unsupported cold wallet driver scheme: %v", LedgerScheme))
-	}
-	if err != nil {
-		return nil, err
+	switch scheme {
+	case LedgerScheme, TrezorScheme:
+		driver = hub.makeDriver()
+	default:
+		return nil, fmt.Errorf("Unsupported cold wallet driver scheme: %v", scheme)
 	}
 
 	path := deviceInfo.Path
@@ -88,6 +85,14 @@ func (w *ColdWallet) NewKey(password string) (common.Address, error) {
 	return common.Address{}, fmt.Errorf("Not supported for cold wallet")
 }
 
+func (w *ColdWallet) ImportKey(privKey *crypto.PrivateKey, password string) (common.Address, error) {
+	return common.Address{}, fmt.Errorf("Not supported for cold wallet")
+}
+
+func (w *ColdWallet) ExportKey(address common.Address, password string) (*crypto.PrivateKey, error) {
+	return nil, fmt.Errorf("Not supported for cold wallet")
+}
+
 // Neither address nor password is used by the function, silently ignored
 func (w *ColdWallet) Unlock(address common.Address, password string) error {
 	w.stateLock.Lock() // State lock is enough since there's no connection yet at this point
@@ -124,6 +129,22 @@ func (w *ColdWallet) Lock(address common.Address) error {
 	return err
 }
 
+func (w *ColdWallet) LockAll() error {
+	err := w.close()
+	return err
+}
+
+func (w *ColdWallet) IsUnlocked(address common.Address) bool {
+	w.stateLock.RLock()
+	defer w.stateLock.RUnlock()
+
+	if w.device == nil {
+		return false
+	}
+	_, ok := w.addressPathMap[address]
+	return ok
+}
+
 func (w *ColdWallet) Delete(address common.Address, password string) error {
 	return fmt.Errorf("Not supported for cold wallet")
 }
@@ -132,9 +153,34 @@ func (w *ColdWallet) UpdatePassword(address common.Address, oldPassword, newPass
 	return fmt.Errorf("Not supported for cold wallet")
 }
 
+// Derive derives the address at the given path on the device. If pin is set, the
+// address is remembered so that it can be used for signing afterwards.
 func (w *ColdWallet) Derive(path types.DerivationPath, pin bool) (common.Address, error) {
+	w.stateLock.Lock()
+	defer w.stateLock.Unlock()
+
+	if w.device == nil {
+		return common.Address{}, fmt.Errorf("wallet closed")
+	}
+
+	w.hub.commsLock.Lock()
+	w.hub.commsPend++
+	w.hub.commsLock.Unlock()
+
+	defer func() {
+		w.hub.commsLock.Lock()
+		w.hub.commsPend--
+		w.hub.commsLock.Unlock()
+	}()
+
 	address, err := w.driver.Derive(path)
-	return address, err
+	if err != nil {
+		return common.Address{}, err
+	}
+	if pin {
+		w.addressPathMap[address] = path
+	}
+	return address, nil
 }
 
 func (w *ColdWallet) GetPublicKey(address common.Address) (*crypto.PublicKey, error) {
@@ -0,0 +1,388 @@
+// Adapted for Theta
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// This file contains the implementation for interacting with the Trezor hardware
+// wallets. The wire protocol spec can be found on the SatoshiLabs website:
+// https://wiki.trezor.io/Developers_guide-Message_Workflows
+
+package keystore
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/common/hexutil"
+	"github.com/thetatoken/ukulele/crypto"
+	"github.com/thetatoken/ukulele/rlp"
+	"github.com/thetatoken/ukulele/wallet/types"
+)
+
+// ErrTrezorPINNeeded is returned if opening the trezor requires a PIN code. In
+// this case, the calling application should display a pinpad and send back the
+// encoded passphrase.
+var ErrTrezorPINNeeded = errors.New("trezor: pin needed")
+
+// ErrTrezorPassphraseNeeded is returned if opening the trezor requires a passphrase
+var ErrTrezorPassphraseNeeded = errors.New("trezor: passphrase needed")
+
+// errTrezorReplyInvalidHeader is the error message returned by a Trezor data exchange
+// if the device replies with a mismatching header. This usually means the device
+// is in browser mode.
+var errTrezorReplyInvalidHeader = errors.New("trezor: invalid reply header")
+
+// trezorMaxInitialDataChunk is the maximum size of the transaction payload sent
+// along with the signing request, the rest is streamed on the device's demand.
+const trezorMaxInitialDataChunk = 1024
+
+// trezorSignBytes is the Ethereum transaction shaped envelope of the Theta
+// sign bytes, see addPrefixForSignBytes in ledger/types.
+type trezorSignBytes struct {
+	Nonce    uint64
+	GasPrice *big.Int
+	GasLimit uint64
+	To       common.Address
+	Value    *big.Int
+	Data     []byte
+}
+
+// trezorDriver implements the communication with a Trezor hardware wallet.
+type trezorDriver struct {
+	device         io.ReadWriter // USB device connection to communicate through
+	version        [3]uint32     // Current version of the Trezor firmware
+	label          string        // Current textual label of the Trezor device
+	pinwait        bool          // Flags whether the device is waiting for PIN entry
+	passphrasewait bool          // Flags whether the device is waiting for passphrase entry
+	failure        error         // Any failure that would make the device unusable
+}
+
+// NewTrezorDriver creates a new instance of a Trezor USB protocol driver.
+func NewTrezorDriver() Driver {
+	return &trezorDriver{}
+}
+
+// Status implements usbwallet.driver, returning whether the Trezor is opened,
+// closed or waiting for the PIN or passphrase entry.
+func (w *trezorDriver) Status() (string, error) {
+	if w.failure != nil {
+		return fmt.Sprintf("Trezor v%d.%d.%d '%s' failed: %v", w.version[0], w.version[1], w.version[2], w.label, w.failure), w.failure
+	}
+	if w.device == nil {
+		return "Closed", w.failure
+	}
+	if w.pinwait {
+		return fmt.Sprintf("Trezor v%d.%d.%d '%s' waiting for PIN", w.version[0], w.version[1], w.version[2], w.label), w.failure
+	}
+	if w.passphrasewait {
+		return fmt.Sprintf("Trezor v%d.%d.%d '%s' waiting for passphrase", w.version[0], w.version[1], w.version[2], w.label), w.failure
+	}
+	return fmt.Sprintf("Trezor v%d.%d.%d '%s' online", w.version[0], w.version[1], w.version[2], w.label), w.failure
+}
+
+// Open implements usbwallet.driver, attempting to initialize the connection to
+// the Trezor hardware wallet. Initializing the Trezor is a two or three phase operation:
+//   - The first phase is to initialize the connection and read the wallet's
+//     features. This phase is invoked if the provided passphrase is empty. The
+//     device will display the pinpad as a result and will return an appropriate
+//     error to notify the user that a second open phase is needed.
+//   - The second phase is to unlock access to the Trezor, which is done by the
+//     user actually providing a passphrase mapping a keyboard keypad to the pin
+//     number of the user (shuffled according to the pinpad displayed).
+//   - If needed the device will ask for passphrase which will require calling
+//     open again with the actual passphrase (3rd phase)
+func (w *trezorDriver) Open(device io.ReadWriter, passphrase string) error {
+	w.device, w.failure = device, nil
+
+	// If phase 1 is requested, init the connection and wait for user callback
+	if passphrase == "" && !w.passphrasewait {
+		// If we're already waiting for a PIN entry, insta-return
+		if w.pinwait {
+			return ErrTrezorPINNeeded
+		}
+		// Initialize a connection to the device
+		features, err := w.trezorExchange(newTrezorMessage(trezorMsgInitialize), trezorMsgFeatures)
+		if err != nil {
+			return err
+		}
+		w.version = [3]uint32{
+			uint32(features.getUint(trezorFieldFeaturesMajorVersion)),
+			uint32(features.getUint(trezorFieldFeaturesMinorVersion)),
+			uint32(features.getUint(trezorFieldFeaturesPatchVersion)),
+		}
+		w.label = features.getString(trezorFieldFeaturesLabel)
+
+		// Do a manual ping, forcing the device to ask for its PIN and Passphrase
+		ping := newTrezorMessage(trezorMsgPing).
+			addBool(trezorFieldPingPinProtection, true).
+			addBool(trezorFieldPingPassphraseProtection, true)
+		res, err := w.trezorExchange(ping, trezorMsgPinMatrixRequest, trezorMsgPassphraseRequest, trezorMsgSuccess)
+		if err != nil {
+			return err
+		}
+		// Only return the PIN request if the device wasn't unlocked until now
+		switch res.kind {
+		case trezorMsgPinMatrixRequest:
+			w.pinwait = true
+			return ErrTrezorPINNeeded
+		case trezorMsgPassphraseRequest:
+			w.pinwait = false
+			w.passphrasewait = true
+			return ErrTrezorPassphraseNeeded
+		default:
+			return nil // responded with Success
+		}
+	}
+	// Phase 2 requested with actual PIN entry
+	if w.pinwait {
+		w.pinwait = false
+		ack := newTrezorMessage(trezorMsgPinMatrixAck).addString(trezorFieldPinMatrixAckPin, passphrase)
+		res, err := w.trezorExchange(ack, trezorMsgSuccess, trezorMsgPassphraseRequest)
+		if err != nil {
+			w.failure = err
+			return err
+		}
+		if res.kind == trezorMsgPassphraseRequest {
+			w.passphrasewait = true
+			return ErrTrezorPassphraseNeeded
+		}
+	} else if w.passphrasewait {
+		w.passphrasewait = false
+		ack := newTrezorMessage(trezorMsgPassphraseAck).addString(trezorFieldPassphraseAckPassphrase, passphrase)
+		if _, err := w.trezorExchange(ack, trezorMsgSuccess); err != nil {
+			w.failure = err
+			return err
+		}
+	}
+	return nil
+}
+
+// Close implements usbwallet.driver, cleaning up and metadata maintained within
+// the Trezor driver.
+func (w *trezorDriver) Close() error {
+	w.version, w.label, w.pinwait, w.passphrasewait = [3]uint32{}, "", false, false
+	return nil
+}
+
+// Heartbeat implements usbwallet.driver, performing a sanity check against the
+// Trezor to see if it's still online.
+func (w *trezorDriver) Heartbeat() error {
+	if _, err := w.trezorExchange(newTrezorMessage(trezorMsgPing), trezorMsgSuccess); err != nil {
+		w.failure = err
+		return err
+	}
+	return nil
+}
+
+// Derive implements usbwallet.driver, sending a derivation request to the Trezor
+// and returning the Ethereum address located on that derivation path.
+func (w *trezorDriver) Derive(path types.DerivationPath) (common.Address, error) {
+	return w.trezorDerive(path)
+}
+
+// SignTx implements usbwallet.driver, sending the transaction to the Trezor and
+// waiting for the user to confirm or deny the transaction.
+func (w *trezorDriver) SignTx(path types.DerivationPath, txrlp common.Bytes) (common.Address, *crypto.Signature, error) {
+	if w.device == nil {
+		return common.Address{}, nil, errors.New("wallet closed")
+	}
+	return w.trezorSign(path, txrlp)
+}
+
+// trezorDerive sends a derivation request to the Trezor device and returns the
+// Ethereum address located on that path.
+func (w *trezorDriver) trezorDerive(derivationPath []uint32) (common.Address, error) {
+	request := newTrezorMessage(trezorMsgEthereumGetAddress).
+		addUints(trezorFieldGetAddressAddressN, derivationPath).
+		addBool(trezorFieldGetAddressShowDisplay, false)
+	reply, err := w.trezorExchange(request, trezorMsgEthereumAddress)
+	if err != nil {
+		return common.Address{}, err
+	}
+	if addr := reply.getBytes(trezorFieldAddressBin); len(addr) > 0 { // Older firmwares use binary formats
+		return common.BytesToAddress(addr), nil
+	}
+	if addr := reply.getString(trezorFieldAddressHex); len(addr) > 0 { // Newer firmwares use hexadecimal formats
+		return common.HexToAddress(addr), nil
+	}
+	return common.Address{}, errors.New("missing derived address")
+}
+
+// trezorSign sends the transaction to the Trezor wallet, and waits for the user
+// to confirm or deny the transaction. The Theta sign bytes are wrapped in an
+// Ethereum transaction envelope, which is unpacked into the fields of the Trezor
+// signing request.
+func (w *trezorDriver) trezorSign(derivationPath []uint32, txrlp common.Bytes) (common.Address, *crypto.Signature, error) {
+	var tx trezorSignBytes
+	if err := rlp.DecodeBytes(txrlp, &tx); err != nil {
+		return common.Address{}, nil, fmt.Errorf("trezor: malformed sign bytes: %v", err)
+	}
+
+	// Create the transaction initiation message
+	data := tx.Data
+	request := newTrezorMessage(trezorMsgEthereumSignTx).
+		addUints(trezorFieldSignTxAddressN, derivationPath).
+		addBytes(trezorFieldSignTxNonce, new(big.Int).SetUint64(tx.Nonce).Bytes()).
+		addBytes(trezorFieldSignTxGasPrice, tx.GasPrice.Bytes()).
+		addBytes(trezorFieldSignTxGasLimit, new(big.Int).SetUint64(tx.GasLimit).Bytes()).
+		addString(trezorFieldSignTxToHex, tx.To.Hex()).
+		addBytes(trezorFieldSignTxValue, tx.Value.Bytes()).
+		addUint(trezorFieldSignTxDataLength, uint64(len(data)))
+	if len(data) > trezorMaxInitialDataChunk {
+		request.addBytes(trezorFieldSignTxDataInitialChunk, data[:trezorMaxInitialDataChunk])
+		data = data[trezorMaxInitialDataChunk:]
+	} else {
+		request.addBytes(trezorFieldSignTxDataInitialChunk, data)
+		data = nil
+	}
+
+	// Send the initiation message and stream content until a signature is returned
+	response, err := w.trezorExchange(request, trezorMsgEthereumTxRequest)
+	if err != nil {
+		return common.Address{}, nil, err
+	}
+	for response.has(trezorFieldTxRequestDataLength) && int(response.getUint(trezorFieldTxRequestDataLength)) <= len(data) {
+		length := int(response.getUint(trezorFieldTxRequestDataLength))
+		chunk := data[:length]
+		data = data[length:]
+
+		ack := newTrezorMessage(trezorMsgEthereumTxAck).addBytes(trezorFieldTxAckDataChunk, chunk)
+		if response, err = w.trezorExchange(ack, trezorMsgEthereumTxRequest); err != nil {
+			return common.Address{}, nil, err
+		}
+	}
+
+	// Extract the Ethereum signature and do a sanity validation
+	sigR := response.getBytes(trezorFieldTxRequestSignatureR)
+	sigS := response.getBytes(trezorFieldTxRequestSignatureS)
+	sigV := response.getUint(trezorFieldTxRequestSignatureV)
+	if len(sigR) == 0 || len(sigS) == 0 || !response.has(trezorFieldTxRequestSignatureV) || sigV < 27 {
+		return common.Address{}, nil, errors.New("reply lacks signature")
+	}
+	sigBytes := make([]byte, 65)
+	copy(sigBytes[32-len(sigR):32], sigR)
+	copy(sigBytes[64-len(sigS):64], sigS)
+	sigBytes[64] = byte(sigV - 27)
+
+	signature, err := crypto.SignatureFromBytes(sigBytes)
+	if err != nil {
+		return common.Address{}, nil, err
+	}
+
+	sender, err := signature.RecoverSignerAddress(txrlp)
+	if err != nil {
+		return common.Address{}, nil, err
+	}
+	log.Infof("Sender address: %v", sender.Hex())
+
+	return sender, signature, nil
+}
+
+// trezorExchange performs a data exchange with the Trezor wallet, sending it a
+// message and retrieving the response. If multiple responses are possible, the
+// method will also return the type of the reply, which can be checked through
+// the kind field of the decoded reply.
+//
+// The message is prefixed with a "##" magic, the message type (big endian, 2
+// bytes) and the payload length (big endian, 4 bytes), and streamed to the
+// device in 64 byte HID reports, each starting with the '?' report ID.
+func (w *trezorDriver) trezorExchange(req *trezorMessage, results ...trezorMessageType) (*trezorReply, error) {
+	// Construct the original message payload to chunk up
+	payload := make([]byte, 8+len(req.data))
+	copy(payload, []byte{0x23, 0x23})
+	binary.BigEndian.PutUint16(payload[2:], uint16(req.kind))
+	binary.BigEndian.PutUint32(payload[4:], uint32(len(req.data)))
+	copy(payload[8:], req.data)
+
+	// Stream all the chunks to the device
+	chunk := make([]byte, 64)
+	chunk[0] = 0x3f // Report ID magic number
+
+	for len(payload) > 0 {
+		// Construct the new message to stream, padding with zeroes if needed
+		if len(payload) > 63 {
+			copy(chunk[1:], payload[:63])
+			payload = payload[63:]
+		} else {
+			copy(chunk[1:], payload)
+			copy(chunk[1+len(payload):], make([]byte, 63-len(payload)))
+			payload = nil
+		}
+		// Send over to the device
+		log.Debugf("Data chunk sent to the Trezor, chunk: %v", hexutil.Bytes(chunk))
+		if _, err := w.device.Write(chunk); err != nil {
+			return nil, err
+		}
+	}
+	// Stream the reply back from the wallet in 64 byte chunks
+	var (
+		kind  uint16
+		reply []byte
+	)
+	for {
+		// Read the next chunk from the Trezor wallet
+		if _, err := io.ReadFull(w.device, chunk); err != nil {
+			return nil, err
+		}
+		log.Debugf("Data chunk received from the Trezor, chunk: %v", hexutil.Bytes(chunk))
+
+		// Make sure the transport header matches
+		if chunk[0] != 0x3f || (len(reply) == 0 && (chunk[1] != 0x23 || chunk[2] != 0x23)) {
+			return nil, errTrezorReplyInvalidHeader
+		}
+		// If it's the first chunk, retrieve the reply message type and total message length
+		var payload []byte
+
+		if len(reply) == 0 {
+			kind = binary.BigEndian.Uint16(chunk[3:5])
+			reply = make([]byte, 0, int(binary.BigEndian.Uint32(chunk[5:9])))
+			payload = chunk[9:]
+		} else {
+			payload = chunk[1:]
+		}
+		// Append to the reply and stop when filled up
+		if left := cap(reply) - len(reply); left > len(payload) {
+			reply = append(reply, payload...)
+		} else {
+			reply = append(reply, payload[:left]...)
+			break
+		}
+	}
+	// Try to parse the reply into the requested reply message
+	decoded, err := decodeTrezorReply(trezorMessageType(kind), reply)
+	if err != nil {
+		return nil, err
+	}
+	switch decoded.kind {
+	case trezorMsgFailure:
+		// Trezor returned a failure, extract and return the message
+		return nil, errors.New("trezor: " + decoded.getString(trezorFieldFailureMessage))
+	case trezorMsgButtonRequest:
+		// Trezor is waiting for user confirmation, ack and wait for the next message
+		return w.trezorExchange(newTrezorMessage(trezorMsgButtonAck), results...)
+	}
+	for _, res := range results {
+		if res == decoded.kind {
+			return decoded, nil
+		}
+	}
+	return nil, fmt.Errorf("trezor: expected reply types %v, got %v", results, decoded.kind)
+}
@@ -0,0 +1,215 @@
+package keystore
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// This file contains a minimal protocol buffers codec for the subset of the
+// Trezor wire messages used by the Trezor driver. The message and field numbers
+// follow the Trezor protobuf definitions:
+// https://github.com/trezor/trezor-common/tree/master/protob
+
+// trezorMessageType is an enumeration encoding the Trezor message types.
+type trezorMessageType uint16
+
+const (
+	trezorMsgInitialize         trezorMessageType = 0
+	trezorMsgPing               trezorMessageType = 1
+	trezorMsgSuccess            trezorMessageType = 2
+	trezorMsgFailure            trezorMessageType = 3
+	trezorMsgFeatures           trezorMessageType = 17
+	trezorMsgPinMatrixRequest   trezorMessageType = 18
+	trezorMsgPinMatrixAck       trezorMessageType = 19
+	trezorMsgButtonRequest      trezorMessageType = 26
+	trezorMsgButtonAck          trezorMessageType = 27
+	trezorMsgPassphraseRequest  trezorMessageType = 41
+	trezorMsgPassphraseAck      trezorMessageType = 42
+	trezorMsgEthereumGetAddress trezorMessageType = 56
+	trezorMsgEthereumAddress    trezorMessageType = 57
+	trezorMsgEthereumSignTx     trezorMessageType = 58
+	trezorMsgEthereumTxRequest  trezorMessageType = 59
+	trezorMsgEthereumTxAck      trezorMessageType = 60
+)
+
+// Field numbers of the Trezor messages used by the driver
+const (
+	trezorFieldFeaturesMajorVersion = 2
+	trezorFieldFeaturesMinorVersion = 3
+	trezorFieldFeaturesPatchVersion = 4
+	trezorFieldFeaturesLabel        = 10
+
+	trezorFieldFailureMessage = 2
+
+	trezorFieldPingPinProtection        = 3
+	trezorFieldPingPassphraseProtection = 4
+
+	trezorFieldPinMatrixAckPin         = 1
+	trezorFieldPassphraseAckPassphrase = 1
+
+	trezorFieldGetAddressAddressN    = 1
+	trezorFieldGetAddressShowDisplay = 2
+
+	trezorFieldAddressBin = 1 // Legacy firmwares return the address as raw bytes
+	trezorFieldAddressHex = 2
+
+	trezorFieldSignTxAddressN         = 1
+	trezorFieldSignTxNonce            = 2
+	trezorFieldSignTxGasPrice         = 3
+	trezorFieldSignTxGasLimit         = 4
+	trezorFieldSignTxValue            = 6
+	trezorFieldSignTxDataInitialChunk = 7
+	trezorFieldSignTxDataLength       = 8
+	trezorFieldSignTxToHex            = 11
+
+	trezorFieldTxRequestDataLength = 1
+	trezorFieldTxRequestSignatureV = 2
+	trezorFieldTxRequestSignatureR = 3
+	trezorFieldTxRequestSignatureS = 4
+
+	trezorFieldTxAckDataChunk = 1
+)
+
+const (
+	protoWireVarint  = 0
+	protoWireFixed64 = 1
+	protoWireBytes   = 2
+	protoWireFixed32 = 5
+)
+
+var errTrezorInvalidMessage = errors.New("trezor: invalid protobuf message")
+
+// trezorMessage is an encoded protobuf message together with its Trezor type.
+type trezorMessage struct {
+	kind trezorMessageType
+	data []byte
+}
+
+func newTrezorMessage(kind trezorMessageType) *trezorMessage {
+	return &trezorMessage{kind: kind}
+}
+
+func (m *trezorMessage) appendKey(field int, wireType int) {
+	m.data = appendProtoVarint(m.data, uint64(field)<<3|uint64(wireType))
+}
+
+// addUint adds a varint encoded field to the message.
+func (m *trezorMessage) addUint(field int, value uint64) *trezorMessage {
+	m.appendKey(field, protoWireVarint)
+	m.data = appendProtoVarint(m.data, value)
+	return m
+}
+
+// addBool adds a boolean field to the message.
+func (m *trezorMessage) addBool(field int, value bool) *trezorMessage {
+	if value {
+		return m.addUint(field, 1)
+	}
+	return m.addUint(field, 0)
+}
+
+// addBytes adds a length delimited field to the message.
+func (m *trezorMessage) addBytes(field int, value []byte) *trezorMessage {
+	m.appendKey(field, protoWireBytes)
+	m.data = appendProtoVarint(m.data, uint64(len(value)))
+	m.data = append(m.data, value...)
+	return m
+}
+
+// addString adds a string field to the message.
+func (m *trezorMessage) addString(field int, value string) *trezorMessage {
+	return m.addBytes(field, []byte(value))
+}
+
+// addUints adds a non-packed repeated varint field to the message.
+func (m *trezorMessage) addUints(field int, values []uint32) *trezorMessage {
+	for _, value := range values {
+		m.addUint(field, uint64(value))
+	}
+	return m
+}
+
+// trezorReply holds the decoded fields of a message received from the device.
+// Only the last value of each field is kept, which is sufficient since none of
+// the replies used by the driver contain repeated fields.
+type trezorReply struct {
+	kind   trezorMessageType
+	uints  map[int]uint64
+	blobs  map[int][]byte
+	hasKey map[int]bool
+}
+
+// decodeTrezorReply decodes the protobuf payload of a message of the given type.
+func decodeTrezorReply(kind trezorMessageType, data []byte) (*trezorReply, error) {
+	reply := &trezorReply{
+		kind:   kind,
+		uints:  make(map[int]uint64),
+		blobs:  make(map[int][]byte),
+		hasKey: make(map[int]bool),
+	}
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		if n <= 0 {
+			return nil, errTrezorInvalidMessage
+		}
+		data = data[n:]
+
+		field := int(key >> 3)
+		switch key & 0x7 {
+		case protoWireVarint:
+			value, n := binary.Uvarint(data)
+			if n <= 0 {
+				return nil, errTrezorInvalidMessage
+			}
+			reply.uints[field] = value
+			data = data[n:]
+		case protoWireBytes:
+			length, n := binary.Uvarint(data)
+			if n <= 0 || uint64(len(data)-n) < length {
+				return nil, errTrezorInvalidMessage
+			}
+			reply.blobs[field] = data[n : n+int(length)]
+			data = data[n+int(length):]
+		case protoWireFixed64:
+			if len(data) < 8 {
+				return nil, errTrezorInvalidMessage
+			}
+			data = data[8:]
+		case protoWireFixed32:
+			if len(data) < 4 {
+				return nil, errTrezorInvalidMessage
+			}
+			data = data[4:]
+		default:
+			return nil, errTrezorInvalidMessage
+		}
+		reply.hasKey[field] = true
+	}
+	return reply, nil
+}
+
+// has returns whether the field is present in the reply.
+func (r *trezorReply) has(field int) bool {
+	return r.hasKey[field]
+}
+
+// getUint returns the varint field, or zero if it is absent.
+func (r *trezorReply) getUint(field int) uint64 {
+	return r.uints[field]
+}
+
+// getBytes returns the length delimited field, or nil if it is absent.
+func (r *trezorReply) getBytes(field int) []byte {
+	return r.blobs[field]
+}
+
+// getString returns the string field, or an empty string if it is absent.
+func (r *trezorReply) getString(field int) string {
+	return string(r.blobs[field])
+}
+
+func appendProtoVarint(buf []byte, value uint64) []byte {
+	var tmp [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(tmp[:], value)
+	return append(buf, tmp[:n]...)
+}
@@ -0,0 +1,193 @@
+package keystore
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/crypto"
+	"github.com/thetatoken/ukulele/rlp"
+	"github.com/thetatoken/ukulele/wallet/types"
+)
+
+// mockTrezorDevice records the reports written by the driver and replays the
+// queued replies
+type mockTrezorDevice struct {
+	written []byte
+	replies bytes.Buffer
+}
+
+func (d *mockTrezorDevice) Write(p []byte) (int, error) {
+	d.written = append(d.written, p...)
+	return len(p), nil
+}
+
+func (d *mockTrezorDevice) Read(p []byte) (int, error) {
+	return d.replies.Read(p)
+}
+
+// queue frames the message into 64 byte HID reports
+func (d *mockTrezorDevice) queue(msg *trezorMessage) {
+	payload := make([]byte, 8+len(msg.data))
+	copy(payload, []byte{0x23, 0x23})
+	binary.BigEndian.PutUint16(payload[2:], uint16(msg.kind))
+	binary.BigEndian.PutUint32(payload[4:], uint32(len(msg.data)))
+	copy(payload[8:], msg.data)
+	for len(payload) > 0 {
+		chunk := make([]byte, 64)
+		chunk[0] = 0x3f
+		n := copy(chunk[1:], payload)
+		payload = payload[n:]
+		d.replies.Write(chunk)
+	}
+}
+
+// requests decodes the messages written by the driver
+func (d *mockTrezorDevice) requests(t *testing.T) []*trezorReply {
+	var msgs []*trezorReply
+	data := d.written
+	for len(data) > 0 {
+		assert.Equal(t, byte(0x3f), data[0])
+		assert.Equal(t, []byte{0x23, 0x23}, data[1:3])
+		kind := binary.BigEndian.Uint16(data[3:5])
+		length := int(binary.BigEndian.Uint32(data[5:9]))
+
+		var payload []byte
+		payload = append(payload, data[9:64]...)
+		data = data[64:]
+		for len(payload) < length {
+			payload = append(payload, data[1:64]...)
+			data = data[64:]
+		}
+		msg, err := decodeTrezorReply(trezorMessageType(kind), payload[:length])
+		assert.Nil(t, err)
+		msgs = append(msgs, msg)
+	}
+	return msgs
+}
+
+func TestTrezorMessageCodec(t *testing.T) {
+	assert := assert.New(t)
+
+	msg := newTrezorMessage(trezorMsgEthereumSignTx).
+		addUints(trezorFieldSignTxAddressN, []uint32{0x8000002c, 1}).
+		addBytes(trezorFieldSignTxGasPrice, []byte{0x1, 0x2}).
+		addString(trezorFieldSignTxToHex, "0xabc").
+		addUint(trezorFieldSignTxDataLength, 300)
+
+	reply, err := decodeTrezorReply(msg.kind, msg.data)
+	assert.Nil(err)
+	assert.True(reply.has(trezorFieldSignTxAddressN))
+	assert.Equal(uint64(1), reply.getUint(trezorFieldSignTxAddressN))
+	assert.Equal([]byte{0x1, 0x2}, reply.getBytes(trezorFieldSignTxGasPrice))
+	assert.Equal("0xabc", reply.getString(trezorFieldSignTxToHex))
+	assert.Equal(uint64(300), reply.getUint(trezorFieldSignTxDataLength))
+	assert.False(reply.has(trezorFieldSignTxNonce))
+
+	_, err = decodeTrezorReply(msg.kind, msg.data[:len(msg.data)-1])
+	assert.Equal(errTrezorInvalidMessage, err)
+}
+
+func TestTrezorOpenWithPIN(t *testing.T) {
+	assert := assert.New(t)
+
+	device := &mockTrezorDevice{}
+	device.queue(newTrezorMessage(trezorMsgFeatures).
+		addUint(trezorFieldFeaturesMajorVersion, 1).
+		addUint(trezorFieldFeaturesMinorVersion, 8).
+		addUint(trezorFieldFeaturesPatchVersion, 3).
+		addString(trezorFieldFeaturesLabel, "theta"))
+	device.queue(newTrezorMessage(trezorMsgPinMatrixRequest))
+
+	driver := NewTrezorDriver()
+	assert.Equal(ErrTrezorPINNeeded, driver.Open(device, ""))
+	status, _ := driver.Status()
+	assert.Equal("Trezor v1.8.3 'theta' waiting for PIN", status)
+
+	device.queue(newTrezorMessage(trezorMsgSuccess))
+	assert.Nil(driver.Open(device, "1234"))
+	status, _ = driver.Status()
+	assert.Equal("Trezor v1.8.3 'theta' online", status)
+
+	reqs := device.requests(t)
+	assert.Equal(3, len(reqs))
+	assert.Equal(trezorMsgInitialize, reqs[0].kind)
+	assert.Equal(trezorMsgPing, reqs[1].kind)
+	assert.Equal(trezorMsgPinMatrixAck, reqs[2].kind)
+	assert.Equal("1234", reqs[2].getString(trezorFieldPinMatrixAckPin))
+}
+
+func TestTrezorDerive(t *testing.T) {
+	assert := assert.New(t)
+
+	device := &mockTrezorDevice{}
+	driver := &trezorDriver{device: device}
+
+	expected := common.HexToAddress("0x2E833968E5bB786Ae419c4d13189fB081Cc43bab")
+	device.queue(newTrezorMessage(trezorMsgButtonRequest))
+	device.queue(newTrezorMessage(trezorMsgEthereumAddress).addString(trezorFieldAddressHex, expected.Hex()))
+
+	address, err := driver.Derive(types.DefaultRootDerivationPath)
+	assert.Nil(err)
+	assert.Equal(expected, address)
+
+	reqs := device.requests(t)
+	assert.Equal(2, len(reqs))
+	assert.Equal(trezorMsgEthereumGetAddress, reqs[0].kind)
+	assert.Equal(trezorMsgButtonAck, reqs[1].kind)
+
+	device.queue(newTrezorMessage(trezorMsgFailure).addString(trezorFieldFailureMessage, "Cancelled"))
+	_, err = driver.Derive(types.DefaultRootDerivationPath)
+	assert.EqualError(err, "trezor: Cancelled")
+}
+
+func TestTrezorSignTx(t *testing.T) {
+	assert := assert.New(t)
+
+	privKey, _, err := crypto.GenerateKeyPair()
+	assert.Nil(err)
+
+	// Payload larger than the initial chunk, so that the rest is streamed
+	data := make([]byte, trezorMaxInitialDataChunk+100)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	txrlp, err := rlp.EncodeToBytes([]interface{}{
+		uint64(0),
+		new(big.Int).SetUint64(0),
+		uint64(0),
+		common.Address{},
+		new(big.Int).SetUint64(0),
+		data})
+	assert.Nil(err)
+
+	sig, err := privKey.Sign(txrlp)
+	assert.Nil(err)
+	sigBytes := sig.ToBytes()
+
+	device := &mockTrezorDevice{}
+	driver := &trezorDriver{device: device}
+	device.queue(newTrezorMessage(trezorMsgEthereumTxRequest).addUint(trezorFieldTxRequestDataLength, 100))
+	device.queue(newTrezorMessage(trezorMsgEthereumTxRequest).
+		addUint(trezorFieldTxRequestSignatureV, uint64(sigBytes[64])+27).
+		addBytes(trezorFieldTxRequestSignatureR, sigBytes[:32]).
+		addBytes(trezorFieldTxRequestSignatureS, sigBytes[32:64]))
+
+	sender, signature, err := driver.SignTx(types.DefaultRootDerivationPath, txrlp)
+	assert.Nil(err)
+	assert.Equal(privKey.PublicKey().Address(), sender)
+	assert.Equal(sigBytes, signature.ToBytes())
+
+	reqs := device.requests(t)
+	assert.Equal(2, len(reqs))
+	assert.Equal(trezorMsgEthereumSignTx, reqs[0].kind)
+	assert.Equal(uint64(len(data)), reqs[0].getUint(trezorFieldSignTxDataLength))
+	assert.Equal(data[:trezorMaxInitialDataChunk], reqs[0].getBytes(trezorFieldSignTxDataInitialChunk))
+	assert.Equal(common.Address{}.Hex(), reqs[0].getString(trezorFieldSignTxToHex))
+	assert.Equal(trezorMsgEthereumTxAck, reqs[1].kind)
+	assert.Equal(data[trezorMaxInitialDataChunk:], reqs[1].getBytes(trezorFieldTxAckDataChunk))
+}
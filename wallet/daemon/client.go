@@ -0,0 +1,131 @@
+package daemon
+
+import (
+	"fmt"
+	"net"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/common/hexutil"
+	"github.com/thetatoken/ukulele/crypto"
+	"github.com/thetatoken/ukulele/wallet/types"
+)
+
+var _ types.Wallet = (*Client)(nil)
+
+//
+// Client implements the Wallet interface on top of a wallet daemon connection
+//
+
+type Client struct {
+	name       string
+	socketPath string
+	rpcClient  *rpc.Client
+}
+
+// Dial connects to the wallet daemon on the unix socket, and authenticates with the token
+func Dial(socketPath, token string) (*Client, error) {
+	conn, err := net.Dial("unix", socketPath)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to connect to the wallet daemon: %v", err)
+	}
+	c := &Client{
+		socketPath: socketPath,
+		rpcClient:  rpc.NewClientWithCodec(jsonrpc.NewClientCodec(conn)),
+	}
+
+	result := AuthResult{}
+	if err := c.call("Auth", &AuthArgs{Token: token}, &result); err != nil {
+		c.Close()
+		return nil, err
+	}
+	c.name = result.Name
+	return c, nil
+}
+
+// Close closes the connection to the daemon
+func (c *Client) Close() error {
+	return c.rpcClient.Close()
+}
+
+func (c *Client) call(method string, args interface{}, result interface{}) error {
+	return c.rpcClient.Call(serviceName+"."+method, args, result)
+}
+
+func (c *Client) ID() string {
+	return c.socketPath
+}
+
+func (c *Client) Status() (string, error) {
+	return fmt.Sprintf("Connected to the wallet daemon as %v", c.name), nil
+}
+
+func (c *Client) List() ([]common.Address, error) {
+	result := ListResult{}
+	if err := c.call("List", &ListArgs{}, &result); err != nil {
+		return nil, err
+	}
+	return result.Addresses, nil
+}
+
+func (c *Client) NewKey(password string) (common.Address, error) {
+	return common.Address{}, fmt.Errorf("Not supported by the wallet daemon")
+}
+
+func (c *Client) ImportKey(privKey *crypto.PrivateKey, password string) (common.Address, error) {
+	return common.Address{}, fmt.Errorf("Not supported by the wallet daemon")
+}
+
+func (c *Client) ExportKey(address common.Address, password string) (*crypto.PrivateKey, error) {
+	return nil, fmt.Errorf("Not supported by the wallet daemon")
+}
+
+func (c *Client) Unlock(address common.Address, password string) error {
+	return c.call("Unlock", &UnlockArgs{Address: address, Password: password}, &UnlockResult{})
+}
+
+func (c *Client) Lock(address common.Address) error {
+	return c.call("Lock", &LockArgs{Address: address}, &LockResult{})
+}
+
+// LockAll is not supported, since the other clients share the unlocked keys
+func (c *Client) LockAll() error {
+	return fmt.Errorf("Not supported by the wallet daemon")
+}
+
+func (c *Client) IsUnlocked(address common.Address) bool {
+	result := IsUnlockedResult{}
+	if err := c.call("IsUnlocked", &IsUnlockedArgs{Address: address}, &result); err != nil {
+		return false
+	}
+	return result.Unlocked
+}
+
+func (c *Client) Delete(address common.Address, password string) error {
+	return fmt.Errorf("Not supported by the wallet daemon")
+}
+
+func (c *Client) UpdatePassword(address common.Address, oldPassword, newPassword string) error {
+	return fmt.Errorf("Not supported by the wallet daemon")
+}
+
+func (c *Client) Derive(path types.DerivationPath, pin bool) (common.Address, error) {
+	return common.Address{}, fmt.Errorf("Not supported by the wallet daemon")
+}
+
+func (c *Client) GetPublicKey(address common.Address) (*crypto.PublicKey, error) {
+	result := GetPublicKeyResult{}
+	if err := c.call("GetPublicKey", &GetPublicKeyArgs{Address: address}, &result); err != nil {
+		return nil, err
+	}
+	return crypto.PublicKeyFromBytes(common.Bytes(result.PublicKey))
+}
+
+func (c *Client) Sign(address common.Address, txrlp common.Bytes) (*crypto.Signature, error) {
+	result := SignResult{}
+	if err := c.call("Sign", &SignArgs{Address: address, Message: hexutil.Bytes(txrlp)}, &result); err != nil {
+		return nil, err
+	}
+	return crypto.SignatureFromBytes(common.Bytes(result.Signature))
+}
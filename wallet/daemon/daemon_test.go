@@ -0,0 +1,179 @@
+package daemon
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/ledger/types"
+	sw "github.com/thetatoken/ukulele/wallet/softwallet"
+)
+
+const (
+	signerToken   = "signer-token-0123456789"
+	backupToken   = "backup-token-0123456789"
+	txSignerToken = "txsigner-token-0123456789"
+)
+
+func newTestDaemon(t *testing.T) (*Server, *sw.SoftWallet, []common.Address, string, func()) {
+	dir, err := ioutil.TempDir("", "walletdaemon")
+	require.Nil(t, err)
+
+	wallet, err := sw.NewSoftWallet(path.Join(dir, "keys"), sw.KeystoreTypePlain)
+	require.Nil(t, err)
+	addr1, err := wallet.NewKey("")
+	require.Nil(t, err)
+	addr2, err := wallet.NewKey("")
+	require.Nil(t, err)
+	require.Nil(t, wallet.LockAll()) // newly created keys are unlocked
+
+	clients := []ClientConfig{
+		{Name: "signer", Token: signerToken, Permissions: []Permission{PermissionList, PermissionUnlock, PermissionSign, PermissionBlindSign}, Addresses: []common.Address{addr1}},
+		{Name: "backup", Token: backupToken, Permissions: []Permission{PermissionList}},
+		{Name: "txsigner", Token: txSignerToken, Permissions: []Permission{PermissionSign}},
+	}
+	socketPath := path.Join(dir, "wallet.sock")
+	server, err := NewServer(wallet, socketPath, clients)
+	require.Nil(t, err)
+	require.Nil(t, server.Start(context.Background()))
+
+	return server, wallet, []common.Address{addr1, addr2}, socketPath, func() {
+		server.Stop()
+		server.Wait()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestWalletDaemonSign(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	_, wallet, addresses, socketPath, cleanup := newTestDaemon(t)
+	defer cleanup()
+
+	client, err := Dial(socketPath, signerToken)
+	require.Nil(err)
+	defer client.Close()
+
+	listed, err := client.List()
+	require.Nil(err)
+	assert.Equal([]common.Address{addresses[0]}, listed) // restricted to the permitted addresses
+
+	msg := common.Bytes("theta wallet daemon")
+	_, err = client.Sign(addresses[0], msg)
+	assert.NotNil(err) // locked
+
+	require.Nil(client.Unlock(addresses[0], ""))
+	assert.True(client.IsUnlocked(addresses[0]))
+	assert.True(wallet.IsUnlocked(addresses[0]))
+
+	sig, err := client.Sign(addresses[0], msg)
+	require.Nil(err)
+	assert.True(sig.Verify(msg, addresses[0]))
+
+	pubKey, err := client.GetPublicKey(addresses[0])
+	require.Nil(err)
+	assert.Equal(addresses[0], pubKey.Address())
+
+	// A second client shares the unlocked key
+	client2, err := Dial(socketPath, signerToken)
+	require.Nil(err)
+	defer client2.Close()
+	_, err = client2.Sign(addresses[0], msg)
+	assert.Nil(err)
+
+	// The address outside of the client permissions is rejected
+	assert.NotNil(client.Unlock(addresses[1], ""))
+	_, err = client.Sign(addresses[1], msg)
+	assert.NotNil(err)
+
+	require.Nil(client.Lock(addresses[0]))
+	assert.False(wallet.IsUnlocked(addresses[0]))
+}
+
+func TestWalletDaemonPermissions(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	_, wallet, addresses, socketPath, cleanup := newTestDaemon(t)
+	defer cleanup()
+
+	_, err := Dial(socketPath, "unknown-token-0123456789")
+	assert.NotNil(err)
+
+	require.Nil(wallet.Unlock(addresses[0], ""))
+
+	client, err := Dial(socketPath, backupToken)
+	require.Nil(err)
+	defer client.Close()
+
+	listed, err := client.List()
+	require.Nil(err)
+	assert.Equal(2, len(listed))
+
+	_, err = client.Sign(addresses[0], common.Bytes("theta wallet daemon"))
+	assert.EqualError(err, ErrPermissionDenied.Error())
+	assert.EqualError(client.Lock(addresses[0]), ErrPermissionDenied.Error())
+	assert.True(wallet.IsUnlocked(addresses[0]))
+}
+
+func TestWalletDaemonBlindSign(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	_, wallet, addresses, socketPath, cleanup := newTestDaemon(t)
+	defer cleanup()
+	require.Nil(wallet.Unlock(addresses[0], ""))
+
+	client, err := Dial(socketPath, txSignerToken)
+	require.Nil(err)
+	defer client.Close()
+
+	sendTx := &types.SendTx{
+		Fee:     types.NewCoins(0, 1000000000000),
+		Inputs:  []types.TxInput{{Address: addresses[0], Coins: types.NewCoins(10, 1000000000000), Sequence: 1}},
+		Outputs: []types.TxOutput{{Address: addresses[1], Coins: types.NewCoins(10, 0)}},
+	}
+	signBytes := sendTx.SignBytes("privatenet")
+	sig, err := client.Sign(addresses[0], signBytes)
+	require.Nil(err)
+	assert.True(sig.Verify(signBytes, addresses[0]))
+
+	// Messages other than transactions require the blind sign permission
+	_, err = client.Sign(addresses[0], common.Bytes("theta wallet daemon"))
+	assert.NotNil(err)
+}
+
+func TestWalletDaemonClientConfigs(t *testing.T) {
+	assert := assert.New(t)
+
+	valid := ClientConfig{Name: "signer", Token: signerToken, Permissions: []Permission{PermissionSign}}
+	assert.Nil(validateClientConfigs([]ClientConfig{valid}))
+
+	shortToken := valid
+	shortToken.Token = "short"
+	assert.NotNil(validateClientConfigs([]ClientConfig{shortToken}))
+
+	unknownPerm := valid
+	unknownPerm.Permissions = []Permission{"export"}
+	assert.NotNil(validateClientConfigs([]ClientConfig{unknownPerm}))
+
+	sameToken := valid
+	sameToken.Name = "other"
+	assert.NotNil(validateClientConfigs([]ClientConfig{valid, sameToken}))
+}
+
+func TestWalletDaemonAlreadyRunning(t *testing.T) {
+	_, wallet, _, socketPath, cleanup := newTestDaemon(t)
+	defer cleanup()
+
+	server, err := NewServer(wallet, socketPath, nil)
+	require.Nil(t, err)
+	assert.NotNil(t, server.Start(context.Background()))
+}
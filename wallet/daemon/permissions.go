@@ -0,0 +1,105 @@
+package daemon
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/thetatoken/ukulele/common"
+)
+
+// Permission is an operation a client of the wallet daemon may perform
+type Permission string
+
+const (
+	PermissionList      Permission = "list"      // List the addresses
+	PermissionUnlock    Permission = "unlock"    // Unlock and lock the addresses with their passwords
+	PermissionSign      Permission = "sign"      // Sign transactions with the unlocked addresses, and get their public keys
+	PermissionBlindSign Permission = "blindsign" // Sign messages that cannot be decoded as transactions
+)
+
+// ClientConfig specifies the credentials and the permissions of a client of the daemon
+type ClientConfig struct {
+	Name        string           `json:"name"`
+	Token       string           `json:"token"`
+	Permissions []Permission     `json:"permissions"`
+	Addresses   []common.Address `json:"addresses"` // Addresses the client has access to, all addresses if empty
+}
+
+// LoadClientConfigs loads the client configs from the JSON file
+func LoadClientConfigs(filePath string) ([]ClientConfig, error) {
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	var clients []ClientConfig
+	if err := json.Unmarshal(data, &clients); err != nil {
+		return nil, fmt.Errorf("Failed to parse client configs: %v", err)
+	}
+	if err := validateClientConfigs(clients); err != nil {
+		return nil, err
+	}
+	return clients, nil
+}
+
+func validateClientConfigs(clients []ClientConfig) error {
+	names := make(map[string]bool)
+	tokens := make(map[string]bool)
+	for _, client := range clients {
+		if len(client.Name) == 0 {
+			return fmt.Errorf("Client name is not specified")
+		}
+		if names[client.Name] {
+			return fmt.Errorf("Duplicated client name: %v", client.Name)
+		}
+		if len(client.Token) < minTokenLength {
+			return fmt.Errorf("Token of client %v is shorter than %v characters", client.Name, minTokenLength)
+		}
+		if tokens[client.Token] {
+			return fmt.Errorf("Token of client %v is used by another client", client.Name)
+		}
+		for _, perm := range client.Permissions {
+			if perm != PermissionList && perm != PermissionUnlock && perm != PermissionSign && perm != PermissionBlindSign {
+				return fmt.Errorf("Unknown permission of client %v: %v", client.Name, perm)
+			}
+		}
+		names[client.Name] = true
+		tokens[client.Token] = true
+	}
+	return nil
+}
+
+const minTokenLength = 16
+
+func (c *ClientConfig) hasPermission(perm Permission) bool {
+	for _, p := range c.Permissions {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *ClientConfig) hasAddress(address common.Address) bool {
+	if len(c.Addresses) == 0 {
+		return true
+	}
+	for _, addr := range c.Addresses {
+		if addr == address {
+			return true
+		}
+	}
+	return false
+}
+
+// findClient returns the client with the token, comparing the tokens in constant time
+func findClient(clients []ClientConfig, token string) *ClientConfig {
+	var found *ClientConfig
+	for i := range clients {
+		if subtle.ConstantTimeCompare([]byte(clients[i].Token), []byte(token)) == 1 {
+			found = &clients[i]
+		}
+	}
+	return found
+}
@@ -0,0 +1,337 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"os"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/common/hexutil"
+	"github.com/thetatoken/ukulele/wallet/preview"
+	"github.com/thetatoken/ukulele/wallet/types"
+)
+
+//
+// The wallet daemon serves a wallet to the other local processes over a unix
+// socket, so that they share the keys unlocked once. The protocol is JSON-RPC
+// (net/rpc/jsonrpc). A connection first authenticates with the token of a
+// client with Wallet.Auth, and may then call the methods permitted to the client.
+//
+
+const serviceName = "Wallet"
+
+var (
+	ErrNotAuthenticated = errors.New("Not authenticated")
+	ErrPermissionDenied = errors.New("Permission denied")
+)
+
+type AuthArgs struct {
+	Token string `json:"token"`
+}
+
+type AuthResult struct {
+	Name string `json:"name"`
+}
+
+type ListArgs struct{}
+
+type ListResult struct {
+	Addresses []common.Address `json:"addresses"`
+}
+
+type UnlockArgs struct {
+	Address  common.Address `json:"address"`
+	Password string         `json:"password"`
+}
+
+type UnlockResult struct{}
+
+type LockArgs struct {
+	Address common.Address `json:"address"`
+}
+
+type LockResult struct{}
+
+type IsUnlockedArgs struct {
+	Address common.Address `json:"address"`
+}
+
+type IsUnlockedResult struct {
+	Unlocked bool `json:"unlocked"`
+}
+
+type GetPublicKeyArgs struct {
+	Address common.Address `json:"address"`
+}
+
+type GetPublicKeyResult struct {
+	PublicKey hexutil.Bytes `json:"public_key"`
+}
+
+type SignArgs struct {
+	Address common.Address `json:"address"`
+	Message hexutil.Bytes  `json:"message"`
+}
+
+type SignResult struct {
+	Signature hexutil.Bytes `json:"signature"`
+}
+
+// Server is the wallet daemon
+type Server struct {
+	wallet     types.Wallet
+	socketPath string
+	clients    []ClientConfig
+
+	listener net.Listener
+	conns    map[net.Conn]bool
+	connLock *sync.Mutex
+
+	// Life cycle
+	wg      *sync.WaitGroup
+	ctx     context.Context
+	cancel  context.CancelFunc
+	stopped bool
+}
+
+// NewServer creates a wallet daemon serving the wallet to the clients on the unix socket
+func NewServer(wallet types.Wallet, socketPath string, clients []ClientConfig) (*Server, error) {
+	if err := validateClientConfigs(clients); err != nil {
+		return nil, err
+	}
+	return &Server{
+		wallet:     wallet,
+		socketPath: socketPath,
+		clients:    clients,
+		conns:      make(map[net.Conn]bool),
+		connLock:   &sync.Mutex{},
+		wg:         &sync.WaitGroup{},
+	}, nil
+}
+
+// Start listens on the unix socket and creates the main goroutine.
+func (s *Server) Start(ctx context.Context) error {
+	if err := removeStaleSocket(s.socketPath); err != nil {
+		return err
+	}
+	l, err := net.Listen("unix", s.socketPath)
+	if err != nil {
+		return err
+	}
+	// Only the owner may connect, the tokens authenticate the processes of the owner
+	if err := os.Chmod(s.socketPath, 0600); err != nil {
+		l.Close()
+		return err
+	}
+	s.listener = l
+
+	c, cancel := context.WithCancel(ctx)
+	s.ctx = c
+	s.cancel = cancel
+
+	s.wg.Add(1)
+	go s.mainLoop()
+
+	log.Infof("Wallet daemon listening on %v", s.socketPath)
+	return nil
+}
+
+func (s *Server) mainLoop() {
+	defer s.wg.Done()
+
+	go s.acceptLoop()
+
+	<-s.ctx.Done()
+	s.stopped = true
+	s.listener.Close()
+
+	s.connLock.Lock()
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.connLock.Unlock()
+
+	s.wallet.LockAll()
+}
+
+func (s *Server) acceptLoop() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			if s.ctx.Err() == nil {
+				log.Errorf("Wallet daemon failed to accept connection: %v", err)
+			}
+			return
+		}
+
+		s.connLock.Lock()
+		s.conns[conn] = true
+		s.connLock.Unlock()
+
+		s.wg.Add(1)
+		go s.serveConn(conn)
+	}
+}
+
+func (s *Server) serveConn(conn net.Conn) {
+	defer s.wg.Done()
+	defer func() {
+		s.connLock.Lock()
+		delete(s.conns, conn)
+		s.connLock.Unlock()
+		conn.Close()
+	}()
+
+	// Each connection has its own session, which holds the authenticated client
+	handler := rpc.NewServer()
+	if err := handler.RegisterName(serviceName, &WalletService{server: s}); err != nil {
+		log.Errorf("Wallet daemon failed to register service: %v", err)
+		return
+	}
+	handler.ServeCodec(jsonrpc.NewServerCodec(conn))
+}
+
+// Stop notifies all goroutines to stop without blocking.
+func (s *Server) Stop() {
+	s.cancel()
+}
+
+// Wait blocks until all goroutines stop.
+func (s *Server) Wait() {
+	s.wg.Wait()
+	os.Remove(s.socketPath)
+}
+
+// removeStaleSocket removes the socket file left by a daemon that is no longer running
+func removeStaleSocket(socketPath string) error {
+	if _, err := os.Stat(socketPath); os.IsNotExist(err) {
+		return nil
+	}
+	if conn, err := net.Dial("unix", socketPath); err == nil {
+		conn.Close()
+		return fmt.Errorf("Wallet daemon already running on %v", socketPath)
+	}
+	return os.Remove(socketPath)
+}
+
+// WalletService implements the RPC methods of a connection to the daemon
+type WalletService struct {
+	server *Server
+	client *ClientConfig
+	mu     sync.Mutex
+}
+
+// authorize returns the authenticated client if it has the permission for the address
+func (ws *WalletService) authorize(perm Permission, address *common.Address) (*ClientConfig, error) {
+	ws.mu.Lock()
+	client := ws.client
+	ws.mu.Unlock()
+
+	if client == nil {
+		return nil, ErrNotAuthenticated
+	}
+	if !client.hasPermission(perm) {
+		return nil, ErrPermissionDenied
+	}
+	if address != nil && !client.hasAddress(*address) {
+		return nil, ErrPermissionDenied
+	}
+	return client, nil
+}
+
+func (ws *WalletService) Auth(args *AuthArgs, result *AuthResult) error {
+	client := findClient(ws.server.clients, args.Token)
+	if client == nil {
+		log.Warnf("Wallet daemon rejected a client with an unknown token")
+		return ErrNotAuthenticated
+	}
+
+	ws.mu.Lock()
+	ws.client = client
+	ws.mu.Unlock()
+
+	result.Name = client.Name
+	return nil
+}
+
+func (ws *WalletService) List(args *ListArgs, result *ListResult) error {
+	client, err := ws.authorize(PermissionList, nil)
+	if err != nil {
+		return err
+	}
+	addresses, err := ws.server.wallet.List()
+	if err != nil {
+		return err
+	}
+	result.Addresses = []common.Address{}
+	for _, address := range addresses {
+		if client.hasAddress(address) {
+			result.Addresses = append(result.Addresses, address)
+		}
+	}
+	return nil
+}
+
+func (ws *WalletService) Unlock(args *UnlockArgs, result *UnlockResult) error {
+	if _, err := ws.authorize(PermissionUnlock, &args.Address); err != nil {
+		return err
+	}
+	return ws.server.wallet.Unlock(args.Address, args.Password)
+}
+
+func (ws *WalletService) Lock(args *LockArgs, result *LockResult) error {
+	if _, err := ws.authorize(PermissionUnlock, &args.Address); err != nil {
+		return err
+	}
+	return ws.server.wallet.Lock(args.Address)
+}
+
+func (ws *WalletService) IsUnlocked(args *IsUnlockedArgs, result *IsUnlockedResult) error {
+	if _, err := ws.authorize(PermissionSign, &args.Address); err != nil {
+		return err
+	}
+	result.Unlocked = ws.server.wallet.IsUnlocked(args.Address)
+	return nil
+}
+
+func (ws *WalletService) GetPublicKey(args *GetPublicKeyArgs, result *GetPublicKeyResult) error {
+	if _, err := ws.authorize(PermissionSign, &args.Address); err != nil {
+		return err
+	}
+	pubKey, err := ws.server.wallet.GetPublicKey(args.Address)
+	if err != nil {
+		return err
+	}
+	result.PublicKey = hexutil.Bytes(pubKey.ToBytes())
+	return nil
+}
+
+func (ws *WalletService) Sign(args *SignArgs, result *SignResult) error {
+	client, err := ws.authorize(PermissionSign, &args.Address)
+	if err != nil {
+		return err
+	}
+	// Only the clients permitted to blind sign may sign messages other than transactions
+	summary, err := preview.DecodeSignBytes(common.Bytes(args.Message))
+	if err != nil && !client.hasPermission(PermissionBlindSign) {
+		return fmt.Errorf("Refusing to sign undecodable message: %v", err)
+	}
+	signature, err := ws.server.wallet.Sign(args.Address, common.Bytes(args.Message))
+	if err != nil {
+		return err
+	}
+	if summary != nil {
+		log.Infof("Wallet daemon signed a %v transaction with %v for client %v", summary.Type, args.Address.Hex(), client.Name)
+	} else {
+		log.Infof("Wallet daemon blind signed a message with %v for client %v", args.Address.Hex(), client.Name)
+	}
+	result.Signature = hexutil.Bytes(signature.ToBytes())
+	return nil
+}
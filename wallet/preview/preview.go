@@ -0,0 +1,412 @@
+package preview
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/ledger/types"
+	"github.com/thetatoken/ukulele/rlp"
+)
+
+//
+// The preview decodes the sign bytes of a transaction into a structured summary,
+// so that the wallet users can review what they sign instead of opaque bytes.
+//
+
+var (
+	ErrInvalidEnvelope = errors.New("Sign bytes are not a transaction signing payload")
+	ErrNonCanonical    = errors.New("Sign bytes are not the canonical encoding of the transaction")
+)
+
+// Input is an account spending coins in the transaction
+type Input struct {
+	Address  common.Address
+	Coins    types.Coins
+	Sequence uint64
+}
+
+// Output is an account receiving coins in the transaction
+type Output struct {
+	Address common.Address
+	Coins   types.Coins
+}
+
+// Summary is the structured summary of the transaction being signed
+type Summary struct {
+	Type    string
+	ChainID string
+	Inputs  []Input
+	Outputs []Output
+	Fee     *types.Coins // Nil if the transaction pays for gas instead
+	Details [][2]string  // Fields specific to the transaction type
+}
+
+// signBytesEnvelope is the Ethereum transaction shaped envelope of the sign bytes,
+// see addPrefixForSignBytes in ledger/types.
+type signBytesEnvelope struct {
+	Nonce    uint64
+	GasPrice *big.Int
+	GasLimit uint64
+	To       common.Address
+	Value    *big.Int
+	Data     []byte
+}
+
+// DecodeSignBytes decodes the sign bytes of a transaction. It returns an error if the
+// bytes are not the canonical sign bytes of a transaction the wallet users sign.
+func DecodeSignBytes(signBytes common.Bytes) (*Summary, error) {
+	var envelope signBytesEnvelope
+	if err := rlp.DecodeBytes(signBytes, &envelope); err != nil {
+		return nil, ErrInvalidEnvelope
+	}
+	if envelope.Nonce != 0 || envelope.GasPrice.Sign() != 0 || envelope.GasLimit != 0 ||
+		envelope.To != (common.Address{}) || envelope.Value.Sign() != 0 {
+		return nil, ErrInvalidEnvelope
+	}
+
+	chainID, txBytes, err := rlp.SplitString(envelope.Data)
+	if err != nil {
+		return nil, ErrInvalidEnvelope
+	}
+	tx, err := types.TxFromBytes(txBytes)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to decode transaction: %v", err)
+	}
+	reencoded, err := types.TxToBytes(tx)
+	if err != nil || !bytes.Equal(reencoded, txBytes) {
+		return nil, ErrNonCanonical
+	}
+
+	summary, err := summarize(tx)
+	if err != nil {
+		return nil, err
+	}
+	summary.ChainID = string(chainID)
+	return summary, nil
+}
+
+func summarize(tx types.Tx) (*Summary, error) {
+	switch tx := tx.(type) {
+	case *types.SendTx:
+		s := &Summary{Type: "Send", Fee: &tx.Fee}
+		for _, input := range tx.Inputs {
+			s.Inputs = append(s.Inputs, newInput(input))
+		}
+		for _, output := range tx.Outputs {
+			s.Outputs = append(s.Outputs, Output{Address: output.Address, Coins: output.Coins})
+		}
+		return s, nil
+	case *types.TimelockedSendTx:
+		s := &Summary{
+			Type: "Timelocked send",
+			Fee:  &tx.Fee,
+			Details: [][2]string{
+				{"Unlock height", fmt.Sprintf("%d", tx.UnlockHeight)},
+			},
+		}
+		for _, input := range tx.Inputs {
+			s.Inputs = append(s.Inputs, newInput(input))
+		}
+		for _, output := range tx.Outputs {
+			s.Outputs = append(s.Outputs, Output{Address: output.Address, Coins: output.Coins})
+		}
+		return s, nil
+	case *types.ReserveFundTx:
+		s := &Summary{
+			Type:   "Reserve fund",
+			Inputs: []Input{newInput(tx.Source)},
+			Fee:    &tx.Fee,
+			Details: [][2]string{
+				{"Collateral", FormatCoins(tx.Collateral)},
+				{"Resources", strings.Join(tx.ResourceIDs, ",")},
+				{"Duration", fmt.Sprintf("%d blocks", tx.Duration)},
+			},
+		}
+		for _, spendLimit := range tx.SpendLimits {
+			s.Details = append(s.Details, [2]string{"Spend limit " + spendLimit.ResourceID, FormatCoins(spendLimit.Limit)})
+		}
+		return s, nil
+	case *types.ReleaseFundTx:
+		return &Summary{
+			Type:    "Release fund",
+			Inputs:  []Input{newInput(tx.Source)},
+			Fee:     &tx.Fee,
+			Details: [][2]string{{"Reserve seq", fmt.Sprintf("%d", tx.ReserveSequence)}},
+		}, nil
+	case *types.ExtendReserveTx:
+		return &Summary{
+			Type:   "Extend reserve",
+			Inputs: []Input{newInput(tx.Source)},
+			Fee:    &tx.Fee,
+			Details: [][2]string{
+				{"Collateral", FormatCoins(tx.Collateral)},
+				{"Duration", fmt.Sprintf("%d blocks", tx.Duration)},
+				{"Reserve seq", fmt.Sprintf("%d", tx.ReserveSequence)},
+			},
+		}, nil
+	case *types.ServicePaymentTx:
+		return &Summary{
+			Type:   "Service payment",
+			Inputs: []Input{newInput(tx.Source), newInput(tx.Target)},
+			Fee:    &tx.Fee,
+			Details: [][2]string{
+				{"Resource", tx.ResourceID},
+				{"Payment seq", fmt.Sprintf("%d", tx.PaymentSequence)},
+				{"Reserve seq", fmt.Sprintf("%d", tx.ReserveSequence)},
+			},
+		}, nil
+	case *types.BatchServicePaymentTx:
+		s := &Summary{
+			Type:   "Batch service payment",
+			Inputs: []Input{newInput(tx.Target)},
+			Fee:    &tx.Fee,
+		}
+		for _, payment := range tx.Payments {
+			s.Details = append(s.Details, [2]string{"Payment", fmt.Sprintf("%v (%v), resource %v, payment seq %d, reserve seq %d",
+				payment.Source.Address.Hex(), FormatCoins(payment.Source.Coins), payment.ResourceID, payment.PaymentSequence, payment.ReserveSequence)})
+		}
+		return s, nil
+	case *types.SplitRuleTx:
+		s := &Summary{
+			Type:   "Split rule",
+			Inputs: []Input{newInput(tx.Initiator)},
+			Fee:    &tx.Fee,
+			Details: [][2]string{
+				{"Resource", tx.ResourceID},
+			},
+		}
+		if len(tx.PlatformSplits) > 0 {
+			s.Details = append(s.Details, [2]string{"Platform splits", formatSplits(tx.PlatformSplits)})
+		}
+		s.Details = append(s.Details,
+			[2]string{"Splits", formatSplits(tx.Splits)},
+			[2]string{"Duration", fmt.Sprintf("%d blocks", tx.Duration)})
+		return s, nil
+	case *types.SmartContractTx:
+		to := tx.To.Address.Hex()
+		if tx.To.Address == (common.Address{}) {
+			to = "(contract deployment)"
+		}
+		gasPrice := tx.GasPrice
+		if gasPrice == nil {
+			gasPrice = big.NewInt(0)
+		}
+		maxFee := new(big.Int).Mul(new(big.Int).SetUint64(tx.GasLimit), gasPrice)
+		return &Summary{
+			Type:   "Smart contract",
+			Inputs: []Input{newInput(tx.From)},
+			Details: [][2]string{
+				{"Contract", to},
+				{"Gas limit", fmt.Sprintf("%d", tx.GasLimit)},
+				{"Gas price", fmt.Sprintf("%v GammaWei", gasPrice)},
+				{"Max fee", fmt.Sprintf("%v Gamma", FormatWei(maxFee))},
+				{"Data size", fmt.Sprintf("%d bytes", len(tx.Data))},
+			},
+		}, nil
+	case *types.DepositStakeTx:
+		return &Summary{
+			Type:    "Deposit stake",
+			Inputs:  []Input{newInput(tx.Source)},
+			Fee:     &tx.Fee,
+			Details: [][2]string{{"Holder", tx.Holder.Address.Hex()}},
+		}, nil
+	case *types.WithdrawStakeTx:
+		return &Summary{
+			Type:   "Withdraw stake",
+			Inputs: []Input{newInput(tx.Source)},
+			Fee:    &tx.Fee,
+			Details: [][2]string{
+				{"Holder", tx.Holder.Address.Hex()},
+				{"Locking period", fmt.Sprintf("%d blocks", types.ReturnLockingPeriod)},
+			},
+		}, nil
+	case *types.SetGuardiansTx:
+		guardians := []string{}
+		for _, guardian := range tx.Guardians {
+			guardians = append(guardians, guardian.Hex())
+		}
+		return &Summary{
+			Type:   "Set guardians",
+			Inputs: []Input{newInput(tx.Account)},
+			Fee:    &tx.Fee,
+			Details: [][2]string{
+				{"Guardians", strings.Join(guardians, ", ")},
+				{"Threshold", fmt.Sprintf("%d", tx.Threshold)},
+				{"Timelock", fmt.Sprintf("%d blocks", tx.Timelock)},
+			},
+		}, nil
+	case *types.RecoveryTx:
+		s := &Summary{
+			Type: "Account recovery",
+			Fee:  &tx.Fee,
+			Details: [][2]string{
+				{"Account", fmt.Sprintf("%v, sequence %d", tx.Account.Address.Hex(), tx.Account.Sequence)},
+				{"New signer", tx.NewSigner.Hex()},
+			},
+		}
+		for _, guardian := range tx.Guardians {
+			s.Inputs = append(s.Inputs, newInput(guardian))
+		}
+		return s, nil
+	case *types.CreateTokenTx:
+		return &Summary{
+			Type:   "Create token",
+			Inputs: []Input{newInput(tx.Issuer)},
+			Fee:    &tx.Fee,
+			Details: [][2]string{
+				{"Symbol", tx.Symbol},
+				{"Decimals", fmt.Sprintf("%d", tx.Decimals)},
+				{"Max supply", fmt.Sprintf("%v", tx.MaxSupply)},
+			},
+		}, nil
+	case *types.ParameterUpdateTx:
+		s := &Summary{
+			Type:   "Parameter update",
+			Inputs: []Input{newInput(tx.Proposer)},
+			Fee:    &tx.Fee,
+			Details: [][2]string{
+				{"Height", fmt.Sprintf("%d", tx.Height)},
+				{"Minimum fee", fmt.Sprintf("%d GammaWei", tx.Parameters.MinimumTransactionFeeGammaWei)},
+				{"Max block gas", fmt.Sprintf("%d", tx.Parameters.MaxBlockGas)},
+				{"Slash collateral", fmt.Sprintf("%d%%", tx.Parameters.SlashCollateralPercent)},
+				{"Slash reporter reward", fmt.Sprintf("%d%%", tx.Parameters.SlashReporterRewardPercent)},
+				{"Slash jail duration", fmt.Sprintf("%d epochs", tx.Parameters.SlashJailDuration)},
+				{"Block reward", fmt.Sprintf("%v GammaWei", tx.Parameters.BlockRewardGammaWei)},
+				{"Reward halving interval", fmt.Sprintf("%d blocks", tx.Parameters.RewardHalvingInterval)},
+				{"Fee burn", fmt.Sprintf("%d%%", tx.Parameters.FeeBurnPercent)},
+				{"Proposer fee", fmt.Sprintf("%d%%", tx.Parameters.ProposerFeePercent)},
+				{"Max reserved funds", fmt.Sprintf("%d", tx.Parameters.MaxReservedFundsPerAccount)},
+				{"Max reserve duration", fmt.Sprintf("%d blocks", tx.Parameters.MaxTotalReserveDuration)},
+			},
+		}
+		for _, approver := range tx.Approvers {
+			s.Inputs = append(s.Inputs, newInput(approver))
+		}
+		return s, nil
+	case *types.ProposalTx:
+		s := &Summary{
+			Type:   "Governance proposal",
+			Inputs: []Input{newInput(tx.Proposer)},
+			Fee:    &tx.Fee,
+			Details: [][2]string{
+				{"Kind", tx.Change.Kind.String()},
+			},
+		}
+		switch tx.Change.Kind {
+		case types.ProposalParameterChange:
+			s.Details = append(s.Details,
+				[2]string{"Minimum fee", fmt.Sprintf("%d GammaWei", tx.Change.Parameters.MinimumTransactionFeeGammaWei)},
+				[2]string{"Max block gas", fmt.Sprintf("%d", tx.Change.Parameters.MaxBlockGas)},
+				[2]string{"Slash collateral", fmt.Sprintf("%d%%", tx.Change.Parameters.SlashCollateralPercent)},
+				[2]string{"Slash reporter reward", fmt.Sprintf("%d%%", tx.Change.Parameters.SlashReporterRewardPercent)},
+				[2]string{"Slash jail duration", fmt.Sprintf("%d epochs", tx.Change.Parameters.SlashJailDuration)},
+				[2]string{"Block reward", fmt.Sprintf("%v GammaWei", tx.Change.Parameters.BlockRewardGammaWei)},
+				[2]string{"Reward halving interval", fmt.Sprintf("%d blocks", tx.Change.Parameters.RewardHalvingInterval)},
+				[2]string{"Fee burn", fmt.Sprintf("%d%%", tx.Change.Parameters.FeeBurnPercent)},
+				[2]string{"Proposer fee", fmt.Sprintf("%d%%", tx.Change.Parameters.ProposerFeePercent)},
+				[2]string{"Max reserved funds", fmt.Sprintf("%d", tx.Change.Parameters.MaxReservedFundsPerAccount)},
+				[2]string{"Max reserve duration", fmt.Sprintf("%d blocks", tx.Change.Parameters.MaxTotalReserveDuration)})
+		case types.ProposalJailValidator:
+			s.Details = append(s.Details,
+				[2]string{"Validator", tx.Change.Validator.Hex()},
+				[2]string{"Jail duration", fmt.Sprintf("%d epochs", tx.Change.JailDuration)})
+		}
+		s.Details = append(s.Details,
+			[2]string{"Voting period", fmt.Sprintf("%d blocks", tx.VotingPeriod)},
+			[2]string{"Description", tx.Description})
+		return s, nil
+	case *types.VoteTx:
+		vote := "Reject"
+		if tx.Approve {
+			vote = "Approve"
+		}
+		return &Summary{
+			Type:   "Governance vote",
+			Inputs: []Input{newInput(tx.Voter)},
+			Fee:    &tx.Fee,
+			Details: [][2]string{
+				{"Proposal", fmt.Sprintf("%d", tx.ProposalID)},
+				{"Vote", vote},
+			},
+		}, nil
+	case *types.UnjailTx:
+		return &Summary{
+			Type:   "Unjail",
+			Inputs: []Input{newInput(tx.Validator)},
+			Fee:    &tx.Fee,
+		}, nil
+	default:
+		return nil, fmt.Errorf("Transaction type %T is not signed by wallets", tx)
+	}
+}
+
+func newInput(input types.TxInput) Input {
+	return Input{Address: input.Address, Coins: input.Coins, Sequence: input.Sequence}
+}
+
+func formatSplits(splits []types.Split) string {
+	formatted := []string{}
+	for _, split := range splits {
+		formatted = append(formatted, fmt.Sprintf("%v: %d%%", split.Address.Hex(), split.Percentage))
+	}
+	return strings.Join(formatted, ", ")
+}
+
+// Fields returns the summary as the label and value pairs to display
+func (s *Summary) Fields() [][2]string {
+	fields := [][2]string{
+		{"Type", s.Type},
+		{"Chain", s.ChainID},
+	}
+	for _, input := range s.Inputs {
+		fields = append(fields, [2]string{"Input", fmt.Sprintf("%v (%v), sequence %d",
+			input.Address.Hex(), FormatCoins(input.Coins), input.Sequence)})
+	}
+	for _, output := range s.Outputs {
+		fields = append(fields, [2]string{"Output", fmt.Sprintf("%v (%v)",
+			output.Address.Hex(), FormatCoins(output.Coins))})
+	}
+	if s.Fee != nil {
+		fields = append(fields, [2]string{"Fee", FormatCoins(*s.Fee)})
+	}
+	return append(fields, s.Details...)
+}
+
+// HasInput returns whether the address spends coins in the transaction
+func (s *Summary) HasInput(address common.Address) bool {
+	for _, input := range s.Inputs {
+		if input.Address == address {
+			return true
+		}
+	}
+	return false
+}
+
+// FormatCoins formats a coin amount in whole tokens
+func FormatCoins(coins types.Coins) string {
+	c := coins.NoNil()
+	s := fmt.Sprintf("%v Theta, %v Gamma", FormatWei(c.ThetaWei), FormatWei(c.GammaWei))
+	for _, token := range c.Tokens {
+		// The decimals of the tokens are not known offline, the amounts are in their smallest unit
+		s += fmt.Sprintf(", %v %v", token.Amount, token.Symbol)
+	}
+	return s
+}
+
+// FormatWei formats an amount in wei as a decimal number of whole tokens
+func FormatWei(amount *big.Int) string {
+	if amount == nil {
+		amount = big.NewInt(0)
+	}
+	unit := new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil)
+	whole, frac := new(big.Int).QuoRem(amount, unit, new(big.Int))
+	if frac.Sign() == 0 {
+		return whole.String()
+	}
+	fracStr := strings.TrimRight(fmt.Sprintf("%018s", new(big.Int).Abs(frac).String()), "0")
+	return fmt.Sprintf("%v.%v", whole, fracStr)
+}
@@ -0,0 +1,101 @@
+package preview
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/ledger/types"
+	"github.com/thetatoken/ukulele/rlp"
+)
+
+var (
+	alice = common.HexToAddress("0x2E833968E5bB786Ae419c4d13189fB081Cc43bab")
+	bob   = common.HexToAddress("0x9F1233798E905E173560071255140b4A8aBd3Ec6")
+)
+
+func TestDecodeSendTx(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	sendTx := &types.SendTx{
+		Fee:     types.NewCoins(0, 1000000000000),
+		Inputs:  []types.TxInput{{Address: alice, Coins: types.NewCoins(0, 1000000000000), Sequence: 5}},
+		Outputs: []types.TxOutput{{Address: bob, Coins: types.Coins{ThetaWei: big.NewInt(1), GammaWei: big.NewInt(0)}}},
+	}
+	sendTx.Inputs[0].Coins.ThetaWei = new(big.Int).Mul(big.NewInt(15), big.NewInt(1e17))
+
+	summary, err := DecodeSignBytes(sendTx.SignBytes("privatenet"))
+	require.Nil(err)
+	assert.Equal("Send", summary.Type)
+	assert.Equal("privatenet", summary.ChainID)
+	assert.True(summary.HasInput(alice))
+	assert.False(summary.HasInput(bob))
+	require.Equal(1, len(summary.Outputs))
+	assert.Equal(bob, summary.Outputs[0].Address)
+
+	fields := summary.Fields()
+	assert.Equal([2]string{"Input", alice.Hex() + " (1.5 Theta, 0.000001 Gamma), sequence 5"}, fields[2])
+	assert.Equal([2]string{"Fee", "0 Theta, 0.000001 Gamma"}, fields[4])
+}
+
+func TestDecodeSmartContractTx(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	smartContractTx := &types.SmartContractTx{
+		From:     types.TxInput{Address: alice, Coins: types.NewCoins(0, 0), Sequence: 1},
+		To:       types.TxOutput{},
+		GasLimit: 50000,
+		GasPrice: big.NewInt(100000000),
+		Data:     common.Bytes{0x60, 0x80, 0x60, 0x40},
+	}
+	summary, err := DecodeSignBytes(smartContractTx.SignBytes("testnet"))
+	require.Nil(err)
+	assert.Equal("Smart contract", summary.Type)
+	assert.Nil(summary.Fee)
+	assert.Contains(summary.Details, [2]string{"Contract", "(contract deployment)"})
+	assert.Contains(summary.Details, [2]string{"Max fee", "0.000005 Gamma"})
+	assert.Contains(summary.Details, [2]string{"Data size", "4 bytes"})
+}
+
+func TestDecodeInvalidSignBytes(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := DecodeSignBytes(common.Bytes("theta"))
+	assert.Equal(ErrInvalidEnvelope, err)
+
+	// The envelope must have the zero prefix fields
+	envelope, err := rlp.EncodeToBytes([]interface{}{uint64(1), big.NewInt(0), uint64(0), common.Address{}, big.NewInt(0), []byte{}})
+	assert.Nil(err)
+	_, err = DecodeSignBytes(envelope)
+	assert.Equal(ErrInvalidEnvelope, err)
+
+	// Trailing bytes after the transaction are not canonical
+	sendTx := &types.SendTx{
+		Fee:     types.NewCoins(0, 1),
+		Inputs:  []types.TxInput{{Address: alice, Coins: types.NewCoins(0, 1), Sequence: 1}},
+		Outputs: []types.TxOutput{},
+	}
+	chainID, err := rlp.EncodeToBytes("privatenet")
+	assert.Nil(err)
+	txBytes, err := types.TxToBytes(sendTx)
+	assert.Nil(err)
+	data := append(append(chainID, txBytes...), 0x1)
+	envelope, err = rlp.EncodeToBytes([]interface{}{uint64(0), big.NewInt(0), uint64(0), common.Address{}, big.NewInt(0), data})
+	assert.Nil(err)
+	_, err = DecodeSignBytes(envelope)
+	assert.NotNil(err)
+}
+
+func TestFormatWei(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("0", FormatWei(nil))
+	assert.Equal("12", FormatWei(new(big.Int).Mul(big.NewInt(12), big.NewInt(1e18))))
+	assert.Equal("0.000000000000000001", FormatWei(big.NewInt(1)))
+	assert.Equal("2.5", FormatWei(big.NewInt(25e17)))
+}
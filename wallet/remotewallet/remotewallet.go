@@ -0,0 +1,201 @@
+package remotewallet
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/common/hexutil"
+	"github.com/thetatoken/ukulele/crypto"
+	"github.com/thetatoken/ukulele/wallet/types"
+)
+
+var _ types.Wallet = (*RemoteWallet)(nil)
+
+// DefaultTimeout is the default timeout of the requests to the remote signer. Signing
+// may require an operator approval on the signer side, hence the generous value.
+const DefaultTimeout = 60 * time.Second
+
+// Config specifies the remote signer endpoint and the credentials of the client
+type Config struct {
+	Endpoint string        // HTTPS URL of the signer, e.g. https://signer.local:9000/rpc
+	CertFile string        // PEM encoded client certificate
+	KeyFile  string        // PEM encoded client private key
+	CAFile   string        // PEM encoded CA certificate that issued the signer certificate
+	Timeout  time.Duration // Request timeout, DefaultTimeout if zero
+}
+
+//
+// RemoteWallet implements the Wallet interface. The keys are held by an external
+// signer service (e.g. backed by an HSM), and the sign requests are forwarded to
+// the signer over a mutually authenticated TLS connection.
+//
+
+type RemoteWallet struct {
+	endpoint string
+	client   *rpcClient
+
+	unlocked map[common.Address]bool // Addresses the wallet is allowed to sign for
+	mu       *sync.RWMutex
+}
+
+// NewRemoteWallet creates a remote wallet connecting to the signer specified by the config
+func NewRemoteWallet(config Config) (*RemoteWallet, error) {
+	endpoint, err := url.Parse(config.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid remote signer endpoint: %v", err)
+	}
+	if endpoint.Scheme != "https" {
+		return nil, fmt.Errorf("Remote signer endpoint must use https: %v", config.Endpoint)
+	}
+	tlsConfig, err := NewClientTLSConfig(config.CertFile, config.KeyFile, config.CAFile)
+	if err != nil {
+		return nil, err
+	}
+	return NewRemoteWalletWithTLSConfig(config.Endpoint, tlsConfig, config.Timeout), nil
+}
+
+// NewRemoteWalletWithTLSConfig creates a remote wallet connecting to the signer with
+// the given TLS config
+func NewRemoteWalletWithTLSConfig(endpoint string, tlsConfig *tls.Config, timeout time.Duration) *RemoteWallet {
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+	httpClient := &http.Client{
+		Transport: &http.Transport{TLSClientConfig: tlsConfig},
+		Timeout:   timeout,
+	}
+	return &RemoteWallet{
+		endpoint: endpoint,
+		client:   &rpcClient{endpoint: endpoint, httpClient: httpClient},
+		unlocked: make(map[common.Address]bool),
+		mu:       &sync.RWMutex{},
+	}
+}
+
+func (w *RemoteWallet) ID() string {
+	return w.endpoint
+}
+
+func (w *RemoteWallet) Status() (string, error) {
+	result := ListAddressesResult{}
+	if err := w.client.call(MethodListAddresses, ListAddressesArgs{}, &result); err != nil {
+		return fmt.Sprintf("Failed: %v", err), err
+	}
+	return fmt.Sprintf("Remote signer online, %v addresses", len(result.Addresses)), nil
+}
+
+func (w *RemoteWallet) List() ([]common.Address, error) {
+	result := ListAddressesResult{}
+	if err := w.client.call(MethodListAddresses, ListAddressesArgs{}, &result); err != nil {
+		return nil, err
+	}
+	return result.Addresses, nil
+}
+
+func (w *RemoteWallet) NewKey(password string) (common.Address, error) {
+	return common.Address{}, fmt.Errorf("Not supported for remote wallet")
+}
+
+func (w *RemoteWallet) ImportKey(privKey *crypto.PrivateKey, password string) (common.Address, error) {
+	return common.Address{}, fmt.Errorf("Not supported for remote wallet")
+}
+
+func (w *RemoteWallet) ExportKey(address common.Address, password string) (*crypto.PrivateKey, error) {
+	return nil, fmt.Errorf("Not supported for remote wallet")
+}
+
+// Unlock checks that the signer holds the key of the address. The password is not
+// used, since the signer authenticates the client with its TLS certificate.
+func (w *RemoteWallet) Unlock(address common.Address, password string) error {
+	addresses, err := w.List()
+	if err != nil {
+		return err
+	}
+	for _, addr := range addresses {
+		if addr == address {
+			w.mu.Lock()
+			w.unlocked[address] = true
+			w.mu.Unlock()
+			return nil
+		}
+	}
+	return fmt.Errorf("Address %v is not held by the remote signer", address.Hex())
+}
+
+func (w *RemoteWallet) Lock(address common.Address) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	delete(w.unlocked, address)
+	return nil
+}
+
+func (w *RemoteWallet) LockAll() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.unlocked = make(map[common.Address]bool)
+	return nil
+}
+
+func (w *RemoteWallet) IsUnlocked(address common.Address) bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	return w.unlocked[address]
+}
+
+func (w *RemoteWallet) Delete(address common.Address, password string) error {
+	return fmt.Errorf("Not supported for remote wallet")
+}
+
+func (w *RemoteWallet) UpdatePassword(address common.Address, oldPassword, newPassword string) error {
+	return fmt.Errorf("Not supported for remote wallet")
+}
+
+func (w *RemoteWallet) Derive(path types.DerivationPath, pin bool) (common.Address, error) {
+	return common.Address{}, fmt.Errorf("Not supported for remote wallet")
+}
+
+func (w *RemoteWallet) GetPublicKey(address common.Address) (*crypto.PublicKey, error) {
+	if !w.IsUnlocked(address) {
+		return nil, fmt.Errorf("Address %v is locked", address.Hex())
+	}
+	result := GetPublicKeyResult{}
+	if err := w.client.call(MethodGetPublicKey, GetPublicKeyArgs{Address: address}, &result); err != nil {
+		return nil, err
+	}
+	pubKey, err := crypto.PublicKeyFromBytes(common.Bytes(result.PublicKey))
+	if err != nil {
+		return nil, err
+	}
+	if pubKey.Address() != address {
+		return nil, fmt.Errorf("Remote signer returned the public key of a different address")
+	}
+	return pubKey, nil
+}
+
+// Sign forwards the sign bytes to the signer, and verifies that the returned
+// signature is produced by the key of the address
+func (w *RemoteWallet) Sign(address common.Address, txrlp common.Bytes) (*crypto.Signature, error) {
+	if !w.IsUnlocked(address) {
+		return nil, fmt.Errorf("Address %v is locked", address.Hex())
+	}
+	result := SignResult{}
+	if err := w.client.call(MethodSign, SignArgs{Address: address, Message: hexutil.Bytes(txrlp)}, &result); err != nil {
+		return nil, err
+	}
+	signature, err := crypto.SignatureFromBytes(common.Bytes(result.Signature))
+	if err != nil {
+		return nil, err
+	}
+	if !signature.Verify(txrlp, address) {
+		return nil, fmt.Errorf("Remote signer returned an invalid signature for address %v", address.Hex())
+	}
+	return signature, nil
+}
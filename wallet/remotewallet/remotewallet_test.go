@@ -0,0 +1,215 @@
+package remotewallet
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/common/hexutil"
+	"github.com/thetatoken/ukulele/crypto"
+)
+
+type testCert struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPEM []byte
+	keyPEM  []byte
+}
+
+func newTestCert(t *testing.T, serial int64, isCA bool, parent *testCert) *testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.Nil(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "theta-test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		IsCA:         isCA,
+
+		BasicConstraintsValid: true,
+	}
+	parentCert, parentKey := template, key
+	if parent != nil {
+		parentCert, parentKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parentCert, &key.PublicKey, parentKey)
+	require.Nil(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.Nil(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.Nil(t, err)
+
+	return &testCert{
+		cert:    cert,
+		key:     key,
+		certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyPEM:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}
+}
+
+// mockSigner serves the signing RPC protocol with the given key
+func mockSigner(t *testing.T, privKey *crypto.PrivateKey, tamper bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		request := struct {
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+			ID     uint64            `json:"id"`
+		}{}
+		require.Nil(t, json.NewDecoder(r.Body).Decode(&request))
+
+		var result interface{}
+		switch request.Method {
+		case MethodListAddresses:
+			result = ListAddressesResult{Addresses: []common.Address{privKey.PublicKey().Address()}}
+		case MethodGetPublicKey:
+			result = GetPublicKeyResult{PublicKey: hexutil.Bytes(privKey.PublicKey().ToBytes())}
+		case MethodSign:
+			args := SignArgs{}
+			require.Nil(t, json.Unmarshal(request.Params[0], &args))
+			if tamper {
+				args.Message = append(args.Message, 0x1)
+			}
+			sig, err := privKey.Sign(common.Bytes(args.Message))
+			require.Nil(t, err)
+			result = SignResult{Signature: hexutil.Bytes(sig.ToBytes())}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "result": result, "id": request.ID})
+	}
+}
+
+func newTestSigner(t *testing.T, handler http.HandlerFunc) (*httptest.Server, Config, func()) {
+	ca := newTestCert(t, 1, true, nil)
+	serverCert := newTestCert(t, 2, false, ca)
+	clientCert := newTestCert(t, 3, false, ca)
+
+	caPool := x509.NewCertPool()
+	caPool.AddCert(ca.cert)
+	serverTLSCert, err := tls.X509KeyPair(serverCert.certPEM, serverCert.keyPEM)
+	require.Nil(t, err)
+
+	server := httptest.NewUnstartedServer(handler)
+	server.TLS = &tls.Config{
+		Certificates: []tls.Certificate{serverTLSCert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    caPool,
+	}
+	server.StartTLS()
+
+	dir, err := ioutil.TempDir("", "remotewallet")
+	require.Nil(t, err)
+	config := Config{
+		Endpoint: server.URL,
+		CertFile: path.Join(dir, "client.crt"),
+		KeyFile:  path.Join(dir, "client.key"),
+		CAFile:   path.Join(dir, "ca.crt"),
+	}
+	require.Nil(t, ioutil.WriteFile(config.CertFile, clientCert.certPEM, 0600))
+	require.Nil(t, ioutil.WriteFile(config.KeyFile, clientCert.keyPEM, 0600))
+	require.Nil(t, ioutil.WriteFile(config.CAFile, ca.certPEM, 0600))
+
+	return server, config, func() {
+		server.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestRemoteWalletSign(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	privKey, _, err := crypto.GenerateKeyPair()
+	require.Nil(err)
+	address := privKey.PublicKey().Address()
+
+	_, config, cleanup := newTestSigner(t, mockSigner(t, privKey, false))
+	defer cleanup()
+
+	wallet, err := NewRemoteWallet(config)
+	require.Nil(err)
+
+	addresses, err := wallet.List()
+	require.Nil(err)
+	assert.Equal([]common.Address{address}, addresses)
+
+	msg := common.Bytes("theta remote signer")
+	_, err = wallet.Sign(address, msg)
+	assert.NotNil(err) // locked
+
+	assert.NotNil(wallet.Unlock(common.HexToAddress("0x2E833968E5bB786Ae419c4d13189fB081Cc43bab"), ""))
+	require.Nil(wallet.Unlock(address, ""))
+	assert.True(wallet.IsUnlocked(address))
+
+	sig, err := wallet.Sign(address, msg)
+	require.Nil(err)
+	assert.True(sig.Verify(msg, address))
+
+	pubKey, err := wallet.GetPublicKey(address)
+	require.Nil(err)
+	assert.Equal(address, pubKey.Address())
+
+	require.Nil(wallet.Lock(address))
+	assert.False(wallet.IsUnlocked(address))
+}
+
+func TestRemoteWalletRejectsInvalidSignature(t *testing.T) {
+	require := require.New(t)
+
+	privKey, _, err := crypto.GenerateKeyPair()
+	require.Nil(err)
+	address := privKey.PublicKey().Address()
+
+	_, config, cleanup := newTestSigner(t, mockSigner(t, privKey, true))
+	defer cleanup()
+
+	wallet, err := NewRemoteWallet(config)
+	require.Nil(err)
+	require.Nil(wallet.Unlock(address, ""))
+
+	_, err = wallet.Sign(address, common.Bytes("theta remote signer"))
+	require.NotNil(err)
+}
+
+func TestRemoteWalletRequiresClientCert(t *testing.T) {
+	assert := assert.New(t)
+
+	privKey, _, err := crypto.GenerateKeyPair()
+	assert.Nil(err)
+
+	server, config, cleanup := newTestSigner(t, mockSigner(t, privKey, false))
+	defer cleanup()
+
+	// Plain HTTP and incomplete credentials are refused
+	_, err = NewRemoteWallet(Config{Endpoint: "http://127.0.0.1:9000", CertFile: config.CertFile, KeyFile: config.KeyFile, CAFile: config.CAFile})
+	assert.NotNil(err)
+	_, err = NewRemoteWallet(Config{Endpoint: config.Endpoint, CAFile: config.CAFile})
+	assert.NotNil(err)
+
+	// The signer rejects clients without a certificate
+	caPool := x509.NewCertPool()
+	caPool.AddCert(server.Certificate())
+	wallet := NewRemoteWalletWithTLSConfig(config.Endpoint, &tls.Config{RootCAs: caPool}, time.Second)
+	_, err = wallet.List()
+	assert.NotNil(err)
+}
@@ -0,0 +1,142 @@
+package remotewallet
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/common/hexutil"
+)
+
+//
+// The signing RPC protocol. The remote signer serves JSON-RPC 2.0 over HTTPS, and
+// authenticates the clients with their TLS certificates (mTLS). Each method takes
+// a single object as its parameters.
+//
+
+const (
+	MethodListAddresses = "signer.ListAddresses"
+	MethodGetPublicKey  = "signer.GetPublicKey"
+	MethodSign          = "signer.Sign"
+)
+
+type ListAddressesArgs struct{}
+
+type ListAddressesResult struct {
+	Addresses []common.Address `json:"addresses"`
+}
+
+type GetPublicKeyArgs struct {
+	Address common.Address `json:"address"`
+}
+
+type GetPublicKeyResult struct {
+	PublicKey hexutil.Bytes `json:"public_key"` // Uncompressed 65-byte public key
+}
+
+type SignArgs struct {
+	Address common.Address `json:"address"`
+	Message hexutil.Bytes  `json:"message"` // The sign bytes, the signer hashes them with Keccak256
+}
+
+type SignResult struct {
+	Signature hexutil.Bytes `json:"signature"` // 65-byte R || S || V signature, with V in {0, 1}
+}
+
+type rpcRequest struct {
+	JSONRPC string        `json:"jsonrpc"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+	ID      uint64        `json:"id"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Result  json.RawMessage `json:"result"`
+	Error   *rpcError       `json:"error"`
+	ID      uint64          `json:"id"`
+}
+
+// rpcClient is a minimal JSON-RPC 2.0 client of the remote signer
+type rpcClient struct {
+	endpoint   string
+	httpClient *http.Client
+	nextID     uint64
+}
+
+func (c *rpcClient) call(method string, args interface{}, result interface{}) error {
+	request := rpcRequest{
+		JSONRPC: "2.0",
+		Method:  method,
+		Params:  []interface{}{args},
+		ID:      atomic.AddUint64(&c.nextID, 1),
+	}
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.httpClient.Post(c.endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Remote signer returned HTTP status %v", resp.Status)
+	}
+
+	response := rpcResponse{}
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		return fmt.Errorf("Failed to parse remote signer response: %v", err)
+	}
+	if response.Error != nil {
+		return fmt.Errorf("Remote signer returned error: %v", response.Error.Message)
+	}
+	if response.ID != request.ID {
+		return errors.New("Remote signer response ID mismatch")
+	}
+	return json.Unmarshal(response.Result, result)
+}
+
+// NewClientTLSConfig creates the TLS config for the mutual authentication with the
+// remote signer. The client presents the certificate in certFile, and only trusts
+// the signer certificates issued by the CA in caFile.
+func NewClientTLSConfig(certFile, keyFile, caFile string) (*tls.Config, error) {
+	if len(certFile) == 0 || len(keyFile) == 0 || len(caFile) == 0 {
+		return nil, errors.New("Client certificate, client key and CA certificate are required for the remote signer")
+	}
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to load client certificate: %v", err)
+	}
+	caPEM, err := ioutil.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read CA certificate: %v", err)
+	}
+	caPool := x509.NewCertPool()
+	if !caPool.AppendCertsFromPEM(caPEM) {
+		return nil, fmt.Errorf("No valid CA certificate found in %v", caFile)
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      caPool,
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
@@ -0,0 +1,133 @@
+package softwallet
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"math/big"
+	"strings"
+
+	"github.com/thetatoken/ukulele/common/math"
+	"github.com/thetatoken/ukulele/crypto"
+	"github.com/thetatoken/ukulele/wallet/types"
+	"github.com/tyler-smith/go-bip39"
+)
+
+//
+// Hierarchical deterministic (HD) key derivation, following BIP-39 for the
+// mnemonic seed phrase and BIP-32 for the key derivation
+//
+
+// MnemonicEntropyBits is the entropy of the generated mnemonics, which
+// corresponds to a 24-word seed phrase
+const MnemonicEntropyBits = 256
+
+const hardenedKeyStart = 0x80000000
+
+var (
+	masterKeySeed = []byte("Bitcoin seed") // HMAC key of the BIP-32 master key generation
+
+	ErrInvalidMnemonic = errors.New("Invalid mnemonic")
+	ErrInvalidHDKey    = errors.New("Derived key is invalid, please use the next index")
+)
+
+// NewMnemonic generates a random 24-word mnemonic
+func NewMnemonic() (string, error) {
+	entropy, err := bip39.NewEntropy(MnemonicEntropyBits)
+	if err != nil {
+		return "", err
+	}
+	return bip39.NewMnemonic(entropy)
+}
+
+// NormalizeMnemonic lowercases the mnemonic and removes the redundant whitespaces
+func NormalizeMnemonic(mnemonic string) string {
+	return strings.Join(strings.Fields(strings.ToLower(mnemonic)), " ")
+}
+
+// ValidateMnemonic checks the word list and the checksum of the mnemonic
+func ValidateMnemonic(mnemonic string) error {
+	if _, err := bip39.EntropyFromMnemonic(NormalizeMnemonic(mnemonic)); err != nil {
+		return ErrInvalidMnemonic
+	}
+	return nil
+}
+
+// DeriveKeyFromMnemonic derives the private key at the given path from the mnemonic
+func DeriveKeyFromMnemonic(mnemonic string, path types.DerivationPath) (*crypto.PrivateKey, error) {
+	if err := ValidateMnemonic(mnemonic); err != nil {
+		return nil, err
+	}
+	seed := bip39.NewSeed(NormalizeMnemonic(mnemonic), "")
+	return DeriveKeyFromSeed(seed, path)
+}
+
+// DeriveKeyFromSeed derives the private key at the given path from the BIP-32 seed
+func DeriveKeyFromSeed(seed []byte, path types.DerivationPath) (*crypto.PrivateKey, error) {
+	key, chainCode, err := hdKeyFromHMAC(masterKeySeed, seed)
+	if err != nil {
+		return nil, err
+	}
+	for _, index := range path {
+		key, chainCode, err = deriveChildKey(key, chainCode, index)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return crypto.PrivateKeyFromBytes(key)
+}
+
+// deriveChildKey derives the child private key and chain code at the index
+func deriveChildKey(key, chainCode []byte, index uint32) ([]byte, []byte, error) {
+	var data []byte
+	if index >= hardenedKeyStart {
+		data = append([]byte{0x0}, key...)
+	} else {
+		privKey, err := crypto.PrivateKeyFromBytes(key)
+		if err != nil {
+			return nil, nil, err
+		}
+		data = compressPublicKey(privKey.PublicKey())
+	}
+	indexBytes := make([]byte, 4)
+	binary.BigEndian.PutUint32(indexBytes, index)
+	data = append(data, indexBytes...)
+
+	il, childChainCode, err := hdKeyFromHMAC(chainCode, data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	curveN := crypto.S256().Params().N
+	childKey := new(big.Int).SetBytes(il)
+	childKey.Add(childKey, new(big.Int).SetBytes(key))
+	childKey.Mod(childKey, curveN)
+	if childKey.Sign() == 0 {
+		return nil, nil, ErrInvalidHDKey
+	}
+	return math.PaddedBigBytes(childKey, 32), childChainCode, nil
+}
+
+// hdKeyFromHMAC splits the HMAC-SHA512 of the data into the key and the chain code
+func hdKeyFromHMAC(hmacKey, data []byte) ([]byte, []byte, error) {
+	mac := hmac.New(sha512.New, hmacKey)
+	mac.Write(data)
+	sum := mac.Sum(nil)
+
+	key, chainCode := sum[:32], sum[32:]
+	keyInt := new(big.Int).SetBytes(key)
+	if keyInt.Sign() == 0 || keyInt.Cmp(crypto.S256().Params().N) >= 0 {
+		return nil, nil, ErrInvalidHDKey
+	}
+	return key, chainCode, nil
+}
+
+// compressPublicKey returns the 33-byte SEC1 compressed form of the public key
+func compressPublicKey(pubKey *crypto.PublicKey) []byte {
+	uncompressed := pubKey.ToBytes() // 0x04 || X || Y
+	compressed := make([]byte, 33)
+	compressed[0] = 0x02 | (uncompressed[64] & 0x1)
+	copy(compressed[1:], uncompressed[1:33])
+	return compressed
+}
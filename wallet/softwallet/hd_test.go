@@ -0,0 +1,76 @@
+package softwallet
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/wallet/types"
+)
+
+func TestNewMnemonic(t *testing.T) {
+	assert := assert.New(t)
+
+	mnemonic, err := NewMnemonic()
+	assert.Nil(err)
+	assert.Equal(24, len(strings.Fields(mnemonic)))
+	assert.Nil(ValidateMnemonic(mnemonic))
+
+	mnemonic2, err := NewMnemonic()
+	assert.Nil(err)
+	assert.NotEqual(mnemonic, mnemonic2)
+}
+
+func TestValidateMnemonic(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Nil(ValidateMnemonic("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"))
+	assert.Nil(ValidateMnemonic("  Abandon abandon abandon abandon abandon abandon\nabandon abandon abandon abandon abandon about "))
+	assert.Equal(ErrInvalidMnemonic, ValidateMnemonic("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon")) // bad checksum
+	assert.Equal(ErrInvalidMnemonic, ValidateMnemonic("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon thetaa"))  // not in word list
+	assert.Equal(ErrInvalidMnemonic, ValidateMnemonic(""))
+}
+
+// Test vector 1 of BIP-32
+func TestDeriveKeyFromSeed(t *testing.T) {
+	assert := assert.New(t)
+
+	seed, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	tests := []struct {
+		path string
+		key  string
+	}{
+		{"m/0'", "edb2e14f9ee77d26dd93b4ecede8d16ed408ce149b6cd80b0715a2d911a0afea"},
+		{"m/0'/1", "3c6cb8d0f6a264c91ea8b5030fadaa8e538b020f0a387421a12de9319dc93368"},
+		{"m/0'/1/2'", "cbce0d719ecf7431d88e6a89fa1483e02e35092af60c042b1df2ff59fa424dca"},
+		{"m/0'/1/2'/2/1000000000", "471b76e389e528d6de6d816857e012c5455051cad6660850e58372a6c3e6e7c8"},
+	}
+	for _, tt := range tests {
+		path, err := types.ParseDerivationPath(tt.path)
+		assert.Nil(err)
+		privKey, err := DeriveKeyFromSeed(seed, path)
+		assert.Nil(err)
+		assert.Equal(tt.key, hex.EncodeToString(privKey.ToBytes()), "path: %v", tt.path)
+	}
+}
+
+func TestDeriveKeyFromMnemonic(t *testing.T) {
+	assert := assert.New(t)
+
+	mnemonic := "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	privKey, err := DeriveKeyFromMnemonic(mnemonic, types.DefaultBaseDerivationPath)
+	assert.Nil(err)
+	assert.Equal(common.HexToAddress("0x9858EfFD232B4033E47d90003D41EC34EcaEda94"), privKey.PublicKey().Address())
+
+	privKey0, err := DeriveKeyFromMnemonic(mnemonic, types.ThetaDerivationPath(0))
+	assert.Nil(err)
+	privKey1, err := DeriveKeyFromMnemonic(mnemonic, types.ThetaDerivationPath(1))
+	assert.Nil(err)
+	assert.NotEqual(privKey0.PublicKey().Address(), privKey1.PublicKey().Address())
+	assert.NotEqual(privKey.PublicKey().Address(), privKey0.PublicKey().Address())
+
+	_, err = DeriveKeyFromMnemonic("abandon abandon abandon", types.ThetaDerivationPath(0))
+	assert.Equal(ErrInvalidMnemonic, err)
+}
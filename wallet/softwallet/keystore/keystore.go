@@ -3,7 +3,9 @@ package keystore
 import (
 	"io/ioutil"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
 
 	"github.com/thetatoken/ukulele/common"
 )
@@ -21,28 +23,67 @@ type Keystore interface {
 
 	// Deletes the key from the disk.
 	DeleteKey(address common.Address, auth string) error
+
+	// Checks whether the mnemonic of the HD seed has been stored.
+	HasMnemonic() bool
+
+	// Loads and decrypts the mnemonic of the HD seed from disk.
+	GetMnemonic(auth string) (string, error)
+
+	// Writes and encrypts the mnemonic of the HD seed.
+	StoreMnemonic(mnemonic string, auth string) error
+}
+
+func listKeyAddresses(keysDirPath string) ([]common.Address, error) {
+	filenames, err := filepath.Glob(path.Join(keysDirPath, "*"))
+	if err != nil {
+		return []common.Address{}, err
+	}
+
+	addresses := []common.Address{}
+	for _, filename := range filenames {
+		addrStr := filepath.Base(filename)
+		if strings.HasPrefix(addrStr, ".") {
+			continue // Skip the temporary files of pending writes
+		}
+		address := common.HexToAddress(addrStr)
+		addresses = append(addresses, address)
+	}
+
+	return addresses, nil
 }
 
 func writeKeyFile(file string, content common.Bytes) error {
+	// Atomic write: create a temporary hidden file first
+	// then move it into place.
+	tmpFile, err := writeTempKeyFile(file, content)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmpFile, file)
+}
+
+// writeTempKeyFile writes the content to a temporary hidden file next to the key
+// file, and returns the path of the temporary file
+func writeTempKeyFile(file string, content common.Bytes) (string, error) {
 	// Create the keystore directory with appropriate permissions
 	// in case it is not present yet.
 	const dirPerm = 0700
 	if err := os.MkdirAll(filepath.Dir(file), dirPerm); err != nil {
-		return err
+		return "", err
 	}
-	// Atomic write: create a temporary hidden file first
-	// then move it into place. TempFile assigns mode 0600.
+	// TempFile assigns mode 0600.
 	f, err := ioutil.TempFile(filepath.Dir(file), "."+filepath.Base(file)+".tmp")
 	if err != nil {
-		return err
+		return "", err
 	}
 	if _, err := f.Write(content); err != nil {
 		f.Close()
 		os.Remove(f.Name())
-		return err
+		return "", err
 	}
 	f.Close()
-	return os.Rename(f.Name(), file)
+	return f.Name(), nil
 }
 
 func deleteKeyFile(file string) error {
@@ -39,7 +39,6 @@ import (
 	"io/ioutil"
 	"os"
 	"path"
-	"path/filepath"
 	"runtime"
 
 	"github.com/pborman/uuid"
@@ -81,13 +80,26 @@ var (
 	ErrDecrypt = fmt.Errorf("could not decrypt key with given password")
 )
 
+var _ Keystore = (*KeystoreEncrypted)(nil)
+
+// KeystoreEncrypted stores the keys encrypted with the scrypt parameters it is
+// created with. The plaintext keys of KeystorePlain under the same root folder, and
+// the keys encrypted with weaker KDF parameters are migrated, i.e. encrypted with
+// the configured parameters, when they are decrypted with the password.
 type KeystoreEncrypted struct {
-	keysDirPath string
-	scryptN     int
-	scryptP     int
+	keysDirPath           string
+	plainKeysDirPath      string
+	mnemonicFilePath      string
+	plainMnemonicFilePath string
+	scryptN               int
+	scryptP               int
 }
 
 func NewKeystoreEncrypted(keysDirRoot string, scryptN, scryptP int) (KeystoreEncrypted, error) {
+	if err := ValidateScryptParams(scryptN, scryptP); err != nil {
+		return KeystoreEncrypted{}, err
+	}
+
 	keysDirPath := path.Join(keysDirRoot, "encrypted")
 	err := os.MkdirAll(keysDirPath, 0700)
 	if err != nil {
@@ -103,25 +115,43 @@ func NewKeystoreEncrypted(keysDirRoot string, scryptN, scryptP int) (KeystoreEnc
 	}
 
 	ks := KeystoreEncrypted{
-		keysDirPath: keysDirPath,
-		scryptN:     scryptN,
-		scryptP:     scryptP,
+		keysDirPath:           keysDirPath,
+		plainKeysDirPath:      path.Join(keysDirRoot, "plain"),
+		mnemonicFilePath:      path.Join(keysDirRoot, "hd", "encrypted"),
+		plainMnemonicFilePath: path.Join(keysDirRoot, "hd", "plain"),
+		scryptN:               scryptN,
+		scryptP:               scryptP,
 	}
 
 	return ks, nil
 }
 
+// ValidateScryptParams checks whether the scrypt parameters are valid
+func ValidateScryptParams(scryptN, scryptP int) error {
+	if scryptN <= 1 || scryptN&(scryptN-1) != 0 {
+		return fmt.Errorf("Invalid scrypt parameter N: %v, must be a power of 2 greater than 1", scryptN)
+	}
+	if scryptP < 1 || uint64(scryptR)*uint64(scryptP) >= 1<<30 {
+		return fmt.Errorf("Invalid scrypt parameter P: %v", scryptP)
+	}
+	return nil
+}
+
+// ListKeyAddresses lists the addresses of the encrypted keys, and of the plaintext
+// keys pending migration
 func (ks KeystoreEncrypted) ListKeyAddresses() ([]common.Address, error) {
-	filenames, err := filepath.Glob(path.Join(ks.keysDirPath, "*"))
+	addresses, err := listKeyAddresses(ks.keysDirPath)
 	if err != nil {
 		return []common.Address{}, err
 	}
-
-	addresses := []common.Address{}
-	for _, filename := range filenames {
-		addrStr := filepath.Base(filename)
-		address := common.HexToAddress(addrStr)
-		addresses = append(addresses, address)
+	plainAddresses, err := listKeyAddresses(ks.plainKeysDirPath)
+	if err != nil {
+		return []common.Address{}, err
+	}
+	for _, plainAddress := range plainAddresses {
+		if !fileExist(ks.getFilePath(plainAddress)) {
+			addresses = append(addresses, plainAddress)
+		}
 	}
 
 	return addresses, nil
@@ -129,11 +159,15 @@ func (ks KeystoreEncrypted) ListKeyAddresses() ([]common.Address, error) {
 
 func (ks KeystoreEncrypted) GetKey(address common.Address, auth string) (*Key, error) {
 	filePath := ks.getFilePath(address)
+	if !fileExist(filePath) && fileExist(ks.getPlainFilePath(address)) {
+		return ks.migratePlainKey(address, auth)
+	}
+
 	keyjson, err := ioutil.ReadFile(filePath)
 	if err != nil {
 		return nil, err
 	}
-	key, err := decryptKey(keyjson, auth)
+	key, err := DecryptKey(keyjson, auth)
 	if err != nil {
 		return nil, err
 	}
@@ -141,13 +175,24 @@ func (ks KeystoreEncrypted) GetKey(address common.Address, auth string) (*Key, e
 	if key.Address != address {
 		return nil, fmt.Errorf("key content mismatch: have account %x, want %x", key.Address, address)
 	}
+
+	encryptedKeyJs := new(encryptedKeyJSON)
+	if err := json.Unmarshal(keyjson, encryptedKeyJs); err != nil {
+		return nil, err
+	}
+	if ks.isWeakKDF(encryptedKeyJs.Crypto) {
+		if err := ks.StoreKey(key, auth); err != nil {
+			return nil, err
+		}
+	}
+
 	return key, nil
 }
 
 func (ks KeystoreEncrypted) StoreKey(key *Key, auth string) error {
 	address := key.Address
 	filePath := ks.getFilePath(address)
-	keyjson, err := encryptKey(key, auth, ks.scryptN, ks.scryptP)
+	keyjson, err := EncryptKey(key, auth, ks.scryptN, ks.scryptP)
 	if err != nil {
 		return err
 	}
@@ -165,14 +210,300 @@ func (ks KeystoreEncrypted) DeleteKey(address common.Address, auth string) error
 	return err
 }
 
+func (ks KeystoreEncrypted) HasMnemonic() bool {
+	return fileExist(ks.mnemonicFilePath) || fileExist(ks.plainMnemonicFilePath)
+}
+
+func (ks KeystoreEncrypted) GetMnemonic(auth string) (string, error) {
+	if !fileExist(ks.mnemonicFilePath) && fileExist(ks.plainMnemonicFilePath) {
+		return ks.migratePlainMnemonic(auth)
+	}
+
+	mnemonicjson, err := ioutil.ReadFile(ks.mnemonicFilePath)
+	if err != nil {
+		return "", err
+	}
+	encryptedMnemonicJs := new(encryptedMnemonicJSON)
+	if err := json.Unmarshal(mnemonicjson, encryptedMnemonicJs); err != nil {
+		return "", err
+	}
+	if encryptedMnemonicJs.Version != version {
+		return "", fmt.Errorf("Version %v not supported", encryptedMnemonicJs.Version)
+	}
+	mnemonic, err := decryptData(encryptedMnemonicJs.Crypto, auth)
+	if err != nil {
+		return "", err
+	}
+
+	if ks.isWeakKDF(encryptedMnemonicJs.Crypto) {
+		if err := ks.StoreMnemonic(string(mnemonic), auth); err != nil {
+			return "", err
+		}
+	}
+
+	return string(mnemonic), nil
+}
+
+func (ks KeystoreEncrypted) StoreMnemonic(mnemonic string, auth string) error {
+	mnemonicjson, err := encryptMnemonic(mnemonic, auth, ks.scryptN, ks.scryptP)
+	if err != nil {
+		return err
+	}
+	return writeKeyFile(ks.mnemonicFilePath, mnemonicjson)
+}
+
+// Reencrypt encrypts all the keys and the mnemonic of the HD seed with the new
+// password and scrypt parameters, e.g. to rotate the password or upgrade the KDF.
+// Everything is decrypted with the current password and written to temporary files
+// before any key file is replaced, so a wrong password or a failed write leaves
+// the keystore unchanged. The plaintext keys pending migration are removed once
+// their encrypted files are in place. It returns the addresses re-encrypted.
+func (ks KeystoreEncrypted) Reencrypt(auth, newAuth string, scryptN, scryptP int) ([]common.Address, error) {
+	if err := ValidateScryptParams(scryptN, scryptP); err != nil {
+		return nil, err
+	}
+	addresses, err := ks.ListKeyAddresses()
+	if err != nil {
+		return nil, err
+	}
+
+	type pendingWrite struct {
+		tmpFile   string
+		file      string
+		plainFile string
+	}
+	pending := []pendingWrite{}
+	cleanup := func(writes []pendingWrite) {
+		for _, write := range writes {
+			os.Remove(write.tmpFile)
+		}
+	}
+
+	for _, address := range addresses {
+		key, err := ks.loadKey(address, auth)
+		if err != nil {
+			cleanup(pending)
+			return nil, fmt.Errorf("Failed to decrypt key %v: %v", address.Hex(), err)
+		}
+		keyjson, err := EncryptKey(key, newAuth, scryptN, scryptP)
+		if err != nil {
+			cleanup(pending)
+			return nil, err
+		}
+		tmpFile, err := writeTempKeyFile(ks.getFilePath(address), keyjson)
+		if err != nil {
+			cleanup(pending)
+			return nil, err
+		}
+		pending = append(pending, pendingWrite{tmpFile, ks.getFilePath(address), ks.getPlainFilePath(address)})
+	}
+
+	if ks.HasMnemonic() {
+		mnemonic, err := ks.loadMnemonic(auth)
+		if err != nil {
+			cleanup(pending)
+			return nil, fmt.Errorf("Failed to decrypt HD seed: %v", err)
+		}
+		mnemonicjson, err := encryptMnemonic(mnemonic, newAuth, scryptN, scryptP)
+		if err != nil {
+			cleanup(pending)
+			return nil, err
+		}
+		tmpFile, err := writeTempKeyFile(ks.mnemonicFilePath, mnemonicjson)
+		if err != nil {
+			cleanup(pending)
+			return nil, err
+		}
+		pending = append(pending, pendingWrite{tmpFile, ks.mnemonicFilePath, ks.plainMnemonicFilePath})
+	}
+
+	for i, write := range pending {
+		if err := os.Rename(write.tmpFile, write.file); err != nil {
+			cleanup(pending[i:])
+			return nil, err
+		}
+	}
+	for _, write := range pending {
+		if fileExist(write.plainFile) {
+			if err := deleteKeyFile(write.plainFile); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return addresses, nil
+}
+
 func (ks KeystoreEncrypted) getFilePath(address common.Address) string {
 	filePath := path.Join(ks.keysDirPath, address.Hex()[2:])
 	return filePath
 }
 
-// encryptKey encrypts a key using the specified scrypt parameters into a json
+func (ks KeystoreEncrypted) getPlainFilePath(address common.Address) string {
+	filePath := path.Join(ks.plainKeysDirPath, address.Hex()[2:])
+	return filePath
+}
+
+// migratePlainKey encrypts the plaintext key of the address with the password,
+// and removes the plaintext key file
+func (ks KeystoreEncrypted) migratePlainKey(address common.Address, auth string) (*Key, error) {
+	plainKeystore := KeystorePlain{keysDirPath: ks.plainKeysDirPath}
+	key, err := plainKeystore.GetKey(address, auth)
+	if err != nil {
+		return nil, err
+	}
+	if err := ks.StoreKey(key, auth); err != nil {
+		return nil, err
+	}
+	if err := deleteKeyFile(ks.getPlainFilePath(address)); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// migratePlainMnemonic encrypts the plaintext mnemonic with the password, and
+// removes the plaintext mnemonic file
+func (ks KeystoreEncrypted) migratePlainMnemonic(auth string) (string, error) {
+	plainKeystore := KeystorePlain{mnemonicFilePath: ks.plainMnemonicFilePath}
+	mnemonic, err := plainKeystore.GetMnemonic(auth)
+	if err != nil {
+		return "", err
+	}
+	if err := ks.StoreMnemonic(mnemonic, auth); err != nil {
+		return "", err
+	}
+	if err := deleteKeyFile(ks.plainMnemonicFilePath); err != nil {
+		return "", err
+	}
+	return mnemonic, nil
+}
+
+// loadKey decrypts the key of the address, or reads its plaintext key pending
+// migration, without modifying the keystore
+func (ks KeystoreEncrypted) loadKey(address common.Address, auth string) (*Key, error) {
+	filePath := ks.getFilePath(address)
+	if !fileExist(filePath) {
+		plainKeystore := KeystorePlain{keysDirPath: ks.plainKeysDirPath}
+		return plainKeystore.GetKey(address, auth)
+	}
+
+	keyjson, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	key, err := DecryptKey(keyjson, auth)
+	if err != nil {
+		return nil, err
+	}
+	if key.Address != address {
+		return nil, fmt.Errorf("key content mismatch: have account %x, want %x", key.Address, address)
+	}
+	return key, nil
+}
+
+// loadMnemonic decrypts the mnemonic, or reads the plaintext mnemonic pending
+// migration, without modifying the keystore
+func (ks KeystoreEncrypted) loadMnemonic(auth string) (string, error) {
+	if !fileExist(ks.mnemonicFilePath) {
+		plainKeystore := KeystorePlain{mnemonicFilePath: ks.plainMnemonicFilePath}
+		return plainKeystore.GetMnemonic(auth)
+	}
+
+	mnemonicjson, err := ioutil.ReadFile(ks.mnemonicFilePath)
+	if err != nil {
+		return "", err
+	}
+	encryptedMnemonicJs := new(encryptedMnemonicJSON)
+	if err := json.Unmarshal(mnemonicjson, encryptedMnemonicJs); err != nil {
+		return "", err
+	}
+	if encryptedMnemonicJs.Version != version {
+		return "", fmt.Errorf("Version %v not supported", encryptedMnemonicJs.Version)
+	}
+	mnemonic, err := decryptData(encryptedMnemonicJs.Crypto, auth)
+	if err != nil {
+		return "", err
+	}
+	return string(mnemonic), nil
+}
+
+// isWeakKDF checks whether the KDF of the encrypted data is weaker than the
+// scrypt parameters of the keystore
+func (ks KeystoreEncrypted) isWeakKDF(cryptoJson cryptoJSON) bool {
+	if cryptoJson.KDF != keyHeaderKDF {
+		return true
+	}
+	n := ensureInt(cryptoJson.KDFParams["n"])
+	r := ensureInt(cryptoJson.KDFParams["r"])
+	p := ensureInt(cryptoJson.KDFParams["p"])
+	return n < ks.scryptN || n*r*p < ks.scryptN*scryptR*ks.scryptP
+}
+
+// EncryptKey encrypts a key using the specified scrypt parameters into a json
 // blob that can be decrypted later on.
-func encryptKey(key *Key, auth string, scryptN, scryptP int) ([]byte, error) {
+func EncryptKey(key *Key, auth string, scryptN, scryptP int) ([]byte, error) {
+	keyBytes := math.PaddedBigBytes(key.PrivateKey.D(), 32)
+	cryptoStruct, err := encryptData(keyBytes, auth, scryptN, scryptP)
+	if err != nil {
+		return nil, err
+	}
+
+	encryptedKeyJSON := encryptedKeyJSON{
+		hex.EncodeToString(key.Address[:]),
+		cryptoStruct,
+		key.Id.String(),
+		version,
+	}
+	return json.Marshal(encryptedKeyJSON)
+}
+
+// encryptMnemonic encrypts the mnemonic using the specified scrypt parameters
+// into a json blob
+func encryptMnemonic(mnemonic string, auth string, scryptN, scryptP int) ([]byte, error) {
+	cryptoStruct, err := encryptData([]byte(mnemonic), auth, scryptN, scryptP)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(encryptedMnemonicJSON{
+		Crypto:  cryptoStruct,
+		Version: version,
+	})
+}
+
+// DecryptKey decrypts a key from a json blob, returning the private key itself.
+func DecryptKey(keyjson []byte, auth string) (*Key, error) {
+	encryptedKeyJs := new(encryptedKeyJSON)
+	if err := json.Unmarshal(keyjson, encryptedKeyJs); err != nil {
+		return nil, err
+	}
+
+	if encryptedKeyJs.Version != version {
+		return nil, fmt.Errorf("Version %v not supported", encryptedKeyJs.Version)
+	}
+
+	keyId := uuid.Parse(encryptedKeyJs.Id)
+
+	keyBytes, err := decryptData(encryptedKeyJs.Crypto, auth)
+	if err != nil {
+		return nil, err
+	}
+
+	// Use the "unsafe" convertor to support legacy private keys
+	// whose lengths are less than 32 bytes
+	privKey := crypto.PrivateKeyFromBytesUnsafe(keyBytes)
+
+	key := &Key{
+		Id:         keyId,
+		Address:    privKey.PublicKey().Address(),
+		PrivateKey: privKey,
+	}
+
+	return key, nil
+}
+
+// encryptData encrypts the data with a key derived from the password using the
+// specified scrypt parameters
+func encryptData(data []byte, auth string, scryptN, scryptP int) (cryptoJSON, error) {
 	authArray := []byte(auth)
 
 	salt := make([]byte, 32)
@@ -181,18 +512,17 @@ func encryptKey(key *Key, auth string, scryptN, scryptP int) ([]byte, error) {
 	}
 	derivedKey, err := scrypt.Key(authArray, salt, scryptN, scryptR, scryptP, scryptDKLen)
 	if err != nil {
-		return nil, err
+		return cryptoJSON{}, err
 	}
 	encryptKey := derivedKey[:16]
-	keyBytes := math.PaddedBigBytes(key.PrivateKey.D(), 32)
 
 	iv := make([]byte, aes.BlockSize) // 16
 	if _, err := io.ReadFull(rand.Reader, iv); err != nil {
 		panic("reading from crypto/rand failed: " + err.Error())
 	}
-	cipherText, err := aesCTRXOR(encryptKey, keyBytes, iv)
+	cipherText, err := aesCTRXOR(encryptKey, data, iv)
 	if err != nil {
-		return nil, err
+		return cryptoJSON{}, err
 	}
 	mac := crypto.Keccak256(derivedKey[16:32], cipherText)
 
@@ -216,48 +546,32 @@ func encryptKey(key *Key, auth string, scryptN, scryptP int) ([]byte, error) {
 		MAC:          hex.EncodeToString(mac),
 	}
 
-	encryptedKeyJSON := encryptedKeyJSON{
-		hex.EncodeToString(key.Address[:]),
-		cryptoStruct,
-		key.Id.String(),
-		version,
-	}
-	return json.Marshal(encryptedKeyJSON)
+	return cryptoStruct, nil
 }
 
-// decryptKey decrypts a key from a json blob, returning the private key itself.
-func decryptKey(keyjson []byte, auth string) (*Key, error) {
-	encryptedKeyJs := new(encryptedKeyJSON)
-	if err := json.Unmarshal(keyjson, encryptedKeyJs); err != nil {
-		return nil, err
-	}
-
-	if encryptedKeyJs.Version != version {
-		return nil, fmt.Errorf("Version %v not supported", encryptedKeyJs.Version)
+// decryptData verifies the MAC and decrypts the cipher text with a key derived
+// from the password
+func decryptData(cryptoJson cryptoJSON, auth string) ([]byte, error) {
+	if cryptoJson.Cipher != "aes-128-ctr" {
+		return nil, fmt.Errorf("Cipher not supported: %v", cryptoJson.Cipher)
 	}
 
-	if encryptedKeyJs.Crypto.Cipher != "aes-128-ctr" {
-		return nil, fmt.Errorf("Cipher not supported: %v", encryptedKeyJs.Crypto.Cipher)
-	}
-
-	keyId := uuid.Parse(encryptedKeyJs.Id)
-
-	mac, err := hex.DecodeString(encryptedKeyJs.Crypto.MAC)
+	mac, err := hex.DecodeString(cryptoJson.MAC)
 	if err != nil {
 		return nil, err
 	}
 
-	iv, err := hex.DecodeString(encryptedKeyJs.Crypto.CipherParams.IV)
+	iv, err := hex.DecodeString(cryptoJson.CipherParams.IV)
 	if err != nil {
 		return nil, err
 	}
 
-	cipherText, err := hex.DecodeString(encryptedKeyJs.Crypto.CipherText)
+	cipherText, err := hex.DecodeString(cryptoJson.CipherText)
 	if err != nil {
 		return nil, err
 	}
 
-	derivedKey, err := getKDFKey(encryptedKeyJs.Crypto, auth)
+	derivedKey, err := getKDFKey(cryptoJson, auth)
 	if err != nil {
 		return nil, err
 	}
@@ -267,22 +581,12 @@ func decryptKey(keyjson []byte, auth string) (*Key, error) {
 		return nil, ErrDecrypt
 	}
 
-	keyBytes, err := aesCTRXOR(derivedKey[:16], cipherText, iv)
+	plainText, err := aesCTRXOR(derivedKey[:16], cipherText, iv)
 	if err != nil {
 		return nil, err
 	}
 
-	// Use the "unsafe" convertor to support legacy private keys
-	// whose lengths are less than 32 bytes
-	privKey := crypto.PrivateKeyFromBytesUnsafe(keyBytes)
-
-	key := &Key{
-		Id:         keyId,
-		Address:    privKey.PublicKey().Address(),
-		PrivateKey: privKey,
-	}
-
-	return key, nil
+	return plainText, nil
 }
 
 func getKDFKey(cryptoJSON cryptoJSON, auth string) ([]byte, error) {
@@ -342,6 +646,11 @@ type encryptedKeyJSON struct {
 	Version int        `json:"version"`
 }
 
+type encryptedMnemonicJSON struct {
+	Crypto  cryptoJSON `json:"crypto"`
+	Version int        `json:"version"`
+}
+
 type cryptoJSON struct {
 	Cipher       string                 `json:"cipher"`
 	CipherText   string                 `json:"ciphertext"`
@@ -18,7 +18,9 @@
 package keystore
 
 import (
+	"bytes"
 	"crypto/rand"
+	"encoding/json"
 	"io/ioutil"
 	"os"
 	"reflect"
@@ -98,11 +100,11 @@ func TestKeyEncryptDecrypt(t *testing.T) {
 	// Do a few rounds of decryption and encryption
 	for i := 0; i < 3; i++ {
 		// Try a bad password first
-		if _, err := decryptKey(keyjson, password+"bad"); err == nil {
+		if _, err := DecryptKey(keyjson, password+"bad"); err == nil {
 			t.Errorf("test %d: json key decrypted with bad password", i)
 		}
 		// Decrypt with the correct password
-		key, err := decryptKey(keyjson, password)
+		key, err := DecryptKey(keyjson, password)
 		if err != nil {
 			t.Fatalf("test %d: json key failed to decrypt: %v", i, err)
 		}
@@ -111,8 +113,271 @@ func TestKeyEncryptDecrypt(t *testing.T) {
 		}
 		// Recrypt with a new password and start over
 		password += "new data appended"
-		if keyjson, err = encryptKey(key, password, veryLightScryptN, veryLightScryptP); err != nil {
+		if keyjson, err = EncryptKey(key, password, veryLightScryptN, veryLightScryptP); err != nil {
 			t.Errorf("test %d: failed to recrypt key %v", i, err)
 		}
 	}
 }
+
+func TestKeyStoreEncryptedMnemonic(t *testing.T) {
+	dir, ks := tmpKeyStoreIface(t, true)
+	defer os.RemoveAll(dir)
+
+	if ks.HasMnemonic() {
+		t.Fatal("keystore should not have a mnemonic yet")
+	}
+	mnemonic := "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	if err := ks.StoreMnemonic(mnemonic, "foo"); err != nil {
+		t.Fatal(err)
+	}
+	if !ks.HasMnemonic() {
+		t.Fatal("keystore should have the mnemonic")
+	}
+	if _, err := ks.GetMnemonic("bar"); err != ErrDecrypt {
+		t.Fatalf("wrong error for invalid password\ngot %q\nwant %q", err, ErrDecrypt)
+	}
+	retrieved, err := ks.GetMnemonic("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if retrieved != mnemonic {
+		t.Fatalf("mnemonic mismatch: have %q, want %q", retrieved, mnemonic)
+	}
+}
+
+func TestValidateScryptParams(t *testing.T) {
+	if err := ValidateScryptParams(StandardScryptN, StandardScryptP); err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateScryptParams(LightScryptN, LightScryptP); err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateScryptParams(1000, 1); err == nil {
+		t.Fatal("N should be a power of 2")
+	}
+	if err := ValidateScryptParams(1, 1); err == nil {
+		t.Fatal("N should be greater than 1")
+	}
+	if err := ValidateScryptParams(StandardScryptN, 0); err == nil {
+		t.Fatal("P should be positive")
+	}
+	if _, err := NewKeystoreEncrypted(os.TempDir(), 3, 1); err == nil {
+		t.Fatal("keystore should not be created with invalid scrypt parameters")
+	}
+}
+
+func TestKeyStoreEncryptedMigratePlainKey(t *testing.T) {
+	dir, err := ioutil.TempDir("", "theta-keystore-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	plainKs, err := NewKeystorePlain(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k1, err := storeNewKeyTest(plainKs, rand.Reader, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mnemonic := "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	if err := plainKs.StoreMnemonic(mnemonic, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	ks, err := NewKeystoreEncrypted(dir, veryLightScryptN, veryLightScryptP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	addresses, err := ks.ListKeyAddresses()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual([]common.Address{k1.Address}, addresses) {
+		t.Fatalf("plaintext key should be listed, got %v", addresses)
+	}
+
+	// The plaintext key is encrypted with the password when first decrypted
+	k2, err := ks.GetKey(k1.Address, "foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(k1.PrivateKey, k2.PrivateKey) {
+		t.Fatal("migrated key mismatch")
+	}
+	if fileExist(ks.getPlainFilePath(k1.Address)) {
+		t.Fatal("plaintext key file should have been removed")
+	}
+	if _, err = ks.GetKey(k1.Address, "bar"); err != ErrDecrypt {
+		t.Fatalf("wrong error for invalid password\ngot %q\nwant %q", err, ErrDecrypt)
+	}
+	addresses, err = ks.ListKeyAddresses()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual([]common.Address{k1.Address}, addresses) {
+		t.Fatalf("migrated key should be listed once, got %v", addresses)
+	}
+
+	if !ks.HasMnemonic() {
+		t.Fatal("plaintext mnemonic should be found")
+	}
+	retrieved, err := ks.GetMnemonic("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if retrieved != mnemonic {
+		t.Fatalf("mnemonic mismatch: have %q, want %q", retrieved, mnemonic)
+	}
+	if fileExist(ks.plainMnemonicFilePath) {
+		t.Fatal("plaintext mnemonic file should have been removed")
+	}
+	if _, err := ks.GetMnemonic("bar"); err != ErrDecrypt {
+		t.Fatalf("wrong error for invalid password\ngot %q\nwant %q", err, ErrDecrypt)
+	}
+}
+
+func TestKeyStoreEncryptedUpgradeWeakKDF(t *testing.T) {
+	dir, weakKs := tmpKeyStoreIface(t, true)
+	defer os.RemoveAll(dir)
+
+	pass := "foo"
+	k1, err := storeNewKeyTest(weakKs, rand.Reader, pass)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ks, err := NewKeystoreEncrypted(dir, 2*veryLightScryptN, veryLightScryptP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyjson, err := ioutil.ReadFile(ks.getFilePath(k1.Address))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ks.isWeakKDF(loadCryptoJSON(t, keyjson)) {
+		t.Fatal("key should be weakly protected")
+	}
+
+	// The weakly protected key is encrypted again with the configured KDF parameters
+	k2, err := ks.GetKey(k1.Address, pass)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(k1.PrivateKey, k2.PrivateKey) {
+		t.Fatal("upgraded key mismatch")
+	}
+	keyjson, err = ioutil.ReadFile(ks.getFilePath(k1.Address))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cryptoJson := loadCryptoJSON(t, keyjson)
+	if ks.isWeakKDF(cryptoJson) || ensureInt(cryptoJson.KDFParams["n"]) != 2*veryLightScryptN {
+		t.Fatalf("key should have been upgraded, KDF params: %v", cryptoJson.KDFParams)
+	}
+	if _, err := DecryptKey(keyjson, pass); err != nil {
+		t.Fatal(err)
+	}
+
+	// The pbkdf2 protected keys are always regarded as weak
+	tests := loadKeyStoreTest("testdata/test_vector.json", t)
+	if !ks.isWeakKDF(tests["wikipage_test_vector_pbkdf2"].Json.Crypto) {
+		t.Fatal("pbkdf2 should be regarded as weak")
+	}
+}
+
+func TestKeyStoreEncryptedReencrypt(t *testing.T) {
+	dir, iface := tmpKeyStoreIface(t, true)
+	defer os.RemoveAll(dir)
+	ks := iface.(KeystoreEncrypted)
+
+	k1, err := storeNewKeyTest(ks, rand.Reader, "foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	k2, err := storeNewKeyTest(ks, rand.Reader, "foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mnemonic := "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	if err := ks.StoreMnemonic(mnemonic, "foo"); err != nil {
+		t.Fatal(err)
+	}
+
+	// A key under another password fails the re-encryption, leaving all the keys unchanged
+	k3, err := storeNewKeyTest(ks, rand.Reader, "baz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	before, err := ioutil.ReadFile(ks.getFilePath(k1.Address))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ks.Reencrypt("foo", "bar", 2*veryLightScryptN, veryLightScryptP); err == nil {
+		t.Fatal("re-encryption should fail with a key under another password")
+	}
+	after, err := ioutil.ReadFile(ks.getFilePath(k1.Address))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(before, after) {
+		t.Fatal("key file should be unchanged")
+	}
+	addresses, err := ks.ListKeyAddresses()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(addresses) != 3 {
+		t.Fatalf("temporary files should have been removed, got %v", addresses)
+	}
+
+	if err := ks.DeleteKey(k3.Address, "baz"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ks.Reencrypt("foo", "bar", 1000, veryLightScryptP); err == nil {
+		t.Fatal("invalid scrypt parameters should be rejected")
+	}
+	addresses, err = ks.Reencrypt("foo", "bar", 2*veryLightScryptN, veryLightScryptP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(addresses) != 2 {
+		t.Fatalf("two keys should be re-encrypted, got %v", addresses)
+	}
+
+	for _, k := range []*Key{k1, k2} {
+		keyjson, err := ioutil.ReadFile(ks.getFilePath(k.Address))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n := ensureInt(loadCryptoJSON(t, keyjson).KDFParams["n"]); n != 2*veryLightScryptN {
+			t.Fatalf("KDF should have been upgraded, have n = %v", n)
+		}
+		if _, err := ks.GetKey(k.Address, "foo"); err != ErrDecrypt {
+			t.Fatalf("wrong error for old password\ngot %q\nwant %q", err, ErrDecrypt)
+		}
+		retrieved, err := ks.GetKey(k.Address, "bar")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(k.PrivateKey, retrieved.PrivateKey) {
+			t.Fatal("re-encrypted key mismatch")
+		}
+	}
+	retrieved, err := ks.GetMnemonic("bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if retrieved != mnemonic {
+		t.Fatalf("mnemonic mismatch: have %q, want %q", retrieved, mnemonic)
+	}
+}
+
+func loadCryptoJSON(t *testing.T, keyjson []byte) cryptoJSON {
+	encryptedKeyJs := new(encryptedKeyJSON)
+	if err := json.Unmarshal(keyjson, encryptedKeyJs); err != nil {
+		t.Fatal(err)
+	}
+	return encryptedKeyJs.Crypto
+}
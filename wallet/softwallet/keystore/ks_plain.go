@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"os"
 	"path"
-	"path/filepath"
 	"runtime"
 
 	"github.com/pborman/uuid"
@@ -17,7 +16,8 @@ import (
 var _ Keystore = (*KeystorePlain)(nil)
 
 type KeystorePlain struct {
-	keysDirPath string
+	keysDirPath      string
+	mnemonicFilePath string
 }
 
 func NewKeystorePlain(keysDirRoot string) (KeystorePlain, error) {
@@ -36,26 +36,15 @@ func NewKeystorePlain(keysDirRoot string) (KeystorePlain, error) {
 	}
 
 	ks := KeystorePlain{
-		keysDirPath: keysDirPath,
+		keysDirPath:      keysDirPath,
+		mnemonicFilePath: path.Join(keysDirRoot, "hd", "plain"),
 	}
 
 	return ks, nil
 }
 
 func (ks KeystorePlain) ListKeyAddresses() ([]common.Address, error) {
-	filenames, err := filepath.Glob(path.Join(ks.keysDirPath, "*"))
-	if err != nil {
-		return []common.Address{}, err
-	}
-
-	addresses := []common.Address{}
-	for _, filename := range filenames {
-		addrStr := filepath.Base(filename)
-		address := common.HexToAddress(addrStr)
-		addresses = append(addresses, address)
-	}
-
-	return addresses, nil
+	return listKeyAddresses(ks.keysDirPath)
 }
 
 func (ks KeystorePlain) GetKey(address common.Address, auth string) (*Key, error) {
@@ -114,6 +103,34 @@ func (ks KeystorePlain) DeleteKey(address common.Address, auth string) error {
 	return err
 }
 
+func (ks KeystorePlain) HasMnemonic() bool {
+	return fileExist(ks.mnemonicFilePath)
+}
+
+func (ks KeystorePlain) GetMnemonic(auth string) (string, error) {
+	fd, err := os.Open(ks.mnemonicFilePath)
+	if err != nil {
+		return "", err
+	}
+	defer fd.Close()
+	plainMnemonicJs := new(plainMnemonicJSON)
+	if err := json.NewDecoder(fd).Decode(plainMnemonicJs); err != nil {
+		return "", err
+	}
+	return plainMnemonicJs.Mnemonic, nil
+}
+
+func (ks KeystorePlain) StoreMnemonic(mnemonic string, auth string) error {
+	content, err := json.Marshal(&plainMnemonicJSON{
+		Mnemonic: mnemonic,
+		Version:  version,
+	})
+	if err != nil {
+		return err
+	}
+	return writeKeyFile(ks.mnemonicFilePath, content)
+}
+
 func (ks KeystorePlain) getFilePath(address common.Address) string {
 	filePath := path.Join(ks.keysDirPath, address.Hex()[2:])
 	return filePath
@@ -125,3 +142,8 @@ type plainKeyJSON struct {
 	Id         string `json:"id"`
 	Version    int    `json:"version"`
 }
+
+type plainMnemonicJSON struct {
+	Mnemonic string `json:"mnemonic"`
+	Version  int    `json:"version"`
+}
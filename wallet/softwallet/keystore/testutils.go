@@ -106,7 +106,7 @@ func testDecrypt(test KeyStoreTest, t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	key, err := decryptKey(keyjson, test.Password)
+	key, err := DecryptKey(keyjson, test.Password)
 	if err != nil {
 		t.Fatal(err)
 	}
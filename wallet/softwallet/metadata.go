@@ -0,0 +1,162 @@
+package softwallet
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/thetatoken/ukulele/common"
+	ks "github.com/thetatoken/ukulele/wallet/softwallet/keystore"
+)
+
+var logger *log.Entry = log.WithFields(log.Fields{"prefix": "wallet"})
+
+// KeyMetadata records the life cycle of a key for audits. A time is nil if the
+// event has not happened, or happened before the metadata was recorded.
+type KeyMetadata struct {
+	Address  common.Address `json:"address"`
+	Created  *time.Time     `json:"created,omitempty"`   // When the key was created, imported or derived
+	LastUsed *time.Time     `json:"last_used,omitempty"` // When the key last signed
+	Rotated  *time.Time     `json:"rotated,omitempty"`   // When the key was last encrypted with a new password or KDF
+}
+
+// ListKeyMetadata returns the metadata of all the keys
+func (w *SoftWallet) ListKeyMetadata() ([]KeyMetadata, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	addresses, err := w.keystore.ListKeyAddresses()
+	if err != nil {
+		return nil, err
+	}
+	metadata, err := w.loadKeyMetadata()
+	if err != nil {
+		return nil, err
+	}
+	result := []KeyMetadata{}
+	for _, address := range addresses {
+		if meta, ok := metadata[address]; ok {
+			result = append(result, *meta)
+		} else {
+			result = append(result, KeyMetadata{Address: address})
+		}
+	}
+	return result, nil
+}
+
+// Rotate encrypts all the keys and the HD seed with the new password and scrypt
+// parameters, e.g. to rotate the password or upgrade the KDF. Nothing is changed
+// if any of them fails to decrypt with the current password. It returns the
+// addresses of the keys rotated.
+func (w *SoftWallet) Rotate(password, newPassword string, scryptN, scryptP int) ([]common.Address, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	keystore, ok := w.keystore.(ks.KeystoreEncrypted)
+	if !ok {
+		return nil, fmt.Errorf("Only the keys of the encrypted keystore can be rotated")
+	}
+	addresses, err := keystore.Reencrypt(password, newPassword, scryptN, scryptP)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	err = w.updateKeyMetadata(addresses, func(meta *KeyMetadata) {
+		meta.Rotated = &now
+	})
+	return addresses, err
+}
+
+// recordKeyCreated records the creation time of a new key
+func (w *SoftWallet) recordKeyCreated(address common.Address) error {
+	now := time.Now().UTC()
+	return w.updateKeyMetadata([]common.Address{address}, func(meta *KeyMetadata) {
+		meta.Created = &now
+	})
+}
+
+// recordKeyUsed records the last time the key signed. Failures are only logged,
+// so that they do not fail the signing.
+func (w *SoftWallet) recordKeyUsed(address common.Address) {
+	now := time.Now().UTC()
+	err := w.updateKeyMetadata([]common.Address{address}, func(meta *KeyMetadata) {
+		meta.LastUsed = &now
+	})
+	if err != nil {
+		logger.Warnf("Failed to record the usage of key %v: %v", address.Hex(), err)
+	}
+}
+
+func (w *SoftWallet) updateKeyMetadata(addresses []common.Address, update func(meta *KeyMetadata)) error {
+	metadata, err := w.loadKeyMetadata()
+	if err != nil {
+		return err
+	}
+	for _, address := range addresses {
+		meta, ok := metadata[address]
+		if !ok {
+			meta = &KeyMetadata{Address: address}
+			metadata[address] = meta
+		}
+		update(meta)
+	}
+	return w.saveKeyMetadata(metadata)
+}
+
+func (w *SoftWallet) removeKeyMetadata(address common.Address) error {
+	metadata, err := w.loadKeyMetadata()
+	if err != nil {
+		return err
+	}
+	if _, ok := metadata[address]; !ok {
+		return nil
+	}
+	delete(metadata, address)
+	return w.saveKeyMetadata(metadata)
+}
+
+func (w *SoftWallet) loadKeyMetadata() (map[common.Address]*KeyMetadata, error) {
+	metadata := make(map[common.Address]*KeyMetadata)
+	content, err := ioutil.ReadFile(w.keyMetadataFile)
+	if os.IsNotExist(err) {
+		return metadata, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []*KeyMetadata{}
+	if err := json.Unmarshal(content, &entries); err != nil {
+		return nil, err
+	}
+	for _, meta := range entries {
+		metadata[meta.Address] = meta
+	}
+	return metadata, nil
+}
+
+func (w *SoftWallet) saveKeyMetadata(metadata map[common.Address]*KeyMetadata) error {
+	entries := []*KeyMetadata{}
+	for _, meta := range metadata {
+		entries = append(entries, meta)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return bytes.Compare(entries[i].Address[:], entries[j].Address[:]) < 0
+	})
+	content, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(w.keyMetadataFile), 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(w.keyMetadataFile, content, 0600)
+}
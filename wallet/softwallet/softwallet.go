@@ -1,8 +1,14 @@
 package softwallet
 
 import (
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
 	"sync"
+	"time"
 
 	"github.com/thetatoken/ukulele/common"
 	"github.com/thetatoken/ukulele/crypto"
@@ -24,21 +30,43 @@ const (
 //
 
 type SoftWallet struct {
-	mu             *sync.RWMutex
-	keystore       ks.Keystore
-	unlockedKeyMap map[common.Address]*UnlockedKey // Currently unlocked keys (decrypted private keys)
+	mu              *sync.RWMutex
+	keystore        ks.Keystore
+	unlockedKeyMap  map[common.Address]*UnlockedKey // Currently unlocked keys (decrypted private keys)
+	autoLockTimeout time.Duration                   // Unlocked keys are locked after being unused for the timeout, zero to disable
+	derivedAccFile  string                          // Records the derivation paths of the keys derived from the HD seed
+	watchOnlyFile   string                          // Records the watch-only addresses
+	keyMetadataFile string                          // Records the creation, usage and rotation times of the keys
+}
+
+// DerivedAccount represents a key derived from the HD seed
+type DerivedAccount struct {
+	Address common.Address
+	Path    types.DerivationPath
+}
+
+type derivedAccountJSON struct {
+	Address common.Address `json:"address"`
+	Path    string         `json:"path"`
 }
 
 type UnlockedKey struct {
 	*ks.Key
-	abort chan struct{}
+	autoLock        *time.Timer   // Locks the key on inactivity, nil if auto-lock is disabled
+	autoLockTimeout time.Duration // Inactivity timeout of the auto-lock
 }
 
 func NewSoftWallet(keysDirPath string, kstype KeystoreType) (*SoftWallet, error) {
+	return NewSoftWalletWithKDFParams(keysDirPath, kstype, ks.StandardScryptN, ks.StandardScryptP)
+}
+
+// NewSoftWalletWithKDFParams creates a soft wallet whose encrypted keystore encrypts
+// the keys with the given scrypt parameters
+func NewSoftWalletWithKDFParams(keysDirPath string, kstype KeystoreType, scryptN, scryptP int) (*SoftWallet, error) {
 	var keystore ks.Keystore
 	var err error
 	if kstype == KeystoreTypeEncrypted {
-		keystore, err = ks.NewKeystoreEncrypted(keysDirPath, ks.StandardScryptN, ks.StandardScryptP)
+		keystore, err = ks.NewKeystoreEncrypted(keysDirPath, scryptN, scryptP)
 	} else {
 		keystore, err = ks.NewKeystorePlain(keysDirPath)
 	}
@@ -47,9 +75,12 @@ func NewSoftWallet(keysDirPath string, kstype KeystoreType) (*SoftWallet, error)
 	}
 
 	wallet := &SoftWallet{
-		mu:             &sync.RWMutex{},
-		keystore:       keystore,
-		unlockedKeyMap: make(map[common.Address]*UnlockedKey),
+		mu:              &sync.RWMutex{},
+		keystore:        keystore,
+		unlockedKeyMap:  make(map[common.Address]*UnlockedKey),
+		derivedAccFile:  path.Join(keysDirPath, "hd", "accounts"),
+		watchOnlyFile:   path.Join(keysDirPath, "watch", "addresses"),
+		keyMetadataFile: path.Join(keysDirPath, "meta", "keys"),
 	}
 
 	return wallet, nil
@@ -74,30 +105,89 @@ func (w *SoftWallet) List() ([]common.Address, error) {
 	return addresses, err
 }
 
-// NewKey creates a new key
+// NewKey creates a new key. If the wallet has an HD seed, the key is derived
+// from the seed at the next unused index of the Theta derivation path, and the
+// password needs to match the password of the seed.
 func (w *SoftWallet) NewKey(password string) (common.Address, error) {
 	w.mu.Lock()
 	defer w.mu.Unlock()
 
-	privKey, _, err := crypto.GenerateKeyPair()
-	if err != nil {
-		return common.Address{}, err
+	var key *ks.Key
+	if w.keystore.HasMnemonic() {
+		path, err := w.nextDerivationPath()
+		if err != nil {
+			return common.Address{}, err
+		}
+		key, err = w.deriveAndStoreKey(path, password)
+		if err != nil {
+			return common.Address{}, err
+		}
+	} else {
+		privKey, _, err := crypto.GenerateKeyPair()
+		if err != nil {
+			return common.Address{}, err
+		}
+		key = ks.NewKey(privKey)
+		w.keystore.StoreKey(key, password)
+		if err := w.recordKeyCreated(key.Address); err != nil {
+			return common.Address{}, err
+		}
+	}
+	address := key.Address
+
+	// newly created key is considerred unlocked
+	w.addUnlockedKey(key)
+
+	return address, nil
+}
+
+// ImportKey stores an externally generated private key under the given password
+func (w *SoftWallet) ImportKey(privKey *crypto.PrivateKey, password string) (common.Address, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if privKey == nil {
+		return common.Address{}, fmt.Errorf("Private key is nil")
 	}
 
 	key := ks.NewKey(privKey)
 	address := key.Address
 
-	w.keystore.StoreKey(key, password)
+	addresses, err := w.keystore.ListKeyAddresses()
+	if err != nil {
+		return common.Address{}, err
+	}
+	for _, addr := range addresses {
+		if addr == address {
+			return common.Address{}, fmt.Errorf("Key for address %v already exists", address.Hex())
+		}
+	}
 
-	// newly created key is considerred unlocked
-	unlockedKey := &UnlockedKey{
-		Key: key,
+	err = w.keystore.StoreKey(key, password)
+	if err != nil {
+		return common.Address{}, err
+	}
+	err = w.recordKeyCreated(address)
+	if err != nil {
+		return common.Address{}, err
 	}
-	w.unlockedKeyMap[address] = unlockedKey
 
 	return address, nil
 }
 
+// ExportKey returns the decrypted private key of the address if the password is correct
+func (w *SoftWallet) ExportKey(address common.Address, password string) (*crypto.PrivateKey, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	key, err := w.keystore.GetKey(address, password)
+	if err != nil {
+		return nil, err
+	}
+
+	return key.PrivateKey, nil
+}
+
 // Unlock unlocks a key if the password is correct
 func (w *SoftWallet) Unlock(address common.Address, password string) error {
 	w.mu.Lock()
@@ -105,13 +195,13 @@ func (w *SoftWallet) Unlock(address common.Address, password string) error {
 
 	key, err := w.keystore.GetKey(address, password)
 	if err != nil {
+		if w.isWatchOnly(address) {
+			return ErrWatchOnly
+		}
 		return err
 	}
 
-	unlockedKey := &UnlockedKey{
-		Key: key,
-	}
-	w.unlockedKeyMap[address] = unlockedKey
+	w.addUnlockedKey(key)
 
 	return nil
 }
@@ -135,6 +225,37 @@ func (w *SoftWallet) Lock(address common.Address) error {
 	return nil
 }
 
+// LockAll locks all the unlocked keys
+func (w *SoftWallet) LockAll() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for address, unlockedKey := range w.unlockedKeyMap {
+		delete(w.unlockedKeyMap, address)
+		w.zeroKey(unlockedKey)
+	}
+
+	return nil
+}
+
+// IsUnlocked returns whether the key of the address is unlocked
+func (w *SoftWallet) IsUnlocked(address common.Address) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	_, found := w.unlockedKeyMap[address]
+	return found
+}
+
+// SetAutoLockTimeout sets the inactivity timeout after which the keys unlocked
+// afterwards are locked automatically. Zero disables the auto-lock.
+func (w *SoftWallet) SetAutoLockTimeout(timeout time.Duration) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.autoLockTimeout = timeout
+}
+
 // Delete deletes a key from disk permanently
 func (w *SoftWallet) Delete(address common.Address, password string) error {
 	w.mu.Lock()
@@ -149,7 +270,10 @@ func (w *SoftWallet) Delete(address common.Address, password string) error {
 	}
 
 	err := w.keystore.DeleteKey(address, password)
-	return err
+	if err != nil {
+		return err
+	}
+	return w.removeKeyMetadata(address)
 }
 
 // UpdatePassword updates the password for a key
@@ -163,7 +287,13 @@ func (w *SoftWallet) UpdatePassword(address common.Address, oldPassword, newPass
 	}
 
 	err = w.keystore.StoreKey(key, newPassword)
-	return err
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	return w.updateKeyMetadata([]common.Address{address}, func(meta *KeyMetadata) {
+		meta.Rotated = &now
+	})
 }
 
 // Derive is not supported for SoftWallet
@@ -171,6 +301,79 @@ func (w *SoftWallet) Derive(path types.DerivationPath, pin bool) (common.Address
 	return common.Address{}, fmt.Errorf("Not supported for software wallet")
 }
 
+// HasSeed returns whether the wallet has an HD seed
+func (w *SoftWallet) HasSeed() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.keystore.HasMnemonic()
+}
+
+// NewSeed generates a 24-word mnemonic as the HD seed of the wallet and stores
+// it under the password. The mnemonic is returned so it can be backed up.
+func (w *SoftWallet) NewSeed(password string) (string, error) {
+	mnemonic, err := NewMnemonic()
+	if err != nil {
+		return "", err
+	}
+	err = w.RestoreSeed(mnemonic, password)
+	if err != nil {
+		return "", err
+	}
+	return mnemonic, nil
+}
+
+// RestoreSeed restores the HD seed of the wallet from the mnemonic and stores it
+// under the password. The keys need to be derived again after the restoration.
+func (w *SoftWallet) RestoreSeed(mnemonic string, password string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.keystore.HasMnemonic() {
+		return fmt.Errorf("HD seed already exists")
+	}
+	if err := ValidateMnemonic(mnemonic); err != nil {
+		return err
+	}
+
+	return w.keystore.StoreMnemonic(NormalizeMnemonic(mnemonic), password)
+}
+
+// ExportSeed returns the mnemonic of the HD seed if the password is correct
+func (w *SoftWallet) ExportSeed(password string) (string, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.keystore.HasMnemonic() {
+		return "", fmt.Errorf("HD seed not found")
+	}
+	return w.keystore.GetMnemonic(password)
+}
+
+// DeriveKey derives the key at the path from the HD seed, and stores it under
+// the password of the seed
+func (w *SoftWallet) DeriveKey(path types.DerivationPath, password string) (common.Address, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.keystore.HasMnemonic() {
+		return common.Address{}, fmt.Errorf("HD seed not found")
+	}
+	key, err := w.deriveAndStoreKey(path, password)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return key.Address, nil
+}
+
+// ListDerived returns the accounts derived from the HD seed
+func (w *SoftWallet) ListDerived() ([]DerivedAccount, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.loadDerivedAccounts()
+}
+
 // GetPublicKey returns the public key of the address if the address has been unlocked
 func (w *SoftWallet) GetPublicKey(address common.Address) (*crypto.PublicKey, error) {
 	w.mu.Lock()
@@ -181,6 +384,8 @@ func (w *SoftWallet) GetPublicKey(address common.Address) (*crypto.PublicKey, er
 		return nil, fmt.Errorf("Key not unlocked yet for address: %v", address)
 	}
 
+	unlockedKey.touch()
+
 	pubKey := unlockedKey.PrivateKey.PublicKey()
 	return pubKey, nil
 }
@@ -192,18 +397,204 @@ func (w *SoftWallet) Sign(address common.Address, txrlp common.Bytes) (*crypto.S
 
 	unlockedKey, found := w.unlockedKeyMap[address]
 	if !found {
+		if w.isWatchOnly(address) {
+			return nil, ErrWatchOnly
+		}
 		return nil, fmt.Errorf("Key not unlocked yet for address: %v", address)
 	}
 
+	unlockedKey.touch()
+
 	signature, err := unlockedKey.Sign(txrlp)
-	return signature, err
+	if err != nil {
+		return nil, err
+	}
+	w.recordKeyUsed(address)
+	return signature, nil
 }
 
-// zeroKey zeroes a private key in memory
+// deriveAndStoreKey derives the key at the path and records its derivation path.
+// The derived key is stored under the password unless it already exists.
+func (w *SoftWallet) deriveAndStoreKey(path types.DerivationPath, password string) (*ks.Key, error) {
+	mnemonic, err := w.keystore.GetMnemonic(password)
+	if err != nil {
+		return nil, err
+	}
+	privKey, err := DeriveKeyFromMnemonic(mnemonic, path)
+	if err != nil {
+		return nil, err
+	}
+	key := ks.NewKey(privKey)
+
+	addresses, err := w.keystore.ListKeyAddresses()
+	if err != nil {
+		return nil, err
+	}
+	exists := false
+	for _, addr := range addresses {
+		if addr == key.Address {
+			exists = true
+			break
+		}
+	}
+	if !exists {
+		err = w.keystore.StoreKey(key, password)
+		if err != nil {
+			return nil, err
+		}
+		err = w.recordKeyCreated(key.Address)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	accounts, err := w.loadDerivedAccounts()
+	if err != nil {
+		return nil, err
+	}
+	for _, account := range accounts {
+		if account.Address == key.Address {
+			return key, nil
+		}
+	}
+	accounts = append(accounts, DerivedAccount{Address: key.Address, Path: path})
+	err = w.saveDerivedAccounts(accounts)
+	if err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// nextDerivationPath returns the Theta derivation path following the highest
+// index derived so far
+func (w *SoftWallet) nextDerivationPath() (types.DerivationPath, error) {
+	accounts, err := w.loadDerivedAccounts()
+	if err != nil {
+		return nil, err
+	}
+	next := uint32(0)
+	for _, account := range accounts {
+		base := types.ThetaDerivationPath(0)
+		if len(account.Path) != len(base) {
+			continue
+		}
+		index := account.Path[len(base)-1]
+		if account.Path.String() == types.ThetaDerivationPath(index).String() && index >= next {
+			next = index + 1
+		}
+	}
+	return types.ThetaDerivationPath(next), nil
+}
+
+func (w *SoftWallet) loadDerivedAccounts() ([]DerivedAccount, error) {
+	accounts := []DerivedAccount{}
+	content, err := ioutil.ReadFile(w.derivedAccFile)
+	if os.IsNotExist(err) {
+		return accounts, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	accountJSONs := []derivedAccountJSON{}
+	if err := json.Unmarshal(content, &accountJSONs); err != nil {
+		return nil, err
+	}
+
+	// Skip the accounts whose keys have been deleted
+	addresses, err := w.keystore.ListKeyAddresses()
+	if err != nil {
+		return nil, err
+	}
+	stored := make(map[common.Address]bool)
+	for _, addr := range addresses {
+		stored[addr] = true
+	}
+	for _, accountJSON := range accountJSONs {
+		if !stored[accountJSON.Address] {
+			continue
+		}
+		path, err := types.ParseDerivationPath(accountJSON.Path)
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, DerivedAccount{Address: accountJSON.Address, Path: path})
+	}
+	return accounts, nil
+}
+
+func (w *SoftWallet) saveDerivedAccounts(accounts []DerivedAccount) error {
+	accountJSONs := []derivedAccountJSON{}
+	for _, account := range accounts {
+		accountJSONs = append(accountJSONs, derivedAccountJSON{
+			Address: account.Address,
+			Path:    account.Path.String(),
+		})
+	}
+	content, err := json.MarshalIndent(accountJSONs, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(w.derivedAccFile), 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(w.derivedAccFile, content, 0600)
+}
+
+// addUnlockedKey adds the decrypted key to the unlocked keys, replacing the
+// previously unlocked one of the same address
+func (w *SoftWallet) addUnlockedKey(key *ks.Key) {
+	address := key.Address
+	if previous, exists := w.unlockedKeyMap[address]; exists {
+		if previous.autoLock != nil {
+			previous.autoLock.Stop()
+		}
+		if previous.Key != key {
+			w.zeroKey(previous)
+		}
+	}
+
+	unlockedKey := &UnlockedKey{
+		Key:             key,
+		autoLockTimeout: w.autoLockTimeout,
+	}
+	if w.autoLockTimeout > 0 {
+		unlockedKey.autoLock = time.AfterFunc(w.autoLockTimeout, func() {
+			w.expire(address, unlockedKey)
+		})
+	}
+	w.unlockedKeyMap[address] = unlockedKey
+}
+
+// expire locks the unlocked key when the auto-lock timer fires, unless it has
+// been locked or unlocked again in the meantime
+func (w *SoftWallet) expire(address common.Address, unlockedKey *UnlockedKey) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.unlockedKeyMap[address] != unlockedKey {
+		return
+	}
+	delete(w.unlockedKeyMap, address)
+	w.zeroKey(unlockedKey)
+}
+
+// touch postpones the auto-lock of the key since it is in use
+func (unlockedKey *UnlockedKey) touch() {
+	if unlockedKey.autoLock != nil {
+		unlockedKey.autoLock.Reset(unlockedKey.autoLockTimeout)
+	}
+}
+
+// zeroKey zeroes a private key in memory, and stops its auto-lock timer
 func (w *SoftWallet) zeroKey(unlockedKey *UnlockedKey) {
 	if unlockedKey == nil {
 		return
 	}
+	if unlockedKey.autoLock != nil {
+		unlockedKey.autoLock.Stop()
+	}
 
 	privKey := unlockedKey.PrivateKey
 	if privKey == nil || privKey.D() == nil {
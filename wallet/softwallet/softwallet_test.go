@@ -4,10 +4,15 @@ import (
 	"io/ioutil"
 	"os"
 	"sort"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/crypto"
+	ks "github.com/thetatoken/ukulele/wallet/softwallet/keystore"
+	"github.com/thetatoken/ukulele/wallet/types"
 )
 
 func TestPlainSoftWalletBasics(t *testing.T) {
@@ -26,8 +31,225 @@ func TestEncryptedSoftWalletMultipleKeys(t *testing.T) {
 	testSoftWalletMultipleKeys(t, KeystoreTypeEncrypted)
 }
 
+func TestPlainSoftWalletImportExport(t *testing.T) {
+	testSoftWalletImportExport(t, KeystoreTypePlain)
+}
+
+func TestEncryptedSoftWalletImportExport(t *testing.T) {
+	testSoftWalletImportExport(t, KeystoreTypeEncrypted)
+}
+
+func TestPlainSoftWalletHDSeed(t *testing.T) {
+	testSoftWalletHDSeed(t, KeystoreTypePlain)
+}
+
+func TestEncryptedSoftWalletHDSeed(t *testing.T) {
+	testSoftWalletHDSeed(t, KeystoreTypeEncrypted)
+}
+
+func TestSoftWalletUnlockSession(t *testing.T) {
+	assert := assert.New(t)
+
+	tmpdir := createTempDir()
+	defer os.RemoveAll(tmpdir)
+
+	wallet, err := NewSoftWallet(tmpdir, KeystoreTypePlain)
+	assert.Nil(err)
+
+	password := "password1"
+	addrs := []common.Address{}
+	for i := 0; i < 3; i++ {
+		addr, err := wallet.NewKey(password)
+		assert.Nil(err)
+		addrs = append(addrs, addr)
+	}
+	err = wallet.LockAll()
+	assert.Nil(err)
+
+	// Several addresses can be unlocked at the same time
+	for _, addr := range addrs {
+		assert.False(wallet.IsUnlocked(addr))
+		err = wallet.Unlock(addr, password)
+		assert.Nil(err)
+	}
+	for _, addr := range addrs {
+		assert.True(wallet.IsUnlocked(addr))
+		signature, err := wallet.Sign(addr, common.Bytes("hello world"))
+		assert.Nil(err)
+		assert.True(signature.Verify(common.Bytes("hello world"), addr))
+	}
+
+	err = wallet.Lock(addrs[0])
+	assert.Nil(err)
+	assert.False(wallet.IsUnlocked(addrs[0]))
+	assert.True(wallet.IsUnlocked(addrs[1]))
+
+	err = wallet.LockAll()
+	assert.Nil(err)
+	for _, addr := range addrs {
+		assert.False(wallet.IsUnlocked(addr))
+		_, err = wallet.Sign(addr, common.Bytes("hello world"))
+		assert.NotNil(err)
+	}
+}
+
+func TestSoftWalletAutoLock(t *testing.T) {
+	assert := assert.New(t)
+
+	tmpdir := createTempDir()
+	defer os.RemoveAll(tmpdir)
+
+	wallet, err := NewSoftWallet(tmpdir, KeystoreTypePlain)
+	assert.Nil(err)
+
+	password := "password1"
+	addr1, err := wallet.NewKey(password)
+	assert.Nil(err)
+	addr2, err := wallet.NewKey(password)
+	assert.Nil(err)
+	assert.True(wallet.IsUnlocked(addr1)) // auto-lock disabled by default
+
+	timeout := 200 * time.Millisecond
+	wallet.SetAutoLockTimeout(timeout)
+	err = wallet.Unlock(addr1, password)
+	assert.Nil(err)
+	err = wallet.Unlock(addr2, password)
+	assert.Nil(err)
+
+	// Signing with addr1 postpones its auto-lock
+	for i := 0; i < 4; i++ {
+		time.Sleep(timeout / 2)
+		_, err = wallet.Sign(addr1, common.Bytes("hello world"))
+		assert.Nil(err)
+	}
+	assert.True(wallet.IsUnlocked(addr1))
+	assert.False(wallet.IsUnlocked(addr2))
+	_, err = wallet.Sign(addr2, common.Bytes("hello world"))
+	assert.NotNil(err)
+
+	time.Sleep(2 * timeout)
+	assert.False(wallet.IsUnlocked(addr1))
+
+	// Locking an unlocked key stops its auto-lock
+	err = wallet.Unlock(addr1, password)
+	assert.Nil(err)
+	err = wallet.Lock(addr1)
+	assert.Nil(err)
+	err = wallet.Unlock(addr1, password)
+	assert.Nil(err)
+	assert.True(wallet.IsUnlocked(addr1))
+	time.Sleep(2 * timeout)
+	assert.False(wallet.IsUnlocked(addr1))
+}
+
 // ---------------- Test Utilities ---------------- //
 
+func TestSoftWalletWatchOnly(t *testing.T) {
+	assert := assert.New(t)
+
+	tmpdir := createTempDir()
+	defer os.RemoveAll(tmpdir)
+
+	wallet, err := NewSoftWallet(tmpdir, KeystoreTypePlain)
+	assert.Nil(err)
+
+	password := "password1"
+	keyAddr, err := wallet.NewKey(password)
+	assert.Nil(err)
+	privKey, _, err := crypto.GenerateKeyPair()
+	assert.Nil(err)
+	watchAddr := privKey.PublicKey().Address()
+
+	assert.NotNil(wallet.AddWatchOnly(keyAddr, "hot")) // the key is in the wallet
+	assert.Nil(wallet.AddWatchOnly(watchAddr, "cold storage"))
+	assert.NotNil(wallet.AddWatchOnly(watchAddr, "cold storage"))
+	assert.True(wallet.IsWatchOnly(watchAddr))
+	assert.False(wallet.IsWatchOnly(keyAddr))
+
+	accounts, err := wallet.ListWatchOnly()
+	assert.Nil(err)
+	assert.Equal([]WatchOnlyAccount{{Address: watchAddr, Label: "cold storage"}}, accounts)
+
+	// Watch-only addresses are not listed as keys, and cannot sign
+	addresses, err := wallet.List()
+	assert.Nil(err)
+	assert.Equal([]common.Address{keyAddr}, addresses)
+	assert.Equal(ErrWatchOnly, wallet.Unlock(watchAddr, password))
+	_, err = wallet.Sign(watchAddr, common.Bytes("hello world"))
+	assert.Equal(ErrWatchOnly, err)
+
+	// Importing the key supersedes the watch-only address
+	_, err = wallet.ImportKey(privKey, password)
+	assert.Nil(err)
+	assert.False(wallet.IsWatchOnly(watchAddr))
+	assert.Nil(wallet.Unlock(watchAddr, password))
+
+	assert.NotNil(wallet.RemoveWatchOnly(keyAddr))
+	accounts, err = wallet.ListWatchOnly()
+	assert.Nil(err)
+	assert.Equal(0, len(accounts))
+}
+
+func TestSoftWalletRotate(t *testing.T) {
+	assert := assert.New(t)
+
+	tmpdir := createTempDir()
+	defer os.RemoveAll(tmpdir)
+
+	wallet, err := NewSoftWalletWithKDFParams(tmpdir, KeystoreTypeEncrypted, ks.LightScryptN, ks.LightScryptP)
+	assert.Nil(err)
+
+	password := "password1"
+	addr1, err := wallet.NewKey(password)
+	assert.Nil(err)
+	addr2, err := wallet.NewKey(password)
+	assert.Nil(err)
+	_, err = wallet.Sign(addr1, common.Bytes("hello world"))
+	assert.Nil(err)
+
+	metadata, err := wallet.ListKeyMetadata()
+	assert.Nil(err)
+	assert.Equal(2, len(metadata))
+	for _, meta := range metadata {
+		assert.NotNil(meta.Created)
+		assert.Nil(meta.Rotated)
+		assert.Equal(meta.Address == addr1, meta.LastUsed != nil)
+	}
+
+	// A wrong password leaves the keys unchanged
+	_, err = wallet.Rotate("wrong password", "password2", ks.LightScryptN, ks.LightScryptP)
+	assert.NotNil(err)
+	assert.Nil(wallet.Unlock(addr2, password))
+
+	addresses, err := wallet.Rotate(password, "password2", 2*ks.LightScryptN, ks.LightScryptP)
+	assert.Nil(err)
+	assert.Equal(sortAddresses([]common.Address{addr1, addr2}), sortAddresses(addresses))
+	assert.NotNil(wallet.Unlock(addr1, password))
+	assert.Nil(wallet.Unlock(addr1, "password2"))
+	assert.Nil(wallet.Unlock(addr2, "password2"))
+
+	metadata, err = wallet.ListKeyMetadata()
+	assert.Nil(err)
+	for _, meta := range metadata {
+		assert.NotNil(meta.Rotated)
+	}
+
+	// Deleted keys are removed from the metadata
+	assert.Nil(wallet.Delete(addr2, "password2"))
+	metadata, err = wallet.ListKeyMetadata()
+	assert.Nil(err)
+	assert.Equal(1, len(metadata))
+	assert.Equal(addr1, metadata[0].Address)
+
+	// The plaintext keystore cannot be rotated
+	tmpdir2 := createTempDir()
+	defer os.RemoveAll(tmpdir2)
+	plainWallet, err := NewSoftWallet(tmpdir2, KeystoreTypePlain)
+	assert.Nil(err)
+	_, err = plainWallet.Rotate("", "password2", ks.LightScryptN, ks.LightScryptP)
+	assert.NotNil(err)
+}
+
 func testSoftWalletBasics(t *testing.T, ksType KeystoreType) {
 	assert := assert.New(t)
 
@@ -182,6 +404,125 @@ func testSoftWalletMultipleKeys(t *testing.T, ksType KeystoreType) {
 	assert.Equal(sortAddresses([]common.Address{addr1, addr4}), sortAddresses(addrs))
 }
 
+func testSoftWalletImportExport(t *testing.T, ksType KeystoreType) {
+	assert := assert.New(t)
+
+	tmpdir := createTempDir()
+	defer os.RemoveAll(tmpdir)
+
+	wallet, err := NewSoftWallet(tmpdir, ksType)
+	assert.Nil(err)
+
+	privKey, _, err := crypto.GenerateKeyPair()
+	assert.Nil(err)
+
+	password := "password1"
+	addr, err := wallet.ImportKey(privKey, password)
+	assert.Nil(err)
+	assert.Equal(privKey.PublicKey().Address(), addr)
+	addrs, err := wallet.List()
+	assert.Nil(err)
+	assert.Equal([]common.Address{addr}, addrs)
+
+	_, err = wallet.ImportKey(privKey, password) // duplicated import
+	assert.NotNil(err)
+
+	if ksType == KeystoreTypeEncrypted {
+		_, err = wallet.ExportKey(addr, "wrong password")
+		assert.NotNil(err)
+	}
+
+	exportedKey, err := wallet.ExportKey(addr, password)
+	assert.Nil(err)
+	assert.Equal(privKey.ToBytes(), exportedKey.ToBytes())
+
+	err = wallet.Unlock(addr, password)
+	assert.Nil(err)
+	signature, err := wallet.Sign(addr, common.Bytes("hello world"))
+	assert.Nil(err)
+	assert.True(signature.Verify(common.Bytes("hello world"), addr))
+}
+
+func testSoftWalletHDSeed(t *testing.T, ksType KeystoreType) {
+	assert := assert.New(t)
+
+	tmpdir := createTempDir()
+	defer os.RemoveAll(tmpdir)
+
+	wallet, err := NewSoftWallet(tmpdir, ksType)
+	assert.Nil(err)
+	assert.False(wallet.HasSeed())
+
+	password := "password1"
+	_, err = wallet.DeriveKey(types.ThetaDerivationPath(0), password) // no seed yet
+	assert.NotNil(err)
+
+	mnemonic, err := wallet.NewSeed(password)
+	assert.Nil(err)
+	assert.True(wallet.HasSeed())
+	_, err = wallet.NewSeed(password) // seed already exists
+	assert.NotNil(err)
+
+	// New keys are derived from the seed at incremental indices
+	addr0, err := wallet.NewKey(password)
+	assert.Nil(err)
+	addr1, err := wallet.NewKey(password)
+	assert.Nil(err)
+	privKey0, err := DeriveKeyFromMnemonic(mnemonic, types.ThetaDerivationPath(0))
+	assert.Nil(err)
+	privKey1, err := DeriveKeyFromMnemonic(mnemonic, types.ThetaDerivationPath(1))
+	assert.Nil(err)
+	assert.Equal(privKey0.PublicKey().Address(), addr0)
+	assert.Equal(privKey1.PublicKey().Address(), addr1)
+
+	path5 := types.ThetaDerivationPath(5)
+	addr5, err := wallet.DeriveKey(path5, password)
+	assert.Nil(err)
+	accounts, err := wallet.ListDerived()
+	assert.Nil(err)
+	assert.Equal([]DerivedAccount{
+		{Address: addr0, Path: types.ThetaDerivationPath(0)},
+		{Address: addr1, Path: types.ThetaDerivationPath(1)},
+		{Address: addr5, Path: path5},
+	}, accounts)
+	addr6, err := wallet.NewKey(password)
+	assert.Nil(err)
+	accounts, err = wallet.ListDerived()
+	assert.Nil(err)
+	assert.Equal(DerivedAccount{Address: addr6, Path: types.ThetaDerivationPath(6)}, accounts[3])
+
+	err = wallet.Unlock(addr5, password)
+	assert.Nil(err)
+	signature, err := wallet.Sign(addr5, common.Bytes("hello world"))
+	assert.Nil(err)
+	assert.True(signature.Verify(common.Bytes("hello world"), addr5))
+
+	if ksType == KeystoreTypeEncrypted {
+		_, err = wallet.ExportSeed("wrong password")
+		assert.NotNil(err)
+	}
+	exported, err := wallet.ExportSeed(password)
+	assert.Nil(err)
+	assert.Equal(mnemonic, exported)
+
+	// Restore the seed into another wallet, and derive the same keys
+	tmpdir2 := createTempDir()
+	defer os.RemoveAll(tmpdir2)
+
+	wallet2, err := NewSoftWallet(tmpdir2, ksType)
+	assert.Nil(err)
+	err = wallet2.RestoreSeed("abandon abandon abandon", password)
+	assert.NotNil(err)
+	err = wallet2.RestoreSeed(strings.ToUpper(mnemonic), password)
+	assert.Nil(err)
+	restoredAddr5, err := wallet2.DeriveKey(path5, password)
+	assert.Nil(err)
+	assert.Equal(addr5, restoredAddr5)
+	restoredAddr0, err := wallet2.NewKey(password)
+	assert.Nil(err)
+	assert.Equal(addr6, restoredAddr0) // next index after 5
+}
+
 func createTempDir() string {
 	dir, err := ioutil.TempDir("", "theta-softwallet-test")
 	if err != nil {
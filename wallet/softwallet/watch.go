@@ -0,0 +1,139 @@
+package softwallet
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/thetatoken/ukulele/common"
+)
+
+// ErrWatchOnly is returned when unlocking or signing with a watch-only address
+var ErrWatchOnly = errors.New("Address is watch-only, its private key is not in the wallet. " +
+	"Please sign with the hardware wallet holding the key instead, e.g. --wallet=nano --path=<derivation path>")
+
+// WatchOnlyAccount is an address tracked by the wallet without its private key
+type WatchOnlyAccount struct {
+	Address common.Address `json:"address"`
+	Label   string         `json:"label"`
+}
+
+// AddWatchOnly adds a watch-only address to the wallet
+func (w *SoftWallet) AddWatchOnly(address common.Address, label string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	addresses, err := w.keystore.ListKeyAddresses()
+	if err != nil {
+		return err
+	}
+	for _, addr := range addresses {
+		if addr == address {
+			return fmt.Errorf("Key for address %v already exists", address.Hex())
+		}
+	}
+
+	accounts, err := w.loadWatchOnlyAccounts()
+	if err != nil {
+		return err
+	}
+	for _, account := range accounts {
+		if account.Address == address {
+			return fmt.Errorf("Address %v is already watched", address.Hex())
+		}
+	}
+	accounts = append(accounts, WatchOnlyAccount{Address: address, Label: label})
+	return w.saveWatchOnlyAccounts(accounts)
+}
+
+// RemoveWatchOnly removes a watch-only address from the wallet
+func (w *SoftWallet) RemoveWatchOnly(address common.Address) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	accounts, err := w.loadWatchOnlyAccounts()
+	if err != nil {
+		return err
+	}
+	for i, account := range accounts {
+		if account.Address == address {
+			accounts = append(accounts[:i], accounts[i+1:]...)
+			return w.saveWatchOnlyAccounts(accounts)
+		}
+	}
+	return fmt.Errorf("Address %v is not watched", address.Hex())
+}
+
+// ListWatchOnly returns the watch-only addresses of the wallet
+func (w *SoftWallet) ListWatchOnly() ([]WatchOnlyAccount, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.loadWatchOnlyAccounts()
+}
+
+// IsWatchOnly returns whether the address is watched without its private key
+func (w *SoftWallet) IsWatchOnly(address common.Address) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.isWatchOnly(address)
+}
+
+func (w *SoftWallet) isWatchOnly(address common.Address) bool {
+	accounts, err := w.loadWatchOnlyAccounts()
+	if err != nil {
+		return false
+	}
+	for _, account := range accounts {
+		if account.Address == address {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *SoftWallet) loadWatchOnlyAccounts() ([]WatchOnlyAccount, error) {
+	accounts := []WatchOnlyAccount{}
+	content, err := ioutil.ReadFile(w.watchOnlyFile)
+	if os.IsNotExist(err) {
+		return accounts, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(content, &accounts); err != nil {
+		return nil, err
+	}
+
+	// Skip the addresses whose keys have been imported since
+	addresses, err := w.keystore.ListKeyAddresses()
+	if err != nil {
+		return nil, err
+	}
+	stored := make(map[common.Address]bool)
+	for _, addr := range addresses {
+		stored[addr] = true
+	}
+	watched := []WatchOnlyAccount{}
+	for _, account := range accounts {
+		if !stored[account.Address] {
+			watched = append(watched, account)
+		}
+	}
+	return watched, nil
+}
+
+func (w *SoftWallet) saveWatchOnlyAccounts(accounts []WatchOnlyAccount) error {
+	content, err := json.MarshalIndent(accounts, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(w.watchOnlyFile), 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(w.watchOnlyFile, content, 0600)
+}
@@ -1,5 +1,13 @@
 package types
 
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+)
+
 // DerivationPath represents the computer friendly version of a hierarchical
 // deterministic wallet account derivaion path.
 type DerivationPath []uint32
@@ -18,3 +26,102 @@ var DefaultBaseDerivationPath = DerivationPath{0x80000000 + 44, 0x80000000 + 60,
 // are incremented. As such, the first account will be at m/44'/60'/0'/0, the second
 // at m/44'/60'/0'/1, etc.
 var DefaultLedgerBaseDerivationPath = DerivationPath{0x80000000 + 44, 0x80000000 + 60, 0x80000000 + 0, 0}
+
+// DefaultThetaBaseDerivationPath is the base path from which the accounts of the
+// soft wallet HD seed are derived, using the SLIP-44 coin type of Theta (500). As
+// such, the first account will be at m/44'/500'/0'/0/0, the second at m/44'/500'/0'/0/1, etc.
+var DefaultThetaBaseDerivationPath = DerivationPath{0x80000000 + 44, 0x80000000 + 500, 0x80000000 + 0, 0, 0}
+
+// LedgerDerivationPath returns the derivation path of the account with the given
+// index on a Ledger device, i.e. m/44'/60'/0'/index.
+func LedgerDerivationPath(index uint32) DerivationPath {
+	path := make(DerivationPath, len(DefaultLedgerBaseDerivationPath))
+	copy(path, DefaultLedgerBaseDerivationPath)
+	path[len(path)-1] += index
+	return path
+}
+
+// ThetaDerivationPath returns the derivation path of the account with the given
+// index derived from the soft wallet HD seed, i.e. m/44'/500'/0'/0/index.
+func ThetaDerivationPath(index uint32) DerivationPath {
+	path := make(DerivationPath, len(DefaultThetaBaseDerivationPath))
+	copy(path, DefaultThetaBaseDerivationPath)
+	path[len(path)-1] += index
+	return path
+}
+
+// ParseDerivationPath converts a user specified derivation path string to the
+// internal binary representation.
+//
+// Full derivation paths need to start with the `m/` prefix, relative derivation
+// paths (which will get appended to the default root path) must not have prefixes
+// in front of the first element. Whitespace is ignored.
+func ParseDerivationPath(path string) (DerivationPath, error) {
+	var result DerivationPath
+
+	// Handle absolute or relative paths
+	components := strings.Split(path, "/")
+	switch {
+	case len(components) == 0:
+		return nil, errors.New("empty derivation path")
+
+	case strings.TrimSpace(components[0]) == "":
+		return nil, errors.New("ambiguous path: use 'm/' prefix for absolute paths, or no leading '/' for relative ones")
+
+	case strings.TrimSpace(components[0]) == "m":
+		components = components[1:]
+
+	default:
+		result = append(result, DefaultRootDerivationPath...)
+	}
+	// All remaining components are relative, append one by one
+	if len(components) == 0 {
+		return nil, errors.New("empty derivation path") // Empty relative paths
+	}
+	for _, component := range components {
+		// Ignore any user added whitespace
+		component = strings.TrimSpace(component)
+		var value uint32
+
+		// Handle hardened paths
+		if strings.HasSuffix(component, "'") {
+			value = 0x80000000
+			component = strings.TrimSpace(strings.TrimSuffix(component, "'"))
+		}
+		// Handle the non hardened component
+		bigval, ok := new(big.Int).SetString(component, 0)
+		if !ok {
+			return nil, fmt.Errorf("invalid component: %s", component)
+		}
+		max := math.MaxUint32 - value
+		if bigval.Sign() < 0 || bigval.Cmp(big.NewInt(int64(max))) > 0 {
+			if value == 0 {
+				return nil, fmt.Errorf("component %v out of allowed range [0, %d]", bigval, max)
+			}
+			return nil, fmt.Errorf("component %v out of allowed hardened range [0, %d]", bigval, max)
+		}
+		value += uint32(bigval.Uint64())
+
+		// Append and repeat
+		result = append(result, value)
+	}
+	return result, nil
+}
+
+// String implements the stringer interface, converting a binary derivation path
+// to its canonical representation.
+func (path DerivationPath) String() string {
+	result := "m"
+	for _, component := range path {
+		var hardened bool
+		if component >= 0x80000000 {
+			component -= 0x80000000
+			hardened = true
+		}
+		result = fmt.Sprintf("%s/%d", result, component)
+		if hardened {
+			result += "'"
+		}
+	}
+	return result
+}
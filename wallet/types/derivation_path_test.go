@@ -0,0 +1,61 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDerivationPath(t *testing.T) {
+	assert := assert.New(t)
+
+	tests := []struct {
+		input  string
+		output DerivationPath
+	}{
+		// Plain absolute derivation paths
+		{"m/44'/60'/0'/0", DerivationPath{0x80000000 + 44, 0x80000000 + 60, 0x80000000 + 0, 0}},
+		{"m/44'/60'/0'/128", DerivationPath{0x80000000 + 44, 0x80000000 + 60, 0x80000000 + 0, 128}},
+		{"m/44'/60'/0'/0'", DerivationPath{0x80000000 + 44, 0x80000000 + 60, 0x80000000 + 0, 0x80000000 + 0}},
+		{"m/2147483692/2147483708/2147483648/0", DerivationPath{0x80000000 + 44, 0x80000000 + 60, 0x80000000 + 0, 0}},
+
+		// Plain relative derivation paths
+		{"0", DerivationPath{0x80000000 + 44, 0x80000000 + 60, 0x80000000 + 0, 0, 0}},
+		{"128", DerivationPath{0x80000000 + 44, 0x80000000 + 60, 0x80000000 + 0, 0, 128}},
+
+		// Whitespace in the path
+		{" m  /   44      '\n/\n   60  \n\n\t'   /\n0 ' /\t\t   0", DerivationPath{0x80000000 + 44, 0x80000000 + 60, 0x80000000 + 0, 0}},
+
+		// Invalid derivation paths
+		{"", nil},              // Empty relative derivation path
+		{"m", nil},             // Empty absolute derivation path
+		{"m/", nil},            // Missing last derivation component
+		{"/44'/60'/0'/0", nil}, // Absolute path without m prefix, might be user error
+		{"m/2147483648'", nil}, // Overflows 32 bit integer
+		{"m/-1'", nil},         // Cannot contain negative number
+	}
+	for _, tt := range tests {
+		path, err := ParseDerivationPath(tt.input)
+		if tt.output == nil {
+			assert.NotNil(err, "input: %v", tt.input)
+			continue
+		}
+		assert.Nil(err, "input: %v", tt.input)
+		assert.Equal(tt.output, path, "input: %v", tt.input)
+	}
+}
+
+func TestDerivationPathString(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("m/44'/60'/0'/0", DefaultRootDerivationPath.String())
+	assert.Equal("m/44'/60'/0'/0/0", DefaultBaseDerivationPath.String())
+	assert.Equal("m/44'/60'/0'/3", LedgerDerivationPath(3).String())
+	assert.Equal(DefaultLedgerBaseDerivationPath, LedgerDerivationPath(0))
+	assert.Equal("m/44'/500'/0'/0/5", ThetaDerivationPath(5).String())
+	assert.Equal(DefaultThetaBaseDerivationPath, ThetaDerivationPath(0))
+
+	path, err := ParseDerivationPath(LedgerDerivationPath(7).String())
+	assert.Nil(err)
+	assert.Equal(LedgerDerivationPath(7), path)
+}
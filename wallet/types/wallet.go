@@ -10,6 +10,9 @@ type WalletType int
 const (
 	WalletTypeSoft WalletType = iota
 	WalletTypeCold
+	WalletTypeTrezor
+	WalletTypeRemote
+	WalletTypeDaemon
 )
 
 type Wallet interface {
@@ -17,8 +20,12 @@ type Wallet interface {
 	Status() (string, error)
 	List() ([]common.Address, error)
 	NewKey(password string) (common.Address, error)
+	ImportKey(privKey *crypto.PrivateKey, password string) (common.Address, error)
+	ExportKey(address common.Address, password string) (*crypto.PrivateKey, error)
 	Unlock(address common.Address, password string) error
 	Lock(address common.Address) error
+	LockAll() error
+	IsUnlocked(address common.Address) bool
 	Delete(address common.Address, password string) error
 	UpdatePassword(address common.Address, oldPassword, newPassword string) error
 	Derive(path DerivationPath, pin bool) (common.Address, error)
@@ -5,6 +5,7 @@ import (
 	"path"
 
 	cw "github.com/thetatoken/ukulele/wallet/coldwallet"
+	rw "github.com/thetatoken/ukulele/wallet/remotewallet"
 	sw "github.com/thetatoken/ukulele/wallet/softwallet"
 	"github.com/thetatoken/ukulele/wallet/types"
 )
@@ -23,8 +24,17 @@ func OpenWallet(cfgPath string, walletType types.WalletType, encrypted bool) (ty
 		if err != nil {
 			return nil, err
 		}
+	} else if walletType == types.WalletTypeRemote {
+		return nil, fmt.Errorf("Remote wallet requires the signer config, use OpenRemoteWallet instead")
+	} else if walletType == types.WalletTypeDaemon {
+		return nil, fmt.Errorf("Wallet daemon requires the client token, use daemon.Dial instead")
 	} else {
-		hub, err := cw.NewLedgerHub() // only support Ledger Nano S for now
+		var hub *cw.Hub
+		if walletType == types.WalletTypeTrezor {
+			hub, err = cw.NewTrezorHub()
+		} else {
+			hub, err = cw.NewLedgerHub()
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -40,3 +50,16 @@ func OpenWallet(cfgPath string, walletType types.WalletType, encrypted bool) (ty
 
 	return wallet, nil
 }
+
+// OpenSoftWallet opens the encrypted soft wallet, which encrypts the keys with the
+// given scrypt parameters
+func OpenSoftWallet(cfgPath string, scryptN, scryptP int) (*sw.SoftWallet, error) {
+	keysDirPath := path.Join(cfgPath, "keys")
+	return sw.NewSoftWalletWithKDFParams(keysDirPath, sw.KeystoreTypeEncrypted, scryptN, scryptP)
+}
+
+// OpenRemoteWallet opens the remote wallet, which forwards the sign requests to the
+// external signer specified by the config
+func OpenRemoteWallet(config rw.Config) (*rw.RemoteWallet, error) {
+	return rw.NewRemoteWallet(config)
+}
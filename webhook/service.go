@@ -0,0 +1,471 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/thetatoken/ukulele/blockchain"
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/core"
+	"github.com/thetatoken/ukulele/crypto"
+	"github.com/thetatoken/ukulele/exporter"
+	"github.com/thetatoken/ukulele/ledger/types"
+	"github.com/thetatoken/ukulele/store"
+)
+
+var logger *log.Entry = log.WithFields(log.Fields{"prefix": "webhook"})
+
+const (
+	webhooksKey   = "wh/hooks"
+	deliveriesKey = "wh/deliveries"
+	cursorKey     = "wh/cursor"
+
+	// MaxWebhooks is the maximum number of registered webhooks
+	MaxWebhooks = 100
+
+	// MaxPendingDeliveries is the maximum number of notifications waiting to be
+	// delivered. The oldest ones are dropped beyond.
+	MaxPendingDeliveries = 10000
+
+	pollInterval       = time.Second
+	maxBlocksPerPoll   = 100
+	firstRetryInterval = 5 * time.Second
+	maxRetryInterval   = time.Hour
+)
+
+// Webhook is an endpoint notified of the finalized transactions selected by its
+// filter, from the height at which it was registered.
+type Webhook struct {
+	ID         string
+	URL        string
+	Secret     string // Key of the HMAC signature of the payloads
+	Filter     Filter
+	FromHeight uint64
+}
+
+type WebhookJSON struct {
+	ID         string            `json:"id"`
+	URL        string            `json:"url"`
+	Filter     FilterJSON        `json:"filter"`
+	FromHeight common.JSONUint64 `json:"from_height"`
+}
+
+// NewWebhookJSON returns the JSON of the webhook, without its secret.
+func NewWebhookJSON(w *Webhook) WebhookJSON {
+	return WebhookJSON{
+		ID:         w.ID,
+		URL:        w.URL,
+		Filter:     NewFilterJSON(w.Filter),
+		FromHeight: common.JSONUint64(w.FromHeight),
+	}
+}
+
+// Payload is the JSON body POSTed to a webhook for a finalized transaction.
+type Payload struct {
+	WebhookID   string            `json:"webhook_id"`
+	ChainID     string            `json:"chain_id"`
+	BlockHash   common.Hash       `json:"block_hash"`
+	BlockHeight common.JSONUint64 `json:"block_height"`
+	Timestamp   *common.JSONBig   `json:"timestamp"`
+	TxHash      common.Hash       `json:"tx_hash"`
+	TxIndex     int               `json:"tx_index"`
+	Type        string            `json:"type"`
+	Addresses   []common.Address  `json:"addresses"` // Addresses of the filter taking part in the transaction
+	Amount      types.Coins       `json:"amount"`    // Coins transferred to or from the addresses
+	Tx          types.Tx          `json:"tx"`
+}
+
+// delivery is a notification waiting to be delivered. The payload is encoded once,
+// so that the retries send the same signed body.
+type delivery struct {
+	ID          string
+	WebhookID   string
+	Payload     common.Bytes
+	Attempts    uint64
+	NextAttempt uint64 // Unix time in seconds
+}
+
+// FinalizedBlockSource returns the latest finalized block.
+type FinalizedBlockSource interface {
+	GetLastFinalizedBlock() *core.ExtendedBlock
+}
+
+// Service POSTs signed notifications to the registered webhooks when the blocks
+// are finalized, and retries the failed deliveries with an exponential backoff.
+// The webhooks and the pending deliveries are persisted, so that they survive the
+// restarts of the node.
+type Service struct {
+	chain       *blockchain.Chain
+	finalized   FinalizedBlockSource
+	store       store.Store
+	client      *http.Client
+	maxAttempts uint64
+
+	mu         *sync.Mutex
+	webhooks   []*Webhook
+	deliveries []*delivery
+
+	// Life cycle
+	wg     *sync.WaitGroup
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewService creates an instance of Service. A delivery is given up after the
+// given number of attempts.
+func NewService(chain *blockchain.Chain, finalized FinalizedBlockSource, store store.Store,
+	maxAttempts uint64, timeout time.Duration) *Service {
+	s := &Service{
+		chain:       chain,
+		finalized:   finalized,
+		store:       store,
+		client:      &http.Client{Timeout: timeout},
+		maxAttempts: maxAttempts,
+		mu:          &sync.Mutex{},
+		webhooks:    []*Webhook{},
+		deliveries:  []*delivery{},
+		wg:          &sync.WaitGroup{},
+	}
+	s.load(webhooksKey, &s.webhooks)
+	s.load(deliveriesKey, &s.deliveries)
+	return s
+}
+
+// Start starts the notifications.
+func (s *Service) Start(ctx context.Context) {
+	c, cancel := context.WithCancel(ctx)
+	s.ctx = c
+	s.cancel = cancel
+
+	s.wg.Add(1)
+	go s.mainLoop()
+}
+
+// Stop notifies the service to stop without blocking.
+func (s *Service) Stop() {
+	s.cancel()
+}
+
+// Wait blocks until the service stops.
+func (s *Service) Wait() {
+	s.wg.Wait()
+}
+
+// Register registers a webhook notified of the transactions selected by the filter
+// in the blocks finalized from now on. It returns the webhook with its secret.
+func (s *Service) Register(rawURL string, filter Filter) (*Webhook, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("%q is not an http(s) URL", rawURL)
+	}
+	filter.MinAmount = filter.MinAmount.NoNil()
+	if !filter.MinAmount.IsNonnegative() {
+		return nil, errors.New("Minimum amount must not be negative")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.webhooks) >= MaxWebhooks {
+		return nil, fmt.Errorf("At most %v webhooks can be registered", MaxWebhooks)
+	}
+	webhook := &Webhook{
+		ID:         randomHex(16),
+		URL:        rawURL,
+		Secret:     randomHex(32),
+		Filter:     filter,
+		FromHeight: s.nextHeight(),
+	}
+	s.webhooks = append(s.webhooks, webhook)
+	s.save(webhooksKey, s.webhooks)
+
+	logger.WithFields(log.Fields{"id": webhook.ID, "url": webhook.URL}).Info("Registered webhook")
+	return webhook, nil
+}
+
+// Unregister removes a webhook and its pending deliveries.
+func (s *Service) Unregister(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	webhooks := []*Webhook{}
+	for _, w := range s.webhooks {
+		if w.ID != id {
+			webhooks = append(webhooks, w)
+		}
+	}
+	if len(webhooks) == len(s.webhooks) {
+		return fmt.Errorf("Webhook %v is not registered", id)
+	}
+	s.webhooks = webhooks
+	deliveries := []*delivery{}
+	for _, d := range s.deliveries {
+		if d.WebhookID != id {
+			deliveries = append(deliveries, d)
+		}
+	}
+	s.deliveries = deliveries
+	s.save(webhooksKey, s.webhooks)
+	s.save(deliveriesKey, s.deliveries)
+	return nil
+}
+
+// Webhooks returns the registered webhooks.
+func (s *Service) Webhooks() []*Webhook {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*Webhook{}, s.webhooks...)
+}
+
+// PendingDeliveries returns the number of notifications waiting to be delivered
+// to the webhook.
+func (s *Service) PendingDeliveries(id string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	count := 0
+	for _, d := range s.deliveries {
+		if d.WebhookID == id {
+			count++
+		}
+	}
+	return count
+}
+
+func (s *Service) mainLoop() {
+	defer s.wg.Done()
+
+	for {
+		s.ProcessFinalizedBlocks()
+		s.Deliver(time.Now())
+
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// ProcessFinalizedBlocks queues the notifications of the blocks finalized since
+// the last call.
+func (s *Service) ProcessFinalizedBlocks() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	next := s.nextHeight()
+	height := next
+	for ; height < next+maxBlocksPerPoll; height++ {
+		block := s.findFinalizedBlockByHeight(height)
+		if block == nil {
+			break
+		}
+		s.queueDeliveries(block)
+	}
+	if height == next {
+		return
+	}
+	if len(s.deliveries) > MaxPendingDeliveries {
+		logger.WithFields(log.Fields{"dropped": len(s.deliveries) - MaxPendingDeliveries}).Warn("Too many pending deliveries, dropping the oldest")
+		s.deliveries = s.deliveries[len(s.deliveries)-MaxPendingDeliveries:]
+	}
+
+	// The deliveries and the cursor are saved together, so that a block is not
+	// notified twice nor skipped after a restart
+	batch := s.store.NewBatch()
+	s.put(batch, deliveriesKey, s.deliveries)
+	s.put(batch, cursorKey, height)
+	if err := batch.Write(); err != nil {
+		logger.WithFields(log.Fields{"err": err}).Panic("Failed to save the webhook deliveries")
+	}
+}
+
+func (s *Service) queueDeliveries(block *core.ExtendedBlock) {
+	for i, raw := range block.Txs {
+		tx, err := types.TxFromBytes(raw)
+		if err != nil {
+			continue
+		}
+		for _, webhook := range s.webhooks {
+			if block.Height < webhook.FromHeight {
+				continue
+			}
+			addresses, amount, ok := webhook.Filter.Match(tx)
+			if !ok {
+				continue
+			}
+			payload, err := json.Marshal(Payload{
+				WebhookID:   webhook.ID,
+				ChainID:     block.ChainID,
+				BlockHash:   block.Hash(),
+				BlockHeight: common.JSONUint64(block.Height),
+				Timestamp:   (*common.JSONBig)(block.Timestamp),
+				TxHash:      crypto.Keccak256Hash(raw),
+				TxIndex:     i,
+				Type:        exporter.TxTypeName(tx),
+				Addresses:   addresses,
+				Amount:      amount,
+				Tx:          tx,
+			})
+			if err != nil {
+				logger.WithFields(log.Fields{"err": err}).Error("Failed to encode webhook payload")
+				continue
+			}
+			s.deliveries = append(s.deliveries, &delivery{
+				ID:        randomHex(16),
+				WebhookID: webhook.ID,
+				Payload:   payload,
+			})
+		}
+	}
+}
+
+// Deliver POSTs the notifications due at the given time. The failed ones are
+// retried after an interval doubling from 5 seconds up to an hour, until they
+// have been attempted the maximum number of times.
+func (s *Service) Deliver(now time.Time) {
+	s.mu.Lock()
+	due := []*delivery{}
+	for _, d := range s.deliveries {
+		if d.NextAttempt <= uint64(now.Unix()) {
+			c := *d // Updated without holding the lock
+			due = append(due, &c)
+		}
+	}
+	webhooks := make(map[string]*Webhook)
+	for _, w := range s.webhooks {
+		webhooks[w.ID] = w
+	}
+	s.mu.Unlock()
+	if len(due) == 0 {
+		return
+	}
+
+	done := make(map[string]bool)
+	retried := make(map[string]*delivery)
+	for _, d := range due {
+		webhook, ok := webhooks[d.WebhookID]
+		if !ok {
+			done[d.ID] = true
+			continue
+		}
+		err := s.post(webhook, d)
+		d.Attempts++
+		if err == nil {
+			done[d.ID] = true
+			continue
+		}
+		if d.Attempts >= s.maxAttempts {
+			logger.WithFields(log.Fields{"webhook": webhook.ID, "delivery": d.ID, "err": err}).Warn("Giving up webhook delivery")
+			done[d.ID] = true
+			continue
+		}
+		retryInterval := firstRetryInterval << (d.Attempts - 1)
+		if retryInterval > maxRetryInterval || retryInterval <= 0 {
+			retryInterval = maxRetryInterval
+		}
+		d.NextAttempt = uint64(now.Add(retryInterval).Unix())
+		retried[d.ID] = d
+		logger.WithFields(log.Fields{"webhook": webhook.ID, "delivery": d.ID, "err": err, "retryIn": retryInterval}).Debug("Webhook delivery failed")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	deliveries := []*delivery{}
+	for _, d := range s.deliveries {
+		if done[d.ID] {
+			continue
+		}
+		if r, ok := retried[d.ID]; ok {
+			d = r
+		}
+		deliveries = append(deliveries, d)
+	}
+	s.deliveries = deliveries
+	s.save(deliveriesKey, s.deliveries)
+}
+
+func (s *Service) post(webhook *Webhook, d *delivery) error {
+	req, err := http.NewRequest("POST", webhook.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Theta-Webhook-Id", webhook.ID)
+	req.Header.Set("X-Theta-Delivery-Id", d.ID)
+	req.Header.Set("X-Theta-Signature", Sign(webhook.Secret, d.Payload))
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("Webhook responded with status %v", resp.Status)
+	}
+	return nil
+}
+
+// nextHeight returns the height of the next block to notify. Before any webhook is
+// registered, it follows the latest finalized block.
+func (s *Service) nextHeight() uint64 {
+	var height uint64
+	err := s.store.Get(common.Bytes(cursorKey), &height)
+	if err == nil {
+		return height
+	}
+	if err != store.ErrKeyNotFound {
+		logger.WithFields(log.Fields{"err": err}).Panic("Failed to read the webhook cursor")
+	}
+	if block := s.finalized.GetLastFinalizedBlock(); block != nil {
+		return block.Height + 1
+	}
+	return 0
+}
+
+func (s *Service) findFinalizedBlockByHeight(height uint64) *core.ExtendedBlock {
+	for _, block := range s.chain.FindBlocksByHeight(height) {
+		if block.Status == core.BlockStatusFinalized {
+			return block
+		}
+	}
+	return nil
+}
+
+func (s *Service) load(key string, value interface{}) {
+	err := s.store.Get(common.Bytes(key), value)
+	if err != nil && err != store.ErrKeyNotFound {
+		logger.WithFields(log.Fields{"err": err, "key": key}).Panic("Failed to load webhooks")
+	}
+}
+
+func (s *Service) save(key string, value interface{}) {
+	if err := s.store.Put(common.Bytes(key), value); err != nil {
+		logger.WithFields(log.Fields{"err": err, "key": key}).Panic("Failed to save webhooks")
+	}
+}
+
+func (s *Service) put(batch store.Batch, key string, value interface{}) {
+	if err := batch.Put(common.Bytes(key), value); err != nil {
+		logger.WithFields(log.Fields{"err": err, "key": key}).Panic("Failed to save webhooks")
+	}
+}
+
+func randomHex(n int) string {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(buf)
+}
@@ -0,0 +1,151 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/exporter"
+	"github.com/thetatoken/ukulele/ledger/types"
+)
+
+// Filter selects the transactions notified to a webhook. An empty field does not
+// filter the transactions.
+type Filter struct {
+	Addresses []common.Address // Transactions sent or received by any of the addresses
+	TxTypes   []string         // Transactions of any of the types, e.g. "SendTx"
+	MinAmount types.Coins      // Transactions transferring at least the coins of each type to or from the addresses
+}
+
+type FilterJSON struct {
+	Addresses []common.Address `json:"addresses"`
+	TxTypes   []string         `json:"tx_types"`
+	MinAmount *types.Coins     `json:"min_amount,omitempty"`
+}
+
+func NewFilterJSON(f Filter) FilterJSON {
+	ret := FilterJSON{
+		Addresses: f.Addresses,
+		TxTypes:   f.TxTypes,
+	}
+	if !f.MinAmount.NoNil().IsZero() {
+		minAmount := f.MinAmount.NoNil()
+		ret.MinAmount = &minAmount
+	}
+	return ret
+}
+
+func (f FilterJSON) Filter() Filter {
+	ret := Filter{
+		Addresses: f.Addresses,
+		TxTypes:   f.TxTypes,
+		MinAmount: types.NewCoins(0, 0),
+	}
+	if f.MinAmount != nil {
+		ret.MinAmount = f.MinAmount.NoNil()
+	}
+	return ret
+}
+
+// Match returns whether the filter selects the transaction, with the watched
+// addresses taking part in it and the coins it transfers to or from them. Without
+// watched addresses, the coins are the ones transferred to all the recipients.
+func (f Filter) Match(tx types.Tx) (addresses []common.Address, amount types.Coins, ok bool) {
+	if len(f.TxTypes) > 0 && !containsString(f.TxTypes, exporter.TxTypeName(tx)) {
+		return nil, types.Coins{}, false
+	}
+
+	addresses = []common.Address{}
+	if len(f.Addresses) > 0 {
+		senders, recipients := types.TxParticipants(tx)
+		for _, addr := range f.Addresses {
+			if containsAddress(senders, addr) || containsAddress(recipients, addr) {
+				addresses = append(addresses, addr)
+			}
+		}
+		if len(addresses) == 0 {
+			return nil, types.Coins{}, false
+		}
+	}
+
+	amount = transferredCoins(tx, addresses)
+	if !amount.IsGTE(f.MinAmount.NoNil()) {
+		return nil, types.Coins{}, false
+	}
+	return addresses, amount, true
+}
+
+// transferredCoins returns the coins the transaction transfers to or from the
+// addresses, or to all its recipients if no address is given. Only the
+// transactions moving coins between accounts transfer coins, the fees are not
+// counted.
+func transferredCoins(tx types.Tx, addresses []common.Address) types.Coins {
+	amount := types.NewCoins(0, 0)
+	add := func(addr common.Address, coins types.Coins, received bool) {
+		if (len(addresses) == 0 && received) || containsAddress(addresses, addr) {
+			amount = amount.Plus(coins.NoNil())
+		}
+	}
+	addInputs := func(inputs ...types.TxInput) {
+		for _, input := range inputs {
+			add(input.Address, input.Coins, false)
+		}
+	}
+	addOutputs := func(outputs ...types.TxOutput) {
+		for _, output := range outputs {
+			add(output.Address, output.Coins, true)
+		}
+	}
+
+	switch tx := tx.(type) {
+	case *types.CoinbaseTx:
+		addOutputs(tx.Outputs...)
+	case *types.SendTx:
+		addInputs(tx.Inputs...)
+		addOutputs(tx.Outputs...)
+	case *types.TimelockedSendTx:
+		addInputs(tx.Inputs...)
+		addOutputs(tx.Outputs...)
+	case *types.SmartContractTx:
+		addInputs(tx.From)
+		addOutputs(tx.To)
+	case *types.ServicePaymentTx:
+		// The target receives the coins of the source
+		add(tx.Source.Address, tx.Source.Coins, false)
+		add(tx.Target.Address, tx.Source.Coins, true)
+	}
+	return amount
+}
+
+// Sign returns the signature of the payload sent in the X-Theta-Signature header:
+// "sha256=" followed by the hex encoded HMAC-SHA256 of the payload with the secret
+// of the webhook.
+func Sign(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the signature of a payload, for the receivers of the notifications.
+func Verify(secret string, payload []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, payload)), []byte(signature))
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func containsAddress(list []common.Address, addr common.Address) bool {
+	for _, item := range list {
+		if item == addr {
+			return true
+		}
+	}
+	return false
+}
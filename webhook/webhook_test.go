@@ -0,0 +1,157 @@
+package webhook
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thetatoken/ukulele/blockchain"
+	"github.com/thetatoken/ukulele/common"
+	"github.com/thetatoken/ukulele/core"
+	"github.com/thetatoken/ukulele/ledger/types"
+	"github.com/thetatoken/ukulele/store/database/backend"
+	"github.com/thetatoken/ukulele/store/kvstore"
+)
+
+var (
+	alice = common.HexToAddress("0x2E833968E5bB786Ae419c4d13189fB081Cc43bab")
+	bob   = common.HexToAddress("0x9F1233798E905E173560071255140b4A8aBd3Ec6")
+	carol = common.HexToAddress("0x36b0a7d8dEc5a4ea7E0de2E5Ad0D6b6a2eD5e2c5")
+)
+
+func newTestSendTx(from, to common.Address, gamma int64) *types.SendTx {
+	return &types.SendTx{
+		Fee: types.NewCoins(0, 1000000000000),
+		Inputs: []types.TxInput{{
+			Address: from,
+			Coins:   types.NewCoins(0, gamma+1000000000000),
+		}},
+		Outputs: []types.TxOutput{{
+			Address: to,
+			Coins:   types.NewCoins(0, gamma),
+		}},
+	}
+}
+
+type testFinalizedBlockSource struct {
+	chain *blockchain.Chain
+}
+
+func (s testFinalizedBlockSource) GetLastFinalizedBlock() *core.ExtendedBlock {
+	block, _ := s.chain.FindBlock(s.chain.Root.Hash())
+	return block
+}
+
+func TestFilterMatch(t *testing.T) {
+	assert := assert.New(t)
+	tx := newTestSendTx(alice, bob, 100)
+
+	// Without filter, the amount is the one received by the outputs
+	addresses, amount, ok := Filter{MinAmount: types.NewCoins(0, 0)}.Match(tx)
+	assert.True(ok)
+	assert.Equal(0, len(addresses))
+	assert.Equal(big.NewInt(100), amount.GammaWei)
+
+	addresses, amount, ok = Filter{Addresses: []common.Address{bob, carol}}.Match(tx)
+	assert.True(ok)
+	assert.Equal([]common.Address{bob}, addresses)
+	assert.Equal(big.NewInt(100), amount.GammaWei)
+
+	_, _, ok = Filter{Addresses: []common.Address{carol}}.Match(tx)
+	assert.False(ok)
+
+	_, _, ok = Filter{Addresses: []common.Address{bob}, MinAmount: types.NewCoins(0, 101)}.Match(tx)
+	assert.False(ok)
+
+	_, _, ok = Filter{TxTypes: []string{"SendTx"}, MinAmount: types.NewCoins(0, 100)}.Match(tx)
+	assert.True(ok)
+
+	_, _, ok = Filter{TxTypes: []string{"ReserveFundTx"}}.Match(tx)
+	assert.False(ok)
+}
+
+func TestSignature(t *testing.T) {
+	assert := assert.New(t)
+
+	payload := []byte(`{"webhook_id":"1"}`)
+	signature := Sign("secret", payload)
+	assert.True(Verify("secret", payload, signature))
+	assert.False(Verify("other", payload, signature))
+	assert.False(Verify("secret", []byte(`{"webhook_id":"2"}`), signature))
+}
+
+func TestServiceDelivery(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	core.ResetTestBlocks()
+
+	var received []byte
+	var signature string
+	failures := 1
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failures > 0 {
+			failures--
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		received, _ = ioutil.ReadAll(r.Body)
+		signature = r.Header.Get("X-Theta-Signature")
+	}))
+	defer server.Close()
+
+	chain := blockchain.CreateTestChain()
+	db := kvstore.NewKVStore(backend.NewMemDatabase())
+	service := NewService(chain, testFinalizedBlockSource{chain}, db, 3, time.Second)
+
+	hook, err := service.Register(server.URL, Filter{Addresses: []common.Address{bob}})
+	require.Nil(err)
+	assert.Equal(uint64(1), hook.FromHeight)
+	_, err = service.Register(server.URL, Filter{TxTypes: []string{"ReserveFundTx"}})
+	require.Nil(err)
+	_, err = service.Register("ftp://example.com", Filter{})
+	assert.NotNil(err)
+
+	raw, err := types.TxToBytes(newTestSendTx(alice, bob, 100))
+	require.Nil(err)
+	block := core.CreateTestBlock("A1", "A0")
+	block.Txs = []common.Bytes{raw}
+	_, err = chain.AddBlock(block)
+	require.Nil(err)
+	eb, _ := chain.FindBlock(block.Hash())
+	chain.FinalizeBlock(eb)
+
+	service.ProcessFinalizedBlocks()
+	assert.Equal(1, service.PendingDeliveries(hook.ID))
+
+	// The failed delivery is retried after the backoff, and survives a restart
+	now := time.Now()
+	service.Deliver(now)
+	assert.Equal(1, service.PendingDeliveries(hook.ID))
+	service.Deliver(now.Add(time.Second))
+	assert.Nil(received)
+
+	service = NewService(chain, testFinalizedBlockSource{chain}, db, 3, time.Second)
+	assert.Equal(2, len(service.Webhooks()))
+	service.ProcessFinalizedBlocks()
+	assert.Equal(1, service.PendingDeliveries(hook.ID))
+	service.Deliver(now.Add(10 * time.Second))
+	assert.Equal(0, service.PendingDeliveries(hook.ID))
+
+	require.NotNil(received)
+	assert.True(Verify(hook.Secret, received, signature))
+	var payload map[string]interface{}
+	require.Nil(json.Unmarshal(received, &payload))
+	assert.Equal(hook.ID, payload["webhook_id"])
+	assert.Equal("SendTx", payload["type"])
+	assert.Equal("1", payload["block_height"])
+
+	require.Nil(service.Unregister(hook.ID))
+	assert.Equal(1, len(service.Webhooks()))
+	assert.NotNil(service.Unregister(hook.ID))
+}